@@ -398,7 +398,7 @@ teardown() {
     dolt commit -m "added conflicting test row"
     dolt checkout master
     run dolt merge test-branch
-    [ "$status" -eq 0 ]
+    [ "$status" -eq 3 ]
     [[ "$output" =~ "CONFLICT (content)" ]]
     run dolt conflicts cat test
     [ "$status" -eq 0 ]
@@ -435,10 +435,11 @@ teardown() {
     dolt add test
     dolt commit -m "added conflicting test row"
     dolt checkout master
-    dolt merge test-branch
+    run dolt merge test-branch
+    [ "$status" -eq 3 ]
     run dolt checkout test
     [ "$status" -eq 0 ]
-    [ "$output" = "" ]
+    [ "$output" = "Backed up the working set before checkout. Run 'dolt reset --undo' to restore it." ]
     run dolt sql -q "select * from test"
     [[ "$output" =~ \|[[:space:]]+5 ]] || false
     run dolt conflicts cat test
@@ -466,7 +467,8 @@ teardown() {
     dolt add test
     dolt commit -m "added conflicting test row"
     dolt checkout master
-    dolt merge test-branch
+    run dolt merge test-branch
+    [ "$status" -eq 3 ]
     run dolt conflicts resolve --theirs test
     [ "$status" -eq 0 ]
     [ "$output" = "" ]
@@ -497,7 +499,7 @@ teardown() {
 
 @test "import data from a csv file with a bad line" {
     run dolt table import test -u `batshelper 1pk5col-ints-badline.csv`
-    [ "$status" -eq 1 ]
+    [ "$status" -eq 4 ]
     [[ "${lines[0]}" =~ "Additions" ]] || false
     [[ "${lines[1]}" =~ "A bad row was encountered" ]] || false
     [[ "${lines[2]}" =~ "expects 6 fields" ]] || false
@@ -129,7 +129,7 @@ teardown() {
         [[ "$output" =~ "Migrating repository to the latest format" ]] || false
         dolt checkout conflict
         run dolt merge newcolumn
-        [ "$status" -eq "0" ]
+        [ "$status" -eq "3" ]
         [[ "$output" =~ "CONFLICT" ]] || false
         run dolt conflicts cat abc
         [ "$status" -eq "0" ]
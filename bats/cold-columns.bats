@@ -0,0 +1,123 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql <<SQL
+CREATE TABLE test (
+  pk BIGINT NOT NULL,
+  a BIGINT,
+  b LONGTEXT,
+  c LONGTEXT,
+  PRIMARY KEY (pk)
+);
+INSERT INTO test VALUES (1, 1, 'b1', 'c1'), (2, 2, NULL, NULL), (3, 3, 'b3', NULL);
+SQL
+    dolt add test
+    dolt commit -m "created table"
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "dolt schema cold-columns lists, sets and unsets cold columns" {
+    run dolt schema cold-columns test
+    [ "$status" -eq 0 ]
+    [ "$output" = "" ]
+
+    dolt schema cold-columns test b c
+    run dolt schema cold-columns test
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "b" ]] || false
+    [[ "$output" =~ "c" ]] || false
+    [ "${#lines[@]}" -eq 2 ]
+
+    dolt schema cold-columns -d test b
+    run dolt schema cold-columns test
+    [ "$output" = "c" ]
+}
+
+@test "dolt schema cold-columns rejects bad columns and tables" {
+    run dolt schema cold-columns test pk
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "primary key column 'pk' can't be cold" ]] || false
+    run dolt schema cold-columns test missing
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "has no column 'missing'" ]] || false
+    run dolt schema cold-columns missing a
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "table 'missing' not found" ]] || false
+}
+
+@test "making columns cold doesn't change the rows of a table" {
+    dolt schema cold-columns test b c
+    run dolt status
+    [[ "$output" =~ "modified:       test" ]] || false
+    run dolt diff --data-only
+    [ "$status" -eq 0 ]
+    [[ ! "$output" =~ "|  <  |" ]] || false
+    run dolt diff --schema-only
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "-- cold" ]] || false
+    run dolt sql -q "SELECT * FROM test ORDER BY pk" -r csv
+    [ "$status" -eq 0 ]
+    [ "${lines[1]}" = "1,1,b1,c1" ]
+    [ "${lines[2]}" = "2,2,," ]
+    [ "${lines[3]}" = "3,3,b3," ]
+}
+
+@test "sql queries and writes to tables with cold columns" {
+    dolt schema cold-columns test b c
+    run dolt sql -q "SELECT pk, a FROM test ORDER BY pk" -r csv
+    [ "$status" -eq 0 ]
+    [ "${lines[1]}" = "1,1" ]
+    [ "${#lines[@]}" -eq 4 ]
+    run dolt sql -q "SELECT pk FROM test WHERE c = 'c1'" -r csv
+    [ "${lines[1]}" = "1" ]
+
+    dolt sql -q "UPDATE test SET c = 'c2' WHERE pk = 2"
+    dolt sql -q "UPDATE test SET b = NULL WHERE pk = 1"
+    dolt sql -q "DELETE FROM test WHERE pk = 3"
+    dolt sql -q "INSERT INTO test VALUES (4, 4, NULL, 'c4')"
+    run dolt sql -q "SELECT * FROM test ORDER BY pk" -r csv
+    [ "$status" -eq 0 ]
+    [ "${lines[1]}" = "1,1,,c1" ]
+    [ "${lines[2]}" = "2,2,,c2" ]
+    [ "${lines[3]}" = "4,4,,c4" ]
+    [ "${#lines[@]}" -eq 4 ]
+
+    run dolt diff --data-only
+    [[ "$output" =~ "|  >  | 2  | 2 | <NULL> | c2" ]] || false
+    [[ "$output" =~ "|  -  | 3  | 3 | b3     | <NULL>" ]] || false
+    [[ "$output" =~ "|  +  | 4  | 4 | <NULL> | c4" ]] || false
+
+    # modifying a cold column leaves it cold
+    dolt sql -q "ALTER TABLE test MODIFY COLUMN c LONGTEXT COMMENT 'cold'"
+    run dolt schema cold-columns test
+    [ "${lines[1]}" = "c" ]
+}
+
+@test "merge branches which change the cold columns and the rows of a table" {
+    dolt checkout -b other
+    dolt schema cold-columns test b c
+    dolt sql -q "UPDATE test SET b = 'x' WHERE pk = 1"
+    dolt add test
+    dolt commit -m "cold columns"
+    dolt checkout master
+    dolt sql -q "UPDATE test SET c = 'y', a = 9 WHERE pk = 3"
+    dolt sql -q "INSERT INTO test VALUES (5, 5, 'b5', 'c5')"
+    dolt add test
+    dolt commit -m "changed rows"
+
+    run dolt merge other
+    [ "$status" -eq 0 ]
+    run dolt schema cold-columns test
+    [ "${#lines[@]}" -eq 2 ]
+    run dolt sql -q "SELECT * FROM test ORDER BY pk" -r csv
+    [ "$status" -eq 0 ]
+    [ "${lines[1]}" = "1,1,x,c1" ]
+    [ "${lines[2]}" = "2,2,," ]
+    [ "${lines[3]}" = "3,9,b3,y" ]
+    [ "${lines[4]}" = "5,5,b5,c5" ]
+}
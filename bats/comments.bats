@@ -0,0 +1,115 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql -q "create table test (pk int primary key comment 'the key', c1 varchar(20)) comment='people we know'"
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "column and table comments are shown by show create table and schema show" {
+    run dolt sql -q "show create table test"
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "COMMENT 'the key tag:" ]] || false
+    [[ "$output" =~ "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='people we know'" ]] || false
+    run dolt schema show test
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "COMMENT 'the key tag:" ]] || false
+    [[ "$output" =~ ") COMMENT='people we know';" ]] || false
+}
+
+@test "show create table escapes comments and enum values" {
+    dolt sql -q "create table quotes (pk int primary key comment 'it''s \\\\ the key', c1 enum('a','b''c')) comment 'it''s'"
+    run dolt sql -q "show create table quotes"
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "COMMENT 'it\\'s \\\\ the key tag:" ]] || false
+    [[ "$output" =~ "ENUM('a','b\\'c')" ]] || false
+    [[ "$output" =~ "COMMENT='it\\'s'" ]] || false
+}
+
+@test "comments are shown in information_schema" {
+    run dolt sql -q "select column_name, column_comment from information_schema.columns where table_name = 'test' order by column_name" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "c1," ]] || false
+    [[ "$output" =~ "pk,the key" ]] || false
+    run dolt sql -q "select table_comment from information_schema.tables where table_name = 'test'" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "people we know" ]] || false
+}
+
+@test "alter table changes column and table comments" {
+    dolt sql -q "alter table test comment 'people we used to know'"
+    dolt sql -q "alter table test modify column c1 varchar(20) comment 'name'"
+    run dolt schema show test
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "COMMENT 'name tag:" ]] || false
+    [[ "$output" =~ "COMMENT='people we used to know'" ]] || false
+    run dolt sql -q "alter table missing comment 'x'"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "table not found" ]] || false
+}
+
+@test "comment changes are shown by schema diff" {
+    dolt add test
+    dolt commit -m "created test"
+    dolt sql -q "alter table test comment 'people'"
+    dolt sql -q "alter table test modify column c1 varchar(20) comment 'name'"
+    run dolt diff --schema
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "<   \`c1\` VARCHAR(20) COMMENT 'tag:" ]] || false
+    [[ "$output" =~ ">   \`c1\` VARCHAR(20) COMMENT 'name tag:" ]] || false
+    [[ "$output" =~ "< ) COMMENT='people we know';" ]] || false
+    [[ "$output" =~ "> ) COMMENT='people';" ]] || false
+    run dolt diff -q
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "ALTER TABLE \`test\` MODIFY COLUMN \`c1\` VARCHAR(20) COMMENT 'name tag:" ]] || false
+    [[ "$output" =~ "ALTER TABLE \`test\` COMMENT='people';" ]] || false
+}
+
+@test "merge takes comments changed on one side and conflicts when both change" {
+    dolt add test
+    dolt commit -m "created test"
+    dolt checkout -b other
+    dolt sql -q "alter table test comment 'other people'"
+    dolt add test
+    dolt commit -m "changed table comment"
+    dolt checkout master
+    dolt sql -q "alter table test modify column c1 varchar(20) comment 'name'"
+    dolt add test
+    dolt commit -m "changed column comment"
+    run dolt merge other
+    [ "$status" -eq 0 ]
+    run dolt schema show test
+    [[ "$output" =~ "COMMENT 'name tag:" ]] || false
+    [[ "$output" =~ "COMMENT='other people'" ]] || false
+
+    dolt add test
+    dolt commit -m "merged other"
+    dolt checkout other
+    dolt sql -q "alter table test comment 'more people'"
+    dolt add test
+    dolt commit -m "changed table comment again"
+    dolt checkout master
+    dolt sql -q "alter table test comment 'fewer people'"
+    dolt add test
+    dolt commit -m "changed table comment on master"
+    run dolt merge other
+    [ "$status" -eq 3 ]
+    [[ "$output" =~ "comment changed differently in both commits" ]] || false
+}
+
+@test "comments round trip through schema export and table import" {
+    dolt schema export test export.json
+    run cat export.json
+    [[ "$output" =~ '"comment": "the key"' ]] || false
+    [[ "$output" =~ '"comment": "people we know"' ]] || false
+    echo -e "pk,c1\n1,a" > data.csv
+    dolt table import -c --schema export.json imported data.csv
+    run dolt schema show imported
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "COMMENT 'the key tag:" ]] || false
+    [[ "$output" =~ "COMMENT='people we know'" ]] || false
+}
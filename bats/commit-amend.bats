@@ -0,0 +1,78 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql -q "CREATE TABLE test (pk BIGINT NOT NULL, c1 BIGINT, PRIMARY KEY (pk))"
+    dolt add test
+    dolt commit -m "create tabel"
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "dolt commit --amend changes the message of the last commit" {
+    run dolt log
+    [[ "$output" =~ "create tabel" ]] || false
+    dolt commit --amend -m "create table"
+    run dolt log
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "create table" ]] || false
+    [[ ! "$output" =~ "create tabel" ]] || false
+    [[ "$output" =~ "Initialize data repository" ]] || false
+    [ "$(echo "$output" | grep -c '^commit')" -eq 2 ]
+    run dolt status
+    [[ "$output" =~ "nothing to commit, working tree clean" ]] || false
+}
+
+@test "dolt commit --amend adds the staged tables to the last commit" {
+    dolt sql -q "INSERT INTO test VALUES (1, 1)"
+    dolt add test
+    dolt commit --amend -m "create table with a row"
+    run dolt log
+    [ "$(echo "$output" | grep -c '^commit')" -eq 2 ]
+    run dolt sql -q "SELECT count(*) FROM test AS OF 'HEAD'" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "1" ]] || false
+    run dolt status
+    [[ "$output" =~ "nothing to commit, working tree clean" ]] || false
+}
+
+@test "dolt commit --amend keeps the replaced commit in refs/original" {
+    original=$(dolt log -n 1 | head -n 1 | cut -d ' ' -f 2)
+    dolt commit --amend -m "create table"
+    run dolt log refs/original/heads/master
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "create tabel" ]] || false
+    [[ "$output" =~ "$original" ]] || false
+}
+
+@test "dolt commit --amend without -m starts the editor with the last commit message" {
+    dolt config --local --add core.editor "/bin/sh -c 'head -n 1 \$1 | sed s/tabel/table/ > \$1.tmp && mv \$1.tmp \$1' --"
+    dolt commit --amend
+    run dolt log -n 1
+    [[ "$output" =~ "create table" ]] || false
+    [[ ! "$output" =~ "create tabel" ]] || false
+}
+
+@test "dolt commit --amend refuses to amend a pushed commit without --force" {
+    mkdir remote
+    dolt remote add origin file://remote/
+    dolt push --set-upstream origin master
+    run dolt commit --amend -m "create table"
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "has been pushed to 'origin/master'" ]] || false
+    run dolt log -n 1
+    [[ "$output" =~ "create tabel" ]] || false
+
+    dolt commit --amend --force -m "create table"
+    run dolt log -n 1
+    [[ "$output" =~ "create table" ]] || false
+}
+
+@test "dolt commit --force requires --amend" {
+    run dolt commit --force -m "message"
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "--force can only be used with --amend" ]] || false
+}
@@ -11,7 +11,7 @@ teardown() {
 
 @test "merge non-existant branch errors" {
     run dolt merge batmans-parents
-    [ $status -eq 1 ]
+    [ $status -eq 7 ]
     [[ "$output" =~ "unknown branch" ]] || false
     [[ ! "$output" =~ "panic" ]] || false
 }
@@ -153,7 +153,7 @@ SQL
     dolt commit -m "changed pk=0 all cells to 11"
     dolt checkout master
     run dolt merge change-cell
-    [ "$status" -eq 0 ]
+    [ "$status" -eq 3 ]
     [[ "$output" =~ "CONFLICT" ]] || false
     run dolt status
     [[ "$output" =~ "You have unmerged tables." ]] || false
@@ -0,0 +1,113 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql <<SQL
+CREATE TABLE test (
+  pk BIGINT NOT NULL,
+  c1 BIGINT NOT NULL,
+  c2 VARCHAR(20),
+  PRIMARY KEY (pk)
+);
+INSERT INTO test VALUES (0, 0, 'zero'), (1, 1, 'one'), (2, 2, 'two');
+SQL
+    dolt add test
+    dolt commit -m "table created"
+
+    dolt checkout -b other
+    dolt sql -q "update test set c1 = 10, c2 = 'theirs' where pk in (0, 1)"
+    dolt sql -q "delete from test where pk = 2"
+    dolt sql -q "insert into test values (3, 3, 'theirs')"
+    dolt add test
+    dolt commit -m "changed rows on other"
+
+    dolt checkout master
+    dolt sql -q "update test set c1 = 20 where pk in (0, 1, 2)"
+    dolt sql -q "insert into test values (3, 30, 'ours')"
+    dolt add test
+    dolt commit -m "changed rows on master"
+    run dolt merge other
+    [ "$status" -eq 3 ]
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "conflicts cat shows the base, ours and theirs rows of each conflict" {
+    run dolt conflicts cat test
+    [ "$status" -eq 0 ]
+    # header, then 3 rows for each modified or deleted row and 2 for the row added on both sides, each conflict boxed
+    [ "${#lines[@]}" -eq 18 ]
+    [[ "${lines[3]}" =~ " base " ]] || false
+    [[ "${lines[3]}" =~ " 0 " ]] || false
+    [[ "${lines[3]}" =~ " zero " ]] || false
+    [[ "${lines[4]}" =~ " * ".*" ours ".*" 20 " ]] || false
+    [[ "${lines[5]}" =~ " * ".*" theirs ".*" 10 ".*" theirs " ]] || false
+    [[ "${lines[6]}" =~ ^\+-+\+ ]] || false
+    [[ "${lines[13]}" =~ " - ".*" theirs ".*" 2 ".*" two " ]] || false
+    [[ "${lines[15]}" =~ " + ".*" ours ".*" 30 " ]] || false
+    [[ "${lines[16]}" =~ " + ".*" theirs ".*" 3 " ]] || false
+}
+
+@test "conflicts cat with --where and --limit" {
+    run dolt conflicts cat --where "pk >= 1 and pk < 3" test
+    [ "$status" -eq 0 ]
+    [[ ! "$output" =~ " zero " ]] || false
+    [[ "$output" =~ " one " ]] || false
+    [[ "$output" =~ " two " ]] || false
+    [[ ! "$output" =~ " 30 " ]] || false
+
+    run dolt conflicts cat --where "theirs_c2 = 'theirs'" test
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ " zero " ]] || false
+    [[ "$output" =~ " one " ]] || false
+    [[ ! "$output" =~ " two " ]] || false
+    [[ "$output" =~ " 30 " ]] || false
+
+    # a column referred to by its name alone matches any version
+    run dolt conflicts cat --where "c1 = 2" test
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ " two " ]] || false
+    [[ ! "$output" =~ " one " ]] || false
+
+    run dolt conflicts cat --limit 1 test
+    [ "$status" -eq 0 ]
+    [ "${#lines[@]}" -eq 7 ]
+    [[ "$output" =~ " zero " ]] || false
+
+    run dolt conflicts cat --where "not_a_column = 1" test
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "'not_a_column' is not a known column" ]] || false
+}
+
+@test "conflicts cat with --result-format json" {
+    run dolt conflicts cat -r json --where "pk = 2" test
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ '{"tables": [{"table": "test", "conflicts": [{"key":{"pk":2},"ours_change":"modified","theirs_change":"deleted","base":{"c1":2,"c2":"two","pk":2},"ours":{"c1":20,"c2":"two","pk":2},"theirs":null}]}]}' ]] || false
+
+    run dolt conflicts cat --result-format json .
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ '"key":{"pk":3},"ours_change":"added","theirs_change":"added","base":null' ]] || false
+
+    run dolt conflicts cat -r json --limit 2 test
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ '"key":{"pk":1}' ]] || false
+    [[ ! "$output" =~ '"key":{"pk":2}' ]] || false
+
+    run dolt conflicts cat -r xml test
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "Valid values are tabular, json" ]] || false
+}
+
+@test "conflicts cat of a table without conflicts or an unknown table" {
+    dolt conflicts resolve --ours test
+    run dolt conflicts cat -r json test
+    [ "$status" -eq 0 ]
+    [ "$output" = '{"tables": [{"table": "test", "conflicts": []}]}' ]
+
+    run dolt conflicts cat not_a_table
+    [ "$status" -eq 7 ]
+    [[ "$output" =~ "unknown table 'not_a_table'" ]] || false
+}
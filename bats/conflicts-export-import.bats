@@ -0,0 +1,116 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql <<SQL
+CREATE TABLE test (
+  pk BIGINT NOT NULL,
+  c1 BIGINT NOT NULL,
+  c2 VARCHAR(20),
+  PRIMARY KEY (pk)
+);
+INSERT INTO test VALUES (0, 0, 'zero'), (1, 1, 'one'), (2, 2, 'two');
+SQL
+    dolt add test
+    dolt commit -m "table created"
+
+    dolt checkout -b other
+    dolt sql -q "update test set c1 = 10, c2 = 'theirs' where pk in (0, 1)"
+    dolt sql -q "delete from test where pk = 2"
+    dolt add test
+    dolt commit -m "changed rows on other"
+
+    dolt checkout master
+    dolt sql -q "update test set c1 = 20 where pk in (0, 1, 2)"
+    dolt add test
+    dolt commit -m "changed rows on master"
+    run dolt merge other
+    [ "$status" -eq 3 ]
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "export conflicts to csv and resolve them by importing the file" {
+    run dolt conflicts export test conflicts.csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Exported 3 conflicts of table 'test' to conflicts.csv" ]] || false
+
+    run cat conflicts.csv
+    [[ "$output" =~ "pk,ours_change,theirs_change,resolution,base_c1,ours_c1,theirs_c1,resolved_c1,base_c2,ours_c2,theirs_c2,resolved_c2" ]] || false
+    [[ "$output" =~ "0,modified,modified,,0,20,10,20,zero,zero,theirs,zero" ]] || false
+    [[ "$output" =~ "2,modified,deleted,,2,20,,20,two,two,,two" ]] || false
+
+    cat > resolutions.csv <<CSV
+pk,resolution,resolved_c1,resolved_c2
+0,theirs,,
+1,custom,15,both
+2,
+CSV
+    run dolt conflicts import test resolutions.csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "2 conflicts resolved" ]] || false
+
+    run dolt sql -q "select * from test order by pk" -r csv
+    [[ "$output" =~ "0,10,theirs" ]] || false
+    [[ "$output" =~ "1,15,both" ]] || false
+    [[ "$output" =~ "2,20,two" ]] || false
+
+    run dolt conflicts cat test
+    [[ "$output" =~ "2" ]] || false
+    [[ ! "$output" =~ "both" ]] || false
+
+    echo "pk,resolution" > delete.csv
+    echo "2,delete" >> delete.csv
+    run dolt conflicts import test delete.csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "1 conflicts resolved" ]] || false
+    run dolt sql -q "select count(*) from test" -r csv
+    [[ "$output" =~ "2" ]] || false
+    dolt add test
+    dolt commit -m "resolved conflicts"
+}
+
+@test "export conflicts to xlsx and import them again" {
+    run dolt conflicts export test conflicts.xlsx
+    [ "$status" -eq 0 ]
+    [ -f conflicts.xlsx ]
+
+    # importing without choosing any resolutions leaves every conflict unresolved
+    run dolt conflicts import test conflicts.xlsx
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "0 conflicts resolved" ]] || false
+
+    run dolt conflicts export test conflicts.txt
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "unsupported file type 'txt'" ]] || false
+}
+
+@test "importing invalid resolutions reports them and applies nothing" {
+    cat > resolutions.csv <<CSV
+pk,resolution,resolved_c1,resolved_c2
+0,theirs,,
+1,custom,,missing c1
+2,mine,,
+7,ours,,
+CSV
+    run dolt conflicts import test resolutions.csv
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "3 invalid resolutions" ]] || false
+    [[ "$output" =~ "line 3: the value of column c1 violates the constraint not_null" ]] || false
+    [[ "$output" =~ "line 4: unknown resolution 'mine'" ]] || false
+    [[ "$output" =~ "line 5: the key" ]] || false
+
+    run dolt sql -q "select c1 from test where pk = 0" -r csv
+    [[ "$output" =~ "20" ]] || false
+    run dolt conflicts export test conflicts.csv
+    [[ "$output" =~ "Exported 3 conflicts" ]] || false
+
+    run dolt conflicts import test missing.csv
+    [ "$status" -eq 1 ]
+    run dolt conflicts export nonexistent conflicts.csv
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "unknown table 'nonexistent'" ]] || false
+}
@@ -0,0 +1,71 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql -q "create table test (pk int primary key, v int)"
+    dolt sql -q "insert into test values (1, 1), (2, 2)"
+    dolt add .
+    dolt commit -m "added rows"
+}
+
+teardown() {
+    teardown_common
+    rm -rf "$BATS_TMPDIR/dolt-cow-copy-$$"
+}
+
+@test "dolt admin cow-copy makes a repository which diverges from the source" {
+    dolt checkout -b other
+    dolt checkout master
+    dolt sql -q "insert into test values (3, 3)"
+
+    run dolt admin cow-copy . "$BATS_TMPDIR/dolt-cow-copy-$$"
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Copied" ]] || false
+
+    cd "$BATS_TMPDIR/dolt-cow-copy-$$"
+    run dolt branch
+    [[ "$output" =~ "other" ]] || false
+    run dolt status
+    [[ "$output" =~ "test" ]] || false
+    run dolt sql -q "select count(*) from test" -r csv
+    [[ "$output" =~ "3" ]] || false
+
+    dolt add .
+    dolt commit -m "committed in the copy"
+    dolt branch copied
+    run dolt log
+    [[ "$output" =~ "committed in the copy" ]] || false
+
+    cd "$BATS_TMPDIR/dolt-repo-$$"
+    run dolt log
+    [[ ! "$output" =~ "committed in the copy" ]] || false
+    run dolt branch
+    [[ ! "$output" =~ "copied" ]] || false
+    run dolt status
+    [[ "$output" =~ "test" ]] || false
+}
+
+@test "dolt admin cow-copy shares table files with the source" {
+    dolt admin cow-copy . "$BATS_TMPDIR/dolt-cow-copy-$$"
+    for file in $(ls .dolt/noms | grep -v -e manifest -e LOCK); do
+        [ "$(stat -c %i .dolt/noms/$file)" = "$(stat -c %i $BATS_TMPDIR/dolt-cow-copy-$$/.dolt/noms/$file)" ]
+    done
+
+    # removing the copy leaves the source intact
+    rm -rf "$BATS_TMPDIR/dolt-cow-copy-$$"
+    run dolt sql -q "select * from test" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "2,2" ]] || false
+}
+
+@test "dolt admin cow-copy refuses to copy over a repository or from a directory which isn't one" {
+    dolt admin cow-copy . "$BATS_TMPDIR/dolt-cow-copy-$$"
+    run dolt admin cow-copy . "$BATS_TMPDIR/dolt-cow-copy-$$"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "already a dolt repository" ]] || false
+
+    run dolt admin cow-copy "$BATS_TMPDIR/dolt-no-repo-$$" "$BATS_TMPDIR/dolt-cow-copy-$$-2"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "is not a dolt repository" ]] || false
+}
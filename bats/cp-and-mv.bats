@@ -110,3 +110,59 @@ teardown() {
     [[ "$output" =~ "not a valid table name" ]] || false
     [[ "$output" =~ "reserved" ]] || false
 }
+
+@test "cp table from another branch" {
+    dolt add test1
+    dolt commit -m "added test1"
+    dolt checkout -b staging
+    dolt sql -q "delete from test1"
+    dolt table rm test2
+    dolt add .
+    dolt commit -m "emptied test1"
+
+    run dolt table cp master:test1 test1_copy
+    [ "$status" -eq 0 ]
+    run dolt sql -q 'select * from test1_copy' -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "1,1" ]] || false
+    [ "${#lines[@]}" -eq 2 ]
+
+    run dolt table cp master test1 test1_copy2
+    [ "$status" -eq 0 ]
+    run dolt sql -q 'select * from test1_copy2' -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "1,1" ]] || false
+
+    run dolt table cp nonexistent:test1 test1_copy3
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "nonexistent" ]] || false
+    run dolt table cp master:test2 test2_copy
+    [ "$status" -eq 7 ]
+    [[ "$output" =~ "not found" ]] || false
+}
+
+@test "cp table with --schema-only" {
+    dolt sql <<SQL
+CREATE TABLE people (
+  id BIGINT NOT NULL,
+  name VARCHAR(80) NOT NULL COMMENT 'full name',
+  PRIMARY KEY (id)
+);
+INSERT INTO people VALUES (1, 'ann');
+SQL
+    dolt add people
+    dolt commit -m "added people"
+    dolt checkout -b staging
+
+    run dolt table cp --schema-only master:people staging_people
+    [ "$status" -eq 0 ]
+    run dolt sql -q 'select count(*) from staging_people' -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "0" ]] || false
+    run dolt schema show staging_people
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "VARCHAR(80) NOT NULL COMMENT 'full name" ]] || false
+
+    run dolt sql -q "insert into staging_people (id) values (2)"
+    [ "$status" -ne 0 ]
+}
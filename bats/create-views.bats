@@ -201,3 +201,56 @@ SQL
     [ "$status" -eq 1 ]
     [[ "${lines[0]}" =~ "table not found: five" ]] || false
 }
+
+@test "show create view" {
+    dolt sql -q "create view four as select 2+2 as res from dual"
+    run dolt sql -q "show create view four"
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ 'CREATE VIEW `four` AS select 2+2 as res from dual' ]] || false
+}
+
+@test "diff shows created, modified and dropped views" {
+    dolt sql -q "create view four as select 2+2 as res from dual"
+    dolt sql -q "create view five as select 2+3 as res from dual"
+    dolt add .
+    dolt commit -m "added views"
+    dolt sql -q "drop view four"
+    dolt sql -q "create view four as select 1+3 as res from dual"
+    dolt sql -q "drop view five"
+    dolt sql -q "create view six as select 3+3 as res from dual"
+    run dolt diff
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "diff --dolt a/five b/five" ]] || false
+    [[ "$output" =~ "deleted view" ]] || false
+    [[ "$output" =~ '- CREATE VIEW `four` AS select 2+2 as res from dual' ]] || false
+    [[ "$output" =~ '+ CREATE VIEW `four` AS select 1+3 as res from dual' ]] || false
+    [[ "$output" =~ "added view" ]] || false
+    [[ "$output" =~ '+ CREATE VIEW `six` AS select 3+3 as res from dual' ]] || false
+    [[ ! "$output" =~ "dolt_schemas" ]] || false
+    run dolt diff --data
+    [ "$status" -eq 0 ]
+    [ "$output" = "" ]
+}
+
+@test "merging edits to the same view on both branches conflicts" {
+    dolt sql -q "create view four as select 2+2 as res from dual"
+    dolt add .
+    dolt commit -m "added view"
+    dolt checkout -b other
+    dolt sql -q "drop view four"
+    dolt sql -q "create view four as select 1+3 as res from dual"
+    dolt add .
+    dolt commit -m "edited view on other"
+    dolt checkout master
+    dolt sql -q "drop view four"
+    dolt sql -q "create view four as select 3+1 as res from dual"
+    dolt add .
+    dolt commit -m "edited view on master"
+    run dolt merge other
+    [ "$status" -eq 3 ]
+    [[ "$output" =~ "CONFLICT" ]] || false
+    run dolt conflicts cat dolt_schemas
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "select 1+3 as res from dual" ]] || false
+    [[ "$output" =~ "select 3+1 as res from dual" ]] || false
+}
@@ -296,6 +296,79 @@ SQL
     [[ "$output" =~ "failed to parse where clause" ]] || false
 }
 
+@test "diff with sql predicate where clause" {
+    dolt sql <<SQL
+CREATE TABLE test (
+  pk BIGINT NOT NULL COMMENT 'tag:0',
+  c1 BIGINT COMMENT 'tag:1',
+  PRIMARY KEY (pk)
+);
+SQL
+    dolt sql -q "insert into test values (0, 0), (1, 1), (2, 2), (3, 3), (4, 4), (5, 5)"
+    dolt add test
+    dolt commit -m "table created"
+    dolt sql -q "update test set c1 = c1 * 11"
+    dolt sql -q "delete from test where pk = 0"
+    dolt sql -q "insert into test values (6, 66)"
+
+    run dolt diff --where "pk >= 2 AND pk < 4"
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "|  >  | 2  | 22 |" ]] || false
+    [[ "$output" =~ "|  >  | 3  | 33 |" ]] || false
+    ! [[ "$output" =~ "|  >  | 1  | 11 |" ]] || false
+    ! [[ "$output" =~ "|  >  | 4  | 44 |" ]] || false
+
+    run dolt diff --where "pk in (0, 6)"
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "|  -  | 0  | 0  |" ]] || false
+    [[ "$output" =~ "|  +  | 6  | 66 |" ]] || false
+    ! [[ "$output" =~ "|  >  | 5  | 55 |" ]] || false
+
+    run dolt diff --where "to_pk > 1 AND to_c1 > 40"
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "|  >  | 4  | 44 |" ]] || false
+    [[ "$output" =~ "|  >  | 5  | 55 |" ]] || false
+    [[ "$output" =~ "|  +  | 6  | 66 |" ]] || false
+    ! [[ "$output" =~ "|  >  | 3  | 33 |" ]] || false
+
+    run dolt diff --summary --where "to_pk > 1 AND to_c1 > 40"
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "1 Row Added" ]] || false
+    [[ "$output" =~ "2 Rows Modified" ]] || false
+    [[ "$output" =~ "2 Changed Rows Filtered Out by --where" ]] || false
+
+    run dolt diff --summary
+    [ "$status" -eq 0 ]
+    ! [[ "$output" =~ "Filtered Out" ]] || false
+}
+
+@test "dolt_diff_ system table pushes down primary key filters" {
+    dolt sql <<SQL
+CREATE TABLE test (
+  pk BIGINT NOT NULL COMMENT 'tag:0',
+  c1 BIGINT COMMENT 'tag:1',
+  PRIMARY KEY (pk)
+);
+SQL
+    dolt sql -q "insert into test values (0, 0), (1, 1), (2, 2), (3, 3)"
+    dolt add test
+    dolt commit -m "table created"
+    dolt sql -q "update test set c1 = c1 * 11"
+    dolt sql -q "delete from test where pk = 0"
+
+    run dolt sql -q "select to_pk, from_pk, to_c1, diff_type from dolt_diff_test where to_pk >= 2" -r csv
+    [ "$status" -eq 0 ]
+    [ "${#lines[@]}" -eq 3 ]
+    [[ "$output" =~ "2,2,22,modified" ]] || false
+    [[ "$output" =~ "3,3,33,modified" ]] || false
+
+    run dolt sql -q "select from_pk, diff_type from dolt_diff_test where from_pk = 0 or to_pk = 1 order by from_pk" -r csv
+    [ "$status" -eq 0 ]
+    [ "${#lines[@]}" -eq 3 ]
+    [[ "$output" =~ "0,removed" ]] || false
+    [[ "$output" =~ "1,modified" ]] || false
+}
+
 @test "diff --cached" {
     dolt sql <<SQL
 CREATE TABLE test (
@@ -326,3 +399,129 @@ SQL
     [ $status -eq 0 ]
     [ $output -eq $CORRECT_DIFF ]
 }
+
+@test "diff --schema-only and --data-only show only changes of their kind" {
+    dolt sql -q "create table test (pk bigint primary key, c1 bigint)"
+    dolt sql -q "create table other (pk bigint primary key, c1 bigint)"
+    dolt add .
+    dolt commit -m "created tables"
+    dolt sql -q "insert into test values (0, 0)"
+    dolt sql -q "alter table other add c2 bigint"
+    run dolt diff --schema-only
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "diff --dolt a/other b/other" ]] || false
+    [[ "$output" =~ \+[[:space:]]+\`c2\` ]] || false
+    [[ ! "$output" =~ "a/test" ]] || false
+    run dolt diff --data-only
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "diff --dolt a/test b/test" ]] || false
+    [[ ! "$output" =~ \+[[:space:]]+\`c2\` ]] || false
+    run dolt diff --schema-only test
+    [ "$status" -eq 0 ]
+    [ "$output" = "" ]
+}
+
+@test "diff --exit-code exits with 1 only when there are differences of the selected kind" {
+    dolt sql -q "create table test (pk bigint primary key, c1 bigint)"
+    dolt add .
+    dolt commit -m "created table"
+    run dolt diff --exit-code
+    [ "$status" -eq 0 ]
+    dolt sql -q "insert into test values (0, 0)"
+    run dolt diff --exit-code
+    [ "$status" -eq 1 ]
+    run dolt diff --data-only --exit-code
+    [ "$status" -eq 1 ]
+    run dolt diff --schema-only --exit-code
+    [ "$status" -eq 0 ]
+    [ "$output" = "" ]
+    dolt add .
+    dolt commit -m "added a row"
+    dolt checkout -b other
+    dolt sql -q "alter table test add c2 bigint"
+    dolt add .
+    dolt commit -m "added a column"
+    run dolt diff --schema-only --exit-code master other
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "\`c2\`" ]] || false
+    run dolt diff --data-only --exit-code master other
+    [ "$status" -eq 0 ]
+    run dolt diff --schema-only --exit-code master master
+    [ "$status" -eq 0 ]
+    run dolt diff --schema-only
+    [ "$status" -eq 0 ]
+}
+
+@test "diff --exit-code with added and dropped tables" {
+    dolt sql -q "create table empty (pk bigint primary key)"
+    run dolt diff --schema-only --exit-code
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "added table" ]] || false
+    run dolt diff --data-only --exit-code
+    [ "$status" -eq 0 ]
+    dolt sql -q "create table nonempty (pk bigint primary key)"
+    dolt sql -q "insert into nonempty values (1)"
+    run dolt diff --data-only --exit-code
+    [ "$status" -eq 1 ]
+    dolt add .
+    dolt commit -m "created tables"
+    dolt table rm empty
+    run dolt diff --schema-only --exit-code
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "deleted table" ]] || false
+    run dolt diff --data-only --exit-code
+    [ "$status" -eq 0 ]
+}
+
+@test "diff --exit-code exits with 2 when the diff fails" {
+    run dolt diff --exit-code missing_table
+    [ "$status" -eq 2 ]
+    [[ "$output" =~ "Unknown table: 'missing_table'" ]] || false
+    run dolt diff missing_table
+    [ "$status" -eq 1 ]
+}
+
+@test "diff --stat composes with --schema-only and --data-only" {
+    dolt sql -q "create table test (pk bigint primary key, c1 bigint, c2 bigint)"
+    dolt sql -q "insert into test values (0, 0, 0), (1, 1, 1)"
+    dolt add .
+    dolt commit -m "created table"
+    dolt sql -q "alter table test add c3 bigint"
+    dolt sql -q "alter table test drop column c2"
+    dolt sql -q "insert into test values (2, 2, 2)"
+    run dolt diff --schema-only --stat
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "1 Column Added" ]] || false
+    [[ "$output" =~ "1 Column Dropped" ]] || false
+    [[ "$output" =~ "0 Columns Modified" ]] || false
+    [[ ! "$output" =~ "Rows Added" ]] || false
+    [[ ! "$output" =~ "CREATE TABLE" ]] || false
+    run dolt diff --data-only --stat
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "1 Row Added" ]] || false
+    [[ ! "$output" =~ "Column Added" ]] || false
+    run dolt diff --stat
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "1 Column Added" ]] || false
+    [[ "$output" =~ "1 Row Added" ]] || false
+    run dolt diff --schema-only --stat --exit-code
+    [ "$status" -eq 1 ]
+}
+
+@test "diff --schema-only shows changes to views" {
+    dolt sql -q "create table test (pk bigint primary key)"
+    dolt add .
+    dolt commit -m "created table"
+    dolt sql -q "create view four as select 2+2 as res from dual"
+    run dolt diff --schema-only --exit-code
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "added view" ]] || false
+    [[ "$output" =~ "CREATE VIEW" ]] || false
+    run dolt diff --schema-only --stat
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "added view" ]] || false
+    [[ ! "$output" =~ "CREATE VIEW" ]] || false
+    run dolt diff --data-only --exit-code
+    [ "$status" -eq 0 ]
+    [ "$output" = "" ]
+}
@@ -787,7 +787,7 @@ SQL
     # A merge with conflicts does not change the working root.
     # If the conflicts are resolved with --ours, the working root and the docs on the filesystem remain the same.
     run dolt merge test-b
-    [ "$status" -eq 0 ]
+    [ "$status" -eq 3 ]
     [[ $output =~ "CONFLICT" ]] || false
     run cat README.md
     [[ "$output" =~ "test-a branch" ]] || false
@@ -816,7 +816,8 @@ SQL
     dolt commit -m "Changed README.md on test-b-again branch"
     dolt checkout master
     dolt merge test-a-again
-    dolt merge test-b-again
+    run dolt merge test-b-again
+    [ "$status" -eq 3 ]
     dolt conflicts resolve dolt_docs --theirs
     run cat README.md
     [[ ! $output =~ "test-a-again branch" ]] || false
@@ -198,3 +198,28 @@ teardown() {
     [ "$status" -eq 1 ]
     [ "$output" = "fatal: 'dots.are.not.supported' is an invalid branch name." ]
 }
+
+@test "dolt commit without -m uses the configured editor" {
+    dolt config --local --add core.editor "/bin/sh -c 'printf \"edited message\" > \$1' --"
+    run dolt commit --allow-empty
+    [ "$status" -eq 0 ]
+    run dolt log
+    [[ "$output" =~ "edited message" ]] || false
+}
+
+@test "dolt commit aborts when the editor message only contains comments" {
+    dolt config --local --add core.editor "/bin/sh -c 'printf \"# just a comment\n\n\" > \$1' --"
+    run dolt commit --allow-empty
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "Aborting commit due to empty commit message." ]] || false
+}
+
+@test "dolt commit includes commit.template in the editor contents" {
+    printf "template line\n" > template.txt
+    dolt config --local --add commit.template "$(pwd)/template.txt"
+    dolt config --local --add core.editor "/bin/sh -c 'head -n 1 \$1 > \$1.tmp && mv \$1.tmp \$1' --"
+    run dolt commit --allow-empty
+    [ "$status" -eq 0 ]
+    run dolt log
+    [[ "$output" =~ "template line" ]] || false
+}
@@ -0,0 +1,135 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql <<SQL
+CREATE TABLE test (
+  pk BIGINT NOT NULL,
+  c1 BIGINT,
+  PRIMARY KEY (pk)
+);
+SQL
+    dolt sql -q "insert into test values (0, 0)"
+    dolt add test
+    dolt commit -m "table created"
+}
+
+teardown() {
+    teardown_common
+}
+
+make_conflict() {
+    dolt branch other
+    dolt sql -q "replace into test values (0, 1)"
+    dolt add test
+    dolt commit -m "changed c1 to 1"
+    dolt checkout other
+    dolt sql -q "replace into test values (0, 11)"
+    dolt add test
+    dolt commit -m "changed c1 to 11"
+    dolt checkout master
+}
+
+@test "merge with conflicts exits with the conflict exit code" {
+    make_conflict
+    run dolt merge other
+    [ "$status" -eq 3 ]
+    [[ "$output" =~ "CONFLICT (content): Merge conflict in test" ]] || false
+    [[ "$output" =~ "Automatic merge failed" ]] || false
+
+    run dolt merge other
+    [ "$status" -eq 3 ]
+    [[ "$output" =~ "you have unmerged files" ]] || false
+}
+
+@test "merge with conflicts prints a json error with --json-errors" {
+    make_conflict
+    run dolt --json-errors merge other
+    [ "$status" -eq 3 ]
+    [[ "$output" =~ '{"category":"conflict","exit_code":3,"message":"Automatic merge failed; fix conflicts and then commit the result."}' ]] || false
+}
+
+@test "merge of an unknown branch exits with the not found exit code" {
+    run dolt merge nonexistent
+    [ "$status" -eq 7 ]
+    [[ "$output" =~ "unknown branch: nonexistent" ]] || false
+
+    run dolt --json-errors merge nonexistent
+    [ "$status" -eq 7 ]
+    [[ "$output" =~ '"category":"not_found"' ]] || false
+    [[ "$output" =~ '"message":"unknown branch: nonexistent"' ]] || false
+}
+
+@test "push to an unknown remote exits with the not found exit code" {
+    run dolt push nonexistent master
+    [ "$status" -eq 7 ]
+    [[ "$output" =~ "fatal: unknown remote nonexistent" ]] || false
+
+    run dolt --json-errors push nonexistent master
+    [ "$status" -eq 7 ]
+    [ "$output" = '{"category":"not_found","exit_code":7,"message":"fatal: unknown remote nonexistent"}' ]
+}
+
+@test "push rejected as non-fast-forward exits with the conflict exit code" {
+    mkdir ../remote
+    dolt remote add origin file://../remote
+    dolt push origin master
+    dolt clone file://../remote ../clone
+
+    pushd ../clone
+    dolt sql -q "insert into test values (1, 1)"
+    dolt add test
+    dolt commit -m "added a row in the clone"
+    dolt push origin master
+    popd
+
+    dolt sql -q "insert into test values (2, 2)"
+    dolt add test
+    dolt commit -m "added a row"
+    run dolt push origin master
+    [ "$status" -eq 3 ]
+    [[ "$output" =~ "[rejected]" ]] || false
+    [[ "$output" =~ "error: failed to push some refs" ]] || false
+
+    run dolt --json-errors push origin master
+    [ "$status" -eq 3 ]
+    [[ "$output" =~ '"category":"conflict"' ]] || false
+}
+
+@test "pull from a remote which can't be reached exits with the network exit code" {
+    dolt remote add origin http://localhost:1/test-org/test-repo
+    run dolt pull origin
+    [ "$status" -eq 6 ]
+
+    run dolt --json-errors pull origin
+    [ "$status" -eq 6 ]
+    [[ "$output" =~ '"category":"network","exit_code":6' ]] || false
+}
+
+@test "table import of a bad row exits with the constraint violation exit code" {
+    cat <<DELIM > bad.csv
+pk,c1
+1,1
+2,not a number
+DELIM
+    run dolt table import -u test bad.csv
+    [ "$status" -eq 4 ]
+    [[ "$output" =~ "A bad row was encountered" ]] || false
+
+    run dolt --json-errors table import -u test bad.csv
+    [ "$status" -eq 4 ]
+    [[ "$output" =~ '"category":"constraint_violation","exit_code":4,"message":"A bad row was encountered while moving data."' ]] || false
+}
+
+@test "--json-errors doesn't print the usage" {
+    mkdir ../remote
+    dolt remote add origin file://../remote
+    run dolt push --set-upstream
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "usage:" ]] || false
+
+    run dolt --json-errors push --set-upstream
+    [ "$status" -eq 1 ]
+    [ "$output" = '{"category":"unknown","exit_code":1,"message":"error: --set-upstream requires <remote> and <refspec> params."}' ]
+}
@@ -100,6 +100,29 @@ if rows[2] != "9,8,7,6,5,4".split(","):
     diff --strip-trailing-cr $BATS_TEST_DIRNAME/helper/1pk5col-ints.sql export.sql
 }
 
+@test "dolt table export to a SQLite file" {
+    which sqlite3 || skip "sqlite3 is not installed"
+    dolt sql -q "insert into test_int values (0, 1, 2, 3, 4, 5)"
+    dolt sql -q "insert into test_string values ('a', 'b', 'c', 'd', 'e', 'f')"
+    run dolt table export --file-type sqlite export.db
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Successfully exported data." ]] || false
+    run sqlite3 export.db "select * from test_int"
+    [ "$output" = "0|1|2|3|4|5" ]
+    run sqlite3 export.db "select * from test_string"
+    [ "$output" = "a|b|c|d|e|f" ]
+
+    run dolt table export export.db test_int
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "already exists" ]] || false
+
+    # only the given tables are exported to a replaced file
+    run dolt table export -f export.db test_int
+    [ "$status" -eq 0 ]
+    run sqlite3 export.db "select name from sqlite_master where type = 'table'"
+    [ "$output" = "test_int" ]
+}
+
 @test "export a table with a string with commas to csv" {
     run dolt sql -q "insert into test_string values ('tim', 'is', 'super', 'duper', 'rad', 'a,b,c,d,e')"
     [ "$status" -eq 0 ]
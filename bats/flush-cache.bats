@@ -0,0 +1,65 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql -q "create table test (pk int primary key, c1 int)"
+    dolt sql -q "insert into test values (1, 1), (2, 2)"
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "dolt admin flush-cache --list lists the caches" {
+    run dolt admin flush-cache --list
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "decoded_chunks" ]] || false
+    [[ "$output" =~ "schemas" ]] || false
+}
+
+@test "dolt admin flush-cache flushes the persisted schemas" {
+    run dolt admin flush-cache schemas
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Flushed 1 entries" ]] || false
+    [[ "$output" =~ "from schemas" ]] || false
+
+    run dolt admin flush-cache --list
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "schemas                   0" ]] || false
+
+    run dolt sql -q "select * from test" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "2,2" ]] || false
+}
+
+@test "dolt admin flush-cache with no name flushes every cache" {
+    run dolt admin flush-cache
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "from decoded_chunks" ]] || false
+    [[ "$output" =~ "from schemas" ]] || false
+}
+
+@test "dolt admin flush-cache with an unknown name fails" {
+    run dolt admin flush-cache bogus
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "unknown cache 'bogus'" ]] || false
+}
+
+@test "dolt_flush_cache flushes the caches from sql" {
+    run dolt sql -q "select dolt_flush_cache() > 0" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "true" ]] || false
+
+    run dolt sql -q "select dolt_flush_cache('sql_diff')" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "0" ]] || false
+
+    run dolt sql -q "select dolt_flush_cache('bogus')"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "unknown cache" ]] || false
+
+    run dolt sql -q "select * from test" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "1,1" ]] || false
+}
@@ -0,0 +1,109 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "columns imported with spaces or unicode in their names can be queried with quoted identifiers" {
+    printf 'pk,unit price,名前\n1,2,three\n' > quoted.csv
+    run dolt table import -c --pk pk test quoted.csv
+    [ "$status" -eq 0 ]
+    run dolt sql -q 'select `unit price`, `名前` from test where `unit price` = 2' -r csv
+    [ "$status" -eq 0 ]
+    [ "${lines[1]}" = "2,three" ]
+
+    run dolt schema import -c --pks pk other quoted.csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ '`unit price`' ]] || false
+}
+
+@test "columns with invalid names or names differing only by case are rejected by import and SQL" {
+    printf 'pk,Name,name\n1,2,3\n' > collide.csv
+    run dolt table import -c --pk pk test collide.csv
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "differs only by case from the column 'Name'" ]] || false
+    run dolt schema import -c --pks pk test collide.csv
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "differs only by case from the column 'Name'" ]] || false
+
+    run dolt sql -q "create table test (pk int primary key, a int, A int)"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "columns 'a' and 'A' differ only by case" ]] || false
+    run dolt sql -q 'create table test (pk int primary key, `a ` int)'
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "column names can't end with a space" ]] || false
+
+    dolt sql -q 'create table test (pk int primary key, `a``b` int, `ü` int)'
+    run dolt sql -q 'alter table test add column `Ü` int'
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "differs only by case from the column 'ü'" ]] || false
+    run dolt sql -q 'alter table test rename column `ü` to `A``B`'
+    [ "$status" -eq 1 ]
+    run dolt sql -q 'alter table test rename column `ü` to `Ü`'
+    [ "$status" -eq 0 ]
+    run dolt schema show test
+    [[ "$output" =~ '`a``b`' ]] || false
+    [[ "$output" =~ '`Ü`' ]] || false
+}
+
+@test "table names are case insensitive in the CLI, imports and SQL" {
+    dolt sql -q "create table test (pk int primary key)"
+    run dolt sql -q "create table TEST (pk int primary key)"
+    [ "$status" -eq 1 ]
+    run dolt sql -q "create table Test2 (pk int primary key)"
+    [ "$status" -eq 0 ]
+    run dolt sql -q "rename table Test2 to TEST"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "differs only by case from the table 'test'" ]] || false
+
+    printf 'pk\n1\n' > test.csv
+    run dolt table import -c --pk pk Test test.csv
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "'Test' is not a valid table name" ]] || false
+    run dolt schema import -c --pks pk Test test.csv
+    [ "$status" -eq 1 ]
+    run dolt table cp test Test
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "differs only by case from the table 'test'" ]] || false
+
+    # a table may be renamed to a different case of its own name
+    run dolt table mv test Test
+    [ "$status" -eq 0 ]
+    run dolt diff test
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "diff --dolt a/Test b/Test" ]] || false
+}
+
+@test "migrate reports and renames tables whose names differ only by case" {
+    dolt checkout -b other
+    dolt sql -q "create table people (pk int primary key)"
+    dolt add .
+    dolt commit -m "created people"
+    dolt checkout master
+    dolt sql -q "create table People (id int primary key)"
+    dolt add .
+    dolt commit -m "created People"
+    dolt merge other
+
+    run dolt migrate --dry-run
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "table people would be renamed to people_2" ]] || false
+    [[ "$output" =~ "dolt migrate --fix-identifiers" ]] || false
+
+    run dolt migrate --fix-identifiers
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Renamed table people to people_2" ]] || false
+    run dolt ls
+    [[ "$output" =~ "People" ]] || false
+    [[ "$output" =~ "people_2" ]] || false
+
+    run dolt migrate --dry-run
+    [[ ! "$output" =~ "identifier rules" ]] || false
+    run dolt migrate --fix-identifiers
+    [ "$output" = "All table and column names follow the identifier rules" ]
+}
@@ -0,0 +1,98 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql -q "INSERT INTO dolt_ignore VALUES ('tmp_*', true), ('tmp_keep', false)"
+    dolt sql -q "CREATE TABLE tmp_scratch (pk BIGINT NOT NULL, PRIMARY KEY (pk))"
+    dolt sql -q "CREATE TABLE tmp_keep (pk BIGINT NOT NULL, PRIMARY KEY (pk))"
+    dolt sql -q "CREATE TABLE test (pk BIGINT NOT NULL, PRIMARY KEY (pk))"
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "dolt_ignore is empty until patterns are added" {
+    rm -rf .dolt
+    dolt init
+    run dolt sql -q "SELECT * FROM dolt_ignore" -r csv
+    [ "$status" -eq 0 ]
+    [ "$output" = "pattern,ignored" ]
+    run dolt status
+    [[ "$output" =~ "nothing to commit, working tree clean" ]] || false
+}
+
+@test "dolt status hides ignored tables unless --ignored" {
+    run dolt status
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "new table:      test" ]] || false
+    [[ "$output" =~ "new table:      tmp_keep" ]] || false
+    [[ "$output" =~ "new table:      dolt_ignore" ]] || false
+    [[ ! "$output" =~ "tmp_scratch" ]] || false
+    [[ ! "$output" =~ "Ignored tables" ]] || false
+
+    run dolt status --ignored
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Ignored tables:" ]] || false
+    [[ "$output" =~ "new table:      tmp_scratch" ]] || false
+}
+
+@test "dolt add . and dolt add --all skip ignored tables" {
+    dolt add .
+    run dolt status --ignored
+    [[ "$output" =~ "Changes to be committed:" ]] || false
+    [[ "$output" =~ "new table:      test" ]] || false
+    [[ "$output" =~ "new table:      tmp_keep" ]] || false
+    [[ "$output" =~ "Ignored tables:" ]] || false
+    [[ "$output" =~ "new table:      tmp_scratch" ]] || false
+
+    dolt reset .
+    dolt add --all
+    dolt commit -m "add tables"
+    run dolt ls HEAD
+    [[ "$output" =~ "test" ]] || false
+    [[ ! "$output" =~ "tmp_scratch" ]] || false
+    run dolt sql -q "SELECT count(*) FROM dolt_ignore AS OF 'HEAD'" -r csv
+    [[ "$output" =~ "2" ]] || false
+}
+
+@test "dolt add with the name of an ignored table stages it" {
+    dolt add tmp_scratch
+    run dolt status
+    [[ "$output" =~ "Changes to be committed:" ]] || false
+    [[ "$output" =~ "new table:      tmp_scratch" ]] || false
+    dolt commit -m "add scratch table"
+
+    # tables which are already committed aren't ignored
+    dolt sql -q "INSERT INTO tmp_scratch VALUES (1)"
+    run dolt status
+    [[ "$output" =~ "modified:       tmp_scratch" ]] || false
+    dolt add .
+    run dolt status
+    [[ "$output" =~ "Changes to be committed:" ]] || false
+    [[ "$output" =~ "modified:       tmp_scratch" ]] || false
+}
+
+@test "dolt commit doesn't list ignored tables" {
+    dolt add test
+    dolt commit -m "add test"
+    dolt add tmp_keep dolt_ignore
+    dolt commit -m "add tmp_keep"
+    run dolt commit -m "nothing"
+    [ "$status" -ne 0 ]
+    [[ ! "$output" =~ "tmp_scratch" ]] || false
+    [[ "$output" =~ 'no changes added to commit (use "dolt add")' ]] || false
+}
+
+@test "patterns which un-ignore tables take precedence when more specific" {
+    dolt sql -q "DELETE FROM dolt_ignore"
+    dolt sql -q "INSERT INTO dolt_ignore VALUES ('*', true), ('t?st', false)"
+    run dolt status --ignored
+    [[ "$output" =~ "Untracked files:" ]] || false
+    [[ "$output" =~ "new table:      test" ]] || false
+    [[ "$output" =~ "new table:      dolt_ignore" ]] || false
+    [[ "$output" =~ "Ignored tables:" ]] || false
+    [[ "$output" =~ "new table:      tmp_keep" ]] || false
+    [[ "$output" =~ "new table:      tmp_scratch" ]] || false
+}
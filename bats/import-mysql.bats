@@ -0,0 +1,130 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+load $BATS_TEST_DIRNAME/helper/query-server-common.bash
+
+setup() {
+    skiponwindows "Has dependencies that are missing on the Jenkins Windows installation."
+
+    setup_no_dolt_init
+    mkdir src
+    cd src
+    dolt init
+    dolt sql -q "CREATE TABLE people (id INT PRIMARY KEY, name VARCHAR(32) NOT NULL, age INT)"
+    dolt sql -q "INSERT INTO people VALUES (1, 'ann', 30), (2, 'bob', 40), (3, 'cat', NULL), (4, 'dan', 25), (5, 'eve', 60)"
+    dolt sql -q "CREATE TABLE pairs (a INT, b INT, val VARCHAR(10), PRIMARY KEY (a, b))"
+    dolt sql -q "INSERT INTO pairs VALUES (1, 1, 'x'), (1, 2, 'y'), (2, 1, 'z')"
+    dolt add .
+    dolt commit -m "source tables"
+    start_sql_server src
+    cd ..
+
+    mkdir dst
+    cd dst
+    dolt init
+    DSN="dolt@tcp(127.0.0.1:$PORT)/src"
+}
+
+teardown() {
+    stop_sql_server
+    teardown_common
+}
+
+@test "import all tables from mysql and commit them" {
+    run dolt table import --from-mysql "$DSN" --all
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Import completed successfully." ]] || false
+
+    run dolt ls
+    [[ "$output" =~ "people" ]] || false
+    [[ "$output" =~ "pairs" ]] || false
+
+    run dolt log
+    [[ "$output" =~ "Import from MySQL database src" ]] || false
+
+    run dolt status
+    [[ "$output" =~ "nothing to commit" ]] || false
+
+    run dolt sql -q "SELECT count(*) FROM people WHERE age IS NULL" -r csv
+    [[ "$output" =~ "1" ]] || false
+    run dolt sql -q "SELECT val FROM pairs WHERE a = 2 AND b = 1" -r csv
+    [[ "$output" =~ "z" ]] || false
+
+    run dolt schema show people
+    [[ "$output" =~ "PRIMARY KEY (\`id\`)" ]] || false
+    [[ "$output" =~ "\`name\` VARCHAR(32)" ]] || false
+    [[ ! "$output" =~ "tag:"[0-9]+" tag:" ]] || false
+}
+
+@test "import selected tables from mysql with a where condition" {
+    run dolt table import --from-mysql "$DSN" --tables people --where "age > 28" --message "adults"
+    [ "$status" -eq 0 ]
+
+    run dolt ls
+    [[ "$output" =~ "people" ]] || false
+    [[ ! "$output" =~ "pairs" ]] || false
+
+    run dolt sql -q "SELECT id FROM people ORDER BY id" -r csv
+    [ "${lines[1]}" = "1" ]
+    [ "${lines[2]}" = "2" ]
+    [ "${lines[3]}" = "5" ]
+    [ "${#lines[@]}" -eq 4 ]
+
+    run dolt log
+    [[ "$output" =~ "adults" ]] || false
+}
+
+@test "import from mysql in batches with a commit per table" {
+    run dolt table import --from-mysql "$DSN" --all --batch-size 2 --commit-per-table --message "batched"
+    [ "$status" -eq 0 ]
+
+    run dolt log
+    [[ "$output" =~ "batched: pairs" ]] || false
+    [[ "$output" =~ "batched: people" ]] || false
+
+    run dolt sql -q "SELECT count(*) FROM people" -r csv
+    [ "${lines[1]}" = "5" ]
+    run dolt sql -q "SELECT count(*) FROM pairs" -r csv
+    [ "${lines[1]}" = "3" ]
+    [ ! -f .dolt/mysql_import.json ]
+}
+
+@test "import from mysql doesn't overwrite existing tables without -f" {
+    dolt sql -q "CREATE TABLE people (id INT PRIMARY KEY)"
+    run dolt table import --from-mysql "$DSN" --tables people
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "table 'people' already exists" ]] || false
+
+    run dolt table import --from-mysql "$DSN" --tables people -f
+    [ "$status" -eq 0 ]
+    run dolt sql -q "SELECT count(*) FROM people" -r csv
+    [ "${lines[1]}" = "5" ]
+}
+
+@test "import from mysql validates its arguments" {
+    run dolt table import --from-mysql "$DSN"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "exactly one of --tables and --all must be given" ]] || false
+
+    run dolt table import --from-mysql "$DSN" --all --tables people
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "exactly one of --tables and --all must be given" ]] || false
+
+    run dolt table import --from-mysql "$DSN" --all -u
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "is not supported when importing from MySQL" ]] || false
+
+    run dolt table import --from-mysql "$DSN" --all --batch-size 0
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "--batch-size must be positive" ]] || false
+
+    run dolt table import --from-mysql "$DSN" --resume --all
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "can't be changed when resuming an import" ]] || false
+
+    run dolt table import --from-mysql "$DSN" --resume
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "there is no import from MySQL to resume" ]] || false
+
+    run dolt table import --from-mysql "$DSN" --tables missing
+    [ "$status" -eq 1 ]
+}
@@ -0,0 +1,58 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql -q "create table test (pk int primary key, v varchar(10))"
+    dolt sql -q "insert into test values (1, 'a'), (2, 'b')"
+    dolt add .
+    dolt commit -m "added rows"
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "dolt index verify reports valid indexes" {
+    run dolt index verify
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "test.PRIMARY: 2 entries, 0 missing, 0 extra, 0 mismatched" ]] || false
+
+    run dolt index verify test.PRIMARY -r json
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ '{"indexes": [{"table": "test", "index": "PRIMARY", "problems": [], "report": {"table":"test","index":"PRIMARY","entries":2,"missing":0,"extra":0,"mismatched":0}}]}' ]] || false
+}
+
+@test "dolt index verify errors for bad arguments" {
+    run dolt index verify missing
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "table 'missing' doesn't exist" ]] || false
+
+    run dolt index verify test.idx
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "table 'test' has no index 'idx'" ]] || false
+
+    run dolt index verify -r csv
+    [ "$status" -eq 1 ]
+}
+
+@test "dolt index rebuild keeps the rows of valid indexes" {
+    run dolt index rebuild --all
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "test.PRIMARY: rebuilt 2 entries" ]] || false
+
+    run dolt status
+    [[ "$output" =~ "nothing to commit" ]] || false
+
+    run dolt sql -q "select * from test where pk = 2" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "2,b" ]] || false
+}
+
+@test "dolt index rebuild needs --all or indexes" {
+    run dolt index rebuild
+    [ "$status" -eq 1 ]
+
+    run dolt index rebuild --all test
+    [ "$status" -eq 1 ]
+}
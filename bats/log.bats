@@ -89,3 +89,139 @@ teardown() {
     regex='Merge:.*MergeCommit.*'
     [[ "$output" =~ $regex ]] || false
 }
+
+@test "dolt log outputs the commit graph as dot and json" {
+    dolt sql -q "create table test (pk int, c1 int, primary key(pk))"
+    dolt add test
+    dolt commit -m "Commit1"
+    dolt checkout -b test-branch
+    dolt sql -q "insert into test values (0,0)"
+    dolt add test
+    dolt commit -m "Commit2"
+    dolt checkout master
+    dolt sql -q "insert into test values (1,1)"
+    dolt add test
+    dolt commit -m "Commit3"
+    dolt merge test-branch
+    dolt add test
+    dolt commit -m "MergeCommit"
+
+    run dolt log -r dot
+    [ $status -eq 0 ]
+    [[ "${lines[0]}" =~ "digraph commits {" ]] || false
+    [[ "$output" =~ "MergeCommit" ]] || false
+    [[ "$output" =~ "Initialize data repository" ]] || false
+    [ `echo "$output" | grep -c -- '->'` -eq 5 ]
+
+    run dolt log -r json --depth 2
+    [ $status -eq 0 ]
+    [[ "$output" =~ '"subject": "MergeCommit"' ]] || false
+    [[ "$output" =~ '"subject": "Commit2"' ]] || false
+    [[ "$output" =~ '"subject": "Commit3"' ]] || false
+    [[ ! "$output" =~ '"subject": "Commit1"' ]] || false
+    [[ "$output" =~ '"parents": [' ]] || false
+
+    run dolt log -r json --decorate test-branch
+    [ $status -eq 0 ]
+    [[ "$output" =~ '"test-branch"' ]] || false
+    [[ ! "$output" =~ "MergeCommit" ]] || false
+
+    run dolt log -r dot --decorate
+    [ $status -eq 0 ]
+    [[ "$output" =~ "(HEAD -> master)" ]] || false
+
+    run dolt log --decorate -n 1
+    [ $status -eq 0 ]
+    [[ "$output" =~ "(HEAD -> master)" ]] || false
+
+    run dolt log -r xml
+    [ $status -ne 0 ]
+    [[ "$output" =~ "Valid values are text, dot, json" ]] || false
+}
+
+setup_merged_tables() {
+    dolt sql -q "create table a (pk int, primary key(pk))"
+    dolt sql -q "create table b (pk int, primary key(pk))"
+    dolt add .
+    dolt commit -m "Create tables"
+    dolt checkout -b side
+    dolt sql -q "insert into b values (1)"
+    dolt add b
+    dolt commit -m "Side b 1"
+    dolt sql -q "insert into b values (2)"
+    dolt add b
+    dolt commit -m "Side b 2"
+    dolt checkout master
+    dolt sql -q "insert into a values (1)"
+    dolt add a
+    dolt commit -m "Master a 1"
+    dolt merge side
+    dolt add .
+    dolt commit -m "Merge side"
+}
+
+@test "dolt log --first-parent only follows the first parents of merge commits" {
+    setup_merged_tables
+    run dolt log --first-parent
+    [ $status -eq 0 ]
+    regex='Merge:.*Merge side.*Master a 1.*Create tables.*Initialize data repository'
+    [[ "$output" =~ $regex ]] || false
+    [[ ! "$output" =~ "Side b" ]] || false
+    run dolt log --first-parent side
+    [ $status -eq 0 ]
+    [[ "$output" =~ "Side b 2" ]] || false
+    [[ "$output" =~ "Side b 1" ]] || false
+    run dolt log -r dot --first-parent
+    [ $status -ne 0 ]
+    [[ "$output" =~ "--first-parent is only supported with --result-format text" ]] || false
+}
+
+@test "dolt log with tables only shows the commits which changed them" {
+    setup_merged_tables
+    run dolt log a
+    [ $status -eq 0 ]
+    regex='Master a 1.*Create tables'
+    [[ "$output" =~ $regex ]] || false
+    [[ ! "$output" =~ "Merge side" ]] || false
+    [[ ! "$output" =~ "Side b" ]] || false
+    [[ ! "$output" =~ "Initialize data repository" ]] || false
+    run dolt log b
+    [ $status -eq 0 ]
+    regex='Side b 2.*Side b 1.*Create tables'
+    [[ "$output" =~ $regex ]] || false
+    [[ ! "$output" =~ "Merge side" ]] || false
+    [[ ! "$output" =~ "Master a 1" ]] || false
+    run dolt log --first-parent b
+    [ $status -eq 0 ]
+    [[ "$output" =~ "Create tables" ]] || false
+    [[ ! "$output" =~ "Side b" ]] || false
+    # the merge brought together changes to both tables, so it differs from both of its parents
+    run dolt log a b
+    [ $status -eq 0 ]
+    regex='Merge side.*Side b 2.*Master a 1.*Side b 1.*Create tables'
+    [[ "$output" =~ $regex ]] || false
+    run dolt log side a
+    [ $status -eq 0 ]
+    [[ "$output" =~ "Create tables" ]] || false
+    [[ ! "$output" =~ "Master a 1" ]] || false
+}
+
+@test "dolt log with a table which doesn't exist" {
+    setup_merged_tables
+    run dolt log notatable
+    [ $status -ne 0 ]
+    [[ "$output" =~ "notatable is neither a commit nor a table" ]] || false
+    run dolt log -- notatable
+    [ $status -eq 0 ]
+    [ "$output" = "" ]
+    dolt table rm a
+    dolt add a
+    dolt commit -m "Remove a"
+    run dolt log -- a
+    [ $status -eq 0 ]
+    regex='Remove a.*Master a 1.*Create tables'
+    [[ "$output" =~ $regex ]] || false
+    run dolt log HEAD -- a
+    [ $status -eq 0 ]
+    [[ "$output" =~ "Remove a" ]] || false
+}
@@ -0,0 +1,113 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql <<SQL
+CREATE TABLE counters (
+  pk BIGINT NOT NULL,
+  c1 BIGINT,
+  PRIMARY KEY (pk)
+);
+INSERT INTO counters VALUES (1,10);
+SQL
+    dolt add .
+    dolt commit -m "added counters"
+}
+
+teardown() {
+    teardown_common
+}
+
+make_conflicting_branches() {
+    dolt checkout -b other
+    dolt sql -q "UPDATE counters SET c1 = 15 WHERE pk = 1"
+    dolt add counters
+    dolt commit -m "add 5 on other"
+
+    dolt checkout master
+    dolt sql -q "UPDATE counters SET c1 = 12 WHERE pk = 1"
+    dolt add counters
+    dolt commit -m "add 2 on master"
+}
+
+@test "dolt table merge-driver sets, lists and removes drivers" {
+    run dolt table merge-driver
+    [ "$status" -eq 0 ]
+    [ "$output" = "" ]
+
+    run dolt table merge-driver counters sum-numeric-columns
+    [ "$status" -eq 0 ]
+    run dolt table merge-driver
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "counters	sum-numeric-columns" ]] || false
+
+    run dolt status
+    [[ "$output" =~ "dolt_merge_drivers" ]] || false
+
+    run dolt table merge-driver -d counters
+    [ "$status" -eq 0 ]
+    run dolt table merge-driver
+    [ "$output" = "" ]
+}
+
+@test "dolt table merge-driver rejects unknown drivers and tables" {
+    run dolt table merge-driver counters not-a-driver
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "unknown merge driver 'not-a-driver'" ]] || false
+
+    run dolt table merge-driver nope union
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "table 'nope' does not exist" ]] || false
+
+    run dolt table merge-driver -d counters
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "table 'counters' has no merge driver" ]] || false
+}
+
+@test "merge uses the driver committed in dolt_merge_drivers" {
+    dolt table merge-driver counters sum-numeric-columns
+    dolt add dolt_merge_drivers
+    dolt commit -m "sum counters"
+    make_conflicting_branches
+
+    run dolt merge other
+    [ "$status" -eq 0 ]
+    [[ ! "$output" =~ "CONFLICT" ]] || false
+
+    run dolt sql -q "SELECT c1 FROM counters WHERE pk = 1" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "17" ]] || false
+}
+
+@test "merge uses the driver selected by config over dolt_merge_drivers" {
+    dolt table merge-driver counters sum-numeric-columns
+    dolt add dolt_merge_drivers
+    dolt commit -m "sum counters"
+    make_conflicting_branches
+
+    dolt config --local --add merge.driver.counters theirs
+    run dolt merge other
+    [ "$status" -eq 0 ]
+    [[ ! "$output" =~ "CONFLICT" ]] || false
+
+    run dolt sql -q "SELECT c1 FROM counters WHERE pk = 1" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "15" ]] || false
+}
+
+@test "merge without a driver conflicts" {
+    make_conflicting_branches
+
+    run dolt merge other
+    [[ "$output" =~ "CONFLICT" ]] || false
+}
+
+@test "merge fails for an unknown configured driver" {
+    make_conflicting_branches
+
+    dolt config --local --add merge.driver.counters not-a-driver
+    run dolt merge other
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "unknown merge driver 'not-a-driver'" ]] || false
+}
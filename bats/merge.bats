@@ -97,7 +97,7 @@ teardown() {
     [[ ! "$output" =~ "test2" ]] || false
 
     run dolt merge merge_branch
-    [ "$status" -eq 1 ]
+    [ "$status" -eq 3 ]
 }
 
 @test "ff merge rejected when working changes touch same tables" {
@@ -115,5 +115,5 @@ teardown() {
     [[ ! "$output" =~ "test2" ]] || false
 
     run dolt merge merge_branch
-    [ "$status" -eq 1 ]
+    [ "$status" -eq 3 ]
 }
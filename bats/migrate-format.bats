@@ -0,0 +1,136 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_no_dolt_init
+    DOLT_DEFAULT_BIN_FORMAT=7.18 dolt init
+    dolt sql <<SQL
+CREATE TABLE test (
+  pk BIGINT NOT NULL,
+  f FLOAT,
+  s VARCHAR(10),
+  PRIMARY KEY (pk)
+);
+INSERT INTO test VALUES (1, 1.5, 'a'), (2, -2.25, 'b');
+SQL
+    dolt add .
+    dolt commit -m "added floats"
+    dolt sql -q "INSERT INTO test VALUES (3, 3.75, 'c')"
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "dolt migrate --dry-run estimates a storage format migration without changing the repo" {
+    run dolt migrate --dry-run
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Repository storage format 7.18 would be migrated to __LD_1__" ]] || false
+    [[ "$output" =~ "chunks" ]] || false
+    run cat .dolt/noms/manifest
+    [[ "$output" =~ ":7.18:" ]] || false
+    [ ! -d .dolt/noms_migrating ]
+    [ ! -d .dolt/noms_pre_migration ]
+}
+
+@test "dolt migrate rewrites the repo into the current storage format" {
+    run dolt log
+    [[ "$output" =~ "added floats" ]] || false
+    old_head=`dolt log | head -n 1 | awk '{print $2}'`
+
+    run dolt migrate
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Storage format migration complete" ]] || false
+    run cat .dolt/noms/manifest
+    [[ "$output" =~ ":__LD_1__:" ]] || false
+    [ -d .dolt/noms_pre_migration ]
+    [ ! -d .dolt/noms_migrating ]
+
+    run cat .dolt/migrated_commits.csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "old_commit,new_commit" ]] || false
+    [[ "$output" =~ "$old_head," ]] || false
+
+    run dolt log
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "added floats" ]] || false
+    [[ ! "$output" =~ "$old_head" ]] || false
+
+    run dolt sql -q "SELECT * FROM test WHERE pk = 3" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "3,3.75,c" ]] || false
+    run dolt status
+    [[ "$output" =~ "modified:" ]] || false
+
+    run dolt migrate --dry-run
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Repository storage format __LD_1__ is up to date" ]] || false
+}
+
+@test "dolt migrate --rollback restores the original store" {
+    old_head=`dolt log | head -n 1 | awk '{print $2}'`
+    dolt migrate
+    run dolt migrate --rollback
+    [ "$status" -eq 0 ]
+    run cat .dolt/noms/manifest
+    [[ "$output" =~ ":7.18:" ]] || false
+    [ ! -d .dolt/noms_pre_migration ]
+    [ ! -f .dolt/migrated_commits.csv ]
+    run dolt log
+    [[ "$output" =~ "$old_head" ]] || false
+    run dolt sql -q "SELECT * FROM test WHERE pk = 3" -r csv
+    [[ "$output" =~ "3,3.75,c" ]] || false
+
+    run dolt migrate --rollback
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "there is no format migration to roll back" ]] || false
+}
+
+@test "pushing a migrated repo to an unmigrated remote fails with a clear error" {
+    mkdir remote
+    dolt remote add origin file://remote
+    dolt push origin master
+    run dolt migrate
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Remote origin uses storage format 7.18" ]] || false
+    run dolt push origin master
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "remote 'origin' uses storage format 7.18 but this repository uses storage format __LD_1__" ]] || false
+}
+
+@test "dolt migrate flags are mutually exclusive" {
+    run dolt migrate --dry-run --rollback
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "mutually exclusive" ]] || false
+}
+
+@test "fetching from an unmigrated remote after migrating explains the remote must be migrated" {
+    mkdir remote
+    dolt remote add origin file://remote
+    dolt push origin master
+    dolt migrate
+    run dolt fetch origin
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "The remote uses storage format 7.18 but storage format __LD_1__ is required" ]] || false
+}
+
+@test "fetching from a migrated remote into an unmigrated repo suggests dolt migrate" {
+    mkdir new-repo
+    pushd new-repo
+    dolt init
+    popd
+    dolt remote add origin file://new-repo/.dolt/noms
+    run dolt fetch origin
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "This repository uses storage format 7.18 but storage format __LD_1__ is required" ]] || false
+    [[ "$output" =~ 'Run "dolt migrate"' ]] || false
+}
+
+@test "a repository written in a newer storage format fails with upgrade guidance" {
+    sed -i.bak 's/:7.18:/:__LD_2__:/' .dolt/noms/manifest
+    run dolt status
+    [ "$status" -ne 0 ]
+    [[ ! "$output" =~ "panic" ]] || false
+    [[ "$output" =~ "This repository uses storage format __LD_2__" ]] || false
+    [[ "$output" =~ "upgrade to a release newer than" ]] || false
+}
@@ -48,7 +48,8 @@ teardown() {
     [[ "${lines[25]}" =~ "table - Commands for copying, renaming, deleting, and exporting tables." ]] || false
     [[ "${lines[26]}" =~ "conflicts - Commands for viewing and resolving merge conflicts." ]] || false
     [[ "${lines[27]}" =~ "migrate - Executes a repository migration to update to the latest format." ]] || false
-    [ "${lines[28]}" = "" ]
+    [[ "${lines[28]}" =~ "size - Show the storage used by tables, branches and commits." ]] || false
+    [ "${lines[29]}" = "" ]
 }
 
 @test "testing dolt version output" {
@@ -208,6 +209,12 @@ NOT_VALID_REPO_ERROR="The current directory is not a valid dolt repository."
     [ "${lines[0]}" = "$NOT_VALID_REPO_ERROR" ]
 }
 
+@test "dolt size outside of a dolt repository" {
+    run dolt size
+    [ "$status" -ne 0 ]
+    [ "${lines[0]}" = "$NOT_VALID_REPO_ERROR" ]
+}
+
 @test "initializing a dolt repository" {
     mkdir dolt-repo-$$-new
     cd dolt-repo-$$-new
@@ -0,0 +1,79 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql <<SQL
+create table people (id int primary key, name varchar(20));
+create table staging_people (id int primary key, name varchar(20));
+insert into dolt_permissions values
+    ('analysts_staging', 'analyst', 'feature/*', 'staging_*', 'write'),
+    ('analysts_read_only', 'analyst', '*', '*', 'read');
+SQL
+    dolt add .
+    dolt commit -m "added permission policies"
+    printf "id,name\n1,amy\n" > people.csv
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "dolt_permissions is empty until policies are added" {
+    rm -rf .dolt
+    dolt init
+    run dolt sql -q "SELECT * FROM dolt_permissions" -r csv
+    [ "$status" -eq 0 ]
+    [ "$output" = "policy_name,grantee,branch_pattern,table_pattern,access" ]
+}
+
+@test "the CLI user is an admin without user.roles" {
+    run dolt table import -u people people.csv
+    [ "$status" -eq 0 ]
+    run dolt sql -q "insert into people values (2, 'bob')"
+    [ "$status" -eq 0 ]
+    run dolt sql -q "delete from dolt_permissions where policy_name = 'analysts_staging'"
+    [ "$status" -eq 0 ]
+}
+
+@test "policies limit the tables the roles of the CLI user can write on each branch" {
+    dolt config --local --add user.roles analyst
+
+    run dolt table import -u people people.csv
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "cannot write table 'people' on branch 'master', which permission policy 'analysts_read_only' makes read only" ]] || false
+    run dolt sql -q "insert into staging_people values (1, 'amy')"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "analysts_read_only" ]] || false
+
+    dolt checkout -b feature/cleanup
+    run dolt table import -u staging_people people.csv
+    [ "$status" -eq 0 ]
+    run dolt sql -q "update staging_people set name = 'bob'"
+    [ "$status" -eq 0 ]
+    run dolt sql -q "insert into people values (2, 'bob')"
+    [ "$status" -eq 1 ]
+    run dolt table rm people
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "permission denied" ]] || false
+    run dolt table mv staging_people people2
+    [ "$status" -eq 1 ]
+
+    # reads aren't limited
+    run dolt sql -q "select count(*) from people" -r csv
+    [ "$status" -eq 0 ]
+}
+
+@test "changing the permission policies requires the admin role" {
+    dolt config --local --add user.roles analyst,auditor
+
+    run dolt sql -q "delete from dolt_permissions"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "requires the admin role" ]] || false
+    run dolt sql -q "drop table dolt_permissions"
+    [ "$status" -eq 1 ]
+
+    dolt config --local --add user.roles admin
+    run dolt sql -q "delete from dolt_permissions"
+    [ "$status" -eq 0 ]
+}
@@ -0,0 +1,63 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql -q "create table test (pk int primary key, c1 int, c2 varchar(20))"
+    dolt sql -q "insert into test values (1, 20, 'x'), (2, 10, 'y'), (3, 10, 'x')"
+    dolt add test
+    dolt commit -m "created test"
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "alter table changes the primary key" {
+    run dolt sql -q "alter table test drop primary key, add primary key (c1, c2)"
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "3 rows affected" ]] || false
+    run dolt schema show test
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "PRIMARY KEY (\`c1\`, \`c2\`)" ]] || false
+    run dolt sql -q "select pk from test" -r csv
+    [ "$status" -eq 0 ]
+    [ "${lines[1]}" = "3" ]
+    [ "${lines[2]}" = "2" ]
+    [ "${lines[3]}" = "1" ]
+    run dolt sql -q "insert into test values (4, 10, 'x')"
+    [ "$status" -eq 1 ]
+}
+
+@test "changing the primary key to duplicated values fails" {
+    run dolt sql -q "alter table test drop primary key, add primary key (c1)"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "duplicate primary key" ]] || false
+    [[ "$output" =~ "(10)" ]] || false
+    run dolt status
+    [[ "$output" =~ "nothing to commit" ]] || false
+}
+
+@test "a table's primary key can't be dropped or added alone" {
+    run dolt sql -q "alter table test drop primary key"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "require a primary key" ]] || false
+    run dolt sql -q "alter table test add primary key (c1)"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "already has a primary key" ]] || false
+}
+
+@test "diff across a primary key change removes and adds every row" {
+    dolt sql -q "alter table test drop primary key, add primary key (c1, c2)"
+    run dolt diff --summary
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "3 Rows Added" ]] || false
+    [[ "$output" =~ "3 Rows Deleted" ]] || false
+    run dolt diff --sql
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "ALTER TABLE \`test\` DROP PRIMARY KEY, ADD PRIMARY KEY (\`c1\`, \`c2\`);" ]] || false
+    [[ ! "$output" =~ "RENAME COLUMN" ]] || false
+    run dolt diff
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "PRIMARY KEY (\`c1\`, \`c2\`)" ]] || false
+}
@@ -88,7 +88,7 @@ teardown() {
     dolt checkout master
     dolt merge edit_a
     run dolt merge edit_b
-    [ "$status" -eq 0 ]
+    [ "$status" -eq 3 ]
     [[ "$output" =~ "Merge conflict in dolt_query_catalog" ]] || false
 
     run dolt conflicts cat .
@@ -0,0 +1,67 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql -q "create table users (pk int primary key, name varchar(40), email varchar(60), state varchar(20))"
+    states=(active inactive pending)
+    for batch in $(seq 0 39); do
+        values=""
+        for i in $(seq $((batch * 500)) $((batch * 500 + 499))); do
+            values="$values,($i, 'user-$i', 'user-$i@example.com', '${states[$((i % 3))]}')"
+        done
+        echo "insert into users values ${values:1};" >> users.sql
+    done
+    dolt sql < users.sql
+    rm users.sql
+    dolt add .
+    dolt commit -m "added users"
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "dolt admin recompress shrinks the repository and keeps its data" {
+    run dolt admin recompress
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Recompressed" ]] || false
+    [[ "$output" =~ "smaller" ]] || false
+
+    run dolt sql -q "select count(*) from users" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "20000" ]] || false
+
+    run dolt sql -q "select email from users where pk = 4321" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "user-4321@example.com" ]] || false
+
+    run dolt log
+    [[ "$output" =~ "added users" ]] || false
+}
+
+@test "dolt admin recompress leaves storage alone when it wouldn't shrink" {
+    dolt admin recompress
+    run dolt admin recompress
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "nothing was recompressed" ]] || false
+}
+
+@test "a recompressed repository can be pushed and cloned" {
+    dolt admin recompress
+    mkdir remotedir
+    dolt remote add origin file://remotedir
+    dolt push origin master
+    mkdir dolt-repo-clones
+    cd dolt-repo-clones
+    dolt clone file://../remotedir test-repo
+    cd test-repo
+    run dolt sql -q "select count(*) from users" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "20000" ]] || false
+}
+
+@test "dolt admin recompress takes no arguments" {
+    run dolt admin recompress extra
+    [ "$status" -ne 0 ]
+}
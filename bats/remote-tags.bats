@@ -0,0 +1,157 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    cd $BATS_TMPDIR
+    cd dolt-repo-$$
+    mkdir "dolt-repo-clones"
+    dolt sql -q "create table test (pk bigint primary key, c1 bigint)"
+    dolt add test
+    dolt commit -m "created table"
+    mkdir remotedir
+    dolt remote add origin file://remotedir
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "dolt tag creates, lists, and deletes tags" {
+    dolt tag v1
+    dolt sql -q "insert into test values (1, 1)"
+    dolt add test
+    dolt commit -m "added a row"
+    dolt tag v2
+    run dolt tag
+    [ "$status" -eq 0 ]
+    [ "${lines[0]}" = "v1" ]
+    [ "${lines[1]}" = "v2" ]
+    run dolt tag -l -v
+    [ "$status" -eq 0 ]
+    [[ "${lines[0]}" =~ "v1" ]] || false
+    run dolt tag v1
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "tag 'v1' already exists" ]] || false
+    dolt tag -f v1 v2
+
+    # tags can be used wherever a branch can, and they aren't branches
+    run dolt log v1
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "added a row" ]] || false
+    run dolt branch -a
+    [[ ! "$output" =~ "v1" ]] || false
+
+    dolt tag -d v1
+    run dolt tag -l
+    [ "$output" = "v2" ]
+    run dolt tag -d v1
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "tag 'v1' not found" ]] || false
+}
+
+@test "push sends the tags reachable from the pushed branch and clone brings them" {
+    dolt tag v1
+    dolt checkout -b other
+    dolt sql -q "insert into test values (1, 1)"
+    dolt add test
+    dolt commit -m "added a row on other"
+    dolt tag unreachable
+    dolt checkout master
+
+    run dolt push origin master
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "[new tag]         v1 -> v1" ]] || false
+
+    cd dolt-repo-clones
+    dolt clone file://../remotedir test-repo
+    cd test-repo
+    run dolt tag -l
+    [ "$status" -eq 0 ]
+    [ "$output" = "v1" ]
+
+    cd ../..
+    run dolt push --tags origin
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "[new tag]         unreachable -> unreachable" ]] || false
+    run dolt push --tags origin
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Everything up-to-date" ]] || false
+
+    cd dolt-repo-clones/test-repo
+    dolt fetch
+    run dolt tag -l
+    [ "$output" = "v1" ]
+    dolt fetch --tags
+    run dolt tag -l
+    [ "${lines[0]}" = "unreachable" ]
+    [ "${lines[1]}" = "v1" ]
+    run dolt log unreachable
+    [[ "$output" =~ "added a row on other" ]] || false
+}
+
+@test "existing remote tags are only replaced with --force and can be deleted" {
+    dolt tag v1
+    dolt push origin master
+    dolt sql -q "insert into test values (1, 1)"
+    dolt add test
+    dolt commit -m "added a row"
+    dolt tag -f v1
+
+    run dolt push origin master
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "[rejected]        v1 -> v1 (already exists)" ]] || false
+    run dolt push origin refs/tags/v1
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "(already exists)" ]] || false
+    run dolt push --force origin refs/tags/v1
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "[forced update]   v1 -> v1" ]] || false
+
+    cd dolt-repo-clones
+    dolt clone file://../remotedir test-repo
+    cd test-repo
+    run dolt log v1
+    [[ "$output" =~ "added a row" ]] || false
+
+    cd ../..
+    run dolt push origin :refs/tags/v1
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "[deleted]         v1" ]] || false
+    run dolt push origin :refs/tags/v1
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "remote ref does not exist" ]] || false
+
+    # the tag is still there locally, and now in the clone too
+    run dolt tag -l
+    [ "$output" = "v1" ]
+    cd dolt-repo-clones/test-repo
+    run dolt tag -l
+    [ "$output" = "v1" ]
+}
+
+@test "fetch doesn't replace local tags without --force" {
+    dolt tag v1
+    dolt push origin master
+    cd dolt-repo-clones
+    dolt clone file://../remotedir test-repo
+    cd ..
+
+    dolt sql -q "insert into test values (1, 1)"
+    dolt add test
+    dolt commit -m "added a row"
+    dolt tag -f v1
+    dolt push --force origin refs/tags/v1
+    dolt push origin master
+
+    cd dolt-repo-clones/test-repo
+    run dolt fetch
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "v1 -> v1 (would clobber existing tag)" ]] || false
+    run dolt log v1
+    [[ ! "$output" =~ "added a row" ]] || false
+
+    dolt fetch --force
+    run dolt log v1
+    [[ "$output" =~ "added a row" ]] || false
+}
@@ -284,3 +284,129 @@ SQL
     [ ! -f LICENSE.md ]
     [ ! -f README.md ]
 }
+
+@test "clone a local repository by its path" {
+    dolt sql <<SQL
+CREATE TABLE test (
+  pk BIGINT NOT NULL COMMENT 'tag:0',
+  c1 BIGINT COMMENT 'tag:1',
+  PRIMARY KEY (pk)
+);
+INSERT INTO test VALUES (0, 0), (1, 1);
+SQL
+    dolt add test
+    dolt commit -m "test commit"
+    dolt branch other
+
+    cd dolt-repo-clones
+    run dolt clone ../ test-repo
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "cloning file://" ]] || false
+    cd test-repo
+
+    run dolt remote -v
+    regex='file://.*/\.dolt/noms'
+    [[ "$output" =~ $regex ]] || false
+
+    run dolt branch -a
+    [[ "$output" =~ "master" ]] || false
+    [[ "$output" =~ "remotes/origin/master" ]] || false
+
+    run dolt sql -q "SELECT * FROM test" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "1,1" ]] || false
+
+    # the clone is independent of the repository it was cloned from
+    dolt sql -q "INSERT INTO test VALUES (2, 2)"
+    dolt add test
+    dolt commit -m "clone commit"
+    cd ../..
+    run dolt log
+    [[ ! "$output" =~ "clone commit" ]] || false
+    run dolt sql -q "SELECT COUNT(*) FROM test" -r csv
+    [[ "$output" =~ "2" ]] || false
+
+    dolt sql -q "INSERT INTO test VALUES (3, 3)"
+    dolt add test
+    dolt commit -m "source commit"
+    cd dolt-repo-clones/test-repo
+    run dolt log
+    [[ ! "$output" =~ "source commit" ]] || false
+
+    # pulling from the source still works
+    run dolt fetch
+    [ "$status" -eq 0 ]
+    run dolt log refs/remotes/origin/master
+    [[ "$output" =~ "source commit" ]] || false
+}
+
+@test "clone a local repository by its file url" {
+    dolt sql -q "CREATE TABLE test (pk BIGINT NOT NULL, PRIMARY KEY (pk))"
+    dolt add test
+    dolt commit -m "test commit"
+
+    cd dolt-repo-clones
+    run dolt clone file://.. test-repo
+    [ "$status" -eq 0 ]
+    cd test-repo
+    run dolt log
+    [[ "$output" =~ "test commit" ]] || false
+}
+
+@test "committed conflicts are pushed and pulled with the commit" {
+    dolt sql <<SQL
+CREATE TABLE test (
+  pk BIGINT NOT NULL COMMENT 'tag:0',
+  c1 BIGINT COMMENT 'tag:1',
+  PRIMARY KEY (pk)
+);
+INSERT INTO test VALUES (0, 0);
+SQL
+    dolt add test
+    dolt commit -m "test commit"
+    mkdir remotedir
+    dolt remote add origin file://remotedir
+    dolt push origin master
+
+    cd dolt-repo-clones
+    dolt clone file://../remotedir test-repo
+    cd ../
+
+    dolt checkout -b other
+    dolt sql -q "UPDATE test SET c1 = 2 WHERE pk = 0"
+    dolt add test
+    dolt commit -m "other commit"
+    dolt checkout master
+    dolt sql -q "UPDATE test SET c1 = 1 WHERE pk = 0"
+    dolt add test
+    dolt commit -m "master commit"
+    dolt merge other
+
+    # tables in conflict are only committed with --force-conflicts
+    run dolt add test
+    [ "$status" -ne 0 ]
+    run dolt commit --force-conflicts -m "commit the conflicts"
+    [ "$status" -eq 0 ]
+    dolt push origin master
+
+    cd dolt-repo-clones/test-repo
+    dolt pull
+    run dolt conflicts cat test
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "ours" ]] || false
+    [[ "$output" =~ "theirs" ]] || false
+
+    dolt conflicts resolve --theirs test
+    dolt add test
+    dolt commit -m "resolve the conflicts"
+    dolt push origin master
+
+    cd ../..
+    dolt pull
+    run dolt conflicts cat test
+    [ "$status" -eq 0 ]
+    [[ ! "$output" =~ "ours" ]] || false
+    run dolt sql -q "SELECT c1 FROM test WHERE pk = 0" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "2" ]] || false
+}
@@ -43,24 +43,72 @@ teardown() {
     [ "$status" -eq 0 ]
     [[ ! "$output" =~ "test-remote" ]] || false
     run dolt remote remove poop
-    [ "$status" -eq 1 ]
+    [ "$status" -eq 7 ]
+    [[ "$output" =~ "unknown remote poop" ]] || false
+}
+
+@test "check a remote" {
+    dolt remote add test-remote http://localhost:50051/test-org/test-repo
+    run dolt remote check test-remote
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "dns" ]] || false
+    [[ "$output" =~ "connect" ]] || false
+    [[ "$output" =~ "tls              skipped" ]] || false
+    [[ "$output" =~ "authentication   ok" ]] || false
+    [[ "$output" =~ "authorization    ok" ]] || false
+    [[ "$output" =~ "storage access   skipped  the remote is empty" ]] || false
+
+    dolt push test-remote master
+    run dolt remote check test-remote
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "storage access   ok" ]] || false
+}
+
+@test "check a remote which can't be reached" {
+    dolt remote add test-remote http://localhost:1/test-org/test-repo
+    run dolt remote check test-remote
+    [ "$status" -eq 6 ]
+    [[ "$output" =~ "connect          failed" ]] || false
+    [[ "$output" =~ "remote 'test-remote' failed the connect check" ]] || false
+    [[ ! "$output" =~ "authentication" ]] || false
+
+    run dolt remote check poop
+    [ "$status" -eq 7 ]
     [[ "$output" =~ "unknown remote poop" ]] || false
+
+    run dolt remote check
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "usage:" ]] || false
+}
+
+@test "check a file remote" {
+    mkdir ../file-remote
+    dolt remote add file-remote file://../file-remote
+    run dolt remote check file-remote
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "storage access   ok" ]] || false
+
+    rm -rf ../file-remote
+    run dolt remote check file-remote
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "storage access   failed" ]] || false
+    [[ "$output" =~ "failed the storage access check" ]] || false
 }
 
 @test "push and pull an unknown remote" {
     dolt remote add test-remote http://localhost:50051/test-org/test-repo
     run dolt push poop master
-    [ "$status" -eq 1 ]
+    [ "$status" -eq 7 ]
     [[ "$output" =~ "unknown remote" ]] || false
     run dolt pull poop
-    [ "$status" -eq 1 ]
+    [ "$status" -eq 7 ]
     [[ "$output" =~ "unknown remote" ]] || false
 }
 
 @test "push with only one argument" {
     dolt remote add test-remote http://localhost:50051/test-org/test-repo
     run dolt push test-remote
-    [ "$status" -eq 1 ]
+    [ "$status" -eq 7 ]
     skip "Bad error message for only one command to push"
     [[ !"$output" =~ "unable to find" ]] || false
     [[ "$output" =~ "must specify remote and branch" ]] || false
@@ -221,7 +269,7 @@ SQL
     dolt remote add test-remote http://localhost:50051/test-org/test-repo
     cd "dolt-repo-clones"
     run dolt clone foo/bar
-    [ "$status" -eq 1 ]
+    [ "$status" -ne 0 ]
     skip "Cloning a non-existant repository fails weirdly and leaves trash"
     [ "$output" = "fatal: repository 'foo/bar' does not exist" ]
     [[ ! "$output" =~ "permission denied" ]] || false
@@ -460,7 +508,7 @@ SQL
     [[ "$output" =~ "Everything up-to-date" ]] || false
     dolt fetch
     run dolt push origin master
-    [ "$status" -eq 1 ]
+    [ "$status" -eq 3 ]
     [[ "$output" =~ "tip of your current branch is behind" ]] || false
 }
 
@@ -531,7 +579,7 @@ SQL
     dolt add test
     dolt commit -m "conflicting row"
     run dolt pull origin
-    [ "$status" -eq 0 ]
+    [ "$status" -eq 3 ]
     [[ "$output" =~ "CONFLICT" ]]
     dolt conflicts resolve test --ours
     dolt add test
@@ -656,7 +704,7 @@ SQL
     dolt commit -m "added other table"
     dolt fetch
     run dolt push origin master
-    [ "$status" -eq 1 ]
+    [ "$status" -eq 3 ]
     [[ "$output" =~ "tip of your current branch is behind" ]] || false
     dolt push -f master
     run dolt push -f origin master
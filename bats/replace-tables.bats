@@ -287,3 +287,39 @@ SQL
     [[ "$output" =~ "Rows Processed: 3, Additions: 3, Modifications: 0, Had No Effect: 0" ]] || false
     [[ "$output" =~ "Import completed successfully." ]] || false
 }
+
+@test "replace table with dedupe deletes missing rows and skips unchanged rows" {
+    dolt sql <<SQL
+CREATE TABLE test (
+  pk BIGINT NOT NULL COMMENT 'tag:0',
+  c1 BIGINT COMMENT 'tag:1',
+  PRIMARY KEY (pk)
+);
+INSERT INTO test VALUES (1,1),(2,2),(3,3);
+SQL
+    dolt add test
+    dolt commit -m "added rows"
+    cat <<DELIM > replace.csv
+pk,c1
+1,1
+2,22
+4,4
+DELIM
+    run dolt table import -r --dedupe test replace.csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Rows Unchanged: 1, Updated: 1, Added: 1, Deleted: 1" ]] || false
+    [[ "$output" =~ "Import completed successfully." ]] || false
+    run dolt sql -q "select pk, c1 from test order by pk" -r csv
+    [ "$status" -eq 0 ]
+    [ "${#lines[@]}" -eq 4 ]
+    [ "${lines[1]}" = "1,1" ]
+    [ "${lines[2]}" = "2,22" ]
+    [ "${lines[3]}" = "4,4" ]
+    dolt reset --hard
+    dolt sql -q "select * from test" -r csv > same.csv
+    run dolt table import -r --dedupe test same.csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Rows Unchanged: 3, Updated: 0, Added: 0, Deleted: 0" ]] || false
+    run dolt status
+    [[ "$output" =~ "nothing to commit, working tree clean" ]] || false
+}
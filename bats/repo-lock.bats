@@ -0,0 +1,88 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    let PORT="$$ % (65536-1024) + 1024"
+    SERVER_PID=""
+}
+
+teardown() {
+    if [ -n "$SERVER_PID" ]; then
+        kill -9 $SERVER_PID 2>/dev/null || true
+    fi
+    teardown_common
+}
+
+start_server_and_wait() {
+    dolt sql-server --port=$PORT --user dolt &
+    SERVER_PID=$!
+    for i in $(seq 1 50); do
+        if dolt sql-server --status > /dev/null 2>&1; then
+            return 0
+        fi
+        sleep 0.1
+    done
+    return 1
+}
+
+@test "dolt sql-server --status reports no server when none is running" {
+    run dolt sql-server --status
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "no dolt sql-server is running" ]] || false
+}
+
+@test "a running sql-server locks the repository against CLI writes" {
+    skiponwindows "Background processes are not supported on the Windows bats installation."
+
+    dolt sql -q "create table test (pk int primary key)"
+    start_server_and_wait
+
+    run dolt sql-server --status
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "dolt sql-server is running on port $PORT (pid $SERVER_PID" ]] || false
+
+    run dolt add test
+    [ "$status" -eq 8 ]
+    [[ "$output" =~ "the repository is locked by dolt sql-server (pid $SERVER_PID, port $PORT" ]] || false
+    run dolt sql -q "insert into test values (1)"
+    [ "$status" -eq 8 ]
+    [[ "$output" =~ "locked" ]] || false
+
+    # commands which only read the repository still work
+    run dolt ls
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "test" ]] || false
+    run dolt workspace
+    [ "$status" -eq 0 ]
+
+    # a second server can't serve the same repository
+    run dolt sql-server --port=$((PORT+1)) --user dolt
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "locked" ]] || false
+
+    kill $SERVER_PID
+    wait $SERVER_PID || true
+    SERVER_PID=""
+
+    run dolt sql-server --status
+    [ "$status" -eq 1 ]
+    dolt add test
+    dolt commit -m "added test"
+}
+
+@test "the lock of a sql-server which was killed is reclaimed" {
+    skiponwindows "Background processes are not supported on the Windows bats installation."
+
+    start_server_and_wait
+    kill -9 $SERVER_PID
+    wait $SERVER_PID || true
+    SERVER_PID=""
+
+    run dolt sql-server --status
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "no dolt sql-server is running" ]] || false
+    dolt sql -q "create table test (pk int primary key)"
+    dolt add test
+    dolt commit -m "added test"
+}
@@ -0,0 +1,122 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql <<SQL
+create table users (pk int primary key, name varchar(20), password varchar(20));
+insert into users values (1, 'bill', 'hunter2');
+create table passwords (pk int primary key, password varchar(20));
+insert into passwords values (1, 'swordfish');
+SQL
+    dolt add .
+    dolt commit -m "added users and passwords"
+    dolt checkout -b other
+    dolt sql -q "insert into passwords values (2, 'letmein')"
+    dolt add .
+    dolt commit -m "added a password"
+    dolt checkout master
+    dolt sql -q "insert into users values (2, 'jill', 'correcthorse')"
+    dolt add .
+    dolt commit -m "added jill"
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "dolt admin rewrite-history removes a table from every commit" {
+    run dolt log
+    [[ "$output" =~ "added jill" ]] || false
+    mastercommits=$(echo "$output" | grep -c "^commit")
+
+    run dolt admin rewrite-history passwords
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "refs/heads/master" ]] || false
+    [[ "$output" =~ "refs/heads/other" ]] || false
+    [[ "$output" =~ "dolt push --force" ]] || false
+
+    run dolt ls
+    [[ ! "$output" =~ "passwords" ]] || false
+    run dolt sql -q "select * from passwords as of 'HEAD~1'"
+    [ "$status" -eq 1 ]
+    dolt checkout other
+    run dolt ls
+    [[ ! "$output" =~ "passwords" ]] || false
+
+    run dolt log
+    [[ "$output" =~ "added a password" ]] || false
+    [[ "$output" =~ "added users and passwords" ]] || false
+    run dolt log master
+    [ $(echo "$output" | grep -c "^commit") -eq "$mastercommits" ]
+
+    run dolt log refs/original/heads/master
+    [ "$status" -eq 0 ]
+    oldhead=$(echo "$output" | head -n1 | cut -d' ' -f2)
+    run cat .dolt/rewritten_commits.csv
+    [[ "$output" =~ "old_commit,new_commit" ]] || false
+    [[ "$output" =~ "$oldhead," ]] || false
+
+    dolt branch restored refs/original/heads/master
+    run dolt sql -q "select * from passwords as of 'restored'" -r csv
+    [[ "$output" =~ "swordfish" ]] || false
+
+    run dolt status
+    [[ "$output" =~ "working tree clean" ]] || false
+}
+
+@test "dolt admin rewrite-history removes a column from every commit" {
+    run dolt admin rewrite-history users pk
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "part of the primary key" ]] || false
+
+    run dolt admin rewrite-history users password
+    [ "$status" -eq 0 ]
+    run dolt sql -q "select * from users as of 'HEAD~1'" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "pk,name" ]] || false
+    [[ ! "$output" =~ "hunter2" ]] || false
+    run dolt schema show users
+    [[ ! "$output" =~ "password" ]] || false
+    run dolt sql -q "select * from passwords" -r csv
+    [[ "$output" =~ "swordfish" ]] || false
+}
+
+@test "dolt admin rewrite-history keeps a single backup of the original refs" {
+    run dolt admin rewrite-history missing
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "table missing was not found" ]] || false
+
+    dolt admin rewrite-history passwords
+    run dolt admin rewrite-history users password
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "refs/original/" ]] || false
+    dolt admin rewrite-history --force users password
+
+    run dolt admin rewrite-history --drop-backup
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Deleted 2 backup refs" ]] || false
+    run dolt log refs/original/heads/master
+    [ "$status" -ne 0 ]
+    run dolt branch -a
+    [[ ! "$output" =~ "original" ]] || false
+}
+
+@test "pushing rewritten history requires --force" {
+    mkdir remote
+    dolt remote add origin file://remote
+    dolt push origin master
+    dolt admin rewrite-history passwords
+    run dolt push origin master
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "rewritten by 'dolt admin rewrite-history'" ]] || false
+    [[ "$output" =~ "dolt push --force" ]] || false
+    dolt push --force origin master
+
+    mkdir clones
+    cd clones
+    dolt clone file://../remote rewritten-clone
+    cd rewritten-clone
+    run dolt ls
+    [[ ! "$output" =~ "passwords" ]] || false
+}
@@ -0,0 +1,49 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql -q "create table test (pk int primary key, v int, notes varchar(20))"
+    dolt sql -q "insert into test values (1, 1, 'one'), (2, 2, null), (3, 3, 'three')"
+    dolt add .
+    dolt commit -m "added rows"
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "dolt admin rewrite-table rewrites a table once" {
+    run dolt admin rewrite-table test
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "of 3 rows of 'test'" ]] || false
+    [ ! -d .dolt/rewrite_table ] || [ -z "$(ls .dolt/rewrite_table)" ]
+
+    run dolt sql -q "select * from test" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "1,1,one" ]] || false
+    [[ "$output" =~ "2,2," ]] || false
+    [[ "$output" =~ "3,3,three" ]] || false
+
+    run dolt diff
+    [ "$status" -eq 0 ]
+    [[ ! "$output" =~ "one" ]] || false
+
+    run dolt admin rewrite-table test
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "already in the current row layout" ]] || false
+
+    dolt sql -q "insert into test values (4, 4, 'four')"
+    run dolt admin rewrite-table test
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "of 4 rows of 'test'" ]] || false
+}
+
+@test "dolt admin rewrite-table errors for bad arguments" {
+    run dolt admin rewrite-table
+    [ "$status" -eq 1 ]
+
+    run dolt admin rewrite-table missing
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "doesn't exist" ]] || false
+}
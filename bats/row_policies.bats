@@ -0,0 +1,73 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql <<SQL
+create table docs (pk int primary key, owner varchar(20), title varchar(50));
+insert into docs values (1, 'alice', 'plan'), (2, 'bob', 'budget'), (3, 'alice', 'notes');
+SQL
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "create, show and drop row policies" {
+    run dolt sql -q "create policy own on docs using (owner = user())"
+    [ "$status" -eq 0 ]
+    run dolt sql -q "create policy audit on docs to 'carol' using (pk < 3)"
+    [ "$status" -eq 0 ]
+
+    run dolt sql -q "show policies on docs" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Table,Policy,User,Predicate" ]] || false
+    [[ "$output" =~ "docs,audit,carol,pk < 3" ]] || false
+    [[ "$output" =~ "docs,own,PUBLIC,owner = user()" ]] || false
+
+    # the CLI isn't limited by row policies
+    run dolt sql -q "select count(*) from docs" -r csv
+    [[ "$output" =~ "3" ]] || false
+
+    run dolt sql -q "create policy own on docs using (true)"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "Row policy own already exists on table docs" ]] || false
+
+    run dolt sql -q "create policy bad on docs using (missing = 1)"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "Invalid row policy bad on table docs" ]] || false
+
+    run dolt sql -q "drop policy audit on docs"
+    [ "$status" -eq 0 ]
+    run dolt sql -q "show policies" -r csv
+    [[ ! "$output" =~ "audit" ]] || false
+    [[ "$output" =~ "own" ]] || false
+}
+
+@test "row policies are versioned and follow their table" {
+    dolt sql -q "create policy own on docs using (owner = user())"
+    run dolt status
+    [[ "$output" =~ "dolt_row_policies" ]] || false
+    dolt add .
+    dolt commit -m "added a row policy"
+
+    run dolt sql -q "select policy_name, predicate from dolt_row_policies as of 'HEAD'" -r csv
+    [[ "$output" =~ "own,owner = user()" ]] || false
+
+    dolt sql -q "rename table docs to documents"
+    run dolt sql -q "show policies" -r csv
+    [[ "$output" =~ "documents,own,PUBLIC" ]] || false
+
+    dolt sql -q "drop table documents"
+    run dolt sql -q "select count(*) from dolt_row_policies" -r csv
+    [[ "$output" =~ "0" ]] || false
+
+    # batch mode runs row policy statements
+    run dolt sql <<SQL
+create table more (pk int primary key);
+create policy first on more using (pk = 1);
+show policies;
+SQL
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "first" ]] || false
+}
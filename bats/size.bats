@@ -0,0 +1,69 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql <<SQL
+CREATE TABLE test (
+  pk BIGINT NOT NULL,
+  c1 BIGINT,
+  PRIMARY KEY (pk)
+);
+INSERT INTO test VALUES (1, 1), (2, 2);
+SQL
+    dolt add .
+    dolt commit -m "added test"
+    dolt checkout -b feature
+    dolt sql -q "INSERT INTO test VALUES (3, 3)"
+    dolt add .
+    dolt commit -m "added feature row"
+    dolt checkout master
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "dolt size reports tables and branches" {
+    run dolt size
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Tables in working set:" ]] || false
+    [[ "$output" =~ "| test " ]] || false
+    [[ "$output" =~ "Branches:" ]] || false
+    [[ "$output" =~ "| master " ]] || false
+    [[ "$output" =~ "| feature " ]] || false
+    [[ ! "$output" =~ "Commits:" ]] || false
+    [ -f .dolt/size_cache.json ]
+}
+
+@test "dolt size --commits reports commit sizes" {
+    run dolt size --commits feature
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Tables in " ]] || false
+    [[ ! "$output" =~ "Tables in working set:" ]] || false
+    [[ "$output" =~ "Commits:" ]] || false
+    [[ "$output" =~ "added test" ]] || false
+    [[ "$output" =~ "added feature row" ]] || false
+
+    run dolt size --commits -n 1
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "added test" ]] || false
+    [[ ! "$output" =~ "added feature row" ]] || false
+}
+
+@test "dolt size -r json" {
+    run dolt size --commits -r json
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ '"tables": [' ]] || false
+    [[ "$output" =~ '"name": "test"' ]] || false
+    [[ "$output" =~ '"exclusive_bytes":' ]] || false
+    [[ "$output" =~ '"branches": [' ]] || false
+    [[ "$output" =~ '"commits": [' ]] || false
+    [[ "$output" =~ '"message": "added test"' ]] || false
+}
+
+@test "dolt size with a bad result format" {
+    run dolt size -r foo
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "Invalid argument for --result-format" ]] || false
+}
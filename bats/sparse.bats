@@ -0,0 +1,129 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql <<SQL
+create table sales_2019 (pk int primary key, c1 int);
+create table sales_2020 (pk int primary key, c1 int);
+create table employees (pk int primary key, name varchar(20));
+SQL
+    dolt add .
+    dolt commit -m "created tables"
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "dolt sparse set, list and clear" {
+    run dolt sparse list
+    [ "$status" -eq 0 ]
+    [ "$output" = "" ]
+    dolt sparse set 'sales_*'
+    run dolt sparse list
+    [ "$status" -eq 0 ]
+    [ "$output" = "sales_*" ]
+    run dolt sparse list --tables
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "employees (not in the sparse working set)" ]] || false
+    [[ ! "$output" =~ "sales_2019 (not" ]] || false
+    dolt sparse clear
+    run dolt sparse list
+    [ "$output" = "" ]
+    run dolt sparse set 'sales_['
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "invalid table pattern" ]] || false
+}
+
+@test "dolt sparse set refuses to drop changes to tables left out" {
+    dolt sql -q "insert into employees values (1, 'jill')"
+    run dolt sparse set 'sales_*'
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "have uncommitted changes" ]] || false
+    [[ "$output" =~ "employees" ]] || false
+    run dolt sparse list
+    [ "$output" = "" ]
+    dolt sparse set --force 'sales_*'
+    run dolt status
+    [[ "$output" =~ "working tree clean" ]] || false
+    run dolt sql -q "select count(*) from employees" -r csv
+    [[ "$output" =~ "0" ]] || false
+}
+
+@test "status, diff and add only use tables in the sparse working set" {
+    dolt sparse set 'sales_*'
+    dolt sql -q "insert into employees values (1, 'jill')"
+    dolt sql -q "insert into sales_2020 values (1, 100)"
+    run dolt status
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "modified:       sales_2020" ]] || false
+    [[ ! "$output" =~ "modified:       employees" ]] || false
+    [[ "$output" =~ "1 changed table outside of the sparse working set not shown: employees" ]] || false
+    run dolt status --include-sparse
+    [[ "$output" =~ "modified:       employees" ]] || false
+    run dolt diff
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "sales_2020" ]] || false
+    [[ ! "$output" =~ "employees" ]] || false
+    run dolt diff employees
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "not in the sparse working set" ]] || false
+    run dolt diff --include-sparse employees
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "jill" ]] || false
+
+    dolt add .
+    dolt commit -m "sales"
+    run dolt diff HEAD~1 HEAD --include-sparse
+    [[ "$output" =~ "sales_2020" ]] || false
+    [[ ! "$output" =~ "employees" ]] || false
+    run dolt status --include-sparse
+    [[ "$output" =~ "modified:       employees" ]] || false
+
+    run dolt add employees
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "not in the sparse working set" ]] || false
+    dolt add --include-sparse employees
+    dolt commit -m "employees"
+    run dolt status --include-sparse
+    [[ "$output" =~ "working tree clean" ]] || false
+}
+
+@test "table commands require --include-sparse for tables outside the sparse working set" {
+    dolt sparse set 'sales_*'
+    echo -e "pk,name\n1,bill" > employees.csv
+    run dolt table import -u employees employees.csv
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "not in the sparse working set" ]] || false
+    dolt table import -u --include-sparse employees employees.csv
+    run dolt table rm employees
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "not in the sparse working set" ]] || false
+    dolt table rm --include-sparse employees
+    run dolt ls
+    [[ ! "$output" =~ "employees" ]] || false
+}
+
+@test "the sparse working set is kept when switching branches" {
+    dolt sparse set 'sales_*'
+    dolt checkout -b other
+    dolt sql -q "insert into sales_2019 values (1, 100)"
+    dolt add .
+    dolt commit -m "sales on other"
+    run dolt checkout master
+    [ "$status" -eq 0 ]
+    [[ ! "$output" =~ "warning" ]] || false
+    run dolt sparse list
+    [ "$output" = "sales_*" ]
+    run dolt status
+    [[ "$output" =~ "working tree clean" ]] || false
+    dolt merge other
+    run dolt sql -q "select * from sales_2019" -r csv
+    [[ "$output" =~ "1,100" ]] || false
+
+    dolt sparse set 'inventory_*'
+    run dolt checkout other
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "doesn't match any tables on this branch" ]] || false
+}
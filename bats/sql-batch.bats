@@ -76,3 +76,50 @@ SQL
     [[ "$output" =~ "error on line 10 for query" ]] || false
     [[ "$output" =~ "poop" ]] || false
 }
+
+@test "dolt sql --commit and --commit-every create commits of a batch" {
+    dolt add test
+    dolt commit -m "created test"
+    run dolt sql --commit --commit-every 2 -m "applied script" <<SQL
+insert into test values (0,0,0,0,0,0);
+insert into test values (1,0,0,0,0,0);
+insert into test values (2,0,0,0,0,0);
+SQL
+    [ "$status" -eq 0 ]
+    run dolt log
+    [ "$status" -eq 0 ]
+    [ "$(echo "$output" | grep -c "applied script")" -eq 2 ]
+    run dolt status
+    [[ "$output" =~ "nothing to commit, working tree clean" ]] || false
+    run dolt sql -q "select count(*) from test"
+    [[ "$output" =~ " 3 " ]] || false
+}
+
+@test "dolt sql --continue-on-error reports the statements which failed" {
+    run dolt sql --continue-on-error --batch-size 1 <<SQL
+insert into test values (0,0,0,0,0,0);
+insert into test values (0,1,0,0,0,0);
+
+insert into test values poop;
+insert into test values (1,0,0,0,0,0);
+SQL
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "2 statements failed" ]] || false
+    [[ "$output" =~ "line 2: insert into test values (0,1,0,0,0,0)" ]] || false
+    [[ "$output" =~ "line 4: insert into test values poop" ]] || false
+    [[ "$output" =~ "error: 2 statements failed" ]] || false
+    run dolt sql -q "select count(*) from test"
+    [[ "$output" =~ " 2 " ]] || false
+}
+
+@test "dolt sql batch options are only used in batch mode" {
+    run dolt sql --commit -q "insert into test values (0,0,0,0,0,0)"
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "only used in batch mode" ]] || false
+    run dolt sql -b --commit-every 2 -q "insert into test values (0,0,0,0,0,0)"
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "--commit-every is only used with --commit" ]] || false
+    run dolt sql -b --batch-size 0 -q "insert into test values (0,0,0,0,0,0)"
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "--batch-size must be a positive number of rows" ]] || false
+}
@@ -0,0 +1,109 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_no_dolt_init
+    cat <<CSV > people.csv
+id,name,age
+1,bill,32
+2,jill,25
+3,phil,41
+CSV
+    cat <<CSV > orders.csv
+person,item
+1,hat
+1,shoe
+3,cup
+CSV
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "dolt sql --no-repo runs queries without a repository" {
+    run dolt sql --no-repo -q "show tables"
+    [ "$status" -eq 0 ]
+    [ ! -d .dolt ]
+}
+
+@test "dolt sql --no-repo imports files into tables" {
+    run dolt sql --no-repo --import "people.csv, orders.csv AS o PRIMARY KEY (person, item)" -r csv -q "select p.name, o.item from people p join o on p.id = o.person order by p.name, o.item"
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "bill,hat" ]] || false
+    [[ "$output" =~ "bill,shoe" ]] || false
+    [[ "$output" =~ "phil,cup" ]] || false
+    [ ! -d .dolt ]
+}
+
+@test "dolt sql --no-repo reads piped queries" {
+    run dolt sql --no-repo --import people.csv -r csv <<SQL
+insert into people values ('4', 'will', '19');
+select count(*) from people;
+SQL
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "4" ]] || false
+}
+
+@test "dolt sql --no-repo rejects files whose key isn't unique" {
+    run dolt sql --no-repo --import orders.csv -q "select * from orders"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "more than one row with the same person" ]] || false
+    [[ "$output" =~ "PRIMARY KEY" ]] || false
+}
+
+@test "dolt sql --no-repo reports missing and unsupported files" {
+    run dolt sql --no-repo --import missing.csv -q "show tables"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "file not found" ]] || false
+
+    echo "{}" > data.json
+    run dolt sql --no-repo --import data.json -q "show tables"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "Only csv, psv and xlsx files can be imported" ]] || false
+}
+
+@test "dolt sql --save-to writes the final state to a new repository" {
+    run dolt sql --no-repo --import people.csv --save-to saved -b -q "delete from people where id = '2'; create table notes (id int primary key, note varchar(20))"
+    [ "$status" -eq 0 ]
+    [ ! -d .dolt ]
+
+    cd saved
+    run dolt status
+    [[ "$output" =~ "new table:      people" ]] || false
+    [[ "$output" =~ "new table:      notes" ]] || false
+
+    run dolt sql -q "select name from people order by name" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "bill" ]] || false
+    [[ "$output" =~ "phil" ]] || false
+    [[ ! "$output" =~ "jill" ]] || false
+}
+
+@test "dolt sql --save-to doesn't overwrite an existing repository" {
+    mkdir saved
+    cd saved
+    dolt init
+    cd ..
+    run dolt sql --no-repo --import people.csv --save-to saved -q "show tables"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "already a dolt data repository" ]] || false
+}
+
+@test "dolt sql --no-repo leaves the repository it's run in alone" {
+    dolt init
+    run dolt sql --no-repo --import people.csv -q "insert into people values ('4', 'will', '19')"
+    [ "$status" -eq 0 ]
+    run dolt ls
+    [[ ! "$output" =~ "people" ]] || false
+}
+
+@test "dolt sql --import and --save-to require --no-repo" {
+    dolt init
+    run dolt sql --import people.csv -q "show tables"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "--import is only used with --no-repo" ]] || false
+    run dolt sql --save-to saved -q "show tables"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "--save-to is only used with --no-repo" ]] || false
+}
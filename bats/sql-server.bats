@@ -244,4 +244,88 @@ teardown() {
 
     server_query 1 "SELECT * FROM repo1.r1_one_pk" "pk,c1,c2\n1,1,1\n2,2,2\n3,3,3"
     server_query 1 "SELECT * FROM repo2.r2_one_pk" "pk,c3,c4\n1,1,1\n2,2,2\n3,3,3"
-}
\ No newline at end of file
+}
+# start_clone_server starts a server which clones its database with the arguments given, and waits for it to listen.
+start_clone_server() {
+    let PORT="$$ % (65536-1024) + 1024"
+    dolt sql-server --host 0.0.0.0 --port=$PORT --user dolt "$@" &
+    SERVER_PID=$!
+    for i in $(seq 1 100); do
+        if (echo > /dev/tcp/localhost/$PORT) 2>/dev/null; then
+            return 0
+        fi
+        sleep 0.1
+    done
+    return 1
+}
+
+push_commit() {
+    cd repo1
+    dolt sql -q "$1"
+    dolt add test
+    dolt commit -m "$2"
+    dolt push origin master
+    cd ..
+}
+
+@test "sql-server clones its database from a remote before listening" {
+    mkdir remote
+    cd repo1
+    dolt remote add origin file://../remote
+    dolt sql -q "CREATE TABLE test (pk BIGINT NOT NULL, PRIMARY KEY (pk))"
+    cd ..
+    push_commit "INSERT INTO test VALUES (1), (2)" "first commit"
+
+    start_clone_server --clone-url file://remote --clone-dir replica
+    cd replica
+    run dolt log
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "first commit" ]] || false
+    run dolt sql -q "SELECT COUNT(*) FROM test" -r csv
+    [ "$status" -eq 0 ]
+    [ "${lines[1]}" = "2" ]
+    cd ..
+    stop_sql_server
+    wait $SERVER_PID || true
+
+    # a database cloned before is fetched from the remote instead
+    push_commit "INSERT INTO test VALUES (3)" "second commit"
+    start_clone_server --clone-url file://remote --clone-dir replica --clone-branch master
+    cd replica
+    run dolt log
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "second commit" ]] || false
+    run dolt sql -q "SELECT COUNT(*) FROM test" -r csv
+    [ "$status" -eq 0 ]
+    [ "${lines[1]}" = "3" ]
+    run dolt status
+    [[ "$output" =~ "nothing to commit, working tree clean" ]] || false
+}
+
+@test "sql-server exits when it fails to clone its database" {
+    run dolt sql-server --port=15111 --clone-url file://missing --clone-dir replica
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "failed to clone database 'replica' from file://missing" ]] || false
+    [ ! -d replica/.dolt ]
+
+    mkdir remote
+    cd repo1
+    dolt remote add origin file://../remote
+    dolt sql -q "CREATE TABLE test (pk BIGINT NOT NULL, PRIMARY KEY (pk))"
+    cd ..
+    push_commit "INSERT INTO test VALUES (1)" "first commit"
+
+    run dolt sql-server --port=15111 --clone-url file://remote --clone-dir replica --clone-branch missing
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "failed to clone database 'replica'" ]] || false
+    [ ! -d replica/.dolt ]
+
+    # a clone with uncommitted changes isn't replaced by the remote branch
+    dolt clone file://remote replica
+    cd replica
+    dolt sql -q "INSERT INTO test VALUES (2)"
+    cd ..
+    run dolt sql-server --port=15111 --clone-url file://remote --clone-dir replica
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "uncommitted changes" ]] || false
+}
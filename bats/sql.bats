@@ -612,6 +612,25 @@ SQL
     [[ "$output" =~ "0" ]] || false
 }
 
+@test "sql delete a range of primary keys" {
+    run dolt sql -q "DELETE FROM one_pk WHERE pk BETWEEN 1 AND 2"
+    [ $status -eq 0 ]
+    [[ "$output" =~ "Query OK, 2 rows affected" ]] || false
+    run dolt sql -q "DELETE FROM two_pk WHERE pk1 >= 1"
+    [ $status -eq 0 ]
+    [[ "$output" =~ "Query OK, 2 rows affected" ]] || false
+    run dolt sql -q "SELECT pk FROM one_pk ORDER BY pk" -r csv
+    [ $status -eq 0 ]
+    [ "${#lines[@]}" -eq 3 ]
+    [ "${lines[1]}" = "0" ]
+    [ "${lines[2]}" = "3" ]
+    run dolt sql -q "SELECT pk1, pk2 FROM two_pk ORDER BY pk1, pk2" -r csv
+    [ $status -eq 0 ]
+    [ "${#lines[@]}" -eq 3 ]
+    [ "${lines[1]}" = "0,0" ]
+    [ "${lines[2]}" = "0,1" ]
+}
+
 @test "sql shell works after failing query" {
     skiponwindows "Need to install expect and make this script work on windows."
     $BATS_TEST_DIRNAME/sql-works-after-failing-query.expect
@@ -626,3 +645,80 @@ SQL
     [ $status -eq 0 ]
     [[ ! "$output" =~ 'unsupported feature' ]] || false
 }
+
+@test "sql rollback discards the changes made in a transaction" {
+    run dolt sql <<SQL
+INSERT INTO one_pk (pk,c1,c2,c3,c4,c5) VALUES (10,0,0,0,0,0);
+BEGIN;
+INSERT INTO one_pk (pk,c1,c2,c3,c4,c5) VALUES (11,0,0,0,0,0);
+DELETE FROM one_pk WHERE pk = 0;
+ROLLBACK;
+START TRANSACTION;
+INSERT INTO one_pk (pk,c1,c2,c3,c4,c5) VALUES (12,0,0,0,0,0);
+COMMIT;
+SQL
+    [ $status -eq 0 ]
+    run dolt sql -q "SELECT pk FROM one_pk WHERE pk = 0 OR pk >= 10 ORDER BY pk" -r csv
+    [ $status -eq 0 ]
+    [ "${lines[1]}" = "0" ]
+    [ "${lines[2]}" = "10" ]
+    [ "${lines[3]}" = "12" ]
+    [ "${#lines[@]}" -eq 4 ]
+}
+
+@test "sql rollback to a savepoint discards the changes made after it" {
+    run dolt sql <<SQL
+BEGIN;
+INSERT INTO one_pk (pk,c1,c2,c3,c4,c5) VALUES (10,0,0,0,0,0);
+SAVEPOINT a;
+INSERT INTO one_pk (pk,c1,c2,c3,c4,c5) VALUES (11,0,0,0,0,0);
+DELETE FROM one_pk WHERE pk = 0;
+ROLLBACK TO SAVEPOINT a;
+INSERT INTO one_pk (pk,c1,c2,c3,c4,c5) VALUES (12,0,0,0,0,0);
+RELEASE SAVEPOINT a;
+COMMIT;
+SQL
+    [ $status -eq 0 ]
+    run dolt sql -q "SELECT pk FROM one_pk WHERE pk = 0 OR pk >= 10 ORDER BY pk" -r csv
+    [ $status -eq 0 ]
+    [ "${lines[1]}" = "0" ]
+    [ "${lines[2]}" = "10" ]
+    [ "${lines[3]}" = "12" ]
+    [ "${#lines[@]}" -eq 4 ]
+    run dolt sql -q "ROLLBACK TO SAVEPOINT a"
+    [ $status -eq 1 ]
+    [[ "$output" =~ "SAVEPOINT a does not exist" ]] || false
+}
+
+@test "sql string comparisons and sorts follow the collations of columns" {
+    dolt sql <<SQL
+CREATE TABLE words (
+  pk BIGINT NOT NULL,
+  ci VARCHAR(20) COLLATE utf8mb4_0900_ai_ci,
+  bin VARCHAR(20) COLLATE utf8mb4_bin,
+  PRIMARY KEY (pk)
+);
+INSERT INTO words (pk,ci,bin) VALUES (0,'apple','apple'),(1,'Apple','Apple'),(2,'banana','banana');
+SQL
+    run dolt sql -q "SELECT pk FROM words WHERE ci = 'APPLE' ORDER BY pk" -r csv
+    [ $status -eq 0 ]
+    [ "${lines[1]}" = "0" ]
+    [ "${lines[2]}" = "1" ]
+    [ "${#lines[@]}" -eq 3 ]
+    run dolt sql -q "SELECT pk FROM words WHERE bin = 'APPLE'" -r csv
+    [ $status -eq 0 ]
+    [ "${#lines[@]}" -eq 1 ]
+    run dolt sql -q "SELECT pk FROM words WHERE ci LIKE 'a%' ORDER BY pk" -r csv
+    [ $status -eq 0 ]
+    [ "${#lines[@]}" -eq 3 ]
+    run dolt sql -q "SELECT pk FROM words ORDER BY bin" -r csv
+    [ $status -eq 0 ]
+    [ "${lines[1]}" = "1" ]
+    [ "${lines[2]}" = "0" ]
+    [ "${lines[3]}" = "2" ]
+    run dolt sql -q "SELECT pk FROM words ORDER BY ci DESC, pk" -r csv
+    [ $status -eq 0 ]
+    [ "${lines[1]}" = "2" ]
+    [ "${lines[2]}" = "0" ]
+    [ "${lines[3]}" = "1" ]
+}
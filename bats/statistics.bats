@@ -0,0 +1,54 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql <<SQL
+create table scores (pk int primary key, team varchar(20), score int);
+insert into scores values (1, 'red', 10), (2, 'blue', 20), (3, 'red', 30), (4, null, 40), (5, 'red', 50);
+SQL
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "analyze table builds statistics which are shown by show stats" {
+    run dolt sql -q "analyze table scores"
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "scores | analyze | status   | OK" ]] || false
+
+    run dolt sql -q "show stats for scores" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Table,Column,Rows,Null_fraction,Distinct,Min,Max,Buckets,Stale" ]] || false
+    [[ "$output" =~ "scores,pk,5,0,5,1,5,5,NO" ]] || false
+    [[ "$output" =~ "scores,team,5,0.2,2,blue,red,2,NO" ]] || false
+
+    run dolt sql -q "analyze table missing"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "table not found: missing" ]] || false
+}
+
+@test "statistics are versioned with the data and become stale" {
+    dolt sql -q "analyze table scores"
+    run dolt status
+    [[ "$output" =~ "dolt_statistics" ]] || false
+    dolt add .
+    dolt commit -m "analyzed scores"
+
+    run dolt sql -q "select column_name, null_count from dolt_statistics as of 'HEAD' where column_name = 'team'" -r csv
+    [[ "$output" =~ "team,1" ]] || false
+
+    dolt sql -q "delete from scores where pk > 3"
+    run dolt sql -q "show stats" -r csv
+    [[ "$output" =~ "scores,pk,5,0,5,1,5,5,YES" ]] || false
+
+    # batch mode prints the results of statistics statements
+    run dolt sql <<SQL
+analyze table scores;
+show stats;
+SQL
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "NO" ]] || false
+    [[ ! "$output" =~ "YES" ]] || false
+}
@@ -0,0 +1,60 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql <<SQL
+CREATE TABLE test (
+  pk BIGINT NOT NULL,
+  c1 BIGINT,
+  PRIMARY KEY (pk)
+);
+INSERT INTO test VALUES (1,1),(2,2),(3,3);
+SQL
+    dolt add test
+    dolt commit -m "added test"
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "status does not print row counts by default" {
+    dolt sql -q "INSERT INTO test VALUES (4,4)"
+    run dolt status
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "modified:       test" ]] || false
+    [[ ! "$output" =~ "added" ]] || false
+    [[ ! "$output" =~ "rows" ]] || false
+}
+
+@test "status --show-counts prints changed rows of modified tables" {
+    dolt sql -q "INSERT INTO test VALUES (4,4)"
+    dolt sql -q "UPDATE test SET c1 = 10 WHERE pk = 1"
+    dolt sql -q "DELETE FROM test WHERE pk = 2"
+    run dolt status --show-counts
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "modified:       test (1 added, 1 modified, 1 deleted)" ]] || false
+
+    dolt add test
+    dolt sql -q "DELETE FROM test WHERE pk = 3"
+    run dolt status --show-counts
+    [ "$status" -eq 0 ]
+    [[ "${lines[3]}" =~ "modified:       test (1 added, 1 modified, 1 deleted)" ]] || false
+    [[ "$output" =~ "modified:       test (0 added, 0 modified, 1 deleted)" ]] || false
+}
+
+@test "status --show-counts prints the rows of new and deleted tables" {
+    dolt sql <<SQL
+CREATE TABLE test2 (
+  pk BIGINT NOT NULL,
+  PRIMARY KEY (pk)
+);
+INSERT INTO test2 VALUES (1);
+SQL
+    dolt table rm test
+    run dolt status --show-counts
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "deleted:        test (3 rows)" ]] || false
+    [[ "$output" =~ "new table:      test2 (1 row)" ]] || false
+}
@@ -0,0 +1,106 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql <<SQL
+CREATE TABLE test (
+  pk BIGINT NOT NULL COMMENT 'tag:0',
+  c1 BIGINT COMMENT 'tag:1',
+  PRIMARY KEY (pk)
+);
+INSERT INTO test VALUES (1,1),(2,2);
+SQL
+    dolt add test
+    dolt commit -m "added rows"
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "dolt table show-ref shows the table ref displayed by dolt ls" {
+    run dolt table show-ref test
+    [ "$status" -eq 0 ]
+    ref=$output
+    run dolt ls -v
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "$ref    2 rows" ]] || false
+    dolt sql -q "INSERT INTO test VALUES (3,3)"
+    run dolt table show-ref HEAD test
+    [ "$status" -eq 0 ]
+    [ "$output" = "$ref" ]
+    run dolt table show-ref test
+    [ "$status" -eq 0 ]
+    [ "$output" != "$ref" ]
+    run dolt table show-ref missing
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "Table 'missing' not found" ]] || false
+}
+
+@test "export and query a table by its table ref" {
+    ref=`dolt table show-ref test`
+    dolt sql -q "DELETE FROM test WHERE pk = 1"
+    dolt table export --ref $ref test export.csv
+    run cat export.csv
+    [ "${#lines[@]}" -eq 3 ]
+    [ "${lines[1]}" = "1,1" ]
+    run dolt sql -r csv -q "SELECT pk, c1 FROM snapshot AS OF '$ref' ORDER BY pk"
+    [ "$status" -eq 0 ]
+    [ "${#lines[@]}" -eq 3 ]
+    [ "${lines[1]}" = "1,1" ]
+    [ "${lines[2]}" = "2,2" ]
+    run dolt table export --ref notahash test export.csv
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "'notahash' is not a valid table ref" ]] || false
+}
+
+@test "pin and unpin table refs" {
+    ref=`dolt table show-ref test`
+    run dolt table pin
+    [ "$status" -eq 0 ]
+    [ "$output" = "" ]
+    dolt table pin $ref
+    run dolt table pin
+    [ "$status" -eq 0 ]
+    [ "$output" = "$ref" ]
+    run dolt branch -a
+    [[ ! "$output" =~ "pins" ]] || false
+    dolt table pin -d $ref
+    run dolt table pin
+    [ "$output" = "" ]
+    run dolt table pin -d $ref
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "table ref '$ref' is not pinned" ]] || false
+    commit=`dolt sql -r csv -q "SELECT commit_hash FROM dolt_log LIMIT 1" | tail -n 1`
+    run dolt table pin $commit
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "'$commit' is not a table ref" ]] || false
+}
+
+@test "fetch a table ref from a remote and pin it" {
+    ref=`dolt table show-ref test`
+    mkdir remote
+    dolt remote add origin file://remote
+    dolt push origin master
+    mkdir fetcher
+    cd fetcher
+    dolt init
+    dolt remote add origin file://../remote
+    run dolt fetch --table-ref $ref
+    [ "$status" -eq 0 ]
+    run dolt table pin
+    [ "$output" = "$ref" ]
+    run dolt branch -a
+    [[ ! "$output" =~ "origin/master" ]] || false
+    run dolt sql -r csv -q "SELECT pk, c1 FROM test AS OF '$ref' ORDER BY pk"
+    [ "$status" -eq 0 ]
+    [ "${lines[1]}" = "1,1" ]
+    [ "${lines[2]}" = "2,2" ]
+    run dolt fetch --table-ref aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa origin
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "unable to find table ref 'aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa' on 'origin'" ]] || false
+    run dolt fetch --table-ref $ref origin master
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "refspecs cannot be fetched with --table-ref" ]] || false
+}
@@ -0,0 +1,119 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql <<SQL
+create table test (pk int primary key, c1 varchar(20), updated_at varchar(20));
+create table audit (pk int primary key, c1 varchar(20));
+SQL
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "before insert trigger sets columns of the inserted row" {
+    dolt sql -q "create trigger stamp before insert on test for each row set new.updated_at = 'now', new.c1 = concat('row ', new.pk)"
+    run dolt sql -q "insert into test (pk) values (1), (2)"
+    [ "$status" -eq 0 ]
+    run dolt sql -q "select * from test order by pk" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "1,row 1,now" ]] || false
+    [[ "$output" =~ "2,row 2,now" ]] || false
+}
+
+@test "after insert trigger writes to another table" {
+    dolt sql -q "create trigger audit_test after insert on test for each row insert into audit values (new.pk, new.c1)"
+    dolt sql -q "insert into test (pk, c1) values (1, 'a')"
+    run dolt sql -q "select * from audit" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "1,a" ]] || false
+}
+
+@test "failing trigger rolls back the statement" {
+    dolt sql -q "create trigger audit_test after insert on test for each row insert into audit values (new.pk, new.c1)"
+    dolt sql -q "insert into audit values (2, 'b')"
+    run dolt sql -q "insert into test (pk, c1) values (1, 'a'), (2, 'b')"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "Trigger audit_test failed" ]] || false
+    run dolt sql -q "select count(*) from test" -r csv
+    [[ "$output" =~ "0" ]] || false
+    run dolt sql -q "select count(*) from audit" -r csv
+    [[ "$output" =~ "1" ]] || false
+}
+
+@test "show triggers, show create trigger and drop trigger" {
+    dolt sql -q "create trigger stamp before insert on test for each row set new.updated_at = 'now'"
+    run dolt sql -q "show triggers"
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "stamp" ]] || false
+    [[ "$output" =~ "BEFORE" ]] || false
+    run dolt sql -q "show create trigger stamp"
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ 'CREATE TRIGGER `stamp` BEFORE INSERT ON `test` FOR EACH ROW set new.updated_at = '"'now'" ]] || false
+    run dolt sql -q "create trigger stamp before insert on test for each row set new.updated_at = 'later'"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "already exists" ]] || false
+    dolt sql -q "drop trigger stamp"
+    dolt sql -q "insert into test (pk) values (1)"
+    run dolt sql -q "select * from test" -r csv
+    [[ "$output" =~ "1,," ]] || false
+    run dolt sql -q "drop trigger stamp"
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "does not exist" ]] || false
+}
+
+@test "invalid triggers are rejected" {
+    run dolt sql -q "create trigger bad before insert on test for each row set new.missing = 1"
+    [ "$status" -eq 1 ]
+    run dolt sql -q "create trigger bad before insert on missing for each row set new.c1 = 1"
+    [ "$status" -eq 1 ]
+    run dolt sql -q "create trigger bad after insert on test for each row insert into test values (new.pk + 1, 'x', 'y')"
+    [ "$status" -eq 1 ]
+    run dolt sql -q "show triggers"
+    [[ ! "$output" =~ "bad" ]] || false
+}
+
+@test "triggers are committed, diffed and merged" {
+    dolt add .
+    dolt commit -m "tables"
+    dolt checkout -b other
+    dolt sql -q "create trigger stamp before insert on test for each row set new.updated_at = 'now'"
+    run dolt diff
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "added trigger" ]] || false
+    [[ "$output" =~ '+ CREATE TRIGGER `stamp` BEFORE INSERT ON `test`' ]] || false
+    dolt add .
+    dolt commit -m "added trigger"
+    dolt checkout master
+    run dolt sql -q "show triggers"
+    [[ ! "$output" =~ "stamp" ]] || false
+    dolt merge other
+    dolt sql -q "insert into test (pk) values (1)"
+    run dolt sql -q "select * from test" -r csv
+    [[ "$output" =~ "1,,now" ]] || false
+}
+
+@test "merging edits to the same trigger on both branches conflicts" {
+    dolt sql -q "create trigger stamp before insert on test for each row set new.updated_at = 'now'"
+    dolt add .
+    dolt commit -m "added trigger"
+    dolt checkout -b other
+    dolt sql -q "drop trigger stamp"
+    dolt sql -q "create trigger stamp before insert on test for each row set new.updated_at = 'other'"
+    dolt add .
+    dolt commit -m "edited trigger on other"
+    dolt checkout master
+    dolt sql -q "drop trigger stamp"
+    dolt sql -q "create trigger stamp before insert on test for each row set new.updated_at = 'master'"
+    dolt add .
+    dolt commit -m "edited trigger on master"
+    run dolt merge other
+    [ "$status" -eq 3 ]
+    [[ "$output" =~ "CONFLICT" ]] || false
+    run dolt conflicts cat dolt_schemas
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "set new.updated_at = 'other'" ]] || false
+    [[ "$output" =~ "set new.updated_at = 'master'" ]] || false
+}
@@ -164,3 +164,46 @@ SQL
     [[ "$output" =~ "Rows Processed: 3, Additions: 3, Modifications: 0, Had No Effect: 0" ]] || false
     [[ "$output" =~ "Import completed successfully." ]] || false
 }
+
+@test "update table with dedupe skips unchanged rows" {
+    dolt sql <<SQL
+CREATE TABLE test (
+  pk BIGINT NOT NULL COMMENT 'tag:0',
+  c1 BIGINT COMMENT 'tag:1',
+  PRIMARY KEY (pk)
+);
+INSERT INTO test VALUES (1,1),(2,2),(3,3);
+SQL
+    dolt add test
+    dolt commit -m "added rows"
+    cat <<DELIM > update.csv
+pk,c1
+1,1
+2,22
+4,4
+DELIM
+    run dolt table import -u --dedupe test update.csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Rows Unchanged: 1, Updated: 1, Added: 1, Deleted: 0" ]] || false
+    [[ "$output" =~ "Import completed successfully." ]] || false
+    run dolt sql -q "select c1 from test where pk in (2, 3, 4) order by pk" -r csv
+    [ "$status" -eq 0 ]
+    [ "${lines[1]}" = "22" ]
+    [ "${lines[2]}" = "3" ]
+    [ "${lines[3]}" = "4" ]
+    dolt reset --hard
+    cat <<DELIM > same.csv
+pk,c1
+1,1
+2,2
+3,3
+DELIM
+    run dolt table import -u --dedupe test same.csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Rows Unchanged: 3, Updated: 0, Added: 0, Deleted: 0" ]] || false
+    run dolt status
+    [[ "$output" =~ "nothing to commit, working tree clean" ]] || false
+    run dolt table import -c --dedupe test2 same.csv
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "fatal: dedupe is only supported for update or replace operations" ]] || false
+}
@@ -0,0 +1,46 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql -q "create table test (pk int primary key, v int)"
+    for batch in $(seq 0 9); do
+        values=""
+        for i in $(seq $((batch * 1000)) $((batch * 1000 + 999))); do
+            values="$values,($i, $i)"
+        done
+        echo "insert into test values ${values:1};" >> test.sql
+    done
+    dolt sql < test.sql
+    rm test.sql
+    dolt add .
+    dolt commit -m "added rows"
+    dolt sql -q "update test set v = 0 where pk in (10, 5000)"
+    dolt sql -q "delete from test where pk = 9000"
+    dolt add .
+    dolt commit -m "changed rows"
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "dolt admin verify-diff finds the same diff both ways" {
+    run dolt admin verify-diff HEAD~1 HEAD test
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "rows: 3 changes" ]] || false
+    [[ ! "$output" =~ "differ" ]] || false
+}
+
+@test "dolt admin verify-diff errors for bad arguments" {
+    run dolt admin verify-diff HEAD~1 HEAD
+    [ "$status" -eq 1 ]
+
+    run dolt admin verify-diff HEAD~1 HEAD missing
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "table 'missing' doesn't exist" ]] || false
+
+    run dolt admin verify-diff nonexistent HEAD test
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "'nonexistent' not found" ]] || false
+}
@@ -0,0 +1,113 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+    dolt sql <<SQL
+CREATE TABLE test (
+  pk BIGINT NOT NULL,
+  c1 BIGINT,
+  PRIMARY KEY (pk)
+);
+INSERT INTO test VALUES (0, 0), (1, 1);
+SQL
+    dolt add test
+    dolt commit -m "table created"
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "reset --hard backs up the working set and reset --undo restores it" {
+    dolt sql -q "insert into test values (2, 2)"
+    dolt add test
+    dolt sql -q "insert into test values (3, 3)"
+    run dolt reset --hard
+    [ "$status" -eq 0 ]
+    [ "$output" = "Backed up the working set before reset --hard. Run 'dolt reset --undo' to restore it." ]
+    run dolt status
+    [[ "$output" =~ "nothing to commit, working tree clean" ]] || false
+
+    run dolt reset --undo
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Restored the working set as it was before reset --hard" ]] || false
+    run dolt sql -q "select * from test where pk > 1"
+    [[ "$output" =~ " 2 " ]] || false
+    [[ "$output" =~ " 3 " ]] || false
+    run dolt status
+    [[ "$output" =~ "Changes to be committed" ]] || false
+    [[ "$output" =~ "Changes not staged for commit" ]] || false
+
+    # undoing again goes back to the state the undo replaced
+    run dolt reset --undo
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "before reset --undo" ]] || false
+    run dolt status
+    [[ "$output" =~ "nothing to commit, working tree clean" ]] || false
+}
+
+@test "a clean working set isn't backed up" {
+    run dolt reset --hard
+    [ "$status" -eq 0 ]
+    [ "$output" = "" ]
+    run dolt reset --undo
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "there is no working set backup to restore" ]] || false
+}
+
+@test "checkout of a table backs up the working set" {
+    dolt sql -q "delete from test where pk = 1"
+    run dolt checkout test
+    [ "$status" -eq 0 ]
+    [ "$output" = "Backed up the working set before checkout. Run 'dolt reset --undo' to restore it." ]
+    run dolt sql -q "select * from test where pk = 1"
+    [[ "$output" =~ " 1 " ]] || false
+
+    dolt reset --undo
+    run dolt sql -q "select count(*) from test"
+    [[ "$output" =~ " 1 " ]] || false
+    [[ ! "$output" =~ " 2 " ]] || false
+}
+
+@test "backups older than backup.retention can't be restored" {
+    dolt config --local --add backup.retention 0s
+    dolt sql -q "insert into test values (2, 2)"
+    dolt reset --hard
+    run dolt reset --undo
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "there is no working set backup to restore" ]] || false
+}
+
+@test "reset --undo can't be combined with other reset options" {
+    run dolt reset --undo --hard
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "--undo can't be used with --hard or --soft" ]] || false
+    run dolt reset --undo test
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "does not support additional params" ]] || false
+}
+
+@test "merge --abort backs up the working set" {
+    dolt checkout -b other
+    dolt sql -q "update test set c1 = 10 where pk = 0"
+    dolt add test
+    dolt commit -m "changed on other"
+    dolt checkout master
+    dolt sql -q "update test set c1 = 20 where pk = 0"
+    dolt add test
+    dolt commit -m "changed on master"
+    run dolt merge other
+    [ "$status" -eq 3 ]
+
+    run dolt merge --abort
+    [ "$status" -eq 0 ]
+    [ "$output" = "Backed up the working set before merge --abort. Run 'dolt reset --undo' to restore it." ]
+    run dolt conflicts cat test
+    [[ ! "$output" =~ "theirs" ]] || false
+
+    # the merged tables and their conflicts come back, but the merge itself is over
+    dolt reset --undo
+    run dolt conflicts cat test
+    [[ "$output" =~ "theirs" ]] || false
+}
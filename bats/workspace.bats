@@ -0,0 +1,40 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+}
+
+teardown() {
+    teardown_common
+}
+
+@test "dolt workspace lists nothing in a new repo" {
+    run dolt workspace
+    [ "$status" -eq 0 ]
+    [ "$output" = "" ]
+    run dolt workspace -v
+    [ "$status" -eq 0 ]
+    [ "$output" = "" ]
+    run dolt sql -q "select * from dolt_workspaces" -r csv
+    [ "$status" -eq 0 ]
+    [ "$output" = "name,hash,base,changed_tables,commits_behind,sessions,last_updated" ]
+}
+
+@test "dolt workspace -d fails for missing workspaces" {
+    run dolt workspace -d alice
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "workspace 'alice' not found" ]] || false
+    run dolt workspace -d
+    [ "$status" -ne 0 ]
+    run dolt workspace -d --all alice
+    [ "$status" -ne 0 ]
+    run dolt workspace -d --all
+    [ "$status" -eq 0 ]
+}
+
+@test "dolt_commit fails outside of a workspace" {
+    run dolt sql -q "select dolt_commit('message')"
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "workspace" ]] || false
+}
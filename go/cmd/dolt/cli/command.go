@@ -20,8 +20,10 @@ import (
 
 	"github.com/fatih/color"
 
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
 	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/errcat"
 	"github.com/liquidata-inc/dolt/go/libraries/events"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
 )
@@ -78,6 +80,14 @@ type RepoNotRequiredCommand interface {
 	RequiresRepo() bool
 }
 
+// RepoLockingCommand is an optional interface that commands which write to the repository implement, so that they hold
+// the lock of the repository while they run. Such a command fails when another process, such as a sql-server, holds the
+// lock.
+type RepoLockingCommand interface {
+	// LocksRepo should return true if the command holds the lock of the repository while it runs
+	LocksRepo() bool
+}
+
 // EventMonitoredCommand is an optional interface that can be overridden in order to generate an event which is sent
 // to the metrics system when the command is run
 type EventMonitoredCommand interface {
@@ -142,6 +152,16 @@ func (hc SubCommandHandler) Exec(ctx context.Context, commandStr string, args []
 				}
 			}
 
+			if lockCmd, ok := cmd.(RepoLockingCommand); ok && lockCmd.LocksRepo() && !hasHelpFlag(args) && dEnv.HasDoltDir() {
+				lock, err := dEnv.Lock(env.NewLockInfo(commandStr+" "+subCommandStr, 0))
+				if err != nil {
+					verr := errhand.BuildDError("error: failed to lock the repository: %v", err).SetCategory(errcat.Of(err)).Build()
+					PrintErrln(errhand.Format(verr))
+					return errhand.ExitCode(verr)
+				}
+				defer lock.Unlock()
+			}
+
 			var evt *events.Event
 			if evtCmd, ok := cmd.(EventMonitoredCommand); ok {
 				evt = events.NewEvent(evtCmd.EventType())
@@ -180,6 +200,11 @@ func CheckEnvIsValid(dEnv *env.DoltEnv) bool {
 	} else if dEnv.DBLoadError != nil {
 		PrintErrln(color.RedString("Failed to load database."))
 		PrintErrln(dEnv.DBLoadError.Error())
+
+		if details, ok := IncompatibleFormatDetails(dEnv.DBLoadError, dEnv.Version); ok {
+			PrintErrln(details)
+		}
+
 		return false
 	}
 
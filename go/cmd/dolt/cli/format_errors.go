@@ -0,0 +1,47 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+)
+
+// IncompatibleFormatDetails returns a message telling the user how to resolve |err| if it is a
+// *chunks.ErrIncompatibleFormat. |version| is the version of this dolt binary.
+func IncompatibleFormatDetails(err error, version string) (string, bool) {
+	ife, ok := err.(*chunks.ErrIncompatibleFormat)
+
+	if !ok {
+		return "", false
+	}
+
+	subject := "This repository"
+	if ife.Remote {
+		subject = "The remote"
+	}
+
+	supported := strings.Join(ife.Supported, ", ")
+
+	if ife.CanMigrate && ife.Remote {
+		return fmt.Sprintf(`%s uses storage format %s but storage format %s is required. The remote must be migrated to storage format %s before data can be moved between it and this repository. See "dolt migrate --help".`, subject, ife.Found, supported, supported), true
+	} else if ife.CanMigrate {
+		return fmt.Sprintf(`%s uses storage format %s but storage format %s is required. Run "dolt migrate" to update this repository to the latest format.`, subject, ife.Found, supported), true
+	}
+
+	return fmt.Sprintf(`%s uses storage format %s, which dolt %s does not support. It was written by a newer version of dolt; upgrade to a release newer than %s to use it.`, subject, ife.Found, version, version), true
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+)
+
+func TestIncompatibleFormatDetails(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			"newer local format",
+			&chunks.ErrIncompatibleFormat{Found: "__LD_2__", Supported: []string{"7.18", "__LD_1__"}},
+			"This repository uses storage format __LD_2__, which dolt 1.2.3 does not support. It was written by a newer version of dolt; upgrade to a release newer than 1.2.3 to use it.",
+		},
+		{
+			"newer remote format",
+			&chunks.ErrIncompatibleFormat{Found: "__LD_2__", Supported: []string{"7.18", "__LD_1__"}, Remote: true},
+			"The remote uses storage format __LD_2__, which dolt 1.2.3 does not support. It was written by a newer version of dolt; upgrade to a release newer than 1.2.3 to use it.",
+		},
+		{
+			"older local format",
+			&chunks.ErrIncompatibleFormat{Found: "7.18", Supported: []string{"__LD_1__"}, CanMigrate: true},
+			`This repository uses storage format 7.18 but storage format __LD_1__ is required. Run "dolt migrate" to update this repository to the latest format.`,
+		},
+		{
+			"older remote format",
+			&chunks.ErrIncompatibleFormat{Found: "7.18", Supported: []string{"__LD_1__"}, CanMigrate: true, Remote: true},
+			`The remote uses storage format 7.18 but storage format __LD_1__ is required. The remote must be migrated to storage format __LD_1__ before data can be moved between it and this repository. See "dolt migrate --help".`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			details, ok := IncompatibleFormatDetails(test.err, "1.2.3")
+			assert.True(t, ok)
+			assert.Equal(t, test.expected, details)
+		})
+	}
+
+	_, ok := IncompatibleFormatDetails(errors.New("other"), "1.2.3")
+	assert.False(t, ok)
+}
@@ -16,19 +16,27 @@ package commands
 
 import (
 	"context"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
 
 	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
 
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
 )
 
 const (
-	allParam = "all"
+	allParam   = "all"
+	patchParam = "patch"
 )
 
 var addDocs = cli.CommandDocumentationContent{
@@ -38,9 +46,15 @@ This command updates the list of tables using the current content found in the w
 
 This command can be performed multiple times before a commit. It only adds the content of the specified table(s) at the time the add command is run; if you want subsequent changes included in the next commit, then you must run dolt add again to add the new content to the index.
 
-The dolt status command can be used to obtain a summary of which tables have changes that are staged for the next commit.`,
+The dolt status command can be used to obtain a summary of which tables have changes that are staged for the next commit.
+
+New tables whose names match the patterns of the {{.EmphasisLeft}}dolt_ignore{{.EmphasisRight}} table are skipped by {{.EmphasisLeft}}dolt add .{{.EmphasisRight}} and {{.EmphasisLeft}}--all{{.EmphasisRight}}, but are staged when they're named explicitly. In a pattern {{.EmphasisLeft}}*{{.EmphasisRight}} matches any sequence of characters and {{.EmphasisLeft}}?{{.EmphasisRight}} matches any single character. Rows whose {{.EmphasisLeft}}ignored{{.EmphasisRight}} column is false un-ignore the tables they match. When a table matches several patterns the most specific one, with the most characters which aren't wildcards, decides, and the table isn't ignored if the most specific patterns disagree. Tables which have already been added are never ignored.
+
+When {{.EmphasisLeft}}--patch{{.EmphasisRight}} is given, each row that differs between the working and staged versions of the tables is shown and you are asked whether that change should be staged. If no tables are named every table with unstaged changes is gone through, one at a time. When {{.EmphasisLeft}}--where{{.EmphasisRight}} is given, only the changed rows whose primary key column matches the {{.LessThan}}column{{.GreaterThan}}={{.LessThan}}value{{.GreaterThan}} filter are staged.`,
 	Synopsis: []string{
 		`[{{.LessThan}}table{{.GreaterThan}}...]`,
+		`--patch [{{.LessThan}}table{{.GreaterThan}}...]`,
+		`--where {{.LessThan}}column{{.GreaterThan}}={{.LessThan}}value{{.GreaterThan}} {{.LessThan}}table{{.GreaterThan}}`,
 	},
 }
 
@@ -51,6 +65,11 @@ func (cmd AddCmd) Name() string {
 	return "add"
 }
 
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd AddCmd) LocksRepo() bool {
+	return true
+}
+
 // Description returns a description of the command
 func (cmd AddCmd) Description() string {
 	return "Add table changes to the list of staged table changes."
@@ -66,6 +85,9 @@ func (cmd AddCmd) createArgParser() *argparser.ArgParser {
 	ap := argparser.NewArgParser()
 	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"table", "Working table(s) to add to the list tables staged to be committed. The abbreviation '.' can be used to add all tables."})
 	ap.SupportsFlag(allParam, "A", "Stages any and all changes (adds, deletes, and modifications).")
+	ap.SupportsFlag(patchParam, "p", "Interactively choose which row changes of a table to stage.")
+	ap.SupportsString(whereParam, "", "column=value", "Stage only the row changes of a table whose primary key matches the filter.")
+	SupportsIncludeSparse(ap)
 	return ap
 }
 
@@ -84,10 +106,23 @@ func (cmd AddCmd) Exec(ctx context.Context, commandStr string, args []string, dE
 	}
 
 	allFlag := apr.Contains(allParam)
+	includeSparse := apr.Contains(IncludeSparseFlag)
+
+	if !allFlag && !(apr.NArg() == 1 && apr.Arg(0) == ".") {
+		if verr := CheckSparseTablesWithVErr(dEnv, apr.Args(), includeSparse); verr != nil {
+			return HandleVErrAndExitCode(verr, nil)
+		}
+	}
+
+	if apr.Contains(patchParam) || apr.Contains(whereParam) {
+		return stageTableRows(ctx, dEnv, apr)
+	}
 
 	var err error
 	if apr.NArg() == 0 && !allFlag {
 		cli.Println("Nothing specified, nothing added.\n Maybe you wanted to say 'dolt add .'?")
+	} else if (allFlag || apr.NArg() == 1 && apr.Arg(0) == ".") && !includeSparse {
+		err = actions.StageActiveTables(ctx, dEnv, false)
 	} else if allFlag || apr.NArg() == 1 && apr.Arg(0) == "." {
 		err = actions.StageAllTables(ctx, dEnv, false)
 	} else {
@@ -102,8 +137,134 @@ func (cmd AddCmd) Exec(ctx context.Context, commandStr string, args []string, dE
 	return 0
 }
 
+func stageTableRows(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgParseResults) int {
+	if apr.Contains(patchParam) && apr.Contains(whereParam) {
+		cli.PrintErrln(color.RedString("--%s and --%s cannot be used together", patchParam, whereParam))
+		return 1
+	}
+
+	if apr.Contains(patchParam) {
+		return patchTableRows(ctx, dEnv, apr)
+	}
+
+	if apr.NArg() != 1 || apr.Arg(0) == "." || apr.Contains(allParam) {
+		cli.PrintErrln(color.RedString("--%s requires exactly one table", whereParam))
+		return 1
+	}
+
+	tblName := apr.Arg(0)
+	if tblName == doltdb.DocTableName {
+		return HandleDocTableVErrAndExitCode()
+	}
+
+	whereClause, _ := apr.GetValue(whereParam)
+	stageFn, verr := newPKFilterStageFn(ctx, dEnv, tblName, whereClause)
+
+	if verr != nil {
+		cli.PrintErrln(verr.Verbose())
+		return 1
+	}
+
+	_, err := actions.StageTableRows(ctx, dEnv, tblName, stageFn)
+
+	if err != nil {
+		cli.PrintErrln(toAddVErr(err).Verbose())
+		return 1
+	}
+
+	return 0
+}
+
+// patchTableRows interactively stages the row changes of the tables given as arguments, or of every table with
+// unstaged changes if none are given.
+func patchTableRows(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgParseResults) int {
+	tblNames := apr.Args()
+	if len(tblNames) == 0 || apr.Contains(allParam) || (len(tblNames) == 1 && tblNames[0] == ".") {
+		_, notStaged, err := diff.GetTableDiffs(ctx, dEnv)
+
+		if err != nil {
+			cli.PrintErrln(errhand.BuildDError("error: failed to get the unstaged tables").AddCause(err).Build().Verbose())
+			return 1
+		}
+
+		notStaged, _ = activeTableDiffs(dEnv, notStaged, apr.Contains(IncludeSparseFlag))
+		tblNames = nil
+		for _, tblName := range notStaged.Tables {
+			if notStaged.TableToType[tblName] != diff.RemovedTable && tblName != doltdb.DocTableName {
+				tblNames = append(tblNames, tblName)
+			}
+		}
+	}
+
+	for _, tblName := range tblNames {
+		if tblName == doltdb.DocTableName {
+			return HandleDocTableVErrAndExitCode()
+		}
+	}
+
+	var err error
+	cli.ExecuteWithStdioRestored(func() {
+		ps := newPatchStager(os.Stdin, os.Stdout)
+		err = ps.stageTables(ctx, dEnv, tblNames)
+	})
+
+	if err != nil {
+		cli.PrintErrln(toAddVErr(err).Verbose())
+		return 1
+	}
+
+	return 0
+}
+
+// newPKFilterStageFn returns an actions.RowStageFn which accepts row changes whose primary key matches the where
+// clause. Deleted rows are matched using their staged values.
+func newPKFilterStageFn(ctx context.Context, dEnv *env.DoltEnv, tblName, whereClause string) (actions.RowStageFn, errhand.VerboseError) {
+	root, err := dEnv.WorkingRoot(ctx)
+
+	if err != nil {
+		return nil, errhand.BuildDError("Unable to read %s.", actions.WorkingRoot.String()).AddCause(err).Build()
+	}
+
+	tbl, ok, err := root.GetTable(ctx, tblName)
+
+	if err != nil {
+		return nil, errhand.BuildDError("error: failed to read table '%s'", tblName).AddCause(err).Build()
+	} else if !ok {
+		return nil, toAddVErr(actions.NewTblNotExistError([]string{tblName}))
+	}
+
+	sch, err := tbl.GetSchema(ctx)
+
+	if err != nil {
+		return nil, errhand.BuildDError("error: failed to read schema of table '%s'", tblName).AddCause(err).Build()
+	}
+
+	colName := strings.TrimSpace(strings.SplitN(whereClause, "=", 2)[0])
+	if _, ok := sch.GetPKCols().GetByName(colName); !ok {
+		return nil, errhand.BuildDError("error: --%s must filter on a primary key column of '%s'", whereParam, tblName).
+			AddDetails("'%s' is not a primary key column", colName).Build()
+	}
+
+	filter, err := ParseWhere(sch, whereClause)
+
+	if err != nil {
+		return nil, errhand.BuildDError("error: failed to parse --%s", whereParam).AddCause(err).Build()
+	}
+
+	return func(ctx context.Context, sch schema.Schema, oldRow, newRow row.Row) (bool, error) {
+		if newRow != nil {
+			return filter(newRow), nil
+		}
+
+		return filter(oldRow), nil
+	}, nil
+}
+
 func toAddVErr(err error) errhand.VerboseError {
 	switch {
+	case err == actions.ErrRowStagingSchemaChanged || err == actions.ErrRowStagingTableDeleted:
+		return errhand.BuildDError("error: unable to stage individual rows").AddDetails(err.Error()).Build()
+
 	case actions.IsRootValUnreachable(err):
 		rt := actions.GetUnreachableRootType(err)
 		bdr := errhand.BuildDError("Unable to read %s.", rt.String())
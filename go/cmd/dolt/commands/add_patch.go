@@ -0,0 +1,178 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const patchPromptHelp = `y - stage this row change
+n - do not stage this row change
+a - stage this row change and all remaining changes in the table
+d - do not stage this row change or any of the remaining changes in the table
+q - quit; do not stage this row change or any of the remaining ones
+? - print help`
+
+// patchStager interactively asks the user which row changes should be staged.
+type patchStager struct {
+	in       *bufio.Reader
+	out      io.Writer
+	stageAll bool
+	quit     bool
+}
+
+func newPatchStager(in io.Reader, out io.Writer) *patchStager {
+	return &patchStager{in: bufio.NewReader(in), out: out}
+}
+
+// stageTables asks which row changes of each of |tblNames| should be staged, a table at a time. Answering d moves on
+// to the next table, and answering q stops without asking about the remaining tables.
+func (ps *patchStager) stageTables(ctx context.Context, dEnv *env.DoltEnv, tblNames []string) error {
+	for _, tblName := range tblNames {
+		if ps.quit {
+			break
+		}
+
+		ps.stageAll = false
+		fmt.Fprintln(ps.out, color.CyanString("table %s", tblName))
+		_, err := actions.StageTableRows(ctx, dEnv, tblName, ps.stageFn)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// stageFn is an actions.RowStageFn which prompts the user for each row change
+func (ps *patchStager) stageFn(ctx context.Context, sch schema.Schema, oldRow, newRow row.Row) (bool, error) {
+	if ps.stageAll {
+		return true, nil
+	}
+
+	err := printRowChange(ps.out, sch, oldRow, newRow)
+
+	if err != nil {
+		return false, err
+	}
+
+	for {
+		fmt.Fprint(ps.out, color.BlueString("Stage this row change [y,n,a,d,q,?]? "))
+		line, err := ps.in.ReadString('\n')
+
+		if err == io.EOF && len(line) == 0 {
+			fmt.Fprintln(ps.out)
+			ps.quit = true
+			return false, actions.ErrStopStaging
+		} else if err != nil && err != io.EOF {
+			return false, err
+		}
+
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "y":
+			return true, nil
+		case "n":
+			return false, nil
+		case "a":
+			ps.stageAll = true
+			return true, nil
+		case "d":
+			return false, actions.ErrStopStaging
+		case "q":
+			ps.quit = true
+			return false, actions.ErrStopStaging
+		default:
+			fmt.Fprintln(ps.out, color.RedString(patchPromptHelp))
+		}
+	}
+}
+
+func printRowChange(wr io.Writer, sch schema.Schema, oldRow, newRow row.Row) error {
+	var oldStr, newStr string
+	var err error
+
+	if oldRow != nil {
+		oldStr, err = fmtRowForPatch(sch, oldRow)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if newRow != nil {
+		newStr, err = fmtRowForPatch(sch, newRow)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case oldRow == nil:
+		fmt.Fprintln(wr, color.GreenString("+ %s", newStr))
+	case newRow == nil:
+		fmt.Fprintln(wr, color.RedString("- %s", oldStr))
+	default:
+		fmt.Fprintln(wr, color.YellowString("< %s", oldStr))
+		fmt.Fprintln(wr, color.YellowString("> %s", newStr))
+	}
+
+	return nil
+}
+
+func fmtRowForPatch(sch schema.Schema, r row.Row) (string, error) {
+	var kvps []string
+	err := sch.GetAllCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+		val, ok := r.GetColVal(tag)
+
+		if !ok || types.IsNull(val) {
+			kvps = append(kvps, col.Name+": NULL")
+			return false, nil
+		}
+
+		str, err := col.TypeInfo.FormatValue(val)
+
+		if err != nil {
+			return true, err
+		}
+
+		if str == nil {
+			kvps = append(kvps, col.Name+": NULL")
+		} else {
+			kvps = append(kvps, col.Name+": "+*str)
+		}
+
+		return false, nil
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(kvps, ", "), nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const (
+	petIdTag   = 100
+	petNameTag = 101
+)
+
+var petsCols, _ = schema.NewColCollection(
+	schema.NewColumn("id", petIdTag, types.IntKind, true, schema.NotNullConstraint{}),
+	schema.NewColumn("name", petNameTag, types.StringKind, false),
+)
+var petsSch = schema.SchemaFromCols(petsCols)
+
+func petsRows(t *testing.T) []row.Row {
+	var rows []row.Row
+	for i, name := range []string{"rex", "tom", "polly"} {
+		r, err := row.New(types.Format_Default, petsSch, row.TaggedValues{
+			petIdTag:   types.Int(i),
+			petNameTag: types.String(name),
+		})
+		require.NoError(t, err)
+		rows = append(rows, r)
+	}
+
+	return rows
+}
+
+func TestPatchStagerStageTables(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]int
+	}{
+		{
+			name:     "stage everything",
+			input:    "a\na\n",
+			expected: map[string]int{"people": 3, "pets": 3},
+		},
+		{
+			name:     "d skips the rest of the table",
+			input:    "y\nd\ny\nn\nn\n",
+			expected: map[string]int{"people": 1, "pets": 1},
+		},
+		{
+			name:     "q stops staging",
+			input:    "y\nq\ny\nn\nn\n",
+			expected: map[string]int{"people": 1, "pets": -1},
+		},
+		{
+			name:     "end of input stops staging",
+			input:    "n\ny\n",
+			expected: map[string]int{"people": 1, "pets": -1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			dEnv := dtestutils.CreateTestEnv()
+			dtestutils.CreateTestTable(t, dEnv, "people", dtestutils.TypedSchema, dtestutils.TypedRows...)
+			dtestutils.CreateTestTable(t, dEnv, "pets", petsSch, petsRows(t)...)
+
+			out := &bytes.Buffer{}
+			ps := newPatchStager(strings.NewReader(test.input), out)
+			err := ps.stageTables(ctx, dEnv, []string{"people", "pets"})
+			require.NoError(t, err)
+
+			staged, err := dEnv.StagedRoot(ctx)
+			require.NoError(t, err)
+
+			for tblName, expected := range test.expected {
+				tbl, ok, err := staged.GetTable(ctx, tblName)
+				require.NoError(t, err)
+
+				if expected < 0 {
+					assert.False(t, ok, "%s shouldn't have been staged", tblName)
+					continue
+				}
+
+				require.True(t, ok, "%s should have been staged", tblName)
+				rows, err := tbl.GetRowData(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, uint64(expected), rows.Len(), tblName)
+			}
+		})
+	}
+}
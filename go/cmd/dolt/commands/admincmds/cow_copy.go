@@ -0,0 +1,118 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admincmds
+
+import (
+	"context"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+var cowCopyDocs = cli.CommandDocumentationContent{
+	ShortDesc: "Make a copy-on-write copy of a repository",
+	LongDesc: `Makes the directory {{.LessThan}}dst{{.GreaterThan}} a repository with the same branches, working set and docs as the repository in {{.LessThan}}src{{.GreaterThan}}, without copying its data. The table files in {{.EmphasisLeft}}.dolt/noms{{.EmphasisRight}} are hard linked into the copy, or copied when {{.LessThan}}dst{{.GreaterThan}} is on a different filesystem, and the rest of the {{.EmphasisLeft}}.dolt{{.EmphasisRight}} directory is copied. Each repository has its own manifest, so commits, branches and merges in either repository are never seen by the other.
+
+Dolt never writes over a table file, so the shared table files can't change under either repository, and removing table files from one repository never removes them from the other. Copies are cheap enough to make a throwaway repository for each test of a suite.
+
+{{.LessThan}}src{{.GreaterThan}} shouldn't be written to while it's copied.
+`,
+	Synopsis: []string{
+		"{{.LessThan}}src{{.GreaterThan}} {{.LessThan}}dst{{.GreaterThan}}",
+	},
+}
+
+type CowCopyCmd struct{}
+
+// Name is returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd CowCopyCmd) Name() string {
+	return "cow-copy"
+}
+
+// Description returns a description of the command
+func (cmd CowCopyCmd) Description() string {
+	return "Make a copy-on-write copy of a repository."
+}
+
+// RequiresRepo should return false if this interface is implemented, and the command does not have the requirement
+// that it be run from within a data repository directory
+func (cmd CowCopyCmd) RequiresRepo() bool {
+	return false
+}
+
+// EventType returns the type of the event to log
+func (cmd CowCopyCmd) EventType() eventsapi.ClientEventType {
+	return eventsapi.ClientEventType_TYPE_UNSPECIFIED
+}
+
+// CreateMarkdown creates a markdown file containing the helptext for the command at the given path
+func (cmd CowCopyCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) error {
+	ap := cmd.createArgParser()
+	return commands.CreateMarkdown(fs, path, cli.GetCommandDocumentation(commandStr, cowCopyDocs, ap))
+}
+
+func (cmd CowCopyCmd) createArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"src", "The directory of the repository to copy."})
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"dst", "The directory to make the copy in. It's created if it doesn't exist."})
+	return ap
+}
+
+// Exec executes the command
+func (cmd CowCopyCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cmd.createArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, cowCopyDocs, ap))
+	apr := cli.ParseArgs(ap, args, help)
+
+	if apr.NArg() != 2 {
+		usage()
+		return 1
+	}
+
+	return commands.HandleVErrAndExitCode(cowCopy(ctx, dEnv, apr.Arg(0), apr.Arg(1)), usage)
+}
+
+func cowCopy(ctx context.Context, dEnv *env.DoltEnv, src, dst string) errhand.VerboseError {
+	srcDir, err := dEnv.FS.Abs(src)
+
+	if err != nil {
+		return errhand.BuildDError("error: invalid path '%s'", src).AddCause(err).Build()
+	}
+
+	dstDir, err := dEnv.FS.Abs(dst)
+
+	if err != nil {
+		return errhand.BuildDError("error: invalid path '%s'", dst).AddCause(err).Build()
+	}
+
+	err = env.CopyOnWrite(ctx, srcDir, dstDir)
+
+	switch err {
+	case nil:
+		cli.Printf("Copied %s to %s\n", src, dst)
+		return nil
+	case env.ErrNoRepoToCopy:
+		return errhand.BuildDError("error: '%s' is not a dolt repository", src).Build()
+	case env.ErrPreexistingDoltDir:
+		return errhand.BuildDError("error: '%s' is already a dolt repository", dst).Build()
+	default:
+		return errhand.BuildDError("error: failed to copy '%s' to '%s'", src, dst).AddCause(err).Build()
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admincmds
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+	"github.com/liquidata-inc/dolt/go/store/util/cacheregistry"
+)
+
+const listFlag = "list"
+
+var flushCacheDocs = cli.CommandDocumentationContent{
+	ShortDesc: "Flush the repository's caches",
+	LongDesc: `Flushes the caches of the repository, or only the caches named {{.LessThan}}name{{.GreaterThan}}. With {{.EmphasisLeft}}--list{{.EmphasisRight}}, the caches are listed with the number of entries they hold and an estimate of their size, and nothing is flushed.
+
+Most caches only live in the memory of the process using them, so flushing them from the command line only affects the schemas Dolt persists in the repository between commands. A running {{.EmphasisLeft}}dolt sql-server{{.EmphasisRight}} flushes its own caches when a client runs {{.EmphasisLeft}}SELECT dolt_flush_cache(){{.EmphasisRight}}, or {{.EmphasisLeft}}SELECT dolt_flush_cache('{{.LessThan}}name{{.GreaterThan}}'){{.EmphasisRight}}, and reports them in its {{.EmphasisLeft}}dolt_caches{{.EmphasisRight}} metrics.
+`,
+	Synopsis: []string{
+		"[{{.LessThan}}name{{.GreaterThan}}]",
+		"--list",
+	},
+}
+
+type FlushCacheCmd struct{}
+
+// Name is returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd FlushCacheCmd) Name() string {
+	return "flush-cache"
+}
+
+// Description returns a description of the command
+func (cmd FlushCacheCmd) Description() string {
+	return "Flush the repository's caches."
+}
+
+// EventType returns the type of the event to log
+func (cmd FlushCacheCmd) EventType() eventsapi.ClientEventType {
+	return eventsapi.ClientEventType_TYPE_UNSPECIFIED
+}
+
+// CreateMarkdown creates a markdown file containing the helptext for the command at the given path
+func (cmd FlushCacheCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) error {
+	ap := cmd.createArgParser()
+	return commands.CreateMarkdown(fs, path, cli.GetCommandDocumentation(commandStr, flushCacheDocs, ap))
+}
+
+func (cmd FlushCacheCmd) createArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"name", "The name of the caches to flush. All of the caches are flushed if it isn't given."})
+	ap.SupportsFlag(listFlag, "", "List the caches instead of flushing them.")
+	return ap
+}
+
+// Exec executes the command
+func (cmd FlushCacheCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cmd.createArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, flushCacheDocs, ap))
+	apr := cli.ParseArgs(ap, args, help)
+
+	if apr.NArg() > 1 || (apr.Contains(listFlag) && apr.NArg() != 0) {
+		usage()
+		return 1
+	}
+
+	defer dEnv.DoltDB.RegisterCaches()()
+
+	if apr.Contains(listFlag) {
+		printCacheStats(cacheregistry.GetStats())
+		return 0
+	}
+
+	var name string
+	if apr.NArg() == 1 {
+		name = apr.Arg(0)
+	}
+
+	return commands.HandleVErrAndExitCode(flushCache(name), usage)
+}
+
+func flushCache(name string) errhand.VerboseError {
+	flushed, err := cacheregistry.Flush(name)
+
+	if errors.Is(err, cacheregistry.ErrUnknownCache) {
+		return errhand.BuildDError("error: unknown cache '%s'. The caches are: %s", name, strings.Join(cacheregistry.Names(), ", ")).Build()
+	} else if err != nil {
+		return errhand.BuildDError("error: failed to flush the caches").AddCause(err).Build()
+	}
+
+	for _, stats := range flushed {
+		cli.Printf("Flushed %d entries (%s) from %s\n", stats.Entries, humanize.Bytes(stats.Bytes), stats.Name)
+	}
+
+	return nil
+}
+
+func printCacheStats(stats []cacheregistry.Stats) {
+	cli.Printf("%-16s %10s %10s\n", "name", "entries", "size")
+	for _, s := range stats {
+		cli.Printf("%-16s %10d %10s\n", s.Name, s.Entries, humanize.Bytes(s.Bytes))
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admincmds
+
+import (
+	"context"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+	"github.com/liquidata-inc/dolt/go/store/nbs"
+)
+
+var recompressDocs = cli.CommandDocumentationContent{
+	ShortDesc: "Compress the repository's storage with a trained dictionary",
+	LongDesc: `Trains a zstd compression dictionary on a sample of the chunks in the repository's storage, then rewrites the table files, combining them as it goes, so that each chunk is compressed with the dictionary wherever that beats the default snappy compression. Repositories made up of many small, similar chunks, such as tables with many short rows, typically shrink considerably. Table files which wouldn't get any smaller are left as they are.
+
+The rewritten table files hold exactly the same data, and nothing about the repository's history changes. The dictionary is stored in each table file, and is kept when table files are later combined. Chunks are decompressed again before they are pushed or fetched, so remotes never need the dictionary, and table files copied whole to a clone bring their dictionary with them. Versions of Dolt which predate dictionaries can't read recompressed table files.
+
+The original table files aren't deleted, and remain in {{.EmphasisLeft}}.dolt/noms{{.EmphasisRight}} until they are garbage collected.
+`,
+	Synopsis: []string{""},
+}
+
+type RecompressCmd struct{}
+
+// Name is returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd RecompressCmd) Name() string {
+	return "recompress"
+}
+
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd RecompressCmd) LocksRepo() bool {
+	return true
+}
+
+// Description returns a description of the command
+func (cmd RecompressCmd) Description() string {
+	return "Compress the repository's storage with a trained dictionary."
+}
+
+// EventType returns the type of the event to log
+func (cmd RecompressCmd) EventType() eventsapi.ClientEventType {
+	return eventsapi.ClientEventType_TYPE_UNSPECIFIED
+}
+
+// CreateMarkdown creates a markdown file containing the helptext for the command at the given path
+func (cmd RecompressCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) error {
+	ap := argparser.NewArgParser()
+	return commands.CreateMarkdown(fs, path, cli.GetCommandDocumentation(commandStr, recompressDocs, ap))
+}
+
+// Exec executes the command
+func (cmd RecompressCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := argparser.NewArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, recompressDocs, ap))
+	apr := cli.ParseArgs(ap, args, help)
+
+	if apr.NArg() != 0 {
+		usage()
+		return 1
+	}
+
+	return commands.HandleVErrAndExitCode(recompress(ctx, dEnv), usage)
+}
+
+func recompress(ctx context.Context, dEnv *env.DoltEnv) errhand.VerboseError {
+	stats, err := dEnv.DoltDB.Recompress(ctx)
+
+	switch {
+	case err == nil:
+
+	case err == nbs.ErrRecompressUnsupported:
+		return errhand.BuildDError("error: only repositories stored on the local filesystem can be recompressed.").Build()
+
+	default:
+		return errhand.BuildDError("error: failed to recompress the repository").AddCause(err).Build()
+	}
+
+	if stats.Tables == 0 {
+		cli.Println("Recompressing wouldn't make the repository's storage any smaller, nothing was recompressed")
+		return nil
+	}
+
+	cli.Printf("Recompressed %d chunks from %d table files into %d with a %s dictionary\n", stats.Chunks, stats.Tables, stats.NewTables, humanize.Bytes(uint64(stats.DictionarySize)))
+	cli.Printf("%s -> %s (%.1f%% smaller)\n", humanize.Bytes(stats.SizeBefore), humanize.Bytes(stats.SizeAfter),
+		100*(1-float64(stats.SizeAfter)/float64(stats.SizeBefore)))
+
+	return nil
+}
@@ -0,0 +1,172 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admincmds
+
+import (
+	"context"
+
+	"github.com/fatih/color"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/rebase"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+const (
+	forceParam      = "force"
+	dropBackupParam = "drop-backup"
+)
+
+var rewriteHistoryDocs = cli.CommandDocumentationContent{
+	ShortDesc: "Remove a table or column from every commit",
+	LongDesc: `Rewrites the history of every branch so that the table given, or the column given of that table, is removed from every commit. The working and staged tables are changed in the same way. Use this to purge data which should never have been committed, such as credentials.
+
+Commit messages, authors, dates and the shape of the commit graph are kept, but every commit which contained the data, and every commit descended from one, is replaced by a commit with a new hash. The old and new hash of every replaced commit are written to {{.EmphasisLeft}}.dolt/rewritten_commits.csv{{.EmphasisRight}}. Primary key columns cannot be removed.
+
+Before a branch is moved to its rewritten history, the commit it pointed to is backed up in a ref under {{.EmphasisLeft}}refs/original/{{.EmphasisRight}}, e.g. {{.EmphasisLeft}}refs/original/heads/master{{.EmphasisRight}}, which can be passed to commands such as {{.EmphasisLeft}}dolt log{{.EmphasisRight}} and {{.EmphasisLeft}}dolt branch{{.EmphasisRight}} to inspect or restore the original history. The history can't be rewritten again while the backups exist unless {{.EmphasisLeft}}--force{{.EmphasisRight}} is given to replace them. The removed data remains in the repository's storage until the backups are deleted with {{.EmphasisLeft}}--drop-backup{{.EmphasisRight}} and the unreferenced chunks are garbage collected. Tables pinned with {{.EmphasisLeft}}dolt table pin{{.EmphasisRight}} aren't changed.
+
+Rewriting shared history is disruptive. Remote tracking branches aren't rewritten, as they record the state of the remote, so a rewritten branch can only be pushed with {{.EmphasisLeft}}dolt push --force{{.EmphasisRight}}, which replaces the branch's history on the remote. Everyone else who has cloned the remote must then clone it again, or reset their branches to the rewritten ones, before doing any more work. Pulling or pushing from a clone which still has the original history merges that history back in, bringing back the data which was removed, and other clones of the remote keep the data regardless.
+`,
+	Synopsis: []string{
+		"[--force] {{.LessThan}}table{{.GreaterThan}} [{{.LessThan}}column{{.GreaterThan}}]",
+		"--drop-backup",
+	},
+}
+
+type RewriteHistoryCmd struct{}
+
+// Name is returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd RewriteHistoryCmd) Name() string {
+	return "rewrite-history"
+}
+
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd RewriteHistoryCmd) LocksRepo() bool {
+	return true
+}
+
+// Description returns a description of the command
+func (cmd RewriteHistoryCmd) Description() string {
+	return "Remove a table or column from every commit."
+}
+
+// EventType returns the type of the event to log
+func (cmd RewriteHistoryCmd) EventType() eventsapi.ClientEventType {
+	return eventsapi.ClientEventType_TYPE_UNSPECIFIED
+}
+
+// CreateMarkdown creates a markdown file containing the helptext for the command at the given path
+func (cmd RewriteHistoryCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) error {
+	ap := cmd.createArgParser()
+	return commands.CreateMarkdown(fs, path, cli.GetCommandDocumentation(commandStr, rewriteHistoryDocs, ap))
+}
+
+func (cmd RewriteHistoryCmd) createArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"table", "The table to remove from history, or the table to remove the column from."})
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"column", "The column to remove from history. The whole table is removed if no column is given."})
+	ap.SupportsFlag(forceParam, "f", "Replace the backups of history rewritten previously.")
+	ap.SupportsFlag(dropBackupParam, "", "Delete the backups of rewritten history in refs/original/, instead of rewriting history.")
+	return ap
+}
+
+// Exec executes the command
+func (cmd RewriteHistoryCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cmd.createArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, rewriteHistoryDocs, ap))
+	apr := cli.ParseArgs(ap, args, help)
+
+	if apr.Contains(dropBackupParam) {
+		if apr.NArg() != 0 || apr.Contains(forceParam) {
+			usage()
+			return 1
+		}
+
+		return commands.HandleVErrAndExitCode(dropBackup(ctx, dEnv), usage)
+	}
+
+	if apr.NArg() < 1 || apr.NArg() > 2 {
+		usage()
+		return 1
+	}
+
+	filter := rebase.HistoryFilter{Table: apr.Arg(0)}
+	if apr.NArg() == 2 {
+		filter.Column = apr.Arg(1)
+	}
+
+	verr := rewriteHistory(ctx, dEnv, filter, apr.Contains(forceParam))
+	return commands.HandleVErrAndExitCode(verr, usage)
+}
+
+func rewriteHistory(ctx context.Context, dEnv *env.DoltEnv, filter rebase.HistoryFilter, force bool) errhand.VerboseError {
+	removed := "table " + filter.Table
+	if filter.Column != "" {
+		removed = "column " + filter.Column + " of table " + filter.Table
+	}
+
+	res, err := rebase.RewriteHistory(ctx, dEnv, filter, force)
+
+	switch {
+	case err == nil:
+
+	case err == rebase.ErrRewriteMergeActive:
+		return errhand.BuildDError("error: history can't be rewritten while merging.").
+			AddDetails(`Use "dolt commit" to conclude the merge or "dolt merge --abort" to abort it.`).Build()
+
+	case err == rebase.ErrHistoryBackupExists:
+		return errhand.BuildDError("error: a backup of previously rewritten history exists in refs/original/").
+			AddDetails("Use --%s to replace it, or --%s to delete it.", forceParam, dropBackupParam).Build()
+
+	case err == rebase.ErrNothingToRewrite:
+		return errhand.BuildDError("error: %s was not found in the history of any branch.", removed).Build()
+
+	case err == rebase.ErrRewritePKColumn:
+		return errhand.BuildDError("error: %s is part of the primary key and can't be removed.", filter.Column).
+			AddDetails("Remove the table %s from history instead.", filter.Table).Build()
+
+	default:
+		return errhand.BuildDError("error: failed to rewrite history").AddCause(err).Build()
+	}
+
+	cli.Printf("Rewrote %d commits to remove %s\n", len(res.Commits), removed)
+	for _, dRef := range res.Refs {
+		cli.Println("\t" + dRef.String())
+	}
+
+	if len(res.Commits) > 0 {
+		cli.Printf("The new hash of each rewritten commit is recorded in %s\n", rebase.RewriteMappingFile)
+		cli.Printf("The original refs are backed up in refs/original/, run 'dolt admin rewrite-history --%s' to delete them\n", dropBackupParam)
+		cli.Println(color.YellowString("warning: rewritten branches can only be pushed with 'dolt push --force'. Everyone else using the remote must then clone it again, as pulling or pushing from a clone with the original history brings the removed data back."))
+	}
+
+	return nil
+}
+
+func dropBackup(ctx context.Context, dEnv *env.DoltEnv) errhand.VerboseError {
+	n, err := rebase.DeleteHistoryBackup(ctx, dEnv.DoltDB)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to delete the backups of rewritten history").AddCause(err).Build()
+	}
+
+	cli.Printf("Deleted %d backup refs\n", n)
+	return nil
+}
@@ -0,0 +1,177 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admincmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/migrate"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+var rewriteTableDocs = cli.CommandDocumentationContent{
+	ShortDesc: "Rewrite the rows of a table into the current row layout",
+	LongDesc: `Rewrites the rows of {{.LessThan}}table{{.GreaterThan}} in the working set so that they are laid out the way rows are written for the table's current schema. Changing the schema of a table doesn't rewrite its rows, so rows may still hold the values of dropped columns, or values written by older versions of Dolt in an older layout, all of which every read of the rows has to skip. Only rows whose layout changes are written.
+
+The rows are rewritten in a single pass, which reports its progress as it goes. Once every row is rewritten, the number of rows is checked and a sample of the rewritten rows is compared with the original rows, and only then are the rewritten rows swapped into the working set. The table records that its rows are in the current layout until they or the schema next change, and rewriting a table again before then does nothing.
+
+The rewrite checkpoints its progress in {{.EmphasisLeft}}.dolt/rewrite_table{{.EmphasisRight}} as it goes. If it's interrupted, running the command again resumes it from the last checkpoint, unless the table was changed in the meantime, in which case it starts over. The table is unchanged until the rewrite completes.
+
+The rewrite changes the working set like any other change of the table's rows, and is committed like one.
+`,
+	Synopsis: []string{
+		"{{.LessThan}}table{{.GreaterThan}}",
+	},
+}
+
+type RewriteTableCmd struct{}
+
+// Name is returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd RewriteTableCmd) Name() string {
+	return "rewrite-table"
+}
+
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd RewriteTableCmd) LocksRepo() bool {
+	return true
+}
+
+// Description returns a description of the command
+func (cmd RewriteTableCmd) Description() string {
+	return "Rewrite the rows of a table into the current row layout."
+}
+
+// EventType returns the type of the event to log
+func (cmd RewriteTableCmd) EventType() eventsapi.ClientEventType {
+	return eventsapi.ClientEventType_TYPE_UNSPECIFIED
+}
+
+// CreateMarkdown creates a markdown file containing the helptext for the command at the given path
+func (cmd RewriteTableCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) error {
+	ap := cmd.createArgParser()
+	return commands.CreateMarkdown(fs, path, cli.GetCommandDocumentation(commandStr, rewriteTableDocs, ap))
+}
+
+func (cmd RewriteTableCmd) createArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"table", "The table whose rows are rewritten."})
+	return ap
+}
+
+// Exec executes the command
+func (cmd RewriteTableCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cmd.createArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, rewriteTableDocs, ap))
+	apr := cli.ParseArgs(ap, args, help)
+
+	if apr.NArg() != 1 {
+		usage()
+		return 1
+	}
+
+	return commands.HandleVErrAndExitCode(rewriteTable(ctx, dEnv, apr.Arg(0)), usage)
+}
+
+func rewriteTable(ctx context.Context, dEnv *env.DoltEnv, tblName string) errhand.VerboseError {
+	// an interrupt stops the rewrite at its next checkpoint rather than killing the process
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	defer signal.Stop(sigCh)
+
+	go func() {
+		select {
+		case <-sigCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	wg := &sync.WaitGroup{}
+	progChan := make(chan migrate.RewriteProgress, 128)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		rewriteProgFunc(progChan)
+	}()
+
+	res, err := migrate.RewriteTable(ctx, dEnv, tblName, progChan)
+	close(progChan)
+	wg.Wait()
+
+	switch {
+	case err == nil:
+
+	case err == doltdb.ErrTableNotFound:
+		return errhand.BuildDError("error: table '%s' doesn't exist in the working set", tblName).Build()
+
+	case err == context.Canceled:
+		cli.Println()
+		return errhand.BuildDError("the rewrite of '%s' was interrupted, run the command again to resume it", tblName).Build()
+
+	default:
+		return errhand.BuildDError("error: failed to rewrite '%s', the table has not been changed", tblName).AddCause(err).Build()
+	}
+
+	if res.AlreadyCanonical {
+		cli.Printf("The rows of '%s' are already in the current row layout, nothing was rewritten\n", tblName)
+		return nil
+	}
+
+	cli.Println()
+	if res.Resumed {
+		cli.Println("Resumed the interrupted rewrite")
+	}
+	cli.Printf("Rewrote %s of %s rows of '%s'\n", humanize.Comma(int64(res.RowsRewritten)), humanize.Comma(int64(res.RowsRead)), tblName)
+
+	return nil
+}
+
+func rewriteProgFunc(progChan chan migrate.RewriteProgress) {
+	var latest migrate.RewriteProgress
+	last := time.Now()
+	lenPrinted := 0
+	for progress := range progChan {
+		latest = progress
+		if time.Since(last) > 500*time.Millisecond {
+			last = time.Now()
+			lenPrinted = cli.DeleteAndPrint(lenPrinted, rewriteProgressString(latest))
+		}
+	}
+
+	if latest.RowsTotal > 0 {
+		cli.DeleteAndPrint(lenPrinted, rewriteProgressString(latest))
+	}
+}
+
+func rewriteProgressString(p migrate.RewriteProgress) string {
+	return fmt.Sprintf("Rows read: %s/%s, rewritten: %s", humanize.Comma(int64(p.RowsRead)), humanize.Comma(int64(p.RowsTotal)), humanize.Comma(int64(p.RowsRewritten)))
+}
@@ -0,0 +1,223 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admincmds
+
+import (
+	"context"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+var verifyDiffDocs = cli.CommandDocumentationContent{
+	ShortDesc: "Verify the diff of a table between two commits",
+	LongDesc: `Diffs the rows of {{.LessThan}}table{{.GreaterThan}} from commit {{.LessThan}}from{{.GreaterThan}} to commit {{.LessThan}}to{{.GreaterThan}} the way {{.EmphasisLeft}}dolt diff{{.EmphasisRight}} does, skipping the chunks of rows the two versions of the table share, and again by comparing every row of both versions. If the diffs differ, the ranges of keys they disagree about are printed, and the command exits with a non-zero status. The number of chunks each diff read from the repository's storage is printed as well.
+
+Comparing every row reads all of the rows of both versions of the table, so this can be slow for large tables. It's meant for checking the correctness of a repository's storage, such as after repairing it by hand.
+`,
+	Synopsis: []string{
+		"{{.LessThan}}from{{.GreaterThan}} {{.LessThan}}to{{.GreaterThan}} {{.LessThan}}table{{.GreaterThan}}",
+	},
+}
+
+type VerifyDiffCmd struct{}
+
+// Name is returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd VerifyDiffCmd) Name() string {
+	return "verify-diff"
+}
+
+// Description returns a description of the command
+func (cmd VerifyDiffCmd) Description() string {
+	return "Verify the diff of a table between two commits."
+}
+
+// EventType returns the type of the event to log
+func (cmd VerifyDiffCmd) EventType() eventsapi.ClientEventType {
+	return eventsapi.ClientEventType_TYPE_UNSPECIFIED
+}
+
+// CreateMarkdown creates a markdown file containing the helptext for the command at the given path
+func (cmd VerifyDiffCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) error {
+	ap := cmd.createArgParser()
+	return commands.CreateMarkdown(fs, path, cli.GetCommandDocumentation(commandStr, verifyDiffDocs, ap))
+}
+
+func (cmd VerifyDiffCmd) createArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"from", "The commit the table is diffed from."})
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"to", "The commit the table is diffed to."})
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"table", "The table whose rows are diffed."})
+	return ap
+}
+
+// Exec executes the command
+func (cmd VerifyDiffCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cmd.createArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, verifyDiffDocs, ap))
+	apr := cli.ParseArgs(ap, args, help)
+
+	if apr.NArg() != 3 {
+		usage()
+		return 1
+	}
+
+	return commands.HandleVErrAndExitCode(verifyDiff(ctx, dEnv, apr.Arg(0), apr.Arg(1), apr.Arg(2)), usage)
+}
+
+func verifyDiff(ctx context.Context, dEnv *env.DoltEnv, fromSpec, toSpec, tblName string) errhand.VerboseError {
+	fromTbl, verr := getTableAtCommit(ctx, dEnv, fromSpec, tblName)
+
+	if verr != nil {
+		return verr
+	}
+
+	toTbl, verr := getTableAtCommit(ctx, dEnv, toSpec, tblName)
+
+	if verr != nil {
+		return verr
+	}
+
+	fromRows, err := fromTbl.GetHotRowData(ctx)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to get the rows of '%s' at %s", tblName, fromSpec).AddCause(err).Build()
+	}
+
+	toRows, err := toTbl.GetHotRowData(ctx)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to get the rows of '%s' at %s", tblName, toSpec).AddCause(err).Build()
+	}
+
+	diverged, verr := verifyRowDataDiff(ctx, dEnv, "rows", fromRows, toRows)
+
+	if verr != nil {
+		return verr
+	}
+
+	fromCold, hasFromCold, err := fromTbl.GetColdRowData(ctx)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to get the cold rows of '%s' at %s", tblName, fromSpec).AddCause(err).Build()
+	}
+
+	toCold, hasToCold, err := toTbl.GetColdRowData(ctx)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to get the cold rows of '%s' at %s", tblName, toSpec).AddCause(err).Build()
+	}
+
+	if hasFromCold || hasToCold {
+		empty, err := types.NewMap(ctx, dEnv.DoltDB.ValueReadWriter())
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to create an empty map").AddCause(err).Build()
+		}
+
+		if !hasFromCold {
+			fromCold = empty
+		} else if !hasToCold {
+			toCold = empty
+		}
+
+		coldDiverged, verr := verifyRowDataDiff(ctx, dEnv, "cold rows", fromCold, toCold)
+
+		if verr != nil {
+			return verr
+		}
+
+		diverged = diverged || coldDiverged
+	}
+
+	if diverged {
+		return errhand.BuildDError("error: the diff of '%s' from %s to %s is wrong", tblName, fromSpec, toSpec).Build()
+	}
+
+	return nil
+}
+
+func getTableAtCommit(ctx context.Context, dEnv *env.DoltEnv, cSpecStr, tblName string) (*doltdb.Table, errhand.VerboseError) {
+	cm, verr := commands.ResolveCommitWithVErr(dEnv, cSpecStr, dEnv.RepoState.CWBHeadRef().String())
+
+	if verr != nil {
+		return nil, verr
+	}
+
+	root, err := cm.GetRootValue()
+
+	if err != nil {
+		return nil, errhand.BuildDError("error: failed to get the root of %s", cSpecStr).AddCause(err).Build()
+	}
+
+	tbl, ok, err := root.GetTable(ctx, tblName)
+
+	if err != nil {
+		return nil, errhand.BuildDError("error: failed to read '%s' at %s", tblName, cSpecStr).AddCause(err).Build()
+	} else if !ok {
+		return nil, errhand.BuildDError("error: table '%s' doesn't exist at %s", tblName, cSpecStr).Build()
+	}
+
+	return tbl, nil
+}
+
+// verifyRowDataDiff verifies the diff of the maps |from| and |to| of the rows of a table, printing the result. It
+// returns whether the diffs diverged.
+func verifyRowDataDiff(ctx context.Context, dEnv *env.DoltEnv, name string, from, to types.Map) (bool, errhand.VerboseError) {
+	verification, err := diff.VerifyMapDiff(ctx, dEnv.DoltDB.ChunkStore(), from, to)
+
+	if err != nil {
+		return false, errhand.BuildDError("error: failed to diff the %s", name).AddCause(err).Build()
+	}
+
+	cli.Printf("%s: %d changes, %d chunks read skipping the chunks shared by both versions, %d chunks read comparing every row\n",
+		name, verification.Changes, verification.SkippingGets, verification.FullGets)
+
+	if len(verification.Divergences) == 0 {
+		return false, nil
+	}
+
+	cli.PrintErrf("The diffs of the %s differ for the keys:\n", name)
+	for _, r := range verification.Divergences {
+		start, err := types.EncodedValue(ctx, r.Start)
+
+		if err != nil {
+			return false, errhand.BuildDError("error: failed to print a key").AddCause(err).Build()
+		}
+
+		if r.Keys == 1 {
+			cli.PrintErrf("\t%s\n", start)
+			continue
+		}
+
+		end, err := types.EncodedValue(ctx, r.End)
+
+		if err != nil {
+			return false, errhand.BuildDError("error: failed to print a key").AddCause(err).Build()
+		}
+
+		cli.PrintErrf("\t%s through %s (%d keys)\n", start, end, r.Keys)
+	}
+
+	return true, nil
+}
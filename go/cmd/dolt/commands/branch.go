@@ -76,6 +76,11 @@ func (cmd BranchCmd) Name() string {
 	return "branch"
 }
 
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd BranchCmd) LocksRepo() bool {
+	return true
+}
+
 // Description returns a description of the command
 func (cmd BranchCmd) Description() string {
 	return "Create, list, edit, delete branches."
@@ -154,7 +159,7 @@ func printBranches(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgPar
 
 		cs, _ := doltdb.NewCommitSpec("HEAD", branch.String())
 
-		if branch.GetType() != ref.BranchRefType && !printAll {
+		if branch.GetType() == ref.TagRefType || (branch.GetType() != ref.BranchRefType && !printAll) {
 			continue
 		}
 
@@ -322,17 +327,19 @@ func createBranchWithStartPt(ctx context.Context, dEnv *env.DoltEnv, newBranch,
 	return nil
 }
 
+// HandleVErrAndExitCode prints |verr|, and the usage if it asks for it, and returns the exit code of its category. The
+// error is printed as a JSON object, without the usage, when dolt is run with --json-errors.
 func HandleVErrAndExitCode(verr errhand.VerboseError, usage cli.UsagePrinter) int {
 	if verr != nil {
-		if msg := verr.Verbose(); strings.TrimSpace(msg) != "" {
+		if msg := errhand.Format(verr); strings.TrimSpace(msg) != "" {
 			cli.PrintErrln(msg)
 		}
 
-		if verr.ShouldPrintUsage() {
+		if verr.ShouldPrintUsage() && !errhand.JSONErrors() {
 			usage()
 		}
 
-		return 1
+		return errhand.ExitCode(verr)
 	}
 
 	return 0
@@ -16,6 +16,9 @@ package commands
 
 import (
 	"context"
+	"strings"
+
+	"github.com/fatih/color"
 
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
@@ -58,6 +61,11 @@ func (cmd CheckoutCmd) Name() string {
 	return "checkout"
 }
 
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd CheckoutCmd) LocksRepo() bool {
+	return true
+}
+
 // Description returns a description of the command
 func (cmd CheckoutCmd) Description() string {
 	return "Checkout a branch or overwrite a table from HEAD."
@@ -179,6 +187,12 @@ func checkoutNewBranch(ctx context.Context, dEnv *env.DoltEnv, newBranch string,
 }
 
 func checkoutTablesAndDocs(ctx context.Context, dEnv *env.DoltEnv, tables []string, docs []doltdb.DocDetails) errhand.VerboseError {
+	verr := backupWorkingSet(ctx, dEnv, "checkout")
+
+	if verr != nil {
+		return verr
+	}
+
 	err := actions.CheckoutTablesAndDocs(ctx, dEnv, tables, docs)
 
 	if err != nil {
@@ -229,10 +243,40 @@ func checkoutBranch(ctx context.Context, dEnv *env.DoltEnv, name string) errhand
 	}
 
 	cli.Printf("Switched to branch '%s'\n", name)
+	warnUnmatchedSparsePatterns(ctx, dEnv)
 
 	return nil
 }
 
+// warnUnmatchedSparsePatterns warns when the patterns of the sparse working set don't match any of the tables of the branch
+// which was checked out. The patterns are kept, as the tables may be created later.
+func warnUnmatchedSparsePatterns(ctx context.Context, dEnv *env.DoltEnv) {
+	if !dEnv.RepoState.IsSparse() {
+		return
+	}
+
+	// Printing here is best effort.  Fail silently
+	working, err := dEnv.WorkingRoot(ctx)
+
+	if err != nil {
+		return
+	}
+
+	tblNames, err := working.GetTableNames(ctx)
+
+	if err != nil {
+		return
+	}
+
+	for _, tblName := range tblNames {
+		if !doltdb.HasDoltPrefix(tblName) && dEnv.RepoState.IsActiveTable(tblName) {
+			return
+		}
+	}
+
+	cli.PrintErrln(color.YellowString("warning: the sparse working set %s doesn't match any tables on this branch", strings.Join(dEnv.RepoState.Sparse, " ")))
+}
+
 func unreadableRootToVErr(err error) errhand.VerboseError {
 	rt := actions.GetUnreachableRootType(err)
 	bdr := errhand.BuildDError("error: unable to read the %s", rt.String())
@@ -52,9 +52,11 @@ var cloneDocs = cli.CommandDocumentationContent{
 After the clone, a plain {{.EmphasisLeft}}dolt fetch{{.EmphasisRight}} without arguments will update all the remote-tracking branches, and a {{.EmphasisLeft}}dolt pull{{.EmphasisRight}} without arguments will in addition merge the remote branch into the current branch.
 
 This default configuration is achieved by creating references to the remote branch heads under {{.LessThan}}refs/remotes/origin{{.GreaterThan}}  and by creating a remote named 'origin'.
+
+A repository on the local filesystem can be cloned by giving its path, or a {{.EmphasisLeft}}file://{{.EmphasisRight}} url of its path, as the remote url.  The table files of a local repository are hard linked into the new repository rather than being copied chunk by chunk, or copied whole when the repositories are on different devices.  Table files are never modified once they are written, so the new repository doesn't share any mutable state with the one it was cloned from.  When the table files can't be linked the clone falls back on copying their chunks.
 `,
 	Synopsis: []string{
-		"[-remote {{.LessThan}}remote{{.GreaterThan}}] [-branch {{.LessThan}}branch{{.GreaterThan}}]  [--aws-region {{.LessThan}}region{{.GreaterThan}}] [--aws-creds-type {{.LessThan}}creds-type{{.GreaterThan}}] [--aws-creds-file {{.LessThan}}file{{.GreaterThan}}] [--aws-creds-profile {{.LessThan}}profile{{.GreaterThan}}] {{.LessThan}}remote-url{{.GreaterThan}} {{.LessThan}}new-dir{{.GreaterThan}}",
+		"[-remote {{.LessThan}}remote{{.GreaterThan}}] [-branch {{.LessThan}}branch{{.GreaterThan}}]  [--aws-region {{.LessThan}}region{{.GreaterThan}}] [--aws-creds-type {{.LessThan}}creds-type{{.GreaterThan}}] [--aws-creds-file {{.LessThan}}file{{.GreaterThan}}] [--aws-creds-profile {{.LessThan}}profile{{.GreaterThan}}] [--ssh-identity {{.LessThan}}file{{.GreaterThan}}] [--ssh-known-hosts {{.LessThan}}file{{.GreaterThan}}] {{.LessThan}}remote-url{{.GreaterThan}} {{.LessThan}}new-dir{{.GreaterThan}}",
 	},
 }
 
@@ -90,6 +92,7 @@ func (cmd CloneCmd) createArgParser() *argparser.ArgParser {
 	ap.SupportsValidatedString(dbfactory.AWSCredsTypeParam, "", "creds-type", "", argparser.ValidatorFromStrList(dbfactory.AWSCredsTypeParam, credTypes))
 	ap.SupportsString(dbfactory.AWSCredsFileParam, "", "file", "AWS credentials file.")
 	ap.SupportsString(dbfactory.AWSCredsProfile, "", "profile", "AWS profile to use.")
+	addSSHArgs(ap)
 	return ap
 }
 
@@ -108,6 +111,7 @@ func (cmd CloneCmd) Exec(ctx context.Context, commandStr string, args []string,
 	branch := apr.GetValueOrDefault(branchParam, "")
 	dir, urlStr, verr := parseArgs(apr)
 
+	urlStr = localRepoUrl(dEnv.FS, urlStr)
 	scheme, remoteUrl, err := getAbsRemoteUrl(dEnv.FS, dEnv.Config, urlStr)
 
 	if err != nil {
@@ -121,7 +125,7 @@ func (cmd CloneCmd) Exec(ctx context.Context, commandStr string, args []string,
 		if verr == nil {
 			var r env.Remote
 			var srcDB *doltdb.DoltDB
-			r, srcDB, verr = createRemote(ctx, remoteName, remoteUrl, params)
+			r, srcDB, verr = createRemote(ctx, dEnv, remoteName, remoteUrl, params)
 
 			if verr == nil {
 				dEnv, verr = envForClone(ctx, srcDB.ValueReadWriter().Format(), r, dir, dEnv.FS, dEnv.Version)
@@ -165,19 +169,45 @@ func parseArgs(apr *argparser.ArgParseResults) (string, string, errhand.VerboseE
 		return "", "", errhand.BuildDError("error: invalid remote url: " + urlStr).Build()
 	}
 
-	var dir string
 	if apr.NArg() == 2 {
-		dir = apr.Arg(1)
-	} else {
-		dir = path.Base(urlStr)
-		if dir == "." {
-			dir = path.Dir(urlStr)
-		} else if dir == "/" {
-			return "", "", errhand.BuildDError("Could not infer repo name.  Please explicitily define a directory for this url").Build()
-		}
+		return apr.Arg(1), urlStr, nil
+	}
+
+	dir, verr := CloneDirForUrl(urlStr)
+	return dir, urlStr, verr
+}
+
+// CloneDirForUrl returns the directory a repository is cloned into from the remote url |urlStr| when no directory is
+// given.
+func CloneDirForUrl(urlStr string) (string, errhand.VerboseError) {
+	dir := path.Base(urlStr)
+	if dir == "." {
+		dir = path.Dir(urlStr)
+	} else if dir == "/" {
+		return "", errhand.BuildDError("Could not infer repo name.  Please explicitily define a directory for this url").Build()
+	}
+
+	return dir, nil
+}
+
+// localRepoUrl returns a file url for the data directory of the dolt repository at |urlStr| when it is the path, or the
+// file url of the path, of a repository on the local filesystem. Any other url is returned unchanged.
+func localRepoUrl(fs filesys.Filesys, urlStr string) string {
+	repoPath := urlStr
+	if u, err := earl.Parse(urlStr); err != nil {
+		return urlStr
+	} else if u.Scheme == dbfactory.FileScheme {
+		repoPath = u.Host + u.Path
+	} else if u.Scheme != "" {
+		return urlStr
+	}
+
+	dataDir := filepath.Join(filepath.FromSlash(repoPath), dbfactory.DoltDataDir)
+	if exists, isDir := fs.Exists(dataDir); !exists || !isDir {
+		return urlStr
 	}
 
-	return dir, urlStr, nil
+	return dbfactory.FileScheme + "://" + filepath.ToSlash(dataDir)
 }
 
 func envForClone(ctx context.Context, nbf *types.NomsBinFormat, r env.Remote, dir string, fs filesys.Filesys, version string) (*env.DoltEnv, errhand.VerboseError) {
@@ -216,7 +246,7 @@ func envForClone(ctx context.Context, nbf *types.NomsBinFormat, r env.Remote, di
 	return dEnv, nil
 }
 
-func createRemote(ctx context.Context, remoteName, remoteUrl string, params map[string]string) (env.Remote, *doltdb.DoltDB, errhand.VerboseError) {
+func createRemote(ctx context.Context, dEnv *env.DoltEnv, remoteName, remoteUrl string, params map[string]string) (env.Remote, *doltdb.DoltDB, errhand.VerboseError) {
 	cli.Printf("cloning %s\n", remoteUrl)
 
 	r := env.NewRemote(remoteName, remoteUrl, params)
@@ -224,7 +254,7 @@ func createRemote(ctx context.Context, remoteName, remoteUrl string, params map[
 	ddb, err := r.GetRemoteDB(ctx, types.Format_Default)
 
 	if err != nil {
-		bdr := errhand.BuildDError("error: failed to get remote db").AddCause(err)
+		bdr := AddIncompatibleFormatDetails(errhand.BuildDError("error: failed to get remote db").AddCause(err), err, dEnv)
 
 		if err == remotestorage.ErrInvalidDoltSpecPath {
 			urlObj, _ := earl.Parse(remoteUrl)
@@ -288,7 +318,7 @@ func cloneRemote(ctx context.Context, srcDB *doltdb.DoltDB, remoteName, branch s
 	wg.Wait()
 
 	if err != nil {
-		return errhand.BuildDError("error: clone failed").AddCause(err).Build()
+		return AddIncompatibleFormatDetails(errhand.BuildDError("error: clone failed").AddCause(err), err, dEnv).Build()
 	}
 
 	if branch == "" {
@@ -346,6 +376,11 @@ func cloneRemote(ctx context.Context, srcDB *doltdb.DoltDB, remoteName, branch s
 		}
 	}
 
+	return checkoutClonedBranch(ctx, dEnv, branch, rootVal)
+}
+
+// checkoutClonedBranch checks out |branch|, whose root value is |rootVal|, replacing the working and staged roots.
+func checkoutClonedBranch(ctx context.Context, dEnv *env.DoltEnv, branch string, rootVal *doltdb.RootValue) errhand.VerboseError {
 	h, err := rootVal.HashOf()
 	if err != nil {
 		return errhand.BuildDError("error: could not get the root value of " + branch).AddCause(err).Build()
@@ -368,6 +403,173 @@ func cloneRemote(ctx context.Context, srcDB *doltdb.DoltDB, remoteName, branch s
 	return nil
 }
 
+// CloneOrFetch clones |branch| of the repository at |urlStr| into the directory |dir|, with the remote named
+// |remoteName|. When |dir| already holds a repository cloned from |urlStr|, the branch is fetched from it instead, and
+// the local branch and the working set are fast-forwarded to it. An empty |branch| clones the branch dolt clone would
+// check out, or fetches the checked out branch. Unlike dolt clone, the working directory of the process isn't changed.
+// It returns the environment of the repository in |dir|.
+func CloneOrFetch(ctx context.Context, dEnv *env.DoltEnv, remoteName, urlStr, branch, dir string) (*env.DoltEnv, errhand.VerboseError) {
+	absDir, err := dEnv.FS.Abs(dir)
+	if err != nil {
+		return nil, errhand.BuildDError("error: invalid directory '%s'", dir).AddCause(err).Build()
+	}
+
+	urlStr = localRepoUrl(dEnv.FS, urlStr)
+	_, remoteUrl, err := getAbsRemoteUrl(dEnv.FS, dEnv.Config, urlStr)
+	if err != nil {
+		return nil, errhand.BuildDError("error: '%s' is not valid.", urlStr).Build()
+	}
+
+	if exists, _ := dEnv.FS.Exists(filepath.Join(absDir, dbfactory.DoltDir)); exists {
+		dirEnv, verr := envForDir(ctx, absDir, dEnv.Version)
+		if verr != nil {
+			return nil, verr
+		}
+
+		return dirEnv, fetchIntoClone(ctx, dirEnv, remoteUrl, branch)
+	}
+
+	r, srcDB, verr := createRemote(ctx, dEnv, remoteName, remoteUrl, map[string]string{})
+	if verr != nil {
+		return nil, verr
+	}
+
+	if err := dEnv.FS.MkDirs(absDir); err != nil {
+		return nil, errhand.BuildDError("error: unable to create directories: " + absDir).AddCause(err).Build()
+	}
+
+	dirEnv, verr := envForDir(ctx, absDir, dEnv.Version)
+	if verr == nil {
+		verr = initClone(ctx, dirEnv, srcDB.ValueReadWriter().Format(), r)
+	}
+	if verr == nil {
+		verr = cloneRemote(ctx, srcDB, remoteName, branch, dirEnv)
+	}
+
+	if verr != nil {
+		// the directory may be a mounted volume, so only the repository is deleted
+		_ = dEnv.FS.Delete(filepath.Join(absDir, dbfactory.DoltDir), true)
+		return nil, verr
+	}
+
+	return dirEnv, nil
+}
+
+// envForDir loads the environment of the directory at the absolute path |absDir|.
+func envForDir(ctx context.Context, absDir, version string) (*env.DoltEnv, errhand.VerboseError) {
+	fs, err := filesys.LocalFilesysWithWorkingDir(absDir)
+	if err != nil {
+		return nil, errhand.BuildDError("error: unable to access directory " + absDir).AddCause(err).Build()
+	}
+
+	urlStr := earl.FileUrlFromPath(filepath.Join(absDir, dbfactory.DoltDataDir), os.PathSeparator)
+	return env.Load(ctx, env.GetCurrentUserHomeDir, fs, urlStr, version), nil
+}
+
+// initClone initializes the repository of |dEnv| for a clone from the remote |r|, as envForClone does.
+func initClone(ctx context.Context, dEnv *env.DoltEnv, nbf *types.NomsBinFormat, r env.Remote) errhand.VerboseError {
+	err := dEnv.InitRepoWithNoData(ctx, nbf)
+	if err != nil {
+		return errhand.BuildDError("error: unable to initialize repo without data").AddCause(err).Build()
+	}
+
+	dEnv.RSLoadErr = nil
+	dEnv.RepoState, err = env.CloneRepoState(dEnv.FS, r)
+	if err != nil {
+		return errhand.BuildDError("error: unable to create repo state with remote " + r.Name).AddCause(err).Build()
+	}
+
+	return nil
+}
+
+// fetchIntoClone fetches |branch| from the remote of |dEnv| whose url is |remoteUrl|, and fast-forwards the local
+// branch and the working set to it. The working set must not have changes, and the local branch must not have commits
+// which the remote branch doesn't.
+func fetchIntoClone(ctx context.Context, dEnv *env.DoltEnv, remoteUrl, branch string) errhand.VerboseError {
+	if dEnv.RSLoadErr != nil {
+		return errhand.BuildDError("error: failed to read the repo state").AddCause(dEnv.RSLoadErr).Build()
+	} else if dEnv.DBLoadError != nil {
+		return errhand.BuildDError("error: failed to load the repository").AddCause(dEnv.DBLoadError).Build()
+	}
+
+	remotes, err := dEnv.GetRemotes()
+	if err != nil {
+		return errhand.BuildDError("error: failed to read the remotes of the repository").AddCause(err).Build()
+	}
+
+	var rem env.Remote
+	found := false
+	for _, r := range remotes {
+		if r.Url == remoteUrl {
+			rem, found = r, true
+			break
+		}
+	}
+
+	if !found {
+		return errhand.BuildDError("error: the repository has no remote with the url '%s'", remoteUrl).Build()
+	}
+
+	if branch == "" {
+		branch = dEnv.RepoState.CWBHeadRef().GetPath()
+	}
+
+	headRoot, err := dEnv.HeadRoot(ctx)
+	if err != nil {
+		return errhand.BuildDError("error: failed to read the root of HEAD").AddCause(err).Build()
+	}
+
+	headHash, err := headRoot.HashOf()
+	if err != nil {
+		return errhand.BuildDError("error: failed to read the root of HEAD").AddCause(err).Build()
+	}
+
+	if dEnv.RepoState.Working != headHash.String() || dEnv.RepoState.Staged != headHash.String() {
+		return errhand.BuildDError("error: the repository has uncommitted changes").Build()
+	}
+
+	srcDB, err := rem.GetRemoteDB(ctx, dEnv.DoltDB.ValueReadWriter().Format())
+	if err != nil {
+		return AddIncompatibleFormatDetails(errhand.BuildDError("error: failed to get remote db").AddCause(err), err, dEnv).Build()
+	}
+
+	branchRef := ref.NewBranchRef(branch)
+	remoteRef := ref.NewRemoteRef(rem.Name, branch)
+	cm, verr := fetchRemoteBranch(ctx, dEnv, rem, srcDB, dEnv.DoltDB, branchRef, remoteRef)
+	if verr != nil {
+		return verr
+	}
+
+	err = dEnv.DoltDB.SetHead(ctx, remoteRef, cm)
+	if err != nil {
+		return errhand.BuildDError("error: could not update remote ref at " + remoteRef.String()).AddCause(err).Build()
+	}
+
+	canFF, err := dEnv.DoltDB.CanFastForward(ctx, branchRef, cm)
+	if err == doltdb.ErrIsAhead || (err == nil && !canFF) {
+		return errhand.BuildDError("error: branch '%s' has diverged from '%s'", branch, remoteRef.GetPath()).Build()
+	} else if err != nil && err != doltdb.ErrUpToDate {
+		return errhand.BuildDError("error: failed to read branch " + branch).AddCause(err).Build()
+	} else if err == nil {
+		err = dEnv.DoltDB.FastForward(ctx, branchRef, cm)
+		if err != nil {
+			return errhand.BuildDError("error: could not fast-forward branch " + branch).AddCause(err).Build()
+		}
+	}
+
+	rootVal, err := cm.GetRootValue()
+	if err != nil {
+		return errhand.BuildDError("error: could not get the root value of " + branch).AddCause(err).Build()
+	}
+
+	err = actions.SaveDocsFromRoot(ctx, rootVal, dEnv)
+	if err != nil {
+		return errhand.BuildDError("error: failed to update docs on the filesystem").AddCause(err).Build()
+	}
+
+	return checkoutClonedBranch(ctx, dEnv, branch, rootVal)
+}
+
 // Inits an empty, newly cloned repo. This would be unnecessary if we properly initialized the storage for a repository
 // when we created it on dolthub. If we do that, this code can be removed.
 func initEmptyClonedRepo(dEnv *env.DoltEnv, err error, ctx context.Context) error {
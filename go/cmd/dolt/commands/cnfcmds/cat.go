@@ -15,7 +15,11 @@
 package cnfcmds
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"io"
+	"strings"
 
 	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
@@ -25,22 +29,45 @@ import (
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/errcat"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/merge"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/rowconv"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/pipeline"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/untyped"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/untyped/blobprinter"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/untyped/fwt"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/untyped/nullprinter"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/iohelp"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const (
+	formatFlag = "result-format"
+	whereParam = "where"
+	limitParam = "limit"
 )
 
 var catDocs = cli.CommandDocumentationContent{
 	ShortDesc: "print conflicts",
-	LongDesc:  `The dolt conflicts cat command reads table conflicts and writes them to the standard output.`,
+	LongDesc: `The dolt conflicts cat command reads table conflicts and writes them to the standard output.
+
+Each conflict is shown as a group of rows: the base row, as it was before either side of the merge changed it, followed by our row and their row. A side which deleted the row is shown with the base row, and there is no base row when both sides added the row. The cells of our and their rows which differ from the base row, or from the other side's row when both sides added the row, are highlighted.
+
+{{.EmphasisLeft}}--result-format json{{.EmphasisRight}} writes the conflicts as a JSON object instead, of the form:
+
+	{"tables": [{"table": "t", "conflicts": [{"key": {"pk": 1}, "ours_change": "modified", "theirs_change": "deleted", "base": {"pk": 1, "c1": 1}, "ours": {"pk": 1, "c1": 2}, "theirs": null}]}]}
+
+where {{.EmphasisLeft}}key{{.EmphasisRight}} holds the primary key of the conflicting row, {{.EmphasisLeft}}ours_change{{.EmphasisRight}} and {{.EmphasisLeft}}theirs_change{{.EmphasisRight}} say whether each side added, modified or deleted the row, and {{.EmphasisLeft}}base{{.EmphasisRight}}, {{.EmphasisLeft}}ours{{.EmphasisRight}} and {{.EmphasisLeft}}theirs{{.EmphasisRight}} hold the versions of the row by column name, or null where the row doesn't exist. Numbers and booleans are JSON numbers and booleans, other values are strings, and NULLs are omitted.
+
+The conflicts displayed can be limited to the first N of each table with {{.EmphasisLeft}}--limit N{{.EmphasisRight}}, and filtered with {{.EmphasisLeft}}--where <predicate>{{.EmphasisRight}}, where the predicate is a SQL expression like those accepted by {{.EmphasisLeft}}dolt diff --where{{.EmphasisRight}}. Columns can be referred to as {{.EmphasisLeft}}base_COLUMN_NAME{{.EmphasisRight}}, {{.EmphasisLeft}}ours_COLUMN_NAME{{.EmphasisRight}} or {{.EmphasisLeft}}theirs_COLUMN_NAME{{.EmphasisRight}} to filter on the value of one version, and a column referred to by its name alone matches a conflict if any of its versions satisfies the predicate. Comparisons of the first primary key column with literal values limit the range of keys whose conflicts are read.
+
+Conflicts are read and written as they're printed, so printing a table with very many conflicts doesn't need to hold them all in memory.`,
 	Synopsis: []string{
-		"[{{.LessThan}}commit{{.GreaterThan}}] {{.LessThan}}table{{.GreaterThan}}...",
+		"[--result-format {{.LessThan}}format{{.GreaterThan}}] [--where {{.LessThan}}predicate{{.GreaterThan}}] [--limit {{.LessThan}}N{{.GreaterThan}}] [{{.LessThan}}commit{{.GreaterThan}}] {{.LessThan}}table{{.GreaterThan}}...",
 	},
 }
 
@@ -70,10 +97,23 @@ func (cmd CatCmd) EventType() eventsapi.ClientEventType {
 func (cmd CatCmd) createArgParser() *argparser.ArgParser {
 	ap := argparser.NewArgParser()
 	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"table", "List of tables to be printed. '.' can be used to print conflicts for all tables."})
+	ap.SupportsFlag(commands.ShowBinaryFlag, "", "Print binary values in full rather than their size and hash when they don't look like text.")
+	ap.SupportsString(formatFlag, "r", "result output format", "How to format the output. Valid values are tabular and json. Defaults to tabular.")
+	ap.SupportsString(whereParam, "", "predicate", "filters the conflicts based on the values of their rows.")
+	ap.SupportsInt(limitParam, "", "record_count", "limits to the first N conflicts of each table.")
 
 	return ap
 }
 
+// catArgs are the options of the conflicts printed by dolt conflicts cat.
+type catArgs struct {
+	showBinary bool
+	json       bool
+	where      string
+	// default value of 0 used to signal no limit.
+	limit int
+}
+
 // Exec executes the command
 func (cmd CatCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
 	ap := cmd.createArgParser()
@@ -88,23 +128,32 @@ func (cmd CatCmd) Exec(ctx context.Context, commandStr string, args []string, dE
 		return 1
 	}
 
+	cArgs := catArgs{showBinary: apr.Contains(commands.ShowBinaryFlag), where: apr.GetValueOrDefault(whereParam, "")}
+	cArgs.limit, _ = apr.GetInt(limitParam)
+
+	if formatStr, ok := apr.GetValue(formatFlag); ok {
+		switch strings.ToLower(formatStr) {
+		case "tabular":
+		case "json":
+			cArgs.json = true
+		default:
+			return commands.HandleVErrAndExitCode(errhand.BuildDError("Invalid argument for --%s. Valid values are tabular, json", formatFlag).Build(), usage)
+		}
+	}
+
 	root, verr := commands.GetWorkingWithVErr(dEnv)
 	if verr != nil {
-		return exitWithVerr(verr)
+		return commands.HandleVErrAndExitCode(verr, usage)
 	}
 
 	cm, verr := commands.MaybeGetCommitWithVErr(dEnv, args[0])
 	if verr != nil {
-		return exitWithVerr(verr)
+		return commands.HandleVErrAndExitCode(verr, usage)
 	}
 
 	// If no commit was resolved from the first argument, assume the args are all table names and print the conflicts
 	if cm == nil {
-		if verr := printConflicts(ctx, root, args); verr != nil {
-			return exitWithVerr(verr)
-		}
-
-		return 0
+		return commands.HandleVErrAndExitCode(printConflicts(ctx, root, args, cArgs), usage)
 	}
 
 	tblNames := args[1:]
@@ -116,22 +165,13 @@ func (cmd CatCmd) Exec(ctx context.Context, commandStr string, args []string, dE
 
 	root, err := cm.GetRootValue()
 	if err != nil {
-		return exitWithVerr(errhand.BuildDError("unable to get the root value").AddCause(err).Build())
+		return commands.HandleVErrAndExitCode(errhand.BuildDError("unable to get the root value").AddCause(err).Build(), usage)
 	}
 
-	if verr = printConflicts(ctx, root, tblNames); verr != nil {
-		return exitWithVerr(verr)
-	}
-
-	return 0
+	return commands.HandleVErrAndExitCode(printConflicts(ctx, root, tblNames, cArgs), usage)
 }
 
-func exitWithVerr(verr errhand.VerboseError) int {
-	cli.PrintErrln(verr.Verbose())
-	return 1
-}
-
-func printConflicts(ctx context.Context, root *doltdb.RootValue, tblNames []string) errhand.VerboseError {
+func printConflicts(ctx context.Context, root *doltdb.RootValue, tblNames []string, cArgs catArgs) errhand.VerboseError {
 	if len(tblNames) == 1 && tblNames[0] == "." {
 		var err error
 		tblNames, err = doltdb.UnionTableNames(ctx, root)
@@ -141,12 +181,17 @@ func printConflicts(ctx context.Context, root *doltdb.RootValue, tblNames []stri
 		}
 	}
 
+	var jsonWr *conflictJSONWriter
+	if cArgs.json {
+		jsonWr = newConflictJSONWriter(cli.CliOut)
+	}
+
 	for _, tblName := range tblNames {
 		verr := func() errhand.VerboseError {
 			if has, err := root.HasTable(ctx, tblName); err != nil {
 				return errhand.BuildDError("error: unable to read database").AddCause(err).Build()
 			} else if !has {
-				return errhand.BuildDError("error: unknown table '%s'", tblName).Build()
+				return errhand.BuildDError("error: unknown table '%s'", tblName).SetCategory(errcat.NotFound).Build()
 			}
 
 			tbl, _, err := root.GetTable(ctx, tblName)
@@ -155,60 +200,343 @@ func printConflicts(ctx context.Context, root *doltdb.RootValue, tblNames []stri
 				return errhand.BuildDError("error: unable to read database").AddCause(err).Build()
 			}
 
-			cnfRd, err := merge.NewConflictReader(ctx, tbl)
+			cnfItr, verr := newConflictIter(ctx, tbl, cArgs)
 
-			if err == doltdb.ErrNoConflicts {
-				return nil
-			} else if err != nil {
-				return errhand.BuildDError("failed to read conflicts").AddCause(err).Build()
+			if verr != nil || cnfItr == nil {
+				return verr
+			}
+
+			defer cnfItr.cnfRd.Close()
+
+			if cArgs.json {
+				return jsonWr.writeTable(ctx, tblName, cnfItr)
 			}
 
-			defer cnfRd.Close()
+			return printConflictsTable(ctx, tbl, cnfItr, cArgs.showBinary)
+		}()
+
+		if verr != nil {
+			return verr
+		}
+	}
+
+	if jsonWr != nil {
+		if err := jsonWr.close(); err != nil {
+			return errhand.BuildDError("error: failed to write conflicts").AddCause(err).Build()
+		}
+	}
+
+	return nil
+}
 
-			cnfWr, err := merge.NewConflictSink(iohelp.NopWrCloser(cli.CliOut), cnfRd.GetSchema(), " | ")
-			defer cnfWr.Close()
+// conflictIter reads the conflicts of a table which satisfy a where clause, up to a limit.
+type conflictIter struct {
+	cnfRd  *merge.ConflictReader
+	joiner *rowconv.Joiner
+	filter *sqle.DiffFilter
+	limit  int
+	read   int
+}
+
+// newConflictIter returns an iterator over the conflicts of |tbl| chosen by |cArgs|, or nil if the table has no
+// conflicts.
+func newConflictIter(ctx context.Context, tbl *doltdb.Table, cArgs catArgs) (*conflictIter, errhand.VerboseError) {
+	base, sch, mergeSch, err := tbl.GetConflictSchemas(ctx)
+
+	if err == doltdb.ErrNoConflicts {
+		return nil, nil
+	} else if err != nil {
+		return nil, errhand.BuildDError("failed to read conflicts").AddCause(err).Build()
+	}
+
+	var joiner *rowconv.Joiner
+	filter := &sqle.DiffFilter{}
+	if cArgs.where != "" {
+		joiner, err = merge.NewConflictJoiner(base, sch, mergeSch)
+
+		if err != nil {
+			return nil, errhand.BuildDError("failed to read conflicts").AddCause(err).Build()
+		}
+
+		filter, err = sqle.ParseConflictWhere(tbl.Format(), base, sch, mergeSch, joiner.GetSchema(), cArgs.where)
+
+		if err != nil {
+			return nil, errhand.BuildDError("error: failed to parse where clause").AddCause(err).SetPrintUsage().Build()
+		}
+	}
+
+	cnfRd, err := merge.NewConflictReaderForRanges(ctx, tbl, filter.KeyRanges)
+
+	if err == doltdb.ErrNoConflicts {
+		return nil, nil
+	} else if err != nil {
+		return nil, errhand.BuildDError("failed to read conflicts").AddCause(err).Build()
+	}
+
+	return &conflictIter{cnfRd: cnfRd, joiner: joiner, filter: filter, limit: cArgs.limit}, nil
+}
+
+// next returns the next conflict which satisfies the where clause, or io.EOF when there are no more or the limit has
+// been reached.
+func (itr *conflictIter) next(ctx context.Context) (merge.ConflictRows, error) {
+	for {
+		if itr.limit > 0 && itr.read >= itr.limit {
+			return merge.ConflictRows{}, io.EOF
+		}
+
+		cnf, err := itr.cnfRd.NextConflictRows(ctx)
+
+		if err != nil {
+			return merge.ConflictRows{}, err
+		}
+
+		if itr.joiner != nil {
+			r, err := cnf.Join(itr.joiner)
 
 			if err != nil {
-				return errhand.BuildDError("error: unable to read database").AddCause(err).Build()
+				return merge.ConflictRows{}, err
 			}
 
-			nullPrinter := nullprinter.NewNullPrinter(cnfRd.GetSchema())
-			fwtTr := fwt.NewAutoSizingFWTTransformer(cnfRd.GetSchema(), fwt.HashFillWhenTooLong, 1000)
-			transforms := pipeline.NewTransformCollection(
-				pipeline.NewNamedTransform(nullprinter.NullPrintingStage, nullPrinter.ProcessRow),
-				pipeline.NamedTransform{Name: "fwt", Func: fwtTr.TransformToFWT},
-			)
+			matches, err := itr.filter.Matches(ctx, r)
+
+			if err != nil {
+				return merge.ConflictRows{}, err
+			} else if !matches {
+				continue
+			}
+		}
 
-			// TODO: Pipeline should be contextified.
-			srcProcFunc := pipeline.ProcFuncForSourceFunc(func() (row.Row, pipeline.ImmutableProperties, error) { return cnfRd.NextConflict(ctx) })
-			sinkProcFunc := pipeline.ProcFuncForSinkFunc(cnfWr.ProcRowWithProps)
-			p := pipeline.NewAsyncPipeline(srcProcFunc, sinkProcFunc, transforms, func(failure *pipeline.TransformRowFailure) (quit bool) {
-				panic("")
-			})
+		itr.read++
+		return cnf, nil
+	}
+}
 
-			colNames, err := schema.ExtractAllColNames(cnfRd.GetSchema())
+func printConflictsTable(ctx context.Context, tbl *doltdb.Table, cnfItr *conflictIter, showBinary bool) errhand.VerboseError {
+	cnfRd := cnfItr.cnfRd
+	cnfWr, err := merge.NewConflictSink(iohelp.NopWrCloser(cli.CliOut), cnfRd.GetSchema(), " | ")
 
-			if err != nil {
-				return errhand.BuildDError("error: failed to read columns from schema").AddCause(err).Build()
+	if err != nil {
+		return errhand.BuildDError("error: unable to read database").AddCause(err).Build()
+	}
+
+	defer cnfWr.Close()
+
+	transforms := pipeline.NewTransformCollection()
+	if !showBinary {
+		base, sch, mergeSch := cnfRd.GetConflictSchemas()
+		blobPrinter := blobprinter.NewBlobPrinter(cnfRd.GetSchema(), blobprinter.BlobTags(base, sch, mergeSch))
+		transforms.AppendTransforms(pipeline.NewNamedTransform(blobprinter.BlobPrintingStage, blobPrinter.ProcessRow))
+	}
+
+	nullPrinter := nullprinter.NewNullPrinter(cnfRd.GetSchema())
+	fwtTr := fwt.NewAutoSizingFWTTransformer(cnfRd.GetSchema(), fwt.HashFillWhenTooLong, 1000)
+	transforms.AppendTransforms(
+		pipeline.NewNamedTransform(nullprinter.NullPrintingStage, nullPrinter.ProcessRow),
+		pipeline.NamedTransform{Name: "fwt", Func: fwtTr.TransformToFWT},
+	)
+
+	// the rows of each conflict are read one conflict at a time, so that only the conflicts being printed are in memory
+	var buffered []pipeline.RowWithProps
+	nextRow := func() (row.Row, pipeline.ImmutableProperties, error) {
+		for len(buffered) == 0 {
+			cnf, err := cnfItr.next(ctx)
+
+			if err == io.EOF {
+				return nil, pipeline.NoProps, io.EOF
+			} else if err != nil {
+				return nil, pipeline.ImmutableProperties{}, err
 			}
-			r, err := untyped.NewRowFromTaggedStrings(tbl.Format(), cnfRd.GetSchema(), colNames)
+
+			buffered, err = cnfRd.RowsWithProps(cnf)
 
 			if err != nil {
-				return errhand.BuildDError("error: failed to create header row for printing").AddCause(err).Build()
+				return nil, pipeline.ImmutableProperties{}, err
 			}
+		}
 
-			p.InjectRow("fwt", r)
+		r := buffered[0]
+		buffered = buffered[1:]
+		return r.Row, r.Props, nil
+	}
 
-			p.Start()
-			p.Wait()
+	// TODO: Pipeline should be contextified.
+	srcProcFunc := pipeline.ProcFuncForSourceFunc(nextRow)
+	sinkProcFunc := pipeline.ProcFuncForSinkFunc(cnfWr.ProcRowWithProps)
+	p := pipeline.NewAsyncPipeline(srcProcFunc, sinkProcFunc, transforms, func(failure *pipeline.TransformRowFailure) (quit bool) {
+		panic("")
+	})
 
-			return nil
-		}()
+	colNames, err := schema.ExtractAllColNames(cnfRd.GetSchema())
 
-		if verr != nil {
-			return verr
+	if err != nil {
+		return errhand.BuildDError("error: failed to read columns from schema").AddCause(err).Build()
+	}
+	r, err := untyped.NewRowFromTaggedStrings(tbl.Format(), cnfRd.GetSchema(), colNames)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to create header row for printing").AddCause(err).Build()
+	}
+
+	p.InjectRow("fwt", r)
+
+	p.Start()
+	err = p.Wait()
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to read conflicts").AddCause(err).Build()
+	}
+
+	return nil
+}
+
+// conflictJSON is a conflict written by --result-format json. The versions of the row are maps from column names to
+// values, and are nil where the row doesn't exist.
+type conflictJSON struct {
+	Key          map[string]interface{} `json:"key"`
+	OursChange   string                 `json:"ours_change"`
+	TheirsChange string                 `json:"theirs_change"`
+	Base         map[string]interface{} `json:"base"`
+	Ours         map[string]interface{} `json:"ours"`
+	Theirs       map[string]interface{} `json:"theirs"`
+}
+
+// conflictJSONWriter streams the conflicts of tables as a single JSON object, writing each conflict as it's read.
+type conflictJSONWriter struct {
+	bWr           *bufio.Writer
+	tablesWritten int
+}
+
+func newConflictJSONWriter(wr io.Writer) *conflictJSONWriter {
+	return &conflictJSONWriter{bWr: bufio.NewWriter(wr)}
+}
+
+func (jw *conflictJSONWriter) write(strs ...string) error {
+	for _, str := range strs {
+		if _, err := jw.bWr.WriteString(str); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (jw *conflictJSONWriter) writeTable(ctx context.Context, tblName string, cnfItr *conflictIter) errhand.VerboseError {
+	prefix := `{"tables": [`
+	if jw.tablesWritten > 0 {
+		prefix = ", "
+	}
+
+	jw.tablesWritten++
+	tblNameJSON, _ := json.Marshal(tblName)
+	err := jw.write(prefix, `{"table": `, string(tblNameJSON), `, "conflicts": [`)
+
+	base, sch, mergeSch := cnfItr.cnfRd.GetConflictSchemas()
+	for i := 0; err == nil; i++ {
+		var cnf merge.ConflictRows
+		cnf, err = cnfItr.next(ctx)
+
+		if err != nil {
+			break
+		}
+
+		var data []byte
+		data, err = marshalConflict(cnf, base, sch, mergeSch)
+
+		if err == nil && i > 0 {
+			err = jw.write(", ")
 		}
+
+		if err == nil {
+			err = jw.write(string(data))
+		}
+	}
+
+	if err == io.EOF {
+		err = jw.write("]}")
+	}
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to write conflicts of table '%s'", tblName).AddCause(err).Build()
 	}
 
 	return nil
 }
+
+func (jw *conflictJSONWriter) close() error {
+	end := "]}\n"
+	if jw.tablesWritten == 0 {
+		end = `{"tables": []}` + "\n"
+	}
+
+	if err := jw.write(end); err != nil {
+		return err
+	}
+
+	return jw.bWr.Flush()
+}
+
+func marshalConflict(cnf merge.ConflictRows, base, sch, mergeSch schema.Schema) ([]byte, error) {
+	var err error
+	cj := conflictJSON{OursChange: cnf.OursChange(), TheirsChange: cnf.TheirsChange()}
+	for _, v := range []struct {
+		dest *map[string]interface{}
+		r    row.Row
+		sch  schema.Schema
+	}{{&cj.Base, cnf.Base, base}, {&cj.Ours, cnf.Ours, sch}, {&cj.Theirs, cnf.Theirs, mergeSch}} {
+		if v.r == nil {
+			continue
+		}
+
+		if cj.Key == nil {
+			cj.Key, err = rowJSON(v.r, v.sch.GetPKCols())
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		*v.dest, err = rowJSON(v.r, v.sch.GetAllCols())
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(cj)
+}
+
+// rowJSON returns the values of the columns |cols| of |r| by column name. Numbers and booleans are kept as they are so
+// that they're written as JSON numbers and booleans, other values are formatted as strings, and NULLs are left out.
+func rowJSON(r row.Row, cols *schema.ColCollection) (map[string]interface{}, error) {
+	vals := make(map[string]interface{})
+	err := cols.Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+		val, ok := r.GetColVal(tag)
+
+		if !ok || types.IsNull(val) {
+			return false, nil
+		}
+
+		switch val.Kind() {
+		case types.IntKind, types.UintKind, types.FloatKind, types.BoolKind:
+			vals[col.Name] = val
+		default:
+			str, err := col.TypeInfo.FormatValue(val)
+
+			if err != nil {
+				return true, err
+			}
+
+			if str != nil {
+				vals[col.Name] = *str
+			}
+		}
+
+		return false, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return vals, nil
+}
@@ -21,4 +21,6 @@ import (
 var Commands = cli.NewSubCommandHandler("conflicts", "Commands for viewing and resolving merge conflicts.", []cli.Command{
 	CatCmd{},
 	ResolveCmd{},
+	ExportCmd{},
+	ImportCmd{},
 })
@@ -0,0 +1,237 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cnfcmds
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tealeg/xlsx"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/merge"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+var exportDocs = cli.CommandDocumentationContent{
+	ShortDesc: "Export the conflicts of a table to a file for resolution outside of dolt",
+	LongDesc: `Writes the conflicts of a table to a csv or xlsx file, so that they can be resolved in a spreadsheet and applied with {{.EmphasisLeft}}dolt conflicts import{{.EmphasisRight}}.
+
+Each conflict is a row of the file, holding the primary key of the conflicting row, how the row was changed on each side of the merge, and the base, ours and theirs values of each of the table's other columns. To resolve a conflict, set its {{.EmphasisLeft}}resolution{{.EmphasisRight}} column to one of:
+
+{{.EmphasisLeft}}ours{{.EmphasisRight}}: keep our version of the row.
+{{.EmphasisLeft}}theirs{{.EmphasisRight}}: take their version of the row.
+{{.EmphasisLeft}}base{{.EmphasisRight}}: restore the version of the row before either change.
+{{.EmphasisLeft}}delete{{.EmphasisRight}}: delete the row.
+{{.EmphasisLeft}}custom{{.EmphasisRight}}: use the values of the {{.EmphasisLeft}}resolved_{{.EmphasisRight}} columns, which start out with our values.
+
+Conflicts whose resolution is left blank remain unresolved. Empty cells are NULL values.
+`,
+	Synopsis: []string{
+		"[--file-type {{.LessThan}}type{{.GreaterThan}}] {{.LessThan}}table{{.GreaterThan}} {{.LessThan}}file{{.GreaterThan}}",
+	},
+}
+
+const fileTypeParam = "file-type"
+
+type ExportCmd struct{}
+
+// Name is returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd ExportCmd) Name() string {
+	return "export"
+}
+
+// Description returns a description of the command
+func (cmd ExportCmd) Description() string {
+	return "Export the conflicts of a table to a file."
+}
+
+// CreateMarkdown creates a markdown file containing the helptext for the command at the given path
+func (cmd ExportCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) error {
+	ap := cmd.createArgParser()
+	return commands.CreateMarkdown(fs, path, cli.GetCommandDocumentation(commandStr, exportDocs, ap))
+}
+
+// EventType returns the type of the event to log
+func (cmd ExportCmd) EventType() eventsapi.ClientEventType {
+	return eventsapi.ClientEventType_TYPE_UNSPECIFIED
+}
+
+func (cmd ExportCmd) createArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"table", "The table whose conflicts are exported."})
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"file", "The file the conflicts are written to."})
+	ap.SupportsString(fileTypeParam, "", "type", "The type of the file, csv or xlsx. Defaults to the file's extension.")
+
+	return ap
+}
+
+// Exec executes the command
+func (cmd ExportCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cmd.createArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, exportDocs, ap))
+	apr := cli.ParseArgs(ap, args, help)
+
+	if apr.NArg() != 2 {
+		usage()
+		return 1
+	}
+
+	return commands.HandleVErrAndExitCode(exportConflicts(ctx, dEnv, apr.Arg(0), apr.Arg(1), apr.GetValueOrDefault(fileTypeParam, "")), usage)
+}
+
+func exportConflicts(ctx context.Context, dEnv *env.DoltEnv, tblName, path, fileType string) errhand.VerboseError {
+	fileType, verr := conflictsFileType(path, fileType)
+
+	if verr != nil {
+		return verr
+	}
+
+	tbl, verr := getConflictedTable(ctx, dEnv, tblName)
+
+	if verr != nil {
+		return verr
+	}
+
+	rows, err := merge.ExportConflicts(ctx, tbl)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to read the conflicts of table '%s'", tblName).AddCause(err).Build()
+	}
+
+	if fileType == xlsxFileType {
+		err = writeXLSX(dEnv.FS, path, tblName, rows)
+	} else {
+		err = writeCSV(dEnv.FS, path, rows)
+	}
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to write '%s'", path).AddCause(err).Build()
+	}
+
+	cli.Printf("Exported %d conflicts of table '%s' to %s\n", len(rows)-1, tblName, path)
+	return nil
+}
+
+const (
+	csvFileType  = "csv"
+	xlsxFileType = "xlsx"
+)
+
+// conflictsFileType returns the type of a conflicts file, which is the file's extension unless a type is given.
+func conflictsFileType(path, fileType string) (string, errhand.VerboseError) {
+	if fileType == "" {
+		fileType = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+
+	switch strings.ToLower(fileType) {
+	case csvFileType:
+		return csvFileType, nil
+	case xlsxFileType:
+		return xlsxFileType, nil
+	default:
+		return "", errhand.BuildDError("error: unsupported file type '%s'. Conflicts files must be csv or xlsx files", fileType).Build()
+	}
+}
+
+// getConflictedTable returns the table of the working root with the name given, which must have conflicts.
+func getConflictedTable(ctx context.Context, dEnv *env.DoltEnv, tblName string) (*doltdb.Table, errhand.VerboseError) {
+	root, verr := commands.GetWorkingWithVErr(dEnv)
+
+	if verr != nil {
+		return nil, verr
+	}
+
+	tbl, ok, err := root.GetTable(ctx, tblName)
+
+	if err != nil {
+		return nil, errhand.BuildDError("error: unable to read database").AddCause(err).Build()
+	} else if !ok {
+		return nil, errhand.BuildDError("error: unknown table '%s'", tblName).Build()
+	}
+
+	if has, err := tbl.HasConflicts(); err != nil {
+		return nil, errhand.BuildDError("error: unable to read database").AddCause(err).Build()
+	} else if !has {
+		return nil, errhand.BuildDError("error: table '%s' has no conflicts", tblName).Build()
+	}
+
+	return tbl, nil
+}
+
+func writeCSV(fs filesys.WritableFS, path string, rows [][]string) error {
+	wr, err := fs.OpenForWrite(path, os.ModePerm)
+
+	if err != nil {
+		return err
+	}
+
+	csvWr := csv.NewWriter(wr)
+	err = csvWr.WriteAll(rows)
+
+	if err != nil {
+		wr.Close()
+		return err
+	}
+
+	return wr.Close()
+}
+
+// The maximum length of the name of a sheet of an xlsx file.
+const maxSheetNameLen = 31
+
+func writeXLSX(fs filesys.WritableFS, path, tblName string, rows [][]string) error {
+	if len(tblName) > maxSheetNameLen {
+		tblName = tblName[:maxSheetNameLen]
+	}
+
+	f := xlsx.NewFile()
+	sheet, err := f.AddSheet(tblName)
+
+	if err != nil {
+		return err
+	}
+
+	for _, r := range rows {
+		xlRow := sheet.AddRow()
+		for _, cell := range r {
+			xlRow.AddCell().SetString(cell)
+		}
+	}
+
+	wr, err := fs.OpenForWrite(path, os.ModePerm)
+
+	if err != nil {
+		return err
+	}
+
+	err = f.Write(wr)
+
+	if err != nil {
+		wr.Close()
+		return err
+	}
+
+	return wr.Close()
+}
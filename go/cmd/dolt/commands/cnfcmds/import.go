@@ -0,0 +1,195 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cnfcmds
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+
+	"github.com/tealeg/xlsx"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/merge"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+var importDocs = cli.CommandDocumentationContent{
+	ShortDesc: "Resolve the conflicts of a table with the resolutions of a file",
+	LongDesc: `Reads the resolutions of the conflicts of a table from a csv or xlsx file written by {{.EmphasisLeft}}dolt conflicts export{{.EmphasisRight}}, applies them to the working set and clears the resolved conflicts. Conflicts whose resolution is blank remain unresolved.
+
+Every resolution is checked against the table's schema and constraints before any is applied. If any resolution is invalid, the offending rows of the file are reported and nothing is applied.
+`,
+	Synopsis: []string{
+		"[--file-type {{.LessThan}}type{{.GreaterThan}}] {{.LessThan}}table{{.GreaterThan}} {{.LessThan}}file{{.GreaterThan}}",
+	},
+}
+
+type ImportCmd struct{}
+
+// Name is returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd ImportCmd) Name() string {
+	return "import"
+}
+
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd ImportCmd) LocksRepo() bool {
+	return true
+}
+
+// Description returns a description of the command
+func (cmd ImportCmd) Description() string {
+	return "Resolve the conflicts of a table with the resolutions of a file."
+}
+
+// CreateMarkdown creates a markdown file containing the helptext for the command at the given path
+func (cmd ImportCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) error {
+	ap := cmd.createArgParser()
+	return commands.CreateMarkdown(fs, path, cli.GetCommandDocumentation(commandStr, importDocs, ap))
+}
+
+// EventType returns the type of the event to log
+func (cmd ImportCmd) EventType() eventsapi.ClientEventType {
+	return eventsapi.ClientEventType_TYPE_UNSPECIFIED
+}
+
+func (cmd ImportCmd) createArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"table", "The table whose conflicts are resolved."})
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"file", "The file the resolutions are read from."})
+	ap.SupportsString(fileTypeParam, "", "type", "The type of the file, csv or xlsx. Defaults to the file's extension.")
+
+	return ap
+}
+
+// Exec executes the command
+func (cmd ImportCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cmd.createArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, importDocs, ap))
+	apr := cli.ParseArgs(ap, args, help)
+
+	if apr.NArg() != 2 {
+		usage()
+		return 1
+	}
+
+	return commands.HandleVErrAndExitCode(importConflicts(ctx, dEnv, apr.Arg(0), apr.Arg(1), apr.GetValueOrDefault(fileTypeParam, "")), usage)
+}
+
+func importConflicts(ctx context.Context, dEnv *env.DoltEnv, tblName, path, fileType string) errhand.VerboseError {
+	fileType, verr := conflictsFileType(path, fileType)
+
+	if verr != nil {
+		return verr
+	}
+
+	tbl, verr := getConflictedTable(ctx, dEnv, tblName)
+
+	if verr != nil {
+		return verr
+	}
+
+	var rows [][]string
+	var err error
+	if fileType == xlsxFileType {
+		rows, err = readXLSX(dEnv.FS, path)
+	} else {
+		rows, err = readCSV(dEnv.FS, path)
+	}
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to read '%s'", path).AddCause(err).Build()
+	}
+
+	updated, resolved, invalid, err := merge.ImportConflictResolutions(ctx, tbl, rows)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to resolve the conflicts of table '%s'", tblName).AddCause(err).Build()
+	}
+
+	if len(invalid) > 0 {
+		bdr := errhand.BuildDError("error: %d invalid resolutions in '%s'. No conflicts were resolved", len(invalid), path)
+		for _, ir := range invalid {
+			bdr.AddDetails(ir.String())
+		}
+
+		return bdr.Build()
+	}
+
+	if resolved > 0 {
+		root, verr := commands.GetWorkingWithVErr(dEnv)
+
+		if verr != nil {
+			return verr
+		}
+
+		root, err = root.PutTable(ctx, tblName, updated)
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to update table '%s'", tblName).AddCause(err).Build()
+		}
+
+		if verr := commands.UpdateWorkingWithVErr(dEnv, root); verr != nil {
+			return verr
+		}
+	}
+
+	cli.Printf("%d conflicts resolved\n", resolved)
+	return saveDocsOnResolve(ctx, dEnv)
+}
+
+func readCSV(fs filesys.ReadableFS, path string) ([][]string, error) {
+	data, err := fs.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	csvRd := csv.NewReader(bytes.NewReader(data))
+	// spreadsheets may drop the trailing empty cells of a row
+	csvRd.FieldsPerRecord = -1
+
+	return csvRd.ReadAll()
+}
+
+func readXLSX(fs filesys.ReadableFS, path string) ([][]string, error) {
+	data, err := fs.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := xlsx.OpenBinary(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sheets, err := f.ToSlice()
+
+	if err != nil {
+		return nil, err
+	} else if len(sheets) == 0 {
+		return nil, errors.New("the file has no sheets")
+	}
+
+	return sheets[0], nil
+}
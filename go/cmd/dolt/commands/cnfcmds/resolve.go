@@ -71,6 +71,11 @@ func (cmd ResolveCmd) Name() string {
 	return "resolve"
 }
 
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd ResolveCmd) LocksRepo() bool {
+	return true
+}
+
 // Description returns a description of the command
 func (cmd ResolveCmd) Description() string {
 	return "Removes rows from list of conflicts"
@@ -18,7 +18,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
-	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -29,6 +29,7 @@ import (
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/errcat"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/merge"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/editor"
@@ -36,9 +37,11 @@ import (
 )
 
 const (
-	allowEmptyFlag   = "allow-empty"
-	dateParam        = "date"
-	commitMessageArg = "message"
+	allowEmptyFlag     = "allow-empty"
+	amendFlag          = "amend"
+	dateParam          = "date"
+	commitMessageArg   = "message"
+	forceConflictsFlag = "force-conflicts"
 )
 
 var commitDocs = cli.CommandDocumentationContent{
@@ -48,9 +51,13 @@ var commitDocs = cli.CommandDocumentationContent{
 	
 	The content to be added can be specified by using dolt add to incrementally \"add\" changes to the staged tables before using the commit command (Note: even modified files must be \"added\").
 	
-	The log message can be added with the parameter {{.EmphasisLeft}}-m <msg>{{.EmphasisRight}}.  If the {{.LessThan}}-m{{.GreaterThan}} parameter is not provided an editor will be opened where you can review the commit and provide a log message.
+	The log message can be added with the parameter {{.EmphasisLeft}}-m <msg>{{.EmphasisRight}}.  If the {{.LessThan}}-m{{.GreaterThan}} parameter is not provided an editor will be opened where you can review the commit and provide a log message. The editor used is taken from the {{.EmphasisLeft}}core.editor{{.EmphasisRight}} config value, then the VISUAL and EDITOR environment variables. If {{.EmphasisLeft}}commit.template{{.EmphasisRight}} is configured, the contents of that file are used as the starting message. Lines starting with '#' are ignored and an empty message aborts the commit.
 	
 	The commit timestamp can be modified using the --date parameter.  Dates can be specified in the formats {{.LessThan}}YYYY-MM-DD{{.GreaterThan}}, {{.LessThan}}YYYY-MM-DDTHH:MM:SS{{.GreaterThan}}, or {{.LessThan}}YYYY-MM-DDTHH:MM:SSZ07:00{{.GreaterThan}} (where {{.LessThan}}07:00{{.GreaterThan}} is the time zone offset)."
+
+	With {{.EmphasisLeft}}--amend{{.EmphasisRight}}, the last commit of the current branch is replaced by a new commit with the same parents, holding the staged tables and the new log message. When nothing is staged, only the log message and author are changed. If no message is given, the editor is opened with the message of the last commit. The replaced commit can be recovered from {{.EmphasisLeft}}refs/original/heads/{{.LessThan}}branch{{.GreaterThan}}{{.EmphasisRight}}. A commit which has been pushed to the upstream of the branch is only amended with {{.EmphasisLeft}}--force{{.EmphasisRight}}, as the amended commit would no longer be in the history of the branch that others have pulled.
+
+	With {{.EmphasisLeft}}--force-conflicts{{.EmphasisRight}}, the tables of the working set which have conflicts are staged along with their conflicts before committing. The conflicts are part of the committed tables, so they are pushed and pulled with the commit, and whoever pulls it sees them with {{.EmphasisLeft}}dolt conflicts{{.EmphasisRight}} and can resolve them and commit the resolution. A branch whose head has committed conflicts can be fast forwarded, but a merge which changes a table with committed conflicts on both sides is refused until the conflicts are resolved.
 	`,
 	Synopsis: []string{
 		"[options]",
@@ -64,6 +71,11 @@ func (cmd CommitCmd) Name() string {
 	return "commit"
 }
 
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd CommitCmd) LocksRepo() bool {
+	return true
+}
+
 // Description returns a description of the command
 func (cmd CommitCmd) Description() string {
 	return "Record changes to the repository."
@@ -80,6 +92,9 @@ func (cmd CommitCmd) createArgParser() *argparser.ArgParser {
 	ap.SupportsString(commitMessageArg, "m", "msg", "Use the given {{.LessThan}}msg{{.GreaterThan}} as the commit message.")
 	ap.SupportsFlag(allowEmptyFlag, "", "Allow recording a commit that has the exact same data as its sole parent. This is usually a mistake, so it is disabled by default. This option bypasses that safety.")
 	ap.SupportsString(dateParam, "", "date", "Specify the date used in the commit. If not specified the current system time is used.")
+	ap.SupportsFlag(amendFlag, "", "Replace the last commit of the current branch with a new commit of the staged tables and the given message.")
+	ap.SupportsFlag(forceFlag, "f", "With --amend, amend the last commit even if it has been pushed to the upstream of the branch.")
+	ap.SupportsFlag(forceConflictsFlag, "", "Stage the tables in conflict along with their conflicts, and commit the conflicts for others to resolve.")
 	return ap
 }
 
@@ -89,9 +104,18 @@ func (cmd CommitCmd) Exec(ctx context.Context, commandStr string, args []string,
 	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, commitDocs, ap))
 	apr := cli.ParseArgs(ap, args, help)
 
+	if apr.Contains(forceFlag) && !apr.Contains(amendFlag) {
+		return HandleVErrAndExitCode(errhand.BuildDError("error: --force can only be used with --amend").SetPrintUsage().Build(), usage)
+	}
+
 	msg, msgOk := apr.GetValue(commitMessageArg)
 	if !msgOk {
-		msg = getCommitMessageFromEditor(ctx, dEnv)
+		var verr errhand.VerboseError
+		msg, verr = getCommitMessageFromEditor(ctx, dEnv, apr.Contains(amendFlag))
+
+		if verr != nil {
+			return HandleVErrAndExitCode(verr, usage)
+		}
 	}
 
 	t := time.Now()
@@ -104,7 +128,19 @@ func (cmd CommitCmd) Exec(ctx context.Context, commandStr string, args []string,
 		}
 	}
 
-	err := actions.CommitStaged(ctx, dEnv, msg, t, apr.Contains(allowEmptyFlag))
+	if apr.Contains(forceConflictsFlag) {
+		if _, err := actions.StageTablesInConflict(ctx, dEnv); err != nil {
+			return HandleVErrAndExitCode(errhand.BuildDError("error: failed to stage the tables in conflict").AddCause(err).Build(), usage)
+		}
+	}
+
+	var err error
+	if apr.Contains(amendFlag) {
+		_, err = actions.AmendCommit(ctx, dEnv, msg, t, apr.Contains(forceFlag))
+	} else {
+		err = actions.CommitStaged(ctx, dEnv, msg, t, apr.Contains(allowEmptyFlag))
+	}
+
 	if err == nil {
 		// if the commit was successful, print it out using the log command
 		return LogCmd{}.Exec(ctx, "log", []string{"-n=1"}, dEnv)
@@ -164,10 +200,40 @@ func handleCommitErr(ctx context.Context, dEnv *env.DoltEnv, err error, usage cl
 		return HandleVErrAndExitCode(bdr.Build(), usage)
 	}
 
+	if err == actions.ErrAmendMergeActive {
+		bdr := errhand.BuildDError("error: the last commit cannot be amended during a merge.")
+		bdr.AddDetails("Commit the merge, or abort it with 'dolt merge --abort', before amending.")
+		return HandleVErrAndExitCode(bdr.Build(), usage)
+	}
+
+	if err == actions.ErrAmendPushed {
+		upstream := dEnv.RepoState.Branches[dEnv.RepoState.CWBHeadRef().GetPath()]
+		bdr := errhand.BuildDError("error: the last commit has been pushed to '%s/%s'.", upstream.Remote, upstream.Merge.Ref.GetPath())
+		bdr.AddDetails("Amending it rewrites history that others may have pulled. Use 'dolt commit --amend --force' to amend it anyway.")
+		return HandleVErrAndExitCode(bdr.Build(), usage)
+	}
+
+	if actions.IsConcurrentModification(err) {
+		bdr := errhand.BuildDError("error: concurrent modification. The branch was updated by another writer while committing.").SetCategory(errcat.ConcurrentModification)
+		if tbls := actions.ConcurrentModificationTables(err); len(tbls) > 0 {
+			bdr.AddDetails("The following tables were changed by both this commit and the other writer:")
+			for _, tbl := range tbls {
+				bdr.AddDetails("\t%s", tbl)
+			}
+		}
+		bdr.AddDetails("Your changes are still staged. Merge the new HEAD or reset your changes and try again.")
+
+		return HandleVErrAndExitCode(bdr.Build(), usage)
+	}
+
 	if actions.IsNothingStaged(err) {
-		notStagedTbls := actions.NothingStagedTblDiffs(err)
+		notStagedTbls, _ := activeTableDiffs(dEnv, actions.NothingStagedTblDiffs(err), false)
+		if kept, _, err := removeIgnoredTableDiffs(ctx, dEnv, notStagedTbls); err == nil {
+			notStagedTbls = kept
+		}
+
 		notStagedDocs := actions.NothingStagedDocsDiffs(err)
-		n := printDiffsNotStaged(ctx, dEnv, cli.CliOut, notStagedTbls, notStagedDocs, false, 0, []string{})
+		n := printDiffsNotStaged(ctx, dEnv, cli.CliOut, notStagedTbls, notStagedDocs, false, 0, []string{}, nil)
 
 		if n == 0 {
 			bdr := errhand.BuildDError(`no changes added to commit (use "dolt add")`)
@@ -179,20 +245,95 @@ func handleCommitErr(ctx context.Context, dEnv *env.DoltEnv, err error, usage cl
 	return HandleVErrAndExitCode(verr, usage)
 }
 
-func getCommitMessageFromEditor(ctx context.Context, dEnv *env.DoltEnv) string {
-	var finalMsg string
-	initialMsg := buildInitalCommitMsg(ctx, dEnv)
-	backupEd := "vim"
-	if ed, edSet := os.LookupEnv("EDITOR"); edSet {
-		backupEd = ed
+// getCommitMessageFromEditor opens the editor to get the message of a commit. When |amend| is true the editor starts
+// with the message of the commit being amended rather than the commit template.
+func getCommitMessageFromEditor(ctx context.Context, dEnv *env.DoltEnv, amend bool) (string, errhand.VerboseError) {
+	var initialMsg string
+	if amend {
+		msg, err := headCommitMessage(ctx, dEnv)
+
+		if err != nil {
+			return "", errhand.BuildDError("error: failed to read the last commit").AddCause(err).Build()
+		}
+
+		initialMsg = msg + "\n"
+	} else {
+		template, err := readCommitTemplate(dEnv)
+
+		if err != nil {
+			return "", errhand.BuildDError("error: failed to read the commit template").AddCause(err).Build()
+		}
+
+		initialMsg = template
 	}
-	editorStr := dEnv.Config.GetStringOrDefault(env.DoltEditor, backupEd)
 
+	initialMsg += buildInitalCommitMsg(ctx, dEnv)
+	editorStr := dEnv.Config.GetStringOrDefault(env.DoltEditor, editor.DefaultEditor())
+
+	var commitMsg string
+	var err error
 	cli.ExecuteWithStdioRestored(func() {
-		commitMsg, _ := editor.OpenCommitEditor(*editorStr, initialMsg)
-		finalMsg = parseCommitMessage(commitMsg)
+		commitMsg, err = editor.OpenCommitEditor(*editorStr, initialMsg)
 	})
-	return finalMsg
+
+	if err != nil {
+		bdr := errhand.BuildDError("error: failed to get a commit message from the editor '%s'", *editorStr)
+		bdr.AddDetails("Use -m to provide the message on the command line, or configure an editor with:")
+		bdr.AddDetails("dolt config [-global|local] -add %s:\"EDITOR\"", env.DoltEditor)
+		return "", bdr.AddCause(err).Build()
+	}
+
+	return parseCommitMessage(commitMsg), nil
+}
+
+// headCommitMessage returns the message of the head commit of the current branch.
+func headCommitMessage(ctx context.Context, dEnv *env.DoltEnv) (string, error) {
+	cm, err := dEnv.DoltDB.Resolve(ctx, dEnv.RepoState.CWBHeadSpec())
+
+	if err != nil {
+		return "", err
+	}
+
+	meta, err := cm.GetCommitMeta()
+
+	if err != nil {
+		return "", err
+	}
+
+	return meta.Description, nil
+}
+
+// readCommitTemplate returns the contents of the file configured with commit.template, or an empty string if no
+// template is configured. A leading ~ in the path refers to the user's home directory.
+func readCommitTemplate(dEnv *env.DoltEnv) (string, error) {
+	path := *dEnv.Config.GetStringOrDefault(env.CommitTemplate, "")
+
+	if path == "" {
+		return "", nil
+	}
+
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, `~\`) {
+		homeDir, err := env.GetCurrentUserHomeDir()
+
+		if err != nil {
+			return "", err
+		}
+
+		path = filepath.Join(homeDir, path[1:])
+	}
+
+	data, err := dEnv.FS.ReadFile(path)
+
+	if err != nil {
+		return "", err
+	}
+
+	template := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if !strings.HasSuffix(template, "\n") {
+		template += "\n"
+	}
+
+	return template, nil
 }
 
 func buildInitalCommitMsg(ctx context.Context, dEnv *env.DoltEnv) string {
@@ -201,6 +342,10 @@ func buildInitalCommitMsg(ctx context.Context, dEnv *env.DoltEnv) string {
 
 	currBranch := dEnv.RepoState.CWBHeadRef()
 	stagedTblDiffs, notStagedTblDiffs, _ := diff.GetTableDiffs(ctx, dEnv)
+	notStagedTblDiffs, _ = activeTableDiffs(dEnv, notStagedTblDiffs, false)
+	if kept, _, err := removeIgnoredTableDiffs(ctx, dEnv, notStagedTblDiffs); err == nil {
+		notStagedTblDiffs = kept
+	}
 
 	workingTblsInConflict, _, _, err := merge.GetTablesInConflict(ctx, dEnv)
 	if err != nil {
@@ -210,8 +355,8 @@ func buildInitalCommitMsg(ctx context.Context, dEnv *env.DoltEnv) string {
 	stagedDocDiffs, notStagedDocDiffs, _ := diff.GetDocDiffs(ctx, dEnv)
 
 	buf := bytes.NewBuffer([]byte{})
-	n := printStagedDiffs(buf, stagedTblDiffs, stagedDocDiffs, true)
-	n = printDiffsNotStaged(ctx, dEnv, buf, notStagedTblDiffs, notStagedDocDiffs, true, n, workingTblsInConflict)
+	n := printStagedDiffs(buf, stagedTblDiffs, stagedDocDiffs, true, nil)
+	n = printDiffsNotStaged(ctx, dEnv, buf, notStagedTblDiffs, notStagedDocDiffs, true, n, workingTblsInConflict, nil)
 
 	initialCommitMessage := "\n" + "# Please enter the commit message for your changes. Lines starting" + "\n" +
 		"# with '#' will be ignored, and an empty message aborts the commit." + "\n# On branch " + currBranch.GetPath() + "\n#" + "\n"
@@ -235,5 +380,5 @@ func parseCommitMessage(cm string) string {
 		}
 		filtered = append(filtered, line)
 	}
-	return strings.Join(filtered, "\n")
+	return strings.TrimSpace(strings.Join(filtered, "\n"))
 }
@@ -53,3 +53,24 @@ func TestParseDate(t *testing.T) {
 		})
 	}
 }
+
+func TestParseCommitMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		editMsg  string
+		expected string
+	}{
+		{"empty", "", ""},
+		{"only comments", "\n# Please enter the commit message\n#\n# On branch master\n", ""},
+		{"only whitespace", "  \n\t\n# comment\n\n", ""},
+		{"message", "add table\n# comment\n", "add table"},
+		{"multiline", "summary\n\n# comment\nbody line\n", "summary\n\nbody line"},
+		{"template with comments", "# template hint\nticket: 123\n\n# On branch master\n", "ticket: 123"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, parseCommitMessage(test.editMsg))
+		})
+	}
+}
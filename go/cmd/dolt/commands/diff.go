@@ -36,15 +36,19 @@ import (
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/rowconv"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sql"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/pipeline"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/untyped"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/untyped/blobprinter"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/untyped/fwt"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/untyped/nullprinter"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/tablediff"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/iohelp"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/mathutil"
 	"github.com/liquidata-inc/dolt/go/store/atomicerr"
+	ndiff "github.com/liquidata-inc/dolt/go/store/diff"
 	"github.com/liquidata-inc/dolt/go/store/hash"
 	"github.com/liquidata-inc/dolt/go/store/types"
 )
@@ -62,12 +66,23 @@ const (
 	TabularDiffOutput diffOutput = 1
 	SQLDiffOutput     diffOutput = 2
 
-	DataFlag    = "data"
-	SchemaFlag  = "schema"
-	SummaryFlag = "summary"
-	whereParam  = "where"
-	limitParam  = "limit"
-	SQLFlag     = "sql"
+	DataFlag       = "data"
+	SchemaFlag     = "schema"
+	DataOnlyFlag   = "data-only"
+	SchemaOnlyFlag = "schema-only"
+	StatFlag       = "stat"
+	SummaryFlag    = "summary"
+	ExitCodeFlag   = "exit-code"
+	whereParam     = "where"
+	limitParam     = "limit"
+	SQLFlag        = "sql"
+	ShowBinaryFlag = "show-binary"
+)
+
+const (
+	// diffExitCodeErr is the exit code of a diff run with --exit-code which fails, so that it can be told apart from a
+	// diff which found differences.
+	diffExitCodeErr = 2
 )
 
 type DiffSink interface {
@@ -90,9 +105,21 @@ Show changes between the working and staged tables, changes between the working
 {{.EmphasisLeft}}dolt diff [--options] <commit> <commit> [<tables>...]{{.EmphasisRight}}
    This is to view the changes between two arbitrary {{.EmphasisLeft}}commit{{.EmphasisRight}}.
 
+When the primary key of a table changes, as it does with {{.EmphasisLeft}}ALTER TABLE t DROP PRIMARY KEY, ADD PRIMARY KEY (...){{.EmphasisRight}}, every row of the table has a new key, so the diff shows each row as removed with its old key and added with its new key. Rows aren't matched up across the change. The columns keep their identities, so the schema diff shows the new key, and {{.EmphasisLeft}}--sql{{.EmphasisRight}} output changes the key before removing and adding the rows.
+
+Changes to views and triggers are shown as diffs of their {{.EmphasisLeft}}CREATE VIEW{{.EmphasisRight}} and {{.EmphasisLeft}}CREATE TRIGGER{{.EmphasisRight}} statements along with the other schema changes.
+
 The diffs displayed can be limited to show the first N by providing the parameter {{.EmphasisLeft}}--limit N{{.EmphasisRight}} where {{.EmphasisLeft}}N{{.EmphasisRight}} is the number of diffs to display.
 
-In order to filter which diffs are displayed {{.EmphasisLeft}}--where key=value{{.EmphasisRight}} can be used.  The key in this case would be either {{.EmphasisLeft}}to_COLUMN_NAME{{.EmphasisRight}} or {{.EmphasisLeft}}from_COLUMN_NAME{{.EmphasisRight}}. where {{.EmphasisLeft}}from_COLUMN_NAME=value{{.EmphasisRight}} would filter based on the original value and {{.EmphasisLeft}}to_COLUMN_NAME{{.EmphasisRight}} would select based on its updated value.
+In order to filter which diffs are displayed {{.EmphasisLeft}}--where <predicate>{{.EmphasisRight}} can be used, where the predicate is a SQL expression made up of comparisons combined with {{.EmphasisLeft}}AND{{.EmphasisRight}} and {{.EmphasisLeft}}OR{{.EmphasisRight}}, such as {{.EmphasisLeft}}--where "pk >= 10 AND pk < 20"{{.EmphasisRight}}.  Columns can be referred to as either {{.EmphasisLeft}}to_COLUMN_NAME{{.EmphasisRight}} or {{.EmphasisLeft}}from_COLUMN_NAME{{.EmphasisRight}}, where {{.EmphasisLeft}}from_COLUMN_NAME=value{{.EmphasisRight}} would filter based on the original value and {{.EmphasisLeft}}to_COLUMN_NAME{{.EmphasisRight}} would select based on its updated value.  A column referred to by its name alone matches a row if either its original or updated value satisfies the predicate.
+
+Comparisons of the first primary key column with literal values limit the range of keys which are diffed, so only the parts of a table containing those keys are compared.  Predicates on other columns are applied to each changed row, so they still require walking all of the changed rows.  The {{.EmphasisLeft}}--where{{.EmphasisRight}} parameter also applies to {{.EmphasisLeft}}--summary{{.EmphasisRight}}, which reports how many of the changed rows in the diffed key ranges were filtered out.
+
+{{.EmphasisLeft}}--schema-only{{.EmphasisRight}} limits the diff to changes to the schemas of tables, including tables which were added or dropped, and to views and triggers. It compares tables by their schemas alone and never reads any rows, so it is fast however much data has changed. {{.EmphasisLeft}}--data-only{{.EmphasisRight}} limits the diff to changes to the rows of tables. Either can be combined with {{.EmphasisLeft}}--stat{{.EmphasisRight}}, which prints how many columns or rows were changed in each table instead of the changes themselves.
+
+With {{.EmphasisLeft}}--exit-code{{.EmphasisRight}} the command exits with 1 if there are differences of the kinds selected, 0 if there are none, and 2 if the diff fails, like {{.EmphasisLeft}}git diff --exit-code{{.EmphasisRight}}. A table has data differences when its rows differ in any way, so {{.EmphasisLeft}}--where{{.EmphasisRight}} and {{.EmphasisLeft}}--limit{{.EmphasisRight}} don't change the exit code. For example, a CI build can fail when a branch changes any schema with:
+
+{{.EmphasisLeft}}dolt diff --schema-only --exit-code master my-branch{{.EmphasisRight}}
 `,
 	Synopsis: []string{
 		`[options] [{{.LessThan}}commit{{.GreaterThan}}] [{{.LessThan}}tables{{.GreaterThan}}...]`,
@@ -101,10 +128,12 @@ In order to filter which diffs are displayed {{.EmphasisLeft}}--where key=value{
 }
 
 type diffArgs struct {
-	diffParts  diffPart
-	diffOutput diffOutput
-	limit      int
-	where      string
+	diffParts     diffPart
+	diffOutput    diffOutput
+	limit         int
+	where         string
+	includeSparse bool
+	showBinary    bool
 }
 
 type DiffCmd struct{}
@@ -132,12 +161,18 @@ func (cmd DiffCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) e
 
 func (cmd DiffCmd) createArgParser() *argparser.ArgParser {
 	ap := argparser.NewArgParser()
-	ap.SupportsFlag(DataFlag, "d", "Show only the data changes, do not show the schema changes (Both shown by default).")
-	ap.SupportsFlag(SchemaFlag, "s", "Show only the schema changes, do not show the data changes (Both shown by default).")
-	ap.SupportsFlag(SummaryFlag, "", "Show summary of data changes")
+	ap.SupportsFlag(DataOnlyFlag, "d", "Show only the data changes, do not show the schema changes (Both shown by default).")
+	ap.SupportsFlag(SchemaOnlyFlag, "s", "Show only the schema changes, do not show the data changes (Both shown by default). No rows are read.")
+	ap.SupportsFlag(DataFlag, "", "Same as {{.EmphasisLeft}}--data-only{{.EmphasisRight}}.")
+	ap.SupportsFlag(SchemaFlag, "", "Same as {{.EmphasisLeft}}--schema-only{{.EmphasisRight}}.")
+	ap.SupportsFlag(StatFlag, "", "Show the number of columns and rows changed in each table instead of the changes.")
+	ap.SupportsFlag(SummaryFlag, "", "Show summary of data changes. Same as {{.EmphasisLeft}}--data-only --stat{{.EmphasisRight}}.")
+	ap.SupportsFlag(ExitCodeFlag, "", "Exit with 1 if there are differences and 0 if there are none.")
 	ap.SupportsFlag(SQLFlag, "q", "Output diff as a SQL patch file of {{.EmphasisLeft}}INSERT{{.EmphasisRight}} / {{.EmphasisLeft}}UPDATE{{.EmphasisRight}} / {{.EmphasisLeft}}DELETE{{.EmphasisRight}} statements")
-	ap.SupportsString(whereParam, "", "column", "filters columns based on values in the diff.  See {{.EmphasisLeft}}dolt diff --help{{.EmphasisRight}} for details.")
+	ap.SupportsString(whereParam, "", "predicate", "filters rows based on values in the diff.  See {{.EmphasisLeft}}dolt diff --help{{.EmphasisRight}} for details.")
 	ap.SupportsInt(limitParam, "", "record_count", "limits to the first N diffs.")
+	ap.SupportsFlag(ShowBinaryFlag, "", "Print binary values in full rather than their size and hash when they don't look like text.")
+	SupportsIncludeSparse(ap)
 	return ap
 }
 
@@ -147,13 +182,25 @@ func (cmd DiffCmd) Exec(ctx context.Context, commandStr string, args []string, d
 	help, _ := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, diffDocs, ap))
 	apr := cli.ParseArgs(ap, args, help)
 
+	errExitCode := 1
+	if apr.Contains(ExitCodeFlag) {
+		errExitCode = diffExitCodeErr
+	}
+
+	dataOnly := apr.Contains(DataOnlyFlag) || apr.Contains(DataFlag)
+	schemaOnly := apr.Contains(SchemaOnlyFlag) || apr.Contains(SchemaFlag)
+
 	diffParts := SchemaAndDataDiff
-	if apr.Contains(DataFlag) && !apr.Contains(SchemaFlag) {
+	if dataOnly && !schemaOnly {
 		diffParts = DataOnlyDiff
-	} else if apr.Contains(SchemaFlag) && !apr.Contains(DataFlag) {
+	} else if schemaOnly && !dataOnly {
 		diffParts = SchemaOnlyDiff
 	}
 
+	if apr.Contains(StatFlag) {
+		diffParts |= Summary
+	}
+
 	diffOutput := TabularDiffOutput
 	if apr.Contains(SQLFlag) {
 		diffOutput = SQLDiffOutput
@@ -162,12 +209,12 @@ func (cmd DiffCmd) Exec(ctx context.Context, commandStr string, args []string, d
 	summary := apr.Contains(SummaryFlag)
 
 	if summary {
-		if apr.Contains(SchemaFlag) || apr.Contains(DataFlag) {
+		if schemaOnly || dataOnly {
 			cli.PrintErrln("Invalid Arguments: --summary cannot be combined with --schema or --data")
-			return 1
+			return errExitCode
 		}
 
-		diffParts = Summary
+		diffParts = DataOnlyDiff | Summary
 	}
 
 	if apr.ContainsArg(doltdb.DocTableName) {
@@ -178,15 +225,25 @@ func (cmd DiffCmd) Exec(ctx context.Context, commandStr string, args []string, d
 
 	// default value of 0 used to signal no limit.
 	limit, _ := apr.GetInt(limitParam)
+	includeSparse := apr.Contains(IncludeSparseFlag)
+
+	if verr == nil {
+		verr = CheckSparseTablesWithVErr(dEnv, tables, includeSparse)
+	}
 
+	changed := false
 	if verr == nil {
 		whereClause := apr.GetValueOrDefault(whereParam, "")
 
-		verr = diffRoots(ctx, r1, r2, tables, docs, dEnv, &diffArgs{diffParts, diffOutput, limit, whereClause})
+		changed, verr = diffRoots(ctx, r1, r2, tables, docs, dEnv, &diffArgs{diffParts, diffOutput, limit, whereClause, includeSparse, apr.Contains(ShowBinaryFlag)})
 	}
 
 	if verr != nil {
 		cli.PrintErrln(verr.Verbose())
+		return errExitCode
+	}
+
+	if changed && apr.Contains(ExitCodeFlag) {
 		return 1
 	}
 
@@ -242,29 +299,42 @@ func getRoots(ctx context.Context, args []string, dEnv *env.DoltEnv) (r1, r2 *do
 	}
 
 	for ; i < len(args); i++ {
-		tbl := args[i]
+		tbl, verr := resolveDiffTableName(ctx, roots, args[i])
 
-		has0, err := roots[0].HasTable(ctx, tbl)
-
-		if err != nil {
-			return nil, nil, nil, nil, errhand.BuildDError("error: failed to read tables").AddCause(err).Build()
+		if verr != nil {
+			return nil, nil, nil, nil, verr
 		}
 
-		has1, err := roots[1].HasTable(ctx, tbl)
+		tables = append(tables, tbl)
+	}
+
+	return roots[0], roots[1], tables, docDetails, nil
+}
+
+// resolveDiffTableName returns the name of the table |tblName| refers to in either of |roots|. As in SQL, table names
+// are case insensitive, but a table whose name matches exactly is preferred.
+func resolveDiffTableName(ctx context.Context, roots []*doltdb.RootValue, tblName string) (string, errhand.VerboseError) {
+	for _, root := range roots {
+		has, err := root.HasTable(ctx, tblName)
 
 		if err != nil {
-			return nil, nil, nil, nil, errhand.BuildDError("error: failed to read tables").AddCause(err).Build()
+			return "", errhand.BuildDError("error: failed to read tables").AddCause(err).Build()
+		} else if has {
+			return tblName, nil
 		}
+	}
 
-		if !(has0 || has1) {
-			verr := errhand.BuildDError("error: Unknown table: '%s'", tbl).Build()
-			return nil, nil, nil, nil, verr
-		}
+	for _, root := range roots {
+		_, name, ok, err := root.GetTableInsensitive(ctx, tblName)
 
-		tables = append(tables, tbl)
+		if err != nil {
+			return "", errhand.BuildDError("error: failed to read tables").AddCause(err).Build()
+		} else if ok {
+			return name, nil
+		}
 	}
 
-	return roots[0], roots[1], tables, docDetails, nil
+	return "", errhand.BuildDError("error: Unknown table: '%s'", tblName).Build()
 }
 
 func getRootForCommitSpecStr(ctx context.Context, csStr string, dEnv *env.DoltEnv) (string, *doltdb.RootValue, errhand.VerboseError) {
@@ -296,35 +366,46 @@ func getRootForCommitSpecStr(ctx context.Context, csStr string, dEnv *env.DoltEn
 	return h.String(), r, nil
 }
 
-func diffRoots(ctx context.Context, r1, r2 *doltdb.RootValue, tblNames []string, docDetails []doltdb.DocDetails, dEnv *env.DoltEnv, dArgs *diffArgs) errhand.VerboseError {
+// diffRoots prints the differences between the tables of |r1| and |r2| selected by |dArgs|. It returns whether there
+// were any differences of the kinds selected.
+func diffRoots(ctx context.Context, r1, r2 *doltdb.RootValue, tblNames []string, docDetails []doltdb.DocDetails, dEnv *env.DoltEnv, dArgs *diffArgs) (bool, errhand.VerboseError) {
 	var err error
 	if len(tblNames) == 0 {
 		tblNames, err = doltdb.UnionTableNames(ctx, r1, r2)
+
+		if err == nil && !dArgs.includeSparse {
+			tblNames, _ = dEnv.RepoState.SplitSparseTables(tblNames)
+		}
 	}
 
 	if err != nil {
-		return errhand.BuildDError("error: unable to read tables").AddCause(err).Build()
+		return false, errhand.BuildDError("error: unable to read tables").AddCause(err).Build()
 	}
 
 	if dArgs.diffOutput == SQLDiffOutput {
 		err = diff.PrintSqlTableDiffs(ctx, r1, r2, iohelp.NopWrCloser(cli.CliOut))
 
 		if err != nil {
-			return errhand.BuildDError("error: unable to diff tables").AddCause(err).Build()
+			return false, errhand.BuildDError("error: unable to diff tables").AddCause(err).Build()
 		}
 	}
 
+	schemaDiff := dArgs.diffParts&SchemaOnlyDiff != 0
+	dataDiff := dArgs.diffParts&DataOnlyDiff != 0
+	stat := dArgs.diffParts&Summary != 0
+
+	changed := false
 	for _, tblName := range tblNames {
 		tbl1, ok1, err := r1.GetTable(ctx, tblName)
 
 		if err != nil {
-			return errhand.BuildDError("error: failed to get table '%s'", tblName).AddCause(err).Build()
+			return false, errhand.BuildDError("error: failed to get table '%s'", tblName).AddCause(err).Build()
 		}
 
 		tbl2, ok2, err := r2.GetTable(ctx, tblName)
 
 		if err != nil {
-			return errhand.BuildDError("error: failed to get table '%s'", tblName).AddCause(err).Build()
+			return false, errhand.BuildDError("error: failed to get table '%s'", tblName).AddCause(err).Build()
 		}
 
 		if !ok1 && !ok2 {
@@ -335,13 +416,13 @@ func diffRoots(ctx context.Context, r1, r2 *doltdb.RootValue, tblNames []string,
 			h1, err := tbl1.HashOf()
 
 			if err != nil {
-				return errhand.BuildDError("error: failed to get table hash").Build()
+				return false, errhand.BuildDError("error: failed to get table hash").Build()
 			}
 
 			h2, err := tbl2.HashOf()
 
 			if err != nil {
-				return errhand.BuildDError("error: failed to get table hash").Build()
+				return false, errhand.BuildDError("error: failed to get table hash").Build()
 			}
 
 			if h1 == h2 {
@@ -349,92 +430,184 @@ func diffRoots(ctx context.Context, r1, r2 *doltdb.RootValue, tblNames []string,
 			}
 		}
 
+		if tblName == doltdb.SchemasTableName && dArgs.diffOutput == TabularDiffOutput {
+			if schemaDiff {
+				fragmentsChanged, verr := diffSchemaFragments(ctx, tbl1, tbl2, stat)
+
+				if verr != nil {
+					return false, verr
+				}
+
+				changed = changed || fragmentsChanged
+			}
+
+			continue
+		}
+
+		// a schema diff skips the tables whose schemas are unchanged without reading their rows
+		if !dataDiff {
+			if tblName == doltdb.DocTableName {
+				continue
+			}
+
+			if tbl1 != nil && tbl2 != nil {
+				sameSch, err := tbl1.HasTheSameSchema(tbl2)
+
+				if err != nil {
+					return false, errhand.BuildDError("error: failed to get schema ref").AddCause(err).Build()
+				}
+
+				if sameSch {
+					continue
+				}
+			}
+		}
+
 		if dArgs.diffOutput == TabularDiffOutput {
 			printTableDiffSummary(ctx, dEnv, tblName, tbl1, tbl2, docDetails)
 		}
 
 		if tbl1 == nil || tbl2 == nil || tblName == doltdb.DocTableName {
+			tblChanged, err := addedOrDroppedTableChanged(ctx, tblName, tbl1, tbl2, dArgs)
+
+			if err != nil {
+				return false, errhand.BuildDError("error: failed to get row data").AddCause(err).Build()
+			}
+
+			changed = changed || tblChanged
 			continue
 		}
 
-		var sch1 schema.Schema
-		var sch2 schema.Schema
-		var sch1Hash hash.Hash
-		var sch2Hash hash.Hash
-		rowData1, err := types.NewMap(ctx, dEnv.DoltDB.ValueReadWriter())
+		sch1, err := tbl1.GetSchema(ctx)
 
 		if err != nil {
-			return errhand.BuildDError("").AddCause(err).Build()
+			return false, errhand.BuildDError("error: failed to get schema").AddCause(err).Build()
 		}
 
-		rowData2, err := types.NewMap(ctx, dEnv.DoltDB.ValueReadWriter())
+		sch2, err := tbl2.GetSchema(ctx)
 
 		if err != nil {
-			return errhand.BuildDError("").AddCause(err).Build()
+			return false, errhand.BuildDError("error: failed to get schema").AddCause(err).Build()
 		}
 
-		if ok1 {
-			sch1, err = tbl1.GetSchema(ctx)
+		if schemaDiff {
+			sameSch, err := tbl1.HasTheSameSchema(tbl2)
 
 			if err != nil {
-				return errhand.BuildDError("error: failed to get schema").AddCause(err).Build()
+				return false, errhand.BuildDError("error: failed to get schema ref").AddCause(err).Build()
 			}
 
-			schRef, err := tbl1.GetSchemaRef()
+			if !sameSch {
+				changed = true
 
-			if err != nil {
-				return errhand.BuildDError("error: failed to get schema ref").AddCause(err).Build()
+				var verr errhand.VerboseError
+				if stat {
+					verr = schemaSummary(sch2, sch1)
+				} else {
+					verr = diffSchemas(tblName, sch2, sch1, dArgs)
+				}
+
+				if verr != nil {
+					return false, verr
+				}
 			}
+		}
 
-			sch1Hash = schRef.TargetHash()
-			rowData1, err = tbl1.GetRowData(ctx)
+		if dataDiff {
+			sameRows, err := tbl1.HasTheSameRows(tbl2)
 
 			if err != nil {
-				return errhand.BuildDError("error: failed to get row data").AddCause(err).Build()
+				return false, errhand.BuildDError("error: failed to get row data").AddCause(err).Build()
 			}
-		}
 
-		if ok2 {
-			sch2, err = tbl2.GetSchema(ctx)
+			changed = changed || !sameRows
+
+			rowData1, err := tbl1.GetRowData(ctx)
 
 			if err != nil {
-				return errhand.BuildDError("error: failed to get schema").AddCause(err).Build()
+				return false, errhand.BuildDError("error: failed to get row data").AddCause(err).Build()
 			}
 
-			schRef, err := tbl2.GetSchemaRef()
+			rowData2, err := tbl2.GetRowData(ctx)
 
 			if err != nil {
-				return errhand.BuildDError("error: failed to get schema ref").AddCause(err).Build()
+				return false, errhand.BuildDError("error: failed to get row data").AddCause(err).Build()
 			}
 
-			sch2Hash = schRef.TargetHash()
-			rowData2, err = tbl2.GetRowData(ctx)
+			var verr errhand.VerboseError
+			if stat {
+				verr = diffSummary(ctx, rowData1, rowData2, sch1, sch2, dArgs)
+			} else {
+				verr = diffRows(ctx, rowData1, rowData2, sch1, sch2, dArgs, tblName)
+			}
 
-			if err != nil {
-				return errhand.BuildDError("error: failed to get row data").AddCause(err).Build()
+			if verr != nil {
+				return false, verr
 			}
 		}
+	}
 
-		var verr errhand.VerboseError
+	return changed, nil
+}
 
-		if dArgs.diffParts&Summary != 0 {
-			colLen := sch2.GetAllCols().Size()
-			verr = diffSummary(ctx, rowData1, rowData2, colLen)
-		}
+// addedOrDroppedTableChanged returns whether a table which only exists in one of two roots, or the docs table, has
+// differences of the kinds selected by |dArgs|. An added or dropped table is a schema difference, and a data
+// difference when it has rows.
+func addedOrDroppedTableChanged(ctx context.Context, tblName string, tbl1, tbl2 *doltdb.Table, dArgs *diffArgs) (bool, error) {
+	if tblName == doltdb.DocTableName {
+		return dArgs.diffParts&DataOnlyDiff != 0, nil
+	}
 
-		if dArgs.diffParts&SchemaOnlyDiff != 0 && sch1Hash != sch2Hash {
-			verr = diffSchemas(tblName, sch2, sch1, dArgs)
-		}
+	if dArgs.diffParts&SchemaOnlyDiff != 0 {
+		return true, nil
+	}
+
+	tbl := tbl1
+	if tbl == nil {
+		tbl = tbl2
+	}
+
+	rowData, err := tbl.GetRowData(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	return !rowData.Empty(), nil
+}
 
-		if dArgs.diffParts&DataOnlyDiff != 0 {
-			verr = diffRows(ctx, rowData1, rowData2, sch1, sch2, dArgs, tblName)
+// schemaSummary prints the number of columns added, dropped and modified between two schemas of a table, and whether
+// its primary key or comment changed.
+func schemaSummary(oldSch, newSch schema.Schema) errhand.VerboseError {
+	changes := tablediff.SchemaDiff(oldSch, newSch)
+
+	var added, dropped, modified uint64
+	for _, cd := range changes.Columns {
+		switch cd.Type {
+		case tablediff.Added:
+			added++
+		case tablediff.Removed:
+			dropped++
+		case tablediff.Modified:
+			modified++
 		}
+	}
 
-		if verr != nil {
-			return verr
+	cli.Println(pluralize("Column Added", "Columns Added", added))
+	cli.Println(pluralize("Column Dropped", "Columns Dropped", dropped))
+	cli.Println(pluralize("Column Modified", "Columns Modified", modified))
+
+	for _, idx := range changes.Indexes {
+		if idx.Name == tablediff.PrimaryKeyIndexName && idx.Type == tablediff.Modified {
+			cli.Println("Primary Key Modified")
 		}
 	}
 
+	if changes.CommentChanged() {
+		cli.Println("Table Comment Modified")
+	}
+
+	cli.Println()
 	return nil
 }
 
@@ -442,17 +615,22 @@ func diffSchemas(tableName string, sch1 schema.Schema, sch2 schema.Schema, dArgs
 	diffs, unionTags := diff.DiffSchemas(sch1, sch2)
 
 	if dArgs.diffOutput == TabularDiffOutput {
-		if verr := tabularSchemaDiff(tableName, unionTags, diffs); verr != nil {
+		if verr := tabularSchemaDiff(tableName, unionTags, diffs, sch1.GetComment(), sch2.GetComment()); verr != nil {
 			return verr
 		}
 	} else {
-		sqlSchemaDiff(tableName, unionTags, diffs)
+		sqlSchemaDiff(tableName, unionTags, diffs, sch1.GetComment(), sch2.GetComment())
+
+		// the rows removed and added by the diff are keyed by the new primary key, so it's changed first
+		if !reflect.DeepEqual(sch1.GetPKCols().Tags, sch2.GetPKCols().Tags) {
+			cli.Println(sql.AlterTablePrimaryKeyStmt(tableName, sch2.GetPKCols().GetColumnNames()))
+		}
 	}
 
 	return nil
 }
 
-func tabularSchemaDiff(tableName string, tags []uint64, diffs map[uint64]diff.SchemaDifference) errhand.VerboseError {
+func tabularSchemaDiff(tableName string, tags []uint64, diffs map[uint64]diff.SchemaDifference, oldComment, newComment string) errhand.VerboseError {
 	cli.Println("  CREATE TABLE", tableName, "(")
 
 	oldPks := make([]string, 0)
@@ -509,8 +687,8 @@ func tabularSchemaDiff(tableName string, tags []uint64, diffs map[uint64]diff.Sc
 				}
 				cli.Println(sql.FmtCol(4, 0, 0, *dff.New))
 			} else {
-				cli.Println("< " + sql.FmtColWithNameAndType(2, nameLen, typeLen, n0, t0, *dff.Old))
-				cli.Println("> " + sql.FmtColWithNameAndType(2, nameLen, typeLen, n1, t1, *dff.New))
+				cli.Println("< " + sql.FmtColWithNameAndType(2, nameLen, typeLen, n0, t0, *dff.Old) + coldMarker(*dff.Old, *dff.New))
+				cli.Println("> " + sql.FmtColWithNameAndType(2, nameLen, typeLen, n1, t1, *dff.New) + coldMarker(*dff.New, *dff.Old))
 			}
 		}
 	}
@@ -525,12 +703,36 @@ func tabularSchemaDiff(tableName string, tags []uint64, diffs map[uint64]diff.Sc
 		cli.Print(sql.FmtColPrimaryKey(4, oldPKStr))
 	}
 
-	cli.Println("  );")
+	if oldComment != newComment {
+		cli.Println("< " + color.YellowString(tableCommentLine(oldComment)))
+		cli.Println("> " + color.YellowString(tableCommentLine(newComment)))
+	} else {
+		cli.Println("  " + tableCommentLine(newComment))
+	}
+
 	cli.Println()
 	return nil
 }
 
-func sqlSchemaDiff(tableName string, tags []uint64, diffs map[uint64]diff.SchemaDifference) {
+// tableCommentLine returns the closing line of a CREATE TABLE statement, which holds the table comment if there is one.
+func tableCommentLine(comment string) string {
+	if comment == "" {
+		return ");"
+	}
+
+	return ") COMMENT=" + sql.QuoteString(comment) + ";"
+}
+
+// coldMarker returns the marker shown after a column which became cold or stopped being cold, when |col| is the cold one.
+func coldMarker(col, other schema.Column) string {
+	if col.Cold && !other.Cold {
+		return color.YellowString(" -- cold")
+	}
+
+	return ""
+}
+
+func sqlSchemaDiff(tableName string, tags []uint64, diffs map[uint64]diff.SchemaDifference, oldComment, newComment string) {
 	for _, tag := range tags {
 		dff := diffs[tag]
 		switch dff.DiffType {
@@ -540,9 +742,31 @@ func sqlSchemaDiff(tableName string, tags []uint64, diffs map[uint64]diff.Schema
 		case diff.SchDiffColRemoved:
 			cli.Print(sql.AlterTableDropColStmt(tableName, dff.Old.Name))
 		case diff.SchDiffColModified:
-			cli.Print(sql.AlterTableRenameColStmt(tableName, dff.Old.Name, dff.New.Name))
+			// a column whose only change is its comment is modified, rather than renamed. Whether a column is cold
+			// can't be expressed in SQL. Changes to the primary key, which make its new columns NOT NULL, are made
+			// by a separate statement.
+			oldWithNewComment := *dff.Old
+			oldWithNewComment.Comment = dff.New.Comment
+			oldWithNewComment.Cold = dff.New.Cold
+			oldWithNewComment.IsPartOfPK = dff.New.IsPartOfPK
+
+			if dff.New.IsPartOfPK && !dff.Old.IsPartOfPK {
+				oldWithNewComment.Constraints = dff.New.Constraints
+			}
+
+			if !oldWithNewComment.Equals(*dff.New) {
+				cli.Print(sql.AlterTableRenameColStmt(tableName, dff.Old.Name, dff.New.Name))
+			}
+
+			if dff.Old.Comment != dff.New.Comment {
+				cli.Println(sql.AlterTableModifyColStmt(tableName, sql.FmtCol(0, 0, 0, *dff.New)))
+			}
 		}
 	}
+
+	if oldComment != newComment {
+		cli.Println(sql.AlterTableCommentStmt(tableName, newComment))
+	}
 }
 
 func dumbDownSchema(in schema.Schema) (schema.Schema, error) {
@@ -552,6 +776,7 @@ func dumbDownSchema(in schema.Schema) (schema.Schema, error) {
 	err := allCols.Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
 		col.Name = strconv.FormatUint(tag, 10)
 		col.Constraints = nil
+		col.Cold = false
 		dumbCols = append(dumbCols, col)
 
 		return false, nil
@@ -566,6 +791,31 @@ func dumbDownSchema(in schema.Schema) (schema.Schema, error) {
 	return schema.SchemaFromCols(dumbColColl), nil
 }
 
+// withPrimaryKeyOf returns |sch| with the columns of the primary key of |keySch| as its primary key. Columns of |sch|
+// which aren't in |keySch| aren't part of the key.
+func withPrimaryKeyOf(sch, keySch schema.Schema) (schema.Schema, error) {
+	pkCols := keySch.GetPKCols()
+
+	var cols []schema.Column
+	err := sch.GetAllCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+		_, col.IsPartOfPK = pkCols.GetByTag(tag)
+		cols = append(cols, col)
+		return false, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	colColl, err := schema.NewColCollection(cols...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.SchemaFromCols(colColl), nil
+}
+
 func toNamer(name string) string {
 	return diff.To + "_" + name
 }
@@ -592,11 +842,14 @@ func diffRows(ctx context.Context, newRows, oldRows types.Map, newSch, oldSch sc
 		return verr
 	}
 
-	ad := diff.NewAsyncDiffer(1024)
-	ad.Start(ctx, newRows, oldRows)
-	defer ad.Close()
+	where, err := sqle.ParseDiffWhere(newRows.Format(), oldSch, newSch, joiner.GetSchema(), dArgs.where)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to parse where clause").AddCause(err).SetPrintUsage().Build()
+	}
 
-	src := diff.NewRowDiffSource(ad, joiner)
+	itr := tablediff.NewRowDiffIteratorForRows(ctx, oldRows, newRows, oldSch, newSch, where.KeyRanges)
+	src := diff.NewRowDiffSource(ctx, itr, joiner)
 	defer src.Close()
 
 	oldColNames, verr := mapTagToColName(oldSch, unionSch)
@@ -636,11 +889,19 @@ func diffRows(ctx context.Context, newRows, oldRows types.Map, newSch, oldSch sc
 		return true
 	}
 
-	p, verr := buildPipeline(dArgs, joiner, ds, unionSch, src, sink, badRowCallback)
-	if verr != nil {
-		return verr
+	var filterErr error
+	filter := func(r row.Row) bool {
+		matches, err := where.Matches(ctx, r)
+
+		if err != nil && filterErr == nil {
+			filterErr = err
+		}
+
+		return matches
 	}
 
+	p := buildPipeline(dArgs, filter, ds, unionSch, blobprinter.BlobTags(newSch, oldSch), src, sink, badRowCallback)
+
 	if dArgs.diffOutput != SQLDiffOutput {
 		if schemasEqual {
 			schRow, err := untyped.NewRowFromTaggedStrings(newRows.Format(), unionSch, newColNames)
@@ -677,27 +938,18 @@ func diffRows(ctx context.Context, newRows, oldRows types.Map, newSch, oldSch sc
 		return badRowVErr
 	}
 
+	if filterErr != nil {
+		return errhand.BuildDError("error: failed to apply where clause").AddCause(filterErr).Build()
+	}
+
 	return nil
 }
 
-func buildPipeline(dArgs *diffArgs, joiner *rowconv.Joiner, ds *diff.DiffSplitter, untypedUnionSch schema.Schema, src *diff.RowDiffSource, sink DiffSink, badRowCB pipeline.BadRowCallback) (*pipeline.Pipeline, errhand.VerboseError) {
-	var where FilterFn
+func buildPipeline(dArgs *diffArgs, where FilterFn, ds *diff.DiffSplitter, untypedUnionSch schema.Schema, blobTags map[uint64]bool, src *diff.RowDiffSource, sink DiffSink, badRowCB pipeline.BadRowCallback) *pipeline.Pipeline {
 	var selTrans *SelectTransform
-	where, err := ParseWhere(joiner.GetSchema(), dArgs.where)
-
-	if err != nil {
-		return nil, errhand.BuildDError("error: failed to parse where clause").AddCause(err).SetPrintUsage().Build()
-	}
-
 	transforms := pipeline.NewTransformCollection()
 
-	if where != nil || dArgs.limit != 0 {
-		if where == nil {
-			where = func(r row.Row) bool {
-				return true
-			}
-		}
-
+	if dArgs.where != "" || dArgs.limit != 0 {
 		selTrans = NewSelTrans(where, dArgs.limit)
 		transforms.AppendTransforms(pipeline.NewNamedTransform("select", selTrans.LimitAndFilter))
 	}
@@ -707,6 +959,11 @@ func buildPipeline(dArgs *diffArgs, joiner *rowconv.Joiner, ds *diff.DiffSplitte
 	)
 
 	if dArgs.diffOutput == TabularDiffOutput {
+		if !dArgs.showBinary {
+			blobPrinter := blobprinter.NewBlobPrinter(untypedUnionSch, blobTags)
+			transforms.AppendTransforms(pipeline.NewNamedTransform(blobprinter.BlobPrintingStage, blobPrinter.ProcessRow))
+		}
+
 		nullPrinter := nullprinter.NewNullPrinter(untypedUnionSch)
 		fwtTr := fwt.NewAutoSizingFWTTransformer(untypedUnionSch, fwt.HashFillWhenTooLong, 1000)
 		transforms.AppendTransforms(
@@ -721,7 +978,7 @@ func buildPipeline(dArgs *diffArgs, joiner *rowconv.Joiner, ds *diff.DiffSplitte
 		selTrans.Pipeline = p
 	}
 
-	return p, nil
+	return p
 }
 
 func mapTagToColName(sch, untypedUnionSch schema.Schema) (map[uint64]string, errhand.VerboseError) {
@@ -762,6 +1019,14 @@ func createSplitter(newSch schema.Schema, oldSch schema.Schema, joiner *rowconv.
 			return nil, nil, errhand.BuildDError("").AddCause(err).Build()
 		}
 
+		// the union of the schemas of a table whose primary key changed is keyed by the new key, so that rows of
+		// both schemas can be shown in it
+		dumbOldSch, err = withPrimaryKeyOf(dumbOldSch, dumbNewSch)
+
+		if err != nil {
+			return nil, nil, errhand.BuildDError("").AddCause(err).Build()
+		}
+
 		unionSch, err = untyped.UntypedSchemaUnion(dumbNewSch, dumbOldSch)
 		if err != nil {
 			return nil, nil, errhand.BuildDError("Failed to merge schemas").Build()
@@ -864,6 +1129,37 @@ func printDeletedDoc(bold *color.Color, pk string, lines []string) {
 	printDiffLines(bold, lines)
 }
 
+// diffSchemaFragments prints the changes to the views and triggers stored in the dolt_schemas tables given as text
+// diffs of their CREATE statements, or only the names of the changed views and triggers when |stat| is true. tbl1 is the
+// newer of the two tables, and either may be nil. It returns whether any views or triggers changed.
+func diffSchemaFragments(ctx context.Context, tbl1, tbl2 *doltdb.Table, stat bool) (bool, errhand.VerboseError) {
+	deltas, err := diff.GetSchemaFragmentDeltas(ctx, tbl1, tbl2)
+
+	if err != nil {
+		return false, errhand.BuildDError("error: failed to diff views and triggers").AddCause(err).Build()
+	}
+
+	bold := color.New(color.Bold)
+	for _, delta := range deltas {
+		_, _ = bold.Printf("diff --dolt a/%[1]s b/%[1]s\n", delta.Name)
+
+		if delta.IsAdd() {
+			_, _ = bold.Printf("added %s\n", delta.Type)
+		} else if delta.IsDrop() {
+			_, _ = bold.Printf("deleted %s\n", delta.Type)
+		} else {
+			_, _ = bold.Printf("--- a/%s\n", delta.Name)
+			_, _ = bold.Printf("+++ b/%s\n", delta.Name)
+		}
+
+		if !stat {
+			printDiffLines(bold, textdiff.LineDiffAsLines(delta.OldDefinition, delta.NewDefinition))
+		}
+	}
+
+	return len(deltas) > 0, nil
+}
+
 func printTableDiffSummary(ctx context.Context, dEnv *env.DoltEnv, tblName string, tbl1, tbl2 *doltdb.Table, docDetails []doltdb.DocDetails) {
 	bold := color.New(color.Bold)
 
@@ -896,12 +1192,45 @@ func printTableDiffSummary(ctx context.Context, dEnv *env.DoltEnv, tblName strin
 	}
 }
 
-func diffSummary(ctx context.Context, v1, v2 types.Map, colLen int) errhand.VerboseError {
+func diffSummary(ctx context.Context, v1, v2 types.Map, sch1, sch2 schema.Schema, dArgs *diffArgs) errhand.VerboseError {
+	var keyRanges []diff.KeyRange
+	var filter diff.FilterFunc
+	if dArgs.where != "" {
+		joiner, err := rowconv.NewJoiner(
+			[]rowconv.NamedSchema{
+				{Name: diff.From, Sch: sch2},
+				{Name: diff.To, Sch: sch1},
+			},
+			map[string]rowconv.ColNamingFunc{diff.To: toNamer, diff.From: fromNamer},
+		)
+
+		if err != nil {
+			return errhand.BuildDError("").AddCause(err).Build()
+		}
+
+		where, err := sqle.ParseDiffWhere(v1.Format(), sch2, sch1, joiner.GetSchema(), dArgs.where)
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to parse where clause").AddCause(err).SetPrintUsage().Build()
+		}
+
+		keyRanges = where.KeyRanges
+		filter = func(d *ndiff.Difference) (bool, error) {
+			r, err := diff.JoinDiff(joiner, rowconv.IdentityConverter, rowconv.IdentityConverter, d)
+
+			if err != nil {
+				return false, err
+			}
+
+			return where.Matches(ctx, r)
+		}
+	}
+
 	ae := atomicerr.New()
 	ch := make(chan diff.DiffSummaryProgress)
 	go func() {
 		defer close(ch)
-		err := diff.Summary(ctx, ch, v1, v2)
+		err := diff.FilteredSummary(ctx, ch, v1, v2, keyRanges, filter)
 
 		ae.SetIfError(err)
 	}()
@@ -920,6 +1249,7 @@ func diffSummary(ctx context.Context, v1, v2 types.Map, colLen int) errhand.Verb
 		acc.CellChanges += p.CellChanges
 		acc.NewSize += p.NewSize
 		acc.OldSize += p.OldSize
+		acc.FilteredOut += p.FilteredOut
 
 		if count%10000 == 0 {
 			statusStr := fmt.Sprintf("prev size: %d, new size: %d, adds: %d, deletes: %d, modifications: %d", acc.OldSize, acc.NewSize, acc.Adds, acc.Removes, acc.Changes)
@@ -936,25 +1266,26 @@ func diffSummary(ctx context.Context, v1, v2 types.Map, colLen int) errhand.Verb
 	}
 
 	if acc.NewSize > 0 || acc.OldSize > 0 {
-		formatSummary(acc, colLen)
+		formatSummary(acc, sch2.GetAllCols().Size(), dArgs.where != "")
 	} else {
-		cli.Println("No data changes. See schema changes by using -s or --schema.")
+		cli.Println("No data changes. See schema changes by using -s or --schema-only.")
 	}
 
 	return nil
 }
 
-func formatSummary(acc diff.DiffSummaryProgress, colLen int) {
-	pluralize := func(singular, plural string, n uint64) string {
-		var noun string
-		if n != 1 {
-			noun = plural
-		} else {
-			noun = singular
-		}
-		return fmt.Sprintf("%s %s", humanize.Comma(int64(n)), noun)
+// pluralize returns |n| followed by |singular| or |plural|, depending on |n|.
+func pluralize(singular, plural string, n uint64) string {
+	var noun string
+	if n != 1 {
+		noun = plural
+	} else {
+		noun = singular
 	}
+	return fmt.Sprintf("%s %s", humanize.Comma(int64(n)), noun)
+}
 
+func formatSummary(acc diff.DiffSummaryProgress, colLen int, filtered bool) {
 	rowsUnmodified := uint64(acc.OldSize - acc.Changes - acc.Removes)
 	unmodified := pluralize("Row Unmodified", "Rows Unmodified", rowsUnmodified)
 	insertions := pluralize("Row Added", "Rows Added", acc.Adds)
@@ -967,10 +1298,20 @@ func formatSummary(acc diff.DiffSummaryProgress, colLen int) {
 
 	percentCellsChanged := float64(100*acc.CellChanges) / (float64(acc.OldSize) * float64(colLen))
 
-	cli.Printf("%s (%.2f%%)\n", unmodified, (float64(100*rowsUnmodified) / float64(acc.OldSize)))
+	// the rows outside of the diffed key ranges aren't compared, so the number of unmodified rows isn't known
+	if !filtered {
+		cli.Printf("%s (%.2f%%)\n", unmodified, (float64(100*rowsUnmodified) / float64(acc.OldSize)))
+	}
+
 	cli.Printf("%s (%.2f%%)\n", insertions, (float64(100*acc.Adds) / float64(acc.OldSize)))
 	cli.Printf("%s (%.2f%%)\n", deletions, (float64(100*acc.Removes) / float64(acc.OldSize)))
 	cli.Printf("%s (%.2f%%)\n", changes, (float64(100*acc.Changes) / float64(acc.OldSize)))
 	cli.Printf("%s (%.2f%%)\n", cellChanges, percentCellsChanged)
+
+	if filtered {
+		filteredOut := pluralize("Changed Row Filtered Out", "Changed Rows Filtered Out", acc.FilteredOut)
+		cli.Printf("%s by --where\n", filteredOut)
+	}
+
 	cli.Printf("(%s vs %s)\n\n", oldValues, newValues)
 }
@@ -32,6 +32,8 @@ import (
 
 const (
 	ForceFetchFlag = "force"
+	FetchTagsFlag  = "tags"
+	tableRefParam  = "table-ref"
 )
 
 var fetchDocs = cli.CommandDocumentationContent{
@@ -41,10 +43,15 @@ var fetchDocs = cli.CommandDocumentationContent{
 By default dolt will attempt to fetch from a remote named {{.EmphasisLeft}}origin{{.EmphasisRight}}.  The {{.LessThan}}remote{{.GreaterThan}} parameter allows you to specify the name of a different remote you wish to pull from by the remote's name.
 
 When no refspec(s) are specified on the command line, the fetch_specs for the default remote are used.
+
+The tags on the remote which point at any of the fetched commits, or at their ancestors, are fetched as well. With {{.EmphasisLeft}}--tags{{.EmphasisRight}} every tag on the remote is fetched. A local tag which points at a different commit than the remote tag of the same name is only replaced when {{.EmphasisLeft}}--force{{.EmphasisRight}} is given.
+
+If {{.EmphasisLeft}}--table-ref{{.EmphasisRight}} is given, no refs are fetched. Instead the table state with the given table ref is fetched from the remote, along with all of its rows, and it is pinned as if it was added with {{.EmphasisLeft}}dolt table pin{{.EmphasisRight}}. It can then be exported with {{.EmphasisLeft}}dolt table export --ref{{.EmphasisRight}} or queried using {{.EmphasisLeft}}AS OF{{.EmphasisRight}} without fetching any commits.
 `,
 
 	Synopsis: []string{
-		"[{{.LessThan}}remote{{.GreaterThan}}] [{{.LessThan}}refspec{{.GreaterThan}} ...]",
+		"[--tags] [{{.LessThan}}remote{{.GreaterThan}}] [{{.LessThan}}refspec{{.GreaterThan}} ...]",
+		"--table-ref {{.LessThan}}table ref{{.GreaterThan}} [{{.LessThan}}remote{{.GreaterThan}}]",
 	},
 }
 
@@ -55,6 +62,11 @@ func (cmd FetchCmd) Name() string {
 	return "fetch"
 }
 
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd FetchCmd) LocksRepo() bool {
+	return true
+}
+
 // Description returns a description of the command
 func (cmd FetchCmd) Description() string {
 	return "Update the database from a remote data repository."
@@ -74,6 +86,8 @@ func (cmd FetchCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string)
 func (cmd FetchCmd) createArgParser() *argparser.ArgParser {
 	ap := argparser.NewArgParser()
 	ap.SupportsFlag(ForceFetchFlag, "f", "Update refs to remote branches with the current state of the remote, overwriting any conflicting history.")
+	ap.SupportsFlag(FetchTagsFlag, "", "Fetch every tag from the remote, rather than only the tags reachable from the fetched commits.")
+	ap.SupportsString(tableRefParam, "", "table_ref", "Fetch and pin the table state with the given table ref instead of fetching refs.")
 	return ap
 }
 
@@ -84,12 +98,18 @@ func (cmd FetchCmd) Exec(ctx context.Context, commandStr string, args []string,
 	apr := cli.ParseArgs(ap, args, help)
 
 	remotes, _ := dEnv.GetRemotes()
+
+	if tblRefStr, ok := apr.GetValue(tableRefParam); ok {
+		verr := fetchTableRef(ctx, dEnv, remotes, apr.Args(), tblRefStr)
+		return HandleVErrAndExitCode(verr, usage)
+	}
+
 	r, refSpecs, verr := getRefSpecs(apr.Args(), dEnv, remotes)
 
 	updateMode := ref.RefUpdateMode{Force: apr.Contains(ForceFetchFlag)}
 
 	if verr == nil {
-		verr = fetchRefSpecs(ctx, updateMode, dEnv, r, refSpecs)
+		verr = fetchRefSpecs(ctx, updateMode, apr.Contains(FetchTagsFlag), dEnv, r, refSpecs)
 	}
 
 	return HandleVErrAndExitCode(verr, usage)
@@ -168,14 +188,17 @@ func mapRefspecsToRemotes(refSpecs []ref.RemoteRefSpec, dEnv *env.DoltEnv) (map[
 	return rsToRem, nil
 }
 
-func fetchRefSpecs(ctx context.Context, mode ref.RefUpdateMode, dEnv *env.DoltEnv, rem env.Remote, refSpecs []ref.RemoteRefSpec) errhand.VerboseError {
-	for _, rs := range refSpecs {
-		srcDB, err := rem.GetRemoteDB(ctx, dEnv.DoltDB.ValueReadWriter().Format())
+// fetchRefSpecs fetches the branches matched by the refspecs given, followed by the tags reachable from them, or every
+// tag if |allTags| is true.
+func fetchRefSpecs(ctx context.Context, mode ref.RefUpdateMode, allTags bool, dEnv *env.DoltEnv, rem env.Remote, refSpecs []ref.RemoteRefSpec) errhand.VerboseError {
+	srcDB, err := rem.GetRemoteDB(ctx, dEnv.DoltDB.ValueReadWriter().Format())
 
-		if err != nil {
-			return errhand.BuildDError("error: failed to get remote db").AddCause(err).Build()
-		}
+	if err != nil {
+		return AddIncompatibleFormatDetails(errhand.BuildDError("error: failed to get remote db").AddCause(err), err, dEnv).Build()
+	}
 
+	var fetched []*doltdb.Commit
+	for _, rs := range refSpecs {
 		branchRefs, err := srcDB.GetRefs(ctx)
 
 		if err != nil {
@@ -192,6 +215,8 @@ func fetchRefSpecs(ctx context.Context, mode ref.RefUpdateMode, dEnv *env.DoltEn
 					return verr
 				}
 
+				fetched = append(fetched, srcDBCommit)
+
 				switch mode {
 				case ref.ForceUpdate:
 					err = dEnv.DoltDB.SetHead(ctx, remoteTrackRef, srcDBCommit)
@@ -212,6 +237,46 @@ func fetchRefSpecs(ctx context.Context, mode ref.RefUpdateMode, dEnv *env.DoltEn
 		}
 	}
 
+	return fetchTags(ctx, mode, allTags, dEnv, rem, srcDB, fetched)
+}
+
+// fetchTags fetches the tags of the remote which are reachable from the commits given, or all of them if |allTags| is
+// true. A tag which would replace a local tag pointing at another commit is rejected unless the update is forced.
+func fetchTags(ctx context.Context, mode ref.RefUpdateMode, allTags bool, dEnv *env.DoltEnv, rem env.Remote, srcDB *doltdb.DoltDB, fetched []*doltdb.Commit) errhand.VerboseError {
+	var tags []actions.Tag
+	var err error
+	if allTags {
+		tags, err = actions.GetTags(ctx, srcDB)
+	} else if len(fetched) > 0 {
+		tags, err = actions.GetTagsReachableFrom(ctx, srcDB, fetched)
+	}
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to read tags from '%s'", rem.Name).AddCause(err).Build()
+	}
+
+	var rejected []string
+	for _, tag := range tags {
+		wg, progChan, pullerEventCh := runProgFuncs()
+		err = actions.FetchTag(ctx, dEnv, mode, tag, srcDB, dEnv.DoltDB, progChan, pullerEventCh)
+		stopProgFuncs(wg, progChan, pullerEventCh)
+
+		if err == actions.ErrTagExists {
+			rejected = append(rejected, tag.Ref.GetPath())
+		} else if err != nil && err != doltdb.ErrUpToDate {
+			return AddIncompatibleFormatDetails(errhand.BuildDError("error: failed to fetch tag '%s'", tag.Ref.GetPath()).AddCause(err), err, dEnv).Build()
+		}
+	}
+
+	if len(rejected) > 0 {
+		cli.Printf("From %s\n", rem.Url)
+		for _, name := range rejected {
+			cli.Printf(" ! [rejected]        %s -> %s (would clobber existing tag)\n", name, name)
+		}
+
+		return errhand.BuildDError("error: fetch failed, some tags already exist locally").AddDetails("Use 'dolt fetch --force' to replace them.").Build()
+	}
+
 	return nil
 }
 
@@ -230,16 +295,65 @@ func fetchRemoteBranch(ctx context.Context, dEnv *env.DoltEnv, rem env.Remote, s
 	srcDBCommit, err := srcDB.Resolve(ctx, cs)
 
 	if err != nil {
-		return nil, errhand.BuildDError("error: unable to find '%s' on '%s'", srcRef.GetPath(), rem.Name).Build()
+		return nil, errhand.BuildDError("error: unable to find '%s' on '%s'", srcRef.GetPath(), rem.Name).AddCause(err).Build()
 	} else {
 		wg, progChan, pullerEventCh := runProgFuncs()
 		err = actions.Fetch(ctx, dEnv, destRef, srcDB, destDB, srcDBCommit, progChan, pullerEventCh)
 		stopProgFuncs(wg, progChan, pullerEventCh)
 
 		if err != nil {
-			return nil, errhand.BuildDError("error: fetch failed").AddCause(err).Build()
+			return nil, AddIncompatibleFormatDetails(errhand.BuildDError("error: fetch failed").AddCause(err), err, dEnv).Build()
 		}
 	}
 
 	return srcDBCommit, nil
 }
+
+func fetchTableRef(ctx context.Context, dEnv *env.DoltEnv, remotes map[string]env.Remote, args []string, tblRefStr string) errhand.VerboseError {
+	if len(args) > 1 {
+		return errhand.BuildDError("error: refspecs cannot be fetched with --%s", tableRefParam).SetPrintUsage().Build()
+	}
+
+	h, verr := ParseTableRefWithVErr(tblRefStr)
+
+	if verr != nil {
+		return verr
+	}
+
+	if len(remotes) == 0 {
+		return errhand.BuildDError("error: no remotes set").AddDetails("to add a remote run: dolt remote add <remote> <url>").Build()
+	}
+
+	remName := "origin"
+	if len(args) == 1 {
+		remName = args[0]
+	}
+
+	rem, ok := remotes[remName]
+
+	if !ok {
+		return errhand.BuildDError("error: unknown remote").SetPrintUsage().Build()
+	}
+
+	srcDB, err := rem.GetRemoteDB(ctx, dEnv.DoltDB.ValueReadWriter().Format())
+
+	if err != nil {
+		return AddIncompatibleFormatDetails(errhand.BuildDError("error: failed to get remote db").AddCause(err), err, dEnv).Build()
+	}
+
+	wg, progChan, pullerEventCh := runProgFuncs()
+	err = actions.FetchTableRef(ctx, dEnv, srcDB, dEnv.DoltDB, h, progChan, pullerEventCh)
+	stopProgFuncs(wg, progChan, pullerEventCh)
+
+	if err != nil {
+		if doltdb.IsNotFoundErr(err) {
+			return errhand.BuildDError("error: unable to find table ref '%s' on '%s'", tblRefStr, rem.Name).Build()
+		} else if err == doltdb.ErrFoundHashNotATable {
+			return errhand.BuildDError("error: '%s' is not a table ref", tblRefStr).Build()
+		}
+
+		return AddIncompatibleFormatDetails(errhand.BuildDError("error: fetch failed").AddCause(err), err, dEnv).Build()
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexcmds
+
+import (
+	"context"
+	"strings"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/indexcheck"
+)
+
+var Commands = cli.NewSubCommandHandler("index", "Commands for checking and repairing the indexes of tables.", []cli.Command{
+	VerifyCmd{},
+	RebuildCmd{},
+})
+
+// resolveTables returns the names of the tables of the indexes given as |args|, which are table names optionally
+// followed by a '.' and the name of an index. Tables only have their primary key index, so that's the only index name
+// accepted.
+func resolveTables(ctx context.Context, root *doltdb.RootValue, args []string) ([]string, errhand.VerboseError) {
+	tblNames := make([]string, 0, len(args))
+	for _, arg := range args {
+		tblName := arg
+		if i := strings.LastIndex(arg, "."); i != -1 {
+			tblName = arg[:i]
+
+			if idxName := arg[i+1:]; !strings.EqualFold(idxName, indexcheck.PrimaryKeyIndexName) {
+				return nil, errhand.BuildDError("error: table '%s' has no index '%s'", tblName, idxName).
+					AddDetails("Tables only have the index %s, of their primary key.", indexcheck.PrimaryKeyIndexName).Build()
+			}
+		}
+
+		if has, err := root.HasTable(ctx, tblName); err != nil {
+			return nil, errhand.BuildDError("error: unable to read the working set").AddCause(err).Build()
+		} else if !has {
+			return nil, errhand.BuildDError("error: table '%s' doesn't exist", tblName).Build()
+		}
+
+		tblNames = append(tblNames, tblName)
+	}
+
+	return tblNames, nil
+}
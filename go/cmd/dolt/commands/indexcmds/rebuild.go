@@ -0,0 +1,153 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexcmds
+
+import (
+	"context"
+	"errors"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/indexcheck"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+const (
+	allParam = "all"
+)
+
+var rebuildDocs = cli.CommandDocumentationContent{
+	ShortDesc: "Rebuild the indexes of tables",
+	LongDesc: `Rebuilds the given indexes of the working set, or every index with {{.EmphasisLeft}}--all{{.EmphasisRight}}, re-encoding each of their entries from the columns of the schemas of their tables. The primary key columns, the values of columns which have been dropped and null values are removed from the values of the entries, and the rest are put in order. Cold values which don't belong to a row are removed. The rebuilt indexes are no longer recorded as broken.
+
+As with {{.EmphasisLeft}}dolt index verify{{.EmphasisRight}}, the only index of a table is its primary key index, which is the map of its rows and the map of the values of its cold columns, so rebuilding an index rewrites those maps.
+
+The keys of the entries are the data of the primary key of the table, so a key with a problem, or a value of the wrong type for its column, can't be rebuilt. The rows with those problems must be fixed first.
+`,
+	Synopsis: []string{
+		"--all",
+		"{{.LessThan}}table{{.GreaterThan}}[.{{.LessThan}}index{{.GreaterThan}}]...",
+	},
+}
+
+type RebuildCmd struct{}
+
+// Name is returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd RebuildCmd) Name() string {
+	return "rebuild"
+}
+
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd RebuildCmd) LocksRepo() bool {
+	return true
+}
+
+// Description returns a description of the command
+func (cmd RebuildCmd) Description() string {
+	return "Rebuild the indexes of tables."
+}
+
+// EventType returns the type of the event to log
+func (cmd RebuildCmd) EventType() eventsapi.ClientEventType {
+	return eventsapi.ClientEventType_TYPE_UNSPECIFIED
+}
+
+// CreateMarkdown creates a markdown file containing the helptext for the command at the given path
+func (cmd RebuildCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) error {
+	ap := cmd.createArgParser()
+	return commands.CreateMarkdown(fs, path, cli.GetCommandDocumentation(commandStr, rebuildDocs, ap))
+}
+
+func (cmd RebuildCmd) createArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"table", "A table whose index is rebuilt."})
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"index", "The name of the index. Tables only have the index PRIMARY, of their primary key."})
+	ap.SupportsFlag(allParam, "a", "Rebuild the indexes of every table.")
+	return ap
+}
+
+// Exec executes the command
+func (cmd RebuildCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cmd.createArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, rebuildDocs, ap))
+	apr := cli.ParseArgs(ap, args, help)
+
+	if apr.Contains(allParam) == (apr.NArg() > 0) {
+		usage()
+		return 1
+	}
+
+	verr := rebuildIndexes(ctx, dEnv, apr.Args())
+	return commands.HandleVErrAndExitCode(verr, usage)
+}
+
+func rebuildIndexes(ctx context.Context, dEnv *env.DoltEnv, args []string) errhand.VerboseError {
+	root, verr := commands.GetWorkingWithVErr(dEnv)
+
+	if verr != nil {
+		return verr
+	}
+
+	tblNames, verr := resolveTables(ctx, root, args)
+
+	if verr != nil {
+		return verr
+	} else if len(args) == 0 {
+		var err error
+		if tblNames, err = root.GetTableNames(ctx); err != nil {
+			return errhand.BuildDError("error: unable to read the working set").AddCause(err).Build()
+		}
+	}
+
+	for _, tblName := range tblNames {
+		tbl, _, err := root.GetTable(ctx, tblName)
+
+		if err != nil {
+			return errhand.BuildDError("error: unable to read table '%s'", tblName).AddCause(err).Build()
+		}
+
+		tbl, entries, err := indexcheck.Rebuild(ctx, tbl)
+
+		if errors.Is(err, indexcheck.ErrUnrebuildable) {
+			return errhand.BuildDError("error: the index of table '%s' can't be rebuilt", tblName).AddDetails(err.Error()).Build()
+		} else if err != nil {
+			return errhand.BuildDError("error: failed to rebuild the index of table '%s'", tblName).AddCause(err).Build()
+		}
+
+		if root, err = root.PutTable(ctx, tblName, tbl); err != nil {
+			return errhand.BuildDError("error: failed to write table '%s'", tblName).AddCause(err).Build()
+		}
+
+		cli.Printf("%s: rebuilt %d entries\n", env.IndexID(tblName, indexcheck.PrimaryKeyIndexName), entries)
+	}
+
+	if err := dEnv.UpdateWorkingRoot(ctx, root); err != nil {
+		return errhand.BuildDError("error: failed to update the working set").AddCause(err).Build()
+	}
+
+	for _, tblName := range tblNames {
+		dEnv.RepoState.MarkBrokenIndex(tblName, indexcheck.PrimaryKeyIndexName, false)
+	}
+
+	if err := dEnv.RepoState.Save(dEnv.FS); err != nil {
+		return errhand.BuildDError("error: failed to record the rebuilt indexes").AddCause(err).Build()
+	}
+
+	return nil
+}
@@ -0,0 +1,276 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexcmds
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/indexcheck"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+const (
+	formatFlag = "result-format"
+)
+
+var verifyDocs = cli.CommandDocumentationContent{
+	ShortDesc: "Verify the indexes of tables",
+	LongDesc: `Checks every entry of the given indexes of the working set against the schemas of their tables. Indexes are given as a table name, optionally followed by {{.EmphasisLeft}}.PRIMARY{{.EmphasisRight}}, the name of the index of the primary key. Every index is verified if none are given.
+
+Tables don't have secondary indexes, so the only index of a table is its primary key index, which is the map of its rows, keyed by their primary keys, along with the map of the values of its cold columns. Those maps are what's verified; there is no separate index data to check.
+
+The keys of the entries must hold the primary key columns of the table, in order, with values of the right types. The values must hold the other columns of the table, with values of the right types, in order. Each problem found is reported as {{.EmphasisLeft}}missing{{.EmphasisRight}}, {{.EmphasisLeft}}extra{{.EmphasisRight}} or {{.EmphasisLeft}}mismatched{{.EmphasisRight}}, along with the key of its entry.
+
+Indexes with problems are recorded as broken, and aren't used by {{.EmphasisLeft}}dolt sql{{.EmphasisRight}} and {{.EmphasisLeft}}dolt sql-server{{.EmphasisRight}} until they're rebuilt with {{.EmphasisLeft}}dolt index rebuild{{.EmphasisRight}}, or until they pass verification. A running sql-server only sees the indexes recorded as broken when it was started. The command fails if any index is broken.
+
+With {{.EmphasisLeft}}--result-format json{{.EmphasisRight}} the problems and the counts of each index are written as a JSON object, as they're found.
+`,
+	Synopsis: []string{
+		"[-r {{.LessThan}}result format{{.GreaterThan}}] [{{.LessThan}}table{{.GreaterThan}}[.{{.LessThan}}index{{.GreaterThan}}]...]",
+	},
+}
+
+type VerifyCmd struct{}
+
+// Name is returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd VerifyCmd) Name() string {
+	return "verify"
+}
+
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd VerifyCmd) LocksRepo() bool {
+	return true
+}
+
+// Description returns a description of the command
+func (cmd VerifyCmd) Description() string {
+	return "Verify the indexes of tables."
+}
+
+// EventType returns the type of the event to log
+func (cmd VerifyCmd) EventType() eventsapi.ClientEventType {
+	return eventsapi.ClientEventType_TYPE_UNSPECIFIED
+}
+
+// CreateMarkdown creates a markdown file containing the helptext for the command at the given path
+func (cmd VerifyCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) error {
+	ap := cmd.createArgParser()
+	return commands.CreateMarkdown(fs, path, cli.GetCommandDocumentation(commandStr, verifyDocs, ap))
+}
+
+func (cmd VerifyCmd) createArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"table", "A table whose index is verified."})
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"index", "The name of the index. Tables only have the index PRIMARY, of their primary key."})
+	ap.SupportsString(formatFlag, "r", "result output format", "How to format the output. Valid values are tabular and json. Defaults to tabular.")
+	return ap
+}
+
+// Exec executes the command
+func (cmd VerifyCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cmd.createArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, verifyDocs, ap))
+	apr := cli.ParseArgs(ap, args, help)
+
+	var jsonWr *reportJSONWriter
+	if formatStr, ok := apr.GetValue(formatFlag); ok {
+		switch strings.ToLower(formatStr) {
+		case "tabular":
+		case "json":
+			jsonWr = newReportJSONWriter(cli.CliOut)
+		default:
+			return commands.HandleVErrAndExitCode(errhand.BuildDError("Invalid argument for --%s. Valid values are tabular, json", formatFlag).Build(), usage)
+		}
+	}
+
+	verr := verifyIndexes(ctx, dEnv, apr.Args(), jsonWr)
+	return commands.HandleVErrAndExitCode(verr, usage)
+}
+
+func verifyIndexes(ctx context.Context, dEnv *env.DoltEnv, args []string, jsonWr *reportJSONWriter) errhand.VerboseError {
+	root, verr := commands.GetWorkingWithVErr(dEnv)
+
+	if verr != nil {
+		return verr
+	}
+
+	tblNames, verr := resolveTables(ctx, root, args)
+
+	if verr != nil {
+		return verr
+	} else if len(args) == 0 {
+		var err error
+		if tblNames, err = root.GetTableNames(ctx); err != nil {
+			return errhand.BuildDError("error: unable to read the working set").AddCause(err).Build()
+		}
+	}
+
+	var broken []string
+	for _, tblName := range tblNames {
+		tbl, _, err := root.GetTable(ctx, tblName)
+
+		if err != nil {
+			return errhand.BuildDError("error: unable to read table '%s'", tblName).AddCause(err).Build()
+		}
+
+		cb := printProblem(tblName)
+		if jsonWr != nil {
+			cb, err = jsonWr.beginIndex(tblName)
+
+			if err != nil {
+				return errhand.BuildDError("error: failed to write the report").AddCause(err).Build()
+			}
+		}
+
+		report, err := indexcheck.Verify(ctx, tblName, tbl, cb)
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to verify the index of table '%s'", tblName).AddCause(err).Build()
+		}
+
+		if jsonWr != nil {
+			err = jsonWr.endIndex(report)
+		} else {
+			cli.Printf("%s: %d entries, %d missing, %d extra, %d mismatched\n", env.IndexID(report.Table, report.Index), report.Entries, report.Missing, report.Extra, report.Mismatched)
+		}
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to write the report").AddCause(err).Build()
+		}
+
+		if report.Broken() {
+			broken = append(broken, env.IndexID(report.Table, report.Index))
+		}
+
+		dEnv.RepoState.MarkBrokenIndex(tblName, indexcheck.PrimaryKeyIndexName, report.Broken())
+	}
+
+	if jsonWr != nil {
+		if err := jsonWr.close(); err != nil {
+			return errhand.BuildDError("error: failed to write the report").AddCause(err).Build()
+		}
+	}
+
+	if err := dEnv.RepoState.Save(dEnv.FS); err != nil {
+		return errhand.BuildDError("error: failed to record the broken indexes").AddCause(err).Build()
+	}
+
+	if len(broken) > 0 {
+		bdr := errhand.BuildDError("error: the following indexes are broken:")
+		for _, id := range broken {
+			bdr.AddDetails("\t%s", id)
+		}
+
+		bdr.AddDetails(`They won't be used by sql queries until they're rebuilt with "dolt index rebuild".`)
+		return bdr.Build()
+	}
+
+	return nil
+}
+
+func printProblem(tblName string) indexcheck.ProblemFunc {
+	return func(p indexcheck.Problem) error {
+		cli.Printf("%s %s %s: %s\n", env.IndexID(tblName, indexcheck.PrimaryKeyIndexName), p.Kind, p.Key, p.Detail)
+		return nil
+	}
+}
+
+// reportJSONWriter streams the reports of the verification of indexes as a single JSON object, writing each problem as
+// it's found.
+type reportJSONWriter struct {
+	bWr            *bufio.Writer
+	indexesWritten int
+	problems       int
+}
+
+func newReportJSONWriter(wr io.Writer) *reportJSONWriter {
+	return &reportJSONWriter{bWr: bufio.NewWriter(wr)}
+}
+
+func (jw *reportJSONWriter) write(strs ...string) error {
+	for _, str := range strs {
+		if _, err := jw.bWr.WriteString(str); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// beginIndex starts the report of the index of the table |tblName|, returning the ProblemFunc which writes its problems.
+func (jw *reportJSONWriter) beginIndex(tblName string) (indexcheck.ProblemFunc, error) {
+	prefix := `{"indexes": [`
+	if jw.indexesWritten > 0 {
+		prefix = ", "
+	}
+
+	jw.indexesWritten++
+	jw.problems = 0
+	tblNameJSON, _ := json.Marshal(tblName)
+	idxNameJSON, _ := json.Marshal(indexcheck.PrimaryKeyIndexName)
+	err := jw.write(prefix, `{"table": `, string(tblNameJSON), `, "index": `, string(idxNameJSON), `, "problems": [`)
+
+	return jw.writeProblem, err
+}
+
+func (jw *reportJSONWriter) writeProblem(p indexcheck.Problem) error {
+	data, err := json.Marshal(p)
+
+	if err != nil {
+		return err
+	}
+
+	sep := ""
+	if jw.problems > 0 {
+		sep = ", "
+	}
+
+	jw.problems++
+	return jw.write(sep, string(data))
+}
+
+// endIndex ends the report of an index with its counts.
+func (jw *reportJSONWriter) endIndex(report indexcheck.Report) error {
+	data, err := json.Marshal(report)
+
+	if err != nil {
+		return err
+	}
+
+	return jw.write(`], "report": `, string(data), "}")
+}
+
+func (jw *reportJSONWriter) close() error {
+	end := "]}\n"
+	if jw.indexesWritten == 0 {
+		end = `{"indexes": []}` + "\n"
+	}
+
+	if err := jw.write(end); err != nil {
+		return err
+	}
+
+	return jw.bWr.Flush()
+}
@@ -16,39 +16,68 @@ package commands
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
+	"sort"
 	"strings"
 
 	"github.com/fatih/color"
 
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
 	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions/commitwalk"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
 	"github.com/liquidata-inc/dolt/go/store/hash"
 )
 
 const (
-	numLinesParam = "number"
+	numLinesParam   = "number"
+	depthParam      = "depth"
+	decorateFlag    = "decorate"
+	firstParentFlag = "first-parent"
+)
+
+const (
+	logTextFormat = "text"
+	logDotFormat  = "dot"
+	logJSONFormat = "json"
 )
 
 var logDocs = cli.CommandDocumentationContent{
 	ShortDesc: `Show commit logs`,
 	LongDesc: `Shows the commit logs
 
-The command takes options to control what is shown and how.`,
+The command takes options to control what is shown and how.
+
+{{.EmphasisLeft}}--result-format dot{{.EmphasisRight}} and {{.EmphasisLeft}}--result-format json{{.EmphasisRight}} output the graph of the commits instead of the log.  Each commit of the graph has its hash, author, date and the subject line of its message, along with edges to its parents.  The dot format can be piped into Graphviz, e.g. {{.EmphasisLeft}}dolt log -r dot | dot -Tsvg > commits.svg{{.EmphasisRight}}.  The graph includes the commits reachable from any of the given commits, and {{.EmphasisLeft}}--depth N{{.EmphasisRight}} limits it to the commits fewer than N parent links away from one of them.
+
+Given {{.LessThan}}table{{.GreaterThan}} names, only the commits which changed any of those tables are shown.  When a merge commit left the tables as they were in its first parent, the commits only reachable through its other parents are skipped, as none of their changes to the tables were merged.  The tables can be given after {{.EmphasisLeft}}--{{.EmphasisRight}} to tell them apart from commits.
+
+{{.EmphasisLeft}}--first-parent{{.EmphasisRight}} only follows the first parent of merge commits, which is the head of the branch the merge was committed to, so the log stays on the history of that branch.
+
+{{.EmphasisLeft}}--decorate{{.EmphasisRight}} labels the commits which are the heads of branches with the names of those branches.`,
 	Synopsis: []string{
-		`[-n {{.LessThan}}num_commits{{.GreaterThan}}] [{{.LessThan}}commit{{.GreaterThan}}]`,
+		`[-n {{.LessThan}}num_commits{{.GreaterThan}}] [--first-parent] [--decorate] [{{.LessThan}}commit{{.GreaterThan}}] [[--] {{.LessThan}}table{{.GreaterThan}}...]`,
+		`-r dot|json [-n {{.LessThan}}num_commits{{.GreaterThan}}] [--depth {{.LessThan}}depth{{.GreaterThan}}] [--decorate] [{{.LessThan}}commit{{.GreaterThan}}...]`,
 	},
 }
 
 type commitLoggerFunc func(*doltdb.CommitMeta, []hash.Hash, hash.Hash)
 
 func logToStdOutFunc(cm *doltdb.CommitMeta, parentHashes []hash.Hash, ch hash.Hash) {
-	cli.Println(color.YellowString("commit %s", ch.String()))
+	logToStdOutWithDecorations(cm, parentHashes, ch, nil)
+}
+
+func logToStdOutWithDecorations(cm *doltdb.CommitMeta, parentHashes []hash.Hash, ch hash.Hash, refs []string) {
+	if len(refs) > 0 {
+		cli.Println(color.YellowString("commit %s (%s)", ch.String(), strings.Join(refs, ", ")))
+	} else {
+		cli.Println(color.YellowString("commit %s", ch.String()))
+	}
 
 	if len(parentHashes) > 1 {
 		printMerge(parentHashes)
@@ -107,6 +136,10 @@ func (cmd LogCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) er
 func createLogArgParser() *argparser.ArgParser {
 	ap := argparser.NewArgParser()
 	ap.SupportsInt(numLinesParam, "n", "num_commits", "Limit the number of commits to output")
+	ap.SupportsString(formatFlag, "r", "result output format", "How to format the output. Valid values are text, dot and json. Defaults to text.")
+	ap.SupportsInt(depthParam, "", "depth", "Limit the commit graph output by dot and json to the commits fewer than {{.LessThan}}depth{{.GreaterThan}} parent links away from the given commits.")
+	ap.SupportsFlag(decorateFlag, "", "Label the commits which are the heads of branches with the names of the branches.")
+	ap.SupportsFlag(firstParentFlag, "", "Only follow the first parent of merge commits.")
 	return ap
 }
 
@@ -120,37 +153,258 @@ func logWithLoggerFunc(ctx context.Context, commandStr string, args []string, dE
 	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, logDocs, ap))
 	apr := cli.ParseArgs(ap, args, help)
 
-	if apr.NArg() > 1 {
-		usage()
-		return 1
+	resultFormat := logTextFormat
+	if formatStr, ok := apr.GetValue(formatFlag); ok {
+		resultFormat = strings.ToLower(formatStr)
+		if resultFormat != logTextFormat && resultFormat != logDotFormat && resultFormat != logJSONFormat {
+			return HandleVErrAndExitCode(errhand.BuildDError("Invalid argument for --%s. Valid values are text, dot, json", formatFlag).Build(), usage)
+		}
+	}
+
+	numLines := apr.GetIntOrDefault(numLinesParam, -1)
+	if resultFormat != logTextFormat {
+		if apr.Contains(firstParentFlag) {
+			return HandleVErrAndExitCode(errhand.BuildDError("--%s is only supported with --%s text", firstParentFlag, formatFlag).SetPrintUsage().Build(), usage)
+		}
+
+		return HandleVErrAndExitCode(logGraph(ctx, dEnv, apr, resultFormat, numLines), usage)
+	}
+
+	if apr.Contains(depthParam) {
+		return HandleVErrAndExitCode(errhand.BuildDError("--%s is only supported with --%s dot or json", depthParam, formatFlag).SetPrintUsage().Build(), usage)
+	}
+
+	cs, tableNames, verr := parseCommitSpecAndTableNames(ctx, dEnv, apr)
+	if verr != nil {
+		return HandleVErrAndExitCode(verr, usage)
+	}
+
+	if apr.Contains(decorateFlag) {
+		decorations, verr := getCommitDecorations(ctx, dEnv)
+
+		if verr != nil {
+			return HandleVErrAndExitCode(verr, usage)
+		}
+
+		loggerFunc = func(cm *doltdb.CommitMeta, parentHashes []hash.Hash, ch hash.Hash) {
+			logToStdOutWithDecorations(cm, parentHashes, ch, decorations[ch])
+		}
+	}
+
+	return logCommits(ctx, dEnv, cs, tableNames, apr.Contains(firstParentFlag), loggerFunc, numLines)
+}
+
+// logGraph outputs the graph of the commits reachable from the commits given as arguments in the dot or json format.
+func logGraph(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgParseResults, resultFormat string, numLines int) errhand.VerboseError {
+	specStrs := apr.Args()
+	if len(specStrs) == 0 {
+		specStrs = []string{"HEAD"}
 	}
 
-	cs, err := parseCommitSpec(dEnv, apr)
+	var startHashes []hash.Hash
+	for _, specStr := range specStrs {
+		cs, err := doltdb.NewCommitSpec(specStr, dEnv.RepoState.CWBHeadRef().String())
+
+		if err != nil {
+			return errhand.BuildDError("invalid commit %s", specStr).Build()
+		}
+
+		cm, err := dEnv.DoltDB.Resolve(ctx, cs)
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to resolve %s", specStr).AddCause(err).Build()
+		}
+
+		h, err := cm.HashOf()
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to get commit hash").AddCause(err).Build()
+		}
+
+		startHashes = append(startHashes, h)
+	}
+
+	depth := apr.GetIntOrDefault(depthParam, -1)
+	graph, err := commitwalk.GetCommitGraph(ctx, dEnv.DoltDB, startHashes, depth, numLines)
+
 	if err != nil {
-		cli.PrintErr(err)
-		return 1
+		return errhand.BuildDError("error: failed to walk the commit graph").AddCause(err).Build()
 	}
 
-	numLines := apr.GetIntOrDefault(numLinesParam, -1)
-	return logCommits(ctx, dEnv, cs, loggerFunc, numLines)
+	if apr.Contains(decorateFlag) {
+		decorations, verr := getCommitDecorations(ctx, dEnv)
+
+		if verr != nil {
+			return verr
+		}
+
+		for _, node := range graph.Nodes {
+			node.Refs = decorations[hash.Parse(node.Hash)]
+		}
+	}
+
+	if resultFormat == logJSONFormat {
+		data, err := json.MarshalIndent(graph, "", "  ")
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to serialize the commit graph").AddCause(err).Build()
+		}
+
+		cli.Println(string(data))
+		return nil
+	}
+
+	err = graph.WriteDot(cli.CliOut)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to write the commit graph").AddCause(err).Build()
+	}
+
+	return nil
+}
+
+// getCommitDecorations returns the names of the branches and remote branches whose heads are each commit. The current
+// branch is shown as HEAD -> branch.
+func getCommitDecorations(ctx context.Context, dEnv *env.DoltEnv) (map[hash.Hash][]string, errhand.VerboseError) {
+	refs, err := dEnv.DoltDB.GetRefsOfType(ctx, map[ref.RefType]struct{}{ref.BranchRefType: {}, ref.RemoteRefType: {}})
+
+	if err != nil {
+		return nil, errhand.BuildDError("error: failed to read refs").AddCause(err).Build()
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].GetType() != refs[j].GetType() {
+			return refs[i].GetType() == ref.BranchRefType
+		}
+
+		return refs[i].GetPath() < refs[j].GetPath()
+	})
+
+	currentBranch := dEnv.RepoState.CWBHeadRef()
+	decorations := make(map[hash.Hash][]string)
+	for _, r := range refs {
+		cs, err := doltdb.NewCommitSpec(r.String(), "")
+
+		if err != nil {
+			return nil, errhand.BuildDError("error: invalid ref %s", r.String()).AddCause(err).Build()
+		}
+
+		cm, err := dEnv.DoltDB.Resolve(ctx, cs)
+
+		if err != nil {
+			return nil, errhand.BuildDError("error: failed to resolve %s", r.String()).AddCause(err).Build()
+		}
+
+		h, err := cm.HashOf()
+
+		if err != nil {
+			return nil, errhand.BuildDError("error: failed to get commit hash").AddCause(err).Build()
+		}
+
+		name := r.GetPath()
+		if ref.Equals(r, currentBranch) {
+			name = "HEAD -> " + name
+			decorations[h] = append([]string{name}, decorations[h]...)
+		} else {
+			decorations[h] = append(decorations[h], name)
+		}
+	}
+
+	return decorations, nil
 }
 
-func parseCommitSpec(dEnv *env.DoltEnv, apr *argparser.ArgParseResults) (*doltdb.CommitSpec, error) {
-	if apr.NArg() == 0 || apr.Arg(0) == "--" {
-		return dEnv.RepoState.CWBHeadSpec(), nil
+// parseCommitSpecAndTableNames parses the arguments [<commit>] [[--] <table>...]. Without --, the first argument is
+// the commit if it resolves to one, and the other arguments are tables. If it doesn't, all of the arguments must be
+// tables of the working set or of HEAD.
+func parseCommitSpecAndTableNames(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgParseResults) (*doltdb.CommitSpec, []string, errhand.VerboseError) {
+	args := apr.Args()
+	revArgs, tableNames := args, []string(nil)
+	for i, arg := range args {
+		if arg == "--" {
+			revArgs, tableNames = args[:i], args[i+1:]
+			break
+		}
+	}
+
+	if len(revArgs) > 1 && tableNames == nil {
+		revArgs, tableNames = revArgs[:1], revArgs[1:]
+	}
+
+	if len(revArgs) > 1 {
+		return nil, nil, errhand.BuildDError("error: only one commit can be given").SetPrintUsage().Build()
+	}
+
+	if len(revArgs) == 0 {
+		return dEnv.RepoState.CWBHeadSpec(), tableNames, nil
 	}
 
-	comSpecStr := apr.Arg(0)
+	comSpecStr := revArgs[0]
 	cs, err := doltdb.NewCommitSpec(comSpecStr, dEnv.RepoState.CWBHeadRef().String())
 
+	if err == nil {
+		_, err = dEnv.DoltDB.Resolve(ctx, cs)
+	}
+
+	if err == nil {
+		return cs, tableNames, nil
+	} else if len(args) > len(revArgs)+len(tableNames) {
+		// the argument came before --, so it can only be a commit
+		return nil, nil, errhand.BuildDError("invalid commit %s", comSpecStr).Build()
+	}
+
+	tableNames = append([]string{comSpecStr}, tableNames...)
+	cs = dEnv.RepoState.CWBHeadSpec()
+
+	for _, tableName := range tableNames {
+		ok, verr := tableExistsInWorkingOrCommit(ctx, dEnv, cs, tableName)
+
+		if verr != nil {
+			return nil, nil, verr
+		}
+
+		if !ok {
+			return nil, nil, errhand.BuildDError("error: %s is neither a commit nor a table. Use -- to separate the commit from tables which no longer exist.", tableName).Build()
+		}
+	}
+
+	return cs, tableNames, nil
+}
+
+func tableExistsInWorkingOrCommit(ctx context.Context, dEnv *env.DoltEnv, cs *doltdb.CommitSpec, tableName string) (bool, errhand.VerboseError) {
+	working, err := dEnv.WorkingRoot(ctx)
+
 	if err != nil {
-		return nil, fmt.Errorf("invalid commit %s\n", comSpecStr)
+		return false, errhand.BuildDError("Unable to get working.").AddCause(err).Build()
+	}
+
+	if ok, err := working.HasTable(ctx, tableName); err != nil {
+		return false, errhand.BuildDError("error: failed to read tables").AddCause(err).Build()
+	} else if ok {
+		return true, nil
 	}
 
-	return cs, nil
+	cm, err := dEnv.DoltDB.Resolve(ctx, cs)
+
+	if err != nil {
+		return false, errhand.BuildDError("Fatal error: cannot get HEAD commit for current branch.").AddCause(err).Build()
+	}
+
+	root, err := cm.GetRootValue()
+
+	if err != nil {
+		return false, errhand.BuildDError("error: failed to get root value").AddCause(err).Build()
+	}
+
+	ok, err := root.HasTable(ctx, tableName)
+
+	if err != nil {
+		return false, errhand.BuildDError("error: failed to read tables").AddCause(err).Build()
+	}
+
+	return ok, nil
 }
 
-func logCommits(ctx context.Context, dEnv *env.DoltEnv, cs *doltdb.CommitSpec, loggerFunc commitLoggerFunc, numLines int) int {
+func logCommits(ctx context.Context, dEnv *env.DoltEnv, cs *doltdb.CommitSpec, tableNames []string, firstParent bool, loggerFunc commitLoggerFunc, numLines int) int {
 	commit, err := dEnv.DoltDB.Resolve(ctx, cs)
 
 	if err != nil {
@@ -165,7 +419,19 @@ func logCommits(ctx context.Context, dEnv *env.DoltEnv, cs *doltdb.CommitSpec, l
 		return 1
 	}
 
-	commits, err := commitwalk.GetTopNTopoOrderedCommits(ctx, dEnv.DoltDB, h, numLines)
+	var itr doltdb.CommitItr
+	if len(tableNames) > 0 {
+		itr, err = commitwalk.GetTableHistoryIterator(ctx, dEnv.DoltDB, h, tableNames, firstParent)
+	} else if firstParent {
+		itr, err = commitwalk.GetTopologicalOrderIteratorWithParents(ctx, dEnv.DoltDB, h, commitwalk.FirstParents)
+	} else {
+		itr, err = commitwalk.GetTopologicalOrderIterator(ctx, dEnv.DoltDB, h)
+	}
+
+	var commits []*doltdb.Commit
+	if err == nil {
+		commits, err = commitwalk.GetTopNCommits(ctx, itr, numLines)
+	}
 
 	if err != nil {
 		cli.PrintErrln("Error retrieving commit.")
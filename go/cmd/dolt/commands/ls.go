@@ -38,7 +38,7 @@ const (
 
 var lsDocs = cli.CommandDocumentationContent{
 	ShortDesc: "List tables",
-	LongDesc: `With no arguments lists the tables in the current working set but if a commit is specified it will list the tables in that commit.  If the {{.EmphasisLeft}}--verbose{{.EmphasisRight}} flag is provided a row count and a hash of the table will also be displayed.
+	LongDesc: `With no arguments lists the tables in the current working set but if a commit is specified it will list the tables in that commit.  If the {{.EmphasisLeft}}--verbose{{.EmphasisRight}} flag is provided a row count and the table ref of the table will also be displayed. A table ref is the hash of that exact state of the table, which can be used with {{.EmphasisLeft}}dolt table export --ref{{.EmphasisRight}}, {{.EmphasisLeft}}AS OF{{.EmphasisRight}} in SQL queries and {{.EmphasisLeft}}dolt table pin{{.EmphasisRight}}.
 
 If the {{.EmphasisLeft}}--system{{.EmphasisRight}} flag is supplied this will show the dolt system tables which are queryable with SQL.  Some system tables can be queried even if they are not in the working set by specifying appropriate parameters in the SQL queries. To see these tables too you may pass the {{.EmphasisLeft}}--verbose{{.EmphasisRight}} flag.
 
@@ -70,7 +70,7 @@ func (cmd LsCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) err
 
 func (cmd LsCmd) createArgParser() *argparser.ArgParser {
 	ap := argparser.NewArgParser()
-	ap.SupportsFlag(verboseFlag, "v", "show the hash of the table")
+	ap.SupportsFlag(verboseFlag, "v", "show the row count and table ref of the table")
 	ap.SupportsFlag(systemFlag, "s", "show system tables")
 	ap.SupportsFlag(allFlag, "a", "show system tables")
 	return ap
@@ -197,10 +197,12 @@ func printSystemTables(ctx context.Context, root *doltdb.RootValue, ddb *doltdb.
 func printWorkingSetSysTables(tblNames []string) {
 	diffTables := funcitr.MapStrings(tblNames, func(s string) string { return sqle.DoltDiffTablePrefix + s })
 	histTables := funcitr.MapStrings(tblNames, func(s string) string { return sqle.DoltHistoryTablePrefix + s })
+	logTables := funcitr.MapStrings(tblNames, func(s string) string { return sqle.DoltLogTablePrefix + s })
 
-	systemTables := []string{sqle.LogTableName, sqle.BranchesTableName, doltdb.DocTableName}
+	systemTables := []string{sqle.LogTableName, sqle.BranchesTableName, sqle.CommitAncestorsTableName, sqle.RemotesTableName, sqle.StatusTableName, sqle.BackgroundTasksTableName, sqle.WorkspacesTableName, doltdb.DocTableName}
 	systemTables = append(systemTables, diffTables...)
 	systemTables = append(systemTables, histTables...)
+	systemTables = append(systemTables, logTables...)
 
 	cli.Println("System tables:")
 	cli.Println("\t" + strings.Join(systemTables, "\n\t"))
@@ -28,6 +28,7 @@ import (
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/errcat"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/merge"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
@@ -46,6 +47,16 @@ var mergeDocs = cli.CommandDocumentationContent{
 The second syntax ({{.LessThan}}dolt merge --abort{{.GreaterThan}}) can only be run after the merge has resulted in conflicts. git merge {{.EmphasisLeft}}--abort{{.EmphasisRight}} will abort the merge process and try to reconstruct the pre-merge state. However, if there were uncommitted changes when the merge started (and especially if those changes were further modified after the merge was started), dolt merge {{.EmphasisLeft}}--abort{{.EmphasisRight}} will in some cases be unable to reconstruct the original (pre-merge) changes. Therefore: 
 
 {{.LessThan}}Warning{{.GreaterThan}}: Running dolt merge with non-trivial uncommitted changes is discouraged: while possible, it may leave you in a state that is hard to back out of in the case of a conflict.
+
+The rows of tables changed on both branches are merged by the table's merge driver. By default rows are merged column by column, and are conflicts if both branches changed the same column differently. A different driver can be selected for a table with {{.EmphasisLeft}}dolt table merge-driver{{.EmphasisRight}}, which is versioned with the table, or with the {{.EmphasisLeft}}merge.driver.{{.LessThan}}table{{.GreaterThan}}{{.EmphasisRight}} config key, which takes precedence. The built in drivers are:
+
+{{.EmphasisLeft}}cell-wise{{.EmphasisRight}}: the default, described above.
+
+{{.EmphasisLeft}}ours{{.EmphasisRight}}, {{.EmphasisLeft}}theirs{{.EmphasisRight}}: merge like {{.EmphasisLeft}}cell-wise{{.EmphasisRight}}, but resolve every conflicting row with the current branch's or the merged branch's version of it.
+
+{{.EmphasisLeft}}union{{.EmphasisRight}}: keep every row which exists on either branch, only deleting rows deleted on both.
+
+{{.EmphasisLeft}}sum-numeric-columns{{.EmphasisRight}}: merge the numeric columns of rows changed on both branches by adding both branches' changes to the common ancestor's value, so that counters changed on both branches sum.
 `,
 
 	Synopsis: []string{
@@ -66,6 +77,11 @@ func (cmd MergeCmd) Name() string {
 	return "merge"
 }
 
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd MergeCmd) LocksRepo() bool {
+	return true
+}
+
 // Description returns a description of the command
 func (cmd MergeCmd) Description() string {
 	return "Merge a branch."
@@ -97,8 +113,8 @@ func (cmd MergeCmd) Exec(ctx context.Context, commandStr string, args []string,
 	var verr errhand.VerboseError
 	if apr.Contains(abortParam) {
 		if !dEnv.IsMergeActive() {
-			cli.PrintErrln("fatal: There is no merge to abort")
-			return 1
+			verr := errhand.BuildDError("fatal: There is no merge to abort").Build()
+			return HandleVErrAndExitCode(verr, usage)
 		}
 
 		verr = abortMerge(ctx, dEnv)
@@ -112,7 +128,7 @@ func (cmd MergeCmd) Exec(ctx context.Context, commandStr string, args []string,
 		dref, err := dEnv.FindRef(ctx, branchName)
 
 		if err != nil {
-			verr := errhand.BuildDError(fmt.Sprintf("unknown branch: %s", branchName)).Build()
+			verr := errhand.BuildDError(fmt.Sprintf("unknown branch: %s", branchName)).SetCategory(errcat.NotFound).Build()
 			return HandleVErrAndExitCode(verr, usage)
 		}
 
@@ -123,16 +139,18 @@ func (cmd MergeCmd) Exec(ctx context.Context, commandStr string, args []string,
 			if has, err := root.HasConflicts(ctx); err != nil {
 				verr = errhand.BuildDError("error: failed to get conflicts").AddCause(err).Build()
 			} else if has {
-				cli.Println("error: Merging is not possible because you have unmerged files.")
-				cli.Println("hint: Fix them up in the work tree, and then use 'dolt add <table>'")
-				cli.Println("hint: as appropriate to mark resolution and make a commit.")
-				cli.Println("fatal: Exiting because of an unresolved conflict.")
-				return 1
+				verr = errhand.BuildDError("error: Merging is not possible because you have unmerged files.").
+					AddDetails("hint: Fix them up in the work tree, and then use 'dolt add <table>'").
+					AddDetails("hint: as appropriate to mark resolution and make a commit.").
+					AddDetails("fatal: Exiting because of an unresolved conflict.").
+					SetCategory(errcat.Conflict).
+					Build()
 			} else if dEnv.IsMergeActive() {
-				cli.Println("error: Merging is not possible because you have not committed an active merge.")
-				cli.Println("hint: add affected tables using 'dolt add <table>' and commit using {{.EmphasisLeft}}dolt commit -m <msg>{{.EmphasisRight}}")
-				cli.Println("fatal: Exiting because of active merge")
-				return 1
+				verr = errhand.BuildDError("error: Merging is not possible because you have not committed an active merge.").
+					AddDetails("hint: add affected tables using 'dolt add <table>' and commit using 'dolt commit -m <msg>'").
+					AddDetails("fatal: Exiting because of active merge").
+					SetCategory(errcat.Conflict).
+					Build()
 			}
 
 			if verr == nil {
@@ -141,10 +159,16 @@ func (cmd MergeCmd) Exec(ctx context.Context, commandStr string, args []string,
 		}
 	}
 
-	return handleCommitErr(ctx, dEnv, verr, usage)
+	return HandleVErrAndExitCode(verr, usage)
 }
 
 func abortMerge(ctx context.Context, doltEnv *env.DoltEnv) errhand.VerboseError {
+	verr := backupWorkingSet(ctx, doltEnv, "merge --abort")
+
+	if verr != nil {
+		return verr
+	}
+
 	err := actions.CheckoutAllTables(ctx, doltEnv)
 
 	if err == nil {
@@ -202,7 +226,7 @@ func mergeBranch(ctx context.Context, dEnv *env.DoltEnv, dref ref.DoltRef) errha
 			bldr.AddDetails(tName)
 		}
 		bldr.AddDetails("Please commit your changes before you merge.")
-		return bldr.Build()
+		return bldr.SetCategory(errcat.Conflict).Build()
 	}
 
 	if ok, err := cm1.CanFastForwardTo(ctx, cm2); ok {
@@ -292,7 +316,19 @@ and take the hash for your current branch and use it for the value for "staged"
 }
 
 func executeMerge(ctx context.Context, dEnv *env.DoltEnv, cm1, cm2 *doltdb.Commit, dref ref.DoltRef, workingDiffs map[string]hash.Hash) errhand.VerboseError {
-	mergedRoot, tblToStats, err := merge.MergeCommits(ctx, dEnv.DoltDB, cm1, cm2)
+	root, err := cm1.GetRootValue()
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to get the root value of the current commit").AddCause(err).Build()
+	}
+
+	drivers, err := merge.GetMergeDrivers(ctx, root, dEnv.Config)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to read the merge drivers").AddCause(err).Build()
+	}
+
+	mergedRoot, tblToStats, err := merge.MergeCommits(ctx, dEnv.DoltDB, cm1, cm2, drivers)
 
 	if err != nil {
 		switch err {
@@ -337,7 +373,7 @@ func executeMerge(ctx context.Context, dEnv *env.DoltEnv, cm1, cm2 *doltdb.Commi
 		hasConflicts := printSuccessStats(tblToStats)
 
 		if hasConflicts {
-			cli.Println("Automatic merge failed; fix conflicts and then commit the result.")
+			verr = errhand.BuildDError("Automatic merge failed; fix conflicts and then commit the result.").SetCategory(errcat.Conflict).Build()
 		} else {
 			err = actions.SaveDocsFromWorkingExcludingFSChanges(ctx, dEnv, unstagedDocs)
 			if err != nil {
@@ -18,26 +18,57 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/fatih/color"
 
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
 	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/migrate"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/rebase"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/alterschema"
+	dsql "github.com/liquidata-inc/dolt/go/libraries/doltcore/sql"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+	"github.com/liquidata-inc/dolt/go/store/types"
 )
 
 const (
 	migrationPrompt = `Run "dolt migrate" to update this repository to the latest format`
 	migrationMsg    = "Migrating repository to the latest format"
 
-	migratePushFlag = "push"
-	migratePullFlag = "pull"
+	migratePushFlag     = "push"
+	migratePullFlag     = "pull"
+	migrateDryRunFlag   = "dry-run"
+	migrateRollbackFlag = "rollback"
+	migrateFixIdsFlag   = "fix-identifiers"
 )
 
+var migrateDocs = cli.CommandDocumentationContent{
+	ShortDesc: "Executes a repository migration to update to the latest format.",
+	LongDesc: `Migrates the repository to the latest format.
+
+If the repository is stored in an older storage format, its data is rewritten into the current storage format. Every ref, along with the working and staged roots, is copied into a new store in {{.EmphasisLeft}}.dolt/noms_migrating{{.EmphasisRight}}, which replaces the original store only after it has been verified. Values whose encoding is unchanged keep their hashes. When a commit's hash changes, the old and new hashes are recorded in {{.EmphasisLeft}}.dolt/migrated_commits.csv{{.EmphasisRight}}. The original store is kept in {{.EmphasisLeft}}.dolt/noms_pre_migration{{.EmphasisRight}} and can be restored with {{.EmphasisLeft}}--rollback{{.EmphasisRight}}.
+
+A repository which has been migrated to a new storage format cannot push to a remote which uses the older storage format.
+
+After the repository's storage format is up to date, branches which predate unique column tags are migrated. Remotes can then be updated with {{.EmphasisLeft}}--push{{.EmphasisRight}}, and remote refs of an already migrated remote can be fetched with {{.EmphasisLeft}}--pull{{.EmphasisRight}}.
+
+Table and column names follow MySQL's rules for identifiers, and are case insensitive. Tables and columns of the working set created before these rules were enforced are reported along with a valid name for each, and {{.EmphasisLeft}}--fix-identifiers{{.EmphasisRight}} renames them in the working set.`,
+	Synopsis: []string{
+		"[--dry-run]",
+		"--rollback",
+		"--push [{{.LessThan}}remote{{.GreaterThan}}]",
+		"--pull [{{.LessThan}}remote{{.GreaterThan}}]",
+		"--fix-identifiers",
+	},
+}
+
 type MigrateCmd struct{}
 
 // Name is returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
@@ -45,6 +76,11 @@ func (cmd MigrateCmd) Name() string {
 	return "migrate"
 }
 
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd MigrateCmd) LocksRepo() bool {
+	return true
+}
+
 // Description returns a description of the command
 func (cmd MigrateCmd) Description() string {
 	return "Executes a repository migration to update to the latest format."
@@ -59,6 +95,9 @@ func (cmd MigrateCmd) createArgParser() *argparser.ArgParser {
 	ap := argparser.NewArgParser()
 	ap.SupportsFlag(migratePushFlag, "", "Push all migrated branches to the remote")
 	ap.SupportsFlag(migratePullFlag, "", "Update all remote refs for a migrated remote")
+	ap.SupportsFlag(migrateDryRunFlag, "", "Report the migrations that are needed and estimate how much data would be rewritten, without changing the repository")
+	ap.SupportsFlag(migrateRollbackFlag, "", "Restore the storage format and data the repository had before its last storage format migration")
+	ap.SupportsFlag(migrateFixIdsFlag, "", "Rename the tables and columns of the working set whose names break the identifier rules")
 	return ap
 }
 
@@ -70,12 +109,17 @@ func (cmd MigrateCmd) EventType() eventsapi.ClientEventType {
 // Exec executes the command
 func (cmd MigrateCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
 	ap := cmd.createArgParser()
-	help, _ := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, pushDocs, ap))
+	help, _ := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, migrateDocs, ap))
 	apr := cli.ParseArgs(ap, args, help)
 
-	if apr.Contains(migratePushFlag) && apr.Contains(migratePullFlag) {
-		cli.PrintErrf(color.RedString("options --%s and --%s are mutually exclusive", migratePushFlag, migratePullFlag))
-		return 1
+	exclusiveFlags := []string{migratePushFlag, migratePullFlag, migrateDryRunFlag, migrateRollbackFlag, migrateFixIdsFlag}
+	for i, f1 := range exclusiveFlags {
+		for _, f2 := range exclusiveFlags[i+1:] {
+			if apr.Contains(f1) && apr.Contains(f2) {
+				cli.PrintErrln(color.RedString("options --%s and --%s are mutually exclusive", f1, f2))
+				return 1
+			}
+		}
 	}
 
 	var err error
@@ -84,6 +128,12 @@ func (cmd MigrateCmd) Exec(ctx context.Context, commandStr string, args []string
 		err = pushMigratedRepo(ctx, dEnv, apr)
 	case apr.Contains(migratePullFlag):
 		err = fetchMigratedRemoteBranches(ctx, dEnv, apr)
+	case apr.Contains(migrateDryRunFlag):
+		err = estimateLocalMigration(ctx, dEnv)
+	case apr.Contains(migrateRollbackFlag):
+		err = rollbackFormatMigration(dEnv)
+	case apr.Contains(migrateFixIdsFlag):
+		err = fixIdentifiers(ctx, dEnv)
 	default:
 		err = migrateLocalRepo(ctx, dEnv)
 	}
@@ -97,6 +147,20 @@ func (cmd MigrateCmd) Exec(ctx context.Context, commandStr string, args []string
 }
 
 func migrateLocalRepo(ctx context.Context, dEnv *env.DoltEnv) error {
+	if migrate.NeedsFormatMigration(dEnv.DoltDB.Format()) {
+		err := migrateRepoFormat(ctx, dEnv)
+
+		if err != nil {
+			return err
+		}
+
+		dEnv = env.Load(ctx, env.GetCurrentUserHomeDir, dEnv.FS, doltdb.LocalDirDoltDB, dEnv.Version)
+
+		if dEnv.DBLoadError != nil {
+			return dEnv.DBLoadError
+		}
+	}
+
 	localMigrationNeeded, err := rebase.NeedsUniqueTagMigration(ctx, dEnv.DoltDB)
 
 	if err != nil {
@@ -114,13 +178,29 @@ func migrateLocalRepo(ctx context.Context, dEnv *env.DoltEnv) error {
 		cli.Println("Repository format is up to date")
 	}
 
+	err = reportIdentifierProblems(ctx, dEnv)
+
+	if err != nil {
+		return err
+	}
+
 	remoteName := "origin"
-	remoteMigrated, err := remoteHasBeenMigrated(ctx, dEnv, remoteName)
+	remoteFormat, err := remoteStorageFormat(ctx, dEnv, remoteName)
 	if err != nil {
 		// if we can't check the remote, exit silently
 		return nil
 	}
 
+	if remoteFormat != dEnv.DoltDB.Format() {
+		cli.Println(fmt.Sprintf("Remote %s uses storage format %s and must be migrated before it can accept pushes from this repository", remoteName, remoteFormat.VersionString()))
+		return nil
+	}
+
+	remoteMigrated, err := remoteHasBeenMigrated(ctx, dEnv, remoteName)
+	if err != nil {
+		return nil
+	}
+
 	if !remoteMigrated {
 		cli.Println(fmt.Sprintf("Remote %s has not been migrated", remoteName))
 		cli.Println(fmt.Sprintf("Run 'dolt migrate --push %s' to update remote", remoteName))
@@ -132,6 +212,162 @@ func migrateLocalRepo(ctx context.Context, dEnv *env.DoltEnv) error {
 	return nil
 }
 
+func migrateRepoFormat(ctx context.Context, dEnv *env.DoltEnv) error {
+	cli.Println(color.YellowString("Migrating repository storage format from %s to %s", dEnv.DoltDB.Format().VersionString(), types.Format_Default.VersionString()))
+
+	wg := &sync.WaitGroup{}
+	progChan := make(chan migrate.Progress, 128)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		migrationProgFunc(progChan)
+	}()
+
+	res, err := migrate.MigrateRepoFormat(ctx, dEnv, progChan)
+	close(progChan)
+	wg.Wait()
+	cli.Println()
+
+	if err != nil {
+		return fmt.Errorf("storage format migration failed, the repository has not been changed: %v", err)
+	}
+
+	cli.Println("Storage format migration complete")
+	if len(res.Commits) > 0 {
+		cli.Printf("%d commits have new hashes, see %s for the mapping from old to new commit hashes\n", len(res.Commits), migrate.CommitMappingFile)
+	}
+	cli.Printf("The original data has been kept in %s, run 'dolt migrate --%s' to restore it\n", migrate.BackupDataDir, migrateRollbackFlag)
+
+	return nil
+}
+
+func migrationProgFunc(progChan chan migrate.Progress) {
+	var latest migrate.Progress
+	last := time.Now()
+	lenPrinted := 0
+	for progress := range progChan {
+		latest = progress
+		if time.Since(last) > 500*time.Millisecond {
+			last = time.Now()
+			lenPrinted = cli.DeleteAndPrint(lenPrinted, fmt.Sprintf("Values written: %s", humanize.Comma(int64(latest.ValuesWritten))))
+		}
+	}
+
+	cli.DeleteAndPrint(lenPrinted, fmt.Sprintf("Values written: %s", humanize.Comma(int64(latest.ValuesWritten))))
+}
+
+func estimateLocalMigration(ctx context.Context, dEnv *env.DoltEnv) error {
+	nbf := dEnv.DoltDB.Format()
+	if migrate.NeedsFormatMigration(nbf) {
+		est, err := migrate.EstimateRepoFormatMigration(ctx, dEnv)
+
+		if err != nil {
+			return err
+		}
+
+		cli.Printf("Repository storage format %s would be migrated to %s\n", nbf.VersionString(), types.Format_Default.VersionString())
+		cli.Printf("%s chunks (%s) reachable from %d refs would be rewritten\n", humanize.Comma(int64(est.Chunks)), humanize.Bytes(est.Bytes), est.Datasets)
+		cli.Println("Unique tags will be checked after the storage format migration")
+		return nil
+	}
+
+	cli.Printf("Repository storage format %s is up to date\n", nbf.VersionString())
+
+	needed, err := rebase.NeedsUniqueTagMigration(ctx, dEnv.DoltDB)
+
+	if err != nil {
+		return err
+	}
+
+	if needed {
+		cli.Println("Branches without unique tags would be migrated")
+	} else {
+		cli.Println("Repository format is up to date")
+	}
+
+	return reportIdentifierProblems(ctx, dEnv)
+}
+
+// reportIdentifierProblems prints the tables and columns of the working set whose names break the identifier rules,
+// and the names they would be given by --fix-identifiers.
+func reportIdentifierProblems(ctx context.Context, dEnv *env.DoltEnv) error {
+	root, err := dEnv.WorkingRoot(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	problems, err := doltdb.CheckIdentifiers(ctx, root)
+
+	if err != nil {
+		return err
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	cli.Println(color.YellowString("Names which break the identifier rules:"))
+	for _, p := range problems {
+		if p.Column == "" {
+			cli.Printf("\ttable %s would be renamed to %s\n", p.Table, p.Suggestion)
+		} else {
+			cli.Printf("\tcolumn %s of table %s would be renamed to %s\n", dsql.QuoteIdentifier(p.Column), p.Table, dsql.QuoteIdentifier(p.Suggestion))
+		}
+
+		cli.Printf("\t\t%s\n", p.Err.Error())
+	}
+
+	cli.Printf("Run 'dolt migrate --%s' to rename them\n", migrateFixIdsFlag)
+	return nil
+}
+
+func fixIdentifiers(ctx context.Context, dEnv *env.DoltEnv) error {
+	root, err := dEnv.WorkingRoot(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	root, problems, err := alterschema.FixIdentifiers(ctx, root)
+
+	if err != nil {
+		return err
+	}
+
+	if len(problems) == 0 {
+		cli.Println("All table and column names follow the identifier rules")
+		return nil
+	}
+
+	err = dEnv.UpdateWorkingRoot(ctx, root)
+
+	if err != nil {
+		return err
+	}
+
+	for _, p := range problems {
+		if p.Column == "" {
+			cli.Printf("Renamed table %s to %s\n", p.Table, p.Suggestion)
+		} else {
+			cli.Printf("Renamed column %s of table %s to %s\n", dsql.QuoteIdentifier(p.Column), p.Table, dsql.QuoteIdentifier(p.Suggestion))
+		}
+	}
+
+	return nil
+}
+
+func rollbackFormatMigration(dEnv *env.DoltEnv) error {
+	err := migrate.RollbackRepoFormatMigration(dEnv)
+
+	if err != nil {
+		return err
+	}
+
+	cli.Println("Restored the repository data from before its storage format migration")
+	return nil
+}
+
 func pushMigratedRepo(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgParseResults) error {
 	localMigrationNeeded, err := rebase.NeedsUniqueTagMigration(ctx, dEnv.DoltDB)
 	if err != nil {
@@ -193,7 +429,7 @@ func pushMigratedRepo(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.Arg
 
 			cli.Println(color.BlueString(fmt.Sprintf("Pushing migrated branch %s to %s", branch.String(), remoteName)))
 			mode := ref.RefUpdateMode{Force: true}
-			err = pushToRemoteBranch(ctx, dEnv, mode, src, dest, remoteRef, dEnv.DoltDB, destDB, remote)
+			err = pushToRemoteBranch(ctx, dEnv, mode, -1, src, dest, remoteRef, dEnv.DoltDB, destDB, remote)
 
 			if err != nil {
 				return err
@@ -232,12 +468,33 @@ func fetchMigratedRemoteBranches(ctx context.Context, dEnv *env.DoltEnv, apr *ar
 	r, refSpecs, err := getRefSpecs(apr.Args(), dEnv, remotes)
 
 	if err == nil {
-		err = fetchRefSpecs(ctx, ref.RefUpdateMode{Force: true}, dEnv, r, refSpecs)
+		err = fetchRefSpecs(ctx, ref.RefUpdateMode{Force: true}, false, dEnv, r, refSpecs)
 	}
 
 	return err
 }
 
+func remoteStorageFormat(ctx context.Context, dEnv *env.DoltEnv, remoteName string) (*types.NomsBinFormat, error) {
+	remotes, err := dEnv.GetRemotes()
+
+	if err != nil {
+		return nil, errors.New("error: failed to read remotes from config.")
+	}
+
+	remote, remoteOK := remotes[remoteName]
+	if !remoteOK {
+		return nil, fmt.Errorf("cannot find remote %s", remoteName)
+	}
+
+	destDB, err := remote.GetRemoteDB(ctx, dEnv.DoltDB.ValueReadWriter().Format())
+
+	if err != nil {
+		return nil, err
+	}
+
+	return destDB.Format(), nil
+}
+
 func remoteHasBeenMigrated(ctx context.Context, dEnv *env.DoltEnv, remoteName string) (bool, error) {
 	remotes, err := dEnv.GetRemotes()
 
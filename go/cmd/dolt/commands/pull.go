@@ -20,6 +20,7 @@ import (
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
 	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
@@ -44,6 +45,11 @@ func (cmd PullCmd) Name() string {
 	return "pull"
 }
 
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd PullCmd) LocksRepo() bool {
+	return true
+}
+
 // Description returns a description of the command
 func (cmd PullCmd) Description() string {
 	return "Fetch from a dolt remote data repository and merge."
@@ -110,7 +116,7 @@ func pullRemoteBranch(ctx context.Context, dEnv *env.DoltEnv, r env.Remote, srcR
 	srcDB, err := r.GetRemoteDB(ctx, dEnv.DoltDB.ValueReadWriter().Format())
 
 	if err != nil {
-		return errhand.BuildDError("error: failed to get remote db").AddCause(err).Build()
+		return AddIncompatibleFormatDetails(errhand.BuildDError("error: failed to get remote db").AddCause(err), err, dEnv).Build()
 	}
 
 	srcDBCommit, verr := fetchRemoteBranch(ctx, dEnv, r, srcDB, dEnv.DoltDB, srcRef, destRef)
@@ -125,5 +131,11 @@ func pullRemoteBranch(ctx context.Context, dEnv *env.DoltEnv, r env.Remote, srcR
 		return errhand.BuildDError("error: fetch failed").AddCause(err).Build()
 	}
 
+	verr = fetchTags(ctx, ref.FastForwardOnly, false, dEnv, r, srcDB, []*doltdb.Commit{srcDBCommit})
+
+	if verr != nil {
+		return verr
+	}
+
 	return mergeBranch(ctx, dEnv, destRef)
 }
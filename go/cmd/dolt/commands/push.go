@@ -17,6 +17,7 @@ package commands
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -28,6 +29,7 @@ import (
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/errcat"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/remotestorage"
 	"github.com/liquidata-inc/dolt/go/libraries/events"
@@ -40,6 +42,10 @@ import (
 const (
 	SetUpstreamFlag = "set-upstream"
 	ForcePushFlag   = "force"
+	PushTagsFlag    = "tags"
+
+	AutoMergeFlag         = "auto-merge"
+	AutoMergeRetriesParam = "auto-merge-retries"
 )
 
 var pushDocs = cli.CommandDocumentationContent{
@@ -51,10 +57,15 @@ When the command line does not specify where to push with the {{.LessThan}}remot
 When the command line does not specify what to push with {{.LessThan}}refspec{{.GreaterThan}}... then the current branch will be used.
 
 When neither the command-line does not specify what to push, the default behavior is used, which corresponds to the current branch being pushed to the corresponding upstream branch, but as a safety measure, the push is aborted if the upstream branch does not have the same name as the local one.
+
+When a branch is pushed, the tags pointing at the pushed commit or any of its ancestors are pushed with it. With {{.EmphasisLeft}}--tags{{.EmphasisRight}} every tag is pushed, and if no refspec is given only the tags are pushed. A single tag can be pushed with the refspec {{.EmphasisLeft}}refs/tags/<tag>{{.EmphasisRight}}, and deleted from the remote with {{.EmphasisLeft}}:refs/tags/<tag>{{.EmphasisRight}}. A tag which already exists on the remote and points at a different commit is only replaced when {{.EmphasisLeft}}--force{{.EmphasisRight}} is given.
+
+With {{.EmphasisLeft}}--auto-merge{{.EmphasisRight}}, a push which is rejected because others have pushed to the remote branch fetches the new head of the remote branch, merges it into the local branch and pushes again, up to {{.EmphasisLeft}}--auto-merge-retries{{.EmphasisRight}} times. This lets writers which change different tables of the same branch push without pulling first. The remote changes are only merged when they can't overlap the local ones: they must change different tables, or different rows of tables whose schemas neither side changed. Otherwise the push is rejected as usual, naming the tables changed on both sides, and the local branch is left as it was. Uncommitted changes to the tables changed by the merge also stop it.
 `,
 
 	Synopsis: []string{
-		"[-u | --set-upstream] [{{.LessThan}}remote{{.GreaterThan}}] [{{.LessThan}}refspec{{.GreaterThan}}]",
+		"[-u | --set-upstream] [--tags] [--auto-merge [--auto-merge-retries {{.LessThan}}n{{.GreaterThan}}]] [{{.LessThan}}remote{{.GreaterThan}}] [{{.LessThan}}refspec{{.GreaterThan}}]",
+		"--tags [{{.LessThan}}remote{{.GreaterThan}}]",
 	},
 }
 
@@ -80,6 +91,9 @@ func (cmd PushCmd) createArgParser() *argparser.ArgParser {
 	ap := argparser.NewArgParser()
 	ap.SupportsFlag(SetUpstreamFlag, "u", "For every branch that is up to date or successfully pushed, add upstream (tracking) reference, used by argument-less {{.EmphasisLeft}}dolt pull{{.EmphasisRight}} and other commands.")
 	ap.SupportsFlag(ForcePushFlag, "f", "Update the remote with local history, overwriting any conflicting history in the remote.")
+	ap.SupportsFlag(PushTagsFlag, "", "Push every tag, rather than only the tags reachable from the pushed commits.")
+	ap.SupportsFlag(AutoMergeFlag, "", "When the push is rejected, merge the remote changes which don't overlap the local ones and push again.")
+	ap.SupportsInt(AutoMergeRetriesParam, "", "n", fmt.Sprintf("The number of times a push with {{.EmphasisLeft}}--auto-merge{{.EmphasisRight}} is retried. Defaults to %d.", actions.DefaultAutoMergeRetries))
 	return ap
 }
 
@@ -97,8 +111,8 @@ func (cmd PushCmd) Exec(ctx context.Context, commandStr string, args []string, d
 	remotes, err := dEnv.GetRemotes()
 
 	if err != nil {
-		cli.PrintErrln("error: failed to read remotes from config.")
-		return 1
+		verr := errhand.BuildDError("error: failed to read remotes from config.").AddCause(err).Build()
+		return HandleVErrAndExitCode(verr, usage)
 	}
 
 	remoteName := "origin"
@@ -107,6 +121,40 @@ func (cmd PushCmd) Exec(ctx context.Context, commandStr string, args []string, d
 	currentBranch := dEnv.RepoState.CWBHeadRef()
 	upstream, hasUpstream := dEnv.RepoState.Branches[currentBranch.GetPath()]
 
+	autoMergeRetries := -1
+	if apr.Contains(AutoMergeFlag) {
+		if apr.Contains(ForcePushFlag) {
+			verr := errhand.BuildDError("error: --%s can't be used with --%s.", AutoMergeFlag, ForcePushFlag).SetPrintUsage().Build()
+			return HandleVErrAndExitCode(verr, usage)
+		}
+
+		autoMergeRetries = apr.GetIntOrDefault(AutoMergeRetriesParam, actions.DefaultAutoMergeRetries)
+
+		if autoMergeRetries < 0 {
+			verr := errhand.BuildDError("error: --%s must not be negative.", AutoMergeRetriesParam).SetPrintUsage().Build()
+			return HandleVErrAndExitCode(verr, usage)
+		}
+	} else if apr.Contains(AutoMergeRetriesParam) {
+		verr := errhand.BuildDError("error: --%s requires --%s.", AutoMergeRetriesParam, AutoMergeFlag).SetPrintUsage().Build()
+		return HandleVErrAndExitCode(verr, usage)
+	}
+
+	// with --tags and no refspec only the tags are pushed
+	if apr.Contains(PushTagsFlag) && apr.NArg() <= 1 {
+		if apr.NArg() == 1 {
+			remoteName = apr.Arg(0)
+		} else if hasUpstream {
+			remoteName = upstream.Remote
+		}
+
+		if _, ok := remotes[remoteName]; ok || apr.NArg() == 0 {
+			verr := pushAllTags(ctx, dEnv, ref.RefUpdateMode{Force: apr.Contains(ForcePushFlag)}, remotes, remoteName)
+			return HandleVErrAndExitCode(verr, usage)
+		}
+
+		remoteName = "origin"
+	}
+
 	var refSpec ref.RefSpec
 	var verr errhand.VerboseError
 	if remoteOK && apr.NArg() == 1 {
@@ -128,21 +176,19 @@ func (cmd PushCmd) Exec(ctx context.Context, commandStr string, args []string, d
 		verr = errhand.BuildDError("error: --set-upstream requires <remote> and <refspec> params.").SetPrintUsage().Build()
 	} else if hasUpstream {
 		if apr.NArg() > 0 {
-			cli.PrintErrf("fatal: upstream branch set for '%s'.  Use 'dolt push' without arguments to push.\n", currentBranch)
-			return 1
+			verr := errhand.BuildDError("fatal: upstream branch set for '%s'.  Use 'dolt push' without arguments to push.", currentBranch).Build()
+			return HandleVErrAndExitCode(verr, usage)
 		}
 
 		if currentBranch.GetPath() != upstream.Merge.Ref.GetPath() {
-			cli.PrintErrln("fatal: The upstream branch of your current branch does not match")
-			cli.PrintErrln("the name of your current branch.  To push to the upstream branch")
-			cli.PrintErrln("on the remote, use")
-			cli.PrintErrln()
-			cli.PrintErrln("\tdolt push origin HEAD:" + currentBranch.GetPath())
-			cli.PrintErrln()
-			cli.PrintErrln("To push to the branch of the same name on the remote, use")
-			cli.PrintErrln()
-			cli.PrintErrln("\tdolt push origin HEAD")
-			return 1
+			verr := errhand.BuildDError("fatal: The upstream branch of your current branch does not match").
+				AddDetails("the name of your current branch.  To push to the upstream branch").
+				AddDetails("on the remote, use\n").
+				AddDetails("\tdolt push origin HEAD:%s\n", currentBranch.GetPath()).
+				AddDetails("To push to the branch of the same name on the remote, use\n").
+				AddDetails("\tdolt push origin HEAD").
+				Build()
+			return HandleVErrAndExitCode(verr, usage)
 		}
 
 		remoteName = upstream.Remote
@@ -154,11 +200,12 @@ func (cmd PushCmd) Exec(ctx context.Context, commandStr string, args []string, d
 				remoteName = defRemote.Name
 			}
 
-			cli.PrintErrln("fatal: The current branch " + currentBranch.GetPath() + " has no upstream branch.")
-			cli.PrintErrln("To push the current branch and set the remote as upstream, use")
-			cli.PrintErrln()
-			cli.PrintErrln("\tdolt push --set-upstream " + remoteName + " " + currentBranch.GetPath())
-			return 1
+			verr := errhand.BuildDError("fatal: The current branch %s has no upstream branch.", currentBranch.GetPath()).
+				AddDetails("To push the current branch and set the remote as upstream, use\n").
+				AddDetails("\tdolt push --set-upstream %s %s", remoteName, currentBranch.GetPath()).
+				SetCategory(errcat.NotFound).
+				Build()
+			return HandleVErrAndExitCode(verr, usage)
 		}
 
 		verr = errhand.BuildDError("").SetPrintUsage().Build()
@@ -167,8 +214,8 @@ func (cmd PushCmd) Exec(ctx context.Context, commandStr string, args []string, d
 	remote, remoteOK = remotes[remoteName]
 
 	if !remoteOK {
-		cli.PrintErrln("fatal: unknown remote " + remoteName)
-		return 1
+		verr := errhand.BuildDError("fatal: unknown remote %s", remoteName).SetCategory(errcat.NotFound).Build()
+		return HandleVErrAndExitCode(verr, usage)
 	}
 
 	if verr == nil {
@@ -177,7 +224,7 @@ func (cmd PushCmd) Exec(ctx context.Context, commandStr string, args []string, d
 		if err != nil {
 			verr = errhand.BuildDError("error: failed to read from db").AddCause(err).Build()
 		} else if !hasRef {
-			verr = errhand.BuildDError("fatal: unknown branch " + currentBranch.GetPath()).Build()
+			verr = errhand.BuildDError("fatal: unknown branch " + currentBranch.GetPath()).SetCategory(errcat.NotFound).Build()
 		} else {
 			src := refSpec.SrcRef(currentBranch)
 			dest := refSpec.DestRef(src)
@@ -186,10 +233,10 @@ func (cmd PushCmd) Exec(ctx context.Context, commandStr string, args []string, d
 			remoteRef, verr = getTrackingRef(dest, remote)
 
 			if verr == nil {
-				destDB, err := remote.GetRemoteDB(ctx, dEnv.DoltDB.ValueReadWriter().Format())
+				destDB, err := remote.GetRemoteDBForPush(ctx, dEnv.DoltDB.ValueReadWriter().Format(), dEnv.PushStateDir())
 
 				if err != nil {
-					bdr := errhand.BuildDError("error: failed to get remote db").AddCause(err)
+					bdr := AddIncompatibleFormatDetails(errhand.BuildDError("error: failed to get remote db").AddCause(err), err, dEnv)
 
 					if err == remotestorage.ErrInvalidDoltSpecPath {
 						urlObj, _ := earl.Parse(remote.Url)
@@ -204,15 +251,26 @@ func (cmd PushCmd) Exec(ctx context.Context, commandStr string, args []string, d
 					}
 
 					verr = bdr.Build()
+				} else if dest.GetType() == ref.TagRefType {
+					if src == ref.EmptyBranchRef {
+						verr = deleteRemoteTag(ctx, dest.(ref.TagRef), destDB, remote)
+					} else {
+						updateMode := ref.RefUpdateMode{Force: apr.Contains(ForcePushFlag)}
+						verr = pushTagRef(ctx, dEnv, updateMode, src, dest.(ref.TagRef), dEnv.DoltDB, destDB, remote)
+					}
 				} else if src == ref.EmptyBranchRef {
 					verr = deleteRemoteBranch(ctx, dest, remoteRef, dEnv.DoltDB, destDB, remote)
 				} else {
 					updateMode := ref.RefUpdateMode{Force: apr.Contains(ForcePushFlag)}
-					verr = pushToRemoteBranch(ctx, dEnv, updateMode, src, dest, remoteRef, dEnv.DoltDB, destDB, remote)
+					verr = pushToRemoteBranch(ctx, dEnv, updateMode, autoMergeRetries, src, dest, remoteRef, dEnv.DoltDB, destDB, remote)
+
+					if verr == nil {
+						verr = pushTagsForBranch(ctx, dEnv, updateMode, apr.Contains(PushTagsFlag), src, dEnv.DoltDB, destDB, remote)
+					}
 				}
 			}
 
-			if verr == nil && apr.Contains(SetUpstreamFlag) {
+			if verr == nil && apr.Contains(SetUpstreamFlag) && dest.GetType() == ref.BranchRefType {
 				dEnv.RepoState.Branches[src.GetPath()] = env.BranchConfig{
 					Merge:  ref.MarshalableRef{Ref: dest},
 					Remote: remoteName,
@@ -258,7 +316,142 @@ func deleteRemoteBranch(ctx context.Context, toDelete, remoteRef ref.DoltRef, lo
 	return nil
 }
 
-func pushToRemoteBranch(ctx context.Context, dEnv *env.DoltEnv, mode ref.RefUpdateMode, srcRef, destRef, remoteRef ref.DoltRef, localDB, remoteDB *doltdb.DoltDB, remote env.Remote) errhand.VerboseError {
+func deleteRemoteTag(ctx context.Context, toDelete ref.TagRef, remoteDB *doltdb.DoltDB, remote env.Remote) errhand.VerboseError {
+	err := actions.DeleteRemoteTag(ctx, toDelete, remoteDB)
+
+	if err == doltdb.ErrTagNotFound {
+		return errhand.BuildDError("error: unable to delete '%s': remote ref does not exist", toDelete.String()).Build()
+	} else if err != nil {
+		return errhand.BuildDError("error: failed to delete '%s' from remote '%s'", toDelete.String(), remote.Name).AddCause(err).Build()
+	}
+
+	cli.Printf("To %s\n", remote.Url)
+	cli.Printf(" - [deleted]         %s\n", toDelete.GetPath())
+
+	return nil
+}
+
+func pushAllTags(ctx context.Context, dEnv *env.DoltEnv, mode ref.RefUpdateMode, remotes map[string]env.Remote, remoteName string) errhand.VerboseError {
+	remote, ok := remotes[remoteName]
+
+	if !ok {
+		return errhand.BuildDError("fatal: unknown remote " + remoteName).SetCategory(errcat.NotFound).Build()
+	}
+
+	destDB, err := remote.GetRemoteDBForPush(ctx, dEnv.DoltDB.ValueReadWriter().Format(), dEnv.PushStateDir())
+
+	if err != nil {
+		return AddIncompatibleFormatDetails(errhand.BuildDError("error: failed to get remote db").AddCause(err), err, dEnv).Build()
+	}
+
+	tags, err := actions.GetTags(ctx, dEnv.DoltDB)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to read tags").AddCause(err).Build()
+	}
+
+	changed, verr := pushTags(ctx, dEnv, mode, tags, dEnv.DoltDB, destDB, remote)
+
+	if verr == nil && !changed {
+		cli.Println("Everything up-to-date")
+	}
+
+	return verr
+}
+
+// pushTagsForBranch pushes the tags reachable from the head of the branch given, or every tag if |allTags| is true.
+func pushTagsForBranch(ctx context.Context, dEnv *env.DoltEnv, mode ref.RefUpdateMode, allTags bool, srcRef ref.DoltRef, localDB, remoteDB *doltdb.DoltDB, remote env.Remote) errhand.VerboseError {
+	var tags []actions.Tag
+	var err error
+	if allTags {
+		tags, err = actions.GetTags(ctx, localDB)
+	} else {
+		cs, _ := doltdb.NewCommitSpec("HEAD", srcRef.GetPath())
+		var cm *doltdb.Commit
+		cm, err = localDB.Resolve(ctx, cs)
+
+		if err == nil {
+			tags, err = actions.GetTagsReachableFrom(ctx, localDB, []*doltdb.Commit{cm})
+		}
+	}
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to read tags").AddCause(err).Build()
+	}
+
+	_, verr := pushTags(ctx, dEnv, mode, tags, localDB, remoteDB, remote)
+	return verr
+}
+
+func pushTagRef(ctx context.Context, dEnv *env.DoltEnv, mode ref.RefUpdateMode, srcRef ref.DoltRef, destRef ref.TagRef, localDB, remoteDB *doltdb.DoltDB, remote env.Remote) errhand.VerboseError {
+	cs, _ := doltdb.NewCommitSpec("HEAD", srcRef.String())
+	cm, err := localDB.Resolve(ctx, cs)
+
+	if err != nil {
+		return errhand.BuildDError("error: src refspec %s does not match any.", srcRef.GetPath()).Build()
+	}
+
+	changed, verr := pushTags(ctx, dEnv, mode, []actions.Tag{{Ref: destRef, Commit: cm}}, localDB, remoteDB, remote)
+
+	if verr == nil && !changed {
+		cli.Println("Everything up-to-date")
+	}
+
+	return verr
+}
+
+// pushTags pushes each of the tags given, printing a line for every tag which is created or replaced on the remote, and
+// returns whether there were any such tags. A tag which is rejected because it already exists on the remote doesn't
+// stop the others from being pushed.
+func pushTags(ctx context.Context, dEnv *env.DoltEnv, mode ref.RefUpdateMode, tags []actions.Tag, localDB, remoteDB *doltdb.DoltDB, remote env.Remote) (bool, errhand.VerboseError) {
+	printedURL := false
+	printRef := func(format string, args ...interface{}) {
+		if !printedURL {
+			cli.Printf("To %s\n", remote.Url)
+			printedURL = true
+		}
+
+		cli.Printf(format, args...)
+	}
+
+	changed := false
+	rejected := false
+	for _, tag := range tags {
+		wg, progChan, pullerEventCh := runProgFuncs()
+		err := actions.PushTag(ctx, dEnv, mode, tag, localDB, remoteDB, progChan, pullerEventCh)
+		stopProgFuncs(wg, progChan, pullerEventCh)
+
+		switch err {
+		case nil:
+			changed = true
+			if mode.Force {
+				printRef(" + [forced update]   %s -> %s\n", tag.Ref.GetPath(), tag.Ref.GetPath())
+			} else {
+				printRef(" * [new tag]         %s -> %s\n", tag.Ref.GetPath(), tag.Ref.GetPath())
+			}
+		case doltdb.ErrUpToDate:
+		case actions.ErrTagExists:
+			rejected = true
+			printRef(" ! [rejected]        %s -> %s (already exists)\n", tag.Ref.GetPath(), tag.Ref.GetPath())
+		default:
+			return changed, AddIncompatibleFormatDetails(errhand.BuildDError("error: failed to push tag '%s'", tag.Ref.GetPath()).AddCause(err), err, dEnv).Build()
+		}
+	}
+
+	if rejected {
+		return changed, errhand.BuildDError("error: failed to push some refs to '%s'", remote.Url).
+			AddDetails("hint: Updates were rejected because the tag already exists in the remote.").
+			AddDetails("hint: Use 'dolt push --force' to replace it.").
+			SetCategory(errcat.Conflict).
+			Build()
+	}
+
+	return changed, nil
+}
+
+// pushToRemoteBranch pushes the head of |srcRef| to |destRef|. When |autoMergeRetries| isn't negative, a push which is
+// rejected is retried up to that many times after merging the remote changes, as it is by actions.PushWithAutoMerge.
+func pushToRemoteBranch(ctx context.Context, dEnv *env.DoltEnv, mode ref.RefUpdateMode, autoMergeRetries int, srcRef, destRef, remoteRef ref.DoltRef, localDB, remoteDB *doltdb.DoltDB, remote env.Remote) errhand.VerboseError {
 	evt := events.GetEventFromContext(ctx)
 
 	u, err := earl.Parse(remote.Url)
@@ -269,29 +462,77 @@ func pushToRemoteBranch(ctx context.Context, dEnv *env.DoltEnv, mode ref.RefUpda
 		}
 	}
 
+	if localDB.Format() != remoteDB.Format() {
+		return errhand.BuildDError("error: remote '%s' uses storage format %s but this repository uses storage format %s", remote.Name, remoteDB.Format().VersionString(), localDB.Format().VersionString()).
+			AddDetails("The remote must be migrated to storage format %s before it can accept pushes from this repository.", localDB.Format().VersionString()).Build()
+	}
+
 	cs, _ := doltdb.NewCommitSpec("HEAD", srcRef.GetPath())
 	cm, err := localDB.Resolve(ctx, cs)
 
 	if err != nil {
 		return errhand.BuildDError("error: unable to find %v", srcRef.GetPath()).Build()
 	} else {
+		srcBranch, isBranch := srcRef.(ref.BranchRef)
+
+		if autoMergeRetries >= 0 && !isBranch {
+			return errhand.BuildDError("error: --%s requires a branch to push, not %s", AutoMergeFlag, srcRef.String()).Build()
+		}
+
+		merges := 0
 		wg, progChan, pullerEventCh := runProgFuncs()
-		err = actions.Push(ctx, dEnv, mode, destRef.(ref.BranchRef), remoteRef.(ref.RemoteRef), localDB, remoteDB, cm, progChan, pullerEventCh)
+		if autoMergeRetries >= 0 {
+			_, merges, err = actions.PushWithAutoMerge(ctx, dEnv, srcBranch, destRef.(ref.BranchRef), remoteRef.(ref.RemoteRef), localDB, remoteDB, cm, autoMergeRetries, progChan, pullerEventCh)
+		} else {
+			err = actions.Push(ctx, dEnv, mode, destRef.(ref.BranchRef), remoteRef.(ref.RemoteRef), localDB, remoteDB, cm, progChan, pullerEventCh)
+		}
 		stopProgFuncs(wg, progChan, pullerEventCh)
 
+		if merges > 0 {
+			cli.Printf("Merged the changes pushed to %s into %s %s\n", remoteRef.GetPath(), srcRef.GetPath(), pluralize("time", "times", uint64(merges)))
+		}
+
 		if err != nil {
 			if err == doltdb.ErrUpToDate {
 				cli.Println("Everything up-to-date")
-			} else if err == doltdb.ErrIsAhead || err == actions.ErrCantFF || err == datas.ErrMergeNeeded {
+			} else if actions.IsOverlappingChanges(err) {
 				cli.Printf("To %s\n", remote.Url)
 				cli.Printf("! [rejected]          %s -> %s (non-fast-forward)\n", destRef.String(), remoteRef.String())
-				cli.Printf("error: failed to push some refs to '%s'\n", remote.Url)
-				cli.Println("hint: Updates were rejected because the tip of your current branch is behind")
-				cli.Println("hint: its remote counterpart. Integrate the remote changes (e.g.")
-				cli.Println("hint: 'dolt pull ...') before pushing again.")
-				return errhand.BuildDError("").Build()
+				bdr := errhand.BuildDError("error: failed to push some refs to '%s'", remote.Url).SetCategory(errcat.Conflict)
+				bdr.AddDetails("hint: The remote changes could not be merged automatically because they overlap")
+				bdr.AddDetails("hint: the local changes to: %s", strings.Join(actions.OverlappingChangesTables(err), ", "))
+				bdr.AddDetails("hint: Integrate the remote changes (e.g. 'dolt pull ...') before pushing again.")
+				return bdr.Build()
+			} else if actions.IsConcurrentModification(err) {
+				bdr := errhand.BuildDError("error: the remote changes could not be merged into %s", srcRef.GetPath()).SetCategory(errcat.Conflict)
+
+				if tables := actions.ConcurrentModificationTables(err); len(tables) > 0 {
+					bdr.AddDetails("hint: They change tables with uncommitted changes: %s", strings.Join(tables, ", "))
+					bdr.AddDetails("hint: Commit or reset the changes before pushing again.")
+				}
+
+				return bdr.Build()
+			} else if err == actions.ErrAutoMergeMergeActive {
+				return errhand.BuildDError("error: %s", err.Error()).AddDetails("hint: Commit or abort the merge before pushing again.").Build()
+			} else if actions.IsPushRejected(err) {
+				cli.Printf("To %s\n", remote.Url)
+				cli.Printf("! [rejected]          %s -> %s (non-fast-forward)\n", destRef.String(), remoteRef.String())
+				bdr := errhand.BuildDError("error: failed to push some refs to '%s'", remote.Url).SetCategory(errcat.Conflict)
+
+				if rewritten, _ := localDB.HasRef(ctx, ref.NewBackupRef(srcRef)); rewritten {
+					bdr.AddDetails("hint: The history of %s was rewritten by 'dolt admin rewrite-history'.", srcRef.GetPath())
+					bdr.AddDetails("hint: Use 'dolt push --force' to replace the history of the remote branch. Everyone")
+					bdr.AddDetails("hint: else using the remote must then clone it again, as pulling or pushing from a")
+					bdr.AddDetails("hint: clone with the original history brings the removed data back.")
+				} else {
+					bdr.AddDetails("hint: Updates were rejected because the tip of your current branch is behind")
+					bdr.AddDetails("hint: its remote counterpart. Integrate the remote changes (e.g.")
+					bdr.AddDetails("hint: 'dolt pull ...') before pushing again.")
+				}
+
+				return bdr.Build()
 			} else {
-				return errhand.BuildDError("error: push failed").AddCause(err).Build()
+				return AddIncompatibleFormatDetails(errhand.BuildDError("error: push failed").AddCause(err), err, dEnv).Build()
 			}
 		}
 	}
@@ -21,14 +21,20 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/fatih/color"
 
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
 	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/errcat"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/remotestorage"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/config"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/earl"
@@ -44,7 +50,7 @@ var remoteDocs = cli.CommandDocumentationContent{
 {{.EmphasisLeft}}add{{.EmphasisRight}}
 Adds a remote named {{.LessThan}}name{{.GreaterThan}} for the repository at {{.LessThan}}url{{.GreaterThan}}. The command dolt fetch {{.LessThan}}name{{.GreaterThan}} can then be used to create and update remote-tracking branches {{.EmphasisLeft}}<name>/<branch>{{.EmphasisRight}}.
 
-The {{.LessThan}}url{{.GreaterThan}} parameter supports url schemes of http, https, aws, gs, and file.  If a url scheme does not prefix the url then https is assumed.  If the {{.LessThan}}url{{.GreaterThan}} paramenter is in the format {{.EmphasisLeft}}<organization>/<repository>{{.EmphasisRight}} then dolt will use the {{.EmphasisLeft}}remotes.default_host{{.EmphasisRight}} from your configuration file (Which will be dolthub.com unless changed).
+The {{.LessThan}}url{{.GreaterThan}} parameter supports url schemes of http, https, aws, gs, file, and ssh.  If a url scheme does not prefix the url then https is assumed.  If the {{.LessThan}}url{{.GreaterThan}} paramenter is in the format {{.EmphasisLeft}}<organization>/<repository>{{.EmphasisRight}} then dolt will use the {{.EmphasisLeft}}remotes.default_host{{.EmphasisRight}} from your configuration file (Which will be dolthub.com unless changed).
 
 AWS cloud remote urls should be of the form {{.EmphasisLeft}}aws://[dynamo-table:s3-bucket]/database{{.EmphasisRight}}.  You may configure your aws cloud remote using the optional parameters {{.EmphasisLeft}}aws-region{{.EmphasisRight}}, {{.EmphasisLeft}}aws-creds-type{{.EmphasisRight}}, {{.EmphasisLeft}}aws-creds-file{{.EmphasisRight}}.
 
@@ -57,13 +63,19 @@ aws-creds-type specifies the means by which credentials should be retrieved in o
 GCP remote urls should be of the form gs://gcs-bucket/database and will use the credentials setup using the gcloud command line available from Google +
 
 The local filesystem can be used as a remote by providing a repository url in the format file://absolute path. See https://en.wikipedia.org/wiki/File_URI_schemethi
+
+SSH remote urls should be of the form {{.EmphasisLeft}}ssh://[user@]host[:port]/path{{.EmphasisRight}}, where path is the path of a dolt repository on the host, or one starting with {{.EmphasisLeft}}/~/{{.EmphasisRight}} for a path relative to the user's home directory. Dolt connects to the host and runs {{.EmphasisLeft}}dolt remote-serve --stdio{{.EmphasisRight}} there, so dolt must be installed on the host. The host's key is checked against {{.EmphasisLeft}}~/.ssh/known_hosts{{.EmphasisRight}}, or the file given by {{.EmphasisLeft}}ssh-known-hosts{{.EmphasisRight}}, and the user is authenticated with the keys of ssh-agent and the unencrypted keys in {{.EmphasisLeft}}~/.ssh{{.EmphasisRight}}, or with the key file given by {{.EmphasisLeft}}ssh-identity{{.EmphasisRight}}.
 {{.EmphasisLeft}}remove{{.EmphasisRight}}, {{.EmphasisLeft}}rm{{.EmphasisRight}}, 
-Remove the remote named {{.LessThan}}name{{.GreaterThan}}. All remote-tracking branches and configuration settings for the remote are removed.`,
+Remove the remote named {{.LessThan}}name{{.GreaterThan}}. All remote-tracking branches and configuration settings for the remote are removed.
+
+{{.EmphasisLeft}}check{{.EmphasisRight}}
+Checks that the remote named {{.LessThan}}name{{.GreaterThan}} can be reached and read from, and prints how long each step of the check took. For remotes served over http or https the host is resolved and connected to, the tls handshake is performed, the credentials are checked with a request for the repository's metadata, the root of the repository is read, and finally a chunk is read from the storage location sent by the remote. The check stops at the first step which fails. Other remotes are checked by reading the root of the repository from their storage.`,
 
 	Synopsis: []string{
 		"[-v | --verbose]",
-		"add [--aws-region {{.LessThan}}region{{.GreaterThan}}] [--aws-creds-type {{.LessThan}}creds-type{{.GreaterThan}}] [--aws-creds-file {{.LessThan}}file{{.GreaterThan}}] [--aws-creds-profile {{.LessThan}}profile{{.GreaterThan}}] {{.LessThan}}name{{.GreaterThan}} {{.LessThan}}url{{.GreaterThan}}",
+		"add [--aws-region {{.LessThan}}region{{.GreaterThan}}] [--aws-creds-type {{.LessThan}}creds-type{{.GreaterThan}}] [--aws-creds-file {{.LessThan}}file{{.GreaterThan}}] [--aws-creds-profile {{.LessThan}}profile{{.GreaterThan}}] [--ssh-identity {{.LessThan}}file{{.GreaterThan}}] [--ssh-known-hosts {{.LessThan}}file{{.GreaterThan}}] {{.LessThan}}name{{.GreaterThan}} {{.LessThan}}url{{.GreaterThan}}",
 		"remove {{.LessThan}}name{{.GreaterThan}}",
+		"check {{.LessThan}}name{{.GreaterThan}}",
 	},
 }
 
@@ -71,9 +83,11 @@ const (
 	addRemoteId         = "add"
 	removeRemoteId      = "remove"
 	removeRemoteShortId = "rm"
+	checkRemoteId       = "check"
 )
 
 var awsParams = []string{dbfactory.AWSRegionParam, dbfactory.AWSCredsTypeParam, dbfactory.AWSCredsFileParam, dbfactory.AWSCredsProfile}
+var sshParams = []string{dbfactory.SSHIdentityParam, dbfactory.SSHKnownHostsParam}
 var credTypes = []string{dbfactory.RoleCS.String(), dbfactory.EnvCS.String(), dbfactory.FileCS.String()}
 
 type RemoteCmd struct{}
@@ -83,6 +97,11 @@ func (cmd RemoteCmd) Name() string {
 	return "remote"
 }
 
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd RemoteCmd) LocksRepo() bool {
+	return true
+}
+
 // Description returns a description of the command
 func (cmd RemoteCmd) Description() string {
 	return "Manage set of tracked repositories."
@@ -104,6 +123,7 @@ func (cmd RemoteCmd) createArgParser() *argparser.ArgParser {
 	ap.SupportsValidatedString(dbfactory.AWSCredsTypeParam, "", "creds-type", "", argparser.ValidatorFromStrList(dbfactory.AWSCredsTypeParam, credTypes))
 	ap.SupportsString(dbfactory.AWSCredsFileParam, "", "file", "AWS credentials file")
 	ap.SupportsString(dbfactory.AWSCredsProfile, "", "profile", "AWS profile to use")
+	addSSHArgs(ap)
 	return ap
 }
 
@@ -129,6 +149,8 @@ func (cmd RemoteCmd) Exec(ctx context.Context, commandStr string, args []string,
 		verr = removeRemote(ctx, dEnv, apr)
 	case apr.Arg(0) == removeRemoteShortId:
 		verr = removeRemote(ctx, dEnv, apr)
+	case apr.Arg(0) == checkRemoteId:
+		verr = checkRemote(ctx, dEnv, apr)
 	default:
 		verr = errhand.BuildDError("").SetPrintUsage().Build()
 	}
@@ -150,7 +172,7 @@ func removeRemote(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgPars
 	}
 
 	if _, ok := remotes[old]; !ok {
-		return errhand.BuildDError("error: unknown remote " + old).Build()
+		return errhand.BuildDError("error: unknown remote " + old).SetCategory(errcat.NotFound).Build()
 	}
 
 	refs, err := dEnv.DoltDB.GetRefsOfType(ctx, map[ref.RefType]struct{}{ref.RemoteRefType: {}})
@@ -181,6 +203,50 @@ func removeRemote(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgPars
 	return nil
 }
 
+func checkRemote(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgParseResults) errhand.VerboseError {
+	if apr.NArg() != 2 {
+		return errhand.BuildDError("").SetPrintUsage().Build()
+	}
+
+	name := strings.TrimSpace(apr.Arg(1))
+
+	remotes, err := dEnv.GetRemotes()
+
+	if err != nil {
+		return errhand.BuildDError("error: unable to read remotes").Build()
+	}
+
+	r, ok := remotes[name]
+
+	if !ok {
+		return errhand.BuildDError("error: unknown remote " + name).SetCategory(errcat.NotFound).Build()
+	}
+
+	res, err := dbfactory.HealthCheck(ctx, dEnv.DoltDB.ValueReadWriter().Format(), r.Url, r.Params)
+
+	if err != nil {
+		return errhand.BuildDError("error: '%s' is not valid.", r.Url).AddCause(err).Build()
+	}
+
+	for _, p := range res.Phases {
+		switch {
+		case p.Skipped:
+			cli.Printf("%-16s skipped  %s\n", p.Phase, p.Note)
+		case p.Err != nil:
+			cli.Printf("%-16s %s   %v\n", p.Phase, color.RedString("failed"), p.Duration.Round(time.Microsecond))
+		default:
+			cli.Printf("%-16s %s       %v\n", p.Phase, color.GreenString("ok"), p.Duration.Round(time.Microsecond))
+		}
+	}
+
+	if err := res.Err(); err != nil {
+		hce := err.(*remotestorage.HealthCheckError)
+		return errhand.BuildDError("error: remote '%s' failed the %s check", name, hce.Phase).AddCause(hce.Err).Build()
+	}
+
+	return nil
+}
+
 func getAbsRemoteUrl(fs filesys.Filesys, cfg config.ReadableConfig, urlArg string) (string, string, error) {
 	u, err := earl.Parse(urlArg)
 
@@ -292,9 +358,44 @@ func parseRemoteArgs(apr *argparser.ArgParseResults, scheme, remoteUrl string) (
 		verr = verifyNoAwsParams(apr)
 	}
 
+	if verr == nil {
+		if scheme == dbfactory.SSHScheme {
+			addSSHParams(apr, params)
+		} else {
+			verr = verifyNoSSHParams(apr)
+		}
+	}
+
 	return params, verr
 }
 
+func addSSHArgs(ap *argparser.ArgParser) {
+	ap.SupportsString(dbfactory.SSHIdentityParam, "", "file", "Private key file to authenticate to an ssh remote with, instead of the keys of ssh-agent and ~/.ssh.")
+	ap.SupportsString(dbfactory.SSHKnownHostsParam, "", "file", "Known hosts file to check the key of an ssh remote's host against, instead of ~/.ssh/known_hosts.")
+}
+
+func addSSHParams(apr *argparser.ArgParseResults, params map[string]string) {
+	for _, p := range sshParams {
+		if val, ok := apr.GetValue(p); ok {
+			params[p] = val
+		}
+	}
+}
+
+func verifyNoSSHParams(apr *argparser.ArgParseResults) errhand.VerboseError {
+	if sshParamVals := apr.GetValues(sshParams...); len(sshParamVals) > 0 {
+		keys := make([]string, 0, len(sshParamVals))
+		for k := range sshParamVals {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+		return errhand.BuildDError("The parameters %s, are only valid for ssh remotes", strings.Join(keys, ",")).SetPrintUsage().Build()
+	}
+
+	return nil
+}
+
 func addAWSParams(remoteUrl string, apr *argparser.ArgParseResults, params map[string]string) errhand.VerboseError {
 	isAWS := strings.HasPrefix(remoteUrl, "aws")
 
@@ -0,0 +1,140 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/remotestream"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+const stdioFlag = "stdio"
+
+var remoteServeDocs = cli.CommandDocumentationContent{
+	ShortDesc: "Serve a database as a remote over stdin and stdout",
+	LongDesc: `Serves the database at {{.LessThan}}path{{.GreaterThan}} as a dolt remote, speaking the remote protocol over stdin and stdout. This is run on the host of an {{.EmphasisLeft}}ssh://{{.EmphasisRight}} remote, and isn't meant to be run by hand.
+
+{{.LessThan}}path{{.GreaterThan}} is the directory of a dolt repository, or of the noms files of a database, which is created if it doesn't exist. A path starting with {{.EmphasisLeft}}~/{{.EmphasisRight}} is relative to the home directory.
+`,
+	Synopsis: []string{
+		"--stdio {{.LessThan}}path{{.GreaterThan}}",
+	},
+}
+
+type RemoteServeCmd struct{}
+
+// Name is returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd RemoteServeCmd) Name() string {
+	return "remote-serve"
+}
+
+// Description returns a description of the command
+func (cmd RemoteServeCmd) Description() string {
+	return "Serve a database as a remote over stdin and stdout."
+}
+
+// RequiresRepo should return false if this interface is implemented, and the command does not have the requirement
+// that it be run from within a data repository directory
+func (cmd RemoteServeCmd) RequiresRepo() bool {
+	return false
+}
+
+// Hidden should return true if this command should be hidden from the help text
+func (cmd RemoteServeCmd) Hidden() bool {
+	return true
+}
+
+// CreateMarkdown creates a markdown file containing the helptext for the command at the given path
+func (cmd RemoteServeCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) error {
+	return nil
+}
+
+func (cmd RemoteServeCmd) createArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"path", "The directory of the database to serve."})
+	ap.SupportsFlag(stdioFlag, "", "Speak the remote protocol over stdin and stdout.")
+	return ap
+}
+
+// Exec executes the command
+func (cmd RemoteServeCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cmd.createArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, remoteServeDocs, ap))
+	apr := cli.ParseArgs(ap, args, help)
+
+	if !apr.Contains(stdioFlag) || apr.NArg() != 1 {
+		usage()
+		return 1
+	}
+
+	dir, verr := remoteServeDir(dEnv, apr.Arg(0))
+
+	if verr == nil {
+		verr = serveStdio(ctx, dir)
+	}
+
+	return HandleVErrAndExitCode(verr, usage)
+}
+
+// remoteServeDir returns the directory of the noms files of the database at |path|, creating it if it doesn't exist.
+func remoteServeDir(dEnv *env.DoltEnv, path string) (string, errhand.VerboseError) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := dEnv.GetUserHomeDir()
+
+		if err != nil {
+			return "", errhand.BuildDError("error: failed to get the home directory").AddCause(err).Build()
+		}
+
+		path = filepath.Join(home, path[1:])
+	}
+
+	// a dolt repository is served from its noms directory
+	if info, err := os.Stat(filepath.Join(path, dbfactory.DoltDataDir)); err == nil && info.IsDir() {
+		return filepath.Join(path, dbfactory.DoltDataDir), nil
+	}
+
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		return "", errhand.BuildDError("error: failed to create '%s'", path).AddCause(err).Build()
+	}
+
+	return path, nil
+}
+
+func serveStdio(ctx context.Context, dir string) errhand.VerboseError {
+	// stdout is redirected while commands run, so that nothing printed by accident can break the protocol
+	stdout := os.Stdout
+	if cli.ExecuteWithStdioRestored != nil {
+		cli.ExecuteWithStdioRestored(func() {
+			stdout = os.Stdout
+		})
+	}
+
+	err := remotestream.NewServer(dir).Serve(ctx, remotestream.NewConn(os.Stdin, stdout, nil))
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to serve '%s'", dir).AddCause(err).Build()
+	}
+
+	return nil
+}
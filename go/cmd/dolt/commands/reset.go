@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
 
@@ -33,6 +34,7 @@ import (
 const (
 	SoftResetParam = "soft"
 	HardResetParam = "hard"
+	UndoResetParam = "undo"
 )
 
 var resetDocContent = cli.CommandDocumentationContent{
@@ -49,11 +51,15 @@ var resetDocContent = cli.CommandDocumentationContent{
 	contents out of the staged tables to the working tables.
 
 dolt reset .
-	This form resets {{.EmphasisLeft}}all{{.EmphasisRight}} staged tables to their values at HEAD. It is the opposite of {{.EmphasisLeft}}dolt add .{{.EmphasisRight}}`,
+	This form resets {{.EmphasisLeft}}all{{.EmphasisRight}} staged tables to their values at HEAD. It is the opposite of {{.EmphasisLeft}}dolt add .{{.EmphasisRight}}
+
+dolt reset --undo
+	Commands which discard changes to the working tables, such as {{.EmphasisLeft}}dolt reset --hard{{.EmphasisRight}}, {{.EmphasisLeft}}dolt checkout <tables>{{.EmphasisRight}} and {{.EmphasisLeft}}dolt merge --abort{{.EmphasisRight}}, first back up the working and staged tables. This form restores the most recent backup, backing up the tables it replaces so that running it again undoes it. Backups are kept for the duration given by the {{.EmphasisLeft}}backup.retention{{.EmphasisRight}} config value, 168h by default.`,
 
 	Synopsis: []string{
 		"{{.LessThan}}tables{{.GreaterThan}}...",
 		"[--hard | --soft]",
+		"--undo",
 	},
 }
 
@@ -64,6 +70,11 @@ func (cmd ResetCmd) Name() string {
 	return "reset"
 }
 
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd ResetCmd) LocksRepo() bool {
+	return true
+}
+
 // Description returns a description of the command
 func (cmd ResetCmd) Description() string {
 	return "Remove table changes from the list of staged table changes."
@@ -79,6 +90,7 @@ func (cmd ResetCmd) createArgParser() *argparser.ArgParser {
 	ap := argparser.NewArgParser()
 	ap.SupportsFlag(HardResetParam, "", "Resets the working tables and staged tables. Any changes to tracked tables in the working tree since {{.LessThan}}commit{{.GreaterThan}} are discarded.")
 	ap.SupportsFlag(SoftResetParam, "", "Does not touch the working tables, but removes all tables staged to be committed.")
+	ap.SupportsFlag(UndoResetParam, "", "Restores the working and staged tables backed up by the last command which discarded changes to them.")
 	return ap
 }
 
@@ -97,6 +109,8 @@ func (cmd ResetCmd) Exec(ctx context.Context, commandStr string, args []string,
 	if verr == nil {
 		if apr.ContainsAll(HardResetParam, SoftResetParam) {
 			verr = errhand.BuildDError("error: --%s and --%s are mutually exclusive options.", HardResetParam, SoftResetParam).Build()
+		} else if apr.Contains(UndoResetParam) {
+			verr = resetUndo(ctx, dEnv, apr)
 		} else if apr.Contains(HardResetParam) {
 			verr = resetHard(ctx, dEnv, apr, workingRoot, headRoot)
 		} else {
@@ -148,6 +162,12 @@ func resetHard(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgParseRe
 		}
 	}
 
+	verr := backupWorkingSet(ctx, dEnv, "reset --hard")
+
+	if verr != nil {
+		return verr
+	}
+
 	// TODO: update working and staged in one repo_state write.
 	err = dEnv.UpdateWorkingRoot(ctx, newWkRoot)
 
@@ -169,6 +189,49 @@ func resetHard(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgParseRe
 	return nil
 }
 
+// backupWorkingSet backs up the working and staged roots before |command| discards changes to them, and tells the user
+// how to restore them.
+func backupWorkingSet(ctx context.Context, dEnv *env.DoltEnv, command string) errhand.VerboseError {
+	backedUp, err := dEnv.BackupWorkingSet(ctx, command)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to back up the working set.").AddCause(err).Build()
+	}
+
+	if backedUp {
+		cli.Printf("Backed up the working set before %s. Run 'dolt reset --%s' to restore it.\n", command, UndoResetParam)
+	}
+
+	return nil
+}
+
+func resetUndo(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgParseResults) errhand.VerboseError {
+	if apr.Contains(HardResetParam) || apr.Contains(SoftResetParam) {
+		return errhand.BuildDError("error: --%s can't be used with --%s or --%s.", UndoResetParam, HardResetParam, SoftResetParam).Build()
+	}
+
+	if apr.NArg() != 0 {
+		return errhand.BuildDError("--%s does not support additional params", UndoResetParam).SetPrintUsage().Build()
+	}
+
+	wb, err := dEnv.RestoreWorkingBackup(ctx, "reset --"+UndoResetParam)
+
+	if err == env.ErrNoWorkingBackup {
+		return errhand.BuildDError("error: %s.", err.Error()).Build()
+	} else if err != nil {
+		return errhand.BuildDError("error: failed to restore the working set backup.").AddCause(err).Build()
+	}
+
+	err = actions.SaveTrackedDocsFromWorking(ctx, dEnv)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to update docs on the filesystem.").AddCause(err).Build()
+	}
+
+	cli.Printf("Restored the working set as it was before %s at %s.\n", wb.Command, wb.Time.Local().Format(time.RFC1123))
+	return nil
+}
+
 // RemoveDocsTbl takes a slice of table names and returns a new slice with DocTableName removed.
 func RemoveDocsTbl(tbls []string) []string {
 	var result []string
@@ -249,6 +312,8 @@ func printNotStaged(ctx context.Context, dEnv *env.DoltEnv, staged *doltdb.RootV
 		return
 	}
 
+	notStagedTbls, _ = activeTableDiffs(dEnv, notStagedTbls, false)
+
 	notStagedDocs, err := diff.NewDocDiffs(ctx, dEnv, working, nil, nil)
 	if err != nil {
 		return
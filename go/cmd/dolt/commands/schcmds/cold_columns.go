@@ -0,0 +1,177 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schcmds
+
+import (
+	"context"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/encoding"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+const deleteColdFlag = "delete"
+
+var coldColumnsDocs = cli.CommandDocumentationContent{
+	ShortDesc: "Lists, sets or unsets the cold columns of a table.",
+	LongDesc: `The values of the cold columns of a table are stored apart from the rest of its rows. Queries which don't reference any of the cold columns of a table don't read their values, so when a table is wide and only some of its columns are read often, making the others cold reduces the data read by most queries, and the data fetched from the remote when working against one.
+
+With only a table name, {{.EmphasisLeft}}dolt schema cold-columns{{.EmphasisRight}} lists the cold columns of the table. Given columns, it makes them cold, or with {{.EmphasisLeft}}--delete{{.EmphasisRight}} stores them with the rest of the row again. Primary key columns can't be cold. Changing the cold columns of a table rewrites it in the working set, and doesn't change its rows.`,
+	Synopsis: []string{
+		"{{.LessThan}}table{{.GreaterThan}}",
+		"[-d] {{.LessThan}}table{{.GreaterThan}} {{.LessThan}}column{{.GreaterThan}}...",
+	},
+}
+
+type ColdColumnsCmd struct{}
+
+// Name is returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd ColdColumnsCmd) Name() string {
+	return "cold-columns"
+}
+
+// Description returns a description of the command
+func (cmd ColdColumnsCmd) Description() string {
+	return "Lists, sets or unsets the cold columns of a table."
+}
+
+// CreateMarkdown creates a markdown file containing the helptext for the command at the given path
+func (cmd ColdColumnsCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) error {
+	ap := cmd.createArgParser()
+	return commands.CreateMarkdown(fs, path, cli.GetCommandDocumentation(commandStr, coldColumnsDocs, ap))
+}
+
+func (cmd ColdColumnsCmd) createArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"table", "table whose cold columns are being listed or changed."})
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"column", "columns being made cold, or with --delete no longer cold."})
+	ap.SupportsFlag(deleteColdFlag, "d", "Store the columns given with the rest of the row again.")
+	return ap
+}
+
+// EventType returns the type of the event to log
+func (cmd ColdColumnsCmd) EventType() eventsapi.ClientEventType {
+	return eventsapi.ClientEventType_SCHEMA
+}
+
+// Exec executes the command
+func (cmd ColdColumnsCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cmd.createArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, coldColumnsDocs, ap))
+	apr := cli.ParseArgs(ap, args, help)
+
+	if apr.NArg() == 0 {
+		usage()
+		return 1
+	}
+
+	verr := updateColdColumns(ctx, dEnv, apr.Arg(0), apr.Args()[1:], !apr.Contains(deleteColdFlag))
+
+	return commands.HandleVErrAndExitCode(verr, usage)
+}
+
+func updateColdColumns(ctx context.Context, dEnv *env.DoltEnv, tblName string, colNames []string, cold bool) errhand.VerboseError {
+	root, verr := commands.GetWorkingWithVErr(dEnv)
+
+	if verr != nil {
+		return verr
+	}
+
+	tbl, ok, err := root.GetTable(ctx, tblName)
+
+	if err != nil {
+		return errhand.BuildDError("unable to get table '%s'", tblName).AddCause(err).Build()
+	} else if !ok {
+		return errhand.BuildDError("error: table '%s' not found", tblName).Build()
+	}
+
+	sch, err := tbl.GetSchema(ctx)
+
+	if err != nil {
+		return errhand.BuildDError("unable to get schema").AddCause(err).Build()
+	}
+
+	if len(colNames) == 0 {
+		for _, tag := range schema.ColdColTags(sch) {
+			col, _ := sch.GetAllCols().GetByTag(tag)
+			cli.Println(col.Name)
+		}
+
+		return nil
+	}
+
+	if doltdb.HasDoltPrefix(tblName) {
+		return errhand.BuildDError("error: the columns of system table '%s' can't be cold", tblName).Build()
+	}
+
+	if hasConflicts, err := tbl.HasConflicts(); err != nil {
+		return errhand.BuildDError("unable to read conflicts").AddCause(err).Build()
+	} else if hasConflicts {
+		return errhand.BuildDError("error: table '%s' has unresolved conflicts", tblName).Build()
+	}
+
+	allCols := sch.GetAllCols()
+	for _, colName := range colNames {
+		col, ok := allCols.GetByName(colName)
+
+		if !ok {
+			return errhand.BuildDError("error: table '%s' has no column '%s'", tblName, colName).Build()
+		} else if col.IsPartOfPK && cold {
+			return errhand.BuildDError("error: primary key column '%s' can't be cold", colName).Build()
+		}
+
+		updated := col
+		updated.Cold = cold
+		allCols, err = allCols.Replace(col, updated)
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to update column '%s'", colName).AddCause(err).Build()
+		}
+	}
+
+	newSch := schema.SchemaWithComment(schema.SchemaFromCols(allCols), sch.GetComment())
+	schVal, err := encoding.MarshalSchemaAsNomsValue(ctx, root.VRW(), newSch)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to encode schema.").AddCause(err).Build()
+	}
+
+	rowData, err := tbl.GetRowData(ctx)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to read table '%s'", tblName).AddCause(err).Build()
+	}
+
+	tbl, err = doltdb.NewTable(ctx, root.VRW(), schVal, rowData)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to rewrite table '%s'", tblName).AddCause(err).Build()
+	}
+
+	root, err = root.PutTable(ctx, tblName, tbl)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to write table back to database").AddCause(err).Build()
+	}
+
+	return commands.UpdateWorkingWithVErr(dEnv, root)
+}
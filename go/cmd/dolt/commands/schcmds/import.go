@@ -101,6 +101,11 @@ func (cmd ImportCmd) Name() string {
 	return "import"
 }
 
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd ImportCmd) LocksRepo() bool {
+	return true
+}
+
 // Description returns a description of the command
 func (cmd ImportCmd) Description() string {
 	return "Creates a new table with an inferred schema."
@@ -165,7 +170,7 @@ func importSchema(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgPars
 		return errhand.BuildDError("error: file '%s' not found.", fileName).Build()
 	}
 
-	if err := tblcmds.ValidateTableNameForCreate(tblName); err != nil {
+	if err := tblcmds.ValidateNewTableName(ctx, root, tblName, ""); err != nil {
 		return err
 	}
 
@@ -278,6 +283,12 @@ func importSchema(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgPars
 		return verr
 	}
 
+	if op != updateOp {
+		if err := doltdb.ValidateSchemaColumnNames(sch); err != nil {
+			return errhand.BuildDError("error: failed to create table.").AddCause(err).Build()
+		}
+	}
+
 	cli.Println(sql.SchemaAsCreateStmt(tblName, sch))
 
 	if !apr.Contains(dryRunFlag) {
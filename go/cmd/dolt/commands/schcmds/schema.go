@@ -19,6 +19,7 @@ import (
 )
 
 var Commands = cli.NewSubCommandHandler("schema", "Commands for showing and importing table schemas.", []cli.Command{
+	ColdColumnsCmd{},
 	ExportCmd{},
 	ImportCmd{},
 	ShowCmd{},
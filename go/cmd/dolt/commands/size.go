@@ -0,0 +1,432 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/jedib0t/go-pretty/table"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions/commitwalk"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+const (
+	sizeCommitsFlag = "commits"
+
+	// sizeCacheSaveInterval is the number of commit sizes computed between writes of the size cache
+	sizeCacheSaveInterval = 100
+)
+
+var sizeCacheFile = filepath.Join(dbfactory.DoltDir, "size_cache.json")
+
+var sizeDocs = cli.CommandDocumentationContent{
+	ShortDesc: "Show the storage used by tables, branches and commits",
+	LongDesc: `Reports the number of bytes of storage attributable to each table, each branch and, with {{.EmphasisLeft}}--commits{{.EmphasisRight}}, each commit.
+
+The size of a table is the size of the chunks which are reachable only through that table's rows, schema and indexes. Chunks which are shared with another table are only included in the table's total size. With no arguments the tables of the working set are reported, but if a commit is specified the tables in that commit are reported.
+
+The size of a commit is the size of the chunks which it introduced, which are those reachable from the commit and from none of its parents. The size of a branch is the sum of the sizes of the commits which are reachable from that branch and from no other branch. The commits reported are those reachable from the given commit, or HEAD when no commit is given, sorted by size.
+
+Sizes of tables and commits never change, so they are cached in {{.EmphasisLeft}}.dolt/size_cache.json{{.EmphasisRight}} to make repeated runs fast.
+`,
+	Synopsis: []string{
+		"[--commits] [-n {{.LessThan}}num_commits{{.GreaterThan}}] [-r tabular|json] [{{.LessThan}}commit{{.GreaterThan}}]",
+	},
+}
+
+type SizeCmd struct{}
+
+// Name is returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd SizeCmd) Name() string {
+	return "size"
+}
+
+// Description returns a description of the command
+func (cmd SizeCmd) Description() string {
+	return "Show the storage used by tables, branches and commits."
+}
+
+// CreateMarkdown creates a markdown file containing the helptext for the command at the given path
+func (cmd SizeCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) error {
+	ap := cmd.createArgParser()
+	return CreateMarkdown(fs, path, cli.GetCommandDocumentation(commandStr, sizeDocs, ap))
+}
+
+func (cmd SizeCmd) createArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.SupportsFlag(sizeCommitsFlag, "", "Report the size of each commit.")
+	ap.SupportsInt(numLinesParam, "n", "num_commits", "Limit the number of commits reported.")
+	ap.SupportsString(formatFlag, "r", "result output format", "How to format the output. Valid values are tabular and json. Defaults to tabular.")
+	return ap
+}
+
+// EventType returns the type of the event to log
+func (cmd SizeCmd) EventType() eventsapi.ClientEventType {
+	return eventsapi.ClientEventType_TYPE_UNSPECIFIED
+}
+
+type tableSizeJSON struct {
+	Name           string `json:"name"`
+	ExclusiveBytes uint64 `json:"exclusive_bytes"`
+	TotalBytes     uint64 `json:"total_bytes"`
+}
+
+type branchSizeJSON struct {
+	Name           string `json:"name"`
+	ExclusiveBytes uint64 `json:"exclusive_bytes"`
+}
+
+type commitSizeJSON struct {
+	Commit  string `json:"commit"`
+	Bytes   uint64 `json:"bytes"`
+	Message string `json:"message"`
+}
+
+type sizeReport struct {
+	Tables   []tableSizeJSON  `json:"tables"`
+	Branches []branchSizeJSON `json:"branches"`
+	Commits  []commitSizeJSON `json:"commits,omitempty"`
+}
+
+// sizeCache holds the sizes which have already been computed, keyed by the hash of the root value or commit
+type sizeCache struct {
+	Tables  map[string][]tableSizeJSON `json:"tables"`
+	Commits map[string]uint64          `json:"commits"`
+
+	computed int
+}
+
+// Exec executes the command
+func (cmd SizeCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cmd.createArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, sizeDocs, ap))
+	apr := cli.ParseArgs(ap, args, help)
+
+	if apr.NArg() > 1 {
+		usage()
+		return 1
+	}
+
+	resultFormat := "tabular"
+	if formatStr, ok := apr.GetValue(formatFlag); ok {
+		resultFormat = strings.ToLower(formatStr)
+		if resultFormat != "tabular" && resultFormat != "json" {
+			return HandleVErrAndExitCode(errhand.BuildDError("Invalid argument for --%s. Valid values are tabular, json", formatFlag).Build(), usage)
+		}
+	}
+
+	var root *doltdb.RootValue
+	var verr errhand.VerboseError
+	label := "working set"
+	startSpec := "HEAD"
+	if apr.NArg() == 0 {
+		root, verr = GetWorkingWithVErr(dEnv)
+	} else {
+		startSpec = apr.Arg(0)
+		label, root, verr = getRootForCommitSpecStr(ctx, startSpec, dEnv)
+	}
+
+	if verr != nil {
+		return HandleVErrAndExitCode(verr, usage)
+	}
+
+	cache := loadSizeCache(dEnv.FS)
+	var report sizeReport
+	report.Tables, verr = getTableSizes(ctx, dEnv, root, cache)
+
+	if verr == nil {
+		report.Branches, verr = getBranchSizes(ctx, dEnv, cache)
+	}
+
+	if verr == nil && apr.Contains(sizeCommitsFlag) {
+		report.Commits, verr = getCommitSizes(ctx, dEnv, startSpec, cache)
+
+		if n, ok := apr.GetInt(numLinesParam); ok && n >= 0 && n < len(report.Commits) {
+			report.Commits = report.Commits[:n]
+		}
+	}
+
+	if verr != nil {
+		return HandleVErrAndExitCode(verr, usage)
+	}
+
+	err := cache.save(dEnv.FS)
+
+	if err != nil {
+		return HandleVErrAndExitCode(errhand.BuildDError("error: failed to write %s", sizeCacheFile).AddCause(err).Build(), usage)
+	}
+
+	if resultFormat == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+
+		if err != nil {
+			return HandleVErrAndExitCode(errhand.BuildDError("error: failed to serialize sizes").AddCause(err).Build(), usage)
+		}
+
+		cli.Println(string(data))
+		return 0
+	}
+
+	printSizeReport(label, report)
+	return 0
+}
+
+func getTableSizes(ctx context.Context, dEnv *env.DoltEnv, root *doltdb.RootValue, cache *sizeCache) ([]tableSizeJSON, errhand.VerboseError) {
+	h, err := root.HashOf()
+
+	if err != nil {
+		return nil, errhand.BuildDError("error: failed to get root hash").AddCause(err).Build()
+	}
+
+	if sizes, ok := cache.Tables[h.String()]; ok {
+		return sizes, nil
+	}
+
+	tableSizes, err := dEnv.DoltDB.TableSizes(ctx, root)
+
+	if err != nil {
+		return nil, errhand.BuildDError("error: failed to compute table sizes").AddCause(err).Build()
+	}
+
+	sizes := make([]tableSizeJSON, len(tableSizes))
+	for i, ts := range tableSizes {
+		sizes[i] = tableSizeJSON{ts.Name, ts.ExclusiveBytes, ts.TotalBytes}
+	}
+
+	cache.Tables[h.String()] = sizes
+	return sizes, nil
+}
+
+// getBranchSizes sums the sizes of the commits which are reachable from each branch and from no other branch.
+func getBranchSizes(ctx context.Context, dEnv *env.DoltEnv, cache *sizeCache) ([]branchSizeJSON, errhand.VerboseError) {
+	branches, err := dEnv.DoltDB.GetBranches(ctx)
+
+	if err != nil {
+		return nil, errhand.BuildDError("error: failed to read branches").AddCause(err).Build()
+	}
+
+	// the index of the only branch which reaches each commit, or -1 for commits reachable from several branches
+	const sharedCommit = -1
+	reachedBy := make(map[hash.Hash]int)
+	commits := make(map[hash.Hash]*doltdb.Commit)
+	for i, b := range branches {
+		verr := walkCommits(ctx, dEnv, b.String(), func(h hash.Hash, cm *doltdb.Commit) {
+			if branch, ok := reachedBy[h]; !ok {
+				reachedBy[h] = i
+				commits[h] = cm
+			} else if branch != i {
+				reachedBy[h] = sharedCommit
+			}
+		})
+
+		if verr != nil {
+			return nil, verr
+		}
+	}
+
+	sizes := make([]branchSizeJSON, len(branches))
+	for i, b := range branches {
+		sizes[i].Name = b.GetPath()
+	}
+
+	for h, branch := range reachedBy {
+		if branch == sharedCommit {
+			continue
+		}
+
+		size, verr := cache.commitSize(ctx, dEnv, h, commits[h])
+
+		if verr != nil {
+			return nil, verr
+		}
+
+		sizes[branch].ExclusiveBytes += size
+	}
+
+	sort.SliceStable(sizes, func(i, j int) bool {
+		return sizes[i].ExclusiveBytes > sizes[j].ExclusiveBytes
+	})
+
+	return sizes, nil
+}
+
+// getCommitSizes returns the sizes of the commits reachable from |startSpec|, sorted by decreasing size.
+func getCommitSizes(ctx context.Context, dEnv *env.DoltEnv, startSpec string, cache *sizeCache) ([]commitSizeJSON, errhand.VerboseError) {
+	var sizes []commitSizeJSON
+	var sizeErr errhand.VerboseError
+	verr := walkCommits(ctx, dEnv, startSpec, func(h hash.Hash, cm *doltdb.Commit) {
+		if sizeErr != nil {
+			return
+		}
+
+		var size uint64
+		size, sizeErr = cache.commitSize(ctx, dEnv, h, cm)
+
+		if sizeErr != nil {
+			return
+		}
+
+		meta, err := cm.GetCommitMeta()
+
+		if err != nil {
+			sizeErr = errhand.BuildDError("error: failed to read metadata of commit %s", h.String()).AddCause(err).Build()
+			return
+		}
+
+		sizes = append(sizes, commitSizeJSON{h.String(), size, strings.SplitN(meta.Description, "\n", 2)[0]})
+	})
+
+	if verr != nil {
+		return nil, verr
+	} else if sizeErr != nil {
+		return nil, sizeErr
+	}
+
+	sort.SliceStable(sizes, func(i, j int) bool {
+		return sizes[i].Bytes > sizes[j].Bytes
+	})
+
+	return sizes, nil
+}
+
+func walkCommits(ctx context.Context, dEnv *env.DoltEnv, startSpec string, cb func(h hash.Hash, cm *doltdb.Commit)) errhand.VerboseError {
+	start, verr := ResolveCommitWithVErr(dEnv, startSpec, dEnv.RepoState.CWBHeadRef().String())
+
+	if verr != nil {
+		return verr
+	}
+
+	startHash, err := start.HashOf()
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to get commit hash").AddCause(err).Build()
+	}
+
+	itr, err := commitwalk.GetTopologicalOrderIterator(ctx, dEnv.DoltDB, startHash)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to walk commits").AddCause(err).Build()
+	}
+
+	for {
+		h, cm, err := itr.Next(ctx)
+
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errhand.BuildDError("error: failed to walk commits").AddCause(err).Build()
+		}
+
+		cb(h, cm)
+	}
+}
+
+func printSizeReport(label string, report sizeReport) {
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Table", "Exclusive Size", "Total Size"})
+	for _, ts := range report.Tables {
+		t.AppendRow(table.Row{ts.Name, humanize.Bytes(ts.ExclusiveBytes), humanize.Bytes(ts.TotalBytes)})
+	}
+
+	cli.Printf("Tables in %s:\n", label)
+	cli.Println(t.Render())
+
+	t = table.NewWriter()
+	t.AppendHeader(table.Row{"Branch", "Exclusive Size"})
+	for _, bs := range report.Branches {
+		t.AppendRow(table.Row{bs.Name, humanize.Bytes(bs.ExclusiveBytes)})
+	}
+
+	cli.Println("Branches:")
+	cli.Println(t.Render())
+
+	if report.Commits != nil {
+		t = table.NewWriter()
+		t.AppendHeader(table.Row{"Commit", "Size", "Message"})
+		for _, cs := range report.Commits {
+			t.AppendRow(table.Row{cs.Commit, humanize.Bytes(cs.Bytes), truncateString(cs.Message, 50)})
+		}
+
+		cli.Println("Commits:")
+		cli.Println(t.Render())
+	}
+}
+
+func loadSizeCache(fs filesys.ReadableFS) *sizeCache {
+	cache := &sizeCache{}
+
+	// a missing or unreadable cache is recomputed
+	if data, err := fs.ReadFile(sizeCacheFile); err == nil {
+		_ = json.Unmarshal(data, cache)
+	}
+
+	if cache.Tables == nil {
+		cache.Tables = make(map[string][]tableSizeJSON)
+	}
+
+	if cache.Commits == nil {
+		cache.Commits = make(map[string]uint64)
+	}
+
+	return cache
+}
+
+// commitSize returns the size of the commit |cm| with hash |h|, computing it if it is not in the cache. The cache is
+// saved periodically so that the work done on large repositories is not lost if the command is interrupted.
+func (cache *sizeCache) commitSize(ctx context.Context, dEnv *env.DoltEnv, h hash.Hash, cm *doltdb.Commit) (uint64, errhand.VerboseError) {
+	if size, ok := cache.Commits[h.String()]; ok {
+		return size, nil
+	}
+
+	size, err := dEnv.DoltDB.CommitSize(ctx, cm)
+
+	if err != nil {
+		return 0, errhand.BuildDError("error: failed to compute the size of commit %s", h.String()).AddCause(err).Build()
+	}
+
+	cache.Commits[h.String()] = size
+	cache.computed++
+
+	if cache.computed%sizeCacheSaveInterval == 0 {
+		_ = cache.save(dEnv.FS)
+	}
+
+	return size, nil
+}
+
+func (cache *sizeCache) save(fs filesys.WritableFS) error {
+	data, err := json.Marshal(cache)
+
+	if err != nil {
+		return err
+	}
+
+	return fs.WriteFile(sizeCacheFile, data)
+}
@@ -0,0 +1,90 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/iohelp"
+)
+
+// IncludeSparseFlag allows a command to use the tables which aren't in the sparse working set.
+const IncludeSparseFlag = "include-sparse"
+
+// SupportsIncludeSparse adds the IncludeSparseFlag to the ArgParser given.
+func SupportsIncludeSparse(ap *argparser.ArgParser) {
+	ap.SupportsFlag(IncludeSparseFlag, "", "Allow tables which aren't in the sparse working set to be used. See {{.EmphasisLeft}}dolt sparse --help{{.EmphasisRight}}.")
+}
+
+// CheckSparseTablesWithVErr returns an error naming the tables given which aren't in the sparse working set, unless
+// |includeSparse| is true. Docs are never left out of the sparse working set.
+func CheckSparseTablesWithVErr(dEnv *env.DoltEnv, tblNames []string, includeSparse bool) errhand.VerboseError {
+	if includeSparse {
+		return nil
+	}
+
+	var inactive []string
+	for _, tblName := range tblNames {
+		if !dEnv.RepoState.IsActiveTable(tblName) && !env.IsValidDoc(tblName) {
+			inactive = append(inactive, tblName)
+		}
+	}
+
+	if len(inactive) == 0 {
+		return nil
+	}
+
+	bdr := errhand.BuildDError("error: the following tables are not in the sparse working set:")
+	for _, tblName := range inactive {
+		bdr.AddDetails("\t%s", tblName)
+	}
+
+	bdr.AddDetails("Use --%s to use them anyway, or add them to the sparse working set with \"dolt sparse set\".", IncludeSparseFlag)
+
+	return bdr.Build()
+}
+
+// activeTableDiffs returns the table diffs given limited to the tables in the sparse working set, along with the names
+// of the tables whose diffs were left out. All of the diffs are returned if |includeSparse| is true.
+func activeTableDiffs(dEnv *env.DoltEnv, tds *diff.TableDiffs, includeSparse bool) (*diff.TableDiffs, []string) {
+	if includeSparse || !dEnv.RepoState.IsSparse() {
+		return tds, nil
+	}
+
+	_, inactive := dEnv.RepoState.SplitSparseTables(tds.Tables)
+	return tds.Filter(dEnv.RepoState.IsActiveTable), inactive
+}
+
+// printSparseHiddenTables writes a note about the changed tables given, which were left out of the output because they
+// aren't in the sparse working set.
+func printSparseHiddenTables(wr io.Writer, hidden []string) {
+	if len(hidden) == 0 {
+		return
+	}
+
+	tables := "tables"
+	if len(hidden) == 1 {
+		tables = "table"
+	}
+
+	iohelp.WriteLine(wr, fmt.Sprintf("%d changed %s outside of the sparse working set not shown: %s", len(hidden), tables, strings.Join(hidden, ", ")))
+	iohelp.WriteLine(wr, fmt.Sprintf("  (use \"dolt status --%s\" to show them)", IncludeSparseFlag))
+}
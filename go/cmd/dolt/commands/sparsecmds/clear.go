@@ -0,0 +1,82 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sparsecmds
+
+import (
+	"context"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+var clearDocs = cli.CommandDocumentationContent{
+	ShortDesc: "Remove the sparse working set",
+	LongDesc: `Removes the sparse working set set with {{.EmphasisLeft}}dolt sparse set{{.EmphasisRight}}, so that every table is in the working set again.
+`,
+	Synopsis: []string{
+		"",
+	},
+}
+
+type ClearCmd struct{}
+
+// Name is returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd ClearCmd) Name() string {
+	return "clear"
+}
+
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd ClearCmd) LocksRepo() bool {
+	return true
+}
+
+// Description returns a description of the command
+func (cmd ClearCmd) Description() string {
+	return "Remove the sparse working set."
+}
+
+// EventType returns the type of the event to log
+func (cmd ClearCmd) EventType() eventsapi.ClientEventType {
+	return eventsapi.ClientEventType_TYPE_UNSPECIFIED
+}
+
+// CreateMarkdown creates a markdown file containing the helptext for the command at the given path
+func (cmd ClearCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) error {
+	ap := cmd.createArgParser()
+	return commands.CreateMarkdown(fs, path, cli.GetCommandDocumentation(commandStr, clearDocs, ap))
+}
+
+func (cmd ClearCmd) createArgParser() *argparser.ArgParser {
+	return argparser.NewArgParser()
+}
+
+// Exec executes the command
+func (cmd ClearCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cmd.createArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, clearDocs, ap))
+	apr := cli.ParseArgs(ap, args, help)
+
+	if apr.NArg() != 0 {
+		usage()
+		return 1
+	}
+
+	verr := setSparseTables(ctx, dEnv, nil, false)
+	return commands.HandleVErrAndExitCode(verr, usage)
+}
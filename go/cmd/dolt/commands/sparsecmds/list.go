@@ -0,0 +1,125 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sparsecmds
+
+import (
+	"context"
+
+	"github.com/fatih/color"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+const (
+	tablesParam = "tables"
+)
+
+var listDocs = cli.CommandDocumentationContent{
+	ShortDesc: "List the patterns of the sparse working set",
+	LongDesc: `Lists the patterns set with {{.EmphasisLeft}}dolt sparse set{{.EmphasisRight}}. Nothing is listed if the repository doesn't have a sparse working set.
+
+When the {{.EmphasisLeft}}--tables{{.EmphasisRight}} flag is provided the tables of the working set are listed instead, with the tables outside of the sparse working set marked as such.
+`,
+	Synopsis: []string{
+		"[--tables]",
+	},
+}
+
+type ListCmd struct{}
+
+// Name is returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd ListCmd) Name() string {
+	return "list"
+}
+
+// Description returns a description of the command
+func (cmd ListCmd) Description() string {
+	return "List the patterns of the sparse working set."
+}
+
+// EventType returns the type of the event to log
+func (cmd ListCmd) EventType() eventsapi.ClientEventType {
+	return eventsapi.ClientEventType_TYPE_UNSPECIFIED
+}
+
+// CreateMarkdown creates a markdown file containing the helptext for the command at the given path
+func (cmd ListCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) error {
+	ap := cmd.createArgParser()
+	return commands.CreateMarkdown(fs, path, cli.GetCommandDocumentation(commandStr, listDocs, ap))
+}
+
+func (cmd ListCmd) createArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.SupportsFlag(tablesParam, "t", "List the tables of the working set and whether each is in the sparse working set.")
+	return ap
+}
+
+// Exec executes the command
+func (cmd ListCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cmd.createArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, listDocs, ap))
+	apr := cli.ParseArgs(ap, args, help)
+
+	if apr.NArg() != 0 {
+		usage()
+		return 1
+	}
+
+	if !apr.Contains(tablesParam) {
+		for _, pattern := range dEnv.RepoState.Sparse {
+			cli.Println(pattern)
+		}
+
+		return 0
+	}
+
+	verr := printSparseTables(ctx, dEnv)
+	return commands.HandleVErrAndExitCode(verr, usage)
+}
+
+func printSparseTables(ctx context.Context, dEnv *env.DoltEnv) errhand.VerboseError {
+	working, verr := commands.GetWorkingWithVErr(dEnv)
+
+	if verr != nil {
+		return verr
+	}
+
+	tblNames, err := working.GetTableNames(ctx)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to read tables from the working set").AddCause(err).Build()
+	}
+
+	for _, tblName := range tblNames {
+		if doltdb.HasDoltPrefix(tblName) {
+			continue
+		}
+
+		if dEnv.RepoState.IsActiveTable(tblName) {
+			cli.Println("\t" + tblName)
+		} else {
+			cli.Println(color.New(color.Faint).Sprintf("\t%s (not in the sparse working set)", tblName))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sparsecmds
+
+import (
+	"context"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+const (
+	forceParam = "force"
+)
+
+var setDocs = cli.CommandDocumentationContent{
+	ShortDesc: "Set the tables in the sparse working set",
+	LongDesc: `Limits the working set to the tables matching the given patterns. Patterns are table names which may contain the wildcards {{.EmphasisLeft}}*{{.EmphasisRight}}, {{.EmphasisLeft}}?{{.EmphasisRight}} and {{.EmphasisLeft}}[...]{{.EmphasisRight}}, so {{.EmphasisLeft}}sales_*{{.EmphasisRight}} matches every table whose name begins with {{.EmphasisLeft}}sales_{{.EmphasisRight}}. The patterns replace any which were set before.
+
+{{.EmphasisLeft}}dolt status{{.EmphasisRight}}, {{.EmphasisLeft}}dolt diff{{.EmphasisRight}} and {{.EmphasisLeft}}dolt add .{{.EmphasisRight}} only consider the tables in the sparse working set, so only the changes to those tables are committed by default. The working and staged versions of the other tables are kept pointing at the versions in HEAD, so they never appear modified. Commands given the name of a table outside of the sparse working set fail unless the {{.EmphasisLeft}}--include-sparse{{.EmphasisRight}} flag is provided.
+
+The sparse working set belongs to the repository rather than a branch, so it is kept when switching branches. dolt system tables and docs are always in the sparse working set.
+
+Tables which would be left out of the sparse working set can't have uncommitted changes, as those changes would be lost. The {{.EmphasisLeft}}--force{{.EmphasisRight}} flag discards them.
+`,
+	Synopsis: []string{
+		"[--force] {{.LessThan}}pattern{{.GreaterThan}}...",
+	},
+}
+
+type SetCmd struct{}
+
+// Name is returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd SetCmd) Name() string {
+	return "set"
+}
+
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd SetCmd) LocksRepo() bool {
+	return true
+}
+
+// Description returns a description of the command
+func (cmd SetCmd) Description() string {
+	return "Set the tables in the sparse working set."
+}
+
+// EventType returns the type of the event to log
+func (cmd SetCmd) EventType() eventsapi.ClientEventType {
+	return eventsapi.ClientEventType_TYPE_UNSPECIFIED
+}
+
+// CreateMarkdown creates a markdown file containing the helptext for the command at the given path
+func (cmd SetCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) error {
+	ap := cmd.createArgParser()
+	return commands.CreateMarkdown(fs, path, cli.GetCommandDocumentation(commandStr, setDocs, ap))
+}
+
+func (cmd SetCmd) createArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"pattern", "A table name, or a pattern matching table names, to include in the sparse working set."})
+	ap.SupportsFlag(forceParam, "f", "Discard the uncommitted changes to the tables left out of the sparse working set.")
+	return ap
+}
+
+// Exec executes the command
+func (cmd SetCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cmd.createArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, setDocs, ap))
+	apr := cli.ParseArgs(ap, args, help)
+
+	if apr.NArg() == 0 {
+		usage()
+		return 1
+	}
+
+	if err := env.ValidateSparsePatterns(apr.Args()); err != nil {
+		return commands.HandleVErrAndExitCode(errhand.BuildDError("error: %s", err.Error()).Build(), usage)
+	}
+
+	verr := setSparseTables(ctx, dEnv, apr.Args(), apr.Contains(forceParam))
+	return commands.HandleVErrAndExitCode(verr, usage)
+}
+
+func setSparseTables(ctx context.Context, dEnv *env.DoltEnv, patterns []string, force bool) errhand.VerboseError {
+	err := actions.SetSparseTables(ctx, dEnv, patterns, force)
+
+	switch {
+	case err == nil:
+		return nil
+
+	case err == actions.ErrSparseMergeActive:
+		return errhand.BuildDError("error: the sparse working set can't be changed while merging.").
+			AddDetails(`Use "dolt commit" to conclude the merge or "dolt merge --abort" to abort it.`).Build()
+
+	case actions.IsRootValUnreachable(err):
+		rt := actions.GetUnreachableRootType(err)
+		return errhand.BuildDError("error: unable to read the %s", rt.String()).AddCause(actions.GetUnreachableRootCause(err)).Build()
+
+	case actions.IsTblSparseChanged(err):
+		bdr := errhand.BuildDError("error: the following tables would be left out of the sparse working set, but have uncommitted changes:")
+		for _, tblName := range actions.GetTablesForError(err) {
+			bdr.AddDetails("\t%s", tblName)
+		}
+
+		bdr.AddDetails("Commit the changes, or use --%s to discard them.", forceParam)
+		return bdr.Build()
+
+	default:
+		return errhand.BuildDError("error: failed to update the sparse working set").AddCause(err).Build()
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/abiosoft/readline"
 	"github.com/fatih/color"
@@ -37,15 +38,18 @@ import (
 	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
 	dsql "github.com/liquidata-inc/dolt/go/libraries/doltcore/sql"
 	dsqle "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
 	_ "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle/dfunctions"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle/sqlarrow"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/pipeline"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/typed/json"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/untyped"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/untyped/blobprinter"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/untyped/csv"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/untyped/fwt"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/untyped/nullprinter"
@@ -72,10 +76,29 @@ var sqlDocs = cli.CommandDocumentationContent{
 		"Pipe SQL statements to dolt sql (no {{.EmphasisLeft}}-q{{.EmphasisRight}}) to execute a SQL import or update " +
 		"script.\n" +
 		"\n" +
+		"In batch mode, statements are read and run one at a time, and the rows edited by INSERT statements are written to " +
+		"the tables in batches of {{.EmphasisLeft}}--batch-size{{.EmphasisRight}} rows. With " +
+		"{{.EmphasisLeft}}--commit{{.EmphasisRight}}, all tables are staged and a dolt commit is created once every " +
+		"statement has run, and with {{.EmphasisLeft}}--commit-every {{.LessThan}}n{{.GreaterThan}}{{.EmphasisRight}} " +
+		"after every n statements as well. The commits have the message given by {{.EmphasisLeft}}-m{{.EmphasisRight}}. " +
+		"A statement which fails stops the batch, reporting its line number, unless " +
+		"{{.EmphasisLeft}}--continue-on-error{{.EmphasisRight}} is given, in which case the statements which failed are " +
+		"reported once the rest have run.\n" +
+		"\n" +
 		"By default this command uses the dolt data repository in the current working directory as the one and only " +
 		"database.  Running with {{.EmphasisLeft}}--multi-db-dir {{.LessThan}}directory{{.GreaterThan}}{{.EmphasisRight}} " +
 		"uses each of the subdirectories of the supplied directory (each subdirectory must be a valid dolt data repository) " +
-		"as databases. Subdirectories starting with '.' are ignored." +
+		"as databases. Subdirectories starting with '.' are ignored.\n" +
+		"\n" +
+		"Running with {{.EmphasisLeft}}--no-repo{{.EmphasisRight}} uses a database held entirely in memory instead, and " +
+		"doesn't need a dolt data repository at all. Files can be imported into its tables with " +
+		"{{.EmphasisLeft}}--import{{.EmphasisRight}}, which takes a comma separated list of " +
+		"{{.LessThan}}file{{.GreaterThan}} [AS {{.LessThan}}table{{.GreaterThan}}] [PRIMARY KEY ({{.LessThan}}column{{.GreaterThan}}, ...)]. " +
+		"Tables are named after their files unless a name is given, and are keyed by their first column unless a primary " +
+		"key is given. csv, psv and xlsx files can be imported. The database is discarded when the command exits, unless " +
+		"{{.EmphasisLeft}}--save-to {{.LessThan}}directory{{.GreaterThan}}{{.EmphasisRight}} is given, in which case a " +
+		"new dolt data repository is created in the directory with the final state of the tables in its working set.\n" +
+		"\n" +
 		"Known limitations:\n" +
 		"* No support for creating indexes\n" +
 		"* No support for foreign keys\n" +
@@ -88,8 +111,11 @@ var sqlDocs = cli.CommandDocumentationContent{
 		"[--multi-db-dir {{.LessThan}}directory{{.GreaterThan}}] [-r {{.LessThan}}result format{{.GreaterThan}}]",
 		"-q {{.LessThan}}query;query{{.GreaterThan}} [-r {{.LessThan}}result format{{.GreaterThan}}] -s {{.LessThan}}name{{.GreaterThan}} -m {{.LessThan}}message{{.GreaterThan}} [-b]",
 		"-q {{.LessThan}}query;query{{.GreaterThan}} --multi-db-dir {{.LessThan}}directory{{.GreaterThan}} [-r {{.LessThan}}result format{{.GreaterThan}}] [-b]",
+		"-q {{.LessThan}}query{{.GreaterThan}} -r arrow --result-file {{.LessThan}}file{{.GreaterThan}}",
 		"-x {{.LessThan}}name{{.GreaterThan}}",
 		"--list-saved",
+		"--no-repo [--import {{.LessThan}}file{{.GreaterThan}} [AS {{.LessThan}}table{{.GreaterThan}}],...] [--save-to {{.LessThan}}directory{{.GreaterThan}}] [-q {{.LessThan}}query;query{{.GreaterThan}}] [-r {{.LessThan}}result format{{.GreaterThan}}] [-b]",
+		"[-b -q {{.LessThan}}query;query{{.GreaterThan}}] [--batch-size {{.LessThan}}rows{{.GreaterThan}}] [--commit [--commit-every {{.LessThan}}n{{.GreaterThan}}] [-m {{.LessThan}}message{{.GreaterThan}}]] [--continue-on-error]",
 	},
 }
 
@@ -102,11 +128,22 @@ const (
 	messageFlag    = "message"
 	batchFlag      = "batch"
 	multiDBDirFlag = "multi-db-dir"
+	noRepoFlag     = "no-repo"
+	importFlag     = "import"
+	saveToFlag     = "save-to"
+	resultFileFlag = "result-file"
 	welcomeMsg     = `# Welcome to the DoltSQL shell.
 # Statements must be terminated with ';'.
 # "exit" or "quit" (or Ctrl-D) to exit.`
 )
 
+const (
+	batchSizeFlag       = "batch-size"
+	commitFlag          = "commit"
+	commitEveryFlag     = "commit-every"
+	continueOnErrorFlag = "continue-on-error"
+)
+
 type SqlCmd struct {
 	VersionStr string
 }
@@ -116,6 +153,11 @@ func (cmd SqlCmd) Name() string {
 	return "sql"
 }
 
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd SqlCmd) LocksRepo() bool {
+	return true
+}
+
 // Description returns a description of the command
 func (cmd SqlCmd) Description() string {
 	return "Run a SQL query against tables in repository."
@@ -130,13 +172,22 @@ func (cmd SqlCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) er
 func (cmd SqlCmd) createArgParser() *argparser.ArgParser {
 	ap := argparser.NewArgParser()
 	ap.SupportsString(queryFlag, "q", "SQL query to run", "Runs a single query and exits")
-	ap.SupportsString(formatFlag, "r", "result output format", "How to format result output. Valid values are tabular, csv, json. Defaults to tabular. ")
+	ap.SupportsString(formatFlag, "r", "result output format", "How to format result output. Valid values are tabular, csv, json, arrow. Defaults to tabular. Arrow results, which are an Apache Arrow IPC stream of record batches, must be written to a --result-file.")
+	ap.SupportsFlag(ShowBinaryFlag, "", "Print binary values in full. By default binary values which don't look like text are printed as their size and hash, except in csv results.")
 	ap.SupportsString(saveFlag, "s", "saved query name", "Used with --query, save the query to the query catalog with the name provided. Saved queries can be examined in the dolt_query_catalog system table.")
 	ap.SupportsString(executeFlag, "x", "saved query name", "Executes a saved query with the given name")
 	ap.SupportsFlag(listSavedFlag, "l", "Lists all saved queries")
-	ap.SupportsString(messageFlag, "m", "saved query description", "Used with --query and --save, saves the query with the descriptive message given. See also --name")
+	ap.SupportsString(messageFlag, "m", "saved query description", "Used with --query and --save, saves the query with the descriptive message given. Used with --commit, the message of the commits created. See also --name")
 	ap.SupportsFlag(batchFlag, "b", "batch mode, to run more than one query with --query, separated by ';'. Piping input to sql with no arguments also uses batch mode")
 	ap.SupportsString(multiDBDirFlag, "", "directory", "Defines a directory whose subdirectories should all be dolt data repositories accessible as independent databases within ")
+	ap.SupportsFlag(noRepoFlag, "", "Runs against a database held in memory, rather than a dolt data repository")
+	ap.SupportsString(importFlag, "", "file [AS table],...", "Used with --no-repo, imports each of the comma separated files into a table of the in memory database before running any queries")
+	ap.SupportsString(saveToFlag, "", "directory", "Used with --no-repo, creates a new dolt data repository in the directory given with the final state of the in memory database")
+	ap.SupportsString(resultFileFlag, "", "file", "Writes the results of the query to the file given, rather than printing them. Used with --query, --execute or --list-saved, but not with --batch")
+	ap.SupportsInt(batchSizeFlag, "", "rows", fmt.Sprintf("In batch mode, the number of edited rows written to the tables at a time. Defaults to %d", defaultBatchSize))
+	ap.SupportsFlag(commitFlag, "", "In batch mode, stages all tables and creates a dolt commit once every statement has run")
+	ap.SupportsInt(commitEveryFlag, "", "n", "Used with --commit, also stages all tables and creates a dolt commit after every n statements")
+	ap.SupportsFlag(continueOnErrorFlag, "", "In batch mode, keeps running statements after one fails, and reports the statements which failed once the rest have run")
 	return ap
 }
 
@@ -154,7 +205,7 @@ func (cmd SqlCmd) RequiresRepo() bool {
 }
 
 // Exec executes the command
-func (cmd SqlCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+func (cmd SqlCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) (exitCode int) {
 	ap := cmd.createArgParser()
 	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, sqlDocs, ap))
 
@@ -175,11 +226,63 @@ func (cmd SqlCmd) Exec(ctx context.Context, commandStr string, args []string, dE
 		}
 	}
 
+	showBinary := apr.Contains(ShowBinaryFlag)
+	batchOpts := getBatchOptions(apr)
+
+	var resultOut io.Writer = cli.CliOut
+	if resultFile, ok := apr.GetValue(resultFileFlag); ok {
+		wr, err := dEnv.FS.OpenForWrite(resultFile, os.ModePerm)
+
+		if err != nil {
+			return HandleVErrAndExitCode(errhand.BuildDError("error: failed to create %s", resultFile).AddCause(err).Build(), usage)
+		}
+
+		defer wr.Close()
+		resultOut = wr
+	}
+
 	dsess := dsqle.DefaultDoltSession()
+	// the user of the CLI owns the repository, so row policies don't limit them
+	dsess.BypassRowPolicies = true
+	dsess.Principal = dEnv.Principal()
 
 	var mrEnv env.MultiRepoEnv
 	var initialRoots map[string]*doltdb.RootValue
-	if multiDir, ok := apr.GetValue(multiDBDirFlag); !ok {
+	if apr.Contains(noRepoFlag) {
+		imports, err := parseEphemeralImports(apr.GetValueOrDefault(importFlag, ""))
+
+		if err != nil {
+			return HandleVErrAndExitCode(errhand.BuildDError("Invalid Argument: %s", err.Error()).Build(), usage)
+		}
+
+		memEnv, verr := newEphemeralEnv(ctx, dEnv)
+
+		if verr == nil {
+			verr = importIntoEphemeralEnv(ctx, memEnv, dEnv.FS, imports)
+		}
+
+		if verr != nil {
+			return HandleVErrAndExitCode(verr, usage)
+		}
+
+		dsess.Username = *dEnv.Config.GetStringOrDefault(env.UserNameKey, "")
+		dsess.Email = *dEnv.Config.GetStringOrDefault(env.UserEmailKey, "")
+
+		diskEnv := dEnv
+		defer func() {
+			if saveTo, ok := apr.GetValue(saveToFlag); ok && exitCode == 0 {
+				exitCode = HandleVErrAndExitCode(saveEphemeralEnv(ctx, diskEnv, memEnv, saveTo), usage)
+			}
+		}()
+
+		dEnv = memEnv
+		mrEnv = env.DoltEnvAsMultiEnv(dEnv)
+		initialRoots, err = mrEnv.GetWorkingRoots(ctx)
+
+		if err != nil {
+			return HandleVErrAndExitCode(errhand.VerboseErrorFromError(err), usage)
+		}
+	} else if multiDir, ok := apr.GetValue(multiDBDirFlag); !ok {
 		if !cli.CheckEnvIsValid(dEnv) {
 			return 2
 		}
@@ -231,14 +334,15 @@ func (cmd SqlCmd) Exec(ctx context.Context, commandStr string, args []string, dE
 		return HandleVErrAndExitCode(err.(errhand.VerboseError), usage)
 	}
 
+	failures := 0
 	if query, queryOK := apr.GetValue(queryFlag); queryOK {
 		batchMode := apr.Contains(batchFlag)
 
 		if batchMode {
 			batchInput := strings.NewReader(query)
-			roots, verr = execBatch(sqlCtx, mrEnv, roots, batchInput, format)
+			roots, failures, verr = execBatch(sqlCtx, mrEnv, roots, batchInput, format, showBinary, batchOpts)
 		} else {
-			roots, verr = execQuery(sqlCtx, mrEnv, roots, query, format)
+			roots, verr = execQuery(sqlCtx, mrEnv, roots, query, format, showBinary, resultOut)
 
 			if verr != nil {
 				return HandleVErrAndExitCode(verr, usage)
@@ -259,7 +363,7 @@ func (cmd SqlCmd) Exec(ctx context.Context, commandStr string, args []string, dE
 		}
 
 		cli.PrintErrf("Executing saved query '%s':\n%s\n", savedQueryName, sq.Query)
-		roots, verr = execQuery(sqlCtx, mrEnv, roots, sq.Query, format)
+		roots, verr = execQuery(sqlCtx, mrEnv, roots, sq.Query, format, showBinary, resultOut)
 	} else if apr.Contains(listSavedFlag) {
 		hasQC, err := roots[currentDB].HasTable(ctx, doltdb.DoltQueryCatalogTableName)
 
@@ -273,7 +377,7 @@ func (cmd SqlCmd) Exec(ctx context.Context, commandStr string, args []string, dE
 		}
 
 		query := "SELECT * FROM " + doltdb.DoltQueryCatalogTableName
-		_, verr = execQuery(sqlCtx, mrEnv, roots, query, format)
+		_, verr = execQuery(sqlCtx, mrEnv, roots, query, format, showBinary, resultOut)
 	} else {
 		// Run in either batch mode for piped input, or shell mode for interactive
 		runInBatchMode := true
@@ -288,9 +392,11 @@ func (cmd SqlCmd) Exec(ctx context.Context, commandStr string, args []string, dE
 		}
 
 		if runInBatchMode {
-			roots, verr = execBatch(sqlCtx, mrEnv, roots, os.Stdin, format)
+			roots, failures, verr = execBatch(sqlCtx, mrEnv, roots, os.Stdin, format, showBinary, batchOpts)
+		} else if apr.ContainsAny(batchSizeFlag, commitFlag, commitEveryFlag, continueOnErrorFlag) {
+			verr = errhand.BuildDError("Invalid Argument: --batch-size, --commit, --commit-every and --continue-on-error are only used in batch mode").Build()
 		} else {
-			roots, verr = execShell(sqlCtx, mrEnv, roots, format)
+			roots, verr = execShell(sqlCtx, mrEnv, roots, format, showBinary)
 		}
 	}
 
@@ -307,12 +413,16 @@ func (cmd SqlCmd) Exec(ctx context.Context, commandStr string, args []string, dE
 		}
 	}
 
+	if verr == nil && failures > 0 {
+		verr = errhand.BuildDError("error: %d statements failed", failures).Build()
+	}
+
 	return HandleVErrAndExitCode(verr, usage)
 }
 
-func execShell(sqlCtx *sql.Context, mrEnv env.MultiRepoEnv, roots map[string]*doltdb.RootValue, format resultFormat) (map[string]*doltdb.RootValue, errhand.VerboseError) {
+func execShell(sqlCtx *sql.Context, mrEnv env.MultiRepoEnv, roots map[string]*doltdb.RootValue, format resultFormat, showBinary bool) (map[string]*doltdb.RootValue, errhand.VerboseError) {
 	dbs := CollectDBs(mrEnv, newDatabase)
-	se, err := newSqlEngine(sqlCtx, mrEnv, roots, format, dbs...)
+	se, err := newSqlEngine(sqlCtx, mrEnv, roots, format, showBinary, dbs...)
 	if err != nil {
 		return nil, errhand.VerboseErrorFromError(err)
 	}
@@ -330,24 +440,26 @@ func execShell(sqlCtx *sql.Context, mrEnv env.MultiRepoEnv, roots map[string]*do
 	return newRoots, nil
 }
 
-func execBatch(sqlCtx *sql.Context, mrEnv env.MultiRepoEnv, roots map[string]*doltdb.RootValue, batchInput io.Reader, format resultFormat) (map[string]*doltdb.RootValue, errhand.VerboseError) {
+// execBatch runs the statements of |batchInput| in batch mode, returning the new roots and the number of statements
+// which failed, which is only ever non-zero with --continue-on-error.
+func execBatch(sqlCtx *sql.Context, mrEnv env.MultiRepoEnv, roots map[string]*doltdb.RootValue, batchInput io.Reader, format resultFormat, showBinary bool, opts batchOptions) (map[string]*doltdb.RootValue, int, errhand.VerboseError) {
 	dbs := CollectDBs(mrEnv, newBatchedDatabase)
-	se, err := newSqlEngine(sqlCtx, mrEnv, roots, format, dbs...)
+	se, err := newSqlEngine(sqlCtx, mrEnv, roots, format, showBinary, dbs...)
 	if err != nil {
-		return nil, errhand.VerboseErrorFromError(err)
+		return nil, 0, errhand.VerboseErrorFromError(err)
 	}
 
-	err = runBatchMode(sqlCtx, se, batchInput)
+	failures, err := runBatchMode(sqlCtx, se, batchInput, opts)
 	if err != nil {
-		return nil, errhand.BuildDError("Error processing batch").Build()
+		return nil, 0, errhand.BuildDError("Error processing batch").Build()
 	}
 
 	newRoots, err := se.getRoots(sqlCtx)
 	if err != nil {
-		return nil, errhand.BuildDError("failed to get roots").AddCause(err).Build()
+		return nil, 0, errhand.BuildDError("failed to get roots").AddCause(err).Build()
 	}
 
-	return newRoots, nil
+	return newRoots, len(failures), nil
 }
 
 type createDBFunc func(name string, dEnv *env.DoltEnv) dsqle.Database
@@ -360,13 +472,16 @@ func newBatchedDatabase(name string, dEnv *env.DoltEnv) dsqle.Database {
 	return dsqle.NewBatchedDatabase(name, dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
 }
 
-func execQuery(sqlCtx *sql.Context, mrEnv env.MultiRepoEnv, roots map[string]*doltdb.RootValue, query string, format resultFormat) (map[string]*doltdb.RootValue, errhand.VerboseError) {
+// execQuery runs |query|, and writes its results to |resultOut| in the format given.
+func execQuery(sqlCtx *sql.Context, mrEnv env.MultiRepoEnv, roots map[string]*doltdb.RootValue, query string, format resultFormat, showBinary bool, resultOut io.Writer) (map[string]*doltdb.RootValue, errhand.VerboseError) {
 	dbs := CollectDBs(mrEnv, newDatabase)
-	se, err := newSqlEngine(sqlCtx, mrEnv, roots, format, dbs...)
+	se, err := newSqlEngine(sqlCtx, mrEnv, roots, format, showBinary, dbs...)
 	if err != nil {
 		return nil, errhand.VerboseErrorFromError(err)
 	}
 
+	se.resultOut = resultOut
+
 	sqlSch, rowIter, err := processQuery(sqlCtx, query, se)
 	if err != nil {
 		verr := formatQueryError("", err)
@@ -469,8 +584,10 @@ func getFormat(format string) (resultFormat, errhand.VerboseError) {
 		return formatCsv, nil
 	case "json":
 		return formatJson, nil
+	case "arrow":
+		return formatArrow, nil
 	default:
-		return formatTabular, errhand.BuildDError("Invalid argument for --result-format. Valid values are tabular, csv, json").Build()
+		return formatTabular, errhand.BuildDError("Invalid argument for --result-format. Valid values are tabular, csv, json, arrow").Build()
 	}
 }
 
@@ -482,6 +599,21 @@ func validateSqlArgs(apr *argparser.ArgParseResults) error {
 	_, list := apr.GetValue(listSavedFlag)
 	_, execute := apr.GetValue(executeFlag)
 	_, multiDB := apr.GetValue(multiDBDirFlag)
+	_, imports := apr.GetValue(importFlag)
+	_, saveTo := apr.GetValue(saveToFlag)
+	_, resultFile := apr.GetValue(resultFileFlag)
+	commit := apr.Contains(commitFlag)
+	batchOnly := apr.ContainsAny(batchSizeFlag, commitFlag, commitEveryFlag, continueOnErrorFlag)
+
+	if apr.Contains(noRepoFlag) {
+		if multiDB {
+			return errhand.BuildDError("Invalid Argument: --no-repo is not compatible with --multi-db-dir").Build()
+		}
+	} else if imports {
+		return errhand.BuildDError("Invalid Argument: --import is only used with --no-repo").Build()
+	} else if saveTo {
+		return errhand.BuildDError("Invalid Argument: --save-to is only used with --no-repo").Build()
+	}
 
 	if len(apr.Args()) > 0 && !query {
 		return errhand.BuildDError("Invalid Argument: use --query or -q to pass inline SQL queries").Build()
@@ -523,21 +655,45 @@ func validateSqlArgs(apr *argparser.ArgParseResults) error {
 		if !query {
 			return errhand.BuildDError("Invalid Argument: --batch|-b must be used with --query|-q").Build()
 		}
-		if save || msg {
-			return errhand.BuildDError("Invalid Argument: --batch|-b is not compatible with --save|-s or --message|-m").Build()
+		if save || msg && !commit {
+			return errhand.BuildDError("Invalid Argument: --batch|-b is not compatible with --save|-s, or with --message|-m without --commit").Build()
 		}
 	}
 
+	if batchOnly && (execute || list || query && !batch) {
+		return errhand.BuildDError("Invalid Argument: --batch-size, --commit, --commit-every and --continue-on-error are only used in batch mode").Build()
+	}
+
+	if n, ok := apr.GetInt(batchSizeFlag); apr.Contains(batchSizeFlag) && (!ok || n <= 0) {
+		return errhand.BuildDError("Invalid Argument: --batch-size must be a positive number of rows").Build()
+	}
+
+	if apr.Contains(commitEveryFlag) {
+		if n, ok := apr.GetInt(commitEveryFlag); !ok || n <= 0 {
+			return errhand.BuildDError("Invalid Argument: --commit-every must be a positive number of statements").Build()
+		} else if !commit {
+			return errhand.BuildDError("Invalid Argument: --commit-every is only used with --commit").Build()
+		}
+	}
+
+	if resultFile && (batch || !query && !execute && !list) {
+		return errhand.BuildDError("Invalid Argument: --result-file is only used with a single query of --query|-q, --execute|-x or --list-saved").Build()
+	}
+
+	if format, ok := apr.GetValue(formatFlag); ok && strings.ToLower(format) == "arrow" && !resultFile {
+		return errhand.BuildDError("Invalid Argument: --result-format arrow must be used with --result-file").Build()
+	}
+
 	if query {
-		if !save && msg {
-			return errhand.BuildDError("Invalid Argument: --message|-m is only used with --query|-q and --save|-s").Build()
+		if !save && !commit && msg {
+			return errhand.BuildDError("Invalid Argument: --message|-m is only used with --query|-q and --save|-s, or with --commit").Build()
 		}
 	} else {
 		if save {
 			return errhand.BuildDError("Invalid Argument: --save|-s is only used with --query|-q").Build()
 		}
-		if msg {
-			return errhand.BuildDError("Invalid Argument: --message|-m is only used with --query|-q and --save|-s").Build()
+		if !commit && msg {
+			return errhand.BuildDError("Invalid Argument: --message|-m is only used with --query|-q and --save|-s, or with --commit").Build()
 		}
 	}
 
@@ -554,24 +710,80 @@ func saveQuery(ctx context.Context, root *doltdb.RootValue, dEnv *env.DoltEnv, q
 	return newRoot, nil
 }
 
-// runBatchMode processes queries until EOF. The Root of the sqlEngine may be updated.
-func runBatchMode(ctx *sql.Context, se *sqlEngine, input io.Reader) error {
+// batchOptions are the options of batch mode.
+type batchOptions struct {
+	// batchSize is the number of edited rows written to the tables at a time
+	batchSize int
+	// commit is whether the changes are committed, with commitMsg, once every statement has run. If commitEvery is
+	// greater than 0, they're also committed after every commitEvery statements.
+	commit      bool
+	commitEvery int
+	commitMsg   string
+	// continueOnError is whether statements keep running after one fails
+	continueOnError bool
+}
+
+const defaultBatchCommitMsg = "Ran SQL batch"
+
+func getBatchOptions(apr *argparser.ArgParseResults) batchOptions {
+	return batchOptions{
+		batchSize:       apr.GetIntOrDefault(batchSizeFlag, defaultBatchSize),
+		commit:          apr.Contains(commitFlag),
+		commitEvery:     apr.GetIntOrDefault(commitEveryFlag, 0),
+		commitMsg:       apr.GetValueOrDefault(messageFlag, defaultBatchCommitMsg),
+		continueOnError: apr.Contains(continueOnErrorFlag),
+	}
+}
+
+// batchFailure is a statement of a batch which failed.
+type batchFailure struct {
+	line  int
+	query string
+	err   error
+}
+
+// runBatchMode processes queries until EOF. The Root of the sqlEngine may be updated. With opts.continueOnError, the
+// statements which fail are returned, and reported once the rest have run; otherwise the first to fail stops the
+// batch. Failures to write a batch of edits, or to commit, always stop it.
+func runBatchMode(ctx *sql.Context, se *sqlEngine, input io.Reader, opts batchOptions) ([]batchFailure, error) {
 	scanner := NewSqlStatementScanner(input)
 
+	var failures []batchFailure
 	var query string
+	// batchStartLine is the line of the first statement whose edits haven't been written to the tables
+	batchStartLine := 1
+	statements := 0
 	for scanner.Scan() {
 		query += scanner.Text()
 		if len(query) == 0 || query == "\n" {
 			continue
 		}
-		if err := processBatchQuery(ctx, query, se); err != nil {
-			// TODO: this line number will not be accurate for errors that occur when flushing a batch of inserts (as opposed
-			//  to processing the query)
+		if batchEditStats.unflushedEdits == 0 {
+			batchStartLine = scanner.statementStartLine
+		}
+		if err := processBatchQuery(ctx, query, se, opts.batchSize); err != nil {
+			if ferr, ok := err.(flushError); ok {
+				verr := formatQueryError(fmt.Sprintf("error writing the edits of the statements on lines %d to %d", batchStartLine, scanner.statementStartLine), ferr.err)
+				cli.PrintErrln(verr.Verbose())
+				return failures, err
+			}
+
 			verr := formatQueryError(fmt.Sprintf("error on line %d for query %s", scanner.statementStartLine, query), err)
 			cli.PrintErrln(verr.Verbose())
-			return err
+			if !opts.continueOnError {
+				return failures, err
+			}
+
+			failures = append(failures, batchFailure{scanner.statementStartLine, query, err})
 		}
 		query = ""
+
+		statements++
+		if opts.commit && opts.commitEvery > 0 && statements%opts.commitEvery == 0 {
+			if err := commitBatch(ctx, se, opts.commitMsg); err != nil {
+				return failures, err
+			}
+		}
 	}
 
 	updateBatchInsertOutput()
@@ -580,7 +792,67 @@ func runBatchMode(ctx *sql.Context, se *sqlEngine, input io.Reader) error {
 		cli.Println(err.Error())
 	}
 
-	return flushBatchedEdits(ctx, se)
+	if err := flushBatchedEdits(ctx, se); err != nil {
+		verr := formatQueryError(fmt.Sprintf("error writing the edits of the statements from line %d", batchStartLine), err.(flushError).err)
+		cli.PrintErrln(verr.Verbose())
+		return failures, err
+	}
+
+	if opts.commit {
+		if err := commitBatch(ctx, se, opts.commitMsg); err != nil {
+			return failures, err
+		}
+	}
+
+	printBatchFailures(failures)
+	return failures, nil
+}
+
+// printBatchFailures prints a report of the statements of a batch which failed.
+func printBatchFailures(failures []batchFailure) {
+	if len(failures) == 0 {
+		return
+	}
+
+	cli.PrintErrf("%d statements failed:\n", len(failures))
+	for _, f := range failures {
+		cli.PrintErrf("line %d: %s\n\t%s\n", f.line, strings.TrimSpace(f.query), f.err.Error())
+	}
+}
+
+// commitBatch writes the batched edits to the tables, and commits the changes of each database, staging all of its
+// tables. Databases which haven't changed since their last commit aren't committed.
+func commitBatch(ctx *sql.Context, se *sqlEngine, msg string) error {
+	if err := flushBatchedEdits(ctx, se); err != nil {
+		return err
+	}
+
+	roots, err := se.getRoots(ctx)
+	if err != nil {
+		return err
+	}
+
+	for name, root := range roots {
+		dEnv := se.mrEnv[name]
+		if err := dEnv.UpdateWorkingRoot(ctx, root); err != nil {
+			return err
+		}
+
+		if err := actions.StageAllTables(ctx, dEnv, false); err != nil {
+			cli.PrintErrln(color.RedString("error: failed to stage the tables of %s: %s", name, err.Error()))
+			return err
+		}
+
+		err := actions.CommitStaged(ctx, dEnv, msg, time.Now(), false)
+		if actions.IsNothingStaged(err) {
+			continue
+		} else if err != nil {
+			cli.PrintErrln(color.RedString("error: failed to commit the changes to %s: %s", name, err.Error()))
+			return err
+		}
+	}
+
+	return nil
 }
 
 // runShell starts a SQL shell. Returns when the user exits the shell. The Root of the sqlEngine may
@@ -785,6 +1057,10 @@ func prepend(s string, ss []string) []string {
 // Processes a single query. The Root of the sqlEngine will be updated if necessary.
 // Returns the schema and the row iterator for the results, which may be nil, and an error if one occurs.
 func processQuery(ctx *sql.Context, query string, se *sqlEngine) (sql.Schema, sql.RowIter, error) {
+	if sch, iter, ok, err := dsqle.ExecuteDoltStatement(ctx, se.engine, query); ok {
+		return sch, iter, err
+	}
+
 	sqlStatement, err := sqlparser.Parse(query)
 	if err == sqlparser.ErrEmpty {
 		// silently skip empty statements
@@ -794,7 +1070,12 @@ func processQuery(ctx *sql.Context, query string, se *sqlEngine) (sql.Schema, sq
 	}
 
 	switch s := sqlStatement.(type) {
-	case *sqlparser.Select, *sqlparser.Insert, *sqlparser.Update, *sqlparser.OtherRead, *sqlparser.Show, *sqlparser.Explain, *sqlparser.Union:
+	case *sqlparser.Select, *sqlparser.Insert, *sqlparser.Update, *sqlparser.OtherRead, *sqlparser.Show, *sqlparser.Union:
+		return se.query(ctx, query)
+	case *sqlparser.Explain:
+		if s.Analyze {
+			return dsqle.ExplainAnalyze(ctx, se.engine, s)
+		}
 		return se.query(ctx, query)
 	case *sqlparser.Use, *sqlparser.Set:
 		sch, rowIter, err := se.query(ctx, query)
@@ -845,7 +1126,9 @@ type stats struct {
 var batchEditStats = &stats{}
 var displayStrLen int
 
-const maxBatchSize = 200000
+// defaultBatchSize is the number of edited rows written to the tables at a time in batch mode, unless --batch-size is
+// given.
+const defaultBatchSize = 200000
 const updateInterval = 1000
 
 func (s *stats) numUpdates() int {
@@ -856,10 +1139,21 @@ func (s *stats) shouldUpdateBatchModeOutput() bool {
 	return s.unprintedEdits >= updateInterval
 }
 
-func (s *stats) shouldFlush() bool {
-	return s.unflushedEdits >= maxBatchSize
+func (s *stats) shouldFlush(batchSize int) bool {
+	return s.unflushedEdits >= batchSize
+}
+
+// flushError is returned when the batched edits can't be written to the tables. Any of the statements since the last
+// flush may be at fault.
+type flushError struct {
+	err error
 }
 
+func (e flushError) Error() string {
+	return e.err.Error()
+}
+
+// flushBatchedEdits writes the batched edits to the tables, returning a flushError if they can't be.
 func flushBatchedEdits(ctx *sql.Context, se *sqlEngine) error {
 	err := se.iterDBs(func(_ string, db dsqle.Database) (bool, error) {
 		err := db.Flush(ctx)
@@ -873,11 +1167,19 @@ func flushBatchedEdits(ctx *sql.Context, se *sqlEngine) error {
 
 	batchEditStats.unflushedEdits = 0
 
-	return err
+	if err != nil {
+		return flushError{err}
+	}
+
+	return nil
 }
 
 // Processes a single query in batch mode. The Root of the sqlEngine may or may not be changed.
-func processBatchQuery(ctx *sql.Context, query string, se *sqlEngine) error {
+func processBatchQuery(ctx *sql.Context, query string, se *sqlEngine, batchSize int) error {
+	if dsqle.IsDoltStatement(query) {
+		return processNonInsertBatchQuery(ctx, se, query, nil)
+	}
+
 	sqlStatement, err := sqlparser.Parse(query)
 	if err == sqlparser.ErrEmpty {
 		// silently skip empty statements
@@ -887,7 +1189,7 @@ func processBatchQuery(ctx *sql.Context, query string, se *sqlEngine) error {
 	}
 
 	if canProcessAsBatchInsert(sqlStatement) {
-		err = processBatchInsert(ctx, se, query, sqlStatement)
+		err = processBatchInsert(ctx, se, query, sqlStatement, batchSize)
 		if err != nil {
 			return err
 		}
@@ -924,9 +1226,15 @@ func processNonInsertBatchQuery(ctx *sql.Context, se *sqlEngine, query string, s
 			return fmt.Errorf("error executing statement: %v", err.Error())
 		}
 
-		// Some statement types should print results, even in batch mode.
+		// Some statement types should print results, even in batch mode. Statements the parser doesn't support print
+		// their results unless they only report success.
+		printResults := sqlStatement == nil && !isOkResult(sqlSch)
 		switch sqlStatement.(type) {
 		case *sqlparser.Select, *sqlparser.OtherRead, *sqlparser.Show, *sqlparser.Explain, *sqlparser.Union:
+			printResults = true
+		}
+
+		if printResults {
 			if displayStrLen > 0 {
 				// If we've been printing in batch mode, print a newline to put the regular output on its own line
 				cli.Print("\n")
@@ -943,7 +1251,7 @@ func processNonInsertBatchQuery(ctx *sql.Context, se *sqlEngine, query string, s
 	return flushBatchedEdits(ctx, se)
 }
 
-func processBatchInsert(ctx *sql.Context, se *sqlEngine, query string, sqlStatement sqlparser.Statement) error {
+func processBatchInsert(ctx *sql.Context, se *sqlEngine, query string, sqlStatement sqlparser.Statement, batchSize int) error {
 	_, rowIter, err := se.query(ctx, query)
 	if err != nil {
 		return fmt.Errorf("Error inserting rows: %v", err.Error())
@@ -957,7 +1265,7 @@ func processBatchInsert(ctx *sql.Context, se *sqlEngine, query string, sqlStatem
 		}
 	}
 
-	if batchEditStats.shouldFlush() {
+	if batchEditStats.shouldFlush(batchSize) {
 		return flushBatchedEdits(ctx, se)
 	}
 
@@ -1023,6 +1331,7 @@ const (
 	formatTabular resultFormat = iota
 	formatCsv
 	formatJson
+	formatArrow
 )
 
 type sqlEngine struct {
@@ -1030,14 +1339,18 @@ type sqlEngine struct {
 	mrEnv        env.MultiRepoEnv
 	engine       *sqle.Engine
 	resultFormat resultFormat
+	// showBinary is whether binary values are printed in full rather than described when they don't look like text
+	showBinary bool
+	// resultOut is where the results of queries are written
+	resultOut io.Writer
 }
 
 var ErrDBNotFoundKind = errors.NewKind("database '%s' not found")
 
 // sqlEngine packages up the context necessary to run sql queries against sqle.
-func newSqlEngine(sqlCtx *sql.Context, mrEnv env.MultiRepoEnv, roots map[string]*doltdb.RootValue, format resultFormat, dbs ...dsqle.Database) (*sqlEngine, error) {
-	engine := sqle.NewDefault()
-	engine.AddDatabase(sql.NewInformationSchemaDatabase(engine.Catalog))
+func newSqlEngine(sqlCtx *sql.Context, mrEnv env.MultiRepoEnv, roots map[string]*doltdb.RootValue, format resultFormat, showBinary bool, dbs ...dsqle.Database) (*sqlEngine, error) {
+	engine := dsqle.NewEngine()
+	engine.AddDatabase(dsqle.NewInformationSchemaDatabase(engine.Catalog))
 
 	dsess := dsqle.DSessFromSess(sqlCtx.Session)
 
@@ -1046,6 +1359,9 @@ func newSqlEngine(sqlCtx *sql.Context, mrEnv env.MultiRepoEnv, roots map[string]
 		nameToDB[db.Name()] = db
 		root := roots[db.Name()]
 		engine.AddDatabase(db)
+
+		// the caches are registered for as long as the command runs, so dolt_flush_cache() flushes them
+		db.RegisterCaches()
 		err := dsess.AddDB(sqlCtx, db)
 
 		if err != nil {
@@ -1070,7 +1386,7 @@ func newSqlEngine(sqlCtx *sql.Context, mrEnv env.MultiRepoEnv, roots map[string]
 		return nil, err
 	}
 
-	return &sqlEngine{nameToDB, mrEnv, engine, format}, nil
+	return &sqlEngine{nameToDB, mrEnv, engine, format, showBinary, cli.CliOut}, nil
 }
 
 func (se *sqlEngine) getDB(name string) (dsqle.Database, error) {
@@ -1129,6 +1445,10 @@ func (se *sqlEngine) prettyPrintResults(ctx context.Context, sqlSch sql.Schema,
 		return printOKResult(ctx, rowIter)
 	}
 
+	if se.resultFormat == formatArrow {
+		return se.writeArrowResults(ctx, sqlSch, rowIter)
+	}
+
 	nbf := types.Format_Default
 
 	doltSch, err := dsqle.SqlSchemaToDoltResultSchema(sqlSch)
@@ -1157,8 +1477,8 @@ func (se *sqlEngine) prettyPrintResults(ctx context.Context, sqlSch sql.Schema,
 		p.AddStage(pipeline.NamedTransform{Name: fwtStageName, Func: autoSizeTransform.TransformToFWT})
 	}
 
-	// Redirect output to the CLI
-	cliWr := iohelp.NopWrCloser(cli.CliOut)
+	// Redirect output to the CLI, or the result file
+	cliWr := iohelp.NopWrCloser(se.resultOut)
 
 	var wr table.TableWriteCloser
 
@@ -1210,10 +1530,14 @@ func (se *sqlEngine) prettyPrintResults(ctx context.Context, sqlSch sql.Schema,
 	switch se.resultFormat {
 	case formatJson:
 		rowFn = func(r sql.Row) (r2 row.Row, err error) {
-			return dsqle.SqlRowToDoltRow(nbf, r, doltSch)
+			return dsqle.SqlRowToDoltRow(nbf, se.printableBlobs(sqlSch, r), doltSch)
 		}
 	default:
 		rowFn = func(r sql.Row) (row.Row, error) {
+			if se.resultFormat == formatTabular {
+				r = se.printableBlobs(sqlSch, r)
+			}
+
 			taggedVals := make(row.TaggedValues)
 			for i, col := range r {
 				if col != nil {
@@ -1252,6 +1576,52 @@ func (se *sqlEngine) prettyPrintResults(ctx context.Context, sqlSch sql.Schema,
 	return nil
 }
 
+// writeArrowResults writes the results of a query as an Arrow IPC stream, converting the rows a batch at a time.
+func (se *sqlEngine) writeArrowResults(ctx context.Context, sqlSch sql.Schema, rowIter sql.RowIter) error {
+	n, err := sqlarrow.WriteRows(ctx, se.resultOut, sqlSch, rowIter)
+
+	if err != nil {
+		return fmt.Errorf("error processing results: %v", err)
+	}
+
+	cli.PrintErrf("Wrote %d rows\n", n)
+	return nil
+}
+
+// printableBlobs returns the row given with its binary values replaced by their printed form, unless the engine shows
+// binary values in full. In tabular results binary values which look like text are quoted, while in json results they
+// are left as they are.
+func (se *sqlEngine) printableBlobs(sqlSch sql.Schema, r sql.Row) sql.Row {
+	if se.showBinary {
+		return r
+	}
+
+	var printable sql.Row
+	for i, col := range sqlSch {
+		str, ok := r[i].(string)
+
+		if !ok || !sql.IsBlob(col.Type) {
+			continue
+		}
+
+		if printable == nil {
+			printable = r.Copy()
+		}
+
+		if se.resultFormat == formatTabular {
+			printable[i] = blobprinter.Format(str)
+		} else if !blobprinter.IsText(str) {
+			printable[i] = blobprinter.Describe(str)
+		}
+	}
+
+	if printable == nil {
+		return r
+	}
+
+	return printable
+}
+
 func printOKResult(ctx context.Context, iter sql.RowIter) error {
 	row, err := iter.Next()
 	defer iter.Close()
@@ -0,0 +1,364 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/mvdata"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/typed/noms"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/earl"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+	"github.com/liquidata-inc/dolt/go/store/datas"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const (
+	ephemeralHomeDir = "/home"
+	ephemeralRepoDir = "/dolt"
+
+	// the author of the commits within an ephemeral database when the user hasn't configured one
+	defaultEphemeralName  = "dolt"
+	defaultEphemeralEmail = "dolt@localhost"
+)
+
+// ephemeralImportRegex matches a single import: <file> [AS <table>] [PRIMARY KEY (<column>[, <column>...])]
+var ephemeralImportRegex = regexp.MustCompile(`(?i)^(.+?)(?:\s+AS\s+([^\s()]+))?(?:\s+PRIMARY\s+KEY\s*\(([^)]*)\))?$`)
+
+// ephemeralImport describes a file to import into a table of an ephemeral database.
+type ephemeralImport struct {
+	path  string
+	table string
+	pks   []string
+}
+
+// parseEphemeralImports parses the comma separated list of imports given with --import.
+func parseEphemeralImports(spec string) ([]ephemeralImport, error) {
+	var items []string
+	depth, start := 0, 0
+	for i, r := range spec {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, spec[start:i])
+				start = i + 1
+			}
+		}
+	}
+	items = append(items, spec[start:])
+
+	var imports []ephemeralImport
+	tables := make(map[string]string)
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+
+		if item == "" {
+			continue
+		}
+
+		matches := ephemeralImportRegex.FindStringSubmatch(item)
+
+		if matches == nil {
+			return nil, fmt.Errorf("invalid import '%s', expected <file> [AS <table>] [PRIMARY KEY (<column>, ...)]", item)
+		}
+
+		imp := ephemeralImport{path: strings.TrimSpace(matches[1]), table: matches[2]}
+
+		if imp.table == "" {
+			base := filepath.Base(imp.path)
+			imp.table = strings.TrimSuffix(base, filepath.Ext(base))
+		}
+
+		if !doltdb.IsValidTableName(imp.table) {
+			return nil, fmt.Errorf("'%s' is not a valid table name, name the table '%s' is imported into with AS <table>", imp.table, imp.path)
+		}
+
+		if other, ok := tables[imp.table]; ok {
+			return nil, fmt.Errorf("'%s' and '%s' are both imported into the table '%s'", other, imp.path, imp.table)
+		}
+
+		tables[imp.table] = imp.path
+
+		if matches[3] != "" {
+			for _, pk := range strings.Split(matches[3], ",") {
+				if pk = strings.TrimSpace(pk); pk != "" {
+					imp.pks = append(imp.pks, pk)
+				}
+			}
+		}
+
+		imports = append(imports, imp)
+	}
+
+	return imports, nil
+}
+
+// newEphemeralEnv creates an environment whose repository is held entirely in memory. Nothing is written to disk,
+// and the repository is gone once the process exits. The name and email in |dEnv|'s config, if any, are used for its
+// initial commit.
+func newEphemeralEnv(ctx context.Context, dEnv *env.DoltEnv) (*env.DoltEnv, errhand.VerboseError) {
+	fs := filesys.NewInMemFS([]string{ephemeralHomeDir, ephemeralRepoDir}, nil, ephemeralRepoDir)
+	memEnv := env.Load(ctx, func() (string, error) { return ephemeralHomeDir, nil }, fs, doltdb.InMemDoltDB, dEnv.Version)
+
+	// loading the environment points the remote factories at it, and they should keep using the user's credentials
+	dbfactory.InitializeFactories(dEnv)
+
+	name := *dEnv.Config.GetStringOrDefault(env.UserNameKey, defaultEphemeralName)
+	email := *dEnv.Config.GetStringOrDefault(env.UserEmailKey, defaultEphemeralEmail)
+	err := memEnv.InitRepo(ctx, types.Format_Default, name, email)
+
+	if err != nil {
+		return nil, errhand.BuildDError("error: failed to create an in memory database").AddCause(err).Build()
+	}
+
+	return memEnv, nil
+}
+
+// importIntoEphemeralEnv imports each of |imports|, read from |fs|, into a new table in the working set of |memEnv|.
+func importIntoEphemeralEnv(ctx context.Context, memEnv *env.DoltEnv, fs filesys.Filesys, imports []ephemeralImport) errhand.VerboseError {
+	for _, imp := range imports {
+		root, err := memEnv.WorkingRoot(ctx)
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to read the in memory database").AddCause(err).Build()
+		}
+
+		fileLoc := mvdata.FileDataLocation{Path: imp.path}
+		var srcOpts interface{}
+		switch strings.ToLower(filepath.Ext(imp.path)) {
+		case string(mvdata.CsvFile):
+			fileLoc.Format = mvdata.CsvFile
+			srcOpts = mvdata.CsvOptions{Delim: ","}
+		case string(mvdata.PsvFile):
+			fileLoc.Format = mvdata.PsvFile
+		case string(mvdata.XlsxFile):
+			fileLoc.Format = mvdata.XlsxFile
+			srcOpts = mvdata.XlsxOptions{SheetName: imp.table}
+		default:
+			return errhand.BuildDError("error: unable to import '%s'", imp.path).AddDetails("Only csv, psv and xlsx files can be imported.").Build()
+		}
+
+		if exists, isDir := fs.Exists(imp.path); !exists || isDir {
+			return errhand.BuildDError("error: unable to import '%s', file not found", imp.path).Build()
+		}
+
+		// the rows are counted up front, as the import quietly replaces rows which share a primary key
+		rd, _, err := fileLoc.NewReader(ctx, root, fs, "", srcOpts)
+
+		if err != nil {
+			return errhand.BuildDError("error: unable to read '%s'", imp.path).AddCause(err).Build()
+		}
+
+		cols := rd.GetSchema().GetAllCols()
+		rowCount, err := countRows(ctx, rd)
+		_ = rd.Close(ctx)
+
+		if err != nil {
+			return errhand.BuildDError("error: unable to read '%s'", imp.path).AddCause(err).Build()
+		} else if cols.Size() == 0 {
+			return errhand.BuildDError("error: '%s' has no columns", imp.path).Build()
+		}
+
+		pks := imp.pks
+		keyedByFirstCol := len(pks) == 0
+		if keyedByFirstCol {
+			pks = []string{cols.GetByIndex(0).Name}
+		}
+
+		mvOpts := &mvdata.MoveOptions{
+			Operation:  mvdata.OverwriteOp,
+			TableName:  imp.table,
+			PrimaryKey: strings.Join(pks, ","),
+			Src:        fileLoc,
+			Dest:       mvdata.TableDataLocation{Name: imp.table},
+			SrcOptions: srcOpts,
+		}
+
+		mover, nDMErr := mvdata.NewDataMover(ctx, root, fs, mvOpts, nil)
+
+		if nDMErr != nil {
+			return errhand.BuildDError("error: unable to import '%s'", imp.path).AddDetails(nDMErr.String()).Build()
+		}
+
+		_, err = mover.Move(ctx)
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to import '%s'", imp.path).AddCause(err).Build()
+		}
+
+		nomsWr := mover.Wr.(noms.NomsMapWriteCloser)
+		rows := *nomsWr.GetMap()
+
+		if rows.Len() != rowCount {
+			bdr := errhand.BuildDError("error: '%s' has more than one row with the same %s", imp.path, strings.Join(pks, ", "))
+
+			if keyedByFirstCol {
+				bdr.AddDetails("Rows are keyed by the first column unless a key is given, as in '%s AS %s PRIMARY KEY (<column>, ...)'", imp.path, imp.table)
+			}
+
+			return bdr.Build()
+		}
+
+		err = memEnv.PutTableToWorking(ctx, rows, nomsWr.GetSchema(), imp.table)
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to import '%s'", imp.path).AddCause(err).Build()
+		}
+	}
+
+	return nil
+}
+
+func countRows(ctx context.Context, rd table.TableReadCloser) (uint64, error) {
+	var count uint64
+	for {
+		_, err := rd.ReadRow(ctx)
+
+		if err == io.EOF {
+			return count, nil
+		} else if err != nil {
+			return 0, err
+		}
+
+		count++
+	}
+}
+
+// saveEphemeralEnv creates a new repository in |dir| whose working set holds the working set of |memEnv|.
+func saveEphemeralEnv(ctx context.Context, dEnv, memEnv *env.DoltEnv, dir string) errhand.VerboseError {
+	absDir, err := dEnv.FS.Abs(dir)
+
+	if err != nil {
+		return errhand.BuildDError("error: invalid directory '%s'", dir).AddCause(err).Build()
+	}
+
+	if exists, isDir := dEnv.FS.Exists(absDir); exists && !isDir {
+		return errhand.BuildDError("error: '%s' exists and is not a directory", dir).Build()
+	} else if exists, _ := dEnv.FS.Exists(filepath.Join(absDir, dbfactory.DoltDir)); exists {
+		return errhand.BuildDError("error: '%s' is already a dolt data repository", dir).Build()
+	}
+
+	err = dEnv.FS.MkDirs(absDir)
+
+	if err != nil {
+		return errhand.BuildDError("error: unable to create directory '%s'", dir).AddCause(err).Build()
+	}
+
+	fs, err := filesys.LocalFilesysWithWorkingDir(absDir)
+
+	if err != nil {
+		return errhand.VerboseErrorFromError(err)
+	}
+
+	urlStr := earl.FileUrlFromPath(filepath.Join(absDir, dbfactory.DoltDataDir), os.PathSeparator)
+	diskEnv := env.Load(ctx, env.GetCurrentUserHomeDir, fs, urlStr, dEnv.Version)
+	dbfactory.InitializeFactories(dEnv)
+
+	name := *dEnv.Config.GetStringOrDefault(env.UserNameKey, defaultEphemeralName)
+	email := *dEnv.Config.GetStringOrDefault(env.UserEmailKey, defaultEphemeralEmail)
+	err = diskEnv.InitRepo(ctx, types.Format_Default, name, email)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to initialize a data repository in '%s'", dir).AddCause(err).Build()
+	}
+
+	root, err := memEnv.WorkingRoot(ctx)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to read the in memory database").AddCause(err).Build()
+	}
+
+	// the working set is carried over by a dangling commit, which pulls every chunk it references into the new repository
+	valHash, err := memEnv.DoltDB.WriteRootValue(ctx, root)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to write the in memory database").AddCause(err).Build()
+	}
+
+	meta, err := doltdb.NewCommitMeta(name, email, "dolt sql --save-to")
+
+	if err != nil {
+		return errhand.VerboseErrorFromError(err)
+	}
+
+	cm, err := memEnv.DoltDB.CommitDanglingWithParentCommits(ctx, valHash, nil, meta)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to write the in memory database").AddCause(err).Build()
+	}
+
+	progChan := make(chan datas.PullProgress)
+	pullerEventCh := make(chan datas.PullerEvent)
+	go func() {
+		for range progChan {
+		}
+	}()
+	go func() {
+		for range pullerEventCh {
+		}
+	}()
+
+	err = diskEnv.DoltDB.PushChunks(ctx, diskEnv.TempTableFilesDir(), memEnv.DoltDB, cm, progChan, pullerEventCh)
+	close(progChan)
+	close(pullerEventCh)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to save the database to '%s'", dir).AddCause(err).Build()
+	}
+
+	cmHash, err := cm.HashOf()
+
+	if err != nil {
+		return errhand.VerboseErrorFromError(err)
+	}
+
+	cs, err := doltdb.NewCommitSpec(cmHash.String(), "")
+
+	if err != nil {
+		return errhand.VerboseErrorFromError(err)
+	}
+
+	savedCm, err := diskEnv.DoltDB.Resolve(ctx, cs)
+
+	var savedRoot *doltdb.RootValue
+	if err == nil {
+		savedRoot, err = savedCm.GetRootValue()
+	}
+
+	if err == nil {
+		err = diskEnv.UpdateWorkingRoot(ctx, savedRoot)
+	}
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to save the database to '%s'", dir).AddCause(err).Build()
+	}
+
+	return nil
+}
@@ -16,13 +16,17 @@ package commands
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/typed/noms"
@@ -79,6 +83,66 @@ func TestSqlBatchMode(t *testing.T) {
 	}
 }
 
+func TestSqlBatchCommits(t *testing.T) {
+	ctx := context.Background()
+	dEnv := createEnvWithSeedData(t)
+	query := "create table test (a int primary key);" +
+		"insert into test values (1);" +
+		"insert into test values (1);" +
+		"insert into test values (2);" +
+		"insert into test values (3);"
+	args := []string{"-b", "-q", query, "--batch-size", "1", "--commit", "--commit-every", "2", "-m", "batch commit", "--continue-on-error"}
+
+	// the statement inserting a duplicate key fails, and is reported once the rest have run
+	result := SqlCmd{}.Exec(ctx, "dolt sql", args, dEnv)
+	assert.Equal(t, 1, result)
+
+	root, err := dEnv.WorkingRoot(ctx)
+	require.NoError(t, err)
+	tbl, ok, err := root.GetTable(ctx, "test")
+	require.NoError(t, err)
+	require.True(t, ok)
+	rows, err := tbl.GetRowData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), rows.Len())
+
+	// commits are made after the second and fourth statements, and after the last
+	cs, err := doltdb.NewCommitSpec("HEAD", "master")
+	require.NoError(t, err)
+	head, err := dEnv.DoltDB.Resolve(ctx, cs)
+	require.NoError(t, err)
+	commits, err := actions.TimeSortedCommits(ctx, dEnv.DoltDB, head, -1)
+	require.NoError(t, err)
+	require.Len(t, commits, 4)
+	for _, cm := range commits[:3] {
+		meta, err := cm.GetCommitMeta()
+		require.NoError(t, err)
+		assert.Equal(t, "batch commit", meta.Description)
+	}
+
+	headRoot, err := head.GetRootValue()
+	require.NoError(t, err)
+	headTbl, ok, err := headRoot.GetTable(ctx, "test")
+	require.NoError(t, err)
+	require.True(t, ok)
+	headRows, err := headTbl.GetRowData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), headRows.Len())
+
+	invalid := [][]string{
+		{"-q", "insert into test values (4)", "--commit"},
+		{"-b", "-q", "insert into test values (4)", "--commit-every", "2"},
+		{"-b", "-q", "insert into test values (4)", "--batch-size", "0"},
+		{"-x", "saved", "--continue-on-error"},
+		{"-b", "-q", "insert into test values (4)", "-m", "message"},
+	}
+	for _, args := range invalid {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			assert.Equal(t, 1, SqlCmd{}.Exec(ctx, "dolt sql", args, dEnv))
+		})
+	}
+}
+
 // Smoke tests, values are printed to console
 func TestSqlSelect(t *testing.T) {
 	tests := []struct {
@@ -562,6 +626,76 @@ func TestDelete(t *testing.T) {
 	}
 }
 
+func TestParseEphemeralImports(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected []ephemeralImport
+		err      bool
+	}{
+		{"", nil, false},
+		{"people.csv", []ephemeralImport{{"people.csv", "people", nil}}, false},
+		{"data/people.csv AS p", []ephemeralImport{{"data/people.csv", "p", nil}}, false},
+		{
+			"people.csv as p primary key (id), orders.psv PRIMARY KEY (pid, item)",
+			[]ephemeralImport{{"people.csv", "p", []string{"id"}}, {"orders.psv", "orders", []string{"pid", "item"}}},
+			false,
+		},
+		{"people.csv, data/people.csv", nil, true},
+		{"people.csv AS 1bad", nil, true},
+		{"2020.csv", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			imports, err := parseEphemeralImports(test.spec)
+
+			if test.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, imports)
+			}
+		})
+	}
+}
+
+func TestSqlNoRepo(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+	err := dEnv.FS.WriteFile("people.csv", []byte("id,name\n1,bill\n2,jill\n"))
+	require.NoError(t, err)
+	err = dEnv.FS.WriteFile("dupes.csv", []byte("id,name\n1,bill\n1,jill\n"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		args        []string
+		expectedRes int
+	}{
+		{[]string{"--no-repo", "-q", "show tables"}, 0},
+		{[]string{"--no-repo", "--import", "people.csv", "-q", "select * from people where name = 'jill'"}, 0},
+		{[]string{"--no-repo", "--import", "people.csv AS p", "-b", "-q", "insert into p values ('3', 'phil'); select * from p"}, 0},
+		{[]string{"--no-repo", "--import", "people.csv AS p", "-q", "select * from people"}, 1},
+		{[]string{"--no-repo", "--import", "dupes.csv", "-q", "select * from dupes"}, 1},
+		{[]string{"--no-repo", "--import", "dupes.csv PRIMARY KEY (id, name)", "-q", "select * from dupes"}, 0},
+		{[]string{"--no-repo", "--import", "missing.csv", "-q", "show tables"}, 1},
+		{[]string{"--import", "people.csv", "-q", "show tables"}, 1},
+		{[]string{"--save-to", "saved", "-q", "show tables"}, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			result := SqlCmd{}.Exec(context.Background(), "dolt sql", test.args, dEnv)
+			assert.Equal(t, test.expectedRes, result)
+		})
+	}
+
+	// the repository of the environment the command ran in is left alone
+	root, err := dEnv.WorkingRoot(context.Background())
+	require.NoError(t, err)
+	names, err := root.GetTableNames(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, names)
+}
+
 func createEnvWithSeedData(t *testing.T) *env.DoltEnv {
 	dEnv := dtestutils.CreateTestEnv()
 	imt, sch := dtestutils.CreateTestDataTable(true)
@@ -0,0 +1,237 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle/sqlarrow"
+)
+
+// ArrowQueryPath is the path of the Arrow HTTP endpoint.
+const ArrowQueryPath = "/query"
+
+// maxArrowQuerySize is the size in bytes of the largest query the Arrow endpoint reads from the body of a request.
+const maxArrowQuerySize = 1 << 20
+
+// arrowConnIDStart is the first connection id of the sessions of the Arrow endpoint. They are numbered from the top
+// half of the range so that they don't share the workspaces of the server's connections.
+const arrowConnIDStart = math.MaxUint32 / 2
+
+// arrowHandler is the http.Handler of the Arrow endpoint. It runs the query of each request on a new session, and
+// streams its results as an Arrow IPC stream of record batches, which are converted from the rows a batch at a time.
+//
+// The query is the body of a POST, or the q parameter of a GET, and the db parameter is the database it's run in,
+// which defaults to the server's database if there is only one. Clients authenticate as one of the server's users with
+// HTTP basic auth. Only queries which read, such as SELECT and SHOW, can be run.
+type arrowHandler struct {
+	engine     *sqle.Engine
+	userAuth   *reloadableAuth
+	newSession sessionFactory
+	host       string
+	nextConnID uint32
+}
+
+func newArrowHandler(engine *sqle.Engine, userAuth *reloadableAuth, newSession sessionFactory, host string) *arrowHandler {
+	return &arrowHandler{engine: engine, userAuth: userAuth, newSession: newSession, host: host, nextConnID: arrowConnIDStart}
+}
+
+func (h *arrowHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != ArrowQueryPath {
+		http.NotFound(w, r)
+		return
+	}
+
+	user, password, ok := r.BasicAuth()
+
+	if !ok || !h.userAuth.checkPassword(user, password) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="dolt"`)
+		http.Error(w, "invalid user or password", http.StatusUnauthorized)
+		return
+	}
+
+	var query string
+	switch r.Method {
+	case http.MethodGet:
+		query = r.URL.Query().Get("q")
+	case http.MethodPost:
+		data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxArrowQuerySize))
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to read the query: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		query = string(data)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query = strings.TrimSpace(query)
+
+	if query == "" {
+		http.Error(w, "missing query", http.StatusBadRequest)
+		return
+	}
+
+	if err := checkArrowQuery(query); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	connID := atomic.AddUint32(&h.nextConnID, 1)
+	sess, ir, vr, err := h.newSession(r.Context(), h.host, r.RemoteAddr, user, connID)
+
+	if err != nil {
+		logrus.Errorf("Arrow endpoint failed to create a session for user '%s': %v", user, err)
+		http.Error(w, "failed to create a session", http.StatusInternalServerError)
+		return
+	}
+
+	defer sess.Release(dbsAsDSQLDBs(h.engine.Catalog.AllDatabases())...)
+
+	sqlCtx := sql.NewContext(r.Context(),
+		sql.WithSession(sess),
+		sql.WithIndexRegistry(ir),
+		sql.WithViewRegistry(vr),
+		sql.WithPid(uint64(connID)),
+		sql.WithQuery(query))
+
+	if err := h.useDatabase(sqlCtx, r.URL.Query().Get("db")); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sch, iter, err := queryEngine(h.engine).Query(sqlCtx, query)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	defer iter.Close()
+
+	w.Header().Set("Content-Type", sqlarrow.ContentType)
+
+	// once the stream has started its status can't be changed, so a failure part of the way through is only logged,
+	// and the client sees a stream without its end
+	n, err := sqlarrow.WriteRows(sqlCtx, w, sch, iter)
+
+	if err != nil {
+		logrus.Errorf("Arrow endpoint failed after writing %d rows of query '%s' for user '%s': %v", n, query, user, err)
+		return
+	}
+
+	logrus.Debugf("Arrow endpoint wrote %d rows of query '%s' for user '%s'", n, query, user)
+}
+
+// useDatabase makes |name| the current database of |ctx|, or the only database of the server if |name| is empty.
+func (h *arrowHandler) useDatabase(ctx *sql.Context, name string) error {
+	if name != "" {
+		if !h.engine.Catalog.HasDB(name) {
+			return sql.ErrDatabaseNotFound.New(name)
+		}
+
+		ctx.SetCurrentDatabase(name)
+		return nil
+	}
+
+	dbs := dbsAsDSQLDBs(h.engine.Catalog.AllDatabases())
+
+	if len(dbs) == 1 {
+		ctx.SetCurrentDatabase(dbs[0].Name())
+	}
+
+	return nil
+}
+
+// checkArrowQuery returns an error unless |query| is a single statement which reads, rather than writes.
+func checkArrowQuery(query string) error {
+	stmt, err := sqlparser.Parse(query)
+
+	if err != nil {
+		return err
+	}
+
+	switch stmt.(type) {
+	case *sqlparser.Select, *sqlparser.Union, *sqlparser.ParenSelect, *sqlparser.Show, *sqlparser.OtherRead:
+		return nil
+	default:
+		return fmt.Errorf("only queries which read, such as SELECT and SHOW, can be run by the Arrow endpoint")
+	}
+}
+
+// arrowServer serves the Arrow endpoint of a server.
+type arrowServer struct {
+	srv *http.Server
+	l   net.Listener
+}
+
+// newArrowServer listens on |addr| and serves |h| there in the background, over TLS if |tlsConfig| isn't nil.
+func newArrowServer(addr string, h http.Handler, tlsConfig *tls.Config) (*arrowServer, error) {
+	l, err := net.Listen("tcp", addr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if tlsConfig != nil {
+		l = tls.NewListener(l, tlsConfig)
+	}
+
+	as := &arrowServer{srv: &http.Server{Handler: h}, l: l}
+
+	go func() {
+		if err := as.srv.Serve(l); err != http.ErrServerClosed {
+			logrus.Errorf("Arrow endpoint stopped: %v", err)
+		}
+	}()
+
+	logrus.Infof("Serving query results as Arrow record batches at %s", addr)
+	return as, nil
+}
+
+// Addr returns the address the endpoint is listening on.
+func (as *arrowServer) Addr() net.Addr {
+	return as.l.Addr()
+}
+
+// Close stops the endpoint, interrupting the queries it's running.
+func (as *arrowServer) Close() error {
+	return as.srv.Shutdown(canceledContext())
+}
+
+// canceledContext returns a context which is already canceled, so that a shutdown doesn't wait for the requests in
+// flight.
+func canceledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
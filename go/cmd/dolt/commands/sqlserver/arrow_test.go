@@ -0,0 +1,122 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle/sqlarrow"
+)
+
+func TestCheckArrowQuery(t *testing.T) {
+	for _, query := range []string{"select * from people", "show tables", "(select 1) union (select 2)", "describe people"} {
+		assert.NoError(t, checkArrowQuery(query), query)
+	}
+
+	for _, query := range []string{"insert into people (name) values ('x')", "delete from people", "drop table people", "select 1; select 2"} {
+		assert.Error(t, checkArrowQuery(query), query)
+	}
+}
+
+func TestServerArrowEndpoint(t *testing.T) {
+	env := createEnvWithSeedData(t)
+	serverConfig := DefaultServerConfig().withLogLevel(LogLevel_Fatal).withPort(15450).withArrowPort(15451).withPassword("pw")
+
+	sc := CreateServerController()
+	defer sc.StopServer()
+	go func() {
+		_, _ = Serve(context.Background(), "", serverConfig, sc, env)
+	}()
+	require.NoError(t, sc.WaitForStart())
+
+	endpoint := fmt.Sprintf("http://localhost:%d%s", serverConfig.ArrowPort(), ArrowQueryPath)
+	do := func(method, query, password string) *http.Response {
+		var req *http.Request
+		var err error
+		if method == http.MethodPost {
+			req, err = http.NewRequest(method, endpoint, strings.NewReader(query))
+		} else {
+			req, err = http.NewRequest(method, endpoint+"?q="+url.QueryEscape(query), nil)
+		}
+		require.NoError(t, err)
+		req.SetBasicAuth(serverConfig.User(), password)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		t.Run(method, func(t *testing.T) {
+			resp := do(method, "select name, age from people order by age", "pw")
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, sqlarrow.ContentType, resp.Header.Get("Content-Type"))
+
+			rd, err := ipc.NewReader(resp.Body)
+			require.NoError(t, err)
+			defer rd.Release()
+
+			assert.Equal(t, "name", rd.Schema().Field(0).Name)
+			require.True(t, rd.Next())
+			rec := rd.Record()
+			require.Equal(t, int64(3), rec.NumRows())
+			assert.Equal(t, rob.Name, rec.Column(0).(*array.String).Value(0))
+			assert.Equal(t, bill.Name, rec.Column(0).(*array.String).Value(2))
+			assert.False(t, rd.Next())
+		})
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		query    string
+		password string
+		status   int
+	}{
+		{"wrong password", http.MethodGet, "select * from people", "nope", http.StatusUnauthorized},
+		{"write", http.MethodPost, "delete from people", "pw", http.StatusBadRequest},
+		{"missing query", http.MethodGet, "", "pw", http.StatusBadRequest},
+		{"bad query", http.MethodGet, "select * from nothing", "pw", http.StatusBadRequest},
+		{"method", http.MethodPut, "select * from people", "pw", http.StatusMethodNotAllowed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := do(test.method, test.query, test.password)
+			defer resp.Body.Close()
+			assert.Equal(t, test.status, resp.StatusCode)
+		})
+	}
+
+	// the rows weren't deleted
+	resp := do(http.MethodGet, "select count(*) from people", "pw")
+	defer resp.Body.Close()
+	rd, err := ipc.NewReader(resp.Body)
+	require.NoError(t, err)
+	defer rd.Release()
+	require.True(t, rd.Next())
+	assert.Equal(t, int64(3), rd.Record().Column(0).(*array.Int64).Value(0))
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"database/sql"
+	"encoding/json"
+	"expvar"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+
+	dsqle "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
+	"github.com/liquidata-inc/dolt/go/store/types"
+	"github.com/liquidata-inc/dolt/go/store/util/cacheregistry"
+)
+
+func cacheCount(name string) int {
+	for _, stats := range cacheregistry.GetStats() {
+		if stats.Name == name {
+			return stats.Caches
+		}
+	}
+
+	return 0
+}
+
+func TestServerFlushCache(t *testing.T) {
+	ctx := context.Background()
+	dEnv := createEnvWithSeedData(t)
+	cachesBefore := cacheCount(types.DecodedChunksCacheName)
+
+	serverConfig := DefaultServerConfig().withLogLevel(LogLevel_Fatal).withPort(15340)
+	sc := startTestServerWithEnv(t, serverConfig, dEnv)
+	defer sc.StopServer()
+
+	const clients = 4
+	db, err := sql.Open("mysql", ConnectionString(serverConfig)+"dolt")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxIdleConns(clients + 1)
+
+	var expected int
+	err = db.QueryRowContext(ctx, "select count(*) from people").Scan(&expected)
+	require.NoError(t, err)
+	require.Greater(t, expected, 0)
+
+	// the caches of the server's database are registered
+	assert.Equal(t, cachesBefore+1, cacheCount(types.DecodedChunksCacheName))
+	assert.Equal(t, 1, cacheCount(dsqle.DiffCacheName))
+
+	var metrics map[string]map[string]uint64
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("dolt_caches").String()), &metrics))
+	assert.Contains(t, metrics, types.DecodedChunksCacheName)
+	assert.Contains(t, metrics, dsqle.DiffCacheName)
+
+	// queries keep returning the same results while the caches are flushed
+	wg := &sync.WaitGroup{}
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 20; j++ {
+				var count int
+				err := db.QueryRowContext(ctx, "select count(*) from people").Scan(&count)
+				assert.NoError(t, err)
+				assert.Equal(t, expected, count)
+			}
+		}()
+	}
+
+	for i := 0; i < 20; i++ {
+		var flushed int64
+		err = db.QueryRowContext(ctx, "select dolt_flush_cache()").Scan(&flushed)
+		require.NoError(t, err)
+	}
+
+	wg.Wait()
+
+	var flushed int64
+	err = db.QueryRowContext(ctx, "select dolt_flush_cache('decoded_chunks')").Scan(&flushed)
+	require.NoError(t, err)
+
+	_, err = db.ExecContext(ctx, "select dolt_flush_cache('nonexistent')")
+	assert.Error(t, err)
+
+	// the caches are unregistered once the server stops
+	sc.StopServer()
+	require.NoError(t, sc.WaitForClose())
+	assert.Equal(t, cachesBefore, cacheCount(types.DecodedChunksCacheName))
+	assert.Equal(t, 0, cacheCount(dsqle.DiffCacheName))
+}
@@ -0,0 +1,707 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"context"
+	"encoding/binary"
+	"expvar"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/sirupsen/logrus"
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/server"
+	"github.com/src-d/go-mysql-server/sql"
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+
+	dsqle "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
+	"github.com/liquidata-inc/dolt/go/store/background"
+)
+
+const (
+	tooManyConnsSQLState     = "08004"
+	tooManyUserConnsSQLState = "42000"
+)
+
+var (
+	// connMetrics are the connection metrics of all servers running in this process, published through expvar.
+	connMetrics       = expvar.NewMap("dolt_sql_server_connections")
+	connsCurrent      = new(expvar.Int)
+	connsPeak         = new(expvar.Int)
+	connsAccepted     = new(expvar.Int)
+	connsRejected     = new(expvar.Int)
+	connsIdleTimedOut = new(expvar.Int)
+)
+
+func init() {
+	connMetrics.Set("current", connsCurrent)
+	connMetrics.Set("peak", connsPeak)
+	connMetrics.Set("accepted", connsAccepted)
+	connMetrics.Set("rejected", connsRejected)
+	connMetrics.Set("idle_timed_out", connsIdleTimedOut)
+}
+
+// ConnectionStats are counts of the connections made to a server.
+type ConnectionStats struct {
+	// Current is the number of open connections
+	Current uint64
+	// Peak is the highest number of connections which have been open at the same time
+	Peak uint64
+	// Rejected is the number of connections which were refused because a connection limit had been reached
+	Rejected uint64
+	// IdleTimedOut is the number of connections which were closed because they were idle for too long
+	IdleTimedOut uint64
+}
+
+// trackedConn is the state kept for each connection that has been handed to the mysql listener.
+type trackedConn struct {
+	conn      *mysql.Conn
+	handler   *connHandler
+	authed    bool
+	user      string
+	sess      sql.Session
+	idleTimer *time.Timer
+	active    bool
+}
+
+// connectionTracker keeps track of the connections to the server. It turns away connections beyond the configured
+// limits, closes connections which have been idle for longer than the idle timeout and releases the state held by the
+// sessions of connections when they are closed. When the server shuts down it is used to drain the connections.
+type connectionTracker struct {
+	maxConns     uint64
+	maxUserConns uint64
+	idleTimeout  time.Duration
+
+	// releaseSession is called with the session of every connection which is closed
+	releaseSession func(sess sql.Session)
+	// persistSession is called with the session of every connection which is open once the server has been drained
+	persistSession func(sess sql.Session) error
+
+	mu         *sync.Mutex
+	userConns  map[string]uint64
+	conns      map[uint32]*trackedConn
+	stats      ConnectionStats
+	draining   bool
+	activeCmds int
+	// cmdsDone is closed once the server is draining and no commands are running
+	cmdsDone chan struct{}
+	// netConns are the sockets accepted by the listener, keyed by the address of the client, until the connection they
+	// were accepted for is opened
+	netConns map[string]*net.TCPConn
+}
+
+func newConnectionTracker(maxConns, maxUserConns uint64, idleTimeout time.Duration, releaseSession func(sql.Session), persistSession func(sql.Session) error) *connectionTracker {
+	if maxConns == 0 {
+		// matches the behavior of server.NewServer
+		maxConns = 1
+	}
+
+	return &connectionTracker{
+		maxConns:       maxConns,
+		maxUserConns:   maxUserConns,
+		idleTimeout:    idleTimeout,
+		releaseSession: releaseSession,
+		persistSession: persistSession,
+		mu:             &sync.Mutex{},
+		userConns:      make(map[string]uint64),
+		conns:          make(map[uint32]*trackedConn),
+		netConns:       make(map[string]*net.TCPConn),
+		cmdsDone:       make(chan struct{}),
+	}
+}
+
+// Stats returns the current connection counts.
+func (ct *connectionTracker) Stats() ConnectionStats {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	return ct.stats
+}
+
+// newServer creates a *server.Server in the same way as server.NewServer, except that the net.Listener, mysql.Handler
+// and server.SessionBuilder are wrapped so that connection limits and idle timeouts are enforced by ct. Queries which
+// take longer than slowQueryThreshold are logged, unless it is 0.
+func newServer(cfg server.Config, e *sqle.Engine, sb server.SessionBuilder, ct *connectionTracker, slowQueryThreshold time.Duration) (*server.Server, error) {
+	if cfg.ConnReadTimeout < 0 {
+		cfg.ConnReadTimeout = 0
+	}
+
+	if cfg.ConnWriteTimeout < 0 {
+		cfg.ConnWriteTimeout = 0
+	}
+
+	sm := server.NewSessionManager(ct.sessionBuilder(sb), opentracing.NoopTracer{}, e.Catalog.HasDB, e.Catalog.MemoryManager, cfg.Address)
+
+	l, err := net.Listen(cfg.Protocol, cfg.Address)
+
+	if err != nil {
+		return nil, err
+	}
+
+	vtListener, err := mysql.NewListenerWithConfig(mysql.ListenerConfig{
+		Listener:           &limitingListener{l, ct},
+		AuthServer:         cfg.Auth.Mysql(),
+		Handler:            &trackingHandler{ct, e, sm, cfg.ConnReadTimeout, slowQueryThreshold},
+		ConnReadTimeout:    cfg.ConnReadTimeout,
+		ConnWriteTimeout:   cfg.ConnWriteTimeout,
+		ConnReadBufferSize: mysql.DefaultConnBufferSize,
+	})
+
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	if cfg.Version != "" {
+		vtListener.ServerVersion = cfg.Version
+	}
+
+	return &server.Server{Listener: vtListener}, nil
+}
+
+// acquire reserves a connection if the server is below its connection limit.
+func (ct *connectionTracker) acquire() bool {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	if ct.stats.Current >= ct.maxConns {
+		ct.stats.Rejected++
+		connsRejected.Add(1)
+		return false
+	}
+
+	ct.stats.Current++
+	connsCurrent.Add(1)
+	connsAccepted.Add(1)
+
+	if ct.stats.Current > ct.stats.Peak {
+		ct.stats.Peak = ct.stats.Current
+	}
+
+	if connsCurrent.Value() > connsPeak.Value() {
+		connsPeak.Set(connsCurrent.Value())
+	}
+
+	return true
+}
+
+// authenticated is called once the user of a connection has authenticated. It returns an error if the user has
+// reached their connection limit.
+func (ct *connectionTracker) authenticated(c *mysql.Conn) error {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	if ct.draining {
+		return errServerShutdown()
+	}
+
+	tc := ct.getOrCreateConn(c)
+
+	if tc.authed {
+		return nil
+	}
+
+	if ct.maxUserConns > 0 && ct.userConns[c.User] >= ct.maxUserConns {
+		ct.stats.Rejected++
+		connsRejected.Add(1)
+		logrus.Warnf("Rejected connection for user '%s' from %s. The user has reached the limit of %d connections", c.User, c.RemoteAddr(), ct.maxUserConns)
+		return mysql.NewSQLError(mysql.ERTooManyUserConnections, tooManyUserConnsSQLState, "User %s already has more than 'max_user_connections' active connections", c.User)
+	}
+
+	tc.authed = true
+	tc.user = c.User
+	ct.userConns[c.User]++
+
+	if ct.idleTimeout > 0 {
+		tc.idleTimer = time.AfterFunc(ct.idleTimeout, func() {
+			ct.closeIfIdle(c)
+		})
+	}
+
+	return nil
+}
+
+func (ct *connectionTracker) closeIfIdle(c *mysql.Conn) {
+	ct.mu.Lock()
+	tc, ok := ct.conns[c.ConnectionID]
+
+	if !ok || tc.active {
+		ct.mu.Unlock()
+		return
+	}
+
+	ct.stats.IdleTimedOut++
+	connsIdleTimedOut.Add(1)
+	ct.mu.Unlock()
+
+	logrus.Infof("Closing connection %d for user '%s' from %s which was idle for more than %v", c.ConnectionID, c.User, c.RemoteAddr(), ct.idleTimeout)
+	c.Close()
+}
+
+// startCommand stops the idle timer of the connection while a command is executing. It returns an error if the server
+// is draining, in which case no new commands are allowed to start.
+func (ct *connectionTracker) startCommand(c *mysql.Conn) error {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	if ct.draining {
+		return errServerShutdown()
+	}
+
+	ct.activeCmds++
+
+	if tc, ok := ct.conns[c.ConnectionID]; ok {
+		tc.active = true
+
+		if tc.idleTimer != nil {
+			tc.idleTimer.Stop()
+		}
+	}
+
+	return nil
+}
+
+// endCommand restarts the idle timer of the connection once a command has completed.
+func (ct *connectionTracker) endCommand(c *mysql.Conn) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	ct.activeCmds--
+
+	if ct.draining && ct.activeCmds == 0 {
+		close(ct.cmdsDone)
+	}
+
+	if tc, ok := ct.conns[c.ConnectionID]; ok {
+		tc.active = false
+
+		if tc.idleTimer != nil && !ct.draining {
+			tc.idleTimer.Reset(ct.idleTimeout)
+		}
+	}
+}
+
+// drain stops new commands from starting on the open connections, and waits for up to timeout for the commands which
+// are running to complete. It returns the number of commands which were still running when the timeout elapsed.
+func (ct *connectionTracker) drain(timeout time.Duration) int {
+	ct.mu.Lock()
+	if ct.draining {
+		ct.mu.Unlock()
+		return 0
+	}
+
+	ct.draining = true
+
+	for _, tc := range ct.conns {
+		if tc.idleTimer != nil {
+			tc.idleTimer.Stop()
+		}
+	}
+
+	if ct.activeCmds == 0 {
+		close(ct.cmdsDone)
+	}
+	ct.mu.Unlock()
+
+	select {
+	case <-ct.cmdsDone:
+		return 0
+	case <-time.After(timeout):
+	}
+
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	return ct.activeCmds
+}
+
+// persistSessions persists the state of the sessions of the open connections. Sessions with a command which is still
+// running are skipped, as they can't be used safely. Must only be called after drain.
+func (ct *connectionTracker) persistSessions() error {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	var firstErr error
+	for id, tc := range ct.conns {
+		if tc.sess == nil || ct.persistSession == nil {
+			continue
+		}
+
+		if tc.active {
+			logrus.Warnf("Not persisting the session of connection %d for user '%s' as its query did not complete in time", id, tc.user)
+			continue
+		}
+
+		err := ct.persistSession(tc.sess)
+
+		if err != nil {
+			logrus.Errorf("Failed to persist the session of connection %d for user '%s'. %v", id, tc.user, err)
+
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// closeAll closes all of the open connections.
+func (ct *connectionTracker) closeAll() {
+	ct.mu.Lock()
+	conns := make([]*mysql.Conn, 0, len(ct.conns))
+	for _, tc := range ct.conns {
+		conns = append(conns, tc.conn)
+	}
+	ct.mu.Unlock()
+
+	for _, c := range conns {
+		c.Close()
+	}
+}
+
+func errServerShutdown() error {
+	return mysql.NewSQLError(mysql.ERServerShutdown, mysql.SSServerShutdown, "Server shutdown in progress")
+}
+
+func (ct *connectionTracker) setSession(c *mysql.Conn, sess sql.Session) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	ct.getOrCreateConn(c).sess = sess
+}
+
+// accepted records the socket of a connection accepted by the listener, so that it can be handed to the handler of the
+// connection once it's opened.
+func (ct *connectionTracker) accepted(netConn *net.TCPConn) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	ct.netConns[netConn.RemoteAddr().String()] = netConn
+}
+
+// opened is called when the listener opens a connection, with the handler which executes the connection's commands.
+// It returns the socket the connection was accepted on, or nil if it wasn't accepted as a TCP connection.
+func (ct *connectionTracker) opened(c *mysql.Conn, handler *connHandler) *net.TCPConn {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	ct.getOrCreateConn(c).handler = handler
+
+	addr := c.RemoteAddr().String()
+	netConn := ct.netConns[addr]
+	delete(ct.netConns, addr)
+
+	return netConn
+}
+
+// handler returns the handler of an open connection.
+func (ct *connectionTracker) handler(c *mysql.Conn) *connHandler {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	return ct.getOrCreateConn(c).handler
+}
+
+// conn returns the open connection with the ID given, or nil if there is none.
+func (ct *connectionTracker) conn(id uint32) *mysql.Conn {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	if tc, ok := ct.conns[id]; ok {
+		return tc.conn
+	}
+
+	return nil
+}
+
+// getOrCreateConn returns the state kept for the connection, creating it if this is the first time the connection has been
+// seen. ct.mu must be held by the caller.
+func (ct *connectionTracker) getOrCreateConn(c *mysql.Conn) *trackedConn {
+	tc, ok := ct.conns[c.ConnectionID]
+
+	if !ok {
+		tc = &trackedConn{conn: c}
+		ct.conns[c.ConnectionID] = tc
+	}
+
+	return tc
+}
+
+// closed releases everything held for the connection.
+func (ct *connectionTracker) closed(c *mysql.Conn) {
+	ct.mu.Lock()
+	tc, ok := ct.conns[c.ConnectionID]
+	delete(ct.conns, c.ConnectionID)
+
+	ct.stats.Current--
+	connsCurrent.Add(-1)
+
+	if ok && tc.authed {
+		ct.userConns[tc.user]--
+
+		if ct.userConns[tc.user] == 0 {
+			delete(ct.userConns, tc.user)
+		}
+	}
+	ct.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	if tc.idleTimer != nil {
+		tc.idleTimer.Stop()
+	}
+
+	if tc.sess != nil && ct.releaseSession != nil {
+		ct.releaseSession(tc.sess)
+	}
+}
+
+func (ct *connectionTracker) sessionBuilder(sb server.SessionBuilder) server.SessionBuilder {
+	return func(ctx context.Context, conn *mysql.Conn, addr string) (sql.Session, *sql.IndexRegistry, *sql.ViewRegistry, error) {
+		sess, ir, vr, err := sb(ctx, conn, addr)
+
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		ct.setSession(conn, sess)
+		return sess, ir, vr, nil
+	}
+}
+
+// limitingListener is a net.Listener which refuses connections once the server has reached its connection limit.
+type limitingListener struct {
+	net.Listener
+	ct *connectionTracker
+}
+
+// Accept waits for and returns the next connection which is allowed by the connection limit. Clients connecting while
+// the server is at its limit are sent a too many connections error and disconnected.
+func (l *limitingListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !l.ct.acquire() {
+			logrus.Warnf("Rejected connection from %s. The server has reached the limit of %d connections", conn.RemoteAddr(), l.ct.maxConns)
+			writeTooManyConnections(conn)
+			conn.Close()
+			continue
+		}
+
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			l.ct.accepted(tcpConn)
+			return shutdownOnCloseConn{tcpConn}, nil
+		}
+
+		return conn, nil
+	}
+}
+
+// shutdownOnCloseConn shuts down the socket when the connection is closed. The connection checker of the handler holds
+// a duplicate of the socket's file descriptor, so closing the connection alone would not disconnect the client when
+// the server closes the connection, such as when it has been idle for too long.
+type shutdownOnCloseConn struct {
+	*net.TCPConn
+}
+
+// Close shuts down both directions of the socket and closes it.
+func (c shutdownOnCloseConn) Close() error {
+	_ = c.CloseRead()
+	_ = c.CloseWrite()
+	return c.TCPConn.Close()
+}
+
+// writeTooManyConnections writes an ER_CON_COUNT_ERROR packet in place of the initial handshake packet.
+func writeTooManyConnections(conn net.Conn) {
+	const msg = "Too many connections"
+
+	payload := make([]byte, 3, 9+len(msg))
+	payload[0] = mysql.ErrPacket
+	binary.LittleEndian.PutUint16(payload[1:], mysql.ERConCount)
+	payload = append(payload, '#')
+	payload = append(payload, tooManyConnsSQLState...)
+	payload = append(payload, msg...)
+
+	header := make([]byte, 4)
+	binary.LittleEndian.PutUint32(header, uint32(len(payload)))
+	header[3] = 0
+
+	_ = conn.SetWriteDeadline(time.Now().Add(time.Second))
+	_, _ = conn.Write(append(header, payload...))
+}
+
+// trackingHandler is a mysql.Handler which reports the lifecycle of connections to a connectionTracker. It also
+// collects the chunk read statistics of every query, which are logged for slow queries and returned by EXPLAIN ANALYZE.
+//
+// The commands of each connection are executed by a server.Handler of its own. A server.Handler reads the connections
+// it knows about while it executes a query, without synchronizing with the other connections opening and closing, so
+// sharing one between the connections of a server races.
+type trackingHandler struct {
+	ct                 *connectionTracker
+	e                  *sqle.Engine
+	sm                 *server.SessionManager
+	readTimeout        time.Duration
+	slowQueryThreshold time.Duration
+}
+
+// connHandler is the handler which executes the commands of a single connection, along with the engine it executes
+// them with.
+type connHandler struct {
+	*server.Handler
+	e *sqle.Engine
+}
+
+// NewConnection reports that a new connection has been established.
+func (h *trackingHandler) NewConnection(c *mysql.Conn) {
+	e := queryEngine(h.e)
+	handler := &connHandler{server.NewHandler(e, h.sm, h.readTimeout), e}
+
+	// the handler's connection checker watches the socket of the connection, to stop its queries if the client
+	// disconnects
+	if tcpConn := h.ct.opened(c, handler); tcpConn != nil {
+		var netConn net.Conn = tcpConn
+		handler.AddNetConnection(&netConn)
+	}
+
+	handler.NewConnection(c)
+}
+
+// ConnectionClosed reports that a connection has been closed.
+func (h *trackingHandler) ConnectionClosed(c *mysql.Conn) {
+	h.ct.handler(c).ConnectionClosed(c)
+	h.ct.closed(c)
+}
+
+// ComInitDB is called once a connection has been authenticated, and whenever the client changes its database.
+func (h *trackingHandler) ComInitDB(c *mysql.Conn, schemaName string) error {
+	err := h.ct.authenticated(c)
+
+	if err != nil {
+		return err
+	}
+
+	err = h.ct.startCommand(c)
+
+	if err != nil {
+		return err
+	}
+
+	defer h.ct.endCommand(c)
+
+	return h.ct.handler(c).ComInitDB(c, schemaName)
+}
+
+// ComPrepare is called when a connection receives a prepared statement query.
+func (h *trackingHandler) ComPrepare(c *mysql.Conn, query string) ([]*querypb.Field, error) {
+	return h.ct.handler(c).ComPrepare(c, query)
+}
+
+// ComStmtExecute is called when a connection receives a statement execute query.
+func (h *trackingHandler) ComStmtExecute(c *mysql.Conn, prepare *mysql.PrepareData, callback func(*sqltypes.Result) error) error {
+	return h.ct.handler(c).ComStmtExecute(c, prepare, callback)
+}
+
+// WarningCount returns the number of warnings of the last query of a connection.
+func (h *trackingHandler) WarningCount(c *mysql.Conn) uint16 {
+	return h.ct.handler(c).WarningCount(c)
+}
+
+// ComResetConnection is called when a connection is reset.
+func (h *trackingHandler) ComResetConnection(c *mysql.Conn) {
+	h.ct.handler(c).ComResetConnection(c)
+}
+
+// ComQuery executes a SQL query.
+func (h *trackingHandler) ComQuery(c *mysql.Conn, query string, callback func(*sqltypes.Result) error) error {
+	err := h.ct.startCommand(c)
+
+	if err != nil {
+		return err
+	}
+
+	defer h.ct.endCommand(c)
+
+	ctx, err := h.sm.NewContextWithQuery(c, query)
+
+	if err != nil {
+		return err
+	}
+
+	handler := h.ct.handler(c)
+	sess, ok := ctx.Session.(*dsqle.DoltSession)
+
+	if !ok {
+		return handler.ComQuery(c, query, callback)
+	}
+
+	// when autocommit is on, each statement outside of a transaction reads the changes committed by other sessions
+	if isAutocommit(ctx) && !sess.InTransaction() {
+		err = sess.CommitWorkingSets(ctx)
+
+		if err != nil {
+			return transactionError(err)
+		}
+	}
+
+	sess.StartQueryStats(false)
+	start := time.Now()
+	e := handler.e
+
+	if explain, ok := parseExplainAnalyze(query); ok {
+		err = explainAnalyze(ctx, e, explain, callback)
+	} else if dsqle.IsDoltStatement(query) {
+		err = doltStatement(ctx, e, query, callback)
+	} else if id, ok := parseKillConnection(query); ok && id != c.ConnectionID {
+		err = h.killConnection(id, callback)
+	} else {
+		err = handler.ComQuery(c, query, callback)
+	}
+
+	elapsed := time.Since(start)
+	background.DefaultScheduler.ObserveLatency(elapsed)
+	logSlowQuery(query, elapsed, h.slowQueryThreshold, sess.QueryStats())
+	return transactionError(err)
+}
+
+// killConnection executes a KILL or KILL CONNECTION statement which kills the connection with the ID |id|, other than
+// the connection executing it. The handler of a connection only knows about its own connection, so these statements
+// are executed here. The killed connection's queries are canceled, and the connection is closed.
+func (h *trackingHandler) killConnection(id uint32, callback func(*sqltypes.Result) error) error {
+	h.e.Catalog.Kill(id)
+
+	c := h.ct.conn(id)
+
+	if c == nil {
+		return fmt.Errorf("connection not found: %d", id)
+	}
+
+	logrus.Infof("kill connection: id %d", id)
+	c.Close()
+
+	return callback(&sqltypes.Result{})
+}
@@ -0,0 +1,191 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+)
+
+func startTestServer(t *testing.T, serverConfig ServerConfig) *ServerController {
+	return startTestServerWithEnv(t, serverConfig, createEnvWithSeedData(t))
+}
+
+func startTestServerWithEnv(t *testing.T, serverConfig ServerConfig, dEnv *env.DoltEnv) *ServerController {
+	sc := CreateServerController()
+	go func() {
+		_, _ = Serve(context.Background(), "", serverConfig, sc, dEnv)
+	}()
+	err := sc.WaitForStart()
+	require.NoError(t, err)
+
+	return sc
+}
+
+// openConn opens a single connection to the server and verifies that it is usable.
+func openConn(ctx context.Context, db *sql.DB) (*sql.Conn, error) {
+	conn, err := db.Conn(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = conn.PingContext(ctx)
+
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func requireMySQLErr(t *testing.T, err error, expectedNum uint16) {
+	require.Error(t, err)
+	mysqlErr, ok := err.(*mysql.MySQLError)
+	require.True(t, ok, "unexpected error type %T: %v", err, err)
+	assert.Equal(t, expectedNum, mysqlErr.Number)
+}
+
+func TestServerMaxConnections(t *testing.T) {
+	ctx := context.Background()
+	serverConfig := DefaultServerConfig().withLogLevel(LogLevel_Fatal).withPort(15320).withMaxConnections(2)
+	sc := startTestServer(t, serverConfig)
+	defer sc.StopServer()
+
+	db, err := sql.Open("mysql", ConnectionString(serverConfig)+"dolt")
+	require.NoError(t, err)
+	defer db.Close()
+
+	conn1, err := openConn(ctx, db)
+	require.NoError(t, err)
+	conn2, err := openConn(ctx, db)
+	require.NoError(t, err)
+	defer conn2.Close()
+
+	rejectedBefore := connsRejected.Value()
+	_, err = openConn(ctx, db)
+	requireMySQLErr(t, err, 1040)
+	assert.Equal(t, rejectedBefore+1, connsRejected.Value())
+	assert.True(t, connsPeak.Value() >= 2)
+
+	// closing a connection makes room for another
+	require.NoError(t, conn1.Close())
+	db.SetMaxIdleConns(0)
+
+	var conn3 *sql.Conn
+	for i := 0; i < 50; i++ {
+		conn3, err = openConn(ctx, db)
+
+		if err == nil {
+			break
+		}
+
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	require.NoError(t, err)
+	conn3.Close()
+}
+
+func TestServerMaxUserConnections(t *testing.T) {
+	ctx := context.Background()
+	serverConfig := DefaultServerConfig().withLogLevel(LogLevel_Fatal).withPort(15321).withMaxUserConnections(1)
+	sc := startTestServer(t, serverConfig)
+	defer sc.StopServer()
+
+	db, err := sql.Open("mysql", ConnectionString(serverConfig)+"dolt")
+	require.NoError(t, err)
+	defer db.Close()
+
+	conn, err := openConn(ctx, db)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = openConn(ctx, db)
+	requireMySQLErr(t, err, 1203)
+}
+
+func TestServerIdleTimeout(t *testing.T) {
+	ctx := context.Background()
+	serverConfig := DefaultServerConfig().withLogLevel(LogLevel_Fatal).withPort(15322).withIdleTimeout(100)
+	sc := startTestServer(t, serverConfig)
+	defer sc.StopServer()
+
+	db, err := sql.Open("mysql", ConnectionString(serverConfig)+"dolt")
+	require.NoError(t, err)
+	defer db.Close()
+
+	conn, err := openConn(ctx, db)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// a connection which keeps issuing queries stays open
+	for i := 0; i < 5; i++ {
+		time.Sleep(50 * time.Millisecond)
+		_, err = conn.ExecContext(ctx, "select * from people")
+		require.NoError(t, err)
+	}
+
+	timedOutBefore := connsIdleTimedOut.Value()
+	time.Sleep(300 * time.Millisecond)
+	_, err = conn.ExecContext(ctx, "select * from people")
+	assert.Error(t, err)
+	assert.Equal(t, timedOutBefore+1, connsIdleTimedOut.Value())
+}
+
+func TestServerKillConnection(t *testing.T) {
+	ctx := context.Background()
+	serverConfig := DefaultServerConfig().withLogLevel(LogLevel_Fatal).withPort(15323)
+	sc := startTestServer(t, serverConfig)
+	defer sc.StopServer()
+
+	db, err := sql.Open("mysql", ConnectionString(serverConfig)+"dolt")
+	require.NoError(t, err)
+	defer db.Close()
+
+	killer, err := openConn(ctx, db)
+	require.NoError(t, err)
+	defer killer.Close()
+
+	killed, err := openConn(ctx, db)
+	require.NoError(t, err)
+	defer killed.Close()
+
+	var id uint32
+	require.NoError(t, killed.QueryRowContext(ctx, "select connection_id()").Scan(&id))
+
+	// each connection is executed by a handler of its own, so killing another connection is handled by the server
+	_, err = killer.ExecContext(ctx, fmt.Sprintf("kill %d", id))
+	require.NoError(t, err)
+
+	_, err = killed.ExecContext(ctx, "select * from people")
+	assert.Error(t, err)
+
+	_, err = killer.ExecContext(ctx, "select * from people")
+	assert.NoError(t, err)
+
+	_, err = killer.ExecContext(ctx, fmt.Sprintf("kill connection %d", id+1000))
+	assert.Error(t, err)
+}
@@ -0,0 +1,90 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"vitess.io/vitess/go/sqltypes"
+
+	dsqle "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
+)
+
+// doltStatement executes a statement identified by dsqle.IsDoltStatement, which the handler can't parse or ignores,
+// and sends its results to callback. The session's transaction is committed afterwards when autocommit is on, as the
+// handler would, if the statement wrote. Statements which write return an OK result, apart from ANALYZE TABLE, which
+// writes the statistics of tables to the dolt_statistics table, so it's also committed after statistics statements.
+func doltStatement(ctx *sql.Context, e *sqle.Engine, query string, callback func(*sqltypes.Result) error) error {
+	sch, iter, _, err := dsqle.ExecuteDoltStatement(ctx, e, query)
+
+	if err != nil {
+		return err
+	}
+
+	// transaction statements have no results
+	if iter == nil {
+		return callback(&sqltypes.Result{})
+	}
+
+	var result *sqltypes.Result
+	if sch.Equals(sql.OkResultSchema) {
+		rows, err := sql.RowIterToRows(iter)
+
+		if err != nil {
+			return err
+		}
+
+		result = &sqltypes.Result{}
+		for _, r := range rows {
+			if ok, isOk := r[0].(sql.OkResult); isOk {
+				result.RowsAffected += ok.RowsAffected
+			}
+		}
+	} else {
+		result, err = rowIterToResult(sch, iter)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if isAutocommit(ctx) && (sch.Equals(sql.OkResultSchema) || dsqle.IsStatisticsStatement(query)) {
+		err = ctx.Session.CommitTransaction(ctx)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return callback(result)
+}
+
+func isAutocommit(ctx *sql.Context) bool {
+	typ, val := ctx.Get(sql.AutoCommitSessionVar)
+
+	if val == nil {
+		return false
+	}
+
+	switch typ {
+	case sql.Int64:
+		return val.(int64) == 1
+	case sql.Boolean:
+		autocommit, _ := sql.ConvertToBool(val)
+		return autocommit
+	default:
+		return false
+	}
+}
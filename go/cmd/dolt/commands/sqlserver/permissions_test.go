@@ -0,0 +1,76 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestServerPermissionPolicies(t *testing.T) {
+	ctx := context.Background()
+	dEnv := createEnvWithSeedData(t)
+
+	defaultConfig := DefaultServerConfig().withLogLevel(LogLevel_Fatal).withPort(15470)
+	serverConfig := usersConfig{defaultConfig, []UserAccount{{Name: "amy", Roles: []string{"analyst"}}, {Name: "bob"}}}
+	sc := startTestServerWithEnv(t, serverConfig, dEnv)
+	defer sc.StopServer()
+
+	db, err := sql.Open("mysql", ConnectionString(serverConfig)+"dolt")
+	require.NoError(t, err)
+	defer db.Close()
+
+	for _, query := range []string{
+		"create table staging_people (id int primary key, name varchar(20))",
+		"insert into dolt_permissions values ('analysts_staging', 'analyst', 'master', 'staging_*', 'write'), " +
+			"('analysts_read_only', 'analyst', '*', '*', 'read')",
+	} {
+		_, err = db.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+
+	connect := func(user string) *sql.DB {
+		userDB, err := sql.Open("mysql", fmt.Sprintf("%s:@tcp(%v:%v)/dolt", user, serverConfig.Host(), serverConfig.Port()))
+		require.NoError(t, err)
+		return userDB
+	}
+
+	amyDB := connect("amy")
+	defer amyDB.Close()
+	bobDB := connect("bob")
+	defer bobDB.Close()
+
+	// the policies limit the users with the analyst role, and only the primary user, an admin, can change them
+	_, err = amyDB.ExecContext(ctx, "insert into staging_people values (1, 'amy')")
+	assert.NoError(t, err)
+	_, err = amyDB.ExecContext(ctx, "delete from people")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "analysts_read_only")
+
+	_, err = bobDB.ExecContext(ctx, "insert into staging_people values (2, 'bob')")
+	assert.NoError(t, err)
+	_, err = bobDB.ExecContext(ctx, "delete from dolt_permissions")
+	assert.Error(t, err)
+
+	var count int
+	err = db.QueryRowContext(ctx, "select count(*) from dolt_permissions").Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
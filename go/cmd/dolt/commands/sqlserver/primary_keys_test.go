@@ -0,0 +1,59 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestServerPrimaryKeyChange(t *testing.T) {
+	ctx := context.Background()
+	dEnv := createEnvWithSeedData(t)
+
+	serverConfig := DefaultServerConfig().withLogLevel(LogLevel_Fatal).withPort(15460)
+	sc := startTestServerWithEnv(t, serverConfig, dEnv)
+	defer sc.StopServer()
+
+	db, err := sql.Open("mysql", ConnectionString(serverConfig)+"dolt")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.ExecContext(ctx, "create table rekeyed (id int primary key, code varchar(10))")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "insert into rekeyed values (1, 'b'), (2, 'a')")
+	require.NoError(t, err)
+
+	_, err = db.ExecContext(ctx, "alter table rekeyed drop primary key")
+	assert.Error(t, err)
+
+	res, err := db.ExecContext(ctx, "alter table rekeyed drop primary key, add primary key (code)")
+	require.NoError(t, err)
+	affected, err := res.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), affected)
+
+	var id int
+	err = db.QueryRowContext(ctx, "select id from rekeyed limit 1").Scan(&id)
+	require.NoError(t, err)
+	assert.Equal(t, 2, id)
+
+	_, err = db.ExecContext(ctx, "insert into rekeyed values (3, 'a')")
+	assert.Error(t, err)
+}
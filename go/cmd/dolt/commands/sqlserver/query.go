@@ -0,0 +1,130 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/analyzer"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+// killConnectionRegex matches the KILL and KILL CONNECTION statements the handler executes, capturing the ID of the
+// connection they kill.
+var killConnectionRegex = regexp.MustCompile(`^kill (?:connection )?(\d+)$`)
+
+// endRowsAfterOkResult is the analyzer rule which ends the rows of a query after its OK result.
+var endRowsAfterOkResult = analyzer.Rule{
+	Name: "end_rows_after_ok_result",
+	Apply: func(ctx *sql.Context, a *analyzer.Analyzer, n sql.Node) (sql.Node, error) {
+		return &okResultNode{plan.UnaryNode{Child: n}}, nil
+	},
+}
+
+// queryEngine returns an engine with the catalog and analyzer rules of e, to run the queries of a single connection
+// with. The analyzer of an engine keeps a stack of debug contexts while it analyzes a query, so an engine can't be
+// shared by concurrent queries.
+func queryEngine(e *sqle.Engine) *sqle.Engine {
+	batches := append([]*analyzer.Batch{}, e.Analyzer.Batches...)
+	batches = append(batches, &analyzer.Batch{
+		Desc:       "end_rows_after_ok_result",
+		Iterations: 1,
+		Rules:      []analyzer.Rule{endRowsAfterOkResult},
+	})
+
+	a := &analyzer.Analyzer{
+		Debug:       e.Analyzer.Debug,
+		Verbose:     e.Analyzer.Verbose,
+		Parallelism: e.Analyzer.Parallelism,
+		Batches:     batches,
+		Catalog:     e.Analyzer.Catalog,
+	}
+
+	return &sqle.Engine{Catalog: e.Catalog, Analyzer: a, Auth: e.Auth}
+}
+
+// parseKillConnection returns the ID of the connection killed by query if it's a KILL or KILL CONNECTION statement.
+func parseKillConnection(query string) (uint32, bool) {
+	m := killConnectionRegex.FindStringSubmatch(strings.ToLower(query))
+
+	if m == nil {
+		return 0, false
+	}
+
+	id, err := strconv.ParseUint(m[1], 10, 32)
+
+	if err != nil {
+		return 0, false
+	}
+
+	return uint32(id), true
+}
+
+// okResultNode is a node whose rows end after the OK result of its child. The handler reads the rows of a query on a
+// goroutine of its own, which keeps reading after the OK result of a statement while the handler closes the rows, so
+// the rows of its child mustn't be read after an OK result.
+type okResultNode struct {
+	plan.UnaryNode
+}
+
+// RowIter implements sql.Node.
+func (n *okResultNode) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	iter, err := n.Child.RowIter(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &okResultIter{RowIter: iter}, nil
+}
+
+// WithChildren implements sql.Node.
+func (n *okResultNode) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(n, len(children), 1)
+	}
+
+	return &okResultNode{plan.UnaryNode{Child: children[0]}}, nil
+}
+
+func (n *okResultNode) String() string {
+	return n.Child.String()
+}
+
+// okResultIter is the sql.RowIter of an okResultNode.
+type okResultIter struct {
+	sql.RowIter
+	sentOk bool
+}
+
+// Next implements sql.RowIter.
+func (it *okResultIter) Next() (sql.Row, error) {
+	if it.sentOk {
+		return nil, io.EOF
+	}
+
+	row, err := it.RowIter.Next()
+
+	if err == nil && len(row) == 1 {
+		_, it.sentOk = row[0].(sql.OkResult)
+	}
+
+	return row, err
+}
@@ -0,0 +1,124 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	dsqle "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+)
+
+// parseExplainAnalyze returns the parsed statement if query is an EXPLAIN ANALYZE query. Only queries starting with
+// "explain" are parsed, so that other queries aren't parsed a second time.
+func parseExplainAnalyze(query string) (*sqlparser.Explain, bool) {
+	trimmed := strings.TrimSpace(query)
+
+	if len(trimmed) < len("explain") || !strings.EqualFold(trimmed[:len("explain")], "explain") {
+		return nil, false
+	}
+
+	stmt, err := sqlparser.Parse(query)
+
+	if err != nil {
+		return nil, false
+	}
+
+	explain, ok := stmt.(*sqlparser.Explain)
+	return explain, ok && explain.Analyze
+}
+
+// explainAnalyze runs an EXPLAIN ANALYZE query and sends its results to callback.
+func explainAnalyze(ctx *sql.Context, e *sqle.Engine, explain *sqlparser.Explain, callback func(*sqltypes.Result) error) error {
+	sch, iter, err := dsqle.ExplainAnalyze(ctx, e, explain)
+
+	if err != nil {
+		return err
+	}
+
+	result, err := rowIterToResult(sch, iter)
+
+	if err != nil {
+		return err
+	}
+
+	return callback(result)
+}
+
+// rowIterToResult converts the rows of a query which was executed outside of the handler into a result to send to the
+// client.
+func rowIterToResult(sch sql.Schema, iter sql.RowIter) (*sqltypes.Result, error) {
+	rows, err := sql.RowIterToRows(iter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &sqltypes.Result{Fields: schemaToFields(sch)}
+	for _, row := range rows {
+		vals, err := rowToSQL(sch, row)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result.Rows = append(result.Rows, vals)
+	}
+
+	result.RowsAffected = uint64(len(result.Rows))
+	return result, nil
+}
+
+func schemaToFields(sch sql.Schema) []*query.Field {
+	fields := make([]*query.Field, len(sch))
+	for i, col := range sch {
+		fields[i] = &query.Field{Name: col.Name, Type: col.Type.Type(), Charset: mysql.CharacterSetUtf8}
+	}
+
+	return fields
+}
+
+func rowToSQL(sch sql.Schema, row sql.Row) ([]sqltypes.Value, error) {
+	vals := make([]sqltypes.Value, len(row))
+	for i, v := range row {
+		var err error
+		vals[i], err = sch[i].Type.SQL(v)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return vals, nil
+}
+
+// logSlowQuery logs query along with the chunk reads it made if it took longer than threshold. A threshold of 0
+// disables logging.
+func logSlowQuery(query string, elapsed, threshold time.Duration, stats *chunks.ReadStats) {
+	if threshold == 0 || elapsed < threshold {
+		return
+	}
+
+	logrus.Warnf("Slow query took %v, %v: %s", elapsed, stats.Snapshot(), query)
+}
@@ -0,0 +1,142 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+)
+
+// syncBuffer is a bytes.Buffer which is safe to write to from the server while being read by a test.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestParseExplainAnalyze(t *testing.T) {
+	tests := []struct {
+		query     string
+		isAnalyze bool
+	}{
+		{"explain analyze select * from people", true},
+		{"  EXPLAIN ANALYZE select * from people", true},
+		{"explain select * from people", false},
+		{"select * from people", false},
+		{"explain", false},
+		{"explain analyze", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			explain, ok := parseExplainAnalyze(test.query)
+			assert.Equal(t, test.isAnalyze, ok)
+
+			if ok {
+				assert.True(t, explain.Analyze)
+			}
+		})
+	}
+}
+
+func TestLogSlowQuery(t *testing.T) {
+	var buf syncBuffer
+	prevOut, prevLevel := logrus.StandardLogger().Out, logrus.GetLevel()
+	logrus.SetOutput(&buf)
+	logrus.SetLevel(logrus.WarnLevel)
+	defer func() {
+		logrus.SetOutput(prevOut)
+		logrus.SetLevel(prevLevel)
+	}()
+
+	stats := chunks.NewReadStats(false)
+	stats.ChunkRead(10)
+	stats.CacheHit()
+
+	logSlowQuery("select 1", time.Millisecond, 0, stats)
+	logSlowQuery("select 2", time.Millisecond, time.Second, stats)
+	assert.Equal(t, "", buf.String())
+
+	logSlowQuery("select 3", time.Second, time.Millisecond, stats)
+	logged := buf.String()
+	assert.Contains(t, logged, "select 3")
+	assert.Contains(t, logged, "chunks read: 1 (10 B), cache hits: 1")
+}
+
+func TestServerExplainAnalyze(t *testing.T) {
+	var buf syncBuffer
+	prevOut := logrus.StandardLogger().Out
+	logrus.SetOutput(&buf)
+	defer logrus.SetOutput(prevOut)
+
+	ctx := context.Background()
+	serverConfig := DefaultServerConfig().withLogLevel(LogLevel_Warning).withPort(15334).withSlowQueryThreshold(1)
+	sc := startTestServer(t, serverConfig)
+	defer sc.StopServer()
+
+	db, err := sql.Open("mysql", ConnectionString(serverConfig)+"dolt")
+	require.NoError(t, err)
+	defer db.Close()
+
+	conn, err := openConn(ctx, db)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(ctx, "explain analyze select * from people where age > 30")
+	require.NoError(t, err)
+
+	var lines []string
+	for rows.Next() {
+		var line string
+		require.NoError(t, rows.Scan(&line))
+		lines = append(lines, line)
+	}
+	require.NoError(t, rows.Err())
+	require.NoError(t, rows.Close())
+
+	require.True(t, len(lines) > 3)
+	assert.True(t, strings.HasPrefix(lines[len(lines)-2], "rows: "), lines[len(lines)-2])
+	assert.Contains(t, lines[len(lines)-1], "storage time: ")
+
+	// queries which exceed the threshold are logged with their chunk read statistics
+	_, err = conn.ExecContext(ctx, "select sleep(0.01) from people")
+	require.NoError(t, err)
+	logged := buf.String()
+	assert.Contains(t, logged, "Slow query")
+	assert.Contains(t, logged, "select sleep(0.01) from people")
+	assert.Contains(t, logged, "chunks read: ")
+}
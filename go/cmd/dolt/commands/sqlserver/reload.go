@@ -0,0 +1,258 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"crypto/subtle"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/src-d/go-mysql-server/sql"
+	"vitess.io/vitess/go/mysql"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+)
+
+// onSignal calls handler whenever one of the given signals is received. The returned function stops listening for the
+// signals.
+func onSignal(handler func(os.Signal), sigs ...os.Signal) (stop func()) {
+	sigCh := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sigCh, sigs...)
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case sig := <-sigCh:
+				handler(sig)
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigCh)
+		close(done)
+	}
+}
+
+// newUserAuth returns the auth.Auth for the user accounts and permissions of the given config, and the nativeUsers it
+// audits.
+func newUserAuth(serverConfig ServerConfig) (auth.Auth, *nativeUsers) {
+	permissions := auth.AllPermissions
+	if serverConfig.ReadOnly() {
+		permissions = auth.ReadPerm
+	}
+
+	accounts := append([]UserAccount{{Name: serverConfig.User(), Password: serverConfig.Password()}}, serverConfig.Users()...)
+	users := newNativeUsers(accounts, permissions)
+	return auth.NewAudit(users, auth.NewAuditLog(logrus.StandardLogger())), users
+}
+
+// nativeUsers is an auth.Auth for a fixed set of user accounts which authenticate with mysql_native_password and all
+// have the same permissions.
+type nativeUsers struct {
+	// the native password hash of each user
+	hashes      map[string]string
+	permissions auth.Permission
+}
+
+func newNativeUsers(accounts []UserAccount, permissions auth.Permission) *nativeUsers {
+	hashes := make(map[string]string, len(accounts))
+	for _, acc := range accounts {
+		hashes[acc.Name] = auth.NativePassword(acc.Password)
+	}
+
+	return &nativeUsers{hashes, permissions}
+}
+
+// Mysql implements the auth.Auth interface.
+func (nu *nativeUsers) Mysql() mysql.AuthServer {
+	as := mysql.NewAuthServerStatic()
+	for name, hash := range nu.hashes {
+		as.Entries[name] = []*mysql.AuthServerStaticEntry{{MysqlNativePassword: hash, Password: hash}}
+	}
+
+	return as
+}
+
+// checkPassword returns whether |password| is the password of |user|.
+func (nu *nativeUsers) checkPassword(user, password string) bool {
+	hash, ok := nu.hashes[user]
+	return ok && subtle.ConstantTimeCompare([]byte(hash), []byte(auth.NativePassword(password))) == 1
+}
+
+// Allowed implements the auth.Auth interface.
+func (nu *nativeUsers) Allowed(ctx *sql.Context, permission auth.Permission) error {
+	if _, ok := nu.hashes[ctx.Client().User]; !ok {
+		return auth.ErrNotAuthorized.Wrap(auth.ErrNoPermission.New(permission))
+	}
+
+	if nu.permissions&permission != permission {
+		return auth.ErrNotAuthorized.Wrap(auth.ErrNoPermission.New(^nu.permissions & permission))
+	}
+
+	return nil
+}
+
+// reloadableAuth is an auth.Auth whose user accounts and permissions can be replaced while the server is running.
+// Connections authenticate against, and queries are checked against, whichever auth.Auth is current at the time.
+type reloadableAuth struct {
+	mu      *sync.RWMutex
+	current auth.Auth
+	// the users of current, which clients of the Arrow endpoint authenticate as
+	users *nativeUsers
+	// the user of the config, whose sessions aren't limited by row policies
+	primaryUser string
+	// the roles of the additional users
+	roles map[string][]string
+}
+
+func newReloadableAuth(serverConfig ServerConfig) *reloadableAuth {
+	current, users := newUserAuth(serverConfig)
+	return &reloadableAuth{mu: &sync.RWMutex{}, current: current, users: users, primaryUser: serverConfig.User(), roles: userRoles(serverConfig)}
+}
+
+// userRoles returns the roles of each of the additional users of the config.
+func userRoles(serverConfig ServerConfig) map[string][]string {
+	roles := make(map[string][]string)
+	for _, acc := range serverConfig.Users() {
+		roles[acc.Name] = acc.Roles
+	}
+
+	return roles
+}
+
+func (ra *reloadableAuth) get() auth.Auth {
+	ra.mu.RLock()
+	defer ra.mu.RUnlock()
+
+	return ra.current
+}
+
+func (ra *reloadableAuth) set(serverConfig ServerConfig) {
+	a, users := newUserAuth(serverConfig)
+
+	ra.mu.Lock()
+	defer ra.mu.Unlock()
+
+	ra.current = a
+	ra.users = users
+	ra.primaryUser = serverConfig.User()
+	ra.roles = userRoles(serverConfig)
+}
+
+// isPrimaryUser returns whether the user given is the current user of the config, rather than one of its additional
+// users.
+func (ra *reloadableAuth) isPrimaryUser(user string) bool {
+	ra.mu.RLock()
+	defer ra.mu.RUnlock()
+
+	return user == ra.primaryUser
+}
+
+// principal returns the user given along with their roles in the current user accounts, which the permission policies
+// of the server's databases apply to. The primary user has the admin role, so isn't limited by them.
+func (ra *reloadableAuth) principal(user string) doltdb.Principal {
+	ra.mu.RLock()
+	defer ra.mu.RUnlock()
+
+	if user == ra.primaryUser {
+		return doltdb.Principal{User: user, Roles: []string{doltdb.AdminRole}}
+	}
+
+	return doltdb.Principal{User: user, Roles: ra.roles[user]}
+}
+
+// checkPassword returns whether |password| is the password of |user| in the current user accounts.
+func (ra *reloadableAuth) checkPassword(user, password string) bool {
+	ra.mu.RLock()
+	defer ra.mu.RUnlock()
+
+	return ra.users.checkPassword(user, password)
+}
+
+// Mysql returns a mysql.AuthServer which defers to the current auth.Auth.
+func (ra *reloadableAuth) Mysql() mysql.AuthServer {
+	return reloadableAuthServer{ra}
+}
+
+// Allowed checks the permissions of the user with the current auth.Auth.
+func (ra *reloadableAuth) Allowed(ctx *sql.Context, permission auth.Permission) error {
+	return ra.get().Allowed(ctx, permission)
+}
+
+type reloadableAuthServer struct {
+	ra *reloadableAuth
+}
+
+// AuthMethod implements the mysql.AuthServer interface.
+func (as reloadableAuthServer) AuthMethod(user string) (string, error) {
+	return as.ra.get().Mysql().AuthMethod(user)
+}
+
+// Salt implements the mysql.AuthServer interface.
+func (as reloadableAuthServer) Salt() ([]byte, error) {
+	return as.ra.get().Mysql().Salt()
+}
+
+// ValidateHash implements the mysql.AuthServer interface.
+func (as reloadableAuthServer) ValidateHash(salt []byte, user string, authResponse []byte, remoteAddr net.Addr) (mysql.Getter, error) {
+	return as.ra.get().Mysql().ValidateHash(salt, user, authResponse, remoteAddr)
+}
+
+// Negotiate implements the mysql.AuthServer interface.
+func (as reloadableAuthServer) Negotiate(c *mysql.Conn, user string, remoteAddr net.Addr) (mysql.Getter, error) {
+	return as.ra.get().Mysql().Negotiate(c, user, remoteAddr)
+}
+
+// reloadServer reloads the TLS certificates, and the user account and permissions if the server was configured with
+// a config file which can be read again. Failures are logged, and the previous settings remain in use.
+func reloadServer(sig os.Signal, serverConfig ServerConfig, userAuth *reloadableAuth, tlsLoader *tlsConfigLoader) {
+	if tlsLoader != nil {
+		err := tlsLoader.Reload()
+
+		if err != nil {
+			logrus.Errorf("Received %v but failed to reload TLS certificates. Continuing to use the previous certificates. %v", sig, err)
+		} else {
+			logrus.Infof("Received %v. Reloaded TLS certificates", sig)
+		}
+	}
+
+	reloadable, ok := serverConfig.(ReloadableServerConfig)
+
+	if !ok {
+		return
+	}
+
+	newConfig, err := reloadable.Reload()
+
+	if err == nil {
+		err = ValidateConfig(newConfig)
+	}
+
+	if err != nil {
+		logrus.Errorf("Received %v but failed to reload the config. Continuing to use the previous config. %v", sig, err)
+		return
+	}
+
+	userAuth.set(newConfig)
+	logrus.Infof("Received %v. Reloaded the users and permissions from the config. Changes to other settings take effect when the server is restarted", sig)
+}
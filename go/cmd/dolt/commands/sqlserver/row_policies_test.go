@@ -0,0 +1,91 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// usersConfig is a ServerConfig with additional users.
+type usersConfig struct {
+	ServerConfig
+	users []UserAccount
+}
+
+func (cfg usersConfig) Users() []UserAccount {
+	return cfg.users
+}
+
+func TestServerRowPolicies(t *testing.T) {
+	ctx := context.Background()
+	dEnv := createEnvWithSeedData(t)
+
+	defaultConfig := DefaultServerConfig().withLogLevel(LogLevel_Fatal).withPort(15339)
+	serverConfig := usersConfig{defaultConfig, []UserAccount{{Name: "alice", Password: "secret"}, {Name: "bob"}}}
+	sc := startTestServerWithEnv(t, serverConfig, dEnv)
+	defer sc.StopServer()
+
+	db, err := sql.Open("mysql", ConnectionString(serverConfig)+"dolt")
+	require.NoError(t, err)
+	defer db.Close()
+
+	for _, query := range []string{
+		"create table docs (pk int primary key, owner varchar(20))",
+		"insert into docs values (1, 'alice'), (2, 'bob'), (3, 'alice')",
+		"create policy own on docs using (owner = user())",
+	} {
+		_, err = db.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+
+	aliceDB, err := sql.Open("mysql", fmt.Sprintf("alice:secret@tcp(%v:%v)/dolt", serverConfig.Host(), serverConfig.Port()))
+	require.NoError(t, err)
+	defer aliceDB.Close()
+
+	// the primary user reads every row, and the additional users only the rows the policies permit them
+	var count int
+	err = db.QueryRowContext(ctx, "select count(*) from docs").Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	err = aliceDB.QueryRowContext(ctx, "select count(*) from docs").Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	err = aliceDB.QueryRowContext(ctx, "select count(*) from dolt_diff_docs").Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	_, err = aliceDB.ExecContext(ctx, "insert into docs values (4, 'bob')")
+	assert.Error(t, err)
+	_, err = aliceDB.ExecContext(ctx, "drop policy own on docs")
+	assert.Error(t, err)
+
+	var table, policy, user, predicate string
+	err = aliceDB.QueryRowContext(ctx, "show policies").Scan(&table, &policy, &user, &predicate)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs", "own", "PUBLIC", "owner = user()"}, []string{table, policy, user, predicate})
+
+	// users must authenticate with their own passwords
+	wrongDB, err := sql.Open("mysql", fmt.Sprintf("alice:wrong@tcp(%v:%v)/dolt", serverConfig.Host(), serverConfig.Port()))
+	require.NoError(t, err)
+	defer wrongDB.Close()
+	assert.Error(t, wrongDB.PingContext(ctx))
+}
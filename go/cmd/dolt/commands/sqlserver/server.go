@@ -16,8 +16,14 @@ package sqlserver
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
 	"net"
+	"os"
 	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -26,14 +32,61 @@ import (
 	"github.com/src-d/go-mysql-server/server"
 	"github.com/src-d/go-mysql-server/sql"
 	"vitess.io/vitess/go/mysql"
+	vtlog "vitess.io/vitess/go/vt/log"
 
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
 	dsqle "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
 	_ "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle/dfunctions"
+	"github.com/liquidata-inc/dolt/go/store/background"
 )
 
+// sqlServerLockCommand is the command recorded in the lock of the databases of a server.
+const sqlServerLockCommand = "dolt sql-server"
+
+// backgroundPauseCooldown is how long the IO of background work is paused for by a query slower than the background
+// pause latency of the server.
+const backgroundPauseCooldown = time.Second
+
+// listenerLogging is the state of the routing of the logging of the mysql protocol listener through the server's
+// logger. The listener's log funcs are replaced once, by the first server, with funcs which route through the server's
+// logger while |servers| is greater than zero. The listener's connections outlive the Serve that accepted them, so the
+// log funcs can't be swapped back without racing with them.
+var listenerLogging struct {
+	once    sync.Once
+	servers int32
+}
+
+// routeListenerLogging routes the logging of the mysql protocol listener, which includes failed TLS handshakes along
+// with the address of the client, through the server's logger. The returned func restores the listener's logging once
+// no other server is routing it.
+func routeListenerLogging() (restore func()) {
+	listenerLogging.once.Do(func() {
+		vtlog.Infof = routedLogf(logrus.Infof, vtlog.Infof)
+		vtlog.Warningf = routedLogf(logrus.Warnf, vtlog.Warningf)
+		vtlog.Errorf = routedLogf(logrus.Errorf, vtlog.Errorf)
+	})
+
+	atomic.AddInt32(&listenerLogging.servers, 1)
+	return func() {
+		atomic.AddInt32(&listenerLogging.servers, -1)
+	}
+}
+
+// routedLogf returns a log func which logs with |serverLogf| while any server is routing the listener's logging, and
+// with |listenerLogf| otherwise.
+func routedLogf(serverLogf, listenerLogf func(format string, args ...interface{})) func(format string, args ...interface{}) {
+	return func(format string, args ...interface{}) {
+		if atomic.LoadInt32(&listenerLogging.servers) > 0 {
+			serverLogf(format, args...)
+		} else {
+			listenerLogf(format, args...)
+		}
+	}
+}
+
 // Serve starts a MySQL-compatible server. Returns any errors that were encountered.
 func Serve(ctx context.Context, version string, serverConfig ServerConfig, serverController *ServerController, dEnv *env.DoltEnv) (startError error, closeError error) {
 	if serverConfig == nil {
@@ -46,6 +99,8 @@ func Serve(ctx context.Context, version string, serverConfig ServerConfig, serve
 		serverController = CreateServerController()
 	}
 
+	restoreListenerLogging := routeListenerLogging()
+
 	var mySQLServer *server.Server
 	// This guarantees unblocking on any routines with a waiting `ServerController`
 	defer func() {
@@ -55,6 +110,7 @@ func Serve(ctx context.Context, version string, serverConfig ServerConfig, serve
 			serverController.registerCloseFunction(startError, func() error { return nil })
 		}
 		serverController.StopServer()
+		restoreListenerLogging()
 		serverController.serverStopped(closeError)
 	}()
 
@@ -72,13 +128,29 @@ func Serve(ctx context.Context, version string, serverConfig ServerConfig, serve
 		logrus.SetLevel(level)
 	}
 
-	permissions := auth.AllPermissions
-	if serverConfig.ReadOnly() {
-		permissions = auth.ReadPerm
+	var tlsLoader *tlsConfigLoader
+	if serverConfig.TLSCert() != "" {
+		tlsLoader, startError = newTLSConfigLoader(serverConfig.TLSKey(), serverConfig.TLSCert(), serverConfig.TLSCA(), serverConfig.RequireSecureTransport())
+
+		if startError != nil {
+			return startError, nil
+		}
+	}
+
+	reloadableUserAuth := newReloadableAuth(serverConfig)
+	var userAuth auth.Auth = reloadableUserAuth
+	if serverConfig.RequireSecureTransport() {
+		userAuth = secureTransportAuth{userAuth, tlsLoader.secureConns}
 	}
 
-	userAuth := auth.NewAudit(auth.NewNativeSingle(serverConfig.User(), serverConfig.Password(), permissions), auth.NewAuditLog(logrus.StandardLogger()))
-	sqlEngine := sqle.NewDefault()
+	// the databases cloned from remotes are cloned before the server listens, so that it never serves one missing them
+	for _, db := range serverConfig.ClonedDatabases() {
+		if startError = cloneDatabase(ctx, dEnv, db); startError != nil {
+			return startError, nil
+		}
+	}
+
+	sqlEngine := dsqle.NewEngine()
 
 	var username string
 	var email string
@@ -103,30 +175,78 @@ func Serve(ctx context.Context, version string, serverConfig ServerConfig, serve
 		}
 	}
 
+	// the repositories are locked while the server runs, so that CLI commands don't write to them at the same time
+	var locks []*env.RepoLock
+	defer func() {
+		for _, lock := range locks {
+			_ = lock.Unlock()
+		}
+	}()
+
+	startError = mrEnv.Iter(func(name string, dEnv *env.DoltEnv) (stop bool, err error) {
+		lock, err := dEnv.Lock(env.NewLockInfo(sqlServerLockCommand, serverConfig.Port()))
+
+		if err != nil {
+			return true, fmt.Errorf("failed to lock database '%s': %w", name, err)
+		}
+
+		locks = append(locks, lock)
+		return false, nil
+	})
+
+	if startError != nil {
+		return
+	}
+
 	dbs := commands.CollectDBs(mrEnv, newDatabase)
 
 	for _, db := range dbs {
 		sqlEngine.AddDatabase(db)
+
+		// the caches of the databases can be flushed with dolt_flush_cache() while the server runs
+		defer db.RegisterCaches()()
 	}
 
-	sqlEngine.AddDatabase(sql.NewInformationSchemaDatabase(sqlEngine.Catalog))
+	sqlEngine.AddDatabase(dsqle.NewInformationSchemaDatabase(sqlEngine.Catalog))
 
 	hostPort := net.JoinHostPort(serverConfig.Host(), strconv.Itoa(serverConfig.Port()))
 	readTimeout := time.Duration(serverConfig.ReadTimeout()) * time.Millisecond
 	writeTimeout := time.Duration(serverConfig.WriteTimeout()) * time.Millisecond
-	mySQLServer, startError = server.NewServer(
+	idleTimeout := time.Duration(serverConfig.IdleTimeout()) * time.Millisecond
+	drainTimeout := time.Duration(serverConfig.DrainTimeout()) * time.Millisecond
+	connTracker := newConnectionTracker(serverConfig.MaxConnections(), serverConfig.MaxUserConnections(), idleTimeout,
+		func(sess sql.Session) {
+			if doltSess, ok := sess.(*dsqle.DoltSession); ok {
+				doltSess.Release(dbsAsDSQLDBs(sqlEngine.Catalog.AllDatabases())...)
+			}
+		},
+		func(sess sql.Session) error {
+			if doltSess, ok := sess.(*dsqle.DoltSession); ok {
+				return doltSess.PersistWorkingSets(ctx)
+			}
+
+			return nil
+		},
+	)
+	background.DefaultScheduler.SetBytesPerSecond(int64(serverConfig.BackgroundIOLimit()))
+	background.DefaultScheduler.SetPauseLatency(time.Duration(serverConfig.BackgroundPauseLatency())*time.Millisecond, backgroundPauseCooldown)
+
+	newSession := newSessionFactory(sqlEngine, username, email, serverConfig.AutoCommit(), reloadableUserAuth.isPrimaryUser, reloadableUserAuth.principal, dsqle.NewWorkspaces(serverConfig.Workspaces()))
+
+	mySQLServer, startError = newServer(
 		server.Config{
 			Protocol:         "tcp",
 			Address:          hostPort,
 			Auth:             userAuth,
 			ConnReadTimeout:  readTimeout,
 			ConnWriteTimeout: writeTimeout,
-			MaxConnections:   serverConfig.MaxConnections(),
 			// Do not set the value of Version.  Let it default to what go-mysql-server uses.  This should be equivalent
 			// to the value of mysql that we support.
 		},
 		sqlEngine,
-		newSessionBuilder(sqlEngine, username, email, serverConfig.AutoCommit()),
+		newSessionBuilder(newSession),
+		connTracker,
+		time.Duration(serverConfig.SlowQueryThreshold())*time.Millisecond,
 	)
 
 	if startError != nil {
@@ -134,7 +254,49 @@ func Serve(ctx context.Context, version string, serverConfig ServerConfig, serve
 		return
 	}
 
-	serverController.registerCloseFunction(startError, mySQLServer.Close)
+	if tlsLoader != nil {
+		mySQLServer.Listener.TLSConfig = tlsLoader.TLSConfig()
+		mySQLServer.Listener.RequireSecureTransport = serverConfig.RequireSecureTransport()
+	}
+
+	if serverConfig.ArrowPort() != 0 {
+		var tlsConfig *tls.Config
+		if tlsLoader != nil {
+			tlsConfig = tlsLoader.TLSConfig()
+		}
+
+		arrowAddr := net.JoinHostPort(serverConfig.Host(), strconv.Itoa(serverConfig.ArrowPort()))
+		handler := newArrowHandler(sqlEngine, reloadableUserAuth, newSession, serverConfig.Host())
+
+		var arrowSrv *arrowServer
+		arrowSrv, startError = newArrowServer(arrowAddr, handler, tlsConfig)
+
+		if startError != nil {
+			cli.PrintErr(startError)
+			return
+		}
+
+		defer arrowSrv.Close()
+	}
+
+	sqlEngine.Catalog.MustRegister(sql.Function0{Name: DrainFuncName, Fn: NewDrainFunc(serverController)})
+
+	// SIGTERM drains the server, after which the default handling of the signal is restored so that a second SIGTERM
+	// stops the server immediately. SIGHUP reloads the TLS certificates and the user and permissions of the config file.
+	stopSignals := onSignal(func(sig os.Signal) {
+		switch sig {
+		case syscall.SIGTERM:
+			logrus.Infof("Received %v", sig)
+			go serverController.StopServer()
+		case syscall.SIGHUP:
+			reloadServer(sig, serverConfig, reloadableUserAuth, tlsLoader)
+		}
+	}, syscall.SIGTERM, syscall.SIGHUP)
+	defer stopSignals()
+
+	serverController.registerCloseFunction(startError, func() error {
+		return gracefulShutdown(mySQLServer.Listener, connTracker, drainTimeout)
+	})
 	closeError = mySQLServer.Start()
 	if closeError != nil {
 		cli.PrintErr(closeError)
@@ -143,15 +305,39 @@ func Serve(ctx context.Context, version string, serverConfig ServerConfig, serve
 	return
 }
 
-func newSessionBuilder(sqlEngine *sqle.Engine, username, email string, autocommit bool) server.SessionBuilder {
-	return func(ctx context.Context, conn *mysql.Conn, host string) (sql.Session, *sql.IndexRegistry, *sql.ViewRegistry, error) {
-		mysqlSess := sql.NewSession(host, conn.RemoteAddr().String(), conn.User, conn.ConnectionID)
+// cloneDatabase clones |db| from its remote into its path, or fetches it from the remote if it was cloned into its path
+// before.
+func cloneDatabase(ctx context.Context, dEnv *env.DoltEnv, db ClonedDatabase) error {
+	logrus.Infof("Cloning or fetching database '%s' from %s into %s", db.Name, db.RemoteUrl, db.Path)
+
+	_, verr := commands.CloneOrFetch(ctx, dEnv, "origin", db.RemoteUrl, db.Branch, db.Path)
+	if verr != nil {
+		return fmt.Errorf("failed to clone database '%s' from %s: %s", db.Name, db.RemoteUrl, verr.Verbose())
+	}
+
+	return nil
+}
+
+// sessionFactory returns a new session, along with its index and view registries, of a client of |user| connected from
+// |addr|. |host| is the address of the server and |connID| identifies the client's connection.
+type sessionFactory func(ctx context.Context, host, addr, user string, connID uint32) (*dsqle.DoltSession, *sql.IndexRegistry, *sql.ViewRegistry, error)
+
+// newSessionFactory returns the sessionFactory of the sessions of the server's clients. Only the sessions of users
+// which satisfy |bypassRowPolicies| can access the rows which row policies would otherwise hide, and the tables a
+// session can write are limited by the permission policies for the principal |principal| returns for its user. Unless
+// |workspaces| is in the dsqle.NoWorkspaces mode, sessions access each database through their workspace.
+func newSessionFactory(sqlEngine *sqle.Engine, username, email string, autocommit bool, bypassRowPolicies func(user string) bool, principal func(user string) doltdb.Principal, workspaces *dsqle.Workspaces) sessionFactory {
+	return func(ctx context.Context, host, addr, user string, connID uint32) (*dsqle.DoltSession, *sql.IndexRegistry, *sql.ViewRegistry, error) {
+		mysqlSess := sql.NewSession(host, addr, user, connID)
 		doltSess, err := dsqle.NewDoltSession(ctx, mysqlSess, username, email, dbsAsDSQLDBs(sqlEngine.Catalog.AllDatabases())...)
 
 		if err != nil {
 			return nil, nil, nil, err
 		}
 
+		doltSess.BypassRowPolicies = bypassRowPolicies(user)
+		doltSess.Principal = principal(user)
+
 		err = doltSess.Set(ctx, sql.AutoCommitSessionVar, sql.Boolean, autocommit)
 
 		if err != nil {
@@ -168,7 +354,12 @@ func newSessionBuilder(sqlEngine *sqle.Engine, username, email string, autocommi
 
 		dbs := dbsAsDSQLDBs(sqlEngine.Catalog.AllDatabases())
 		for _, db := range dbs {
-			err := db.LoadRootFromRepoState(sqlCtx)
+			if workspaces.Mode() == dsqle.NoWorkspaces {
+				err = db.LoadRootFromRepoState(sqlCtx)
+			} else {
+				err = doltSess.UseWorkspace(sqlCtx, db, workspaces, workspaces.Name(user, connID))
+			}
+
 			if err != nil {
 				return nil, nil, nil, err
 			}
@@ -198,6 +389,14 @@ func newSessionBuilder(sqlEngine *sqle.Engine, username, email string, autocommi
 	}
 }
 
+// newSessionBuilder returns the server.SessionBuilder for the sessions of the server's connections, which are made by
+// |newSession|.
+func newSessionBuilder(newSession sessionFactory) server.SessionBuilder {
+	return func(ctx context.Context, conn *mysql.Conn, host string) (sql.Session, *sql.IndexRegistry, *sql.ViewRegistry, error) {
+		return newSession(ctx, host, conn.RemoteAddr().String(), conn.User, conn.ConnectionID)
+	}
+}
+
 func newDatabase(name string, dEnv *env.DoltEnv) dsqle.Database {
 	return dsqle.NewDatabase(name, dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
 }
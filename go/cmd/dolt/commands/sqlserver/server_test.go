@@ -113,6 +113,11 @@ func TestServerBadArgs(t *testing.T) {
 		{"-P", "90000"},
 		{"-u", ""},
 		{"-l", "everything"},
+		{"--tls-key", "key.pem"},
+		{"--tls-ca", "ca.pem"},
+		{"--require-secure-transport"},
+		{"--workspaces", "everyone"},
+		{"--tls-key", "missing-key.pem", "--tls-cert", "missing-cert.pem"},
 	}
 
 	for _, test := range tests {
@@ -132,6 +137,38 @@ func TestServerBadArgs(t *testing.T) {
 	}
 }
 
+func TestServerBadCloneArgs(t *testing.T) {
+	dEnv := createEnvWithSeedData(t)
+
+	tests := [][]string{
+		{"--clone-branch", "master"},
+		{"--clone-dir", "replica"},
+		{"--clone-url", "file:///remote", "--multi-db-dir", "."},
+	}
+
+	for _, test := range tests {
+		t.Run(strings.Join(test, " "), func(t *testing.T) {
+			apr, err := createArgParser().Parse(test)
+			require.NoError(t, err)
+			_, err = getServerConfig(dEnv, apr)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestServerCloneArgs(t *testing.T) {
+	dEnv := createEnvWithSeedData(t)
+
+	apr, err := createArgParser().Parse([]string{"--clone-url", "file:///remote/org/repo-name", "--clone-branch", "release"})
+	require.NoError(t, err)
+	serverConfig, err := getServerConfig(dEnv, apr)
+	require.NoError(t, err)
+
+	expected := ClonedDatabase{Name: "repo_name", Path: "repo-name", RemoteUrl: "file:///remote/org/repo-name", Branch: "release"}
+	assert.Equal(t, []ClonedDatabase{expected}, serverConfig.ClonedDatabases())
+	assert.Equal(t, []env.EnvNameAndPath{{Name: "repo_name", Path: "repo-name"}}, serverConfig.DatabaseNamesAndPaths())
+}
+
 func TestServerGoodParams(t *testing.T) {
 	env := createEnvWithSeedData(t)
 
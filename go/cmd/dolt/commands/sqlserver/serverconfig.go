@@ -19,6 +19,7 @@ import (
 	"net"
 
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	dsqle "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
 )
 
 // LogLevel defines the available levels of logging for the server.
@@ -38,13 +39,21 @@ const (
 const (
 	defaultHost           = "localhost"
 	defaultPort           = 3306
+	defaultArrowPort      = 0
 	defaultUser           = "root"
 	defaultPass           = ""
 	defaultTimeout        = 30 * 1000
 	defaultReadOnly       = false
 	defaultLogLevel       = LogLevel_Info
 	defaultAutoCommit     = true
-	defaultMaxConnections = 0
+	defaultMaxConnections = 100
+	defaultMaxUserConns   = 0
+	defaultIdleTimeout    = 0
+	defaultDrainTimeout   = 30 * 1000
+	defaultSlowQuery      = 0
+	defaultBackgroundIO   = 0
+	defaultPauseLatency   = 0
+	defaultWorkspaces     = dsqle.NoWorkspaces
 )
 
 // String returns the string representation of the log level.
@@ -67,16 +76,41 @@ func (level LogLevel) String() string {
 	}
 }
 
+// UserAccount is the name and password of a user which clients can connect as, along with the roles which the
+// permission policies of the server's databases are granted to.
+type UserAccount struct {
+	Name     string
+	Password string
+	Roles    []string
+}
+
+// ClonedDatabase is a database which the server clones from a remote when it starts, or which it fetches from the
+// remote when it was cloned into its path before.
+type ClonedDatabase struct {
+	Name string
+	Path string
+	// RemoteUrl is the url of the remote the database is cloned from.
+	RemoteUrl string
+	// Branch is the branch which is cloned and checked out. "" clones the branch dolt clone would check out.
+	Branch string
+}
+
 // ServerConfig contains all of the configurable options for the MySQL-compatible server.
 type ServerConfig interface {
 	// Host returns the domain that the server will run on. Accepts an IPv4 or IPv6 address, in addition to localhost.
 	Host() string
 	// Port returns the port that the server will run on. The valid range is [1024, 65535].
 	Port() int
+	// ArrowPort returns the port of the HTTP endpoint which streams the results of queries as Arrow record batches. 0
+	// means the endpoint isn't served.
+	ArrowPort() int
 	// User returns the username that connecting clients must use.
 	User() string
 	// Password returns the password that connecting clients must use.
 	Password() string
+	// Users returns the accounts of additional users which clients can connect as. Unlike the user returned by User,
+	// these users are limited by row policies.
+	Users() []UserAccount
 	// ReadTimeout returns the read timeout in milliseconds
 	ReadTimeout() uint64
 	// WriteTimeout returns the write timeout in milliseconds
@@ -91,21 +125,66 @@ type ServerConfig interface {
 	// a multiple db configuration. If nil is returned the server will look for a database in the current directory and
 	// give it a name automatically.
 	DatabaseNamesAndPaths() []env.EnvNameAndPath
-	// MaxConnections returns the maximum number of simultaneous connections the server will allow.  The default is 1
+	// ClonedDatabases returns the databases which are cloned from remotes before the server accepts connections. They
+	// are also returned by DatabaseNamesAndPaths.
+	ClonedDatabases() []ClonedDatabase
+	// MaxConnections returns the maximum number of simultaneous connections the server will allow.  The default is 100
 	MaxConnections() uint64
+	// MaxUserConnections returns the maximum number of simultaneous connections the server will allow for a single
+	// user. 0 means there is no per user limit.
+	MaxUserConnections() uint64
+	// IdleTimeout returns the time in milliseconds after which a connection which hasn't sent a query is closed. 0 means
+	// idle connections are never closed.
+	IdleTimeout() uint64
+	// DrainTimeout returns the time in milliseconds that queries which are running when the server is asked to shut
+	// down are given to complete before their connections are closed.
+	DrainTimeout() uint64
+	// SlowQueryThreshold returns the time in milliseconds beyond which a query is logged as a slow query along with the
+	// chunk reads it made. 0 means slow queries are not logged.
+	SlowQueryThreshold() uint64
+	// BackgroundIOLimit returns the budget of bytes per second of the IO of background work, such as conjoins. 0 means
+	// it isn't limited.
+	BackgroundIOLimit() uint64
+	// BackgroundPauseLatency returns the time in milliseconds beyond which a query pauses the IO of background work for
+	// a while. 0 means it isn't paused.
+	BackgroundPauseLatency() uint64
+	// Workspaces returns whether sessions get a private workspace, and whether it is shared by the sessions of a user.
+	Workspaces() dsqle.WorkspaceMode
+	// TLSKey returns a path to the server's PEM-encoded private TLS key. "" if there is none.
+	TLSKey() string
+	// TLSCert returns a path to the server's PEM-encoded TLS certificate chain. "" if there is none.
+	TLSCert() string
+	// TLSCA returns a path to a PEM-encoded bundle of CA certificates. When set, clients must present a certificate
+	// signed by one of these authorities. "" if there is none.
+	TLSCA() string
+	// RequireSecureTransport is true if the server should reject connections which do not use TLS.
+	RequireSecureTransport() bool
 }
 
 type commandLineServerConfig struct {
 	host            string
 	port            int
+	arrowPort       int
 	user            string
 	password        string
 	timeout         uint64
 	readOnly        bool
 	logLevel        LogLevel
 	dbNamesAndPaths []env.EnvNameAndPath
+	clonedDBs       []ClonedDatabase
 	autoCommit      bool
 	maxConnections  uint64
+	maxUserConns    uint64
+	idleTimeout     uint64
+	drainTimeout    uint64
+	slowQuery       uint64
+	backgroundIO    uint64
+	pauseLatency    uint64
+	workspaces      dsqle.WorkspaceMode
+	tlsKey          string
+	tlsCert         string
+	tlsCA           string
+	requireSecure   bool
 }
 
 // Host returns the domain that the server will run on. Accepts an IPv4 or IPv6 address, in addition to localhost.
@@ -118,6 +197,11 @@ func (cfg *commandLineServerConfig) Port() int {
 	return cfg.port
 }
 
+// ArrowPort returns the port of the Arrow HTTP endpoint, or 0 if it isn't served.
+func (cfg *commandLineServerConfig) ArrowPort() int {
+	return cfg.arrowPort
+}
+
 // User returns the username that connecting clients must use.
 func (cfg *commandLineServerConfig) User() string {
 	return cfg.user
@@ -128,6 +212,12 @@ func (cfg *commandLineServerConfig) Password() string {
 	return cfg.password
 }
 
+// Users returns the accounts of additional users, of which there are none when the server is configured on the
+// command line.
+func (cfg *commandLineServerConfig) Users() []UserAccount {
+	return nil
+}
+
 // ReadTimeout returns the read and write timeouts.
 func (cfg *commandLineServerConfig) ReadTimeout() uint64 {
 	return cfg.timeout
@@ -153,11 +243,67 @@ func (cfg *commandLineServerConfig) AutoCommit() bool {
 	return cfg.autoCommit
 }
 
-// MaxConnections returns the maximum number of simultaneous connections the server will allow.  The default is 1
+// MaxConnections returns the maximum number of simultaneous connections the server will allow.  The default is 100
 func (cfg *commandLineServerConfig) MaxConnections() uint64 {
 	return cfg.maxConnections
 }
 
+// MaxUserConnections returns the maximum number of simultaneous connections the server will allow for a single user.
+func (cfg *commandLineServerConfig) MaxUserConnections() uint64 {
+	return cfg.maxUserConns
+}
+
+// IdleTimeout returns the time in milliseconds after which a connection which hasn't sent a query is closed.
+func (cfg *commandLineServerConfig) IdleTimeout() uint64 {
+	return cfg.idleTimeout
+}
+
+// DrainTimeout returns the time in milliseconds that running queries are given to complete when the server shuts down.
+func (cfg *commandLineServerConfig) DrainTimeout() uint64 {
+	return cfg.drainTimeout
+}
+
+// SlowQueryThreshold returns the time in milliseconds beyond which a query is logged as a slow query.
+func (cfg *commandLineServerConfig) SlowQueryThreshold() uint64 {
+	return cfg.slowQuery
+}
+
+// BackgroundIOLimit returns the budget of bytes per second of the IO of background work.
+func (cfg *commandLineServerConfig) BackgroundIOLimit() uint64 {
+	return cfg.backgroundIO
+}
+
+// BackgroundPauseLatency returns the time in milliseconds beyond which a query pauses the IO of background work.
+func (cfg *commandLineServerConfig) BackgroundPauseLatency() uint64 {
+	return cfg.pauseLatency
+}
+
+// Workspaces returns whether sessions get a private workspace, and whether it is shared by the sessions of a user.
+func (cfg *commandLineServerConfig) Workspaces() dsqle.WorkspaceMode {
+	return cfg.workspaces
+}
+
+// TLSKey returns a path to the server's PEM-encoded private TLS key. "" if there is none.
+func (cfg *commandLineServerConfig) TLSKey() string {
+	return cfg.tlsKey
+}
+
+// TLSCert returns a path to the server's PEM-encoded TLS certificate chain. "" if there is none.
+func (cfg *commandLineServerConfig) TLSCert() string {
+	return cfg.tlsCert
+}
+
+// TLSCA returns a path to a PEM-encoded bundle of CA certificates used to verify client certificates. "" if there is
+// none.
+func (cfg *commandLineServerConfig) TLSCA() string {
+	return cfg.tlsCA
+}
+
+// RequireSecureTransport is true if the server should reject connections which do not use TLS.
+func (cfg *commandLineServerConfig) RequireSecureTransport() bool {
+	return cfg.requireSecure
+}
+
 // DatabaseNamesAndPaths returns an array of env.EnvNameAndPathObjects corresponding to the databases to be loaded in
 // a multiple db configuration. If nil is returned the server will look for a database in the current directory and
 // give it a name automatically.
@@ -165,6 +311,11 @@ func (cfg *commandLineServerConfig) DatabaseNamesAndPaths() []env.EnvNameAndPath
 	return cfg.dbNamesAndPaths
 }
 
+// ClonedDatabases returns the databases which are cloned from remotes before the server accepts connections.
+func (cfg *commandLineServerConfig) ClonedDatabases() []ClonedDatabase {
+	return cfg.clonedDBs
+}
+
 // withHost updates the host and returns the called `*commandLineServerConfig`, which is useful for chaining calls.
 func (cfg *commandLineServerConfig) withHost(host string) *commandLineServerConfig {
 	cfg.host = host
@@ -177,6 +328,13 @@ func (cfg *commandLineServerConfig) withPort(port int) *commandLineServerConfig
 	return cfg
 }
 
+// withArrowPort updates the port of the Arrow HTTP endpoint and returns the called `*commandLineServerConfig`, which is
+// useful for chaining calls.
+func (cfg *commandLineServerConfig) withArrowPort(port int) *commandLineServerConfig {
+	cfg.arrowPort = port
+	return cfg
+}
+
 // withUser updates the user and returns the called `*commandLineServerConfig`, which is useful for chaining calls.
 func (cfg *commandLineServerConfig) withUser(user string) *commandLineServerConfig {
 	cfg.user = user
@@ -212,11 +370,92 @@ func (cfg *commandLineServerConfig) withDBNamesAndPaths(dbNamesAndPaths []env.En
 	return cfg
 }
 
+// withClonedDatabase adds a database which is cloned from a remote, serving it instead of the database in the current
+// directory, and returns the called `*commandLineServerConfig`, which is useful for chaining calls.
+func (cfg *commandLineServerConfig) withClonedDatabase(db ClonedDatabase) *commandLineServerConfig {
+	cfg.clonedDBs = append(cfg.clonedDBs, db)
+	cfg.dbNamesAndPaths = append(cfg.dbNamesAndPaths, env.EnvNameAndPath{Name: db.Name, Path: db.Path})
+	return cfg
+}
+
+// withMaxConnections updates the maximum number of connections and returns the called `*commandLineServerConfig`, which
+// is useful for chaining calls.
+func (cfg *commandLineServerConfig) withMaxConnections(maxConns uint64) *commandLineServerConfig {
+	cfg.maxConnections = maxConns
+	return cfg
+}
+
+// withMaxUserConnections updates the maximum number of connections per user and returns the called
+// `*commandLineServerConfig`, which is useful for chaining calls.
+func (cfg *commandLineServerConfig) withMaxUserConnections(maxUserConns uint64) *commandLineServerConfig {
+	cfg.maxUserConns = maxUserConns
+	return cfg
+}
+
+// withIdleTimeout updates the idle timeout and returns the called `*commandLineServerConfig`, which is useful for
+// chaining calls.
+func (cfg *commandLineServerConfig) withIdleTimeout(idleTimeout uint64) *commandLineServerConfig {
+	cfg.idleTimeout = idleTimeout
+	return cfg
+}
+
+// withDrainTimeout updates the drain timeout and returns the called `*commandLineServerConfig`, which is useful for
+// chaining calls.
+func (cfg *commandLineServerConfig) withDrainTimeout(drainTimeout uint64) *commandLineServerConfig {
+	cfg.drainTimeout = drainTimeout
+	return cfg
+}
+
+// withSlowQueryThreshold updates the slow query threshold and returns the called `*commandLineServerConfig`, which is
+// useful for chaining calls.
+func (cfg *commandLineServerConfig) withSlowQueryThreshold(slowQuery uint64) *commandLineServerConfig {
+	cfg.slowQuery = slowQuery
+	return cfg
+}
+
+// withBackgroundIOLimit updates the budget of bytes per second of background work and returns the called
+// `*commandLineServerConfig`, which is useful for chaining calls.
+func (cfg *commandLineServerConfig) withBackgroundIOLimit(bytesPerSec uint64) *commandLineServerConfig {
+	cfg.backgroundIO = bytesPerSec
+	return cfg
+}
+
+// withBackgroundPauseLatency updates the latency which pauses background work and returns the called
+// `*commandLineServerConfig`, which is useful for chaining calls.
+func (cfg *commandLineServerConfig) withBackgroundPauseLatency(pauseLatency uint64) *commandLineServerConfig {
+	cfg.pauseLatency = pauseLatency
+	return cfg
+}
+
+// withWorkspaces updates the workspace mode and returns the called `*commandLineServerConfig`, which is useful for
+// chaining calls.
+func (cfg *commandLineServerConfig) withWorkspaces(mode dsqle.WorkspaceMode) *commandLineServerConfig {
+	cfg.workspaces = mode
+	return cfg
+}
+
+// withTLS updates the paths to the TLS key, certificate and client CA bundle and returns the called
+// `*commandLineServerConfig`, which is useful for chaining calls.
+func (cfg *commandLineServerConfig) withTLS(key, cert, ca string) *commandLineServerConfig {
+	cfg.tlsKey = key
+	cfg.tlsCert = cert
+	cfg.tlsCA = ca
+	return cfg
+}
+
+// withRequireSecureTransport updates the require secure transport flag and returns the called
+// `*commandLineServerConfig`, which is useful for chaining calls.
+func (cfg *commandLineServerConfig) withRequireSecureTransport(requireSecure bool) *commandLineServerConfig {
+	cfg.requireSecure = requireSecure
+	return cfg
+}
+
 // DefaultServerConfig creates a `*ServerConfig` that has all of the options set to their default values.
 func DefaultServerConfig() *commandLineServerConfig {
 	return &commandLineServerConfig{
 		host:           defaultHost,
 		port:           defaultPort,
+		arrowPort:      defaultArrowPort,
 		user:           defaultUser,
 		password:       defaultPass,
 		timeout:        defaultTimeout,
@@ -224,6 +463,13 @@ func DefaultServerConfig() *commandLineServerConfig {
 		logLevel:       defaultLogLevel,
 		autoCommit:     defaultAutoCommit,
 		maxConnections: defaultMaxConnections,
+		maxUserConns:   defaultMaxUserConns,
+		idleTimeout:    defaultIdleTimeout,
+		drainTimeout:   defaultDrainTimeout,
+		slowQuery:      defaultSlowQuery,
+		backgroundIO:   defaultBackgroundIO,
+		pauseLatency:   defaultPauseLatency,
+		workspaces:     defaultWorkspaces,
 	}
 }
 
@@ -238,15 +484,57 @@ func ValidateConfig(config ServerConfig) error {
 	if config.Port() < 1024 || config.Port() > 65535 {
 		return fmt.Errorf("port is not in the range between 1024-65535: %v\n", config.Port())
 	}
+	if config.ArrowPort() != 0 {
+		if config.ArrowPort() < 1024 || config.ArrowPort() > 65535 {
+			return fmt.Errorf("arrow port is not in the range between 1024-65535: %v", config.ArrowPort())
+		}
+		if config.ArrowPort() == config.Port() {
+			return fmt.Errorf("arrow port must be different from the port: %v", config.ArrowPort())
+		}
+	}
 	if len(config.User()) == 0 {
 		return fmt.Errorf("user cannot be empty")
 	}
+	names := map[string]bool{config.User(): true}
+	for _, acc := range config.Users() {
+		if len(acc.Name) == 0 {
+			return fmt.Errorf("the name of a user cannot be empty")
+		} else if names[acc.Name] {
+			return fmt.Errorf("duplicate user: %v", acc.Name)
+		}
+		names[acc.Name] = true
+	}
 	if config.LogLevel().String() == "unknown" {
 		return fmt.Errorf("loglevel is invalid: %v\n", string(config.LogLevel()))
 	}
+	for _, db := range config.ClonedDatabases() {
+		if db.Path == "" {
+			return fmt.Errorf("the path of the database cloned from %v cannot be empty", db.RemoteUrl)
+		}
+	}
+	if !config.Workspaces().IsValid() {
+		return fmt.Errorf("workspaces must be one of none, session or user: %v", string(config.Workspaces()))
+	}
+	if (config.TLSKey() == "") != (config.TLSCert() == "") {
+		return fmt.Errorf("a TLS key and a TLS certificate must both be provided to enable TLS")
+	}
+	if config.TLSCert() == "" && config.TLSCA() != "" {
+		return fmt.Errorf("a TLS CA bundle can only be used when a TLS key and certificate are provided")
+	}
+	if config.TLSCert() == "" && config.RequireSecureTransport() {
+		return fmt.Errorf("requiring secure transport is only possible when a TLS key and certificate are provided")
+	}
 	return nil
 }
 
+// ReloadableServerConfig is a ServerConfig which is read from a source, such as a config file, which can be read again
+// while the server is running.
+type ReloadableServerConfig interface {
+	ServerConfig
+	// Reload reads the config from its source again and returns it.
+	Reload() (ServerConfig, error)
+}
+
 // ConnectionString returns a Data Source Name (DSN) to be used by go clients for connecting to a running server.
 func ConnectionString(config ServerConfig) string {
 	return fmt.Sprintf("%v:%v@tcp(%v:%v)/", config.User(), config.Password(), config.Host(), config.Port())
@@ -0,0 +1,100 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/src-d/go-mysql-server/sql"
+	"vitess.io/vitess/go/mysql"
+)
+
+// DrainFuncName is the name of the SQL function which begins a graceful shutdown of the server.
+const DrainFuncName = "dolt_drain"
+
+// gracefulShutdown stops the listener from accepting connections, gives the queries which are running up to
+// drainTimeout to complete, persists the working sets of the sessions which are still open and then closes their
+// connections.
+func gracefulShutdown(l *mysql.Listener, ct *connectionTracker, drainTimeout time.Duration) error {
+	logrus.Infof("Shutting down. Running queries have %v to complete", drainTimeout)
+	l.Shutdown()
+
+	if running := ct.drain(drainTimeout); running > 0 {
+		logrus.Warnf("%d queries did not complete within %v and will be interrupted", running, drainTimeout)
+	}
+
+	err := ct.persistSessions()
+	ct.closeAll()
+
+	logrus.Info("Server drained")
+	return err
+}
+
+// DrainFunc is a SQL function which begins a graceful shutdown of the server, allowing orchestration systems to drain
+// a server over a regular client connection. The query calling it completes before the server shuts down.
+type DrainFunc struct {
+	sc *ServerController
+}
+
+// NewDrainFunc returns a function which creates DrainFunc expressions that stop the server controlled by sc.
+func NewDrainFunc(sc *ServerController) func() sql.Expression {
+	return func() sql.Expression {
+		return &DrainFunc{sc}
+	}
+}
+
+// Eval implements the sql.Expression interface. The shutdown happens in the background as it waits for this query to
+// complete.
+func (d *DrainFunc) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	logrus.Infof("%s() called by user '%s' from %s", DrainFuncName, ctx.Client().User, ctx.Client().Address)
+	go d.sc.StopServer()
+
+	return int8(1), nil
+}
+
+// Type implements the sql.Expression interface.
+func (d *DrainFunc) Type() sql.Type {
+	return sql.Int8
+}
+
+// IsNullable implements the sql.Expression interface.
+func (d *DrainFunc) IsNullable() bool {
+	return false
+}
+
+// String implements the Stringer interface.
+func (d *DrainFunc) String() string {
+	return "DOLT_DRAIN()"
+}
+
+// WithChildren implements the sql.Expression interface.
+func (d *DrainFunc) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 0 {
+		return nil, sql.ErrInvalidChildrenNumber.New(d, len(children), 0)
+	}
+
+	return NewDrainFunc(d.sc)(), nil
+}
+
+// Resolved implements the sql.Expression interface.
+func (d *DrainFunc) Resolved() bool {
+	return true
+}
+
+// Children implements the sql.Expression interface.
+func (d *DrainFunc) Children() []sql.Expression {
+	return nil
+}
@@ -0,0 +1,210 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+
+	dsqle "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+func TestServerDrain(t *testing.T) {
+	ctx := context.Background()
+	serverConfig := DefaultServerConfig().withLogLevel(LogLevel_Fatal).withPort(15330).withDrainTimeout(5000)
+	sc := startTestServer(t, serverConfig)
+	defer sc.StopServer()
+
+	db, err := sql.Open("mysql", ConnectionString(serverConfig)+"dolt")
+	require.NoError(t, err)
+	defer db.Close()
+
+	running, err := openConn(ctx, db)
+	require.NoError(t, err)
+	defer running.Close()
+	admin, err := openConn(ctx, db)
+	require.NoError(t, err)
+	defer admin.Close()
+
+	queryErr := make(chan error, 1)
+	go func() {
+		_, err := running.ExecContext(ctx, "select sleep(1)")
+		queryErr <- err
+	}()
+
+	// give the query time to start
+	time.Sleep(200 * time.Millisecond)
+
+	var drained int
+	err = admin.QueryRowContext(ctx, "select dolt_drain()").Scan(&drained)
+	require.NoError(t, err)
+	assert.Equal(t, 1, drained)
+
+	// new connections are refused while the running query is allowed to complete
+	time.Sleep(100 * time.Millisecond)
+	_, err = openConn(ctx, db)
+	assert.Error(t, err)
+	_, err = admin.ExecContext(ctx, "select * from people")
+	assert.Error(t, err)
+
+	assert.NoError(t, <-queryErr)
+	assert.NoError(t, sc.WaitForClose())
+}
+
+func TestServerShutdownRacingCommits(t *testing.T) {
+	ctx := context.Background()
+	dEnv := createEnvWithSeedData(t)
+	serverConfig := DefaultServerConfig().withLogLevel(LogLevel_Fatal).withPort(15331)
+	sc := startTestServerWithEnv(t, serverConfig, dEnv)
+	defer sc.StopServer()
+
+	db, err := sql.Open("mysql", ConnectionString(serverConfig)+"dolt")
+	require.NoError(t, err)
+	defer db.Close()
+
+	conn, err := openConn(ctx, db)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// insert rows until the server shuts down, keeping track of the inserts which the server acknowledged
+	var acknowledged []string
+	started := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for i := 0; ; i++ {
+			name := fmt.Sprintf("row %d", i)
+			query := fmt.Sprintf("insert into people (id, name, age, is_married) values ('%s', '%s', 1, false)", uuid.New().String(), name)
+			_, err := conn.ExecContext(ctx, query)
+
+			if err != nil {
+				return
+			}
+
+			acknowledged = append(acknowledged, name)
+
+			if i == 0 {
+				close(started)
+			}
+		}
+	}()
+
+	<-started
+	sc.StopServer()
+	<-done
+	require.NoError(t, sc.WaitForClose())
+
+	root, err := dEnv.WorkingRoot(ctx)
+	require.NoError(t, err)
+	rows, err := dsqle.ExecuteSelect(dEnv, dEnv.DoltDB, root, "select name from people")
+	require.NoError(t, err)
+
+	persisted := make(map[string]bool)
+	for _, r := range rows {
+		persisted[r[0].(string)] = true
+	}
+
+	require.NotEmpty(t, acknowledged)
+	for _, name := range acknowledged {
+		assert.True(t, persisted[name], "acknowledged insert of '%s' was lost", name)
+	}
+}
+
+func TestServerShutdownPersistsWorkingSets(t *testing.T) {
+	ctx := context.Background()
+	dEnv := createEnvWithSeedData(t)
+	serverConfig := DefaultServerConfig().withLogLevel(LogLevel_Fatal).withPort(15332)
+	serverConfig.autoCommit = false
+	sc := startTestServerWithEnv(t, serverConfig, dEnv)
+	defer sc.StopServer()
+
+	db, err := sql.Open("mysql", ConnectionString(serverConfig)+"dolt")
+	require.NoError(t, err)
+	defer db.Close()
+
+	conn, err := openConn(ctx, db)
+	require.NoError(t, err)
+	defer conn.Close()
+	reader, err := openConn(ctx, db)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	_, err = conn.ExecContext(ctx, "insert into people (id, name, age, is_married) values ('"+uuid.New().String()+"', 'Homer Simpson', 39, true)")
+	require.NoError(t, err)
+
+	// the session which only read doesn't overwrite the working set of the session which wrote
+	_, err = reader.ExecContext(ctx, "select * from people")
+	require.NoError(t, err)
+
+	sc.StopServer()
+	require.NoError(t, sc.WaitForClose())
+
+	root, err := dEnv.WorkingRoot(ctx)
+	require.NoError(t, err)
+	rows, err := dsqle.ExecuteSelect(dEnv, dEnv.DoltDB, root, "select name from people where name = 'Homer Simpson'")
+	require.NoError(t, err)
+	assert.Len(t, rows, 1)
+}
+
+func TestServerReloadOnSIGHUP(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestServerReloadOnSIGHUP")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	configFile := filepath.Join(dir, "config.yaml")
+	writeConfig := func(password string) {
+		config := fmt.Sprintf("log_level: fatal\nuser:\n  name: dolt\n  password: %s\nlistener:\n  port: 15333\n", password)
+		require.NoError(t, ioutil.WriteFile(configFile, []byte(config), os.ModePerm))
+	}
+
+	writeConfig("first")
+	serverConfig, err := getYAMLServerConfig(filesys.LocalFS, configFile)
+	require.NoError(t, err)
+	sc := startTestServer(t, serverConfig)
+	defer sc.StopServer()
+
+	ping := func(password string) error {
+		db, err := sql.Open("mysql", "dolt:"+password+"@tcp(localhost:15333)/dolt")
+		require.NoError(t, err)
+		defer db.Close()
+
+		return db.Ping()
+	}
+
+	require.NoError(t, ping("first"))
+
+	writeConfig("second")
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+
+	for i := 0; i < 50 && ping("second") != nil; i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	assert.NoError(t, ping("second"))
+	assert.Error(t, ping("first"))
+}
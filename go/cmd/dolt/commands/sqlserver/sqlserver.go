@@ -18,37 +18,73 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/fatih/color"
 	"gopkg.in/yaml.v2"
 
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
 	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	dsqle "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
 )
 
 const (
-	hostFlag         = "host"
-	portFlag         = "port"
-	userFlag         = "user"
-	passwordFlag     = "password"
-	timeoutFlag      = "timeout"
-	readonlyFlag     = "readonly"
-	logLevelFlag     = "loglevel"
-	multiDBDirFlag   = "multi-db-dir"
-	noAutoCommitFlag = "no-auto-commit"
-	configFileFlag   = "config"
+	hostFlag          = "host"
+	portFlag          = "port"
+	arrowPortFlag     = "arrow-port"
+	userFlag          = "user"
+	passwordFlag      = "password"
+	timeoutFlag       = "timeout"
+	readonlyFlag      = "readonly"
+	logLevelFlag      = "loglevel"
+	multiDBDirFlag    = "multi-db-dir"
+	noAutoCommitFlag  = "no-auto-commit"
+	configFileFlag    = "config"
+	maxConnsFlag      = "max-connections"
+	maxUserConnsFlag  = "max-user-connections"
+	idleTimeoutFlag   = "idle-timeout"
+	drainTimeoutFlag  = "drain-timeout"
+	slowQueryFlag     = "slow-query-threshold"
+	backgroundIOFlag  = "background-io-limit"
+	pauseLatencyFlag  = "background-pause-latency"
+	workspacesFlag    = "workspaces"
+	statusFlag        = "status"
+	tlsKeyFlag        = "tls-key"
+	tlsCertFlag       = "tls-cert"
+	tlsCAFlag         = "tls-ca"
+	requireSecureFlag = "require-secure-transport"
+	cloneUrlFlag      = "clone-url"
+	cloneBranchFlag   = "clone-branch"
+	cloneDirFlag      = "clone-dir"
 )
 
 var sqlServerDocs = cli.CommandDocumentationContent{
 	ShortDesc: "Start a MySQL-compatible server.",
-	LongDesc:  `Start a MySQL-compatible server which can be connected to by MySQL clients.`,
+	LongDesc: `Start a MySQL-compatible server which can be connected to by MySQL clients.
+
+When {{.EmphasisLeft}}--tls-key{{.EmphasisRight}} and {{.EmphasisLeft}}--tls-cert{{.EmphasisRight}} are provided, clients may connect using TLS. If {{.EmphasisLeft}}--tls-ca{{.EmphasisRight}} is also provided, clients must present a certificate signed by one of the given certificate authorities. Sending the server a SIGHUP reloads the certificates from disk without affecting established connections.
+
+Sending the server a SIGTERM, or calling {{.EmphasisLeft}}dolt_drain(){{.EmphasisRight}} from a client, shuts the server down gracefully. New connections are refused, running queries are given {{.EmphasisLeft}}--drain-timeout{{.EmphasisRight}} seconds to complete, the working sets of the open sessions are persisted and the server exits. A second SIGTERM stops the server immediately. When the server is configured with {{.EmphasisLeft}}--config{{.EmphasisRight}}, SIGHUP also reloads the user and permissions from the config file.
+
+When {{.EmphasisLeft}}--slow-query-threshold{{.EmphasisRight}} is provided, queries which take longer than the threshold are logged along with the number of chunks they read, how many of those were served from cache and the number of round trips made to remotes. {{.EmphasisLeft}}EXPLAIN ANALYZE SELECT ...{{.EmphasisRight}} runs a query and returns its plan along with the same statistics and the time spent in storage.
+
+When {{.EmphasisLeft}}--workspaces{{.EmphasisRight}} is {{.EmphasisLeft}}session{{.EmphasisRight}} or {{.EmphasisLeft}}user{{.EmphasisRight}}, each session, or all of the sessions of a user, get a private workspace, so their uncommitted changes aren't seen by other sessions. {{.EmphasisLeft}}SELECT DOLT_COMMIT('message'){{.EmphasisRight}} merges a workspace's changes into the branch and commits them. Workspaces are listed in the {{.EmphasisLeft}}dolt_workspaces{{.EmphasisRight}} system table and by {{.EmphasisLeft}}dolt workspace{{.EmphasisRight}}.
+
+When {{.EmphasisLeft}}--arrow-port{{.EmphasisRight}} is provided, the server also serves an HTTP endpoint at {{.EmphasisLeft}}/query{{.EmphasisRight}} on that port, which streams the results of a query as an Apache Arrow IPC stream of record batches. The query is the body of a POST or the {{.EmphasisLeft}}q{{.EmphasisRight}} parameter of a GET, and the optional {{.EmphasisLeft}}db{{.EmphasisRight}} parameter is the database it's run in. Clients authenticate as one of the server's users with HTTP basic auth, the endpoint is served over TLS when the server is, and only queries which read, such as {{.EmphasisLeft}}SELECT{{.EmphasisRight}} and {{.EmphasisLeft}}SHOW{{.EmphasisRight}}, can be run.
+
+When {{.EmphasisLeft}}--clone-url{{.EmphasisRight}} is provided, the server serves the database cloned from the remote at the url into {{.EmphasisLeft}}--clone-dir{{.EmphasisRight}} instead of the database in the current directory. If {{.EmphasisLeft}}--clone-dir{{.EmphasisRight}} already holds a clone of the remote, the branch is fetched and fast-forwarded instead. The clone or fetch completes before the server starts listening for connections, and the server exits with an error rather than starting if it fails. Databases listed in the config file with a {{.EmphasisLeft}}remote{{.EmphasisRight}}, and optionally a {{.EmphasisLeft}}branch{{.EmphasisRight}}, are cloned into their {{.EmphasisLeft}}path{{.EmphasisRight}} in the same way.
+
+The server holds the lock of each of its databases while it runs, so commands which write to a database, such as {{.EmphasisLeft}}dolt commit{{.EmphasisRight}}, fail until it stops. The lock is released by the operating system when the server exits, even if it crashes. {{.EmphasisLeft}}dolt sql-server --status{{.EmphasisRight}} prints the process id, port and start time of the server holding the lock of the databases, and exits with a non-zero status if they aren't locked by a server.`,
 	Synopsis: []string{
-		"[-H {{.LessThan}}host{{.GreaterThan}}] [-P {{.LessThan}}port{{.GreaterThan}}] [-u {{.LessThan}}user{{.GreaterThan}}] [-p {{.LessThan}}password{{.GreaterThan}}] [-t {{.LessThan}}timeout{{.GreaterThan}}] [-l {{.LessThan}}loglevel{{.GreaterThan}}] [--multi-db-dir {{.LessThan}}directory{{.GreaterThan}}] [-r]",
+		"[-H {{.LessThan}}host{{.GreaterThan}}] [-P {{.LessThan}}port{{.GreaterThan}}] [-u {{.LessThan}}user{{.GreaterThan}}] [-p {{.LessThan}}password{{.GreaterThan}}] [-t {{.LessThan}}timeout{{.GreaterThan}}] [-l {{.LessThan}}loglevel{{.GreaterThan}}] [--arrow-port {{.LessThan}}port{{.GreaterThan}}] [--multi-db-dir {{.LessThan}}directory{{.GreaterThan}}] [-r] [--tls-key {{.LessThan}}file{{.GreaterThan}} --tls-cert {{.LessThan}}file{{.GreaterThan}} [--tls-ca {{.LessThan}}file{{.GreaterThan}}] [--require-secure-transport]] [--clone-url {{.LessThan}}url{{.GreaterThan}} [--clone-branch {{.LessThan}}branch{{.GreaterThan}}] [--clone-dir {{.LessThan}}directory{{.GreaterThan}}]]",
 	},
 }
 
@@ -78,6 +114,7 @@ func createArgParser() *argparser.ArgParser {
 	ap := argparser.NewArgParser()
 	ap.SupportsString(hostFlag, "H", "Host address", fmt.Sprintf("Defines the host address that the server will run on (default `%v`)", serverConfig.Host()))
 	ap.SupportsUint(portFlag, "P", "Port", fmt.Sprintf("Defines the port that the server will run on (default `%v`)", serverConfig.Port()))
+	ap.SupportsUint(arrowPortFlag, "", "Port", "Defines the port of an HTTP endpoint which streams the results of queries as Apache Arrow record batches\nA value of `0` means the endpoint is not served (default `0`)")
 	ap.SupportsString(userFlag, "u", "User", fmt.Sprintf("Defines the server user (default `%v`)", serverConfig.User()))
 	ap.SupportsString(passwordFlag, "p", "Password", fmt.Sprintf("Defines the server password (default `%v`)", serverConfig.Password()))
 	ap.SupportsInt(timeoutFlag, "t", "Connection timeout", fmt.Sprintf("Defines the timeout, in seconds, used for connections\nA value of `0` represents an infinite timeout (default `%v`)", serverConfig.ReadTimeout()))
@@ -86,6 +123,22 @@ func createArgParser() *argparser.ArgParser {
 	ap.SupportsString(multiDBDirFlag, "", "directory", "Defines a directory whose subdirectories should all be dolt data repositories accessible as independent databases.")
 	ap.SupportsFlag(noAutoCommitFlag, "", "When provided sessions will not automatically commit their changes to the working set. Anything not manually committed will be lost.")
 	ap.SupportsString(configFileFlag, "", "file", "When provided configuration is taken from the yaml config file and all command line parameters are ignored.")
+	ap.SupportsUint(maxConnsFlag, "", "Max connections", fmt.Sprintf("Defines the maximum number of simultaneous connections. Connections beyond the limit are refused with a too many connections error (default `%v`)", serverConfig.MaxConnections()))
+	ap.SupportsUint(maxUserConnsFlag, "", "Max user connections", "Defines the maximum number of simultaneous connections for a single user\nA value of `0` means there is no per user limit (default `0`)")
+	ap.SupportsString(workspacesFlag, "", "mode", "Gives each session (`session`) or each user (`user`) a private workspace for its uncommitted changes (default `none`)")
+	ap.SupportsUint(idleTimeoutFlag, "", "Idle timeout", "Defines the time, in seconds, after which a connection which has not sent a query is closed\nA value of `0` means idle connections are never closed (default `0`)")
+	ap.SupportsUint(drainTimeoutFlag, "", "Drain timeout", fmt.Sprintf("Defines the time, in seconds, that running queries are given to complete when the server shuts down (default `%v`)", serverConfig.DrainTimeout()/1000))
+	ap.SupportsUint(slowQueryFlag, "", "Slow query threshold", "Defines the time, in milliseconds, beyond which a query is logged along with the chunks it read\nA value of `0` means slow queries are not logged (default `0`)")
+	ap.SupportsUint(backgroundIOFlag, "", "Bytes per second", "Limits the IO of background work, such as conjoining table files, to the number of bytes per second given\nA value of `0` means it is not limited (default `0`)")
+	ap.SupportsUint(pauseLatencyFlag, "", "Pause latency", "Defines the time, in milliseconds, beyond which a query pauses the IO of background work for a second\nA value of `0` means it is not paused (default `0`)")
+	ap.SupportsString(tlsKeyFlag, "", "file", "Path to the PEM-encoded private key used for TLS connections.")
+	ap.SupportsString(tlsCertFlag, "", "file", "Path to the PEM-encoded certificate chain used for TLS connections.")
+	ap.SupportsString(tlsCAFlag, "", "file", "Path to a PEM-encoded bundle of CA certificates. When provided, clients must present a certificate signed by one of these authorities.")
+	ap.SupportsFlag(requireSecureFlag, "", "When provided, connections which do not use TLS are rejected.")
+	ap.SupportsString(cloneUrlFlag, "", "url", "Clones the database served from the remote at the url, or fetches from it, before the server starts.")
+	ap.SupportsString(cloneBranchFlag, "", "branch", "The branch cloned with --clone-url. If not specified, the branch dolt clone would check out is cloned.")
+	ap.SupportsString(cloneDirFlag, "", "directory", "The directory the database is cloned into with --clone-url. If not specified, it is inferred from the url as it is by dolt clone.")
+	ap.SupportsFlag(statusFlag, "", "Prints the server which holds the lock of the databases instead of starting a server.")
 	return ap
 }
 
@@ -123,6 +176,15 @@ func startServer(ctx context.Context, versionStr, commandStr string, args []stri
 		return 1
 	}
 
+	if apr.Contains(statusFlag) {
+		if serverController != nil {
+			serverController.StopServer()
+			serverController.serverStopped(nil)
+		}
+
+		return printServerStatus(dEnv, serverConfig)
+	}
+
 	cli.PrintErrf("Starting server with Config %v\n", ConfigInfo(serverConfig))
 
 	if startError, closeError := Serve(ctx, versionStr, serverConfig, serverController, dEnv); startError != nil || closeError != nil {
@@ -138,6 +200,46 @@ func startServer(ctx context.Context, versionStr, commandStr string, args []stri
 	return 0
 }
 
+// printServerStatus prints the process which holds the lock of each of the databases of |serverConfig|, returning 0 if
+// they're all locked by a sql-server.
+func printServerStatus(dEnv *env.DoltEnv, serverConfig ServerConfig) int {
+	dbNamesAndPaths := serverConfig.DatabaseNamesAndPaths()
+	if len(dbNamesAndPaths) == 0 {
+		if !dEnv.HasDoltDir() {
+			cli.PrintErrln(color.RedString("The current directory is not a valid dolt repository."))
+			return 1
+		}
+
+		dbNamesAndPaths = []env.EnvNameAndPath{{Name: "", Path: "."}}
+	}
+
+	status := 0
+	for _, nameAndPath := range dbNamesAndPaths {
+		prefix := ""
+		if nameAndPath.Name != "" {
+			prefix = nameAndPath.Name + ": "
+		}
+
+		info, err := env.ReadLockInfo(dEnv.FS, filepath.Join(nameAndPath.Path, dbfactory.DoltDir))
+
+		switch {
+		case err != nil:
+			cli.PrintErrln(color.RedString("%sfailed to read the lock of the repository: %v", prefix, err))
+			status = 1
+		case info == nil:
+			cli.Println(prefix + "no dolt sql-server is running")
+			status = 1
+		case info.Command == sqlServerLockCommand:
+			cli.Printf("%sdolt sql-server is running on port %d (pid %d, started %s)\n", prefix, info.Port, info.Pid, info.StartTime.Format(time.RFC3339))
+		default:
+			cli.Println(prefix + "no dolt sql-server is running, the repository is locked by " + info.String())
+			status = 1
+		}
+	}
+
+	return status
+}
+
 func getServerConfig(dEnv *env.DoltEnv, apr *argparser.ArgParseResults) (ServerConfig, error) {
 	cfgFile, ok := apr.GetValue(configFileFlag)
 
@@ -157,6 +259,9 @@ func getCommandLineServerConfig(dEnv *env.DoltEnv, apr *argparser.ArgParseResult
 	if port, ok := apr.GetInt(portFlag); ok {
 		serverConfig.withPort(port)
 	}
+	if arrowPort, ok := apr.GetInt(arrowPortFlag); ok {
+		serverConfig.withArrowPort(arrowPort)
+	}
 	if user, ok := apr.GetValue(userFlag); ok {
 		serverConfig.withUser(user)
 	}
@@ -186,12 +291,61 @@ func getCommandLineServerConfig(dEnv *env.DoltEnv, apr *argparser.ArgParseResult
 		}
 
 		serverConfig.withDBNamesAndPaths(dbNamesAndPaths)
-	} else {
-		if !cli.CheckEnvIsValid(dEnv) {
-			return nil, errors.New("not a valid dolt directory")
+	}
+
+	if cloneUrl, ok := apr.GetValue(cloneUrlFlag); ok {
+		if apr.Contains(multiDBDirFlag) {
+			return nil, fmt.Errorf("--%s can't be used with --%s", cloneUrlFlag, multiDBDirFlag)
+		}
+
+		cloneDir, ok := apr.GetValue(cloneDirFlag)
+		if !ok {
+			var verr errhand.VerboseError
+			cloneDir, verr = commands.CloneDirForUrl(cloneUrl)
+			if verr != nil {
+				return nil, verr
+			}
 		}
+
+		serverConfig.withClonedDatabase(ClonedDatabase{
+			Name:      env.DBNameForPath(cloneDir),
+			Path:      cloneDir,
+			RemoteUrl: cloneUrl,
+			Branch:    apr.GetValueOrDefault(cloneBranchFlag, ""),
+		})
+	} else if apr.Contains(cloneBranchFlag) || apr.Contains(cloneDirFlag) {
+		return nil, fmt.Errorf("--%s and --%s can only be used with --%s", cloneBranchFlag, cloneDirFlag, cloneUrlFlag)
+	} else if !apr.Contains(multiDBDirFlag) && !cli.CheckEnvIsValid(dEnv) {
+		return nil, errors.New("not a valid dolt directory")
+	}
+
+	if maxConns, ok := apr.GetUint(maxConnsFlag); ok {
+		serverConfig.withMaxConnections(maxConns)
+	}
+	if maxUserConns, ok := apr.GetUint(maxUserConnsFlag); ok {
+		serverConfig.withMaxUserConnections(maxUserConns)
+	}
+	if idleTimeout, ok := apr.GetUint(idleTimeoutFlag); ok {
+		serverConfig.withIdleTimeout(idleTimeout * 1000)
+	}
+	if drainTimeout, ok := apr.GetUint(drainTimeoutFlag); ok {
+		serverConfig.withDrainTimeout(drainTimeout * 1000)
+	}
+	if slowQuery, ok := apr.GetUint(slowQueryFlag); ok {
+		serverConfig.withSlowQueryThreshold(slowQuery)
+	}
+	if backgroundIO, ok := apr.GetUint(backgroundIOFlag); ok {
+		serverConfig.withBackgroundIOLimit(backgroundIO)
+	}
+	if pauseLatency, ok := apr.GetUint(pauseLatencyFlag); ok {
+		serverConfig.withBackgroundPauseLatency(pauseLatency)
+	}
+	if workspaces, ok := apr.GetValue(workspacesFlag); ok {
+		serverConfig.withWorkspaces(dsqle.WorkspaceMode(workspaces))
 	}
 
+	serverConfig.withTLS(apr.GetValueOrDefault(tlsKeyFlag, ""), apr.GetValueOrDefault(tlsCertFlag, ""), apr.GetValueOrDefault(tlsCAFlag, ""))
+	serverConfig.withRequireSecureTransport(apr.Contains(requireSecureFlag))
 	serverConfig.autoCommit = !apr.Contains(noAutoCommitFlag)
 	return serverConfig, nil
 }
@@ -210,5 +364,5 @@ func getYAMLServerConfig(fs filesys.Filesys, path string) (ServerConfig, error)
 		return nil, fmt.Errorf("Failed to parse yaml file '%s'. Error: %s", path, err.Error())
 	}
 
-	return cfg, nil
+	return yamlConfigFile{cfg, fs, path}, nil
 }
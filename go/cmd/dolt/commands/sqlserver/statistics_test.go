@@ -0,0 +1,69 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestServerStatistics(t *testing.T) {
+	ctx := context.Background()
+	dEnv := createEnvWithSeedData(t)
+
+	serverConfig := DefaultServerConfig().withLogLevel(LogLevel_Fatal).withPort(15338)
+	sc := startTestServerWithEnv(t, serverConfig, dEnv)
+	defer sc.StopServer()
+
+	db, err := sql.Open("mysql", ConnectionString(serverConfig)+"dolt")
+	require.NoError(t, err)
+	defer db.Close()
+
+	var table, op, msgType, msgText string
+	err = db.QueryRowContext(ctx, "analyze table people").Scan(&table, &op, &msgType, &msgText)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dolt.people", "analyze", "status", "OK"}, []string{table, op, msgType, msgText})
+
+	// the statistics are committed to the working set
+	var rows int
+	err = db.QueryRowContext(ctx, "select count(*) from dolt_statistics where table_name = 'people'").Scan(&rows)
+	require.NoError(t, err)
+	assert.Greater(t, rows, 0)
+
+	root, err := dEnv.WorkingRoot(ctx)
+	require.NoError(t, err)
+	ok, err := root.HasTable(ctx, "dolt_statistics")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	res, err := db.QueryContext(ctx, "show stats for people")
+	require.NoError(t, err)
+	defer res.Close()
+
+	cols, err := res.Columns()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Table", "Column", "Rows", "Null_fraction", "Distinct", "Min", "Max", "Buckets", "Stale"}, cols)
+
+	var statsRows int
+	for res.Next() {
+		statsRows++
+	}
+	require.NoError(t, res.Err())
+	assert.Equal(t, rows, statsRows)
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestServerTableComments(t *testing.T) {
+	ctx := context.Background()
+	dEnv := createEnvWithSeedData(t)
+
+	serverConfig := DefaultServerConfig().withLogLevel(LogLevel_Fatal).withPort(15337)
+	sc := startTestServerWithEnv(t, serverConfig, dEnv)
+	defer sc.StopServer()
+
+	db, err := sql.Open("mysql", ConnectionString(serverConfig)+"dolt")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.ExecContext(ctx, "create table commented (a int primary key comment 'the key', b int) comment='first'")
+	require.NoError(t, err)
+
+	var comment string
+	err = db.QueryRowContext(ctx, "select table_comment from information_schema.tables where table_name = 'commented'").Scan(&comment)
+	require.NoError(t, err)
+	assert.Equal(t, "first", comment)
+
+	err = db.QueryRowContext(ctx, "select column_comment from information_schema.columns where table_name = 'commented' and column_name = 'a'").Scan(&comment)
+	require.NoError(t, err)
+	assert.Equal(t, "the key", comment)
+
+	_, err = db.ExecContext(ctx, "alter table commented comment 'second'")
+	require.NoError(t, err)
+
+	var name, stmt string
+	err = db.QueryRowContext(ctx, "show create table commented").Scan(&name, &stmt)
+	require.NoError(t, err)
+	assert.Contains(t, stmt, "COMMENT='second'")
+}
@@ -0,0 +1,215 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/src-d/go-mysql-server/auth"
+	"vitess.io/vitess/go/mysql"
+)
+
+// tlsConfigLoader holds the certificates used for TLS connections to the server, and allows them to be reloaded from
+// disk while the server is running. Connections which have already completed their handshake are not affected by a
+// reload.
+type tlsConfigLoader struct {
+	keyFile  string
+	certFile string
+	caFile   string
+
+	mu        *sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+
+	// secureConns is nil unless secure transport is required
+	secureConns *secureConnSet
+}
+
+// newTLSConfigLoader loads the key, certificate and optional client CA bundle at the given paths. If requireSecure is
+// true the remote address of every connection which completes a TLS handshake is tracked so that a
+// secureTransportAuth can reject the connections which did not.
+func newTLSConfigLoader(keyFile, certFile, caFile string, requireSecure bool) (*tlsConfigLoader, error) {
+	l := &tlsConfigLoader{keyFile: keyFile, certFile: certFile, caFile: caFile, mu: &sync.RWMutex{}}
+
+	if requireSecure {
+		l.secureConns = newSecureConnSet()
+	}
+
+	err := l.Reload()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Reload reads the key, certificate and client CA bundle from disk. If any of them fail to load the previously loaded
+// certificates remain in use.
+func (l *tlsConfigLoader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+
+	if err != nil {
+		return fmt.Errorf("failed to load TLS key pair cert: '%s', key: '%s'. error: %v", l.certFile, l.keyFile, err)
+	}
+
+	var clientCAs *x509.CertPool
+	if l.caFile != "" {
+		data, err := ioutil.ReadFile(l.caFile)
+
+		if err != nil {
+			return fmt.Errorf("failed to read TLS CA bundle '%s'. error: %v", l.caFile, err)
+		}
+
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(data) {
+			return fmt.Errorf("no PEM encoded certificates found in TLS CA bundle '%s'", l.caFile)
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cert = &cert
+	l.clientCAs = clientCAs
+
+	return nil
+}
+
+// TLSConfig returns the *tls.Config given to the listener. The certificates are looked up for every handshake so that
+// reloaded certificates are used by new connections.
+func (l *tlsConfigLoader) TLSConfig() *tls.Config {
+	return &tls.Config{GetConfigForClient: l.configForClient}
+}
+
+func (l *tlsConfigLoader) configForClient(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	remoteAddr := hello.Conn.RemoteAddr().String()
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{*l.cert},
+		MinVersion:   tls.VersionTLS12,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			logrus.Debugf("TLS handshake with %s completed using %s", remoteAddr, tlsVersionName(cs.Version))
+
+			if l.secureConns != nil {
+				l.secureConns.add(remoteAddr)
+			}
+
+			return nil
+		},
+	}
+
+	if l.clientCAs != nil {
+		cfg.ClientCAs = l.clientCAs
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return cfg, nil
+}
+
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLSv1.0"
+	case tls.VersionTLS11:
+		return "TLSv1.1"
+	case tls.VersionTLS12:
+		return "TLSv1.2"
+	case tls.VersionTLS13:
+		return "TLSv1.3"
+	default:
+		return fmt.Sprintf("unknown TLS version 0x%x", version)
+	}
+}
+
+// secureConnSet is the set of remote addresses of connections which have completed a TLS handshake but have not yet
+// authenticated.
+type secureConnSet struct {
+	mu    *sync.Mutex
+	addrs map[string]struct{}
+}
+
+func newSecureConnSet() *secureConnSet {
+	return &secureConnSet{mu: &sync.Mutex{}, addrs: make(map[string]struct{})}
+}
+
+func (s *secureConnSet) add(addr string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.addrs[addr] = struct{}{}
+}
+
+// remove removes the address from the set and returns whether it was present.
+func (s *secureConnSet) remove(addr string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.addrs[addr]
+	delete(s.addrs, addr)
+
+	return ok
+}
+
+// secureTransportAuth wraps an auth.Auth and turns away clients which have not completed a TLS handshake. The mysql
+// listener writes an error to clients which connect without TLS when secure transport is required, but it still lets
+// authentication proceed, so the check has to be repeated here.
+type secureTransportAuth struct {
+	auth.Auth
+	secureConns *secureConnSet
+}
+
+// Mysql returns a mysql.AuthServer which rejects insecure connections before validating credentials.
+func (a secureTransportAuth) Mysql() mysql.AuthServer {
+	return secureTransportAuthServer{a.Auth.Mysql(), a.secureConns}
+}
+
+type secureTransportAuthServer struct {
+	mysql.AuthServer
+	secureConns *secureConnSet
+}
+
+// ValidateHash rejects connections which did not complete a TLS handshake, and otherwise defers to the wrapped
+// mysql.AuthServer.
+func (as secureTransportAuthServer) ValidateHash(salt []byte, user string, authResponse []byte, remoteAddr net.Addr) (mysql.Getter, error) {
+	if !as.secureConns.remove(remoteAddr.String()) {
+		return nil, insecureConnErr(user, remoteAddr)
+	}
+
+	return as.AuthServer.ValidateHash(salt, user, authResponse, remoteAddr)
+}
+
+// Negotiate rejects connections which did not complete a TLS handshake, and otherwise defers to the wrapped
+// mysql.AuthServer.
+func (as secureTransportAuthServer) Negotiate(c *mysql.Conn, user string, remoteAddr net.Addr) (mysql.Getter, error) {
+	if !as.secureConns.remove(remoteAddr.String()) || c.Capabilities&mysql.CapabilityClientSSL == 0 {
+		return nil, insecureConnErr(user, remoteAddr)
+	}
+
+	return as.AuthServer.Negotiate(c, user, remoteAddr)
+}
+
+func insecureConnErr(user string, remoteAddr net.Addr) error {
+	logrus.Warnf("Rejected connection for user '%s' from %s which did not use TLS while secure transport is required", user, remoteAddr)
+	return mysql.NewSQLError(mysql.ERAccessDeniedError, mysql.SSAccessDeniedError, "Connections using insecure transport are prohibited while secure transport is required")
+}
@@ -0,0 +1,256 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/gocraft/dbr/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	tlsCert tls.Certificate
+}
+
+func newTestCert(t *testing.T, commonName string, serial int64, parent *testCert, isCA bool) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	if isCA {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	}
+
+	parentCert, parentKey := tmpl, key
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parentCert, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCert{cert, key, tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}}
+}
+
+// writePEM writes the certificate and key of tc to dir and returns the paths of the key and cert files.
+func (tc *testCert) writePEM(t *testing.T, dir, name string) (string, string) {
+	keyDER, err := x509.MarshalECPrivateKey(tc.key)
+	require.NoError(t, err)
+
+	keyFile := filepath.Join(dir, name+"-key.pem")
+	certFile := filepath.Join(dir, name+"-cert.pem")
+	err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), os.ModePerm)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tc.cert.Raw}), os.ModePerm)
+	require.NoError(t, err)
+
+	return keyFile, certFile
+}
+
+func TestTLSConfigLoaderReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestTLSConfigLoaderReload")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCert(t, "ca", 1, nil, true)
+	_, caFile := ca.writePEM(t, dir, "ca")
+	first := newTestCert(t, "first", 2, ca, false)
+	keyFile, certFile := first.writePEM(t, dir, "server")
+	client := newTestCert(t, "client", 3, ca, false)
+
+	loader, err := newTLSConfigLoader(keyFile, certFile, caFile, false)
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientCfg := &tls.Config{RootCAs: roots, ServerName: "localhost", Certificates: []tls.Certificate{client.tlsCert}}
+
+	established := handshake(t, loader.TLSConfig(), clientCfg)
+	defer established.Close()
+	assert.Equal(t, "first", established.ConnectionState().PeerCertificates[0].Subject.CommonName)
+
+	second := newTestCert(t, "second", 4, ca, false)
+	second.writePEM(t, dir, "server")
+	require.NoError(t, loader.Reload())
+
+	conn := handshake(t, loader.TLSConfig(), clientCfg)
+	defer conn.Close()
+	assert.Equal(t, "second", conn.ConnectionState().PeerCertificates[0].Subject.CommonName)
+
+	// a failed reload keeps the previous certificates
+	err = ioutil.WriteFile(certFile, []byte("not a certificate"), os.ModePerm)
+	require.NoError(t, err)
+	assert.Error(t, loader.Reload())
+
+	conn = handshake(t, loader.TLSConfig(), clientCfg)
+	defer conn.Close()
+	assert.Equal(t, "second", conn.ConnectionState().PeerCertificates[0].Subject.CommonName)
+
+	// connections established before the reload keep working
+	_, err = established.Write([]byte("ping"))
+	assert.NoError(t, err)
+}
+
+func TestTLSConfigLoaderRequiresClientCert(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestTLSConfigLoaderRequiresClientCert")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCert(t, "ca", 1, nil, true)
+	_, caFile := ca.writePEM(t, dir, "ca")
+	keyFile, certFile := newTestCert(t, "server", 2, ca, false).writePEM(t, dir, "server")
+
+	loader, err := newTLSConfigLoader(keyFile, certFile, caFile, true)
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- tls.Server(serverConn, loader.TLSConfig()).Handshake()
+	}()
+
+	// with TLS 1.3 the client completes its side of the handshake before the server rejects it, so read the alert sent by
+	// the server
+	client := tls.Client(clientConn, &tls.Config{RootCAs: roots, ServerName: "localhost"})
+	if client.Handshake() == nil {
+		_, err = client.Read(make([]byte, 1))
+		assert.Error(t, err)
+	}
+
+	assert.Error(t, <-errCh)
+	assert.False(t, loader.secureConns.remove(serverConn.RemoteAddr().String()))
+}
+
+func TestSecureConnSet(t *testing.T) {
+	s := newSecureConnSet()
+	assert.False(t, s.remove("127.0.0.1:5000"))
+
+	s.add("127.0.0.1:5000")
+	assert.True(t, s.remove("127.0.0.1:5000"))
+	assert.False(t, s.remove("127.0.0.1:5000"))
+}
+
+func TestServerTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestServerTLS")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCert(t, "ca", 1, nil, true)
+	_, caFile := ca.writePEM(t, dir, "ca")
+	keyFile, certFile := newTestCert(t, "server", 2, ca, false).writePEM(t, dir, "server")
+	client := newTestCert(t, "client", 3, ca, false)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	err = mysql.RegisterTLSConfig("dolt-mutual", &tls.Config{RootCAs: roots, ServerName: "localhost", Certificates: []tls.Certificate{client.tlsCert}})
+	require.NoError(t, err)
+	err = mysql.RegisterTLSConfig("dolt-no-client-cert", &tls.Config{RootCAs: roots, ServerName: "localhost"})
+	require.NoError(t, err)
+
+	env := createEnvWithSeedData(t)
+	serverConfig := DefaultServerConfig().withLogLevel(LogLevel_Fatal).withPort(15310).withTLS(keyFile, certFile, caFile).withRequireSecureTransport(true)
+
+	sc := CreateServerController()
+	defer sc.StopServer()
+	go func() {
+		_, _ = Serve(context.Background(), "", serverConfig, sc, env)
+	}()
+	err = sc.WaitForStart()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		tlsParam  string
+		expectErr bool
+	}{
+		{"mutual tls", "?tls=dolt-mutual", false},
+		{"no client cert", "?tls=dolt-no-client-cert", true},
+		{"plaintext", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conn, err := dbr.Open("mysql", ConnectionString(serverConfig)+"dolt"+test.tlsParam, nil)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			err = conn.Ping()
+
+			if test.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// handshake performs a TLS handshake over an in memory connection and returns the client side of the connection.
+func handshake(t *testing.T, serverCfg, clientCfg *tls.Config) *tls.Conn {
+	serverConn, clientConn := net.Pipe()
+
+	go func() {
+		srv := tls.Server(serverConn, serverCfg)
+
+		if srv.Handshake() == nil {
+			// keep reading so that writes by the client don't block
+			_, _ = ioutil.ReadAll(srv)
+		}
+	}()
+
+	conn := tls.Client(clientConn, clientCfg)
+	require.NoError(t, conn.Handshake())
+
+	return conn
+}
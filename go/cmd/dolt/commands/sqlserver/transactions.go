@@ -0,0 +1,31 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"vitess.io/vitess/go/mysql"
+
+	dsqle "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
+)
+
+// transactionError returns the MySQL error for a transaction which conflicted with another, which tells clients that
+// the transaction can be retried. Other errors are returned unchanged.
+func transactionError(err error) error {
+	if err != nil && dsqle.ErrTransactionConflict.Is(err) {
+		return mysql.NewSQLError(mysql.ERLockDeadlock, mysql.SSLockDeadlock, "%s", err.Error())
+	}
+
+	return err
+}
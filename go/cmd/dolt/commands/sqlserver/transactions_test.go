@@ -0,0 +1,170 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerTransactionConflict(t *testing.T) {
+	ctx := context.Background()
+	serverConfig := DefaultServerConfig().withLogLevel(LogLevel_Fatal).withPort(15420)
+	sc := startTestServer(t, serverConfig)
+	defer sc.StopServer()
+
+	db, err := sql.Open("mysql", ConnectionString(serverConfig)+"dolt")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.ExecContext(ctx, "create table counters (id int primary key, n int)")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "insert into counters values (1, 0)")
+	require.NoError(t, err)
+
+	tx1, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	tx2, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+
+	_, err = tx1.ExecContext(ctx, "update counters set n = n + 1 where id = 1")
+	require.NoError(t, err)
+	_, err = tx2.ExecContext(ctx, "update counters set n = n + 1 where id = 1")
+	require.NoError(t, err)
+
+	// neither transaction sees the other's update before it commits
+	var n int
+	require.NoError(t, db.QueryRowContext(ctx, "select n from counters where id = 1").Scan(&n))
+	assert.Equal(t, 0, n)
+
+	require.NoError(t, tx1.Commit())
+	requireMySQLErr(t, tx2.Commit(), 1213)
+
+	require.NoError(t, db.QueryRowContext(ctx, "select n from counters where id = 1").Scan(&n))
+	assert.Equal(t, 1, n)
+
+	tx3, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	_, err = tx3.ExecContext(ctx, "update counters set n = 10 where id = 1")
+	require.NoError(t, err)
+	require.NoError(t, tx3.Rollback())
+
+	require.NoError(t, db.QueryRowContext(ctx, "select n from counters where id = 1").Scan(&n))
+	assert.Equal(t, 1, n)
+}
+
+// TestServerConcurrentTransactions runs transactions from many clients at once which each insert a row of their own and
+// increment a shared counter. The inserts never conflict and the increments always do, so the transactions which fail
+// with a serialization error are retried, and every committed transaction's writes must be kept.
+func TestServerConcurrentTransactions(t *testing.T) {
+	const clients = 8
+	const txsPerClient = 10
+
+	ctx := context.Background()
+	serverConfig := DefaultServerConfig().withLogLevel(LogLevel_Fatal).withPort(15421)
+	sc := startTestServer(t, serverConfig)
+	defer sc.StopServer()
+
+	db, err := sql.Open("mysql", ConnectionString(serverConfig)+"dolt")
+	require.NoError(t, err)
+	defer db.Close()
+	// keep the clients' connections open after they've finished rather than closing them as they're returned to the pool
+	db.SetMaxIdleConns(clients + 1)
+
+	for _, query := range []string{
+		"create table counters (id int primary key, n int)",
+		"create table events (id int primary key, client int)",
+		"insert into counters values (1, 0)",
+	} {
+		_, err = db.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+
+	var conflicts int64
+	runTx := func(conn *sql.Conn, client, i int) error {
+		tx, err := conn.BeginTx(ctx, nil)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("insert into events values (%d, %d)", client*txsPerClient+i, client))
+
+		if err == nil {
+			_, err = tx.ExecContext(ctx, "update counters set n = n + 1 where id = 1")
+		}
+
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		return tx.Commit()
+	}
+
+	wg := &sync.WaitGroup{}
+	errs := make(chan error, clients)
+	for client := 0; client < clients; client++ {
+		wg.Add(1)
+		go func(client int) {
+			defer wg.Done()
+
+			conn, err := db.Conn(ctx)
+
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			defer conn.Close()
+
+			for i := 0; i < txsPerClient; {
+				err := runTx(conn, client, i)
+
+				if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == 1213 {
+					atomic.AddInt64(&conflicts, 1)
+					continue
+				} else if err != nil {
+					errs <- err
+					return
+				}
+
+				i++
+			}
+		}(client)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	var n, events, distinctClients int
+	require.NoError(t, db.QueryRowContext(ctx, "select n from counters where id = 1").Scan(&n))
+	require.NoError(t, db.QueryRowContext(ctx, "select count(*), count(distinct client) from events").Scan(&events, &distinctClients))
+	assert.Equal(t, clients*txsPerClient, n, "%d transactions were retried", conflicts)
+	assert.Equal(t, clients*txsPerClient, events)
+	assert.Equal(t, clients, distinctClients)
+}
@@ -0,0 +1,82 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestServerTriggers(t *testing.T) {
+	ctx := context.Background()
+	dEnv := createEnvWithSeedData(t)
+
+	serverConfig := DefaultServerConfig().withLogLevel(LogLevel_Fatal).withPort(15335)
+	sc := startTestServerWithEnv(t, serverConfig, dEnv)
+
+	db, err := sql.Open("mysql", ConnectionString(serverConfig)+"dolt")
+	require.NoError(t, err)
+
+	for _, query := range []string{
+		"create table stamped (a int primary key, b varchar(20))",
+		"create trigger stamp before insert on stamped for each row set new.b = concat('row ', new.a)",
+		"insert into stamped (a) values (1)",
+	} {
+		_, err = db.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+
+	var name, event, table, statement, timing string
+	err = db.QueryRowContext(ctx, "show triggers").Scan(&name, &event, &table, &statement, &timing)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stamp", "INSERT", "stamped", "set new.b = concat('row ', new.a)", "BEFORE"},
+		[]string{name, event, table, statement, timing})
+
+	require.NoError(t, db.Close())
+	sc.StopServer()
+	require.NoError(t, sc.WaitForClose())
+
+	// the trigger is persisted, and fires after a restart
+	serverConfig = serverConfig.withPort(15336)
+	sc = startTestServerWithEnv(t, serverConfig, dEnv)
+	defer sc.StopServer()
+
+	db, err = sql.Open("mysql", ConnectionString(serverConfig)+"dolt")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.ExecContext(ctx, "insert into stamped (a) values (2)")
+	require.NoError(t, err)
+
+	var bs []string
+	rows, err := db.QueryContext(ctx, "select b from stamped order by a")
+	require.NoError(t, err)
+	for rows.Next() {
+		var b string
+		require.NoError(t, rows.Scan(&b))
+		bs = append(bs, b)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"row 1", "row 2"}, bs)
+
+	_, err = db.ExecContext(ctx, "drop trigger stamp")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "drop trigger stamp")
+	assert.Error(t, err)
+}
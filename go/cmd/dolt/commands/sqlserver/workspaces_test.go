@@ -0,0 +1,123 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions"
+	dsqle "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
+)
+
+func TestServerUserWorkspaces(t *testing.T) {
+	ctx := context.Background()
+	dEnv := createEnvWithSeedData(t)
+
+	defaultConfig := DefaultServerConfig().withLogLevel(LogLevel_Fatal).withPort(15440).withWorkspaces(dsqle.UserWorkspaces)
+	serverConfig := usersConfig{defaultConfig, []UserAccount{{Name: "alice"}, {Name: "bob"}}}
+	sc := startTestServerWithEnv(t, serverConfig, dEnv)
+	defer sc.StopServer()
+
+	// each connection gets its own pool, so that closing the pool closes the connection
+	openUserConn := func(user string) (*sql.DB, *sql.Conn) {
+		db, err := sql.Open("mysql", fmt.Sprintf("%s@tcp(%v:%v)/dolt", user, serverConfig.Host(), serverConfig.Port()))
+		require.NoError(t, err)
+		conn, err := openConn(ctx, db)
+		require.NoError(t, err)
+		return db, conn
+	}
+
+	aliceDB, alice := openUserConn("alice")
+	defer aliceDB.Close()
+	defer alice.Close()
+	bobDB, bob := openUserConn("bob")
+	defer bobDB.Close()
+	defer bob.Close()
+
+	_, err := alice.ExecContext(ctx, "create table t (pk int primary key)")
+	require.NoError(t, err)
+	_, err = alice.ExecContext(ctx, "insert into t values (1), (2)")
+	require.NoError(t, err)
+	_, err = bob.ExecContext(ctx, "create table u (pk int primary key)")
+	require.NoError(t, err)
+
+	// the changes of a user are seen by the user's other sessions, but not by other users
+	var count int
+	_, err = bob.ExecContext(ctx, "select * from t")
+	assert.Error(t, err)
+	alice2DB, alice2 := openUserConn("alice")
+	err = alice2.QueryRowContext(ctx, "select count(*) from t").Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	var changed string
+	var sessions int
+	err = bob.QueryRowContext(ctx, "select changed_tables, sessions from dolt_workspaces where name = 'alice'").Scan(&changed, &sessions)
+	require.NoError(t, err)
+	assert.Equal(t, "t", changed)
+	assert.Equal(t, 2, sessions)
+
+	// a workspace in use can't be deleted
+	_, err = bob.ExecContext(ctx, "delete from dolt_workspaces where name = 'alice'")
+	assert.Error(t, err)
+
+	// the changes of each user are committed to the branch, merged with the changes committed before them
+	var h string
+	err = alice.QueryRowContext(ctx, "select dolt_commit('add t')").Scan(&h)
+	require.NoError(t, err)
+	assert.NotEmpty(t, h)
+	err = bob.QueryRowContext(ctx, "select dolt_commit('add u')").Scan(&h)
+	require.NoError(t, err)
+	_, err = bob.ExecContext(ctx, "select dolt_commit('nothing')")
+	assert.Error(t, err)
+
+	cs, err := doltdb.NewCommitSpec("HEAD", "master")
+	require.NoError(t, err)
+	head, err := dEnv.DoltDB.Resolve(ctx, cs)
+	require.NoError(t, err)
+	root, err := head.GetRootValue()
+	require.NoError(t, err)
+	names, err := root.GetTableNames(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"t", "u"}, names)
+	meta, err := head.GetCommitMeta()
+	require.NoError(t, err)
+	assert.Equal(t, "add u", meta.Description)
+
+	// workspaces are kept after their sessions end, and can be deleted then
+	require.NoError(t, alice2.Close())
+	require.NoError(t, alice2DB.Close())
+	require.NoError(t, alice.Close())
+	require.NoError(t, aliceDB.Close())
+	require.Eventually(t, func() bool {
+		err = bob.QueryRowContext(ctx, "select sessions from dolt_workspaces where name = 'alice'").Scan(&sessions)
+		return err == nil && sessions == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	_, err = bob.ExecContext(ctx, "delete from dolt_workspaces where name = 'alice'")
+	require.NoError(t, err)
+
+	workspaces, err := actions.GetWorkspaces(ctx, dEnv.DoltDB)
+	require.NoError(t, err)
+	require.Len(t, workspaces, 1)
+	assert.Equal(t, "bob", workspaces[0].Ref.GetPath())
+}
@@ -18,24 +18,42 @@ import (
 	"gopkg.in/yaml.v2"
 
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	dsqle "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
 )
 
 // BehaviorYAMLConfig contains server configuration regarding how the server should behave
 type BehaviorYAMLConfig struct {
 	ReadOnly   *bool `yaml:"read_only"`
 	AutoCommit *bool
+	// DrainTimeoutMillis is the time running queries are given to complete when the server shuts down.
+	DrainTimeoutMillis *uint64 `yaml:"drain_timeout_millis"`
+	// SlowQueryThresholdMillis is the time beyond which queries are logged as slow queries.
+	SlowQueryThresholdMillis *uint64 `yaml:"slow_query_threshold_millis"`
+	// BackgroundIOBytesPerSec is the budget of bytes per second of the IO of background work, such as conjoins.
+	BackgroundIOBytesPerSec *uint64 `yaml:"background_io_bytes_per_sec"`
+	// BackgroundPauseLatencyMillis is the latency of a query beyond which the IO of background work is paused.
+	BackgroundPauseLatencyMillis *uint64 `yaml:"background_pause_latency_millis"`
+	// Workspaces is "none", "session" or "user", and defines whether sessions get a private workspace.
+	Workspaces *string `yaml:"workspaces"`
 }
 
 // UserYAMLConfig contains server configuration regarding the user account clients must use to connect
 type UserYAMLConfig struct {
 	Name     *string
 	Password *string
+	// Roles are the roles of one of the additional users, which permission policies can be granted to.
+	Roles []string `yaml:"roles,omitempty"`
 }
 
 // DatabaseYAMLConfig contains information on a database that this server will provide access to
 type DatabaseYAMLConfig struct {
 	Name string
 	Path string
+	// Remote is the url of a remote the database is cloned from, into Path, when the server starts.
+	Remote string `yaml:"remote,omitempty"`
+	// Branch is the branch cloned from Remote.
+	Branch string `yaml:"branch,omitempty"`
 }
 
 // ListenerYAMLConfig contains information on the network connection that the server will open
@@ -45,6 +63,20 @@ type ListenerYAMLConfig struct {
 	MaxConnections     *uint64 `yaml:"max_connections"`
 	ReadTimeoutMillis  *uint64 `yaml:"read_timeout_millis"`
 	WriteTimeoutMillis *uint64 `yaml:"write_timeout_millis"`
+	// MaxUserConnections is the maximum number of simultaneous connections allowed for a single user.
+	MaxUserConnections *uint64 `yaml:"max_user_connections"`
+	// IdleTimeoutMillis is the time after which a connection which has not sent a query is closed.
+	IdleTimeoutMillis *uint64 `yaml:"idle_timeout_millis"`
+	// TLSKey is a file system path to an unencrypted private TLS key in PEM format.
+	TLSKey *string `yaml:"tls_key"`
+	// TLSCert is a file system path to a TLS certificate chain in PEM format.
+	TLSCert *string `yaml:"tls_cert"`
+	// TLSCA is a file system path to a bundle of CA certificates in PEM format used to verify client certificates.
+	TLSCA *string `yaml:"tls_ca"`
+	// RequireSecureTransport can enable a mode where non-TLS connections are turned away.
+	RequireSecureTransport *bool `yaml:"require_secure_transport"`
+	// ArrowPortNumber is the port of the HTTP endpoint which streams the results of queries as Arrow record batches.
+	ArrowPortNumber *int `yaml:"arrow_port"`
 }
 
 // YAMLConfig is a ServerConfig implementation which is read from a yaml file
@@ -52,6 +84,7 @@ type YAMLConfig struct {
 	LogLevelStr    *string              `yaml:"log_level"`
 	BehaviorConfig BehaviorYAMLConfig   `yaml:"behavior"`
 	UserConfig     UserYAMLConfig       `yaml:"user"`
+	UsersConfig    []UserYAMLConfig     `yaml:"users"`
 	ListenerConfig ListenerYAMLConfig   `yaml:"listener"`
 	DatabaseConfig []DatabaseYAMLConfig `yaml:"databases"`
 }
@@ -85,6 +118,15 @@ func (cfg YAMLConfig) Port() int {
 	return *cfg.ListenerConfig.PortNumber
 }
 
+// ArrowPort returns the port of the Arrow HTTP endpoint, or 0 if it isn't served.
+func (cfg YAMLConfig) ArrowPort() int {
+	if cfg.ListenerConfig.ArrowPortNumber == nil {
+		return defaultArrowPort
+	}
+
+	return *cfg.ListenerConfig.ArrowPortNumber
+}
+
 // ReadTimeout returns the read timeout in milliseconds.
 func (cfg YAMLConfig) ReadTimeout() uint64 {
 	if cfg.ListenerConfig.ReadTimeoutMillis == nil {
@@ -121,6 +163,24 @@ func (cfg YAMLConfig) Password() string {
 	return *cfg.UserConfig.Password
 }
 
+// Users returns the accounts of the additional users, which are limited by row policies and permission policies.
+func (cfg YAMLConfig) Users() []UserAccount {
+	var accounts []UserAccount
+	for _, userConfig := range cfg.UsersConfig {
+		var acc UserAccount
+		if userConfig.Name != nil {
+			acc.Name = *userConfig.Name
+		}
+		if userConfig.Password != nil {
+			acc.Password = *userConfig.Password
+		}
+		acc.Roles = userConfig.Roles
+		accounts = append(accounts, acc)
+	}
+
+	return accounts
+}
+
 // ReadOnly returns whether the server will only accept read statements or all statements.
 func (cfg YAMLConfig) ReadOnly() bool {
 	if cfg.BehaviorConfig.ReadOnly == nil {
@@ -160,7 +220,19 @@ func (cfg YAMLConfig) DatabaseNamesAndPaths() []env.EnvNameAndPath {
 	return dbNamesAndPaths
 }
 
-// MaxConnections returns the maximum number of simultaneous connections the server will allow.  The default is 1
+// ClonedDatabases returns the databases with a remote, which are cloned from it before the server accepts connections.
+func (cfg YAMLConfig) ClonedDatabases() []ClonedDatabase {
+	var dbs []ClonedDatabase
+	for _, dbConfig := range cfg.DatabaseConfig {
+		if dbConfig.Remote != "" {
+			dbs = append(dbs, ClonedDatabase{Name: dbConfig.Name, Path: dbConfig.Path, RemoteUrl: dbConfig.Remote, Branch: dbConfig.Branch})
+		}
+	}
+
+	return dbs
+}
+
+// MaxConnections returns the maximum number of simultaneous connections the server will allow.  The default is 100
 func (cfg YAMLConfig) MaxConnections() uint64 {
 	if cfg.ListenerConfig.MaxConnections == nil {
 		return defaultMaxConnections
@@ -168,3 +240,117 @@ func (cfg YAMLConfig) MaxConnections() uint64 {
 
 	return *cfg.ListenerConfig.MaxConnections
 }
+
+// MaxUserConnections returns the maximum number of simultaneous connections the server will allow for a single user.
+func (cfg YAMLConfig) MaxUserConnections() uint64 {
+	if cfg.ListenerConfig.MaxUserConnections == nil {
+		return defaultMaxUserConns
+	}
+
+	return *cfg.ListenerConfig.MaxUserConnections
+}
+
+// IdleTimeout returns the time in milliseconds after which a connection which hasn't sent a query is closed.
+func (cfg YAMLConfig) IdleTimeout() uint64 {
+	if cfg.ListenerConfig.IdleTimeoutMillis == nil {
+		return defaultIdleTimeout
+	}
+
+	return *cfg.ListenerConfig.IdleTimeoutMillis
+}
+
+// TLSKey returns a path to the server's PEM-encoded private TLS key. "" if there is none.
+func (cfg YAMLConfig) TLSKey() string {
+	if cfg.ListenerConfig.TLSKey == nil {
+		return ""
+	}
+
+	return *cfg.ListenerConfig.TLSKey
+}
+
+// TLSCert returns a path to the server's PEM-encoded TLS certificate chain. "" if there is none.
+func (cfg YAMLConfig) TLSCert() string {
+	if cfg.ListenerConfig.TLSCert == nil {
+		return ""
+	}
+
+	return *cfg.ListenerConfig.TLSCert
+}
+
+// TLSCA returns a path to a PEM-encoded bundle of CA certificates used to verify client certificates. "" if there is
+// none.
+func (cfg YAMLConfig) TLSCA() string {
+	if cfg.ListenerConfig.TLSCA == nil {
+		return ""
+	}
+
+	return *cfg.ListenerConfig.TLSCA
+}
+
+// RequireSecureTransport is true if the server should reject connections which do not use TLS.
+func (cfg YAMLConfig) RequireSecureTransport() bool {
+	if cfg.ListenerConfig.RequireSecureTransport == nil {
+		return false
+	}
+
+	return *cfg.ListenerConfig.RequireSecureTransport
+}
+
+// DrainTimeout returns the time in milliseconds that running queries are given to complete when the server shuts down.
+func (cfg YAMLConfig) DrainTimeout() uint64 {
+	if cfg.BehaviorConfig.DrainTimeoutMillis == nil {
+		return defaultDrainTimeout
+	}
+
+	return *cfg.BehaviorConfig.DrainTimeoutMillis
+}
+
+// SlowQueryThreshold returns the time in milliseconds beyond which a query is logged as a slow query.
+func (cfg YAMLConfig) SlowQueryThreshold() uint64 {
+	if cfg.BehaviorConfig.SlowQueryThresholdMillis == nil {
+		return defaultSlowQuery
+	}
+
+	return *cfg.BehaviorConfig.SlowQueryThresholdMillis
+}
+
+// BackgroundIOLimit returns the budget of bytes per second of the IO of background work.
+func (cfg YAMLConfig) BackgroundIOLimit() uint64 {
+	if cfg.BehaviorConfig.BackgroundIOBytesPerSec == nil {
+		return defaultBackgroundIO
+	}
+
+	return *cfg.BehaviorConfig.BackgroundIOBytesPerSec
+}
+
+// BackgroundPauseLatency returns the time in milliseconds beyond which a query pauses the IO of background work.
+func (cfg YAMLConfig) BackgroundPauseLatency() uint64 {
+	if cfg.BehaviorConfig.BackgroundPauseLatencyMillis == nil {
+		return defaultPauseLatency
+	}
+
+	return *cfg.BehaviorConfig.BackgroundPauseLatencyMillis
+}
+
+// Workspaces returns whether sessions get a private workspace, and whether it is shared by the sessions of a user.
+func (cfg YAMLConfig) Workspaces() dsqle.WorkspaceMode {
+	if cfg.BehaviorConfig.Workspaces == nil {
+		return defaultWorkspaces
+	}
+
+	return dsqle.WorkspaceMode(*cfg.BehaviorConfig.Workspaces)
+}
+
+// yamlConfigFile is a YAMLConfig which was read from a file, and which can be reloaded from that file.
+type yamlConfigFile struct {
+	YAMLConfig
+	fs   filesys.Filesys
+	path string
+}
+
+var _ ReloadableServerConfig = yamlConfigFile{}
+
+// Reload reads the config file again.
+func (cfg yamlConfigFile) Reload() (ServerConfig, error) {
+	return getYAMLServerConfig(cfg.fs, cfg.path)
+}
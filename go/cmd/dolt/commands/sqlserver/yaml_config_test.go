@@ -21,6 +21,8 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v2"
+
+	dsqle "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
 )
 
 func strPtr(s string) *string {
@@ -46,41 +48,74 @@ log_level: debug
 behavior:
     read_only: false
     autocommit: true
+    drain_timeout_millis: 10000
+    slow_query_threshold_millis: 500
+    workspaces: user
 
 user:
     name: root
     password: 1234
 
+users:
+    - name: alice
+      password: secret
+    - name: bob
+
 listener:
     host: 0.0.0.0
     port: 3306
     max_connections: 100
     read_timeout_millis: 0
     write_timeout_millis: 0
+    max_user_connections: 10
+    idle_timeout_millis: 60000
+    tls_key: ./key.pem
+    tls_cert: ./cert.pem
+    tls_ca: ./ca.pem
+    require_secure_transport: true
+    arrow_port: 8080
     
 databases:
     - name: irs_soi
       path: ./datasets/irs-soi
     - name: noaa
       path: /Users/brian/datasets/noaa
+    - name: nba
+      path: ./datasets/nba
+      remote: https://doltremoteapi.dolthub.com/dolthub/nba
+      branch: release
 `
 
 	expected := YAMLConfig{
 		LogLevelStr: strPtr("debug"),
 		BehaviorConfig: BehaviorYAMLConfig{
-			ReadOnly:   boolPtr(false),
-			AutoCommit: boolPtr(true),
+			ReadOnly:                 boolPtr(false),
+			AutoCommit:               boolPtr(true),
+			DrainTimeoutMillis:       uint64Ptr(10000),
+			SlowQueryThresholdMillis: uint64Ptr(500),
+			Workspaces:               strPtr("user"),
 		},
 		UserConfig: UserYAMLConfig{
 			Name:     strPtr("root"),
 			Password: strPtr("1234"),
 		},
+		UsersConfig: []UserYAMLConfig{
+			{Name: strPtr("alice"), Password: strPtr("secret")},
+			{Name: strPtr("bob")},
+		},
 		ListenerConfig: ListenerYAMLConfig{
-			HostStr:            strPtr("0.0.0.0"),
-			PortNumber:         intPtr(3306),
-			MaxConnections:     uint64Ptr(100),
-			ReadTimeoutMillis:  uint64Ptr(0),
-			WriteTimeoutMillis: uint64Ptr(0),
+			HostStr:                strPtr("0.0.0.0"),
+			PortNumber:             intPtr(3306),
+			MaxConnections:         uint64Ptr(100),
+			ReadTimeoutMillis:      uint64Ptr(0),
+			WriteTimeoutMillis:     uint64Ptr(0),
+			MaxUserConnections:     uint64Ptr(10),
+			IdleTimeoutMillis:      uint64Ptr(60000),
+			TLSKey:                 strPtr("./key.pem"),
+			TLSCert:                strPtr("./cert.pem"),
+			TLSCA:                  strPtr("./ca.pem"),
+			RequireSecureTransport: boolPtr(true),
+			ArrowPortNumber:        intPtr(8080),
 		},
 		DatabaseConfig: []DatabaseYAMLConfig{
 			{
@@ -91,6 +126,12 @@ databases:
 				Name: "noaa",
 				Path: "/Users/brian/datasets/noaa",
 			},
+			{
+				Name:   "nba",
+				Path:   "./datasets/nba",
+				Remote: "https://doltremoteapi.dolthub.com/dolthub/nba",
+				Branch: "release",
+			},
 		},
 	}
 
@@ -98,6 +139,8 @@ databases:
 	err := yaml.Unmarshal([]byte(testStr), &config)
 	require.NoError(t, err)
 	assert.Equal(t, expected, config)
+	assert.Equal(t, []UserAccount{{Name: "alice", Password: "secret"}, {Name: "bob"}}, config.Users())
+	assert.Equal(t, []ClonedDatabase{{Name: "nba", Path: "./datasets/nba", RemoteUrl: "https://doltremoteapi.dolthub.com/dolthub/nba", Branch: "release"}}, config.ClonedDatabases())
 }
 
 func TestYAMLConfigDefaults(t *testing.T) {
@@ -107,12 +150,25 @@ func TestYAMLConfigDefaults(t *testing.T) {
 
 	assert.Equal(t, defaultHost, cfg.Host())
 	assert.Equal(t, defaultPort, cfg.Port())
+	assert.Equal(t, defaultArrowPort, cfg.ArrowPort())
 	assert.Equal(t, defaultUser, cfg.User())
 	assert.Equal(t, defaultPass, cfg.Password())
+	assert.Empty(t, cfg.Users())
 	assert.Equal(t, uint64(defaultTimeout), cfg.WriteTimeout())
 	assert.Equal(t, uint64(defaultTimeout), cfg.ReadTimeout())
 	assert.Equal(t, defaultReadOnly, cfg.ReadOnly())
 	assert.Equal(t, defaultLogLevel, cfg.LogLevel())
 	assert.Equal(t, defaultAutoCommit, cfg.AutoCommit())
 	assert.Equal(t, uint64(defaultMaxConnections), cfg.MaxConnections())
+	assert.Equal(t, uint64(defaultMaxUserConns), cfg.MaxUserConnections())
+	assert.Equal(t, uint64(defaultIdleTimeout), cfg.IdleTimeout())
+	assert.Equal(t, uint64(defaultDrainTimeout), cfg.DrainTimeout())
+	assert.Equal(t, uint64(defaultSlowQuery), cfg.SlowQueryThreshold())
+	assert.Equal(t, uint64(defaultBackgroundIO), cfg.BackgroundIOLimit())
+	assert.Equal(t, uint64(defaultPauseLatency), cfg.BackgroundPauseLatency())
+	assert.Equal(t, dsqle.NoWorkspaces, cfg.Workspaces())
+	assert.Equal(t, "", cfg.TLSKey())
+	assert.Equal(t, "", cfg.TLSCert())
+	assert.Equal(t, "", cfg.TLSCA())
+	assert.False(t, cfg.RequireSecureTransport())
 }
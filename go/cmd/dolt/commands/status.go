@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 
 	"github.com/fatih/color"
@@ -33,12 +34,17 @@ import (
 	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/iohelp"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/set"
+	"github.com/liquidata-inc/dolt/go/store/types"
 )
 
 var statusDocs = cli.CommandDocumentationContent{
 	ShortDesc: "Show the working status",
-	LongDesc:  `Displays working tables that differ from the current HEAD commit, tables that differ from the staged tables, and tables that are in the working tree that are not tracked by dolt. The first are what you would commit by running {{.EmphasisLeft}}dolt commit{{.GreaterThan}}; the second and third are what you could commit by running {{.EmphasisLeft}}dolt add .{{.GreaterThan}} before running {{.EmphasisLeft}}dolt commit{{.GreaterThan}}.`,
-	Synopsis:  []string{""},
+	LongDesc: `Displays working tables that differ from the current HEAD commit, tables that differ from the staged tables, and tables that are in the working tree that are not tracked by dolt. The first are what you would commit by running {{.EmphasisLeft}}dolt commit{{.GreaterThan}}; the second and third are what you could commit by running {{.EmphasisLeft}}dolt add .{{.GreaterThan}} before running {{.EmphasisLeft}}dolt commit{{.GreaterThan}}.
+
+New tables whose names match the patterns of the {{.EmphasisLeft}}dolt_ignore{{.EmphasisRight}} table are ignored, and are only listed with {{.EmphasisLeft}}--ignored{{.EmphasisRight}}.
+
+Tables are compared by the hashes of their contents, so status never reads any rows and takes the same time however many rows have changed. Use {{.EmphasisLeft}}--show-counts{{.EmphasisRight}} to also print how many rows were added, modified and deleted in each changed table, which requires reading every changed row.`,
+	Synopsis: []string{"[--show-counts] [--ignored]"},
 }
 
 type StatusCmd struct{}
@@ -59,8 +65,16 @@ func (cmd StatusCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string)
 	return CreateMarkdown(fs, path, cli.GetCommandDocumentation(commandStr, statusDocs, ap))
 }
 
+const (
+	showCountsFlag = "show-counts"
+	ignoredFlag    = "ignored"
+)
+
 func (cmd StatusCmd) createArgParser() *argparser.ArgParser {
 	ap := argparser.NewArgParser()
+	SupportsIncludeSparse(ap)
+	ap.SupportsFlag(showCountsFlag, "", "Print the number of rows added, modified and deleted in each changed table. This reads every changed row, and can be slow when many rows have changed.")
+	ap.SupportsFlag(ignoredFlag, "", "Also list the new tables which are ignored by the patterns of the dolt_ignore table.")
 	return ap
 }
 
@@ -68,7 +82,7 @@ func (cmd StatusCmd) createArgParser() *argparser.ArgParser {
 func (cmd StatusCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
 	ap := cmd.createArgParser()
 	help, _ := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, statusDocs, ap))
-	cli.ParseArgs(ap, args, help)
+	apr := cli.ParseArgs(ap, args, help)
 
 	stagedTblDiffs, notStagedTblDiffs, err := diff.GetTableDiffs(ctx, dEnv)
 
@@ -76,6 +90,24 @@ func (cmd StatusCmd) Exec(ctx context.Context, commandStr string, args []string,
 		cli.PrintErrln(toStatusVErr((err)))
 		return 1
 	}
+
+	stagedTblDiffs, stagedHidden := activeTableDiffs(dEnv, stagedTblDiffs, apr.Contains(IncludeSparseFlag))
+	notStagedTblDiffs, notStagedHidden := activeTableDiffs(dEnv, notStagedTblDiffs, apr.Contains(IncludeSparseFlag))
+	hiddenTbls := set.NewStrSet(stagedHidden)
+	hiddenTbls.Add(notStagedHidden...)
+	hidden := hiddenTbls.AsSlice()
+	sort.Strings(hidden)
+	notStagedTblDiffs, ignored, err := removeIgnoredTableDiffs(ctx, dEnv, notStagedTblDiffs)
+
+	if err != nil {
+		cli.PrintErrln(toStatusVErr((err)))
+		return 1
+	}
+
+	if !apr.Contains(ignoredFlag) {
+		ignored = nil
+	}
+
 	workingTblsInConflict, _, _, err := merge.GetTablesInConflict(ctx, dEnv)
 
 	if err != nil {
@@ -97,7 +129,17 @@ func (cmd StatusCmd) Exec(ctx context.Context, commandStr string, args []string,
 		return 1
 	}
 
-	printStatus(ctx, dEnv, stagedTblDiffs, notStagedTblDiffs, workingTblsInConflict, workingDocsInConflict, stagedDocDiffs, notStagedDocDiffs)
+	var stagedCounts, notStagedCounts map[string]string
+	if apr.Contains(showCountsFlag) {
+		stagedCounts, notStagedCounts, err = statusRowCounts(ctx, dEnv, stagedTblDiffs, notStagedTblDiffs)
+
+		if err != nil {
+			cli.PrintErrln(errhand.BuildDError("error: failed to count changed rows").AddCause(err).Build().Verbose())
+			return 1
+		}
+	}
+
+	printStatus(ctx, dEnv, stagedTblDiffs, notStagedTblDiffs, workingTblsInConflict, workingDocsInConflict, stagedDocDiffs, notStagedDocDiffs, hidden, ignored, stagedCounts, notStagedCounts)
 	return 0
 }
 
@@ -149,11 +191,14 @@ const (
 	untrackedHeader     = `Untracked files:`
 	untrackedHeaderHelp = `  (use "dolt add <table|doc>" to include in what will be committed)`
 
+	ignoredHeader     = `Ignored tables:`
+	ignoredHeaderHelp = `  (use "dolt add <table>" to include in what will be committed)`
+
 	statusFmt         = "\t%-16s%s"
 	bothModifiedLabel = "both modified:"
 )
 
-func printStagedDiffs(wr io.Writer, stagedTbls *diff.TableDiffs, stagedDocs *diff.DocDiffs, printHelp bool) int {
+func printStagedDiffs(wr io.Writer, stagedTbls *diff.TableDiffs, stagedDocs *diff.DocDiffs, printHelp bool, rowCounts map[string]string) int {
 	if stagedTbls.Len()+stagedDocs.Len() > 0 {
 		iohelp.WriteLine(wr, stagedHeader)
 
@@ -165,7 +210,7 @@ func printStagedDiffs(wr io.Writer, stagedTbls *diff.TableDiffs, stagedDocs *dif
 		for _, tblName := range stagedTbls.Tables {
 			if !doltdb.IsSystemTable(tblName) {
 				tdt := stagedTbls.TableToType[tblName]
-				lines = append(lines, fmt.Sprintf(statusFmt, tblDiffTypeToLabel[tdt], tblName))
+				lines = append(lines, fmt.Sprintf(statusFmt, tblDiffTypeToLabel[tdt], tblName)+rowCounts[tblName])
 			}
 		}
 
@@ -180,7 +225,7 @@ func printStagedDiffs(wr io.Writer, stagedTbls *diff.TableDiffs, stagedDocs *dif
 	return 0
 }
 
-func printDiffsNotStaged(ctx context.Context, dEnv *env.DoltEnv, wr io.Writer, notStagedTbls *diff.TableDiffs, notStagedDocs *diff.DocDiffs, printHelp bool, linesPrinted int, workingTblsInConflict []string) int {
+func printDiffsNotStaged(ctx context.Context, dEnv *env.DoltEnv, wr io.Writer, notStagedTbls *diff.TableDiffs, notStagedDocs *diff.DocDiffs, printHelp bool, linesPrinted int, workingTblsInConflict []string, rowCounts map[string]string) int {
 	inCnfSet := set.NewStrSet(workingTblsInConflict)
 
 	if len(workingTblsInConflict) > 0 {
@@ -220,7 +265,7 @@ func printDiffsNotStaged(ctx context.Context, dEnv *env.DoltEnv, wr io.Writer, n
 				iohelp.WriteLine(wr, workingHeaderHelp)
 			}
 
-			lines := getModifiedAndRemovedNotStaged(notStagedTbls, notStagedDocs, inCnfSet)
+			lines := getModifiedAndRemovedNotStaged(notStagedTbls, notStagedDocs, inCnfSet, rowCounts)
 
 			iohelp.WriteLine(wr, color.RedString(strings.Join(lines, "\n")))
 			linesPrinted += len(lines)
@@ -242,7 +287,7 @@ func printDiffsNotStaged(ctx context.Context, dEnv *env.DoltEnv, wr io.Writer, n
 				iohelp.WriteLine(wr, untrackedHeaderHelp)
 			}
 
-			lines := getAddedNotStaged(notStagedTbls, notStagedDocs)
+			lines := getAddedNotStaged(notStagedTbls, notStagedDocs, rowCounts)
 
 			iohelp.WriteLine(wr, color.RedString(strings.Join(lines, "\n")))
 			linesPrinted += len(lines)
@@ -254,13 +299,13 @@ func printDiffsNotStaged(ctx context.Context, dEnv *env.DoltEnv, wr io.Writer, n
 	return linesPrinted
 }
 
-func getModifiedAndRemovedNotStaged(notStagedTbls *diff.TableDiffs, notStagedDocs *diff.DocDiffs, inCnfSet *set.StrSet) (lines []string) {
+func getModifiedAndRemovedNotStaged(notStagedTbls *diff.TableDiffs, notStagedDocs *diff.DocDiffs, inCnfSet *set.StrSet, rowCounts map[string]string) (lines []string) {
 	lines = make([]string, 0, notStagedTbls.Len()+notStagedDocs.Len())
 	for _, tblName := range notStagedTbls.Tables {
 		tdt := notStagedTbls.TableToType[tblName]
 
 		if tdt != diff.AddedTable && !inCnfSet.Contains(tblName) && tblName != doltdb.DocTableName {
-			lines = append(lines, fmt.Sprintf(statusFmt, tblDiffTypeToLabel[tdt], tblName))
+			lines = append(lines, fmt.Sprintf(statusFmt, tblDiffTypeToLabel[tdt], tblName)+rowCounts[tblName])
 		}
 	}
 
@@ -276,13 +321,13 @@ func getModifiedAndRemovedNotStaged(notStagedTbls *diff.TableDiffs, notStagedDoc
 	return lines
 }
 
-func getAddedNotStaged(notStagedTbls *diff.TableDiffs, notStagedDocs *diff.DocDiffs) (lines []string) {
+func getAddedNotStaged(notStagedTbls *diff.TableDiffs, notStagedDocs *diff.DocDiffs, rowCounts map[string]string) (lines []string) {
 	lines = make([]string, 0, notStagedTbls.Len()+notStagedDocs.Len())
 	for _, tblName := range notStagedTbls.Tables {
 		tdt := notStagedTbls.TableToType[tblName]
 
 		if tdt == diff.AddedTable {
-			lines = append(lines, fmt.Sprintf(statusFmt, tblDiffTypeToLabel[tdt], tblName))
+			lines = append(lines, fmt.Sprintf(statusFmt, tblDiffTypeToLabel[tdt], tblName)+rowCounts[tblName])
 		}
 	}
 
@@ -297,7 +342,52 @@ func getAddedNotStaged(notStagedTbls *diff.TableDiffs, notStagedDocs *diff.DocDi
 	return lines
 }
 
-func printStatus(ctx context.Context, dEnv *env.DoltEnv, stagedTbls, notStagedTbls *diff.TableDiffs, workingTblsInConflict []string, workingDocsInConflict *diff.DocDiffs, stagedDocs, notStagedDocs *diff.DocDiffs) {
+// removeIgnoredTableDiffs returns the unstaged table diffs given without the new tables which are ignored by the
+// dolt_ignore table of the working root, along with the names of the ignored tables.
+func removeIgnoredTableDiffs(ctx context.Context, dEnv *env.DoltEnv, notStagedTbls *diff.TableDiffs) (*diff.TableDiffs, []string, error) {
+	working, err := dEnv.WorkingRoot(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ips, err := doltdb.GetIgnorePatterns(ctx, working)
+
+	if err != nil || len(ips) == 0 {
+		return notStagedTbls, nil, err
+	}
+
+	var ignored []string
+	kept := notStagedTbls.Filter(func(tblName string) bool {
+		if notStagedTbls.TableToType[tblName] == diff.AddedTable && ips.IsTableNameIgnored(tblName) {
+			ignored = append(ignored, tblName)
+			return false
+		}
+
+		return true
+	})
+
+	return kept, ignored, nil
+}
+
+func printIgnoredTables(wr io.Writer, ignored []string, linesPrinted int) int {
+	if linesPrinted > 0 {
+		cli.Println()
+	}
+
+	iohelp.WriteLine(wr, ignoredHeader)
+	iohelp.WriteLine(wr, ignoredHeaderHelp)
+
+	lines := make([]string, 0, len(ignored))
+	for _, tblName := range ignored {
+		lines = append(lines, fmt.Sprintf(statusFmt, tblDiffTypeToLabel[diff.AddedTable], tblName))
+	}
+
+	iohelp.WriteLine(wr, color.RedString(strings.Join(lines, "\n")))
+	return linesPrinted + len(lines)
+}
+
+func printStatus(ctx context.Context, dEnv *env.DoltEnv, stagedTbls, notStagedTbls *diff.TableDiffs, workingTblsInConflict []string, workingDocsInConflict *diff.DocDiffs, stagedDocs, notStagedDocs *diff.DocDiffs, hiddenTbls, ignoredTbls []string, stagedCounts, notStagedCounts map[string]string) {
 	cli.Printf(branchHeader, dEnv.RepoState.CWBHeadRef().GetPath())
 
 	if dEnv.RepoState.Merge != nil {
@@ -308,14 +398,123 @@ func printStatus(ctx context.Context, dEnv *env.DoltEnv, stagedTbls, notStagedTb
 		}
 	}
 
-	n := printStagedDiffs(cli.CliOut, stagedTbls, stagedDocs, true)
-	n = printDiffsNotStaged(ctx, dEnv, cli.CliOut, notStagedTbls, notStagedDocs, true, n, workingTblsInConflict)
+	n := printStagedDiffs(cli.CliOut, stagedTbls, stagedDocs, true, stagedCounts)
+	n = printDiffsNotStaged(ctx, dEnv, cli.CliOut, notStagedTbls, notStagedDocs, true, n, workingTblsInConflict, notStagedCounts)
+
+	if len(ignoredTbls) > 0 {
+		n = printIgnoredTables(cli.CliOut, ignoredTbls, n+stagedTbls.Len()+stagedDocs.Len())
+	}
 
-	if dEnv.RepoState.Merge == nil && n == 0 {
+	if dEnv.RepoState.Merge == nil && n == 0 && len(hiddenTbls) == 0 {
 		cli.Println("nothing to commit, working tree clean")
+	} else if dEnv.RepoState.Merge == nil && n == 0 && stagedTbls.Len()+stagedDocs.Len() == 0 {
+		cli.Println("nothing to commit in the sparse working set")
+	}
+
+	if len(hiddenTbls) > 0 {
+		cli.Println()
+		printSparseHiddenTables(cli.CliOut, hiddenTbls)
 	}
 }
 
+// statusRowCounts returns descriptions of the number of rows changed in each of the staged and unstaged tables, keyed
+// by table name, to be appended to the lines of the status output.
+func statusRowCounts(ctx context.Context, dEnv *env.DoltEnv, stagedTbls, notStagedTbls *diff.TableDiffs) (staged, notStaged map[string]string, err error) {
+	headRoot, err := dEnv.HeadRoot(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stagedRoot, err := dEnv.StagedRoot(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	workingRoot, err := dEnv.WorkingRoot(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	staged, err = tableRowCounts(ctx, stagedRoot, headRoot, stagedTbls)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	notStaged, err = tableRowCounts(ctx, workingRoot, stagedRoot, notStagedTbls)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return staged, notStaged, nil
+}
+
+func tableRowCounts(ctx context.Context, newer, older *doltdb.RootValue, tblDiffs *diff.TableDiffs) (map[string]string, error) {
+	counts := make(map[string]string, tblDiffs.Len())
+	for _, tblName := range tblDiffs.Tables {
+		if tblName == doltdb.DocTableName {
+			continue
+		}
+
+		newRows, err := tableRowsOrEmpty(ctx, newer, tblName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		oldRows, err := tableRowsOrEmpty(ctx, older, tblName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch tblDiffs.TableToType[tblName] {
+		case diff.AddedTable:
+			counts[tblName] = numRowsStr(newRows.Len())
+
+		case diff.RemovedTable:
+			counts[tblName] = numRowsStr(oldRows.Len())
+
+		default:
+			summary, err := diff.SummarizeRowChanges(ctx, newRows, oldRows)
+
+			if err != nil {
+				return nil, err
+			}
+
+			counts[tblName] = fmt.Sprintf(" (%d added, %d modified, %d deleted)", summary.Adds, summary.Changes, summary.Removes)
+		}
+	}
+
+	return counts, nil
+}
+
+func numRowsStr(n uint64) string {
+	if n == 1 {
+		return " (1 row)"
+	}
+
+	return fmt.Sprintf(" (%d rows)", n)
+}
+
+func tableRowsOrEmpty(ctx context.Context, root *doltdb.RootValue, tblName string) (types.Map, error) {
+	tbl, ok, err := root.GetTable(ctx, tblName)
+
+	if err != nil {
+		return types.EmptyMap, err
+	}
+
+	if !ok {
+		return types.NewMap(ctx, root.VRW())
+	}
+
+	return tbl.GetRowData(ctx)
+}
+
 func toStatusVErr(err error) errhand.VerboseError {
 	switch {
 	case actions.IsRootValUnreachable(err):
@@ -0,0 +1,180 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+var tagDocs = cli.CommandDocumentationContent{
+	ShortDesc: `List, create, or delete tags`,
+	LongDesc: `If {{.EmphasisLeft}}--list{{.EmphasisRight}} is given, or if there are no non-option arguments, existing tags are listed.
+
+The command's second form creates a new tag named {{.LessThan}}tagname{{.GreaterThan}} which points to the current {{.EmphasisLeft}}HEAD{{.EmphasisRight}}, or {{.LessThan}}commit{{.GreaterThan}} if given. Unlike a branch, a tag keeps pointing at the same commit. With {{.EmphasisLeft}}-f{{.EmphasisRight}} an existing tag is moved to the new commit.
+
+Tags can be used anywhere a branch name can, and are sent to and from remotes by {{.EmphasisLeft}}dolt push{{.EmphasisRight}}, {{.EmphasisLeft}}dolt fetch{{.EmphasisRight}} and {{.EmphasisLeft}}dolt clone{{.EmphasisRight}}.
+
+With a {{.EmphasisLeft}}-d{{.EmphasisRight}}, {{.LessThan}}tagname{{.GreaterThan}} will be deleted. To delete a tag from a remote use {{.EmphasisLeft}}dolt push <remote> :refs/tags/<tagname>{{.EmphasisRight}}.`,
+	Synopsis: []string{
+		`[-l] [-v]`,
+		`[-f] {{.LessThan}}tagname{{.GreaterThan}} [{{.LessThan}}commit{{.GreaterThan}}]`,
+		`-d {{.LessThan}}tagname{{.GreaterThan}}`,
+	},
+}
+
+type TagCmd struct{}
+
+// Name is returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd TagCmd) Name() string {
+	return "tag"
+}
+
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd TagCmd) LocksRepo() bool {
+	return true
+}
+
+// Description returns a description of the command
+func (cmd TagCmd) Description() string {
+	return "Create, list, delete tags."
+}
+
+// CreateMarkdown creates a markdown file containing the helptext for the command at the given path
+func (cmd TagCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) error {
+	ap := cmd.createArgParser()
+	return CreateMarkdown(fs, path, cli.GetCommandDocumentation(commandStr, tagDocs, ap))
+}
+
+func (cmd TagCmd) createArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"commit", "A commit that a new tag should point at."})
+	ap.SupportsFlag(listFlag, "l", "List tags")
+	ap.SupportsFlag(forceFlag, "f", "Replace an existing tag with the given name instead of failing.")
+	ap.SupportsFlag(deleteFlag, "d", "Delete a tag.")
+	ap.SupportsFlag(verboseFlag, "v", "When in list mode, show the hash of the commit each tag points at")
+	return ap
+}
+
+// EventType returns the type of the event to log
+func (cmd TagCmd) EventType() eventsapi.ClientEventType {
+	return eventsapi.ClientEventType_TYPE_UNSPECIFIED
+}
+
+// Exec executes the command
+func (cmd TagCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cmd.createArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, tagDocs, ap))
+	apr := cli.ParseArgs(ap, args, help)
+
+	var verr errhand.VerboseError
+	switch {
+	case apr.Contains(deleteFlag):
+		verr = deleteTag(ctx, dEnv, apr)
+	case apr.Contains(listFlag) || apr.NArg() == 0:
+		verr = printTags(ctx, dEnv, apr)
+	default:
+		verr = createTag(ctx, dEnv, apr)
+	}
+
+	return HandleVErrAndExitCode(verr, usage)
+}
+
+func printTags(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgParseResults) errhand.VerboseError {
+	tags, err := actions.GetTags(ctx, dEnv.DoltDB)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to read refs from db").AddCause(err).Build()
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Ref.GetPath() < tags[j].Ref.GetPath()
+	})
+
+	for _, tag := range tags {
+		if !apr.Contains(verboseFlag) {
+			cli.Println(tag.Ref.GetPath())
+			continue
+		}
+
+		h, err := tag.Commit.HashOf()
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to hash commit").AddCause(err).Build()
+		}
+
+		cli.Println(fmt.Sprintf("%-48s%s", tag.Ref.GetPath(), h.String()))
+	}
+
+	return nil
+}
+
+func createTag(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgParseResults) errhand.VerboseError {
+	if apr.NArg() > 2 {
+		return errhand.BuildDError("").SetPrintUsage().Build()
+	}
+
+	tagName := apr.Arg(0)
+	startPt := "head"
+
+	if apr.NArg() == 2 {
+		startPt = apr.Arg(1)
+	}
+
+	err := actions.CreateTag(ctx, dEnv, tagName, startPt, apr.Contains(forceFlag))
+
+	if err != nil {
+		if err == actions.ErrAlreadyExists {
+			return errhand.BuildDError("fatal: tag '%s' already exists", tagName).Build()
+		} else if err == doltdb.ErrInvBranchName {
+			return errhand.BuildDError("fatal: '%s' is not a valid tag name.", tagName).Build()
+		} else if err == doltdb.ErrInvHash || doltdb.IsNotACommit(err) {
+			return errhand.BuildDError("fatal: '%s' is not a commit and a tag '%s' cannot be created from it", startPt, tagName).Build()
+		}
+
+		return errhand.BuildDError("fatal: Unexpected error creating tag '%s'", tagName).AddCause(err).Build()
+	}
+
+	return nil
+}
+
+func deleteTag(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgParseResults) errhand.VerboseError {
+	if apr.NArg() != 1 {
+		return errhand.BuildDError("").SetPrintUsage().Build()
+	}
+
+	tagName := apr.Arg(0)
+	err := actions.DeleteTag(ctx, dEnv.DoltDB, tagName)
+
+	if err != nil {
+		if err == doltdb.ErrTagNotFound {
+			return errhand.BuildDError("error: tag '%s' not found.", tagName).Build()
+		}
+
+		return errhand.BuildDError("fatal: Unexpected error deleting tag '%s'", tagName).AddCause(err).Build()
+	}
+
+	return nil
+}
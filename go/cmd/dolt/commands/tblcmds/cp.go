@@ -16,9 +16,9 @@ package tblcmds
 
 import (
 	"context"
+	"strings"
 
 	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
-	"github.com/liquidata-inc/dolt/go/libraries/doltcore/mvdata"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
 
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
@@ -26,19 +26,26 @@ import (
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/errcat"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
 )
 
+const schemaOnlyParam = "schema-only"
+
 var tblCpDocs = cli.CommandDocumentationContent{
 	ShortDesc: "Makes a copy of a table",
-	LongDesc: `The dolt table cp command makes a copy of a table at a given commit. If a commit is not specified the copy is made of the table from the current working set.
+	LongDesc: `The dolt table cp command makes a copy of a table at a given commit. If a commit is not specified the copy is made of the table from the current working set. The commit may also be given with the table as {{.LessThan}}commit{{.GreaterThan}}:{{.LessThan}}oldtable{{.GreaterThan}}, to copy a table from another branch, e.g. {{.EmphasisLeft}}dolt table cp production:users staging_users{{.EmphasisRight}}.
+
+The copy has the schema of the table, including its column constraints and comments, and its rows unless {{.EmphasisLeft}}--schema-only{{.EmphasisRight}} is given, in which case the new table is empty. The columns of a table copied from another commit keep their tags when no other table uses them, and the copy then shares the stored rows of the original rather than writing them again.
 
 If a table exists at the target location this command will fail unless the {{.EmphasisLeft}}--force|-f{{.EmphasisRight}} flag is provided.  In this case the table at the target location will be overwritten with the copied table.
 
 All changes will be applied to the working tables and will need to be staged using {{.EmphasisLeft}}dolt add{{.EmphasisRight}} and committed using {{.EmphasisLeft}}dolt commit{{.EmphasisRight}}.
 `,
 	Synopsis: []string{
-		"[-f] [{{.LessThan}}commit{{.GreaterThan}}] {{.LessThan}}oldtable{{.GreaterThan}} {{.LessThan}}newtable{{.GreaterThan}}",
+		"[-f] [--schema-only] [{{.LessThan}}commit{{.GreaterThan}}] {{.LessThan}}oldtable{{.GreaterThan}} {{.LessThan}}newtable{{.GreaterThan}}",
+		"[-f] [--schema-only] {{.LessThan}}commit{{.GreaterThan}}:{{.LessThan}}oldtable{{.GreaterThan}} {{.LessThan}}newtable{{.GreaterThan}}",
 	},
 }
 
@@ -49,6 +56,11 @@ func (cmd CpCmd) Name() string {
 	return "cp"
 }
 
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd CpCmd) LocksRepo() bool {
+	return true
+}
+
 // Description returns a description of the command
 func (cmd CpCmd) Description() string {
 	return "Copies a table"
@@ -66,6 +78,7 @@ func (cmd CpCmd) createArgParser() *argparser.ArgParser {
 	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"oldtable", "The table being copied."})
 	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"newtable", "The destination where the table is being copied to."})
 	ap.SupportsFlag(forceParam, "f", "If data already exists in the destination, the Force flag will allow the target to be overwritten.")
+	ap.SupportsFlag(schemaOnlyParam, "", "Copy the schema of the table without its rows.")
 	return ap
 }
 
@@ -93,13 +106,25 @@ func (cmd CpCmd) Exec(ctx context.Context, commandStr string, args []string, dEn
 
 	root := working
 
-	var old, new string
+	var srcSpec, old, new string
 	if apr.NArg() == 3 {
+		srcSpec, old, new = apr.Arg(0), apr.Arg(1), apr.Arg(2)
+	} else {
+		old, new = apr.Arg(0), apr.Arg(1)
+
+		// table names can't contain a colon, so the last one separates the commit from the table
+		if i := strings.LastIndex(old, ":"); i != -1 {
+			srcSpec, old = old[:i], old[i+1:]
+		}
+	}
+
+	if srcSpec != "" {
 		var cm *doltdb.Commit
-		cm, verr = commands.ResolveCommitWithVErr(dEnv, apr.Arg(0), dEnv.RepoState.CWBHeadRef().String())
+		cm, verr = commands.ResolveCommitWithVErr(dEnv, srcSpec, dEnv.RepoState.CWBHeadRef().String())
 		if verr != nil {
 			return commands.HandleVErrAndExitCode(verr, usage)
 		}
+
 		var err error
 		root, err = cm.GetRootValue()
 
@@ -107,16 +132,16 @@ func (cmd CpCmd) Exec(ctx context.Context, commandStr string, args []string, dEn
 			verr = errhand.BuildDError("error: failed to get root value").AddCause(err).Build()
 			return commands.HandleVErrAndExitCode(verr, usage)
 		}
-
-		old, new = apr.Arg(1), apr.Arg(2)
-	} else {
-		old, new = apr.Arg(0), apr.Arg(1)
 	}
 
-	if err := ValidateTableNameForCreate(new); err != nil {
+	if err := ValidateNewTableName(ctx, working, new, ""); err != nil {
 		return commands.HandleVErrAndExitCode(err, usage)
 	}
 
+	if verr := CheckWritePermission(ctx, dEnv, working, new); verr != nil {
+		return commands.HandleVErrAndExitCode(verr, usage)
+	}
+
 	_, ok, err := root.GetTable(ctx, old)
 
 	if err != nil {
@@ -124,7 +149,7 @@ func (cmd CpCmd) Exec(ctx context.Context, commandStr string, args []string, dEn
 		return commands.HandleVErrAndExitCode(verr, usage)
 	}
 	if !ok {
-		verr = errhand.BuildDError("Table '%s' not found in root", old).Build()
+		verr = errhand.BuildDError("Table '%s' not found in root", old).SetCategory(errcat.NotFound).Build()
 		return commands.HandleVErrAndExitCode(verr, usage)
 	}
 
@@ -138,19 +163,12 @@ func (cmd CpCmd) Exec(ctx context.Context, commandStr string, args []string, dEn
 		return commands.HandleVErrAndExitCode(verr, usage)
 	}
 
-	mvOpts := &mvdata.MoveOptions{
-		Operation: mvdata.OverwriteOp,
-		ContOnErr: true,
-		Src:       mvdata.TableDataLocation{Name: old},
-		Dest:      mvdata.TableDataLocation{Name: new},
-	}
+	working, err = actions.CopyTable(ctx, root, old, working, new, apr.Contains(schemaOnlyParam))
 
-	res := executeMove(ctx, dEnv, force, mvOpts)
-
-	if res != 0 {
-		verr = errhand.BuildDError("could not copy table %s to table %s", old, new).Build()
+	if err != nil {
+		verr = errhand.BuildDError("could not copy table %s to table %s", old, new).AddCause(err).Build()
 		return commands.HandleVErrAndExitCode(verr, usage)
 	}
 
-	return 0
+	return commands.HandleVErrAndExitCode(commands.UpdateWorkingWithVErr(dEnv, working), usage)
 }
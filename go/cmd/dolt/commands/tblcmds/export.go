@@ -22,6 +22,7 @@ import (
 
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
 	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
@@ -35,10 +36,25 @@ var exportDocs = cli.CommandDocumentationContent{
 	ShortDesc: `Export the contents of a table to a file.`,
 	LongDesc: `{{.EmphasisLeft}}dolt table export{{.EmphasisRight}} will export the contents of {{.LessThan}}table{{.GreaterThan}} to {{.LessThan}}|file{{.GreaterThan}}
 
+If {{.EmphasisLeft}}--ref{{.EmphasisRight}} is given the table state with that table ref is exported instead of {{.LessThan}}table{{.GreaterThan}} in the working set. Table refs are displayed by {{.EmphasisLeft}}dolt table show-ref{{.EmphasisRight}} and {{.EmphasisLeft}}dolt ls --verbose{{.EmphasisRight}}.
+
 See the help for {{.EmphasisLeft}}dolt table import{{.EmphasisRight}} as the options are the same.
+
+With {{.EmphasisLeft}}--file-type sqlite{{.EmphasisRight}}, or a {{.EmphasisLeft}}.sqlite{{.EmphasisRight}} or {{.EmphasisLeft}}.db{{.EmphasisRight}} file, the given tables, or all of the tables if none are given, are exported to a SQLite database file, which is replaced if it exists and {{.EmphasisLeft}}-f{{.EmphasisRight}} is given. Each table is created with its primary key, and with these column types:
+
+  bit, bool, int, uint, year    INTEGER
+  float                         REAL
+  varchar, text, uuid           TEXT
+  enum, set, time               TEXT
+  datetime                      TEXT, as an ISO 8601 timestamp in UTC
+  decimal                       TEXT
+  varbinary, blob               BLOB
+
+Columns of other types are exported as TEXT. A uint too large for a SQLite INTEGER is exported as a REAL, and a value larger than SQLite's limit of 1GB as NULL. A warning is printed for each.
 `,
 	Synopsis: []string{
-		"[-f] [-pk {{.LessThan}}field{{.GreaterThan}}] [-schema {{.LessThan}}file{{.GreaterThan}}] [-map {{.LessThan}}file{{.GreaterThan}}] [-continue] [-file-type {{.LessThan}}type{{.GreaterThan}}] {{.LessThan}}table{{.GreaterThan}} {{.LessThan}}file{{.GreaterThan}}",
+		"[-f] [-pk {{.LessThan}}field{{.GreaterThan}}] [-schema {{.LessThan}}file{{.GreaterThan}}] [-map {{.LessThan}}file{{.GreaterThan}}] [-continue] [-file-type {{.LessThan}}type{{.GreaterThan}}] [--ref {{.LessThan}}table ref{{.GreaterThan}}] {{.LessThan}}table{{.GreaterThan}} {{.LessThan}}file{{.GreaterThan}}",
+		"[-f] [-continue] -file-type sqlite [--ref {{.LessThan}}table ref{{.GreaterThan}}] {{.LessThan}}file{{.GreaterThan}} [{{.LessThan}}table{{.GreaterThan}}...]",
 	},
 }
 
@@ -90,20 +106,18 @@ func validateExportArgs(apr *argparser.ArgParseResults, usage cli.UsagePrinter)
 	return tableName, tableLoc, destLoc
 }
 
-func parseExportArgs(ap *argparser.ArgParser, commandStr string, args []string) (bool, *mvdata.MoveOptions) {
-	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, exportDocs, ap))
-	apr := cli.ParseArgs(ap, args, help)
+func parseExportArgs(apr *argparser.ArgParseResults, usage cli.UsagePrinter) *mvdata.MoveOptions {
 	tableName, tableLoc, fileLoc := validateExportArgs(apr, usage)
 
 	if fileLoc == nil || len(tableLoc.Name) == 0 {
-		return false, nil
+		return nil
 	}
 
 	schemaFile, _ := apr.GetValue(outSchemaParam)
 	mappingFile, _ := apr.GetValue(mappingFileParam)
 	primaryKey, _ := apr.GetValue(primaryKeyParam)
 
-	return apr.Contains(forceParam), &mvdata.MoveOptions{
+	return &mvdata.MoveOptions{
 		Operation:   mvdata.OverwriteOp,
 		ContOnErr:   apr.Contains(contOnErrParam),
 		TableName:   tableName,
@@ -143,6 +157,7 @@ func (cmd ExportCmd) createArgParser() *argparser.ArgParser {
 	ap.SupportsString(mappingFileParam, "m", "mapping_file", "A file that lays out how fields should be mapped from input data to output data.")
 	ap.SupportsString(primaryKeyParam, "pk", "primary_key", "Explicitly define the name of the field in the schema which should be used as the primary key.")
 	ap.SupportsString(fileTypeParam, "", "file_type", "Explicitly define the type of the file if it can't be inferred from the file extension.")
+	ap.SupportsString(refParam, "", "table_ref", "Export the table state with the given table ref instead of the table in the working set.")
 	return ap
 }
 
@@ -154,17 +169,67 @@ func (cmd ExportCmd) EventType() eventsapi.ClientEventType {
 // Exec executes the command
 func (cmd ExportCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
 	ap := cmd.createArgParser()
-	force, mvOpts := parseExportArgs(ap, commandStr, args)
+	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, exportDocs, ap))
+	apr := cli.ParseArgs(ap, args, help)
+
+	if isSqliteExport(apr) {
+		root, verr := commands.GetWorkingWithVErr(dEnv)
+
+		if verr == nil {
+			verr = exportSqlite(ctx, dEnv, root, apr)
+		}
+
+		if verr == nil {
+			cli.PrintErrln(color.CyanString("Successfully exported data."))
+		}
+
+		return commands.HandleVErrAndExitCode(verr, usage)
+	}
+
+	mvOpts := parseExportArgs(apr, usage)
 
 	if mvOpts == nil {
 		return 1
 	}
 
-	result := executeMove(ctx, dEnv, force, mvOpts)
+	root, verr := commands.GetWorkingWithVErr(dEnv)
 
-	if result == 0 {
+	if verr != nil {
+		cli.PrintErrln(verr.Verbose())
+		return 1
+	}
+
+	if tblRefStr, ok := apr.GetValue(refParam); ok {
+		root, verr = rootWithTableRef(ctx, dEnv, root, mvOpts.TableName, tblRefStr)
+
+		if verr != nil {
+			cli.PrintErrln(verr.Verbose())
+			return 1
+		}
+	}
+
+	verr = executeMoveFromRoot(ctx, dEnv, root, apr.Contains(forceParam), mvOpts)
+
+	if verr == nil {
 		cli.PrintErrln(color.CyanString("Successfully exported data."))
 	}
 
-	return result
+	return commands.HandleVErrAndExitCode(verr, nil)
+}
+
+// rootWithTableRef returns |root| with the table |tableName| replaced by the table state with the table ref |tblRefStr|
+func rootWithTableRef(ctx context.Context, dEnv *env.DoltEnv, root *doltdb.RootValue, tableName, tblRefStr string) (*doltdb.RootValue, errhand.VerboseError) {
+	h, _, verr := commands.ResolveTableRefWithVErr(dEnv, tblRefStr)
+
+	if verr != nil {
+		return nil, verr
+	}
+
+	root, err := root.SetTableHash(ctx, tableName, h)
+
+	if err != nil {
+		return nil, errhand.BuildDError("error: failed to read table ref '%s'", tblRefStr).AddCause(err).Build()
+	}
+
+	return root, nil
 }
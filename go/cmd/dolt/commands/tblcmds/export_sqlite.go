@@ -0,0 +1,124 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tblcmds
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/mvdata"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+)
+
+// isSqliteExport returns true if the args of an export are those of an export to a SQLite file, whose first argument
+// is the file, rather than a table.
+func isSqliteExport(apr *argparser.ArgParseResults) bool {
+	if fType, ok := apr.GetValue(fileTypeParam); ok {
+		return mvdata.DFFromString(fType) == mvdata.SqliteFile
+	}
+
+	if apr.NArg() == 0 || doltdb.IsValidTableName(apr.Arg(0)) {
+		return false
+	}
+
+	ext := strings.ToLower(filepath.Ext(apr.Arg(0)))
+	return ext == string(mvdata.SqliteFile) || ext == ".db"
+}
+
+// exportSqlite exports the tables given after the SQLite file in the args, or all of the tables of |root| if none are,
+// to the file. Each table is streamed into the file in its own transaction.
+func exportSqlite(ctx context.Context, dEnv *env.DoltEnv, root *doltdb.RootValue, apr *argparser.ArgParseResults) errhand.VerboseError {
+	if apr.NArg() == 0 {
+		return errhand.BuildDError("missing the SQLite file to export to").SetPrintUsage().Build()
+	}
+
+	for _, param := range []string{outSchemaParam, mappingFileParam, primaryKeyParam} {
+		if apr.Contains(param) {
+			return errhand.BuildDError("--%s isn't supported when exporting to a SQLite file", param).Build()
+		}
+	}
+
+	path := apr.Arg(0)
+	tableNames := apr.Args()[1:]
+
+	if len(tableNames) == 0 {
+		var err error
+		tableNames, err = root.GetTableNames(ctx)
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to read the tables").AddCause(err).Build()
+		}
+	}
+
+	if tblRefStr, ok := apr.GetValue(refParam); ok {
+		if len(tableNames) != 1 {
+			return errhand.BuildDError("--%s can only be used when exporting a single table", refParam).Build()
+		}
+
+		var verr errhand.VerboseError
+		root, verr = rootWithTableRef(ctx, dEnv, root, tableNames[0], tblRefStr)
+
+		if verr != nil {
+			return verr
+		}
+	}
+
+	// an existing file is replaced, rather than having the tables added to it
+	if exists, isDir := dEnv.FS.Exists(path); isDir {
+		return errhand.BuildDError("%s is a directory", path).Build()
+	} else if exists {
+		if !apr.Contains(forceParam) {
+			return errhand.BuildDError("%s already exists. Use -f to overwrite.", path).Build()
+		}
+
+		if err := dEnv.FS.DeleteFile(path); err != nil {
+			return errhand.BuildDError("error: failed to delete %s", path).AddCause(err).Build()
+		}
+	}
+
+	warn := func(msg string) {
+		cli.PrintErrln(color.YellowString("warning: %s", msg))
+	}
+
+	dest := mvdata.FileDataLocation{Path: path, Format: mvdata.SqliteFile}
+	for _, tableName := range tableNames {
+		mvOpts := &mvdata.MoveOptions{
+			Operation:   mvdata.OverwriteOp,
+			ContOnErr:   apr.Contains(contOnErrParam),
+			TableName:   tableName,
+			Src:         mvdata.TableDataLocation{Name: tableName},
+			Dest:        dest,
+			DestOptions: mvdata.SqliteOptions{Warn: warn},
+		}
+
+		// the file exists after the first table is exported
+		verr := executeMoveFromRoot(ctx, dEnv, root, true, mvOpts)
+
+		if verr != nil {
+			return verr
+		}
+
+		cli.PrintErrln("Exported table", tableName)
+	}
+
+	return nil
+}
@@ -25,7 +25,9 @@ import (
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
 	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/errcat"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/mvdata"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/pipeline"
@@ -49,6 +51,8 @@ const (
 	primaryKeyParam  = "pk"
 	fileTypeParam    = "file-type"
 	delimParam       = "delim"
+	dedupeParam      = "dedupe"
+	refParam         = "ref"
 )
 
 var SchemaFileHelp = "Schema definition files are json files in the format:" + `
@@ -92,17 +96,23 @@ If {{.EmphasisLeft}}--replace-table | -r{{.EmphasisRight}} is given the operatio
 
 If the schema for the existing table does not match the schema for the new file, the import will be aborted by default. To overwrite both the table and the schema, use {{.EmphasisLeft}}-c -f{{.EmphasisRight}}.
 
+When updating or replacing a table, the {{.EmphasisLeft}}--dedupe{{.EmphasisRight}} flag compares each imported row with the existing row with the same primary key, and skips the rows which are identical so that only the rows which actually changed are written. When replacing, the existing rows which are not in the file are deleted without rewriting the rest of the table. The number of unchanged, updated, added and deleted rows is reported once the import completes.
+
 A mapping file can be used to map fields between the file being imported and the table being written to. This can be used when creating a new table, or updating or replacing an existing table.
 
 ` + MappingFileHelp +
 
 		`
-In create, update, and replace scenarios the file's extension is used to infer the type of the file.  If a file does not have the expected extension then the {{.EmphasisLeft}}--file-type{{.EmphasisRight}} parameter should be used to explicitly define the format of the file in one of the supported formats (csv, psv, json, xlsx).  For files separated by a delimiter other than a ',' (type csv) or a '|' (type psv), the --delim parameter can be used to specify a delimeter`,
+In create, update, and replace scenarios the file's extension is used to infer the type of the file.  If a file does not have the expected extension then the {{.EmphasisLeft}}--file-type{{.EmphasisRight}} parameter should be used to explicitly define the format of the file in one of the supported formats (csv, psv, json, xlsx).  For files separated by a delimiter other than a ',' (type csv) or a '|' (type psv), the --delim parameter can be used to specify a delimeter
+
+` + mysqlImportHelp,
 
 	Synopsis: []string{
 		"-c [-f] [--pk {{.LessThan}}field{{.GreaterThan}}] [--schema {{.LessThan}}file{{.GreaterThan}}] [--map {{.LessThan}}file{{.GreaterThan}}] [--continue] [--file-type {{.LessThan}}type{{.GreaterThan}}] {{.LessThan}}table{{.GreaterThan}} {{.LessThan}}file{{.GreaterThan}}",
-		"-u [--map {{.LessThan}}file{{.GreaterThan}}] [--continue] [--file-type {{.LessThan}}type{{.GreaterThan}}] {{.LessThan}}table{{.GreaterThan}} {{.LessThan}}file{{.GreaterThan}}",
-		"-r [--map {{.LessThan}}file{{.GreaterThan}}] [--file-type {{.LessThan}}type{{.GreaterThan}}] {{.LessThan}}table{{.GreaterThan}} {{.LessThan}}file{{.GreaterThan}}",
+		"-u [--map {{.LessThan}}file{{.GreaterThan}}] [--continue] [--dedupe] [--file-type {{.LessThan}}type{{.GreaterThan}}] {{.LessThan}}table{{.GreaterThan}} {{.LessThan}}file{{.GreaterThan}}",
+		"-r [--map {{.LessThan}}file{{.GreaterThan}}] [--dedupe] [--file-type {{.LessThan}}type{{.GreaterThan}}] {{.LessThan}}table{{.GreaterThan}} {{.LessThan}}file{{.GreaterThan}}",
+		"--from-mysql {{.LessThan}}dsn{{.GreaterThan}} (--tables {{.LessThan}}table{{.GreaterThan}}[,{{.LessThan}}table{{.GreaterThan}}...] | --all) [-f] [--where {{.LessThan}}condition{{.GreaterThan}}] [--where-file {{.LessThan}}file{{.GreaterThan}}] [--batch-size {{.LessThan}}n{{.GreaterThan}}] [--commit-per-table] [--message {{.LessThan}}msg{{.GreaterThan}}]",
+		"--from-mysql {{.LessThan}}dsn{{.GreaterThan}} --resume",
 	},
 }
 
@@ -172,7 +182,9 @@ func validateImportArgs(apr *argparser.ArgParseResults) errhand.VerboseError {
 	if !apr.Contains(createParam) && !apr.Contains(updateParam) && !apr.Contains(replaceParam) {
 		return errhand.BuildDError("Must include '-c' for initial table import or -u to update existing table or -r to replace existing table.").Build()
 	} else if apr.Contains(createParam) {
-		// nothing to validate
+		if apr.Contains(dedupeParam) {
+			return errhand.BuildDError("fatal: " + dedupeParam + " is only supported for update or replace operations").Build()
+		}
 	} else {
 		if apr.Contains(outSchemaParam) {
 			return errhand.BuildDError("fatal: " + outSchemaParam + " is not supported for update or replace operations").Build()
@@ -222,6 +234,11 @@ func (cmd ImportCmd) Name() string {
 	return "import"
 }
 
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd ImportCmd) LocksRepo() bool {
+	return true
+}
+
 // Description returns a description of the command
 func (cmd ImportCmd) Description() string {
 	return "Creates, overwrites, replaces, or updates a table from the data in a file."
@@ -235,6 +252,7 @@ func (cmd ImportCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string)
 
 func (cmd ImportCmd) createArgParser() *argparser.ArgParser {
 	ap := createArgParser()
+	commands.SupportsIncludeSparse(ap)
 	return ap
 }
 
@@ -250,11 +268,30 @@ func (cmd ImportCmd) Exec(ctx context.Context, commandStr string, args []string,
 	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, importDocs, ap))
 	apr := cli.ParseArgs(ap, args, help)
 
+	if apr.Contains(fromMySQLParam) {
+		verr := validateMySQLImportArgs(apr)
+
+		if verr == nil {
+			verr = importFromMySQL(ctx, dEnv, apr)
+		}
+
+		if verr == nil {
+			cli.PrintErrln(color.CyanString("Import completed successfully."))
+		}
+
+		return commands.HandleVErrAndExitCode(verr, usage)
+	}
+
 	err := validateImportArgs(apr)
 	if err != nil {
 		return commands.HandleVErrAndExitCode(err, usage)
 	}
 
+	err = commands.CheckSparseTablesWithVErr(dEnv, []string{apr.Arg(0)}, apr.Contains(commands.IncludeSparseFlag))
+	if err != nil {
+		return commands.HandleVErrAndExitCode(err, usage)
+	}
+
 	moveOp, tableLoc, fileLoc, srcOpts := getMoveParameters(apr)
 
 	schemaFile, _ := apr.GetValue(outSchemaParam)
@@ -271,15 +308,16 @@ func (cmd ImportCmd) Exec(ctx context.Context, commandStr string, args []string,
 		Src:         fileLoc,
 		Dest:        tableLoc,
 		SrcOptions:  srcOpts,
+		Dedupe:      apr.Contains(dedupeParam),
 	}
 
-	res := executeMove(ctx, dEnv, force, mvOpts)
+	verr := executeMove(ctx, dEnv, force, mvOpts)
 
-	if res == 0 {
+	if verr == nil {
 		cli.PrintErrln(color.CyanString("Import completed successfully."))
 	}
 
-	return res
+	return commands.HandleVErrAndExitCode(verr, usage)
 }
 
 func createArgParser() *argparser.ArgParser {
@@ -291,11 +329,21 @@ func createArgParser() *argparser.ArgParser {
 	ap.SupportsFlag(forceParam, "f", "If a create operation is being executed, data already exists in the destination, the Force flag will allow the target to be overwritten.")
 	ap.SupportsFlag(replaceParam, "r", "Replace existing table with imported data while preserving the original schema.")
 	ap.SupportsFlag(contOnErrParam, "", "Continue importing when row import errors are encountered.")
+	ap.SupportsFlag(dedupeParam, "", "When updating or replacing a table, skip the rows which are identical to the existing rows with the same primary key.")
 	ap.SupportsString(outSchemaParam, "s", "schema_file", "The schema for the output data.")
 	ap.SupportsString(mappingFileParam, "m", "mapping_file", "A file that lays out how fields should be mapped from input data to output data.")
 	ap.SupportsString(primaryKeyParam, "pk", "primary_key", "Explicitly define the name of the field in the schema which should be used as the primary key.")
 	ap.SupportsString(fileTypeParam, "", "file_type", "Explicitly define the type of the file if it can't be inferred from the file extension.")
 	ap.SupportsString(delimParam, "", "delimiter", "Specify a delimeter for a csv style file with a non-comma delimiter.")
+	ap.SupportsString(fromMySQLParam, "", "dsn", "Import tables from the MySQL database with this data source name, e.g. user:password@tcp(host:3306)/db.")
+	ap.SupportsString(tablesParam, "", "tables", "A comma separated list of the MySQL tables to import.")
+	ap.SupportsFlag(allTablesParam, "", "Import every table of the MySQL database.")
+	ap.SupportsString(whereParam, "", "condition", "Only import the MySQL rows matching this condition.")
+	ap.SupportsString(whereFileParam, "", "file", "A json file mapping MySQL table names to the conditions of the rows to import from them.")
+	ap.SupportsInt(batchSizeParam, "", "n", fmt.Sprintf("The number of rows imported from MySQL between saves of the import's progress. Defaults to %d.", defaultMySQLBatchSize))
+	ap.SupportsFlag(commitPerTableParam, "", "Commit each table imported from MySQL, rather than all of them once the import is done.")
+	ap.SupportsString(messageParam, "", "msg", "The message of the commits of the tables imported from MySQL.")
+	ap.SupportsFlag(resumeParam, "", "Continue an import from MySQL which was interrupted.")
 	return ap
 }
 
@@ -308,47 +356,66 @@ func importStatsCB(stats types.AppliedEditStats) {
 	displayStrLen = cli.DeleteAndPrint(displayStrLen, displayStr)
 }
 
-func executeMove(ctx context.Context, dEnv *env.DoltEnv, force bool, mvOpts *mvdata.MoveOptions) int {
+func printDedupeStats(stats noms.DedupeStats) {
+	cli.PrintErrln(fmt.Sprintf("Rows Unchanged: %d, Updated: %d, Added: %d, Deleted: %d", stats.Unchanged, stats.Updated, stats.Added, stats.Deleted))
+}
+
+func executeMove(ctx context.Context, dEnv *env.DoltEnv, force bool, mvOpts *mvdata.MoveOptions) errhand.VerboseError {
 	root, err := dEnv.WorkingRoot(ctx)
 
 	if err != nil {
-		cli.PrintErrln(color.RedString("Unable to get the working root value for this data repository."))
-		return 1
+		return errhand.BuildDError("Unable to get the working root value for this data repository.").AddCause(err).Build()
 	}
 
+	return executeMoveFromRoot(ctx, dEnv, root, force, mvOpts)
+}
+
+func executeMoveFromRoot(ctx context.Context, dEnv *env.DoltEnv, root *doltdb.RootValue, force bool, mvOpts *mvdata.MoveOptions) errhand.VerboseError {
 	_, isStdOut := mvOpts.Dest.(mvdata.StreamDataLocation)
 	if !isStdOut && mvOpts.Operation == mvdata.OverwriteOp && !force {
 		if exists, err := mvOpts.Dest.Exists(ctx, root, dEnv.FS); err != nil {
-			cli.Println(color.RedString(err.Error()))
-			return 1
+			return errhand.VerboseErrorFromError(err)
 		} else if exists {
-			cli.PrintErrln(color.RedString(fmt.Sprintf("%s already exists. Use -f to overwrite.", mvOpts.Dest.String())))
-			return 1
+			return errhand.BuildDError("%s already exists. Use -f to overwrite.", mvOpts.Dest.String()).Build()
+		}
+	}
+
+	if tableDest, isTable := mvOpts.Dest.(mvdata.TableDataLocation); isTable {
+		if mvOpts.Operation == mvdata.OverwriteOp {
+			if verr := ValidateNewTableName(ctx, root, tableDest.Name, ""); verr != nil {
+				return verr
+			}
+		}
+
+		if verr := CheckWritePermission(ctx, dEnv, root, tableDest.Name); verr != nil {
+			return verr
 		}
 	}
 
 	if srcFileLoc, isFileType := mvOpts.Src.(mvdata.FileDataLocation); isFileType {
 		if srcFileLoc.Format == mvdata.SqlFile {
-			cli.Println(color.RedString("For SQL import, please pipe SQL input files to `dolt sql`"))
-			return 1
+			return errhand.BuildDError("For SQL import, please pipe SQL input files to `dolt sql`").Build()
 		}
 
 		if srcFileLoc.Format == mvdata.JsonFile && mvOpts.Operation == mvdata.OverwriteOp && mvOpts.SchFile == "" {
-			cli.Println(color.RedString("Please specify schema file for .json tables."))
-			return 1
+			return errhand.BuildDError("Please specify schema file for .json tables.").Build()
 		}
 	}
 
-	mover, nDMErr := mvdata.NewDataMover(ctx, root, dEnv.FS, mvOpts, importStatsCB)
+	// the applied edit stats only count the rows which changed when deduplicating, so the totals are reported once the
+	// move is done instead
+	var statsCB noms.StatsCB = importStatsCB
+	if mvOpts.Dedupe {
+		statsCB = nil
+	}
+
+	mover, nDMErr := mvdata.NewDataMover(ctx, root, dEnv.FS, mvOpts, statsCB)
 
 	if nDMErr != nil {
-		verr := newDataMoverErrToVerr(mvOpts, nDMErr)
-		cli.PrintErrln(verr.Verbose())
-		return 1
+		return newDataMoverErrToVerr(mvOpts, nDMErr)
 	}
 
-	var badCount int64
-	badCount, err = mover.Move(ctx)
+	badCount, err := mover.Move(ctx)
 
 	if displayStrLen > 0 {
 		displayStrLen = 0
@@ -357,24 +424,10 @@ func executeMove(ctx context.Context, dEnv *env.DoltEnv, force bool, mvOpts *mvd
 
 	if err != nil {
 		if pipeline.IsTransformFailure(err) {
-			bdr := errhand.BuildDError("A bad row was encountered while moving data.")
-
-			r := pipeline.GetTransFailureRow(err)
-			if r != nil {
-				bdr.AddDetails("Bad Row:" + row.Fmt(ctx, r, mover.Rd.GetSchema()))
-			}
-
-			details := pipeline.GetTransFailureDetails(err)
-
-			bdr.AddDetails(details)
-			bdr.AddDetails("These can be ignored using the '--continue'")
-
-			cli.PrintErrln(bdr.Build().Verbose())
-		} else {
-			cli.PrintErrln("An error occurred moving data:\n", err.Error())
+			return badRowVErr(ctx, mover, err)
 		}
 
-		return 1
+		return errhand.BuildDError("An error occurred moving data:").AddCause(err).Build()
 	}
 
 	if nomsWr, ok := mover.Wr.(noms.NomsMapWriteCloser); ok {
@@ -382,16 +435,36 @@ func executeMove(ctx context.Context, dEnv *env.DoltEnv, force bool, mvOpts *mvd
 		err = dEnv.PutTableToWorking(ctx, *nomsWr.GetMap(), nomsWr.GetSchema(), tableDest.Name)
 
 		if err != nil {
-			cli.PrintErrln(color.RedString("Failed to update the working value."))
-			return 1
+			return errhand.BuildDError("Failed to update the working value.").AddCause(err).Build()
 		}
 	}
 
+	if dedupeWr, ok := mover.Wr.(*noms.DedupingMapUpdater); ok {
+		printDedupeStats(dedupeWr.GetDedupeStats())
+	}
+
 	if badCount > 0 {
 		cli.PrintErrln(color.YellowString("Lines skipped: %d", badCount))
 	}
 
-	return 0
+	return nil
+}
+
+// badRowVErr returns the error for a bad row which stopped a move.
+func badRowVErr(ctx context.Context, mover *mvdata.DataMover, err error) errhand.VerboseError {
+	bdr := errhand.BuildDError("A bad row was encountered while moving data.")
+
+	r := pipeline.GetTransFailureRow(err)
+	if r != nil {
+		bdr.AddDetails("Bad Row:" + row.Fmt(ctx, r, mover.Rd.GetSchema()))
+	}
+
+	details := pipeline.GetTransFailureDetails(err)
+
+	bdr.AddDetails(details)
+	bdr.AddDetails("These can be ignored using the '--continue'")
+
+	return bdr.SetCategory(errcat.ConstraintViolation).Build()
 }
 
 func newDataMoverErrToVerr(mvOpts *mvdata.MoveOptions, err *mvdata.DataMoverCreationError) errhand.VerboseError {
@@ -0,0 +1,432 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tblcmds
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	gomysql "github.com/go-sql-driver/mysql"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/mvdata"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/pipeline"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/typed/mysql"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/typed/noms"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+)
+
+const (
+	fromMySQLParam      = "from-mysql"
+	tablesParam         = "tables"
+	allTablesParam      = "all"
+	whereParam          = "where"
+	whereFileParam      = "where-file"
+	resumeParam         = "resume"
+	commitPerTableParam = "commit-per-table"
+	messageParam        = "message"
+	batchSizeParam      = "batch-size"
+
+	defaultMySQLBatchSize = 100000
+
+	// mysqlImportFile records the progress of an import from MySQL, so that it can be resumed
+	mysqlImportFile = "mysql_import.json"
+)
+
+var mysqlImportHelp = `If {{.EmphasisLeft}}--from-mysql{{.EmphasisRight}} is given, tables are imported from the MySQL database with the given data source name, in the format {{.EmphasisLeft}}user:password@tcp(host:port)/database{{.EmphasisRight}}. The tables to import are listed with {{.EmphasisLeft}}--tables{{.EmphasisRight}}, or every table of the database is imported with {{.EmphasisLeft}}--all{{.EmphasisRight}}. Each table is created with the schema of the MySQL table, failing if the table already exists unless {{.EmphasisLeft}}--force | -f{{.EmphasisRight}} is given. Integer, floating point, decimal, bit, date and time, char, varchar, text, enum and set columns keep their MySQL types, and json columns are imported as longtext. Tables with binary string, blob or spatial columns, or without a primary key, can't be imported.
+
+Rows are read in primary key order, {{.EmphasisLeft}}--batch-size{{.EmphasisRight}} rows at a time, and only the rows matching the condition given by {{.EmphasisLeft}}--where{{.EmphasisRight}} are read. A file given by {{.EmphasisLeft}}--where-file{{.EmphasisRight}} holds a json object mapping table names to conditions for just those tables. The imported tables are committed once every table has been imported, or after each table is imported if {{.EmphasisLeft}}--commit-per-table{{.EmphasisRight}} is given, with the message given by {{.EmphasisLeft}}--message{{.EmphasisRight}}.
+
+The progress of the import is saved after each batch of rows. If the import is interrupted or fails it can be continued with {{.EmphasisLeft}}--resume{{.EmphasisRight}}, which imports the remaining rows of the same tables after the primary key of the last row that was imported.
+`
+
+// mysqlImportProgress is the state of an import from MySQL, saved after every batch of rows.
+type mysqlImportProgress struct {
+	Source         string                `json:"source"`
+	Message        string                `json:"message"`
+	CommitPerTable bool                  `json:"commit_per_table"`
+	BatchSize      uint64                `json:"batch_size"`
+	Tables         []*mysqlTableProgress `json:"tables"`
+}
+
+type mysqlTableProgress struct {
+	Name    string   `json:"name"`
+	Where   string   `json:"where,omitempty"`
+	LastKey []string `json:"last_key,omitempty"`
+	Rows    uint64   `json:"rows"`
+	Done    bool     `json:"done"`
+}
+
+func mysqlImportPath() string {
+	return filepath.Join(dbfactory.DoltDir, mysqlImportFile)
+}
+
+func loadMySQLImportProgress(dEnv *env.DoltEnv) (*mysqlImportProgress, error) {
+	data, err := dEnv.FS.ReadFile(mysqlImportPath())
+
+	if err != nil {
+		return nil, err
+	}
+
+	var progress mysqlImportProgress
+	err = json.Unmarshal(data, &progress)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &progress, nil
+}
+
+func (progress *mysqlImportProgress) save(dEnv *env.DoltEnv) error {
+	data, err := json.MarshalIndent(progress, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return dEnv.FS.WriteFile(mysqlImportPath(), data)
+}
+
+func validateMySQLImportArgs(apr *argparser.ArgParseResults) errhand.VerboseError {
+	if apr.NArg() != 0 {
+		return errhand.BuildDError("the tables to import from MySQL are given by --%s or --%s", tablesParam, allTablesParam).SetPrintUsage().Build()
+	}
+
+	for _, param := range []string{createParam, updateParam, replaceParam, outSchemaParam, mappingFileParam, primaryKeyParam, fileTypeParam, delimParam, dedupeParam} {
+		if apr.Contains(param) {
+			return errhand.BuildDError("fatal: --%s is not supported when importing from MySQL", param).Build()
+		}
+	}
+
+	if apr.Contains(resumeParam) {
+		for _, param := range []string{tablesParam, allTablesParam, whereParam, whereFileParam, commitPerTableParam, messageParam, batchSizeParam} {
+			if apr.Contains(param) {
+				return errhand.BuildDError("fatal: --%s can't be changed when resuming an import", param).Build()
+			}
+		}
+
+		return nil
+	}
+
+	if apr.Contains(tablesParam) == apr.Contains(allTablesParam) {
+		return errhand.BuildDError("fatal: exactly one of --%s and --%s must be given", tablesParam, allTablesParam).Build()
+	}
+
+	if batchSize, ok := apr.GetInt(batchSizeParam); ok && batchSize <= 0 {
+		return errhand.BuildDError("fatal: --%s must be positive", batchSizeParam).Build()
+	}
+
+	return nil
+}
+
+// openMySQL connects to the MySQL database given by the data source name |dsn|, returning the connection along with
+// the data source name without its password, and the name of the database.
+func openMySQL(ctx context.Context, dsn string) (*sql.DB, string, string, errhand.VerboseError) {
+	cfg, err := gomysql.ParseDSN(dsn)
+
+	if err != nil {
+		return nil, "", "", errhand.BuildDError("error: invalid MySQL data source name").AddCause(err).Build()
+	} else if cfg.DBName == "" {
+		return nil, "", "", errhand.BuildDError("error: no database given by the MySQL data source name").Build()
+	}
+
+	// parameters are interpolated by the client so that resuming an import doesn't need prepared statements
+	cfg.InterpolateParams = true
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+
+	cfg.InterpolateParams = false
+	cfg.Passwd = ""
+	source := cfg.FormatDSN()
+
+	if err == nil {
+		err = db.PingContext(ctx)
+	}
+
+	if err != nil {
+		return nil, "", "", errhand.BuildDError("error: failed to connect to %s", source).AddCause(err).Build()
+	}
+
+	return db, source, cfg.DBName, nil
+}
+
+func importFromMySQL(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgParseResults) errhand.VerboseError {
+	db, source, dbName, verr := openMySQL(ctx, apr.MustGetValue(fromMySQLParam))
+
+	if verr != nil {
+		return verr
+	}
+
+	defer db.Close()
+
+	var err error
+	var progress *mysqlImportProgress
+	if apr.Contains(resumeParam) {
+		if exists, _ := dEnv.FS.Exists(mysqlImportPath()); !exists {
+			return errhand.BuildDError("error: there is no import from MySQL to resume").Build()
+		}
+
+		progress, err = loadMySQLImportProgress(dEnv)
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to read the progress of the import").AddCause(err).Build()
+		} else if progress.Source != source {
+			return errhand.BuildDError("error: the import being resumed is from %s", progress.Source).Build()
+		}
+	} else {
+		var verr errhand.VerboseError
+		progress, verr = newMySQLImportProgress(ctx, dEnv, db, apr, source, dbName)
+
+		if verr != nil {
+			return verr
+		}
+
+		err = progress.save(dEnv)
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to save the progress of the import").AddCause(err).Build()
+		}
+	}
+
+	var tblNames []string
+	for _, tblProgress := range progress.Tables {
+		tblNames = append(tblNames, tblProgress.Name)
+
+		if tblProgress.Done {
+			continue
+		}
+
+		verr := importMySQLTable(ctx, dEnv, db, source, progress, tblProgress)
+
+		if verr != nil {
+			cli.PrintErrln(color.YellowString("The import can be continued with --%s", resumeParam))
+			return verr
+		}
+
+		if progress.CommitPerTable {
+			verr = commitMySQLImport(ctx, dEnv, []string{tblProgress.Name}, fmt.Sprintf("%s: %s", progress.Message, tblProgress.Name))
+
+			if verr != nil {
+				return verr
+			}
+		}
+
+		tblProgress.Done = true
+		err = progress.save(dEnv)
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to save the progress of the import").AddCause(err).Build()
+		}
+	}
+
+	if !progress.CommitPerTable {
+		verr := commitMySQLImport(ctx, dEnv, tblNames, progress.Message)
+
+		if verr != nil {
+			return verr
+		}
+	}
+
+	err = dEnv.FS.DeleteFile(mysqlImportPath())
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to remove the progress of the import").AddCause(err).Build()
+	}
+
+	return nil
+}
+
+func newMySQLImportProgress(ctx context.Context, dEnv *env.DoltEnv, db *sql.DB, apr *argparser.ArgParseResults, source, dbName string) (*mysqlImportProgress, errhand.VerboseError) {
+	var tblNames []string
+	if apr.Contains(allTablesParam) {
+		var err error
+		tblNames, err = mysql.ReadTableNames(ctx, db)
+
+		if err != nil {
+			return nil, errhand.BuildDError("error: failed to list the tables of %s", source).AddCause(err).Build()
+		}
+	} else {
+		for _, tblName := range strings.Split(apr.MustGetValue(tablesParam), ",") {
+			if tblName = strings.TrimSpace(tblName); tblName != "" {
+				tblNames = append(tblNames, tblName)
+			}
+		}
+	}
+
+	if len(tblNames) == 0 {
+		return nil, errhand.BuildDError("error: no tables to import").Build()
+	}
+
+	wheres := make(map[string]string)
+	if whereFile, ok := apr.GetValue(whereFileParam); ok {
+		data, err := dEnv.FS.ReadFile(whereFile)
+
+		if err != nil {
+			return nil, errhand.BuildDError("error: failed to read %s", whereFile).AddCause(err).Build()
+		}
+
+		err = json.Unmarshal(data, &wheres)
+
+		if err != nil {
+			return nil, errhand.BuildDError("error: %s is not a json object mapping tables to conditions", whereFile).AddCause(err).Build()
+		}
+	}
+
+	verr := commands.CheckSparseTablesWithVErr(dEnv, tblNames, apr.Contains(commands.IncludeSparseFlag))
+
+	if verr != nil {
+		return nil, verr
+	}
+
+	root, verr := commands.GetWorkingWithVErr(dEnv)
+
+	if verr != nil {
+		return nil, verr
+	}
+
+	progress := &mysqlImportProgress{
+		Source:         source,
+		Message:        apr.GetValueOrDefault(messageParam, "Import from MySQL database "+dbName),
+		CommitPerTable: apr.Contains(commitPerTableParam),
+		BatchSize:      uint64(apr.GetIntOrDefault(batchSizeParam, defaultMySQLBatchSize)),
+	}
+
+	for _, tblName := range tblNames {
+		if verr := ValidateNewTableName(ctx, root, tblName, ""); verr != nil {
+			return nil, verr
+		}
+
+		if has, err := root.HasTable(ctx, tblName); err != nil {
+			return nil, errhand.BuildDError("error: failed to read the working set").AddCause(err).Build()
+		} else if has && !apr.Contains(forceParam) {
+			return nil, errhand.BuildDError("error: table '%s' already exists. Use -f to overwrite.", tblName).Build()
+		}
+
+		where, ok := wheres[tblName]
+
+		if !ok {
+			where, _ = apr.GetValue(whereParam)
+		}
+
+		progress.Tables = append(progress.Tables, &mysqlTableProgress{Name: tblName, Where: where})
+	}
+
+	return progress, nil
+}
+
+// importMySQLTable imports the remaining rows of a table one batch at a time, saving the progress after each batch.
+func importMySQLTable(ctx context.Context, dEnv *env.DoltEnv, db *sql.DB, source string, progress *mysqlImportProgress, tblProgress *mysqlTableProgress) errhand.VerboseError {
+	displayStrLen = cli.DeleteAndPrint(0, fmt.Sprintf("Importing %s: %d rows", tblProgress.Name, tblProgress.Rows))
+
+	for {
+		root, verr := commands.GetWorkingWithVErr(dEnv)
+
+		if verr != nil {
+			return verr
+		}
+
+		// the table is created by the first batch, even if the table has no rows, and updated by the rest
+		op := mvdata.UpdateOp
+		if tblProgress.LastKey == nil {
+			op = mvdata.OverwriteOp
+		}
+
+		mvOpts := &mvdata.MoveOptions{
+			Operation:  op,
+			TableName:  tblProgress.Name,
+			Src:        mvdata.MySQLDataLocation{DB: db, Source: source, Table: tblProgress.Name},
+			Dest:       mvdata.TableDataLocation{Name: tblProgress.Name},
+			SrcOptions: mvdata.MySQLOptions{Where: tblProgress.Where, After: tblProgress.LastKey, Limit: progress.BatchSize},
+		}
+
+		mover, nDMErr := mvdata.NewDataMover(ctx, root, dEnv.FS, mvOpts, nil)
+
+		if nDMErr != nil {
+			return newDataMoverErrToVerr(mvOpts, nDMErr)
+		}
+
+		_, err := mover.Move(ctx)
+
+		if err != nil {
+			cli.PrintErrln("")
+
+			if pipeline.IsTransformFailure(err) {
+				return badRowVErr(ctx, mover, err)
+			}
+
+			return errhand.BuildDError("error: failed to import %s", tblProgress.Name).AddCause(err).Build()
+		}
+
+		nomsWr := mover.Wr.(noms.NomsMapWriteCloser)
+		err = dEnv.PutTableToWorking(ctx, *nomsWr.GetMap(), nomsWr.GetSchema(), tblProgress.Name)
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to update the working value").AddCause(err).Build()
+		}
+
+		rd := mover.Rd.(*mysql.MySQLReader)
+		tblProgress.Rows += rd.NumRows()
+		if rd.LastKey() != nil {
+			tblProgress.LastKey = rd.LastKey()
+		} else if tblProgress.LastKey == nil {
+			// the table is empty, so no batches are left to import
+			tblProgress.LastKey = []string{}
+		}
+
+		err = progress.save(dEnv)
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to save the progress of the import").AddCause(err).Build()
+		}
+
+		displayStrLen = cli.DeleteAndPrint(displayStrLen, fmt.Sprintf("Importing %s: %d rows", tblProgress.Name, tblProgress.Rows))
+
+		if rd.NumRows() < progress.BatchSize {
+			displayStrLen = 0
+			cli.PrintErrln("")
+			return nil
+		}
+	}
+}
+
+func commitMySQLImport(ctx context.Context, dEnv *env.DoltEnv, tblNames []string, msg string) errhand.VerboseError {
+	err := actions.StageTables(ctx, dEnv, tblNames, false)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to stage the imported tables").AddCause(err).Build()
+	}
+
+	err = actions.CommitStaged(ctx, dEnv, msg, time.Now(), false)
+
+	// a resumed import may have already committed the tables before it was interrupted
+	if actions.IsNothingStaged(err) {
+		return nil
+	} else if err != nil {
+		return errhand.BuildDError("error: failed to commit the imported tables").AddCause(err).Build()
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tblcmds
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/merge"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+const (
+	deleteMergeDriverParam = "delete"
+)
+
+var tblMergeDriverDocs = cli.CommandDocumentationContent{
+	ShortDesc: "List, set or remove the merge drivers of tables",
+	LongDesc: `With no arguments {{.EmphasisLeft}}dolt table merge-driver{{.EmphasisRight}} lists the merge drivers selected for tables. Given a table and a driver it selects the driver for the table, and given just a table with the {{.EmphasisLeft}}--delete|-d{{.EmphasisRight}} flag it removes the table's driver, so that it's merged by the default {{.EmphasisLeft}}cell-wise{{.EmphasisRight}} driver.
+
+A table's merge driver decides how the rows of the table are merged by {{.EmphasisLeft}}dolt merge{{.EmphasisRight}} when the table was changed on both branches. The built in drivers are described by {{.EmphasisLeft}}dolt merge --help{{.EmphasisRight}}.
+
+The drivers are stored in the {{.EmphasisLeft}}dolt_merge_drivers{{.EmphasisRight}} table of the working set, and are staged and committed like any other table. The drivers of the current branch are used when merging. A driver can also be selected for a table with the {{.EmphasisLeft}}merge.driver.{{.LessThan}}table{{.GreaterThan}}{{.EmphasisRight}} config key, which takes precedence over the {{.EmphasisLeft}}dolt_merge_drivers{{.EmphasisRight}} table and isn't versioned.
+`,
+	Synopsis: []string{
+		"[{{.LessThan}}table{{.GreaterThan}} {{.LessThan}}driver{{.GreaterThan}}]",
+		"-d {{.LessThan}}table{{.GreaterThan}}",
+	},
+}
+
+type MergeDriverCmd struct{}
+
+// Name is returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd MergeDriverCmd) Name() string {
+	return "merge-driver"
+}
+
+// Description returns a description of the command
+func (cmd MergeDriverCmd) Description() string {
+	return "List, set or remove the merge drivers of tables"
+}
+
+// EventType returns the type of the event to log
+func (cmd MergeDriverCmd) EventType() eventsapi.ClientEventType {
+	return eventsapi.ClientEventType_TYPE_UNSPECIFIED
+}
+
+// CreateMarkdown creates a markdown file containing the helptext for the command at the given path
+func (cmd MergeDriverCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) error {
+	ap := cmd.createArgParser()
+	return commands.CreateMarkdown(fs, path, cli.GetCommandDocumentation(commandStr, tblMergeDriverDocs, ap))
+}
+
+func (cmd MergeDriverCmd) createArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"table", "The table whose merge driver is set or removed."})
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"driver", "The merge driver to select for the table, one of " + strings.Join(merge.MergeDriverNames(), ", ") + "."})
+	ap.SupportsFlag(deleteMergeDriverParam, "d", "Remove the merge driver of the given table.")
+	return ap
+}
+
+// Exec executes the command
+func (cmd MergeDriverCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cmd.createArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, tblMergeDriverDocs, ap))
+	apr := cli.ParseArgs(ap, args, help)
+
+	var verr errhand.VerboseError
+	switch {
+	case apr.NArg() == 0 && !apr.Contains(deleteMergeDriverParam):
+		verr = printMergeDrivers(ctx, dEnv)
+	case apr.NArg() == 1 && apr.Contains(deleteMergeDriverParam):
+		verr = setMergeDriver(ctx, dEnv, apr.Arg(0), "")
+	case apr.NArg() == 2 && !apr.Contains(deleteMergeDriverParam):
+		verr = setMergeDriver(ctx, dEnv, apr.Arg(0), apr.Arg(1))
+	default:
+		usage()
+		return 1
+	}
+
+	return commands.HandleVErrAndExitCode(verr, usage)
+}
+
+func printMergeDrivers(ctx context.Context, dEnv *env.DoltEnv) errhand.VerboseError {
+	working, verr := commands.GetWorkingWithVErr(dEnv)
+
+	if verr != nil {
+		return verr
+	}
+
+	tblDrivers, err := merge.GetTableMergeDrivers(ctx, working)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to read the merge drivers").AddCause(err).Build()
+	}
+
+	drivers, err := merge.GetMergeDrivers(ctx, working, dEnv.Config)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to read the merge drivers").AddCause(err).Build()
+	}
+
+	tblNames := make([]string, 0, len(drivers))
+	for tblName := range drivers {
+		tblNames = append(tblNames, tblName)
+	}
+
+	sort.Strings(tblNames)
+	for _, tblName := range tblNames {
+		line := fmt.Sprintf("%s\t%s", tblName, drivers[tblName])
+
+		if tblDrivers[tblName] != drivers[tblName] {
+			line += fmt.Sprintf("\t(set by %s%s)", merge.MergeDriverConfigPrefix, tblName)
+		}
+
+		cli.Println(line)
+	}
+
+	return nil
+}
+
+func setMergeDriver(ctx context.Context, dEnv *env.DoltEnv, tblName, driver string) errhand.VerboseError {
+	if driver != "" && !merge.IsValidMergeDriver(driver) {
+		return errhand.BuildDError("error: unknown merge driver '%s'", driver).
+			AddDetails("The merge drivers are: %s", strings.Join(merge.MergeDriverNames(), ", ")).Build()
+	}
+
+	working, verr := commands.GetWorkingWithVErr(dEnv)
+
+	if verr != nil {
+		return verr
+	}
+
+	if has, err := working.HasTable(ctx, tblName); err != nil {
+		return errhand.BuildDError("error: failed to read the working set").AddCause(err).Build()
+	} else if !has && driver != "" {
+		return errhand.BuildDError("error: table '%s' does not exist", tblName).Build()
+	} else if doltdb.HasDoltPrefix(tblName) {
+		return errhand.BuildDError("error: merge drivers can't be set for system tables").Build()
+	}
+
+	if driver == "" {
+		drivers, err := merge.GetTableMergeDrivers(ctx, working)
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to read the merge drivers").AddCause(err).Build()
+		} else if _, ok := drivers[strings.ToLower(tblName)]; !ok {
+			return errhand.BuildDError("error: table '%s' has no merge driver", tblName).Build()
+		}
+	}
+
+	working, err := merge.SetTableMergeDriver(ctx, working, tblName, driver)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to update the merge drivers").AddCause(err).Build()
+	}
+
+	return commands.UpdateWorkingWithVErr(dEnv, working)
+}
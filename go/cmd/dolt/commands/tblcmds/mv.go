@@ -50,6 +50,11 @@ func (cmd MvCmd) Name() string {
 	return "mv"
 }
 
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd MvCmd) LocksRepo() bool {
+	return true
+}
+
 // Description returns a description of the command
 func (cmd MvCmd) Description() string {
 	return "Moves a table"
@@ -100,7 +105,11 @@ func (cmd MvCmd) Exec(ctx context.Context, commandStr string, args []string, dEn
 			errhand.BuildDError("error renaming  table %s", oldName).AddCause(doltdb.ErrSystemTableCannotBeModified).Build(), usage)
 	}
 
-	if verr = ValidateTableNameForCreate(newName); verr != nil {
+	if verr = ValidateNewTableName(ctx, working, newName, oldName); verr != nil {
+		return commands.HandleVErrAndExitCode(verr, usage)
+	}
+
+	if verr = CheckWritePermission(ctx, dEnv, working, oldName, newName); verr != nil {
 		return commands.HandleVErrAndExitCode(verr, usage)
 	}
 
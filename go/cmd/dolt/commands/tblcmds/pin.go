@@ -0,0 +1,154 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tblcmds
+
+import (
+	"context"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+const (
+	deletePinParam = "delete"
+)
+
+var tblPinDocs = cli.CommandDocumentationContent{
+	ShortDesc: "List, add or remove pinned table refs",
+	LongDesc: `With no arguments {{.EmphasisLeft}}dolt table pin{{.EmphasisRight}} lists the pinned table refs. When table refs are given they are added to the pins, or removed from them if the {{.EmphasisLeft}}--delete|-d{{.EmphasisRight}} flag is provided.
+
+A pinned table ref, and all of the rows of the table it refers to, are kept in the repository whether or not any commit references the table state. Table refs are displayed by {{.EmphasisLeft}}dolt table show-ref{{.EmphasisRight}} and {{.EmphasisLeft}}dolt ls --verbose{{.EmphasisRight}}. A table ref can also be fetched from a remote and pinned using {{.EmphasisLeft}}dolt fetch --table-ref{{.EmphasisRight}}.
+`,
+	Synopsis: []string{
+		"[{{.LessThan}}table ref{{.GreaterThan}}...]",
+		"-d {{.LessThan}}table ref{{.GreaterThan}}...",
+	},
+}
+
+type PinCmd struct{}
+
+// Name is returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd PinCmd) Name() string {
+	return "pin"
+}
+
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd PinCmd) LocksRepo() bool {
+	return true
+}
+
+// Description returns a description of the command
+func (cmd PinCmd) Description() string {
+	return "List, add or remove pinned table refs"
+}
+
+// EventType returns the type of the event to log
+func (cmd PinCmd) EventType() eventsapi.ClientEventType {
+	return eventsapi.ClientEventType_TYPE_UNSPECIFIED
+}
+
+// CreateMarkdown creates a markdown file containing the helptext for the command at the given path
+func (cmd PinCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) error {
+	ap := cmd.createArgParser()
+	return commands.CreateMarkdown(fs, path, cli.GetCommandDocumentation(commandStr, tblPinDocs, ap))
+}
+
+func (cmd PinCmd) createArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"table ref", "The hash of a table ref to add to or remove from the pins."})
+	ap.SupportsFlag(deletePinParam, "d", "Remove the given table refs from the pins.")
+	return ap
+}
+
+// Exec executes the command
+func (cmd PinCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cmd.createArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, tblPinDocs, ap))
+	apr := cli.ParseArgs(ap, args, help)
+
+	var verr errhand.VerboseError
+	if apr.NArg() == 0 {
+		if apr.Contains(deletePinParam) {
+			usage()
+			return 1
+		}
+
+		verr = printTablePins(ctx, dEnv)
+	} else if apr.Contains(deletePinParam) {
+		verr = unpinTableRefs(ctx, dEnv, apr.Args())
+	} else {
+		verr = pinTableRefs(ctx, dEnv, apr.Args())
+	}
+
+	return commands.HandleVErrAndExitCode(verr, usage)
+}
+
+func printTablePins(ctx context.Context, dEnv *env.DoltEnv) errhand.VerboseError {
+	pins, err := dEnv.DoltDB.GetTablePins(ctx)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to read the pinned table refs").AddCause(err).Build()
+	}
+
+	for _, h := range pins {
+		cli.Println(h.String())
+	}
+
+	return nil
+}
+
+func pinTableRefs(ctx context.Context, dEnv *env.DoltEnv, tblRefStrs []string) errhand.VerboseError {
+	for _, tblRefStr := range tblRefStrs {
+		h, _, verr := commands.ResolveTableRefWithVErr(dEnv, tblRefStr)
+
+		if verr != nil {
+			return verr
+		}
+
+		err := dEnv.DoltDB.PinTable(ctx, h)
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to pin '%s'", tblRefStr).AddCause(err).Build()
+		}
+	}
+
+	return nil
+}
+
+func unpinTableRefs(ctx context.Context, dEnv *env.DoltEnv, tblRefStrs []string) errhand.VerboseError {
+	for _, tblRefStr := range tblRefStrs {
+		h, verr := commands.ParseTableRefWithVErr(tblRefStr)
+
+		if verr != nil {
+			return verr
+		}
+
+		err := dEnv.DoltDB.UnpinTable(ctx, h)
+
+		if err == doltdb.ErrTablePinNotFound {
+			return errhand.BuildDError("error: table ref '%s' is not pinned", tblRefStr).Build()
+		} else if err != nil {
+			return errhand.BuildDError("error: failed to unpin '%s'", tblRefStr).AddCause(err).Build()
+		}
+	}
+
+	return nil
+}
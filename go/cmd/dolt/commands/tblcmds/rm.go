@@ -43,6 +43,11 @@ func (cmd RmCmd) Name() string {
 	return "rm"
 }
 
+// LocksRepo should return true if the command holds the lock of the repository while it runs
+func (cmd RmCmd) LocksRepo() bool {
+	return true
+}
+
 // Description returns a description of the command
 func (cmd RmCmd) Description() string {
 	return "Deletes a table"
@@ -62,6 +67,7 @@ func (cmd RmCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) err
 func (cmd RmCmd) createArgParser() *argparser.ArgParser {
 	ap := argparser.NewArgParser()
 	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"table", "The table to remove"})
+	commands.SupportsIncludeSparse(ap)
 	return ap
 }
 
@@ -83,6 +89,10 @@ func (cmd RmCmd) Exec(ctx context.Context, commandStr string, args []string, dEn
 		}
 	}
 
+	if verr := commands.CheckSparseTablesWithVErr(dEnv, apr.Args(), apr.Contains(commands.IncludeSparseFlag)); verr != nil {
+		return exitWithVerr(verr)
+	}
+
 	working, verr := commands.GetWorkingWithVErr(dEnv)
 	if verr != nil {
 		return exitWithVerr(verr)
@@ -105,6 +115,10 @@ func exitWithVerr(verr errhand.VerboseError) int {
 }
 
 func removeTables(ctx context.Context, dEnv *env.DoltEnv, tables []string, working *doltdb.RootValue) errhand.VerboseError {
+	if verr := CheckWritePermission(ctx, dEnv, working, tables...); verr != nil {
+		return verr
+	}
+
 	working, err := working.RemoveTables(ctx, tables...)
 
 	if err != nil {
@@ -0,0 +1,118 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tblcmds
+
+import (
+	"context"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+var tblShowRefDocs = cli.CommandDocumentationContent{
+	ShortDesc: "Shows the ref of a table",
+	LongDesc: `{{.EmphasisLeft}}dolt table show-ref{{.EmphasisRight}} prints the table ref of {{.LessThan}}table{{.GreaterThan}} at a given commit. If a commit is not specified the ref of the table in the current working set is printed.
+
+A table ref is the hash of an exact state of a table, including its schema and all of its rows, independent of any commit. It can be used to refer to that table state with {{.EmphasisLeft}}dolt table export --ref{{.EmphasisRight}}, in SQL queries using {{.EmphasisLeft}}AS OF{{.EmphasisRight}}, and it can be pinned using {{.EmphasisLeft}}dolt table pin{{.EmphasisRight}} so that the table state is kept even if no commit references it.
+`,
+	Synopsis: []string{
+		"[{{.LessThan}}commit{{.GreaterThan}}] {{.LessThan}}table{{.GreaterThan}}",
+	},
+}
+
+type ShowRefCmd struct{}
+
+// Name is returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd ShowRefCmd) Name() string {
+	return "show-ref"
+}
+
+// Description returns a description of the command
+func (cmd ShowRefCmd) Description() string {
+	return "Shows the ref of a table"
+}
+
+// EventType returns the type of the event to log
+func (cmd ShowRefCmd) EventType() eventsapi.ClientEventType {
+	return eventsapi.ClientEventType_TYPE_UNSPECIFIED
+}
+
+// CreateMarkdown creates a markdown file containing the helptext for the command at the given path
+func (cmd ShowRefCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) error {
+	ap := cmd.createArgParser()
+	return commands.CreateMarkdown(fs, path, cli.GetCommandDocumentation(commandStr, tblShowRefDocs, ap))
+}
+
+func (cmd ShowRefCmd) createArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"commit", "The commit at which the table's ref is shown."})
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"table", "The table whose ref is shown."})
+	return ap
+}
+
+// Exec executes the command
+func (cmd ShowRefCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cmd.createArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, tblShowRefDocs, ap))
+	apr := cli.ParseArgs(ap, args, help)
+
+	if apr.NArg() < 1 || apr.NArg() > 2 {
+		usage()
+		return 1
+	}
+
+	root, verr := commands.GetWorkingWithVErr(dEnv)
+	if verr != nil {
+		return commands.HandleVErrAndExitCode(verr, usage)
+	}
+
+	tableName := apr.Arg(0)
+	if apr.NArg() == 2 {
+		cm, verr := commands.ResolveCommitWithVErr(dEnv, apr.Arg(0), dEnv.RepoState.CWBHeadRef().String())
+		if verr != nil {
+			return commands.HandleVErrAndExitCode(verr, usage)
+		}
+
+		var err error
+		root, err = cm.GetRootValue()
+
+		if err != nil {
+			verr = errhand.BuildDError("error: failed to get root value").AddCause(err).Build()
+			return commands.HandleVErrAndExitCode(verr, usage)
+		}
+
+		tableName = apr.Arg(1)
+	}
+
+	h, ok, err := root.GetTableHash(ctx, tableName)
+
+	if err != nil {
+		verr = errhand.BuildDError("error: failed to get table hash").AddCause(err).Build()
+		return commands.HandleVErrAndExitCode(verr, usage)
+	}
+
+	if !ok {
+		verr = errhand.BuildDError("Table '%s' not found in root", tableName).Build()
+		return commands.HandleVErrAndExitCode(verr, usage)
+	}
+
+	cli.Println(h.String())
+	return 0
+}
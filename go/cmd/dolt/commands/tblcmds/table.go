@@ -15,27 +15,70 @@
 package tblcmds
 
 import (
+	"context"
+	"errors"
+
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
 )
 
-var Commands = cli.NewSubCommandHandler("table", "Commands for copying, renaming, deleting, and exporting tables.", []cli.Command{
+var Commands = cli.NewSubCommandHandler("table", "Commands for copying, renaming, deleting, exporting and pinning tables, and selecting their merge drivers.", []cli.Command{
 	ImportCmd{},
 	ExportCmd{},
 	RmCmd{},
 	MvCmd{},
 	CpCmd{},
+	ShowRefCmd{},
+	PinCmd{},
+	MergeDriverCmd{},
 })
 
 // ValidateTableNameForCreate validates the given table name for creation as a user table, returning an error if the
 // table name is not valid.
 func ValidateTableNameForCreate(tableName string) errhand.VerboseError {
-	if !doltdb.IsValidTableName(tableName) {
-		return errhand.BuildDError("'%s' is not a valid table name\ntable names must match the regular expression: %s",
-			tableName, doltdb.TableNameRegexStr).Build()
+	if err := doltdb.ValidateTableName(tableName); err != nil {
+		return errhand.BuildDError("'%s' is not a valid table name\n%s", tableName, err.(doltdb.IdentifierError).Reason).Build()
 	} else if doltdb.HasDoltPrefix(tableName) {
 		return errhand.BuildDError("'%s' is not a valid table name\ntable names beginning with dolt_ are reserved for internal use", tableName).Build()
 	}
 	return nil
 }
+
+// ValidateNewTableName validates the given table name for a table being created in |root|, or being renamed from
+// |oldName|. Table names are case insensitive, so the name may not differ only by case from another table's.
+func ValidateNewTableName(ctx context.Context, root *doltdb.RootValue, tableName, oldName string) errhand.VerboseError {
+	if verr := ValidateTableNameForCreate(tableName); verr != nil {
+		return verr
+	}
+
+	err := doltdb.ValidateNewTableName(ctx, root, tableName, oldName)
+
+	if doltdb.IsIdentifierError(err) {
+		return errhand.BuildDError("%s", err.Error()).Build()
+	} else if err != nil {
+		return errhand.BuildDError("error: failed to read tables").AddCause(err).Build()
+	}
+
+	return nil
+}
+
+// CheckWritePermission returns an error if the permission policies of |root| don't let the user of the CLI write the
+// tables given on the current branch. See env.DoltEnv.Principal.
+func CheckWritePermission(ctx context.Context, dEnv *env.DoltEnv, root *doltdb.RootValue, tblNames ...string) errhand.VerboseError {
+	principal := dEnv.Principal()
+	branch := dEnv.RepoState.CWBHeadRef().GetPath()
+
+	for _, tblName := range tblNames {
+		err := doltdb.CheckWritePermission(ctx, root, principal, branch, tblName)
+
+		if errors.Is(err, doltdb.ErrPermissionDenied) {
+			return errhand.BuildDError("error: %s", err.Error()).Build()
+		} else if err != nil {
+			return errhand.BuildDError("error: failed to read the permission policies").AddCause(err).Build()
+		}
+	}
+
+	return nil
+}
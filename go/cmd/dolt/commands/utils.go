@@ -17,10 +17,12 @@ package commands
 import (
 	"context"
 
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions"
+	"github.com/liquidata-inc/dolt/go/store/hash"
 )
 
 var fwtStageName = "fwt"
@@ -126,3 +128,47 @@ func MaybeGetCommitWithVErr(dEnv *env.DoltEnv, maybeCommit string) (*doltdb.Comm
 
 	return cm, nil
 }
+
+// ParseTableRefWithVErr parses the hash of a table ref, such as the hashes displayed by dolt ls --verbose
+func ParseTableRefWithVErr(tblRefStr string) (hash.Hash, errhand.VerboseError) {
+	h, ok := hash.MaybeParse(tblRefStr)
+
+	if !ok {
+		return hash.Hash{}, errhand.BuildDError("'%s' is not a valid table ref", tblRefStr).Build()
+	}
+
+	return h, nil
+}
+
+// ResolveTableRefWithVErr returns the hash and the table of the table ref |tblRefStr|
+func ResolveTableRefWithVErr(dEnv *env.DoltEnv, tblRefStr string) (hash.Hash, *doltdb.Table, errhand.VerboseError) {
+	h, verr := ParseTableRefWithVErr(tblRefStr)
+
+	if verr != nil {
+		return hash.Hash{}, nil, verr
+	}
+
+	tbl, err := dEnv.DoltDB.ReadTableFromHash(context.TODO(), h)
+
+	if err != nil {
+		if doltdb.IsNotFoundErr(err) {
+			return hash.Hash{}, nil, errhand.BuildDError("table ref '%s' not found", tblRefStr).Build()
+		} else if err == doltdb.ErrFoundHashNotATable {
+			return hash.Hash{}, nil, errhand.BuildDError("'%s' is not a table ref", tblRefStr).Build()
+		} else {
+			return hash.Hash{}, nil, errhand.BuildDError("Unexpected error resolving '%s'", tblRefStr).AddCause(err).Build()
+		}
+	}
+
+	return h, tbl, nil
+}
+
+// AddIncompatibleFormatDetails adds instructions for resolving |err| to |bdr| if |err| is a
+// *chunks.ErrIncompatibleFormat, such as the result of pushing to or pulling from a remote using another storage format.
+func AddIncompatibleFormatDetails(bdr *errhand.DErrorBuilder, err error, dEnv *env.DoltEnv) *errhand.DErrorBuilder {
+	if details, ok := cli.IncompatibleFormatDetails(err, dEnv.Version); ok {
+		bdr.AddDetails(details)
+	}
+
+	return bdr
+}
@@ -0,0 +1,231 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	eventsapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+const (
+	workspaceAllFlag = "all"
+)
+
+var workspaceDocs = cli.CommandDocumentationContent{
+	ShortDesc: `List or delete sql-server workspaces`,
+	LongDesc: `When {{.EmphasisLeft}}dolt sql-server{{.EmphasisRight}} is started with {{.EmphasisLeft}}--workspaces{{.EmphasisRight}}, the uncommitted changes of each session, or of each user, are kept in a workspace under {{.EmphasisLeft}}refs/workspaces/{{.EmphasisRight}} rather than in the shared working set. A workspace is kept after its sessions have ended, so that its changes can be picked up again by the next session of the user.
+
+With no arguments, the workspaces are listed. With {{.EmphasisLeft}}-v{{.EmphasisRight}}, the tables changed in each workspace and the number of commits made to the current branch since the workspace's changes were made are shown as well.
+
+With a {{.EmphasisLeft}}-d{{.EmphasisRight}}, {{.LessThan}}workspace{{.GreaterThan}} will be deleted. You may specify more than one workspace for deletion, or {{.EmphasisLeft}}--all{{.EmphasisRight}} to delete every workspace. Workspaces which hold uncommitted changes are only deleted with {{.EmphasisLeft}}-f{{.EmphasisRight}}. Workspaces can't be deleted by this command while a sql-server holds the lock of the repository; delete them from the {{.EmphasisLeft}}dolt_workspaces{{.EmphasisRight}} system table instead, which refuses to delete workspaces in use by a session.`,
+	Synopsis: []string{
+		`[-v]`,
+		`-d [-f] {{.LessThan}}workspace{{.GreaterThan}}...`,
+		`-d [-f] --all`,
+	},
+}
+
+type WorkspaceCmd struct{}
+
+// Name is returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd WorkspaceCmd) Name() string {
+	return "workspace"
+}
+
+// Description returns a description of the command
+func (cmd WorkspaceCmd) Description() string {
+	return "List, delete sql-server workspaces."
+}
+
+// CreateMarkdown creates a markdown file containing the helptext for the command at the given path
+func (cmd WorkspaceCmd) CreateMarkdown(fs filesys.Filesys, path, commandStr string) error {
+	ap := cmd.createArgParser()
+	return CreateMarkdown(fs, path, cli.GetCommandDocumentation(commandStr, workspaceDocs, ap))
+}
+
+func (cmd WorkspaceCmd) createArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.SupportsFlag(deleteFlag, "d", "Delete a workspace.")
+	ap.SupportsFlag(forceFlag, "f", "Delete workspaces which hold uncommitted changes.")
+	ap.SupportsFlag(workspaceAllFlag, "", "When deleting, delete every workspace.")
+	ap.SupportsFlag(verboseFlag, "v", "When listing, show the tables changed in each workspace and how far behind the current branch it is.")
+	return ap
+}
+
+// EventType returns the type of the event to log
+func (cmd WorkspaceCmd) EventType() eventsapi.ClientEventType {
+	return eventsapi.ClientEventType_TYPE_UNSPECIFIED
+}
+
+// Exec executes the command
+func (cmd WorkspaceCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cmd.createArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.GetCommandDocumentation(commandStr, workspaceDocs, ap))
+	apr := cli.ParseArgs(ap, args, help)
+
+	var verr errhand.VerboseError
+	switch {
+	case apr.Contains(deleteFlag):
+		verr = deleteWorkspaces(ctx, dEnv, apr)
+	case apr.NArg() == 0 && !apr.Contains(workspaceAllFlag):
+		verr = printWorkspaces(ctx, dEnv, apr)
+	default:
+		verr = errhand.BuildDError("").SetPrintUsage().Build()
+	}
+
+	return HandleVErrAndExitCode(verr, usage)
+}
+
+func printWorkspaces(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgParseResults) errhand.VerboseError {
+	workspaces, err := actions.GetWorkspaces(ctx, dEnv.DoltDB)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to read refs from db").AddCause(err).Build()
+	}
+
+	if !apr.Contains(verboseFlag) {
+		for _, ws := range workspaces {
+			cli.Println(ws.Ref.GetPath())
+		}
+
+		return nil
+	}
+
+	head, err := dEnv.DoltDB.Resolve(ctx, dEnv.RepoState.CWBHeadSpec())
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to get the head of the current branch").AddCause(err).Build()
+	}
+
+	for _, ws := range workspaces {
+		div, err := ws.Divergence(ctx, dEnv.DoltDB, head)
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to compare workspace '%s' with the current branch", ws.Ref.GetPath()).AddCause(err).Build()
+		}
+
+		changes := "no changes"
+		if len(div.ChangedTables) > 0 {
+			changes = "changed: " + strings.Join(div.ChangedTables, ", ")
+		}
+
+		cli.Println(fmt.Sprintf("%-48s%s, %d commits behind", ws.Ref.GetPath(), changes, div.CommitsBehind))
+	}
+
+	return nil
+}
+
+func deleteWorkspaces(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgParseResults) errhand.VerboseError {
+	// listing workspaces is fine while a sql-server is running, but deleting them could delete the changes of a session
+	lock, err := dEnv.Lock(env.NewLockInfo("dolt workspace -d", 0))
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to lock the repository").AddCause(err).Build()
+	}
+
+	defer lock.Unlock()
+
+	var workspaces []actions.Workspace
+	if apr.Contains(workspaceAllFlag) {
+		if apr.NArg() != 0 {
+			return errhand.BuildDError("").SetPrintUsage().Build()
+		}
+
+		workspaces, err = actions.GetWorkspaces(ctx, dEnv.DoltDB)
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to read refs from db").AddCause(err).Build()
+		}
+	} else {
+		if apr.NArg() == 0 {
+			return errhand.BuildDError("").SetPrintUsage().Build()
+		}
+
+		for i := 0; i < apr.NArg(); i++ {
+			name := apr.Arg(i)
+			ws, err := actions.GetWorkspace(ctx, dEnv.DoltDB, name)
+
+			if err == doltdb.ErrWorkspaceNotFound {
+				return errhand.BuildDError("error: workspace '%s' not found.", name).Build()
+			} else if err != nil {
+				return errhand.BuildDError("fatal: Unexpected error reading workspace '%s'", name).AddCause(err).Build()
+			}
+
+			workspaces = append(workspaces, ws)
+		}
+	}
+
+	for _, ws := range workspaces {
+		name := ws.Ref.GetPath()
+
+		if !apr.Contains(forceFlag) {
+			changed, err := workspaceHasChanges(ws)
+
+			if err != nil {
+				return errhand.BuildDError("fatal: Unexpected error reading workspace '%s'", name).AddCause(err).Build()
+			} else if changed {
+				return errhand.BuildDError("error: workspace '%s' has uncommitted changes.", name).
+					AddDetails("If you are sure you want to delete it, run 'dolt workspace -d -f %s'.", name).Build()
+			}
+		}
+
+		err := actions.DeleteWorkspace(ctx, dEnv.DoltDB, name)
+
+		if err != nil {
+			return errhand.BuildDError("fatal: Unexpected error deleting workspace '%s'", name).AddCause(err).Build()
+		}
+	}
+
+	return nil
+}
+
+// workspaceHasChanges returns whether the root of the workspace differs from the root of its base commit.
+func workspaceHasChanges(ws actions.Workspace) (bool, error) {
+	root, err := ws.Commit.GetRootValue()
+
+	if err != nil {
+		return false, err
+	}
+
+	baseRoot, err := ws.Base.GetRootValue()
+
+	if err != nil {
+		return false, err
+	}
+
+	h, err := root.HashOf()
+
+	if err != nil {
+		return false, err
+	}
+
+	baseH, err := baseRoot.HashOf()
+
+	if err != nil {
+		return false, err
+	}
+
+	return h != baseH, nil
+}
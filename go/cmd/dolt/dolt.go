@@ -27,14 +27,19 @@ import (
 
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands/admincmds"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands/cnfcmds"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands/credcmds"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands/indexcmds"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands/schcmds"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands/sparsecmds"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands/sqlserver"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands/tblcmds"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/remotestorage"
 	"github.com/liquidata-inc/dolt/go/libraries/events"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
@@ -58,6 +63,8 @@ var doltCommand = cli.NewSubCommandHandler("dolt", "it's git for data", []cli.Co
 	commands.BlameCmd{},
 	commands.MergeCmd{},
 	commands.BranchCmd{},
+	commands.TagCmd{},
+	commands.WorkspaceCmd{},
 	commands.CheckoutCmd{},
 	commands.RemoteCmd{},
 	commands.PushCmd{},
@@ -72,19 +79,26 @@ var doltCommand = cli.NewSubCommandHandler("dolt", "it's git for data", []cli.Co
 	schcmds.Commands,
 	tblcmds.Commands,
 	cnfcmds.Commands,
+	sparsecmds.Commands,
+	indexcmds.Commands,
 	commands.SendMetricsCmd{},
+	commands.RemoteServeCmd{},
 	dumpDocsCommand,
 	commands.MigrateCmd{},
+	commands.SizeCmd{},
+	admincmds.Commands,
 })
 
 func init() {
 	dumpDocsCommand.DoltCommand = doltCommand
 	sqlserver.CliVersion = Version
+	remotestorage.DoltVersion = Version
 }
 
 const chdirFlag = "--chdir"
 const profFlag = "--prof"
 const csMetricsFlag = "--csmetrics"
+const jsonErrorsFlag = "--json-errors"
 const cpuProf = "cpu"
 const memProf = "mem"
 const blockingProf = "blocking"
@@ -100,7 +114,7 @@ func runMain() int {
 	csMetrics := false
 	if len(args) > 0 {
 		var doneDebugFlags bool
-		for !doneDebugFlags {
+		for !doneDebugFlags && len(args) > 0 {
 			switch args[0] {
 			case profFlag:
 				switch args[1] {
@@ -137,6 +151,11 @@ func runMain() int {
 				csMetrics = true
 				args = args[1:]
 
+			// Errors are printed on stderr as a JSON object giving their category and exit code, rather than as prose.
+			case jsonErrorsFlag:
+				errhand.SetJSONErrors(true)
+				args = args[1:]
+
 			default:
 				doneDebugFlags = true
 			}
@@ -196,6 +215,9 @@ func runMain() int {
 
 	res := doltCommand.Exec(context.Background(), "dolt", args, dEnv)
 
+	// the schema cache only saves work, so failing to write it doesn't fail the command
+	_ = doltdb.SaveSchemaCache()
+
 	if csMetrics && dEnv.DoltDB != nil {
 		metricsSummary := dEnv.DoltDB.CSMetricsSummary()
 		cli.PrintErrln(metricsSummary)
@@ -225,6 +247,8 @@ func commandNeedsMigrationCheck(args []string) bool {
 		commands.DiffCmd{},
 		commands.MergeCmd{},
 		commands.BranchCmd{},
+		commands.TagCmd{},
+		commands.WorkspaceCmd{},
 		commands.CheckoutCmd{},
 		commands.RemoteCmd{},
 		commands.PushCmd{},
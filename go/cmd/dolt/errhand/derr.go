@@ -19,6 +19,8 @@ import (
 	"strings"
 
 	"github.com/fatih/color"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/errcat"
 )
 
 type DErrorBuilder struct {
@@ -26,6 +28,7 @@ type DErrorBuilder struct {
 	details    string
 	cause      error
 	printUsage bool
+	category   errcat.Category
 }
 
 func BuildDError(dispFmt string, args ...interface{}) *DErrorBuilder {
@@ -35,7 +38,7 @@ func BuildDError(dispFmt string, args ...interface{}) *DErrorBuilder {
 		dispMsg = fmt.Sprintf(dispFmt, args...)
 	}
 
-	return &DErrorBuilder{dispMsg, "", nil, false}
+	return &DErrorBuilder{dispMsg: dispMsg}
 }
 
 func BuildIf(err error, dispFmt string, args ...interface{}) *DErrorBuilder {
@@ -49,7 +52,7 @@ func BuildIf(err error, dispFmt string, args ...interface{}) *DErrorBuilder {
 		dispMsg = fmt.Sprintf(dispFmt, args...)
 	}
 
-	return &DErrorBuilder{dispMsg, "", err, false}
+	return &DErrorBuilder{dispMsg: dispMsg, cause: err}
 }
 
 func (builder *DErrorBuilder) AddDetails(detailsFmt string, args ...interface{}) *DErrorBuilder {
@@ -90,12 +93,23 @@ func (builder *DErrorBuilder) SetPrintUsage() *DErrorBuilder {
 	return builder
 }
 
+// SetCategory sets the category of the error, which decides the exit code of a command failing with it. Without a
+// category the error has the category of its cause.
+func (builder *DErrorBuilder) SetCategory(cat errcat.Category) *DErrorBuilder {
+	if builder == nil {
+		return nil
+	}
+
+	builder.category = cat
+	return builder
+}
+
 func (builder *DErrorBuilder) Build() VerboseError {
 	if builder == nil {
 		return nil
 	}
 
-	return &DError{builder.dispMsg, builder.details, builder.cause, builder.printUsage}
+	return &DError{builder.dispMsg, builder.details, builder.cause, builder.printUsage, builder.category}
 }
 
 type DError struct {
@@ -103,6 +117,7 @@ type DError struct {
 	details    string
 	cause      error
 	printUsage bool
+	category   errcat.Category
 }
 
 // Returns a verbose error using the error given. If the error given is already a VerboseError, returns it. Otherwise,
@@ -116,12 +131,12 @@ func VerboseErrorFromError(err error) VerboseError {
 		return verr
 	}
 
-	builder := &DErrorBuilder{err.Error(), "", nil, false}
+	builder := &DErrorBuilder{dispMsg: err.Error(), category: errcat.Of(err)}
 	return builder.Build()
 }
 
 func NewDError(dispMsg, details string, cause error, printUsage bool) *DError {
-	return &DError{dispMsg, details, cause, printUsage}
+	return &DError{dispMsg, details, cause, printUsage, errcat.Unknown}
 }
 
 func (derr *DError) Error() string {
@@ -161,6 +176,20 @@ func (derr *DError) ShouldPrintUsage() bool {
 	return derr.printUsage
 }
 
+// Category returns the category of the error, which is the category of its cause if it wasn't given one.
+func (derr *DError) Category() errcat.Category {
+	if derr.category != errcat.Unknown {
+		return derr.category
+	}
+
+	return errcat.Of(derr.cause)
+}
+
+// Unwrap returns the cause of the error.
+func (derr *DError) Unwrap() error {
+	return derr.cause
+}
+
 func indent(str, indentStr string) string {
 	lines := strings.Split(str, "\n")
 	return indentStr + strings.Join(lines, "\n"+indentStr)
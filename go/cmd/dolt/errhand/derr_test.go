@@ -15,8 +15,14 @@
 package errhand
 
 import (
+	"encoding/json"
 	"errors"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/errcat"
 )
 
 func TestIndent(t *testing.T) {
@@ -71,3 +77,51 @@ func TestBuildIf(t *testing.T) {
 		t.Fatal("Should have built a valid display error.")
 	}
 }
+
+func TestDErrorCategory(t *testing.T) {
+	errNotFound := errcat.New(errcat.NotFound, "thing not found")
+
+	derr := BuildDError("error: uncategorized").Build()
+	assert.Equal(t, errcat.Unknown, errcat.Of(derr))
+	assert.Equal(t, 1, ExitCode(derr))
+
+	derr = BuildDError("error: looking for thing").AddCause(errNotFound).Build()
+	assert.Equal(t, errcat.NotFound, errcat.Of(derr))
+	assert.Equal(t, errcat.ExitCodeNotFound, ExitCode(derr))
+	assert.True(t, errors.Is(derr, errNotFound))
+
+	derr = BuildDError("error: updating thing").AddCause(errNotFound).SetCategory(errcat.ConcurrentModification).Build()
+	assert.Equal(t, errcat.ConcurrentModification, errcat.Of(derr))
+
+	derr = BuildDError("outer").AddCause(BuildDError("inner").SetCategory(errcat.Conflict).Build()).Build()
+	assert.Equal(t, errcat.Conflict, errcat.Of(derr))
+
+	assert.Equal(t, errcat.NotFound, errcat.Of(VerboseErrorFromError(errNotFound)))
+	assert.Equal(t, 1, ExitCode(ErrVerboseEmpty))
+}
+
+func TestFormat(t *testing.T) {
+	cause := BuildDError("error: inner").AddCause(errors.New("root cause")).Build()
+	derr := BuildDError("error: outer").AddDetails("details").AddCause(cause).SetCategory(errcat.Network).Build()
+
+	assert.Equal(t, derr.Verbose(), Format(derr))
+
+	SetJSONErrors(true)
+	defer SetJSONErrors(false)
+
+	var jerr JSONError
+	err := json.Unmarshal([]byte(Format(derr)), &jerr)
+	require.NoError(t, err)
+	assert.Equal(t, JSONError{
+		Category: "network",
+		ExitCode: errcat.ExitCodeNetwork,
+		Message:  "error: outer",
+		Details:  "details",
+		Cause:    "error: inner: root cause",
+	}, jerr)
+
+	var emptyJErr JSONError
+	err = json.Unmarshal([]byte(Format(ErrVerboseEmpty)), &emptyJErr)
+	require.NoError(t, err)
+	assert.Equal(t, JSONError{Category: "unknown", ExitCode: 1}, emptyJErr)
+}
@@ -0,0 +1,102 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errhand
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/errcat"
+)
+
+var jsonErrors = false
+
+// SetJSONErrors sets whether Format formats errors as JSON objects, as the --json-errors flag does.
+func SetJSONErrors(enabled bool) {
+	jsonErrors = enabled
+}
+
+// JSONErrors returns whether errors are formatted as JSON objects.
+func JSONErrors() bool {
+	return jsonErrors
+}
+
+// ExitCode returns the exit code of a command failing with |err|, which is the exit code of the error's category.
+func ExitCode(err error) int {
+	return errcat.Of(err).ExitCode()
+}
+
+// JSONError is the structured form of an error printed by --json-errors.
+type JSONError struct {
+	Category string `json:"category"`
+	ExitCode int    `json:"exit_code"`
+	Message  string `json:"message"`
+	Details  string `json:"details,omitempty"`
+	Cause    string `json:"cause,omitempty"`
+}
+
+// NewJSONError returns the structured form of |err|.
+func NewJSONError(err error) JSONError {
+	cat := errcat.Of(err)
+	jerr := JSONError{Category: cat.String(), ExitCode: cat.ExitCode()}
+
+	if derr, ok := err.(*DError); ok {
+		jerr.Message = derr.displayMsg
+		jerr.Details = derr.details
+
+		if derr.cause != nil {
+			jerr.Cause = plainMessage(derr.cause)
+		}
+	} else {
+		jerr.Message = err.Error()
+	}
+
+	return jerr
+}
+
+// Format returns |verr| as it's printed when a command fails with it. That's its verbose message, or its JSON object
+// when errors are formatted as JSON.
+func Format(verr VerboseError) string {
+	if !jsonErrors {
+		return verr.Verbose()
+	}
+
+	// messages are printed on a terminal, so characters like < and > in them aren't escaped as they would be for html
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(NewJSONError(verr)); err != nil {
+		return verr.Verbose()
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// plainMessage returns the message of |err| and its causes without the color of a DError's message.
+func plainMessage(err error) string {
+	derr, ok := err.(*DError)
+
+	if !ok {
+		return err.Error()
+	} else if derr.cause == nil {
+		return derr.displayMsg
+	} else if derr.displayMsg == "" {
+		return plainMessage(derr.cause)
+	}
+
+	return derr.displayMsg + ": " + plainMessage(derr.cause)
+}
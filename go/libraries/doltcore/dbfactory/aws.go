@@ -137,7 +137,8 @@ func (fact AWSFactory) newChunkStore(ctx context.Context, nbf *types.NomsBinForm
 	}
 
 	sess := session.Must(session.NewSessionWithOptions(opts))
-	return nbs.NewAWSStore(ctx, nbf.VersionString(), parts[0], dbName, parts[1], s3.New(sess), dynamodb.New(sess), defaultMemTableSize)
+	pushStates := nbs.NewPushStateStore(params[PushStateDirParam])
+	return nbs.NewAWSStoreWithPushStates(ctx, nbf.VersionString(), parts[0], dbName, parts[1], s3.New(sess), dynamodb.New(sess), defaultMemTableSize, pushStates)
 }
 
 func validatePath(path string) (string, error) {
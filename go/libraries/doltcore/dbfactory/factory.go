@@ -20,6 +20,7 @@ import (
 	"net/url"
 	"strings"
 
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/remotestorage"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/earl"
 	"github.com/liquidata-inc/dolt/go/store/datas"
 	"github.com/liquidata-inc/dolt/go/store/types"
@@ -44,15 +45,28 @@ const (
 	// HTTPScheme
 	HTTPScheme = "http"
 
+	// SSHScheme
+	SSHScheme = "ssh"
+
 	defaultScheme       = HTTPSScheme
 	defaultMemTableSize = 256 * 1024 * 1024
 )
 
+// PushStateDirParam is a creation parameter giving the directory in which a remote keeps the state of its uploads of
+// table files, so that an upload which fails partway is resumed by the next push rather than restarted.
+const PushStateDirParam = "push-state-dir"
+
 // DBFactory is an interface for creating concrete datas.Database instances which may have different backing stores.
 type DBFactory interface {
 	CreateDB(ctx context.Context, nbf *types.NomsBinFormat, urlObj *url.URL, params map[string]string) (datas.Database, error)
 }
 
+// HealthChecker is implemented by the DBFactory implementations which can check that a database can be read from in
+// more detail than by creating it.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context, nbf *types.NomsBinFormat, urlObj *url.URL, params map[string]string) remotestorage.HealthCheckResult
+}
+
 // DBFactories is a map from url scheme name to DBFactory.  Additional factories can be added to the DBFactories map
 // from external packages.
 var DBFactories = map[string]DBFactory{
@@ -60,6 +74,7 @@ var DBFactories = map[string]DBFactory{
 	GSScheme:   GSFactory{},
 	FileScheme: FileFactory{},
 	MemScheme:  MemFactory{},
+	SSHScheme:  SSHFactory{},
 }
 
 // InitializeFactories initializes any factories that rely on a GRPCConnectionProvider (Namely http and https)
@@ -88,3 +103,44 @@ func CreateDB(ctx context.Context, nbf *types.NomsBinFormat, urlStr string, para
 
 	return nil, fmt.Errorf("unknown url scheme: '%s'", urlObj.Scheme)
 }
+
+// HealthCheck checks that the database at urlStr can be read from. Databases whose DBFactory isn't a HealthChecker are
+// checked by creating the database and reading its datasets, which is reported as the storage access phase.
+func HealthCheck(ctx context.Context, nbf *types.NomsBinFormat, urlStr string, params map[string]string) (remotestorage.HealthCheckResult, error) {
+	urlObj, err := earl.Parse(urlStr)
+
+	if err != nil {
+		return remotestorage.HealthCheckResult{}, err
+	}
+
+	scheme := urlObj.Scheme
+	if len(scheme) == 0 {
+		scheme = defaultScheme
+	}
+
+	fact, ok := DBFactories[strings.ToLower(scheme)]
+
+	if !ok {
+		return remotestorage.HealthCheckResult{}, fmt.Errorf("unknown url scheme: '%s'", urlObj.Scheme)
+	}
+
+	if hc, ok := fact.(HealthChecker); ok {
+		return hc.HealthCheck(ctx, nbf, urlObj, params), nil
+	}
+
+	var res remotestorage.HealthCheckResult
+	res.Run(remotestorage.StorageAccessPhase, func() error {
+		db, err := fact.CreateDB(ctx, nbf, urlObj, params)
+
+		if err != nil {
+			return err
+		}
+
+		defer db.Close()
+
+		_, err = db.Datasets(ctx)
+		return err
+	})
+
+	return res, nil
+}
@@ -18,6 +18,8 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"strings"
+	"time"
 
 	"google.golang.org/grpc"
 
@@ -73,7 +75,44 @@ func (fact DoltRemoteFactory) newChunkStore(ctx context.Context, nbf *types.Noms
 
 	if err == remotestorage.ErrInvalidDoltSpecPath {
 		return nil, fmt.Errorf("invalid dolt url '%s'", urlObj.String())
+	} else if err != nil {
+		return nil, err
+	}
+
+	if dir, ok := params[PushStateDirParam]; ok {
+		cs = cs.WithPushStateDir(dir)
+	}
+
+	return cs, nil
+}
+
+// HealthCheck checks that the host of the remote can be reached, and then that the remote can be read from. The phases
+// run are described by remotestorage.HealthCheckPhase.
+func (fact DoltRemoteFactory) HealthCheck(ctx context.Context, nbf *types.NomsBinFormat, urlObj *url.URL, params map[string]string) remotestorage.HealthCheckResult {
+	hostAndPort := urlObj.Host
+	if strings.IndexRune(hostAndPort, ':') == -1 {
+		if fact.insecure {
+			hostAndPort += ":80"
+		} else {
+			hostAndPort += ":443"
+		}
+	}
+
+	res := remotestorage.CheckHost(ctx, hostAndPort, fact.insecure)
+
+	if res.Err() != nil {
+		return res
+	}
+
+	// creating the chunk store requests the repository's metadata, so a failure is reported as an authentication failure
+	start := time.Now()
+	cs, err := fact.newChunkStore(ctx, nbf, urlObj, params)
+
+	if err != nil {
+		res.Fail(remotestorage.AuthenticationPhase, time.Since(start), err)
+		return res
 	}
 
-	return cs, err
+	cs.(*remotestorage.DoltChunkStore).HealthCheck(ctx, &res)
+	return res
 }
@@ -0,0 +1,323 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbfactory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/remotestorage"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/remotestream"
+	"github.com/liquidata-inc/dolt/go/store/datas"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const (
+	// SSHIdentityParam is a creation parameter that can be used to set the private key file used to authenticate with
+	// the host, instead of the keys of ssh-agent and the default identity files.
+	SSHIdentityParam = "ssh-identity"
+
+	// SSHKnownHostsParam is a creation parameter that can be used to set the known hosts file the host's key is
+	// checked against, instead of ~/.ssh/known_hosts.
+	SSHKnownHostsParam = "ssh-known-hosts"
+
+	defaultSSHPort = "22"
+)
+
+// RemoteServeCommand is the command run on the host of an ssh remote. It's followed by the quoted path of the remote.
+var RemoteServeCommand = "dolt remote-serve --stdio"
+
+var defaultIdentityFiles = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// SSHFactory is a DBFactory implementation for creating databases on hosts reached over ssh, with urls of the form
+// ssh://[user@]host[:port]/path. It runs RemoteServeCommand on the host, and talks to it over the session's stdin and
+// stdout. The path is the directory of a dolt repository, or of the noms files of a database, and a path starting with
+// /~/ is relative to the user's home directory.
+//
+// The host's key must be in the known hosts file. The user is authenticated with the keys of ssh-agent, if it's
+// running, and with the unencrypted keys of the default identity files in ~/.ssh.
+type SSHFactory struct {
+}
+
+// CreateDB creates a database backed by a dolt remote-serve process on a host reached over ssh.
+func (fact SSHFactory) CreateDB(ctx context.Context, nbf *types.NomsBinFormat, urlObj *url.URL, params map[string]string) (datas.Database, error) {
+	path, err := url.PathUnescape(urlObj.Path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(path, "/~/") {
+		path = path[1:]
+	}
+
+	client, err := dialSSH(urlObj, params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := client.NewSession()
+
+	if err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+
+	stdin, err := session.StdinPipe()
+
+	if err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+
+	stdout, err := session.StdoutPipe()
+
+	if err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+
+	stderr := &syncBuffer{}
+	session.Stderr = stderr
+
+	cmd := RemoteServeCommand + " " + shellQuote(path)
+	err = session.Start(cmd)
+
+	if err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+
+	conn := remotestream.NewConn(stdout, stdin, func() error {
+		_ = stdin.Close()
+		_ = session.Close()
+		return client.Close()
+	})
+
+	// the remote command's own error explains a failure better than the broken stream it leaves
+	remoteErr := func(err error) error {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("'%s' failed on %s: %s", RemoteServeCommand, urlObj.Hostname(), msg)
+		}
+
+		return err
+	}
+
+	streamClient, err := remotestream.NewClient(ctx, conn)
+
+	if err != nil {
+		return nil, remoteErr(err)
+	}
+
+	cs, err := remotestorage.NewDoltChunkStore(ctx, nbf, urlObj.Hostname(), path, urlObj.Host, streamClient.ChunkStoreClient())
+
+	if err != nil {
+		_ = streamClient.Close()
+		return nil, remoteErr(err)
+	}
+
+	return datas.NewDatabase(sshChunkStore{cs.WithHTTPFetcher(streamClient), streamClient}), nil
+}
+
+// sshChunkStore closes the ssh connection along with the chunk store.
+type sshChunkStore struct {
+	*remotestorage.DoltChunkStore
+	client *remotestream.Client
+}
+
+func (cs sshChunkStore) Close() error {
+	err := cs.DoltChunkStore.Close()
+
+	if closeErr := cs.client.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+func dialSSH(urlObj *url.URL, params map[string]string) (*ssh.Client, error) {
+	userName := urlObj.User.Username()
+
+	if userName == "" {
+		usr, err := user.Current()
+
+		if err != nil {
+			return nil, err
+		}
+
+		userName = usr.Username
+	}
+
+	port := urlObj.Port()
+	if port == "" {
+		port = defaultSSHPort
+	}
+
+	addr := net.JoinHostPort(urlObj.Hostname(), port)
+	auth, err := sshAuthMethods(params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	knownHostsFile, err := sshKnownHostsFile(params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	knownHostsCallback, err := knownhosts.New(knownHostsFile)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the known hosts file %s: %v", knownHostsFile, err)
+	}
+
+	// ssh.Dial doesn't wrap the error of the callback, so it's kept to tell why the host key was refused
+	var keyErr *knownhosts.KeyError
+	hostKeyCallback := func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := knownHostsCallback(hostname, remote, key)
+
+		if ke, ok := err.(*knownhosts.KeyError); ok {
+			keyErr = ke
+		}
+
+		return err
+	}
+
+	config := &ssh.ClientConfig{User: userName, Auth: auth, HostKeyCallback: hostKeyCallback}
+	client, err := ssh.Dial("tcp", addr, config)
+
+	// the host may have offered a type of key other than the types known for it, so try again with only known types
+	if keyErr != nil && len(keyErr.Want) > 0 {
+		for _, known := range keyErr.Want {
+			config.HostKeyAlgorithms = append(config.HostKeyAlgorithms, known.Key.Type())
+		}
+
+		keyErr = nil
+		client, err = ssh.Dial("tcp", addr, config)
+	}
+
+	if keyErr != nil {
+		if len(keyErr.Want) == 0 {
+			return nil, fmt.Errorf("the host key of %s isn't in the known hosts file %s. Connect to it with ssh to check its key and add it", urlObj.Hostname(), knownHostsFile)
+		}
+
+		return nil, fmt.Errorf("the host key of %s doesn't match its key in the known hosts file %s. Someone may be impersonating the host", urlObj.Hostname(), knownHostsFile)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// sshAuthMethods returns public key authentication with the identity file of |params|, or else with the keys of
+// ssh-agent and the default identity files.
+func sshAuthMethods(params map[string]string) ([]ssh.AuthMethod, error) {
+	if identityFile, ok := params[SSHIdentityParam]; ok {
+		signer, err := readSigner(identityFile)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the identity file %s: %v", identityFile, err)
+		}
+
+		return []ssh.AuthMethod{ssh.PublicKeys(signer)}, nil
+	}
+
+	var auth []ssh.AuthMethod
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if agentConn, err := net.Dial("unix", sock); err == nil {
+			auth = append(auth, ssh.PublicKeysCallback(agent.NewClient(agentConn).Signers))
+		}
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		var signers []ssh.Signer
+		for _, name := range defaultIdentityFiles {
+			// missing files and keys which need a passphrase are skipped
+			if signer, err := readSigner(filepath.Join(home, ".ssh", name)); err == nil {
+				signers = append(signers, signer)
+			}
+		}
+
+		if len(signers) > 0 {
+			auth = append(auth, ssh.PublicKeys(signers...))
+		}
+	}
+
+	return auth, nil
+}
+
+func readSigner(path string) (ssh.Signer, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ssh.ParsePrivateKey(data)
+}
+
+func sshKnownHostsFile(params map[string]string) (string, error) {
+	if knownHostsFile, ok := params[SSHKnownHostsParam]; ok {
+		return knownHostsFile, nil
+	}
+
+	home, err := os.UserHomeDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".ssh", "known_hosts"), nil
+}
+
+// shellQuote quotes |s| for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// syncBuffer is a bytes.Buffer which can be written to while it's read.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (sb *syncBuffer) Write(p []byte) (int, error) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	return sb.buf.Write(p)
+}
+
+func (sb *syncBuffer) String() string {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	return sb.buf.String()
+}
@@ -0,0 +1,289 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbfactory
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/remotestream"
+	"github.com/liquidata-inc/dolt/go/store/datas"
+	"github.com/liquidata-inc/dolt/go/store/nbs"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+func newTestKey(t *testing.T) (ssh.Signer, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(key)
+	require.NoError(t, err)
+
+	return signer, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+// startSSHServer starts an ssh server on a local port which accepts |authorized| and runs the remote-serve command in
+// process. It returns the address of the server.
+func startSSHServer(t *testing.T, hostKey ssh.Signer, authorized ssh.PublicKey) string {
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), authorized.Marshal()) {
+				return nil, nil
+			}
+
+			return nil, errors.New("unauthorized key")
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		for {
+			nConn, err := lis.Accept()
+
+			if err != nil {
+				return
+			}
+
+			go serveSSHConn(nConn, config)
+		}
+	}()
+
+	return lis.Addr().String()
+}
+
+func serveSSHConn(nConn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(nConn, config)
+
+	if err != nil {
+		return
+	}
+
+	go ssh.DiscardRequests(reqs)
+
+	for newCh := range chans {
+		if newCh.ChannelType() != "session" {
+			_ = newCh.Reject(ssh.UnknownChannelType, "only sessions are supported")
+			continue
+		}
+
+		ch, chReqs, err := newCh.Accept()
+
+		if err != nil {
+			continue
+		}
+
+		go func() {
+			for req := range chReqs {
+				var exec struct{ Command string }
+				if req.Type != "exec" || ssh.Unmarshal(req.Payload, &exec) != nil || !strings.HasPrefix(exec.Command, RemoteServeCommand+" ") {
+					_ = req.Reply(false, nil)
+					continue
+				}
+
+				_ = req.Reply(true, nil)
+
+				quoted := strings.TrimPrefix(exec.Command, RemoteServeCommand+" ")
+				dir := strings.Replace(quoted[1:len(quoted)-1], `'\''`, "'", -1)
+
+				go func() {
+					err := remotestream.NewServer(dir).Serve(context.Background(), remotestream.NewConn(ch, ch, nil))
+
+					status := uint32(0)
+					if err != nil {
+						status = 1
+					}
+
+					_, _ = ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+					_ = ch.Close()
+				}()
+			}
+		}()
+	}
+}
+
+type sshTestEnv struct {
+	dir       string
+	addr      string
+	params    map[string]string
+	remoteDir string
+}
+
+func newSSHTestEnv(t *testing.T) *sshTestEnv {
+	dir, err := ioutil.TempDir("", "ssh_remote")
+	require.NoError(t, err)
+
+	hostKey, _ := newTestKey(t)
+	userKey, userPEM := newTestKey(t)
+	addr := startSSHServer(t, hostKey, userKey.PublicKey())
+
+	identityFile := filepath.Join(dir, "id_ecdsa")
+	require.NoError(t, ioutil.WriteFile(identityFile, userPEM, 0600))
+
+	knownHostsFile := filepath.Join(dir, "known_hosts")
+	knownHosts := knownhosts.Line([]string{knownhosts.Normalize(addr)}, hostKey.PublicKey()) + "\n"
+	require.NoError(t, ioutil.WriteFile(knownHostsFile, []byte(knownHosts), 0600))
+
+	// the path is quoted for the remote's shell
+	remoteDir := filepath.Join(dir, "it's remote")
+	require.NoError(t, os.Mkdir(remoteDir, os.ModePerm))
+
+	params := map[string]string{SSHIdentityParam: identityFile, SSHKnownHostsParam: knownHostsFile}
+	return &sshTestEnv{dir, addr, params, remoteDir}
+}
+
+func (env *sshTestEnv) url() *url.URL {
+	return &url.URL{Scheme: SSHScheme, User: url.User("dolt"), Host: env.addr, Path: env.remoteDir}
+}
+
+func (env *sshTestEnv) remoteDB(t *testing.T) datas.Database {
+	db, err := SSHFactory{}.CreateDB(context.Background(), types.Format_Default, env.url(), env.params)
+	require.NoError(t, err)
+	return db
+}
+
+func (env *sshTestEnv) localDB(t *testing.T, name string) datas.Database {
+	dir := filepath.Join(env.dir, name)
+	require.NoError(t, os.MkdirAll(dir, os.ModePerm))
+	st, err := nbs.NewLocalStore(context.Background(), types.Format_Default.VersionString(), dir, defaultMemTableSize)
+	require.NoError(t, err)
+	return datas.NewDatabase(st)
+}
+
+// pull sends the head of the dataset "master" from |srcDB| to |sinkDB|, and sets it as the head there.
+func pull(t *testing.T, srcDB, sinkDB datas.Database) {
+	ctx := context.Background()
+	srcDS, err := srcDB.GetDataset(ctx, "master")
+	require.NoError(t, err)
+	headRef, ok, err := srcDS.MaybeHeadRef()
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.NoError(t, datas.Pull(ctx, srcDB, sinkDB, headRef, nil))
+
+	sinkDS, err := sinkDB.GetDataset(ctx, "master")
+	require.NoError(t, err)
+	_, err = sinkDB.SetHead(ctx, sinkDS, headRef)
+	require.NoError(t, err)
+}
+
+func requireHeadValue(t *testing.T, db datas.Database, expected types.Value) {
+	ds, err := db.GetDataset(context.Background(), "master")
+	require.NoError(t, err)
+	val, ok, err := ds.MaybeHeadValue()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.True(t, expected.Equals(val))
+}
+
+func commitValue(t *testing.T, db datas.Database, val types.Value) {
+	ctx := context.Background()
+	ds, err := db.GetDataset(ctx, "master")
+	require.NoError(t, err)
+	_, err = db.CommitValue(ctx, ds, val)
+	require.NoError(t, err)
+}
+
+func TestSSHPushCloneFetch(t *testing.T) {
+	ctx := context.Background()
+	env := newSSHTestEnv(t)
+	defer os.RemoveAll(env.dir)
+
+	// push
+	local := env.localDB(t, "local")
+	commitValue(t, local, types.String("first"))
+	remote := env.remoteDB(t)
+	pull(t, local, remote)
+	require.NoError(t, remote.Close())
+
+	// clone
+	remote = env.remoteDB(t)
+	cloned := env.localDB(t, "cloned")
+	require.NoError(t, datas.Clone(ctx, remote, cloned, nil))
+	require.NoError(t, remote.Close())
+	requireHeadValue(t, cloned, types.String("first"))
+
+	// push another commit, and fetch it into the clone
+	commitValue(t, local, types.String("second"))
+	remote = env.remoteDB(t)
+	pull(t, local, remote)
+	require.NoError(t, remote.Close())
+
+	remote = env.remoteDB(t)
+	defer remote.Close()
+	pull(t, remote, cloned)
+	requireHeadValue(t, cloned, types.String("second"))
+}
+
+func TestSSHHostKeyVerification(t *testing.T) {
+	env := newSSHTestEnv(t)
+	defer os.RemoveAll(env.dir)
+
+	_, err := SSHFactory{}.CreateDB(context.Background(), types.Format_Default, env.url(), env.params)
+	require.NoError(t, err)
+
+	// a host which isn't in the known hosts file
+	emptyKnownHosts := filepath.Join(env.dir, "empty_known_hosts")
+	require.NoError(t, ioutil.WriteFile(emptyKnownHosts, nil, 0600))
+	params := map[string]string{SSHIdentityParam: env.params[SSHIdentityParam], SSHKnownHostsParam: emptyKnownHosts}
+	_, err = SSHFactory{}.CreateDB(context.Background(), types.Format_Default, env.url(), params)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "isn't in the known hosts file")
+
+	// a host whose key doesn't match the known hosts file
+	otherKey, _ := newTestKey(t)
+	wrongKnownHosts := filepath.Join(env.dir, "wrong_known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(env.addr)}, otherKey.PublicKey()) + "\n"
+	require.NoError(t, ioutil.WriteFile(wrongKnownHosts, []byte(line), 0600))
+	params[SSHKnownHostsParam] = wrongKnownHosts
+	_, err = SSHFactory{}.CreateDB(context.Background(), types.Format_Default, env.url(), params)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "doesn't match")
+
+	// a key which isn't authorized
+	_, otherPEM := newTestKey(t)
+	otherIdentity := filepath.Join(env.dir, "id_other")
+	require.NoError(t, ioutil.WriteFile(otherIdentity, otherPEM, 0600))
+	params = map[string]string{SSHIdentityParam: otherIdentity, SSHKnownHostsParam: env.params[SSHKnownHostsParam]}
+	_, err = SSHFactory{}.CreateDB(context.Background(), types.Format_Default, env.url(), params)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to authenticate")
+}
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, "'/repos/db'", shellQuote("/repos/db"))
+	assert.Equal(t, `'/repos/it'\''s'`, shellQuote("/repos/it's"))
+}
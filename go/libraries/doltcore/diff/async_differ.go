@@ -19,6 +19,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/tablediff"
 	"github.com/liquidata-inc/dolt/go/store/atomicerr"
 
 	"github.com/liquidata-inc/dolt/go/store/diff"
@@ -59,6 +60,36 @@ func (ad *AsyncDiffer) Start(ctx context.Context, v1, v2 types.Map) {
 	}()
 }
 
+// KeyRange is a range of row keys which begins at Start, or at the first key when Start is nil, and continues while
+// InRange returns true, or through the last key when InRange is nil.
+type KeyRange = tablediff.KeyRange
+
+// StartWithRanges starts diffing |v1| against |v2| like Start, but only the keys within |ranges| are diffed. Ranges
+// are diffed in order, so sorted ranges which don't overlap produce their diffs in key order.
+func (ad *AsyncDiffer) StartWithRanges(ctx context.Context, v1, v2 types.Map, ranges []KeyRange) {
+	go func() {
+		defer close(ad.diffChan)
+		defer func() {
+			// Ignore a panic from Diff...
+			recover()
+		}()
+
+		for _, r := range ranges {
+			select {
+			case <-ad.stopChan:
+				return
+			default:
+			}
+
+			if ad.ae.IsSet() {
+				return
+			}
+
+			diff.DiffMapRange(ctx, ad.ae, v2, v1, r.Start, r.InRange, ad.diffChan, ad.stopChan, tableDontDescendLists)
+		}
+	}()
+}
+
 func (ad *AsyncDiffer) IsDone() bool {
 	return ad.isDone
 }
@@ -15,14 +15,15 @@
 package diff
 
 import (
-	"errors"
+	"context"
 	"io"
-	"time"
 
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/rowconv"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/pipeline"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/tablediff"
+	"github.com/liquidata-inc/dolt/go/store/diff"
 	"github.com/liquidata-inc/dolt/go/store/types"
 )
 
@@ -31,16 +32,22 @@ const (
 	To   = "to"
 )
 
+// RowDiffSource joins the old and new rows of the diffs of a tablediff.RowDiffIterator into the rows of a single
+// schema with a joiner.
 type RowDiffSource struct {
-	ad         *AsyncDiffer
+	ctx        context.Context
+	itr        *tablediff.RowDiffIterator
 	joiner     *rowconv.Joiner
 	oldRowConv *rowconv.RowConverter
 	newRowConv *rowconv.RowConverter
 }
 
-func NewRowDiffSource(ad *AsyncDiffer, joiner *rowconv.Joiner) *RowDiffSource {
+// NewRowDiffSource returns a RowDiffSource which joins the rows of the diffs of |itr| with |joiner|. The iterator is
+// closed when the source is.
+func NewRowDiffSource(ctx context.Context, itr *tablediff.RowDiffIterator, joiner *rowconv.Joiner) *RowDiffSource {
 	return &RowDiffSource{
-		ad,
+		ctx,
+		itr,
 		joiner,
 		rowconv.IdentityConverter,
 		rowconv.IdentityConverter,
@@ -60,79 +67,86 @@ func (rdRd *RowDiffSource) GetSchema() schema.Schema {
 // NextDiff reads a row from a table.  If there is a bad row the returned error will be non nil, and callin IsBadRow(err)
 // will be return true. This is a potentially non-fatal error and callers can decide if they want to continue on a bad row, or fail.
 func (rdRd *RowDiffSource) NextDiff() (row.Row, pipeline.ImmutableProperties, error) {
-	if rdRd.ad.isDone {
+	rd, err := rdRd.itr.Next(rdRd.ctx)
+
+	if err == io.EOF {
 		return nil, pipeline.NoProps, io.EOF
+	} else if err != nil {
+		return nil, pipeline.ImmutableProperties{}, err
 	}
 
-	diffs, err := rdRd.ad.GetDiffs(1, time.Second)
+	rows := make(map[string]row.Row)
+	if rd.Old != nil {
+		rows[From], err = rdRd.oldRowConv.Convert(rd.Old)
 
-	if err != nil {
-		return nil, pipeline.ImmutableProperties{}, err
+		if err != nil {
+			return nil, pipeline.ImmutableProperties{}, err
+		}
 	}
 
-	if len(diffs) == 0 {
-		if rdRd.ad.isDone {
-			return nil, pipeline.NoProps, io.EOF
-		}
+	if rd.New != nil {
+		rows[To], err = rdRd.newRowConv.Convert(rd.New)
 
-		return nil, pipeline.NoProps, errors.New("timeout")
+		if err != nil {
+			return nil, pipeline.ImmutableProperties{}, err
+		}
 	}
 
-	if len(diffs) != 1 {
-		panic("only a single diff requested, multiple returned.  bug in AsyncDiffer")
+	joinedRow, err := rdRd.joiner.Join(rows)
+
+	if err != nil {
+		return nil, pipeline.ImmutableProperties{}, err
 	}
 
-	d := diffs[0]
+	return joinedRow, pipeline.ImmutableProperties{}, nil
+}
+
+// JoinDiff converts the old and new values of |d| with |oldRowConv| and |newRowConv| and joins the resulting rows with
+// |joiner|.
+func JoinDiff(joiner *rowconv.Joiner, oldRowConv, newRowConv *rowconv.RowConverter, d *diff.Difference) (row.Row, error) {
 	rows := make(map[string]row.Row)
 	if d.OldValue != nil {
-		sch := rdRd.joiner.SchemaForName(From)
-		if !rdRd.oldRowConv.IdentityConverter {
-			sch = rdRd.oldRowConv.SrcSch
+		sch := joiner.SchemaForName(From)
+		if !oldRowConv.IdentityConverter {
+			sch = oldRowConv.SrcSch
 		}
 
 		oldRow, err := row.FromNoms(sch, d.KeyValue.(types.Tuple), d.OldValue.(types.Tuple))
 
 		if err != nil {
-			return nil, pipeline.ImmutableProperties{}, err
+			return nil, err
 		}
 
-		rows[From], err = rdRd.oldRowConv.Convert(oldRow)
+		rows[From], err = oldRowConv.Convert(oldRow)
 
 		if err != nil {
-			return nil, pipeline.NoProps, err
+			return nil, err
 		}
 	}
 
 	if d.NewValue != nil {
-		sch := rdRd.joiner.SchemaForName(To)
-		if !rdRd.newRowConv.IdentityConverter {
-			sch = rdRd.newRowConv.SrcSch
+		sch := joiner.SchemaForName(To)
+		if !newRowConv.IdentityConverter {
+			sch = newRowConv.SrcSch
 		}
 
 		newRow, err := row.FromNoms(sch, d.KeyValue.(types.Tuple), d.NewValue.(types.Tuple))
 
 		if err != nil {
-			return nil, pipeline.ImmutableProperties{}, err
+			return nil, err
 		}
 
-		rows[To], err = rdRd.newRowConv.Convert(newRow)
+		rows[To], err = newRowConv.Convert(newRow)
 
 		if err != nil {
-			return nil, pipeline.NoProps, err
+			return nil, err
 		}
 	}
 
-	joinedRow, err := rdRd.joiner.Join(rows)
-
-	if err != nil {
-		return nil, pipeline.ImmutableProperties{}, err
-	}
-
-	return joinedRow, pipeline.ImmutableProperties{}, nil
+	return joiner.Join(rows)
 }
 
 // Close should release resources being held
 func (rdRd *RowDiffSource) Close() error {
-	rdRd.ad.Close()
-	return nil
+	return rdRd.itr.Close()
 }
@@ -24,13 +24,28 @@ import (
 )
 
 type DiffSummaryProgress struct {
-	Adds, Removes, Changes, CellChanges, NewSize, OldSize uint64
+	Adds, Removes, Changes, CellChanges, NewSize, OldSize, FilteredOut uint64
 }
 
+// FilterFunc returns whether a difference should be included in a diff
+type FilterFunc func(d *diff.Difference) (bool, error)
+
 // Summary reports a summary of diff changes between two values
 func Summary(ctx context.Context, ch chan DiffSummaryProgress, v1, v2 types.Map) error {
+	return FilteredSummary(ctx, ch, v1, v2, nil, nil)
+}
+
+// FilteredSummary reports a summary of the diff changes between two values like Summary, but only for the keys within
+// |ranges| and only for the changes which |filter| accepts. A nil |ranges| includes every key and a nil |filter|
+// accepts every change. The changes within |ranges| which |filter| rejects are reported as filtered out, while changes
+// outside of |ranges| are never compared and so are not reported at all.
+func FilteredSummary(ctx context.Context, ch chan DiffSummaryProgress, v1, v2 types.Map, ranges []KeyRange, filter FilterFunc) error {
 	ad := NewAsyncDiffer(1024)
-	ad.Start(ctx, v1, v2)
+	if ranges != nil {
+		ad.StartWithRanges(ctx, v1, v2, ranges)
+	} else {
+		ad.Start(ctx, v1, v2)
+	}
 	defer ad.Close()
 
 	ch <- DiffSummaryProgress{OldSize: v2.Len(), NewSize: v1.Len()}
@@ -44,6 +59,20 @@ func Summary(ctx context.Context, ch chan DiffSummaryProgress, v1, v2 types.Map)
 
 		for i := range diffs {
 			curr := diffs[i]
+
+			if filter != nil {
+				include, err := filter(curr)
+
+				if err != nil {
+					return err
+				}
+
+				if !include {
+					ch <- DiffSummaryProgress{FilteredOut: 1}
+					continue
+				}
+			}
+
 			err := reportChanges(curr, ch)
 
 			if err != nil {
@@ -55,6 +84,33 @@ func Summary(ctx context.Context, ch chan DiffSummaryProgress, v1, v2 types.Map)
 	return nil
 }
 
+// SummarizeRowChanges returns the totals of the row changes between |v1| and |v2| reported by Summary, walking every
+// row which differs between the two.
+func SummarizeRowChanges(ctx context.Context, v1, v2 types.Map) (DiffSummaryProgress, error) {
+	var summaryErr error
+	ch := make(chan DiffSummaryProgress)
+	go func() {
+		defer close(ch)
+		summaryErr = Summary(ctx, ch, v1, v2)
+	}()
+
+	acc := DiffSummaryProgress{}
+	for p := range ch {
+		acc.Adds += p.Adds
+		acc.Removes += p.Removes
+		acc.Changes += p.Changes
+		acc.CellChanges += p.CellChanges
+		acc.NewSize += p.NewSize
+		acc.OldSize += p.OldSize
+	}
+
+	if summaryErr != nil {
+		return DiffSummaryProgress{}, summaryErr
+	}
+
+	return acc, nil
+}
+
 func reportChanges(change *diff.Difference, ch chan<- DiffSummaryProgress) error {
 	switch change.ChangeType {
 	case types.DiffChangeAdded:
@@ -121,6 +121,31 @@ func (td *TableDiffs) Len() int {
 	return len(td.Tables)
 }
 
+// Filter returns the diffs of the tables for which |keep| returns true.
+func (td *TableDiffs) Filter(keep func(tblName string) bool) *TableDiffs {
+	filtered := &TableDiffs{TableToType: make(map[string]TableDiffType)}
+	for _, tblName := range td.Tables {
+		if !keep(tblName) {
+			continue
+		}
+
+		tdt := td.TableToType[tblName]
+		switch tdt {
+		case AddedTable:
+			filtered.NumAdded++
+		case ModifiedTable:
+			filtered.NumModified++
+		case RemovedTable:
+			filtered.NumRemoved++
+		}
+
+		filtered.TableToType[tblName] = tdt
+		filtered.Tables = append(filtered.Tables, tblName)
+	}
+
+	return filtered
+}
+
 func GetTableDiffs(ctx context.Context, dEnv *env.DoltEnv) (*TableDiffs, *TableDiffs, error) {
 	headRoot, err := dEnv.HeadRoot(ctx)
 
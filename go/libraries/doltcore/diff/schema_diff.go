@@ -16,6 +16,7 @@ package diff
 
 import (
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/tablediff"
 )
 
 type SchemaChangeType int
@@ -41,22 +42,29 @@ type SchemaDifference struct {
 
 type columnPair [2]*schema.Column
 
-// DiffSchemas compares two schemas by looking at columns with the same tag.
+// DiffSchemas compares two schemas by looking at columns with the same tag. The differences of the columns are those
+// of tablediff.SchemaDiff, and columns it doesn't return are unchanged.
 func DiffSchemas(sch1, sch2 schema.Schema) (map[uint64]SchemaDifference, []uint64) {
 	colPairMap, unionTags := pairColumns(sch1, sch2)
 
 	diffs := make(map[uint64]SchemaDifference)
 	for _, tag := range unionTags {
 		colPair := colPairMap[tag]
-		if colPair[0] == nil {
-			diffs[tag] = SchemaDifference{SchDiffColAdded, tag, nil, colPair[1]}
-		} else if colPair[1] == nil {
-			diffs[tag] = SchemaDifference{SchDiffColRemoved, tag, colPair[0], nil}
-		} else if !colPair[0].Equals(*colPair[1]) {
-			diffs[tag] = SchemaDifference{SchDiffColModified, tag, colPair[0], colPair[1]}
-		} else {
-			diffs[tag] = SchemaDifference{SchDiffNone, tag, colPair[0], colPair[1]}
+		diffs[tag] = SchemaDifference{SchDiffNone, tag, colPair[0], colPair[1]}
+	}
+
+	for _, cd := range tablediff.SchemaDiff(sch1, sch2).Columns {
+		dff := diffs[cd.Tag]
+		switch cd.Type {
+		case tablediff.Added:
+			dff.DiffType = SchDiffColAdded
+		case tablediff.Removed:
+			dff.DiffType = SchDiffColRemoved
+		case tablediff.Modified:
+			dff.DiffType = SchDiffColModified
 		}
+
+		diffs[cd.Tag] = dff
 	}
 
 	return diffs, unionTags
@@ -0,0 +1,145 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sql"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// SchemaFragmentDelta is the change to the definition of a single schema fragment, a view or a trigger, between two
+// versions of the dolt_schemas table.
+type SchemaFragmentDelta struct {
+	// Type is the type of the fragment, `view` or `trigger`
+	Type string
+	Name string
+	// OldDefinition is the CREATE statement of the fragment before the change, or "" if the fragment was added
+	OldDefinition string
+	// NewDefinition is the CREATE statement of the fragment after the change, or "" if the fragment was dropped
+	NewDefinition string
+}
+
+// IsAdd returns true if the fragment was added.
+func (fd SchemaFragmentDelta) IsAdd() bool {
+	return fd.OldDefinition == ""
+}
+
+// IsDrop returns true if the fragment was dropped.
+func (fd SchemaFragmentDelta) IsDrop() bool {
+	return fd.NewDefinition == ""
+}
+
+type fragmentKey struct {
+	typ  string
+	name string
+}
+
+// GetSchemaFragmentDeltas returns the changes to the views and triggers stored in the dolt_schemas tables given, sorted
+// by type and then name. Either table can be nil if it doesn't exist on that side of the diff.
+func GetSchemaFragmentDeltas(ctx context.Context, newTbl, oldTbl *doltdb.Table) ([]SchemaFragmentDelta, error) {
+	newFragments, err := getFragmentDefinitions(ctx, newTbl)
+
+	if err != nil {
+		return nil, err
+	}
+
+	oldFragments, err := getFragmentDefinitions(ctx, oldTbl)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var deltas []SchemaFragmentDelta
+	for key, newDef := range newFragments {
+		if oldDef := oldFragments[key]; oldDef != newDef {
+			deltas = append(deltas, SchemaFragmentDelta{key.typ, key.name, oldDef, newDef})
+		}
+	}
+
+	for key, oldDef := range oldFragments {
+		if _, ok := newFragments[key]; !ok {
+			deltas = append(deltas, SchemaFragmentDelta{key.typ, key.name, oldDef, ""})
+		}
+	}
+
+	sort.Slice(deltas, func(i, j int) bool {
+		if deltas[i].Type != deltas[j].Type {
+			return deltas[i].Type < deltas[j].Type
+		}
+		return deltas[i].Name < deltas[j].Name
+	})
+
+	return deltas, nil
+}
+
+// getFragmentDefinitions returns the CREATE statement of each view and trigger in the dolt_schemas table given. Views
+// are stored as their SELECT statement and triggers as their CREATE TRIGGER statement.
+func getFragmentDefinitions(ctx context.Context, tbl *doltdb.Table) (map[fragmentKey]string, error) {
+	fragments := make(map[fragmentKey]string)
+
+	if tbl == nil {
+		return fragments, nil
+	}
+
+	sch, err := tbl.GetSchema(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rowData, err := tbl.GetRowData(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = rowData.IterAll(ctx, func(key, value types.Value) error {
+		r, err := row.FromNoms(sch, key.(types.Tuple), value.(types.Tuple))
+
+		if err != nil {
+			return err
+		}
+
+		typ, _ := r.GetColVal(doltdb.DoltSchemasTypeTag)
+		name, _ := r.GetColVal(doltdb.DoltSchemasNameTag)
+		fragment, _ := r.GetColVal(doltdb.DoltSchemasFragmentTag)
+
+		if typ == nil || name == nil || fragment == nil {
+			return nil
+		}
+
+		fragmentName := string(name.(types.String))
+		switch typ {
+		case types.String("view"):
+			fragments[fragmentKey{"view", fragmentName}] = fmt.Sprintf("CREATE VIEW %s AS %s", sql.QuoteIdentifier(fragmentName), string(fragment.(types.String)))
+		case types.String("trigger"):
+			fragments[fragmentKey{"trigger", fragmentName}] = string(fragment.(types.String))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return fragments, nil
+}
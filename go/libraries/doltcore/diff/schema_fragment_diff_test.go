@@ -0,0 +1,97 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// createSchemasTable creates a dolt_schemas table with the fragments given, each of which is a type, name and fragment.
+func createSchemasTable(ctx context.Context, t *testing.T, dEnv *env.DoltEnv, fragments [][3]string) *doltdb.Table {
+	cols, err := schema.NewColCollection(
+		schema.NewColumn(doltdb.SchemasTablesTypeCol, doltdb.DoltSchemasTypeTag, types.StringKind, true),
+		schema.NewColumn(doltdb.SchemasTablesNameCol, doltdb.DoltSchemasNameTag, types.StringKind, true),
+		schema.NewColumn(doltdb.SchemasTablesFragmentCol, doltdb.DoltSchemasFragmentTag, types.StringKind, false),
+	)
+	require.NoError(t, err)
+	sch := schema.SchemaFromCols(cols)
+
+	var rows []row.Row
+	for _, fragment := range fragments {
+		r, err := row.New(types.Format_7_18, sch, row.TaggedValues{
+			doltdb.DoltSchemasTypeTag:     types.String(fragment[0]),
+			doltdb.DoltSchemasNameTag:     types.String(fragment[1]),
+			doltdb.DoltSchemasFragmentTag: types.String(fragment[2]),
+		})
+		require.NoError(t, err)
+		rows = append(rows, r)
+	}
+
+	dtestutils.CreateTestTable(t, dEnv, doltdb.SchemasTableName, sch, rows...)
+	root, err := dEnv.WorkingRoot(ctx)
+	require.NoError(t, err)
+	tbl, ok, err := root.GetTable(ctx, doltdb.SchemasTableName)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	return tbl
+}
+
+func TestGetSchemaFragmentDeltas(t *testing.T) {
+	ctx, _, dEnv := setupSchema()
+
+	oldTbl := createSchemasTable(ctx, t, dEnv, [][3]string{
+		{"view", "unchanged", "select 1"},
+		{"view", "modified", "select a from t"},
+		{"view", "dropped", "select b from t"},
+		{"trigger", "stamp", "CREATE TRIGGER `stamp` BEFORE INSERT ON `t` FOR EACH ROW set new.a = 1"},
+	})
+	newTbl := createSchemasTable(ctx, t, dEnv, [][3]string{
+		{"view", "unchanged", "select 1"},
+		{"view", "modified", "select a, b from t"},
+		{"view", "added", "select * from missing"},
+		{"trigger", "audit", "CREATE TRIGGER `audit` AFTER INSERT ON `t` FOR EACH ROW delete from log"},
+	})
+
+	deltas, err := GetSchemaFragmentDeltas(ctx, newTbl, oldTbl)
+	require.NoError(t, err)
+	assert.Equal(t, []SchemaFragmentDelta{
+		{"trigger", "audit", "", "CREATE TRIGGER `audit` AFTER INSERT ON `t` FOR EACH ROW delete from log"},
+		{"trigger", "stamp", "CREATE TRIGGER `stamp` BEFORE INSERT ON `t` FOR EACH ROW set new.a = 1", ""},
+		{"view", "added", "", "CREATE VIEW `added` AS select * from missing"},
+		{"view", "dropped", "CREATE VIEW `dropped` AS select b from t", ""},
+		{"view", "modified", "CREATE VIEW `modified` AS select a from t", "CREATE VIEW `modified` AS select a, b from t"},
+	}, deltas)
+	assert.True(t, deltas[0].IsAdd())
+	assert.True(t, deltas[1].IsDrop())
+
+	deltas, err = GetSchemaFragmentDeltas(ctx, nil, oldTbl)
+	require.NoError(t, err)
+	assert.Len(t, deltas, 4)
+	for _, delta := range deltas {
+		assert.True(t, delta.IsDrop())
+	}
+}
@@ -0,0 +1,324 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+
+	"github.com/liquidata-inc/dolt/go/store/atomicerr"
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// DivergentKeyRange is a range of consecutive keys, from Start through End, for which the diff which skips the chunks
+// two maps share found different changes than comparing every entry of the maps did. Keys is the number of keys in the
+// range.
+type DivergentKeyRange struct {
+	Start, End types.Value
+	Keys       int
+}
+
+// DiffVerification is the result of VerifyMapDiff.
+type DiffVerification struct {
+	// Changes is the number of changes found by comparing every entry of the maps
+	Changes int
+	// Divergences are the ranges of keys the diffs disagree about, in key order
+	Divergences []DivergentKeyRange
+	// SkippingGets and FullGets are the number of chunks gotten by the diff which skips shared chunks and by the
+	// comparison of every entry
+	SkippingGets, FullGets int32
+}
+
+// mapDiffFunc streams the diff from |from| to |to| into |changes| like types.Map.DiffLeftRight.
+type mapDiffFunc func(ctx context.Context, from, to types.Map, ae *atomicerr.AtomicError, changes chan<- types.ValueChanged, stopChan <-chan struct{})
+
+func diffLeftRight(ctx context.Context, from, to types.Map, ae *atomicerr.AtomicError, changes chan<- types.ValueChanged, stopChan <-chan struct{}) {
+	to.DiffLeftRight(ctx, from, ae, changes, stopChan)
+}
+
+// VerifyMapDiff diffs |from| and |to| the way the diffs of tables are computed, skipping the chunks the maps share, and
+// again by comparing every entry of the maps, and reports the keys for which the diffs differ. Each diff reads the maps
+// from |cs| through its own CSMetricWrapper and value store, so nothing either diff reads is cached for the other, and
+// the number of chunks each one got is reported. Reading every entry of both maps is slow, so this is only meant for
+// checking the correctness of the storage.
+func VerifyMapDiff(ctx context.Context, cs chunks.ChunkStore, from, to types.Map) (DiffVerification, error) {
+	return verifyMapDiff(ctx, cs, from, to, diffLeftRight)
+}
+
+func verifyMapDiff(ctx context.Context, cs chunks.ChunkStore, from, to types.Map, skippingDiff mapDiffFunc) (DiffVerification, error) {
+	var verification DiffVerification
+
+	skippingCS := chunks.NewCSMetricWrapper(cs)
+	skippingFrom, skippingTo, err := readMaps(ctx, skippingCS, from, to)
+
+	if err != nil {
+		return DiffVerification{}, err
+	}
+
+	skipped, err := collectChanges(ctx, skippingFrom, skippingTo, skippingDiff)
+
+	if err != nil {
+		return DiffVerification{}, err
+	}
+
+	verification.SkippingGets = atomic.LoadInt32(&skippingCS.TotalChunkGets)
+
+	fullCS := chunks.NewCSMetricWrapper(cs)
+	fullFrom, fullTo, err := readMaps(ctx, fullCS, from, to)
+
+	if err != nil {
+		return DiffVerification{}, err
+	}
+
+	var current *DivergentKeyRange
+	err = compareEveryEntry(ctx, fullFrom, fullTo, func(key types.Value, change *types.ValueChanged) error {
+		if change != nil {
+			verification.Changes++
+		}
+
+		h, err := key.Hash(from.Format())
+
+		if err != nil {
+			return err
+		}
+
+		skippedChange, found := skipped[h]
+		delete(skipped, h)
+
+		if sameChange(change, skippedChange, found) {
+			current = nil
+		} else if current != nil {
+			current.End = key
+			current.Keys++
+		} else {
+			verification.Divergences = append(verification.Divergences, DivergentKeyRange{Start: key, End: key, Keys: 1})
+			current = &verification.Divergences[len(verification.Divergences)-1]
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return DiffVerification{}, err
+	}
+
+	verification.FullGets = atomic.LoadInt32(&fullCS.TotalChunkGets)
+
+	// changes of keys which are in neither map
+	for _, change := range skipped {
+		verification.Divergences = append(verification.Divergences, DivergentKeyRange{Start: change.Key, End: change.Key, Keys: 1})
+	}
+
+	err = sortKeyRanges(from.Format(), verification.Divergences)
+
+	if err != nil {
+		return DiffVerification{}, err
+	}
+
+	return verification, nil
+}
+
+// readMaps reads |from| and |to| again from |cs| with a new value store. Empty maps may not be stored, and are used as
+// they are.
+func readMaps(ctx context.Context, cs chunks.ChunkStore, from, to types.Map) (types.Map, types.Map, error) {
+	vs := types.NewValueStore(cs)
+
+	readMap := func(m types.Map) (types.Map, error) {
+		if m.Empty() {
+			return m, nil
+		}
+
+		h, err := m.Hash(m.Format())
+
+		if err != nil {
+			return types.EmptyMap, err
+		}
+
+		v, err := vs.ReadValue(ctx, h)
+
+		if err != nil {
+			return types.EmptyMap, err
+		}
+
+		return v.(types.Map), nil
+	}
+
+	from, err := readMap(from)
+
+	if err != nil {
+		return types.EmptyMap, types.EmptyMap, err
+	}
+
+	to, err = readMap(to)
+
+	if err != nil {
+		return types.EmptyMap, types.EmptyMap, err
+	}
+
+	return from, to, nil
+}
+
+// collectChanges returns the changes |diff| finds from |from| to |to|, keyed by the hashes of their keys.
+func collectChanges(ctx context.Context, from, to types.Map, diff mapDiffFunc) (map[hash.Hash]types.ValueChanged, error) {
+	ae := atomicerr.New()
+	changes := make(chan types.ValueChanged, 128)
+	stopChan := make(chan struct{})
+	defer close(stopChan)
+
+	go func() {
+		defer close(changes)
+		diff(ctx, from, to, ae, changes, stopChan)
+	}()
+
+	collected := make(map[hash.Hash]types.ValueChanged)
+	for change := range changes {
+		h, err := change.Key.Hash(from.Format())
+
+		if ae.SetIfError(err) {
+			break
+		}
+
+		collected[h] = change
+	}
+
+	if err := ae.Get(); err != nil {
+		return nil, err
+	}
+
+	return collected, nil
+}
+
+// compareEveryEntry iterates over both |from| and |to| in key order, calling |cb| with every key of either map, and
+// the change from |from| to |to| of its value, or nil if it's unchanged.
+func compareEveryEntry(ctx context.Context, from, to types.Map, cb func(key types.Value, change *types.ValueChanged) error) error {
+	fromItr, err := from.Iterator(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	toItr, err := to.Iterator(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	fromKey, fromVal, err := fromItr.Next(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	toKey, toVal, err := toItr.Next(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	nbf := from.Format()
+	for fromKey != nil || toKey != nil {
+		var key types.Value
+		var change *types.ValueChanged
+		advanceFrom, advanceTo := false, false
+
+		switch {
+		case toKey == nil:
+			key, advanceFrom = fromKey, true
+		case fromKey == nil:
+			key, advanceTo = toKey, true
+		default:
+			if fromKey.Equals(toKey) {
+				key, advanceFrom, advanceTo = fromKey, true, true
+			} else if isLess, err := fromKey.Less(nbf, toKey); err != nil {
+				return err
+			} else if isLess {
+				key, advanceFrom = fromKey, true
+			} else {
+				key, advanceTo = toKey, true
+			}
+		}
+
+		switch {
+		case advanceFrom && advanceTo:
+			if !fromVal.Equals(toVal) {
+				change = &types.ValueChanged{ChangeType: types.DiffChangeModified, Key: key, OldValue: fromVal, NewValue: toVal}
+			}
+		case advanceFrom:
+			change = &types.ValueChanged{ChangeType: types.DiffChangeRemoved, Key: key, OldValue: fromVal}
+		default:
+			change = &types.ValueChanged{ChangeType: types.DiffChangeAdded, Key: key, NewValue: toVal}
+		}
+
+		err = cb(key, change)
+
+		if err != nil {
+			return err
+		}
+
+		if advanceFrom {
+			fromKey, fromVal, err = fromItr.Next(ctx)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		if advanceTo {
+			toKey, toVal, err = toItr.Next(ctx)
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// sameChange returns whether |change|, found by comparing every entry, is the change which was found by the diff
+// skipping shared chunks, if |found|.
+func sameChange(change *types.ValueChanged, skipped types.ValueChanged, found bool) bool {
+	if change == nil || !found {
+		return change == nil && !found
+	}
+
+	return change.ChangeType == skipped.ChangeType && valuesEqual(change.OldValue, skipped.OldValue) && valuesEqual(change.NewValue, skipped.NewValue)
+}
+
+func valuesEqual(v1, v2 types.Value) bool {
+	if v1 == nil || v2 == nil {
+		return v1 == nil && v2 == nil
+	}
+
+	return v1.Equals(v2)
+}
+
+func sortKeyRanges(nbf *types.NomsBinFormat, ranges []DivergentKeyRange) error {
+	var err error
+	sort.SliceStable(ranges, func(i, j int) bool {
+		isLess, lessErr := ranges[i].Start.Less(nbf, ranges[j].Start)
+
+		if lessErr != nil && err == nil {
+			err = lessErr
+		}
+
+		return isLess
+	})
+
+	return err
+}
@@ -0,0 +1,118 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/atomicerr"
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// createVerifyDiffMaps returns a chunk store holding a map of |n| ints, and a map with the values of the keys 10, 11
+// and 12 changed, the key 500 removed and the key |n| added.
+func createVerifyDiffMaps(t *testing.T, n int) (chunks.ChunkStore, types.Map, types.Map) {
+	ctx := context.Background()
+	cs := (&chunks.MemoryStorage{}).NewView()
+	vs := types.NewValueStore(cs)
+
+	from, err := types.NewMap(ctx, vs)
+	require.NoError(t, err)
+
+	me := from.Edit()
+	for i := 0; i < n; i++ {
+		me.Set(types.Int(i), types.Int(i))
+	}
+	from, err = me.Map(ctx)
+	require.NoError(t, err)
+
+	me = from.Edit()
+	for i := 10; i < 13; i++ {
+		me.Set(types.Int(i), types.Int(-i))
+	}
+	me.Remove(types.Int(500))
+	me.Set(types.Int(n), types.Int(n))
+	to, err := me.Map(ctx)
+	require.NoError(t, err)
+
+	_, err = vs.WriteValue(ctx, from)
+	require.NoError(t, err)
+	_, err = vs.WriteValue(ctx, to)
+	require.NoError(t, err)
+
+	root, err := vs.Root(ctx)
+	require.NoError(t, err)
+	ok, err := vs.Commit(ctx, root, root)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	return cs, from, to
+}
+
+func TestVerifyMapDiff(t *testing.T) {
+	cs, from, to := createVerifyDiffMaps(t, 10000)
+
+	verification, err := VerifyMapDiff(context.Background(), cs, from, to)
+	require.NoError(t, err)
+	assert.Equal(t, 5, verification.Changes)
+	assert.Empty(t, verification.Divergences)
+	assert.True(t, verification.SkippingGets < verification.FullGets, "skipping the shared chunks read %d chunks, and comparing every entry read %d", verification.SkippingGets, verification.FullGets)
+
+	verification, err = VerifyMapDiff(context.Background(), cs, from, from)
+	require.NoError(t, err)
+	assert.Equal(t, 0, verification.Changes)
+	assert.Empty(t, verification.Divergences)
+
+	empty, err := types.NewMap(context.Background(), types.NewValueStore(cs))
+	require.NoError(t, err)
+	verification, err = VerifyMapDiff(context.Background(), cs, empty, to)
+	require.NoError(t, err)
+	assert.Equal(t, 10000, verification.Changes)
+	assert.Empty(t, verification.Divergences)
+}
+
+func TestVerifyMapDiffDivergence(t *testing.T) {
+	cs, from, to := createVerifyDiffMaps(t, 10000)
+
+	// drops the changes of the keys 10 and 11 and claims the unchanged key 20 was modified
+	wrongDiff := func(ctx context.Context, from, to types.Map, ae *atomicerr.AtomicError, changes chan<- types.ValueChanged, stopChan <-chan struct{}) {
+		correct := make(chan types.ValueChanged)
+		go func() {
+			defer close(correct)
+			diffLeftRight(ctx, from, to, ae, correct, stopChan)
+		}()
+
+		for change := range correct {
+			if !change.Key.Equals(types.Int(10)) && !change.Key.Equals(types.Int(11)) {
+				changes <- change
+			}
+		}
+
+		changes <- types.ValueChanged{ChangeType: types.DiffChangeModified, Key: types.Int(20), OldValue: types.Int(20), NewValue: types.Int(0)}
+	}
+
+	verification, err := verifyMapDiff(context.Background(), cs, from, to, wrongDiff)
+	require.NoError(t, err)
+	assert.Equal(t, 5, verification.Changes)
+	assert.Equal(t, []DivergentKeyRange{
+		{Start: types.Int(10), End: types.Int(11), Keys: 2},
+		{Start: types.Int(20), End: types.Int(20), Keys: 1},
+	}, verification.Divergences)
+}
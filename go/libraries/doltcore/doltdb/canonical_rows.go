@@ -0,0 +1,161 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doltdb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// The rows of a table are written in the canonical layout of its schema: key tuples hold the values of the primary key
+// columns in primary key order, with nulls encoded, and value tuples hold the non-null values of the other columns in
+// tag order. Changing the schema of a table doesn't rewrite its rows, so rows written before a column was dropped may
+// still hold values of its tag, and rows written by older versions may hold nulls or values out of tag order. Readers
+// have to tolerate all of that.
+//
+// Rewriting a table into the canonical layout (see CanonicalRowTuples) records the hashes of its schema and row maps
+// in the table, so readers can tell the rows haven't changed since without reading them. Any later update of the rows
+// or the schema changes their hashes, which invalidates the record.
+
+const canonicalRowsStructName = "canonical_rows"
+
+const (
+	canonicalSchemaField   = "schema"
+	canonicalRowsField     = "rows"
+	canonicalColdRowsField = "cold_rows"
+)
+
+// CanonicalRowTuples returns the key and value tuples of the row |key|, |val| of a table with the schema |sch| in the
+// canonical layout. It returns an error if the key holds the value of a column which isn't in the primary key of |sch|,
+// or if the kind of a value doesn't match the kind of its column. Values of tags which aren't columns of |sch| are
+// dropped.
+func CanonicalRowTuples(ctx context.Context, sch schema.Schema, key, val types.Tuple) (types.Tuple, types.Tuple, error) {
+	nbf := key.Format()
+	keyVals, err := row.ParseTaggedValues(key)
+
+	if err != nil {
+		return types.EmptyTuple(nbf), types.EmptyTuple(nbf), err
+	}
+
+	pkCols := sch.GetPKCols()
+	for tag := range keyVals {
+		if _, ok := pkCols.GetByTag(tag); !ok {
+			return types.EmptyTuple(nbf), types.EmptyTuple(nbf), fmt.Errorf("the key %s has a value for tag %d, which isn't a primary key column of the schema", keyVals.String(), tag)
+		}
+	}
+
+	r, err := row.FromNoms(sch, key, val)
+
+	if err != nil {
+		return types.EmptyTuple(nbf), types.EmptyTuple(nbf), fmt.Errorf("the row with the key %s doesn't match the schema: %v", keyVals.String(), err)
+	}
+
+	canonicalKey, err := r.NomsMapKey(sch).Value(ctx)
+
+	if err != nil {
+		return types.EmptyTuple(nbf), types.EmptyTuple(nbf), err
+	}
+
+	canonicalVal, err := r.NomsMapValue(sch).Value(ctx)
+
+	if err != nil {
+		return types.EmptyTuple(nbf), types.EmptyTuple(nbf), err
+	}
+
+	return canonicalKey.(types.Tuple), canonicalVal.(types.Tuple), nil
+}
+
+// SetCanonicalRows replaces the rows of the table with |hot| and |cold|, as UpdateHotAndColdRows does, and records that
+// they are in the canonical layout of the table's schema. The caller is responsible for their being so.
+func (t *Table) SetCanonicalRows(ctx context.Context, hot types.Map, cold *types.Map) (*Table, error) {
+	tbl, err := t.UpdateHotAndColdRows(ctx, hot, cold)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := tbl.canonicalRowsFields()
+
+	if err != nil {
+		return nil, err
+	}
+
+	canonical, err := types.NewStruct(t.vrw.Format(), canonicalRowsStructName, fields)
+
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := tbl.tableStruct.Set(canonicalRowsKey, canonical)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Table{t.vrw, st}, nil
+}
+
+// HasCanonicalRows returns whether the rows of the table were recorded as being in the canonical layout of its schema
+// by SetCanonicalRows, and neither the rows nor the schema have changed since.
+func (t *Table) HasCanonicalRows() (bool, error) {
+	val, ok, err := t.tableStruct.MaybeGet(canonicalRowsKey)
+
+	if err != nil || !ok {
+		return false, err
+	}
+
+	recorded, ok := val.(types.Struct)
+
+	if !ok {
+		return false, nil
+	}
+
+	fields, err := t.canonicalRowsFields()
+
+	if err != nil {
+		return false, err
+	}
+
+	current, err := types.NewStruct(t.vrw.Format(), canonicalRowsStructName, fields)
+
+	if err != nil {
+		return false, err
+	}
+
+	return recorded.Equals(current), nil
+}
+
+// canonicalRowsFields returns the fields of the record of the canonical rows of the table: the hashes of its schema
+// and of its row maps.
+func (t *Table) canonicalRowsFields() (types.StructData, error) {
+	fields := make(types.StructData)
+	for field, key := range map[string]string{canonicalSchemaField: schemaRefKey, canonicalRowsField: tableRowsKey, canonicalColdRowsField: coldRowsKey} {
+		val, ok, err := t.tableStruct.MaybeGet(key)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			fields[field] = types.String(val.(types.Ref).TargetHash().String())
+		}
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,307 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doltdb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/set"
+	"github.com/liquidata-inc/dolt/go/store/atomicerr"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// The values of the cold columns of a table (see schema.Column.Cold) are stored in a map of their own, keyed by the
+// same primary keys as the map of the table's other values. Rows without any non-null cold values have no entry in the
+// cold map. Reading the map of a table's other values never reads any chunk of its cold map, so scans which don't need
+// the cold columns of a wide table don't fetch them from the table's chunk store, which may be a remote.
+//
+// Row value tuples hold their tagged values in tag order, so joining a row's values from both maps gives the same
+// tuple, and joining all of a table's rows gives the same map, as storing the whole row would.
+
+var errColdRowWithoutRow = errors.New("the cold values of a row are stored without the rest of the row")
+
+// coldTagSet returns the set of the tags of the cold columns of |sch|, or nil if it has none.
+func coldTagSet(sch schema.Schema) *set.Uint64Set {
+	tags := schema.ColdColTags(sch)
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	return set.NewUint64Set(tags)
+}
+
+// SplitRowValue splits the value tuple of a row into the tuple of the values of the columns which aren't in |coldTags|
+// and the tuple of the values of those which are. The cold tuple is empty when the row has no cold values.
+func SplitRowValue(nbf *types.NomsBinFormat, val types.Tuple, coldTags *set.Uint64Set) (hot, cold types.Tuple, err error) {
+	var hotVals, coldVals []types.Value
+	err = val.IterFields(func(i uint64, v types.Value) (stop bool, err error) {
+		if i%2 == 1 {
+			return false, nil
+		}
+
+		fv, err := val.Get(i + 1)
+
+		if err != nil {
+			return true, err
+		}
+
+		if coldTags.Contains(uint64(v.(types.Uint))) {
+			coldVals = append(coldVals, v, fv)
+		} else {
+			hotVals = append(hotVals, v, fv)
+		}
+
+		return false, nil
+	})
+
+	if err != nil {
+		return types.EmptyTuple(nbf), types.EmptyTuple(nbf), err
+	}
+
+	hot, err = types.NewTuple(nbf, hotVals...)
+
+	if err != nil {
+		return types.EmptyTuple(nbf), types.EmptyTuple(nbf), err
+	}
+
+	cold, err = types.NewTuple(nbf, coldVals...)
+
+	if err != nil {
+		return types.EmptyTuple(nbf), types.EmptyTuple(nbf), err
+	}
+
+	return hot, cold, nil
+}
+
+// JoinRowValues returns the value tuple of a row whose values are split between |hot| and |cold|, merging their tagged
+// values in tag order.
+func JoinRowValues(nbf *types.NomsBinFormat, hot, cold types.Tuple) (types.Tuple, error) {
+	if cold.Len() == 0 {
+		return hot, nil
+	}
+
+	vals := make([]types.Value, 0, hot.Len()+cold.Len())
+	var hi, ci uint64
+	for hi < hot.Len() || ci < cold.Len() {
+		src, i := &hot, &hi
+
+		if hi >= hot.Len() {
+			src, i = &cold, &ci
+		} else if ci < cold.Len() {
+			hotTag, err := hot.Get(hi)
+
+			if err != nil {
+				return types.EmptyTuple(nbf), err
+			}
+
+			coldTag, err := cold.Get(ci)
+
+			if err != nil {
+				return types.EmptyTuple(nbf), err
+			}
+
+			if coldTag.(types.Uint) < hotTag.(types.Uint) {
+				src, i = &cold, &ci
+			}
+		}
+
+		tag, err := src.Get(*i)
+
+		if err != nil {
+			return types.EmptyTuple(nbf), err
+		}
+
+		val, err := src.Get(*i + 1)
+
+		if err != nil {
+			return types.EmptyTuple(nbf), err
+		}
+
+		vals = append(vals, tag, val)
+		*i += 2
+	}
+
+	return types.NewTuple(nbf, vals...)
+}
+
+// splitRowData splits the rows of |rowData| into the map of the values of the columns which aren't in |coldTags| and
+// the map of the values of those which are.
+func splitRowData(ctx context.Context, vrw types.ValueReadWriter, rowData types.Map, coldTags *set.Uint64Set) (hot, cold types.Map, err error) {
+	ae := atomicerr.New()
+	hotKVs := make(chan types.Value, 64)
+	coldKVs := make(chan types.Value, 64)
+	hotMapChan := types.NewStreamingMap(ctx, vrw, ae, hotKVs)
+	coldMapChan := types.NewStreamingMap(ctx, vrw, ae, coldKVs)
+
+	err = func() error {
+		defer close(hotKVs)
+		defer close(coldKVs)
+
+		return rowData.IterAll(ctx, func(key, val types.Value) error {
+			if err := ae.Get(); err != nil {
+				return err
+			}
+
+			hotVal, coldVal, err := SplitRowValue(vrw.Format(), val.(types.Tuple), coldTags)
+
+			if err != nil {
+				return err
+			}
+
+			hotKVs <- key
+			hotKVs <- hotVal
+
+			if coldVal.Len() > 0 {
+				coldKVs <- key
+				coldKVs <- coldVal
+			}
+
+			return nil
+		})
+	}()
+
+	hot, cold = <-hotMapChan, <-coldMapChan
+
+	if err != nil {
+		return types.EmptyMap, types.EmptyMap, err
+	} else if err := ae.Get(); err != nil {
+		return types.EmptyMap, types.EmptyMap, err
+	}
+
+	return hot, cold, nil
+}
+
+// joinRowData returns the map of the rows whose values are split between |hot| and |cold|.
+func joinRowData(ctx context.Context, vrw types.ValueReadWriter, hot, cold types.Map) (types.Map, error) {
+	if cold.Empty() {
+		return hot, nil
+	}
+
+	ae := atomicerr.New()
+	kvs := make(chan types.Value, 64)
+	mapChan := types.NewStreamingMap(ctx, vrw, ae, kvs)
+
+	err := func() error {
+		defer close(kvs)
+
+		itr, err := NewJoinedRowIterator(ctx, hot, cold)
+
+		if err != nil {
+			return err
+		}
+
+		for {
+			if err := ae.Get(); err != nil {
+				return err
+			}
+
+			key, val, err := itr.Next(ctx)
+
+			if err != nil {
+				return err
+			} else if key == nil {
+				return nil
+			}
+
+			kvs <- key
+			kvs <- val
+		}
+	}()
+
+	rowData := <-mapChan
+
+	if err != nil {
+		return types.EmptyMap, err
+	} else if err := ae.Get(); err != nil {
+		return types.EmptyMap, err
+	}
+
+	return rowData, nil
+}
+
+// JoinedRowIterator iterates over the rows of a table whose values are split between two maps in primary key order,
+// joining the values of each row.
+type JoinedRowIterator struct {
+	nbf      *types.NomsBinFormat
+	hotItr   types.MapIterator
+	coldItr  types.MapIterator
+	coldKey  types.Value
+	coldVal  types.Value
+	coldDone bool
+}
+
+// NewJoinedRowIterator returns an iterator over the rows whose values are split between |hot| and |cold|.
+func NewJoinedRowIterator(ctx context.Context, hot, cold types.Map) (*JoinedRowIterator, error) {
+	hotItr, err := hot.BufferedIterator(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	coldItr, err := cold.BufferedIterator(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &JoinedRowIterator{nbf: hot.Format(), hotItr: hotItr, coldItr: coldItr}, nil
+}
+
+// Next returns the key and value of the next row, or nil ones when there are no more rows.
+func (itr *JoinedRowIterator) Next(ctx context.Context) (types.Value, types.Value, error) {
+	key, val, err := itr.hotItr.Next(ctx)
+
+	if err != nil || key == nil {
+		if err == nil && itr.coldKey != nil {
+			err = errColdRowWithoutRow
+		}
+
+		return nil, nil, err
+	}
+
+	if itr.coldKey == nil && !itr.coldDone {
+		itr.coldKey, itr.coldVal, err = itr.coldItr.Next(ctx)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		itr.coldDone = itr.coldKey == nil
+	}
+
+	if itr.coldKey == nil || !itr.coldKey.Equals(key) {
+		if itr.coldKey != nil {
+			if isLess, err := itr.coldKey.Less(itr.nbf, key); err != nil {
+				return nil, nil, err
+			} else if isLess {
+				return nil, nil, errColdRowWithoutRow
+			}
+		}
+
+		return key, val, nil
+	}
+
+	joined, err := JoinRowValues(itr.nbf, val.(types.Tuple), itr.coldVal.(types.Tuple))
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	itr.coldKey, itr.coldVal = nil, nil
+	return key, joined, nil
+}
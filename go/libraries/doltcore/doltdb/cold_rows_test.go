@@ -0,0 +1,136 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doltdb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/set"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+func createColdTestSchema(t *testing.T) schema.Schema {
+	sch := createTestSchema()
+	col, _ := sch.GetAllCols().GetByTag(isMarriedTag)
+	cold := col
+	cold.Cold = true
+	allCols, err := sch.GetAllCols().Replace(col, cold)
+	require.NoError(t, err)
+	return schema.SchemaFromCols(allCols)
+}
+
+func TestSplitAndJoinRowValues(t *testing.T) {
+	nbf := types.Format_7_18
+	val, err := types.NewTuple(nbf, types.Uint(1), types.String("a"), types.Uint(3), types.Bool(true), types.Uint(4), types.Uint(5))
+	require.NoError(t, err)
+
+	hot, cold, err := SplitRowValue(nbf, val, set.NewUint64Set([]uint64{3}))
+	require.NoError(t, err)
+	expectedHot, _ := types.NewTuple(nbf, types.Uint(1), types.String("a"), types.Uint(4), types.Uint(5))
+	expectedCold, _ := types.NewTuple(nbf, types.Uint(3), types.Bool(true))
+	assert.True(t, expectedHot.Equals(hot))
+	assert.True(t, expectedCold.Equals(cold))
+
+	joined, err := JoinRowValues(nbf, hot, cold)
+	require.NoError(t, err)
+	assert.True(t, val.Equals(joined))
+
+	hot, cold, err = SplitRowValue(nbf, expectedHot, set.NewUint64Set([]uint64{3}))
+	require.NoError(t, err)
+	assert.True(t, expectedHot.Equals(hot))
+	assert.Equal(t, uint64(0), cold.Len())
+}
+
+func TestTableWithColdColumns(t *testing.T) {
+	ctx := context.Background()
+	db, _ := dbfactory.MemFactory{}.CreateDB(ctx, types.Format_7_18, nil, nil)
+
+	sch := createColdTestSchema(t)
+	rowData, rows := createTestRowData(t, db, sch)
+	tbl, err := createTestTable(db, sch, rowData)
+	require.NoError(t, err)
+
+	hasCold, err := tbl.HasColdRows()
+	require.NoError(t, err)
+	assert.True(t, hasCold)
+
+	// the table's rows are the rows it was created with, but the cold values are stored apart from them
+	joined, err := tbl.GetRowData(ctx)
+	require.NoError(t, err)
+	assert.True(t, rowData.Equals(joined))
+
+	hot, err := tbl.GetHotRowData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, rowData.Len(), hot.Len())
+	err = hot.IterAll(ctx, func(key, val types.Value) error {
+		r, err := row.FromNoms(sch, key.(types.Tuple), val.(types.Tuple))
+		require.NoError(t, err)
+		_, ok := r.GetColVal(isMarriedTag)
+		assert.False(t, ok)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// only the rows with cold values are in the cold map
+	cold, ok, err := tbl.GetColdRowData(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, uint64(2), cold.Len())
+
+	for _, r := range rows {
+		key, err := r.NomsMapKey(sch).Value(ctx)
+		require.NoError(t, err)
+		read, ok, err := tbl.GetRow(ctx, key.(types.Tuple), sch)
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.True(t, row.AreEqual(r, read, sch), "%s != %s", row.Fmt(ctx, read, sch), row.Fmt(ctx, r, sch))
+	}
+
+	// rows are split when they're updated too
+	updatedData, err := rowData.Edit().Remove(rows[1].NomsMapKey(sch)).Map(ctx)
+	require.NoError(t, err)
+	updated, err := tbl.UpdateRows(ctx, updatedData)
+	require.NoError(t, err)
+	cold, _, err = updated.GetColdRowData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), cold.Len())
+	joined, err = updated.GetRowData(ctx)
+	require.NoError(t, err)
+	assert.True(t, updatedData.Equals(joined))
+
+	same, err := tbl.HasTheSameRows(updated)
+	require.NoError(t, err)
+	assert.False(t, same)
+
+	// a table without cold columns has the same row data, but stores it differently
+	notCold, err := createTestTable(db, createTestSchema(), rowData)
+	require.NoError(t, err)
+	hasCold, err = notCold.HasColdRows()
+	require.NoError(t, err)
+	assert.False(t, hasCold)
+	notColdData, err := notCold.GetRowData(ctx)
+	require.NoError(t, err)
+	assert.True(t, rowData.Equals(notColdData))
+	same, err = tbl.HasTheSameRows(notCold)
+	require.NoError(t, err)
+	assert.False(t, same)
+}
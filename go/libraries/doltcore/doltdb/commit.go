@@ -24,9 +24,10 @@ import (
 )
 
 const (
-	metaField      = "meta"
-	parentsField   = "parents"
-	rootValueField = "value"
+	metaField        = "meta"
+	parentsField     = "parents"
+	parentsListField = "parents_list"
+	rootValueField   = "value"
 )
 
 var errCommitHasNoMeta = errors.New("commit has no metadata")
@@ -78,23 +79,50 @@ func (c *Commit) GetCommitMeta() (*CommitMeta, error) {
 	return nil, errors.New(h.String() + " is a commit without the required metadata.")
 }
 
+// ParentHashes returns the hashes of the parents of the commit. The first parent of a merge commit is the head of the
+// branch the merge was committed to, except for merge commits written before the order of parents was recorded.
 func (c *Commit) ParentHashes(ctx context.Context) ([]hash.Hash, error) {
-	parentSet, err := c.getParents()
+	parentRefs, err := c.getParentRefs(ctx)
 
 	if err != nil {
 		return nil, err
 	}
 
-	hashes := make([]hash.Hash, 0, parentSet.Len())
-	err = parentSet.IterAll(ctx, func(parentVal types.Value) error {
-		parentRef := parentVal.(types.Ref)
-		parentHash := parentRef.TargetHash()
-		hashes = append(hashes, parentHash)
+	hashes := make([]hash.Hash, len(parentRefs))
+	for i, parentRef := range parentRefs {
+		hashes[i] = parentRef.TargetHash()
+	}
+
+	return hashes, nil
+}
 
+// getParentRefs returns the refs of the parents of the commit, in order if the commit records their order, and in the
+// order of the parents set otherwise.
+func (c *Commit) getParentRefs(ctx context.Context) ([]types.Ref, error) {
+	var parentRefs []types.Ref
+	appendRef := func(parentVal types.Value) error {
+		parentRefs = append(parentRefs, parentVal.(types.Ref))
 		return nil
-	})
+	}
+
+	if listVal, found, err := c.commitSt.MaybeGet(parentsListField); err != nil {
+		return nil, err
+	} else if found && listVal != nil {
+		err = listVal.(types.List).IterAll(ctx, func(parentVal types.Value, _ uint64) error {
+			return appendRef(parentVal)
+		})
+
+		return parentRefs, err
+	}
+
+	parentSet, err := c.getParents()
+
+	if err != nil {
+		return nil, err
+	}
 
-	return hashes, err
+	err = parentSet.IterAll(ctx, appendRef)
+	return parentRefs, err
 }
 
 func (c *Commit) getParents() (types.Set, error) {
@@ -127,30 +155,17 @@ func (c *Commit) Height() (uint64, error) {
 }
 
 func (c *Commit) getParent(ctx context.Context, idx int) (*types.Struct, error) {
-	parentSet, err := c.getParents()
+	parentRefs, err := c.getParentRefs(ctx)
 
 	if err != nil {
 		return nil, err
 	}
 
-	itr, err := parentSet.IteratorAt(ctx, uint64(idx))
-
-	if err != nil {
-		return nil, err
-	}
-
-	parentVal, err := itr.Next(ctx)
-
-	if err != nil {
-		return nil, err
-	}
-
-	if parentVal == nil {
+	if idx >= len(parentRefs) {
 		return nil, nil
 	}
 
-	parentRef := parentVal.(types.Ref)
-	targVal, err := parentRef.TargetValue(ctx, c.vrw)
+	targVal, err := parentRefs[idx].TargetValue(ctx, c.vrw)
 
 	if err != nil {
 		return nil, err
@@ -227,6 +242,20 @@ func getCommitAncestorRef(ctx context.Context, ref1, ref2 types.Ref, vrw types.V
 	return ancestorRef, nil
 }
 
+// IsAncestor returns whether |cm| is |descendant| or one of its ancestors. Both commits must be read from the same
+// database.
+func IsAncestor(ctx context.Context, cm, descendant *Commit) (bool, error) {
+	ancestor, err := GetCommitAncestor(ctx, cm, descendant)
+
+	if err == ErrNoCommonAncestor {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return ancestor.commitSt.Equals(cm.commitSt), nil
+}
+
 func (c *Commit) CanFastForwardTo(ctx context.Context, new *Commit) (bool, error) {
 	ancestor, err := GetCommitAncestor(ctx, c, new)
 
@@ -58,6 +58,12 @@ func NewCommitMeta(name, email, desc string) (*CommitMeta, error) {
 
 // NewCommitMetaWithUserTS creates a user metadata
 func NewCommitMetaWithUserTS(name, email, desc string, userTS time.Time) (*CommitMeta, error) {
+	return NewCommitMetaWithTimes(name, email, desc, CommitNowFunc(), userTS)
+}
+
+// NewCommitMetaWithTimes creates a CommitMeta with the timestamp |ts| and the user timestamp |userTS|, rather than
+// reading the timestamp from CommitNowFunc.
+func NewCommitMetaWithTimes(name, email, desc string, ts, userTS time.Time) (*CommitMeta, error) {
 	n := strings.TrimSpace(name)
 	e := strings.TrimSpace(email)
 	d := strings.TrimSpace(desc)
@@ -66,7 +72,7 @@ func NewCommitMetaWithUserTS(name, email, desc string, userTS time.Time) (*Commi
 		return nil, errors.New("Aborting commit due to empty commit message.")
 	}
 
-	ns := uint64(CommitNowFunc().UnixNano())
+	ns := uint64(ts.UnixNano())
 	ms := ns / uMilliToNano
 
 	userMS := userTS.UnixNano() / milliToNano
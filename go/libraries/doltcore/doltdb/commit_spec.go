@@ -42,6 +42,11 @@ func IsValidBranchRef(dref ref.DoltRef) bool {
 	return dref.GetType() == ref.BranchRefType && IsValidUserBranchName(dref.GetPath())
 }
 
+// IsValidTagRef returns true if the ref given is a tag whose name follows the same rules as branch names.
+func IsValidTagRef(dref ref.DoltRef) bool {
+	return dref.GetType() == ref.TagRefType && IsValidUserBranchName(dref.GetPath())
+}
+
 type CommitSpecType string
 
 const (
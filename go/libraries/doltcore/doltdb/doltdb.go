@@ -31,6 +31,7 @@ import (
 	"github.com/liquidata-inc/dolt/go/libraries/utils/pantoerr"
 	"github.com/liquidata-inc/dolt/go/store/datas"
 	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/nbs"
 	"github.com/liquidata-inc/dolt/go/store/types"
 )
 
@@ -45,6 +46,9 @@ const (
 	MasterBranch     = "master"
 	CommitStructName = "Commit"
 
+	// CreationCommitMessage is the message of the commit which initializes a repository
+	CreationCommitMessage = "Initialize data repository"
+
 	defaultChunksPerTF = 256 * 1024
 )
 
@@ -99,6 +103,18 @@ func (ddb *DoltDB) CSMetricsSummary() string {
 	return datas.GetCSStatSummaryForDB(ddb.db)
 }
 
+// Recompress rewrites the table files of the database, compressing its chunks with a dictionary trained on a sample of
+// them. Returns nbs.ErrRecompressUnsupported for databases whose table files aren't local.
+func (ddb *DoltDB) Recompress(ctx context.Context) (nbs.RecompressStats, error) {
+	rc, ok := datas.ChunkStoreFromDatabase(ddb.db).(nbs.Recompressor)
+
+	if !ok {
+		return nbs.RecompressStats{}, nbs.ErrRecompressUnsupported
+	}
+
+	return rc.Recompress(ctx)
+}
+
 // WriteEmptyRepo will create initialize the given db with a master branch which points to a commit which has valid
 // metadata for the creation commit, and an empty RootValue.
 func (ddb *DoltDB) WriteEmptyRepo(ctx context.Context, name, email string) error {
@@ -114,6 +130,13 @@ func (ddb *DoltDB) WriteEmptyRepoWithCommitTime(ctx context.Context, name, email
 		panic("Passed bad name or email.  Both should be valid")
 	}
 
+	cm, _ := NewCommitMetaWithUserTS(name, email, CreationCommitMessage, t)
+	return ddb.WriteEmptyRepoWithCommitMeta(ctx, cm)
+}
+
+// WriteEmptyRepoWithCommitMeta initializes the db in the same way as WriteEmptyRepo, using |cm| as the metadata of the
+// creation commit.
+func (ddb *DoltDB) WriteEmptyRepoWithCommitMeta(ctx context.Context, cm *CommitMeta) error {
 	ds, err := ddb.db.GetDataset(ctx, creationBranch)
 
 	if err != nil {
@@ -136,8 +159,6 @@ func (ddb *DoltDB) WriteEmptyRepoWithCommitTime(ctx context.Context, name, email
 		return err
 	}
 
-	cm, _ := NewCommitMetaWithUserTS(name, email, "Initialize data repository", t)
-
 	parentSet, err := types.NewSet(ctx, ddb.db)
 
 	if err != nil {
@@ -275,7 +296,15 @@ func (ddb *DoltDB) Resolve(ctx context.Context, cs *CommitSpec) (*Commit, error)
 	if cs.CSType == HashCommitSpec {
 		commitSt, err = getCommitStForHash(ctx, ddb.db, cs.CommitStringer.String())
 	} else if cs.CSType == RefCommitSpec {
-		commitSt, err = getCommitStForRef(ctx, ddb.db, cs.CommitStringer.(ref.DoltRef))
+		dref := cs.CommitStringer.(ref.DoltRef)
+		commitSt, err = getCommitStForRef(ctx, ddb.db, dref)
+
+		// a name which isn't the name of a branch can be the name of a tag
+		if err == ErrBranchNotFound && dref.GetType() == ref.BranchRefType {
+			if tagSt, tagErr := getCommitStForRef(ctx, ddb.db, ref.NewTagRef(dref.GetPath())); tagErr == nil {
+				commitSt, err = tagSt, nil
+			}
+		}
 	}
 
 	if err != nil {
@@ -313,6 +342,12 @@ func (ddb *DoltDB) WriteRootValue(ctx context.Context, rv *RootValue) (hash.Hash
 	return valHash, err
 }
 
+// Flush persists the values written to the database, including those which aren't reachable from any of its refs or
+// root values, such as the partial results of a long running rewrite which is yet to be swapped in.
+func (ddb *DoltDB) Flush(ctx context.Context) error {
+	return ddb.db.Flush(ctx)
+}
+
 // ReadRootValue reads the RootValue associated with the hash given and returns it. Returns an error if the value cannot
 // be read, or if the hash given doesn't represent a dolt RootValue.
 func (ddb *DoltDB) ReadRootValue(ctx context.Context, h hash.Hash) (*RootValue, error) {
@@ -421,29 +456,13 @@ func (ddb *DoltDB) WriteCommitDanglingCommit(ctx context.Context, valHash hash.H
 		return nil, errors.New("can't commit a value that is not a valid root value")
 	}
 
-	s, err := types.NewSet(ctx, ddb.db)
+	parentRefs, err := appendCommitRefs(nil, parentCommits, ddb.db.Format())
 
 	if err != nil {
 		return nil, err
 	}
 
-	parentEditor := s.Edit()
-
-	for _, cm := range parentCommits {
-		rf, err := types.NewRef(cm.commitSt, ddb.db.Format())
-
-		if err != nil {
-			return nil, err
-		}
-
-		_, err = parentEditor.Insert(rf)
-
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	parents, err := parentEditor.Set(ctx)
+	parents, parentsList, err := newParents(ctx, ddb.db, parentRefs)
 
 	if err != nil {
 		return nil, err
@@ -455,7 +474,7 @@ func (ddb *DoltDB) WriteCommitDanglingCommit(ctx context.Context, valHash hash.H
 		return nil, err
 	}
 
-	commitOpts := datas.CommitOptions{Parents: parents, Meta: st, Policy: nil}
+	commitOpts := datas.CommitOptions{Parents: parents, ParentsList: parentsList, Meta: st, Policy: nil}
 	commitSt, err = ddb.db.CommitDangling(ctx, val, commitOpts)
 
 	if err != nil {
@@ -483,69 +502,120 @@ func (ddb *DoltDB) CommitWithParentCommits(ctx context.Context, valHash hash.Has
 		return nil, err
 	}
 
-	s, err := types.NewSet(ctx, ddb.db)
+	var parentRefs []types.Ref
+	headRef, hasHead, err := ds.MaybeHeadRef()
 
 	if err != nil {
 		return nil, err
 	}
 
-	parentEditor := s.Edit()
+	if hasHead {
+		parentRefs = append(parentRefs, headRef)
+	}
 
-	headRef, hasHead, err := ds.MaybeHeadRef()
+	parentRefs, err = appendCommitRefs(parentRefs, parentCommits, ddb.db.Format())
 
 	if err != nil {
 		return nil, err
 	}
 
-	if hasHead {
-		_, err := parentEditor.Insert(headRef)
+	parents, parentsList, err := newParents(ctx, ddb.db, parentRefs)
 
-		if err != nil {
-			return nil, err
-		}
+	if err != nil {
+		return nil, err
 	}
 
-	for _, cm := range parentCommits {
-		rf, err := types.NewRef(cm.commitSt, ddb.db.Format())
+	st, err := cm.toNomsStruct(ddb.db.Format())
 
-		if err != nil {
-			return nil, err
-		}
+	if err != nil {
+		return nil, err
+	}
 
-		_, err = parentEditor.Insert(rf)
+	commitOpts := datas.CommitOptions{Parents: parents, ParentsList: parentsList, Meta: st, Policy: nil}
+	ds, err = ddb.db.GetDataset(ctx, dref.String())
 
-		if err != nil {
-			return nil, err
-		}
+	if err != nil {
+		return nil, err
 	}
 
-	parents, err := parentEditor.Set(ctx)
+	ds, err = ddb.db.Commit(ctx, ds, val, commitOpts)
 
 	if err != nil {
 		return nil, err
 	}
 
-	st, err := cm.toNomsStruct(ddb.db.Format())
+	var ok bool
+	commitSt, ok = ds.MaybeHead()
+	if !ok {
+		return nil, errors.New("commit has no head but commit succeeded (How?!?!?)")
+	}
+
+	return &Commit{ddb.db, commitSt}, nil
+}
+
+// CommitWithExpectedHead commits the value hash given to the branch given, using expectedHead and the list of parent
+// commits given as the parents of the new commit. The branch head is only updated if it is still expectedHead when the
+// commit is written. If another writer has moved the head of the branch ErrHeadMoved is returned and the branch is left
+// unchanged.
+func (ddb *DoltDB) CommitWithExpectedHead(ctx context.Context, valHash hash.Hash, dref ref.DoltRef, expectedHead *Commit, parentCommits []*Commit, cm *CommitMeta) (*Commit, error) {
+	val, err := ddb.db.ReadValue(ctx, valHash)
 
 	if err != nil {
 		return nil, err
 	}
 
-	commitOpts := datas.CommitOptions{Parents: parents, Meta: st, Policy: nil}
-	ds, err = ddb.db.GetDataset(ctx, dref.String())
+	if st, ok := val.(types.Struct); !ok || st.Name() != ddbRootStructName {
+		return nil, errors.New("can't commit a value that is not a valid root value")
+	}
+
+	expectedHeadRef, err := types.NewRef(expectedHead.commitSt, ddb.db.Format())
 
 	if err != nil {
 		return nil, err
 	}
 
-	ds, err = ddb.db.Commit(ctx, ds, val, commitOpts)
+	ds, err := ddb.db.GetDataset(ctx, dref.String())
 
 	if err != nil {
 		return nil, err
 	}
 
-	var ok bool
-	commitSt, ok = ds.MaybeHead()
+	if headRef, hasHead, err := ds.MaybeHeadRef(); err != nil {
+		return nil, err
+	} else if !hasHead || !headRef.Equals(expectedHeadRef) {
+		return nil, ErrHeadMoved
+	}
+
+	parentRefs, err := appendCommitRefs([]types.Ref{expectedHeadRef}, parentCommits, ddb.db.Format())
+
+	if err != nil {
+		return nil, err
+	}
+
+	parents, parentsList, err := newParents(ctx, ddb.db, parentRefs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := cm.toNomsStruct(ddb.db.Format())
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Without a merge policy the commit fails with ErrMergeNeeded unless the current head of the dataset is an ancestor
+	// of the new commit, which is only the case if the head is still expectedHead.
+	commitOpts := datas.CommitOptions{Parents: parents, ParentsList: parentsList, Meta: st, Policy: nil}
+	ds, err = ddb.db.Commit(ctx, ds, val, commitOpts)
+
+	if err == datas.ErrMergeNeeded {
+		return nil, ErrHeadMoved
+	} else if err != nil {
+		return nil, err
+	}
+
+	commitSt, ok := ds.MaybeHead()
 	if !ok {
 		return nil, errors.New("commit has no head but commit succeeded (How?!?!?)")
 	}
@@ -568,30 +638,14 @@ func (ddb *DoltDB) CommitDanglingWithParentCommits(ctx context.Context, valHash
 			return errors.New("can't commit a value that is not a valid root value")
 		}
 
-		s, err := types.NewSet(ctx, ddb.db)
+		parentRefs, err := appendCommitRefs(nil, parentCommits, ddb.db.Format())
 
 		if err != nil {
 			return err
 		}
 
-		parentEditor := s.Edit()
-
-		for _, cm := range parentCommits {
-			rf, err := types.NewRef(cm.commitSt, ddb.db.Format())
-
-			if err != nil {
-				return err
-			}
-
-			_, err = parentEditor.Insert(rf)
-
-			if err != nil {
-				return err
-			}
-		}
-
 		// even orphans have parents
-		parents, err := parentEditor.Set(ctx)
+		parents, parentsList, err := newParents(ctx, ddb.db, parentRefs)
 
 		if err != nil {
 			return err
@@ -603,7 +657,7 @@ func (ddb *DoltDB) CommitDanglingWithParentCommits(ctx context.Context, valHash
 			return err
 		}
 
-		commitOpts := datas.CommitOptions{Parents: parents, Meta: st, Policy: nil}
+		commitOpts := datas.CommitOptions{Parents: parents, ParentsList: parentsList, Meta: st, Policy: nil}
 
 		commitSt, err = ddb.db.CommitDangling(ctx, val, commitOpts)
 
@@ -617,15 +671,78 @@ func (ddb *DoltDB) CommitDanglingWithParentCommits(ctx context.Context, valHash
 	return &Commit{ddb.db, commitSt}, nil
 }
 
+func appendCommitRefs(refs []types.Ref, commits []*Commit, nbf *types.NomsBinFormat) ([]types.Ref, error) {
+	for _, cm := range commits {
+		rf, err := types.NewRef(cm.commitSt, nbf)
+
+		if err != nil {
+			return nil, err
+		}
+
+		refs = append(refs, rf)
+	}
+
+	return refs, nil
+}
+
+// newParents returns the parents set of a commit with the parents given, and the list of them in order when there is
+// more than one, as the order of the set is that of the hashes of the parents. Parents given more than once are kept
+// at their first position.
+func newParents(ctx context.Context, vrw types.ValueReadWriter, parentRefs []types.Ref) (types.Set, types.List, error) {
+	seen := make(map[hash.Hash]bool)
+	vals := make([]types.Value, 0, len(parentRefs))
+	for _, rf := range parentRefs {
+		if !seen[rf.TargetHash()] {
+			seen[rf.TargetHash()] = true
+			vals = append(vals, rf)
+		}
+	}
+
+	var parentsList types.List
+	if len(vals) > 1 {
+		var err error
+		parentsList, err = types.NewList(ctx, vrw, vals...)
+
+		if err != nil {
+			return types.EmptySet, types.List{}, err
+		}
+	}
+
+	// NewSet sorts the values it's given, so the list is built first
+	parents, err := types.NewSet(ctx, vrw, vals...)
+
+	if err != nil {
+		return types.EmptySet, types.List{}, err
+	}
+
+	return parents, parentsList, nil
+}
+
 // ValueReadWriter returns the underlying noms database as a types.ValueReadWriter.
 func (ddb *DoltDB) ValueReadWriter() types.ValueReadWriter {
 	return ddb.db
 }
 
+// ChunkStore returns the chunk store of the underlying noms database.
+func (ddb *DoltDB) ChunkStore() chunks.ChunkStore {
+	return datas.ChunkStoreFromDatabase(ddb.db)
+}
+
 func (ddb *DoltDB) Format() *types.NomsBinFormat {
 	return ddb.db.Format()
 }
 
+// RegisterCaches registers the caches of the underlying noms database with the cacheregistry, so that they can be
+// reported and flushed. The function returned unregisters them. The schema cache is shared by every DoltDB, and is
+// always registered.
+func (ddb *DoltDB) RegisterCaches() (unregister func()) {
+	if vs, ok := ddb.db.(interface{ RegisterCaches() func() }); ok {
+		return vs.RegisterCaches()
+	}
+
+	return func() {}
+}
+
 func writeValAndGetRef(ctx context.Context, vrw types.ValueReadWriter, val types.Value) (types.Ref, error) {
 	valRef, err := types.NewRef(val, vrw.Format())
 
@@ -654,66 +771,41 @@ func writeValAndGetRef(ctx context.Context, vrw types.ValueReadWriter, val types
 // non-nil in the case that the commit cannot be resolved, there aren't as many ancestors as requested, or the
 // underlying storage cannot be accessed.
 func (ddb *DoltDB) ResolveParent(ctx context.Context, commit *Commit, parentIdx int) (*Commit, error) {
-	var parentCommitSt types.Struct
-	parentSet, err := commit.getParents()
-
-	if err != nil {
-		return nil, err
-	}
-
-	itr, err := parentSet.IteratorAt(ctx, uint64(parentIdx))
+	parentRefs, err := commit.getParentRefs(ctx)
 
 	if err != nil {
 		return nil, err
 	}
 
-	parentCommRef, err := itr.Next(ctx)
-
-	if err != nil {
-		return nil, err
+	if parentIdx >= len(parentRefs) {
+		return nil, fmt.Errorf("commit has %d parents, no parent at index %d", len(parentRefs), parentIdx)
 	}
 
-	parentVal, err := parentCommRef.(types.Ref).TargetValue(ctx, ddb.ValueReadWriter())
+	parentVal, err := parentRefs[parentIdx].TargetValue(ctx, ddb.ValueReadWriter())
 
 	if err != nil {
 		return nil, err
 	}
 
-	parentCommitSt = parentVal.(types.Struct)
-
-	return &Commit{ddb.ValueReadWriter(), parentCommitSt}, nil
+	return &Commit{ddb.ValueReadWriter(), parentVal.(types.Struct)}, nil
 }
 
 func (ddb *DoltDB) ResolveAllParents(ctx context.Context, commit *Commit) ([]*Commit, error) {
-	var parentCommitSt types.Struct
-	parentSet, err := commit.getParents()
-
-	if err != nil {
-		return nil, err
-	}
-
-	itr, err := parentSet.IteratorAt(ctx, 0)
+	parentRefs, err := commit.getParentRefs(ctx)
 
 	if err != nil {
 		return nil, err
 	}
 
 	var allParents []*Commit
-	for i := 0; i < int(parentSet.Len()); i++ {
-		parentCommRef, err := itr.Next(ctx)
-		if err != nil {
-			return nil, err
-		}
-
-		parentVal, err := parentCommRef.(types.Ref).TargetValue(ctx, ddb.ValueReadWriter())
+	for _, parentRef := range parentRefs {
+		parentVal, err := parentRef.TargetValue(ctx, ddb.ValueReadWriter())
 
 		if err != nil {
 			return nil, err
 		}
 
-		parentCommitSt = parentVal.(types.Struct)
-
-		allParents = append(allParents, &Commit{ddb.ValueReadWriter(), parentCommitSt})
+		allParents = append(allParents, &Commit{ddb.ValueReadWriter(), parentVal.(types.Struct)})
 	}
 	return allParents, nil
 }
@@ -736,6 +828,20 @@ func (ddb *DoltDB) GetBranches(ctx context.Context) ([]ref.DoltRef, error) {
 	return ddb.GetRefsOfType(ctx, branchRefFilter)
 }
 
+var tagRefFilter = map[ref.RefType]struct{}{ref.TagRefType: {}}
+
+// GetTags returns a list of all tags in the database.
+func (ddb *DoltDB) GetTags(ctx context.Context) ([]ref.DoltRef, error) {
+	return ddb.GetRefsOfType(ctx, tagRefFilter)
+}
+
+var workspaceRefFilter = map[ref.RefType]struct{}{ref.WorkspaceRefType: {}}
+
+// GetWorkspaces returns a list of all sql-server workspaces in the database.
+func (ddb *DoltDB) GetWorkspaces(ctx context.Context) ([]ref.DoltRef, error) {
+	return ddb.GetRefsOfType(ctx, workspaceRefFilter)
+}
+
 func (ddb *DoltDB) GetRefs(ctx context.Context) ([]ref.DoltRef, error) {
 	return ddb.GetRefsOfType(ctx, ref.RefTypes)
 }
@@ -809,6 +915,38 @@ func (ddb *DoltDB) DeleteBranch(ctx context.Context, dref ref.DoltRef) error {
 	return err
 }
 
+// NewTagAtCommit points the tag given at the commit given, replacing it if it already exists. Tag names must pass
+// IsValidUserBranchName.
+func (ddb *DoltDB) NewTagAtCommit(ctx context.Context, dref ref.DoltRef, commit *Commit) error {
+	if !IsValidTagRef(dref) {
+		panic(fmt.Sprintf("invalid tag name %s, use IsValidUserBranchName check", dref.String()))
+	}
+
+	return ddb.SetHead(ctx, dref, commit)
+}
+
+// DeleteTag deletes the tag given, returning an error if it doesn't exist.
+func (ddb *DoltDB) DeleteTag(ctx context.Context, dref ref.DoltRef) error {
+	err := ddb.DeleteBranch(ctx, dref)
+
+	if err == ErrBranchNotFound {
+		return ErrTagNotFound
+	}
+
+	return err
+}
+
+// DeleteWorkspace deletes the workspace given, returning an error if it doesn't exist.
+func (ddb *DoltDB) DeleteWorkspace(ctx context.Context, dref ref.DoltRef) error {
+	err := ddb.DeleteBranch(ctx, dref)
+
+	if err == ErrBranchNotFound {
+		return ErrWorkspaceNotFound
+	}
+
+	return err
+}
+
 // PushChunks initiates a push into a database from the source database given, at the commit given. Pull progress is
 // communicated over the provided channel.
 func (ddb *DoltDB) PushChunks(ctx context.Context, tempDir string, srcDB *DoltDB, cm *Commit, progChan chan datas.PullProgress, pullerEventCh chan datas.PullerEvent) error {
@@ -834,7 +972,8 @@ func (ddb *DoltDB) PushChunks(ctx context.Context, tempDir string, srcDB *DoltDB
 }
 
 // PullChunks initiates a pull into a database from the source database given, at the commit given. Progress is
-// communicated over the provided channel.
+// communicated over the provided channel. The heads of the database's branches are the known heads of the pull, so
+// only the commits which are new to the database are walked.
 func (ddb *DoltDB) PullChunks(ctx context.Context, tempDir string, srcDB *DoltDB, cm *Commit, progChan chan datas.PullProgress, pullerEventCh chan datas.PullerEvent) error {
 	rf, err := types.NewRef(cm.commitSt, ddb.db.Format())
 
@@ -843,7 +982,13 @@ func (ddb *DoltDB) PullChunks(ctx context.Context, tempDir string, srcDB *DoltDB
 	}
 
 	if datas.CanUsePuller(srcDB.db) && datas.CanUsePuller(ddb.db) {
-		puller, err := datas.NewPuller(ctx, tempDir, 256*1024, srcDB.db, ddb.db, rf.TargetHash(), pullerEventCh)
+		knownHeads, err := datas.CommitHeads(ctx, ddb.db)
+
+		if err != nil {
+			return err
+		}
+
+		puller, err := datas.NewPullerWithKnownHeads(ctx, tempDir, 256*1024, srcDB.db, ddb.db, rf, knownHeads, pullerEventCh)
 
 		if err == datas.ErrDBUpToDate {
 			return nil
@@ -18,15 +18,21 @@ import (
 	"context"
 	"io/ioutil"
 	"path/filepath"
+	"strconv"
+	"sync"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/test"
+	"github.com/liquidata-inc/dolt/go/store/atomicerr"
 	"github.com/liquidata-inc/dolt/go/store/hash"
 	"github.com/liquidata-inc/dolt/go/store/types"
 )
@@ -219,3 +225,204 @@ func TestLDNoms(t *testing.T) {
 		}
 	}
 }
+
+func TestCommitWithExpectedHeadConcurrentWriters(t *testing.T) {
+	const numWriters = 4
+	const commitsPerWriter = 10
+
+	ctx := context.Background()
+	ddb, err := LoadDoltDB(ctx, types.Format_7_18, InMemDoltDB)
+	require.NoError(t, err)
+	require.NoError(t, ddb.WriteEmptyRepo(ctx, "Bill Billerson", "bigbillieb@fake.horse"))
+
+	branch := ref.NewBranchRef("master")
+	cs, _ := NewCommitSpec("HEAD", branch.String())
+	head, err := ddb.Resolve(ctx, cs)
+	require.NoError(t, err)
+	root, err := head.GetRootValue()
+	require.NoError(t, err)
+
+	sch := createTestSchema()
+	emptyRows, err := types.NewMap(ctx, ddb.db)
+	require.NoError(t, err)
+	tbl, err := createTestTable(ddb.db, sch, emptyRows)
+	require.NoError(t, err)
+	root, err = root.PutTable(ctx, "test", tbl)
+	require.NoError(t, err)
+	h, err := ddb.WriteRootValue(ctx, root)
+	require.NoError(t, err)
+	meta, err := NewCommitMeta("Bill Billerson", "bigbillieb@fake.horse", "create table")
+	require.NoError(t, err)
+	_, err = ddb.CommitWithExpectedHead(ctx, h, branch, head, nil, meta)
+	require.NoError(t, err)
+
+	ae := atomicerr.New()
+	wg := &sync.WaitGroup{}
+	for i := 0; i < numWriters; i++ {
+		writer := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ae.SetIfError(writeConcurrently(ctx, ddb, cs, branch, sch, writer, commitsPerWriter))
+		}()
+	}
+
+	wg.Wait()
+	require.NoError(t, ae.Get())
+
+	head, err = ddb.Resolve(ctx, cs)
+	require.NoError(t, err)
+	root, err = head.GetRootValue()
+	require.NoError(t, err)
+	tbl, _, err = root.GetTable(ctx, "test")
+	require.NoError(t, err)
+	rows, err := tbl.GetRowData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(numWriters*commitsPerWriter), rows.Len())
+}
+
+func writeConcurrently(ctx context.Context, ddb *DoltDB, cs *CommitSpec, branch ref.DoltRef, sch schema.Schema, writer, numCommits int) error {
+	for j := 0; j < numCommits; j++ {
+		for {
+			head, err := ddb.Resolve(ctx, cs)
+
+			if err != nil {
+				return err
+			}
+
+			h, err := addRowAndWriteRoot(ctx, ddb, head, sch, writer, j)
+
+			if err != nil {
+				return err
+			}
+
+			meta, err := NewCommitMeta("Bill Billerson", "bigbillieb@fake.horse", "concurrent write")
+
+			if err != nil {
+				return err
+			}
+
+			_, err = ddb.CommitWithExpectedHead(ctx, h, branch, head, nil, meta)
+
+			if err == nil {
+				break
+			} else if err != ErrHeadMoved {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func addRowAndWriteRoot(ctx context.Context, ddb *DoltDB, head *Commit, sch schema.Schema, writer, n int) (hash.Hash, error) {
+	root, err := head.GetRootValue()
+
+	if err != nil {
+		return hash.Hash{}, err
+	}
+
+	tbl, _, err := root.GetTable(ctx, "test")
+
+	if err != nil {
+		return hash.Hash{}, err
+	}
+
+	rows, err := tbl.GetRowData(ctx)
+
+	if err != nil {
+		return hash.Hash{}, err
+	}
+
+	r, err := row.New(types.Format_7_18, sch, row.TaggedValues{
+		idTag:    types.UUID(uuid.New()),
+		firstTag: types.String("writer " + strconv.Itoa(writer)),
+		lastTag:  types.String(strconv.Itoa(n)),
+	})
+
+	if err != nil {
+		return hash.Hash{}, err
+	}
+
+	rows, err = rows.Edit().Set(r.NomsMapKey(sch), r.NomsMapValue(sch)).Map(ctx)
+
+	if err != nil {
+		return hash.Hash{}, err
+	}
+
+	tbl, err = tbl.UpdateRows(ctx, rows)
+
+	if err != nil {
+		return hash.Hash{}, err
+	}
+
+	root, err = root.PutTable(ctx, "test", tbl)
+
+	if err != nil {
+		return hash.Hash{}, err
+	}
+
+	return ddb.WriteRootValue(ctx, root)
+}
+
+func TestMergeCommitParentOrder(t *testing.T) {
+	ctx := context.Background()
+	ddb, err := LoadDoltDB(ctx, types.Format_7_18, InMemDoltDB)
+	require.NoError(t, err)
+	require.NoError(t, ddb.WriteEmptyRepo(ctx, "Bill Billerson", "bigbillieb@fake.horse"))
+
+	cs, _ := NewCommitSpec("HEAD", "master")
+	init, err := ddb.Resolve(ctx, cs)
+	require.NoError(t, err)
+	root, err := init.GetRootValue()
+	require.NoError(t, err)
+	h, err := ddb.WriteRootValue(ctx, root)
+	require.NoError(t, err)
+
+	var branchHeads []*Commit
+	for _, msg := range []string{"one", "two"} {
+		meta, err := NewCommitMeta("Bill Billerson", "bigbillieb@fake.horse", msg)
+		require.NoError(t, err)
+		cm, err := ddb.CommitWithParentCommits(ctx, h, ref.NewBranchRef(msg), []*Commit{init}, meta)
+		require.NoError(t, err)
+		branchHeads = append(branchHeads, cm)
+	}
+
+	one, err := branchHeads[0].HashOf()
+	require.NoError(t, err)
+	two, err := branchHeads[1].HashOf()
+	require.NoError(t, err)
+
+	// whichever way round the parents hash, the first parent is the head the merge was committed on
+	for _, parents := range [][]*Commit{{branchHeads[0], branchHeads[1]}, {branchHeads[1], branchHeads[0]}} {
+		meta, err := NewCommitMeta("Bill Billerson", "bigbillieb@fake.horse", "merge")
+		require.NoError(t, err)
+		merge, err := ddb.CommitDanglingWithParentCommits(ctx, h, parents, meta)
+		require.NoError(t, err)
+
+		expected := []hash.Hash{one, two}
+		if parents[0] == branchHeads[1] {
+			expected = []hash.Hash{two, one}
+		}
+
+		parentHashes, err := merge.ParentHashes(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, expected, parentHashes)
+
+		first, err := ddb.ResolveParent(ctx, merge, 0)
+		require.NoError(t, err)
+		firstHash, err := first.HashOf()
+		require.NoError(t, err)
+		assert.Equal(t, expected[0], firstHash)
+
+		_, err = ddb.ResolveParent(ctx, merge, 2)
+		assert.Error(t, err)
+
+		allParents, err := ddb.ResolveAllParents(ctx, merge)
+		require.NoError(t, err)
+		require.Len(t, allParents, 2)
+		secondHash, err := allParents[1].HashOf()
+		require.NoError(t, err)
+		assert.Equal(t, expected[1], secondHash)
+	}
+}
@@ -14,7 +14,11 @@
 
 package doltdb
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/errcat"
+)
 
 var ErrInvBranchName = errors.New("not a valid user branch name")
 var ErrInvTableName = errors.New("not a valid table name")
@@ -24,12 +28,17 @@ var ErrInvalidBranchOrHash = errors.New("string is not a valid branch or hash")
 var ErrInvalidHash = errors.New("string is not a valid hash")
 
 var ErrFoundHashNotACommit = errors.New("the value retrieved for this hash is not a commit")
+var ErrFoundHashNotATable = errors.New("the value retrieved for this hash is not a table")
 
-var ErrHashNotFound = errors.New("could not find a value for this hash")
-var ErrBranchNotFound = errors.New("branch not found")
-var ErrTableNotFound = errors.New("table not found")
+var ErrHashNotFound = errcat.New(errcat.NotFound, "could not find a value for this hash")
+var ErrBranchNotFound = errcat.New(errcat.NotFound, "branch not found")
+var ErrTagNotFound = errcat.New(errcat.NotFound, "tag not found")
+var ErrWorkspaceNotFound = errcat.New(errcat.NotFound, "workspace not found")
+var ErrTableNotFound = errcat.New(errcat.NotFound, "table not found")
 var ErrTableExists = errors.New("table already exists")
+var ErrTablePinNotFound = errors.New("table ref is not pinned")
 var ErrAlreadyOnBranch = errors.New("Already on branch")
+var ErrHeadMoved = errcat.New(errcat.ConcurrentModification, "the head of the branch was changed by another writer")
 
 var ErrNomsIO = errors.New("error reading from or writing to noms")
 
@@ -49,7 +58,7 @@ func IsInvalidFormatErr(err error) bool {
 
 func IsNotFoundErr(err error) bool {
 	switch err {
-	case ErrHashNotFound, ErrBranchNotFound, ErrTableNotFound:
+	case ErrHashNotFound, ErrBranchNotFound, ErrTagNotFound, ErrWorkspaceNotFound, ErrTableNotFound:
 		return true
 	default:
 		return false
@@ -0,0 +1,331 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doltdb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+)
+
+// Table and column names follow the same rules whether they're created with the CLI, an import or SQL, so that
+// anything created by one can be used by the others. The rules are those of MySQL for quoted identifiers:
+//
+//   - Names are 1 to MaxIdentifierLength characters long.
+//   - Table names must also match TableNameRegexStr, so they never need quoting.
+//   - Column names may use any character of the Unicode Basic Multilingual Plane except U+0000, but may not end with a
+//     space. A column name which isn't a plain word must be quoted with backticks in SQL, as in `unit price`.
+//   - Names are case insensitive when tables or columns are looked up, so two tables of a root, or two columns of a
+//     table, may not have names which differ only by case.
+
+// MaxIdentifierLength is the maximum length, in characters, of a table or column name.
+const MaxIdentifierLength = 64
+
+// maxBMPRune is the last character of the Unicode Basic Multilingual Plane.
+const maxBMPRune = 0xFFFF
+
+// IdentifierError is the error for a table or column name which breaks the identifier rules.
+type IdentifierError struct {
+	// Kind is "table" or "column"
+	Kind   string
+	Name   string
+	Reason string
+}
+
+func (e IdentifierError) Error() string {
+	return fmt.Sprintf("'%s' is not a valid %s name: %s", e.Name, e.Kind, e.Reason)
+}
+
+// IsIdentifierError returns whether an error is an IdentifierError.
+func IsIdentifierError(err error) bool {
+	_, ok := err.(IdentifierError)
+	return ok
+}
+
+// ValidateTableName returns an IdentifierError if |name| isn't a valid table name.
+func ValidateTableName(name string) error {
+	if !IsValidTableName(name) {
+		return IdentifierError{"table", name, "table names must match the regular expression: " + TableNameRegexStr}
+	} else if utf8.RuneCountInString(name) > MaxIdentifierLength {
+		return IdentifierError{"table", name, fmt.Sprintf("table names may be at most %d characters long", MaxIdentifierLength)}
+	}
+
+	return nil
+}
+
+// ValidateColumnName returns an IdentifierError if |name| isn't a valid column name.
+func ValidateColumnName(name string) error {
+	reason := ""
+	switch {
+	case name == "":
+		reason = "column names can't be empty"
+	case !utf8.ValidString(name):
+		reason = "column names must be valid UTF-8"
+	case utf8.RuneCountInString(name) > MaxIdentifierLength:
+		reason = fmt.Sprintf("column names may be at most %d characters long", MaxIdentifierLength)
+	case strings.HasSuffix(name, " "):
+		reason = "column names can't end with a space"
+	case strings.IndexFunc(name, func(r rune) bool { return r == 0 || r > maxBMPRune }) != -1:
+		reason = "column names can't contain U+0000 or characters outside the Unicode Basic Multilingual Plane"
+	}
+
+	if reason != "" {
+		return IdentifierError{"column", name, reason}
+	}
+
+	return nil
+}
+
+// ValidateSchemaColumnNames returns an IdentifierError for the first column of |sch| with an invalid name, or with a
+// name which differs only by case from the name of another of its columns.
+func ValidateSchemaColumnNames(sch schema.Schema) error {
+	var names []string
+	err := sch.GetAllCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+		names = append(names, col.Name)
+		return false, ValidateColumnName(col.Name)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if collisions := CaseCollisions(names); len(collisions) > 0 {
+		return caseCollisionErr("column", collisions[0][1], collisions[0][0])
+	}
+
+	return nil
+}
+
+// ValidateNewColumnName returns an IdentifierError if |name| isn't a valid column name, or if it differs only by case
+// from the name of a column of |sch| other than the column with tag |tag|.
+func ValidateNewColumnName(sch schema.Schema, tag uint64, name string) error {
+	if err := ValidateColumnName(name); err != nil {
+		return err
+	}
+
+	return sch.GetAllCols().Iter(func(colTag uint64, col schema.Column) (stop bool, err error) {
+		if colTag != tag && col.Name != name && strings.EqualFold(col.Name, name) {
+			return true, caseCollisionErr("column", name, col.Name)
+		}
+
+		return false, nil
+	})
+}
+
+func caseCollisionErr(kind, name, existing string) error {
+	return IdentifierError{kind, name, fmt.Sprintf("%s names are case insensitive, and it differs only by case from the %s '%s'", kind, kind, existing)}
+}
+
+// CaseCollisions returns the groups of |names| which differ only by case, each in the order they appear in |names|.
+func CaseCollisions(names []string) [][]string {
+	groups := make(map[string][]string)
+	var order []string
+	for _, name := range names {
+		lwr := strings.ToLower(name)
+		if _, ok := groups[lwr]; !ok {
+			order = append(order, lwr)
+		}
+
+		groups[lwr] = append(groups[lwr], name)
+	}
+
+	var collisions [][]string
+	for _, lwr := range order {
+		if len(groups[lwr]) > 1 {
+			collisions = append(collisions, groups[lwr])
+		}
+	}
+
+	return collisions
+}
+
+// ValidateNewTableName returns an IdentifierError if |name| isn't a valid table name, or if |root| has a table whose
+// name differs from it only by case. A table already named |name|, or named |oldName| when a table is being renamed,
+// isn't an error.
+func ValidateNewTableName(ctx context.Context, root *RootValue, name, oldName string) error {
+	if err := ValidateTableName(name); err != nil {
+		return err
+	}
+
+	names, err := root.GetTableNames(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range names {
+		if existing != name && existing != oldName && strings.EqualFold(existing, name) {
+			return caseCollisionErr("table", name, existing)
+		}
+	}
+
+	return nil
+}
+
+// IdentifierProblem describes a table or column of a root whose name breaks the identifier rules.
+type IdentifierProblem struct {
+	Table string
+	// Column is empty for a problem with the name of a table
+	Column string
+	Err    error
+	// Suggestion is a valid name which doesn't collide with any other name, which the table or column could be renamed to.
+	Suggestion string
+}
+
+// CheckIdentifiers returns the problems with the names of the tables of |root| and their columns, in table name order.
+// Tables and columns created before the identifier rules were enforced may break them.
+func CheckIdentifiers(ctx context.Context, root *RootValue) ([]IdentifierProblem, error) {
+	names, err := root.GetTableNames(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(names)
+	tblProblems := checkNames("table", names, ValidateTableName, SuggestTableName)
+
+	var problems []IdentifierProblem
+	for _, name := range names {
+		if p, ok := tblProblems[name]; ok {
+			problems = append(problems, IdentifierProblem{name, "", p.Err, p.Suggestion})
+		}
+
+		tbl, _, err := root.GetTable(ctx, name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		sch, err := tbl.GetSchema(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var colNames []string
+		_ = sch.GetAllCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+			colNames = append(colNames, col.Name)
+			return false, nil
+		})
+
+		colProblems := checkNames("column", colNames, ValidateColumnName, SuggestColumnName)
+		for _, colName := range colNames {
+			if p, ok := colProblems[colName]; ok {
+				problems = append(problems, IdentifierProblem{name, colName, p.Err, p.Suggestion})
+			}
+		}
+	}
+
+	return problems, nil
+}
+
+// checkNames returns the problems with |names|, keyed by name. The first name of a group which differs only by case is
+// kept, and the others are given suggestions which don't collide.
+func checkNames(kind string, names []string, validate func(string) error, suggest func(string) string) map[string]IdentifierProblem {
+	taken := make(map[string]bool)
+	for _, name := range names {
+		taken[strings.ToLower(name)] = true
+	}
+
+	suggestUnique := func(name string) string {
+		base := suggest(name)
+		suggestion := base
+		for i := 2; taken[strings.ToLower(suggestion)]; i++ {
+			suffix := fmt.Sprintf("_%d", i)
+			suggestion = truncateIdentifier(base, MaxIdentifierLength-len(suffix)) + suffix
+		}
+
+		taken[strings.ToLower(suggestion)] = true
+		return suggestion
+	}
+
+	problems := make(map[string]IdentifierProblem)
+	for _, name := range names {
+		if err := validate(name); err != nil {
+			problems[name] = IdentifierProblem{Err: err, Suggestion: suggestUnique(name)}
+		}
+	}
+
+	for _, group := range CaseCollisions(names) {
+		for _, name := range group[1:] {
+			if _, ok := problems[name]; !ok {
+				problems[name] = IdentifierProblem{Err: caseCollisionErr(kind, name, group[0]), Suggestion: suggestUnique(name)}
+			}
+		}
+	}
+
+	return problems
+}
+
+// SuggestTableName returns a valid table name resembling |name|. Characters which can't be used in a table name are
+// replaced with underscores, and names which don't start with a letter are prefixed with "t_".
+func SuggestTableName(name string) string {
+	var sb strings.Builder
+	for _, r := range name {
+		if r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_') {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteRune('_')
+		}
+	}
+
+	suggestion := sb.String()
+	if suggestion == "" || !unicode.IsLetter(rune(suggestion[0])) {
+		suggestion = "t_" + suggestion
+	}
+
+	suggestion = truncateIdentifier(suggestion, MaxIdentifierLength)
+	suggestion = strings.TrimRight(suggestion, "-_")
+
+	if !IsValidTableName(suggestion) {
+		return "t"
+	}
+
+	return suggestion
+}
+
+// SuggestColumnName returns a valid column name resembling |name|, dropping the characters which can't be used in a
+// column name and any trailing spaces.
+func SuggestColumnName(name string) string {
+	name = strings.ToValidUTF8(name, "")
+	name = strings.Map(func(r rune) rune {
+		if r == 0 || r > maxBMPRune {
+			return -1
+		}
+
+		return r
+	}, name)
+
+	name = strings.TrimRight(truncateIdentifier(name, MaxIdentifierLength), " ")
+
+	if name == "" {
+		return "col"
+	}
+
+	return name
+}
+
+func truncateIdentifier(name string, maxLen int) string {
+	if utf8.RuneCountInString(name) <= maxLen {
+		return name
+	}
+
+	return string([]rune(name)[:maxLen])
+}
@@ -0,0 +1,144 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doltdb
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+func TestValidateIdentifiers(t *testing.T) {
+	for _, name := range []string{"t", "people", "people-2020", "People_2020"} {
+		assert.NoError(t, ValidateTableName(name), name)
+	}
+
+	for _, name := range []string{"", "my table", "2020_people", "people_", "café", strings.Repeat("t", 65)} {
+		err := ValidateTableName(name)
+		assert.True(t, IsIdentifierError(err), name)
+	}
+
+	for _, name := range []string{"c", "unit price", "`quoted`", "café", "名前", " leading space", strings.Repeat("c", 64)} {
+		assert.NoError(t, ValidateColumnName(name), name)
+	}
+
+	for _, name := range []string{"", "trailing space ", "nul\x00", "emoji😀", "bad\xffutf8", strings.Repeat("c", 65)} {
+		err := ValidateColumnName(name)
+		assert.True(t, IsIdentifierError(err), name)
+	}
+}
+
+func TestCaseCollisions(t *testing.T) {
+	assert.Empty(t, CaseCollisions([]string{"a", "b", "ab"}))
+	assert.Equal(t, [][]string{{"Name", "name", "NAME"}, {"café", "CAFÉ"}}, CaseCollisions([]string{"Name", "café", "id", "name", "CAFÉ", "NAME"}))
+
+	sch := schema.SchemaFromCols(mustColColl(t,
+		schema.NewColumn("id", 0, types.IntKind, true),
+		schema.NewColumn("Name", 1, types.StringKind, false),
+		schema.NewColumn("name", 2, types.StringKind, false)))
+
+	err := ValidateSchemaColumnNames(sch)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "'name' is not a valid column name")
+
+	// renaming a column to a different case of its own name is fine, but not to another column's
+	sch = schema.SchemaFromCols(mustColColl(t,
+		schema.NewColumn("id", 0, types.IntKind, true),
+		schema.NewColumn("Name", 1, types.StringKind, false)))
+
+	assert.NoError(t, ValidateNewColumnName(sch, 1, "NAME"))
+	assert.Error(t, ValidateNewColumnName(sch, 0, "NAME"))
+	assert.Error(t, ValidateNewColumnName(sch, 0, "bad "))
+}
+
+func TestSuggestNames(t *testing.T) {
+	tests := map[string]string{
+		"my table":    "my_table",
+		"2020_people": "t_2020_people",
+		"people_":     "people",
+		"café":        "caf",
+		"":            "t",
+		"__":          "t",
+	}
+
+	for name, expected := range tests {
+		assert.Equal(t, expected, SuggestTableName(name), name)
+		assert.NoError(t, ValidateTableName(SuggestTableName(name)), name)
+	}
+
+	assert.Equal(t, "name", SuggestColumnName("name   "))
+	assert.Equal(t, "nul", SuggestColumnName("nul\x00"))
+	assert.Equal(t, "col", SuggestColumnName("😀"))
+	assert.Len(t, []rune(SuggestColumnName(strings.Repeat("名", 100))), MaxIdentifierLength)
+}
+
+func TestCheckIdentifiers(t *testing.T) {
+	ctx := context.Background()
+	ddb, _ := LoadDoltDB(ctx, types.Format_7_18, InMemDoltDB)
+	require.NoError(t, ddb.WriteEmptyRepo(ctx, "billy bob", "bigbillieb@fake.horse"))
+
+	cs, _ := NewCommitSpec("head", "master")
+	cm, _ := ddb.Resolve(ctx, cs)
+	root, err := cm.GetRootValue()
+	require.NoError(t, err)
+
+	problems, err := CheckIdentifiers(ctx, root)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+
+	m, err := types.NewMap(ctx, ddb.ValueReadWriter())
+	require.NoError(t, err)
+
+	tables := map[string][]string{"people": {"id"}, "People": {"id"}, "people_2": {"id"}, "sales": {"id", "total ", "total"}}
+	tag := uint64(0)
+	for name, colNames := range tables {
+		var cols []schema.Column
+		for i, colName := range colNames {
+			cols = append(cols, schema.NewColumn(colName, tag, types.IntKind, i == 0))
+			tag++
+		}
+
+		tbl, err := createTestTable(ddb.ValueReadWriter(), schema.SchemaFromCols(mustColColl(t, cols...)), m)
+		require.NoError(t, err)
+
+		root, err = root.PutTable(ctx, name, tbl)
+		require.NoError(t, err)
+	}
+
+	problems, err = CheckIdentifiers(ctx, root)
+	require.NoError(t, err)
+	require.Len(t, problems, 2)
+
+	// People sorts first, so people collides with it, and doesn't get the taken people_2 as its suggestion
+	assert.Equal(t, "people", problems[0].Table)
+	assert.Equal(t, "", problems[0].Column)
+	assert.Equal(t, "people_3", problems[0].Suggestion)
+
+	assert.Equal(t, "sales", problems[1].Table)
+	assert.Equal(t, "total ", problems[1].Column)
+	assert.Equal(t, "total_2", problems[1].Suggestion)
+}
+
+func mustColColl(t *testing.T, cols ...schema.Column) *schema.ColCollection {
+	colColl, err := schema.NewColCollection(cols...)
+	require.NoError(t, err)
+	return colColl
+}
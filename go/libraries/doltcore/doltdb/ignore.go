@@ -0,0 +1,179 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doltdb
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/encoding"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+var ignoreColumns, _ = schema.NewColCollection(
+	schema.NewColumn(IgnorePatternCol, IgnorePatternTag, types.StringKind, true, schema.NotNullConstraint{}),
+	schema.NewColumn(IgnoreIgnoredCol, IgnoreIgnoredTag, types.BoolKind, false, schema.NotNullConstraint{}),
+)
+
+// IgnoreSchema is the schema of the dolt_ignore table
+var IgnoreSchema = schema.SchemaFromCols(ignoreColumns)
+
+// IgnorePattern is a row of the dolt_ignore table. Pattern matches table names case-insensitively, with * matching
+// any sequence of characters and ? matching any single character. Ignored is false for patterns which un-ignore the
+// tables they match.
+type IgnorePattern struct {
+	Pattern string
+	Ignored bool
+	re      *regexp.Regexp
+}
+
+// NewIgnorePattern returns the IgnorePattern for |pattern|.
+func NewIgnorePattern(pattern string, ignored bool) IgnorePattern {
+	return IgnorePattern{Pattern: pattern, Ignored: ignored, re: globRegexp(pattern, true)}
+}
+
+// globRegexp returns the regular expression matching the whole of the strings which match |pattern|, in which *
+// matches any sequence of characters and ? matches any single character.
+func globRegexp(pattern string, caseInsensitive bool) *regexp.Regexp {
+	var sb strings.Builder
+	if caseInsensitive {
+		sb.WriteString("(?i)")
+	}
+	sb.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+
+	return regexp.MustCompile(sb.String())
+}
+
+// globSpecificity is the number of characters of a pattern which aren't wildcards.
+func globSpecificity(pattern string) int {
+	return len(strings.NewReplacer("*", "", "?", "").Replace(pattern))
+}
+
+// Matches returns whether the table name given matches the pattern.
+func (ip IgnorePattern) Matches(tblName string) bool {
+	return ip.re.MatchString(tblName)
+}
+
+// specificity is the number of characters of the pattern which aren't wildcards.
+func (ip IgnorePattern) specificity() int {
+	return globSpecificity(ip.Pattern)
+}
+
+// IgnorePatterns are the patterns of a dolt_ignore table.
+type IgnorePatterns []IgnorePattern
+
+// IsTableNameIgnored returns whether the table name given is ignored by the patterns. When a table name matches
+// several patterns, the most specific of them, which is the one with the most characters which aren't wildcards,
+// decides whether it's ignored. If the most specific patterns disagree the table isn't ignored. System tables are never
+// ignored.
+func (ips IgnorePatterns) IsTableNameIgnored(tblName string) bool {
+	if HasDoltPrefix(tblName) {
+		return false
+	}
+
+	ignored := false
+	best := -1
+	for _, ip := range ips {
+		if !ip.Matches(tblName) {
+			continue
+		}
+
+		if spec := ip.specificity(); spec > best {
+			best = spec
+			ignored = ip.Ignored
+		} else if spec == best && !ip.Ignored {
+			ignored = false
+		}
+	}
+
+	return ignored
+}
+
+// GetIgnorePatterns returns the patterns of the dolt_ignore table of |root|, which are empty if it has no dolt_ignore
+// table.
+func GetIgnorePatterns(ctx context.Context, root *RootValue) (IgnorePatterns, error) {
+	tbl, ok, err := root.GetTable(ctx, IgnoreTableName)
+
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	sch, err := tbl.GetSchema(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rowData, err := tbl.GetRowData(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ips IgnorePatterns
+	err = rowData.IterAll(ctx, func(key, val types.Value) error {
+		r, err := row.FromNoms(sch, key.(types.Tuple), val.(types.Tuple))
+
+		if err != nil {
+			return err
+		}
+
+		pattern, _ := r.GetColVal(IgnorePatternTag)
+		ignored, _ := r.GetColVal(IgnoreIgnoredTag)
+
+		if pattern != nil && ignored != nil {
+			ips = append(ips, NewIgnorePattern(string(pattern.(types.String)), bool(ignored.(types.Bool))))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ips, nil
+}
+
+// NewEmptyIgnoreTable returns an empty dolt_ignore table.
+func NewEmptyIgnoreTable(ctx context.Context, vrw types.ValueReadWriter) (*Table, error) {
+	schVal, err := encoding.MarshalSchemaAsNomsValue(ctx, vrw, IgnoreSchema)
+
+	if err != nil {
+		return nil, err
+	}
+
+	empty, err := types.NewMap(ctx, vrw)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTable(ctx, vrw, schVal, empty)
+}
@@ -0,0 +1,96 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doltdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIgnorePatternMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		tblName string
+		matches bool
+	}{
+		{"tmp_*", "tmp_", true},
+		{"tmp_*", "tmp_table", true},
+		{"tmp_*", "TMP_table", true},
+		{"tmp_*", "table_tmp_", false},
+		{"tmp_?", "tmp_a", true},
+		{"tmp_?", "tmp_ab", false},
+		{"*_backup", "people_backup", true},
+		{"*", "anything", true},
+		{"a.b", "axb", false},
+		{"people", "people", true},
+		{"people", "people2", false},
+	}
+
+	for _, test := range tests {
+		ip := NewIgnorePattern(test.pattern, true)
+		assert.Equal(t, test.matches, ip.Matches(test.tblName), "%s matching %s", test.pattern, test.tblName)
+	}
+}
+
+func TestIsTableNameIgnored(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns IgnorePatterns
+		tblName  string
+		ignored  bool
+	}{
+		{"no patterns", nil, "tmp_a", false},
+		{"ignored", IgnorePatterns{NewIgnorePattern("tmp_*", true)}, "tmp_a", true},
+		{"not matched", IgnorePatterns{NewIgnorePattern("tmp_*", true)}, "people", false},
+		{
+			"un-ignored by a more specific pattern",
+			IgnorePatterns{NewIgnorePattern("tmp_*", true), NewIgnorePattern("tmp_keep", false)},
+			"tmp_keep",
+			false,
+		},
+		{
+			"ignored by a more specific pattern",
+			IgnorePatterns{NewIgnorePattern("*", false), NewIgnorePattern("tmp_*", true)},
+			"tmp_a",
+			true,
+		},
+		{
+			"more specific wildcard pattern",
+			IgnorePatterns{NewIgnorePattern("tmp_keep_*", false), NewIgnorePattern("tmp_*", true)},
+			"tmp_keep_a",
+			false,
+		},
+		{
+			"equally specific patterns disagree",
+			IgnorePatterns{NewIgnorePattern("tmp_*", true), NewIgnorePattern("*_tmp", false)},
+			"tmp_tmp",
+			false,
+		},
+		{
+			"equally specific patterns agree",
+			IgnorePatterns{NewIgnorePattern("tmp_*", true), NewIgnorePattern("*_tmp", true)},
+			"tmp_tmp",
+			true,
+		},
+		{"system tables are never ignored", IgnorePatterns{NewIgnorePattern("*", true)}, IgnoreTableName, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.ignored, test.patterns.IsTableNameIgnored(test.tblName))
+		})
+	}
+}
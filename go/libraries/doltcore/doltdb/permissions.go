@@ -0,0 +1,249 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doltdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/encoding"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const (
+	// PermissionWrite is the access of a permission policy which lets its grantees write the tables it covers
+	PermissionWrite = "write"
+
+	// PermissionRead is the access of a permission policy which makes the tables it covers read only for its grantees
+	PermissionRead = "read"
+
+	// AdminRole is the role of the users who aren't limited by permission policies, and who are the only users which
+	// can change them
+	AdminRole = "admin"
+)
+
+// ErrPermissionDenied is wrapped by the errors returned when a write isn't permitted by the permission policies.
+var ErrPermissionDenied = errors.New("permission denied")
+
+var permissionsColumns, _ = schema.NewColCollection(
+	schema.NewColumn(PermissionsNameCol, PermissionsNameTag, types.StringKind, true, schema.NotNullConstraint{}),
+	schema.NewColumn(PermissionsGranteeCol, PermissionsGranteeTag, types.StringKind, false, schema.NotNullConstraint{}),
+	schema.NewColumn(PermissionsBranchCol, PermissionsBranchTag, types.StringKind, false, schema.NotNullConstraint{}),
+	schema.NewColumn(PermissionsTableCol, PermissionsTableTag, types.StringKind, false, schema.NotNullConstraint{}),
+	schema.NewColumn(PermissionsAccessCol, PermissionsAccessTag, types.StringKind, false, schema.NotNullConstraint{}),
+)
+
+// PermissionsSchema is the schema of the dolt_permissions table
+var PermissionsSchema = schema.SchemaFromCols(permissionsColumns)
+
+// Principal is a user making writes, along with their roles, which permission policies are granted to.
+type Principal struct {
+	User  string
+	Roles []string
+}
+
+// IsAdmin returns whether the principal has the admin role.
+func (p Principal) IsAdmin() bool {
+	return p.HasRole(AdminRole)
+}
+
+// HasRole returns whether the principal has the role given.
+func (p Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isGrantee returns whether a permission policy granted to |grantee| applies to the principal.
+func (p Principal) isGrantee(grantee string) bool {
+	return grantee == PermissionsAllGrantees || grantee == p.User || p.HasRole(grantee)
+}
+
+// PermissionPolicy is a row of the dolt_permissions table. It grants Access to the tables whose names match Table on
+// the branches whose names match Branch, to Grantee, which is a user, a role or PermissionsAllGrantees. In the patterns,
+// * matches any sequence of characters, including /, and ? matches any single character. Table names are matched
+// case-insensitively, and branch names case-sensitively.
+type PermissionPolicy struct {
+	Name    string
+	Grantee string
+	Branch  string
+	Table   string
+	Access  string
+
+	branchRe *regexp.Regexp
+	tableRe  *regexp.Regexp
+}
+
+// NewPermissionPolicy returns the PermissionPolicy with the fields given.
+func NewPermissionPolicy(name, grantee, branch, table, access string) PermissionPolicy {
+	return PermissionPolicy{
+		Name:     name,
+		Grantee:  grantee,
+		Branch:   branch,
+		Table:    table,
+		Access:   access,
+		branchRe: globRegexp(branch, false),
+		tableRe:  globRegexp(table, true),
+	}
+}
+
+// Matches returns whether the policy covers the table given on the branch given.
+func (pp PermissionPolicy) Matches(branch, tblName string) bool {
+	return pp.branchRe.MatchString(branch) && pp.tableRe.MatchString(tblName)
+}
+
+// specificity is the number of characters of the policy's patterns which aren't wildcards.
+func (pp PermissionPolicy) specificity() int {
+	return globSpecificity(pp.Branch) + globSpecificity(pp.Table)
+}
+
+// PermissionPolicies are the policies of a dolt_permissions table.
+type PermissionPolicies []PermissionPolicy
+
+// CheckWrite returns an error wrapping ErrPermissionDenied if |principal| may not write the table given on the branch
+// given. Of the policies which apply to the principal and cover the table on the branch, the most specific, which is
+// the one whose patterns have the most characters which aren't wildcards, decides whether the write is permitted, and
+// if the most specific policies disagree it is. A policy with an access other than PermissionWrite makes the tables it
+// covers read only. Writes which no policy covers are permitted, so a database without permission policies doesn't
+// limit anyone.
+//
+// Principals with the admin role can write every table, and only they can write the dolt_permissions table itself.
+func (pps PermissionPolicies) CheckWrite(principal Principal, branch, tblName string) error {
+	if principal.IsAdmin() {
+		return nil
+	}
+
+	if strings.EqualFold(tblName, PermissionsTableName) {
+		return fmt.Errorf("%w: user '%s' cannot change the permission policies, which requires the %s role", ErrPermissionDenied, principal.User, AdminRole)
+	}
+
+	var decider *PermissionPolicy
+	best := -1
+	for i, pp := range pps {
+		if !principal.isGrantee(pp.Grantee) || !pp.Matches(branch, tblName) {
+			continue
+		}
+
+		if spec := pp.specificity(); spec > best || (spec == best && pp.Access == PermissionWrite) {
+			best = spec
+			decider = &pps[i]
+		}
+	}
+
+	if decider == nil || decider.Access == PermissionWrite {
+		return nil
+	}
+
+	return fmt.Errorf("%w: user '%s' cannot write table '%s' on branch '%s', which permission policy '%s' makes read only",
+		ErrPermissionDenied, principal.User, tblName, branch, decider.Name)
+}
+
+// GetPermissionPolicies returns the policies of the dolt_permissions table of |root|, which are empty if it has no
+// dolt_permissions table.
+func GetPermissionPolicies(ctx context.Context, root *RootValue) (PermissionPolicies, error) {
+	tbl, ok, err := root.GetTable(ctx, PermissionsTableName)
+
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	sch, err := tbl.GetSchema(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rowData, err := tbl.GetRowData(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var pps PermissionPolicies
+	err = rowData.IterAll(ctx, func(key, val types.Value) error {
+		r, err := row.FromNoms(sch, key.(types.Tuple), val.(types.Tuple))
+
+		if err != nil {
+			return err
+		}
+
+		pps = append(pps, NewPermissionPolicy(
+			permissionColString(r, PermissionsNameTag),
+			permissionColString(r, PermissionsGranteeTag),
+			permissionColString(r, PermissionsBranchTag),
+			permissionColString(r, PermissionsTableTag),
+			strings.ToLower(permissionColString(r, PermissionsAccessTag)),
+		))
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pps, nil
+}
+
+func permissionColString(r row.Row, tag uint64) string {
+	val, ok := r.GetColVal(tag)
+	if !ok || types.IsNull(val) {
+		return ""
+	}
+
+	return string(val.(types.String))
+}
+
+// CheckWritePermission returns an error wrapping ErrPermissionDenied if the permission policies of |root| don't let
+// |principal| write the table given on the branch given. See PermissionPolicies.CheckWrite.
+func CheckWritePermission(ctx context.Context, root *RootValue, principal Principal, branch, tblName string) error {
+	if principal.IsAdmin() {
+		return nil
+	}
+
+	pps, err := GetPermissionPolicies(ctx, root)
+
+	if err != nil {
+		return err
+	}
+
+	return pps.CheckWrite(principal, branch, tblName)
+}
+
+// NewEmptyPermissionsTable returns an empty dolt_permissions table.
+func NewEmptyPermissionsTable(ctx context.Context, vrw types.ValueReadWriter) (*Table, error) {
+	schVal, err := encoding.MarshalSchemaAsNomsValue(ctx, vrw, PermissionsSchema)
+
+	if err != nil {
+		return nil, err
+	}
+
+	empty, err := types.NewMap(ctx, vrw)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTable(ctx, vrw, schVal, empty)
+}
@@ -0,0 +1,107 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doltdb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPermissionPoliciesCheckWrite(t *testing.T) {
+	analyst := Principal{User: "amy", Roles: []string{"analyst"}}
+	other := Principal{User: "bob"}
+	admin := Principal{User: "root", Roles: []string{AdminRole}}
+
+	analystPolicies := PermissionPolicies{
+		NewPermissionPolicy("analysts_staging", "analyst", "feature/*", "staging_*", PermissionWrite),
+		NewPermissionPolicy("analysts_read_only", "analyst", "*", "*", PermissionRead),
+	}
+
+	tests := []struct {
+		name      string
+		policies  PermissionPolicies
+		principal Principal
+		branch    string
+		tblName   string
+		denied    string
+	}{
+		{"no policies", nil, analyst, "master", "people", ""},
+		{"granted", analystPolicies, analyst, "feature/a", "staging_people", ""},
+		{"table names are case insensitive", analystPolicies, analyst, "feature/a", "STAGING_people", ""},
+		{"nested branch", analystPolicies, analyst, "feature/a/b", "staging_people", ""},
+		{"other table", analystPolicies, analyst, "feature/a", "people", "analysts_read_only"},
+		{"other branch", analystPolicies, analyst, "master", "staging_people", "analysts_read_only"},
+		{"branch names are case sensitive", analystPolicies, analyst, "Feature/a", "staging_people", "analysts_read_only"},
+		{"policies for other grantees", analystPolicies, other, "master", "people", ""},
+		{
+			"policies for every user",
+			PermissionPolicies{NewPermissionPolicy("frozen", PermissionsAllGrantees, "release-*", "*", PermissionRead)},
+			other,
+			"release-1",
+			"people",
+			"frozen",
+		},
+		{
+			"policies for a user",
+			PermissionPolicies{
+				NewPermissionPolicy("frozen", PermissionsAllGrantees, "release-*", "*", PermissionRead),
+				NewPermissionPolicy("release_manager", "bob", "release-*", "*", PermissionWrite),
+			},
+			other,
+			"release-1",
+			"people",
+			"",
+		},
+		{
+			"equally specific policies disagree",
+			PermissionPolicies{
+				NewPermissionPolicy("no_people", "analyst", "*", "people", PermissionRead),
+				NewPermissionPolicy("people", "analyst", "*", "people", PermissionWrite),
+			},
+			analyst,
+			"master",
+			"people",
+			"",
+		},
+		{
+			"unknown access is read only",
+			PermissionPolicies{NewPermissionPolicy("typo", "analyst", "*", "*", "wirte")},
+			analyst,
+			"master",
+			"people",
+			"typo",
+		},
+		{"admins aren't limited", analystPolicies, Principal{User: "amy", Roles: []string{"analyst", AdminRole}}, "master", "people", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.policies.CheckWrite(test.principal, test.branch, test.tblName)
+
+			if test.denied == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.True(t, errors.Is(err, ErrPermissionDenied))
+				assert.Contains(t, err.Error(), "'"+test.denied+"'")
+			}
+		})
+	}
+
+	err := PermissionPolicies(nil).CheckWrite(other, "master", PermissionsTableName)
+	assert.True(t, errors.Is(err, ErrPermissionDenied))
+	assert.NoError(t, analystPolicies.CheckWrite(admin, "master", PermissionsTableName))
+}
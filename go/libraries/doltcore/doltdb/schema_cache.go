@@ -0,0 +1,261 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doltdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/encoding"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/util/cacheregistry"
+)
+
+// maxPersistedSchemas is the number of schemas a persisted schema cache holds at most. Past it, the schemas which
+// weren't used by the process saving the cache are dropped from the file.
+const maxPersistedSchemas = 8192
+
+// The schemas of tables are decoded from their noms values every time a table is read, which adds up for commands
+// which read every table of a database with hundreds of them. Decoded schemas are cached by the hash of their noms
+// value. A schema value can't change without its hash changing, so the entries of the cache never go stale.
+var globalSchemaCache = newSchemaCache()
+
+// SchemasCacheName is the name the cache of decoded schemas is registered with in the cacheregistry.
+const SchemasCacheName = "schemas"
+
+func init() {
+	cacheregistry.Register(SchemasCacheName, globalSchemaCache)
+}
+
+// schemaColumnSizeEstimate is the number of bytes a decoded schema is estimated to take for each of its columns.
+const schemaColumnSizeEstimate = 256
+
+// PersistSchemaCache sets the file the cache of decoded schemas is persisted to, so that later processes don't decode
+// the same schemas again. The file is read the first time a schema is looked up, and written by SaveSchemaCache. A
+// file written by a different |version| of dolt is ignored, as the encoding of schemas may differ between versions.
+func PersistSchemaCache(fs filesys.ReadWriteFS, path, version string) {
+	globalSchemaCache.persist(fs, path, version)
+}
+
+// SaveSchemaCache writes the file set by PersistSchemaCache if schemas which aren't in it were decoded.
+func SaveSchemaCache() error {
+	return globalSchemaCache.save()
+}
+
+type schemaCacheEntry struct {
+	sch schema.Schema
+
+	// data is the JSON encoding of a schema read from the cache's file, which is decoded the first time it's used
+	data json.RawMessage
+	used bool
+}
+
+type persistedSchemaCache struct {
+	Version string                     `json:"version"`
+	Schemas map[string]json.RawMessage `json:"schemas"`
+}
+
+type schemaCache struct {
+	mu      sync.Mutex
+	entries map[hash.Hash]*schemaCacheEntry
+
+	fs      filesys.ReadWriteFS
+	path    string
+	version string
+	loaded  bool
+	dirty   bool
+}
+
+func newSchemaCache() *schemaCache {
+	return &schemaCache{entries: make(map[hash.Hash]*schemaCacheEntry)}
+}
+
+func (c *schemaCache) persist(fs filesys.ReadWriteFS, path, version string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.fs, c.path, c.version = fs, path, version
+	c.loaded = false
+}
+
+func (c *schemaCache) get(h hash.Hash) (schema.Schema, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.load()
+
+	e, ok := c.entries[h]
+
+	if !ok {
+		return nil, false
+	}
+
+	if e.sch == nil {
+		sch, err := encoding.UnmarshalJson(string(e.data))
+
+		if err != nil {
+			// the entry is decoded from its noms value again and replaced
+			delete(c.entries, h)
+			return nil, false
+		}
+
+		e.sch, e.data = sch, nil
+	}
+
+	e.used = true
+	return e.sch, true
+}
+
+func (c *schemaCache) put(h hash.Hash, sch schema.Schema) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[h] = &schemaCacheEntry{sch: sch, used: true}
+	c.dirty = true
+}
+
+// Len returns the number of schemas in the cache, including the ones read from its file which haven't been decoded.
+func (c *schemaCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.load()
+	return len(c.entries)
+}
+
+// Size returns an estimate of the bytes held by the schemas in the cache.
+func (c *schemaCache) Size() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.load()
+
+	var size uint64
+	for _, e := range c.entries {
+		if e.sch != nil {
+			size += uint64(e.sch.GetAllCols().Size() * schemaColumnSizeEstimate)
+		} else {
+			size += uint64(len(e.data))
+		}
+	}
+
+	return size
+}
+
+// Purge drops the schemas in the cache, along with its file, so that every schema is decoded from its noms value
+// again.
+func (c *schemaCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[hash.Hash]*schemaCacheEntry)
+	c.dirty = false
+
+	if c.fs != nil {
+		// the file isn't read again, as it's gone
+		c.loaded = true
+		_ = c.fs.DeleteFile(c.path)
+	}
+}
+
+// load reads the cache's file the first time it's called after the file is set. A file which can't be read, or which
+// was written by another version, leaves the cache as it is.
+func (c *schemaCache) load() {
+	if c.loaded || c.fs == nil {
+		return
+	}
+
+	c.loaded = true
+
+	if exists, _ := c.fs.Exists(c.path); !exists {
+		return
+	}
+
+	var persisted persistedSchemaCache
+	if err := filesys.UnmarshalJSONFile(c.fs, c.path, &persisted); err != nil || persisted.Version != c.version {
+		return
+	}
+
+	for hashStr, data := range persisted.Schemas {
+		h, ok := hash.MaybeParse(hashStr)
+
+		if !ok {
+			continue
+		}
+
+		if _, ok := c.entries[h]; !ok {
+			c.entries[h] = &schemaCacheEntry{data: data}
+		}
+	}
+}
+
+func (c *schemaCache) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.fs == nil || !c.dirty {
+		return nil
+	}
+
+	c.load()
+
+	persisted := persistedSchemaCache{Version: c.version, Schemas: make(map[string]json.RawMessage)}
+	for h, e := range c.entries {
+		if !e.used && len(c.entries) > maxPersistedSchemas {
+			continue
+		}
+
+		data := e.data
+		if data == nil {
+			jsonStr, err := encoding.MarshalAsJson(e.sch)
+
+			if err != nil {
+				return err
+			}
+
+			data = json.RawMessage(jsonStr)
+		}
+
+		persisted.Schemas[h.String()] = data
+	}
+
+	data, err := json.Marshal(persisted)
+
+	if err != nil {
+		return err
+	}
+
+	// other processes may be reading the file, so it's replaced rather than written in place
+	tmpPath := fmt.Sprintf("%s.%d", c.path, os.Getpid())
+	err = c.fs.WriteFile(tmpPath, data)
+
+	if err != nil {
+		return err
+	}
+
+	err = c.fs.MoveFile(tmpPath, c.path)
+
+	if err != nil {
+		_ = c.fs.DeleteFile(tmpPath)
+		return err
+	}
+
+	c.dirty = false
+	return nil
+}
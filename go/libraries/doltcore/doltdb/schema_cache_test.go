@@ -0,0 +1,244 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doltdb
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const schemaCacheTestPath = "/repo/.dolt/temptf/schema_cache.json"
+
+// withSchemaCache runs |f| with |c| as the global schema cache.
+func withSchemaCache(c *schemaCache, f func()) {
+	prev := globalSchemaCache
+	globalSchemaCache = c
+	defer func() { globalSchemaCache = prev }()
+
+	f()
+}
+
+func schemaCacheTestTables(t testing.TB, n int) []*Table {
+	ddb, err := LoadDoltDB(context.Background(), types.Format_7_18, InMemDoltDB)
+	require.NoError(t, err)
+
+	m, err := types.NewMap(context.Background(), ddb.ValueReadWriter())
+	require.NoError(t, err)
+
+	var tbls []*Table
+	for i := 0; i < n; i++ {
+		cols := []schema.Column{schema.NewColumn("pk", uint64(i*100), types.IntKind, true, schema.NotNullConstraint{})}
+		for j := 1; j < 10; j++ {
+			cols = append(cols, schema.NewColumn(fmt.Sprintf("c%d", j), uint64(i*100+j), types.StringKind, false))
+		}
+
+		colColl, err := schema.NewColCollection(cols...)
+		require.NoError(t, err)
+
+		tbl, err := createTestTable(ddb.ValueReadWriter(), schema.SchemaFromCols(colColl), m)
+		require.NoError(t, err)
+		tbls = append(tbls, tbl)
+	}
+
+	return tbls
+}
+
+func TestSchemaCache(t *testing.T) {
+	ctx := context.Background()
+	tbl := schemaCacheTestTables(t, 1)[0]
+
+	withSchemaCache(newSchemaCache(), func() {
+		sch, err := tbl.GetSchema(ctx)
+		require.NoError(t, err)
+		assert.Len(t, globalSchemaCache.entries, 1)
+
+		cached, err := tbl.GetSchema(ctx)
+		require.NoError(t, err)
+		assert.True(t, sch == cached)
+		assert.Len(t, globalSchemaCache.entries, 1)
+	})
+}
+
+func TestPersistedSchemaCache(t *testing.T) {
+	ctx := context.Background()
+	tbl := schemaCacheTestTables(t, 1)[0]
+	fs := filesys.NewInMemFS(nil, nil, "/repo")
+
+	var sch schema.Schema
+	withSchemaCache(newSchemaCache(), func() {
+		PersistSchemaCache(fs, schemaCacheTestPath, "1.0")
+
+		var err error
+		sch, err = tbl.GetSchema(ctx)
+		require.NoError(t, err)
+		require.NoError(t, SaveSchemaCache())
+	})
+
+	exists, _ := fs.Exists(schemaCacheTestPath)
+	require.True(t, exists)
+
+	withSchemaCache(newSchemaCache(), func() {
+		PersistSchemaCache(fs, schemaCacheTestPath, "1.0")
+
+		schRef, err := tbl.GetSchemaRef()
+		require.NoError(t, err)
+
+		cached, ok := globalSchemaCache.get(schRef.TargetHash())
+		require.True(t, ok)
+		eq, err := schema.SchemasAreEqual(sch, cached)
+		require.NoError(t, err)
+		assert.True(t, eq)
+
+		// nothing was decoded, so there's nothing to save
+		assert.False(t, globalSchemaCache.dirty)
+	})
+
+	withSchemaCache(newSchemaCache(), func() {
+		PersistSchemaCache(fs, schemaCacheTestPath, "2.0")
+
+		schRef, err := tbl.GetSchemaRef()
+		require.NoError(t, err)
+
+		_, ok := globalSchemaCache.get(schRef.TargetHash())
+		assert.False(t, ok, "the cache of another version is ignored")
+	})
+}
+
+func TestPersistedSchemaCacheBadFile(t *testing.T) {
+	ctx := context.Background()
+	tbl := schemaCacheTestTables(t, 1)[0]
+	fs := filesys.NewInMemFS(nil, nil, "/repo")
+
+	schRef, err := tbl.GetSchemaRef()
+	require.NoError(t, err)
+
+	badEntry := fmt.Sprintf(`{"version":"1.0","schemas":{"%s":{"columns":"not columns"},"not a hash":{}}}`, schRef.TargetHash().String())
+	require.NoError(t, fs.WriteFile(schemaCacheTestPath, []byte(badEntry)))
+
+	withSchemaCache(newSchemaCache(), func() {
+		PersistSchemaCache(fs, schemaCacheTestPath, "1.0")
+
+		// the entry which can't be decoded is replaced by the schema decoded from its noms value
+		sch, err := tbl.GetSchema(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 10, sch.GetAllCols().Size())
+
+		require.NoError(t, SaveSchemaCache())
+	})
+
+	require.NoError(t, fs.WriteFile(schemaCacheTestPath, []byte("not json")))
+
+	withSchemaCache(newSchemaCache(), func() {
+		PersistSchemaCache(fs, schemaCacheTestPath, "1.0")
+
+		_, ok := globalSchemaCache.get(hash.Of([]byte("not a schema")))
+		assert.False(t, ok)
+
+		sch, err := tbl.GetSchema(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 10, sch.GetAllCols().Size())
+	})
+}
+
+// BenchmarkGetSchema reads the schemas of 400 tables, as a command reading every table of a database does, decoding
+// each schema from its noms value, from the schema cache of the process, and from a persisted schema cache.
+func BenchmarkGetSchema(b *testing.B) {
+	ctx := context.Background()
+	tbls := schemaCacheTestTables(b, 400)
+
+	readSchemas := func(b *testing.B) {
+		for _, tbl := range tbls {
+			if _, err := tbl.GetSchema(ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	b.Run("decoded", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			withSchemaCache(newSchemaCache(), func() { readSchemas(b) })
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		withSchemaCache(newSchemaCache(), func() {
+			readSchemas(b)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				readSchemas(b)
+			}
+		})
+	})
+
+	b.Run("persisted", func(b *testing.B) {
+		fs := filesys.NewInMemFS(nil, nil, "/repo")
+		withSchemaCache(newSchemaCache(), func() {
+			PersistSchemaCache(fs, schemaCacheTestPath, "1.0")
+			readSchemas(b)
+
+			if err := SaveSchemaCache(); err != nil {
+				b.Fatal(err)
+			}
+		})
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			withSchemaCache(newSchemaCache(), func() {
+				PersistSchemaCache(fs, schemaCacheTestPath, "1.0")
+				readSchemas(b)
+			})
+		}
+	})
+}
+
+func TestPurgeSchemaCache(t *testing.T) {
+	ctx := context.Background()
+	tbls := schemaCacheTestTables(t, 2)
+	fs := filesys.NewInMemFS(nil, nil, "/repo")
+
+	withSchemaCache(newSchemaCache(), func() {
+		PersistSchemaCache(fs, schemaCacheTestPath, "1.0")
+
+		_, err := tbls[0].GetSchema(ctx)
+		require.NoError(t, err)
+		require.NoError(t, SaveSchemaCache())
+
+		_, err = tbls[1].GetSchema(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, globalSchemaCache.Len())
+		assert.Equal(t, uint64(2*10*schemaColumnSizeEstimate), globalSchemaCache.Size())
+
+		// purging drops the decoded schemas and the persisted ones
+		globalSchemaCache.Purge()
+		assert.Equal(t, 0, globalSchemaCache.Len())
+		assert.Equal(t, uint64(0), globalSchemaCache.Size())
+		exists, _ := fs.Exists(schemaCacheTestPath)
+		assert.False(t, exists)
+
+		_, err = tbls[0].GetSchema(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, globalSchemaCache.Len())
+	})
+}
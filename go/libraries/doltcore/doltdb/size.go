@@ -0,0 +1,301 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doltdb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/datas"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// sizeBatchSize is the maximum number of chunks read from the chunk store at once while computing sizes
+const sizeBatchSize = 4096
+
+// TableSize is the storage used by a single table of a root value
+type TableSize struct {
+	Name string
+
+	// ExclusiveBytes is the size of the chunks which are reachable only from this table
+	ExclusiveBytes uint64
+
+	// TotalBytes is the size of all the chunks reachable from this table, including those shared with other tables
+	TotalBytes uint64
+}
+
+// TableSizes returns the storage used by each table in |root|, sorted by decreasing exclusive size.
+func (ddb *DoltDB) TableSizes(ctx context.Context, root *RootValue) ([]TableSize, error) {
+	tableMap, err := root.getTableMap()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	var refs []types.Ref
+	err = tableMap.IterAll(ctx, func(key, value types.Value) error {
+		names = append(names, string(key.(types.String)))
+		refs = append(refs, value.(types.Ref))
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// each chunk is owned by the first table which reaches it, until a second table reaches it and it becomes shared
+	const shared = -1
+	type chunkOwner struct {
+		table int
+		size  uint64
+	}
+
+	owners := make(map[hash.Hash]*chunkOwner)
+	sizes := make([]TableSize, len(names))
+	for i, r := range refs {
+		sizes[i].Name = names[i]
+
+		visited := hash.HashSet{r.TargetHash(): struct{}{}}
+		next := hash.HashSet{r.TargetHash(): struct{}{}}
+		for len(next) > 0 {
+			curr := next
+			next = hash.HashSet{}
+
+			err = ddb.getChunks(ctx, curr, func(c *chunks.Chunk) error {
+				h := c.Hash()
+				if owner, ok := owners[h]; !ok {
+					owners[h] = &chunkOwner{i, uint64(len(c.Data()))}
+				} else if owner.table != i {
+					owner.table = shared
+				}
+
+				sizes[i].TotalBytes += uint64(len(c.Data()))
+				return types.WalkRefs(*c, ddb.Format(), func(child types.Ref) error {
+					if !visited.Has(child.TargetHash()) {
+						visited.Insert(child.TargetHash())
+						next.Insert(child.TargetHash())
+					}
+
+					return nil
+				})
+			})
+
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, owner := range owners {
+		if owner.table != shared {
+			sizes[owner.table].ExclusiveBytes += owner.size
+		}
+	}
+
+	sort.SliceStable(sizes, func(i, j int) bool {
+		return sizes[i].ExclusiveBytes > sizes[j].ExclusiveBytes
+	})
+
+	return sizes, nil
+}
+
+// CommitSize returns the size of the chunks introduced by |cm|. This is the size of the commit itself plus the size
+// of the chunks reachable from its root value which are not reachable from the root values of its parents. Chunks which
+// are reachable through a changed chunk but which a parent only references beneath an unchanged chunk, such as the rows
+// of a copied table, are counted as introduced by the commit.
+func (ddb *DoltDB) CommitSize(ctx context.Context, cm *Commit) (uint64, error) {
+	h, err := cm.HashOf()
+
+	if err != nil {
+		return 0, err
+	}
+
+	var size uint64
+	var include, exclude types.RefByHeight
+	err = ddb.getChunks(ctx, hash.HashSet{h: struct{}{}}, func(c *chunks.Chunk) error {
+		size = uint64(len(c.Data()))
+		include, err = ddb.commitContentRefs(ctx, cm, c)
+		return err
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	parentHashes, err := cm.ParentHashes(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	parents := hash.NewHashSet(parentHashes...)
+	err = ddb.getChunks(ctx, parents, func(c *chunks.Chunk) error {
+		v, err := types.DecodeValue(*c, ddb.db)
+
+		if err != nil {
+			return err
+		}
+
+		refs, err := ddb.commitContentRefs(ctx, NewCommit(ddb.db, v.(types.Struct)), c)
+		exclude = append(exclude, refs...)
+		return err
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	contentSize, err := ddb.exclusiveSize(ctx, include, exclude)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return size + contentSize, nil
+}
+
+// commitContentRefs returns the refs in the chunk |c| of the commit |cm|, excluding the refs to its parents
+func (ddb *DoltDB) commitContentRefs(ctx context.Context, cm *Commit, c *chunks.Chunk) (types.RefByHeight, error) {
+	parentHashes, err := cm.ParentHashes(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	parents := hash.NewHashSet(parentHashes...)
+
+	var refs types.RefByHeight
+	err = types.WalkRefs(*c, ddb.Format(), func(r types.Ref) error {
+		if !parents.Has(r.TargetHash()) {
+			refs = append(refs, r)
+		}
+
+		return nil
+	})
+
+	return refs, err
+}
+
+// exclusiveSize returns the size of the chunks which are reachable from the refs |srcQ| and not reachable from the
+// refs |sinkQ|. Both sides are walked from the tallest refs down. A chunk can only be referenced by chunks which are
+// taller than it, so once the walk reaches a height every ref of that height on either side is known, and the refs
+// common to both sides, along with everything beneath them, can be skipped.
+func (ddb *DoltDB) exclusiveSize(ctx context.Context, srcQ, sinkQ types.RefByHeight) (uint64, error) {
+	var size uint64
+	for !srcQ.Empty() {
+		sort.Sort(srcQ)
+		sort.Sort(sinkQ)
+
+		srcHt, sinkHt := srcQ.MaxHeight(), sinkQ.MaxHeight()
+		srcHashes, sinkHashes := hash.HashSet{}, hash.HashSet{}
+
+		if srcHt >= sinkHt {
+			for _, r := range srcQ.PopRefsOfHeight(srcHt) {
+				srcHashes.Insert(r.TargetHash())
+			}
+		}
+
+		if sinkHt >= srcHt {
+			for _, r := range sinkQ.PopRefsOfHeight(sinkHt) {
+				if srcHashes.Has(r.TargetHash()) {
+					srcHashes.Remove(r.TargetHash())
+				} else {
+					sinkHashes.Insert(r.TargetHash())
+				}
+			}
+		}
+
+		err := ddb.getChunks(ctx, srcHashes, func(c *chunks.Chunk) error {
+			size += uint64(len(c.Data()))
+			return types.WalkRefs(*c, ddb.Format(), func(r types.Ref) error {
+				srcQ.PushBack(r)
+				return nil
+			})
+		})
+
+		if err != nil {
+			return 0, err
+		}
+
+		err = ddb.getChunks(ctx, sinkHashes, func(c *chunks.Chunk) error {
+			return types.WalkRefs(*c, ddb.Format(), func(r types.Ref) error {
+				sinkQ.PushBack(r)
+				return nil
+			})
+		})
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return size, nil
+}
+
+// getChunks reads the chunks with the given hashes from the chunk store in batches, calling |cb| for each one.
+func (ddb *DoltDB) getChunks(ctx context.Context, hashes hash.HashSet, cb func(c *chunks.Chunk) error) error {
+	cs := datas.ChunkStoreFromDatabase(ddb.db)
+
+	batch := make(hash.HashSet, sizeBatchSize)
+	flush := func() error {
+		found := 0
+		foundChunks := make(chan *chunks.Chunk, 16)
+		errChan := make(chan error, 1)
+
+		go func() {
+			defer close(foundChunks)
+			errChan <- cs.GetMany(ctx, batch, foundChunks)
+		}()
+
+		var err error
+		for c := range foundChunks {
+			found++
+			if err == nil {
+				err = cb(c)
+			}
+		}
+
+		if getErr := <-errChan; getErr != nil {
+			return getErr
+		} else if err != nil {
+			return err
+		} else if found != len(batch) {
+			return fmt.Errorf("%d of %d chunks are missing from the database", len(batch)-found, len(batch))
+		}
+
+		batch = make(hash.HashSet, sizeBatchSize)
+		return nil
+	}
+
+	for h := range hashes {
+		batch.Insert(h)
+
+		if len(batch) == sizeBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(batch) > 0 {
+		return flush()
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doltdb
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+func createSizeTestSchema(tagOffset uint64) schema.Schema {
+	colColl, _ := schema.NewColCollection(
+		schema.NewColumn("id", tagOffset, types.UUIDKind, true, schema.NotNullConstraint{}),
+		schema.NewColumn("first", tagOffset+1, types.StringKind, false),
+		schema.NewColumn("age", tagOffset+2, types.UintKind, false),
+	)
+
+	return schema.SchemaFromCols(colColl)
+}
+
+func createSizeTestTable(t *testing.T, vrw types.ValueReadWriter, sch schema.Schema, numRows int) *Table {
+	m, err := types.NewMap(context.Background(), vrw)
+	require.NoError(t, err)
+	ed := m.Edit()
+
+	for i := 0; i < numRows; i++ {
+		tags := sch.GetAllCols().Tags
+		r, err := row.New(types.Format_7_18, sch, row.TaggedValues{
+			tags[0]: types.UUID(uuid.New()),
+			tags[1]: types.String("first " + strconv.Itoa(i)),
+			tags[2]: types.Uint(i),
+		})
+		require.NoError(t, err)
+		ed = ed.Set(r.NomsMapKey(sch), r.NomsMapValue(sch))
+	}
+
+	m, err = ed.Map(context.Background())
+	require.NoError(t, err)
+
+	tbl, err := createTestTable(vrw, sch, m)
+	require.NoError(t, err)
+
+	return tbl
+}
+
+func commitSizeTestRoot(t *testing.T, ddb *DoltDB, root *RootValue, msg string) *Commit {
+	ctx := context.Background()
+	h, err := ddb.WriteRootValue(ctx, root)
+	require.NoError(t, err)
+	meta, err := NewCommitMeta("Bill Billerson", "bigbillieb@fake.horse", msg)
+	require.NoError(t, err)
+	cm, err := ddb.Commit(ctx, h, ref.NewBranchRef("master"), meta)
+	require.NoError(t, err)
+
+	return cm
+}
+
+func TestSizes(t *testing.T) {
+	ctx := context.Background()
+	ddb, err := LoadDoltDB(ctx, types.Format_7_18, InMemDoltDB)
+	require.NoError(t, err)
+	require.NoError(t, ddb.WriteEmptyRepo(ctx, "Bill Billerson", "bigbillieb@fake.horse"))
+
+	cs, _ := NewCommitSpec("HEAD", "master")
+	head, err := ddb.Resolve(ctx, cs)
+	require.NoError(t, err)
+	root, err := head.GetRootValue()
+	require.NoError(t, err)
+
+	bigSch, smallSch := createSizeTestSchema(100), createSizeTestSchema(200)
+	big := createSizeTestTable(t, ddb.db, bigSch, 5000)
+	small := createSizeTestTable(t, ddb.db, smallSch, 10)
+
+	root, err = root.PutTable(ctx, "big", big)
+	require.NoError(t, err)
+	root, err = root.PutTable(ctx, "small", small)
+	require.NoError(t, err)
+	first := commitSizeTestRoot(t, ddb, root, "add tables")
+
+	t.Run("TableSizes", func(t *testing.T) {
+		sizes, err := ddb.TableSizes(ctx, root)
+		require.NoError(t, err)
+		require.Len(t, sizes, 2)
+
+		for _, size := range sizes {
+			assert.True(t, size.ExclusiveBytes > 0)
+			assert.True(t, size.TotalBytes >= size.ExclusiveBytes)
+		}
+
+		assert.Equal(t, "big", sizes[0].Name)
+		assert.Equal(t, "small", sizes[1].Name)
+		assert.True(t, sizes[0].ExclusiveBytes > sizes[1].ExclusiveBytes)
+	})
+
+	t.Run("CommitSize", func(t *testing.T) {
+		small := createSizeTestTable(t, ddb.db, smallSch, 20)
+		root, err := root.PutTable(ctx, "small", small)
+		require.NoError(t, err)
+		second := commitSizeTestRoot(t, ddb, root, "update small")
+
+		firstSize, err := ddb.CommitSize(ctx, first)
+		require.NoError(t, err)
+		secondSize, err := ddb.CommitSize(ctx, second)
+		require.NoError(t, err)
+
+		assert.True(t, secondSize > 0)
+		assert.True(t, firstSize > secondSize)
+
+		initSize, err := ddb.CommitSize(ctx, head)
+		require.NoError(t, err)
+		assert.True(t, initSize > 0)
+		assert.True(t, firstSize > initSize)
+	})
+}
@@ -34,8 +34,10 @@ const (
 
 	schemaRefKey       = "schema_ref"
 	tableRowsKey       = "rows"
+	coldRowsKey        = "cold_rows"
 	conflictsKey       = "conflicts"
 	conflictSchemasKey = "conflict_schemas"
+	canonicalRowsKey   = "canonical_rows"
 
 	// TableNameRegexStr is the regular expression that valid tables must match.
 	TableNameRegexStr = `^[a-zA-Z]{1}$|^[a-zA-Z]+[-_0-9a-zA-Z]*[0-9a-zA-Z]+$`
@@ -49,6 +51,22 @@ const (
 	// SchemasTableName is the name of the dolt schema fragment table
 	SchemasTableName = "dolt_schemas"
 
+	// StatisticsTableName is the name of the table holding the column statistics built by ANALYZE TABLE
+	StatisticsTableName = "dolt_statistics"
+
+	// RowPoliciesTableName is the name of the table holding the row policies created by CREATE POLICY
+	RowPoliciesTableName = "dolt_row_policies"
+
+	// MergeDriversTableName is the name of the table holding the merge drivers selected for tables
+	MergeDriversTableName = "dolt_merge_drivers"
+
+	// IgnoreTableName is the name of the table holding the patterns of tables ignored by dolt status and dolt add
+	IgnoreTableName = "dolt_ignore"
+
+	// PermissionsTableName is the name of the table holding the permission policies which limit the tables users can
+	// write on each branch
+	PermissionsTableName = "dolt_permissions"
+
 	// SystemTableReservedMin defines the lower bound of the tag space reserved for system tables
 	SystemTableReservedMin uint64 = schema.ReservedTagMin << 1
 )
@@ -117,12 +135,138 @@ const (
 	DoltSchemasFragmentTag
 )
 
+const (
+	// StatisticsTableCol is the name of the column of the analyzed table
+	StatisticsTableCol = "table_name"
+
+	// StatisticsColumnCol is the name of the column of the analyzed column
+	StatisticsColumnCol = "column_name"
+
+	// StatisticsRowCountCol is the name of the column containing the number of rows of the analyzed table
+	StatisticsRowCountCol = "row_count"
+
+	// StatisticsNullCountCol is the name of the column containing the number of rows in which the column is null
+	StatisticsNullCountCol = "null_count"
+
+	// StatisticsDistinctCountCol is the name of the column containing the estimated number of distinct values
+	StatisticsDistinctCountCol = "distinct_count"
+
+	// StatisticsMinCol is the name of the column containing the least value of the column
+	StatisticsMinCol = "min_value"
+
+	// StatisticsMaxCol is the name of the column containing the greatest value of the column
+	StatisticsMaxCol = "max_value"
+
+	// StatisticsHistogramCol is the name of the column containing the column's histogram, encoded as JSON
+	StatisticsHistogramCol = "histogram"
+
+	// StatisticsSampleSizeCol is the name of the column containing the number of rows the histogram was built from
+	StatisticsSampleSizeCol = "sample_size"
+
+	// StatisticsRowDataCol is the name of the column containing the hash of the row data of the analyzed table
+	StatisticsRowDataCol = "row_data"
+
+	// Tags for dolt_statistics table
+	StatisticsTableTag = iota + SystemTableReservedMin + uint64(5000)
+	StatisticsColumnTag
+	StatisticsRowCountTag
+	StatisticsNullCountTag
+	StatisticsDistinctCountTag
+	StatisticsMinTag
+	StatisticsMaxTag
+	StatisticsHistogramTag
+	StatisticsSampleSizeTag
+	StatisticsRowDataTag
+)
+
+const (
+	// RowPoliciesTableCol is the name of the column of the table a row policy limits
+	RowPoliciesTableCol = "table_name"
+
+	// RowPoliciesNameCol is the name of the column containing the name of a row policy
+	RowPoliciesNameCol = "policy_name"
+
+	// RowPoliciesUserCol is the name of the column containing the user a row policy applies to, or
+	// RowPoliciesAllUsers if it applies to every user
+	RowPoliciesUserCol = "user_name"
+
+	// RowPoliciesPredicateCol is the name of the column containing the predicate rows must satisfy
+	RowPoliciesPredicateCol = "predicate"
+
+	// RowPoliciesAllUsers is the user of a row policy which applies to every user
+	RowPoliciesAllUsers = "%"
+
+	// Tags for dolt_row_policies table
+	RowPoliciesTableTag = iota + SystemTableReservedMin + uint64(6000)
+	RowPoliciesNameTag
+	RowPoliciesUserTag
+	RowPoliciesPredicateTag
+)
+
+const (
+	// MergeDriversTableCol is the name of the column of the table a merge driver is selected for
+	MergeDriversTableCol = "table_name"
+
+	// MergeDriversDriverCol is the name of the column containing the name of the merge driver selected for a table
+	MergeDriversDriverCol = "driver"
+
+	// Tags for dolt_merge_drivers table
+	MergeDriversTableTag = iota + SystemTableReservedMin + uint64(7000)
+	MergeDriversDriverTag
+)
+
+const (
+	// IgnorePatternCol is the name of the column containing a pattern of table names
+	IgnorePatternCol = "pattern"
+
+	// IgnoreIgnoredCol is the name of the column containing whether the tables matching a pattern are ignored
+	IgnoreIgnoredCol = "ignored"
+
+	// Tags for dolt_ignore table
+	IgnorePatternTag = iota + SystemTableReservedMin + uint64(8000)
+	IgnoreIgnoredTag
+)
+
+const (
+	// PermissionsNameCol is the name of the column containing the name of a permission policy
+	PermissionsNameCol = "policy_name"
+
+	// PermissionsGranteeCol is the name of the column containing the user or role a permission policy applies to, or
+	// PermissionsAllGrantees if it applies to every user
+	PermissionsGranteeCol = "grantee"
+
+	// PermissionsBranchCol is the name of the column containing the pattern of the branches a permission policy covers
+	PermissionsBranchCol = "branch_pattern"
+
+	// PermissionsTableCol is the name of the column containing the pattern of the tables a permission policy covers
+	PermissionsTableCol = "table_pattern"
+
+	// PermissionsAccessCol is the name of the column containing the access a permission policy grants, which is
+	// either PermissionWrite or PermissionRead
+	PermissionsAccessCol = "access"
+
+	// PermissionsAllGrantees is the grantee of a permission policy which applies to every user
+	PermissionsAllGrantees = "%"
+
+	// Tags for dolt_permissions table
+	PermissionsNameTag = iota + SystemTableReservedMin + uint64(9000)
+	PermissionsGranteeTag
+	PermissionsBranchTag
+	PermissionsTableTag
+	PermissionsAccessTag
+)
+
 // The set of reserved dolt_ tables that should be considered part of user space, like any other user-created table,
 // for the purposes of the dolt command line. These tables cannot be created or altered explicitly, but can be updated
 // like normal SQL tables.
 var userSpaceReservedTables = set.NewStrSet([]string{
 	DoltQueryCatalogTableName,
 	SchemasTableName,
+	StatisticsTableName,
+	RowPoliciesTableName,
+	MergeDriversTableName,
+	IgnoreTableName,
+	PermissionsTableName,
 })
 
 var tableNameRegex, _ = regexp.Compile(TableNameRegexStr)
@@ -153,7 +297,8 @@ type Table struct {
 	tableStruct types.Struct
 }
 
-// NewTable creates a noms Struct which stores the schema and the row data
+// NewTable creates a noms Struct which stores the schema and the row data. The values of the cold columns of the schema
+// are stored apart from the rest of the row data.
 func NewTable(ctx context.Context, vrw types.ValueReadWriter, schema types.Value, rowData types.Map) (*Table, error) {
 	schemaRef, err := writeValAndGetRef(ctx, vrw, schema)
 
@@ -161,6 +306,23 @@ func NewTable(ctx context.Context, vrw types.ValueReadWriter, schema types.Value
 		return nil, err
 	}
 
+	sch, err := encoding.UnmarshalSchemaNomsValue(ctx, vrw.Format(), schema)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var coldRows *types.Map
+	if coldTags := coldTagSet(sch); coldTags != nil {
+		hot, cold, err := splitRowData(ctx, vrw, rowData, coldTags)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rowData, coldRows = hot, &cold
+	}
+
 	rowDataRef, err := writeValAndGetRef(ctx, vrw, rowData)
 
 	if err != nil {
@@ -172,6 +334,14 @@ func NewTable(ctx context.Context, vrw types.ValueReadWriter, schema types.Value
 		tableRowsKey: rowDataRef,
 	}
 
+	if coldRows != nil {
+		sd[coldRowsKey], err = writeValAndGetRef(ctx, vrw, *coldRows)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	tableStruct, err := types.NewStruct(vrw.Format(), tableStructName, sd)
 
 	if err != nil {
@@ -342,7 +512,15 @@ func (t *Table) GetConflictSchemas(ctx context.Context) (base, sch, mergeSch sch
 	return nil, nil, nil, ErrNoConflicts
 }
 
+// RefToSchema returns the schema referenced by |ref|. Schemas decoded before, by this process or one which persisted
+// the schema cache, aren't read or decoded again.
 func RefToSchema(ctx context.Context, vrw types.ValueReadWriter, ref types.Ref) (schema.Schema, error) {
+	h := ref.TargetHash()
+
+	if sch, ok := globalSchemaCache.get(h); ok {
+		return sch, nil
+	}
+
 	schemaVal, err := ref.TargetValue(ctx, vrw)
 
 	if err != nil {
@@ -355,6 +533,8 @@ func RefToSchema(ctx context.Context, vrw types.ValueReadWriter, ref types.Ref)
 		return nil, err
 	}
 
+	globalSchemaCache.put(h, schema)
+
 	return schema, nil
 }
 
@@ -405,6 +585,32 @@ func (t *Table) HasTheSameSchema(t2 *Table) (bool, error) {
 	return schemaRef.TargetHash() == schema2Ref.TargetHash(), nil
 }
 
+// HasTheSameRows tests the row data within 2 tables for equality by comparing the hashes of their row maps, without
+// reading any rows
+func (t *Table) HasTheSameRows(t2 *Table) (bool, error) {
+	for _, key := range []string{tableRowsKey, coldRowsKey} {
+		rowsVal, ok, err := t.tableStruct.MaybeGet(key)
+
+		if err != nil {
+			return false, err
+		}
+
+		rows2Val, ok2, err := t2.tableStruct.MaybeGet(key)
+
+		if err != nil {
+			return false, err
+		}
+
+		if ok != ok2 {
+			return false, nil
+		} else if ok && rowsVal.(types.Ref).TargetHash() != rows2Val.(types.Ref).TargetHash() {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
 // HashOf returns the hash of the underlying table struct
 func (t *Table) HashOf() (hash.Hash, error) {
 	return t.tableStruct.Hash(t.vrw.Format())
@@ -424,13 +630,19 @@ func (t *Table) GetRowByPKVals(ctx context.Context, pkVals row.TaggedValues, sch
 // GetRow uses the noms DestRef containing the row data to lookup a row by primary key.  If a valid row exists with this pk
 // then the supplied TableRowFactory will be used to create a TableRow using the row data.
 func (t *Table) GetRow(ctx context.Context, pk types.Tuple, sch schema.Schema) (row.Row, bool, error) {
-	rowMap, err := t.GetRowData(ctx)
+	rowMap, err := t.GetHotRowData(ctx)
 
 	if err != nil {
 		return nil, false, err
 	}
 
-	fieldsVal, _, err := rowMap.MaybeGet(ctx, pk)
+	coldMap, hasCold, err := t.GetColdRowData(ctx)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	fieldsVal, err := getRowValue(ctx, rowMap, coldMap, hasCold, pk)
 
 	if err != nil {
 		return nil, false, err
@@ -462,7 +674,13 @@ func (t *Table) GetRows(ctx context.Context, pkItr PKItr, numPKs int, sch schema
 	rows = make([]row.Row, 0, numPKs)
 	missing = make([]types.Value, 0, numPKs)
 
-	rowMap, err := t.GetRowData(ctx)
+	rowMap, err := t.GetHotRowData(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	coldMap, hasCold, err := t.GetColdRowData(ctx)
 
 	if err != nil {
 		return nil, nil, err
@@ -473,7 +691,7 @@ func (t *Table) GetRows(ctx context.Context, pkItr PKItr, numPKs int, sch schema
 			return nil, nil, err
 		}
 
-		fieldsVal, _, err := rowMap.MaybeGet(ctx, pk)
+		fieldsVal, err := getRowValue(ctx, rowMap, coldMap, hasCold, pk)
 
 		if err != nil {
 			return nil, nil, err
@@ -498,7 +716,31 @@ func (t *Table) GetRows(ctx context.Context, pkItr PKItr, numPKs int, sch schema
 // UpdateRows replaces the current row data and returns and updated Table.  Calls to UpdateRows will not be written to the
 // database.  The root must be updated with the updated table, and the root must be committed or written.
 func (t *Table) UpdateRows(ctx context.Context, updatedRows types.Map) (*Table, error) {
-	rowDataRef, err := writeValAndGetRef(ctx, t.vrw, updatedRows)
+	sch, err := t.GetSchema(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	coldTags := coldTagSet(sch)
+
+	if coldTags == nil {
+		return t.UpdateHotAndColdRows(ctx, updatedRows, nil)
+	}
+
+	hot, cold, err := splitRowData(ctx, t.vrw, updatedRows, coldTags)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return t.UpdateHotAndColdRows(ctx, hot, &cold)
+}
+
+// UpdateHotAndColdRows replaces the current row data with |hot|, the values of the columns of the table which aren't
+// cold, and |cold|, the values of its cold columns if it has any, in a single update of the table.
+func (t *Table) UpdateHotAndColdRows(ctx context.Context, hot types.Map, cold *types.Map) (*Table, error) {
+	rowDataRef, err := writeValAndGetRef(ctx, t.vrw, hot)
 
 	if err != nil {
 		return nil, err
@@ -510,11 +752,46 @@ func (t *Table) UpdateRows(ctx context.Context, updatedRows types.Map) (*Table,
 		return nil, err
 	}
 
+	if cold != nil {
+		coldRowsRef, err := writeValAndGetRef(ctx, t.vrw, *cold)
+
+		if err != nil {
+			return nil, err
+		}
+
+		updatedSt, err = updatedSt.Set(coldRowsKey, coldRowsRef)
+	} else {
+		updatedSt, err = updatedSt.Delete(coldRowsKey)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
 	return &Table{t.vrw, updatedSt}, nil
 }
 
-// GetRowData retrieves the underlying map which is a map from a primary key to a list of field values.
+// GetRowData retrieves the underlying map which is a map from a primary key to a list of field values. The values of
+// the cold columns of the table are joined with the rest of each row, which reads all of them.
 func (t *Table) GetRowData(ctx context.Context) (types.Map, error) {
+	rowMap, err := t.GetHotRowData(ctx)
+
+	if err != nil {
+		return types.EmptyMap, err
+	}
+
+	coldMap, ok, err := t.GetColdRowData(ctx)
+
+	if err != nil || !ok {
+		return rowMap, err
+	}
+
+	return joinRowData(ctx, t.vrw, rowMap, coldMap)
+}
+
+// GetHotRowData retrieves the map from a primary key to the values of the columns of the table which aren't cold, which
+// is the same map GetRowData returns for tables without cold columns.
+func (t *Table) GetHotRowData(ctx context.Context) (types.Map, error) {
 	val, _, err := t.tableStruct.MaybeGet(tableRowsKey)
 
 	if err != nil {
@@ -533,6 +810,48 @@ func (t *Table) GetRowData(ctx context.Context) (types.Map, error) {
 	return rowMap, nil
 }
 
+// HasColdRows returns whether the values of the cold columns of the table are stored apart from the rest of its rows.
+func (t *Table) HasColdRows() (bool, error) {
+	_, ok, err := t.tableStruct.MaybeGet(coldRowsKey)
+	return ok, err
+}
+
+// GetColdRowData retrieves the map from a primary key to the values of the cold columns of the table. Rows whose cold
+// values are all null aren't in the map. The bool returned is false for tables without cold columns.
+func (t *Table) GetColdRowData(ctx context.Context) (types.Map, bool, error) {
+	val, ok, err := t.tableStruct.MaybeGet(coldRowsKey)
+
+	if err != nil || !ok {
+		return types.EmptyMap, false, err
+	}
+
+	val, err = val.(types.Ref).TargetValue(ctx, t.vrw)
+
+	if err != nil {
+		return types.EmptyMap, false, err
+	}
+
+	return val.(types.Map), true, nil
+}
+
+// getRowValue returns the value tuple of the row with the primary key given, joining its values in |rowMap| with those
+// in |coldMap| when |hasCold| is true. It returns nil if there is no such row.
+func getRowValue(ctx context.Context, rowMap, coldMap types.Map, hasCold bool, pk types.Tuple) (types.Value, error) {
+	fieldsVal, _, err := rowMap.MaybeGet(ctx, pk)
+
+	if err != nil || fieldsVal == nil || !hasCold {
+		return fieldsVal, err
+	}
+
+	coldVal, _, err := coldMap.MaybeGet(ctx, pk)
+
+	if err != nil || coldVal == nil {
+		return fieldsVal, err
+	}
+
+	return JoinRowValues(rowMap.Format(), fieldsVal.(types.Tuple), coldVal.(types.Tuple))
+}
+
 /*func (t *Table) ResolveConflicts(keys []map[uint64]string) (invalid, notFound []types.Value, tbl *Table, err error) {
 	sch := t.GetSchema()
 	pkCols := sch.GetPKCols()
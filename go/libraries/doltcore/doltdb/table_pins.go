@@ -0,0 +1,239 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doltdb
+
+import (
+	"context"
+	"sort"
+
+	"github.com/liquidata-inc/dolt/go/store/datas"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// tablePinsDatasetID is the dataset holding the set of pinned tables. It isn't a dolt ref so the pins are never listed,
+// pushed or fetched as branches, but as the head of a dataset everything reachable from the pinned tables is reachable
+// from the root of the database, which is what garbage collection treats as live.
+const tablePinsDatasetID = "dolt_table_pins"
+
+// ReadTableFromHash returns the table whose value has the hash |h|. This is the table ref displayed by dolt ls
+// --verbose, which refers to an exact state of a table independent of any commit.
+func (ddb *DoltDB) ReadTableFromHash(ctx context.Context, h hash.Hash) (*Table, error) {
+	val, err := ddb.db.ReadValue(ctx, h)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if val == nil {
+		return nil, ErrHashNotFound
+	}
+
+	st, ok := val.(types.Struct)
+
+	if !ok || st.Name() != tableStructName {
+		return nil, ErrFoundHashNotATable
+	}
+
+	return &Table{ddb.db, st}, nil
+}
+
+// PullTableChunks pulls the chunks of the table with the hash |h|, and everything it references, from |srcDB| into
+// this database. The table isn't referenced by anything in this database afterwards, so it should be pinned with
+// PinTable. Progress is communicated over the provided channels.
+func (ddb *DoltDB) PullTableChunks(ctx context.Context, tempDir string, srcDB *DoltDB, h hash.Hash, progChan chan datas.PullProgress, pullerEventCh chan datas.PullerEvent) error {
+	tbl, err := srcDB.ReadTableFromHash(ctx, h)
+
+	if err != nil {
+		return err
+	}
+
+	rf, err := types.NewRef(tbl.tableStruct, ddb.db.Format())
+
+	if err != nil {
+		return err
+	}
+
+	if datas.CanUsePuller(srcDB.db) && datas.CanUsePuller(ddb.db) {
+		puller, err := datas.NewPuller(ctx, tempDir, 256*1024, srcDB.db, ddb.db, rf.TargetHash(), pullerEventCh)
+
+		if err == datas.ErrDBUpToDate {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		return puller.Pull(ctx)
+	} else {
+		return datas.PullWithoutBatching(ctx, srcDB.db, ddb.db, rf, progChan)
+	}
+}
+
+// GetTablePins returns the hashes of the pinned tables in sorted order.
+func (ddb *DoltDB) GetTablePins(ctx context.Context) ([]hash.Hash, error) {
+	pins, err := ddb.getTablePinSet(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var hashes hash.HashSlice
+	err = pins.IterAll(ctx, func(v types.Value) error {
+		hashes = append(hashes, v.(types.Ref).TargetHash())
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Sort(hashes)
+	return hashes, nil
+}
+
+// PinTable adds the table with the hash |h| to the pinned tables, so that it and all of its rows are kept in the
+// database whether or not any commit references it.
+func (ddb *DoltDB) PinTable(ctx context.Context, h hash.Hash) error {
+	tbl, err := ddb.ReadTableFromHash(ctx, h)
+
+	if err != nil {
+		return err
+	}
+
+	rf, err := types.NewRef(tbl.tableStruct, ddb.db.Format())
+
+	if err != nil {
+		return err
+	}
+
+	pins, err := ddb.getTablePinSet(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	se, err := pins.Edit().Insert(rf)
+
+	if err != nil {
+		return err
+	}
+
+	pins, err = se.Set(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	return ddb.setTablePins(ctx, pins)
+}
+
+// UnpinTable removes the table with the hash |h| from the pinned tables, returning ErrTablePinNotFound if it isn't
+// pinned.
+func (ddb *DoltDB) UnpinTable(ctx context.Context, h hash.Hash) error {
+	pins, err := ddb.getTablePinSet(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	var pinRef types.Value
+	err = pins.IterAll(ctx, func(v types.Value) error {
+		if v.(types.Ref).TargetHash() == h {
+			pinRef = v
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if pinRef == nil {
+		return ErrTablePinNotFound
+	}
+
+	se, err := pins.Edit().Remove(pinRef)
+
+	if err != nil {
+		return err
+	}
+
+	pins, err = se.Set(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	return ddb.setTablePins(ctx, pins)
+}
+
+func (ddb *DoltDB) getTablePinSet(ctx context.Context) (types.Set, error) {
+	ds, err := ddb.db.GetDataset(ctx, tablePinsDatasetID)
+
+	if err != nil {
+		return types.EmptySet, err
+	}
+
+	val, ok, err := ds.MaybeHeadValue()
+
+	if err != nil {
+		return types.EmptySet, err
+	}
+
+	if !ok {
+		return types.NewSet(ctx, ddb.db)
+	}
+
+	return val.(types.Set), nil
+}
+
+func (ddb *DoltDB) setTablePins(ctx context.Context, pins types.Set) error {
+	ds, err := ddb.db.GetDataset(ctx, tablePinsDatasetID)
+
+	if err != nil {
+		return err
+	}
+
+	if pins.Len() == 0 {
+		if ds.HasHead() {
+			_, err = ddb.db.Delete(ctx, ds)
+		}
+
+		return err
+	}
+
+	// the pins are committed without parents so that the history of the pin set doesn't keep unpinned tables alive
+	parents, err := types.NewSet(ctx, ddb.db)
+
+	if err != nil {
+		return err
+	}
+
+	commitSt, err := ddb.db.CommitDangling(ctx, pins, datas.CommitOptions{Parents: parents})
+
+	if err != nil {
+		return err
+	}
+
+	rf, err := types.NewRef(commitSt, ddb.db.Format())
+
+	if err != nil {
+		return err
+	}
+
+	_, err = ddb.db.SetHead(ctx, ds, rf)
+	return err
+}
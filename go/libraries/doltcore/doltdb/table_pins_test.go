@@ -0,0 +1,120 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doltdb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+func writeUncommittedTestTable(t *testing.T, ddb *DoltDB) hash.Hash {
+	ctx := context.Background()
+	tSchema := createTestSchema()
+	rowData, _ := createTestRowData(t, ddb.ValueReadWriter(), tSchema)
+	tbl, err := createTestTable(ddb.ValueReadWriter(), tSchema, rowData)
+	require.NoError(t, err)
+
+	_, err = ddb.ValueReadWriter().WriteValue(ctx, tbl.tableStruct)
+	require.NoError(t, err)
+	require.NoError(t, ddb.db.Flush(ctx))
+
+	h, err := tbl.HashOf()
+	require.NoError(t, err)
+
+	return h
+}
+
+func TestTablePins(t *testing.T) {
+	ctx := context.Background()
+	ddb, err := LoadDoltDB(ctx, types.Format_7_18, InMemDoltDB)
+	require.NoError(t, err)
+	require.NoError(t, ddb.WriteEmptyRepo(ctx, "Bill Billerson", "bigbillieb@fake.horse"))
+
+	tblHash := writeUncommittedTestTable(t, ddb)
+
+	tbl, err := ddb.ReadTableFromHash(ctx, tblHash)
+	require.NoError(t, err)
+	h, err := tbl.HashOf()
+	require.NoError(t, err)
+	assert.Equal(t, tblHash, h)
+
+	pins, err := ddb.GetTablePins(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, pins)
+
+	require.NoError(t, ddb.PinTable(ctx, tblHash))
+	require.NoError(t, ddb.PinTable(ctx, tblHash))
+
+	pins, err = ddb.GetTablePins(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []hash.Hash{tblHash}, pins)
+
+	// the pins aren't refs
+	refs, err := ddb.GetRefs(ctx)
+	require.NoError(t, err)
+	for _, r := range refs {
+		assert.NotContains(t, r.String(), tablePinsDatasetID)
+	}
+
+	require.NoError(t, ddb.UnpinTable(ctx, tblHash))
+	pins, err = ddb.GetTablePins(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, pins)
+
+	err = ddb.UnpinTable(ctx, tblHash)
+	assert.Equal(t, ErrTablePinNotFound, err)
+
+	cs, err := NewCommitSpec("HEAD", "master")
+	require.NoError(t, err)
+	cm, err := ddb.Resolve(ctx, cs)
+	require.NoError(t, err)
+	cmHash, err := cm.HashOf()
+	require.NoError(t, err)
+
+	err = ddb.PinTable(ctx, cmHash)
+	assert.Equal(t, ErrFoundHashNotATable, err)
+
+	err = ddb.PinTable(ctx, hash.Of([]byte("not a chunk")))
+	assert.Equal(t, ErrHashNotFound, err)
+}
+
+func TestPullTableChunks(t *testing.T) {
+	ctx := context.Background()
+	srcDB, err := LoadDoltDB(ctx, types.Format_7_18, InMemDoltDB)
+	require.NoError(t, err)
+	destDB, err := LoadDoltDB(ctx, types.Format_7_18, InMemDoltDB)
+	require.NoError(t, err)
+
+	tblHash := writeUncommittedTestTable(t, srcDB)
+	_, err = destDB.ReadTableFromHash(ctx, tblHash)
+	assert.Equal(t, ErrHashNotFound, err)
+
+	err = destDB.PullTableChunks(ctx, "", srcDB, tblHash, nil, nil)
+	require.NoError(t, err)
+
+	tbl, err := destDB.ReadTableFromHash(ctx, tblHash)
+	require.NoError(t, err)
+	rowData, err := tbl.GetRowData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), rowData.Len())
+
+	require.NoError(t, destDB.PinTable(ctx, tblHash))
+}
@@ -143,3 +143,31 @@ func TestTables(t *testing.T) {
 		}
 	}
 }
+
+func TestHasTheSameRows(t *testing.T) {
+	db, _ := dbfactory.MemFactory{}.CreateDB(context.Background(), types.Format_7_18, nil, nil)
+
+	tSchema := createTestSchema()
+	rowData, _ := createTestRowData(t, db, tSchema)
+	tbl1, err := createTestTable(db, tSchema, rowData)
+	assert.NoError(t, err)
+	tbl2, err := createTestTable(db, tSchema, rowData)
+	assert.NoError(t, err)
+
+	same, err := tbl1.HasTheSameRows(tbl2)
+	assert.NoError(t, err)
+	assert.True(t, same)
+
+	emptyRows, err := types.NewMap(context.Background(), db)
+	assert.NoError(t, err)
+	tbl2, err = tbl2.UpdateRows(context.Background(), emptyRows)
+	assert.NoError(t, err)
+
+	same, err = tbl1.HasTheSameRows(tbl2)
+	assert.NoError(t, err)
+	assert.False(t, same)
+
+	same, err = tbl1.HasTheSameSchema(tbl2)
+	assert.NoError(t, err)
+	assert.True(t, same)
+}
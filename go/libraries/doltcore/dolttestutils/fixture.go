@@ -0,0 +1,178 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dolttestutils builds dolt repositories for tests from a declarative description of their history.
+//
+// A repository is described by a sequence of Steps which are applied in order to a new repository held in memory:
+//
+//	fix, err := dolttestutils.Build(ctx,
+//		dolttestutils.PutTable{Name: "people", Schema: sch, Rows: dolttestutils.Rows{{1, "ann"}, {2, "bob"}}},
+//		dolttestutils.Commit{Name: "c1"},
+//		dolttestutils.Branch{Name: "feature"},
+//		dolttestutils.Checkout{Branch: "feature"},
+//		dolttestutils.UpsertRows{Table: "people", Rows: dolttestutils.Rows{{3, "cat"}}},
+//		dolttestutils.Commit{Name: "f1"},
+//		dolttestutils.Checkout{Branch: "master"},
+//		dolttestutils.Merge{Branch: "feature"},
+//		dolttestutils.Commit{Name: "merge"},
+//	)
+//
+// Commit times come from a clock which starts at Epoch, so building the same steps always results in the same commit
+// hashes. The package doesn't depend on the testing package, or on the packages which implement dolt's commands, so it
+// can be used by the tests of those packages and by projects which embed dolt.
+package dolttestutils
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const (
+	// UserName is the name of the author of a fixture's commits
+	UserName = "Fixture Builder"
+
+	// UserEmail is the email of the author of a fixture's commits
+	UserEmail = "fixture@example.com"
+
+	// InitCommit is the name of the commit which initializes a fixture's repository
+	InitCommit = "init"
+
+	homeDir    = "/user/fixture"
+	workingDir = "/user/fixture/repo"
+)
+
+// Epoch is the time of the commit which initializes a fixture's repository. Each commit after it is a minute later
+// than the one before.
+var Epoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Step is a change made to the repository of a fixture.
+type Step interface {
+	// Apply makes the change described by the step to |f|.
+	Apply(ctx context.Context, f *Fixture) error
+}
+
+// Fixture is a repository built by Build.
+type Fixture struct {
+	// DEnv is the environment of the repository. Its filesystem and database are held in memory.
+	DEnv *env.DoltEnv
+
+	// Commits holds the hashes of the commits made by steps which named them, along with the hash of InitCommit.
+	Commits map[string]hash.Hash
+
+	now time.Time
+}
+
+// Build creates a repository and applies |steps| to it in order. The times of its commits are read from the fixture's
+// clock rather than from doltdb.CommitNowFunc, which is left alone.
+func Build(ctx context.Context, steps ...Step) (*Fixture, error) {
+	f := &Fixture{Commits: make(map[string]hash.Hash), now: Epoch.Add(-time.Minute)}
+
+	fs := filesys.NewInMemFS([]string{homeDir, workingDir}, nil, workingDir)
+	dEnv := env.Load(ctx, func() (string, error) { return homeDir, nil }, fs, doltdb.InMemDoltDB, "test")
+
+	cfg, _ := dEnv.Config.GetConfig(env.GlobalConfig)
+	err := cfg.SetStrings(map[string]string{
+		env.UserNameKey:  UserName,
+		env.UserEmailKey: UserEmail,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := f.commitMeta(doltdb.CreationCommitMessage)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = dEnv.InitRepoWithCommitMeta(ctx, types.Format_Default, meta)
+
+	if err != nil {
+		return nil, err
+	}
+
+	f.DEnv = dEnv
+
+	head, err := f.resolve(ctx, "HEAD")
+
+	if err != nil {
+		return nil, err
+	}
+
+	f.Commits[InitCommit], err = head.HashOf()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i, step := range steps {
+		if err := step.Apply(ctx, f); err != nil {
+			return nil, fmt.Errorf("step %d (%T): %w", i, step, err)
+		}
+	}
+
+	return f, nil
+}
+
+// Now advances the fixture's clock by a minute and returns its time.
+func (f *Fixture) Now() time.Time {
+	f.now = f.now.Add(time.Minute)
+	return f.now
+}
+
+// commitMeta returns the metadata of a commit made by the fixture's user at the next time of the fixture's clock.
+func (f *Fixture) commitMeta(msg string) (*doltdb.CommitMeta, error) {
+	t := f.Now()
+	return doltdb.NewCommitMetaWithTimes(UserName, UserEmail, msg, t, t)
+}
+
+// Commit returns the commit with the name |name|.
+func (f *Fixture) Commit(ctx context.Context, name string) (*doltdb.Commit, error) {
+	h, ok := f.Commits[name]
+
+	if !ok {
+		return nil, fmt.Errorf("no commit named '%s'", name)
+	}
+
+	cs, err := doltdb.NewCommitSpec(h.String(), f.DEnv.RepoState.CWBHeadRef().String())
+
+	if err != nil {
+		return nil, err
+	}
+
+	return f.DEnv.DoltDB.Resolve(ctx, cs)
+}
+
+// resolve returns the commit named |spec|, which is either the name of a commit of the fixture or a commit spec.
+func (f *Fixture) resolve(ctx context.Context, spec string) (*doltdb.Commit, error) {
+	if _, ok := f.Commits[spec]; ok {
+		return f.Commit(ctx, spec)
+	}
+
+	cs, err := doltdb.NewCommitSpec(spec, f.DEnv.RepoState.CWBHeadRef().String())
+
+	if err != nil {
+		return nil, err
+	}
+
+	return f.DEnv.DoltDB.Resolve(ctx, cs)
+}
@@ -0,0 +1,238 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dolttestutils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/typeinfo"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const (
+	idTag   = 0
+	nameTag = 1
+)
+
+func peopleSchema(t *testing.T) schema.Schema {
+	return idNameSchema(t, idTag, nameTag)
+}
+
+func idNameSchema(t *testing.T, idTag, nameTag uint64) schema.Schema {
+	id, err := schema.NewColumnWithTypeInfo("id", idTag, typeinfo.Int32Type, true, schema.NotNullConstraint{})
+	require.NoError(t, err)
+	name, err := schema.NewColumnWithTypeInfo("name", nameTag, typeinfo.StringDefaultType, false)
+	require.NoError(t, err)
+	colColl, err := schema.NewColCollection(id, name)
+	require.NoError(t, err)
+
+	return schema.SchemaFromCols(colColl)
+}
+
+func historySteps(t *testing.T) []Step {
+	return []Step{
+		PutTable{Name: "people", Schema: peopleSchema(t), Rows: Rows{{1, "ann"}, {2, "bob"}}},
+		Commit{Name: "c1"},
+		Branch{Name: "feature"},
+		Checkout{Branch: "feature"},
+		UpsertRows{Table: "people", Rows: Rows{{3, "cat"}, {1, types.String("anne")}}},
+		Commit{Name: "f1", Message: "feature changes"},
+		Checkout{Branch: "master"},
+		DeleteRows{Table: "people", Keys: Rows{{2}}},
+		Commit{Name: "c2"},
+		Merge{Branch: "feature"},
+		Commit{Name: "merge"},
+	}
+}
+
+func readPeople(t *testing.T, ctx context.Context, f *Fixture) map[int64]interface{} {
+	root, err := f.DEnv.WorkingRoot(ctx)
+	require.NoError(t, err)
+	tbl, ok, err := root.GetTable(ctx, "people")
+	require.NoError(t, err)
+	require.True(t, ok)
+	sch, err := tbl.GetSchema(ctx)
+	require.NoError(t, err)
+	rowData, err := tbl.GetRowData(ctx)
+	require.NoError(t, err)
+
+	people := make(map[int64]interface{})
+	err = rowData.Iter(ctx, func(k, v types.Value) (bool, error) {
+		r, err := row.FromNoms(sch, k.(types.Tuple), v.(types.Tuple))
+		require.NoError(t, err)
+		id, _ := r.GetColVal(idTag)
+		name, ok := r.GetColVal(nameTag)
+		if ok {
+			people[int64(id.(types.Int))] = string(name.(types.String))
+		} else {
+			people[int64(id.(types.Int))] = nil
+		}
+
+		return false, nil
+	})
+	require.NoError(t, err)
+
+	return people
+}
+
+func TestBuild(t *testing.T) {
+	ctx := context.Background()
+	f, err := Build(ctx, historySteps(t)...)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[int64]interface{}{1: "anne", 3: "cat"}, readPeople(t, ctx, f))
+	assert.Equal(t, "refs/heads/master", f.DEnv.RepoState.CWBHeadRef().String())
+	assert.False(t, f.DEnv.IsMergeActive())
+
+	for _, name := range []string{InitCommit, "c1", "f1", "c2", "merge"} {
+		assert.Contains(t, f.Commits, name)
+	}
+
+	mergeCm, err := f.Commit(ctx, "merge")
+	require.NoError(t, err)
+	numParents, err := mergeCm.NumParents()
+	require.NoError(t, err)
+	assert.Equal(t, 2, numParents)
+
+	meta, err := mergeCm.GetCommitMeta()
+	require.NoError(t, err)
+	assert.Equal(t, UserName, meta.Name)
+	assert.Equal(t, UserEmail, meta.Email)
+	assert.Equal(t, "commit merge", meta.Description)
+
+	f1, err := f.Commit(ctx, "f1")
+	require.NoError(t, err)
+	meta, err = f1.GetCommitMeta()
+	require.NoError(t, err)
+	assert.Equal(t, "feature changes", meta.Description)
+
+	_, err = f.Commit(ctx, "missing")
+	assert.Error(t, err)
+}
+
+func TestBuildIsDeterministic(t *testing.T) {
+	ctx := context.Background()
+	f1, err := Build(ctx, historySteps(t)...)
+	require.NoError(t, err)
+	f2, err := Build(ctx, historySteps(t)...)
+	require.NoError(t, err)
+
+	assert.Equal(t, f1.Commits, f2.Commits)
+}
+
+func TestBuildUsesOwnClock(t *testing.T) {
+	ctx := context.Background()
+	expected, err := Build(ctx, historySteps(t)...)
+	require.NoError(t, err)
+
+	prevNowFunc := doltdb.CommitNowFunc
+	defer func() { doltdb.CommitNowFunc = prevNowFunc }()
+
+	nowCalls := 0
+	doltdb.CommitNowFunc = func() time.Time {
+		nowCalls++
+		return time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	f, err := Build(ctx, historySteps(t)...)
+	require.NoError(t, err)
+	assert.Equal(t, 0, nowCalls)
+	assert.Equal(t, expected.Commits, f.Commits)
+
+	c1, err := f.Commit(ctx, "c1")
+	require.NoError(t, err)
+	meta, err := c1.GetCommitMeta()
+	require.NoError(t, err)
+	assert.Equal(t, Epoch.Add(time.Minute), meta.Time().UTC())
+}
+
+func TestBuildWithConflicts(t *testing.T) {
+	ctx := context.Background()
+	steps := []Step{
+		PutTable{Name: "people", Schema: peopleSchema(t), Rows: Rows{{1, "ann"}}},
+		Commit{Name: "c1"},
+		Branch{Name: "other", From: "c1"},
+		UpsertRows{Table: "people", Rows: Rows{{1, "master"}}},
+		Commit{Name: "c2"},
+		Checkout{Branch: "other"},
+		UpsertRows{Table: "people", Rows: Rows{{1, "other"}}},
+		Commit{Name: "o1"},
+		Checkout{Branch: "master"},
+	}
+
+	_, err := Build(ctx, append(steps, Merge{Branch: "other"})...)
+	assert.True(t, errors.Is(err, ErrMergeConflicts))
+
+	f, err := Build(ctx, append(steps, Merge{Branch: "o1", AllowConflicts: true})...)
+	require.NoError(t, err)
+	assert.True(t, f.DEnv.IsMergeActive())
+
+	root, err := f.DEnv.WorkingRoot(ctx)
+	require.NoError(t, err)
+	inConflict, err := root.TablesInConflict(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"people"}, inConflict)
+
+	err = Commit{}.Apply(ctx, f)
+	assert.Error(t, err)
+}
+
+func TestBuildErrors(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := Build(ctx,
+		PutTable{Name: "people", Schema: peopleSchema(t)},
+		Checkout{Branch: "master"},
+	)
+	assert.True(t, errors.Is(err, ErrUncommittedChanges))
+	assert.Contains(t, err.Error(), "step 1 (dolttestutils.Checkout)")
+
+	_, err = Build(ctx, UpsertRows{Table: "people", Rows: Rows{{1, "ann"}}})
+	assert.Error(t, err)
+
+	_, err = Build(ctx, PutTable{Name: "people", Schema: peopleSchema(t), Rows: Rows{{1}}})
+	assert.Error(t, err)
+
+	_, err = Build(ctx, PutTable{Name: "people", Schema: peopleSchema(t), Rows: Rows{{"not a number", "ann"}}})
+	assert.Error(t, err)
+}
+
+func TestNullsAndDrops(t *testing.T) {
+	ctx := context.Background()
+	f, err := Build(ctx,
+		PutTable{Name: "people", Schema: peopleSchema(t), Rows: Rows{{1, nil}}},
+		PutTable{Name: "other", Schema: idNameSchema(t, 10, 11)},
+		DropTable{Name: "other"},
+		Commit{},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[int64]interface{}{1: nil}, readPeople(t, ctx, f))
+
+	root, err := f.DEnv.WorkingRoot(ctx)
+	require.NoError(t, err)
+	names, err := root.GetTableNames(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"people"}, names)
+}
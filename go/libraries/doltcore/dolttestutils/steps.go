@@ -0,0 +1,486 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dolttestutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/merge"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/encoding"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// ErrMergeConflicts is returned by a Merge step which resulted in conflicts without AllowConflicts.
+var ErrMergeConflicts = errors.New("merge resulted in conflicts")
+
+// ErrUncommittedChanges is returned by a Checkout step when the working set has changes which haven't been committed.
+var ErrUncommittedChanges = errors.New("the working set has uncommitted changes")
+
+// Rows are row literals. Each row holds a value for each column in the order of the columns of the table's schema. A
+// value is either a types.Value, or a go value which can be converted to the column's type, such as an int or a
+// string. nil is a null value.
+type Rows [][]interface{}
+
+// PutTable creates the table |Name| in the working set with the schema |Schema| and the rows |Rows|, replacing the table
+// if it already exists.
+type PutTable struct {
+	Name   string
+	Schema schema.Schema
+	Rows   Rows
+}
+
+// Apply implements Step.
+func (pt PutTable) Apply(ctx context.Context, f *Fixture) error {
+	root, err := f.DEnv.WorkingRoot(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	rowData, err := types.NewMap(ctx, root.VRW())
+
+	if err != nil {
+		return err
+	}
+
+	rowData, err = setRows(ctx, rowData, pt.Schema, pt.Rows)
+
+	if err != nil {
+		return err
+	}
+
+	return putTable(ctx, f, root, pt.Name, pt.Schema, rowData)
+}
+
+// UpsertRows inserts |Rows| into the table |Table| of the working set, replacing the rows with the same primary keys.
+type UpsertRows struct {
+	Table string
+	Rows  Rows
+}
+
+// Apply implements Step.
+func (ur UpsertRows) Apply(ctx context.Context, f *Fixture) error {
+	root, sch, rowData, err := getTable(ctx, f, ur.Table)
+
+	if err != nil {
+		return err
+	}
+
+	rowData, err = setRows(ctx, rowData, sch, ur.Rows)
+
+	if err != nil {
+		return err
+	}
+
+	return putTable(ctx, f, root, ur.Table, sch, rowData)
+}
+
+// DeleteRows deletes rows from the table |Table| of the working set. Each of |Keys| holds the values of a row's primary
+// key columns, in the order of the primary key.
+type DeleteRows struct {
+	Table string
+	Keys  Rows
+}
+
+// Apply implements Step.
+func (dr DeleteRows) Apply(ctx context.Context, f *Fixture) error {
+	root, sch, rowData, err := getTable(ctx, f, dr.Table)
+
+	if err != nil {
+		return err
+	}
+
+	pkCols := sch.GetPKCols().GetColumns()
+	me := rowData.Edit()
+	for _, key := range dr.Keys {
+		if len(key) != len(pkCols) {
+			return fmt.Errorf("expected %d primary key values, got %d", len(pkCols), len(key))
+		}
+
+		taggedVals, err := taggedValues(pkCols, key)
+
+		if err != nil {
+			return err
+		}
+
+		me = me.Remove(taggedVals.NomsTupleForTags(root.VRW().Format(), sch.GetPKCols().Tags, true))
+	}
+
+	rowData, err = me.Map(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	return putTable(ctx, f, root, dr.Table, sch, rowData)
+}
+
+// DropTable removes the table |Name| from the working set.
+type DropTable struct {
+	Name string
+}
+
+// Apply implements Step.
+func (dt DropTable) Apply(ctx context.Context, f *Fixture) error {
+	root, err := f.DEnv.WorkingRoot(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	root, err = root.RemoveTables(ctx, dt.Name)
+
+	if err != nil {
+		return err
+	}
+
+	return f.DEnv.UpdateWorkingRoot(ctx, root)
+}
+
+// Commit commits every change in the working set to the current branch. If the step follows a Merge the commit is a
+// merge commit. When |Name| is given the commit's hash is added to the fixture's Commits. A working set with conflicts
+// can only be committed, along with its conflicts, if |AllowConflicts| is true.
+type Commit struct {
+	Name string
+
+	// Message is the commit message, which defaults to "commit" followed by the commit's name
+	Message string
+
+	AllowConflicts bool
+}
+
+// Apply implements Step.
+func (c Commit) Apply(ctx context.Context, f *Fixture) error {
+	dEnv := f.DEnv
+	root, err := dEnv.WorkingRoot(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if hasConflicts, err := root.HasConflicts(ctx); err != nil {
+		return err
+	} else if hasConflicts && !c.AllowConflicts {
+		return errors.New("can't commit a working set with conflicts")
+	}
+
+	tblNames, err := root.GetTableNames(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	root, err = root.UpdateSuperSchemasFromOther(ctx, tblNames, root)
+
+	if err != nil {
+		return err
+	}
+
+	h, err := dEnv.DoltDB.WriteRootValue(ctx, root)
+
+	if err != nil {
+		return err
+	}
+
+	var parents []*doltdb.Commit
+	if dEnv.IsMergeActive() {
+		mergeCm, err := f.resolve(ctx, dEnv.RepoState.Merge.Commit)
+
+		if err != nil {
+			return err
+		}
+
+		parents = append(parents, mergeCm)
+	}
+
+	msg := c.Message
+	if msg == "" {
+		msg = "commit " + c.Name
+	}
+
+	meta, err := f.commitMeta(msg)
+
+	if err != nil {
+		return err
+	}
+
+	cm, err := dEnv.DoltDB.CommitWithParentCommits(ctx, h, dEnv.RepoState.CWBHeadRef(), parents, meta)
+
+	if err != nil {
+		return err
+	}
+
+	if err := setRoots(ctx, f, root); err != nil {
+		return err
+	}
+
+	if dEnv.IsMergeActive() {
+		if err := dEnv.RepoState.ClearMerge(dEnv.FS); err != nil {
+			return err
+		}
+	}
+
+	if c.Name != "" {
+		f.Commits[c.Name], err = cm.HashOf()
+	}
+
+	return err
+}
+
+// Branch creates the branch |Name| at the commit |From|, which is either the name of a commit of the fixture, or a
+// commit spec such as a branch name. The branch is created at the head of the current branch if |From| isn't given.
+type Branch struct {
+	Name string
+	From string
+}
+
+// Apply implements Step.
+func (b Branch) Apply(ctx context.Context, f *Fixture) error {
+	from := b.From
+	if from == "" {
+		from = "HEAD"
+	}
+
+	cm, err := f.resolve(ctx, from)
+
+	if err != nil {
+		return err
+	}
+
+	return f.DEnv.DoltDB.NewBranchAtCommit(ctx, ref.NewBranchRef(b.Name), cm)
+}
+
+// Checkout makes |Branch| the current branch, and sets the working set to its head. The working set must not have
+// uncommitted changes.
+type Checkout struct {
+	Branch string
+}
+
+// Apply implements Step.
+func (c Checkout) Apply(ctx context.Context, f *Fixture) error {
+	dEnv := f.DEnv
+	working, err := dEnv.WorkingRoot(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	head, err := dEnv.HeadRoot(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if workingHash, err := working.HashOf(); err != nil {
+		return err
+	} else if headHash, err := head.HashOf(); err != nil {
+		return err
+	} else if workingHash != headHash {
+		return ErrUncommittedChanges
+	}
+
+	branchRef := ref.NewBranchRef(c.Branch)
+	cm, err := f.resolve(ctx, branchRef.String())
+
+	if err != nil {
+		return err
+	}
+
+	root, err := cm.GetRootValue()
+
+	if err != nil {
+		return err
+	}
+
+	dEnv.RepoState.Head = ref.MarshalableRef{Ref: branchRef}
+	return setRoots(ctx, f, root)
+}
+
+// Merge merges the commit |Branch|, which is either the name of a commit of the fixture or a commit spec such as a
+// branch name, into the working set. The merge never fast forwards, so that a Commit step which follows it makes a
+// merge commit. A merge which results in conflicts returns ErrMergeConflicts unless |AllowConflicts| is true, in which
+// case the conflicts are left in the working set to be tested.
+type Merge struct {
+	Branch         string
+	AllowConflicts bool
+}
+
+// Apply implements Step.
+func (m Merge) Apply(ctx context.Context, f *Fixture) error {
+	dEnv := f.DEnv
+	head, err := f.resolve(ctx, "HEAD")
+
+	if err != nil {
+		return err
+	}
+
+	mergeCm, err := f.resolve(ctx, m.Branch)
+
+	if err != nil {
+		return err
+	}
+
+	mergedRoot, tblToStats, err := merge.MergeCommits(ctx, dEnv.DoltDB, head, mergeCm, nil)
+
+	if err != nil {
+		return err
+	}
+
+	conflicts := 0
+	for _, stats := range tblToStats {
+		conflicts += stats.Conflicts
+	}
+
+	if conflicts > 0 && !m.AllowConflicts {
+		return ErrMergeConflicts
+	}
+
+	mergeHash, err := mergeCm.HashOf()
+
+	if err != nil {
+		return err
+	}
+
+	err = dEnv.RepoState.StartMerge(dEnv.RepoState.CWBHeadRef(), mergeHash.String(), dEnv.FS)
+
+	if err != nil {
+		return err
+	}
+
+	if conflicts > 0 {
+		return dEnv.UpdateWorkingRoot(ctx, mergedRoot)
+	}
+
+	return setRoots(ctx, f, mergedRoot)
+}
+
+// setRoots sets the working and staged roots of the fixture to |root|.
+func setRoots(ctx context.Context, f *Fixture, root *doltdb.RootValue) error {
+	if err := f.DEnv.UpdateWorkingRoot(ctx, root); err != nil {
+		return err
+	}
+
+	_, err := f.DEnv.UpdateStagedRoot(ctx, root)
+	return err
+}
+
+func getTable(ctx context.Context, f *Fixture, tblName string) (*doltdb.RootValue, schema.Schema, types.Map, error) {
+	root, err := f.DEnv.WorkingRoot(ctx)
+
+	if err != nil {
+		return nil, nil, types.EmptyMap, err
+	}
+
+	tbl, ok, err := root.GetTable(ctx, tblName)
+
+	if err != nil {
+		return nil, nil, types.EmptyMap, err
+	} else if !ok {
+		return nil, nil, types.EmptyMap, doltdb.ErrTableNotFound
+	}
+
+	sch, err := tbl.GetSchema(ctx)
+
+	if err != nil {
+		return nil, nil, types.EmptyMap, err
+	}
+
+	rowData, err := tbl.GetRowData(ctx)
+
+	if err != nil {
+		return nil, nil, types.EmptyMap, err
+	}
+
+	return root, sch, rowData, nil
+}
+
+func putTable(ctx context.Context, f *Fixture, root *doltdb.RootValue, tblName string, sch schema.Schema, rowData types.Map) error {
+	schVal, err := encoding.MarshalSchemaAsNomsValue(ctx, root.VRW(), sch)
+
+	if err != nil {
+		return err
+	}
+
+	tbl, err := doltdb.NewTable(ctx, root.VRW(), schVal, rowData)
+
+	if err != nil {
+		return err
+	}
+
+	root, err = root.PutTable(ctx, tblName, tbl)
+
+	if err != nil {
+		return err
+	}
+
+	return f.DEnv.UpdateWorkingRoot(ctx, root)
+}
+
+func setRows(ctx context.Context, rowData types.Map, sch schema.Schema, rows Rows) (types.Map, error) {
+	cols := sch.GetAllCols().GetColumns()
+	me := rowData.Edit()
+	for _, vals := range rows {
+		if len(vals) != len(cols) {
+			return types.EmptyMap, fmt.Errorf("expected %d values in row %v, got %d", len(cols), vals, len(vals))
+		}
+
+		taggedVals, err := taggedValues(cols, vals)
+
+		if err != nil {
+			return types.EmptyMap, err
+		}
+
+		r, err := row.New(rowData.Format(), sch, taggedVals)
+
+		if err != nil {
+			return types.EmptyMap, err
+		}
+
+		me = me.Set(r.NomsMapKey(sch), r.NomsMapValue(sch))
+	}
+
+	return me.Map(ctx)
+}
+
+// taggedValues converts the row literal |vals| to the types of |cols|.
+func taggedValues(cols []schema.Column, vals []interface{}) (row.TaggedValues, error) {
+	taggedVals := make(row.TaggedValues)
+	for i, col := range cols {
+		if vals[i] == nil {
+			continue
+		}
+
+		if val, ok := vals[i].(types.Value); ok {
+			taggedVals[col.Tag] = val
+			continue
+		}
+
+		val, err := col.TypeInfo.ConvertValueToNomsValue(vals[i])
+
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", col.Name, err)
+		}
+
+		taggedVals[col.Tag] = val
+	}
+
+	return taggedVals, nil
+}
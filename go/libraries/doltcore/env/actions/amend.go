@@ -0,0 +1,172 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+)
+
+var ErrAmendMergeActive = errors.New("the last commit cannot be amended during a merge")
+var ErrAmendPushed = errors.New("the last commit has been pushed to the upstream of the branch")
+
+// AmendCommit replaces the head commit of the current branch with a new commit of the staged root, which is the root
+// of the head commit when nothing is staged, with the message |msg| and the user's name and email. The new commit has
+// the parents of the commit it replaces. The replaced commit is kept in the branch's ref.BackupRef so that it can be
+// recovered. Unless |force| is true, ErrAmendPushed is returned if the commit has been pushed to the upstream of the
+// branch.
+func AmendCommit(ctx context.Context, dEnv *env.DoltEnv, msg string, date time.Time, force bool) (*doltdb.Commit, error) {
+	if msg == "" {
+		return nil, ErrEmptyCommitMessage
+	}
+
+	if dEnv.IsMergeActive() {
+		return nil, ErrAmendMergeActive
+	}
+
+	headRef := dEnv.RepoState.CWBHeadRef()
+	headCommit, err := resolveHeadCommit(ctx, dEnv, headRef)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !force {
+		pushed, err := isPushedToUpstream(ctx, dEnv, headRef, headCommit)
+
+		if err != nil {
+			return nil, err
+		} else if pushed {
+			return nil, ErrAmendPushed
+		}
+	}
+
+	name, email, err := GetNameAndEmail(dEnv.Config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := doltdb.NewCommitMetaWithUserTS(name, email, msg, date)
+
+	if err != nil {
+		return nil, ErrEmptyCommitMessage
+	}
+
+	parents, err := dEnv.DoltDB.ResolveAllParents(ctx, headCommit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stagedTbls, _, err := diff.GetTableDiffs(ctx, dEnv)
+
+	if err != nil {
+		return nil, err
+	}
+
+	srt, err := dEnv.StagedRoot(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	wrt, err := dEnv.WorkingRoot(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := updateSuperSchemasAndSaveRoots(ctx, dEnv, stagedTbls.Tables, srt, wrt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	amended, err := dEnv.DoltDB.CommitDanglingWithParentCommits(ctx, h, parents, meta)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = dEnv.DoltDB.SetHead(ctx, ref.NewBackupRef(headRef), headCommit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = dEnv.DoltDB.SetHead(ctx, headRef, amended)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return amended, nil
+}
+
+// isPushedToUpstream returns whether |cm| is in the history of the remote tracking branch of the upstream of the
+// branch |branchRef|. It returns false if the branch has no upstream, or it hasn't been fetched.
+func isPushedToUpstream(ctx context.Context, dEnv *env.DoltEnv, branchRef ref.DoltRef, cm *doltdb.Commit) (bool, error) {
+	upstream, ok := dEnv.RepoState.Branches[branchRef.GetPath()]
+
+	if !ok || upstream.Merge.Ref == nil {
+		return false, nil
+	}
+
+	trackingRef := ref.NewRemoteRef(upstream.Remote, upstream.Merge.Ref.GetPath())
+	hasRef, err := dEnv.DoltDB.HasRef(ctx, trackingRef)
+
+	if err != nil || !hasRef {
+		return false, err
+	}
+
+	cs, err := doltdb.NewCommitSpec("HEAD", trackingRef.String())
+
+	if err != nil {
+		return false, err
+	}
+
+	trackingCm, err := dEnv.DoltDB.Resolve(ctx, cs)
+
+	if err != nil {
+		return false, err
+	}
+
+	ancestor, err := doltdb.GetCommitAncestor(ctx, cm, trackingCm)
+
+	if err != nil || ancestor == nil {
+		return false, err
+	}
+
+	ancestorHash, err := ancestor.HashOf()
+
+	if err != nil {
+		return false, err
+	}
+
+	h, err := cm.HashOf()
+
+	if err != nil {
+		return false, err
+	}
+
+	return ancestorHash == h, nil
+}
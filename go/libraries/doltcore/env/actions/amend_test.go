@@ -0,0 +1,127 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+)
+
+func resolveRef(t *testing.T, dEnv *env.DoltEnv, dRef ref.DoltRef) *doltdb.Commit {
+	cs, err := doltdb.NewCommitSpec("HEAD", dRef.String())
+	require.NoError(t, err)
+	cm, err := dEnv.DoltDB.Resolve(context.Background(), cs)
+	require.NoError(t, err)
+
+	return cm
+}
+
+func commitHash(t *testing.T, cm *doltdb.Commit) string {
+	h, err := cm.HashOf()
+	require.NoError(t, err)
+
+	return h.String()
+}
+
+func tableRowCount(t *testing.T, cm *doltdb.Commit) uint64 {
+	ctx := context.Background()
+	root, err := cm.GetRootValue()
+	require.NoError(t, err)
+	tbl, ok, err := root.GetTable(ctx, cliTable)
+	require.NoError(t, err)
+	require.True(t, ok)
+	rows, err := tbl.GetRowData(ctx)
+	require.NoError(t, err)
+
+	return rows.Len()
+}
+
+func TestAmendCommit(t *testing.T) {
+	ctx := context.Background()
+	dEnv := dtestutils.CreateTestEnv()
+	dtestutils.CreateTestTable(t, dEnv, cliTable, dtestutils.TypedSchema)
+	require.NoError(t, StageAllTables(ctx, dEnv, false))
+	require.NoError(t, CommitStaged(ctx, dEnv, "create table", time.Now(), false))
+
+	headRef := dEnv.RepoState.CWBHeadRef()
+	parent := resolveRef(t, dEnv, headRef)
+	root, err := dEnv.WorkingRoot(ctx)
+	require.NoError(t, err)
+	_, err = dtestutils.AddRowToRoot(dEnv, ctx, root, cliTable, dtestutils.NewTypedRow(uuid.New(), "one", 1, false, nil))
+	require.NoError(t, err)
+	require.NoError(t, StageAllTables(ctx, dEnv, false))
+	require.NoError(t, CommitStaged(ctx, dEnv, "add a rwo", time.Now(), false))
+	original := resolveRef(t, dEnv, headRef)
+
+	// with nothing staged, only the message is changed
+	amended, err := AmendCommit(ctx, dEnv, "add a row", time.Now(), false)
+	require.NoError(t, err)
+	assert.Equal(t, commitHash(t, amended), commitHash(t, resolveRef(t, dEnv, headRef)))
+	meta, err := amended.GetCommitMeta()
+	require.NoError(t, err)
+	assert.Equal(t, "add a row", meta.Description)
+	parents, err := dEnv.DoltDB.ResolveAllParents(ctx, amended)
+	require.NoError(t, err)
+	require.Len(t, parents, 1)
+	assert.Equal(t, commitHash(t, parent), commitHash(t, parents[0]))
+	assert.Equal(t, uint64(1), tableRowCount(t, amended))
+	assert.Equal(t, commitHash(t, original), commitHash(t, resolveRef(t, dEnv, ref.NewBackupRef(headRef))))
+
+	// staged changes are added to the amended commit
+	root, err = dEnv.WorkingRoot(ctx)
+	require.NoError(t, err)
+	_, err = dtestutils.AddRowToRoot(dEnv, ctx, root, cliTable, dtestutils.NewTypedRow(uuid.New(), "two", 2, false, nil))
+	require.NoError(t, err)
+	require.NoError(t, StageAllTables(ctx, dEnv, false))
+	amended, err = AmendCommit(ctx, dEnv, "add two rows", time.Now(), false)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), tableRowCount(t, amended))
+	parents, err = dEnv.DoltDB.ResolveAllParents(ctx, amended)
+	require.NoError(t, err)
+	require.Len(t, parents, 1)
+	assert.Equal(t, commitHash(t, parent), commitHash(t, parents[0]))
+}
+
+func TestAmendPushedCommit(t *testing.T) {
+	ctx := context.Background()
+	dEnv := dtestutils.CreateTestEnv()
+	dtestutils.CreateTestTable(t, dEnv, cliTable, dtestutils.TypedSchema)
+	require.NoError(t, StageAllTables(ctx, dEnv, false))
+	require.NoError(t, CommitStaged(ctx, dEnv, "create table", time.Now(), false))
+
+	headRef := dEnv.RepoState.CWBHeadRef()
+	dEnv.RepoState.Branches[headRef.GetPath()] = env.BranchConfig{Merge: ref.MarshalableRef{Ref: headRef}, Remote: "origin"}
+	require.NoError(t, dEnv.DoltDB.SetHead(ctx, ref.NewRemoteRef("origin", headRef.GetPath()), resolveRef(t, dEnv, headRef)))
+
+	_, err := AmendCommit(ctx, dEnv, "create a table", time.Now(), false)
+	assert.Equal(t, ErrAmendPushed, err)
+
+	_, err = AmendCommit(ctx, dEnv, "create a table", time.Now(), true)
+	require.NoError(t, err)
+
+	// the amended commit hasn't been pushed
+	_, err = AmendCommit(ctx, dEnv, "create the table", time.Now(), false)
+	require.NoError(t, err)
+}
@@ -23,7 +23,9 @@ import (
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/config"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/set"
 	"github.com/liquidata-inc/dolt/go/store/hash"
 )
 
@@ -52,13 +54,33 @@ func GetNameAndEmail(cfg config.ReadableConfig) (string, string, error) {
 	return name, email, nil
 }
 
+// maxCommitRetries is the number of times CommitStaged will try to combine the staged changes with a branch head that
+// was moved by another writer before giving up.
+const maxCommitRetries = 5
+
 func CommitStaged(ctx context.Context, dEnv *env.DoltEnv, msg string, date time.Time, allowEmpty bool) error {
-	stagedTbls, notStagedTbls, err := diff.GetTableDiffs(ctx, dEnv)
+	return commitStaged(ctx, dEnv, msg, date, allowEmpty, nil)
+}
 
+// commitStaged implements CommitStaged. If |beforeHeadUpdate| isn't nil, it's called after the roots being committed
+// have been saved and before each attempt to update the branch head, which lets tests commit to the branch from
+// another writer during that window.
+func commitStaged(ctx context.Context, dEnv *env.DoltEnv, msg string, date time.Time, allowEmpty bool, beforeHeadUpdate func()) error {
 	if msg == "" {
 		return ErrEmptyCommitMessage
 	}
 
+	// The head commit is read before the staged root so that any commit made by another writer after this point is
+	// detected when the branch head is updated.
+	headRef := dEnv.RepoState.CWBHeadRef()
+	headCommit, err := resolveHeadCommit(ctx, dEnv, headRef)
+
+	if err != nil {
+		return err
+	}
+
+	stagedTbls, notStagedTbls, err := diff.GetTableDiffs(ctx, dEnv)
+
 	if err != nil {
 		return err
 	}
@@ -79,7 +101,7 @@ func CommitStaged(ctx context.Context, dEnv *env.DoltEnv, msg string, date time.
 		return err
 	}
 
-	var mergeCmSpec []*doltdb.CommitSpec
+	var mergeParents []*doltdb.Commit
 	if dEnv.IsMergeActive() {
 		spec, err := doltdb.NewCommitSpec(dEnv.RepoState.Merge.Commit, dEnv.RepoState.Merge.Head.Ref.String())
 
@@ -87,7 +109,13 @@ func CommitStaged(ctx context.Context, dEnv *env.DoltEnv, msg string, date time.
 			panic("Corrupted repostate. Active merge state is not valid.")
 		}
 
-		mergeCmSpec = []*doltdb.CommitSpec{spec}
+		mergeCm, err := dEnv.DoltDB.Resolve(ctx, spec)
+
+		if err != nil {
+			return err
+		}
+
+		mergeParents = []*doltdb.Commit{mergeCm}
 	}
 
 	srt, err := dEnv.StagedRoot(ctx)
@@ -96,48 +124,168 @@ func CommitStaged(ctx context.Context, dEnv *env.DoltEnv, msg string, date time.
 		return err
 	}
 
-	srt, err = srt.UpdateSuperSchemasFromOther(ctx, stagedTbls.Tables, srt)
+	wrt, err := dEnv.WorkingRoot(ctx)
 
 	if err != nil {
 		return err
 	}
 
-	h, err := dEnv.UpdateStagedRoot(ctx, srt)
+	meta, noCommitMsgErr := doltdb.NewCommitMetaWithUserTS(name, email, msg, date)
+	if noCommitMsgErr != nil {
+		return ErrEmptyCommitMessage
+	}
+
+	for i := 0; ; i++ {
+		h, err := updateSuperSchemasAndSaveRoots(ctx, dEnv, stagedTbls.Tables, srt, wrt)
+
+		if err != nil {
+			return err
+		}
+
+		if beforeHeadUpdate != nil {
+			beforeHeadUpdate()
+		}
+
+		_, err = dEnv.DoltDB.CommitWithExpectedHead(ctx, h, headRef, headCommit, mergeParents, meta)
+
+		if err == nil {
+			break
+		} else if err != doltdb.ErrHeadMoved {
+			return err
+		} else if i == maxCommitRetries {
+			return ConcurrentModification{}
+		}
+
+		newHead, err := resolveHeadCommit(ctx, dEnv, headRef)
+
+		if err != nil {
+			return err
+		}
+
+		srt, wrt, err = rebaseRootsOntoHead(ctx, headCommit, newHead, srt, wrt)
+
+		if err != nil {
+			return err
+		}
+
+		headCommit = newHead
+	}
+
+	dEnv.RepoState.ClearMerge(dEnv.FS)
+
+	return nil
+}
+
+func resolveHeadCommit(ctx context.Context, dEnv *env.DoltEnv, headRef ref.DoltRef) (*doltdb.Commit, error) {
+	cs, err := doltdb.NewCommitSpec("HEAD", headRef.String())
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	wrt, err := dEnv.WorkingRoot(ctx)
+	return dEnv.DoltDB.Resolve(ctx, cs)
+}
+
+func updateSuperSchemasAndSaveRoots(ctx context.Context, dEnv *env.DoltEnv, stagedTbls []string, srt, wrt *doltdb.RootValue) (hash.Hash, error) {
+	srt, err := srt.UpdateSuperSchemasFromOther(ctx, stagedTbls, srt)
 
 	if err != nil {
-		return err
+		return hash.Hash{}, err
 	}
 
-	wrt, err = wrt.UpdateSuperSchemasFromOther(ctx, stagedTbls.Tables, srt)
+	h, err := dEnv.UpdateStagedRoot(ctx, srt)
 
 	if err != nil {
-		return err
+		return hash.Hash{}, err
+	}
+
+	wrt, err = wrt.UpdateSuperSchemasFromOther(ctx, stagedTbls, srt)
+
+	if err != nil {
+		return hash.Hash{}, err
 	}
 
 	err = dEnv.UpdateWorkingRoot(ctx, wrt)
 
 	if err != nil {
-		return err
+		return hash.Hash{}, err
 	}
 
-	meta, noCommitMsgErr := doltdb.NewCommitMetaWithUserTS(name, email, msg, date)
-	if noCommitMsgErr != nil {
-		return ErrEmptyCommitMessage
+	return h, nil
+}
+
+// rebaseRootsOntoHead moves the changes in the staged and working roots which were made relative to oldHead onto
+// newHead. This is only possible when none of the tables changed between oldHead and newHead have also been changed in
+// the staged or working roots, otherwise a ConcurrentModification error is returned.
+func rebaseRootsOntoHead(ctx context.Context, oldHead, newHead *doltdb.Commit, srt, wrt *doltdb.RootValue) (*doltdb.RootValue, *doltdb.RootValue, error) {
+	oldHeadRoot, err := oldHead.GetRootValue()
+
+	if err != nil {
+		return nil, nil, err
 	}
 
-	_, err = dEnv.DoltDB.CommitWithParentSpecs(ctx, h, dEnv.RepoState.CWBHeadRef(), mergeCmSpec, meta)
+	newHeadRoot, err := newHead.GetRootValue()
 
-	if err == nil {
-		dEnv.RepoState.ClearMerge(dEnv.FS)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return err
+	return rebaseRoots(ctx, oldHeadRoot, newHeadRoot, srt, wrt)
+}
+
+// rebaseRoots moves the changes in the staged and working roots which were made relative to oldHeadRoot onto
+// newHeadRoot, like rebaseRootsOntoHead.
+func rebaseRoots(ctx context.Context, oldHeadRoot, newHeadRoot, srt, wrt *doltdb.RootValue) (*doltdb.RootValue, *doltdb.RootValue, error) {
+	headChanges, err := changedTables(ctx, oldHeadRoot, newHeadRoot)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rebased []*doltdb.RootValue
+	for _, root := range []*doltdb.RootValue{srt, wrt} {
+		localChanges, err := changedTables(ctx, oldHeadRoot, root)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var overlapping []string
+		for _, tbl := range localChanges.AsSlice() {
+			if headChanges.Contains(tbl) {
+				overlapping = append(overlapping, tbl)
+			}
+		}
+
+		if len(overlapping) > 0 {
+			sort.Strings(overlapping)
+			return nil, nil, ConcurrentModification{overlapping}
+		}
+
+		root, err = newHeadRoot.UpdateTablesFromOther(ctx, localChanges.AsSlice(), root)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		rebased = append(rebased, root)
+	}
+
+	return rebased[0], rebased[1], nil
+}
+
+func changedTables(ctx context.Context, from, to *doltdb.RootValue) (*set.StrSet, error) {
+	added, modified, removed, err := to.TableDiff(ctx, from)
+
+	if err != nil {
+		return nil, err
+	}
+
+	changed := set.NewStrSet(added)
+	changed.Add(modified...)
+	changed.Add(removed...)
+
+	return changed, nil
 }
 
 // TimeSortedCommits returns a reverse-chronological (latest-first) list of the most recent `n` ancestors of `commit`.
@@ -0,0 +1,232 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const (
+	cliTable    = "cli_writes"
+	serverTable = "server_writes"
+	numWrites   = 20
+)
+
+var serverSch = dtestutils.MustSchema(
+	schema.NewColumn("id", 100, types.UUIDKind, true, schema.NotNullConstraint{}),
+	schema.NewColumn("name", 101, types.StringKind, false),
+)
+
+func TestCommitStagedWithConcurrentWriter(t *testing.T) {
+	ctx := context.Background()
+	dEnv := dtestutils.CreateTestEnv()
+	dtestutils.CreateTestTable(t, dEnv, cliTable, dtestutils.TypedSchema)
+	dtestutils.CreateTestTable(t, dEnv, serverTable, serverSch)
+	require.NoError(t, StageAllTables(ctx, dEnv, false))
+	require.NoError(t, CommitStaged(ctx, dEnv, "create tables", time.Now(), false))
+
+	headRef := dEnv.RepoState.CWBHeadRef()
+
+	// before the first attempt of every cli commit to update the branch head the background writer commits first.
+	writeReq := make(chan struct{})
+	writeDone := make(chan error)
+	beforeHeadUpdate := func() {
+		writeReq <- struct{}{}
+		<-writeDone
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	numBgWrites := 0
+	go func() {
+		defer wg.Done()
+		numReqs := 0
+		for range writeReq {
+			numReqs++
+			if numReqs%2 == 0 {
+				writeDone <- nil
+				continue
+			}
+
+			numBgWrites++
+			writeDone <- writeAndCommitDirectly(ctx, dEnv, headRef, "server "+strconv.Itoa(numBgWrites))
+		}
+	}()
+
+	for i := 0; i < numWrites; i++ {
+		root, err := dEnv.WorkingRoot(ctx)
+		require.NoError(t, err)
+		_, err = dtestutils.AddRowToRoot(dEnv, ctx, root, cliTable, dtestutils.NewTypedRow(uuid.New(), "cli "+strconv.Itoa(i), uint(i), false, nil))
+		require.NoError(t, err)
+		require.NoError(t, StageTables(ctx, dEnv, []string{cliTable}, false))
+		require.NoError(t, commitStaged(ctx, dEnv, "cli write "+strconv.Itoa(i), time.Now(), false, beforeHeadUpdate))
+	}
+
+	close(writeReq)
+	wg.Wait()
+
+	headRoot, err := dEnv.HeadRoot(ctx)
+	require.NoError(t, err)
+
+	expectedRows := map[string]int{cliTable: numWrites, serverTable: numBgWrites}
+	for tblName, expected := range expectedRows {
+		tbl, ok, err := headRoot.GetTable(ctx, tblName)
+		require.NoError(t, err)
+		require.True(t, ok)
+		rows, err := tbl.GetRowData(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(expected), rows.Len(), "lost updates in table %s", tblName)
+	}
+	assert.Equal(t, numWrites, numBgWrites)
+}
+
+func TestCommitStagedConcurrentModificationOfSameTable(t *testing.T) {
+	ctx := context.Background()
+	dEnv := dtestutils.CreateTestEnv()
+	dtestutils.CreateTestTable(t, dEnv, cliTable, dtestutils.TypedSchema)
+	require.NoError(t, StageAllTables(ctx, dEnv, false))
+	require.NoError(t, CommitStaged(ctx, dEnv, "create table", time.Now(), false))
+
+	root, err := dEnv.WorkingRoot(ctx)
+	require.NoError(t, err)
+	_, err = dtestutils.AddRowToRoot(dEnv, ctx, root, cliTable, dtestutils.NewTypedRow(uuid.New(), "cli", 1, false, nil))
+	require.NoError(t, err)
+	require.NoError(t, StageTables(ctx, dEnv, []string{cliTable}, false))
+
+	// another writer commits a change to the same table before the staged changes are committed
+	headRef := dEnv.RepoState.CWBHeadRef()
+	head, err := resolveHeadCommit(ctx, dEnv, headRef)
+	require.NoError(t, err)
+	headRoot, err := head.GetRootValue()
+	require.NoError(t, err)
+	otherRoot, err := addRowToTable(ctx, headRoot, cliTable, "other")
+	require.NoError(t, err)
+	h, err := dEnv.DoltDB.WriteRootValue(ctx, otherRoot)
+	require.NoError(t, err)
+	meta, err := doltdb.NewCommitMeta("other", "other@fake.horse", "other write")
+	require.NoError(t, err)
+	_, err = dEnv.DoltDB.CommitWithParentCommits(ctx, h, headRef, nil, meta)
+	require.NoError(t, err)
+
+	// simulate the staged root having been read before the other writer committed
+	_, err = dEnv.DoltDB.CommitWithExpectedHead(ctx, h, headRef, head, nil, meta)
+	assert.Equal(t, doltdb.ErrHeadMoved, err)
+
+	newHead, err := resolveHeadCommit(ctx, dEnv, headRef)
+	require.NoError(t, err)
+	srt, err := dEnv.StagedRoot(ctx)
+	require.NoError(t, err)
+	wrt, err := dEnv.WorkingRoot(ctx)
+	require.NoError(t, err)
+	_, _, err = rebaseRootsOntoHead(ctx, head, newHead, srt, wrt)
+	require.True(t, IsConcurrentModification(err))
+	assert.Equal(t, []string{cliTable}, ConcurrentModificationTables(err))
+}
+
+// writeAndCommitDirectly simulates another process, such as a sql-server, committing to the branch without going
+// through the repo state of dEnv.
+func writeAndCommitDirectly(ctx context.Context, dEnv *env.DoltEnv, headRef ref.DoltRef, name string) error {
+	head, err := resolveHeadCommit(ctx, dEnv, headRef)
+
+	if err != nil {
+		return err
+	}
+
+	root, err := head.GetRootValue()
+
+	if err != nil {
+		return err
+	}
+
+	root, err = addRowToTable(ctx, root, serverTable, name)
+
+	if err != nil {
+		return err
+	}
+
+	h, err := dEnv.DoltDB.WriteRootValue(ctx, root)
+
+	if err != nil {
+		return err
+	}
+
+	meta, err := doltdb.NewCommitMeta("server", "server@fake.horse", name)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = dEnv.DoltDB.CommitWithExpectedHead(ctx, h, headRef, head, nil, meta)
+
+	return err
+}
+
+func addRowToTable(ctx context.Context, root *doltdb.RootValue, tblName, name string) (*doltdb.RootValue, error) {
+	tbl, _, err := root.GetTable(ctx, tblName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := tbl.GetRowData(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sch, err := tbl.GetSchema(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pkTag := sch.GetPKCols().GetColumns()[0].Tag
+	nameCol, _ := sch.GetAllCols().GetByName("name")
+	r, err := row.New(root.VRW().Format(), sch, row.TaggedValues{pkTag: types.UUID(uuid.New()), nameCol.Tag: types.String(name)})
+
+	if err != nil {
+		return nil, err
+	}
+
+	m, err = m.Edit().Set(r.NomsMapKey(sch), r.NomsMapValue(sch)).Map(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tbl, err = tbl.UpdateRows(ctx, m)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return root.PutTable(ctx, tblName, tbl)
+}
@@ -0,0 +1,206 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dolttestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/merge"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+var otherPeopleSch = dtestutils.MustSchema(
+	schema.NewColumn("id", 200, types.UUIDKind, true, schema.NotNullConstraint{}),
+	schema.NewColumn("name", 201, types.StringKind, false),
+)
+
+func person(id int, name string) []interface{} {
+	return []interface{}{types.UUID(dtestutils.UUIDS[id]), name}
+}
+
+func headCommit(t *testing.T, dEnv *env.DoltEnv) *doltdb.Commit {
+	cm, err := dEnv.DoltDB.Resolve(context.Background(), dEnv.RepoState.CWBHeadSpec())
+	require.NoError(t, err)
+
+	return cm
+}
+
+// pullMaster fetches the master branch of |remoteDB| into |dEnv| and fast forwards its master branch and working set
+// to it, like a pull which fast forwards.
+func pullMaster(t *testing.T, dEnv *env.DoltEnv, remoteDB *doltdb.DoltDB) {
+	ctx := context.Background()
+	master := ref.NewBranchRef("master")
+	spec, err := doltdb.NewCommitSpec("master", "")
+	require.NoError(t, err)
+	cm, err := remoteDB.Resolve(ctx, spec)
+	require.NoError(t, err)
+	require.NoError(t, Fetch(ctx, dEnv, master, remoteDB, dEnv.DoltDB, cm, nil, nil))
+	require.NoError(t, dEnv.DoltDB.FastForward(ctx, master, cm))
+
+	root, err := cm.GetRootValue()
+	require.NoError(t, err)
+	_, err = dEnv.UpdateStagedRoot(ctx, root)
+	require.NoError(t, err)
+	require.NoError(t, dEnv.UpdateWorkingRoot(ctx, root))
+}
+
+func pushMaster(t *testing.T, dEnv *env.DoltEnv, remoteDB *doltdb.DoltDB) {
+	master := ref.NewBranchRef("master")
+	err := Push(context.Background(), dEnv, ref.ForceUpdate, master, ref.NewRemoteRef("origin", "master"), dEnv.DoltDB, remoteDB, headCommit(t, dEnv), nil, nil)
+	require.NoError(t, err)
+}
+
+func tablesInConflict(t *testing.T, root *doltdb.RootValue) []string {
+	inConflict, err := root.TablesInConflict(context.Background())
+	require.NoError(t, err)
+
+	return inConflict
+}
+
+func TestCommittedConflictsRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	steps := []dolttestutils.Step{
+		dolttestutils.PutTable{Name: "people", Schema: serverSch},
+		dolttestutils.Commit{Name: "c1"},
+	}
+
+	ours, err := dolttestutils.Build(ctx, steps...)
+	require.NoError(t, err)
+	theirs, err := dolttestutils.Build(ctx, steps...)
+	require.NoError(t, err)
+	remote, err := dolttestutils.Build(ctx, steps...)
+	require.NoError(t, err)
+
+	// make conflicting changes to the same row on two branches, and merge them
+	dEnv := ours.DEnv
+	require.NoError(t, dEnv.DoltDB.NewBranchAtCommit(ctx, ref.NewBranchRef("other"), headCommit(t, dEnv)))
+	require.NoError(t, dolttestutils.UpsertRows{Table: "people", Rows: dolttestutils.Rows{person(0, "master")}}.Apply(ctx, ours))
+	require.NoError(t, dolttestutils.Commit{}.Apply(ctx, ours))
+	require.NoError(t, dolttestutils.Checkout{Branch: "other"}.Apply(ctx, ours))
+	require.NoError(t, dolttestutils.UpsertRows{Table: "people", Rows: dolttestutils.Rows{person(0, "other")}}.Apply(ctx, ours))
+	require.NoError(t, dolttestutils.Commit{}.Apply(ctx, ours))
+	require.NoError(t, dolttestutils.Checkout{Branch: "master"}.Apply(ctx, ours))
+	require.NoError(t, dolttestutils.Merge{Branch: "other", AllowConflicts: true}.Apply(ctx, ours))
+
+	// the tables in conflict can't be staged without their conflicts
+	assert.True(t, IsTblInConflict(StageAllTables(ctx, dEnv, false)))
+
+	staged, err := StageTablesInConflict(ctx, dEnv)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"people"}, staged)
+	require.NoError(t, CommitStaged(ctx, dEnv, "commit the conflicts", time.Now(), false))
+	assert.False(t, dEnv.IsMergeActive())
+
+	head, err := headCommit(t, dEnv).GetRootValue()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"people"}, tablesInConflict(t, head))
+
+	// the conflicts are pushed and pulled with the commit
+	pushMaster(t, dEnv, remote.DEnv.DoltDB)
+	pullMaster(t, theirs.DEnv, remote.DEnv.DoltDB)
+
+	working, err := theirs.DEnv.WorkingRoot(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"people"}, tablesInConflict(t, working))
+
+	tbl, _, err := working.GetTable(ctx, "people")
+	require.NoError(t, err)
+	n, err := tbl.NumRowsInConflict(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), n)
+
+	// resolving them on the other side and pushing the resolution clears them for everyone
+	tbl, err = merge.ResolveTable(ctx, working.VRW(), tbl, merge.Theirs)
+	require.NoError(t, err)
+	working, err = working.PutTable(ctx, "people", tbl)
+	require.NoError(t, err)
+	require.NoError(t, theirs.DEnv.UpdateWorkingRoot(ctx, working))
+	require.NoError(t, StageAllTables(ctx, theirs.DEnv, false))
+	require.NoError(t, CommitStaged(ctx, theirs.DEnv, "resolve the conflicts", time.Now(), false))
+	pushMaster(t, theirs.DEnv, remote.DEnv.DoltDB)
+
+	pullMaster(t, dEnv, remote.DEnv.DoltDB)
+	head, err = headCommit(t, dEnv).GetRootValue()
+	require.NoError(t, err)
+	assert.Empty(t, tablesInConflict(t, head))
+
+	tbl, _, err = head.GetTable(ctx, "people")
+	require.NoError(t, err)
+	rowData, err := tbl.GetRowData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), rowData.Len())
+}
+
+func TestMergeCommittedConflicts(t *testing.T) {
+	ctx := context.Background()
+	f, err := dolttestutils.Build(ctx,
+		dolttestutils.PutTable{Name: "people", Schema: serverSch},
+		dolttestutils.PutTable{Name: "other_people", Schema: otherPeopleSch},
+		dolttestutils.Commit{Name: "c1"},
+		dolttestutils.Branch{Name: "other"},
+		dolttestutils.Branch{Name: "unrelated"},
+		dolttestutils.Branch{Name: "related"},
+		dolttestutils.UpsertRows{Table: "people", Rows: dolttestutils.Rows{person(0, "master")}},
+		dolttestutils.Commit{Name: "c2"},
+		dolttestutils.Checkout{Branch: "other"},
+		dolttestutils.UpsertRows{Table: "people", Rows: dolttestutils.Rows{person(0, "other")}},
+		dolttestutils.Commit{Name: "o1"},
+		dolttestutils.Checkout{Branch: "master"},
+		dolttestutils.Merge{Branch: "other", AllowConflicts: true},
+		dolttestutils.Commit{Name: "conflicts", AllowConflicts: true},
+		dolttestutils.Checkout{Branch: "unrelated"},
+		dolttestutils.UpsertRows{Table: "other_people", Rows: dolttestutils.Rows{person(1, "unrelated")}},
+		dolttestutils.Commit{Name: "u1"},
+		dolttestutils.Checkout{Branch: "related"},
+		dolttestutils.UpsertRows{Table: "people", Rows: dolttestutils.Rows{person(1, "related")}},
+		dolttestutils.Commit{Name: "r1"},
+		dolttestutils.Checkout{Branch: "master"},
+	)
+	require.NoError(t, err)
+
+	conflicts, err := f.Commit(ctx, "conflicts")
+	require.NoError(t, err)
+
+	// the conflicts of a table which only one side changed are carried forward
+	unrelated, err := f.Commit(ctx, "u1")
+	require.NoError(t, err)
+	merged, _, err := merge.MergeCommits(ctx, f.DEnv.DoltDB, conflicts, unrelated, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"people"}, tablesInConflict(t, merged))
+
+	merged, _, err = merge.MergeCommits(ctx, f.DEnv.DoltDB, unrelated, conflicts, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"people"}, tablesInConflict(t, merged))
+
+	// a table changed on both sides isn't merged while either side has conflicts
+	related, err := f.Commit(ctx, "r1")
+	require.NoError(t, err)
+	_, _, err = merge.MergeCommits(ctx, f.DEnv.DoltDB, conflicts, related, nil)
+	assert.True(t, errors.Is(err, merge.ErrTableHasConflicts))
+	_, _, err = merge.MergeCommits(ctx, f.DEnv.DoltDB, related, conflicts, nil)
+	assert.True(t, errors.Is(err, merge.ErrTableHasConflicts))
+}
@@ -17,6 +17,7 @@ package commitwalk
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/store/hash"
@@ -142,14 +143,17 @@ func newQueue(ddb *doltdb.DoltDB) *q {
 // GetDotDotRevisions returns the commits reachable from commit at hash
 // `includedHead` that are not reachable from hash `excludedHead`.
 // `includedHead` and `excludedHead` must be commits in `ddb`. Returns up
-// to `num` commits, in reverse topological order starting at `includedHead`,
+// to `num` commits, or all of them if `num` is 0, in reverse topological order starting at `includedHead`,
 // with tie breaking based on the height of commit graph between
 // concurrent commits --- higher commits appear first. Remaining
 // ties are broken by timestamp; newer commits appear first.
 //
 // Roughly mimics `git log master..feature`.
 func GetDotDotRevisions(ctx context.Context, ddb *doltdb.DoltDB, includedHead hash.Hash, excludedHead hash.Hash, num int) ([]*doltdb.Commit, error) {
-	commitList := make([]*doltdb.Commit, 0, num)
+	commitList := make([]*doltdb.Commit, 0)
+	if num > 0 {
+		commitList = make([]*doltdb.Commit, 0, num)
+	}
 	q := newQueue(ddb)
 	if err := q.SetInvisible(ctx, excludedHead); err != nil {
 		return nil, err
@@ -196,21 +200,73 @@ func GetTopologicalOrderCommits(ctx context.Context, ddb *doltdb.DoltDB, startCo
 // GetTopologicalOrderCommitIterator returns an iterator for commits generated with the same semantics as
 // GetTopologicalOrderCommits
 func GetTopologicalOrderIterator(ctx context.Context, ddb *doltdb.DoltDB, startCommitHash hash.Hash) (doltdb.CommitItr, error) {
-	return newCommiterator(ctx, ddb, startCommitHash)
+	return newCommiterator(ctx, ddb, startCommitHash, AllParents)
+}
+
+// ParentsFunc returns the parents of a commit which a walk of the commit graph continues on to.
+type ParentsFunc func(ctx context.Context, cm *doltdb.Commit) ([]hash.Hash, error)
+
+// AllParents is a ParentsFunc which follows every parent of a commit.
+func AllParents(ctx context.Context, cm *doltdb.Commit) ([]hash.Hash, error) {
+	return cm.ParentHashes(ctx)
+}
+
+// MadeSince returns a ParentsFunc which follows the parents |parents| returns for commits made at or after |since|,
+// and stops the walk at commits made before it. Commits are stamped with the time they're made, so the ancestors of a
+// commit made before |since| were made before it too, unless the clocks of the machines which made them disagree.
+// Roughly mimics `git log --since`.
+func MadeSince(parents ParentsFunc, since time.Time) ParentsFunc {
+	return func(ctx context.Context, cm *doltdb.Commit) ([]hash.Hash, error) {
+		meta, err := cm.GetCommitMeta()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if meta.Time().Before(since) {
+			return nil, nil
+		}
+
+		return parents(ctx, cm)
+	}
+}
+
+// FirstParents is a ParentsFunc which only follows the first parent of merge commits, so that a walk stays on the
+// mainline of a branch and skips the commits of the branches merged into it. Roughly mimics `git log --first-parent`.
+func FirstParents(ctx context.Context, cm *doltdb.Commit) ([]hash.Hash, error) {
+	parents, err := cm.ParentHashes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(parents) > 1 {
+		parents = parents[:1]
+	}
+
+	return parents, nil
+}
+
+// GetTopologicalOrderIteratorWithParents returns an iterator for the commits reachable from the commit at hash
+// `startCommitHash` through the parents which `parents` returns for each commit, in the same order as
+// GetTopologicalOrderCommits.
+func GetTopologicalOrderIteratorWithParents(ctx context.Context, ddb *doltdb.DoltDB, startCommitHash hash.Hash, parents ParentsFunc) (doltdb.CommitItr, error) {
+	return newCommiterator(ctx, ddb, startCommitHash, parents)
 }
 
 type commiterator struct {
 	ddb             *doltdb.DoltDB
 	startCommitHash hash.Hash
+	parents         ParentsFunc
 	q               *q
 }
 
 var _ doltdb.CommitItr = (*commiterator)(nil)
 
-func newCommiterator(ctx context.Context, ddb *doltdb.DoltDB, startCommitHash hash.Hash) (*commiterator, error) {
+func newCommiterator(ctx context.Context, ddb *doltdb.DoltDB, startCommitHash hash.Hash, parents ParentsFunc) (*commiterator, error) {
 	itr := &commiterator{
 		ddb:             ddb,
 		startCommitHash: startCommitHash,
+		parents:         parents,
 	}
 
 	err := itr.Reset(ctx)
@@ -225,7 +281,7 @@ func newCommiterator(ctx context.Context, ddb *doltdb.DoltDB, startCommitHash ha
 func (i *commiterator) Next(ctx context.Context) (hash.Hash, *doltdb.Commit, error) {
 	if i.q.NumVisiblePending() > 0 {
 		nextC := i.q.PopPending()
-		parents, err := nextC.commit.ParentHashes(ctx)
+		parents, err := i.parents(ctx, nextC.commit)
 		if err != nil {
 			return hash.Hash{}, nil, err
 		}
@@ -260,6 +316,11 @@ func GetTopNTopoOrderedCommits(ctx context.Context, ddb *doltdb.DoltDB, startCom
 		return nil, err
 	}
 
+	return GetTopNCommits(ctx, itr, n)
+}
+
+// GetTopNCommits returns the first N commits (If N < 0 then all commits) of the iterator given.
+func GetTopNCommits(ctx context.Context, itr doltdb.CommitItr, n int) ([]*doltdb.Commit, error) {
 	var commitList []*doltdb.Commit
 	for n < 0 || len(commitList) < n {
 		_, commit, err := itr.Next(ctx)
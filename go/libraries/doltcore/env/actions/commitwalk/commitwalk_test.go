@@ -16,6 +16,7 @@ package commitwalk
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -24,6 +25,7 @@ import (
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
 	"github.com/liquidata-inc/dolt/go/store/hash"
 	"github.com/liquidata-inc/dolt/go/store/types"
@@ -127,6 +129,10 @@ func TestGetDotDotRevisions(t *testing.T) {
 	assert.Equal(t, featureCommits[6], res[1])
 	assert.Equal(t, featureCommits[5], res[2])
 
+	res, err = GetDotDotRevisions(context.Background(), env.DoltDB, featureHash, masterHash, 0)
+	require.NoError(t, err)
+	assert.Len(t, res, 7)
+
 	res, err = GetDotDotRevisions(context.Background(), env.DoltDB, featurePreMergeHash, masterHash, 3)
 	require.NoError(t, err)
 	assert.Len(t, res, 3)
@@ -155,3 +161,131 @@ func mustGetHash(t *testing.T, c *doltdb.Commit) hash.Hash {
 	require.NoError(t, err)
 	return h
 }
+
+// tableHistory is a history in which table b only changed on the side branches of merges, and table a only on master:
+//
+//	master: C0--M1---M2--M3--M4
+//	         \      /       /
+//	side:     S1--S2    T1--'
+//
+// T1 forks from M2 and changes b, but M4 keeps b from M3.
+type tableHistory struct {
+	ddb                        *doltdb.DoltDB
+	c0, s1, s2, m1, m2, t1, m3 *doltdb.Commit
+	m4                         *doltdb.Commit
+}
+
+func createTableHistory(t *testing.T) *tableHistory {
+	ctx := context.Background()
+	env := createUninitializedEnv()
+	err := env.InitRepo(ctx, types.Format_LD_1, "Bill Billerson", "bill@billerson.com")
+	require.NoError(t, err)
+
+	cs, err := doltdb.NewCommitSpec("HEAD", "master")
+	require.NoError(t, err)
+	init, err := env.DoltDB.Resolve(ctx, cs)
+	require.NoError(t, err)
+	root, err := init.GetRootValue()
+	require.NoError(t, err)
+
+	th := &tableHistory{ddb: env.DoltDB}
+	th.c0 = mustCommitTables(t, env.DoltDB, root, 0, 0, "C0", init)
+	th.s1 = mustCommitTables(t, env.DoltDB, root, 0, 1, "S1", th.c0)
+	th.s2 = mustCommitTables(t, env.DoltDB, root, 0, 2, "S2", th.s1)
+	th.m1 = mustCommitTables(t, env.DoltDB, root, 1, 0, "M1", th.c0)
+	th.m2 = mustCommitTables(t, env.DoltDB, root, 1, 2, "M2", th.m1, th.s2)
+	th.t1 = mustCommitTables(t, env.DoltDB, root, 1, 3, "T1", th.m2)
+	th.m3 = mustCommitTables(t, env.DoltDB, root, 2, 2, "M3", th.m2)
+	th.m4 = mustCommitTables(t, env.DoltDB, root, 2, 2, "M4", th.m3, th.t1)
+	return th
+}
+
+// mustCommitTables commits |root| with the tables a and b at the versions given, each version of a table having a
+// different schema.
+func mustCommitTables(t *testing.T, ddb *doltdb.DoltDB, root *doltdb.RootValue, aVersion, bVersion int, desc string, parents ...*doltdb.Commit) *doltdb.Commit {
+	ctx := context.Background()
+	for i, tbl := range []struct {
+		name    string
+		version int
+	}{{"a", aVersion}, {"b", bVersion}} {
+		colColl, err := schema.NewColCollection(schema.NewColumn(fmt.Sprintf("v%d", tbl.version), uint64(i), types.IntKind, true))
+		require.NoError(t, err)
+		root, err = root.CreateEmptyTable(ctx, tbl.name, schema.SchemaFromCols(colColl))
+		require.NoError(t, err)
+	}
+
+	rvh, err := ddb.WriteRootValue(ctx, root)
+	require.NoError(t, err)
+	meta, err := doltdb.NewCommitMeta("Bill Billerson", "bill@billerson.com", desc)
+	require.NoError(t, err)
+	cm, err := ddb.CommitDanglingWithParentCommits(ctx, rvh, parents, meta)
+	require.NoError(t, err)
+	return cm
+}
+
+func mustIterDescriptions(t *testing.T, itr doltdb.CommitItr) []string {
+	commits, err := GetTopNCommits(context.Background(), itr, -1)
+	require.NoError(t, err)
+
+	var descs []string
+	for _, cm := range commits {
+		meta, err := cm.GetCommitMeta()
+		require.NoError(t, err)
+		descs = append(descs, meta.Description)
+	}
+
+	return descs
+}
+
+func TestFirstParents(t *testing.T) {
+	th := createTableHistory(t)
+
+	itr, err := GetTopologicalOrderIteratorWithParents(context.Background(), th.ddb, mustGetHash(t, th.m4), FirstParents)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"M4", "M3", "M2", "M1", "C0", "Initialize data repository"}, mustIterDescriptions(t, itr))
+
+	itr, err = GetTopologicalOrderIteratorWithParents(context.Background(), th.ddb, mustGetHash(t, th.t1), FirstParents)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"T1", "M2", "M1", "C0", "Initialize data repository"}, mustIterDescriptions(t, itr))
+
+	// the full walk still reaches every commit
+	itr, err = GetTopologicalOrderIteratorWithParents(context.Background(), th.ddb, mustGetHash(t, th.m4), AllParents)
+	require.NoError(t, err)
+	assert.Len(t, mustIterDescriptions(t, itr), 9)
+}
+
+func TestGetTableHistoryIterator(t *testing.T) {
+	th := createTableHistory(t)
+	m4 := mustGetHash(t, th.m4)
+
+	tests := []struct {
+		name        string
+		tables      []string
+		firstParent bool
+		start       hash.Hash
+		expected    []string
+	}{
+		// M4 and M2 took a from their first parents, so neither T1 nor the side branch is walked
+		{"a", []string{"a"}, false, m4, []string{"M3", "M1", "C0"}},
+		// M4 took b from M3, so T1 is skipped. M2 took b from S2, its second parent, so the side branch is walked,
+		// but M2 itself didn't change b
+		{"b", []string{"b"}, false, m4, []string{"S2", "S1", "C0"}},
+		{"b from T1", []string{"b"}, false, mustGetHash(t, th.t1), []string{"T1", "S2", "S1", "C0"}},
+		{"b first parent", []string{"b"}, true, m4, []string{"C0"}},
+		// M2 brought together a from M1 and b from S2, so it differs from both of its parents
+		{"a and b", []string{"a", "b"}, false, m4, []string{"M3", "M2", "S2", "M1", "S1", "C0"}},
+		{"a and b first parent", []string{"a", "b"}, true, m4, []string{"M3", "M2", "M1", "C0"}},
+		{"missing table", []string{"c"}, false, m4, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			itr, err := GetTableHistoryIterator(context.Background(), th.ddb, test.start, test.tables, test.firstParent)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, mustIterDescriptions(t, itr))
+
+			require.NoError(t, itr.Reset(context.Background()))
+			assert.Equal(t, test.expected, mustIterDescriptions(t, itr))
+		})
+	}
+}
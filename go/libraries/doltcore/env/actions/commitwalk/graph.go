@@ -0,0 +1,164 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commitwalk
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// CommitGraphNode is a single commit of a CommitGraph. Parents holds the hashes of all of the commit's parents, even
+// those which are not nodes of the graph because they are beyond the depth it was walked to.
+type CommitGraphNode struct {
+	Hash    string   `json:"hash"`
+	Author  string   `json:"author"`
+	Email   string   `json:"email"`
+	Date    string   `json:"date"`
+	Subject string   `json:"subject"`
+	Parents []string `json:"parents"`
+	Refs    []string `json:"refs,omitempty"`
+}
+
+// CommitGraph is a DAG of commits, ordered in reverse topological order. Its JSON encoding is the format used to share
+// commit graphs with other tools.
+type CommitGraph struct {
+	Nodes []*CommitGraphNode `json:"nodes"`
+}
+
+// GetCommitGraph returns the graph of the commits reachable from the commits with the hashes |startCommitHashes|,
+// in the same order as GetTopologicalOrderCommits. If |depth| is greater than 0 then only the commits which are fewer
+// than |depth| parent links away from one of the start commits are included, and if |n| is greater than 0 then at most
+// |n| commits are included.
+func GetCommitGraph(ctx context.Context, ddb *doltdb.DoltDB, startCommitHashes []hash.Hash, depth, n int) (*CommitGraph, error) {
+	q := newQueue(ddb)
+	depths := make(map[hash.Hash]int)
+	for _, h := range startCommitHashes {
+		if err := q.AddPendingIfUnseen(ctx, h); err != nil {
+			return nil, err
+		}
+
+		depths[h] = 1
+	}
+
+	// commits are popped in order of decreasing height, so every child of a commit in the graph is popped before it
+	// and its depth can't decrease once it is popped.
+	graph := &CommitGraph{}
+	for q.NumVisiblePending() > 0 && (n <= 0 || len(graph.Nodes) < n) {
+		nextC := q.PopPending()
+		parents, err := nextC.commit.ParentHashes(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		node, err := newCommitGraphNode(nextC.hash, nextC.commit, parents)
+
+		if err != nil {
+			return nil, err
+		}
+
+		graph.Nodes = append(graph.Nodes, node)
+
+		d := depths[nextC.hash]
+		if depth > 0 && d >= depth {
+			continue
+		}
+
+		for _, parentID := range parents {
+			if pd, ok := depths[parentID]; !ok || d+1 < pd {
+				depths[parentID] = d + 1
+			}
+
+			if err := q.AddPendingIfUnseen(ctx, parentID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return graph, nil
+}
+
+func newCommitGraphNode(h hash.Hash, cm *doltdb.Commit, parents []hash.Hash) (*CommitGraphNode, error) {
+	meta, err := cm.GetCommitMeta()
+
+	if err != nil {
+		return nil, err
+	}
+
+	parentStrs := make([]string, len(parents))
+	for i, p := range parents {
+		parentStrs[i] = p.String()
+	}
+
+	return &CommitGraphNode{
+		Hash:    h.String(),
+		Author:  meta.Name,
+		Email:   meta.Email,
+		Date:    meta.Time().UTC().Format(time.RFC3339),
+		Subject: strings.SplitN(strings.TrimSpace(meta.Description), "\n", 2)[0],
+		Parents: parentStrs,
+	}, nil
+}
+
+// WriteDot writes the graph to |wr| in the Graphviz dot language. Each commit is a node labeled with its hash, author,
+// date, subject and refs, with an edge to each of its parents which is in the graph.
+func (g *CommitGraph) WriteDot(wr io.Writer) error {
+	nodes := make(map[string]bool, len(g.Nodes))
+	for _, node := range g.Nodes {
+		nodes[node.Hash] = true
+	}
+
+	lines := []string{"digraph commits {", "  node [shape=box];"}
+	for _, node := range g.Nodes {
+		label := []string{node.Hash}
+		if len(node.Refs) > 0 {
+			label = append(label, "("+strings.Join(node.Refs, ", ")+")")
+		}
+
+		label = append(label, fmt.Sprintf("%s <%s>", node.Author, node.Email), node.Date, node.Subject)
+		for i := range label {
+			label[i] = escapeDotString(label[i])
+		}
+
+		attrs := fmt.Sprintf(`label="%s"`, strings.Join(label, `\n`))
+		if len(node.Refs) > 0 {
+			attrs += ", style=bold"
+		}
+
+		lines = append(lines, fmt.Sprintf(`  "%s" [%s];`, node.Hash, attrs))
+	}
+
+	for _, node := range g.Nodes {
+		for _, parent := range node.Parents {
+			if nodes[parent] {
+				lines = append(lines, fmt.Sprintf(`  "%s" -> "%s";`, node.Hash, parent))
+			}
+		}
+	}
+
+	lines = append(lines, "}")
+	_, err := io.WriteString(wr, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+func escapeDotString(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
@@ -0,0 +1,128 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commitwalk
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dolttestutils"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+func graphHashes(graph *CommitGraph) []string {
+	hashes := make([]string, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		hashes[i] = node.Hash
+	}
+
+	return hashes
+}
+
+func TestGetCommitGraph(t *testing.T) {
+	// master: init--m1--m2--merge
+	//                 \       /
+	// feature:         f1----
+	ctx := context.Background()
+	fix, err := dolttestutils.Build(ctx,
+		dolttestutils.Commit{Name: "m1"},
+		dolttestutils.Branch{Name: "feature"},
+		dolttestutils.Checkout{Branch: "feature"},
+		dolttestutils.Commit{Name: "f1"},
+		dolttestutils.Checkout{Branch: "master"},
+		dolttestutils.Commit{Name: "m2"},
+		dolttestutils.Merge{Branch: "feature"},
+		dolttestutils.Commit{Name: "merge", Message: "A New Commit."},
+	)
+	require.NoError(t, err)
+
+	env := fix.DEnv
+	initHash, m1Hash, f1Hash := fix.Commits[dolttestutils.InitCommit], fix.Commits["m1"], fix.Commits["f1"]
+	m2Hash, mergeHash := fix.Commits["m2"], fix.Commits["merge"]
+
+	graph, err := GetCommitGraph(ctx, env.DoltDB, []hash.Hash{mergeHash}, -1, -1)
+	require.NoError(t, err)
+	require.Len(t, graph.Nodes, 5)
+	assert.Equal(t, mergeHash.String(), graph.Nodes[0].Hash)
+	assert.ElementsMatch(t, []string{m2Hash.String(), f1Hash.String()}, graph.Nodes[0].Parents)
+	assert.ElementsMatch(t, []string{m2Hash.String(), f1Hash.String()}, graphHashes(graph)[1:3])
+	assert.Equal(t, []string{m1Hash.String(), initHash.String()}, graphHashes(graph)[3:])
+	assert.Equal(t, dolttestutils.UserName, graph.Nodes[0].Author)
+	assert.Equal(t, dolttestutils.UserEmail, graph.Nodes[0].Email)
+	assert.Equal(t, "A New Commit.", graph.Nodes[0].Subject)
+	assert.Empty(t, graph.Nodes[4].Parents)
+
+	graph, err = GetCommitGraph(ctx, env.DoltDB, []hash.Hash{mergeHash}, 2, -1)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{mergeHash.String(), m2Hash.String(), f1Hash.String()}, graphHashes(graph))
+
+	graph, err = GetCommitGraph(ctx, env.DoltDB, []hash.Hash{mergeHash}, -1, 2)
+	require.NoError(t, err)
+	assert.Len(t, graph.Nodes, 2)
+
+	graph, err = GetCommitGraph(ctx, env.DoltDB, []hash.Hash{m2Hash, f1Hash}, 2, -1)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{m2Hash.String(), f1Hash.String(), m1Hash.String()}, graphHashes(graph))
+	assert.Equal(t, m1Hash.String(), graph.Nodes[2].Hash)
+
+	graph, err = GetCommitGraph(ctx, env.DoltDB, []hash.Hash{mergeHash}, 2, -1)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	err = graph.WriteDot(buf)
+	require.NoError(t, err)
+	dot := buf.String()
+	assert.Contains(t, dot, `"`+mergeHash.String()+`" -> "`+m2Hash.String()+`";`)
+	assert.Contains(t, dot, `"`+mergeHash.String()+`" -> "`+f1Hash.String()+`";`)
+	assert.NotContains(t, dot, m1Hash.String())
+}
+
+func TestWriteDot(t *testing.T) {
+	graph := &CommitGraph{Nodes: []*CommitGraphNode{
+		{
+			Hash:    "child",
+			Author:  "Bill Billerson",
+			Email:   "bill@billerson.com",
+			Date:    "2020-05-01T00:00:00Z",
+			Subject: `fixed the "quoted\path" bug`,
+			Parents: []string{"parent", "missing"},
+			Refs:    []string{"HEAD -> master"},
+		},
+		{
+			Hash:    "parent",
+			Author:  "Bill Billerson",
+			Email:   "bill@billerson.com",
+			Date:    "2020-04-01T00:00:00Z",
+			Subject: "Initialize data repository",
+		},
+	}}
+
+	buf := &bytes.Buffer{}
+	err := graph.WriteDot(buf)
+	require.NoError(t, err)
+
+	expected := `digraph commits {
+  node [shape=box];
+  "child" [label="child\n(HEAD -> master)\nBill Billerson <bill@billerson.com>\n2020-05-01T00:00:00Z\nfixed the \"quoted\\path\" bug", style=bold];
+  "parent" [label="parent\nBill Billerson <bill@billerson.com>\n2020-04-01T00:00:00Z\nInitialize data repository"];
+  "child" -> "parent";
+}
+`
+	assert.Equal(t, expected, buf.String())
+}
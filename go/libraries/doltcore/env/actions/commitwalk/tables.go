@@ -0,0 +1,195 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commitwalk
+
+import (
+	"context"
+	"time"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// GetTableHistoryIterator returns an iterator for the commits reachable from the commit at hash `startCommitHash`
+// which changed any of the tables in `tableNames`, in the same order as GetTopologicalOrderCommits. A commit changed
+// a table if the table differs from the table in each of the commit's parents, or if the commit has no parents and
+// the table exists. When the tables of a merge commit are the same as those of its first parent, none of the
+// changes the other parents brought in made it into the merge, and the commits only reachable through them aren't
+// walked. If `firstParent` is true, only the first parents of merge commits are walked at all.
+func GetTableHistoryIterator(ctx context.Context, ddb *doltdb.DoltDB, startCommitHash hash.Hash, tableNames []string, firstParent bool) (doltdb.CommitItr, error) {
+	return GetTableHistoryIteratorSince(ctx, ddb, startCommitHash, tableNames, firstParent, time.Time{})
+}
+
+// GetTableHistoryIteratorSince is like GetTableHistoryIterator, but doesn't walk past the commits made before `since`,
+// as described by MadeSince. A zero `since` walks the whole history.
+func GetTableHistoryIteratorSince(ctx context.Context, ddb *doltdb.DoltDB, startCommitHash hash.Hash, tableNames []string, firstParent bool, since time.Time) (doltdb.CommitItr, error) {
+	ti := &tableHistoryIterator{
+		tableNames:  tableNames,
+		firstParent: firstParent,
+		tableHashes: make(map[hash.Hash][]hash.Hash),
+		touched:     make(map[hash.Hash]bool),
+	}
+
+	parents := ParentsFunc(ti.parents)
+	if !since.IsZero() {
+		parents = MadeSince(parents, since)
+	}
+
+	itr, err := newCommiterator(ctx, ddb, startCommitHash, parents)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ti.itr = itr
+	return ti, nil
+}
+
+type tableHistoryIterator struct {
+	itr         *commiterator
+	tableNames  []string
+	firstParent bool
+
+	// tableHashes holds the hashes of the tables in each commit seen, in the order of tableNames, as each commit is
+	// compared to both its parents and its children
+	tableHashes map[hash.Hash][]hash.Hash
+	// touched holds whether each commit walked changed any of the tables
+	touched map[hash.Hash]bool
+}
+
+var _ doltdb.CommitItr = (*tableHistoryIterator)(nil)
+
+// Next implements doltdb.CommitItr
+func (ti *tableHistoryIterator) Next(ctx context.Context) (hash.Hash, *doltdb.Commit, error) {
+	for {
+		h, cm, err := ti.itr.Next(ctx)
+
+		if err != nil {
+			return hash.Hash{}, nil, err
+		}
+
+		if ti.touched[h] {
+			return h, cm, nil
+		}
+	}
+}
+
+// Reset implements doltdb.CommitItr
+func (ti *tableHistoryIterator) Reset(ctx context.Context) error {
+	return ti.itr.Reset(ctx)
+}
+
+// parents is the ParentsFunc of the walk. It compares the tables of |cm| to those of its parents, which decides both
+// whether |cm| is returned and which of its parents are walked.
+func (ti *tableHistoryIterator) parents(ctx context.Context, cm *doltdb.Commit) ([]hash.Hash, error) {
+	h, err := cm.HashOf()
+
+	if err != nil {
+		return nil, err
+	}
+
+	hashes, err := ti.getTableHashes(ctx, h, cm)
+
+	if err != nil {
+		return nil, err
+	}
+
+	parents, err := cm.ParentHashes(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(parents) == 0 {
+		for _, th := range hashes {
+			if !th.IsEmpty() {
+				ti.touched[h] = true
+			}
+		}
+
+		return parents, nil
+	}
+
+	touched := true
+	sameAsFirst := false
+	for i := range parents {
+		parentHashes, err := ti.getParentTableHashes(ctx, cm, i, parents[i])
+
+		if err != nil {
+			return nil, err
+		}
+
+		if equalHashes(hashes, parentHashes) {
+			touched = false
+			sameAsFirst = sameAsFirst || i == 0
+		}
+	}
+
+	ti.touched[h] = touched
+
+	if ti.firstParent || sameAsFirst {
+		return parents[:1], nil
+	}
+
+	return parents, nil
+}
+
+func (ti *tableHistoryIterator) getParentTableHashes(ctx context.Context, cm *doltdb.Commit, idx int, h hash.Hash) ([]hash.Hash, error) {
+	if hashes, ok := ti.tableHashes[h]; ok {
+		return hashes, nil
+	}
+
+	parent, err := ti.itr.ddb.ResolveParent(ctx, cm, idx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ti.getTableHashes(ctx, h, parent)
+}
+
+func (ti *tableHistoryIterator) getTableHashes(ctx context.Context, h hash.Hash, cm *doltdb.Commit) ([]hash.Hash, error) {
+	if hashes, ok := ti.tableHashes[h]; ok {
+		return hashes, nil
+	}
+
+	root, err := cm.GetRootValue()
+
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]hash.Hash, len(ti.tableNames))
+	for i, tName := range ti.tableNames {
+		hashes[i], _, err = root.GetTableHash(ctx, tName)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ti.tableHashes[h] = hashes
+	return hashes, nil
+}
+
+func equalHashes(hashes, other []hash.Hash) bool {
+	for i := range hashes {
+		if hashes[i] != other[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,207 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/encoding"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// CopyTable returns |destRoot| with a copy of the table |srcName| of |srcRoot| named |destName|, replacing the table of
+// that name if there is one. The copy has the schema of the source table, with its constraints and comments, and its
+// rows unless |schemaOnly| is true. The roots may be those of different commits of the same database.
+//
+// The columns of the copy keep their tags unless a different table of |destRoot| uses them, as the source table does
+// when it's copied within a root. A copy which keeps its tags references the schema and rows of the source table
+// rather than writing them again, so the two share all their chunks. A copy with new tags has its rows rewritten.
+func CopyTable(ctx context.Context, srcRoot *doltdb.RootValue, srcName string, destRoot *doltdb.RootValue, destName string, schemaOnly bool) (*doltdb.RootValue, error) {
+	tbl, ok, err := srcRoot.GetTable(ctx, srcName)
+
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, NewTblNotExistError([]string{srcName})
+	}
+
+	sch, err := tbl.GetSchema(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tagMapping, err := tagsForCopy(ctx, destRoot, destName, sch)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if tagMapping == nil {
+		if schemaOnly {
+			return destRoot.CreateEmptyTable(ctx, destName, sch)
+		}
+
+		tbl, err = tbl.ClearConflicts()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return destRoot.PutTable(ctx, destName, tbl)
+	}
+
+	destSch, err := retagSchema(sch, tagMapping)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if schemaOnly {
+		return destRoot.CreateEmptyTable(ctx, destName, destSch)
+	}
+
+	rowData, err := tbl.GetRowData(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rowData, err = retagRows(ctx, destRoot.VRW(), rowData, sch, destSch, tagMapping)
+
+	if err != nil {
+		return nil, err
+	}
+
+	schVal, err := encoding.MarshalSchemaAsNomsValue(ctx, destRoot.VRW(), destSch)
+
+	if err != nil {
+		return nil, err
+	}
+
+	destTbl, err := doltdb.NewTable(ctx, destRoot.VRW(), schVal, rowData)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return destRoot.PutTable(ctx, destName, destTbl)
+}
+
+// tagsForCopy returns the new tag of each column of |sch| for a copy of its table named |tblName| in |root|, or nil if
+// the columns can keep their tags because no other table of |root| uses any of them.
+func tagsForCopy(ctx context.Context, root *doltdb.RootValue, tblName string, sch schema.Schema) (map[uint64]uint64, error) {
+	cols := sch.GetAllCols()
+	tagToTable, err := root.TablesNamesForTags(ctx, cols.Tags...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	inUse := false
+	for _, tn := range tagToTable {
+		if tn != tblName {
+			inUse = true
+			break
+		}
+	}
+
+	if !inUse {
+		return nil, nil
+	}
+
+	var colNames []string
+	var colKinds []types.NomsKind
+	_ = cols.Iter(func(_ uint64, col schema.Column) (stop bool, err error) {
+		colNames = append(colNames, col.Name)
+		colKinds = append(colKinds, col.Kind)
+		return false, nil
+	})
+
+	newTags, err := root.GenerateTagsForNewColumns(ctx, tblName, colNames, colKinds)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tagMapping := make(map[uint64]uint64, len(newTags))
+	for i, tag := range cols.Tags {
+		tagMapping[tag] = newTags[i]
+	}
+
+	return tagMapping, nil
+}
+
+func retagSchema(sch schema.Schema, tagMapping map[uint64]uint64) (schema.Schema, error) {
+	cc, _ := schema.NewColCollection()
+	for _, tag := range sch.GetAllCols().Tags {
+		col, _ := sch.GetAllCols().GetByTag(tag)
+		col.Tag = tagMapping[tag]
+
+		var err error
+		cc, err = cc.Append(col)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return schema.SchemaWithComment(schema.SchemaFromCols(cc), sch.GetComment()), nil
+}
+
+func retagRows(ctx context.Context, vrw types.ValueReadWriter, rowData types.Map, srcSch, destSch schema.Schema, tagMapping map[uint64]uint64) (types.Map, error) {
+	m, err := types.NewMap(ctx, vrw)
+
+	if err != nil {
+		return types.EmptyMap, err
+	}
+
+	me := m.Edit()
+	err = rowData.IterAll(ctx, func(key, value types.Value) error {
+		r, err := row.FromNoms(srcSch, key.(types.Tuple), value.(types.Tuple))
+
+		if err != nil {
+			return err
+		}
+
+		taggedVals := make(row.TaggedValues)
+		_, err = r.IterCols(func(tag uint64, val types.Value) (stop bool, err error) {
+			taggedVals[tagMapping[tag]] = val
+			return false, nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		r, err = row.New(vrw.Format(), destSch, taggedVals)
+
+		if err != nil {
+			return err
+		}
+
+		me = me.Set(r.NomsMapKey(destSch), r.NomsMapValue(destSch))
+		return nil
+	})
+
+	if err != nil {
+		return types.EmptyMap, err
+	}
+
+	return me.Map(ctx)
+}
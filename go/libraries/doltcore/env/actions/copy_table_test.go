@@ -0,0 +1,143 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+)
+
+const copyTableTestTable = "people"
+
+func copyTableTestRoot(t *testing.T) *doltdb.RootValue {
+	dEnv := dtestutils.CreateTestEnv()
+	dtestutils.CreateTestTable(t, dEnv, copyTableTestTable, dtestutils.TypedSchema, dtestutils.TypedRows...)
+
+	root, err := dEnv.WorkingRoot(context.Background())
+	require.NoError(t, err)
+
+	return root
+}
+
+func TestCopyTableWithinRoot(t *testing.T) {
+	ctx := context.Background()
+	root := copyTableTestRoot(t)
+
+	root, err := CopyTable(ctx, root, copyTableTestTable, root, "people_copy", false)
+	require.NoError(t, err)
+
+	tbl, ok, err := root.GetTable(ctx, "people_copy")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	sch, err := tbl.GetSchema(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, dtestutils.TypedSchema.GetAllCols().GetColumnNames(), sch.GetAllCols().GetColumnNames())
+
+	// the source table still uses the tags of its columns, so the copy gets new ones
+	for _, tag := range sch.GetAllCols().Tags {
+		_, ok := dtestutils.TypedSchema.GetAllCols().GetByTag(tag)
+		assert.False(t, ok)
+	}
+
+	rows, err := tbl.GetRowData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(len(dtestutils.TypedRows)), rows.Len())
+
+	idTag := newTagOf(t, sch, dtestutils.IdTag)
+	nameTag := newTagOf(t, sch, dtestutils.NameTag)
+	for _, r := range dtestutils.TypedRows {
+		id, _ := r.GetColVal(dtestutils.IdTag)
+		name, _ := r.GetColVal(dtestutils.NameTag)
+
+		copied, ok, err := tbl.GetRowByPKVals(ctx, row.TaggedValues{idTag: id}, sch)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		copiedName, _ := copied.GetColVal(nameTag)
+		assert.True(t, name.Equals(copiedName))
+	}
+}
+
+func TestCopyTableSharesRows(t *testing.T) {
+	ctx := context.Background()
+	srcRoot := copyTableTestRoot(t)
+
+	destRoot, err := srcRoot.RemoveTables(ctx, copyTableTestTable)
+	require.NoError(t, err)
+
+	destRoot, err = CopyTable(ctx, srcRoot, copyTableTestTable, destRoot, copyTableTestTable, false)
+	require.NoError(t, err)
+
+	srcTbl, _, err := srcRoot.GetTable(ctx, copyTableTestTable)
+	require.NoError(t, err)
+	destTbl, ok, err := destRoot.GetTable(ctx, copyTableTestTable)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	srcHash, err := srcTbl.HashOf()
+	require.NoError(t, err)
+	destHash, err := destTbl.HashOf()
+	require.NoError(t, err)
+	assert.Equal(t, srcHash, destHash)
+}
+
+func TestCopyTableSchemaOnly(t *testing.T) {
+	ctx := context.Background()
+	srcRoot := copyTableTestRoot(t)
+
+	destRoot, err := srcRoot.RemoveTables(ctx, copyTableTestTable)
+	require.NoError(t, err)
+
+	destRoot, err = CopyTable(ctx, srcRoot, copyTableTestTable, destRoot, copyTableTestTable, true)
+	require.NoError(t, err)
+
+	tbl, ok, err := destRoot.GetTable(ctx, copyTableTestTable)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	sch, err := tbl.GetSchema(ctx)
+	require.NoError(t, err)
+	eq, err := schema.SchemasAreEqual(dtestutils.TypedSchema, sch)
+	require.NoError(t, err)
+	assert.True(t, eq)
+
+	rows, err := tbl.GetRowData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), rows.Len())
+}
+
+func TestCopyTableMissingTable(t *testing.T) {
+	root := copyTableTestRoot(t)
+	_, err := CopyTable(context.Background(), root, "not_a_table", root, "copy", false)
+	assert.True(t, IsTblNotExist(err))
+}
+
+// newTagOf returns the tag of the column of |sch| with the name of the column with the tag |tag| in TypedSchema.
+func newTagOf(t *testing.T, sch schema.Schema, tag uint64) uint64 {
+	col, ok := dtestutils.TypedSchema.GetAllCols().GetByTag(tag)
+	require.True(t, ok)
+	newCol, ok := sch.GetAllCols().GetByName(col.Name)
+	require.True(t, ok)
+	return newCol.Tag
+}
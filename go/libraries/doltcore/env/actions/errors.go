@@ -18,6 +18,7 @@ import (
 	"strings"
 
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/errcat"
 )
 
 type tblErrorType string
@@ -26,6 +27,8 @@ const (
 	tblErrInvalid        tblErrorType = "invalid"
 	tblErrTypeNotExist   tblErrorType = "do not exist"
 	tblErrTypeInConflict tblErrorType = "in conflict"
+	tblErrTypeNotSparse  tblErrorType = "are not in the sparse working set"
+	tblErrTypeSparse     tblErrorType = "have changes which would be lost"
 )
 
 type TblError struct {
@@ -41,10 +44,30 @@ func NewTblInConflictError(tbls []string) TblError {
 	return TblError{tbls, tblErrTypeInConflict}
 }
 
+func NewTblNotSparseError(tbls []string) TblError {
+	return TblError{tbls, tblErrTypeNotSparse}
+}
+
+func NewTblSparseChangedError(tbls []string) TblError {
+	return TblError{tbls, tblErrTypeSparse}
+}
+
 func (te TblError) Error() string {
 	return "error: the tables " + strings.Join(te.tables, ", ") + string(te.tblErrType)
 }
 
+// Category implements errcat.Categorized.
+func (te TblError) Category() errcat.Category {
+	switch te.tblErrType {
+	case tblErrTypeNotExist:
+		return errcat.NotFound
+	case tblErrTypeInConflict:
+		return errcat.Conflict
+	default:
+		return errcat.Unknown
+	}
+}
+
 func getTblErrType(err error) tblErrorType {
 	te, ok := err.(TblError)
 
@@ -67,6 +90,14 @@ func IsTblInConflict(err error) bool {
 	return getTblErrType(err) == tblErrTypeInConflict
 }
 
+func IsTblNotSparse(err error) bool {
+	return getTblErrType(err) == tblErrTypeNotSparse
+}
+
+func IsTblSparseChanged(err error) bool {
+	return getTblErrType(err) == tblErrTypeSparse
+}
+
 func GetTablesForError(err error) []string {
 	te, ok := err.(TblError)
 
@@ -177,6 +208,11 @@ func (cwo CheckoutWouldOverwrite) Error() string {
 	return "local changes would be overwritten by overwrite"
 }
 
+// Category implements errcat.Categorized. The working changes conflict with the branch being checked out.
+func (cwo CheckoutWouldOverwrite) Category() errcat.Category {
+	return errcat.Conflict
+}
+
 func IsCheckoutWouldOverwrite(err error) bool {
 	_, ok := err.(CheckoutWouldOverwrite)
 	return ok
@@ -225,3 +261,61 @@ func NothingStagedDocsDiffs(err error) *diff.DocDiffs {
 
 	return ns.NotStagedDocs
 }
+
+type ConcurrentModification struct {
+	tables []string
+}
+
+func (cm ConcurrentModification) Error() string {
+	return "the branch head was modified concurrently and the changes could not be combined"
+}
+
+// Category implements errcat.Categorized.
+func (cm ConcurrentModification) Category() errcat.Category {
+	return errcat.ConcurrentModification
+}
+
+func IsConcurrentModification(err error) bool {
+	_, ok := err.(ConcurrentModification)
+	return ok
+}
+
+func ConcurrentModificationTables(err error) []string {
+	cm, ok := err.(ConcurrentModification)
+
+	if !ok {
+		panic("Must validate with IsConcurrentModification before calling ConcurrentModificationTables")
+	}
+
+	return cm.tables
+}
+
+// OverlappingChanges is returned by PushWithAutoMerge when the commits made to the remote branch can't be merged
+// automatically, because they change some of the same tables as the local commits in ways that may overlap.
+type OverlappingChanges struct {
+	tables []string
+}
+
+func (oc OverlappingChanges) Error() string {
+	return "the local and remote changes overlap and could not be merged automatically"
+}
+
+// Category implements errcat.Categorized.
+func (oc OverlappingChanges) Category() errcat.Category {
+	return errcat.Conflict
+}
+
+func IsOverlappingChanges(err error) bool {
+	_, ok := err.(OverlappingChanges)
+	return ok
+}
+
+func OverlappingChangesTables(err error) []string {
+	oc, ok := err.(OverlappingChanges)
+
+	if !ok {
+		panic("Must validate with IsOverlappingChanges before calling OverlappingChangesTables")
+	}
+
+	return oc.tables
+}
@@ -0,0 +1,385 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/merge"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/tablediff"
+	"github.com/liquidata-inc/dolt/go/store/datas"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// DefaultAutoMergeRetries is the number of times a push which is rejected is retried after merging the remote changes,
+// when no other number is given.
+const DefaultAutoMergeRetries = 3
+
+var ErrAutoMergeMergeActive = errors.New("remote changes cannot be merged automatically during a merge")
+
+// IsPushRejected returns whether |err| is the error of a push which was rejected because it wasn't a fast forward of
+// the remote branch.
+func IsPushRejected(err error) bool {
+	return err == doltdb.ErrIsAhead || err == ErrCantFF || err == datas.ErrMergeNeeded
+}
+
+// PushWithAutoMerge pushes |commit|, the head of the local branch |srcRef|, to the branch |destRef| of |destDB| like a
+// fast forward Push. When the push is rejected because commits were pushed to the remote branch by someone else, the
+// new head of the remote branch is fetched and merged into the local branch, and the push is retried, up to
+// |maxRetries| times. The remote changes are only merged when they can't overlap the local changes, which is the case
+// when they change different tables, or different rows of tables whose schemas neither of them changed. Otherwise an
+// OverlappingChanges error is returned and the local branch is left as it was.
+//
+// When |srcRef| is the current branch its staged and working roots are moved onto the merge, as they are when a commit
+// is made concurrently, so a ConcurrentModification error is returned if the remote changes overlap them.
+//
+// The commit which was pushed is returned along with the number of merges made.
+func PushWithAutoMerge(ctx context.Context, dEnv *env.DoltEnv, srcRef, destRef ref.BranchRef, remoteRef ref.RemoteRef, srcDB, destDB *doltdb.DoltDB, commit *doltdb.Commit, maxRetries int, progChan chan datas.PullProgress, pullerEventCh chan datas.PullerEvent) (*doltdb.Commit, int, error) {
+	merges := 0
+	for i := 0; ; i++ {
+		err := Push(ctx, dEnv, ref.FastForwardOnly, destRef, remoteRef, srcDB, destDB, commit, progChan, pullerEventCh)
+
+		if err == nil {
+			return commit, merges, nil
+		} else if !IsPushRejected(err) || i == maxRetries {
+			return nil, merges, err
+		}
+
+		remoteHead, err := fetchRemoteHead(ctx, dEnv, destRef, remoteRef, srcDB, destDB, progChan, pullerEventCh)
+
+		if err != nil {
+			return nil, merges, err
+		}
+
+		ancestor, err := doltdb.GetCommitAncestor(ctx, commit, remoteHead)
+
+		if err != nil {
+			return nil, merges, err
+		}
+
+		if same, err := sameCommit(ancestor, commit); err != nil {
+			return nil, merges, err
+		} else if same {
+			// everything being pushed is already in the remote branch
+			return nil, merges, doltdb.ErrUpToDate
+		}
+
+		if same, err := sameCommit(ancestor, remoteHead); err != nil {
+			return nil, merges, err
+		} else if same {
+			// the remote tracking branch was behind the remote branch, which the commit already includes
+			continue
+		}
+
+		commit, err = mergeDisjointChanges(ctx, dEnv, srcDB, srcRef, remoteRef, commit, remoteHead, ancestor)
+
+		if err != nil {
+			return nil, merges, err
+		}
+
+		merges++
+	}
+}
+
+// fetchRemoteHead fetches the head of the branch |destRef| of |destDB| into |srcDB|, points the remote tracking branch
+// |remoteRef| at it, and returns it.
+func fetchRemoteHead(ctx context.Context, dEnv *env.DoltEnv, destRef ref.BranchRef, remoteRef ref.RemoteRef, srcDB, destDB *doltdb.DoltDB, progChan chan datas.PullProgress, pullerEventCh chan datas.PullerEvent) (*doltdb.Commit, error) {
+	cs, _ := doltdb.NewCommitSpec("HEAD", destRef.String())
+	remoteHead, err := destDB.Resolve(ctx, cs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = Fetch(ctx, dEnv, remoteRef, destDB, srcDB, remoteHead, progChan, pullerEventCh)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = srcDB.SetHead(ctx, remoteRef, remoteHead)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cs, _ = doltdb.NewCommitSpec("HEAD", remoteRef.String())
+	return srcDB.Resolve(ctx, cs)
+}
+
+func sameCommit(cm1, cm2 *doltdb.Commit) (bool, error) {
+	h1, err := cm1.HashOf()
+
+	if err != nil {
+		return false, err
+	}
+
+	h2, err := cm2.HashOf()
+
+	if err != nil {
+		return false, err
+	}
+
+	return h1 == h2, nil
+}
+
+// mergeDisjointChanges merges |remoteHead| into |localHead|, the head of the branch |srcRef|, when the changes made
+// since their common ancestor can't overlap, and returns the merge commit.
+func mergeDisjointChanges(ctx context.Context, dEnv *env.DoltEnv, ddb *doltdb.DoltDB, srcRef ref.BranchRef, remoteRef ref.RemoteRef, localHead, remoteHead, ancestor *doltdb.Commit) (*doltdb.Commit, error) {
+	isCurrentBranch := ref.Equals(dEnv.RepoState.CWBHeadRef(), srcRef)
+
+	if isCurrentBranch && dEnv.IsMergeActive() {
+		return nil, ErrAutoMergeMergeActive
+	}
+
+	ancRoot, err := ancestor.GetRootValue()
+
+	if err != nil {
+		return nil, err
+	}
+
+	localRoot, err := localHead.GetRootValue()
+
+	if err != nil {
+		return nil, err
+	}
+
+	remoteRoot, err := remoteHead.GetRootValue()
+
+	if err != nil {
+		return nil, err
+	}
+
+	overlapping, err := overlappingTables(ctx, ancRoot, localRoot, remoteRoot)
+
+	if err != nil {
+		return nil, err
+	} else if len(overlapping) > 0 {
+		return nil, OverlappingChanges{overlapping}
+	}
+
+	mergedRoot, stats, err := merge.MergeRoots(ctx, localRoot, remoteRoot, ancRoot, ddb.ValueReadWriter())
+
+	if err != nil {
+		return nil, err
+	}
+
+	for tblName, tblStats := range stats {
+		if tblStats.Conflicts > 0 {
+			overlapping = append(overlapping, tblName)
+		}
+	}
+
+	if len(overlapping) > 0 {
+		sort.Strings(overlapping)
+		return nil, OverlappingChanges{overlapping}
+	}
+
+	var srt, wrt *doltdb.RootValue
+	if isCurrentBranch {
+		if srt, err = dEnv.StagedRoot(ctx); err != nil {
+			return nil, err
+		}
+
+		if wrt, err = dEnv.WorkingRoot(ctx); err != nil {
+			return nil, err
+		}
+
+		srt, wrt, err = rebaseRoots(ctx, localRoot, mergedRoot, srt, wrt)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	h, err := ddb.WriteRootValue(ctx, mergedRoot)
+
+	if err != nil {
+		return nil, err
+	}
+
+	name, email, err := GetNameAndEmail(dEnv.Config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := doltdb.NewCommitMeta(name, email, fmt.Sprintf("Merge remote-tracking branch '%s'", remoteRef.GetPath()))
+
+	if err != nil {
+		return nil, err
+	}
+
+	mergeCommit, err := ddb.CommitWithExpectedHead(ctx, h, srcRef, localHead, []*doltdb.Commit{remoteHead}, meta)
+
+	if err == doltdb.ErrHeadMoved {
+		return nil, ConcurrentModification{}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if isCurrentBranch {
+		if _, err = dEnv.UpdateStagedRoot(ctx, srt); err != nil {
+			return nil, err
+		}
+
+		if err = dEnv.UpdateWorkingRoot(ctx, wrt); err != nil {
+			return nil, err
+		}
+
+		remoteChanges, err := changedTables(ctx, ancRoot, remoteRoot)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if remoteChanges.Contains(doltdb.DocTableName) {
+			if err = dEnv.UpdateFSDocsToRootDocs(ctx, wrt, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return mergeCommit, nil
+}
+
+// overlappingTables returns the sorted names of the tables which were changed both between |ancRoot| and |localRoot|
+// and between |ancRoot| and |remoteRoot|, and whose changes may overlap.
+func overlappingTables(ctx context.Context, ancRoot, localRoot, remoteRoot *doltdb.RootValue) ([]string, error) {
+	localChanges, err := changedTables(ctx, ancRoot, localRoot)
+
+	if err != nil {
+		return nil, err
+	}
+
+	remoteChanges, err := changedTables(ctx, ancRoot, remoteRoot)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var overlapping []string
+	for _, tblName := range localChanges.AsSlice() {
+		if !remoteChanges.Contains(tblName) {
+			continue
+		}
+
+		disjoint, err := rowChangesAreDisjoint(ctx, ancRoot, localRoot, remoteRoot, tblName)
+
+		if err != nil {
+			return nil, err
+		} else if !disjoint {
+			overlapping = append(overlapping, tblName)
+		}
+	}
+
+	sort.Strings(overlapping)
+	return overlapping, nil
+}
+
+// rowChangesAreDisjoint returns whether the changes made to the table |tblName| between |ancRoot| and |localRoot| and
+// between |ancRoot| and |remoteRoot| are to different rows. The changes of a table which was added, removed or had
+// its schema changed in either root are never disjoint.
+func rowChangesAreDisjoint(ctx context.Context, ancRoot, localRoot, remoteRoot *doltdb.RootValue, tblName string) (bool, error) {
+	var schs []schema.Schema
+	for _, root := range []*doltdb.RootValue{ancRoot, localRoot, remoteRoot} {
+		tbl, ok, err := root.GetTable(ctx, tblName)
+
+		if err != nil {
+			return false, err
+		} else if !ok {
+			return false, nil
+		}
+
+		sch, err := tbl.GetSchema(ctx)
+
+		if err != nil {
+			return false, err
+		}
+
+		schs = append(schs, sch)
+	}
+
+	for _, sch := range schs[1:] {
+		if eq, err := schema.SchemasAreEqual(schs[0], sch); err != nil {
+			return false, err
+		} else if !eq {
+			return false, nil
+		}
+	}
+
+	localKeys := hash.NewHashSet()
+	err := iterChangedKeys(ctx, ancRoot, localRoot, tblName, func(h hash.Hash) (stop bool) {
+		localKeys.Insert(h)
+		return false
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	disjoint := true
+	err = iterChangedKeys(ctx, ancRoot, remoteRoot, tblName, func(h hash.Hash) (stop bool) {
+		disjoint = !localKeys.Has(h)
+		return !disjoint
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return disjoint, nil
+}
+
+// iterChangedKeys calls |cb| with the hash of the key of every row of the table |tblName| which changed between
+// |fromRoot| and |toRoot|, until it returns true.
+func iterChangedKeys(ctx context.Context, fromRoot, toRoot *doltdb.RootValue, tblName string, cb func(h hash.Hash) (stop bool)) error {
+	itr, err := tablediff.NewRowDiffIterator(ctx, fromRoot, toRoot, tblName, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer itr.Close()
+
+	nbf := fromRoot.VRW().Format()
+	for {
+		rd, err := itr.Next(ctx)
+
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		h, err := rd.Key.Hash(nbf)
+
+		if err != nil {
+			return err
+		}
+
+		if cb(h) {
+			return nil
+		}
+	}
+}
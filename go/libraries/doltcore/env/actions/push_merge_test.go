@@ -0,0 +1,261 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dolttestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// pushMergeSch returns the schema of a table with an id primary key and a v column, plus a w column if |withW| is set.
+// Column tags are unique across tables, so each table takes its tags from |firstTag|.
+func pushMergeSch(firstTag uint64, withW bool) schema.Schema {
+	cols := []schema.Column{
+		schema.NewColumn("id", firstTag, types.IntKind, true, schema.NotNullConstraint{}),
+		schema.NewColumn("v", firstTag+1, types.IntKind, false),
+	}
+
+	if withW {
+		cols = append(cols, schema.NewColumn("w", firstTag+2, types.IntKind, false))
+	}
+
+	return dtestutils.MustSchema(cols...)
+}
+
+var (
+	pushMergeMaster = ref.NewBranchRef("master")
+	pushMergeRemote = ref.NewRemoteRef("origin", "master")
+)
+
+// pushMergeFixture builds a repository in which the branch other makes |otherChanges|, and master makes |localChanges|,
+// to the tables a and b of the commit base. It pushes base and then other to the master branch of a remote, and points
+// the remote tracking branch back at base, as if other had been pushed by someone else.
+func pushMergeFixture(t *testing.T, otherChanges, localChanges []dolttestutils.Step) (*dolttestutils.Fixture, *doltdb.DoltDB) {
+	ctx := context.Background()
+	steps := []dolttestutils.Step{
+		dolttestutils.PutTable{Name: "a", Schema: pushMergeSch(0, false), Rows: dolttestutils.Rows{{1, 1}, {2, 2}}},
+		dolttestutils.PutTable{Name: "b", Schema: pushMergeSch(10, false), Rows: dolttestutils.Rows{{1, 1}, {2, 2}}},
+		dolttestutils.Commit{Name: "base"},
+		dolttestutils.Branch{Name: "other"},
+		dolttestutils.Checkout{Branch: "other"},
+	}
+	steps = append(steps, otherChanges...)
+	steps = append(steps, dolttestutils.Commit{Name: "other"}, dolttestutils.Checkout{Branch: "master"})
+	steps = append(steps, localChanges...)
+	steps = append(steps, dolttestutils.Commit{Name: "local"})
+
+	fix, err := dolttestutils.Build(ctx, steps...)
+	require.NoError(t, err)
+
+	remote, err := dolttestutils.Build(ctx)
+	require.NoError(t, err)
+
+	localDB, remoteDB := fix.DEnv.DoltDB, remote.DEnv.DoltDB
+	base, err := fix.Commit(ctx, "base")
+	require.NoError(t, err)
+	other, err := fix.Commit(ctx, "other")
+	require.NoError(t, err)
+
+	require.NoError(t, Push(ctx, fix.DEnv, ref.ForceUpdate, pushMergeMaster, pushMergeRemote, localDB, remoteDB, base, nil, nil))
+	require.NoError(t, Push(ctx, fix.DEnv, ref.ForceUpdate, pushMergeMaster, pushMergeRemote, localDB, remoteDB, other, nil, nil))
+	require.NoError(t, localDB.SetHead(ctx, pushMergeRemote, base))
+
+	return fix, remoteDB
+}
+
+func pushWithAutoMerge(t *testing.T, fix *dolttestutils.Fixture, remoteDB *doltdb.DoltDB, maxRetries int) (*doltdb.Commit, int, error) {
+	ctx := context.Background()
+	local, err := fix.Commit(ctx, "local")
+	require.NoError(t, err)
+
+	return PushWithAutoMerge(ctx, fix.DEnv, pushMergeMaster, pushMergeMaster, pushMergeRemote, fix.DEnv.DoltDB, remoteDB, local, maxRetries, nil, nil)
+}
+
+// readRows returns the values of the v column of the table |tblName| of |root|, keyed by id.
+func readRows(t *testing.T, root *doltdb.RootValue, tblName string) map[int64]int64 {
+	ctx := context.Background()
+	tbl, ok, err := root.GetTable(ctx, tblName)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	rowData, err := tbl.GetRowData(ctx)
+	require.NoError(t, err)
+
+	rows := make(map[int64]int64)
+	err = rowData.IterAll(ctx, func(key, value types.Value) error {
+		id, err := key.(types.Tuple).Get(1)
+		require.NoError(t, err)
+		v, err := value.(types.Tuple).Get(1)
+		require.NoError(t, err)
+
+		rows[int64(id.(types.Int))] = int64(v.(types.Int))
+		return nil
+	})
+	require.NoError(t, err)
+
+	return rows
+}
+
+func headHash(t *testing.T, ddb *doltdb.DoltDB, dref ref.DoltRef) string {
+	cs, err := doltdb.NewCommitSpec("HEAD", dref.String())
+	require.NoError(t, err)
+	cm, err := ddb.Resolve(context.Background(), cs)
+	require.NoError(t, err)
+	h, err := cm.HashOf()
+	require.NoError(t, err)
+
+	return h.String()
+}
+
+func TestPushWithAutoMerge(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		other       []dolttestutils.Step
+		local       []dolttestutils.Step
+		expectedA   map[int64]int64
+		expectedB   map[int64]int64
+		overlapping []string
+	}{
+		{
+			name:      "different tables",
+			other:     []dolttestutils.Step{dolttestutils.UpsertRows{Table: "b", Rows: dolttestutils.Rows{{3, 3}}}},
+			local:     []dolttestutils.Step{dolttestutils.UpsertRows{Table: "a", Rows: dolttestutils.Rows{{1, 10}}}},
+			expectedA: map[int64]int64{1: 10, 2: 2},
+			expectedB: map[int64]int64{1: 1, 2: 2, 3: 3},
+		},
+		{
+			name:      "different rows",
+			other:     []dolttestutils.Step{dolttestutils.UpsertRows{Table: "b", Rows: dolttestutils.Rows{{1, 10}}}},
+			local:     []dolttestutils.Step{dolttestutils.DeleteRows{Table: "b", Keys: dolttestutils.Rows{{2}}}},
+			expectedA: map[int64]int64{1: 1, 2: 2},
+			expectedB: map[int64]int64{1: 10},
+		},
+		{
+			name:        "same rows",
+			other:       []dolttestutils.Step{dolttestutils.UpsertRows{Table: "b", Rows: dolttestutils.Rows{{1, 10}}}},
+			local:       []dolttestutils.Step{dolttestutils.UpsertRows{Table: "a", Rows: dolttestutils.Rows{{1, 10}}}, dolttestutils.DeleteRows{Table: "b", Keys: dolttestutils.Rows{{1}}}},
+			overlapping: []string{"b"},
+		},
+		{
+			name:        "schema changed",
+			other:       []dolttestutils.Step{dolttestutils.PutTable{Name: "b", Schema: pushMergeSch(10, true), Rows: dolttestutils.Rows{{1, 1, nil}, {2, 2, nil}}}},
+			local:       []dolttestutils.Step{dolttestutils.UpsertRows{Table: "b", Rows: dolttestutils.Rows{{3, 3}}}},
+			overlapping: []string{"b"},
+		},
+		{
+			name:        "table dropped",
+			other:       []dolttestutils.Step{dolttestutils.UpsertRows{Table: "a", Rows: dolttestutils.Rows{{3, 3}}}},
+			local:       []dolttestutils.Step{dolttestutils.DropTable{Name: "a"}},
+			overlapping: []string{"a"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fix, remoteDB := pushMergeFixture(t, test.other, test.local)
+			localDB := fix.DEnv.DoltDB
+			localHash := fix.Commits["local"].String()
+
+			pushed, merges, err := pushWithAutoMerge(t, fix, remoteDB, DefaultAutoMergeRetries)
+
+			if test.overlapping != nil {
+				require.True(t, IsOverlappingChanges(err), "unexpected error: %v", err)
+				assert.Equal(t, test.overlapping, OverlappingChangesTables(err))
+				assert.Equal(t, 0, merges)
+				assert.Equal(t, localHash, headHash(t, localDB, pushMergeMaster))
+				assert.Equal(t, fix.Commits["other"].String(), headHash(t, remoteDB, pushMergeMaster))
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, 1, merges)
+
+			pushedHash, err := pushed.HashOf()
+			require.NoError(t, err)
+			assert.Equal(t, pushedHash.String(), headHash(t, remoteDB, pushMergeMaster))
+			assert.Equal(t, pushedHash.String(), headHash(t, localDB, pushMergeMaster))
+			assert.Equal(t, pushedHash.String(), headHash(t, localDB, pushMergeRemote))
+
+			parents, err := localDB.ResolveAllParents(ctx, pushed)
+			require.NoError(t, err)
+			require.Len(t, parents, 2)
+
+			root, err := pushed.GetRootValue()
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedA, readRows(t, root, "a"))
+			assert.Equal(t, test.expectedB, readRows(t, root, "b"))
+
+			// the working set of the current branch is moved onto the merge
+			working, err := fix.DEnv.WorkingRoot(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedB, readRows(t, working, "b"))
+		})
+	}
+}
+
+func TestPushWithAutoMergeNoRetries(t *testing.T) {
+	fix, remoteDB := pushMergeFixture(t,
+		[]dolttestutils.Step{dolttestutils.UpsertRows{Table: "b", Rows: dolttestutils.Rows{{3, 3}}}},
+		[]dolttestutils.Step{dolttestutils.UpsertRows{Table: "a", Rows: dolttestutils.Rows{{3, 3}}}},
+	)
+
+	_, merges, err := pushWithAutoMerge(t, fix, remoteDB, 0)
+	assert.True(t, IsPushRejected(err), "unexpected error: %v", err)
+	assert.Equal(t, 0, merges)
+	assert.Equal(t, fix.Commits["local"].String(), headHash(t, fix.DEnv.DoltDB, pushMergeMaster))
+}
+
+func TestPushWithAutoMergeUncommittedChanges(t *testing.T) {
+	ctx := context.Background()
+	fix, remoteDB := pushMergeFixture(t,
+		[]dolttestutils.Step{dolttestutils.UpsertRows{Table: "b", Rows: dolttestutils.Rows{{3, 3}}}},
+		[]dolttestutils.Step{dolttestutils.UpsertRows{Table: "a", Rows: dolttestutils.Rows{{3, 3}}}},
+	)
+
+	// the uncommitted change to b would be lost by moving the working set onto the merge
+	require.NoError(t, dolttestutils.UpsertRows{Table: "b", Rows: dolttestutils.Rows{{4, 4}}}.Apply(ctx, fix))
+
+	_, _, err := pushWithAutoMerge(t, fix, remoteDB, DefaultAutoMergeRetries)
+	require.True(t, IsConcurrentModification(err), "unexpected error: %v", err)
+	assert.Equal(t, []string{"b"}, ConcurrentModificationTables(err))
+	assert.Equal(t, fix.Commits["local"].String(), headHash(t, fix.DEnv.DoltDB, pushMergeMaster))
+
+	// uncommitted changes to other tables are kept
+	fix, remoteDB = pushMergeFixture(t,
+		[]dolttestutils.Step{dolttestutils.UpsertRows{Table: "b", Rows: dolttestutils.Rows{{3, 3}}}},
+		[]dolttestutils.Step{dolttestutils.UpsertRows{Table: "a", Rows: dolttestutils.Rows{{3, 3}}}},
+	)
+	require.NoError(t, dolttestutils.UpsertRows{Table: "a", Rows: dolttestutils.Rows{{4, 4}}}.Apply(ctx, fix))
+
+	_, _, err = pushWithAutoMerge(t, fix, remoteDB, DefaultAutoMergeRetries)
+	require.NoError(t, err)
+
+	working, err := fix.DEnv.WorkingRoot(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]int64{1: 1, 2: 2, 3: 3, 4: 4}, readRows(t, working, "a"))
+	assert.Equal(t, map[int64]int64{1: 1, 2: 2, 3: 3}, readRows(t, working, "b"))
+}
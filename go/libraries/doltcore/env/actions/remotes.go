@@ -16,15 +16,18 @@ package actions
 
 import (
 	"context"
-	"errors"
 
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/errcat"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	"github.com/liquidata-inc/dolt/go/store/background"
+	"github.com/liquidata-inc/dolt/go/store/chunks"
 	"github.com/liquidata-inc/dolt/go/store/datas"
+	"github.com/liquidata-inc/dolt/go/store/hash"
 )
 
-var ErrCantFF = errors.New("can't fast forward merge")
+var ErrCantFF = errcat.New(errcat.Conflict, "can't fast forward merge")
 
 // Push will update a destination branch, in a given destination database if it can be done as a fast forward merge.
 // This is accomplished first by verifying that the remote tracking reference for the source database can be updated to
@@ -43,10 +46,13 @@ func Push(ctx context.Context, dEnv *env.DoltEnv, mode ref.RefUpdateMode, destRe
 		}
 	}
 
-	err = destDB.PushChunks(ctx, dEnv.TempTableFilesDir(), srcDB, commit, progChan, pullerEventCh)
+	// the push holds back the background work of lower priorities, such as conjoins, until its chunks are pushed
+	task := background.DefaultScheduler.Start("push "+destRef.String(), background.ReplicationPriority)
+	err = destDB.PushChunks(background.WithTask(ctx, task), dEnv.TempTableFilesDir(), srcDB, commit, progChan, pullerEventCh)
+	task.Done()
 
 	if err != nil {
-		return err
+		return chunks.MarkRemoteFormat(err, destDB.Format().VersionString())
 	}
 
 	switch mode {
@@ -94,9 +100,99 @@ func DeleteRemoteBranch(ctx context.Context, targetRef ref.BranchRef, remoteRef
 }
 
 func Fetch(ctx context.Context, dEnv *env.DoltEnv, destRef ref.DoltRef, srcDB, destDB *doltdb.DoltDB, srcDBCommit *doltdb.Commit, progChan chan datas.PullProgress, pullerEventCh chan datas.PullerEvent) error {
-	return destDB.PullChunks(ctx, dEnv.TempTableFilesDir(), srcDB, srcDBCommit, progChan, pullerEventCh)
+	err := destDB.PullChunks(ctx, dEnv.TempTableFilesDir(), srcDB, srcDBCommit, progChan, pullerEventCh)
+	return chunks.MarkRemoteFormat(err, srcDB.Format().VersionString())
+}
+
+// FetchTableRef fetches the table with the hash |h| from |srcDB|, along with all of its rows, and pins it in |destDB| so
+// that it's kept even though no commit references it.
+func FetchTableRef(ctx context.Context, dEnv *env.DoltEnv, srcDB, destDB *doltdb.DoltDB, h hash.Hash, progChan chan datas.PullProgress, pullerEventCh chan datas.PullerEvent) error {
+	err := destDB.PullTableChunks(ctx, dEnv.TempTableFilesDir(), srcDB, h, progChan, pullerEventCh)
+
+	if err != nil {
+		return chunks.MarkRemoteFormat(err, srcDB.Format().VersionString())
+	}
+
+	return destDB.PinTable(ctx, h)
 }
 
 func Clone(ctx context.Context, srcDB, destDB *doltdb.DoltDB, eventCh chan<- datas.TableFileEvent) error {
 	return srcDB.Clone(ctx, destDB, eventCh)
 }
+
+// PushTag points |tag| at the commit it names in the destination database, after sending the commit along with its
+// history. A tag which already exists in the destination database and names another commit is only replaced if the
+// update is forced, otherwise ErrTagExists is returned. ErrUpToDate is returned if it already names the same commit.
+func PushTag(ctx context.Context, dEnv *env.DoltEnv, mode ref.RefUpdateMode, tag Tag, srcDB, destDB *doltdb.DoltDB, progChan chan datas.PullProgress, pullerEventCh chan datas.PullerEvent) error {
+	err := checkTagUpdate(ctx, mode, tag, destDB)
+
+	if err != nil {
+		return err
+	}
+
+	err = destDB.PushChunks(ctx, dEnv.TempTableFilesDir(), srcDB, tag.Commit, progChan, pullerEventCh)
+
+	if err != nil {
+		return chunks.MarkRemoteFormat(err, destDB.Format().VersionString())
+	}
+
+	return destDB.NewTagAtCommit(ctx, tag.Ref, tag.Commit)
+}
+
+// FetchTag points |tag|, which was read from the source database, at the commit it names in the destination database
+// after fetching the commit along with its history. Existing tags are treated as they are by PushTag.
+func FetchTag(ctx context.Context, dEnv *env.DoltEnv, mode ref.RefUpdateMode, tag Tag, srcDB, destDB *doltdb.DoltDB, progChan chan datas.PullProgress, pullerEventCh chan datas.PullerEvent) error {
+	err := checkTagUpdate(ctx, mode, tag, destDB)
+
+	if err != nil {
+		return err
+	}
+
+	err = Fetch(ctx, dEnv, tag.Ref, srcDB, destDB, tag.Commit, progChan, pullerEventCh)
+
+	if err != nil {
+		return err
+	}
+
+	return destDB.NewTagAtCommit(ctx, tag.Ref, tag.Commit)
+}
+
+// DeleteRemoteTag deletes the tag given from the remote database.
+func DeleteRemoteTag(ctx context.Context, tagRef ref.TagRef, remoteDB *doltdb.DoltDB) error {
+	return remoteDB.DeleteTag(ctx, tagRef)
+}
+
+func checkTagUpdate(ctx context.Context, mode ref.RefUpdateMode, tag Tag, db *doltdb.DoltDB) error {
+	hasRef, err := db.HasRef(ctx, tag.Ref)
+
+	if err != nil || !hasRef {
+		return err
+	}
+
+	cs, _ := doltdb.NewCommitSpec("HEAD", tag.Ref.String())
+	cm, err := db.Resolve(ctx, cs)
+
+	if err != nil {
+		return err
+	}
+
+	existing, err := cm.HashOf()
+
+	if err != nil {
+		return err
+	}
+
+	h, err := tag.Commit.HashOf()
+
+	if err != nil {
+		return err
+	}
+
+	if existing == h {
+		return doltdb.ErrUpToDate
+	} else if !mode.Force {
+		return ErrTagExists
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/set"
+)
+
+var ErrSparseMergeActive = errors.New("the sparse working set cannot be changed during a merge")
+
+// SetSparseTables makes the tables matching the patterns given the sparse working set of the repository. An empty
+// list of patterns makes every table active again. The working and staged versions of the tables which aren't active
+// are reset to the versions in the HEAD commit, so that they can't differ from HEAD. Unless |force| is true, an error
+// is returned if any of those tables have changes which would be lost.
+func SetSparseTables(ctx context.Context, dEnv *env.DoltEnv, patterns []string, force bool) error {
+	if dEnv.IsMergeActive() {
+		return ErrSparseMergeActive
+	}
+
+	err := env.ValidateSparsePatterns(patterns)
+
+	if err != nil {
+		return err
+	}
+
+	roots, err := getRoots(ctx, dEnv, WorkingRoot, StagedRoot, HeadRoot)
+
+	if err != nil {
+		return err
+	}
+
+	tblNames, err := doltdb.UnionTableNames(ctx, roots[WorkingRoot], roots[StagedRoot], roots[HeadRoot])
+
+	if err != nil {
+		return err
+	}
+
+	var inactive []string
+	for _, tblName := range tblNames {
+		if len(patterns) > 0 && !env.MatchesSparsePatterns(patterns, tblName) {
+			inactive = append(inactive, tblName)
+		}
+	}
+
+	if !force {
+		changed, err := tablesChangedFromHead(ctx, inactive, roots)
+
+		if err != nil {
+			return err
+		} else if len(changed) > 0 {
+			return NewTblSparseChangedError(changed)
+		}
+	}
+
+	if len(inactive) > 0 {
+		working, err := roots[WorkingRoot].UpdateTablesFromOther(ctx, inactive, roots[HeadRoot])
+
+		if err != nil {
+			return err
+		}
+
+		staged, err := roots[StagedRoot].UpdateTablesFromOther(ctx, inactive, roots[HeadRoot])
+
+		if err != nil {
+			return err
+		}
+
+		_, err = dEnv.UpdateStagedRoot(ctx, staged)
+
+		if err != nil {
+			return err
+		}
+
+		err = dEnv.UpdateWorkingRoot(ctx, working)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	dEnv.RepoState.Sparse = patterns
+
+	if err = dEnv.RepoState.Save(dEnv.FS); err != nil {
+		return env.ErrStateUpdate
+	}
+
+	return nil
+}
+
+// tablesChangedFromHead returns the tables given whose working or staged versions differ from the version in HEAD.
+func tablesChangedFromHead(ctx context.Context, tblNames []string, roots map[RootType]*doltdb.RootValue) ([]string, error) {
+	changed := set.NewStrSet(nil)
+	for _, rt := range []RootType{WorkingRoot, StagedRoot} {
+		added, modified, removed, err := roots[rt].TableDiff(ctx, roots[HeadRoot])
+
+		if err != nil {
+			return nil, err
+		}
+
+		changed.Add(added...)
+		changed.Add(modified...)
+		changed.Add(removed...)
+	}
+
+	var tbls []string
+	for _, tblName := range tblNames {
+		if changed.Contains(tblName) {
+			tbls = append(tbls, tblName)
+		}
+	}
+
+	sort.Strings(tbls)
+
+	return tbls, nil
+}
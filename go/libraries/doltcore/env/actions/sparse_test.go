@@ -0,0 +1,77 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+func TestSetSparseTables(t *testing.T) {
+	ctx := context.Background()
+	dEnv := dtestutils.CreateTestEnv()
+	dtestutils.CreateTestTable(t, dEnv, "sales_a", dtestutils.TypedSchema)
+	dtestutils.CreateTestTable(t, dEnv, "hr", serverSch)
+	require.NoError(t, StageAllTables(ctx, dEnv, false))
+	require.NoError(t, CommitStaged(ctx, dEnv, "create tables", time.Now(), false))
+
+	// hr has uncommitted changes, which would be lost
+	dtestutils.CreateTestTable(t, dEnv, "hr", serverSch, newServerRow(t, "bill"))
+	err := SetSparseTables(ctx, dEnv, []string{"sales_*"}, false)
+	require.True(t, IsTblSparseChanged(err), "unexpected error %v", err)
+	assert.Equal(t, []string{"hr"}, GetTablesForError(err))
+	assert.False(t, dEnv.RepoState.IsSparse())
+
+	require.NoError(t, SetSparseTables(ctx, dEnv, []string{"sales_*"}, true))
+	assert.Equal(t, []string{"sales_*"}, dEnv.RepoState.Sparse)
+	assert.False(t, dEnv.RepoState.IsActiveTable("hr"))
+
+	// the changes to hr were discarded so that it points at the table in HEAD
+	working, err := dEnv.WorkingRoot(ctx)
+	require.NoError(t, err)
+	head, err := dEnv.HeadRoot(ctx)
+	require.NoError(t, err)
+	_, modified, _, err := working.TableDiff(ctx, head)
+	require.NoError(t, err)
+	assert.Empty(t, modified)
+
+	// only the changes to active tables are staged
+	dtestutils.CreateTestTable(t, dEnv, "sales_a", dtestutils.TypedSchema, dtestutils.NewTypedRow(uuid.New(), "jill", 40, true, nil))
+	dtestutils.CreateTestTable(t, dEnv, "hr", serverSch, newServerRow(t, "bill"))
+	require.NoError(t, StageActiveTables(ctx, dEnv, false))
+	staged, err := dEnv.StagedRoot(ctx)
+	require.NoError(t, err)
+	_, modified, _, err = staged.TableDiff(ctx, head)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sales_a"}, modified)
+
+	require.NoError(t, SetSparseTables(ctx, dEnv, nil, false))
+	assert.False(t, dEnv.RepoState.IsSparse())
+}
+
+func newServerRow(t *testing.T, name string) row.Row {
+	r, err := row.New(types.Format_7_18, serverSch, row.TaggedValues{100: types.UUID(uuid.New()), 101: types.String(name)})
+	require.NoError(t, err)
+	return r
+}
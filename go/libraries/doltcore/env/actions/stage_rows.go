@@ -0,0 +1,207 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const stageRowsDiffBufSize = 1024
+
+var ErrRowStagingSchemaChanged = errors.New("the schema of the table has changed. The whole table must be staged")
+var ErrRowStagingTableDeleted = errors.New("the table has been deleted. The whole table must be staged")
+
+// ErrStopStaging can be returned by a RowStageFn to stop iterating over the remaining changes. Changes accepted before
+// ErrStopStaging was returned are still staged.
+var ErrStopStaging = errors.New("stop staging")
+
+// RowStageFn is called for every row which differs between the staged and working versions of a table, and returns
+// whether that change should be staged. oldRow is nil for rows added in the working set, and newRow is nil for rows
+// deleted in the working set.
+type RowStageFn func(ctx context.Context, sch schema.Schema, oldRow, newRow row.Row) (bool, error)
+
+// StageTableRows stages the subset of row changes in the working version of a table which are accepted by stageFn.
+// It returns the number of row changes that were staged. Only changes to row data can be staged this way; if the
+// schema of the table differs between the staged and working roots the entire table must be staged.
+func StageTableRows(ctx context.Context, dEnv *env.DoltEnv, tblName string, stageFn RowStageFn) (int, error) {
+	staged, working, err := getStagedAndWorking(ctx, dEnv)
+
+	if err != nil {
+		return 0, err
+	}
+
+	workingTbl, ok, err := working.GetTable(ctx, tblName)
+
+	if err != nil {
+		return 0, err
+	} else if !ok {
+		if has, err := staged.HasTable(ctx, tblName); err != nil {
+			return 0, err
+		} else if has {
+			return 0, ErrRowStagingTableDeleted
+		}
+
+		return 0, NewTblNotExistError([]string{tblName})
+	}
+
+	if num, err := workingTbl.NumRowsInConflict(ctx); err != nil {
+		return 0, err
+	} else if num > 0 {
+		return 0, NewTblInConflictError([]string{tblName})
+	}
+
+	stagedTbl, ok, err := staged.GetTable(ctx, tblName)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var stagedRows types.Map
+	if ok {
+		if same, err := stagedTbl.HasTheSameSchema(workingTbl); err != nil {
+			return 0, err
+		} else if !same {
+			return 0, ErrRowStagingSchemaChanged
+		}
+
+		stagedRows, err = stagedTbl.GetRowData(ctx)
+
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		// a table which is new in the working set is staged as the working table with only the accepted rows
+		stagedTbl = workingTbl
+		stagedRows, err = types.NewMap(ctx, working.VRW())
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	sch, err := workingTbl.GetSchema(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	workingRows, err := workingTbl.GetRowData(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	newStagedRows, numStaged, err := applyAcceptedRowDiffs(ctx, sch, stagedRows, workingRows, stageFn)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if numStaged == 0 {
+		return 0, nil
+	}
+
+	stagedTbl, err = stagedTbl.UpdateRows(ctx, newStagedRows)
+
+	if err != nil {
+		return 0, err
+	}
+
+	staged, err = staged.PutTable(ctx, tblName, stagedTbl)
+
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = dEnv.UpdateStagedRoot(ctx, staged)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return numStaged, nil
+}
+
+func applyAcceptedRowDiffs(ctx context.Context, sch schema.Schema, stagedRows, workingRows types.Map, stageFn RowStageFn) (types.Map, int, error) {
+	ad := diff.NewAsyncDiffer(stageRowsDiffBufSize)
+	ad.Start(ctx, workingRows, stagedRows)
+	defer ad.Close()
+
+	numStaged := 0
+	me := stagedRows.Edit()
+	for !ad.IsDone() {
+		diffs, err := ad.GetDiffs(stageRowsDiffBufSize/2, time.Second)
+
+		if err != nil {
+			return types.EmptyMap, 0, err
+		}
+
+		for _, d := range diffs {
+			var oldRow, newRow row.Row
+			if d.OldValue != nil {
+				oldRow, err = row.FromNoms(sch, d.KeyValue.(types.Tuple), d.OldValue.(types.Tuple))
+
+				if err != nil {
+					return types.EmptyMap, 0, err
+				}
+			}
+
+			if d.NewValue != nil {
+				newRow, err = row.FromNoms(sch, d.KeyValue.(types.Tuple), d.NewValue.(types.Tuple))
+
+				if err != nil {
+					return types.EmptyMap, 0, err
+				}
+			}
+
+			accepted, err := stageFn(ctx, sch, oldRow, newRow)
+
+			if err == ErrStopStaging {
+				m, err := me.Map(ctx)
+				return m, numStaged, err
+			} else if err != nil {
+				return types.EmptyMap, 0, err
+			}
+
+			if !accepted {
+				continue
+			}
+
+			if d.ChangeType == types.DiffChangeRemoved {
+				me = me.Remove(d.KeyValue)
+			} else {
+				me = me.Set(d.KeyValue, d.NewValue)
+			}
+
+			numStaged++
+		}
+	}
+
+	m, err := me.Map(ctx)
+
+	if err != nil {
+		return types.EmptyMap, 0, err
+	}
+
+	return m, numStaged, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const stageRowsTestTable = "people"
+
+func TestStageTableRows(t *testing.T) {
+	ctx := context.Background()
+	dEnv := dtestutils.CreateTestEnv()
+	dtestutils.CreateTestTable(t, dEnv, stageRowsTestTable, dtestutils.TypedSchema, dtestutils.TypedRows...)
+
+	secondRow := types.UUID(dtestutils.UUIDS[1])
+	n, err := StageTableRows(ctx, dEnv, stageRowsTestTable, func(ctx context.Context, sch schema.Schema, oldRow, newRow row.Row) (bool, error) {
+		assert.Nil(t, oldRow)
+		id, _ := newRow.GetColVal(dtestutils.IdTag)
+		return id.Equals(secondRow), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	staged, err := dEnv.StagedRoot(ctx)
+	require.NoError(t, err)
+	tbl, ok, err := staged.GetTable(ctx, stageRowsTestTable)
+	require.NoError(t, err)
+	require.True(t, ok)
+	rows, err := tbl.GetRowData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), rows.Len())
+
+	_, ok, err = tbl.GetRowByPKVals(ctx, row.TaggedValues{dtestutils.IdTag: secondRow}, dtestutils.TypedSchema)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// stopping staging keeps the changes accepted so far
+	seen := 0
+	n, err = StageTableRows(ctx, dEnv, stageRowsTestTable, func(ctx context.Context, sch schema.Schema, oldRow, newRow row.Row) (bool, error) {
+		seen++
+		if seen == 1 {
+			return true, nil
+		}
+		return false, ErrStopStaging
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	staged, err = dEnv.StagedRoot(ctx)
+	require.NoError(t, err)
+	tbl, _, err = staged.GetTable(ctx, stageRowsTestTable)
+	require.NoError(t, err)
+	rows, err = tbl.GetRowData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), rows.Len())
+}
+
+func TestStageTableRowsMissingTable(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+	_, err := StageTableRows(context.Background(), dEnv, "not_a_table", func(ctx context.Context, sch schema.Schema, oldRow, newRow row.Row) (bool, error) {
+		return true, nil
+	})
+	assert.True(t, IsTblNotExist(err))
+}
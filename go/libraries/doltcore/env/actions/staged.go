@@ -16,13 +16,13 @@ package actions
 
 import (
 	"context"
-	"errors"
 
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/errcat"
 )
 
-var ErrTablesInConflict = errors.New("table is in conflict")
+var ErrTablesInConflict = errcat.New(errcat.Conflict, "table is in conflict")
 
 func StageTables(ctx context.Context, dEnv *env.DoltEnv, tbls []string, allowConflicts bool) error {
 	tables, docDetails, err := GetTblsAndDocDetails(dEnv, tbls)
@@ -68,6 +68,34 @@ func GetTblsAndDocDetails(dEnv *env.DoltEnv, tbls []string) (tables []string, do
 }
 
 func StageAllTables(ctx context.Context, dEnv *env.DoltEnv, allowConflicts bool) error {
+	return stageAllTables(ctx, dEnv, false, allowConflicts)
+}
+
+// StageActiveTables stages the changes to all of the tables in the sparse working set, leaving the changes to any
+// other tables unstaged.
+func StageActiveTables(ctx context.Context, dEnv *env.DoltEnv, allowConflicts bool) error {
+	return stageAllTables(ctx, dEnv, true, allowConflicts)
+}
+
+// StageTablesInConflict stages the tables of the working set which have conflicts, along with their conflicts, so that
+// they're committed for whoever pulls the commit to resolve. It returns the names of the tables it staged.
+func StageTablesInConflict(ctx context.Context, dEnv *env.DoltEnv) ([]string, error) {
+	staged, working, err := getStagedAndWorking(ctx, dEnv)
+
+	if err != nil {
+		return nil, err
+	}
+
+	inConflict, err := working.TablesInConflict(ctx)
+
+	if err != nil || len(inConflict) == 0 {
+		return nil, err
+	}
+
+	return inConflict, stageTables(ctx, dEnv, inConflict, staged, working, true)
+}
+
+func stageAllTables(ctx context.Context, dEnv *env.DoltEnv, activeOnly, allowConflicts bool) error {
 	err := dEnv.PutDocsToWorking(ctx, nil)
 	if err != nil {
 		return err
@@ -85,6 +113,16 @@ func StageAllTables(ctx context.Context, dEnv *env.DoltEnv, allowConflicts bool)
 		return err
 	}
 
+	if activeOnly {
+		tbls, _ = dEnv.RepoState.SplitSparseTables(tbls)
+	}
+
+	tbls, err = removeIgnoredTables(ctx, tbls, staged, working)
+
+	if err != nil {
+		return err
+	}
+
 	err = stageTables(ctx, dEnv, tbls, staged, working, allowConflicts)
 	if err != nil {
 		dEnv.ResetWorkingDocsToStagedDocs(ctx)
@@ -173,6 +211,31 @@ func stageTables(ctx context.Context, dEnv *env.DoltEnv, tbls []string, staged *
 	return doltdb.ErrNomsIO
 }
 
+// removeIgnoredTables returns the tables of |tbls| which aren't ignored by the dolt_ignore table of |working|. Only new
+// tables can be ignored, tables which exist in |staged| never are.
+func removeIgnoredTables(ctx context.Context, tbls []string, staged, working *doltdb.RootValue) ([]string, error) {
+	ips, err := doltdb.GetIgnorePatterns(ctx, working)
+
+	if err != nil || len(ips) == 0 {
+		return tbls, err
+	}
+
+	var kept []string
+	for _, tblName := range tbls {
+		if ips.IsTableNameIgnored(tblName) {
+			if has, err := staged.HasTable(ctx, tblName); err != nil {
+				return nil, err
+			} else if !has {
+				continue
+			}
+		}
+
+		kept = append(kept, tblName)
+	}
+
+	return kept, nil
+}
+
 func ValidateTables(ctx context.Context, tbls []string, roots ...*doltdb.RootValue) error {
 	var missing []string
 	for _, tbl := range tbls {
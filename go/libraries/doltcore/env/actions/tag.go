@@ -0,0 +1,124 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"errors"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+)
+
+// ErrTagExists is the error returned when a tag would be moved from the commit it names to another one without forcing
+// it.
+var ErrTagExists = errors.New("tag already exists")
+
+// Tag is a tag along with the commit it names.
+type Tag struct {
+	Ref    ref.TagRef
+	Commit *doltdb.Commit
+}
+
+// CreateTag creates the tag given at the starting point given, which can be anything that resolves to a commit. An
+// existing tag is only replaced if |force| is true.
+func CreateTag(ctx context.Context, dEnv *env.DoltEnv, tagName, startingPoint string, force bool) error {
+	tagRef := ref.NewTagRef(tagName)
+
+	if !doltdb.IsValidTagRef(tagRef) {
+		return doltdb.ErrInvBranchName
+	}
+
+	hasRef, err := dEnv.DoltDB.HasRef(ctx, tagRef)
+
+	if err != nil {
+		return err
+	}
+
+	if !force && hasRef {
+		return ErrAlreadyExists
+	}
+
+	cs, err := doltdb.NewCommitSpec(startingPoint, dEnv.RepoState.CWBHeadRef().String())
+
+	if err != nil {
+		return err
+	}
+
+	cm, err := dEnv.DoltDB.Resolve(ctx, cs)
+
+	if err != nil {
+		return err
+	}
+
+	return dEnv.DoltDB.NewTagAtCommit(ctx, tagRef, cm)
+}
+
+// DeleteTag deletes the tag with the name given.
+func DeleteTag(ctx context.Context, ddb *doltdb.DoltDB, tagName string) error {
+	return ddb.DeleteTag(ctx, ref.NewTagRef(tagName))
+}
+
+// GetTags returns every tag in the database given, along with the commits they name.
+func GetTags(ctx context.Context, ddb *doltdb.DoltDB) ([]Tag, error) {
+	refs, err := ddb.GetTags(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]Tag, 0, len(refs))
+	for _, r := range refs {
+		cs, _ := doltdb.NewCommitSpec("HEAD", r.String())
+		cm, err := ddb.Resolve(ctx, cs)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, Tag{r.(ref.TagRef), cm})
+	}
+
+	return tags, nil
+}
+
+// GetTagsReachableFrom returns the tags in the database given which name one of the commits given, or one of their
+// ancestors. The commits must be read from the same database.
+func GetTagsReachableFrom(ctx context.Context, ddb *doltdb.DoltDB, commits []*doltdb.Commit) ([]Tag, error) {
+	tags, err := GetTags(ctx, ddb)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var reachable []Tag
+	for _, tag := range tags {
+		for _, cm := range commits {
+			isAncestor, err := doltdb.IsAncestor(ctx, tag.Commit, cm)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if isAncestor {
+				reachable = append(reachable, tag)
+				break
+			}
+		}
+	}
+
+	return reachable, nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dolttestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+)
+
+func TestTags(t *testing.T) {
+	ctx := context.Background()
+	fix, err := dolttestutils.Build(ctx,
+		dolttestutils.PutTable{Name: "people", Schema: dtestutils.TypedSchema},
+		dolttestutils.Commit{Name: "people", Message: "create people"},
+		dolttestutils.PutTable{Name: "other", Schema: serverSch},
+		dolttestutils.Commit{Name: "other", Message: "create other"},
+	)
+	require.NoError(t, err)
+
+	dEnv := fix.DEnv
+	require.NoError(t, CreateTag(ctx, dEnv, "v1", fix.Commits["people"].String(), false))
+	require.NoError(t, CreateTag(ctx, dEnv, "v2", fix.Commits["other"].String(), false))
+
+	assert.Equal(t, ErrAlreadyExists, CreateTag(ctx, dEnv, "v1", "head", false))
+	assert.Equal(t, doltdb.ErrInvBranchName, CreateTag(ctx, dEnv, "v1.0", "head", false))
+
+	tags, err := GetTags(ctx, dEnv.DoltDB)
+	require.NoError(t, err)
+	require.Equal(t, []string{"refs/tags/v1", "refs/tags/v2"}, tagNames(tags))
+	v1, v2 := tags[0], tags[1]
+
+	reachable, err := GetTagsReachableFrom(ctx, dEnv.DoltDB, []*doltdb.Commit{v1.Commit})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"refs/tags/v1"}, tagNames(reachable))
+	reachable, err = GetTagsReachableFrom(ctx, dEnv.DoltDB, []*doltdb.Commit{v2.Commit})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"refs/tags/v1", "refs/tags/v2"}, tagNames(reachable))
+
+	remote, err := dolttestutils.Build(ctx)
+	require.NoError(t, err)
+	remoteEnv := remote.DEnv
+	remoteDB := remoteEnv.DoltDB
+	require.NoError(t, PushTag(ctx, dEnv, ref.FastForwardOnly, v1, dEnv.DoltDB, remoteDB, nil, nil))
+	assert.Equal(t, doltdb.ErrUpToDate, PushTag(ctx, dEnv, ref.FastForwardOnly, v1, dEnv.DoltDB, remoteDB, nil, nil))
+	assertTagNames(t, remoteEnv, v1.Commit)
+
+	// moving the tag locally doesn't move it on the remote unless the push is forced
+	require.NoError(t, CreateTag(ctx, dEnv, "v1", "v2", true))
+	moved := Tag{v1.Ref, v2.Commit}
+	assert.Equal(t, ErrTagExists, PushTag(ctx, dEnv, ref.FastForwardOnly, moved, dEnv.DoltDB, remoteDB, nil, nil))
+	assertTagNames(t, remoteEnv, v1.Commit)
+	require.NoError(t, PushTag(ctx, dEnv, ref.ForceUpdate, moved, dEnv.DoltDB, remoteDB, nil, nil))
+	assertTagNames(t, remoteEnv, v2.Commit)
+
+	// fetching follows the same rules
+	require.NoError(t, DeleteTag(ctx, dEnv.DoltDB, "v1"))
+	require.NoError(t, CreateTag(ctx, dEnv, "v1", "head~1", false))
+	assert.Equal(t, doltdb.ErrUpToDate, FetchTag(ctx, dEnv, ref.FastForwardOnly, v2, remoteDB, dEnv.DoltDB, nil, nil))
+	remoteTags, err := GetTags(ctx, remoteDB)
+	require.NoError(t, err)
+	assert.Equal(t, ErrTagExists, FetchTag(ctx, dEnv, ref.FastForwardOnly, remoteTags[0], remoteDB, dEnv.DoltDB, nil, nil))
+	require.NoError(t, FetchTag(ctx, dEnv, ref.ForceUpdate, remoteTags[0], remoteDB, dEnv.DoltDB, nil, nil))
+	assertTagNames(t, dEnv, v2.Commit, v2.Commit)
+
+	require.NoError(t, DeleteRemoteTag(ctx, v1.Ref, remoteDB))
+	assert.Equal(t, doltdb.ErrTagNotFound, DeleteRemoteTag(ctx, v1.Ref, remoteDB))
+	assertTagNames(t, remoteEnv)
+}
+
+func tagNames(tags []Tag) []string {
+	var names []string
+	for _, tag := range tags {
+		names = append(names, tag.Ref.String())
+	}
+
+	return names
+}
+
+// assertTagNames asserts that the tags of |dEnv| are v1, v2, and so on up to the number of commits given, and that
+// they name those commits.
+func assertTagNames(t *testing.T, dEnv *env.DoltEnv, commits ...*doltdb.Commit) {
+	tags, err := GetTags(context.Background(), dEnv.DoltDB)
+	require.NoError(t, err)
+	require.Len(t, tags, len(commits))
+
+	for i, tag := range tags {
+		assert.Equal(t, ref.NewTagRef("v"+string(rune('1'+i))), tag.Ref)
+
+		expected, err := commits[i].HashOf()
+		require.NoError(t, err)
+		actual, err := tag.Commit.HashOf()
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	}
+}
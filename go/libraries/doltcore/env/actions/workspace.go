@@ -0,0 +1,142 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"sort"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions/commitwalk"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+)
+
+// Workspace is the workspace of a sql-server user or session. Its commit holds the changes made in the workspace, and
+// its parent is the commit of the branch the changes were made on top of.
+type Workspace struct {
+	Ref    ref.WorkspaceRef
+	Commit *doltdb.Commit
+	Base   *doltdb.Commit
+}
+
+// WorkspaceDivergence describes how a workspace differs from the head of its branch.
+type WorkspaceDivergence struct {
+	// ChangedTables are the names of the tables changed in the workspace since its base commit.
+	ChangedTables []string
+	// CommitsBehind is the number of commits made to the branch since the workspace's base commit.
+	CommitsBehind int
+}
+
+// GetWorkspaces returns every workspace in the database given, sorted by name.
+func GetWorkspaces(ctx context.Context, ddb *doltdb.DoltDB) ([]Workspace, error) {
+	refs, err := ddb.GetWorkspaces(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	workspaces := make([]Workspace, 0, len(refs))
+	for _, r := range refs {
+		ws, err := GetWorkspace(ctx, ddb, r.GetPath())
+
+		if err != nil {
+			return nil, err
+		}
+
+		workspaces = append(workspaces, ws)
+	}
+
+	sort.Slice(workspaces, func(i, j int) bool {
+		return workspaces[i].Ref.GetPath() < workspaces[j].Ref.GetPath()
+	})
+
+	return workspaces, nil
+}
+
+// GetWorkspace returns the workspace with the name given, or doltdb.ErrWorkspaceNotFound if there is none.
+func GetWorkspace(ctx context.Context, ddb *doltdb.DoltDB, name string) (Workspace, error) {
+	wsRef := ref.NewWorkspaceRef(name)
+	cs, _ := doltdb.NewCommitSpec("HEAD", wsRef.String())
+	cm, err := ddb.Resolve(ctx, cs)
+
+	if err == doltdb.ErrBranchNotFound {
+		return Workspace{}, doltdb.ErrWorkspaceNotFound
+	} else if err != nil {
+		return Workspace{}, err
+	}
+
+	base, err := ddb.ResolveParent(ctx, cm, 0)
+
+	if err != nil {
+		return Workspace{}, err
+	}
+
+	return Workspace{wsRef, cm, base}, nil
+}
+
+// DeleteWorkspace deletes the workspace with the name given.
+func DeleteWorkspace(ctx context.Context, ddb *doltdb.DoltDB, name string) error {
+	return ddb.DeleteWorkspace(ctx, ref.NewWorkspaceRef(name))
+}
+
+// Divergence returns how the workspace differs from |head|, the current head of its branch.
+func (ws Workspace) Divergence(ctx context.Context, ddb *doltdb.DoltDB, head *doltdb.Commit) (WorkspaceDivergence, error) {
+	root, err := ws.Commit.GetRootValue()
+
+	if err != nil {
+		return WorkspaceDivergence{}, err
+	}
+
+	return GetWorkspaceDivergence(ctx, ddb, root, ws.Base, head)
+}
+
+// GetWorkspaceDivergence returns how a workspace whose working root is |root| and whose base commit is |base| differs
+// from |head|, the current head of its branch.
+func GetWorkspaceDivergence(ctx context.Context, ddb *doltdb.DoltDB, root *doltdb.RootValue, base, head *doltdb.Commit) (WorkspaceDivergence, error) {
+	baseRoot, err := base.GetRootValue()
+
+	if err != nil {
+		return WorkspaceDivergence{}, err
+	}
+
+	added, modified, removed, err := root.TableDiff(ctx, baseRoot)
+
+	if err != nil {
+		return WorkspaceDivergence{}, err
+	}
+
+	changed := append(append(added, modified...), removed...)
+	sort.Strings(changed)
+
+	baseHash, err := base.HashOf()
+
+	if err != nil {
+		return WorkspaceDivergence{}, err
+	}
+
+	headHash, err := head.HashOf()
+
+	if err != nil {
+		return WorkspaceDivergence{}, err
+	}
+
+	behind, err := commitwalk.GetDotDotRevisions(ctx, ddb, headHash, baseHash, 0)
+
+	if err != nil {
+		return WorkspaceDivergence{}, err
+	}
+
+	return WorkspaceDivergence{changed, len(behind)}, nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+// IndexID returns the name of the index |idxName| of the table |tblName|, as it's given to dolt index commands.
+func IndexID(tblName, idxName string) string {
+	return tblName + "." + idxName
+}
+
+// IsBrokenIndex returns whether the index |idxName| of the table |tblName| failed its last verification, and hasn't
+// been rebuilt since.
+func (rs *RepoState) IsBrokenIndex(tblName, idxName string) bool {
+	id := IndexID(tblName, idxName)
+	for _, broken := range rs.BrokenIndexes {
+		if broken == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MarkBrokenIndex records whether the index |idxName| of the table |tblName| is broken. The repo state must be saved
+// for the mark to be seen by other commands.
+func (rs *RepoState) MarkBrokenIndex(tblName, idxName string, broken bool) {
+	id := IndexID(tblName, idxName)
+	marks := make([]string, 0, len(rs.BrokenIndexes)+1)
+	for _, mark := range rs.BrokenIndexes {
+		if mark != id {
+			marks = append(marks, mark)
+		}
+	}
+
+	if broken {
+		marks = append(marks, id)
+	}
+
+	if len(marks) == 0 {
+		marks = nil
+	}
+
+	rs.BrokenIndexes = marks
+}
@@ -0,0 +1,40 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkBrokenIndex(t *testing.T) {
+	rs := &RepoState{}
+	assert.False(t, rs.IsBrokenIndex("people", "PRIMARY"))
+
+	rs.MarkBrokenIndex("people", "PRIMARY", true)
+	rs.MarkBrokenIndex("people", "PRIMARY", true)
+	rs.MarkBrokenIndex("pets", "PRIMARY", true)
+	assert.Equal(t, []string{"people.PRIMARY", "pets.PRIMARY"}, rs.BrokenIndexes)
+	assert.True(t, rs.IsBrokenIndex("people", "PRIMARY"))
+	assert.False(t, rs.IsBrokenIndex("people", "other"))
+
+	rs.MarkBrokenIndex("people", "PRIMARY", false)
+	assert.False(t, rs.IsBrokenIndex("people", "PRIMARY"))
+	assert.True(t, rs.IsBrokenIndex("pets", "PRIMARY"))
+
+	rs.MarkBrokenIndex("pets", "PRIMARY", false)
+	assert.Nil(t, rs.BrokenIndexes)
+}
@@ -33,11 +33,17 @@ const (
 	UserEmailKey = "user.email"
 	UserNameKey  = "user.name"
 
+	// UserRolesKey lists the roles of the user, separated by commas, which the permission policies of a repository are
+	// granted to
+	UserRolesKey = "user.roles"
+
 	// should be able to have remote specific creds?
 	UserCreds = "user.creds"
 
 	DoltEditor = "core.editor"
 
+	CommitTemplate = "commit.template"
+
 	RemotesApiHostKey     = "remotes.default_host"
 	RemotesApiHostPortKey = "remotes.default_port"
 
@@ -47,6 +53,9 @@ const (
 	MetricsHost     = "metrics.host"
 	MetricsPort     = "metrics.port"
 	MetricsInsecure = "metrics.insecure"
+
+	// WorkingBackupRetention is how long working set backups are kept, as a duration such as 72h.
+	WorkingBackupRetention = "backup.retention"
 )
 
 var LocalConfigWhitelist = set.NewStrSet([]string{UserNameKey, UserEmailKey})
@@ -0,0 +1,154 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/liquidata-inc/dolt/go/store/nbs"
+)
+
+// ErrNoRepoToCopy is returned by CopyOnWrite when the source directory isn't a repository.
+var ErrNoRepoToCopy = errors.New("there is no dolt repository to copy")
+
+// CopyOnWrite makes |destDir| a repository holding the branches, working set and docs of the repository in |srcDir|,
+// without copying the data of its database. The database's table files are shared with the source repository as
+// described by nbs.CopyOnWriteStore, and the rest of the .dolt directory, which is small, is copied, so the
+// repositories diverge from the first write to either of them. The source repository isn't locked while it's copied,
+// so it shouldn't be written to until the copy returns.
+func CopyOnWrite(ctx context.Context, srcDir, destDir string) (err error) {
+	srcDoltDir := filepath.Join(srcDir, dbfactory.DoltDir)
+	destDoltDir := filepath.Join(destDir, dbfactory.DoltDir)
+
+	if info, statErr := os.Stat(srcDoltDir); statErr != nil || !info.IsDir() {
+		return ErrNoRepoToCopy
+	}
+
+	if _, statErr := os.Stat(destDoltDir); statErr == nil {
+		return ErrPreexistingDoltDir
+	}
+
+	if err := os.MkdirAll(destDoltDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = os.RemoveAll(destDoltDir)
+		}
+	}()
+
+	infos, err := ioutil.ReadDir(srcDoltDir)
+
+	if err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		srcPath := filepath.Join(srcDoltDir, info.Name())
+		destPath := filepath.Join(destDoltDir, info.Name())
+
+		switch info.Name() {
+		case dbfactory.DataDir:
+			err = nbs.CopyOnWriteStore(ctx, srcPath, destPath)
+		case tempTablesDir:
+			err = os.Mkdir(destPath, os.ModePerm)
+		case lockFile, lockInfoFile:
+			// the copy isn't locked by the process locking the source
+		default:
+			err = copyRepoPath(srcPath, destPath)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, doc := range *AllValidDocDetails {
+		srcPath := filepath.Join(srcDoltDir, doc.File)
+
+		if _, statErr := os.Stat(srcPath); statErr == nil {
+			if err := copyRepoPath(srcPath, filepath.Join(destDoltDir, doc.File)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// copyRepoPath copies the file or directory at |srcPath| to |destPath|.
+func copyRepoPath(srcPath, destPath string) error {
+	info, err := os.Stat(srcPath)
+
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return copyRepoFile(srcPath, destPath, info.Mode())
+	}
+
+	if err := os.Mkdir(destPath, info.Mode()); err != nil {
+		return err
+	}
+
+	infos, err := ioutil.ReadDir(srcPath)
+
+	if err != nil {
+		return err
+	}
+
+	for _, child := range infos {
+		if err := copyRepoPath(filepath.Join(srcPath, child.Name()), filepath.Join(destPath, child.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyRepoFile(srcPath, destPath string, mode os.FileMode) (err error) {
+	src, err := os.Open(srcPath)
+
+	if err != nil {
+		return err
+	}
+
+	defer src.Close()
+
+	dest, err := os.OpenFile(destPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		closeErr := dest.Close()
+
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	_, err = io.Copy(dest, src)
+	return err
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// loadLocalEnv loads the repository in |dir| on the local filesystem.
+func loadLocalEnv(t *testing.T, dir string) *DoltEnv {
+	fs, err := filesys.LocalFilesysWithWorkingDir(dir)
+	require.NoError(t, err)
+
+	return Load(context.Background(), testHomeDirFunc, fs, "file://"+filepath.Join(dir, dbfactory.DoltDataDir), "test")
+}
+
+func TestCopyOnWrite(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "cow_copy")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	srcDir, destDir := filepath.Join(dir, "src"), filepath.Join(dir, "dest")
+	require.NoError(t, os.Mkdir(srcDir, os.ModePerm))
+	src := loadLocalEnv(t, srcDir)
+	require.NoError(t, src.InitRepo(ctx, types.Format_Default, "bheni", "bheni@dolthub.com"))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(srcDir, "README.md"), []byte("readme"), os.ModePerm))
+	_, err = LockRepo(src.FS, filepath.Join(srcDir, dbfactory.DoltDir), NewLockInfo("dolt sql-server", 3306))
+	require.NoError(t, err)
+
+	require.NoError(t, CopyOnWrite(ctx, srcDir, destDir))
+
+	dest := loadLocalEnv(t, destDir)
+	require.NoError(t, dest.DBLoadError)
+	assert.Equal(t, src.RepoState.Head, dest.RepoState.Head)
+	assert.Equal(t, src.RepoState.Working, dest.RepoState.Working)
+	readme, err := ioutil.ReadFile(filepath.Join(destDir, "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "readme", string(readme))
+	assert.True(t, dest.HasDoltTempTableDir())
+
+	// the copy isn't locked by the process which locked the source
+	info, err := ReadLockInfo(dest.FS, filepath.Join(destDir, dbfactory.DoltDir))
+	require.NoError(t, err)
+	assert.Nil(t, info)
+
+	srcBranches, err := src.DoltDB.GetBranches(ctx)
+	require.NoError(t, err)
+	destBranches, err := dest.DoltDB.GetBranches(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, srcBranches, destBranches)
+
+	// a branch made in the copy isn't seen by the source
+	cs, _ := doltdb.NewCommitSpec("HEAD", "master")
+	master, err := dest.DoltDB.Resolve(ctx, cs)
+	require.NoError(t, err)
+	require.NoError(t, dest.DoltDB.NewBranchAtCommit(ctx, ref.NewBranchRef("other"), master))
+
+	has, err := dest.DoltDB.HasRef(ctx, ref.NewBranchRef("other"))
+	require.NoError(t, err)
+	assert.True(t, has)
+	reloaded := loadLocalEnv(t, srcDir)
+	has, err = reloaded.DoltDB.HasRef(ctx, ref.NewBranchRef("other"))
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	assert.Equal(t, ErrPreexistingDoltDir, CopyOnWrite(ctx, srcDir, destDir))
+	assert.Equal(t, ErrNoRepoToCopy, CopyOnWrite(ctx, filepath.Join(dir, "missing"), filepath.Join(dir, "other")))
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/creds"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/errcat"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
@@ -51,6 +52,8 @@ const (
 	DefaultRemotesApiHost = "doltremoteapi.dolthub.com"
 	DefaultRemotesApiPort = "443"
 	tempTablesDir         = "temptf"
+	pushStateDir          = "push"
+	schemaCacheFile       = "schema_cache.json"
 )
 
 var ErrPreexistingDoltDir = errors.New(".dolt dir already exists")
@@ -103,14 +106,16 @@ func Load(ctx context.Context, hdp HomeDirProvider, fs filesys.Filesys, urlStr,
 	}
 
 	if dbLoadErr == nil && dEnv.HasDoltDir() {
+		tempDir := dEnv.TempTableFilesDir()
+
 		if !dEnv.HasDoltTempTableDir() {
-			err := os.Mkdir(dEnv.TempTableFilesDir(), os.ModePerm)
+			err := os.Mkdir(tempDir, os.ModePerm)
 			dEnv.DBLoadError = err
 		} else {
 			// fire and forget cleanup routine.  Will delete as many old temp files as it can during the main commands execution.
 			// The process will not wait for this to finish so this may not always complete.
 			go func() {
-				_ = fs.Iter(dEnv.TempTableFilesDir(), true, func(path string, size int64, isDir bool) (stop bool) {
+				_ = fs.Iter(tempDir, true, func(path string, size int64, isDir bool) (stop bool) {
 					if !isDir {
 						lm, exists := fs.LastModified(path)
 
@@ -123,6 +128,10 @@ func Load(ctx context.Context, hdp HomeDirProvider, fs filesys.Filesys, urlStr,
 				})
 			}()
 		}
+
+		if dEnv.DBLoadError == nil {
+			doltdb.PersistSchemaCache(fs, filepath.Join(tempDir, schemaCacheFile), version)
+		}
 	}
 
 	dbfactory.InitializeFactories(dEnv)
@@ -217,6 +226,35 @@ func (dEnv *DoltEnv) InitRepo(ctx context.Context, nbf *types.NomsBinFormat, nam
 }
 
 func (dEnv *DoltEnv) InitRepoWithTime(ctx context.Context, nbf *types.NomsBinFormat, name, email string, t time.Time) error { // should remove name and email args
+	return dEnv.initRepo(func() error {
+		return dEnv.InitDBAndRepoState(ctx, nbf, name, email, t)
+	})
+}
+
+// InitRepoWithCommitMeta initializes the repo in the same way as InitRepo, using |cm| as the metadata of the commit
+// which initializes the database.
+func (dEnv *DoltEnv) InitRepoWithCommitMeta(ctx context.Context, nbf *types.NomsBinFormat, cm *doltdb.CommitMeta) error {
+	return dEnv.initRepo(func() error {
+		var err error
+		dEnv.DoltDB, err = doltdb.LoadDoltDB(ctx, nbf, dEnv.urlStr)
+
+		if err != nil {
+			return err
+		}
+
+		err = dEnv.DoltDB.WriteEmptyRepoWithCommitMeta(ctx, cm)
+
+		if err != nil {
+			return doltdb.ErrNomsIO
+		}
+
+		return dEnv.initializeRepoState(ctx)
+	})
+}
+
+// initRepo creates the .dolt directory and local config of a new repo, and then calls |initDB| to create its database
+// and repo state. Everything is removed again if any of it fails.
+func (dEnv *DoltEnv) initRepo(initDB func() error) error {
 	doltDir, err := dEnv.createDirectories(".")
 
 	if err != nil {
@@ -226,7 +264,7 @@ func (dEnv *DoltEnv) InitRepoWithTime(ctx context.Context, nbf *types.NomsBinFor
 	err = dEnv.configureRepo(doltDir)
 
 	if err == nil {
-		err = dEnv.InitDBAndRepoState(ctx, nbf, name, email, t)
+		err = initDB()
 	}
 
 	if err != nil {
@@ -736,7 +774,7 @@ func (dEnv *DoltEnv) GetRefSpecs(remoteName string) ([]ref.RemoteRefSpec, errhan
 	} else if r, ok := dEnv.RepoState.Remotes[remoteName]; ok {
 		remote = r
 	} else {
-		verr = errhand.BuildDError("error: unknown remote '%s'", remoteName).Build()
+		verr = errhand.BuildDError("error: unknown remote '%s'", remoteName).SetCategory(errcat.NotFound).Build()
 	}
 
 	if verr != nil {
@@ -763,7 +801,7 @@ func (dEnv *DoltEnv) GetRefSpecs(remoteName string) ([]ref.RemoteRefSpec, errhan
 	return refSpecs, nil
 }
 
-var ErrNoRemote = errhand.BuildDError("error: no remote.").Build()
+var ErrNoRemote = errhand.BuildDError("error: no remote.").SetCategory(errcat.NotFound).Build()
 var ErrCantDetermineDefault = errhand.BuildDError("error: unable to determine the default remote.").Build()
 
 // GetDefaultRemote gets the default remote for the environment.  Not fully implemented yet.  Needs to support multiple
@@ -786,6 +824,28 @@ func (dEnv *DoltEnv) GetDefaultRemote() (Remote, errhand.VerboseError) {
 	return NoRemote, ErrCantDetermineDefault
 }
 
+// Principal returns the user of the CLI, named by user.name, and their roles, named by user.roles, which the
+// permission policies of the repository limit. Without user.roles the user of the CLI owns the repository, and has the
+// admin role.
+func (dEnv *DoltEnv) Principal() doltdb.Principal {
+	principal := doltdb.Principal{User: *dEnv.Config.GetStringOrDefault(UserNameKey, "")}
+
+	roles, err := dEnv.Config.GetString(UserRolesKey)
+
+	if err != nil {
+		principal.Roles = []string{doltdb.AdminRole}
+		return principal
+	}
+
+	for _, role := range strings.Split(roles, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			principal.Roles = append(principal.Roles, role)
+		}
+	}
+
+	return principal
+}
+
 // GetUserHomeDir returns the user's home dir
 // based on current filesys
 func (dEnv *DoltEnv) GetUserHomeDir() (string, error) {
@@ -796,6 +856,12 @@ func (dEnv *DoltEnv) TempTableFilesDir() string {
 	return mustAbs(dEnv, dEnv.GetDoltDir(), tempTablesDir)
 }
 
+// PushStateDir returns the directory in which pushes keep the state of their uploads of table files to remotes. It's
+// within the temp table files dir, so the state of uploads which are never resumed is cleaned up with old temp files.
+func (dEnv *DoltEnv) PushStateDir() string {
+	return filepath.Join(dEnv.TempTableFilesDir(), pushStateDir)
+}
+
 func (dEnv *DoltEnv) GetAllValidDocDetails() (docs []doltdb.DocDetails, err error) {
 	docs = []doltdb.DocDetails{}
 	for _, doc := range *AllValidDocDetails {
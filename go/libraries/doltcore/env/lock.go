@@ -0,0 +1,262 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/errcat"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+const (
+	lockFile     = "lock"
+	lockInfoFile = "lock_info.json"
+)
+
+// errLockHeld is returned by tryLockFile when another open file holds the lock of the file.
+var errLockHeld = errors.New("lock is held")
+
+// LockInfo describes the process which holds the lock of a repository.
+type LockInfo struct {
+	Pid       int       `json:"pid"`
+	Command   string    `json:"command"`
+	Port      int       `json:"port,omitempty"`
+	StartTime time.Time `json:"start_time"`
+}
+
+// NewLockInfo returns the LockInfo of the current process, which is running |command| and, if |port| isn't 0,
+// listening on |port|.
+func NewLockInfo(command string, port int) LockInfo {
+	return LockInfo{Pid: os.Getpid(), Command: command, Port: port, StartTime: time.Now().UTC()}
+}
+
+// String returns a description of the process holding the lock.
+func (info LockInfo) String() string {
+	if info.Port != 0 {
+		return fmt.Sprintf("%s (pid %d, port %d, started %s)", info.Command, info.Pid, info.Port, info.StartTime.Format(time.RFC3339))
+	}
+
+	return fmt.Sprintf("%s (pid %d, started %s)", info.Command, info.Pid, info.StartTime.Format(time.RFC3339))
+}
+
+// RepoLockedError is returned by LockRepo when another process holds the lock of the repository.
+type RepoLockedError struct {
+	// Info describes the process holding the lock. It's nil if the process hasn't written its LockInfo yet.
+	Info *LockInfo
+}
+
+func (e RepoLockedError) Error() string {
+	if e.Info == nil {
+		return "the repository is locked by another process"
+	}
+
+	return "the repository is locked by " + e.Info.String()
+}
+
+// Category implements errcat.Categorized. A repository locked by another process is a concurrent modification.
+func (e RepoLockedError) Category() errcat.Category {
+	return errcat.ConcurrentModification
+}
+
+// IsRepoLocked returns whether |err| is a RepoLockedError.
+func IsRepoLocked(err error) bool {
+	_, ok := err.(RepoLockedError)
+	return ok
+}
+
+// RepoLock is an advisory lock of a repository held by the current process. The lock is held on the open lock file of
+// the repository, flock on unix and LockFileEx on Windows, so it's released by the operating system when the process
+// exits, however it exits. A lock left by a process which has died is therefore reclaimed by the next process to lock
+// the repository, which overwrites the process's LockInfo.
+type RepoLock struct {
+	fs       filesys.Filesys
+	infoPath string
+	mu       *sync.Mutex
+	unlock   func() error
+}
+
+// LockRepo locks the repository whose .dolt directory is |doltDir|, recording |info| as the description of the
+// process holding the lock. It returns a RepoLockedError if another process holds the lock.
+func LockRepo(fs filesys.Filesys, doltDir string, info LockInfo) (*RepoLock, error) {
+	unlock, err := tryLock(fs, filepath.Join(doltDir, lockFile))
+
+	if err == errLockHeld {
+		held, err := readLockInfo(fs, doltDir)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, RepoLockedError{held}
+	} else if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(info)
+
+	if err == nil {
+		// the info is written to a temporary file which is then moved into place, so that it's never read when
+		// it's partially written
+		tmpPath := filepath.Join(doltDir, lockInfoFile+".tmp")
+		err = fs.WriteFile(tmpPath, data)
+
+		if err == nil {
+			err = fs.MoveFile(tmpPath, filepath.Join(doltDir, lockInfoFile))
+		}
+	}
+
+	if err != nil {
+		_ = unlock()
+		return nil, err
+	}
+
+	return &RepoLock{fs, filepath.Join(doltDir, lockInfoFile), &sync.Mutex{}, unlock}, nil
+}
+
+// ReadLockInfo returns the LockInfo of the process holding the lock of the repository whose .dolt directory is
+// |doltDir|, or nil if it isn't locked. The LockInfo left by a process which died while holding the lock is deleted.
+func ReadLockInfo(fs filesys.Filesys, doltDir string) (*LockInfo, error) {
+	unlock, err := tryLock(fs, filepath.Join(doltDir, lockFile))
+
+	if err == errLockHeld {
+		return readLockInfo(fs, doltDir)
+	} else if err != nil {
+		return nil, err
+	}
+
+	defer unlock()
+
+	infoPath := filepath.Join(doltDir, lockInfoFile)
+	if exists, _ := fs.Exists(infoPath); exists {
+		err = fs.DeleteFile(infoPath)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// readLockInfo reads the LockInfo of the process holding the lock of a repository. It returns nil if the process
+// hasn't written it yet.
+func readLockInfo(fs filesys.Filesys, doltDir string) (*LockInfo, error) {
+	infoPath := filepath.Join(doltDir, lockInfoFile)
+	if exists, _ := fs.Exists(infoPath); !exists {
+		return nil, nil
+	}
+
+	data, err := fs.ReadFile(infoPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var info LockInfo
+	err = json.Unmarshal(data, &info)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid lock info file '%s': %w", infoPath, err)
+	}
+
+	return &info, nil
+}
+
+// Unlock releases the lock. Calling Unlock on a lock which has been released does nothing.
+func (l *RepoLock) Unlock() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.unlock == nil {
+		return nil
+	}
+
+	err := l.fs.DeleteFile(l.infoPath)
+	unlockErr := l.unlock()
+	l.unlock = nil
+
+	if err != nil {
+		return err
+	}
+
+	return unlockErr
+}
+
+// Lock locks the repository of the environment, recording |info| as the description of the current process. It
+// returns a RepoLockedError if another process holds the lock.
+func (dEnv *DoltEnv) Lock(info LockInfo) (*RepoLock, error) {
+	return LockRepo(dEnv.FS, mustAbs(dEnv, dbfactory.DoltDir), info)
+}
+
+// LockInfo returns the LockInfo of the process holding the lock of the repository of the environment, or nil if it
+// isn't locked.
+func (dEnv *DoltEnv) LockInfo() (*LockInfo, error) {
+	return ReadLockInfo(dEnv.FS, mustAbs(dEnv, dbfactory.DoltDir))
+}
+
+// inMemLocks holds the paths of the lock files of filesystems other than the local filesystem which are locked, so
+// that repositories of environments in memory are locked like repositories on disk within a process.
+var inMemLocks = struct {
+	mu     *sync.Mutex
+	locked map[inMemLockKey]bool
+}{&sync.Mutex{}, make(map[inMemLockKey]bool)}
+
+type inMemLockKey struct {
+	fs   filesys.Filesys
+	path string
+}
+
+// tryLock takes the lock of the file at |path|, returning a function which releases it, or errLockHeld if it's held.
+func tryLock(fs filesys.Filesys, path string) (func() error, error) {
+	if fs == filesys.LocalFS {
+		absPath, err := fs.Abs(path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		f, err := tryLockFile(absPath)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return f.Close, nil
+	}
+
+	key := inMemLockKey{fs, path}
+	inMemLocks.mu.Lock()
+	defer inMemLocks.mu.Unlock()
+
+	if inMemLocks.locked[key] {
+		return nil, errLockHeld
+	}
+
+	inMemLocks.locked[key] = true
+	return func() error {
+		inMemLocks.mu.Lock()
+		defer inMemLocks.mu.Unlock()
+		delete(inMemLocks.locked, key)
+		return nil
+	}, nil
+}
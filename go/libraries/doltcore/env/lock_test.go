@@ -0,0 +1,139 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+const lockHelperDirEnvVar = "DOLT_TEST_LOCK_HELPER_DIR"
+
+func tempDoltDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "dolt-lock-test")
+	require.NoError(t, err)
+
+	return dir
+}
+
+func testLockRepo(t *testing.T, fs filesys.Filesys, doltDir string) {
+	info := NewLockInfo("dolt sql-server", 3306)
+	lock, err := LockRepo(fs, doltDir, info)
+	require.NoError(t, err)
+
+	read, err := ReadLockInfo(fs, doltDir)
+	require.NoError(t, err)
+	require.NotNil(t, read)
+	assert.Equal(t, info.Pid, read.Pid)
+	assert.Equal(t, 3306, read.Port)
+	assert.True(t, info.StartTime.Equal(read.StartTime))
+
+	// the lock is exclusive, even within a process
+	_, err = LockRepo(fs, doltDir, NewLockInfo("dolt commit", 0))
+	require.True(t, IsRepoLocked(err), "unexpected error: %v", err)
+	assert.Contains(t, err.Error(), "dolt sql-server (pid")
+
+	require.NoError(t, lock.Unlock())
+	require.NoError(t, lock.Unlock())
+	read, err = ReadLockInfo(fs, doltDir)
+	require.NoError(t, err)
+	assert.Nil(t, read)
+
+	// the info left by a process which died holding the lock is ignored and deleted
+	require.NoError(t, fs.WriteFile(filepath.Join(doltDir, lockInfoFile), []byte(`{"pid":1,"command":"dolt sql-server"}`)))
+	read, err = ReadLockInfo(fs, doltDir)
+	require.NoError(t, err)
+	assert.Nil(t, read)
+	exists, _ := fs.Exists(filepath.Join(doltDir, lockInfoFile))
+	assert.False(t, exists)
+
+	lock, err = LockRepo(fs, doltDir, NewLockInfo("dolt commit", 0))
+	require.NoError(t, err)
+	require.NoError(t, lock.Unlock())
+}
+
+func TestLockRepo(t *testing.T) {
+	t.Run("local", func(t *testing.T) {
+		doltDir := tempDoltDir(t)
+		defer os.RemoveAll(doltDir)
+		testLockRepo(t, filesys.LocalFS, doltDir)
+	})
+	t.Run("in memory", func(t *testing.T) {
+		testLockRepo(t, filesys.NewInMemFS([]string{workingDir}, nil, workingDir), workingDir)
+	})
+}
+
+// TestLockHelperProcess isn't a test. It's run in a child process by TestLockReclaimedFromDeadProcess to lock a
+// repository until it's killed.
+func TestLockHelperProcess(t *testing.T) {
+	doltDir := os.Getenv(lockHelperDirEnvVar)
+	if doltDir == "" {
+		t.Skip("only run as a child process")
+	}
+
+	_, err := LockRepo(filesys.LocalFS, doltDir, NewLockInfo("lock helper", 0))
+	if err != nil {
+		os.Stdout.WriteString(err.Error() + "\n")
+		os.Exit(1)
+	}
+
+	os.Stdout.WriteString("locked\n")
+	time.Sleep(time.Minute)
+	os.Exit(1)
+}
+
+func TestLockReclaimedFromDeadProcess(t *testing.T) {
+	doltDir := tempDoltDir(t)
+	defer os.RemoveAll(doltDir)
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLockHelperProcess$")
+	cmd.Env = append(os.Environ(), lockHelperDirEnvVar+"="+doltDir)
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+	defer cmd.Process.Kill()
+
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "locked\n", line)
+
+	info, err := ReadLockInfo(filesys.LocalFS, doltDir)
+	require.NoError(t, err)
+	require.NotNil(t, info)
+	assert.Equal(t, cmd.Process.Pid, info.Pid)
+	_, err = LockRepo(filesys.LocalFS, doltDir, NewLockInfo("dolt commit", 0))
+	require.True(t, IsRepoLocked(err), "unexpected error: %v", err)
+
+	// the process dies without unlocking, leaving its lock info behind
+	require.NoError(t, cmd.Process.Kill())
+	_ = cmd.Wait()
+
+	info, err = ReadLockInfo(filesys.LocalFS, doltDir)
+	require.NoError(t, err)
+	assert.Nil(t, info)
+	lock, err := LockRepo(filesys.LocalFS, doltDir, NewLockInfo("dolt commit", 0))
+	require.NoError(t, err)
+	require.NoError(t, lock.Unlock())
+}
@@ -0,0 +1,48 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package env
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// tryLockFile opens the file at |path|, creating it if it doesn't exist, and takes an exclusive flock of it. The lock
+// is held until the returned file is closed. The file is opened close-on-exec, so child processes don't inherit the
+// lock. Returns errLockHeld if another open file holds the lock.
+func tryLockFile(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+
+	if err != nil {
+		f.Close()
+
+		if err == unix.EWOULDBLOCK {
+			return nil, errLockHeld
+		}
+
+		return nil, err
+	}
+
+	return f, nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build windows
+
+package env
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// tryLockFile opens the file at |path|, creating it if it doesn't exist, and takes an exclusive LockFileEx lock of its
+// first byte. The lock is held until the returned file is closed. Returns errLockHeld if another open file holds the
+// lock.
+func tryLockFile(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, &windows.Overlapped{})
+
+	if err != nil {
+		f.Close()
+
+		if err == windows.ERROR_LOCK_VIOLATION {
+			return nil, errLockHeld
+		}
+
+		return nil, err
+	}
+
+	return f, nil
+}
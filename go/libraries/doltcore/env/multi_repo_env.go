@@ -235,6 +235,12 @@ func LoadMultiEnvFromDir(ctx context.Context, hdp HomeDirProvider, fs filesys.Fi
 	return LoadMultiEnv(ctx, hdp, fs, version, envNamesAndPaths...)
 }
 
+// DBNameForPath returns the name given to the database in the directory at |path| when it's loaded from a directory of
+// databases.
+func DBNameForPath(path string) string {
+	return dirToDBName(filepath.Base(path))
+}
+
 func dirToDBName(dirName string) string {
 	dbName := strings.TrimSpace(dirName)
 	dbName = strings.Map(func(r rune) rune {
@@ -17,7 +17,9 @@ package env
 import (
 	"context"
 
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/store/chunks"
 	"github.com/liquidata-inc/dolt/go/store/types"
 )
 
@@ -50,5 +52,27 @@ func (r *Remote) GetParamOrDefault(pName, defVal string) string {
 }
 
 func (r *Remote) GetRemoteDB(ctx context.Context, nbf *types.NomsBinFormat) (*doltdb.DoltDB, error) {
-	return doltdb.LoadDoltDBWithParams(ctx, nbf, r.Url, r.Params)
+	return r.loadDB(ctx, nbf, r.Params)
+}
+
+// GetRemoteDBForPush returns the database of the remote, which keeps the state of its uploads of table files in
+// |pushStateDir|, so that a push which fails partway through uploading a large table file is resumed by the next push.
+func (r *Remote) GetRemoteDBForPush(ctx context.Context, nbf *types.NomsBinFormat, pushStateDir string) (*doltdb.DoltDB, error) {
+	params := make(map[string]string, len(r.Params)+1)
+	for k, v := range r.Params {
+		params[k] = v
+	}
+	params[dbfactory.PushStateDirParam] = pushStateDir
+
+	return r.loadDB(ctx, nbf, params)
+}
+
+func (r *Remote) loadDB(ctx context.Context, nbf *types.NomsBinFormat, params map[string]string) (*doltdb.DoltDB, error) {
+	ddb, err := doltdb.LoadDoltDBWithParams(ctx, nbf, r.Url, params)
+
+	if ife, ok := err.(*chunks.ErrIncompatibleFormat); ok {
+		return nil, ife.AsRemote()
+	}
+
+	return ddb, err
 }
@@ -31,6 +31,16 @@ type RepoStateReader interface {
 	StagedHash() hash.Hash
 }
 
+// RemotesReader is implemented by the RepoStateReaders which can list the remotes of the repository.
+type RemotesReader interface {
+	GetRemotes() map[string]Remote
+}
+
+// BrokenIndexesReader is implemented by the RepoStateReaders which know which indexes of the repository are broken.
+type BrokenIndexesReader interface {
+	IsBrokenIndex(tblName, idxName string) bool
+}
+
 type RepoStateWriter interface {
 	// SetCWBHeadRef(context.Context, ref.DoltRef) error
 	// SetCWBHeadSpec(context.Context, *doltdb.CommitSpec) error
@@ -56,6 +66,11 @@ type RepoState struct {
 	Merge    *MergeState             `json:"merge"`
 	Remotes  map[string]Remote       `json:"remotes"`
 	Branches map[string]BranchConfig `json:"branches"`
+	Sparse   []string                `json:"sparse,omitempty"`
+	// Backups are the most recent working set backups, oldest first. See BackupWorkingSet.
+	Backups []WorkingBackup `json:"working_backups,omitempty"`
+	// BrokenIndexes are the indexes which failed verification, by their IndexIDs. See MarkBrokenIndex.
+	BrokenIndexes []string `json:"broken_indexes,omitempty"`
 }
 
 func LoadRepoState(fs filesys.ReadWriteFS) (*RepoState, error) {
@@ -86,6 +101,9 @@ func CloneRepoState(fs filesys.ReadWriteFS, r Remote) (*RepoState, error) {
 		nil,
 		map[string]Remote{r.Name: r},
 		make(map[string]BranchConfig),
+		nil,
+		nil,
+		nil,
 	}
 
 	err := rs.Save(fs)
@@ -112,6 +130,9 @@ func CreateRepoState(fs filesys.ReadWriteFS, br string, rootHash hash.Hash) (*Re
 		nil,
 		make(map[string]Remote),
 		make(map[string]BranchConfig),
+		nil,
+		nil,
+		nil,
 	}
 
 	err = rs.Save(fs)
@@ -164,6 +185,11 @@ func (rs *RepoState) AddRemote(r Remote) {
 	rs.Remotes[r.Name] = r
 }
 
+// GetRemotes returns the remotes of the repository by name.
+func (rs *RepoState) GetRemotes() map[string]Remote {
+	return rs.Remotes
+}
+
 func (rs *RepoState) WorkingHash() hash.Hash {
 	return hash.Parse(rs.Working)
 }
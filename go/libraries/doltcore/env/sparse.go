@@ -0,0 +1,76 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+)
+
+// ValidateSparsePatterns returns an error if any of the table name patterns given is malformed. Patterns use the
+// syntax of path.Match, so "sales_*" matches every table whose name begins with "sales_".
+func ValidateSparsePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid table pattern '%s': %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// IsSparse returns whether the repository has a sparse working set, in which only the tables matching the sparse
+// patterns are active.
+func (rs *RepoState) IsSparse() bool {
+	return len(rs.Sparse) > 0
+}
+
+// IsActiveTable returns whether the table named is in the sparse working set. Every table is active when the
+// repository doesn't have a sparse working set.
+func (rs *RepoState) IsActiveTable(tblName string) bool {
+	return !rs.IsSparse() || MatchesSparsePatterns(rs.Sparse, tblName)
+}
+
+// MatchesSparsePatterns returns whether the table named matches one of the sparse patterns given. dolt system tables
+// match any patterns, so they're always active.
+func MatchesSparsePatterns(patterns []string, tblName string) bool {
+	if doltdb.HasDoltPrefix(tblName) {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, tblName); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SplitSparseTables splits the table names given into those which are in the sparse working set and those which
+// aren't.
+func (rs *RepoState) SplitSparseTables(tblNames []string) (active, inactive []string) {
+	for _, tblName := range tblNames {
+		if rs.IsActiveTable(tblName) {
+			active = append(active, tblName)
+		} else {
+			inactive = append(inactive, tblName)
+		}
+	}
+
+	return active, inactive
+}
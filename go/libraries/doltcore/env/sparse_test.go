@@ -0,0 +1,44 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsActiveTable(t *testing.T) {
+	rs := &RepoState{}
+	assert.False(t, rs.IsSparse())
+	assert.True(t, rs.IsActiveTable("hr"))
+
+	rs.Sparse = []string{"sales_*", "inventory"}
+	assert.True(t, rs.IsSparse())
+	assert.True(t, rs.IsActiveTable("sales_2020"))
+	assert.True(t, rs.IsActiveTable("inventory"))
+	assert.True(t, rs.IsActiveTable("dolt_docs"))
+	assert.False(t, rs.IsActiveTable("hr"))
+	assert.False(t, rs.IsActiveTable("inventory_old"))
+
+	active, inactive := rs.SplitSparseTables([]string{"hr", "sales_a", "inventory", "sales"})
+	assert.Equal(t, []string{"sales_a", "inventory"}, active)
+	assert.Equal(t, []string{"hr", "sales"}, inactive)
+}
+
+func TestValidateSparsePatterns(t *testing.T) {
+	assert.NoError(t, ValidateSparsePatterns([]string{"sales_*", "t?", "[a-c]*"}))
+	assert.Error(t, ValidateSparsePatterns([]string{"sales_*", "sales_["}))
+}
@@ -0,0 +1,150 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// MaxWorkingBackups is the number of working set backups a repository keeps. Recording another drops the oldest.
+const MaxWorkingBackups = 16
+
+// DefaultWorkingBackupRetention is how long working set backups are kept when WorkingBackupRetention isn't set.
+const DefaultWorkingBackupRetention = 7 * 24 * time.Hour
+
+var ErrNoWorkingBackup = errors.New("there is no working set backup to restore")
+
+// WorkingBackup records the working and staged roots of a repository as they were before a command replaced them. The
+// roots are already in the chunk store, so a backup is only their hashes. While a backup is kept, its roots are live
+// roots of the repository: anything which removes unreferenced chunks from the store must keep them.
+type WorkingBackup struct {
+	Working string `json:"working"`
+	Staged  string `json:"staged"`
+	// Command is the command which replaced the roots, such as "reset --hard".
+	Command string    `json:"command"`
+	Time    time.Time `json:"time"`
+}
+
+func (wb WorkingBackup) WorkingHash() hash.Hash {
+	return hash.Parse(wb.Working)
+}
+
+func (wb WorkingBackup) StagedHash() hash.Hash {
+	return hash.Parse(wb.Staged)
+}
+
+// WorkingBackupRetentionPeriod returns how long working set backups are kept. A value of WorkingBackupRetention which
+// isn't a valid duration is ignored.
+func (dEnv *DoltEnv) WorkingBackupRetentionPeriod() time.Duration {
+	if val, err := dEnv.Config.GetString(WorkingBackupRetention); err == nil {
+		if d, err := time.ParseDuration(val); err == nil && d >= 0 {
+			return d
+		}
+	}
+
+	return DefaultWorkingBackupRetention
+}
+
+// BackupWorkingSet records a backup of the working and staged roots before |command| replaces them, so that they can
+// be restored with RestoreWorkingBackup. Nothing is recorded when the roots are those of HEAD, as nothing would be lost
+// by replacing them, or when they're the roots of the most recent backup. Backups older than the retention period, and
+// the oldest backups past MaxWorkingBackups, are dropped. It returns whether a backup was recorded.
+func (dEnv *DoltEnv) BackupWorkingSet(ctx context.Context, command string) (bool, error) {
+	headRoot, err := dEnv.HeadRoot(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	headHash, err := headRoot.HashOf()
+
+	if err != nil {
+		return false, err
+	}
+
+	rs := dEnv.RepoState
+	if rs.WorkingHash() == headHash && rs.StagedHash() == headHash {
+		return false, nil
+	}
+
+	if n := len(rs.Backups); n > 0 && rs.Backups[n-1].Working == rs.Working && rs.Backups[n-1].Staged == rs.Staged {
+		return false, nil
+	}
+
+	dEnv.addWorkingBackup(WorkingBackup{rs.Working, rs.Staged, command, time.Now()})
+	err = rs.Save(dEnv.FS)
+
+	if err != nil {
+		return false, ErrStateUpdate
+	}
+
+	return true, nil
+}
+
+func (dEnv *DoltEnv) addWorkingBackup(wb WorkingBackup) {
+	rs := dEnv.RepoState
+	cutoff := wb.Time.Add(-dEnv.WorkingBackupRetentionPeriod())
+
+	var kept []WorkingBackup
+	for _, backup := range rs.Backups {
+		if backup.Time.After(cutoff) {
+			kept = append(kept, backup)
+		}
+	}
+
+	kept = append(kept, wb)
+	if len(kept) > MaxWorkingBackups {
+		kept = kept[len(kept)-MaxWorkingBackups:]
+	}
+
+	rs.Backups = kept
+}
+
+// RestoreWorkingBackup replaces the working and staged roots with those of the most recent working set backup, and
+// returns the backup restored. The roots it replaces are backed up in its place, so restoring again undoes the
+// restore. ErrNoWorkingBackup is returned when there's no backup within the retention period.
+func (dEnv *DoltEnv) RestoreWorkingBackup(ctx context.Context, command string) (WorkingBackup, error) {
+	rs := dEnv.RepoState
+	cutoff := time.Now().Add(-dEnv.WorkingBackupRetentionPeriod())
+
+	n := len(rs.Backups)
+	if n == 0 || !rs.Backups[n-1].Time.After(cutoff) {
+		return WorkingBackup{}, ErrNoWorkingBackup
+	}
+
+	wb := rs.Backups[n-1]
+	for _, h := range []hash.Hash{wb.WorkingHash(), wb.StagedHash()} {
+		if _, err := dEnv.DoltDB.ReadRootValue(ctx, h); err != nil {
+			return WorkingBackup{}, err
+		}
+	}
+
+	rs.Backups = rs.Backups[:n-1]
+	dEnv.addWorkingBackup(WorkingBackup{rs.Working, rs.Staged, command, time.Now()})
+
+	rs.Working = wb.Working
+	rs.Staged = wb.Staged
+	err := rs.Save(dEnv.FS)
+
+	if err != nil {
+		return WorkingBackup{}, ErrStateUpdate
+	}
+
+	return wb, nil
+}
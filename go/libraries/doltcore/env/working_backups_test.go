@@ -0,0 +1,95 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+func TestBackupAndRestoreWorkingSet(t *testing.T) {
+	ctx := context.Background()
+	dEnv := createTestEnv(false, false)
+	require.NoError(t, dEnv.InitRepo(ctx, types.Format_7_18, "aoeu aoeu", "aoeu@aoeu.org"))
+
+	// nothing is lost by replacing the roots of HEAD, so they aren't backed up
+	backedUp, err := dEnv.BackupWorkingSet(ctx, "reset --hard")
+	require.NoError(t, err)
+	assert.False(t, backedUp)
+
+	_, err = dEnv.RestoreWorkingBackup(ctx, "reset --undo")
+	assert.Equal(t, ErrNoWorkingBackup, err)
+
+	clean := dEnv.RepoState.Working
+	docs := []doltdb.DocDetails{{DocPk: doltdb.ReadmePk, File: ReadmeFile, NewerText: []byte("changed")}}
+	require.NoError(t, dEnv.PutDocsToWorking(ctx, docs))
+	changed := dEnv.RepoState.Working
+
+	backedUp, err = dEnv.BackupWorkingSet(ctx, "reset --hard")
+	require.NoError(t, err)
+	assert.True(t, backedUp)
+
+	// the same roots aren't backed up twice
+	backedUp, err = dEnv.BackupWorkingSet(ctx, "checkout")
+	require.NoError(t, err)
+	assert.False(t, backedUp)
+
+	dEnv.RepoState.Working = clean
+	wb, err := dEnv.RestoreWorkingBackup(ctx, "reset --undo")
+	require.NoError(t, err)
+	assert.Equal(t, "reset --hard", wb.Command)
+	assert.Equal(t, changed, dEnv.RepoState.Working)
+
+	// restoring backed up the roots it replaced, so restoring again undoes it
+	wb, err = dEnv.RestoreWorkingBackup(ctx, "reset --undo")
+	require.NoError(t, err)
+	assert.Equal(t, "reset --undo", wb.Command)
+	assert.Equal(t, clean, dEnv.RepoState.Working)
+
+	rs, err := LoadRepoState(dEnv.FS)
+	require.NoError(t, err)
+	require.Len(t, rs.Backups, 1)
+	assert.Equal(t, changed, rs.Backups[0].Working)
+	assert.True(t, wb.Time.Before(rs.Backups[0].Time))
+}
+
+func TestWorkingBackupRetention(t *testing.T) {
+	dEnv := createTestEnv(true, true)
+	assert.Equal(t, DefaultWorkingBackupRetention, dEnv.WorkingBackupRetentionPeriod())
+
+	cfg, _ := dEnv.Config.GetConfig(LocalConfig)
+	require.NoError(t, cfg.SetStrings(map[string]string{WorkingBackupRetention: "1h"}))
+	assert.Equal(t, time.Hour, dEnv.WorkingBackupRetentionPeriod())
+
+	now := time.Now()
+	dEnv.RepoState.Backups = []WorkingBackup{{Command: "expired", Time: now.Add(-2 * time.Hour)}}
+	for i := 0; i < MaxWorkingBackups; i++ {
+		dEnv.addWorkingBackup(WorkingBackup{Command: "checkout", Time: now})
+	}
+
+	dEnv.addWorkingBackup(WorkingBackup{Command: "last", Time: now})
+	require.Len(t, dEnv.RepoState.Backups, MaxWorkingBackups)
+	assert.Equal(t, "last", dEnv.RepoState.Backups[MaxWorkingBackups-1].Command)
+
+	require.NoError(t, cfg.SetStrings(map[string]string{WorkingBackupRetention: "not a duration"}))
+	assert.Equal(t, DefaultWorkingBackupRetention, dEnv.WorkingBackupRetentionPeriod())
+}
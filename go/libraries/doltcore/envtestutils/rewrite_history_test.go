@@ -0,0 +1,228 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envtestutils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	dtu "github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	tc "github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils/testcommands"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/rebase"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const createSecretsTable = `create table secrets (id int primary key, password varchar(20));`
+
+var rewriteHistorySetup = []tc.Command{
+	tc.Query{Query: createPeopleTable},
+	tc.Query{Query: `insert into people (id, name, age) values (7, "Maggie Simpson", 1);`},
+	tc.CommitAll{Message: "created people"},
+	tc.Query{Query: createSecretsTable},
+	tc.Query{Query: `insert into secrets values (1, "hunter2");`},
+	tc.CommitAll{Message: "created secrets"},
+	tc.Branch{BranchName: "other"},
+	tc.Query{Query: `insert into people (id, name, age) values (8, "Milhouse Van Houten", 8);`},
+	tc.CommitAll{Message: "added milhouse"},
+	tc.Checkout{BranchName: "other"},
+	tc.Query{Query: `insert into secrets values (2, "swordfish");`},
+	tc.CommitAll{Message: "added a secret"},
+	tc.Checkout{BranchName: "master"},
+	tc.Merge{BranchName: "other"},
+	tc.CommitAll{Message: "merged other"},
+}
+
+func setupRewriteHistoryTest(t *testing.T) *env.DoltEnv {
+	dEnv := dtu.CreateTestEnv()
+	for _, cmd := range rewriteHistorySetup {
+		require.NoError(t, cmd.Exec(t, dEnv))
+	}
+
+	return dEnv
+}
+
+func resolveHistoryCommits(t *testing.T, ddb *doltdb.DoltDB, refStr string) []*doltdb.Commit {
+	cs, err := doltdb.NewCommitSpec("HEAD", refStr)
+	require.NoError(t, err)
+	cm, err := ddb.Resolve(context.Background(), cs)
+	require.NoError(t, err)
+
+	var commits []*doltdb.Commit
+	itr := doltdb.CommitItrForRoots(ddb, cm)
+	for {
+		_, cm, err := itr.Next(context.Background())
+
+		if err != nil {
+			break
+		}
+
+		commits = append(commits, cm)
+	}
+
+	return commits
+}
+
+func TestRewriteHistoryRemovesTable(t *testing.T) {
+	ctx := context.Background()
+	dEnv := setupRewriteHistoryTest(t)
+	oldMaster := resolveHistoryCommits(t, dEnv.DoltDB, "master")
+
+	res, err := rebase.RewriteHistory(ctx, dEnv, rebase.HistoryFilter{Table: "secrets"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []ref.DoltRef{ref.NewBranchRef("master"), ref.NewBranchRef("other")}, res.Refs)
+
+	newMaster := make(map[hash.Hash]*doltdb.Commit)
+	for _, cm := range resolveHistoryCommits(t, dEnv.DoltDB, "master") {
+		h, _ := cm.HashOf()
+		newMaster[h] = cm
+	}
+	require.Equal(t, len(oldMaster), len(newMaster))
+
+	rewritten := 0
+	for _, oldCm := range oldMaster {
+		oldHash, _ := oldCm.HashOf()
+		newHash := oldHash
+		if mapped, ok := res.Commits[oldHash]; ok {
+			newHash = mapped
+			rewritten++
+		}
+
+		newCm, ok := newMaster[newHash]
+		require.True(t, ok)
+
+		oldMeta, err := oldCm.GetCommitMeta()
+		require.NoError(t, err)
+		newMeta, err := newCm.GetCommitMeta()
+		require.NoError(t, err)
+		assert.Equal(t, oldMeta, newMeta)
+
+		oldParents, _ := oldCm.NumParents()
+		newParents, _ := newCm.NumParents()
+		assert.Equal(t, oldParents, newParents)
+
+		root, err := newCm.GetRootValue()
+		require.NoError(t, err)
+		hasSecrets, err := root.HasTable(ctx, "secrets")
+		require.NoError(t, err)
+		assert.False(t, hasSecrets)
+	}
+
+	// the init commit and the commit creating people never had the secrets table
+	assert.Equal(t, len(oldMaster)-2, rewritten)
+
+	backupCs, err := doltdb.NewCommitSpec("HEAD", ref.NewBackupRef(ref.NewBranchRef("master")).String())
+	require.NoError(t, err)
+	backupCm, err := dEnv.DoltDB.Resolve(ctx, backupCs)
+	require.NoError(t, err)
+	backupHash, _ := backupCm.HashOf()
+	oldHash, _ := oldMaster[0].HashOf()
+	assert.Equal(t, oldHash, backupHash)
+
+	working, err := dEnv.WorkingRoot(ctx)
+	require.NoError(t, err)
+	hasSecrets, err := working.HasTable(ctx, "secrets")
+	require.NoError(t, err)
+	assert.False(t, hasSecrets)
+
+	exists, _ := dEnv.FS.Exists(rebase.RewriteMappingFile)
+	assert.True(t, exists)
+
+	_, err = rebase.RewriteHistory(ctx, dEnv, rebase.HistoryFilter{Table: "people"}, false)
+	assert.Equal(t, rebase.ErrHistoryBackupExists, err)
+
+	n, err := rebase.DeleteHistoryBackup(ctx, dEnv.DoltDB)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	_, err = dEnv.DoltDB.Resolve(ctx, backupCs)
+	assert.Error(t, err)
+}
+
+func TestRewriteHistoryRemovesColumn(t *testing.T) {
+	ctx := context.Background()
+	dEnv := setupRewriteHistoryTest(t)
+
+	_, err := rebase.RewriteHistory(ctx, dEnv, rebase.HistoryFilter{Table: "secrets", Column: "id"}, false)
+	assert.Equal(t, rebase.ErrRewritePKColumn, err)
+
+	_, err = rebase.RewriteHistory(ctx, dEnv, rebase.HistoryFilter{Table: "secrets", Column: "password"}, false)
+	require.NoError(t, err)
+
+	for _, cm := range resolveHistoryCommits(t, dEnv.DoltDB, "master") {
+		root, err := cm.GetRootValue()
+		require.NoError(t, err)
+		tbl, ok, err := root.GetTable(ctx, "secrets")
+		require.NoError(t, err)
+
+		if !ok {
+			continue
+		}
+
+		sch, err := tbl.GetSchema(ctx)
+		require.NoError(t, err)
+		_, ok = sch.GetAllCols().GetByName("password")
+		assert.False(t, ok)
+
+		rowData, err := tbl.GetRowData(ctx)
+		require.NoError(t, err)
+		err = rowData.IterAll(ctx, func(key, value types.Value) error {
+			tv, err := row.ParseTaggedValues(value.(types.Tuple))
+			require.NoError(t, err)
+			assert.Empty(t, tv)
+			return nil
+		})
+		require.NoError(t, err)
+	}
+}
+
+func TestRewriteHistoryNothingToRewrite(t *testing.T) {
+	ctx := context.Background()
+	dEnv := setupRewriteHistoryTest(t)
+	oldMaster := resolveHistoryCommits(t, dEnv.DoltDB, "master")
+
+	_, err := rebase.RewriteHistory(ctx, dEnv, rebase.HistoryFilter{Table: "missing"}, false)
+	assert.Equal(t, rebase.ErrNothingToRewrite, err)
+
+	_, err = rebase.RewriteHistory(ctx, dEnv, rebase.HistoryFilter{Table: "people", Column: "missing"}, false)
+	assert.Equal(t, rebase.ErrNothingToRewrite, err)
+
+	newMaster := resolveHistoryCommits(t, dEnv.DoltDB, "master")
+	require.Equal(t, len(oldMaster), len(newMaster))
+	for i := range oldMaster {
+		oldHash, _ := oldMaster[i].HashOf()
+		newHash, _ := newMaster[i].HashOf()
+		assert.Equal(t, oldHash, newHash)
+	}
+
+	backups, err := dEnv.DoltDB.GetRefsOfType(ctx, map[ref.RefType]struct{}{ref.BackupRefType: {}})
+	require.NoError(t, err)
+	assert.Empty(t, backups)
+
+	checkSchema(t, mustWorkingRoot(t, dEnv), "people", schema.SchemaFromCols(people))
+}
+
+func mustWorkingRoot(t *testing.T, dEnv *env.DoltEnv) *doltdb.RootValue {
+	root, err := dEnv.WorkingRoot(context.Background())
+	require.NoError(t, err)
+	return root
+}
@@ -0,0 +1,223 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package errcat categorizes the errors of dolt by what went wrong, so that callers can react to a kind of failure
+// without matching error messages. Each category has an exit code, which is the exit code of a dolt command failing
+// with an error of the category.
+package errcat
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/liquidata-inc/dolt/go/store/datas"
+)
+
+// Category is the kind of failure an error describes.
+type Category int
+
+const (
+	// Unknown is the category of errors which aren't categorized.
+	Unknown Category = iota
+
+	// Conflict is the category of errors caused by changes which conflict with each other, such as a merge with
+	// conflicts or a push to a remote branch which has diverged.
+	Conflict
+
+	// ConstraintViolation is the category of errors caused by data which violates the schema of a table, such as a
+	// row with a null primary key or a value which can't be converted to the type of its column.
+	ConstraintViolation
+
+	// Auth is the category of errors caused by missing, invalid or insufficient credentials.
+	Auth
+
+	// Network is the category of errors caused by a remote which can't be reached.
+	Network
+
+	// NotFound is the category of errors caused by a branch, tag, table, commit or remote which doesn't exist.
+	NotFound
+
+	// ConcurrentModification is the category of errors caused by another writer, such as a branch which was updated
+	// while committing or a repository locked by another process.
+	ConcurrentModification
+)
+
+// Exit codes of the categories. 1 is the exit code of a command failing with an error which isn't categorized, and 2
+// the exit code of a command run outside of a repository.
+const (
+	ExitCodeUnknown                = 1
+	ExitCodeConflict               = 3
+	ExitCodeConstraintViolation    = 4
+	ExitCodeAuth                   = 5
+	ExitCodeNetwork                = 6
+	ExitCodeNotFound               = 7
+	ExitCodeConcurrentModification = 8
+)
+
+var categoryNames = map[Category]string{
+	Unknown:                "unknown",
+	Conflict:               "conflict",
+	ConstraintViolation:    "constraint_violation",
+	Auth:                   "auth",
+	Network:                "network",
+	NotFound:               "not_found",
+	ConcurrentModification: "concurrent_modification",
+}
+
+var categoryExitCodes = map[Category]int{
+	Unknown:                ExitCodeUnknown,
+	Conflict:               ExitCodeConflict,
+	ConstraintViolation:    ExitCodeConstraintViolation,
+	Auth:                   ExitCodeAuth,
+	Network:                ExitCodeNetwork,
+	NotFound:               ExitCodeNotFound,
+	ConcurrentModification: ExitCodeConcurrentModification,
+}
+
+// String returns the name of the category, which is the category of the errors printed by --json-errors.
+func (c Category) String() string {
+	if name, ok := categoryNames[c]; ok {
+		return name
+	}
+
+	return categoryNames[Unknown]
+}
+
+// ExitCode returns the exit code of a command failing with an error of the category.
+func (c Category) ExitCode() int {
+	if code, ok := categoryExitCodes[c]; ok {
+		return code
+	}
+
+	return ExitCodeUnknown
+}
+
+// Categorized is implemented by errors which know their category.
+type Categorized interface {
+	error
+	Category() Category
+}
+
+type categorizedErr struct {
+	cat Category
+	err error
+}
+
+func (e *categorizedErr) Error() string {
+	return e.err.Error()
+}
+
+func (e *categorizedErr) Unwrap() error {
+	return e.err
+}
+
+func (e *categorizedErr) Category() Category {
+	return e.cat
+}
+
+// New returns an error of the category |cat| with the message |msg|. Like errors.New, each call returns a distinct
+// error, so it's used to declare sentinel errors.
+func New(cat Category, msg string) error {
+	return &categorizedErr{cat, errors.New(msg)}
+}
+
+// Errorf returns an error of the category |cat| whose message is formatted as with fmt.Errorf, so that %w wraps an
+// error.
+func Errorf(cat Category, format string, args ...interface{}) error {
+	return &categorizedErr{cat, fmt.Errorf(format, args...)}
+}
+
+// Wrap returns an error of the category |cat| with the message of |err|, which it wraps. It returns nil if |err| is
+// nil.
+func Wrap(cat Category, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &categorizedErr{cat, err}
+}
+
+// Of returns the category of |err|. That's the category of the first error in the chain of |err| and its causes which
+// has one, unwrapping with either Unwrap or Cause. Errors of the noms layer, network errors and gRPC statuses are
+// categorized as well. Of returns Unknown if no error in the chain has a category.
+func Of(err error) Category {
+	for err != nil {
+		if cat := categoryOf(err); cat != Unknown {
+			return cat
+		}
+
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		case interface{ Cause() error }:
+			err = e.Cause()
+		default:
+			err = nil
+		}
+	}
+
+	return Unknown
+}
+
+// Is returns whether |err| is of the category |cat|.
+func Is(err error, cat Category) bool {
+	return err != nil && Of(err) == cat
+}
+
+// ForCode returns the category of an error with the gRPC status code |code|.
+func ForCode(code codes.Code) Category {
+	switch code {
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return Auth
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return Network
+	case codes.NotFound:
+		return NotFound
+	case codes.Aborted:
+		return ConcurrentModification
+	case codes.AlreadyExists:
+		return Conflict
+	default:
+		return Unknown
+	}
+}
+
+func categoryOf(err error) Category {
+	if c, ok := err.(Categorized); ok {
+		return c.Category()
+	}
+
+	switch err {
+	case datas.ErrOptimisticLockFailed:
+		return ConcurrentModification
+	case datas.ErrMergeNeeded:
+		return Conflict
+	}
+
+	// net.Error is also implemented by syscall.Errno, so a file which doesn't exist would be a network error
+	switch err.(type) {
+	case *net.OpError, *net.DNSError:
+		return Network
+	}
+
+	if st, ok := err.(interface{ GRPCStatus() *status.Status }); ok {
+		return ForCode(st.GRPCStatus().Code())
+	}
+
+	return Unknown
+}
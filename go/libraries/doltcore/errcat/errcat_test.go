@@ -0,0 +1,112 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errcat
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/liquidata-inc/dolt/go/store/datas"
+)
+
+func TestOf(t *testing.T) {
+	errNotFound := New(NotFound, "thing not found")
+
+	tests := []struct {
+		name     string
+		err      error
+		expected Category
+	}{
+		{"nil", nil, Unknown},
+		{"uncategorized", errors.New("bad"), Unknown},
+		{"new", errNotFound, NotFound},
+		{"wrapped with fmt", fmt.Errorf("looking for thing: %w", errNotFound), NotFound},
+		{"wrapped with pkg/errors", pkgerrors.Wrap(errNotFound, "looking for thing"), NotFound},
+		{"wrap", Wrap(Auth, errors.New("bad creds")), Auth},
+		{"outermost category", Wrap(Conflict, errNotFound), Conflict},
+		{"unknown wrapping categorized", Wrap(Unknown, errNotFound), NotFound},
+		{"errorf", Errorf(ConstraintViolation, "row %d: %w", 1, errors.New("null key")), ConstraintViolation},
+		{"optimistic lock", datas.ErrOptimisticLockFailed, ConcurrentModification},
+		{"merge needed", fmt.Errorf("push: %w", datas.ErrMergeNeeded), Conflict},
+		{"net", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, Network},
+		{"dns", fmt.Errorf("dialing: %w", &net.DNSError{Err: "no such host", Name: "doltremoteapi.dolthub.com"}), Network},
+		{"file", &os.PathError{Op: "open", Path: "missing.csv", Err: syscall.ENOENT}, Unknown},
+		{"status unavailable", status.Error(codes.Unavailable, "down"), Network},
+		{"status unauthenticated", status.Error(codes.Unauthenticated, "who"), Auth},
+		{"status permission denied", status.Error(codes.PermissionDenied, "no"), Auth},
+		{"status not found", status.Error(codes.NotFound, "gone"), NotFound},
+		{"status internal", status.Error(codes.Internal, "oops"), Unknown},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Of(test.err))
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	assert.Nil(t, Wrap(Conflict, nil))
+
+	cause := errors.New("cause")
+	err := Wrap(Conflict, cause)
+
+	assert.Equal(t, "cause", err.Error())
+	assert.True(t, errors.Is(err, cause))
+	assert.True(t, Is(err, Conflict))
+	assert.False(t, Is(err, Network))
+	assert.False(t, Is(nil, Unknown))
+}
+
+func TestNewIsDistinct(t *testing.T) {
+	err1 := New(NotFound, "not found")
+	err2 := New(NotFound, "not found")
+
+	assert.True(t, err1 == err1)
+	assert.False(t, err1 == err2)
+	assert.False(t, errors.Is(err1, err2))
+}
+
+func TestCategoryNamesAndExitCodes(t *testing.T) {
+	codes := make(map[int]Category)
+	names := make(map[string]Category)
+
+	for cat := Unknown; cat <= ConcurrentModification; cat++ {
+		code := cat.ExitCode()
+		assert.NotContains(t, codes, code, "exit code %d is used by %s and %s", code, codes[code], cat)
+		assert.NotEqual(t, 0, code)
+		assert.NotEqual(t, 2, code)
+		codes[code] = cat
+
+		name := cat.String()
+		assert.NotContains(t, names, name)
+		names[name] = cat
+	}
+
+	assert.Equal(t, 1, Unknown.ExitCode())
+	assert.Equal(t, 3, Conflict.ExitCode())
+	assert.Equal(t, "conflict", Conflict.String())
+	assert.Equal(t, "unknown", Category(100).String())
+	assert.Equal(t, 1, Category(100).ExitCode())
+}
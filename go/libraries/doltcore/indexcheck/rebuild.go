@@ -0,0 +1,195 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/atomicerr"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// ErrUnrebuildable is wrapped by the errors Rebuild returns for entries which can't be rebuilt.
+var ErrUnrebuildable = errors.New("the index can't be rebuilt")
+
+// Rebuild re-encodes every entry of the primary key index of |tbl| from the columns of its schema, returning the
+// table with the rebuilt index and the number of its entries. The primary key columns, the columns which have been
+// dropped and the null values are removed from the value of each row, and the rest of its values are put in tag
+// order. The cold values which don't belong to a row are removed.
+//
+// The keys of the index are the primary data of the table, so they aren't rebuilt. A key which fails verification, or
+// a value of the wrong kind for its column, can't be rebuilt, and an error wrapping ErrUnrebuildable is returned for it.
+//
+// The rows are streamed to new maps, so the memory used doesn't depend on the size of the table.
+func Rebuild(ctx context.Context, tbl *doltdb.Table) (*doltdb.Table, uint64, error) {
+	sch, err := tbl.GetSchema(ctx)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	hot, err := tbl.GetHotRowData(ctx)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cold, hasCold, err := tbl.GetColdRowData(ctx)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if !hasCold {
+		if cold, err = types.NewMap(ctx, tbl.ValueReadWriter()); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var entries uint64
+	keyChecker := &verifier{ctx: ctx, sch: sch, report: &Report{}, cb: func(p Problem) error {
+		return fmt.Errorf("%w: the key %s: %s", ErrUnrebuildable, p.Key, p.Detail)
+	}}
+
+	vrw := tbl.ValueReadWriter()
+	ae := atomicerr.New()
+	hotKVs := make(chan types.Value, 64)
+	coldKVs := make(chan types.Value, 64)
+	hotMapChan := types.NewStreamingMap(ctx, vrw, ae, hotKVs)
+	coldMapChan := types.NewStreamingMap(ctx, vrw, ae, coldKVs)
+
+	err = func() error {
+		defer close(hotKVs)
+		defer close(coldKVs)
+
+		return scanRows(ctx, hot, cold, func(key, val, coldVal types.Value) error {
+			if err := ae.Get(); err != nil {
+				return err
+			}
+
+			if key == nil {
+				return nil
+			}
+
+			if err := keyChecker.checkKey(key); err != nil {
+				return err
+			}
+
+			rebuilt, err := rebuildValue(ctx, sch, key, val)
+
+			if err != nil {
+				return err
+			}
+
+			entries++
+			hotKVs <- key
+			hotKVs <- rebuilt
+
+			if coldVal == nil {
+				return nil
+			}
+
+			rebuilt, err = rebuildValue(ctx, sch, key, coldVal)
+
+			if err != nil {
+				return err
+			}
+
+			if rebuilt.Len() > 0 {
+				coldKVs <- key
+				coldKVs <- rebuilt
+			}
+
+			return nil
+		})
+	}()
+
+	hot, cold = <-hotMapChan, <-coldMapChan
+
+	if err != nil {
+		return nil, 0, err
+	} else if err := ae.Get(); err != nil {
+		return nil, 0, err
+	}
+
+	var coldRows *types.Map
+	if hasCold {
+		coldRows = &cold
+	}
+
+	tbl, err = tbl.UpdateHotAndColdRows(ctx, hot, coldRows)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return tbl, entries, nil
+}
+
+// rebuildValue re-encodes |val|, the value of the row with the key |key|, from the non primary key columns of |sch|.
+func rebuildValue(ctx context.Context, sch schema.Schema, key, val types.Value) (types.Tuple, error) {
+	tvs, malformed, err := parseTagged(val)
+
+	if err != nil {
+		return types.Tuple{}, err
+	} else if malformed != "" {
+		return types.Tuple{}, unrebuildableValue(ctx, key, "the value %s", malformed)
+	}
+
+	allCols := sch.GetAllCols()
+	kept := make([]taggedValue, 0, len(tvs))
+	for _, tv := range tvs {
+		col, ok := allCols.GetByTag(tv.tag)
+
+		if !ok || col.IsPartOfPK || types.IsNull(tv.val) {
+			continue
+		} else if tv.val.Kind() != col.Kind {
+			return types.Tuple{}, unrebuildableValue(ctx, key, "the column %s has the kind %s rather than %s", col.Name, tv.val.Kind().String(), col.Kind.String())
+		}
+
+		kept = append(kept, tv)
+	}
+
+	sort.Slice(kept, func(i, j int) bool {
+		return kept[i].tag < kept[j].tag
+	})
+
+	vals := make([]types.Value, 0, 2*len(kept))
+	for i, tv := range kept {
+		if i > 0 && tv.tag == kept[i-1].tag {
+			col, _ := allCols.GetByTag(tv.tag)
+			return types.Tuple{}, unrebuildableValue(ctx, key, "the value has the column %s twice", col.Name)
+		}
+
+		vals = append(vals, types.Uint(tv.tag), tv.val)
+	}
+
+	return types.NewTuple(key.(types.Tuple).Format(), vals...)
+}
+
+func unrebuildableValue(ctx context.Context, key types.Value, format string, args ...interface{}) error {
+	keyStr, err := types.EncodedValue(ctx, key)
+
+	if err != nil {
+		return err
+	}
+
+	return fmt.Errorf("%w: the row %s: %s", ErrUnrebuildable, keyStr, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,123 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexcheck
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// encodedRows returns the rows of |m|, encoded as they're printed by noms.
+func encodedRows(t *testing.T, m types.Map) []string {
+	var rows []string
+	err := m.IterAll(context.Background(), func(k, v types.Value) error {
+		rows = append(rows, encodedRow(t, k, v))
+		return nil
+	})
+	require.NoError(t, err)
+
+	return rows
+}
+
+func encodedRow(t *testing.T, k, v types.Value) string {
+	ctx := context.Background()
+	kStr, err := types.EncodedValue(ctx, k)
+	require.NoError(t, err)
+	vStr, err := types.EncodedValue(ctx, v)
+	require.NoError(t, err)
+
+	return kStr + ": " + vStr
+}
+
+func TestRebuild(t *testing.T) {
+	ctx := context.Background()
+	kvs := []types.Value{
+		key(1), tuple(types.Uint(idTag), types.Int(1), types.Uint(nameTag), types.String("bill")),
+		key(2), tuple(types.Uint(ageTag), types.Uint(40), types.Uint(nameTag), types.String("jane")),
+		key(3), tuple(types.Uint(nameTag), types.String("john"), types.Uint(droppedTag), types.Bool(true), types.Uint(ageTag), types.NullValue),
+	}
+	coldKVs := []types.Value{
+		key(0), tuple(types.Uint(ageTag), types.Uint(50)),
+		key(1), tuple(types.Uint(ageTag), types.Uint(32)),
+	}
+
+	report, _ := verifyTestTable(t, createTestTable(t, kvs, coldKVs))
+	require.True(t, report.Broken())
+
+	tbl, entries, err := Rebuild(ctx, createTestTable(t, kvs, coldKVs))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), entries)
+
+	report, problems := verifyTestTable(t, tbl)
+	assert.Empty(t, problems)
+	assert.False(t, report.Broken())
+
+	hot, err := tbl.GetHotRowData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		encodedRow(t, key(1), tuple(types.Uint(nameTag), types.String("bill"))),
+		encodedRow(t, key(2), value("jane", 40)),
+		encodedRow(t, key(3), tuple(types.Uint(nameTag), types.String("john"))),
+	}, encodedRows(t, hot))
+
+	cold, ok, err := tbl.GetColdRowData(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, []string{encodedRow(t, key(1), tuple(types.Uint(ageTag), types.Uint(32)))}, encodedRows(t, cold))
+}
+
+func TestRebuildValidTable(t *testing.T) {
+	ctx := context.Background()
+	orig := createTestTable(t, validKVs[:4], nil)
+	tbl, entries, err := Rebuild(ctx, orig)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), entries)
+
+	// the rows of a valid table are rebuilt as they were
+	same, err := tbl.HasTheSameRows(orig)
+	require.NoError(t, err)
+	assert.True(t, same)
+
+	_, hasCold, err := tbl.GetColdRowData(ctx)
+	require.NoError(t, err)
+	assert.False(t, hasCold)
+}
+
+func TestRebuildUnrebuildable(t *testing.T) {
+	tests := []struct {
+		name string
+		key  types.Value
+		val  types.Value
+	}{
+		{"null key", tuple(types.Uint(idTag), types.NullValue), value("bill", 32)},
+		{"key of the wrong kind", tuple(types.Uint(idTag), types.String("1")), value("bill", 32)},
+		{"value of the wrong kind", key(1), tuple(types.Uint(ageTag), types.Int(32))},
+		{"repeated value tag", key(1), tuple(types.Uint(nameTag), types.String("bill"), types.Uint(nameTag), types.String("will"))},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kvs := append([]types.Value{test.key, test.val}, validKVs[2:]...)
+			_, _, err := Rebuild(context.Background(), createTestTable(t, kvs, nil))
+			assert.True(t, errors.Is(err, ErrUnrebuildable), "%v", err)
+		})
+	}
+}
@@ -0,0 +1,373 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexcheck
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// PrimaryKeyIndexName is the name of the index of a table's primary key, which is the map of its rows. Tables have no
+// secondary indexes in this version of dolt, so it's the only index there is to verify or rebuild.
+const PrimaryKeyIndexName = "PRIMARY"
+
+// ProblemKind is the kind of a Problem with an entry of an index.
+type ProblemKind string
+
+const (
+	// Missing is the kind of the problems of entries lacking a value they must have, such as a primary key column.
+	Missing ProblemKind = "missing"
+	// Extra is the kind of the problems of entries holding a value they mustn't have, such as a primary key column in
+	// the value of a row, or of entries which mustn't be in the index at all.
+	Extra ProblemKind = "extra"
+	// Mismatched is the kind of the problems of entries holding a value which doesn't match the schema of the table,
+	// or whose values are malformed or out of order.
+	Mismatched ProblemKind = "mismatched"
+)
+
+// Problem is a problem with an entry of an index, found by Verify.
+type Problem struct {
+	Kind ProblemKind `json:"kind"`
+	// Key is the key of the entry, encoded as it's printed by noms.
+	Key    string `json:"key"`
+	Detail string `json:"detail"`
+}
+
+// Report is the result of the verification of an index of a table.
+type Report struct {
+	Table      string `json:"table"`
+	Index      string `json:"index"`
+	Entries    uint64 `json:"entries"`
+	Missing    uint64 `json:"missing"`
+	Extra      uint64 `json:"extra"`
+	Mismatched uint64 `json:"mismatched"`
+}
+
+// Broken returns whether the verification found any problems with the index.
+func (r Report) Broken() bool {
+	return r.Missing+r.Extra+r.Mismatched > 0
+}
+
+// ProblemFunc is called by Verify with each problem it finds. An error returned by it stops the verification.
+type ProblemFunc func(Problem) error
+
+// Verify checks every entry of the primary key index of |tbl|, which is the map of its rows, against the schema of the
+// table. Its keys must hold the primary key columns of the table, in order, with values of the right kinds, and
+// nothing else. Its values mustn't hold primary key columns, and must hold values of the right kinds for the other
+// columns, by tag order. They may hold the values of columns which have been dropped from the table, as dropping a
+// column doesn't rewrite the rows of the table.
+//
+// The values of the cold columns of a table are in a map of their own, which is read in key order alongside the rows.
+// Each of its entries must belong to a row, and is checked like the value of a row.
+//
+// The entries are streamed, and each problem found is passed to |cb| as it's found, so the memory used doesn't depend
+// on the size of the table.
+func Verify(ctx context.Context, tblName string, tbl *doltdb.Table, cb ProblemFunc) (Report, error) {
+	report := Report{Table: tblName, Index: PrimaryKeyIndexName}
+	sch, err := tbl.GetSchema(ctx)
+
+	if err != nil {
+		return report, err
+	}
+
+	hot, err := tbl.GetHotRowData(ctx)
+
+	if err != nil {
+		return report, err
+	}
+
+	cold, hasCold, err := tbl.GetColdRowData(ctx)
+
+	if err != nil {
+		return report, err
+	}
+
+	if !hasCold {
+		if cold, err = types.NewMap(ctx, tbl.ValueReadWriter()); err != nil {
+			return report, err
+		}
+	}
+
+	v := &verifier{ctx: ctx, sch: sch, report: &report, cb: cb}
+	err = scanRows(ctx, hot, cold, func(key, val, coldVal types.Value) error {
+		if key == nil {
+			return v.problem(Extra, coldVal, "the cold values don't belong to a row")
+		}
+
+		report.Entries++
+		if err := v.checkKey(key); err != nil {
+			return err
+		}
+
+		if err := v.checkValue(key, val, "value"); err != nil {
+			return err
+		}
+
+		if coldVal != nil {
+			return v.checkValue(key, coldVal, "cold value")
+		}
+
+		return nil
+	})
+
+	return report, err
+}
+
+// scanRows calls |cb| with the key and value of each row of |hot|, in key order, along with the value of the row in
+// |cold| if there is one. The entries of |cold| which don't belong to a row are passed to |cb| with a nil key, with
+// their keys in place of their values.
+func scanRows(ctx context.Context, hot, cold types.Map, cb func(key, val, coldVal types.Value) error) error {
+	hotItr, err := hot.BufferedIterator(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	coldItr, err := cold.BufferedIterator(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	coldKey, coldVal, err := coldItr.Next(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	for {
+		key, val, err := hotItr.Next(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		for coldKey != nil {
+			if key != nil {
+				if isLess, err := coldKey.Less(hot.Format(), key); err != nil {
+					return err
+				} else if !isLess {
+					break
+				}
+			}
+
+			if err := cb(nil, nil, coldKey); err != nil {
+				return err
+			}
+
+			if coldKey, coldVal, err = coldItr.Next(ctx); err != nil {
+				return err
+			}
+		}
+
+		if key == nil {
+			return nil
+		}
+
+		var rowCold types.Value
+		if coldKey != nil && coldKey.Equals(key) {
+			rowCold = coldVal
+
+			if coldKey, coldVal, err = coldItr.Next(ctx); err != nil {
+				return err
+			}
+		}
+
+		if err := cb(key, val, rowCold); err != nil {
+			return err
+		}
+	}
+}
+
+// taggedValue is a value of a tagged tuple, along with its tag.
+type taggedValue struct {
+	tag uint64
+	val types.Value
+}
+
+// parseTagged parses the tagged tuple |v|, returning a description of the problem with it if it's malformed.
+func parseTagged(v types.Value) ([]taggedValue, string, error) {
+	tpl, ok := v.(types.Tuple)
+
+	if !ok {
+		return nil, fmt.Sprintf("is of the kind %s rather than a tuple", v.Kind().String()), nil
+	} else if tpl.Len()%2 != 0 {
+		return nil, "has an odd number of fields", nil
+	}
+
+	tvs := make([]taggedValue, 0, tpl.Len()/2)
+	var malformed string
+	err := tpl.IterFields(func(i uint64, field types.Value) (bool, error) {
+		if i%2 == 1 {
+			tvs[len(tvs)-1].val = field
+			return false, nil
+		}
+
+		tag, ok := field.(types.Uint)
+
+		if !ok {
+			malformed = fmt.Sprintf("has a tag of the kind %s", field.Kind().String())
+			return true, nil
+		}
+
+		tvs = append(tvs, taggedValue{tag: uint64(tag)})
+		return false, nil
+	})
+
+	if err != nil || malformed != "" {
+		return nil, malformed, err
+	}
+
+	return tvs, "", nil
+}
+
+type verifier struct {
+	ctx    context.Context
+	sch    schema.Schema
+	report *Report
+	cb     ProblemFunc
+}
+
+func (v *verifier) problem(kind ProblemKind, key types.Value, format string, args ...interface{}) error {
+	switch kind {
+	case Missing:
+		v.report.Missing++
+	case Extra:
+		v.report.Extra++
+	case Mismatched:
+		v.report.Mismatched++
+	}
+
+	keyStr, err := types.EncodedValue(v.ctx, key)
+
+	if err != nil {
+		return err
+	}
+
+	return v.cb(Problem{Kind: kind, Key: keyStr, Detail: fmt.Sprintf(format, args...)})
+}
+
+func (v *verifier) checkKey(key types.Value) error {
+	tvs, malformed, err := parseTagged(key)
+
+	if err != nil {
+		return err
+	} else if malformed != "" {
+		return v.problem(Mismatched, key, "the key %s", malformed)
+	}
+
+	allCols := v.sch.GetAllCols()
+	var keyTags []uint64
+	for _, tv := range tvs {
+		col, ok := allCols.GetByTag(tv.tag)
+
+		if !ok || !col.IsPartOfPK {
+			if err := v.problem(Extra, key, "the key has the tag %d, which isn't a primary key column", tv.tag); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if containsTag(keyTags, tv.tag) {
+			if err := v.problem(Extra, key, "the key has the primary key column %s twice", col.Name); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		keyTags = append(keyTags, tv.tag)
+		if types.IsNull(tv.val) {
+			err = v.problem(Missing, key, "the primary key column %s is null", col.Name)
+		} else if tv.val.Kind() != col.Kind {
+			err = v.problem(Mismatched, key, "the primary key column %s has the kind %s rather than %s", col.Name, tv.val.Kind().String(), col.Kind.String())
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	pkCols := v.sch.GetPKCols()
+	inOrder := len(keyTags) == len(pkCols.Tags)
+	for i, tag := range pkCols.Tags {
+		if !containsTag(keyTags, tag) {
+			col, _ := pkCols.GetByTag(tag)
+			if err := v.problem(Missing, key, "the key doesn't have the primary key column %s", col.Name); err != nil {
+				return err
+			}
+		}
+
+		inOrder = inOrder && keyTags[i] == tag
+	}
+
+	if !inOrder && len(keyTags) == len(pkCols.Tags) {
+		return v.problem(Mismatched, key, "the primary key columns of the key are out of order")
+	}
+
+	return nil
+}
+
+// checkValue checks |val|, the value of the row with the key |key|, which is described as |name| in problems.
+func (v *verifier) checkValue(key, val types.Value, name string) error {
+	tvs, malformed, err := parseTagged(val)
+
+	if err != nil {
+		return err
+	} else if malformed != "" {
+		return v.problem(Mismatched, key, "the %s %s", name, malformed)
+	}
+
+	allCols := v.sch.GetAllCols()
+	for i, tv := range tvs {
+		if i > 0 && tv.tag <= tvs[i-1].tag {
+			if err := v.problem(Mismatched, key, "the tags of the %s are out of order", name); err != nil {
+				return err
+			}
+		}
+
+		col, ok := allCols.GetByTag(tv.tag)
+
+		if !ok {
+			// the value of a dropped column
+			continue
+		} else if col.IsPartOfPK {
+			err = v.problem(Extra, key, "the %s has the primary key column %s", name, col.Name)
+		} else if !types.IsNull(tv.val) && tv.val.Kind() != col.Kind {
+			err = v.problem(Mismatched, key, "the column %s has the kind %s rather than %s", col.Name, tv.val.Kind().String(), col.Kind.String())
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func containsTag(tags []uint64, tag uint64) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
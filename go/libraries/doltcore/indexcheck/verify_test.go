@@ -0,0 +1,172 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexcheck
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/encoding"
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const (
+	idTag   uint64 = 0
+	nameTag uint64 = 1
+	ageTag  uint64 = 2
+	// droppedTag is the tag of a column which isn't in the schema any more
+	droppedTag uint64 = 3
+)
+
+var testSch = schema.SchemaFromCols(mustColColl(
+	schema.NewColumn("id", idTag, types.IntKind, true),
+	schema.NewColumn("name", nameTag, types.StringKind, false),
+	schema.NewColumn("age", ageTag, types.UintKind, false),
+))
+
+func mustColColl(cols ...schema.Column) *schema.ColCollection {
+	colColl, err := schema.NewColCollection(cols...)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return colColl
+}
+
+func tuple(vals ...types.Value) types.Tuple {
+	tpl, err := types.NewTuple(types.Format_Default, vals...)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return tpl
+}
+
+func key(id int) types.Tuple {
+	return tuple(types.Uint(idTag), types.Int(id))
+}
+
+func value(name string, age uint) types.Tuple {
+	return tuple(types.Uint(nameTag), types.String(name), types.Uint(ageTag), types.Uint(age))
+}
+
+// createTestTable returns a table of testSch with the rows and cold values given, which are alternating keys and
+// values. The table has no cold rows if |coldKVs| is nil.
+func createTestTable(t *testing.T, kvs []types.Value, coldKVs []types.Value) *doltdb.Table {
+	ctx := context.Background()
+	vrw := types.NewValueStore((&chunks.MemoryStorage{}).NewView())
+	schVal, err := encoding.MarshalSchemaAsNomsValue(ctx, vrw, testSch)
+	require.NoError(t, err)
+
+	rows, err := types.NewMap(ctx, vrw, kvs...)
+	require.NoError(t, err)
+
+	tbl, err := doltdb.NewTable(ctx, vrw, schVal, rows)
+	require.NoError(t, err)
+
+	if coldKVs != nil {
+		cold, err := types.NewMap(ctx, vrw, coldKVs...)
+		require.NoError(t, err)
+
+		tbl, err = tbl.UpdateHotAndColdRows(ctx, rows, &cold)
+		require.NoError(t, err)
+	}
+
+	return tbl
+}
+
+func verifyTestTable(t *testing.T, tbl *doltdb.Table) (Report, []Problem) {
+	var problems []Problem
+	report, err := Verify(context.Background(), "people", tbl, func(p Problem) error {
+		problems = append(problems, p)
+		return nil
+	})
+	require.NoError(t, err)
+
+	return report, problems
+}
+
+var validKVs = []types.Value{
+	key(1), value("bill", 32),
+	key(2), tuple(types.Uint(nameTag), types.String("jane")),
+	key(3), tuple(types.Uint(nameTag), types.String("john"), types.Uint(droppedTag), types.Bool(true)),
+}
+
+func TestVerifyValidTable(t *testing.T) {
+	report, problems := verifyTestTable(t, createTestTable(t, validKVs, nil))
+	assert.Empty(t, problems)
+	assert.Equal(t, Report{Table: "people", Index: "PRIMARY", Entries: 3}, report)
+	assert.False(t, report.Broken())
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		key    types.Value
+		val    types.Value
+		kind   ProblemKind
+		detail string
+	}{
+		{"null key", tuple(types.Uint(idTag), types.NullValue), value("bill", 32), Missing, "the primary key column id is null"},
+		{"empty key", tuple(), value("bill", 32), Missing, "the key doesn't have the primary key column id"},
+		{"extra key tag", tuple(types.Uint(idTag), types.Int(1), types.Uint(nameTag), types.String("bill")), value("bill", 32), Extra, "the key has the tag 1, which isn't a primary key column"},
+		{"repeated key tag", tuple(types.Uint(idTag), types.Int(1), types.Uint(idTag), types.Int(2)), value("bill", 32), Extra, "the key has the primary key column id twice"},
+		{"key of the wrong kind", tuple(types.Uint(idTag), types.String("1")), value("bill", 32), Mismatched, "the primary key column id has the kind String rather than Int"},
+		{"malformed key", tuple(types.Uint(idTag)), value("bill", 32), Mismatched, "the key has an odd number of fields"},
+		{"key with a string tag", tuple(types.String("id"), types.Int(1)), value("bill", 32), Mismatched, "the key has a tag of the kind String"},
+		{"key in the value", key(1), tuple(types.Uint(idTag), types.Int(1), types.Uint(nameTag), types.String("bill")), Extra, "the value has the primary key column id"},
+		{"value of the wrong kind", key(1), tuple(types.Uint(ageTag), types.Int(32)), Mismatched, "the column age has the kind Int rather than Uint"},
+		{"value out of order", key(1), tuple(types.Uint(ageTag), types.Uint(32), types.Uint(nameTag), types.String("bill")), Mismatched, "the tags of the value are out of order"},
+		{"value which isn't a tuple", key(1), types.String("bill"), Mismatched, "the value is of the kind String rather than a tuple"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kvs := append([]types.Value{test.key, test.val}, validKVs[2:]...)
+			report, problems := verifyTestTable(t, createTestTable(t, kvs, nil))
+
+			require.Len(t, problems, 1)
+			assert.Equal(t, test.kind, problems[0].Kind)
+			assert.Equal(t, test.detail, problems[0].Detail)
+			assert.True(t, report.Broken())
+			assert.Equal(t, uint64(3), report.Entries)
+		})
+	}
+}
+
+func TestVerifyColdRows(t *testing.T) {
+	coldKVs := []types.Value{
+		key(0), tuple(types.Uint(ageTag), types.Uint(50)),
+		key(1), tuple(types.Uint(ageTag), types.Uint(32)),
+		key(2), tuple(types.Uint(ageTag), types.String("old")),
+		key(9), tuple(types.Uint(ageTag), types.Uint(50)),
+	}
+
+	report, problems := verifyTestTable(t, createTestTable(t, validKVs, coldKVs))
+	assert.Equal(t, []Problem{
+		{Extra, "(0,0)", "the cold values don't belong to a row"},
+		{Mismatched, "(0,2)", "the column age has the kind String rather than Uint"},
+		{Extra, "(0,9)", "the cold values don't belong to a row"},
+	}, problems)
+	assert.Equal(t, Report{Table: "people", Index: "PRIMARY", Entries: 3, Extra: 2, Mismatched: 1}, report)
+}
@@ -0,0 +1,505 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// The columns of an exported conflicts file, besides the primary key columns of the table. Each of the table's other
+// columns has a base, ours, theirs and resolved column of its own.
+const (
+	OursChangeCol     = "ours_change"
+	TheirsChangeCol   = "theirs_change"
+	ResolutionCol     = "resolution"
+	BaseColPrefix     = "base_"
+	OursColPrefix     = "ours_"
+	TheirsColPrefix   = "theirs_"
+	ResolvedColPrefix = "resolved_"
+)
+
+// The resolutions which can be chosen for a conflict in an exported conflicts file. A conflict whose resolution is left
+// blank remains unresolved.
+const (
+	ResolveWithOurs   = "ours"
+	ResolveWithTheirs = "theirs"
+	ResolveWithBase   = "base"
+	ResolveWithDelete = "delete"
+	// ResolveWithCustom resolves a conflict with the values of the resolved_ columns.
+	ResolveWithCustom = "custom"
+)
+
+// The changes made to a conflicting row on each side of a merge, as shown in the ours_change and theirs_change columns.
+const (
+	changeAdded    = "added"
+	changeModified = "modified"
+	changeDeleted  = "deleted"
+)
+
+// InvalidResolution is a row of a conflicts file whose resolution can't be applied.
+type InvalidResolution struct {
+	// Line is the line of the row within the file, where the header is line 1.
+	Line   int
+	Reason string
+}
+
+func (ir InvalidResolution) String() string {
+	return fmt.Sprintf("line %d: %s", ir.Line, ir.Reason)
+}
+
+// conflictVersions holds the schemas of the three versions of the rows of a table's conflicts.
+type conflictVersions struct {
+	tblSch, base, ours, theirs schema.Schema
+}
+
+func getConflictVersions(ctx context.Context, tbl *doltdb.Table) (conflictVersions, error) {
+	tblSch, err := tbl.GetSchema(ctx)
+
+	if err != nil {
+		return conflictVersions{}, err
+	}
+
+	base, ours, theirs, err := tbl.GetConflictSchemas(ctx)
+
+	if err != nil {
+		return conflictVersions{}, err
+	}
+
+	return conflictVersions{tblSch, base, ours, theirs}, nil
+}
+
+// toTableRow returns the row of a version with the schema |verSch| as a row of the table's current schema, or nil if
+// the version of the row doesn't exist. The values of columns which the table no longer has are dropped.
+func (cv conflictVersions) toTableRow(nbf *types.NomsBinFormat, verSch schema.Schema, key types.Tuple, val types.Value) (row.Row, error) {
+	if val == nil || types.IsNull(val) {
+		return nil, nil
+	}
+
+	r, err := row.FromNoms(verSch, key, val.(types.Tuple))
+
+	if err != nil {
+		return nil, err
+	}
+
+	cols := cv.tblSch.GetAllCols()
+	taggedVals := make(row.TaggedValues)
+	_, err = r.IterCols(func(tag uint64, v types.Value) (bool, error) {
+		if _, ok := cols.GetByTag(tag); ok {
+			taggedVals[tag] = v
+		}
+
+		return false, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return row.New(nbf, cv.tblSch, taggedVals)
+}
+
+func changeOf(base, r row.Row) string {
+	switch {
+	case base == nil && r != nil:
+		return changeAdded
+	case r == nil:
+		return changeDeleted
+	default:
+		return changeModified
+	}
+}
+
+// ExportConflictsHeader returns the header of the exported conflicts of a table with the schema given.
+func ExportConflictsHeader(sch schema.Schema) []string {
+	header := append([]string{}, sch.GetPKCols().GetColumnNames()...)
+	header = append(header, OursChangeCol, TheirsChangeCol, ResolutionCol)
+
+	_ = sch.GetNonPKCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+		header = append(header, BaseColPrefix+col.Name, OursColPrefix+col.Name, TheirsColPrefix+col.Name, ResolvedColPrefix+col.Name)
+		return false, nil
+	})
+
+	return header
+}
+
+// ExportConflicts returns the conflicts of |tbl| as rows of cells, so that they can be resolved outside of dolt and
+// imported again with ImportConflictResolutions. The first row is the header returned by ExportConflictsHeader for the
+// table's schema, and each other row is a conflict, holding the key of the conflicting row followed by the base, our
+// and their value of each of the table's other columns. The resolved_ columns start out with our values, or their
+// values if we deleted the row, and the resolution column starts out blank. NULLs are exported as empty cells.
+func ExportConflicts(ctx context.Context, tbl *doltdb.Table) ([][]string, error) {
+	cv, err := getConflictVersions(ctx, tbl)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, conflicts, err := tbl.GetConflicts(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	nbf := tbl.Format()
+	pkCols := cv.tblSch.GetPKCols()
+	nonPKCols := cv.tblSch.GetNonPKCols()
+	rows := [][]string{ExportConflictsHeader(cv.tblSch)}
+
+	err = conflicts.IterAll(ctx, func(key, value types.Value) error {
+		cnf, err := doltdb.ConflictFromTuple(value.(types.Tuple))
+
+		if err != nil {
+			return err
+		}
+
+		keyTpl := key.(types.Tuple)
+		var versions [3]row.Row
+		for i, v := range []struct {
+			sch schema.Schema
+			val types.Value
+		}{{cv.base, cnf.Base}, {cv.ours, cnf.Value}, {cv.theirs, cnf.MergeValue}} {
+			versions[i], err = cv.toTableRow(nbf, v.sch, keyTpl, v.val)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		base, ours, theirs := versions[0], versions[1], versions[2]
+		resolved := ours
+		if resolved == nil {
+			resolved = theirs
+		}
+
+		keyVals, err := row.ParseTaggedValues(keyTpl)
+
+		if err != nil {
+			return err
+		}
+
+		var cells []string
+		err = pkCols.Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+			str, err := formatCell(col, keyVals[tag], true)
+			cells = append(cells, str)
+			return false, err
+		})
+
+		if err != nil {
+			return err
+		}
+
+		cells = append(cells, changeOf(base, ours), changeOf(base, theirs), "")
+		err = nonPKCols.Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+			for _, r := range []row.Row{base, ours, theirs, resolved} {
+				var val types.Value
+				if r != nil {
+					val, _ = r.GetColVal(tag)
+				}
+
+				str, err := formatCell(col, val, r != nil)
+
+				if err != nil {
+					return true, err
+				}
+
+				cells = append(cells, str)
+			}
+
+			return false, nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		rows = append(rows, cells)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+func formatCell(col schema.Column, val types.Value, exists bool) (string, error) {
+	if !exists || val == nil || types.IsNull(val) {
+		return "", nil
+	}
+
+	str, err := col.TypeInfo.FormatValue(val)
+
+	if err != nil || str == nil {
+		return "", err
+	}
+
+	return *str, nil
+}
+
+func parseCell(col schema.Column, cell string) (types.Value, error) {
+	if cell == "" {
+		return types.NullValue, nil
+	}
+
+	return col.TypeInfo.ParseValue(&cell)
+}
+
+// resolution is a conflict resolution read from a conflicts file which is ready to be applied.
+type resolution struct {
+	key types.Value
+	// the resolved row, or nil if the row is deleted
+	r row.Row
+}
+
+// ImportConflictResolutions applies the resolutions of the conflicts of |tbl| chosen in |rows|, which are the rows of a
+// conflicts file exported by ExportConflicts, and clears the resolved conflicts. It returns the updated table and the
+// number of conflicts resolved. Every resolution is validated against the table's schema and constraints before any
+// is applied, and if any resolution is invalid, nothing is applied and the invalid resolutions are returned instead.
+func ImportConflictResolutions(ctx context.Context, tbl *doltdb.Table, rows [][]string) (*doltdb.Table, int, []InvalidResolution, error) {
+	if len(rows) == 0 {
+		return nil, 0, []InvalidResolution{{1, "the file has no header"}}, nil
+	}
+
+	cv, err := getConflictVersions(ctx, tbl)
+
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	_, conflicts, err := tbl.GetConflicts(ctx)
+
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	colIdx := make(map[string]int)
+	for i, name := range rows[0] {
+		colIdx[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+
+	var missing []string
+	for _, name := range append(cv.tblSch.GetPKCols().GetColumnNames(), ResolutionCol) {
+		if _, ok := colIdx[strings.ToLower(name)]; !ok {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, 0, []InvalidResolution{{1, "missing columns: " + strings.Join(missing, ", ")}}, nil
+	}
+
+	nbf := tbl.Format()
+	pkTags := cv.tblSch.GetPKCols().Tags
+	cell := func(r []string, name string) (string, bool) {
+		i, ok := colIdx[strings.ToLower(name)]
+		if !ok {
+			return "", false
+		} else if i >= len(r) {
+			return "", true
+		}
+
+		return strings.TrimSpace(r[i]), true
+	}
+
+	var invalid []InvalidResolution
+	var resolutions []resolution
+	seen := make(map[hash.Hash]int)
+	for i, r := range rows[1:] {
+		line := i + 2
+		res, reason, err := parseResolution(ctx, nbf, cv, conflicts, r, pkTags, cell)
+
+		if err != nil {
+			return nil, 0, nil, err
+		} else if reason != "" {
+			invalid = append(invalid, InvalidResolution{line, reason})
+			continue
+		} else if res == nil {
+			continue
+		}
+
+		h, err := res.key.Hash(nbf)
+
+		if err != nil {
+			return nil, 0, nil, err
+		}
+
+		if prev, ok := seen[h]; ok {
+			invalid = append(invalid, InvalidResolution{line, fmt.Sprintf("the conflict is also resolved on line %d", prev)})
+			continue
+		}
+
+		seen[h] = line
+		resolutions = append(resolutions, *res)
+	}
+
+	if len(invalid) > 0 {
+		return nil, 0, invalid, nil
+	} else if len(resolutions) == 0 {
+		return tbl, 0, nil, nil
+	}
+
+	rowData, err := tbl.GetRowData(ctx)
+
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	rowEditor := rowData.Edit()
+	keys := make([]types.Value, len(resolutions))
+	for i, res := range resolutions {
+		keys[i] = res.key
+
+		if res.r == nil {
+			rowEditor.Remove(res.key)
+		} else {
+			rowEditor.Set(res.key, res.r.NomsMapValue(cv.tblSch))
+		}
+	}
+
+	rowData, err = rowEditor.Map(ctx)
+
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	tbl, err = tbl.UpdateRows(ctx, rowData)
+
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	_, _, tbl, err = tbl.ResolveConflicts(ctx, keys)
+
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	return tbl, len(resolutions), nil, nil
+}
+
+// parseResolution parses the resolution of a row of a conflicts file. It returns a nil resolution for a row whose
+// resolution is blank, and the reason the resolution is invalid if it is.
+func parseResolution(ctx context.Context, nbf *types.NomsBinFormat, cv conflictVersions, conflicts types.Map, r []string, pkTags []uint64, cell func([]string, string) (string, bool)) (*resolution, string, error) {
+	resStr, _ := cell(r, ResolutionCol)
+	resStr = strings.ToLower(resStr)
+
+	if resStr == "" {
+		return nil, "", nil
+	}
+
+	keyVals := make(row.TaggedValues)
+	for _, tag := range pkTags {
+		col, _ := cv.tblSch.GetAllCols().GetByTag(tag)
+		str, _ := cell(r, col.Name)
+		val, err := parseCell(col, str)
+
+		if err != nil {
+			return nil, fmt.Sprintf("invalid value '%s' for column %s: %v", str, col.Name, err), nil
+		} else if types.IsNull(val) {
+			return nil, fmt.Sprintf("the primary key column %s has no value", col.Name), nil
+		}
+
+		keyVals[tag] = val
+	}
+
+	key, err := keyVals.NomsTupleForTags(nbf, pkTags, true).Value(ctx)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	cnfVal, ok, err := conflicts.MaybeGet(ctx, key)
+
+	if err != nil {
+		return nil, "", err
+	} else if !ok {
+		return nil, "the key " + keyVals.String() + " is not the key of a conflicting row", nil
+	}
+
+	cnf, err := doltdb.ConflictFromTuple(cnfVal.(types.Tuple))
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	keyTpl := key.(types.Tuple)
+	var resolved row.Row
+	switch resStr {
+	case ResolveWithOurs:
+		resolved, err = cv.toTableRow(nbf, cv.ours, keyTpl, cnf.Value)
+	case ResolveWithTheirs:
+		resolved, err = cv.toTableRow(nbf, cv.theirs, keyTpl, cnf.MergeValue)
+	case ResolveWithBase:
+		resolved, err = cv.toTableRow(nbf, cv.base, keyTpl, cnf.Base)
+	case ResolveWithDelete:
+	case ResolveWithCustom:
+		taggedVals := keyVals
+		var reason string
+		err = cv.tblSch.GetNonPKCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+			str, ok := cell(r, ResolvedColPrefix+col.Name)
+
+			if !ok {
+				reason = "a custom resolution requires the column " + ResolvedColPrefix + col.Name
+				return true, nil
+			}
+
+			val, err := parseCell(col, str)
+
+			if err != nil {
+				reason = fmt.Sprintf("invalid value '%s' for column %s: %v", str, col.Name, err)
+				return true, nil
+			}
+
+			taggedVals[tag] = val
+			return false, nil
+		})
+
+		if err != nil || reason != "" {
+			return nil, reason, err
+		}
+
+		resolved, err = row.New(nbf, cv.tblSch, taggedVals)
+	default:
+		return nil, fmt.Sprintf("unknown resolution '%s'. Valid resolutions are %s, %s, %s, %s and %s", resStr,
+			ResolveWithOurs, ResolveWithTheirs, ResolveWithBase, ResolveWithDelete, ResolveWithCustom), nil
+	}
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if resolved != nil {
+		col, cnst, err := row.GetInvalidConstraint(resolved, cv.tblSch)
+
+		if err != nil {
+			return nil, err.Error(), nil
+		} else if cnst != nil {
+			return nil, fmt.Sprintf("the value of column %s violates the constraint %s", col.Name, cnst.GetConstraintType()), nil
+		} else if col != nil {
+			return nil, fmt.Sprintf("the value of column %s is not valid for its type", col.Name), nil
+		}
+	}
+
+	return &resolution{key, resolved}, "", nil
+}
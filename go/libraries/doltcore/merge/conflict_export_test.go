@@ -0,0 +1,129 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+func mergedTableWithConflicts(t *testing.T) *doltdb.Table {
+	vrw, commit, mergeCommit, _, _ := setupMergeTest()
+
+	root, err := commit.GetRootValue()
+	require.NoError(t, err)
+	mergeRoot, err := mergeCommit.GetRootValue()
+	require.NoError(t, err)
+	ancCm, err := doltdb.GetCommitAncestor(context.Background(), commit, mergeCommit)
+	require.NoError(t, err)
+	ancRoot, err := ancCm.GetRootValue()
+	require.NoError(t, err)
+
+	merged, stats, err := NewMerger(context.Background(), root, mergeRoot, ancRoot, vrw).MergeTable(context.Background(), tableName)
+	require.NoError(t, err)
+	require.Equal(t, 2, stats.Conflicts)
+
+	return merged
+}
+
+func TestExportConflicts(t *testing.T) {
+	tbl := mergedTableWithConflicts(t)
+
+	rows, err := ExportConflicts(context.Background(), tbl)
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]string{
+		{"id", "ours_change", "theirs_change", "resolution", "base_name", "ours_name", "theirs_name", "resolved_name", "base_title", "ours_title", "theirs_title", "resolved_title"},
+		{"00000000-0000-0000-0000-000000000008", "modified", "modified", "", "person 9", "person nine", "person number nine", "person nine", "", "", "", ""},
+		{"00000000-0000-0000-0000-00000000000c", "added", "added", "", "", "person thirteen", "person number thirteen", "person thirteen", "", "", "", ""},
+	}, rows)
+}
+
+func TestImportConflictResolutions(t *testing.T) {
+	ctx := context.Background()
+	tbl := mergedTableWithConflicts(t)
+
+	rows, err := ExportConflicts(ctx, tbl)
+	require.NoError(t, err)
+
+	// invalid resolutions are all reported, and none of the resolutions are applied
+	invalidRows := [][]string{rows[0], append([]string{}, rows[1]...), append([]string{}, rows[2]...), append([]string{}, rows[1]...)}
+	invalidRows[1][3] = "custom"
+	invalidRows[1][7] = ""
+	invalidRows[2][3] = "mine"
+	invalidRows[3][0] = "00000000-0000-0000-0000-000000000000"
+	invalidRows[3][3] = "ours"
+	updated, n, invalid, err := ImportConflictResolutions(ctx, tbl, invalidRows)
+	require.NoError(t, err)
+	assert.Nil(t, updated)
+	assert.Equal(t, 0, n)
+	require.Len(t, invalid, 3)
+	assert.Equal(t, 2, invalid[0].Line)
+	assert.Contains(t, invalid[0].Reason, "column name violates the constraint")
+	assert.Equal(t, 3, invalid[1].Line)
+	assert.Contains(t, invalid[1].Reason, "unknown resolution 'mine'")
+	assert.Equal(t, 4, invalid[2].Line)
+	assert.Contains(t, invalid[2].Reason, "is not the key of a conflicting row")
+
+	_, _, invalid, err = ImportConflictResolutions(ctx, tbl, [][]string{{"resolution"}})
+	require.NoError(t, err)
+	require.Len(t, invalid, 1)
+	assert.Equal(t, "line 1: missing columns: id", invalid[0].String())
+
+	// a custom resolution of one conflict leaves the other unresolved
+	rows[1][3] = "CUSTOM"
+	rows[1][7] = "person 9ine"
+	rows[1][11] = "mx"
+	updated, n, invalid, err = ImportConflictResolutions(ctx, tbl, rows)
+	require.NoError(t, err)
+	require.Empty(t, invalid)
+	assert.Equal(t, 1, n)
+
+	rowData, err := updated.GetRowData(ctx)
+	require.NoError(t, err)
+	val, ok, err := rowData.MaybeGet(ctx, keyTuples[8])
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.True(t, val.Equals(valsToTestTupleWithoutPks([]types.Value{types.String("person 9ine"), types.String("mx")})))
+
+	remaining, err := updated.NumRowsInConflict(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), remaining)
+
+	// resolutions can take either side, or delete the row
+	rows, err = ExportConflicts(ctx, updated)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	rows[1][3] = "delete"
+	updated, n, invalid, err = ImportConflictResolutions(ctx, updated, rows)
+	require.NoError(t, err)
+	require.Empty(t, invalid)
+	assert.Equal(t, 1, n)
+
+	rowData, err = updated.GetRowData(ctx)
+	require.NoError(t, err)
+	ok, err = rowData.Has(ctx, keyTuples[12])
+	require.NoError(t, err)
+	assert.False(t, ok)
+	remaining, err = updated.NumRowsInConflict(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), remaining)
+}
@@ -55,6 +55,8 @@ var diffTypeToColor = map[types.DiffChangeType]diff.ColorFunc{
 type ConflictSink struct {
 	sch schema.Schema
 	ttw *tabular.TextTableWriter
+	// rowsWritten is the number of rows written after the header
+	rowsWritten int
 }
 
 const (
@@ -76,7 +78,7 @@ func NewConflictSink(wr io.WriteCloser, sch schema.Schema, colSep string) (*Conf
 		return nil, err
 	}
 
-	return &ConflictSink{outSch, ttw}, nil
+	return &ConflictSink{sch: outSch, ttw: ttw}, nil
 }
 
 // GetSchema gets the schema of the rows that this writer writes
@@ -92,6 +94,9 @@ var noColorFunc = func(s string, i ...interface{}) string {
 	}
 }
 
+// ProcRowWithProps writes a row read by a ConflictReader. The rows of each conflict are set apart from those of the
+// conflict before them, and the cells of our and their rows which differ from the row they're compared to are colored
+// by how the row was changed.
 func (cs *ConflictSink) ProcRowWithProps(r row.Row, props pipeline.ReadableMap) error {
 	taggedVals := make(row.TaggedValues)
 
@@ -117,9 +122,19 @@ func (cs *ConflictSink) ProcRowWithProps(r row.Row, props pipeline.ReadableMap)
 		}
 	}
 
+	// rows which don't say which of their cells changed, such as deleted rows, are colored in full
+	var changed map[uint64]bool
+	if changedVal, ok := props.Get(changedColsProp); ok {
+		changed = changedVal.(map[uint64]bool)
+	}
+
 	err := cs.sch.GetAllCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
 		if val, ok := r.GetColVal(tag); ok {
-			taggedVals[tag] = types.String(colorFunc(string(val.(types.String))))
+			if changed == nil || changed[tag] {
+				taggedVals[tag] = types.String(colorFunc(string(val.(types.String))))
+			} else {
+				taggedVals[tag] = val
+			}
 		}
 		return false, nil
 	})
@@ -134,6 +149,18 @@ func (cs *ConflictSink) ProcRowWithProps(r row.Row, props pipeline.ReadableMap)
 		return err
 	}
 
+	if _, ok := props.Get(conflictStartProp); ok && cs.rowsWritten > 0 {
+		err = cs.ttw.WriteSeparator()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if mergeVersion != Blank {
+		cs.rowsWritten++
+	}
+
 	return cs.ttw.WriteRow(context.TODO(), r)
 }
 
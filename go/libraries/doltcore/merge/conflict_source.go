@@ -18,6 +18,7 @@ import (
 	"context"
 	"io"
 
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/rowconv"
@@ -30,6 +31,11 @@ import (
 const (
 	mergeVersionProp  = "merge_version"
 	mergeRowOperation = "row_operation"
+	// conflictStartProp is set on the first row shown for each conflict
+	conflictStartProp = "conflict_start"
+	// changedColsProp holds the tags of the columns whose values differ from those of the row ours or theirs is
+	// compared against: the base row, or the other side's row when both sides added the row.
+	changedColsProp = "changed_cols"
 )
 
 type MergeVersion int
@@ -41,17 +47,86 @@ const (
 	Blank // for display only
 )
 
+// The names of the versions of a conflicting row. The columns of rows joining the versions, such as those joined by
+// NewConflictJoiner, are prefixed with them.
+const (
+	BaseVersionName   = "base"
+	OursVersionName   = "ours"
+	TheirsVersionName = "theirs"
+)
+
+// ConflictRows holds the versions of a conflicting row, each with the schema the table had in that version. Base is nil
+// when both sides added the row, and Ours or Theirs is nil when that side deleted it.
+type ConflictRows struct {
+	Key    types.Tuple
+	Base   row.Row
+	Ours   row.Row
+	Theirs row.Row
+}
+
+// OursChange returns how our side of the merge changed the row: added, modified or deleted.
+func (cnf ConflictRows) OursChange() string {
+	return changeOf(cnf.Base, cnf.Ours)
+}
+
+// TheirsChange returns how their side of the merge changed the row: added, modified or deleted.
+func (cnf ConflictRows) TheirsChange() string {
+	return changeOf(cnf.Base, cnf.Theirs)
+}
+
+// NewConflictJoiner returns a joiner which joins the versions of conflicting rows with the schemas given into single
+// rows, whose columns are named with the prefixes base_, ours_ and theirs_.
+func NewConflictJoiner(baseSch, oursSch, theirsSch schema.Schema) (*rowconv.Joiner, error) {
+	namer := func(prefix string) rowconv.ColNamingFunc {
+		return func(name string) string {
+			return prefix + "_" + name
+		}
+	}
+
+	return rowconv.NewJoiner(
+		[]rowconv.NamedSchema{
+			{Name: BaseVersionName, Sch: baseSch},
+			{Name: OursVersionName, Sch: oursSch},
+			{Name: TheirsVersionName, Sch: theirsSch},
+		},
+		map[string]rowconv.ColNamingFunc{
+			BaseVersionName:   namer(BaseVersionName),
+			OursVersionName:   namer(OursVersionName),
+			TheirsVersionName: namer(TheirsVersionName),
+		},
+	)
+}
+
+// Join returns the versions of |cnf| joined into a single row by |joiner|, which must have been created by
+// NewConflictJoiner.
+func (cnf ConflictRows) Join(joiner *rowconv.Joiner) (row.Row, error) {
+	return joiner.Join(map[string]row.Row{BaseVersionName: cnf.Base, OursVersionName: cnf.Ours, TheirsVersionName: cnf.Theirs})
+}
+
 type ConflictReader struct {
+	conflicts types.Map
+	// ranges are the ranges of keys whose conflicts are read, or nil if all of them are
+	ranges       []diff.KeyRange
 	confItr      types.MapIterator
+	inRange      func(types.Value) (bool, error)
+	base         schema.Schema
+	sch          schema.Schema
+	mergeSch     schema.Schema
 	unionedSch   schema.Schema
 	baseConv     *rowconv.RowConverter
 	conv         *rowconv.RowConverter
 	mergeConv    *rowconv.RowConverter
-	bufferedRows [3]pipeline.RowWithProps
-	currIdx      int
+	bufferedRows []pipeline.RowWithProps
 }
 
+// NewConflictReader returns a reader of all of the conflicts of |tbl|.
 func NewConflictReader(ctx context.Context, tbl *doltdb.Table) (*ConflictReader, error) {
+	return NewConflictReaderForRanges(ctx, tbl, nil)
+}
+
+// NewConflictReaderForRanges returns a reader of the conflicts of |tbl| whose keys are within |ranges|, which must be
+// sorted and must not overlap. When |ranges| is nil all of the conflicts are read.
+func NewConflictReaderForRanges(ctx context.Context, tbl *doltdb.Table, ranges []diff.KeyRange) (*ConflictReader, error) {
 	base, sch, mergeSch, err := tbl.GetConflictSchemas(ctx)
 
 	if err != nil {
@@ -89,12 +164,6 @@ func NewConflictReader(ctx context.Context, tbl *doltdb.Table) (*ConflictReader,
 		return nil, err
 	}
 
-	confItr, err := confData.Iterator(ctx)
-
-	if err != nil {
-		return nil, err
-	}
-
 	baseConv, err := rowconv.NewRowConverter(baseMapping)
 
 	if err != nil {
@@ -113,14 +182,27 @@ func NewConflictReader(ctx context.Context, tbl *doltdb.Table) (*ConflictReader,
 		return nil, err
 	}
 
-	return &ConflictReader{
-		confItr,
-		untypedUnSch,
-		baseConv,
-		conv,
-		mergeConv,
-		[3]pipeline.RowWithProps{},
-		0}, nil
+	cr := &ConflictReader{
+		conflicts:  confData,
+		ranges:     ranges,
+		base:       base,
+		sch:        sch,
+		mergeSch:   mergeSch,
+		unionedSch: untypedUnSch,
+		baseConv:   baseConv,
+		conv:       conv,
+		mergeConv:  mergeConv,
+	}
+
+	if ranges == nil {
+		cr.confItr, err = confData.Iterator(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cr, nil
 }
 
 // GetSchema gets the schema of the rows that this reader will return
@@ -128,103 +210,218 @@ func (cr *ConflictReader) GetSchema() schema.Schema {
 	return cr.unionedSch
 }
 
-// NextConflict reads a row from a table.  If there is a bad row the returned error will be non nil, and callin IsBadRow(err)
-// will be return true. This is a potentially non-fatal error and callers can decide if they want to continue on a bad row, or fail.
-func (cr *ConflictReader) NextConflict(ctx context.Context) (row.Row, pipeline.ImmutableProperties, error) {
-	for {
-		if cr.currIdx == 0 {
-			key, value, err := cr.confItr.Next(ctx)
-
-			if err != nil {
-				return nil, pipeline.ImmutableProperties{}, err
-			}
+// GetConflictSchemas returns the schemas of the base, our and their versions of the rows returned by NextConflictRows.
+func (cr *ConflictReader) GetConflictSchemas() (base, sch, mergeSch schema.Schema) {
+	return cr.base, cr.sch, cr.mergeSch
+}
 
-			if key == nil {
-				return nil, pipeline.NoProps, io.EOF
+// nextKV returns the next conflict within the reader's key ranges, or a nil key when there are no more.
+func (cr *ConflictReader) nextKV(ctx context.Context) (types.Value, types.Value, error) {
+	for {
+		if cr.confItr == nil {
+			if len(cr.ranges) == 0 {
+				return nil, nil, nil
 			}
 
-			keyTpl := key.(types.Tuple)
-			conflict, err := doltdb.ConflictFromTuple(value.(types.Tuple))
+			r := cr.ranges[0]
+			cr.ranges = cr.ranges[1:]
 
-			if err != nil {
-				return nil, pipeline.ImmutableProperties{}, err
+			var err error
+			if r.Start == nil {
+				cr.confItr, err = cr.conflicts.Iterator(ctx)
+			} else {
+				cr.confItr, err = cr.conflicts.IteratorFrom(ctx, r.Start)
 			}
 
-			baseRow, err := createRow(keyTpl, conflict.Base, cr.baseConv)
-
 			if err != nil {
-				return nil, pipeline.ImmutableProperties{}, err
+				return nil, nil, err
 			}
 
-			r, err := createRow(keyTpl, conflict.Value, cr.conv)
+			cr.inRange = r.InRange
+		}
+
+		key, value, err := cr.confItr.Next(ctx)
 
-			if err != nil {
-				return nil, pipeline.ImmutableProperties{}, err
-			}
+		if err != nil {
+			return nil, nil, err
+		}
 
-			mergeRow, err := createRow(keyTpl, conflict.MergeValue.(types.Tuple), cr.mergeConv)
+		if key != nil && cr.inRange != nil {
+			inRange, err := cr.inRange(key)
 
 			if err != nil {
-				return nil, pipeline.ImmutableProperties{}, err
+				return nil, nil, err
 			}
 
-			if baseRow != nil {
-				if mergeRow != nil && r != nil {
-					cr.bufferedRows[2] = pipeline.NewRowWithProps(baseRow, map[string]interface{}{mergeVersionProp: BaseVersion})
-					cr.bufferedRows[1] = pipeline.NewRowWithProps(mergeRow, map[string]interface{}{mergeVersionProp: TheirVersion, mergeRowOperation: types.DiffChangeModified})
-					cr.bufferedRows[0] = pipeline.NewRowWithProps(r, map[string]interface{}{mergeVersionProp: OurVersion, mergeRowOperation: types.DiffChangeModified})
-					cr.currIdx = 3
-				} else if r != nil {
-					cr.bufferedRows[2] = pipeline.NewRowWithProps(baseRow, map[string]interface{}{mergeVersionProp: BaseVersion})
-					cr.bufferedRows[1] = pipeline.NewRowWithProps(baseRow, map[string]interface{}{mergeVersionProp: TheirVersion, mergeRowOperation: types.DiffChangeRemoved})
-					cr.bufferedRows[0] = pipeline.NewRowWithProps(r, map[string]interface{}{mergeVersionProp: OurVersion, mergeRowOperation: types.DiffChangeModified})
-					cr.currIdx = 3
-				} else {
-					cr.bufferedRows[2] = pipeline.NewRowWithProps(baseRow, map[string]interface{}{mergeVersionProp: BaseVersion})
-					cr.bufferedRows[1] = pipeline.NewRowWithProps(mergeRow, map[string]interface{}{mergeVersionProp: TheirVersion, mergeRowOperation: types.DiffChangeModified})
-					cr.bufferedRows[0] = pipeline.NewRowWithProps(baseRow, map[string]interface{}{mergeVersionProp: OurVersion, mergeRowOperation: types.DiffChangeRemoved})
-					cr.currIdx = 3
-				}
-			} else {
-				if mergeRow != nil {
-					cr.bufferedRows[0] = pipeline.NewRowWithProps(mergeRow, map[string]interface{}{mergeVersionProp: TheirVersion, mergeRowOperation: types.DiffChangeAdded})
-					cr.currIdx++
-				}
-
-				if r != nil {
-					cr.bufferedRows[1] = pipeline.NewRowWithProps(r, map[string]interface{}{mergeVersionProp: OurVersion, mergeRowOperation: types.DiffChangeAdded})
-					cr.currIdx++
-				}
+			if !inRange {
+				key = nil
 			}
 		}
 
-		cr.currIdx--
-		result := cr.bufferedRows[cr.currIdx]
+		if key != nil {
+			return key, value, nil
+		}
 
-		if result.Row != nil {
-			return result.Row, result.Props, nil
+		if cr.ranges == nil {
+			return nil, nil, nil
 		}
+
+		cr.confItr = nil
 	}
 }
 
-func createRow(key types.Tuple, nonKey types.Value, rowConv *rowconv.RowConverter) (row.Row, error) {
-	if types.IsNull(nonKey) {
-		return nil, nil
+// NextConflictRows returns the versions of the next conflicting row, or io.EOF when there are no more conflicts.
+func (cr *ConflictReader) NextConflictRows(ctx context.Context) (ConflictRows, error) {
+	key, value, err := cr.nextKV(ctx)
+
+	if err != nil {
+		return ConflictRows{}, err
+	}
+
+	if key == nil {
+		return ConflictRows{}, io.EOF
 	}
 
-	srcData, err := row.FromNoms(rowConv.SrcSch, key, nonKey.(types.Tuple))
+	keyTpl := key.(types.Tuple)
+	conflict, err := doltdb.ConflictFromTuple(value.(types.Tuple))
 
 	if err != nil {
-		return nil, err
+		return ConflictRows{}, err
+	}
+
+	cnf := ConflictRows{Key: keyTpl}
+	for _, v := range []struct {
+		r   *row.Row
+		sch schema.Schema
+		val types.Value
+	}{{&cnf.Base, cr.base, conflict.Base}, {&cnf.Ours, cr.sch, conflict.Value}, {&cnf.Theirs, cr.mergeSch, conflict.MergeValue}} {
+		if types.IsNull(v.val) {
+			continue
+		}
+
+		*v.r, err = row.FromNoms(v.sch, keyTpl, v.val.(types.Tuple))
+
+		if err != nil {
+			return ConflictRows{}, err
+		}
+	}
+
+	return cnf, nil
+}
+
+// RowsWithProps returns the rows shown for |cnf| by a ConflictSink: the base row, then our row and their row, as rows
+// of the reader's schema. A side which deleted the row is shown with the base row. The changed cells of our and their
+// rows are those whose values differ from the base row's, or from the other side's when both sides added the row.
+func (cr *ConflictReader) RowsWithProps(cnf ConflictRows) ([]pipeline.RowWithProps, error) {
+	var versions [3]row.Row
+	for i, v := range []struct {
+		r    row.Row
+		conv *rowconv.RowConverter
+	}{{cnf.Base, cr.baseConv}, {cnf.Ours, cr.conv}, {cnf.Theirs, cr.mergeConv}} {
+		if v.r == nil {
+			continue
+		}
+
+		var err error
+		versions[i], err = v.conv.Convert(v.r)
+
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	row, err := rowConv.Convert(srcData)
+	baseRow, r, mergeRow := versions[0], versions[1], versions[2]
+
+	var rows []pipeline.RowWithProps
+	if baseRow != nil {
+		rows = append(rows, pipeline.NewRowWithProps(baseRow, map[string]interface{}{mergeVersionProp: BaseVersion}))
+	}
+
+	for _, side := range []struct {
+		version     MergeVersion
+		r, otherRow row.Row
+	}{{OurVersion, r, mergeRow}, {TheirVersion, mergeRow, r}} {
+		compareTo := baseRow
+		props := map[string]interface{}{mergeVersionProp: side.version}
+
+		switch {
+		case side.r == nil:
+			if baseRow != nil {
+				props[mergeRowOperation] = types.DiffChangeRemoved
+				rows = append(rows, pipeline.NewRowWithProps(baseRow, props))
+			}
+
+			continue
+		case baseRow == nil:
+			props[mergeRowOperation] = types.DiffChangeAdded
+			compareTo = side.otherRow
+		default:
+			props[mergeRowOperation] = types.DiffChangeModified
+		}
+
+		changed, err := changedCols(cr.unionedSch, side.r, compareTo)
+
+		if err != nil {
+			return nil, err
+		}
+
+		props[changedColsProp] = changed
+		rows = append(rows, pipeline.NewRowWithProps(side.r, props))
+	}
+
+	rows[0].Props = rows[0].Props.Set(map[string]interface{}{conflictStartProp: true})
+	return rows, nil
+}
+
+// changedCols returns the tags of the columns of |sch| whose values in |r| differ from those in |compareTo|. Every
+// column is changed when there's no row to compare to.
+func changedCols(sch schema.Schema, r, compareTo row.Row) (map[uint64]bool, error) {
+	changed := make(map[uint64]bool)
+	err := sch.GetAllCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+		if compareTo == nil {
+			changed[tag] = true
+			return false, nil
+		}
+
+		val, _ := r.GetColVal(tag)
+		compareVal, _ := compareTo.GetColVal(tag)
+
+		if types.IsNull(val) != types.IsNull(compareVal) || (!types.IsNull(val) && !val.Equals(compareVal)) {
+			changed[tag] = true
+		}
+
+		return false, nil
+	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	return row, nil
+	return changed, nil
+}
+
+// NextConflict reads a row from a table.  If there is a bad row the returned error will be non nil, and callin IsBadRow(err)
+// will be return true. This is a potentially non-fatal error and callers can decide if they want to continue on a bad row, or fail.
+func (cr *ConflictReader) NextConflict(ctx context.Context) (row.Row, pipeline.ImmutableProperties, error) {
+	for len(cr.bufferedRows) == 0 {
+		cnf, err := cr.NextConflictRows(ctx)
+
+		if err == io.EOF {
+			return nil, pipeline.NoProps, io.EOF
+		} else if err != nil {
+			return nil, pipeline.ImmutableProperties{}, err
+		}
+
+		cr.bufferedRows, err = cr.RowsWithProps(cnf)
+
+		if err != nil {
+			return nil, pipeline.ImmutableProperties{}, err
+		}
+	}
+
+	result := cr.bufferedRows[0]
+	cr.bufferedRows = cr.bufferedRows[1:]
+	return result.Row, result.Props, nil
 }
 
 // Close should release resources being held
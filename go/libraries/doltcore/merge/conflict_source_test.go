@@ -0,0 +1,133 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+func TestConflictReaderRows(t *testing.T) {
+	ctx := context.Background()
+	cnfRd, err := NewConflictReader(ctx, mergedTableWithConflicts(t))
+	require.NoError(t, err)
+
+	modified, err := cnfRd.NextConflictRows(ctx)
+	require.NoError(t, err)
+	assert.True(t, modified.Key.Equals(keyTuples[8]))
+	assert.NotNil(t, modified.Base)
+	assert.Equal(t, changeModified, modified.OursChange())
+	assert.Equal(t, changeModified, modified.TheirsChange())
+
+	rows, err := cnfRd.RowsWithProps(modified)
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+
+	for i, version := range []MergeVersion{BaseVersion, OurVersion, TheirVersion} {
+		v, _ := rows[i].Props.Get(mergeVersionProp)
+		assert.Equal(t, version, v)
+
+		_, isStart := rows[i].Props.Get(conflictStartProp)
+		assert.Equal(t, i == 0, isStart)
+	}
+
+	// only the names of the modified rows differ from the base row's
+	for _, r := range rows[1:] {
+		changed, ok := r.Props.Get(changedColsProp)
+		require.True(t, ok)
+		assert.Equal(t, map[uint64]bool{nameTag: true}, changed)
+	}
+
+	added, err := cnfRd.NextConflictRows(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, added.Base)
+	assert.Equal(t, changeAdded, added.OursChange())
+
+	// the added rows are compared to each other, and have the same NULL title
+	rows, err = cnfRd.RowsWithProps(added)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	changed, _ := rows[0].Props.Get(changedColsProp)
+	assert.Equal(t, map[uint64]bool{nameTag: true}, changed)
+
+	_, err = cnfRd.NextConflictRows(ctx)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestConflictReaderForRanges(t *testing.T) {
+	ctx := context.Background()
+	tbl := mergedTableWithConflicts(t)
+
+	cnfRd, err := NewConflictReaderForRanges(ctx, tbl, []diff.KeyRange{{Start: keyTuples[10]}})
+	require.NoError(t, err)
+
+	cnf, err := cnfRd.NextConflictRows(ctx)
+	require.NoError(t, err)
+	assert.True(t, cnf.Key.Equals(keyTuples[12]))
+
+	_, err = cnfRd.NextConflictRows(ctx)
+	assert.Equal(t, io.EOF, err)
+
+	inFirstRange := func(key types.Value) (bool, error) {
+		return key.Less(types.Format_Default, keyTuples[9])
+	}
+
+	cnfRd, err = NewConflictReaderForRanges(ctx, tbl, []diff.KeyRange{{InRange: inFirstRange}})
+	require.NoError(t, err)
+
+	var read int
+	for _, err = cnfRd.NextConflictRows(ctx); err == nil; _, err = cnfRd.NextConflictRows(ctx) {
+		read++
+	}
+
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, 1, read)
+
+	cnfRd, err = NewConflictReaderForRanges(ctx, tbl, []diff.KeyRange{})
+	require.NoError(t, err)
+
+	_, err = cnfRd.NextConflictRows(ctx)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestConflictJoiner(t *testing.T) {
+	ctx := context.Background()
+	cnfRd, err := NewConflictReader(ctx, mergedTableWithConflicts(t))
+	require.NoError(t, err)
+
+	joiner, err := NewConflictJoiner(cnfRd.GetConflictSchemas())
+	require.NoError(t, err)
+
+	cnf, err := cnfRd.NextConflictRows(ctx)
+	require.NoError(t, err)
+
+	r, err := cnf.Join(joiner)
+	require.NoError(t, err)
+
+	for name, expected := range map[string]string{"base_name": "person 9", "ours_name": "person nine", "theirs_name": "person number nine"} {
+		col, ok := joiner.GetSchema().GetAllCols().GetByName(name)
+		require.True(t, ok)
+
+		val, _ := r.GetColVal(col.Tag)
+		assert.Equal(t, types.String(expected), val)
+	}
+}
@@ -26,30 +26,45 @@ import (
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/errcat"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/encoding"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/typed"
-	"github.com/liquidata-inc/dolt/go/libraries/utils/valutil"
 	"github.com/liquidata-inc/dolt/go/store/types"
 )
 
 var ErrFastForward = errors.New("fast forward")
-var ErrSameTblAddedTwice = errors.New("table with same name added in 2 commits can't be merged")
+var ErrSameTblAddedTwice = errcat.New(errcat.Conflict, "table with same name added in 2 commits can't be merged")
+var ErrCommentConflict = errcat.New(errcat.Conflict, "comment changed differently in both commits")
+
+// ErrTableHasConflicts is returned when merging a table which was changed in both commits, and which has conflicts
+// committed in either of them. The conflicts have to be resolved before the table can be merged, as they are recorded
+// against the rows of the commit which has them, and a merge would mix them up with the conflicts it finds itself.
+var ErrTableHasConflicts = errcat.New(errcat.Conflict, "table has unresolved conflicts committed")
 
 type Merger struct {
 	root      *doltdb.RootValue
 	mergeRoot *doltdb.RootValue
 	ancRoot   *doltdb.RootValue
 	vrw       types.ValueReadWriter
+	drivers   map[string]string
 }
 
 // NewMerger creates a new merger utility object.
 func NewMerger(ctx context.Context, root, mergeRoot, ancRoot *doltdb.RootValue, vrw types.ValueReadWriter) *Merger {
-	return &Merger{root, mergeRoot, ancRoot, vrw}
+	return &Merger{root, mergeRoot, ancRoot, vrw, nil}
+}
+
+// NewMergerWithDrivers creates a new merger utility object which merges the rows of the tables in |drivers|, keyed by
+// lower case table name, with the named merge drivers. Other tables are merged with the CellWiseMergeDriver.
+func NewMergerWithDrivers(ctx context.Context, root, mergeRoot, ancRoot *doltdb.RootValue, vrw types.ValueReadWriter, drivers map[string]string) *Merger {
+	return &Merger{root, mergeRoot, ancRoot, vrw, drivers}
 }
 
-// MergeTable merges schema and table data for the table tblName.
+// MergeTable merges schema and table data for the table tblName. The conflicts of a table which was only changed in one
+// of the commits are carried forward with it, while a table which was changed in both of them can only be merged if
+// neither has conflicts, or else ErrTableHasConflicts is returned.
 func (merger *Merger) MergeTable(ctx context.Context, tblName string) (*doltdb.Table, *MergeStats, error) {
 	tbl, ok, err := merger.root.GetTable(ctx, tblName)
 
@@ -116,6 +131,14 @@ func (merger *Merger) MergeTable(ctx context.Context, tblName string) (*doltdb.T
 		return tbl, &MergeStats{Operation: TableUnmodified}, nil
 	}
 
+	for _, t := range []*doltdb.Table{tbl, mergeTbl} {
+		if has, err := t.HasConflicts(); err != nil {
+			return nil, nil, err
+		} else if has {
+			return nil, nil, fmt.Errorf("%w: %s", ErrTableHasConflicts, tblName)
+		}
+	}
+
 	tblSchema, err := tbl.GetSchema(ctx)
 
 	if err != nil {
@@ -158,7 +181,20 @@ func (merger *Merger) MergeTable(ctx context.Context, tblName string) (*doltdb.T
 		return nil, nil, err
 	}
 
-	mergedRowData, conflicts, stats, err := mergeTableData(ctx, postMergeSchema, rows, mergeRows, ancRows, merger.vrw)
+	var mergedRowData, conflicts types.Map
+	var stats *MergeStats
+	driverName := merger.drivers[strings.ToLower(tblName)]
+	if driverName == "" {
+		driverName = CellWiseMergeDriver
+	}
+
+	if keyedDriver, ok := builtinMergeDrivers[driverName]; ok {
+		mergedRowData, conflicts, stats, err = mergeTableData(ctx, keyedDriver, postMergeSchema, rows, mergeRows, ancRows, merger.vrw)
+	} else if driver, ok := getRegisteredMergeDriver(driverName); ok {
+		mergedRowData, conflicts, stats, err = mergeTableDataWithDriver(ctx, driver, postMergeSchema, rows, mergeRows, ancRows, merger.vrw)
+	} else {
+		err = fmt.Errorf("%w '%s' selected for table %s", ErrUnknownMergeDriver, driverName, tblName)
+	}
 
 	if err != nil {
 		return nil, nil, err
@@ -257,10 +293,62 @@ func mergeTableSchema(sch, mergeSch, ancSch schema.Schema) (schema.Schema, error
 		return nil, err
 	}
 
-	return schema.SchemaFromCols(union), nil
+	// comments and storage of the columns remaining on both branches may have been changed on either
+	var cols []schema.Column
+	err = union.Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+		mergeCol, inMerge := mergeSch.GetAllCols().GetByTag(tag)
+
+		if _, inSch := sch.GetAllCols().GetByTag(tag); inSch && inMerge {
+			ancCol, _ := ancSch.GetAllCols().GetByTag(tag)
+			col.Comment, err = mergeComment(col.Comment, mergeCol.Comment, ancCol.Comment)
+
+			if err != nil {
+				return true, fmt.Errorf("%w: column %s", err, col.Name)
+			}
+
+			// where a column is stored doesn't change its values, so the merge branch's change is taken whenever it
+			// made one
+			if mergeCol.Cold != ancCol.Cold {
+				col.Cold = mergeCol.Cold
+			}
+		}
+
+		cols = append(cols, col)
+		return false, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err := mergeComment(sch.GetComment(), mergeSch.GetComment(), ancSch.GetComment())
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: table", err)
+	}
+
+	union, err = schema.NewColCollection(cols...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.SchemaWithComment(schema.SchemaFromCols(union), comment), nil
+}
+
+// mergeComment takes the comment from whichever branch changed it since the common ancestor, returning
+// ErrCommentConflict if both branches changed it to different values.
+func mergeComment(comment, mergeComment, ancComment string) (string, error) {
+	if comment == mergeComment || mergeComment == ancComment {
+		return comment, nil
+	} else if comment == ancComment {
+		return mergeComment, nil
+	}
+
+	return "", ErrCommentConflict
 }
 
-func mergeTableData(ctx context.Context, sch schema.Schema, rows, mergeRows, ancRows types.Map, vrw types.ValueReadWriter) (types.Map, types.Map, *MergeStats, error) {
+func mergeTableData(ctx context.Context, driver *keyedMergeDriver, sch schema.Schema, rows, mergeRows, ancRows types.Map, vrw types.ValueReadWriter) (types.Map, types.Map, *MergeStats, error) {
 	//changeChan1, changeChan2 := make(chan diff.Difference, 32), make(chan diff.Difference, 32)
 	ae := atomicerr.New()
 	changeChan, mergeChangeChan := make(chan types.ValueChanged, 32), make(chan types.ValueChanged, 32)
@@ -323,7 +411,11 @@ func mergeTableData(ctx context.Context, sch schema.Schema, rows, mergeRows, anc
 				}
 
 				if mkNilOrKeyLess {
-					// change will already be in the map
+					// change will already be in the map, unless it deleted a row the driver keeps
+					if driver.keepDeleted && change.ChangeType == types.DiffChangeRemoved {
+						applyChange(mapEditor, stats, types.ValueChanged{ChangeType: types.DiffChangeAdded, Key: key, NewValue: change.OldValue})
+					}
+
 					change = types.ValueChanged{}
 					processed = true
 				}
@@ -340,7 +432,10 @@ func mergeTableData(ctx context.Context, sch schema.Schema, rows, mergeRows, anc
 				}
 
 				if keyNilOrMKLess {
-					applyChange(mapEditor, stats, mergeChange)
+					if !driver.keepDeleted || mergeChange.ChangeType != types.DiffChangeRemoved {
+						applyChange(mapEditor, stats, mergeChange)
+					}
+
 					mergeChange = types.ValueChanged{}
 					processed = true
 				}
@@ -348,7 +443,7 @@ func mergeTableData(ctx context.Context, sch schema.Schema, rows, mergeRows, anc
 
 			if !processed {
 				r, mergeRow, ancRow := change.NewValue, mergeChange.NewValue, change.OldValue
-				mergedRow, isConflict, err := rowMerge(ctx, vrw.Format(), sch, r, mergeRow, ancRow)
+				mergedRow, isConflict, err := driver.mergeRow(ctx, vrw.Format(), sch, r, mergeRow, ancRow)
 
 				if err != nil {
 					return err
@@ -364,7 +459,14 @@ func mergeTableData(ctx context.Context, sch schema.Schema, rows, mergeRows, anc
 
 					addConflict(conflictValChan, key, conflictTuple)
 				} else {
-					applyChange(mapEditor, stats, types.ValueChanged{ChangeType: change.ChangeType, Key: key, OldValue: r, NewValue: mergedRow})
+					changeType := change.ChangeType
+					if mergedRow == nil {
+						changeType = types.DiffChangeRemoved
+					} else if r == nil {
+						changeType = types.DiffChangeAdded
+					}
+
+					applyChange(mapEditor, stats, types.ValueChanged{ChangeType: changeType, Key: key, OldValue: r, NewValue: mergedRow})
 				}
 
 				change = types.ValueChanged{}
@@ -415,19 +517,27 @@ func applyChange(me *types.MapEditor, stats *MergeStats, change types.ValueChang
 }
 
 func rowMerge(ctx context.Context, nbf *types.NomsBinFormat, sch schema.Schema, r, mergeRow, baseRow types.Value) (types.Value, bool, error) {
+	if baseRow == nil && r != nil && r.Equals(mergeRow) {
+		// same row added to both
+		return r, false, nil
+	}
+
+	return mergeRowCells(ctx, nbf, sch, r, mergeRow, baseRow, func(_ schema.Column, baseVal, val, mergeVal types.Value) (types.Value, bool) {
+		return mergeCell(baseVal, val, mergeVal)
+	})
+}
+
+// mergeRowCells merges a row changed on both branches by merging each of its non primary key columns with
+// |mergeCol|. A row deleted on one branch and modified on the other is a conflict.
+func mergeRowCells(ctx context.Context, nbf *types.NomsBinFormat, sch schema.Schema, r, mergeRow, baseRow types.Value, mergeCol func(col schema.Column, baseVal, val, mergeVal types.Value) (types.Value, bool)) (types.Value, bool, error) {
 	var baseVals row.TaggedValues
-	if baseRow == nil {
-		if r.Equals(mergeRow) {
-			// same row added to both
-			return r, false, nil
-		}
-	} else if r == nil && mergeRow == nil {
+	if r == nil && mergeRow == nil {
 		// same row removed from both
 		return nil, false, nil
 	} else if r == nil || mergeRow == nil {
 		// removed from one and modified in another
 		return nil, true, nil
-	} else {
+	} else if baseRow != nil {
 		var err error
 		baseVals, err = row.ParseTaggedValues(baseRow.(types.Tuple))
 
@@ -448,35 +558,17 @@ func rowMerge(ctx context.Context, nbf *types.NomsBinFormat, sch schema.Schema,
 		return nil, false, err
 	}
 
-	processTagFunc := func(tag uint64) (resultVal types.Value, isConflict bool) {
+	resultVals := make(row.TaggedValues)
+
+	var isConflict bool
+	err = sch.GetNonPKCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
 		baseVal, _ := baseVals.Get(tag)
 		val, _ := rowVals.Get(tag)
 		mergeVal, _ := mergeVals.Get(tag)
 
-		if valutil.NilSafeEqCheck(val, mergeVal) {
-			return val, false
-		} else {
-			modified := !valutil.NilSafeEqCheck(val, baseVal)
-			mergeModified := !valutil.NilSafeEqCheck(mergeVal, baseVal)
-			switch {
-			case modified && mergeModified:
-				return nil, true
-			case modified:
-				return val, false
-			default:
-				return mergeVal, false
-			}
-		}
-
-	}
-
-	resultVals := make(row.TaggedValues)
-
-	var isConflict bool
-	err = sch.GetNonPKCols().Iter(func(tag uint64, _ schema.Column) (stop bool, err error) {
-		var val types.Value
-		val, isConflict = processTagFunc(tag)
-		resultVals[tag] = val
+		var resultVal types.Value
+		resultVal, isConflict = mergeCol(col, baseVal, val, mergeVal)
+		resultVals[tag] = resultVal
 
 		return isConflict, nil
 	})
@@ -499,7 +591,9 @@ func rowMerge(ctx context.Context, nbf *types.NomsBinFormat, sch schema.Schema,
 	return v, false, nil
 }
 
-func MergeCommits(ctx context.Context, ddb *doltdb.DoltDB, commit, mergeCommit *doltdb.Commit) (*doltdb.RootValue, map[string]*MergeStats, error) {
+// MergeCommits merges |mergeCommit| into |commit|, like MergeRoots, from their common ancestor. The rows of the tables in
+// |drivers|, keyed by lower case table name, are merged with the named merge drivers. |drivers| may be nil.
+func MergeCommits(ctx context.Context, ddb *doltdb.DoltDB, commit, mergeCommit *doltdb.Commit, drivers map[string]string) (*doltdb.RootValue, map[string]*MergeStats, error) {
 	ancCommit, err := doltdb.GetCommitAncestor(ctx, commit, mergeCommit)
 
 	if err != nil {
@@ -524,7 +618,20 @@ func MergeCommits(ctx context.Context, ddb *doltdb.DoltDB, commit, mergeCommit *
 		return nil, nil, err
 	}
 
-	merger := NewMerger(ctx, root, mergeRoot, ancRoot, ddb.ValueReadWriter())
+	return MergeRootsWithDrivers(ctx, root, mergeRoot, ancRoot, ddb.ValueReadWriter(), drivers)
+}
+
+// MergeRoots merges the changes made in |mergeRoot| since |ancRoot| into |root|, returning the merged root and the
+// stats of the merge of each table. Rows which were changed differently in both roots are recorded as conflicts of the
+// merged tables.
+func MergeRoots(ctx context.Context, root, mergeRoot, ancRoot *doltdb.RootValue, vrw types.ValueReadWriter) (*doltdb.RootValue, map[string]*MergeStats, error) {
+	return MergeRootsWithDrivers(ctx, root, mergeRoot, ancRoot, vrw, nil)
+}
+
+// MergeRootsWithDrivers merges roots like MergeRoots, but merges the rows of the tables in |drivers|, keyed by lower
+// case table name, with the named merge drivers.
+func MergeRootsWithDrivers(ctx context.Context, root, mergeRoot, ancRoot *doltdb.RootValue, vrw types.ValueReadWriter, drivers map[string]string) (*doltdb.RootValue, map[string]*MergeStats, error) {
+	merger := NewMergerWithDrivers(ctx, root, mergeRoot, ancRoot, vrw, drivers)
 
 	tblNames, err := doltdb.UnionTableNames(ctx, root, mergeRoot)
 
@@ -0,0 +1,631 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/encoding"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/typed/noms"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/config"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/valutil"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const (
+	// CellWiseMergeDriver is the default merge driver. Rows changed on both branches are merged column by column, and
+	// are conflicts if both branches changed the same column differently, or one deleted a row the other modified.
+	CellWiseMergeDriver = "cell-wise"
+
+	// OursMergeDriver merges like CellWiseMergeDriver, but resolves any conflicting row with our version of it.
+	OursMergeDriver = "ours"
+
+	// TheirsMergeDriver merges like CellWiseMergeDriver, but resolves any conflicting row with their version of it.
+	TheirsMergeDriver = "theirs"
+
+	// UnionMergeDriver keeps every row which exists on either branch, so rows are only deleted if both branches deleted
+	// them. Rows modified on both branches are merged like CellWiseMergeDriver.
+	UnionMergeDriver = "union"
+
+	// SumNumericMergeDriver merges the numeric columns of rows changed on both branches by adding both branches'
+	// changes to the ancestor's value, treating the ancestor's value as zero for rows added on both branches. The other
+	// columns are merged like CellWiseMergeDriver, and sums which don't fit in their column are conflicts.
+	SumNumericMergeDriver = "sum-numeric-columns"
+
+	// MergeDriverConfigPrefix is the prefix of the config keys which select the merge driver of a table, e.g.
+	// merge.driver.counters.
+	MergeDriverConfigPrefix = "merge.driver."
+)
+
+// ErrUnknownMergeDriver is returned when merging a table whose selected merge driver is neither built in nor
+// registered.
+var ErrUnknownMergeDriver = errors.New("unknown merge driver")
+
+// ErrMergeDriverExists is returned when registering a merge driver with the name of a built in or already registered
+// merge driver.
+var ErrMergeDriverExists = errors.New("a merge driver with this name already exists")
+
+// MergeDriver merges the rows of a table which was changed on both branches of a merge. Each of the readers returns
+// the rows of one version of the table in primary key order, read with the merged schema |sch|. The driver writes
+// every row of the merged table to |out|, along with a conflict for each row it can't merge. Drivers are only used
+// for tables changed on both branches, tables changed on only one branch are always taken from that branch.
+type MergeDriver interface {
+	MergeRows(ctx context.Context, sch schema.Schema, base, ours, theirs table.TableReader, out MergedRowWriter) error
+}
+
+// MergedRowWriter receives the rows and conflicts of the merged table from a MergeDriver.
+type MergedRowWriter interface {
+	// WriteRow adds |r| to the merged table, replacing any row with the same primary key written before.
+	WriteRow(ctx context.Context, r row.Row) error
+
+	// WriteConflict records a conflict between the versions of a row. Any of them may be nil if the row doesn't exist
+	// in that version of the table. Until the conflict is resolved the merged table holds our version of the row.
+	WriteConflict(ctx context.Context, base, ours, theirs row.Row) error
+}
+
+// keyedMergeDriver is a built in merge driver, which merges each row changed on either branch independently so that
+// only the changed rows of the table need to be read.
+type keyedMergeDriver struct {
+	// mergeRow merges a row changed on both branches. The rows are nil where the row doesn't exist.
+	mergeRow func(ctx context.Context, nbf *types.NomsBinFormat, sch schema.Schema, r, mergeRow, baseRow types.Value) (types.Value, bool, error)
+
+	// keepDeleted is whether rows deleted on just one branch are kept.
+	keepDeleted bool
+}
+
+var builtinMergeDrivers = map[string]*keyedMergeDriver{
+	CellWiseMergeDriver:   {mergeRow: rowMerge},
+	OursMergeDriver:       {mergeRow: resolvingRowMerge(true)},
+	TheirsMergeDriver:     {mergeRow: resolvingRowMerge(false)},
+	UnionMergeDriver:      {mergeRow: unionRowMerge, keepDeleted: true},
+	SumNumericMergeDriver: {mergeRow: sumNumericRowMerge},
+}
+
+var registeredMergeDrivers = struct {
+	mu      *sync.RWMutex
+	drivers map[string]MergeDriver
+}{mu: &sync.RWMutex{}, drivers: make(map[string]MergeDriver)}
+
+// RegisterMergeDriver makes |driver| available to merges under |name|, which can then be selected for tables in the
+// same way as the built in merge drivers.
+func RegisterMergeDriver(name string, driver MergeDriver) error {
+	registeredMergeDrivers.mu.Lock()
+	defer registeredMergeDrivers.mu.Unlock()
+
+	if _, ok := builtinMergeDrivers[name]; ok {
+		return fmt.Errorf("%w: %s", ErrMergeDriverExists, name)
+	} else if _, ok := registeredMergeDrivers.drivers[name]; ok {
+		return fmt.Errorf("%w: %s", ErrMergeDriverExists, name)
+	}
+
+	registeredMergeDrivers.drivers[name] = driver
+	return nil
+}
+
+// MergeDriverNames returns the names of the built in and registered merge drivers in sorted order.
+func MergeDriverNames() []string {
+	registeredMergeDrivers.mu.RLock()
+	defer registeredMergeDrivers.mu.RUnlock()
+
+	names := make([]string, 0, len(builtinMergeDrivers)+len(registeredMergeDrivers.drivers))
+	for name := range builtinMergeDrivers {
+		names = append(names, name)
+	}
+
+	for name := range registeredMergeDrivers.drivers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// IsValidMergeDriver returns whether |name| is the name of a built in or registered merge driver.
+func IsValidMergeDriver(name string) bool {
+	if _, ok := builtinMergeDrivers[name]; ok {
+		return true
+	}
+
+	registeredMergeDrivers.mu.RLock()
+	defer registeredMergeDrivers.mu.RUnlock()
+	_, ok := registeredMergeDrivers.drivers[name]
+	return ok
+}
+
+func getRegisteredMergeDriver(name string) (MergeDriver, bool) {
+	registeredMergeDrivers.mu.RLock()
+	defer registeredMergeDrivers.mu.RUnlock()
+	driver, ok := registeredMergeDrivers.drivers[name]
+	return driver, ok
+}
+
+var mergeDriversColumns, _ = schema.NewColCollection(
+	schema.NewColumn(doltdb.MergeDriversTableCol, doltdb.MergeDriversTableTag, types.StringKind, true, schema.NotNullConstraint{}),
+	schema.NewColumn(doltdb.MergeDriversDriverCol, doltdb.MergeDriversDriverTag, types.StringKind, false, schema.NotNullConstraint{}),
+)
+
+// MergeDriversSchema is the schema of the dolt_merge_drivers table
+var MergeDriversSchema = schema.SchemaFromCols(mergeDriversColumns)
+
+// GetMergeDrivers returns the names of the merge drivers selected for the tables of |root|, keyed by the lower case
+// name of the table. Drivers are selected by the dolt_merge_drivers table of |root|, and by the merge.driver.<table>
+// keys of |cfg|, which take precedence. |cfg| may be nil. Tables without a selected driver aren't included.
+func GetMergeDrivers(ctx context.Context, root *doltdb.RootValue, cfg config.ReadableConfig) (map[string]string, error) {
+	drivers, err := GetTableMergeDrivers(ctx, root)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg == nil {
+		return drivers, nil
+	}
+
+	tblNames, err := root.GetTableNames(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tblName := range tblNames {
+		driver, err := cfg.GetString(MergeDriverConfigPrefix + strings.ToLower(tblName))
+
+		if err == nil && driver != "" {
+			drivers[strings.ToLower(tblName)] = driver
+		} else if err != nil && err != config.ErrConfigParamNotFound {
+			return nil, err
+		}
+	}
+
+	return drivers, nil
+}
+
+// GetTableMergeDrivers returns the merge drivers selected by the dolt_merge_drivers table of |root|, keyed by the lower
+// case name of the table.
+func GetTableMergeDrivers(ctx context.Context, root *doltdb.RootValue) (map[string]string, error) {
+	drivers := make(map[string]string)
+	tbl, ok, err := root.GetTable(ctx, doltdb.MergeDriversTableName)
+
+	if err != nil || !ok {
+		return drivers, err
+	}
+
+	sch, err := tbl.GetSchema(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rowData, err := tbl.GetRowData(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = rowData.IterAll(ctx, func(key, val types.Value) error {
+		r, err := row.FromNoms(sch, key.(types.Tuple), val.(types.Tuple))
+
+		if err != nil {
+			return err
+		}
+
+		tblName, _ := r.GetColVal(doltdb.MergeDriversTableTag)
+		driver, _ := r.GetColVal(doltdb.MergeDriversDriverTag)
+
+		if tblName != nil && driver != nil {
+			drivers[strings.ToLower(string(tblName.(types.String)))] = string(driver.(types.String))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return drivers, nil
+}
+
+// SetTableMergeDriver returns a root whose dolt_merge_drivers table selects |driver| for the table |tblName|, creating
+// the dolt_merge_drivers table if it doesn't already exist. An empty |driver| removes the table's driver.
+func SetTableMergeDriver(ctx context.Context, root *doltdb.RootValue, tblName, driver string) (*doltdb.RootValue, error) {
+	vrw := root.VRW()
+	tbl, ok, err := root.GetTable(ctx, doltdb.MergeDriversTableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		schVal, err := encoding.MarshalSchemaAsNomsValue(ctx, vrw, MergeDriversSchema)
+
+		if err != nil {
+			return nil, err
+		}
+
+		empty, err := types.NewMap(ctx, vrw)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tbl, err = doltdb.NewTable(ctx, vrw, schVal, empty)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rowData, err := tbl.GetRowData(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// remove the table's existing driver, which may be keyed by a differently cased table name
+	me := rowData.Edit()
+	err = rowData.IterAll(ctx, func(key, val types.Value) error {
+		tplVal, err := key.(types.Tuple).Get(1)
+
+		if err != nil {
+			return err
+		}
+
+		if name, ok := tplVal.(types.String); ok && strings.EqualFold(string(name), tblName) {
+			me.Remove(key)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if driver != "" {
+		r, err := row.New(vrw.Format(), MergeDriversSchema, row.TaggedValues{
+			doltdb.MergeDriversTableTag:  types.String(tblName),
+			doltdb.MergeDriversDriverTag: types.String(driver),
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := r.NomsMapKey(MergeDriversSchema).Value(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		val, err := r.NomsMapValue(MergeDriversSchema).Value(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		me.Set(key, val)
+	}
+
+	rowData, err = me.Map(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tbl, err = tbl.UpdateRows(ctx, rowData)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return root.PutTable(ctx, doltdb.MergeDriversTableName, tbl)
+}
+
+// resolvingRowMerge returns a row merge function which merges like rowMerge, but resolves conflicts with our row if
+// |ours| is true, or their row otherwise.
+func resolvingRowMerge(ours bool) func(ctx context.Context, nbf *types.NomsBinFormat, sch schema.Schema, r, mergeRow, baseRow types.Value) (types.Value, bool, error) {
+	return func(ctx context.Context, nbf *types.NomsBinFormat, sch schema.Schema, r, mergeRow, baseRow types.Value) (types.Value, bool, error) {
+		merged, isConflict, err := rowMerge(ctx, nbf, sch, r, mergeRow, baseRow)
+
+		if err != nil || !isConflict {
+			return merged, isConflict, err
+		} else if ours {
+			return r, false, nil
+		}
+
+		return mergeRow, false, nil
+	}
+}
+
+func unionRowMerge(ctx context.Context, nbf *types.NomsBinFormat, sch schema.Schema, r, mergeRow, baseRow types.Value) (types.Value, bool, error) {
+	if r == nil {
+		return mergeRow, false, nil
+	} else if mergeRow == nil {
+		return r, false, nil
+	}
+
+	return rowMerge(ctx, nbf, sch, r, mergeRow, baseRow)
+}
+
+func sumNumericRowMerge(ctx context.Context, nbf *types.NomsBinFormat, sch schema.Schema, r, mergeRow, baseRow types.Value) (types.Value, bool, error) {
+	if r == nil || mergeRow == nil {
+		return rowMerge(ctx, nbf, sch, r, mergeRow, baseRow)
+	}
+
+	return mergeRowCells(ctx, nbf, sch, r, mergeRow, baseRow, func(col schema.Column, baseVal, val, mergeVal types.Value) (types.Value, bool) {
+		if types.IsNull(val) || types.IsNull(mergeVal) {
+			return mergeCell(baseVal, val, mergeVal)
+		}
+
+		sum, ok := sumNumericChanges(baseVal, val, mergeVal)
+
+		if !ok {
+			return mergeCell(baseVal, val, mergeVal)
+		} else if sum == nil || !col.TypeInfo.IsValid(sum) {
+			return nil, true
+		}
+
+		return sum, false
+	})
+}
+
+// sumNumericChanges returns |val| + |mergeVal| - |baseVal|, or false if the values aren't all of the same numeric
+// kind. A null |baseVal| counts as zero. The returned value is nil if the sum overflows.
+func sumNumericChanges(baseVal, val, mergeVal types.Value) (types.Value, bool) {
+	switch v := val.(type) {
+	case types.Int:
+		mv, ok := mergeVal.(types.Int)
+		bv, baseOk := baseVal.(types.Int)
+
+		if !ok || !(baseOk || types.IsNull(baseVal)) {
+			return nil, false
+		}
+
+		sum := big.NewInt(int64(v))
+		sum.Add(sum, big.NewInt(int64(mv)))
+		sum.Sub(sum, big.NewInt(int64(bv)))
+
+		if !sum.IsInt64() {
+			return nil, true
+		}
+
+		return types.Int(sum.Int64()), true
+
+	case types.Uint:
+		mv, ok := mergeVal.(types.Uint)
+		bv, baseOk := baseVal.(types.Uint)
+
+		if !ok || !(baseOk || types.IsNull(baseVal)) {
+			return nil, false
+		}
+
+		sum := new(big.Int).SetUint64(uint64(v))
+		sum.Add(sum, new(big.Int).SetUint64(uint64(mv)))
+		sum.Sub(sum, new(big.Int).SetUint64(uint64(bv)))
+
+		if !sum.IsUint64() {
+			return nil, true
+		}
+
+		return types.Uint(sum.Uint64()), true
+
+	case types.Float:
+		mv, ok := mergeVal.(types.Float)
+		bv, baseOk := baseVal.(types.Float)
+
+		if !ok || !(baseOk || types.IsNull(baseVal)) {
+			return nil, false
+		}
+
+		sum := float64(v) + float64(mv) - float64(bv)
+
+		if math.IsInf(sum, 0) || math.IsNaN(sum) {
+			return nil, true
+		}
+
+		return types.Float(sum), true
+	}
+
+	return nil, false
+}
+
+// mergeCell merges the values of a column of a row changed on both branches, returning whether the column is
+// a conflict.
+func mergeCell(baseVal, val, mergeVal types.Value) (types.Value, bool) {
+	if valutil.NilSafeEqCheck(val, mergeVal) {
+		return val, false
+	}
+
+	modified := !valutil.NilSafeEqCheck(val, baseVal)
+	mergeModified := !valutil.NilSafeEqCheck(mergeVal, baseVal)
+	switch {
+	case modified && mergeModified:
+		return nil, true
+	case modified:
+		return val, false
+	default:
+		return mergeVal, false
+	}
+}
+
+// mergeTableDataWithDriver merges the rows of a table changed on both branches using a MergeDriver.
+func mergeTableDataWithDriver(ctx context.Context, driver MergeDriver, sch schema.Schema, rows, mergeRows, ancRows types.Map, vrw types.ValueReadWriter) (types.Map, types.Map, *MergeStats, error) {
+	base, err := noms.NewNomsMapReader(ctx, ancRows, sch)
+
+	if err != nil {
+		return types.EmptyMap, types.EmptyMap, nil, err
+	}
+
+	defer base.Close(ctx)
+
+	ours, err := noms.NewNomsMapReader(ctx, rows, sch)
+
+	if err != nil {
+		return types.EmptyMap, types.EmptyMap, nil, err
+	}
+
+	defer ours.Close(ctx)
+
+	theirs, err := noms.NewNomsMapReader(ctx, mergeRows, sch)
+
+	if err != nil {
+		return types.EmptyMap, types.EmptyMap, nil, err
+	}
+
+	defer theirs.Close(ctx)
+
+	out, err := newDriverRowWriter(ctx, sch, vrw)
+
+	if err != nil {
+		return types.EmptyMap, types.EmptyMap, nil, err
+	}
+
+	err = driver.MergeRows(ctx, sch, base, ours, theirs, out)
+
+	if err != nil {
+		return types.EmptyMap, types.EmptyMap, nil, err
+	}
+
+	mergedData, err := out.rows.Map(ctx)
+
+	if err != nil {
+		return types.EmptyMap, types.EmptyMap, nil, err
+	}
+
+	conflicts, err := out.conflicts.Map(ctx)
+
+	if err != nil {
+		return types.EmptyMap, types.EmptyMap, nil, err
+	}
+
+	summary, err := diff.SummarizeRowChanges(ctx, mergedData, rows)
+
+	if err != nil {
+		return types.EmptyMap, types.EmptyMap, nil, err
+	}
+
+	stats := &MergeStats{
+		Operation:     TableModified,
+		Adds:          int(summary.Adds),
+		Deletes:       int(summary.Removes),
+		Modifications: int(summary.Changes),
+		Conflicts:     int(conflicts.Len()),
+	}
+
+	return mergedData, conflicts, stats, nil
+}
+
+// driverRowWriter is the MergedRowWriter given to merge drivers, which builds the merged row data and conflicts.
+type driverRowWriter struct {
+	sch       schema.Schema
+	vrw       types.ValueReadWriter
+	rows      *types.MapEditor
+	conflicts *types.MapEditor
+}
+
+func newDriverRowWriter(ctx context.Context, sch schema.Schema, vrw types.ValueReadWriter) (*driverRowWriter, error) {
+	rows, err := types.NewMap(ctx, vrw)
+
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts, err := types.NewMap(ctx, vrw)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &driverRowWriter{sch, vrw, rows.Edit(), conflicts.Edit()}, nil
+}
+
+// WriteRow adds |r| to the merged table.
+func (w *driverRowWriter) WriteRow(ctx context.Context, r row.Row) error {
+	key, err := r.NomsMapKey(w.sch).Value(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	val, err := r.NomsMapValue(w.sch).Value(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	w.rows.Set(key, val)
+	return nil
+}
+
+// WriteConflict records a conflict between the versions of a row, leaving our version in the merged table.
+func (w *driverRowWriter) WriteConflict(ctx context.Context, base, ours, theirs row.Row) error {
+	var key types.LesserValuable
+	for _, r := range []row.Row{ours, theirs, base} {
+		if r != nil {
+			key = r.NomsMapKey(w.sch)
+			break
+		}
+	}
+
+	if key == nil {
+		return errors.New("a conflict must have at least one version of the row")
+	}
+
+	keyVal, err := key.Value(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	vals := make([]types.Value, 3)
+	for i, r := range []row.Row{base, ours, theirs} {
+		if r != nil {
+			vals[i], err = r.NomsMapValue(w.sch).Value(ctx)
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	conflictTuple, err := doltdb.NewConflict(vals[0], vals[1], vals[2]).ToNomsList(w.vrw)
+
+	if err != nil {
+		return err
+	}
+
+	w.conflicts.Set(keyVal, conflictTuple)
+
+	if ours != nil {
+		w.rows.Set(keyVal, vals[1])
+	} else {
+		w.rows.Remove(keyVal)
+	}
+
+	return nil
+}
@@ -0,0 +1,331 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/encoding"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/config"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const (
+	driverTestTable = "counters"
+
+	driverPkTag    = 0
+	driverNameTag  = 1
+	driverCountTag = 2
+)
+
+var driverTestColColl, _ = schema.NewColCollection(
+	schema.NewColumn("pk", driverPkTag, types.IntKind, true, schema.NotNullConstraint{}),
+	schema.NewColumn("name", driverNameTag, types.StringKind, false),
+	schema.NewColumn("count", driverCountTag, types.IntKind, false),
+)
+var driverTestSch = schema.SchemaFromCols(driverTestColColl)
+
+type driverTestRow struct {
+	name  string
+	count int64
+}
+
+func driverTestRows(t *testing.T, vrw types.ValueReadWriter, rows map[int64]driverTestRow) types.Map {
+	m, err := types.NewMap(context.Background(), vrw)
+	require.NoError(t, err)
+
+	me := m.Edit()
+	for pk, tr := range rows {
+		r, err := row.New(vrw.Format(), driverTestSch, row.TaggedValues{
+			driverPkTag:    types.Int(pk),
+			driverNameTag:  types.String(tr.name),
+			driverCountTag: types.Int(tr.count),
+		})
+		require.NoError(t, err)
+
+		me.Set(r.NomsMapKey(driverTestSch), r.NomsMapValue(driverTestSch))
+	}
+
+	m, err = me.Map(context.Background())
+	require.NoError(t, err)
+
+	return m
+}
+
+func readDriverTestRows(t *testing.T, tbl *doltdb.Table) map[int64]driverTestRow {
+	rowData, err := tbl.GetRowData(context.Background())
+	require.NoError(t, err)
+
+	rows := make(map[int64]driverTestRow)
+	err = rowData.IterAll(context.Background(), func(key, val types.Value) error {
+		r, err := row.FromNoms(driverTestSch, key.(types.Tuple), val.(types.Tuple))
+		require.NoError(t, err)
+
+		pk, _ := r.GetColVal(driverPkTag)
+		name, _ := r.GetColVal(driverNameTag)
+		count, _ := r.GetColVal(driverCountTag)
+		rows[int64(pk.(types.Int))] = driverTestRow{string(name.(types.String)), int64(count.(types.Int))}
+		return nil
+	})
+	require.NoError(t, err)
+
+	return rows
+}
+
+// setupDriverMergeTest returns our, their and the ancestor's root, each holding the counters table with the given rows.
+func setupDriverMergeTest(t *testing.T, rows, mergeRows, ancRows map[int64]driverTestRow) (types.ValueReadWriter, *doltdb.RootValue, *doltdb.RootValue, *doltdb.RootValue) {
+	ctx := context.Background()
+	ddb, err := doltdb.LoadDoltDB(ctx, types.Format_Default, doltdb.InMemDoltDB)
+	require.NoError(t, err)
+	require.NoError(t, ddb.WriteEmptyRepo(ctx, name, email))
+
+	vrw := ddb.ValueReadWriter()
+	masterHeadSpec, _ := doltdb.NewCommitSpec("head", "master")
+	masterHead, err := ddb.Resolve(ctx, masterHeadSpec)
+	require.NoError(t, err)
+	emptyRoot, err := masterHead.GetRootValue()
+	require.NoError(t, err)
+
+	schVal, err := encoding.MarshalSchemaAsNomsValue(ctx, vrw, driverTestSch)
+	require.NoError(t, err)
+
+	roots := make([]*doltdb.RootValue, 3)
+	for i, r := range []map[int64]driverTestRow{rows, mergeRows, ancRows} {
+		tbl, err := doltdb.NewTable(ctx, vrw, schVal, driverTestRows(t, vrw, r))
+		require.NoError(t, err)
+		roots[i], err = emptyRoot.PutTable(ctx, driverTestTable, tbl)
+		require.NoError(t, err)
+	}
+
+	return vrw, roots[0], roots[1], roots[2]
+}
+
+func TestBuiltinMergeDrivers(t *testing.T) {
+	ancRows := map[int64]driverTestRow{
+		1: {"one", 10},
+		2: {"two", 20},
+		3: {"three", 0},
+	}
+	rows := map[int64]driverTestRow{
+		1: {"uno", 12},
+		3: {"three", math.MaxInt64},
+		4: {"four", 4},
+	}
+	mergeRows := map[int64]driverTestRow{
+		1: {"ein", 15},
+		2: {"two", 20},
+		3: {"three", 1},
+		4: {"four", 6},
+	}
+
+	tests := []struct {
+		driver            string
+		expectedRows      map[int64]driverTestRow
+		expectedConflicts int
+	}{
+		{
+			driver: CellWiseMergeDriver,
+			expectedRows: map[int64]driverTestRow{
+				1: {"uno", 12},
+				3: {"three", math.MaxInt64},
+				4: {"four", 4},
+			},
+			expectedConflicts: 3,
+		},
+		{
+			driver: OursMergeDriver,
+			expectedRows: map[int64]driverTestRow{
+				1: {"uno", 12},
+				3: {"three", math.MaxInt64},
+				4: {"four", 4},
+			},
+		},
+		{
+			driver: TheirsMergeDriver,
+			expectedRows: map[int64]driverTestRow{
+				1: {"ein", 15},
+				3: {"three", 1},
+				4: {"four", 6},
+			},
+		},
+		{
+			driver: UnionMergeDriver,
+			expectedRows: map[int64]driverTestRow{
+				1: {"uno", 12},
+				2: {"two", 20},
+				3: {"three", math.MaxInt64},
+				4: {"four", 4},
+			},
+			expectedConflicts: 3,
+		},
+		{
+			driver: SumNumericMergeDriver,
+			expectedRows: map[int64]driverTestRow{
+				1: {"uno", 12},
+				3: {"three", math.MaxInt64},
+				4: {"four", 10},
+			},
+			// the names of row 1 and the overflowing count of row 3
+			expectedConflicts: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.driver, func(t *testing.T) {
+			vrw, root, mergeRoot, ancRoot := setupDriverMergeTest(t, rows, mergeRows, ancRows)
+			merger := NewMergerWithDrivers(context.Background(), root, mergeRoot, ancRoot, vrw, map[string]string{driverTestTable: test.driver})
+
+			merged, stats, err := merger.MergeTable(context.Background(), driverTestTable)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedRows, readDriverTestRows(t, merged))
+			assert.Equal(t, test.expectedConflicts, stats.Conflicts)
+		})
+	}
+
+	t.Run("sum-numeric-columns sums counters", func(t *testing.T) {
+		vrw, root, mergeRoot, ancRoot := setupDriverMergeTest(t,
+			map[int64]driverTestRow{1: {"one", 12}},
+			map[int64]driverTestRow{1: {"one", 15}},
+			map[int64]driverTestRow{1: {"one", 10}})
+		merger := NewMergerWithDrivers(context.Background(), root, mergeRoot, ancRoot, vrw, map[string]string{driverTestTable: SumNumericMergeDriver})
+
+		merged, stats, err := merger.MergeTable(context.Background(), driverTestTable)
+		require.NoError(t, err)
+		assert.Equal(t, map[int64]driverTestRow{1: {"one", 17}}, readDriverTestRows(t, merged))
+		assert.Equal(t, 0, stats.Conflicts)
+		assert.Equal(t, 1, stats.Modifications)
+	})
+}
+
+func TestUnknownMergeDriver(t *testing.T) {
+	rows := map[int64]driverTestRow{1: {"one", 1}}
+	vrw, root, mergeRoot, ancRoot := setupDriverMergeTest(t, rows, map[int64]driverTestRow{1: {"one", 2}}, map[int64]driverTestRow{})
+	merger := NewMergerWithDrivers(context.Background(), root, mergeRoot, ancRoot, vrw, map[string]string{driverTestTable: "not-a-driver"})
+
+	_, _, err := merger.MergeTable(context.Background(), driverTestTable)
+	assert.True(t, errors.Is(err, ErrUnknownMergeDriver))
+}
+
+// theirsWithConflictsDriver takes every row from their branch, and records a conflict for each row of ours which they
+// don't have.
+type theirsWithConflictsDriver struct{}
+
+func (d theirsWithConflictsDriver) MergeRows(ctx context.Context, sch schema.Schema, base, ours, theirs table.TableReader, out MergedRowWriter) error {
+	theirRows := make(map[types.Value]bool)
+	for {
+		r, err := theirs.ReadRow(ctx)
+
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		pk, _ := r.GetColVal(driverPkTag)
+		theirRows[pk] = true
+
+		if err := out.WriteRow(ctx, r); err != nil {
+			return err
+		}
+	}
+
+	for {
+		r, err := ours.ReadRow(ctx)
+
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if pk, _ := r.GetColVal(driverPkTag); !theirRows[pk] {
+			if err := out.WriteConflict(ctx, nil, r, nil); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func TestRegisteredMergeDriver(t *testing.T) {
+	const driverName = "theirs-with-conflicts"
+	require.NoError(t, RegisterMergeDriver(driverName, theirsWithConflictsDriver{}))
+	assert.True(t, IsValidMergeDriver(driverName))
+	assert.Contains(t, MergeDriverNames(), driverName)
+	assert.True(t, errors.Is(RegisterMergeDriver(driverName, theirsWithConflictsDriver{}), ErrMergeDriverExists))
+	assert.True(t, errors.Is(RegisterMergeDriver(UnionMergeDriver, theirsWithConflictsDriver{}), ErrMergeDriverExists))
+
+	vrw, root, mergeRoot, ancRoot := setupDriverMergeTest(t,
+		map[int64]driverTestRow{1: {"one", 1}, 2: {"two", 2}},
+		map[int64]driverTestRow{1: {"uno", 1}, 3: {"three", 3}},
+		map[int64]driverTestRow{1: {"one", 1}})
+	merger := NewMergerWithDrivers(context.Background(), root, mergeRoot, ancRoot, vrw, map[string]string{driverTestTable: driverName})
+
+	merged, stats, err := merger.MergeTable(context.Background(), driverTestTable)
+	require.NoError(t, err)
+
+	// the conflicting row holds our version until it's resolved
+	assert.Equal(t, map[int64]driverTestRow{1: {"uno", 1}, 2: {"two", 2}, 3: {"three", 3}}, readDriverTestRows(t, merged))
+	assert.Equal(t, 1, stats.Conflicts)
+	assert.Equal(t, 1, stats.Adds)
+	assert.Equal(t, 1, stats.Modifications)
+
+	has, err := merged.HasConflicts()
+	require.NoError(t, err)
+	assert.True(t, has)
+}
+
+func TestSetTableMergeDriver(t *testing.T) {
+	ctx := context.Background()
+	rows := map[int64]driverTestRow{1: {"one", 1}}
+	_, root, _, _ := setupDriverMergeTest(t, rows, rows, rows)
+
+	drivers, err := GetMergeDrivers(ctx, root, nil)
+	require.NoError(t, err)
+	assert.Empty(t, drivers)
+
+	root, err = SetTableMergeDriver(ctx, root, driverTestTable, OursMergeDriver)
+	require.NoError(t, err)
+	root, err = SetTableMergeDriver(ctx, root, "Counters", SumNumericMergeDriver)
+	require.NoError(t, err)
+	root, err = SetTableMergeDriver(ctx, root, "other", UnionMergeDriver)
+	require.NoError(t, err)
+
+	drivers, err = GetTableMergeDrivers(ctx, root)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{driverTestTable: SumNumericMergeDriver, "other": UnionMergeDriver}, drivers)
+
+	cfg := config.NewMapConfig(map[string]string{MergeDriverConfigPrefix + driverTestTable: TheirsMergeDriver})
+	drivers, err = GetMergeDrivers(ctx, root, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{driverTestTable: TheirsMergeDriver, "other": UnionMergeDriver}, drivers)
+
+	root, err = SetTableMergeDriver(ctx, root, "COUNTERS", "")
+	require.NoError(t, err)
+
+	drivers, err = GetTableMergeDrivers(ctx, root)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"other": UnionMergeDriver}, drivers)
+}
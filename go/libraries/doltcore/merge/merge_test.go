@@ -16,6 +16,7 @@ package merge
 
 import (
 	"context"
+	"errors"
 	"strconv"
 	"testing"
 
@@ -468,3 +469,86 @@ func TestMergeCommits(t *testing.T) {
 		}
 	}
 }
+
+func commentedSchema(tableComment, colComment string) schema.Schema {
+	pk := schema.NewColumn("pk", 0, types.IntKind, true, schema.NotNullConstraint{})
+	col := schema.NewColumn("c1", 1, types.StringKind, false)
+	col.Comment = colComment
+
+	colColl, _ := schema.NewColCollection(pk, col)
+	return schema.SchemaWithComment(schema.SchemaFromCols(colColl), tableComment)
+}
+
+func TestMergeTableSchemaComments(t *testing.T) {
+	tests := []struct {
+		name                        string
+		sch, mergeSch, ancSch       schema.Schema
+		expectedTblCmt, expectedCmt string
+		expectConflict              bool
+	}{
+		{
+			name:           "unchanged",
+			sch:            commentedSchema("tbl", "col"),
+			mergeSch:       commentedSchema("tbl", "col"),
+			ancSch:         commentedSchema("tbl", "col"),
+			expectedTblCmt: "tbl",
+			expectedCmt:    "col",
+		},
+		{
+			name:           "changed on merge branch",
+			sch:            commentedSchema("tbl", "col"),
+			mergeSch:       commentedSchema("new tbl", "new col"),
+			ancSch:         commentedSchema("tbl", "col"),
+			expectedTblCmt: "new tbl",
+			expectedCmt:    "new col",
+		},
+		{
+			name:           "changed on main branch",
+			sch:            commentedSchema("", "new col"),
+			mergeSch:       commentedSchema("tbl", "col"),
+			ancSch:         commentedSchema("tbl", "col"),
+			expectedTblCmt: "",
+			expectedCmt:    "new col",
+		},
+		{
+			name:           "changed the same on both branches",
+			sch:            commentedSchema("new tbl", "new col"),
+			mergeSch:       commentedSchema("new tbl", "new col"),
+			ancSch:         commentedSchema("tbl", "col"),
+			expectedTblCmt: "new tbl",
+			expectedCmt:    "new col",
+		},
+		{
+			name:           "table comment changed differently",
+			sch:            commentedSchema("tbl 1", "col"),
+			mergeSch:       commentedSchema("tbl 2", "col"),
+			ancSch:         commentedSchema("tbl", "col"),
+			expectConflict: true,
+		},
+		{
+			name:           "column comment changed differently",
+			sch:            commentedSchema("tbl", "col 1"),
+			mergeSch:       commentedSchema("tbl", "col 2"),
+			ancSch:         commentedSchema("tbl", "col"),
+			expectConflict: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merged, err := mergeTableSchema(test.sch, test.mergeSch, test.ancSch)
+
+			if test.expectConflict {
+				assert.True(t, errors.Is(err, ErrCommentConflict))
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedTblCmt, merged.GetComment())
+
+			col, ok := merged.GetAllCols().GetByName("c1")
+			require.True(t, ok)
+			assert.Equal(t, test.expectedCmt, col.Comment)
+		})
+	}
+}
@@ -0,0 +1,428 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package migrate rewrites the contents of a noms database from one storage format into another.
+package migrate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/liquidata-inc/dolt/go/store/datas"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const walkBatchSize = 256
+
+// formatOrder lists the supported storage formats from oldest to newest.
+var formatOrder = []*types.NomsBinFormat{types.Format_7_18, types.Format_LD_1}
+
+// Progress is sent on the progress channel of MigrateFormat each time a value is written to the destination database.
+type Progress struct {
+	ValuesWritten uint64
+}
+
+// Estimate describes the data that a format migration would need to rewrite.
+type Estimate struct {
+	Datasets int
+	Chunks   uint64
+	Bytes    uint64
+}
+
+// Result holds the hashes which changed while migrating a database.
+type Result struct {
+	// Roots maps each of the root hashes given to MigrateFormat to the hash of its migrated value.
+	Roots map[hash.Hash]hash.Hash
+
+	// Commits maps the hash of every commit whose hash changed to the hash of its migrated commit. Commits which
+	// contain no format dependent values keep their hash and are not included.
+	Commits map[hash.Hash]hash.Hash
+}
+
+// NeedsFormatMigration returns true if |nbf| is a supported format which is older than types.Format_Default.
+func NeedsFormatMigration(nbf *types.NomsBinFormat) bool {
+	return formatIndex(nbf) < formatIndex(types.Format_Default)
+}
+
+func formatIndex(nbf *types.NomsBinFormat) int {
+	for i, f := range formatOrder {
+		if f == nbf {
+			return i
+		}
+	}
+
+	return len(formatOrder)
+}
+
+// MigrateFormat writes every dataset in |src|, along with the values with the hashes |roots| which need not be
+// reachable from a dataset, into |dest| using the storage format of |dest|. |src| is only read from.
+func MigrateFormat(ctx context.Context, src, dest datas.Database, roots []hash.Hash, progChan chan<- Progress) (*Result, error) {
+	m := &migrator{
+		src:      src,
+		dest:     dest,
+		nbf:      dest.Format(),
+		refs:     make(map[hash.Hash]types.Ref),
+		commits:  make(map[hash.Hash]hash.Hash),
+		progChan: progChan,
+	}
+
+	dss, err := src.Datasets(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = dss.IterAll(ctx, func(k, v types.Value) error {
+		newHead, err := m.migrateRef(ctx, v.(types.Ref))
+
+		if err != nil {
+			return err
+		}
+
+		ds, err := dest.GetDataset(ctx, string(k.(types.String)))
+
+		if err != nil {
+			return err
+		}
+
+		_, err = dest.SetHead(ctx, ds, newHead)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	migratedRoots := make(map[hash.Hash]hash.Hash, len(roots))
+	for _, h := range roots {
+		if _, ok := migratedRoots[h]; ok {
+			continue
+		}
+
+		v, err := src.ReadValue(ctx, h)
+
+		if err != nil {
+			return nil, err
+		} else if v == nil {
+			return nil, fmt.Errorf("unable to read value %s from the source database", h.String())
+		}
+
+		nv, err := m.migrateValue(ctx, v)
+
+		if err != nil {
+			return nil, err
+		}
+
+		r, err := m.writeValue(ctx, nv)
+
+		if err != nil {
+			return nil, err
+		}
+
+		migratedRoots[h] = r.TargetHash()
+	}
+
+	err = dest.Flush(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{Roots: migratedRoots, Commits: m.commits}, nil
+}
+
+type migrator struct {
+	src      datas.Database
+	dest     datas.Database
+	nbf      *types.NomsBinFormat
+	refs     map[hash.Hash]types.Ref
+	commits  map[hash.Hash]hash.Hash
+	written  uint64
+	progChan chan<- Progress
+}
+
+func (m *migrator) migrateRef(ctx context.Context, r types.Ref) (types.Ref, error) {
+	newRef, ok := m.refs[r.TargetHash()]
+
+	if !ok {
+		v, err := r.TargetValue(ctx, m.src)
+
+		if err != nil {
+			return types.Ref{}, err
+		} else if v == nil {
+			return types.Ref{}, fmt.Errorf("unable to read value %s from the source database", r.TargetHash().String())
+		}
+
+		nv, err := m.migrateValue(ctx, v)
+
+		if err != nil {
+			return types.Ref{}, err
+		}
+
+		newRef, err = m.writeValue(ctx, nv)
+
+		if err != nil {
+			return types.Ref{}, err
+		}
+
+		m.refs[r.TargetHash()] = newRef
+
+		if newRef.TargetHash() != r.TargetHash() {
+			if isCommit, err := datas.IsCommit(v); err != nil {
+				return types.Ref{}, err
+			} else if isCommit {
+				m.commits[r.TargetHash()] = newRef.TargetHash()
+			}
+		}
+	}
+
+	// refs which were written without type information must stay that way for their parents to keep their hashes
+	targetType, err := r.TargetType()
+
+	if err != nil {
+		return types.Ref{}, err
+	}
+
+	if targetType.TargetKind() == types.ValueKind {
+		return types.ToRefOfValue(newRef, m.nbf)
+	}
+
+	return newRef, nil
+}
+
+func (m *migrator) writeValue(ctx context.Context, v types.Value) (types.Ref, error) {
+	r, err := m.dest.WriteValue(ctx, v)
+
+	if err != nil {
+		return types.Ref{}, err
+	}
+
+	m.written++
+	if m.progChan != nil {
+		m.progChan <- Progress{ValuesWritten: m.written}
+	}
+
+	return r, nil
+}
+
+// migrateValue rebuilds |v| in the destination format. Collections are rebuilt from their elements, which writes
+// their chunks to the destination database as needed.
+func (m *migrator) migrateValue(ctx context.Context, v types.Value) (types.Value, error) {
+	switch v := v.(type) {
+	case types.Ref:
+		return m.migrateRef(ctx, v)
+
+	case types.Struct:
+		data := make(types.StructData)
+		err := v.IterFields(func(name string, fv types.Value) error {
+			nv, err := m.migrateValue(ctx, fv)
+			data[name] = nv
+			return err
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		return types.NewStruct(m.nbf, v.Name(), data)
+
+	case types.Tuple:
+		var vals []types.Value
+		err := v.IterFields(func(_ uint64, fv types.Value) (bool, error) {
+			nv, err := m.migrateValue(ctx, fv)
+			vals = append(vals, nv)
+			return false, err
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		return types.NewTuple(m.nbf, vals...)
+
+	case types.Map:
+		empty, err := types.NewMap(ctx, m.dest)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ed := empty.Edit()
+		err = v.IterAll(ctx, func(key, val types.Value) error {
+			nk, err := m.migrateValue(ctx, key)
+
+			if err != nil {
+				return err
+			}
+
+			nv, err := m.migrateValue(ctx, val)
+
+			if err != nil {
+				return err
+			}
+
+			ed.Set(nk, nv)
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		return ed.Map(ctx)
+
+	case types.Set:
+		empty, err := types.NewSet(ctx, m.dest)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ed := empty.Edit()
+		err = v.IterAll(ctx, func(val types.Value) error {
+			nv, err := m.migrateValue(ctx, val)
+
+			if err != nil {
+				return err
+			}
+
+			_, err = ed.Insert(nv)
+			return err
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		return ed.Set(ctx)
+
+	case types.List:
+		var vals []types.Value
+		err := v.IterAll(ctx, func(val types.Value, _ uint64) error {
+			nv, err := m.migrateValue(ctx, val)
+			vals = append(vals, nv)
+			return err
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		return types.NewList(ctx, m.dest, vals...)
+
+	case types.Blob:
+		return types.NewBlob(ctx, m.dest, v.Reader(ctx))
+
+	default:
+		// the remaining kinds are primitives which are encoded by their parent value
+		return v, nil
+	}
+}
+
+// EstimateFormatMigration walks every chunk of |db| reachable from its datasets and from |roots|, counting the chunks
+// and bytes that a format migration would need to rewrite.
+func EstimateFormatMigration(ctx context.Context, db datas.Database, roots []hash.Hash) (Estimate, error) {
+	var est Estimate
+	dss, err := db.Datasets(ctx)
+
+	if err != nil {
+		return est, err
+	}
+
+	est.Datasets = int(dss.Len())
+	err = walkChunks(ctx, db, roots, func(_ hash.Hash, size int) {
+		est.Chunks++
+		est.Bytes += uint64(size)
+	})
+
+	return est, err
+}
+
+// Verify checks that every chunk reachable from the datasets of |db| and from |roots| is present, decodes, and
+// matches its hash.
+func Verify(ctx context.Context, db datas.Database, roots []hash.Hash) error {
+	return walkChunks(ctx, db, roots, func(hash.Hash, int) {})
+}
+
+func walkChunks(ctx context.Context, db datas.Database, roots []hash.Hash, cb func(h hash.Hash, size int)) error {
+	visited := hash.HashSet{}
+	var next hash.HashSlice
+	visit := func(h hash.Hash) {
+		if !visited.Has(h) {
+			visited.Insert(h)
+			next = append(next, h)
+		}
+	}
+
+	dss, err := db.Datasets(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	err = dss.IterAll(ctx, func(_, v types.Value) error {
+		visit(v.(types.Ref).TargetHash())
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, h := range roots {
+		visit(h)
+	}
+
+	for len(next) > 0 {
+		batch := next
+		if len(batch) > walkBatchSize {
+			batch = batch[:walkBatchSize]
+		}
+		next = next[len(batch):]
+
+		vals, err := db.ReadManyValues(ctx, batch)
+
+		if err != nil {
+			return err
+		}
+
+		for i, v := range vals {
+			h := batch[i]
+			if v == nil {
+				return fmt.Errorf("chunk %s is missing", h.String())
+			}
+
+			c, err := types.EncodeValue(v, db.Format())
+
+			if err != nil {
+				return err
+			} else if c.Hash() != h {
+				return fmt.Errorf("chunk %s does not match its hash", h.String())
+			}
+
+			cb(h, len(c.Data()))
+			err = v.WalkRefs(db.Format(), func(r types.Ref) error {
+				visit(r.TargetHash())
+				return nil
+			})
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/datas"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/nbs"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const testMemTableSize = 1 << 20
+
+func newTestDB(t *testing.T, nbf *types.NomsBinFormat) (datas.Database, func()) {
+	dir, err := ioutil.TempDir("", "migrate_test")
+	require.NoError(t, err)
+
+	st, err := nbs.NewLocalStore(context.Background(), nbf.VersionString(), dir, testMemTableSize)
+	require.NoError(t, err)
+
+	db := datas.NewDatabase(st)
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func commitRows(t *testing.T, db datas.Database, dsName string, rows ...types.Value) types.Ref {
+	ctx := context.Background()
+	m, err := types.NewMap(ctx, db, rows...)
+	require.NoError(t, err)
+
+	ds, err := db.GetDataset(ctx, dsName)
+	require.NoError(t, err)
+
+	ds, err = db.CommitValue(ctx, ds, m)
+	require.NoError(t, err)
+
+	head, ok, err := ds.MaybeHeadRef()
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	return head
+}
+
+func TestNeedsFormatMigration(t *testing.T) {
+	assert.Equal(t, types.Format_Default != types.Format_7_18, NeedsFormatMigration(types.Format_7_18))
+	assert.False(t, NeedsFormatMigration(types.Format_Default))
+}
+
+func TestMigrateFormat(t *testing.T) {
+	ctx := context.Background()
+	src, cleanupSrc := newTestDB(t, types.Format_7_18)
+	defer cleanupSrc()
+	dest, cleanupDest := newTestDB(t, types.Format_LD_1)
+	defer cleanupDest()
+
+	floatParent := commitRows(t, src, "floats", types.Uint(1), types.Float(1.5))
+	floatHead := commitRows(t, src, "floats", types.Uint(1), types.Float(1.5), types.Uint(2), types.Float(-2.25))
+	stringHead := commitRows(t, src, "strings", types.String("a"), types.String("b"))
+
+	working, err := types.NewMap(ctx, src, types.Uint(3), types.Float(3.75))
+	require.NoError(t, err)
+	workingRef, err := src.WriteValue(ctx, working)
+	require.NoError(t, err)
+	require.NoError(t, src.Flush(ctx))
+
+	roots := []hash.Hash{workingRef.TargetHash()}
+	est, err := EstimateFormatMigration(ctx, src, roots)
+	require.NoError(t, err)
+	assert.Equal(t, 2, est.Datasets)
+	assert.True(t, est.Chunks > 0)
+	assert.True(t, est.Bytes > 0)
+
+	progChan := make(chan Progress, 1024)
+	res, err := MigrateFormat(ctx, src, dest, roots, progChan)
+	require.NoError(t, err)
+	close(progChan)
+
+	var last Progress
+	for p := range progChan {
+		last = p
+	}
+	assert.True(t, last.ValuesWritten > 0)
+
+	newWorking, ok := res.Roots[workingRef.TargetHash()]
+	require.True(t, ok)
+	assert.NotEqual(t, workingRef.TargetHash(), newWorking)
+	require.NoError(t, Verify(ctx, dest, []hash.Hash{newWorking}))
+
+	// commits without floats keep their hashes, the others are recorded in the mapping
+	stringDS, err := dest.GetDataset(ctx, "strings")
+	require.NoError(t, err)
+	newStringHead, ok, err := stringDS.MaybeHeadRef()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, stringHead.TargetHash(), newStringHead.TargetHash())
+
+	require.Len(t, res.Commits, 2)
+	assert.Contains(t, res.Commits, floatParent.TargetHash())
+	floatDS, err := dest.GetDataset(ctx, "floats")
+	require.NoError(t, err)
+	newFloatHead, ok, err := floatDS.MaybeHeadRef()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, res.Commits[floatHead.TargetHash()], newFloatHead.TargetHash())
+
+	headVal, ok, err := floatDS.MaybeHeadValue()
+	require.NoError(t, err)
+	require.True(t, ok)
+	v, ok, err := headVal.(types.Map).MaybeGet(ctx, types.Uint(2))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, types.Float(-2.25), v)
+
+	workingVal, err := dest.ReadValue(ctx, newWorking)
+	require.NoError(t, err)
+	v, ok, err = workingVal.(types.Map).MaybeGet(ctx, types.Uint(3))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, types.Float(3.75), v)
+}
+
+func TestVerifyMissingChunk(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := newTestDB(t, types.Format_LD_1)
+	defer cleanup()
+
+	err := Verify(ctx, db, []hash.Hash{hash.Of([]byte("missing"))})
+	assert.Error(t, err)
+}
@@ -0,0 +1,296 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/store/datas"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/nbs"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const memTableSize = 256 * 1024 * 1024
+
+var (
+	// MigratingDataDir holds the new store while a format migration is in progress
+	MigratingDataDir = filepath.Join(dbfactory.DoltDir, "noms_migrating")
+
+	// BackupDataDir holds the original store after a format migration completes
+	BackupDataDir = filepath.Join(dbfactory.DoltDir, "noms_pre_migration")
+
+	// BackupRepoStateFile holds the original repo state after a format migration completes
+	BackupRepoStateFile = filepath.Join(dbfactory.DoltDir, "repo_state_pre_migration.json")
+
+	// CommitMappingFile records the new hash of every commit whose hash was changed by a format migration
+	CommitMappingFile = filepath.Join(dbfactory.DoltDir, "migrated_commits.csv")
+)
+
+var ErrNotLocalRepo = errors.New("storage format migrations are only supported for local repositories")
+var ErrBackupExists = fmt.Errorf("a backup of a previous format migration exists at %s", BackupDataDir)
+var ErrNoBackup = errors.New("there is no format migration to roll back")
+
+// EstimateRepoFormatMigration estimates the amount of data a format migration of the repository in |dEnv| would rewrite.
+func EstimateRepoFormatMigration(ctx context.Context, dEnv *env.DoltEnv) (Estimate, error) {
+	src, err := openSrcDB(ctx, dEnv)
+
+	if err != nil {
+		return Estimate{}, err
+	}
+
+	defer src.Close()
+
+	return EstimateFormatMigration(ctx, src, repoStateRoots(dEnv.RepoState))
+}
+
+// MigrateRepoFormat rewrites the repository in |dEnv| into types.Format_Default. The new store is built and verified
+// alongside the existing one, which is only replaced once the new store is complete. The original store and repo state
+// are kept as backups which RollbackRepoFormatMigration restores. |dEnv| must be reloaded after a successful migration.
+func MigrateRepoFormat(ctx context.Context, dEnv *env.DoltEnv, progChan chan<- Progress) (*Result, error) {
+	if exists, _ := dEnv.FS.Exists(BackupDataDir); exists {
+		return nil, ErrBackupExists
+	}
+
+	src, err := openSrcDB(ctx, dEnv)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer src.Close()
+
+	res, err := migrateIntoDir(ctx, dEnv, src, progChan)
+
+	if err != nil {
+		_ = dEnv.FS.Delete(MigratingDataDir, true)
+		return nil, err
+	}
+
+	rsBackup, err := json.MarshalIndent(dEnv.RepoState, "", "  ")
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = dEnv.FS.WriteFile(BackupRepoStateFile, rsBackup)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = dEnv.FS.MoveFile(dbfactory.DoltDataDir, BackupDataDir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = dEnv.FS.MoveFile(MigratingDataDir, dbfactory.DoltDataDir)
+
+	if err != nil {
+		_ = dEnv.FS.MoveFile(BackupDataDir, dbfactory.DoltDataDir)
+		_ = dEnv.FS.DeleteFile(BackupRepoStateFile)
+		return nil, err
+	}
+
+	updateRepoState(dEnv.RepoState, res.Roots)
+	err = dEnv.RepoState.Save(dEnv.FS)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Commits) > 0 {
+		err = dEnv.FS.WriteFile(CommitMappingFile, commitMappingCSV(res.Commits))
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+func migrateIntoDir(ctx context.Context, dEnv *env.DoltEnv, src datas.Database, progChan chan<- Progress) (*Result, error) {
+	err := dEnv.FS.Delete(MigratingDataDir, true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = dEnv.FS.MkDirs(MigratingDataDir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	dest, err := openDB(ctx, dEnv, MigratingDataDir, types.Format_Default)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer dest.Close()
+
+	res, err := MigrateFormat(ctx, src, dest, repoStateRoots(dEnv.RepoState), progChan)
+
+	if err != nil {
+		return nil, err
+	}
+
+	newRoots := make([]hash.Hash, 0, len(res.Roots))
+	for _, h := range res.Roots {
+		newRoots = append(newRoots, h)
+	}
+
+	err = Verify(ctx, dest, newRoots)
+
+	if err != nil {
+		return nil, fmt.Errorf("the migrated store failed verification: %v", err)
+	}
+
+	return res, nil
+}
+
+// RollbackRepoFormatMigration restores the store and repo state which were backed up by MigrateRepoFormat.
+func RollbackRepoFormatMigration(dEnv *env.DoltEnv) error {
+	if exists, isDir := dEnv.FS.Exists(BackupDataDir); !exists || !isDir {
+		return ErrNoBackup
+	}
+
+	data, err := dEnv.FS.ReadFile(BackupRepoStateFile)
+
+	if err != nil {
+		return err
+	}
+
+	var rs env.RepoState
+	err = json.Unmarshal(data, &rs)
+
+	if err != nil {
+		return err
+	}
+
+	err = dEnv.FS.Delete(dbfactory.DoltDataDir, true)
+
+	if err != nil {
+		return err
+	}
+
+	err = dEnv.FS.MoveFile(BackupDataDir, dbfactory.DoltDataDir)
+
+	if err != nil {
+		return err
+	}
+
+	err = rs.Save(dEnv.FS)
+
+	if err != nil {
+		return err
+	}
+
+	_ = dEnv.FS.Delete(CommitMappingFile, false)
+	return dEnv.FS.DeleteFile(BackupRepoStateFile)
+}
+
+func openSrcDB(ctx context.Context, dEnv *env.DoltEnv) (datas.Database, error) {
+	if exists, isDir := dEnv.FS.Exists(dbfactory.DoltDataDir); !exists || !isDir {
+		return nil, ErrNotLocalRepo
+	}
+
+	return openDB(ctx, dEnv, dbfactory.DoltDataDir, dEnv.DoltDB.Format())
+}
+
+func openDB(ctx context.Context, dEnv *env.DoltEnv, dir string, nbf *types.NomsBinFormat) (datas.Database, error) {
+	path, err := dEnv.FS.Abs(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := nbs.NewLocalStore(ctx, nbf.VersionString(), path, memTableSize)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return datas.NewDatabase(st), nil
+}
+
+// repoStateRoots returns the hashes of the values referenced by |rs| which need not be reachable from a ref
+func repoStateRoots(rs *env.RepoState) []hash.Hash {
+	var roots []hash.Hash
+	addRoot := func(s string) {
+		if h, ok := hash.MaybeParse(s); ok {
+			roots = append(roots, h)
+		}
+	}
+
+	addRoot(rs.Working)
+	addRoot(rs.Staged)
+
+	if rs.Merge != nil {
+		addRoot(rs.Merge.Commit)
+		addRoot(rs.Merge.PreMergeWorking)
+	}
+
+	return roots
+}
+
+func updateRepoState(rs *env.RepoState, migrated map[hash.Hash]hash.Hash) {
+	update := func(s string) string {
+		if h, ok := hash.MaybeParse(s); ok {
+			if newHash, ok := migrated[h]; ok {
+				return newHash.String()
+			}
+		}
+
+		return s
+	}
+
+	rs.Working = update(rs.Working)
+	rs.Staged = update(rs.Staged)
+
+	if rs.Merge != nil {
+		rs.Merge.Commit = update(rs.Merge.Commit)
+		rs.Merge.PreMergeWorking = update(rs.Merge.PreMergeWorking)
+	}
+}
+
+func commitMappingCSV(commits map[hash.Hash]hash.Hash) []byte {
+	oldHashes := make([]string, 0, len(commits))
+	newHashes := make(map[string]string, len(commits))
+	for oldHash, newHash := range commits {
+		oldHashes = append(oldHashes, oldHash.String())
+		newHashes[oldHash.String()] = newHash.String()
+	}
+
+	sort.Strings(oldHashes)
+
+	buf := bytes.NewBufferString("old_commit,new_commit\n")
+	for _, oldHash := range oldHashes {
+		buf.WriteString(oldHash + "," + newHashes[oldHash] + "\n")
+	}
+
+	return buf.Bytes()
+}
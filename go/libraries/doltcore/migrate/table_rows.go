@@ -0,0 +1,560 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// RewriteTableDir holds the state of the table rewrites which are in progress, in a file for each table.
+var RewriteTableDir = filepath.Join(dbfactory.DoltDir, "rewrite_table")
+
+// rewriteBatchSize is the number of rows rewritten between checkpoints. A var so tests can checkpoint more often.
+var rewriteBatchSize = 64 * 1024
+
+// rewriteSampleSize is the number of rows of each map compared with their rewrites once the rewrite completes.
+const rewriteSampleSize = 1024
+
+const (
+	rewriteRowsPhase     = "rows"
+	rewriteColdRowsPhase = "cold_rows"
+)
+
+// RewriteProgress is sent on the progress channel of RewriteTable after each batch of rows is rewritten.
+type RewriteProgress struct {
+	RowsRead      uint64
+	RowsRewritten uint64
+	// RowsTotal is the number of entries of both the table's row map and its cold map, if it has one
+	RowsTotal uint64
+}
+
+// RewriteResult describes a completed table rewrite.
+type RewriteResult struct {
+	// RowsRead is the number of entries of both the table's row map and its cold map read
+	RowsRead uint64
+	// RowsRewritten is the number of those whose encoding changed
+	RowsRewritten uint64
+	// Resumed is true if the rewrite continued one which was interrupted
+	Resumed bool
+	// AlreadyCanonical is true if the rows were recorded as canonical by an earlier rewrite, so nothing was rewritten
+	AlreadyCanonical bool
+}
+
+// rewriteState is the checkpoint of a table rewrite, written to the rewrite's file after each batch of rows.
+type rewriteState struct {
+	// Original is the hash of the table being rewritten. A rewrite is only resumed if the table is unchanged.
+	Original string `json:"original"`
+	// Rows and ColdRows are the hashes of the partially rewritten maps, which are flushed to the database
+	Rows     string `json:"rows"`
+	ColdRows string `json:"cold_rows,omitempty"`
+	// Phase is the map being rewritten, and Position the number of its entries rewritten so far
+	Phase    string `json:"phase"`
+	Position uint64 `json:"position"`
+	// RowsRead and RowsRewritten count the entries of both maps
+	RowsRead      uint64 `json:"rows_read"`
+	RowsRewritten uint64 `json:"rows_rewritten"`
+	// ColdRowsRemoved counts the cold map entries removed because all of their values were dropped
+	ColdRowsRemoved uint64 `json:"cold_rows_removed"`
+}
+
+func rewriteStateFile(tblName string) string {
+	return filepath.Join(RewriteTableDir, tblName+".json")
+}
+
+// RewriteTable rewrites the rows of the table |tblName| in the working root of |dEnv| into the canonical layout of its
+// schema (see doltdb.CanonicalRowTuples) in a single pass over its row map and its cold map, then verifies the counts
+// of the rewritten maps and compares a sample of their rows with the originals, and swaps them into the working root,
+// recording that the rows are canonical. The working root is unchanged until the swap, so an error at any point leaves
+// the table as it was.
+//
+// The partially rewritten maps are checkpointed after every batch of rows, so a rewrite which is canceled through
+// |ctx|, or whose process dies, resumes from its last checkpoint when it's run again, provided the table hasn't changed
+// in the meantime. Only rows whose encoding changes are written.
+func RewriteTable(ctx context.Context, dEnv *env.DoltEnv, tblName string, progChan chan<- RewriteProgress) (RewriteResult, error) {
+	root, err := dEnv.WorkingRoot(ctx)
+
+	if err != nil {
+		return RewriteResult{}, err
+	}
+
+	tbl, ok, err := root.GetTable(ctx, tblName)
+
+	if err != nil {
+		return RewriteResult{}, err
+	} else if !ok {
+		return RewriteResult{}, doltdb.ErrTableNotFound
+	}
+
+	canonical, err := tbl.HasCanonicalRows()
+
+	if err != nil {
+		return RewriteResult{}, err
+	} else if canonical {
+		_ = dEnv.FS.Delete(rewriteStateFile(tblName), false)
+		return RewriteResult{AlreadyCanonical: true}, nil
+	}
+
+	rw, err := newTableRewriter(ctx, dEnv, tblName, tbl, progChan)
+
+	if err != nil {
+		return RewriteResult{}, err
+	}
+
+	err = rw.rewrite(ctx)
+
+	if err != nil {
+		return RewriteResult{}, err
+	}
+
+	err = rw.verify(ctx)
+
+	if err != nil {
+		_ = dEnv.FS.Delete(rewriteStateFile(tblName), false)
+		return RewriteResult{}, fmt.Errorf("the rewritten rows of '%s' failed verification: %v", tblName, err)
+	}
+
+	err = rw.swap(ctx)
+
+	if err != nil {
+		return RewriteResult{}, err
+	}
+
+	err = dEnv.FS.Delete(rewriteStateFile(tblName), false)
+
+	if err != nil {
+		return RewriteResult{}, err
+	}
+
+	return RewriteResult{RowsRead: rw.state.RowsRead, RowsRewritten: rw.state.RowsRewritten, Resumed: rw.resumed}, nil
+}
+
+type tableRewriter struct {
+	dEnv     *env.DoltEnv
+	vrw      types.ValueReadWriter
+	tblName  string
+	sch      schema.Schema
+	progChan chan<- RewriteProgress
+
+	origRows, origCold types.Map
+	rows, cold         types.Map
+	hasCold            bool
+	total              uint64
+
+	state   rewriteState
+	resumed bool
+}
+
+// newTableRewriter returns a rewriter of |tbl|, resuming the rewrite checkpointed in the table's rewrite file if there
+// is one and it was of the same table.
+func newTableRewriter(ctx context.Context, dEnv *env.DoltEnv, tblName string, tbl *doltdb.Table, progChan chan<- RewriteProgress) (*tableRewriter, error) {
+	sch, err := tbl.GetSchema(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	origRows, err := tbl.GetHotRowData(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	origCold, hasCold, err := tbl.GetColdRowData(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tblHash, err := tbl.HashOf()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rw := &tableRewriter{
+		dEnv:     dEnv,
+		vrw:      dEnv.DoltDB.ValueReadWriter(),
+		tblName:  tblName,
+		sch:      sch,
+		progChan: progChan,
+		origRows: origRows,
+		origCold: origCold,
+		rows:     origRows,
+		cold:     origCold,
+		hasCold:  hasCold,
+		total:    origRows.Len(),
+		state:    rewriteState{Original: tblHash.String(), Phase: rewriteRowsPhase},
+	}
+
+	if hasCold {
+		rw.total += origCold.Len()
+	}
+
+	state, ok, err := loadRewriteState(dEnv, tblName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok || state.Original != rw.state.Original {
+		return rw, nil
+	}
+
+	rw.rows, err = rw.readMap(ctx, state.Rows)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if hasCold {
+		rw.cold, err = rw.readMap(ctx, state.ColdRows)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rw.state = state
+	rw.resumed = true
+
+	return rw, nil
+}
+
+func loadRewriteState(dEnv *env.DoltEnv, tblName string) (rewriteState, bool, error) {
+	path := rewriteStateFile(tblName)
+
+	if exists, _ := dEnv.FS.Exists(path); !exists {
+		return rewriteState{}, false, nil
+	}
+
+	data, err := dEnv.FS.ReadFile(path)
+
+	if err != nil {
+		return rewriteState{}, false, err
+	}
+
+	var state rewriteState
+	err = json.Unmarshal(data, &state)
+
+	if err != nil {
+		return rewriteState{}, false, fmt.Errorf("failed to read the state of the rewrite of '%s' from %s: %v", tblName, path, err)
+	}
+
+	return state, true, nil
+}
+
+func (rw *tableRewriter) readMap(ctx context.Context, hashStr string) (types.Map, error) {
+	h, ok := hash.MaybeParse(hashStr)
+
+	if !ok {
+		return types.EmptyMap, fmt.Errorf("the state of the rewrite of '%s' holds the invalid hash '%s'", rw.tblName, hashStr)
+	}
+
+	val, err := rw.vrw.ReadValue(ctx, h)
+
+	if err != nil {
+		return types.EmptyMap, err
+	}
+
+	m, ok := val.(types.Map)
+
+	if !ok {
+		return types.EmptyMap, fmt.Errorf("the rewritten rows of '%s' are missing from the database", rw.tblName)
+	}
+
+	return m, nil
+}
+
+func (rw *tableRewriter) rewrite(ctx context.Context) error {
+	if rw.state.Phase == rewriteRowsPhase {
+		err := rw.rewriteMap(ctx, rw.origRows, &rw.rows, false)
+
+		if err != nil {
+			return err
+		}
+
+		rw.state.Phase, rw.state.Position = rewriteColdRowsPhase, 0
+	}
+
+	if rw.hasCold {
+		return rw.rewriteMap(ctx, rw.origCold, &rw.cold, true)
+	}
+
+	return nil
+}
+
+// rewriteMap rewrites the entries of |orig| from the position of the state onward into |rewritten|, which starts out
+// as a copy of |orig|, checkpointing after every batch of entries. Cold map entries left without any values are removed.
+func (rw *tableRewriter) rewriteMap(ctx context.Context, orig types.Map, rewritten *types.Map, isCold bool) error {
+	itr, err := orig.IteratorAt(ctx, rw.state.Position)
+
+	if err != nil {
+		return err
+	}
+
+	for {
+		ed := rewritten.Edit()
+		n := 0
+		done := false
+		for n < rewriteBatchSize {
+			key, val, err := itr.Next(ctx)
+
+			if err != nil {
+				return err
+			}
+
+			if key == nil {
+				done = true
+				break
+			}
+
+			newKey, newVal, err := doltdb.CanonicalRowTuples(ctx, rw.sch, key.(types.Tuple), val.(types.Tuple))
+
+			if err != nil {
+				return err
+			}
+
+			keyChanged := !newKey.Equals(key)
+
+			if keyChanged {
+				if has, err := orig.Has(ctx, newKey); err != nil {
+					return err
+				} else if has {
+					return fmt.Errorf("the rewritten key of the row with the key %s is the key of another row", keyString(key))
+				}
+
+				ed.Remove(key)
+			}
+
+			if isCold && newVal.Len() == 0 {
+				ed.Remove(key)
+				rw.state.ColdRowsRemoved++
+				rw.state.RowsRewritten++
+			} else if keyChanged || !newVal.Equals(val) {
+				ed.Set(newKey, newVal)
+				rw.state.RowsRewritten++
+			}
+
+			n++
+		}
+
+		m, err := ed.Map(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		*rewritten = m
+		rw.state.Position += uint64(n)
+		rw.state.RowsRead += uint64(n)
+
+		err = rw.checkpoint(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		if rw.progChan != nil {
+			select {
+			case rw.progChan <- RewriteProgress{RowsRead: rw.state.RowsRead, RowsRewritten: rw.state.RowsRewritten, RowsTotal: rw.total}:
+			case <-ctx.Done():
+			}
+		}
+
+		if done {
+			return nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// checkpoint flushes the partially rewritten maps to the database and records them in the rewrite's file.
+func (rw *tableRewriter) checkpoint(ctx context.Context) error {
+	rowsRef, err := rw.vrw.WriteValue(ctx, rw.rows)
+
+	if err != nil {
+		return err
+	}
+
+	rw.state.Rows = rowsRef.TargetHash().String()
+
+	if rw.hasCold {
+		coldRef, err := rw.vrw.WriteValue(ctx, rw.cold)
+
+		if err != nil {
+			return err
+		}
+
+		rw.state.ColdRows = coldRef.TargetHash().String()
+	}
+
+	err = rw.dEnv.DoltDB.Flush(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(rw.state, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	err = rw.dEnv.FS.MkDirs(RewriteTableDir)
+
+	if err != nil {
+		return err
+	}
+
+	return rw.dEnv.FS.WriteFile(rewriteStateFile(rw.tblName), data)
+}
+
+// verify checks that the rewritten maps have as many entries as the originals, less the removed cold entries, and
+// that a sample of evenly spaced rows of the originals read the same as their rewrites.
+func (rw *tableRewriter) verify(ctx context.Context) error {
+	if rw.rows.Len() != rw.origRows.Len() {
+		return fmt.Errorf("the table has %d rows, but the rewrite has %d", rw.origRows.Len(), rw.rows.Len())
+	}
+
+	err := rw.verifySample(ctx, rw.origRows, rw.rows)
+
+	if err != nil || !rw.hasCold {
+		return err
+	}
+
+	if rw.cold.Len()+rw.state.ColdRowsRemoved != rw.origCold.Len() {
+		return fmt.Errorf("the table has %d cold rows, but the rewrite has %d and removed %d", rw.origCold.Len(), rw.cold.Len(), rw.state.ColdRowsRemoved)
+	}
+
+	return rw.verifySample(ctx, rw.origCold, rw.cold)
+}
+
+func (rw *tableRewriter) verifySample(ctx context.Context, orig, rewritten types.Map) error {
+	n := orig.Len()
+	samples := uint64(rewriteSampleSize)
+
+	if n < samples {
+		samples = n
+	}
+
+	nbf := orig.Format()
+	for i := uint64(0); i < samples; i++ {
+		key, val, err := orig.At(ctx, i*n/samples)
+
+		if err != nil {
+			return err
+		}
+
+		origRow, err := row.FromNoms(rw.sch, key.(types.Tuple), val.(types.Tuple))
+
+		if err != nil {
+			return err
+		}
+
+		newKey, err := origRow.NomsMapKey(rw.sch).Value(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		newVal, ok, err := rewritten.MaybeGet(ctx, newKey)
+
+		if err != nil {
+			return err
+		} else if !ok {
+			newVal = types.EmptyTuple(nbf)
+		}
+
+		newRow, err := row.FromNoms(rw.sch, newKey.(types.Tuple), newVal.(types.Tuple))
+
+		if err != nil {
+			return err
+		}
+
+		if !row.AreEqual(origRow, newRow, rw.sch) {
+			return fmt.Errorf("the rewrite of the row with the key %s differs from the row", keyString(key))
+		}
+	}
+
+	return nil
+}
+
+// swap replaces the rows of the table in the working root with the rewritten ones, provided the table hasn't changed
+// since the rewrite started.
+func (rw *tableRewriter) swap(ctx context.Context) error {
+	root, err := rw.dEnv.WorkingRoot(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	tbl, ok, err := root.GetTable(ctx, rw.tblName)
+
+	if err != nil {
+		return err
+	} else if !ok {
+		return doltdb.ErrTableNotFound
+	}
+
+	if h, err := tbl.HashOf(); err != nil {
+		return err
+	} else if h.String() != rw.state.Original {
+		return fmt.Errorf("'%s' was changed while it was being rewritten", rw.tblName)
+	}
+
+	var cold *types.Map
+	if rw.hasCold {
+		cold = &rw.cold
+	}
+
+	tbl, err = tbl.SetCanonicalRows(ctx, rw.rows, cold)
+
+	if err != nil {
+		return err
+	}
+
+	root, err = root.PutTable(ctx, rw.tblName, tbl)
+
+	if err != nil {
+		return err
+	}
+
+	return rw.dEnv.UpdateWorkingRoot(ctx, root)
+}
+
+// keyString returns the tagged values of the key tuple |key| for error messages.
+func keyString(key types.Value) string {
+	vals, err := row.ParseTaggedValues(key.(types.Tuple))
+
+	if err != nil {
+		return key.HumanReadableString()
+	}
+
+	return vals.String()
+}
@@ -0,0 +1,249 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const (
+	idTag    = 0
+	nameTag  = 1
+	ageTag   = 2
+	notesTag = 3
+	// droppedTag is the tag of a column which was dropped after rows holding its values were written
+	droppedTag = 99
+)
+
+func rewriteTestSchema() schema.Schema {
+	notes := schema.NewColumn("notes", notesTag, types.StringKind, false)
+	notes.Cold = true
+	return dtestutils.MustSchema(
+		schema.NewColumn("id", idTag, types.IntKind, true),
+		schema.NewColumn("name", nameTag, types.StringKind, false),
+		schema.NewColumn("age", ageTag, types.UintKind, false),
+		notes,
+	)
+}
+
+// createRewriteTestTable puts a table of |n| rows into the working root of |dEnv|. The values of the even rows are out of
+// tag order and hold an explicit null and the value of a dropped column, and the cold value of every fourth row is an
+// explicit null, so the rewrite removes its entry from the cold map.
+func createRewriteTestTable(t *testing.T, dEnv *env.DoltEnv, n int) *doltdb.Table {
+	ctx := context.Background()
+	nbf := dEnv.DoltDB.Format()
+	sch := rewriteTestSchema()
+
+	m, err := types.NewMap(ctx, dEnv.DoltDB.ValueReadWriter())
+	require.NoError(t, err)
+
+	me := m.Edit()
+	for i := 0; i < n; i++ {
+		key, err := types.NewTuple(nbf, types.Uint(idTag), types.Int(i))
+		require.NoError(t, err)
+
+		var val types.Tuple
+		switch {
+		case i%4 == 0:
+			val, err = types.NewTuple(nbf, types.Uint(ageTag), types.Uint(i), types.Uint(nameTag), types.String("name"), types.Uint(droppedTag), types.Int(i), types.Uint(notesTag), types.NullValue)
+		case i%2 == 0:
+			val, err = types.NewTuple(nbf, types.Uint(ageTag), types.Uint(i), types.Uint(nameTag), types.NullValue, types.Uint(notesTag), types.String("notes"), types.Uint(droppedTag), types.Int(i))
+		default:
+			val, err = types.NewTuple(nbf, types.Uint(nameTag), types.String("name"), types.Uint(ageTag), types.Uint(i), types.Uint(notesTag), types.String("notes"))
+		}
+		require.NoError(t, err)
+
+		me.Set(key, val)
+	}
+
+	m, err = me.Map(ctx)
+	require.NoError(t, err)
+
+	err = dEnv.PutTableToWorking(ctx, m, sch, "test")
+	require.NoError(t, err)
+
+	return getWorkingTable(t, dEnv)
+}
+
+func getWorkingTable(t *testing.T, dEnv *env.DoltEnv) *doltdb.Table {
+	root, err := dEnv.WorkingRoot(context.Background())
+	require.NoError(t, err)
+	tbl, ok, err := root.GetTable(context.Background(), "test")
+	require.NoError(t, err)
+	require.True(t, ok)
+	return tbl
+}
+
+func requireCanonical(t *testing.T, sch schema.Schema, m types.Map) {
+	ctx := context.Background()
+	err := m.IterAll(ctx, func(key, val types.Value) error {
+		canonicalKey, canonicalVal, err := doltdb.CanonicalRowTuples(ctx, sch, key.(types.Tuple), val.(types.Tuple))
+		require.NoError(t, err)
+		assert.True(t, canonicalKey.Equals(key))
+		assert.True(t, canonicalVal.Equals(val))
+		assert.NotEqual(t, uint64(0), val.(types.Tuple).Len())
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestRewriteTable(t *testing.T) {
+	defer func(batchSize int) { rewriteBatchSize = batchSize }(rewriteBatchSize)
+	rewriteBatchSize = 100
+
+	ctx := context.Background()
+	dEnv := dtestutils.CreateTestEnv()
+	orig := createRewriteTestTable(t, dEnv, 1000)
+	origRows, err := orig.GetRowData(ctx)
+	require.NoError(t, err)
+
+	// interrupt the rewrite once it has checkpointed its first batch
+	cancelCtx, cancel := context.WithCancel(ctx)
+	progChan := make(chan RewriteProgress)
+	go func() {
+		p := <-progChan
+		assert.Equal(t, uint64(100), p.RowsRead)
+		assert.Equal(t, uint64(2000), p.RowsTotal)
+		cancel()
+	}()
+
+	_, err = RewriteTable(cancelCtx, dEnv, "test", progChan)
+	require.Equal(t, context.Canceled, err)
+
+	// the original is intact, and the checkpoint is kept for the next run
+	origHash, err := orig.HashOf()
+	require.NoError(t, err)
+	tblHash, err := getWorkingTable(t, dEnv).HashOf()
+	require.NoError(t, err)
+	assert.Equal(t, origHash, tblHash)
+	exists, _ := dEnv.FS.Exists(rewriteStateFile("test"))
+	assert.True(t, exists)
+
+	res, err := RewriteTable(ctx, dEnv, "test", nil)
+	require.NoError(t, err)
+	assert.True(t, res.Resumed)
+	assert.Equal(t, uint64(2000), res.RowsRead)
+	assert.Equal(t, uint64(750), res.RowsRewritten)
+
+	exists, _ = dEnv.FS.Exists(rewriteStateFile("test"))
+	assert.False(t, exists)
+
+	tbl := getWorkingTable(t, dEnv)
+	canonical, err := tbl.HasCanonicalRows()
+	require.NoError(t, err)
+	assert.True(t, canonical)
+
+	sch := rewriteTestSchema()
+	hot, err := tbl.GetHotRowData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1000), hot.Len())
+	requireCanonical(t, sch, hot)
+
+	cold, ok, err := tbl.GetColdRowData(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, uint64(750), cold.Len())
+	requireCanonical(t, sch, cold)
+
+	// the rewritten rows are the original rows, less the values of the dropped column and the nulls
+	rows, err := tbl.GetRowData(ctx)
+	require.NoError(t, err)
+	err = origRows.IterAll(ctx, func(key, val types.Value) error {
+		_, canonicalVal, err := doltdb.CanonicalRowTuples(ctx, sch, key.(types.Tuple), val.(types.Tuple))
+		require.NoError(t, err)
+		rewritten, ok, err := rows.MaybeGet(ctx, key)
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.True(t, canonicalVal.Equals(rewritten))
+		return nil
+	})
+	require.NoError(t, err)
+
+	res, err = RewriteTable(ctx, dEnv, "test", nil)
+	require.NoError(t, err)
+	assert.True(t, res.AlreadyCanonical)
+
+	// updating the rows drops the record of their being canonical
+	key, err := types.NewTuple(dEnv.DoltDB.Format(), types.Uint(idTag), types.Int(1000))
+	require.NoError(t, err)
+	rows, err = rows.Edit().Set(key, types.EmptyTuple(dEnv.DoltDB.Format())).Map(ctx)
+	require.NoError(t, err)
+	tbl, err = tbl.UpdateRows(ctx, rows)
+	require.NoError(t, err)
+	canonical, err = tbl.HasCanonicalRows()
+	require.NoError(t, err)
+	assert.False(t, canonical)
+}
+
+func TestRewriteTableRestartsWhenTableChanged(t *testing.T) {
+	defer func(batchSize int) { rewriteBatchSize = batchSize }(rewriteBatchSize)
+	rewriteBatchSize = 100
+
+	ctx := context.Background()
+	dEnv := dtestutils.CreateTestEnv()
+	createRewriteTestTable(t, dEnv, 1000)
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	progChan := make(chan RewriteProgress)
+	go func() {
+		<-progChan
+		cancel()
+	}()
+
+	_, err := RewriteTable(cancelCtx, dEnv, "test", progChan)
+	require.Equal(t, context.Canceled, err)
+
+	createRewriteTestTable(t, dEnv, 500)
+
+	res, err := RewriteTable(ctx, dEnv, "test", nil)
+	require.NoError(t, err)
+	assert.False(t, res.Resumed)
+	assert.Equal(t, uint64(1000), res.RowsRead)
+}
+
+func TestRewriteTableKindMismatch(t *testing.T) {
+	ctx := context.Background()
+	dEnv := dtestutils.CreateTestEnv()
+	nbf := dEnv.DoltDB.Format()
+
+	key, err := types.NewTuple(nbf, types.Uint(idTag), types.Int(1))
+	require.NoError(t, err)
+	val, err := types.NewTuple(nbf, types.Uint(ageTag), types.String("not a uint"))
+	require.NoError(t, err)
+	m, err := types.NewMap(ctx, dEnv.DoltDB.ValueReadWriter(), key, val)
+	require.NoError(t, err)
+
+	err = dEnv.PutTableToWorking(ctx, m, rewriteTestSchema(), "test")
+	require.NoError(t, err)
+	origHash, err := getWorkingTable(t, dEnv).HashOf()
+	require.NoError(t, err)
+
+	_, err = RewriteTable(ctx, dEnv, "test", nil)
+	assert.Error(t, err)
+
+	tblHash, err := getWorkingTable(t, dEnv).HashOf()
+	require.NoError(t, err)
+	assert.Equal(t, origHash, tblHash)
+}
@@ -61,6 +61,9 @@ const (
 
 	// SqlFile is the format of a data location that is a .sql file
 	SqlFile DataFormat = ".sql"
+
+	// SqliteFile is the format of a data location that is a SQLite database file
+	SqliteFile DataFormat = ".sqlite"
 )
 
 // ReadableStr returns a human readable string for a DataFormat
@@ -78,6 +81,8 @@ func (df DataFormat) ReadableStr() string {
 		return "json file"
 	case SqlFile:
 		return "sql file"
+	case SqliteFile:
+		return "sqlite file"
 	default:
 		return "invalid"
 	}
@@ -130,6 +135,8 @@ func NewDataLocation(path, fileFmtStr string) DataLocation {
 				dataFmt = JsonFile
 			case string(SqlFile):
 				dataFmt = SqlFile
+			case string(SqliteFile), ".db":
+				dataFmt = SqliteFile
 			}
 		}
 	}
@@ -21,6 +21,7 @@ import (
 	"sync/atomic"
 
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/typed/noms"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/typed/sqlite"
 
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/rowconv"
@@ -55,6 +56,12 @@ type JSONOptions struct {
 	TableName string
 }
 
+// SqliteOptions are the DestOptions of an export to a SQLite file.
+type SqliteOptions struct {
+	// Warn is called with the warnings about the values and columns which can't be exported as they are
+	Warn sqlite.WarnFunc
+}
+
 type MoveOptions struct {
 	Operation   MoveOperation
 	ContOnErr   bool
@@ -65,12 +72,15 @@ type MoveOptions struct {
 	Src         DataLocation
 	Dest        DataLocation
 	SrcOptions  interface{}
+	DestOptions interface{}
+	Dedupe      bool
 }
 
 func (m MoveOptions) isImport() bool {
 	_, fromFile := m.Src.(FileDataLocation)
+	_, fromMySQL := m.Src.(MySQLDataLocation)
 	_, toTable := m.Dest.(TableDataLocation)
-	return fromFile && toTable
+	return (fromFile || fromMySQL) && toTable
 }
 
 func (m MoveOptions) isCopy() bool {
@@ -133,6 +143,14 @@ func NewDataMover(ctx context.Context, root *doltdb.RootValue, fs filesys.Filesy
 		return nil, &DataMoverCreationError{SchemaErr, err}
 	}
 
+	if mvOpts.Operation == OverwriteOp {
+		if _, isTable := mvOpts.Dest.(TableDataLocation); isTable {
+			if err := doltdb.ValidateSchemaColumnNames(outSch); err != nil {
+				return nil, &DataMoverCreationError{SchemaErr, err}
+			}
+		}
+	}
+
 	if mvOpts.Operation == ReplaceOp && mvOpts.MappingFile == "" {
 		fileMatchesSchema, err := rd.VerifySchema(outSch)
 		if err != nil {
@@ -326,7 +344,7 @@ func addPrimaryKey(sch schema.Schema, explicitKey string) (schema.Schema, error)
 			return nil, err
 		}
 
-		return schema.SchemaFromCols(updatedColColl), nil
+		return schema.SchemaWithComment(schema.SchemaFromCols(updatedColColl), sch.GetComment()), nil
 	}
 
 	return sch, nil
@@ -356,5 +374,5 @@ func makeTagsUnique(ctx context.Context, root *doltdb.RootValue, tblName string,
 			return nil, err
 		}
 	}
-	return schema.SchemaFromCols(cc), nil
+	return schema.SchemaWithComment(schema.SchemaFromCols(cc), sch.GetComment()), nil
 }
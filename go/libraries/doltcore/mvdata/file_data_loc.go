@@ -26,6 +26,7 @@ import (
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/typed/json"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/typed/noms"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/typed/sqlite"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/untyped/csv"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/untyped/sqlexport"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/untyped/xlsx"
@@ -45,6 +46,8 @@ func DFFromString(dfStr string) DataFormat {
 		return JsonFile
 	case "sql", ".sql":
 		return SqlFile
+	case "sqlite", ".sqlite":
+		return SqliteFile
 	default:
 		return InvalidDataFormat
 	}
@@ -145,6 +148,9 @@ func (dl FileDataLocation) NewCreatingWriter(ctx context.Context, mvOpts *MoveOp
 		return json.OpenJSONWriter(dl.Path, fs, outSch)
 	case SqlFile:
 		return sqlexport.OpenSQLExportWriter(dl.Path, mvOpts.TableName, fs, outSch)
+	case SqliteFile:
+		sqliteOpts, _ := mvOpts.DestOptions.(SqliteOptions)
+		return sqlite.OpenSQLiteWriter(ctx, dl.Path, mvOpts.TableName, outSch, sqliteOpts.Warn)
 	}
 
 	panic("Invalid Data Format." + string(dl.Format))
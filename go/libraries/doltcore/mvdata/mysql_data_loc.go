@@ -0,0 +1,91 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvdata
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/typed/mysql"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/typed/noms"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+// MySQLDataLocation is a table of a MySQL database which can be imported from.
+type MySQLDataLocation struct {
+	// DB is the connection to the MySQL database
+	DB *sql.DB
+
+	// Source describes the database for messages, and shouldn't include a password
+	Source string
+
+	// Table is the name of the MySQL table
+	Table string
+}
+
+// MySQLOptions are the options of a MySQLDataLocation's reader, and limit the rows read.
+type MySQLOptions = mysql.ReadOptions
+
+// String returns a string representation of the data location.
+func (dl MySQLDataLocation) String() string {
+	return dl.Source + " table " + dl.Table
+}
+
+// Exists returns true if the DataLocation already exists
+func (dl MySQLDataLocation) Exists(ctx context.Context, root *doltdb.RootValue, fs filesys.ReadableFS) (bool, error) {
+	_, _, err := mysql.ReadSchema(ctx, dl.DB, dl.Table)
+	return err == nil, nil
+}
+
+// NewReader creates a TableReadCloser for the DataLocation. The rows are read in the primary key order of the MySQL
+// table, which may differ from their order in dolt, so they aren't reported as sorted.
+func (dl MySQLDataLocation) NewReader(ctx context.Context, root *doltdb.RootValue, fs filesys.ReadableFS, schPath string, opts interface{}) (rdCl table.TableReadCloser, sorted bool, err error) {
+	sch, pkCols, err := mysql.ReadSchema(ctx, dl.DB, dl.Table)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	mysqlOpts, _ := opts.(MySQLOptions)
+	rd, err := mysql.NewMySQLReader(ctx, root.VRW().Format(), dl.DB, dl.Table, sch, pkCols, mysqlOpts)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return rd, false, nil
+}
+
+// NewCreatingWriter will create a TableWriteCloser for a DataLocation that will create a new table, or overwrite
+// an existing table.
+func (dl MySQLDataLocation) NewCreatingWriter(ctx context.Context, mvOpts *MoveOptions, root *doltdb.RootValue, fs filesys.WritableFS, sortedInput bool, outSch schema.Schema, statsCB noms.StatsCB) (table.TableWriteCloser, error) {
+	return nil, errors.New("writing to MySQL is not supported")
+}
+
+// NewUpdatingWriter will create a TableWriteCloser for a DataLocation that will update and append rows based on
+// their primary key.
+func (dl MySQLDataLocation) NewUpdatingWriter(ctx context.Context, mvOpts *MoveOptions, root *doltdb.RootValue, fs filesys.WritableFS, srcIsSorted bool, outSch schema.Schema, statsCB noms.StatsCB) (table.TableWriteCloser, error) {
+	return nil, errors.New("writing to MySQL is not supported")
+}
+
+// NewReplacingWriter will create a TableWriteCloser for a DataLocation that will overwrite an existing table while
+// preserving schema
+func (dl MySQLDataLocation) NewReplacingWriter(ctx context.Context, mvOpts *MoveOptions, root *doltdb.RootValue, fs filesys.WritableFS, srcIsSorted bool, outSch schema.Schema, statsCB noms.StatsCB) (table.TableWriteCloser, error) {
+	return nil, errors.New("writing to MySQL is not supported")
+}
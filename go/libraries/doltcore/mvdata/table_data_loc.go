@@ -117,13 +117,18 @@ func (dl TableDataLocation) NewUpdatingWriter(ctx context.Context, mvOpts *MoveO
 		return nil, err
 	}
 
+	if mvOpts.Dedupe {
+		return noms.NewDedupingMapUpdater(ctx, root.VRW(), m, outSch, false, statsCB), nil
+	}
+
 	return noms.NewNomsMapUpdater(ctx, root.VRW(), m, outSch, statsCB), nil
 }
 
 // NewReplacingWriter will create a TableWriteCloser for a DataLocation that will overwrite an existing table while
-// preserving schema
+// preserving schema. When deduplicating, the existing rows are updated in place and the rows missing from the
+// imported data are deleted, rather than building the table up from an empty map.
 func (dl TableDataLocation) NewReplacingWriter(ctx context.Context, mvOpts *MoveOptions, root *doltdb.RootValue, fs filesys.WritableFS, srcIsSorted bool, outSch schema.Schema, statsCB noms.StatsCB) (table.TableWriteCloser, error) {
-	_, ok, err := root.GetTable(ctx, dl.Name)
+	tbl, ok, err := root.GetTable(ctx, dl.Name)
 
 	if err != nil {
 		return nil, err
@@ -133,6 +138,16 @@ func (dl TableDataLocation) NewReplacingWriter(ctx context.Context, mvOpts *Move
 		return nil, errors.New("Could not find table " + dl.Name)
 	}
 
+	if mvOpts.Dedupe {
+		m, err := tbl.GetRowData(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return noms.NewDedupingMapUpdater(ctx, root.VRW(), m, outSch, true, statsCB), nil
+	}
+
 	m, err := types.NewMap(ctx, root.VRW())
 
 	if err != nil {
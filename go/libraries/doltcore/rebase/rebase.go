@@ -16,7 +16,6 @@ package rebase
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/store/hash"
@@ -24,6 +23,8 @@ import (
 
 type visitedSet map[hash.Hash]*doltdb.Commit
 
+// replayCommitFn returns the root of a rebased commit. |parentRoot| and |rebasedParentRoot| are nil for commits
+// without parents.
 type replayCommitFn func(ctx context.Context, root, parentRoot, rebasedParentRoot *doltdb.RootValue) (rebaseRoot *doltdb.RootValue, err error)
 
 type needsRebaseFn func(ctx context.Context, cm *doltdb.Commit) (bool, error)
@@ -71,8 +72,8 @@ func rebaseRecursive(ctx context.Context, ddb *doltdb.DoltDB, replay replayCommi
 
 	allParents, err := ddb.ResolveAllParents(ctx, commit)
 
-	if len(allParents) < 1 {
-		panic(fmt.Sprintf("commit: %s has no parents", commitHash.String()))
+	if err != nil {
+		return nil, err
 	}
 
 	var allRebasedParents []*doltdb.Commit
@@ -92,17 +93,20 @@ func rebaseRecursive(ctx context.Context, ddb *doltdb.DoltDB, replay replayCommi
 		return nil, err
 	}
 
-	parentRoot, err := allParents[0].GetRootValue()
+	var parentRoot, rebasedParentRoot *doltdb.RootValue
+	if len(allParents) > 0 {
+		parentRoot, err = allParents[0].GetRootValue()
 
-	if err != nil {
-		return nil, err
-	}
+		if err != nil {
+			return nil, err
+		}
 
-	// we can diff off of any parent
-	rebasedParentRoot, err := allRebasedParents[0].GetRootValue()
+		// we can diff off of any parent
+		rebasedParentRoot, err = allRebasedParents[0].GetRootValue()
 
-	if err != nil {
-		return nil, err
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	rebasedRoot, err := replay(ctx, root, parentRoot, rebasedParentRoot)
@@ -360,7 +360,7 @@ func replayCommitWithNewTag(ctx context.Context, root, parentRoot, rebasedParent
 			return nil, err
 		}
 
-		rebasedSch := schema.SchemaFromCols(schCC)
+		rebasedSch := schema.SchemaWithComment(schema.SchemaFromCols(schCC), sch.GetComment())
 
 		// super schema rebase
 		ss, _, err := root.GetSuperSchema(ctx, tblName)
@@ -850,6 +850,31 @@ func handleSystemTableMappings(ctx context.Context, tblName string, root *doltdb
 			doltdb.SchemasTablesNameCol:     doltdb.DoltSchemasNameTag,
 			doltdb.SchemasTablesFragmentCol: doltdb.DoltSchemasFragmentTag,
 		}
+	case doltdb.StatisticsTableName:
+		newTagsByColName = map[string]uint64{
+			doltdb.StatisticsTableCol:         doltdb.StatisticsTableTag,
+			doltdb.StatisticsColumnCol:        doltdb.StatisticsColumnTag,
+			doltdb.StatisticsRowCountCol:      doltdb.StatisticsRowCountTag,
+			doltdb.StatisticsNullCountCol:     doltdb.StatisticsNullCountTag,
+			doltdb.StatisticsDistinctCountCol: doltdb.StatisticsDistinctCountTag,
+			doltdb.StatisticsMinCol:           doltdb.StatisticsMinTag,
+			doltdb.StatisticsMaxCol:           doltdb.StatisticsMaxTag,
+			doltdb.StatisticsHistogramCol:     doltdb.StatisticsHistogramTag,
+			doltdb.StatisticsSampleSizeCol:    doltdb.StatisticsSampleSizeTag,
+			doltdb.StatisticsRowDataCol:       doltdb.StatisticsRowDataTag,
+		}
+	case doltdb.RowPoliciesTableName:
+		newTagsByColName = map[string]uint64{
+			doltdb.RowPoliciesTableCol:     doltdb.RowPoliciesTableTag,
+			doltdb.RowPoliciesNameCol:      doltdb.RowPoliciesNameTag,
+			doltdb.RowPoliciesUserCol:      doltdb.RowPoliciesUserTag,
+			doltdb.RowPoliciesPredicateCol: doltdb.RowPoliciesPredicateTag,
+		}
+	case doltdb.MergeDriversTableName:
+		newTagsByColName = map[string]uint64{
+			doltdb.MergeDriversTableCol:  doltdb.MergeDriversTableTag,
+			doltdb.MergeDriversDriverCol: doltdb.MergeDriversDriverTag,
+		}
 	}
 
 	_ = sch.GetAllCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
@@ -0,0 +1,322 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rebase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"sort"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/alterschema"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// RewriteMappingFile records the new hash of every commit whose hash was changed by RewriteHistory
+var RewriteMappingFile = filepath.Join(dbfactory.DoltDir, "rewritten_commits.csv")
+
+var ErrRewriteMergeActive = errors.New("history cannot be rewritten during a merge")
+var ErrHistoryBackupExists = errors.New("a backup of previously rewritten history exists in refs/original/")
+var ErrNothingToRewrite = errors.New("nothing to rewrite")
+var ErrRewritePKColumn = errors.New("primary key columns cannot be removed from history")
+
+var backupRefFilter = map[ref.RefType]struct{}{ref.BackupRefType: {}}
+
+// HistoryFilter identifies what RewriteHistory removes from every commit.
+type HistoryFilter struct {
+	// Table is the name of the table to remove, or of the table to remove Column from
+	Table string
+
+	// Column is the name of the column to remove. The entire table is removed if it's empty.
+	Column string
+}
+
+// RewriteResult describes the refs and commits changed by RewriteHistory.
+type RewriteResult struct {
+	// Refs are the refs which point at rewritten commits. Each one's original head is kept by its ref.BackupRef.
+	Refs []ref.DoltRef
+
+	// Commits maps the hash of every commit which was rewritten to the hash of the commit which replaced it. Commits
+	// which never contained what was removed, and whose ancestors didn't either, keep their hashes.
+	Commits map[hash.Hash]hash.Hash
+}
+
+func allCommits(_ context.Context, _ *doltdb.Commit) (bool, error) {
+	return true, nil
+}
+
+// RewriteHistory removes the table or column described by |filter| from every commit reachable from the branches of the
+// repository, along with the working and staged roots. Remote tracking branches still record the state of their
+// remotes, so pushing a rewritten branch requires a forced update. Commit metadata and the shape of the commit graph
+// are kept, but every commit which contained what was removed, and every descendant of one, gets a new hash. Before a
+// ref is moved its original head is saved as a ref.BackupRef, and the old and new commit hashes are written to
+// RewriteMappingFile. Unless |overwriteBackup| is true, an error is returned if backups from an earlier rewrite exist.
+// Nothing is deleted from the store, the old chunks remain until they're garbage collected.
+func RewriteHistory(ctx context.Context, dEnv *env.DoltEnv, filter HistoryFilter, overwriteBackup bool) (*RewriteResult, error) {
+	if dEnv.IsMergeActive() {
+		return nil, ErrRewriteMergeActive
+	}
+
+	ddb := dEnv.DoltDB
+	backups, err := ddb.GetRefsOfType(ctx, backupRefFilter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(backups) > 0 && !overwriteBackup {
+		return nil, ErrHistoryBackupExists
+	}
+
+	refs, err := ddb.GetBranches(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var heads []*doltdb.Commit
+	for _, dRef := range refs {
+		cs, err := doltdb.NewCommitSpec("head", dRef.String())
+
+		if err != nil {
+			return nil, err
+		}
+
+		cm, err := ddb.Resolve(ctx, cs)
+
+		if err != nil {
+			return nil, err
+		}
+
+		heads = append(heads, cm)
+	}
+
+	replay := func(ctx context.Context, root, _, _ *doltdb.RootValue) (*doltdb.RootValue, error) {
+		return filterRoot(ctx, root, filter)
+	}
+
+	vs := make(visitedSet)
+	newHeads := make([]*doltdb.Commit, len(heads))
+	for i, cm := range heads {
+		newHeads[i], err = rebaseRecursive(ctx, ddb, replay, allCommits, vs, cm)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res := &RewriteResult{Commits: make(map[hash.Hash]hash.Hash)}
+	for oldHash, cm := range vs {
+		newHash, err := cm.HashOf()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if newHash != oldHash {
+			res.Commits[oldHash] = newHash
+		}
+	}
+
+	working, err := dEnv.WorkingRoot(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	newWorking, workingChanged, err := filterRootAndCompare(ctx, working, filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	staged, err := dEnv.StagedRoot(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	newStaged, stagedChanged, err := filterRootAndCompare(ctx, staged, filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Commits) == 0 && !workingChanged && !stagedChanged {
+		return nil, ErrNothingToRewrite
+	}
+
+	for _, backup := range backups {
+		err = ddb.DeleteBranch(ctx, backup)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, dRef := range refs {
+		oldHash, err := heads[i].HashOf()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := res.Commits[oldHash]; !ok {
+			continue
+		}
+
+		err = ddb.SetHead(ctx, ref.NewBackupRef(dRef), heads[i])
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = ddb.SetHead(ctx, dRef, newHeads[i])
+
+		if err != nil {
+			return nil, err
+		}
+
+		res.Refs = append(res.Refs, dRef)
+	}
+
+	if stagedChanged {
+		_, err = dEnv.UpdateStagedRoot(ctx, newStaged)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if workingChanged {
+		err = dEnv.UpdateWorkingRoot(ctx, newWorking)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(res.Commits) > 0 {
+		err = dEnv.FS.WriteFile(RewriteMappingFile, commitMappingCSV(res.Commits))
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// DeleteHistoryBackup deletes the backups of the refs rewritten by RewriteHistory, returning the number of backups
+// deleted. Once they're deleted the original history can only be recovered from another copy of the repository.
+func DeleteHistoryBackup(ctx context.Context, ddb *doltdb.DoltDB) (int, error) {
+	backups, err := ddb.GetRefsOfType(ctx, backupRefFilter)
+
+	if err != nil {
+		return 0, err
+	}
+
+	for _, backup := range backups {
+		err = ddb.DeleteBranch(ctx, backup)
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(backups), nil
+}
+
+// filterRoot returns |root| without the table or column described by |filter|.
+func filterRoot(ctx context.Context, root *doltdb.RootValue, filter HistoryFilter) (*doltdb.RootValue, error) {
+	tbl, ok, err := root.GetTable(ctx, filter.Table)
+
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return root, nil
+	}
+
+	if filter.Column == "" {
+		return root.RemoveTables(ctx, filter.Table)
+	}
+
+	sch, err := tbl.GetSchema(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	col, ok := sch.GetAllCols().GetByName(filter.Column)
+
+	if !ok {
+		return root, nil
+	} else if col.IsPartOfPK {
+		return nil, ErrRewritePKColumn
+	}
+
+	tbl, err = alterschema.DropColumn(ctx, tbl, filter.Column)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return root.PutTable(ctx, filter.Table, tbl)
+}
+
+func filterRootAndCompare(ctx context.Context, root *doltdb.RootValue, filter HistoryFilter) (*doltdb.RootValue, bool, error) {
+	filtered, err := filterRoot(ctx, root, filter)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	h, err := root.HashOf()
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	fh, err := filtered.HashOf()
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return filtered, h != fh, nil
+}
+
+func commitMappingCSV(commits map[hash.Hash]hash.Hash) []byte {
+	oldHashes := make([]string, 0, len(commits))
+	newHashes := make(map[string]string, len(commits))
+	for oldHash, newHash := range commits {
+		oldHashes = append(oldHashes, oldHash.String())
+		newHashes[oldHash.String()] = newHash.String()
+	}
+
+	sort.Strings(oldHashes)
+
+	buf := bytes.NewBufferString("old_commit,new_commit\n")
+	for _, oldHash := range oldHashes {
+		buf.WriteString(oldHash + "," + newHashes[oldHash] + "\n")
+	}
+
+	return buf.Bytes()
+}
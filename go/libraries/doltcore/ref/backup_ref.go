@@ -0,0 +1,45 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ref
+
+// BackupRef is a reference to the commit another ref pointed to before its history was rewritten
+type BackupRef struct {
+	path string
+}
+
+// GetType returns BackupRefType
+func (br BackupRef) GetType() RefType {
+	return BackupRefType
+}
+
+// GetPath returns the type and path of the original reference e.g. heads/master
+func (br BackupRef) GetPath() string {
+	return br.path
+}
+
+// String returns the fully qualified reference e.g. refs/original/heads/master
+func (br BackupRef) String() string {
+	return String(br)
+}
+
+// GetOriginal returns the reference which was backed up
+func (br BackupRef) GetOriginal() (DoltRef, error) {
+	return Parse(refPrefix + br.path)
+}
+
+// NewBackupRef creates the backup reference for the reference given
+func NewBackupRef(dr DoltRef) BackupRef {
+	return BackupRef{string(dr.GetType()) + "/" + dr.GetPath()}
+}
@@ -45,11 +45,23 @@ const (
 
 	// InternalRefType is a reference to a dolt internal commit
 	InternalRefType RefType = "internal"
+
+	// TagRefType is a reference to a tag in the format refs/tags/...
+	TagRefType RefType = "tags"
+
+	// BackupRefType is a reference to the original head of a ref whose history was rewritten, in the format
+	// refs/original/type/...
+	BackupRefType RefType = "original"
+
+	// WorkspaceRefType is a reference to the uncommitted changes of a sql-server user or session, in the format
+	// refs/workspaces/...
+	WorkspaceRefType RefType = "workspaces"
 )
 
 // RefTypes is the set of all supported reference types.  External RefTypes can be added to this map in order to add
-// RefTypes for external tooling
-var RefTypes = map[RefType]struct{}{BranchRefType: {}, RemoteRefType: {}, InternalRefType: {}}
+// RefTypes for external tooling. BackupRefType and WorkspaceRefType are left out so that the backups of rewritten refs
+// and the workspaces of sql-server sessions aren't treated as refs by the commands which operate on every ref.
+var RefTypes = map[RefType]struct{}{BranchRefType: {}, RemoteRefType: {}, InternalRefType: {}, TagRefType: {}}
 
 // PrefixForType returns what a reference string for a given type should start with
 func PrefixForType(refType RefType) string {
@@ -126,6 +138,14 @@ func Parse(str string) (DoltRef, error) {
 		}
 	}
 
+	if prefix := PrefixForType(BackupRefType); strings.HasPrefix(str, prefix) {
+		return BackupRef{str[len(prefix):]}, nil
+	}
+
+	if prefix := PrefixForType(WorkspaceRefType); strings.HasPrefix(str, prefix) {
+		return NewWorkspaceRef(str), nil
+	}
+
 	for rType := range RefTypes {
 		prefix := PrefixForType(rType)
 		if strings.HasPrefix(str, prefix) {
@@ -137,6 +157,8 @@ func Parse(str string) (DoltRef, error) {
 				return NewRemoteRefFromPathStr(str)
 			case InternalRefType:
 				return NewInternalRef(str), nil
+			case TagRefType:
+				return NewTagRef(str), nil
 			default:
 				panic("unknown type " + rType)
 			}
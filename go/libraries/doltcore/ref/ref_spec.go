@@ -101,6 +101,8 @@ func ParseRefSpecForRemote(remote, refSpecStr string) (RefSpec, error) {
 		return newLocalToRemoteTrackingRef(remote, fromRef.(BranchRef), toRef.(RemoteRef))
 	} else if fromRef.GetType() == BranchRefType && toRef.GetType() == BranchRefType {
 		return NewBranchToBranchRefSpec(fromRef.(BranchRef), toRef.(BranchRef))
+	} else if toRef.GetType() == TagRefType && (fromRef == EmptyBranchRef || fromRef.GetType() == TagRefType) {
+		return NewTagToTagRefSpec(fromRef, toRef.(TagRef))
 	}
 
 	return nil, ErrUnsupportedMapping
@@ -164,6 +166,40 @@ func (rs BranchToBranchRefSpec) DestRef(r DoltRef) DoltRef {
 	return nil
 }
 
+// TagToTagRefSpec maps one tag to another. A source of EmptyBranchRef maps nothing to the destination tag, which is
+// used to delete it.
+type TagToTagRefSpec struct {
+	srcRef  DoltRef
+	destRef TagRef
+}
+
+// NewTagToTagRefSpec takes a source TagRef, or EmptyBranchRef, and a destination TagRef and returns a RefSpec that maps
+// source to dest.
+func NewTagToTagRefSpec(srcRef DoltRef, destRef TagRef) (RefSpec, error) {
+	if srcRef != EmptyBranchRef && srcRef.GetType() != TagRefType {
+		return nil, ErrInvalidMapping
+	}
+
+	return TagToTagRefSpec{
+		srcRef:  srcRef,
+		destRef: destRef,
+	}, nil
+}
+
+// SrcRef will always determine the DoltRef specified as the source ref regardless to the cwbRef
+func (rs TagToTagRefSpec) SrcRef(cwbRef DoltRef) DoltRef {
+	return rs.srcRef
+}
+
+// DestRef returns the destination tag if the ref given is the source of the refspec, or nil otherwise.
+func (rs TagToTagRefSpec) DestRef(r DoltRef) DoltRef {
+	if Equals(r, rs.srcRef) {
+		return rs.destRef
+	}
+
+	return nil
+}
+
 // BranchToTrackingBranchRefSpec maps a branch to the branch that should be tracking it
 type BranchToTrackingBranchRefSpec struct {
 	localPattern  pattern
@@ -65,6 +65,28 @@ func TestRefSpec(t *testing.T) {
 				"refs/heads/master":  "refs/heads/master",
 				"refs/heads/feature": "refs/nil/",
 			},
+		}, {
+			"",
+			"refs/tags/v1",
+			true,
+			map[string]string{
+				"refs/tags/v1":      "refs/tags/v1",
+				"refs/tags/v2":      "refs/nil/",
+				"refs/heads/master": "refs/nil/",
+			},
+		}, {
+			"",
+			":refs/tags/v1",
+			true,
+			map[string]string{
+				"":             "refs/tags/v1",
+				"refs/tags/v1": "refs/nil/",
+			},
+		}, {
+			"",
+			"refs/heads/master:refs/tags/v1",
+			false,
+			nil,
 		}, {
 			"origin",
 			"refs/heads/master:refs/remotes/not_borigin/mymaster",
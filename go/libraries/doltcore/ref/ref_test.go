@@ -40,6 +40,18 @@ func TestJsonMarshalAndUnmarshal(t *testing.T) {
 			NewInternalRef("create"),
 			`{"test":"refs/internal/create"}`,
 		},
+		{
+			NewTagRef("v1"),
+			`{"test":"refs/tags/v1"}`,
+		},
+		{
+			NewBackupRef(NewBranchRef("master")),
+			`{"test":"refs/original/heads/master"}`,
+		},
+		{
+			NewWorkspaceRef("alice"),
+			`{"test":"refs/workspaces/alice"}`,
+		},
 	}
 
 	for _, test := range tests {
@@ -134,6 +146,16 @@ func TestEqualsStr(t *testing.T) {
 			"refs/internal/create",
 			true,
 		},
+		{
+			NewTagRef("refs/tags/v1"),
+			"refs/tags/v1",
+			true,
+		},
+		{
+			NewTagRef("v1"),
+			"refs/heads/v1",
+			false,
+		},
 	}
 
 	for _, test := range tests {
@@ -144,3 +166,27 @@ func TestEqualsStr(t *testing.T) {
 		}
 	}
 }
+
+func TestBackupRef(t *testing.T) {
+	tests := []DoltRef{
+		NewBranchRef("master"),
+		NewBranchRef("feature/login"),
+		NewRemoteRef("origin", "master"),
+	}
+
+	for _, test := range tests {
+		br := NewBackupRef(test)
+
+		if !EqualsStr(br, "refs/original/"+test.String()[len(refPrefix):]) {
+			t.Error("unexpected backup ref", br, "for", test)
+		}
+
+		original, err := br.GetOriginal()
+
+		if err != nil {
+			t.Error(err)
+		} else if !Equals(test, original) {
+			t.Error(original, "!=", test)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ref
+
+import "strings"
+
+// TagRef is a reference to a tag, which names a commit and never moves unless it is replaced
+type TagRef struct {
+	tag string
+}
+
+// GetType will return TagRefType
+func (tr TagRef) GetType() RefType {
+	return TagRefType
+}
+
+// GetPath returns the name of the tag
+func (tr TagRef) GetPath() string {
+	return tr.tag
+}
+
+// String returns the fully qualified reference name e.g. refs/tags/v1
+func (tr TagRef) String() string {
+	return String(tr)
+}
+
+func (tr TagRef) MarshalJSON() ([]byte, error) {
+	return MarshalJSON(tr)
+}
+
+// NewTagRef creates a reference to a tag from a tag name or a tag ref e.g. v1, or refs/tags/v1
+func NewTagRef(tagName string) TagRef {
+	if IsRef(tagName) {
+		prefix := PrefixForType(TagRefType)
+		if strings.HasPrefix(tagName, prefix) {
+			tagName = tagName[len(prefix):]
+		} else {
+			panic(tagName + " is a ref that is not of type " + prefix)
+		}
+	}
+
+	return TagRef{tagName}
+}
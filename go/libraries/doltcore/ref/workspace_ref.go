@@ -0,0 +1,52 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ref
+
+import "strings"
+
+// WorkspaceRef is a reference to the workspace of a sql-server user or session, which holds the changes they've made
+// to a branch until they are committed to it
+type WorkspaceRef struct {
+	name string
+}
+
+// GetType returns WorkspaceRefType
+func (wr WorkspaceRef) GetType() RefType {
+	return WorkspaceRefType
+}
+
+// GetPath returns the name of the workspace
+func (wr WorkspaceRef) GetPath() string {
+	return wr.name
+}
+
+// String returns the fully qualified reference e.g. refs/workspaces/alice
+func (wr WorkspaceRef) String() string {
+	return String(wr)
+}
+
+// NewWorkspaceRef creates a reference to the workspace with the name given
+func NewWorkspaceRef(name string) WorkspaceRef {
+	if IsRef(name) {
+		prefix := PrefixForType(WorkspaceRefType)
+		if strings.HasPrefix(name, prefix) {
+			name = name[len(prefix):]
+		} else {
+			panic(name + " is a ref that is not of type " + prefix)
+		}
+	}
+
+	return WorkspaceRef{name}
+}
@@ -0,0 +1,276 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotestorage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	remotesapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/remotesapi/v1alpha1"
+)
+
+// The versions of the remotes protocol which this dolt speaks. Version 0 is the protocol of the clients and servers
+// which don't exchange capabilities.
+const (
+	ProtocolVersion    = 1
+	MinProtocolVersion = 0
+)
+
+// SnappyCompression is the compression codec of the chunks of table files.
+const SnappyCompression = "snappy"
+
+// DoltVersion is the version of dolt which is sent to remotes along with its capabilities, so that the errors of
+// incompatible clients and servers can name the versions of both.
+var DoltVersion = "unknown"
+
+// The capabilities are exchanged in the metadata of the GetRepoMetadata call which starts a push or pull. The client
+// sends its capabilities with the request, and the server answers with its own in the response's header, or fails the
+// call if the two are incompatible.
+const (
+	doltVersionKey        = "dolt-version"
+	protocolVersionKey    = "dolt-protocol-version"
+	minProtocolVersionKey = "dolt-min-protocol-version"
+	compressionKey        = "dolt-compression"
+	shallowKey            = "dolt-shallow"
+	tableSubtreeFetchKey  = "dolt-table-subtree-fetch"
+)
+
+// Capabilities are the features of the remotes protocol which a client or server supports.
+type Capabilities struct {
+	// DoltVersion is the version of dolt which the client or server is running
+	DoltVersion string
+
+	// ProtocolVersion and MinProtocolVersion are the newest and oldest versions of the protocol which are spoken
+	ProtocolVersion    int
+	MinProtocolVersion int
+
+	// Compression lists the compression codecs of table files which are supported, in order of preference
+	Compression []string
+
+	// Shallow is true if pulls of a commit without its history are supported
+	Shallow bool
+
+	// TableSubtreeFetch is true if fetches of the chunks of single tables are supported
+	TableSubtreeFetch bool
+}
+
+// LocalCapabilities returns the capabilities of this dolt.
+func LocalCapabilities() Capabilities {
+	return Capabilities{
+		DoltVersion:        DoltVersion,
+		ProtocolVersion:    ProtocolVersion,
+		MinProtocolVersion: MinProtocolVersion,
+		Compression:        []string{SnappyCompression},
+	}
+}
+
+// legacyCapabilities returns the capabilities of a client or server which doesn't exchange them.
+func legacyCapabilities() Capabilities {
+	return Capabilities{Compression: []string{SnappyCompression}}
+}
+
+// ErrIncompatibleCapability is returned when a client and server have no version of a capability in common.
+type ErrIncompatibleCapability struct {
+	// Capability names the capability, and is one of the capability keys
+	Capability string
+
+	Client Capabilities
+	Server Capabilities
+}
+
+func (e *ErrIncompatibleCapability) Error() string {
+	client := describeDolt("the client", e.Client)
+	server := describeDolt("the remote", e.Server)
+
+	switch e.Capability {
+	case protocolVersionKey:
+		if e.Server.MinProtocolVersion > e.Client.ProtocolVersion {
+			return fmt.Sprintf("%s speaks remotes protocol versions %d to %d, but %s requires version %d or later; upgrade dolt", client, e.Client.MinProtocolVersion, e.Client.ProtocolVersion, server, e.Server.MinProtocolVersion)
+		}
+
+		return fmt.Sprintf("%s speaks remotes protocol versions %d to %d, but %s requires version %d or later; upgrade the remote", server, e.Server.MinProtocolVersion, e.Server.ProtocolVersion, client, e.Client.MinProtocolVersion)
+	case compressionKey:
+		return fmt.Sprintf("no table file compression is supported by both sides: %s supports %s, and %s supports %s", client, strings.Join(e.Client.Compression, ", "), server, strings.Join(e.Server.Compression, ", "))
+	default:
+		return fmt.Sprintf("%s and %s are incompatible: %s", client, server, e.Capability)
+	}
+}
+
+func describeDolt(side string, c Capabilities) string {
+	if c.DoltVersion == "" {
+		return side
+	}
+
+	return side + " (dolt " + c.DoltVersion + ")"
+}
+
+// NegotiateCapabilities returns the capabilities which both |client| and |server| support, or an
+// *ErrIncompatibleCapability if there's a capability they need which they have no version of in common.
+func NegotiateCapabilities(client, server Capabilities) (Capabilities, error) {
+	common := Capabilities{
+		DoltVersion:        server.DoltVersion,
+		ProtocolVersion:    client.ProtocolVersion,
+		MinProtocolVersion: client.MinProtocolVersion,
+		Shallow:            client.Shallow && server.Shallow,
+		TableSubtreeFetch:  client.TableSubtreeFetch && server.TableSubtreeFetch,
+	}
+
+	if server.ProtocolVersion < common.ProtocolVersion {
+		common.ProtocolVersion = server.ProtocolVersion
+	}
+
+	if server.MinProtocolVersion > common.MinProtocolVersion {
+		common.MinProtocolVersion = server.MinProtocolVersion
+	}
+
+	if common.ProtocolVersion < common.MinProtocolVersion {
+		return Capabilities{}, &ErrIncompatibleCapability{protocolVersionKey, client, server}
+	}
+
+	for _, codec := range client.Compression {
+		for _, serverCodec := range server.Compression {
+			if codec == serverCodec {
+				common.Compression = append(common.Compression, codec)
+			}
+		}
+	}
+
+	if len(common.Compression) == 0 {
+		return Capabilities{}, &ErrIncompatibleCapability{compressionKey, client, server}
+	}
+
+	return common, nil
+}
+
+// metadataPairs returns the keys and values of the metadata the capabilities are exchanged in.
+func (c Capabilities) metadataPairs() []string {
+	return []string{
+		doltVersionKey, c.DoltVersion,
+		protocolVersionKey, strconv.Itoa(c.ProtocolVersion),
+		minProtocolVersionKey, strconv.Itoa(c.MinProtocolVersion),
+		compressionKey, strings.Join(c.Compression, ","),
+		shallowKey, strconv.FormatBool(c.Shallow),
+		tableSubtreeFetchKey, strconv.FormatBool(c.TableSubtreeFetch),
+	}
+}
+
+// capabilitiesFromMetadata reads the capabilities in |md|, returning false if it doesn't have them.
+func capabilitiesFromMetadata(md metadata.MD) (Capabilities, bool) {
+	get := func(key string) string {
+		if vals := md.Get(key); len(vals) > 0 {
+			return vals[0]
+		}
+
+		return ""
+	}
+
+	protocolVersion, err := strconv.Atoi(get(protocolVersionKey))
+
+	if err != nil {
+		return Capabilities{}, false
+	}
+
+	minProtocolVersion, _ := strconv.Atoi(get(minProtocolVersionKey))
+	shallow, _ := strconv.ParseBool(get(shallowKey))
+	tableSubtreeFetch, _ := strconv.ParseBool(get(tableSubtreeFetchKey))
+
+	var compression []string
+	if codecs := get(compressionKey); codecs != "" {
+		compression = strings.Split(codecs, ",")
+	}
+
+	return Capabilities{
+		DoltVersion:        get(doltVersionKey),
+		ProtocolVersion:    protocolVersion,
+		MinProtocolVersion: minProtocolVersion,
+		Compression:        compression,
+		Shallow:            shallow,
+		TableSubtreeFetch:  tableSubtreeFetch,
+	}, true
+}
+
+// ServeCapabilities negotiates the capabilities of the client of a GetRepoMetadata call with |server|, the
+// capabilities of the server handling it. The server's capabilities are sent in the header of the response, and a
+// FailedPrecondition error naming the incompatible capability is returned if the client and server have no version of
+// it in common. Clients which don't send their capabilities are taken to speak protocol version 0.
+func ServeCapabilities(ctx context.Context, server Capabilities) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	client, ok := capabilitiesFromMetadata(md)
+
+	if !ok {
+		client = legacyCapabilities()
+	}
+
+	if _, err := NegotiateCapabilities(client, server); err != nil {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	return grpc.SetHeader(ctx, metadata.Pairs(server.metadataPairs()...))
+}
+
+// negotiatedCapabilities caches the capabilities negotiated with each remote host for the rest of the session.
+var negotiatedCapabilities = struct {
+	mu     sync.Mutex
+	byHost map[string]Capabilities
+}{byHost: map[string]Capabilities{}}
+
+// getRepoMetadata makes the GetRepoMetadata call which starts a push or pull, negotiating capabilities with the
+// remote's host if they haven't been negotiated in this session. Remotes which don't answer with their capabilities
+// are taken to speak protocol version 0.
+func getRepoMetadata(ctx context.Context, csClient remotesapi.ChunkStoreServiceClient, host string, req *remotesapi.GetRepoMetadataRequest) (*remotesapi.GetRepoMetadataResponse, Capabilities, error) {
+	negotiatedCapabilities.mu.Lock()
+	caps, ok := negotiatedCapabilities.byHost[host]
+	negotiatedCapabilities.mu.Unlock()
+
+	if ok {
+		resp, err := csClient.GetRepoMetadata(ctx, req)
+		return resp, caps, err
+	}
+
+	local := LocalCapabilities()
+	var header metadata.MD
+	ctx = metadata.AppendToOutgoingContext(ctx, local.metadataPairs()...)
+	resp, err := csClient.GetRepoMetadata(ctx, req, grpc.Header(&header))
+
+	if err != nil {
+		return nil, Capabilities{}, err
+	}
+
+	server, ok := capabilitiesFromMetadata(header)
+
+	if !ok {
+		server = legacyCapabilities()
+	}
+
+	caps, err = NegotiateCapabilities(local, server)
+
+	if err != nil {
+		return nil, Capabilities{}, err
+	}
+
+	negotiatedCapabilities.mu.Lock()
+	negotiatedCapabilities.byHost[host] = caps
+	negotiatedCapabilities.mu.Unlock()
+
+	return resp, caps, nil
+}
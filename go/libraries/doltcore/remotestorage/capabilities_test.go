@@ -0,0 +1,173 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotestorage
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	remotesapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/remotesapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/store/nbs"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+func TestNegotiateCapabilities(t *testing.T) {
+	v1 := Capabilities{DoltVersion: "0.16.1", ProtocolVersion: 1, Compression: []string{"zstd", SnappyCompression}, Shallow: true}
+	v3 := Capabilities{DoltVersion: "0.20.0", ProtocolVersion: 3, MinProtocolVersion: 1, Compression: []string{SnappyCompression}, Shallow: true, TableSubtreeFetch: true}
+
+	common, err := NegotiateCapabilities(v1, v3)
+	require.NoError(t, err)
+	assert.Equal(t, Capabilities{DoltVersion: "0.20.0", ProtocolVersion: 1, MinProtocolVersion: 1, Compression: []string{SnappyCompression}, Shallow: true}, common)
+
+	common, err = NegotiateCapabilities(legacyCapabilities(), LocalCapabilities())
+	require.NoError(t, err)
+	assert.Equal(t, 0, common.ProtocolVersion)
+
+	v4 := Capabilities{DoltVersion: "0.30.0", ProtocolVersion: 4, MinProtocolVersion: 2, Compression: []string{SnappyCompression}}
+	_, err = NegotiateCapabilities(v1, v4)
+	var incompatible *ErrIncompatibleCapability
+	require.True(t, errors.As(err, &incompatible), "unexpected error %v", err)
+	assert.Equal(t, protocolVersionKey, incompatible.Capability)
+	assert.Equal(t, "the client (dolt 0.16.1) speaks remotes protocol versions 0 to 1, but the remote (dolt 0.30.0) requires version 2 or later; upgrade dolt", err.Error())
+
+	_, err = NegotiateCapabilities(v4, v1)
+	require.True(t, errors.As(err, &incompatible), "unexpected error %v", err)
+	assert.Equal(t, "the remote (dolt 0.16.1) speaks remotes protocol versions 0 to 1, but the client (dolt 0.30.0) requires version 2 or later; upgrade the remote", err.Error())
+
+	zstdOnly := Capabilities{DoltVersion: "0.20.0", ProtocolVersion: 1, Compression: []string{"zstd"}}
+	_, err = NegotiateCapabilities(legacyCapabilities(), zstdOnly)
+	require.True(t, errors.As(err, &incompatible), "unexpected error %v", err)
+	assert.Equal(t, compressionKey, incompatible.Capability)
+	assert.Equal(t, "no table file compression is supported by both sides: the client supports snappy, and the remote (dolt 0.20.0) supports zstd", err.Error())
+}
+
+func TestCapabilitiesFromMetadata(t *testing.T) {
+	caps := Capabilities{DoltVersion: "0.16.1", ProtocolVersion: 2, MinProtocolVersion: 1, Compression: []string{"zstd", SnappyCompression}, TableSubtreeFetch: true}
+	parsed, ok := capabilitiesFromMetadata(metadata.Pairs(caps.metadataPairs()...))
+	require.True(t, ok)
+	assert.Equal(t, caps, parsed)
+
+	_, ok = capabilitiesFromMetadata(metadata.Pairs("other", "value"))
+	assert.False(t, ok)
+}
+
+// capabilitiesServer answers GetRepoMetadata calls, negotiating capabilities as a server with |caps|, or as a server
+// which doesn't exchange capabilities if |caps| is nil.
+type capabilitiesServer struct {
+	remotesapi.UnimplementedChunkStoreServiceServer
+	caps *Capabilities
+
+	// clientCaps are the capabilities sent with each call
+	clientCaps []bool
+}
+
+func (s *capabilitiesServer) GetRepoMetadata(ctx context.Context, req *remotesapi.GetRepoMetadataRequest) (*remotesapi.GetRepoMetadataResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	_, sent := capabilitiesFromMetadata(md)
+	s.clientCaps = append(s.clientCaps, sent)
+
+	if s.caps != nil {
+		if err := ServeCapabilities(ctx, *s.caps); err != nil {
+			return nil, err
+		}
+	}
+
+	return &remotesapi.GetRepoMetadataResponse{NbfVersion: types.Format_Default.VersionString(), NbsVersion: nbs.StorageVersion}, nil
+}
+
+func TestCapabilitiesExchange(t *testing.T) {
+	ctx := context.Background()
+
+	serve := func(t *testing.T, server *capabilitiesServer) (remotesapi.ChunkStoreServiceClient, func()) {
+		lis := bufconn.Listen(1024 * 1024)
+		grpcServer := grpc.NewServer()
+		remotesapi.RegisterChunkStoreServiceServer(grpcServer, server)
+		go func() {
+			_ = grpcServer.Serve(lis)
+		}()
+
+		dialer := grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return lis.Dial()
+		})
+		conn, err := grpc.DialContext(ctx, "bufconn", dialer, grpc.WithInsecure())
+		require.NoError(t, err)
+
+		return remotesapi.NewChunkStoreServiceClient(conn), func() {
+			_ = conn.Close()
+			grpcServer.Stop()
+		}
+	}
+
+	t.Run("Compatible", func(t *testing.T) {
+		serverCaps := Capabilities{DoltVersion: "0.20.0", ProtocolVersion: 3, Compression: []string{SnappyCompression}, Shallow: true}
+		server := &capabilitiesServer{caps: &serverCaps}
+		client, stop := serve(t, server)
+		defer stop()
+
+		dcs, err := NewDoltChunkStore(ctx, types.Format_Default, "org", "repo", "compatible.host", client)
+		require.NoError(t, err)
+		assert.Equal(t, ProtocolVersion, dcs.Capabilities().ProtocolVersion)
+		assert.Equal(t, "0.20.0", dcs.Capabilities().DoltVersion)
+		assert.False(t, dcs.Capabilities().Shallow)
+
+		// the capabilities negotiated with the host are used for the rest of the session
+		dcs, err = NewDoltChunkStore(ctx, types.Format_Default, "org", "other_repo", "compatible.host", client)
+		require.NoError(t, err)
+		assert.Equal(t, "0.20.0", dcs.Capabilities().DoltVersion)
+		assert.Equal(t, []bool{true, false}, server.clientCaps)
+	})
+
+	t.Run("Legacy", func(t *testing.T) {
+		client, stop := serve(t, &capabilitiesServer{})
+		defer stop()
+
+		dcs, err := NewDoltChunkStore(ctx, types.Format_Default, "org", "repo", "legacy.host", client)
+		require.NoError(t, err)
+		assert.Equal(t, 0, dcs.Capabilities().ProtocolVersion)
+		assert.Equal(t, []string{SnappyCompression}, dcs.Capabilities().Compression)
+	})
+
+	t.Run("ServerRequiresNewerClient", func(t *testing.T) {
+		serverCaps := Capabilities{DoltVersion: "0.30.0", ProtocolVersion: ProtocolVersion + 2, MinProtocolVersion: ProtocolVersion + 1, Compression: []string{SnappyCompression}}
+		client, stop := serve(t, &capabilitiesServer{caps: &serverCaps})
+		defer stop()
+
+		_, err := NewDoltChunkStore(ctx, types.Format_Default, "org", "repo", "newer.host", client)
+		require.Error(t, err)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Contains(t, err.Error(), "the remote (dolt 0.30.0) requires version 2 or later")
+	})
+
+	t.Run("ClientRejectsServer", func(t *testing.T) {
+		// the server doesn't check the client's capabilities, so the client rejects the server's
+		serverCaps := Capabilities{DoltVersion: "0.20.0", ProtocolVersion: 1, Compression: []string{"zstd"}}
+		server := &capabilitiesServer{caps: &serverCaps}
+		client, stop := serve(t, server)
+		defer stop()
+
+		_, err := NewDoltChunkStore(ctx, types.Format_Default, "org", "repo", "zstd.host", client)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err), "unexpected error %v", err)
+	})
+}
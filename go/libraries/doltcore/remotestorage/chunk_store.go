@@ -81,6 +81,13 @@ type DoltChunkStore struct {
 	metadata    *remotesapi.GetRepoMetadataResponse
 	nbf         *types.NomsBinFormat
 	httpFetcher HTTPFetcher
+
+	// capabilities are the capabilities of the remotes protocol which were negotiated with the remote
+	capabilities Capabilities
+
+	// pushStates keeps the state of resumable uploads of table files, and uploadPartSize is the size of their parts
+	pushStates     nbs.PushStateStore
+	uploadPartSize uint64
 }
 
 func NewDoltChunkStoreFromPath(ctx context.Context, nbf *types.NomsBinFormat, path, host string, csClient remotesapi.ChunkStoreServiceClient) (*DoltChunkStore, error) {
@@ -110,7 +117,7 @@ func NewDoltChunkStore(ctx context.Context, nbf *types.NomsBinFormat, org, repoN
 
 	counter := events.NewCounter(eventsapi.MetricID_REMOTEAPI_RPC_ERROR)
 
-	metadata, err := csClient.GetRepoMetadata(ctx, &remotesapi.GetRepoMetadataRequest{
+	metadata, capabilities, err := getRepoMetadata(ctx, csClient, host, &remotesapi.GetRepoMetadataRequest{
 		RepoId: &remotesapi.RepoId{
 			Org:      org,
 			RepoName: repoName,
@@ -126,15 +133,50 @@ func NewDoltChunkStore(ctx context.Context, nbf *types.NomsBinFormat, org, repoN
 		return nil, err
 	}
 
-	return &DoltChunkStore{org, repoName, host, csClient, newMapChunkCache(), metadata, nbf, globalHttpFetcher}, nil
+	if metadata.NbsVersion != "" && metadata.NbsVersion != nbs.StorageVersion {
+		return nil, &chunks.ErrIncompatibleFormat{Found: metadata.NbsVersion, Supported: []string{nbs.StorageVersion}, Remote: true}
+	}
+
+	err = chunks.CheckFormatVersion(metadata.NbfVersion)
+
+	if err != nil {
+		return nil, err.(*chunks.ErrIncompatibleFormat).AsRemote()
+	}
+
+	return &DoltChunkStore{org, repoName, host, csClient, newMapChunkCache(), metadata, nbf, globalHttpFetcher, capabilities, nbs.PushStateStore{}, defaultUploadPartSize}, nil
+}
+
+// Capabilities returns the capabilities of the remotes protocol which were negotiated with the remote.
+func (dcs *DoltChunkStore) Capabilities() Capabilities {
+	return dcs.capabilities
 }
 
 func (dcs *DoltChunkStore) WithHTTPFetcher(fetcher HTTPFetcher) *DoltChunkStore {
-	return &DoltChunkStore{dcs.org, dcs.repoName, dcs.host, dcs.csClient, dcs.cache, dcs.metadata, dcs.nbf, fetcher}
+	cs := *dcs
+	cs.httpFetcher = fetcher
+	return &cs
 }
 
 func (dcs *DoltChunkStore) WithNoopChunkCache() *DoltChunkStore {
-	return &DoltChunkStore{dcs.org, dcs.repoName, dcs.host, dcs.csClient, noopChunkCache, dcs.metadata, dcs.nbf, dcs.httpFetcher}
+	cs := *dcs
+	cs.cache = noopChunkCache
+	return &cs
+}
+
+// WithPushStateDir returns a DoltChunkStore which keeps the state of its resumable uploads of table files in |dir|, so
+// that an upload which fails partway is resumed by the next push of the table file rather than restarted.
+func (dcs *DoltChunkStore) WithPushStateDir(dir string) *DoltChunkStore {
+	cs := *dcs
+	cs.pushStates = nbs.NewPushStateStore(dir)
+	return &cs
+}
+
+// WithUploadPartSize returns a DoltChunkStore which uploads table files larger than |partSize| in parts of that size,
+// to remotes which support resumable uploads. A |partSize| of 0 uploads every table file in a single request.
+func (dcs *DoltChunkStore) WithUploadPartSize(partSize uint64) *DoltChunkStore {
+	cs := *dcs
+	cs.uploadPartSize = partSize
+	return &cs
 }
 
 func (dcs *DoltChunkStore) getRepoId() *remotesapi.RepoId {
@@ -204,6 +246,10 @@ func (dcs *DoltChunkStore) GetMany(ctx context.Context, hashes hash.HashSet, fou
 	return nil
 }
 
+func (dcs *DoltChunkStore) GetManyF(ctx context.Context, hashes hash.HashSet, found func(*chunks.Chunk)) error {
+	return chunks.GetManyFFromChannel(ctx, hashes, found, dcs.GetMany)
+}
+
 // GetMany gets the Chunks with |hashes| from the store. On return, |foundChunks| will have been fully sent all chunks
 // which have been found. Any non-present chunks will silently be ignored.
 func (dcs *DoltChunkStore) GetManyCompressed(ctx context.Context, hashes hash.HashSet, foundChunks chan<- nbs.CompressedChunk) error {
@@ -289,6 +335,7 @@ func (dcs *DoltChunkStore) getDLLocs(ctx context.Context, hashes []hash.Hash) (m
 			counter := events.NewCounter(eventsapi.MetricID_REMOTEAPI_RPC_ERROR)
 
 			req := remotesapi.GetDownloadLocsRequest{RepoId: dcs.getRepoId(), ChunkHashes: batch}
+			chunks.GetReadStats(ctx).RoundTrip()
 			resp, err := dcs.csClient.GetDownloadLocations(ctx, &req)
 
 			if err != nil {
@@ -388,6 +435,10 @@ const maxHasManyBatchSize = 16 * 1024
 // Returns a new HashSet containing any members of |hashes| that are
 // absent from the store.
 func (dcs *DoltChunkStore) HasMany(ctx context.Context, hashes hash.HashSet) (hash.HashSet, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// get the set of hashes that isn't already in the cache
 	notCached := dcs.cache.Has(hashes)
 
@@ -414,6 +465,7 @@ func (dcs *DoltChunkStore) HasMany(ctx context.Context, hashes hash.HashSet) (ha
 
 		// send a request to the remote api to determine which chunks the remote api already has
 		req := remotesapi.HasChunksRequest{RepoId: dcs.getRepoId(), Hashes: currByteSl}
+		chunks.GetReadStats(ctx).RoundTrip()
 		resp, err := dcs.csClient.HasChunks(ctx, &req)
 
 		if err != nil {
@@ -470,11 +522,34 @@ func (dcs *DoltChunkStore) HasMany(ctx context.Context, hashes hash.HashSet) (ha
 // to Flush(). Put may be called concurrently with other calls to Put(),
 // Get(), GetMany(), Has() and HasMany().
 func (dcs *DoltChunkStore) Put(ctx context.Context, c chunks.Chunk) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	cc := nbs.ChunkToCompressedChunk(c)
 	dcs.cache.Put([]nbs.CompressedChunk{cc})
 	return nil
 }
 
+// PutMany caches all of |chunks| with a single write to the cache of chunks waiting to be uploaded.
+func (dcs *DoltChunkStore) PutMany(ctx context.Context, chnks []chunks.Chunk) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	seen := make(hash.HashSet, len(chnks))
+	ccs := make([]nbs.CompressedChunk, 0, len(chnks))
+	for _, c := range chnks {
+		if !seen.Has(c.Hash()) {
+			seen.Insert(c.Hash())
+			ccs = append(ccs, nbs.ChunkToCompressedChunk(c))
+		}
+	}
+
+	dcs.cache.Put(ccs)
+	return nil
+}
+
 // Returns the NomsVersion with which this ChunkSource is compatible.
 func (dcs *DoltChunkStore) Version() string {
 	return dcs.metadata.NbfVersion
@@ -483,6 +558,10 @@ func (dcs *DoltChunkStore) Version() string {
 // Rebase brings this ChunkStore into sync with the persistent storage's
 // current root.
 func (dcs *DoltChunkStore) Rebase(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	evt := events.NewEvent(eventsapi.ClientEventType_REMOTEAPI_REBASE)
 	defer events.GlobalCollector.CloseEventAndAdd(evt)
 
@@ -650,7 +729,7 @@ func (dcs *DoltChunkStore) uploadChunks(ctx context.Context) (map[hash.Hash]int,
 		details := hashToDetails[h]
 		switch typedLoc := loc.Location.(type) {
 		case *remotesapi.UploadLoc_HttpPost:
-			err = dcs.httpPostUpload(ctx, loc.TableFileHash, typedLoc.HttpPost, bytes.NewBuffer(data), details.ContentHash)
+			err = dcs.uploadTableFile(ctx, h.String(), typedLoc.HttpPost, bytes.NewReader(data), details.ContentLength, details.ContentHash)
 		default:
 			break
 		}
@@ -846,6 +925,7 @@ func rangeDownloadWithRetries(ctx context.Context, fetcher HTTPFetcher, offset,
 		req.Header.Set("Range", rangeVal)
 
 		var resp *http.Response
+		chunks.GetReadStats(ctx).RoundTrip()
 		resp, err = fetcher.Do(req.WithContext(ctx))
 
 		if err == nil {
@@ -943,7 +1023,7 @@ func (dcs *DoltChunkStore) WriteTableFile(ctx context.Context, fileId string, nu
 	loc := resp.Locs[0]
 	switch typedLoc := loc.Location.(type) {
 	case *remotesapi.UploadLoc_HttpPost:
-		err = dcs.httpPostUpload(ctx, loc.TableFileHash, typedLoc.HttpPost, rd, contentHash)
+		err = dcs.uploadTableFile(ctx, fileId, typedLoc.HttpPost, rd, contentLength, contentHash)
 
 		if err != nil {
 			return err
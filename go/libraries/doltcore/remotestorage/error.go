@@ -18,6 +18,8 @@ import (
 	"encoding/json"
 
 	"google.golang.org/grpc/status"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/errcat"
 )
 
 type RpcError struct {
@@ -38,6 +40,15 @@ func (rpce *RpcError) Error() string {
 	return rpce.originalErrMsg
 }
 
+// Category implements errcat.Categorized. The category is decided by the status code of the rpc.
+func (rpce *RpcError) Category() errcat.Category {
+	if rpce.status == nil {
+		return errcat.Unknown
+	}
+
+	return errcat.ForCode(rpce.status.Code())
+}
+
 func (rpce *RpcError) FullDetails() string {
 	jsonStr, _ := GetJsonEncodedRequest(rpce)
 	return rpce.originalErrMsg + "\nhost:" + rpce.host + "\nrpc: " + rpce.rpc + "\nparams:" + jsonStr
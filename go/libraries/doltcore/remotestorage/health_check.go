@@ -0,0 +1,294 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotestorage
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	remotesapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/remotesapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/nbs"
+)
+
+// HealthCheckPhase is a step of a health check of a remote.
+type HealthCheckPhase string
+
+const (
+	// DNSPhase resolves the host of the remote
+	DNSPhase HealthCheckPhase = "dns"
+
+	// ConnectPhase opens a tcp connection to the host of the remote
+	ConnectPhase HealthCheckPhase = "connect"
+
+	// TLSPhase performs a tls handshake with the host of the remote
+	TLSPhase HealthCheckPhase = "tls"
+
+	// AuthenticationPhase makes a request identifying the client to the remote
+	AuthenticationPhase HealthCheckPhase = "authentication"
+
+	// AuthorizationPhase reads the root of the remote, which requires permission to read the repository
+	AuthorizationPhase HealthCheckPhase = "authorization"
+
+	// StorageAccessPhase reads data from the storage backing the remote
+	StorageAccessPhase HealthCheckPhase = "storage access"
+)
+
+const healthCheckDialTimeout = 10 * time.Second
+
+// HealthCheckPhaseResult is the result of a single phase of a health check.
+type HealthCheckPhaseResult struct {
+	Phase    HealthCheckPhase
+	Duration time.Duration
+
+	// Skipped is true if the phase doesn't apply to the remote, in which case Note says why
+	Skipped bool
+	Note    string
+
+	Err error
+}
+
+// HealthCheckError is the error of the first failed phase of a health check.
+type HealthCheckError struct {
+	Phase HealthCheckPhase
+	Err   error
+}
+
+func (hce *HealthCheckError) Error() string {
+	return fmt.Sprintf("%s check failed: %s", hce.Phase, hce.Err.Error())
+}
+
+func (hce *HealthCheckError) Unwrap() error {
+	return hce.Err
+}
+
+// HealthCheckResult holds the results of the phases of a health check in the order they were run. A health check stops
+// at the first phase which fails.
+type HealthCheckResult struct {
+	Phases []HealthCheckPhaseResult
+}
+
+// Err returns a *HealthCheckError for the phase which failed, or nil if no phase failed.
+func (res *HealthCheckResult) Err() error {
+	for _, p := range res.Phases {
+		if p.Err != nil {
+			return &HealthCheckError{p.Phase, p.Err}
+		}
+	}
+
+	return nil
+}
+
+// Run times |f| as the phase |phase| and adds its result. It returns false if |f| returned an error, in which case the
+// result is added as Fail would.
+func (res *HealthCheckResult) Run(phase HealthCheckPhase, f func() error) bool {
+	start := time.Now()
+	err := f()
+
+	if err != nil {
+		res.Fail(phase, time.Since(start), err)
+		return false
+	}
+
+	res.Phases = append(res.Phases, HealthCheckPhaseResult{Phase: phase, Duration: time.Since(start)})
+	return true
+}
+
+// Skip adds a result for a phase which doesn't apply.
+func (res *HealthCheckResult) Skip(phase HealthCheckPhase, note string) {
+	res.Phases = append(res.Phases, HealthCheckPhaseResult{Phase: phase, Skipped: true, Note: note})
+}
+
+// Fail adds a result for the phase |phase| which failed with |err|. If |err| is an rpc error whose status says the
+// client isn't authenticated or authorized the result is for the authentication or authorization phase instead.
+func (res *HealthCheckResult) Fail(phase HealthCheckPhase, d time.Duration, err error) {
+	res.Phases = append(res.Phases, HealthCheckPhaseResult{Phase: phaseForErr(phase, err), Duration: d, Err: err})
+}
+
+func phaseForErr(phase HealthCheckPhase, err error) HealthCheckPhase {
+	var rpcErr *RpcError
+	if errors.As(err, &rpcErr) && rpcErr.status != nil {
+		err = rpcErr.status.Err()
+	}
+
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case codes.Unauthenticated:
+			return AuthenticationPhase
+		case codes.PermissionDenied:
+			return AuthorizationPhase
+		}
+	}
+
+	return phase
+}
+
+// CheckHost runs the dns, connect and tls phases of a health check against |hostAndPort|. The tls phase is skipped
+// when |insecure| is true.
+func CheckHost(ctx context.Context, hostAndPort string, insecure bool) HealthCheckResult {
+	var res HealthCheckResult
+
+	host, _, err := net.SplitHostPort(hostAndPort)
+
+	if err != nil {
+		res.Fail(DNSPhase, 0, err)
+		return res
+	}
+
+	ok := res.Run(DNSPhase, func() error {
+		_, err := net.DefaultResolver.LookupHost(ctx, host)
+		return err
+	})
+
+	if !ok {
+		return res
+	}
+
+	dialer := &net.Dialer{Timeout: healthCheckDialTimeout}
+
+	var conn net.Conn
+	ok = res.Run(ConnectPhase, func() error {
+		conn, err = dialer.DialContext(ctx, "tcp", hostAndPort)
+		return err
+	})
+
+	if !ok {
+		return res
+	}
+
+	defer conn.Close()
+
+	if insecure {
+		res.Skip(TLSPhase, "the remote doesn't use tls")
+		return res
+	}
+
+	res.Run(TLSPhase, func() error {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: host})
+		_ = tlsConn.SetDeadline(time.Now().Add(healthCheckDialTimeout))
+		return tlsConn.Handshake()
+	})
+
+	return res
+}
+
+// HealthCheck checks that the remote can be read from. It makes an authenticated request for the metadata of the
+// repository, reads its root, and then reads the root chunk from the storage location the remote sends, so that a
+// problem can be found before starting a long running operation. The results are added to |res|.
+func (dcs *DoltChunkStore) HealthCheck(ctx context.Context, res *HealthCheckResult) {
+	ok := res.Run(AuthenticationPhase, func() error {
+		req := &remotesapi.GetRepoMetadataRequest{
+			RepoId: dcs.getRepoId(),
+			ClientRepoFormat: &remotesapi.ClientRepoFormat{
+				NbfVersion: dcs.nbf.VersionString(),
+				NbsVersion: nbs.StorageVersion,
+			},
+		}
+
+		_, err := dcs.csClient.GetRepoMetadata(ctx, req)
+
+		if err != nil {
+			return NewRpcError(err, "GetRepoMetadata", dcs.host, req)
+		}
+
+		return nil
+	})
+
+	if !ok {
+		return
+	}
+
+	var root hash.Hash
+	ok = res.Run(AuthorizationPhase, func() error {
+		var err error
+		root, err = dcs.Root(ctx)
+		return err
+	})
+
+	if !ok {
+		return
+	}
+
+	if root.IsEmpty() {
+		res.Skip(StorageAccessPhase, "the remote is empty")
+		return
+	}
+
+	res.Run(StorageAccessPhase, func() error {
+		return dcs.readFirstRange(ctx, root)
+	})
+}
+
+// readFirstRange gets the download location of the chunk |h| and reads it with a single range request.
+func (dcs *DoltChunkStore) readFirstRange(ctx context.Context, h hash.Hash) error {
+	req := &remotesapi.GetDownloadLocsRequest{RepoId: dcs.getRepoId(), ChunkHashes: HashesToSlices([]hash.Hash{h})}
+	resp, err := dcs.csClient.GetDownloadLocations(ctx, req)
+
+	if err != nil {
+		return NewRpcError(err, "GetDownloadLocations", dcs.host, req)
+	}
+
+	for _, loc := range resp.Locs {
+		getRange, ok := loc.Location.(*remotesapi.DownloadLoc_HttpGetRange)
+
+		if !ok || len(getRange.HttpGetRange.Ranges) == 0 {
+			continue
+		}
+
+		r := getRange.HttpGetRange.Ranges[0]
+		httpReq, err := http.NewRequest(http.MethodGet, getRange.HttpGetRange.Url, nil)
+
+		if err != nil {
+			return err
+		}
+
+		httpReq.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", r.Offset, r.Offset+uint64(r.Length)-1))
+		httpResp, err := dcs.httpFetcher.Do(httpReq.WithContext(ctx))
+
+		if err != nil {
+			return err
+		}
+
+		defer httpResp.Body.Close()
+
+		if httpResp.StatusCode/100 != 2 {
+			return fmt.Errorf("http %d reading %s", httpResp.StatusCode, getRange.HttpGetRange.Url)
+		}
+
+		n, err := io.Copy(ioutil.Discard, httpResp.Body)
+
+		if err != nil {
+			return err
+		}
+
+		if n != int64(r.Length) {
+			return fmt.Errorf("expected %d bytes reading %s, got %d", r.Length, getRange.HttpGetRange.Url, n)
+		}
+
+		return nil
+	}
+
+	return errors.New("the remote didn't return a download location for its root")
+}
@@ -0,0 +1,187 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotestorage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	remotesapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/remotesapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+type healthCheckClient struct {
+	remotesapi.ChunkStoreServiceClient
+	metadataErr error
+	rootErr     error
+	root        hash.Hash
+}
+
+func (c healthCheckClient) GetRepoMetadata(ctx context.Context, in *remotesapi.GetRepoMetadataRequest, opts ...grpc.CallOption) (*remotesapi.GetRepoMetadataResponse, error) {
+	if c.metadataErr != nil {
+		return nil, c.metadataErr
+	}
+
+	return &remotesapi.GetRepoMetadataResponse{}, nil
+}
+
+func (c healthCheckClient) Root(ctx context.Context, in *remotesapi.RootRequest, opts ...grpc.CallOption) (*remotesapi.RootResponse, error) {
+	if c.rootErr != nil {
+		return nil, c.rootErr
+	}
+
+	return &remotesapi.RootResponse{RootHash: c.root[:]}, nil
+}
+
+func (c healthCheckClient) GetDownloadLocations(ctx context.Context, in *remotesapi.GetDownloadLocsRequest, opts ...grpc.CallOption) (*remotesapi.GetDownloadLocsResponse, error) {
+	loc := &remotesapi.DownloadLoc{Location: &remotesapi.DownloadLoc_HttpGetRange{HttpGetRange: &remotesapi.HttpGetRange{
+		Url:    "http://storage/tablefile",
+		Ranges: []*remotesapi.RangeChunk{{Hash: in.ChunkHashes[0], Offset: 10, Length: 4}},
+	}}}
+
+	return &remotesapi.GetDownloadLocsResponse{Locs: []*remotesapi.DownloadLoc{loc}}, nil
+}
+
+type healthCheckFetcher struct {
+	statusCode int
+	rangeHdr   *string
+}
+
+func (f healthCheckFetcher) Do(req *http.Request) (*http.Response, error) {
+	*f.rangeHdr = req.Header.Get("Range")
+	return &http.Response{StatusCode: f.statusCode, Body: ioutil.NopCloser(bytes.NewReader([]byte("abcd")))}, nil
+}
+
+func TestDoltChunkStoreHealthCheck(t *testing.T) {
+	root := hash.Of([]byte("root"))
+
+	tests := []struct {
+		name          string
+		client        healthCheckClient
+		statusCode    int
+		expected      []HealthCheckPhase
+		expectedSkip  HealthCheckPhase
+		expectedError HealthCheckPhase
+	}{
+		{
+			name:       "healthy",
+			client:     healthCheckClient{root: root},
+			statusCode: http.StatusPartialContent,
+			expected:   []HealthCheckPhase{AuthenticationPhase, AuthorizationPhase, StorageAccessPhase},
+		},
+		{
+			name:         "empty",
+			client:       healthCheckClient{},
+			expected:     []HealthCheckPhase{AuthenticationPhase, AuthorizationPhase, StorageAccessPhase},
+			expectedSkip: StorageAccessPhase,
+		},
+		{
+			name:          "unauthenticated",
+			client:        healthCheckClient{metadataErr: status.Error(codes.Unauthenticated, "bad creds")},
+			expected:      []HealthCheckPhase{AuthenticationPhase},
+			expectedError: AuthenticationPhase,
+		},
+		{
+			name:          "permission denied reading metadata",
+			client:        healthCheckClient{metadataErr: status.Error(codes.PermissionDenied, "private")},
+			expected:      []HealthCheckPhase{AuthorizationPhase},
+			expectedError: AuthorizationPhase,
+		},
+		{
+			name:          "permission denied reading root",
+			client:        healthCheckClient{rootErr: status.Error(codes.PermissionDenied, "private")},
+			expected:      []HealthCheckPhase{AuthenticationPhase, AuthorizationPhase},
+			expectedError: AuthorizationPhase,
+		},
+		{
+			name:          "storage forbidden",
+			client:        healthCheckClient{root: root},
+			statusCode:    http.StatusForbidden,
+			expected:      []HealthCheckPhase{AuthenticationPhase, AuthorizationPhase, StorageAccessPhase},
+			expectedError: StorageAccessPhase,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var rangeHdr string
+			dcs := &DoltChunkStore{
+				org:         "org",
+				repoName:    "repo",
+				host:        "host",
+				csClient:    test.client,
+				cache:       noopChunkCache,
+				nbf:         types.Format_Default,
+				httpFetcher: healthCheckFetcher{test.statusCode, &rangeHdr},
+			}
+
+			var res HealthCheckResult
+			dcs.HealthCheck(context.Background(), &res)
+
+			var phases []HealthCheckPhase
+			for _, p := range res.Phases {
+				phases = append(phases, p.Phase)
+				assert.Equal(t, p.Phase == test.expectedSkip, p.Skipped)
+			}
+
+			assert.Equal(t, test.expected, phases)
+
+			err := res.Err()
+			if test.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				var hce *HealthCheckError
+				require.True(t, errors.As(err, &hce))
+				assert.Equal(t, test.expectedError, hce.Phase)
+			}
+
+			if test.client.root == root && test.statusCode != 0 {
+				assert.Equal(t, "bytes=10-13", rangeHdr)
+			}
+		})
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	res := CheckHost(context.Background(), l.Addr().String(), true)
+	require.NoError(t, res.Err())
+	require.Len(t, res.Phases, 3)
+	assert.Equal(t, DNSPhase, res.Phases[0].Phase)
+	assert.Equal(t, ConnectPhase, res.Phases[1].Phase)
+	assert.Equal(t, TLSPhase, res.Phases[2].Phase)
+	assert.True(t, res.Phases[2].Skipped)
+
+	require.NoError(t, l.Close())
+	res = CheckHost(context.Background(), l.Addr().String(), true)
+
+	var hce *HealthCheckError
+	require.True(t, errors.As(res.Err(), &hce))
+	assert.Equal(t, ConnectPhase, hce.Phase)
+}
@@ -0,0 +1,228 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotestorage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/cenkalti/backoff"
+
+	remotesapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/remotesapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/nbs"
+)
+
+// A remote which supports resumable uploads answers a HEAD request for the upload url of a table file with the
+// number of bytes of the file it has received, in the Upload-Offset header. Each part of the file is then PUT to the
+// url with a Content-Range header giving its place in the file, and a Content-MD5 header the remote checks it against.
+// A part may start anywhere up to the remote's offset, and the remote drops anything it has received past the start
+// of the part. The remote answers each part with its new offset, and answers the last part with the MD5 of the whole
+// file it has received, in the Upload-Content-MD5 header, which the HEAD request also reports once the file is
+// complete.
+const (
+	UploadOffsetHeader     = "Upload-Offset"
+	UploadContentMD5Header = "Upload-Content-MD5"
+
+	defaultUploadPartSize = 64 * 1024 * 1024
+)
+
+// uploadTableFile uploads a table file to the url of |post|. Table files larger than the upload part size are uploaded
+// in parts if the remote supports resumable uploads, and otherwise in a single request.
+func (dcs *DoltChunkStore) uploadTableFile(ctx context.Context, fileId string, post *remotesapi.HttpPostTableFile, rd io.Reader, contentLength uint64, contentHash []byte) error {
+	if ra, ok := rd.(io.ReaderAt); ok && dcs.uploadPartSize > 0 && contentLength > dcs.uploadPartSize {
+		uploaded, err := dcs.resumableUpload(ctx, fileId, post.Url, ra, contentLength, contentHash)
+
+		if err != nil || uploaded {
+			return err
+		}
+	}
+
+	fileIdBytes := hash.Parse(fileId)
+	return dcs.httpPostUpload(ctx, fileIdBytes[:], post, rd, contentHash)
+}
+
+// resumableUpload uploads a table file in parts, which are recorded in the push state of the table file as the remote
+// acknowledges them, so that a later upload of the table file only uploads the parts which weren't acknowledged. The
+// MD5 the remote reports for the file it received is checked against |contentHash| before the upload succeeds. It
+// returns false, having uploaded nothing, if the remote doesn't support resumable uploads.
+func (dcs *DoltChunkStore) resumableUpload(ctx context.Context, fileId, url string, rd io.ReaderAt, contentLength uint64, contentHash []byte) (bool, error) {
+	offset, reportedHash, ok, err := dcs.uploadOffset(ctx, url)
+
+	if err != nil || !ok {
+		return false, err
+	}
+
+	state, err := dcs.pushStates.Load(fileId, contentLength, contentHash, dcs.uploadPartSize)
+
+	if err != nil {
+		return true, err
+	}
+
+	// the remote may have dropped parts which it acknowledged
+	if err := state.Truncate(offset); err != nil {
+		return true, err
+	}
+
+	for _, part := range uploadParts(contentLength, dcs.uploadPartSize) {
+		if _, ok := state.Part(part.Number); ok {
+			continue
+		}
+
+		data := make([]byte, part.Length)
+		if _, err := rd.ReadAt(data, int64(part.Offset)); err != nil && err != io.EOF {
+			return true, err
+		}
+
+		partMD5 := md5.Sum(data)
+		part.MD5 = partMD5[:]
+
+		partHash, err := dcs.uploadPart(ctx, url, part, data, contentLength)
+
+		if err != nil {
+			return true, err
+		} else if partHash != nil {
+			reportedHash = partHash
+		}
+
+		if err := state.Acknowledge(part); err != nil {
+			return true, err
+		}
+	}
+
+	// an upload which doesn't match can't be resumed, so it's started over by the next push
+	removeErr := state.Remove()
+
+	if !bytes.Equal(reportedHash, contentHash) {
+		return true, fmt.Errorf("%w: the remote reported the MD5 %x for %s, which should be %x", nbs.ErrUploadHashMismatch, reportedHash, fileId, contentHash)
+	}
+
+	return true, removeErr
+}
+
+// uploadParts returns the parts a table file of |contentLength| bytes is uploaded in.
+func uploadParts(contentLength, partSize uint64) []nbs.UploadedPart {
+	var parts []nbs.UploadedPart
+	for offset := uint64(0); offset < contentLength; offset += partSize {
+		length := partSize
+		if offset+length > contentLength {
+			length = contentLength - offset
+		}
+
+		parts = append(parts, nbs.UploadedPart{Number: len(parts) + 1, Offset: offset, Length: length})
+	}
+
+	return parts
+}
+
+// uploadOffset asks the remote how many bytes of the table file being uploaded to |url| it has received, and the MD5
+// of the file if it's complete. It returns false if the remote doesn't support resumable uploads.
+func (dcs *DoltChunkStore) uploadOffset(ctx context.Context, url string) (offset uint64, reportedHash []byte, ok bool, err error) {
+	req, err := http.NewRequest(http.MethodHead, url, nil)
+
+	if err != nil {
+		return 0, nil, false, err
+	}
+
+	var resp *http.Response
+	op := func() error {
+		var err error
+		resp, err = dcs.httpFetcher.Do(req.WithContext(ctx))
+
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+
+		return processHttpResp(resp, err)
+	}
+
+	err = backoff.Retry(op, backoff.WithMaxRetries(uploadRetryParams, uploadRetryCount))
+
+	if err != nil {
+		if resp != nil {
+			// remotes which don't support resumable uploads may refuse the request outright
+			return 0, nil, false, nil
+		}
+
+		return 0, nil, false, err
+	}
+
+	offset, ok = parseUploadOffset(resp)
+	return offset, parseUploadContentMD5(resp), ok, nil
+}
+
+// uploadPart uploads a part of the table file being uploaded to |url|, retrying it if the upload fails. It returns the
+// MD5 the remote reports for the whole file, once the last part is uploaded.
+func (dcs *DoltChunkStore) uploadPart(ctx context.Context, url string, part nbs.UploadedPart, data []byte, contentLength uint64) ([]byte, error) {
+	var reportedHash []byte
+	op := func() error {
+		req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+
+		if err != nil {
+			return backoff.Permanent(err)
+		}
+
+		req.ContentLength = int64(len(data))
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", part.Offset, part.Offset+part.Length-1, contentLength))
+		req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(part.MD5))
+
+		resp, err := dcs.httpFetcher.Do(req.WithContext(ctx))
+
+		if err == nil {
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+		}
+
+		if err := processHttpResp(resp, err); err != nil {
+			return err
+		}
+
+		end := part.Offset + part.Length
+		if offset, ok := parseUploadOffset(resp); !ok || offset != end {
+			return backoff.Permanent(fmt.Errorf("%w: the remote didn't acknowledge the part of the table file ending at byte %d", ErrUploadFailed, end))
+		}
+
+		if end == contentLength {
+			reportedHash = parseUploadContentMD5(resp)
+		}
+
+		return nil
+	}
+
+	err := backoff.Retry(op, backoff.WithMaxRetries(uploadRetryParams, uploadRetryCount))
+	return reportedHash, err
+}
+
+func parseUploadOffset(resp *http.Response) (uint64, bool) {
+	offset, err := strconv.ParseUint(resp.Header.Get(UploadOffsetHeader), 10, 64)
+	return offset, err == nil
+}
+
+func parseUploadContentMD5(resp *http.Response) []byte {
+	md5Bytes, err := base64.StdEncoding.DecodeString(resp.Header.Get(UploadContentMD5Header))
+
+	if err != nil {
+		return nil
+	}
+
+	return md5Bytes
+}
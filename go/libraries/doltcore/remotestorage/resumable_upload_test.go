@@ -0,0 +1,254 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotestorage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	remotesapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/remotesapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/nbs"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// uploadServer receives a table file uploaded in parts, killing the connections of uploads of parts at random offsets.
+type uploadServer struct {
+	mu        sync.Mutex
+	rand      *rand.Rand
+	dropRate  float64
+	rejectAt  int64
+	resumable bool
+	badMD5    bool
+
+	data     []byte
+	complete bool
+	attempts int
+	// uploads counts the uploads of the part at each offset which were received
+	uploads map[uint64]int
+}
+
+func (s *uploadServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.resumable {
+		if req.Method != http.MethodPut || req.Header.Get("Content-Range") != "" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		s.data, _ = ioutil.ReadAll(req.Body)
+		s.uploads[0]++
+		return
+	}
+
+	if req.Method == http.MethodHead {
+		w.Header().Set(UploadOffsetHeader, strconv.Itoa(len(s.data)))
+		if s.complete {
+			w.Header().Set(UploadContentMD5Header, s.md5())
+		}
+		return
+	}
+
+	s.attempts++
+	var start, end, total uint64
+	_, err := fmt.Sscanf(req.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &total)
+	if err != nil || start > uint64(len(s.data)) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if s.rand.Float64() < s.dropRate {
+		_, _ = io.CopyN(ioutil.Discard, req.Body, s.rand.Int63n(int64(end-start+1)))
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		_ = conn.Close()
+		return
+	}
+
+	if int64(start) == s.rejectAt {
+		s.rejectAt = -1
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	part, err := ioutil.ReadAll(req.Body)
+	partMD5 := md5.Sum(part)
+	if err != nil || req.Header.Get("Content-MD5") != base64.StdEncoding.EncodeToString(partMD5[:]) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.data = append(s.data[:start], part...)
+	s.uploads[start]++
+	s.complete = end+1 == total
+	w.Header().Set(UploadOffsetHeader, strconv.Itoa(len(s.data)))
+	if s.complete {
+		w.Header().Set(UploadContentMD5Header, s.md5())
+	}
+}
+
+func (s *uploadServer) md5() string {
+	sum := md5.Sum(s.data)
+	if s.badMD5 {
+		sum = md5.Sum(nil)
+	}
+
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+type uploadClient struct {
+	remotesapi.ChunkStoreServiceClient
+	url        string
+	tableFiles int
+}
+
+func (c *uploadClient) GetUploadLocations(ctx context.Context, in *remotesapi.GetUploadLocsRequest, opts ...grpc.CallOption) (*remotesapi.GetUploadLocsResponse, error) {
+	loc := &remotesapi.UploadLoc{TableFileHash: in.TableFileDetails[0].Id, Location: &remotesapi.UploadLoc_HttpPost{HttpPost: &remotesapi.HttpPostTableFile{Url: c.url}}}
+	return &remotesapi.GetUploadLocsResponse{Locs: []*remotesapi.UploadLoc{loc}}, nil
+}
+
+func (c *uploadClient) AddTableFiles(ctx context.Context, in *remotesapi.AddTableFilesRequest, opts ...grpc.CallOption) (*remotesapi.AddTableFilesResponse, error) {
+	c.tableFiles++
+	return &remotesapi.AddTableFilesResponse{Success: true}, nil
+}
+
+func TestResumableUpload(t *testing.T) {
+	initialInterval := uploadRetryParams.InitialInterval
+	uploadRetryParams.InitialInterval = time.Millisecond
+	defer func() {
+		uploadRetryParams.InitialInterval = initialInterval
+	}()
+
+	const partSize = 1024
+	data := make([]byte, 64*partSize+partSize/2)
+	rand.New(rand.NewSource(0)).Read(data)
+	contentHash := md5.Sum(data)
+	fileId := hash.Of(data).String()
+	numParts := len(data)/partSize + 1
+
+	setup := func(t *testing.T, server *uploadServer) (func() *DoltChunkStore, *uploadClient, func()) {
+		dir, err := ioutil.TempDir("", "push_state")
+		require.NoError(t, err)
+
+		server.uploads = map[uint64]int{}
+		httpServer := httptest.NewServer(server)
+		client := &uploadClient{url: httpServer.URL + "/org/repo/" + fileId}
+
+		newStore := func() *DoltChunkStore {
+			dcs := &DoltChunkStore{org: "org", repoName: "repo", csClient: client, cache: newMapChunkCache(), nbf: types.Format_Default, httpFetcher: &http.Client{}}
+			return dcs.WithPushStateDir(dir).WithUploadPartSize(partSize)
+		}
+
+		return newStore, client, func() {
+			httpServer.Close()
+			_ = os.RemoveAll(dir)
+		}
+	}
+
+	write := func(dcs *DoltChunkStore) error {
+		return dcs.WriteTableFile(context.Background(), fileId, 1, bytes.NewReader(data), uint64(len(data)), contentHash[:])
+	}
+
+	t.Run("DroppedConnections", func(t *testing.T) {
+		server := &uploadServer{rand: rand.New(rand.NewSource(1)), dropRate: 0.2, rejectAt: -1, resumable: true}
+		newStore, client, cleanup := setup(t, server)
+		defer cleanup()
+
+		var err error
+		pushes := 0
+		for err = errors.New("not pushed"); err != nil && pushes < 10; pushes++ {
+			err = write(newStore())
+		}
+
+		require.NoError(t, err)
+		assert.Equal(t, data, server.data)
+		assert.Equal(t, 1, client.tableFiles)
+
+		// the connections were killed, but no part was uploaded twice, and the retries were bounded
+		assert.Len(t, server.uploads, numParts)
+		for offset, count := range server.uploads {
+			assert.Equal(t, 1, count, "the part at %d was uploaded %d times", offset, count)
+		}
+		assert.True(t, server.attempts > numParts)
+		assert.True(t, server.attempts < 2*numParts)
+	})
+
+	t.Run("ResumedByNextPush", func(t *testing.T) {
+		server := &uploadServer{rand: rand.New(rand.NewSource(1)), rejectAt: 10 * partSize, resumable: true}
+		newStore, client, cleanup := setup(t, server)
+		defer cleanup()
+
+		require.Error(t, write(newStore()))
+		assert.Equal(t, 0, client.tableFiles)
+		assert.Len(t, server.uploads, 10)
+
+		require.NoError(t, write(newStore()))
+		assert.Equal(t, data, server.data)
+		assert.Equal(t, 1, client.tableFiles)
+		for offset, count := range server.uploads {
+			assert.Equal(t, 1, count, "the part at %d was uploaded %d times", offset, count)
+		}
+	})
+
+	t.Run("HashMismatch", func(t *testing.T) {
+		server := &uploadServer{rand: rand.New(rand.NewSource(1)), rejectAt: -1, resumable: true, badMD5: true}
+		newStore, client, cleanup := setup(t, server)
+		defer cleanup()
+
+		err := write(newStore())
+		assert.True(t, errors.Is(err, nbs.ErrUploadHashMismatch), "unexpected error %v", err)
+		assert.Equal(t, 0, client.tableFiles)
+	})
+
+	t.Run("NotResumable", func(t *testing.T) {
+		server := &uploadServer{rejectAt: -1}
+		newStore, client, cleanup := setup(t, server)
+		defer cleanup()
+
+		require.NoError(t, write(newStore()))
+		assert.Equal(t, data, server.data)
+		assert.Equal(t, map[uint64]int{0: 1}, server.uploads)
+		assert.Equal(t, 1, client.tableFiles)
+	})
+}
+
+func TestUploadParts(t *testing.T) {
+	assert.Empty(t, uploadParts(0, 10))
+	assert.Equal(t, []nbs.UploadedPart{{Number: 1, Offset: 0, Length: 10}}, uploadParts(10, 10))
+	assert.Equal(t, []nbs.UploadedPart{
+		{Number: 1, Offset: 0, Length: 10},
+		{Number: 2, Offset: 10, Length: 10},
+		{Number: 3, Offset: 20, Length: 5},
+	}, uploadParts(25, 10))
+}
@@ -0,0 +1,124 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotestream
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+
+	remotesapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/remotesapi/v1alpha1"
+)
+
+// Client connects to a Server over a stream. It's a remotestorage.HTTPFetcher which sends requests over the stream, and
+// gives out a chunk store service client which calls the server over it.
+type Client struct {
+	conn     net.Conn
+	h2Conn   *http2.ClientConn
+	grpcConn *grpc.ClientConn
+}
+
+// NewClient returns a Client which talks to the Server at the other end of |conn|. Closing the Client closes |conn|.
+func NewClient(ctx context.Context, conn net.Conn) (*Client, error) {
+	h2Conn, err := (&http2.Transport{AllowHTTP: true}).NewClientConn(conn)
+
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	// the dial blocks until the tunnel is open, so that a server which fails to start is reported here rather than
+	// retried by each call
+	c := &Client{conn: conn, h2Conn: h2Conn}
+	c.grpcConn, err = grpc.DialContext(ctx, streamHost,
+		grpc.WithInsecure(),
+		grpc.WithBlock(),
+		grpc.FailOnNonTempDialError(true),
+		grpc.WithContextDialer(c.dialTunnel),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxMsgSize)))
+
+	if err != nil {
+		_ = h2Conn.Close()
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// tunnelErr is the error of a failed tunnel request. The stream doesn't recover once a request fails, so it isn't
+// temporary.
+type tunnelErr struct {
+	err error
+}
+
+func (te tunnelErr) Error() string {
+	return "failed to open a tunnel to the remote: " + te.err.Error()
+}
+
+func (te tunnelErr) Temporary() bool {
+	return false
+}
+
+// dialTunnel starts a tunnel request, and returns a connection which writes to its body and reads from its response.
+func (c *Client) dialTunnel(ctx context.Context, addr string) (net.Conn, error) {
+	pr, pw := io.Pipe()
+	req, err := http.NewRequest(http.MethodPost, "http://"+streamHost+tunnelPath, pr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the request lasts as long as the connection, so it isn't bound to |ctx|, which only covers dialing
+	resp, err := c.h2Conn.RoundTrip(req)
+
+	if err != nil {
+		_ = pw.Close()
+		return nil, tunnelErr{err}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		_ = pw.Close()
+		_ = resp.Body.Close()
+		return nil, tunnelErr{fmt.Errorf("http %d", resp.StatusCode)}
+	}
+
+	return NewConn(resp.Body, pw, func() error {
+		_ = pw.Close()
+		return resp.Body.Close()
+	}), nil
+}
+
+// ChunkStoreClient returns a client of the chunk store service of the Server.
+func (c *Client) ChunkStoreClient() remotesapi.ChunkStoreServiceClient {
+	return remotesapi.NewChunkStoreServiceClient(c.grpcConn)
+}
+
+// Do sends |req| to the Server over the stream, whatever the host of its url.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	return c.h2Conn.RoundTrip(req)
+}
+
+// Close ends all requests, and closes the stream.
+func (c *Client) Close() error {
+	_ = c.grpcConn.Close()
+	_ = c.h2Conn.Close()
+	return c.conn.Close()
+}
@@ -0,0 +1,125 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package remotestream serves and connects to a dolt remote over a single byte stream, such as the stdin and stdout of
+// `dolt remote-serve --stdio` run over ssh.
+//
+// The stream carries HTTP/2. The chunk store service is served with gRPC over a tunnel request, and table files are
+// read and written with their own HTTP requests, so the stream is used the same way as a dolt remote server's gRPC and
+// HTTP endpoints, and remotestorage.DoltChunkStore works over it unchanged.
+package remotestream
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+var errListenerClosed = errors.New("listener closed")
+
+type streamAddr struct{}
+
+func (streamAddr) Network() string {
+	return "stream"
+}
+
+func (streamAddr) String() string {
+	return "stream"
+}
+
+type streamConn struct {
+	io.Reader
+	io.Writer
+
+	closeOnce sync.Once
+	closer    func() error
+	closeErr  error
+}
+
+// NewConn returns a net.Conn which reads from |rd| and writes to |wr|. |closer| is called the first time the
+// connection is closed, and may be nil. Deadlines aren't supported, and setting them does nothing.
+func NewConn(rd io.Reader, wr io.Writer, closer func() error) net.Conn {
+	return &streamConn{Reader: rd, Writer: wr, closer: closer}
+}
+
+func (c *streamConn) Close() error {
+	c.closeOnce.Do(func() {
+		if c.closer != nil {
+			c.closeErr = c.closer()
+		}
+	})
+
+	return c.closeErr
+}
+
+func (c *streamConn) LocalAddr() net.Addr {
+	return streamAddr{}
+}
+
+func (c *streamConn) RemoteAddr() net.Addr {
+	return streamAddr{}
+}
+
+func (c *streamConn) SetDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *streamConn) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *streamConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
+
+// connListener is a net.Listener which accepts the connections pushed to it.
+type connListener struct {
+	conns     chan net.Conn
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newConnListener() *connListener {
+	return &connListener{conns: make(chan net.Conn), done: make(chan struct{})}
+}
+
+// push waits for |conn| to be accepted, and returns false if the listener is closed first.
+func (l *connListener) push(conn net.Conn) bool {
+	select {
+	case l.conns <- conn:
+		return true
+	case <-l.done:
+		return false
+	}
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, errListenerClosed
+	}
+}
+
+func (l *connListener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return streamAddr{}
+}
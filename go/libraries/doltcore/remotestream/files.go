@@ -0,0 +1,147 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotestream
+
+import (
+	"bytes"
+	"crypto/md5"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	remotesapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/remotesapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+const (
+	// streamHost is the host of the urls requested over the stream. Requests are always sent over the stream, so it's
+	// never resolved.
+	streamHost = "dolt-remote"
+	tunnelPath = "/grpc"
+	filesPath  = "/files/"
+)
+
+// tableFiles serves the table files of a store's directory. A GET reads a file, or the bytes of its Range header, and
+// a PUT writes one of the files the client has been given an upload location for.
+type tableFiles struct {
+	dir string
+
+	mu       sync.Mutex
+	expected map[string]*remotesapi.TableFileDetails
+}
+
+func newTableFiles(dir string) *tableFiles {
+	return &tableFiles{dir: dir, expected: make(map[string]*remotesapi.TableFileDetails)}
+}
+
+func (tf *tableFiles) url(fileId string) string {
+	return "http://" + streamHost + filesPath + fileId
+}
+
+func (tf *tableFiles) expect(fileId string, tfd *remotesapi.TableFileDetails) {
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+
+	tf.expected[fileId] = tfd
+}
+
+func (tf *tableFiles) exists(fileId string) bool {
+	info, err := os.Stat(filepath.Join(tf.dir, fileId))
+	return err == nil && !info.IsDir()
+}
+
+func (tf *tableFiles) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	fileId := strings.TrimPrefix(req.URL.Path, filesPath)
+
+	if _, ok := hash.MaybeParse(fileId); !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		tf.read(w, req, fileId)
+	case http.MethodPost, http.MethodPut:
+		w.WriteHeader(tf.write(req.Body, fileId))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (tf *tableFiles) read(w http.ResponseWriter, req *http.Request, fileId string) {
+	f, err := os.Open(filepath.Join(tf.dir, fileId))
+
+	if os.IsNotExist(err) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	defer f.Close()
+
+	// ServeContent handles the Range header
+	http.ServeContent(w, req, fileId, time.Time{}, f)
+}
+
+// write writes the body of an upload to a temporary file, which is renamed to the table file once its length and
+// content hash have been checked. It returns the status of the response.
+func (tf *tableFiles) write(body io.Reader, fileId string) int {
+	tf.mu.Lock()
+	tfd, ok := tf.expected[fileId]
+	tf.mu.Unlock()
+
+	if !ok {
+		return http.StatusBadRequest
+	}
+
+	tmp, err := ioutil.TempFile(tf.dir, fileId+"-*.tmp")
+
+	if err != nil {
+		return http.StatusInternalServerError
+	}
+
+	defer os.Remove(tmp.Name())
+
+	md5Hash := md5.New()
+	n, err := io.Copy(io.MultiWriter(tmp, md5Hash), body)
+	closeErr := tmp.Close()
+
+	if err != nil || closeErr != nil {
+		return http.StatusInternalServerError
+	}
+
+	if tfd.ContentLength != 0 && tfd.ContentLength != uint64(n) {
+		return http.StatusBadRequest
+	} else if len(tfd.ContentHash) > 0 && !bytes.Equal(tfd.ContentHash, md5Hash.Sum(nil)) {
+		return http.StatusBadRequest
+	}
+
+	if err := os.Rename(tmp.Name(), filepath.Join(tf.dir, fileId)); err != nil {
+		return http.StatusInternalServerError
+	}
+
+	tf.mu.Lock()
+	delete(tf.expected, fileId)
+	tf.mu.Unlock()
+
+	return http.StatusOK
+}
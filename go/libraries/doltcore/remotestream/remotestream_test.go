@@ -0,0 +1,108 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotestream
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/remotestorage"
+	"github.com/liquidata-inc/dolt/go/store/datas"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// connect serves |dir| over a pipe, and returns a database which is a client of it, and a func which closes the client
+// and waits for the server to exit.
+func connect(t *testing.T, ctx context.Context, dir string) (datas.Database, func()) {
+	clientConn, serverConn := net.Pipe()
+
+	served := make(chan error)
+	go func() {
+		served <- NewServer(dir).Serve(ctx, serverConn)
+	}()
+
+	client, err := NewClient(ctx, clientConn)
+	require.NoError(t, err)
+
+	cs, err := remotestorage.NewDoltChunkStore(ctx, types.Format_Default, "org", "repo", streamHost, client.ChunkStoreClient())
+	require.NoError(t, err)
+
+	return datas.NewDatabase(cs.WithHTTPFetcher(client)), func() {
+		require.NoError(t, client.Close())
+		require.NoError(t, <-served)
+	}
+}
+
+func TestServeOverStream(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "remotestream")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	db, closeDB := connect(t, ctx, dir)
+	ds, err := db.GetDataset(ctx, "ds")
+	require.NoError(t, err)
+	_, err = db.CommitValue(ctx, ds, types.String("written over the stream"))
+	require.NoError(t, err)
+	closeDB()
+
+	db, closeDB = connect(t, ctx, dir)
+	defer closeDB()
+
+	ds, err = db.GetDataset(ctx, "ds")
+	require.NoError(t, err)
+	val, ok, err := ds.MaybeHeadValue()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, types.String("written over the stream"), val)
+}
+
+func TestTableFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remotestream")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	clientConn, serverConn := net.Pipe()
+	go func() {
+		_ = NewServer(dir).Serve(context.Background(), serverConn)
+	}()
+
+	client, err := NewClient(context.Background(), clientConn)
+	require.NoError(t, err)
+	defer client.Close()
+
+	const fileId = "0123456789abcdefghijklmnopqrstuv"
+	statusOf := func(method, path string) int {
+		req, err := http.NewRequest(method, "http://"+streamHost+path, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusNotFound, statusOf(http.MethodGet, filesPath+fileId))
+	assert.Equal(t, http.StatusNotFound, statusOf(http.MethodGet, filesPath+"not_a_hash"))
+	// uploads are refused without an upload location
+	assert.Equal(t, http.StatusBadRequest, statusOf(http.MethodPut, filesPath+fileId))
+	assert.Equal(t, http.StatusMethodNotAllowed, statusOf(http.MethodGet, tunnelPath))
+}
@@ -0,0 +1,148 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotestream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+
+	remotesapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/remotesapi/v1alpha1"
+)
+
+const maxMsgSize = 128 * 1024 * 1024
+
+var errTunnelClosed = errors.New("tunnel closed")
+
+// Server serves the noms block store in a directory over a stream. The store is created when a client first connects
+// if the directory doesn't hold one.
+type Server struct {
+	files *tableFiles
+	css   *chunkStoreService
+}
+
+// NewServer returns a Server for the noms block store in |dir|.
+func NewServer(dir string) *Server {
+	files := newTableFiles(dir)
+	return &Server{files, newChunkStoreService(dir, files)}
+}
+
+// Serve serves the store over |conn| until the client closes it or |ctx| is canceled, and then closes the store.
+func (s *Server) Serve(ctx context.Context, conn net.Conn) error {
+	defer s.css.close()
+
+	lis := newConnListener()
+	grpcServer := grpc.NewServer(grpc.MaxRecvMsgSize(maxMsgSize))
+	remotesapi.RegisterChunkStoreServiceServer(grpcServer, s.css)
+
+	go func() {
+		_ = grpcServer.Serve(lis)
+	}()
+
+	defer grpcServer.Stop()
+
+	mux := http.NewServeMux()
+	mux.Handle(tunnelPath, tunnelHandler{lis})
+	mux.Handle(filesPath, s.files)
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-stop:
+		}
+	}()
+
+	h2Server := &http2.Server{}
+	h2Server.ServeConn(conn, &http2.ServeConnOpts{Handler: mux})
+
+	return ctx.Err()
+}
+
+// tunnelHandler turns each tunnel request into a connection accepted by the gRPC server. The request body is read from
+// and the response is written to until the gRPC server closes the connection, or the client ends the request.
+type tunnelHandler struct {
+	lis *connListener
+}
+
+func (th tunnelHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	flusher, ok := w.(http.Flusher)
+
+	if req.Method != http.MethodPost || !ok {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	done := make(chan struct{})
+	fw := &flushWriter{w: w, flusher: flusher}
+	conn := NewConn(req.Body, fw, func() error {
+		fw.close()
+		close(done)
+		return nil
+	})
+
+	if !th.lis.push(conn) {
+		return
+	}
+
+	select {
+	case <-done:
+	case <-req.Context().Done():
+		_ = conn.Close()
+	}
+}
+
+// flushWriter flushes each write to the response, so that it's sent to the client right away. Once closed, it can no
+// longer be written to, as a response mustn't be written to after its handler returns.
+type flushWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	if fw.closed {
+		return 0, errTunnelClosed
+	}
+
+	n, err := fw.w.Write(p)
+	fw.flusher.Flush()
+
+	return n, err
+}
+
+func (fw *flushWriter) close() {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	fw.closed = true
+}
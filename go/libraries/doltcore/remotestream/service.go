@@ -0,0 +1,280 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotestream
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	remotesapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/remotesapi/v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/remotestorage"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/nbs"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const defaultMemTableSize = 128 * 1024 * 1024
+
+// chunkStoreService implements the chunk store service for the noms block store in a single directory. The repository
+// ids of requests are ignored, as there is only the one store. Table files are transferred with requests for the urls
+// of tableFiles.
+type chunkStoreService struct {
+	dir   string
+	files *tableFiles
+
+	mu sync.Mutex
+	cs *nbs.NomsBlockStore
+}
+
+func newChunkStoreService(dir string, files *tableFiles) *chunkStoreService {
+	return &chunkStoreService{dir: dir, files: files}
+}
+
+// store returns the store, opening it with the format |nbfVerStr| if it isn't open yet. The format is only used when the
+// directory doesn't hold a store already.
+func (css *chunkStoreService) store(ctx context.Context, nbfVerStr string) (*nbs.NomsBlockStore, error) {
+	css.mu.Lock()
+	defer css.mu.Unlock()
+
+	if css.cs == nil {
+		cs, err := nbs.NewLocalStore(ctx, nbfVerStr, css.dir, defaultMemTableSize)
+
+		if err != nil {
+			return nil, status.Error(codes.Internal, "failed to open the chunk store: "+err.Error())
+		}
+
+		css.cs = cs
+	}
+
+	return css.cs, nil
+}
+
+func (css *chunkStoreService) defaultStore(ctx context.Context) (*nbs.NomsBlockStore, error) {
+	return css.store(ctx, types.Format_Default.VersionString())
+}
+
+func (css *chunkStoreService) close() error {
+	css.mu.Lock()
+	defer css.mu.Unlock()
+
+	if css.cs == nil {
+		return nil
+	}
+
+	return css.cs.Close()
+}
+
+func (css *chunkStoreService) GetRepoMetadata(ctx context.Context, req *remotesapi.GetRepoMetadataRequest) (*remotesapi.GetRepoMetadataResponse, error) {
+	if err := remotestorage.ServeCapabilities(ctx, remotestorage.LocalCapabilities()); err != nil {
+		return nil, err
+	}
+
+	nbfVerStr := types.Format_Default.VersionString()
+	if req.ClientRepoFormat != nil {
+		nbfVerStr = req.ClientRepoFormat.NbfVersion
+	}
+
+	cs, err := css.store(ctx, nbfVerStr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &remotesapi.GetRepoMetadataResponse{
+		NbfVersion: cs.Version(),
+		NbsVersion: nbs.StorageVersion,
+	}, nil
+}
+
+func (css *chunkStoreService) HasChunks(ctx context.Context, req *remotesapi.HasChunksRequest) (*remotesapi.HasChunksResponse, error) {
+	cs, err := css.defaultStore(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	hashes, hashToIndex := remotestorage.ParseByteSlices(req.Hashes)
+	absent, err := cs.HasMany(ctx, hashes)
+
+	if err != nil {
+		return nil, status.Error(codes.Internal, "HasMany failure: "+err.Error())
+	}
+
+	indices := make([]int32, 0, len(absent))
+	for h := range absent {
+		indices = append(indices, int32(hashToIndex[h]))
+	}
+
+	return &remotesapi.HasChunksResponse{Absent: indices}, nil
+}
+
+func (css *chunkStoreService) GetDownloadLocations(ctx context.Context, req *remotesapi.GetDownloadLocsRequest) (*remotesapi.GetDownloadLocsResponse, error) {
+	cs, err := css.defaultStore(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	hashes, _ := remotestorage.ParseByteSlices(req.ChunkHashes)
+	locations, err := cs.GetChunkLocations(hashes)
+
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to get chunk locations: "+err.Error())
+	}
+
+	var locs []*remotesapi.DownloadLoc
+	for loc, hashToRange := range locations {
+		var ranges []*remotesapi.RangeChunk
+		for h, r := range hashToRange {
+			hCpy := h
+			ranges = append(ranges, &remotesapi.RangeChunk{Hash: hCpy[:], Offset: r.Offset, Length: r.Length})
+		}
+
+		getRange := &remotesapi.HttpGetRange{Url: css.files.url(loc.String()), Ranges: ranges}
+		locs = append(locs, &remotesapi.DownloadLoc{Location: &remotesapi.DownloadLoc_HttpGetRange{HttpGetRange: getRange}})
+	}
+
+	return &remotesapi.GetDownloadLocsResponse{Locs: locs}, nil
+}
+
+func (css *chunkStoreService) GetUploadLocations(ctx context.Context, req *remotesapi.GetUploadLocsRequest) (*remotesapi.GetUploadLocsResponse, error) {
+	tfds := req.GetTableFileDetails()
+
+	if len(tfds) == 0 {
+		for _, hashBytes := range req.TableFileHashes {
+			tfds = append(tfds, &remotesapi.TableFileDetails{Id: hashBytes})
+		}
+	}
+
+	var locs []*remotesapi.UploadLoc
+	for _, tfd := range tfds {
+		h := hash.New(tfd.Id)
+		css.files.expect(h.String(), tfd)
+
+		loc := &remotesapi.UploadLoc_HttpPost{HttpPost: &remotesapi.HttpPostTableFile{Url: css.files.url(h.String())}}
+		locs = append(locs, &remotesapi.UploadLoc{TableFileHash: h[:], Location: loc})
+	}
+
+	return &remotesapi.GetUploadLocsResponse{Locs: locs}, nil
+}
+
+func (css *chunkStoreService) Rebase(ctx context.Context, req *remotesapi.RebaseRequest) (*remotesapi.RebaseResponse, error) {
+	cs, err := css.defaultStore(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cs.Rebase(ctx); err != nil {
+		return nil, status.Error(codes.Internal, "failed to rebase: "+err.Error())
+	}
+
+	return &remotesapi.RebaseResponse{}, nil
+}
+
+func (css *chunkStoreService) Root(ctx context.Context, req *remotesapi.RootRequest) (*remotesapi.RootResponse, error) {
+	cs, err := css.defaultStore(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := cs.Root(ctx)
+
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to get root: "+err.Error())
+	}
+
+	return &remotesapi.RootResponse{RootHash: h[:]}, nil
+}
+
+func (css *chunkStoreService) Commit(ctx context.Context, req *remotesapi.CommitRequest) (*remotesapi.CommitResponse, error) {
+	cs, err := css.updateManifest(ctx, req.ChunkTableInfo)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := cs.Commit(ctx, hash.New(req.Current), hash.New(req.Last))
+
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to commit: "+err.Error())
+	}
+
+	return &remotesapi.CommitResponse{Success: ok}, nil
+}
+
+func (css *chunkStoreService) ListTableFiles(ctx context.Context, req *remotesapi.ListTableFilesRequest) (*remotesapi.ListTableFilesResponse, error) {
+	cs, err := css.defaultStore(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	root, tables, err := cs.Sources(ctx)
+
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to get sources: "+err.Error())
+	}
+
+	var tableFileInfo []*remotesapi.TableFileInfo
+	for _, tbl := range tables {
+		tableFileInfo = append(tableFileInfo, &remotesapi.TableFileInfo{
+			FileId:    tbl.FileID(),
+			NumChunks: uint32(tbl.NumChunks()),
+			Url:       css.files.url(tbl.FileID()),
+		})
+	}
+
+	return &remotesapi.ListTableFilesResponse{RootHash: root[:], TableFileInfo: tableFileInfo}, nil
+}
+
+func (css *chunkStoreService) AddTableFiles(ctx context.Context, req *remotesapi.AddTableFilesRequest) (*remotesapi.AddTableFilesResponse, error) {
+	if _, err := css.updateManifest(ctx, req.ChunkTableInfo); err != nil {
+		return nil, err
+	}
+
+	return &remotesapi.AddTableFilesResponse{Success: true}, nil
+}
+
+// updateManifest adds the table files uploaded by the client to the store's manifest.
+func (css *chunkStoreService) updateManifest(ctx context.Context, infos []*remotesapi.ChunkTableInfo) (*nbs.NomsBlockStore, error) {
+	cs, err := css.defaultStore(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[hash.Hash]uint32)
+	for _, cti := range infos {
+		h := hash.New(cti.Hash)
+
+		if !css.files.exists(h.String()) {
+			return nil, status.Error(codes.FailedPrecondition, fmt.Sprintf("table file %s hasn't been uploaded", h.String()))
+		}
+
+		updates[h] = cti.ChunkCount
+	}
+
+	if _, err := cs.UpdateManifest(ctx, updates); err != nil {
+		return nil, status.Error(codes.Internal, "manifest update error: "+err.Error())
+	}
+
+	return cs, nil
+}
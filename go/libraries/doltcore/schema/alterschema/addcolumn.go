@@ -42,9 +42,10 @@ type ColumnOrder struct {
 
 // Adds a new column to the schema given and returns the new table value. Non-null column additions rewrite the entire
 // table, since we must write a value for each row. If the column is not nullable, a default value must be provided.
+// The comment given is stored with the new column, and may be empty.
 //
 // Returns an error if the column added conflicts with the existing schema in tag or name.
-func AddColumnToTable(ctx context.Context, root *doltdb.RootValue, tbl *doltdb.Table, tblName string, tag uint64, newColName string, typeInfo typeinfo.TypeInfo, nullable Nullable, defaultVal types.Value, order *ColumnOrder) (*doltdb.Table, error) {
+func AddColumnToTable(ctx context.Context, root *doltdb.RootValue, tbl *doltdb.Table, tblName string, tag uint64, newColName string, typeInfo typeinfo.TypeInfo, nullable Nullable, defaultVal types.Value, comment string, order *ColumnOrder) (*doltdb.Table, error) {
 	sch, err := tbl.GetSchema(ctx)
 	if err != nil {
 		return nil, err
@@ -54,7 +55,7 @@ func AddColumnToTable(ctx context.Context, root *doltdb.RootValue, tbl *doltdb.T
 		return nil, err
 	}
 
-	newSchema, err := addColumnToSchema(sch, tag, newColName, typeInfo, nullable, order)
+	newSchema, err := addColumnToSchema(sch, tag, newColName, typeInfo, nullable, comment, order)
 	if err != nil {
 		return nil, err
 	}
@@ -112,11 +113,12 @@ func updateTableWithNewSchema(ctx context.Context, tbl *doltdb.Table, tag uint64
 }
 
 // addColumnToSchema creates a new schema with a column as specified by the params.
-func addColumnToSchema(sch schema.Schema, tag uint64, newColName string, typeInfo typeinfo.TypeInfo, nullable Nullable, order *ColumnOrder) (schema.Schema, error) {
+func addColumnToSchema(sch schema.Schema, tag uint64, newColName string, typeInfo typeinfo.TypeInfo, nullable Nullable, comment string, order *ColumnOrder) (schema.Schema, error) {
 	newCol, err := createColumn(nullable, newColName, tag, typeInfo)
 	if err != nil {
 		return nil, err
 	}
+	newCol.Comment = comment
 
 	var newCols []schema.Column
 	if order != nil && order.First {
@@ -138,7 +140,7 @@ func addColumnToSchema(sch schema.Schema, tag uint64, newColName string, typeInf
 		return nil, err
 	}
 
-	return schema.SchemaFromCols(collection), nil
+	return schema.SchemaWithComment(schema.SchemaFromCols(collection), sch.GetComment()), nil
 }
 
 func createColumn(nullable Nullable, newColName string, tag uint64, typeInfo typeinfo.TypeInfo) (schema.Column, error) {
@@ -43,6 +43,7 @@ func TestAddColumnToTable(t *testing.T) {
 		colKind        types.NomsKind
 		nullable       Nullable
 		defaultVal     types.Value
+		comment        string
 		order          *ColumnOrder
 		expectedSchema schema.Schema
 		expectedRows   []row.Row
@@ -58,6 +59,17 @@ func TestAddColumnToTable(t *testing.T) {
 				schema.NewColumn("newCol", dtestutils.NextTag, types.StringKind, false)),
 			expectedRows: dtestutils.TypedRows,
 		},
+		{
+			name:       "string column with comment",
+			tag:        dtestutils.NextTag,
+			newColName: "newCol",
+			colKind:    types.StringKind,
+			nullable:   Null,
+			comment:    "a new column",
+			expectedSchema: dtestutils.AddColumnToSchema(dtestutils.TypedSchema,
+				commentedColumn(schema.NewColumn("newCol", dtestutils.NextTag, types.StringKind, false), "a new column")),
+			expectedRows: dtestutils.TypedRows,
+		},
 		{
 			name:       "int column no default",
 			tag:        dtestutils.NextTag,
@@ -281,7 +293,7 @@ func TestAddColumnToTable(t *testing.T) {
 			tbl, _, err := root.GetTable(ctx, tableName)
 			assert.NoError(t, err)
 
-			updatedTable, err := AddColumnToTable(ctx, root, tbl, tableName, tt.tag, tt.newColName, typeinfo.FromKind(tt.colKind), tt.nullable, tt.defaultVal, tt.order)
+			updatedTable, err := AddColumnToTable(ctx, root, tbl, tableName, tt.tag, tt.newColName, typeinfo.FromKind(tt.colKind), tt.nullable, tt.defaultVal, tt.comment, tt.order)
 			if len(tt.expectedErr) > 0 {
 				require.Error(t, err)
 				assert.Contains(t, err.Error(), tt.expectedErr)
@@ -339,3 +351,8 @@ func createEnvWithSeedData(t *testing.T) *env.DoltEnv {
 
 	return dEnv
 }
+
+func commentedColumn(col schema.Column, comment string) schema.Column {
+	col.Comment = comment
+	return col
+}
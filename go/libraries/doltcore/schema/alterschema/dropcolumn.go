@@ -65,7 +65,7 @@ func DropColumn(ctx context.Context, tbl *doltdb.Table, colName string) (*doltdb
 		return nil, err
 	}
 
-	newSch := schema.SchemaFromCols(colColl)
+	newSch := schema.SchemaWithComment(schema.SchemaFromCols(colColl), tblSch.GetComment())
 
 	vrw := tbl.ValueReadWriter()
 	schemaVal, err := encoding.MarshalSchemaAsNomsValue(ctx, vrw, newSch)
@@ -0,0 +1,83 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alterschema
+
+import (
+	"context"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+)
+
+// FixIdentifiers renames the tables and columns of |root| whose names break the identifier rules of doltdb to their
+// suggested names, and returns the updated root along with the problems which were fixed.
+func FixIdentifiers(ctx context.Context, root *doltdb.RootValue) (*doltdb.RootValue, []doltdb.IdentifierProblem, error) {
+	problems, err := doltdb.CheckIdentifiers(ctx, root)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// tables are renamed first, as tables with invalid names can't be updated
+	tblNames := make(map[string]string)
+	for _, p := range problems {
+		tblNames[p.Table] = p.Table
+		if p.Column == "" {
+			root, err = RenameTable(ctx, root, p.Table, p.Suggestion)
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			tblNames[p.Table] = p.Suggestion
+		}
+	}
+
+	for _, p := range problems {
+		if p.Column == "" {
+			continue
+		}
+
+		tblName := tblNames[p.Table]
+		tbl, _, err := root.GetTable(ctx, tblName)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		sch, err := tbl.GetSchema(ctx)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		col, _ := sch.GetAllCols().GetByName(p.Column)
+		renamed := col
+		renamed.Name = p.Suggestion
+
+		tbl, err = ModifyColumn(ctx, tbl, col, renamed, nil, nil)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		root, err = root.PutTable(ctx, tblName, tbl)
+
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return root, problems, nil
+}
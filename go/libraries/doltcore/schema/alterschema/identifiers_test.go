@@ -0,0 +1,76 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alterschema
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+func TestFixIdentifiers(t *testing.T) {
+	dEnv := createEnvWithSeedData(t)
+	ctx := context.Background()
+
+	cc, _ := schema.NewColCollection(
+		schema.NewColumn("id", uint64(100), types.IntKind, true, schema.NotNullConstraint{}),
+		schema.NewColumn("total ", uint64(101), types.IntKind, false),
+		schema.NewColumn("Total", uint64(102), types.IntKind, false),
+	)
+	sch := schema.SchemaFromCols(cc)
+	r, err := row.New(types.Format_7_18, sch, row.TaggedValues{100: types.Int(1), 101: types.Int(2), 102: types.Int(3)})
+	require.NoError(t, err)
+
+	dtestutils.CreateTestTable(t, dEnv, "People", sch, r)
+
+	root, err := dEnv.WorkingRoot(ctx)
+	require.NoError(t, err)
+
+	root, problems, err := FixIdentifiers(ctx, root)
+	require.NoError(t, err)
+	require.Len(t, problems, 2)
+
+	problems, err = doltdb.CheckIdentifiers(ctx, root)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+
+	names, err := root.GetTableNames(ctx)
+	require.NoError(t, err)
+	// People sorts first, so the seeded people table is the one renamed
+	assert.ElementsMatch(t, []string{"People", "people_2"}, names)
+
+	tbl, _, err := root.GetTable(ctx, "People")
+	require.NoError(t, err)
+
+	newSch, err := tbl.GetSchema(ctx)
+	require.NoError(t, err)
+
+	col, ok := newSch.GetAllCols().GetByTag(101)
+	require.True(t, ok)
+	assert.Equal(t, "total_2", col.Name)
+
+	// renaming a column keeps its values
+	rows, err := tbl.GetRowData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), rows.Len())
+}
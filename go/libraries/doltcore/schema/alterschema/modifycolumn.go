@@ -176,5 +176,5 @@ func replaceColumnInSchema(sch schema.Schema, oldColName string, newCol schema.C
 		return nil, err
 	}
 
-	return schema.SchemaFromCols(collection), nil
+	return schema.SchemaWithComment(schema.SchemaFromCols(collection), sch.GetComment()), nil
 }
@@ -0,0 +1,315 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alterschema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/encoding"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/typed/noms"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// maxDuplicateKeyExamples is the number of duplicated keys listed by the error ChangePrimaryKey returns when the new
+// primary key isn't unique.
+const maxDuplicateKeyExamples = 5
+
+// ErrDuplicatePrimaryKey is returned by ChangePrimaryKey when more than one row of the table has the same values for
+// the columns of the new primary key.
+var ErrDuplicatePrimaryKey = errors.New("duplicate primary key")
+
+// ChangePrimaryKey replaces the primary key of the table given with the columns named, and returns the updated table.
+// The columns keep their tags and their order in the table, and the columns of the key are ordered as they are in the
+// table, as they are for CREATE TABLE. The columns of the new key become NOT NULL.
+//
+// The row data is rebuilt keyed by the new primary key. Every row is read and sorted by its new key, so the time and
+// memory this takes grow with the size of the table. If any rows have the same new key, an error wrapping
+// ErrDuplicatePrimaryKey which lists some of the duplicated keys is returned.
+//
+// As the keys of all of the rows change, a diff of the table across the change shows every row as removed with its old
+// key and added with its new one, rather than trying to match up the rows. The columns' tags are unchanged, so the
+// history of the table's columns, and the schema diff, stay connected across the change.
+func ChangePrimaryKey(ctx context.Context, tbl *doltdb.Table, pkColNames []string) (*doltdb.Table, error) {
+	sch, err := tbl.GetSchema(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	newSch, err := schemaWithPrimaryKey(sch, pkColNames)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if sameColumns(sch.GetPKCols(), newSch.GetPKCols()) {
+		return tbl, nil
+	}
+
+	hasConflicts, err := tbl.HasConflicts()
+
+	if err != nil {
+		return nil, err
+	} else if hasConflicts {
+		return nil, errors.New("cannot change the primary key of a table with unresolved conflicts")
+	}
+
+	rowData, err := tbl.GetRowData(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	vrw := tbl.ValueReadWriter()
+	newRowData, err := rekeyRowData(ctx, vrw, rowData, sch, newSch)
+
+	if err != nil {
+		return nil, err
+	}
+
+	newSchemaVal, err := encoding.MarshalSchemaAsNomsValue(ctx, vrw, newSch)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return doltdb.NewTable(ctx, vrw, newSchemaVal, newRowData)
+}
+
+// schemaWithPrimaryKey returns the schema given with the columns named as its primary key.
+func schemaWithPrimaryKey(sch schema.Schema, pkColNames []string) (schema.Schema, error) {
+	if len(pkColNames) == 0 {
+		return nil, schema.ErrNoPrimaryKeyColumns
+	}
+
+	allCols := sch.GetAllCols()
+	pkTags := make(map[uint64]bool)
+	for _, name := range pkColNames {
+		col, ok := allCols.GetByNameCaseInsensitive(name)
+
+		if !ok {
+			return nil, fmt.Errorf("Couldn't find column %s", name)
+		} else if pkTags[col.Tag] {
+			return nil, fmt.Errorf("Column %s is in the primary key more than once", col.Name)
+		} else if col.Cold {
+			return nil, fmt.Errorf("Cold column %s cannot be part of the primary key", col.Name)
+		}
+
+		pkTags[col.Tag] = true
+	}
+
+	var newCols []schema.Column
+	err := allCols.Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+		col.IsPartOfPK = pkTags[tag]
+
+		if col.IsPartOfPK && col.IsNullable() {
+			col.Constraints = append(col.Constraints, schema.NotNullConstraint{})
+		}
+
+		newCols = append(newCols, col)
+		return false, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	collection, err := schema.NewColCollection(newCols...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.SchemaWithComment(schema.SchemaFromCols(collection), sch.GetComment()), nil
+}
+
+// sameColumns returns whether two collections have the same columns in the same order.
+func sameColumns(cc1, cc2 *schema.ColCollection) bool {
+	if cc1.Size() != cc2.Size() {
+		return false
+	}
+
+	for i := 0; i < cc1.Size(); i++ {
+		if cc1.GetByIndex(i).Tag != cc2.GetByIndex(i).Tag {
+			return false
+		}
+	}
+
+	return true
+}
+
+// rekeyedRow is a row of a table with its key under a new primary key.
+type rekeyedRow struct {
+	key types.Value
+	r   row.Row
+}
+
+// rekeyRowData returns a map of the rows of |rowData|, which are keyed by the primary key of |oldSch|, keyed by the
+// primary key of |newSch| instead. The rows are sorted by their new keys, so that the map can be built by a
+// NomsMapCreator, and adjacent rows with the same new key are reported as duplicates.
+func rekeyRowData(ctx context.Context, vrw types.ValueReadWriter, rowData types.Map, oldSch, newSch schema.Schema) (types.Map, error) {
+	pkCols := newSch.GetPKCols()
+	rows := make([]rekeyedRow, 0, rowData.Len())
+	err := rowData.Iter(ctx, func(key, value types.Value) (stop bool, err error) {
+		oldRow, err := row.FromNoms(oldSch, key.(types.Tuple), value.(types.Tuple))
+
+		if err != nil {
+			return false, err
+		}
+
+		// rows keep their values split into their key and non-key columns, so they're split again by the new schema
+		taggedVals, err := row.GetTaggedVals(oldRow)
+
+		if err != nil {
+			return false, err
+		}
+
+		r, err := row.New(rowData.Format(), newSch, taggedVals)
+
+		if err != nil {
+			return false, err
+		}
+
+		err = pkCols.Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+			if val, ok := r.GetColVal(tag); !ok || types.IsNull(val) {
+				return true, fmt.Errorf("Column %s cannot be part of the primary key when one or more values is NULL", col.Name)
+			}
+
+			return false, nil
+		})
+
+		if err != nil {
+			return false, err
+		}
+
+		newKey, err := r.NomsMapKey(newSch).Value(ctx)
+
+		if err != nil {
+			return false, err
+		}
+
+		rows = append(rows, rekeyedRow{newKey, r})
+		return false, nil
+	})
+
+	if err != nil {
+		return types.EmptyMap, err
+	}
+
+	nbf := vrw.Format()
+	var sortErr error
+	sort.Slice(rows, func(i, j int) bool {
+		less, err := rows[i].key.Less(nbf, rows[j].key)
+
+		if err != nil && sortErr == nil {
+			sortErr = err
+		}
+
+		return less
+	})
+
+	if sortErr != nil {
+		return types.EmptyMap, sortErr
+	}
+
+	if err := checkDuplicateKeys(ctx, rows, pkCols); err != nil {
+		return types.EmptyMap, err
+	}
+
+	creator := noms.NewNomsMapCreator(ctx, vrw, newSch)
+	for _, rr := range rows {
+		if err := creator.WriteRow(ctx, rr.r); err != nil {
+			_ = creator.Close(ctx)
+			return types.EmptyMap, err
+		}
+	}
+
+	if err := creator.Close(ctx); err != nil {
+		return types.EmptyMap, err
+	}
+
+	return *creator.GetMap(), nil
+}
+
+// checkDuplicateKeys returns an error wrapping ErrDuplicatePrimaryKey which lists the first of the duplicated keys if
+// any of the sorted rows given have the same key.
+func checkDuplicateKeys(ctx context.Context, rows []rekeyedRow, pkCols *schema.ColCollection) error {
+	var examples []string
+	duplicates := 0
+	for i := 1; i < len(rows); i++ {
+		if !rows[i].key.Equals(rows[i-1].key) {
+			continue
+		}
+
+		// only count each duplicated key once, however many rows have it
+		if i > 1 && rows[i-1].key.Equals(rows[i-2].key) {
+			continue
+		}
+
+		duplicates++
+
+		if len(examples) < maxDuplicateKeyExamples {
+			example, err := formatKey(ctx, rows[i].r, pkCols)
+
+			if err != nil {
+				return err
+			}
+
+			examples = append(examples, example)
+		}
+	}
+
+	if duplicates == 0 {
+		return nil
+	}
+
+	names := strings.Join(pkCols.GetColumnNames(), ", ")
+	msg := fmt.Sprintf("%d values of (%s) are shared by more than one row: %s", duplicates, names, strings.Join(examples, ", "))
+
+	if duplicates > len(examples) {
+		msg += ", ..."
+	}
+
+	return fmt.Errorf("%w: %s", ErrDuplicatePrimaryKey, msg)
+}
+
+// formatKey returns the values of the key columns of a row as a parenthesized list.
+func formatKey(ctx context.Context, r row.Row, pkCols *schema.ColCollection) (string, error) {
+	var vals []string
+	err := pkCols.Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+		val, _ := r.GetColVal(tag)
+		str, err := types.EncodedValue(ctx, val)
+
+		if err != nil {
+			return true, err
+		}
+
+		vals = append(vals, str)
+		return false, nil
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return "(" + strings.Join(vals, ", ") + ")", nil
+}
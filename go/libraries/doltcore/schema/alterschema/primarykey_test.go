@@ -0,0 +1,185 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alterschema
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+func TestChangePrimaryKey(t *testing.T) {
+	tests := []struct {
+		name         string
+		pkColNames   []string
+		expectedPK   []uint64
+		expectedRows []string
+		expectedErr  string
+	}{
+		{
+			name:         "single column",
+			pkColNames:   []string{"name"},
+			expectedPK:   []uint64{dtestutils.NameTag},
+			expectedRows: []string{"Bill Billerson", "John Johnson", "Rob Robertson"},
+		},
+		{
+			name:         "ordered by the table",
+			pkColNames:   []string{"AGE", "is_married"},
+			expectedPK:   []uint64{dtestutils.AgeTag, dtestutils.IsMarriedTag},
+			expectedRows: []string{"Rob Robertson", "John Johnson", "Bill Billerson"},
+		},
+		{
+			name:         "composite with the old key",
+			pkColNames:   []string{"title", "id"},
+			expectedPK:   []uint64{dtestutils.IdTag, dtestutils.TitleTag},
+			expectedRows: dtestutils.Names,
+		},
+		{
+			name:        "duplicate keys",
+			pkColNames:  []string{"is_married"},
+			expectedErr: "1 values of (is_married) are shared by more than one row: (false)",
+		},
+		{
+			name:        "column not found",
+			pkColNames:  []string{"id", "not found"},
+			expectedErr: "Couldn't find column not found",
+		},
+		{
+			name:        "repeated column",
+			pkColNames:  []string{"name", "name"},
+			expectedErr: "Column name is in the primary key more than once",
+		},
+		{
+			name:        "no columns",
+			expectedErr: schema.ErrNoPrimaryKeyColumns.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dEnv := createEnvWithSeedData(t)
+			ctx := context.Background()
+
+			root, err := dEnv.WorkingRoot(ctx)
+			require.NoError(t, err)
+			tbl, _, err := root.GetTable(ctx, tableName)
+			require.NoError(t, err)
+
+			updatedTable, err := ChangePrimaryKey(ctx, tbl, tt.pkColNames)
+			if len(tt.expectedErr) > 0 {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			sch, err := updatedTable.GetSchema(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedPK, sch.GetPKCols().Tags)
+			assert.Equal(t, dtestutils.TypedSchema.GetAllCols().GetColumnNames(), sch.GetAllCols().GetColumnNames())
+
+			for _, tag := range tt.expectedPK {
+				col, _ := sch.GetPKCols().GetByTag(tag)
+				assert.False(t, col.IsNullable(), "column %s", col.Name)
+			}
+
+			rowData, err := updatedTable.GetRowData(ctx)
+			require.NoError(t, err)
+
+			var names []string
+			err = rowData.Iter(ctx, func(key, value types.Value) (stop bool, err error) {
+				r, err := row.FromNoms(sch, key.(types.Tuple), value.(types.Tuple))
+				require.NoError(t, err)
+
+				expected := dtestutils.TypedRows[indexOfName(t, r)]
+				assert.True(t, row.AreEqual(expected, r, sch), "row %v", r)
+
+				name, _ := r.GetColVal(dtestutils.NameTag)
+				names = append(names, string(name.(types.String)))
+				return false, nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedRows, names)
+		})
+	}
+}
+
+func TestChangePrimaryKeyErrors(t *testing.T) {
+	dEnv := createEnvWithSeedData(t)
+	ctx := context.Background()
+
+	root, err := dEnv.WorkingRoot(ctx)
+	require.NoError(t, err)
+	tbl, _, err := root.GetTable(ctx, tableName)
+	require.NoError(t, err)
+
+	// the same key leaves the table as it was
+	unchanged, err := ChangePrimaryKey(ctx, tbl, []string{"id"})
+	require.NoError(t, err)
+	assert.Equal(t, tbl, unchanged)
+
+	rowData, err := tbl.GetRowData(ctx)
+	require.NoError(t, err)
+
+	ed := rowData.Edit()
+	for i, age := range []uint64{1, 1, 1, 2, 2, 3} {
+		r, err := row.New(types.Format_7_18, dtestutils.TypedSchema, row.TaggedValues{
+			dtestutils.IdTag:        types.UUID(uuid.UUID{byte(i + 1)}),
+			dtestutils.NameTag:      types.String("Anon"),
+			dtestutils.AgeTag:       types.Uint(age),
+			dtestutils.IsMarriedTag: types.Bool(false),
+		})
+		require.NoError(t, err)
+		ed.Set(r.NomsMapKey(dtestutils.TypedSchema), r.NomsMapValue(dtestutils.TypedSchema))
+	}
+
+	rowData, err = ed.Map(ctx)
+	require.NoError(t, err)
+	tbl, err = tbl.UpdateRows(ctx, rowData)
+	require.NoError(t, err)
+
+	// each of the duplicated keys is only listed once
+	_, err = ChangePrimaryKey(ctx, tbl, []string{"age"})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrDuplicatePrimaryKey))
+	assert.Contains(t, err.Error(), "2 values of (age) are shared by more than one row: (1), (2)")
+
+	_, err = ChangePrimaryKey(ctx, tbl, []string{"title"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Column title cannot be part of the primary key when one or more values is NULL")
+}
+
+func indexOfName(t *testing.T, r row.Row) int {
+	name, ok := r.GetColVal(dtestutils.NameTag)
+	require.True(t, ok)
+
+	for i, n := range dtestutils.Names {
+		if string(name.(types.String)) == n {
+			return i
+		}
+	}
+
+	t.Fatalf("unexpected row %v", r)
+	return -1
+}
@@ -0,0 +1,48 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alterschema
+
+import (
+	"context"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/encoding"
+)
+
+// SetTableComment sets the comment of the table given and returns the updated table. An empty comment removes the
+// table's comment.
+func SetTableComment(ctx context.Context, tbl *doltdb.Table, comment string) (*doltdb.Table, error) {
+	sch, err := tbl.GetSchema(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	vrw := tbl.ValueReadWriter()
+	schemaVal, err := encoding.MarshalSchemaAsNomsValue(ctx, vrw, schema.SchemaWithComment(sch, comment))
+
+	if err != nil {
+		return nil, err
+	}
+
+	rowData, err := tbl.GetRowData(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return doltdb.NewTable(ctx, vrw, schemaVal, rowData)
+}
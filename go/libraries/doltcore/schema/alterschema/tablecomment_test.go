@@ -0,0 +1,58 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alterschema
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+)
+
+func TestSetTableComment(t *testing.T) {
+	dEnv := createEnvWithSeedData(t)
+	ctx := context.Background()
+
+	root, err := dEnv.WorkingRoot(ctx)
+	require.NoError(t, err)
+	tbl, _, err := root.GetTable(ctx, tableName)
+	require.NoError(t, err)
+
+	tbl, err = SetTableComment(ctx, tbl, "everyone we know")
+	require.NoError(t, err)
+
+	sch, err := tbl.GetSchema(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "everyone we know", sch.GetComment())
+
+	// the comment is kept when the table's columns are altered
+	tbl, err = DropColumn(ctx, tbl, "age")
+	require.NoError(t, err)
+
+	sch, err = tbl.GetSchema(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "everyone we know", sch.GetComment())
+	assert.Equal(t, dtestutils.TypedSchema.GetAllCols().Size()-1, sch.GetAllCols().Size())
+
+	tbl, err = SetTableComment(ctx, tbl, "")
+	require.NoError(t, err)
+
+	sch, err = tbl.GetSchema(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "", sch.GetComment())
+}
@@ -43,6 +43,8 @@ var (
 		false,
 		typeinfo.UnknownType,
 		nil,
+		"",
+		false,
 	}
 )
 
@@ -72,6 +74,13 @@ type Column struct {
 
 	// Constraints are rules that can be checked on each column to say if the columns value is valid
 	Constraints []ColConstraint
+
+	// Comment is the user supplied comment describing the column
+	Comment string
+
+	// Cold is whether the values of the column are stored apart from the rest of the row, so that reading the other
+	// columns of a table doesn't read them
+	Cold bool
 }
 
 // NewColumn creates a Column instance with the default type info for the NomsKind
@@ -103,6 +112,8 @@ func NewColumnWithTypeInfo(name string, tag uint64, typeInfo typeinfo.TypeInfo,
 		partOfPK,
 		typeInfo,
 		constraints,
+		"",
+		false,
 	}, nil
 }
 
@@ -123,7 +134,9 @@ func (c Column) Equals(other Column) bool {
 		c.Kind == other.Kind &&
 		c.IsPartOfPK == other.IsPartOfPK &&
 		c.TypeInfo.Equals(other.TypeInfo) &&
-		ColConstraintsAreEqual(c.Constraints, other.Constraints)
+		ColConstraintsAreEqual(c.Constraints, other.Constraints) &&
+		c.Comment == other.Comment &&
+		c.Cold == other.Cold
 }
 
 // KindString returns the string representation of the NomsKind stored in the column.
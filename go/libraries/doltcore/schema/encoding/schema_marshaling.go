@@ -47,6 +47,12 @@ type encodedColumn struct {
 
 	Constraints []encodedConstraint `noms:"col_constraints" json:"col_constraints"`
 
+	// Comment is not written when empty, leaving the encoding of columns without comments unchanged
+	Comment string `noms:"comment,omitempty" json:"comment,omitempty"`
+
+	// Cold is not written when false, leaving the encoding of columns stored with the rest of the row unchanged
+	Cold bool `noms:"cold,omitempty" json:"cold,omitempty"`
+
 	// NB: all new fields must have the 'omitempty' annotation. See comment above
 }
 
@@ -83,6 +89,8 @@ func encodeColumn(col schema.Column) encodedColumn {
 		col.IsPartOfPK,
 		encodeTypeInfo(col.TypeInfo),
 		encodeAllColConstraints(col.Constraints),
+		col.Comment,
+		col.Cold,
 	}
 }
 
@@ -100,7 +108,14 @@ func (nfd encodedColumn) decodeColumn() (schema.Column, error) {
 		return schema.Column{}, errors.New("cannot decode column due to unknown schema format")
 	}
 	colConstraints := decodeAllColConstraint(nfd.Constraints)
-	return schema.NewColumnWithTypeInfo(nfd.Name, nfd.Tag, typeInfo, nfd.IsPartOfPK, colConstraints...)
+	col, err := schema.NewColumnWithTypeInfo(nfd.Name, nfd.Tag, typeInfo, nfd.IsPartOfPK, colConstraints...)
+	if err != nil {
+		return schema.Column{}, err
+	}
+
+	col.Comment = nfd.Comment
+	col.Cold = nfd.Cold
+	return col, nil
 }
 
 type encodedConstraint struct {
@@ -132,6 +147,7 @@ func (enc encodedTypeInfo) decodeTypeInfo() (typeinfo.TypeInfo, error) {
 
 type schemaData struct {
 	Columns []encodedColumn `noms:"columns" json:"columns"`
+	Comment string          `noms:"comment,omitempty" json:"comment,omitempty"`
 }
 
 func toSchemaData(sch schema.Schema) (schemaData, error) {
@@ -150,7 +166,7 @@ func toSchemaData(sch schema.Schema) (schemaData, error) {
 		return schemaData{}, err
 	}
 
-	return schemaData{encCols}, nil
+	return schemaData{encCols, sch.GetComment()}, nil
 }
 
 func (sd schemaData) decodeSchema() (schema.Schema, error) {
@@ -171,7 +187,13 @@ func (sd schemaData) decodeSchema() (schema.Schema, error) {
 		return nil, err
 	}
 
-	return schema.SchemaFromCols(colColl), nil
+	sch := schema.SchemaFromCols(colColl)
+
+	if sd.Comment != "" {
+		sch = schema.SchemaWithComment(sch, sd.Comment)
+	}
+
+	return sch, nil
 }
 
 // MarshalSchemaAsNomsValue takes a Schema and converts it to a types.Value
@@ -40,13 +40,46 @@ func createTestSchema() schema.Schema {
 		schema.NewColumn("last", 2, types.StringKind, false, schema.NotNullConstraint{}),
 		schema.NewColumn("age", 3, types.UintKind, false),
 	}
+	columns[1].Comment = "given name"
+	columns[3].Cold = true
 
 	colColl, _ := schema.NewColCollection(columns...)
-	sch := schema.SchemaFromCols(colColl)
+	sch := schema.SchemaWithComment(schema.SchemaFromCols(colColl), "people we know")
 
 	return sch
 }
 
+func TestNomsMarshallingWithoutComments(t *testing.T) {
+	col := schema.NewColumn("id", 0, types.IntKind, true, schema.NotNullConstraint{})
+	colColl, err := schema.NewColCollection(col)
+	require.NoError(t, err)
+	tSchema := schema.SchemaFromCols(colColl)
+
+	db, err := dbfactory.MemFactory{}.CreateDB(context.Background(), types.Format_7_18, nil, nil)
+	require.NoError(t, err)
+
+	val, err := MarshalSchemaAsNomsValue(context.Background(), db, tSchema)
+	require.NoError(t, err)
+
+	// schemas without comments must encode exactly as they did before comments were supported
+	st := val.(types.Struct)
+	_, ok, err := st.MaybeGet("comment")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	cols, ok, err := st.MaybeGet("columns")
+	require.NoError(t, err)
+	require.True(t, ok)
+	encCol, err := cols.(types.List).Get(context.Background(), 0)
+	require.NoError(t, err)
+	_, ok, err = encCol.(types.Struct).MaybeGet("comment")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	_, ok, err = encCol.(types.Struct).MaybeGet("cold")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
 func TestNomsMarshalling(t *testing.T) {
 	tSchema := createTestSchema()
 	db, err := dbfactory.MemFactory{}.CreateDB(context.Background(), types.Format_7_18, nil, nil)
@@ -224,10 +257,18 @@ type testEncodedColumn struct {
 	TypeInfo encodedTypeInfo `noms:"typeinfo" json:"typeinfo"`
 
 	Constraints []encodedConstraint `noms:"col_constraints" json:"col_constraints"`
+
+	// Comment is the exception to the rule above. Empty comments are not written so that the encoding of schemas
+	// without comments is unchanged.
+	Comment string `noms:"comment,omitempty" json:"comment,omitempty"`
+
+	// Cold is an exception for the same reason.
+	Cold bool `noms:"cold,omitempty" json:"cold,omitempty"`
 }
 
 type testSchemaData struct {
 	Columns []testEncodedColumn `noms:"columns" json:"columns"`
+	Comment string              `noms:"comment,omitempty" json:"comment,omitempty"`
 }
 
 func (tec testEncodedColumn) decodeColumn() (schema.Column, error) {
@@ -244,7 +285,14 @@ func (tec testEncodedColumn) decodeColumn() (schema.Column, error) {
 		return schema.Column{}, errors.New("cannot decode column due to unknown schema format")
 	}
 	colConstraints := decodeAllColConstraint(tec.Constraints)
-	return schema.NewColumnWithTypeInfo(tec.Name, tec.Tag, typeInfo, tec.IsPartOfPK, colConstraints...)
+	col, err := schema.NewColumnWithTypeInfo(tec.Name, tec.Tag, typeInfo, tec.IsPartOfPK, colConstraints...)
+	if err != nil {
+		return schema.Column{}, err
+	}
+
+	col.Comment = tec.Comment
+	col.Cold = tec.Cold
+	return col, nil
 }
 
 func (tsd testSchemaData) decodeSchema() (schema.Schema, error) {
@@ -265,5 +313,5 @@ func (tsd testSchemaData) decodeSchema() (schema.Schema, error) {
 		return nil, err
 	}
 
-	return schema.SchemaFromCols(colColl), nil
+	return schema.SchemaWithComment(schema.SchemaFromCols(colColl), tsd.Comment), nil
 }
@@ -24,6 +24,14 @@ type Schema interface {
 
 	// GetAllCols gets the collection of all columns (pk and non-pk)
 	GetAllCols() *ColCollection
+
+	// GetComment gets the user supplied comment describing the table.
+	GetComment() string
+}
+
+// SchemaWithComment returns a copy of the schema given with its table comment set to comment.
+func SchemaWithComment(sch Schema, comment string) Schema {
+	return &schemaImpl{sch.GetPKCols(), sch.GetNonPKCols(), sch.GetAllCols(), comment}
 }
 
 // ColFromTag returns a schema.Column from a schema and a tag
@@ -51,13 +59,25 @@ func ExtractAllColNames(sch Schema) (map[uint64]string, error) {
 	return colNames, nil
 }
 
+// ColdColTags returns the tags of the cold columns of a schema in tag order.
+func ColdColTags(sch Schema) []uint64 {
+	var tags []uint64
+	for _, tag := range sch.GetNonPKCols().SortedTags {
+		if col, _ := sch.GetNonPKCols().GetByTag(tag); col.Cold {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
 // TODO: this function never returns an error
 // SchemasAreEqual tests equality of two schemas.
 func SchemasAreEqual(sch1, sch2 Schema) (bool, error) {
 	all1 := sch1.GetAllCols()
 	all2 := sch2.GetAllCols()
 
-	if all1.Size() != all2.Size() {
+	if all1.Size() != all2.Size() || sch1.GetComment() != sch2.GetComment() {
 		return false, nil
 	}
 
@@ -24,10 +24,12 @@ var EmptySchema = &schemaImpl{
 	EmptyColColl,
 	EmptyColColl,
 	EmptyColColl,
+	"",
 }
 
 type schemaImpl struct {
 	pkCols, nonPKCols, allCols *ColCollection
+	comment                    string
 }
 
 // SchemaFromCols creates a Schema from a collection of columns
@@ -51,7 +53,7 @@ func SchemaFromCols(allCols *ColCollection) Schema {
 	nonPKColColl, _ := NewColCollection(nonPKCols...)
 
 	return &schemaImpl{
-		pkColColl, nonPKColColl, allCols,
+		pkColColl, nonPKColColl, allCols, "",
 	}
 }
 
@@ -104,7 +106,7 @@ func UnkeyedSchemaFromCols(allCols *ColCollection) Schema {
 	nonPKColColl, _ := NewColCollection(nonPKCols...)
 
 	return &schemaImpl{
-		pkColColl, nonPKColColl, nonPKColColl,
+		pkColColl, nonPKColColl, nonPKColColl, "",
 	}
 }
 
@@ -138,7 +140,7 @@ func SchemaFromPKAndNonPKCols(pkCols, nonPKCols *ColCollection) (Schema, error)
 	}
 
 	return &schemaImpl{
-		pkCols, nonPKCols, allColColl,
+		pkCols, nonPKCols, allColColl, "",
 	}, nil
 }
 
@@ -157,6 +159,11 @@ func (si *schemaImpl) GetPKCols() *ColCollection {
 	return si.pkCols
 }
 
+// GetComment gets the user supplied comment describing the table.
+func (si *schemaImpl) GetComment() string {
+	return si.comment
+}
+
 func (si *schemaImpl) String() string {
 	var b strings.Builder
 	writeColFn := func(tag uint64, col Column) (stop bool, err error) {
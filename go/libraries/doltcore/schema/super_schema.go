@@ -318,7 +318,9 @@ func SuperSchemaUnion(superSchemas ...*SuperSchema) (*SuperSchema, error) {
 func stripColNameAndConstraints(col Column) Column {
 	// track column names in SuperSchema.tagNames
 	col.Name = ""
-	// don't track constraints
+	// don't track constraints, comments or storage
 	col.Constraints = []ColConstraint(nil)
+	col.Comment = ""
+	col.Cold = false
 	return col
 }
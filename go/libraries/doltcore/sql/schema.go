@@ -16,6 +16,10 @@ package sql
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+
+	gmssql "github.com/src-d/go-mysql-server/sql"
 
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
 )
@@ -26,7 +30,42 @@ const TagCommentPrefix = "tag:"
 // typeWidth are 0 or less than the length of the name or type, then the length of the name or type will be used
 func FmtCol(indent, nameWidth, typeWidth int, col schema.Column) string {
 	sqlType := col.TypeInfo.ToSqlType()
-	return FmtColWithNameAndType(indent, nameWidth, typeWidth, col.Name, sqlType.String(), col)
+	return FmtColWithNameAndType(indent, nameWidth, typeWidth, col.Name, SqlTypeString(sqlType), col)
+}
+
+// SqlTypeString returns the SQL type given as it's written in a column definition. The values of ENUM and SET types are
+// quoted, so values containing quotes or backslashes are written so that they parse back to the same values.
+func SqlTypeString(t gmssql.Type) string {
+	var s string
+	var collation gmssql.Collation
+
+	switch t := t.(type) {
+	case gmssql.EnumType:
+		s, collation = fmtValuesType("ENUM", t.Values()), t.Collation()
+	case gmssql.SetType:
+		s, collation = fmtValuesType("SET", t.Values()), t.Collation()
+	default:
+		return t.String()
+	}
+
+	if collation.CharacterSet() != gmssql.Collation_Default.CharacterSet() {
+		s += " CHARACTER SET " + collation.CharacterSet().String()
+	}
+
+	if collation != gmssql.Collation_Default {
+		s += " COLLATE " + collation.String()
+	}
+
+	return s
+}
+
+func fmtValuesType(name string, vals []string) string {
+	quoted := make([]string, len(vals))
+	for i, val := range vals {
+		quoted[i] = QuoteString(val)
+	}
+
+	return name + "(" + strings.Join(quoted, ",") + ")"
 }
 
 // FmtColWithNameAndType creates a string representing a column within a sql create table statement with a given indent
@@ -46,7 +85,7 @@ func FmtColWithNameAndType(indent, nameWidth, typeWidth int, colName, typeStr st
 		}
 	}
 
-	return colStr + fmt.Sprintf(" COMMENT 'tag:%d'", col.Tag)
+	return colStr + " COMMENT " + QuoteString(FmtColComment(col))
 }
 
 // FmtColPrimaryKey creates a string representing a primary key constraint within a sql create table statement with a
@@ -59,3 +98,29 @@ func FmtColPrimaryKey(indent int, colStr string) string {
 func FmtColTagComment(tag uint64) string {
 	return fmt.Sprintf("%s%d", TagCommentPrefix, tag)
 }
+
+// FmtColComment returns the SQL comment for a column, which is the column's tag comment following the column's comment
+// if it has one.
+func FmtColComment(col schema.Column) string {
+	if col.Comment == "" {
+		return FmtColTagComment(col.Tag)
+	}
+
+	return col.Comment + " " + FmtColTagComment(col.Tag)
+}
+
+// ParseColComment splits a SQL column comment into the column's comment and tag. The tag returned is
+// schema.InvalidTag if the comment doesn't end with a tag comment.
+func ParseColComment(s string) (string, uint64) {
+	i := strings.LastIndex(s, TagCommentPrefix)
+	if i < 0 || (i > 0 && s[i-1] != ' ') {
+		return s, schema.InvalidTag
+	}
+
+	tag, err := strconv.ParseUint(s[i+len(TagCommentPrefix):], 10, 64)
+	if err != nil {
+		return s, schema.InvalidTag
+	}
+
+	return strings.TrimSuffix(s[:i], " "), tag
+}
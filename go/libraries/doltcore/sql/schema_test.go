@@ -59,6 +59,13 @@ func TestFmtCol(t *testing.T) {
 			15,
 			"   `aoeui` BIGINT UNSIGNED COMMENT 'tag:52'",
 		},
+		{
+			commentedCol(schema.NewColumn("name", 7, types.StringKind, false), "it's a name"),
+			0,
+			0,
+			0,
+			"`name` LONGTEXT COMMENT 'it\\'s a name tag:7'",
+		},
 	}
 
 	for _, test := range tests {
@@ -68,3 +75,37 @@ func TestFmtCol(t *testing.T) {
 		})
 	}
 }
+
+func TestParseColComment(t *testing.T) {
+	tests := []struct {
+		Comment         string
+		ExpectedComment string
+		ExpectedTag     uint64
+	}{
+		{"tag:12", "", 12},
+		{"the key tag:5", "the key", 5},
+		{"tag:1 tag:2", "tag:1", 2},
+		{"no tag", "no tag", schema.InvalidTag},
+		{"tag:x", "tag:x", schema.InvalidTag},
+		{"mytag:3", "mytag:3", schema.InvalidTag},
+		{"", "", schema.InvalidTag},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Comment, func(t *testing.T) {
+			comment, tag := ParseColComment(test.Comment)
+			assert.Equal(t, test.ExpectedComment, comment)
+			assert.Equal(t, test.ExpectedTag, tag)
+		})
+	}
+
+	col := commentedCol(schema.NewColumn("name", 7, types.StringKind, false), "the tag:1 name")
+	comment, tag := ParseColComment(FmtColComment(col))
+	assert.Equal(t, col.Comment, comment)
+	assert.Equal(t, col.Tag, tag)
+}
+
+func commentedCol(col schema.Column, comment string) schema.Column {
+	col.Comment = comment
+	return col
+}
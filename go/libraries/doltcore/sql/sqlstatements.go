@@ -15,6 +15,7 @@
 package sql
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
@@ -28,19 +29,54 @@ import (
 
 const doubleQuot = `"`
 
-// Quotes the identifier given with backticks.
+// Quotes the identifier given with backticks, doubling any backticks it contains.
 func QuoteIdentifier(s string) string {
-	return "`" + s + "`"
+	return "`" + strings.ReplaceAll(s, "`", "``") + "`"
 }
 
+// QuoteString quotes the string given with single quotes, escaping any quotes or backslashes it contains.
+func QuoteString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "'", `\'`)
+	return "'" + s + "'"
+}
+
+// ErrNotExpressibleInSql is returned when a schema has a column which can't be written in a CREATE TABLE statement,
+// such as a column of a type which has no SQL equivalent, or with a constraint which SQL can't express.
+var ErrNotExpressibleInSql = errors.New("the schema can't be expressed in SQL")
+
 // SchemaAsCreateStmt takes a Schema and returns a string representing a SQL create table command that could be used to
 // create this table
 func SchemaAsCreateStmt(tableName string, sch schema.Schema) string {
+	stmt, err := createTableStmt(tableName, sch, "")
+
+	// TODO: fix panics
+	if err != nil {
+		panic(err)
+	}
+
+	return stmt + ";"
+}
+
+// ShowCreateTableStmt returns the statement SHOW CREATE TABLE shows for a table with the schema given, in the form
+// MySQL shows it. Executing the statement creates a table with an equivalent schema: the primary key is in the order
+// of the table's key, and each column's tag is kept in its comment. Dolt's uuid, bool and inlineblob types are written
+// as the SQL types dolt shows them as, CHAR(36), TINYINT and BINARY. ErrNotExpressibleInSql is returned for schemas
+// which SQL can't express, rather than a statement which would create a different table.
+func ShowCreateTableStmt(tableName string, sch schema.Schema) (string, error) {
+	return createTableStmt(tableName, sch, " ENGINE=InnoDB DEFAULT CHARSET=utf8mb4")
+}
+
+func createTableStmt(tableName string, sch schema.Schema, tableOptions string) (string, error) {
 	sb := &strings.Builder{}
 	fmt.Fprintf(sb, "CREATE TABLE %s (\n", QuoteIdentifier(tableName))
 
 	firstLine := true
-	_ = sch.GetAllCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+	err := sch.GetAllCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+		if err := checkColExpressible(col); err != nil {
+			return true, err
+		}
+
 		if firstLine {
 			firstLine = false
 		} else {
@@ -53,8 +89,12 @@ func SchemaAsCreateStmt(tableName string, sch schema.Schema) string {
 		return false, nil
 	})
 
+	if err != nil {
+		return "", err
+	}
+
 	firstPK := true
-	err := sch.GetPKCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+	err = sch.GetPKCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
 		if firstPK {
 			sb.WriteString(",\n  PRIMARY KEY (")
 			firstPK = false
@@ -65,13 +105,35 @@ func SchemaAsCreateStmt(tableName string, sch schema.Schema) string {
 		return false, nil
 	})
 
-	// TODO: fix panics
 	if err != nil {
-		panic(err)
+		return "", err
+	}
+
+	sb.WriteString(")\n)")
+	sb.WriteString(tableOptions)
+
+	if sch.GetComment() != "" {
+		sb.WriteString(" COMMENT=")
+		sb.WriteString(QuoteString(sch.GetComment()))
+	}
+
+	return sb.String(), nil
+}
+
+// checkColExpressible returns ErrNotExpressibleInSql if the column given can't be written in a CREATE TABLE statement.
+func checkColExpressible(col schema.Column) error {
+	switch col.TypeInfo.GetTypeIdentifier() {
+	case typeinfo.UnknownTypeIdentifier, typeinfo.TupleTypeIdentifier:
+		return fmt.Errorf("%w: column %s has the type %s, which has no SQL equivalent", ErrNotExpressibleInSql, col.Name, col.TypeInfo.GetTypeIdentifier())
 	}
 
-	sb.WriteString(")\n);")
-	return sb.String()
+	for _, cnst := range col.Constraints {
+		if cnst.GetConstraintType() != schema.NotNullConstraintType {
+			return fmt.Errorf("%w: column %s has the constraint %s", ErrNotExpressibleInSql, col.Name, cnst.GetConstraintType())
+		}
+	}
+
+	return nil
 }
 
 func DropTableStmt(tableName string) string {
@@ -122,6 +184,41 @@ func AlterTableRenameColStmt(tableName string, oldColName string, newColName str
 	return b.String()
 }
 
+func AlterTableCommentStmt(tableName string, comment string) string {
+	var b strings.Builder
+	b.WriteString("ALTER TABLE ")
+	b.WriteString(QuoteIdentifier(tableName))
+	b.WriteString(" COMMENT=")
+	b.WriteString(QuoteString(comment))
+	b.WriteRune(';')
+	return b.String()
+}
+
+func AlterTablePrimaryKeyStmt(tableName string, pkColNames []string) string {
+	var b strings.Builder
+	b.WriteString("ALTER TABLE ")
+	b.WriteString(QuoteIdentifier(tableName))
+	b.WriteString(" DROP PRIMARY KEY, ADD PRIMARY KEY (")
+	for i, name := range pkColNames {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(QuoteIdentifier(name))
+	}
+	b.WriteString(");")
+	return b.String()
+}
+
+func AlterTableModifyColStmt(tableName string, colDef string) string {
+	var b strings.Builder
+	b.WriteString("ALTER TABLE ")
+	b.WriteString(QuoteIdentifier(tableName))
+	b.WriteString(" MODIFY COLUMN ")
+	b.WriteString(colDef)
+	b.WriteRune(';')
+	return b.String()
+}
+
 func RenameTableStmt(fromName string, toName string) string {
 	var b strings.Builder
 	b.WriteString("RENAME TABLE ")
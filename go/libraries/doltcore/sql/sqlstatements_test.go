@@ -15,16 +15,20 @@
 package sql
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/typeinfo"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sql/sqltestutil"
 	"github.com/liquidata-inc/dolt/go/store/types"
 
 	"github.com/google/uuid"
+	gmssql "github.com/src-d/go-mysql-server/sql"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const expectedCreateSQL = "CREATE TABLE `table_name` (\n" +
@@ -57,6 +61,56 @@ func TestSchemaAsCreateStmt(t *testing.T) {
 	stmt := SchemaAsCreateStmt("table_name", tSchema)
 
 	assert.Equal(t, expectedCreateSQL, stmt)
+
+	stmt = SchemaAsCreateStmt("table_name", schema.SchemaWithComment(tSchema, "it's people"))
+	assert.Equal(t, expectedCreateSQL[:len(expectedCreateSQL)-1]+" COMMENT='it\\'s people';", stmt)
+}
+
+func TestShowCreateTableStmt(t *testing.T) {
+	stmt, err := ShowCreateTableStmt("table_name", schema.SchemaWithComment(sqltestutil.PeopleTestSchema, "it's people"))
+	require.NoError(t, err)
+	assert.Equal(t, expectedCreateSQL[:len(expectedCreateSQL)-1]+" ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='it\\'s people'", stmt)
+
+	unknownCol, err := schema.NewColumnWithTypeInfo("unknown", 1, typeinfo.UnknownType, false)
+	require.NoError(t, err)
+	constrainedCol := schema.NewColumn("constrained", 2, types.IntKind, false, unknownConstraint{})
+
+	for _, col := range []schema.Column{unknownCol, constrainedCol} {
+		colColl, err := schema.NewColCollection(schema.NewColumn("pk", 0, types.IntKind, true, schema.NotNullConstraint{}), col)
+		require.NoError(t, err)
+
+		_, err = ShowCreateTableStmt("table_name", schema.SchemaFromCols(colColl))
+		assert.True(t, errors.Is(err, ErrNotExpressibleInSql), "unexpected error %v", err)
+	}
+}
+
+type unknownConstraint struct {
+	schema.NotNullConstraint
+}
+
+func (unknownConstraint) GetConstraintType() string {
+	return "unknown"
+}
+
+func TestSqlTypeString(t *testing.T) {
+	enumType := gmssql.MustCreateEnumType([]string{"a", "it's", `back\slash`}, gmssql.Collation_Default)
+	setType := gmssql.MustCreateSetType([]string{"a", "b'c"}, gmssql.Collation_latin1_swedish_ci)
+
+	assert.Equal(t, `ENUM('a','it\'s','back\\slash')`, SqlTypeString(enumType))
+	assert.Equal(t, `SET('a','b\'c') CHARACTER SET latin1 COLLATE latin1_swedish_ci`, SqlTypeString(setType))
+	assert.Equal(t, "BIGINT", SqlTypeString(gmssql.Int64))
+}
+
+func TestAlterTableCommentStmt(t *testing.T) {
+	stmt := AlterTableCommentStmt("table_name", "it's people")
+
+	assert.Equal(t, "ALTER TABLE `table_name` COMMENT='it\\'s people';", stmt)
+}
+
+func TestAlterTablePrimaryKeyStmt(t *testing.T) {
+	stmt := AlterTablePrimaryKeyStmt("table_name", []string{"last_name", "first_name"})
+
+	assert.Equal(t, "ALTER TABLE `table_name` DROP PRIMARY KEY, ADD PRIMARY KEY (`last_name`, `first_name`);", stmt)
 }
 
 func TestTableDropStmt(t *testing.T) {
@@ -26,7 +26,6 @@ import (
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
-	"github.com/liquidata-inc/dolt/go/libraries/doltcore/envtestutils"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/typeinfo"
@@ -397,90 +396,3 @@ var InitialHistSch = dtestutils.MustSchema(idColTag0TypeUUID, firstColTag1TypeSt
 var AddAddrAt3HistSch = dtestutils.MustSchema(idColTag0TypeUUID, firstColTag1TypeStr, lastColTag2TypeStr, addrColTag3TypeStr)
 var AddAgeAt4HistSch = dtestutils.MustSchema(idColTag0TypeUUID, firstColTag1TypeStr, lastColTag2TypeStr, ageColTag4TypeInt)
 var ReaddAgeAt5HistSch = dtestutils.MustSchema(idColTag0TypeUUID, firstColTag1TypeStr, lastColTag2TypeStr, addrColTag3TypeStr, ageColTag5TypeUint)
-
-func CreateHistory(ctx context.Context, dEnv *env.DoltEnv, t *testing.T) []envtestutils.HistoryNode {
-	vrw := dEnv.DoltDB.ValueReadWriter()
-
-	return []envtestutils.HistoryNode{
-		{
-			Branch:    "seed",
-			CommitMsg: "Seeding with initial user data",
-			Updates: map[string]envtestutils.TableUpdate{
-				tblName: {
-					NewSch: InitialHistSch,
-					NewRowData: dtestutils.MustRowData(t, ctx, vrw, InitialHistSch, []row.TaggedValues{
-						{0: types.Int(0), 1: types.String("Aaron"), 2: types.String("Son")},
-						{0: types.Int(1), 1: types.String("Brian"), 2: types.String("Hendriks")},
-						{0: types.Int(2), 1: types.String("Tim"), 2: types.String("Sehn")},
-					}),
-				},
-			},
-			Children: []envtestutils.HistoryNode{
-				{
-					Branch:    "add-age",
-					CommitMsg: "Adding int age to users with tag 3",
-					Updates: map[string]envtestutils.TableUpdate{
-						tblName: {
-							NewSch: AddAgeAt4HistSch,
-							NewRowData: dtestutils.MustRowData(t, ctx, vrw, AddAgeAt4HistSch, []row.TaggedValues{
-								{0: types.Int(0), 1: types.String("Aaron"), 2: types.String("Son"), 4: types.Int(35)},
-								{0: types.Int(1), 1: types.String("Brian"), 2: types.String("Hendriks"), 4: types.Int(38)},
-								{0: types.Int(2), 1: types.String("Tim"), 2: types.String("Sehn"), 4: types.Int(37)},
-								{0: types.Int(3), 1: types.String("Zach"), 2: types.String("Musgrave"), 4: types.Int(37)},
-							}),
-						},
-					},
-					Children: nil,
-				},
-				{
-					Branch:    "master",
-					CommitMsg: "Adding string address to users with tag 3",
-					Updates: map[string]envtestutils.TableUpdate{
-						tblName: {
-							NewSch: AddAddrAt3HistSch,
-							NewRowData: dtestutils.MustRowData(t, ctx, vrw, AddAddrAt3HistSch, []row.TaggedValues{
-								{0: types.Int(0), 1: types.String("Aaron"), 2: types.String("Son"), 3: types.String("123 Fake St")},
-								{0: types.Int(1), 1: types.String("Brian"), 2: types.String("Hendriks"), 3: types.String("456 Bull Ln")},
-								{0: types.Int(2), 1: types.String("Tim"), 2: types.String("Sehn"), 3: types.String("789 Not Real Ct")},
-								{0: types.Int(3), 1: types.String("Zach"), 2: types.String("Musgrave")},
-								{0: types.Int(4), 1: types.String("Matt"), 2: types.String("Jesuele")},
-							}),
-						},
-					},
-					Children: []envtestutils.HistoryNode{
-						{
-							Branch:    "master",
-							CommitMsg: "Re-add age as a uint with tag 4",
-							Updates: map[string]envtestutils.TableUpdate{
-								tblName: {
-									NewSch: ReaddAgeAt5HistSch,
-									NewRowData: dtestutils.MustRowData(t, ctx, vrw, ReaddAgeAt5HistSch, []row.TaggedValues{
-										{0: types.Int(0), 1: types.String("Aaron"), 2: types.String("Son"), 3: types.String("123 Fake St"), 5: types.Uint(35)},
-										{0: types.Int(1), 1: types.String("Brian"), 2: types.String("Hendriks"), 3: types.String("456 Bull Ln"), 5: types.Uint(38)},
-										{0: types.Int(2), 1: types.String("Tim"), 2: types.String("Sehn"), 3: types.String("789 Not Real Ct"), 5: types.Uint(37)},
-										{0: types.Int(3), 1: types.String("Zach"), 2: types.String("Musgrave"), 3: types.String("-1 Imaginary Wy"), 5: types.Uint(37)},
-										{0: types.Int(4), 1: types.String("Matt"), 2: types.String("Jesuele")},
-										{0: types.Int(5), 1: types.String("Daylon"), 2: types.String("Wilkins")},
-									}),
-								},
-							},
-							Children: nil,
-						},
-					},
-				},
-			},
-		},
-	}
-}
-
-func CreateWorkingRootUpdate() map[string]envtestutils.TableUpdate {
-	return map[string]envtestutils.TableUpdate{
-		tblName: {
-			RowUpdates: []row.Row{
-				mustRow(row.New(types.Format_Default, ReaddAgeAt5HistSch, row.TaggedValues{
-					0: types.Int(6), 1: types.String("Katie"), 2: types.String("McCulloch"),
-				})),
-			},
-		},
-	}
-}
@@ -84,6 +84,41 @@ var BasicDeleteTests = []DeleteTest{
 		ExpectedRows:   CompressRows(PeopleTestSchema, Homer, Marge, Bart),
 		ExpectedSchema: CompressSchema(PeopleTestSchema),
 	},
+	{
+		Name:           "delete where id between",
+		DeleteQuery:    "delete from people where id between 1 and 3",
+		SelectQuery:    "select * from people",
+		ExpectedRows:   CompressRows(PeopleTestSchema, Homer, Moe, Barney),
+		ExpectedSchema: CompressSchema(PeopleTestSchema),
+	},
+	{
+		Name:           "delete where id in range",
+		DeleteQuery:    "delete from people where id > 1 and id < 4",
+		SelectQuery:    "select * from people",
+		ExpectedRows:   CompressRows(PeopleTestSchema, Homer, Marge, Moe, Barney),
+		ExpectedSchema: CompressSchema(PeopleTestSchema),
+	},
+	{
+		Name:           "delete where id in empty range",
+		DeleteQuery:    "delete from people where id > 3 and id < 2",
+		SelectQuery:    "select * from people",
+		ExpectedRows:   CompressRows(PeopleTestSchema, Homer, Marge, Bart, Lisa, Moe, Barney),
+		ExpectedSchema: CompressSchema(PeopleTestSchema),
+	},
+	{
+		Name:           "delete where id in range and last_name matches",
+		DeleteQuery:    "delete from people where id >= 2 and last_name = 'Simpson'",
+		SelectQuery:    "select * from people",
+		ExpectedRows:   CompressRows(PeopleTestSchema, Homer, Marge, Moe, Barney),
+		ExpectedSchema: CompressSchema(PeopleTestSchema),
+	},
+	{
+		Name:           "delete where id greater than reversed",
+		DeleteQuery:    "delete from people where 2 < id",
+		SelectQuery:    "select * from people",
+		ExpectedRows:   CompressRows(PeopleTestSchema, Homer, Marge, Bart),
+		ExpectedSchema: CompressSchema(PeopleTestSchema),
+	},
 	{
 		Name:           "delete where id equals nothing",
 		DeleteQuery:    "delete from people where id = 9999",
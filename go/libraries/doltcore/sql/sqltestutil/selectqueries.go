@@ -859,10 +859,10 @@ var AsOfTests = []SelectTest{
 		},
 		ExpectedSchema: ReaddAgeAt5HistSch,
 	},
-	// Because of an implementation detail in the way we process history for test setup, each commit is 2 hours apart.
+	// The commits of the history are made a minute apart, starting with the initial commit at 2020-01-01 00:00:00.
 	{
 		Name:  "select * from timestamp after HEAD",
-		Query: "select * from test_table as of CONVERT('1970-01-01 10:00:00', DATETIME)",
+		Query: "select * from test_table as of CONVERT('2020-01-01 00:10:00', DATETIME)",
 		ExpectedRows: []row.Row{
 			mustRow(row.New(types.Format_7_18, ReaddAgeAt5HistSch, row.TaggedValues{0: types.Int(0), 1: types.String("Aaron"), 2: types.String("Son"), 3: types.String("123 Fake St"), 5: types.Uint(35)})),
 			mustRow(row.New(types.Format_7_18, ReaddAgeAt5HistSch, row.TaggedValues{0: types.Int(1), 1: types.String("Brian"), 2: types.String("Hendriks"), 3: types.String("456 Bull Ln"), 5: types.Uint(38)})),
@@ -875,7 +875,7 @@ var AsOfTests = []SelectTest{
 	},
 	{
 		Name:  "select * from timestamp, HEAD exact",
-		Query: "select * from test_table as of CONVERT('1970-01-01 08:00:00', DATETIME)",
+		Query: "select * from test_table as of CONVERT('2020-01-01 00:04:00', DATETIME)",
 		ExpectedRows: []row.Row{
 			mustRow(row.New(types.Format_7_18, ReaddAgeAt5HistSch, row.TaggedValues{0: types.Int(0), 1: types.String("Aaron"), 2: types.String("Son"), 3: types.String("123 Fake St"), 5: types.Uint(35)})),
 			mustRow(row.New(types.Format_7_18, ReaddAgeAt5HistSch, row.TaggedValues{0: types.Int(1), 1: types.String("Brian"), 2: types.String("Hendriks"), 3: types.String("456 Bull Ln"), 5: types.Uint(38)})),
@@ -888,7 +888,7 @@ var AsOfTests = []SelectTest{
 	},
 	{
 		Name:  "select * from timestamp, HEAD~ + 1",
-		Query: "select * from test_table as of CONVERT('1970-01-01 07:00:00', DATETIME)",
+		Query: "select * from test_table as of CONVERT('2020-01-01 00:03:30', DATETIME)",
 		ExpectedRows: []row.Row{
 			mustRow(row.New(types.Format_7_18, AddAddrAt3HistSch, row.TaggedValues{0: types.Int(0), 1: types.String("Aaron"), 2: types.String("Son"), 3: types.String("123 Fake St")})),
 			mustRow(row.New(types.Format_7_18, AddAddrAt3HistSch, row.TaggedValues{0: types.Int(1), 1: types.String("Brian"), 2: types.String("Hendriks"), 3: types.String("456 Bull Ln")})),
@@ -900,7 +900,7 @@ var AsOfTests = []SelectTest{
 	},
 	{
 		Name:  "select * from timestamp, HEAD~",
-		Query: "select * from test_table as of CONVERT('1970-01-01 06:00:00', DATETIME)",
+		Query: "select * from test_table as of CONVERT('2020-01-01 00:03:00', DATETIME)",
 		ExpectedRows: []row.Row{
 			mustRow(row.New(types.Format_7_18, AddAddrAt3HistSch, row.TaggedValues{0: types.Int(0), 1: types.String("Aaron"), 2: types.String("Son"), 3: types.String("123 Fake St")})),
 			mustRow(row.New(types.Format_7_18, AddAddrAt3HistSch, row.TaggedValues{0: types.Int(1), 1: types.String("Brian"), 2: types.String("Hendriks"), 3: types.String("456 Bull Ln")})),
@@ -912,7 +912,7 @@ var AsOfTests = []SelectTest{
 	},
 	{
 		Name:        "select * from timestamp, before table creation",
-		Query:       "select * from test_table as of CONVERT('1970-01-01 02:00:00', DATETIME)",
+		Query:       "select * from test_table as of CONVERT('2020-01-01 00:02:00', DATETIME)",
 		ExpectedErr: "not found",
 	},
 }
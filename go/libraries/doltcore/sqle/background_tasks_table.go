@@ -0,0 +1,88 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"github.com/src-d/go-mysql-server/sql"
+
+	"github.com/liquidata-inc/dolt/go/store/background"
+)
+
+const (
+	// BackgroundTasksTableName is the system table name
+	BackgroundTasksTableName = "dolt_background_tasks"
+)
+
+var _ sql.Table = (*BackgroundTasksTable)(nil)
+
+// BackgroundTasksTable is a sql.Table implementation that implements a system table which shows the background tasks
+// of the process, such as conjoins and pushes, which are running, and their progress. The tasks are those of the
+// whole process rather than of the database the table is read from.
+type BackgroundTasksTable struct {
+	sched *background.Scheduler
+}
+
+// NewBackgroundTasksTable creates a BackgroundTasksTable of the tasks of background.DefaultScheduler
+func NewBackgroundTasksTable() *BackgroundTasksTable {
+	return &BackgroundTasksTable{background.DefaultScheduler}
+}
+
+// Name is a sql.Table interface function which returns the name of the table which is defined by the constant
+// BackgroundTasksTableName
+func (bt *BackgroundTasksTable) Name() string {
+	return BackgroundTasksTableName
+}
+
+// String is a sql.Table interface function which returns the name of the table which is defined by the constant
+// BackgroundTasksTableName
+func (bt *BackgroundTasksTable) String() string {
+	return BackgroundTasksTableName
+}
+
+// Schema is a sql.Table interface function that gets the sql.Schema of the background tasks system table
+func (bt *BackgroundTasksTable) Schema() sql.Schema {
+	return []*sql.Column{
+		{Name: "id", Type: sql.Uint64, Source: BackgroundTasksTableName, PrimaryKey: true, Nullable: false},
+		{Name: "name", Type: sql.Text, Source: BackgroundTasksTableName, PrimaryKey: false, Nullable: false},
+		{Name: "priority", Type: sql.Text, Source: BackgroundTasksTableName, PrimaryKey: false, Nullable: false},
+		{Name: "state", Type: sql.Text, Source: BackgroundTasksTableName, PrimaryKey: false, Nullable: false},
+		{Name: "started", Type: sql.Datetime, Source: BackgroundTasksTableName, PrimaryKey: false, Nullable: false},
+		{Name: "bytes_done", Type: sql.Int64, Source: BackgroundTasksTableName, PrimaryKey: false, Nullable: false},
+		{Name: "bytes_total", Type: sql.Int64, Source: BackgroundTasksTableName, PrimaryKey: false, Nullable: true},
+	}
+}
+
+// Partitions is a sql.Table interface function that returns a partition of the data.  Currently the data is unpartitioned.
+func (bt *BackgroundTasksTable) Partitions(*sql.Context) (sql.PartitionIter, error) {
+	return &doltTablePartitionIter{}, nil
+}
+
+// PartitionRows is a sql.Table interface function that gets a row iterator for a partition. The tasks are ordered by
+// when they were started. The total bytes of a task which doesn't know how much IO it will do are NULL.
+func (bt *BackgroundTasksTable) PartitionRows(sqlCtx *sql.Context, part sql.Partition) (sql.RowIter, error) {
+	tasks := bt.sched.Tasks()
+
+	rows := make([]sql.Row, len(tasks))
+	for i, t := range tasks {
+		var total interface{}
+		if t.Total > 0 {
+			total = t.Total
+		}
+
+		rows[i] = sql.NewRow(t.ID, t.Name, t.Priority.String(), string(t.State), t.Started, t.Done, total)
+	}
+
+	return sql.RowsToRowIter(rows...), nil
+}
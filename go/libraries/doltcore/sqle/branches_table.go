@@ -30,14 +30,17 @@ const (
 )
 
 var _ sql.Table = (*BranchesTable)(nil)
+var _ sql.FilteredTable = (*BranchesTable)(nil)
 var _ sql.UpdatableTable = (*BranchesTable)(nil)
 var _ sql.DeletableTable = (*BranchesTable)(nil)
 var _ sql.InsertableTable = (*BranchesTable)(nil)
 var _ sql.ReplaceableTable = (*BranchesTable)(nil)
 
-// BranchesTable is a sql.Table implementation that implements a system table which shows the dolt branches
+// BranchesTable is a sql.Table implementation that implements a system table which shows the dolt branches. A filter
+// on the name of the branch limits the branches whose head commits are read.
 type BranchesTable struct {
-	ddb *doltdb.DoltDB
+	ddb     *doltdb.DoltDB
+	filters []sql.Expression
 }
 
 // NewBranchesTable creates a BranchesTable
@@ -48,7 +51,7 @@ func NewBranchesTable(sqlCtx *sql.Context, dbName string) (*BranchesTable, error
 		return nil, sql.ErrDatabaseNotFound.New(dbName)
 	}
 
-	return &BranchesTable{ddb}, nil
+	return &BranchesTable{ddb: ddb}, nil
 }
 
 // Name is a sql.Table interface function which returns the name of the table which is defined by the constant
@@ -75,6 +78,21 @@ func (bt *BranchesTable) Schema() sql.Schema {
 	}
 }
 
+// HandledFilters returns the filters which the table evaluates while reading the branches.
+func (bt *BranchesTable) HandledFilters(filters []sql.Expression) []sql.Expression {
+	return handledSystemTableFilters(filters)
+}
+
+// Filters returns the filters applied to this table.
+func (bt *BranchesTable) Filters() []sql.Expression {
+	return bt.filters
+}
+
+// WithFilters returns a copy of the table which only has the rows satisfying |filters|.
+func (bt *BranchesTable) WithFilters(filters []sql.Expression) sql.Table {
+	return &BranchesTable{ddb: bt.ddb, filters: filters}
+}
+
 // Partitions is a sql.Table interface function that returns a partition of the data.  Currently the data is unpartitioned.
 func (bt *BranchesTable) Partitions(*sql.Context) (sql.PartitionIter, error) {
 	return &doltTablePartitionIter{}, nil
@@ -82,50 +100,78 @@ func (bt *BranchesTable) Partitions(*sql.Context) (sql.PartitionIter, error) {
 
 // PartitionRows is a sql.Table interface function that gets a row iterator for a partition
 func (bt *BranchesTable) PartitionRows(sqlCtx *sql.Context, part sql.Partition) (sql.RowIter, error) {
-	return NewBranchItr(sqlCtx, bt.ddb)
+	return NewBranchItr(sqlCtx, bt.ddb, bt.filters)
 }
 
 // BranchItr is a sql.RowItr implementation which iterates over each commit as if it's a row in the table.
 type BranchItr struct {
-	branches []string
-	commits  []*doltdb.Commit
-	idx      int
+	rows []sql.Row
+	idx  int
 }
 
-// NewBranchItr creates a BranchItr from the current environment.
-func NewBranchItr(sqlCtx *sql.Context, ddb *doltdb.DoltDB) (*BranchItr, error) {
+// NewBranchItr creates a BranchItr from the current environment, with the rows which satisfy |filters|.
+func NewBranchItr(sqlCtx *sql.Context, ddb *doltdb.DoltDB, filters []sql.Expression) (*BranchItr, error) {
 	branches, err := ddb.GetBranches(sqlCtx)
 
 	if err != nil {
 		return nil, err
 	}
 
-	branchNames := make([]string, len(branches))
-	commits := make([]*doltdb.Commit, len(branches))
-	for i, branch := range branches {
+	names, limited, err := equalityValues(sqlCtx, filters, "name")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []sql.Row
+	for _, branch := range branches {
+		if limited && !names.Contains(branch.GetPath()) {
+			continue
+		}
+
 		cs, err := doltdb.NewCommitSpec("HEAD", branch.GetPath())
 
 		if err != nil {
 			return nil, err
 		}
 
-		commit, err := ddb.Resolve(sqlCtx, cs)
+		cm, err := ddb.Resolve(sqlCtx, cs)
+
+		if err != nil {
+			return nil, err
+		}
+
+		meta, err := cm.GetCommitMeta()
 
 		if err != nil {
 			return nil, err
 		}
 
-		branchNames[i] = branch.GetPath()
-		commits[i] = commit
+		h, err := cm.HashOf()
+
+		if err != nil {
+			return nil, err
+		}
+
+		r := sql.NewRow(branch.GetPath(), h.String(), meta.Name, meta.Email, meta.Time(), meta.Description)
+		ok, err := filtersMatch(sqlCtx, filters, r)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			rows = append(rows, r)
+		}
 	}
 
-	return &BranchItr{branchNames, commits, 0}, nil
+	return &BranchItr{rows, 0}, nil
 }
 
 // Next retrieves the next row. It will return io.EOF if it's the last row.
 // After retrieving the last row, Close will be automatically closed.
 func (itr *BranchItr) Next() (sql.Row, error) {
-	if itr.idx >= len(itr.commits) {
+	if itr.idx >= len(itr.rows) {
 		return nil, io.EOF
 	}
 
@@ -133,21 +179,7 @@ func (itr *BranchItr) Next() (sql.Row, error) {
 		itr.idx++
 	}()
 
-	name := itr.branches[itr.idx]
-	cm := itr.commits[itr.idx]
-	meta, err := cm.GetCommitMeta()
-
-	if err != nil {
-		return nil, err
-	}
-
-	h, err := cm.HashOf()
-
-	if err != nil {
-		return nil, err
-	}
-
-	return sql.NewRow(name, h.String(), meta.Name, meta.Email, meta.Time(), meta.Description), nil
+	return itr.rows[itr.idx], nil
 }
 
 // Close closes the iterator.
@@ -0,0 +1,145 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/encoding"
+)
+
+// makeColdColumns rewrites the table given in the working set of the test with the columns given made cold.
+func (tt *transactionTest) makeColdColumns(tblName string, colNames ...string) {
+	ctx := context.Background()
+	root, err := tt.dEnv.WorkingRoot(ctx)
+	require.NoError(tt.t, err)
+	tbl, _, err := root.GetTable(ctx, tblName)
+	require.NoError(tt.t, err)
+	sch, err := tbl.GetSchema(ctx)
+	require.NoError(tt.t, err)
+
+	allCols := sch.GetAllCols()
+	for _, name := range colNames {
+		col, ok := allCols.GetByName(name)
+		require.True(tt.t, ok)
+		cold := col
+		cold.Cold = true
+		allCols, err = allCols.Replace(col, cold)
+		require.NoError(tt.t, err)
+	}
+
+	schVal, err := encoding.MarshalSchemaAsNomsValue(ctx, root.VRW(), schema.SchemaFromCols(allCols))
+	require.NoError(tt.t, err)
+	rowData, err := tbl.GetRowData(ctx)
+	require.NoError(tt.t, err)
+	tbl, err = doltdb.NewTable(ctx, root.VRW(), schVal, rowData)
+	require.NoError(tt.t, err)
+	root, err = root.PutTable(ctx, tblName, tbl)
+	require.NoError(tt.t, err)
+	require.NoError(tt.t, tt.dEnv.UpdateWorkingRoot(ctx, root))
+}
+
+// coldRowCount returns the number of rows of the table given with cold values in the working set of the test.
+func (tt *transactionTest) coldRowCount(tblName string) uint64 {
+	ctx := context.Background()
+	root, err := tt.dEnv.WorkingRoot(ctx)
+	require.NoError(tt.t, err)
+	tbl, _, err := root.GetTable(ctx, tblName)
+	require.NoError(tt.t, err)
+	cold, ok, err := tbl.GetColdRowData(ctx)
+	require.NoError(tt.t, err)
+	require.True(tt.t, ok)
+
+	return cold.Len()
+}
+
+func TestColdColumns(t *testing.T) {
+	tt := newTransactionTest(t)
+	allQuery := "select * from people order by id"
+	expected := tt.workingRows(allQuery)
+
+	tt.makeColdColumns("people", "uuid", "num_episodes")
+	// homer is the only person without cold values
+	assert.Equal(t, uint64(5), tt.coldRowCount("people"))
+
+	s := tt.newSession()
+	assert.Equal(t, expected, tt.mustExec(s, allQuery))
+	assert.Equal(t, []sql.Row{{int64(1), uint64(111)}}, tt.mustExec(s, "select id, num_episodes from people where first_name = 'Marge'"))
+	assert.Equal(t, []sql.Row{{"Bart"}}, tt.mustExec(s, "select first_name from people where num_episodes = 222"))
+	assert.Equal(t, []sql.Row{{int64(6)}}, tt.mustExec(s, "select count(*) from people"))
+
+	tt.mustExec(s,
+		"update people set num_episodes = 1 where id = 0",
+		"update people set uuid = null, num_episodes = null where id = 1",
+		"update people set age = 11 where id = 2",
+		"delete from people where id = 3",
+		`insert into people (id, first_name, last_name, num_episodes) values (10, "Maggie", "Simpson", 2)`,
+	)
+	assert.Equal(t, uint64(5), tt.coldRowCount("people"))
+	assert.Equal(t, []sql.Row{
+		{int64(0), int64(40), uint64(1)},
+		{int64(1), int64(38), nil},
+		{int64(2), int64(11), uint64(222)},
+		{int64(4), int64(48), uint64(444)},
+		{int64(5), int64(40), uint64(555)},
+		{int64(10), nil, uint64(2)},
+	}, tt.mustExec(s, "select id, age, num_episodes from people order by id"))
+}
+
+func TestColdColumnsProjection(t *testing.T) {
+	tt := newTransactionTest(t)
+	tt.makeColdColumns("people", "uuid", "num_episodes")
+
+	ctx := tt.newSession()
+	root, err := tt.db.GetRoot(ctx)
+	require.NoError(t, err)
+	tbl, ok, err := tt.db.GetTableInsensitiveWithRoot(ctx, root, "people")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// scans which don't project the cold columns return rows of the same width without their values
+	numEpisodesIdx := tbl.Schema().IndexOf("num_episodes", "people")
+	readNumEpisodes := func(tbl sql.Table) []interface{} {
+		rows, err := sql.RowIterToRows(rowIter(t, ctx, tbl))
+		require.NoError(t, err)
+		var vals []interface{}
+		for _, r := range rows {
+			require.Len(t, r, len(tbl.Schema()))
+			vals = append(vals, r[numEpisodesIdx])
+		}
+		return vals
+	}
+
+	projected := tbl.(sql.ProjectedTable).WithProjection([]string{"id", "first_name"})
+	assert.Equal(t, []string{"id", "first_name"}, projected.(sql.ProjectedTable).Projection())
+	assert.Equal(t, []interface{}{nil, nil, nil, nil, nil, nil}, readNumEpisodes(projected))
+
+	projected = tbl.(sql.ProjectedTable).WithProjection([]string{"id", "NUM_EPISODES"})
+	assert.Equal(t, []interface{}{nil, uint64(111), uint64(222), uint64(333), uint64(444), uint64(555)}, readNumEpisodes(projected))
+	assert.Equal(t, []interface{}{nil, uint64(111), uint64(222), uint64(333), uint64(444), uint64(555)}, readNumEpisodes(tbl))
+}
+
+func rowIter(t *testing.T, ctx *sql.Context, tbl sql.Table) sql.RowIter {
+	partitions, err := tbl.Partitions(ctx)
+	require.NoError(t, err)
+	return sql.NewTableRowIter(ctx, tbl, partitions)
+}
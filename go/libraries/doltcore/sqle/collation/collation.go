@@ -0,0 +1,118 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package collation implements the comparison of strings according to the collations of the columns they come from.
+// The sql engine compares strings by their bytes, so the comparisons, LIKE expressions and sorts involving columns
+// with other collations are rewritten by an analyzer rule to compare the weight strings of their operands instead.
+package collation
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// strength is how much of the difference between two characters a collation takes into account
+type strength int
+
+const (
+	// binaryStrength compares the bytes of strings
+	binaryStrength strength = iota
+	// primaryStrength ignores the case and accents of characters
+	primaryStrength
+	// secondaryStrength ignores the case of characters, but not their accents
+	secondaryStrength
+	// tertiaryStrength takes both the case and accents of characters into account
+	tertiaryStrength
+)
+
+type behavior struct {
+	strength strength
+	// padSpace is whether trailing spaces are ignored, as with all of MySQL's collations except the 0900 ones and binary
+	padSpace bool
+}
+
+// behaviorOf returns how strings are compared in |c|, following the suffixes of MySQL's collation names.
+func behaviorOf(c sql.Collation) behavior {
+	name := c.String()
+	padSpace := !strings.Contains(name, "_0900_")
+
+	switch {
+	case c == sql.Collation_binary:
+		return behavior{binaryStrength, false}
+	case strings.HasSuffix(name, "_bin"):
+		return behavior{binaryStrength, padSpace}
+	case strings.HasSuffix(name, "_as_ci"):
+		return behavior{secondaryStrength, padSpace}
+	case strings.HasSuffix(name, "_ci"):
+		return behavior{primaryStrength, padSpace}
+	case strings.HasSuffix(name, "_cs"):
+		return behavior{tertiaryStrength, padSpace}
+	default:
+		return behavior{binaryStrength, padSpace}
+	}
+}
+
+// ByteOrdered returns whether |c| orders strings by their bytes, in which case comparisons in it needn't be rewritten.
+func ByteOrdered(c sql.Collation) bool {
+	b := behaviorOf(c)
+	return b.strength == binaryStrength && !b.padSpace
+}
+
+var collatorPools = map[strength]*sync.Pool{
+	primaryStrength:   newCollatorPool(collate.IgnoreCase, collate.IgnoreDiacritics),
+	secondaryStrength: newCollatorPool(collate.IgnoreCase),
+	tertiaryStrength:  newCollatorPool(),
+}
+
+// newCollatorPool returns a pool of collators with |opts|, as a collate.Collator can't be used concurrently.
+func newCollatorPool(opts ...collate.Option) *sync.Pool {
+	return &sync.Pool{
+		New: func() interface{} {
+			return collate.New(language.Und, opts...)
+		},
+	}
+}
+
+// WeightString returns the weight string of |s| in |c|. Two strings are equal in |c| if their weight strings have the
+// same bytes, and are ordered as their weight strings are.
+func WeightString(c sql.Collation, s string) string {
+	b := behaviorOf(c)
+
+	if b.padSpace {
+		s = strings.TrimRight(s, " ")
+	}
+
+	return weightString(b.strength, s)
+}
+
+func weightString(st strength, s string) string {
+	if st == binaryStrength {
+		return s
+	}
+
+	pool := collatorPools[st]
+	collator := pool.Get().(*collate.Collator)
+	defer pool.Put(collator)
+
+	return string(collator.KeyFromString(&collate.Buffer{}, s))
+}
+
+// Compare compares |a| and |b| in |c|, returning -1, 0 or 1.
+func Compare(c sql.Collation, a, b string) int {
+	return strings.Compare(WeightString(c, a), WeightString(c, b))
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collation
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		collation sql.Collation
+		a, b      string
+		expected  int
+	}{
+		{sql.Collation_utf8mb4_0900_ai_ci, "a", "A", 0},
+		{sql.Collation_utf8mb4_0900_ai_ci, "a", "á", 0},
+		{sql.Collation_utf8mb4_0900_ai_ci, "résumé", "RESUME", 0},
+		{sql.Collation_utf8mb4_0900_ai_ci, "a", "a ", -1},
+		{sql.Collation_utf8mb4_0900_ai_ci, "a", "B", -1},
+		{sql.Collation_utf8mb4_0900_ai_ci, "b", "A", 1},
+		{sql.Collation_utf8mb4_0900_as_ci, "a", "A", 0},
+		{sql.Collation_utf8mb4_0900_as_ci, "a", "á", -1},
+		{sql.Collation_utf8mb4_0900_as_cs, "a", "A", -1},
+		{sql.Collation_utf8mb4_0900_as_cs, "A", "b", -1},
+		{sql.Collation_utf8mb4_0900_as_cs, "a", "á", -1},
+		{sql.Collation_utf8mb4_0900_as_cs, "a", "a", 0},
+		{sql.Collation_utf8mb4_general_ci, "a", "A", 0},
+		{sql.Collation_utf8mb4_general_ci, "a", "á", 0},
+		{sql.Collation_utf8mb4_general_ci, "a", "a  ", 0},
+		{sql.Collation_utf8mb4_unicode_ci, "A", "a", 0},
+		{sql.Collation_latin1_swedish_ci, "ABC", "abc", 0},
+		{sql.Collation_utf8mb4_bin, "a", "A", 1},
+		{sql.Collation_utf8mb4_bin, "a", "a ", 0},
+		{sql.Collation_utf8mb4_0900_bin, "a", "a ", -1},
+		{sql.Collation_binary, "a", "a ", -1},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.collation)+" "+test.a+" "+test.b, func(t *testing.T) {
+			assert.Equal(t, test.expected, Compare(test.collation, test.a, test.b))
+			assert.Equal(t, -test.expected, Compare(test.collation, test.b, test.a))
+		})
+	}
+}
+
+func TestWeightStringOrder(t *testing.T) {
+	words := []string{"b", "B", "a", "A", "apple", "Banana"}
+
+	sortIn := func(c sql.Collation) []string {
+		sorted := append([]string(nil), words...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return WeightString(c, sorted[i]) < WeightString(c, sorted[j])
+		})
+		return sorted
+	}
+
+	assert.Equal(t, []string{"a", "A", "apple", "b", "B", "Banana"}, sortIn(sql.Collation_utf8mb4_0900_ai_ci))
+	assert.Equal(t, []string{"a", "A", "apple", "b", "B", "Banana"}, sortIn(sql.Collation_utf8mb4_0900_as_cs))
+	assert.Equal(t, []string{"A", "B", "Banana", "a", "apple", "b"}, sortIn(sql.Collation_utf8mb4_bin))
+}
+
+func TestByteOrdered(t *testing.T) {
+	assert.True(t, ByteOrdered(sql.Collation_binary))
+	assert.True(t, ByteOrdered(sql.Collation_utf8mb4_0900_bin))
+	assert.False(t, ByteOrdered(sql.Collation_utf8mb4_bin))
+	assert.False(t, ByteOrdered(sql.Collation_utf8mb4_0900_ai_ci))
+	assert.False(t, ByteOrdered(sql.Collation_utf8mb4_general_ci))
+}
+
+func TestLike(t *testing.T) {
+	tests := []struct {
+		collation sql.Collation
+		s         string
+		pattern   string
+		expected  bool
+	}{
+		{sql.Collation_utf8mb4_0900_ai_ci, "ABC", "a%", true},
+		{sql.Collation_utf8mb4_0900_ai_ci, "ábc", "a%", true},
+		{sql.Collation_utf8mb4_0900_ai_ci, "abc", "%B%", true},
+		{sql.Collation_utf8mb4_0900_ai_ci, "abc", "_B_", true},
+		{sql.Collation_utf8mb4_0900_ai_ci, "abc", "__", false},
+		{sql.Collation_utf8mb4_0900_ai_ci, "abc", "%c%c", false},
+		{sql.Collation_utf8mb4_0900_ai_ci, "abcabc", "%c%c", true},
+		{sql.Collation_utf8mb4_0900_ai_ci, "abc ", "abc", false},
+		{sql.Collation_utf8mb4_0900_ai_ci, "", "%", true},
+		{sql.Collation_utf8mb4_0900_ai_ci, "", "_", false},
+		{sql.Collation_utf8mb4_0900_ai_ci, "50%", `50\%`, true},
+		{sql.Collation_utf8mb4_0900_ai_ci, "500", `50\%`, false},
+		{sql.Collation_utf8mb4_0900_ai_ci, "a_b", `A\_B`, true},
+		{sql.Collation_utf8mb4_0900_ai_ci, `a\`, `a\`, true},
+		{sql.Collation_utf8mb4_0900_as_ci, "ábc", "a%", false},
+		{sql.Collation_utf8mb4_0900_as_ci, "ABC", "a%", true},
+		{sql.Collation_utf8mb4_0900_as_cs, "ABC", "a%", false},
+		{sql.Collation_utf8mb4_general_ci, "ABC", "abc", true},
+		{sql.Collation_utf8mb4_general_ci, "abc ", "abc", false},
+		{sql.Collation_utf8mb4_bin, "ABC", "a%", false},
+		{sql.Collation_utf8mb4_bin, "abc", "a%", true},
+		{sql.Collation_binary, "abc", "a_c", true},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.collation)+" "+test.s+" LIKE "+test.pattern, func(t *testing.T) {
+			assert.Equal(t, test.expected, Like(test.collation, test.s, test.pattern))
+		})
+	}
+}
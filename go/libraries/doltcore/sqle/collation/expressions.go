@@ -0,0 +1,138 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collation
+
+import (
+	"fmt"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+)
+
+// WeightStringExpr evaluates to the weight string of its child in a collation. The weight strings of two strings
+// compare as the strings do in the collation, so the engine's byte-wise comparisons of them are collation-aware.
+type WeightStringExpr struct {
+	expression.UnaryExpression
+	collation sql.Collation
+}
+
+var _ sql.Expression = (*WeightStringExpr)(nil)
+
+// NewWeightString returns a WeightStringExpr for |child| in |c|.
+func NewWeightString(c sql.Collation, child sql.Expression) *WeightStringExpr {
+	return &WeightStringExpr{expression.UnaryExpression{Child: child}, c}
+}
+
+// Collation returns the collation of the weight string.
+func (w *WeightStringExpr) Collation() sql.Collation {
+	return w.collation
+}
+
+// Eval implements the Expression interface.
+func (w *WeightStringExpr) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	val, err := w.Child.Eval(ctx, row)
+
+	if err != nil || val == nil {
+		return nil, err
+	}
+
+	val, err = sql.LongText.Convert(val)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return WeightString(w.collation, val.(string)), nil
+}
+
+// String implements the Stringer interface.
+func (w *WeightStringExpr) String() string {
+	return fmt.Sprintf("WEIGHT_STRING(%s COLLATE %s)", w.Child, w.collation)
+}
+
+// Type implements the Expression interface. Weight strings are compared by their bytes.
+func (w *WeightStringExpr) Type() sql.Type {
+	return sql.LongBlob
+}
+
+// WithChildren implements the Expression interface.
+func (w *WeightStringExpr) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(w, len(children), 1)
+	}
+
+	return NewWeightString(w.collation, children[0]), nil
+}
+
+// LikeExpr is a LIKE expression which matches characters according to a collation.
+type LikeExpr struct {
+	expression.BinaryExpression
+	collation sql.Collation
+}
+
+var _ sql.Expression = (*LikeExpr)(nil)
+
+// NewLike returns a LikeExpr matching |left| against the pattern |right| in |c|.
+func NewLike(c sql.Collation, left, right sql.Expression) *LikeExpr {
+	return &LikeExpr{expression.BinaryExpression{Left: left, Right: right}, c}
+}
+
+// Eval implements the Expression interface.
+func (l *LikeExpr) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	left, err := l.Left.Eval(ctx, row)
+
+	if err != nil || left == nil {
+		return nil, err
+	}
+
+	right, err := l.Right.Eval(ctx, row)
+
+	if err != nil || right == nil {
+		return nil, err
+	}
+
+	left, err = sql.LongText.Convert(left)
+
+	if err != nil {
+		return nil, err
+	}
+
+	right, err = sql.LongText.Convert(right)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return Like(l.collation, left.(string), right.(string)), nil
+}
+
+// String implements the Stringer interface.
+func (l *LikeExpr) String() string {
+	return fmt.Sprintf("%s LIKE %s COLLATE %s", l.Left, l.Right, l.collation)
+}
+
+// Type implements the Expression interface.
+func (l *LikeExpr) Type() sql.Type {
+	return sql.Boolean
+}
+
+// WithChildren implements the Expression interface.
+func (l *LikeExpr) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 2 {
+		return nil, sql.ErrInvalidChildrenNumber.New(l, len(children), 2)
+	}
+
+	return NewLike(l.collation, children[0], children[1]), nil
+}
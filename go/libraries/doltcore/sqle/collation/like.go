@@ -0,0 +1,102 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collation
+
+import "github.com/src-d/go-mysql-server/sql"
+
+type patternKind int
+
+const (
+	literalChar patternKind = iota
+	anyChar
+	anyChars
+)
+
+type patternElem struct {
+	kind patternKind
+	// weight is the weight string of the character matched by a literalChar
+	weight string
+}
+
+// Like returns whether |s| matches the LIKE |pattern| in |c|. A % in the pattern matches any number of characters,
+// a _ matches exactly one character, and a \ escapes the character after it. Every other character of the pattern
+// matches the characters which are equal to it in |c|. Unlike comparisons, trailing spaces are never ignored.
+func Like(c sql.Collation, s, pattern string) bool {
+	st := behaviorOf(c).strength
+	elems := parsePattern(st, pattern)
+
+	in := []rune(s)
+	weights := make([]string, len(in))
+	weightOf := func(i int) string {
+		if weights[i] == "" {
+			weights[i] = weightString(st, string(in[i]))
+		}
+
+		return weights[i]
+	}
+
+	// the greedy matching of a wildcard pattern, going back to the last % when a character doesn't match
+	var i, p int
+	star, starIn := -1, 0
+	for i < len(in) {
+		if p < len(elems) && elems[p].kind == anyChars {
+			star, starIn = p, i
+			p++
+		} else if p < len(elems) && (elems[p].kind == anyChar || elems[p].weight == weightOf(i)) {
+			i++
+			p++
+		} else if star >= 0 {
+			starIn++
+			i, p = starIn, star+1
+		} else {
+			return false
+		}
+	}
+
+	for p < len(elems) && elems[p].kind == anyChars {
+		p++
+	}
+
+	return p == len(elems)
+}
+
+func parsePattern(st strength, pattern string) []patternElem {
+	var elems []patternElem
+	var escaped bool
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+			continue
+		case r == '%':
+			elems = append(elems, patternElem{kind: anyChars})
+			continue
+		case r == '_':
+			elems = append(elems, patternElem{kind: anyChar})
+			continue
+		}
+
+		elems = append(elems, patternElem{kind: literalChar, weight: weightString(st, string(r))})
+	}
+
+	// a trailing \ matches itself
+	if escaped {
+		elems = append(elems, patternElem{kind: literalChar, weight: weightString(st, `\`)})
+	}
+
+	return elems
+}
@@ -0,0 +1,230 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collation
+
+import (
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/analyzer"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// RuleName is the name of the analyzer rule applying the collations of columns.
+const RuleName = "apply_collations"
+
+// ErrIllegalMixOfCollations is returned when two columns with different collations are compared. As in MySQL, the
+// comparison is allowed when one of the collations is a binary one, which is then used.
+var ErrIllegalMixOfCollations = errors.NewKind("Illegal mix of collations (%s,IMPLICIT) and (%s,IMPLICIT) for operation '%s'")
+
+// ApplyCollations is the analyzer rule named RuleName. It rewrites the comparisons, LIKE expressions and sorts of
+// string columns whose collation doesn't order strings by their bytes, so they compare weight strings in the
+// collation. It must run after the pushdown of filters, which leaves the filters of index lookups in place, so their
+// results are filtered by the collation too. Applying it to a node it has already rewritten changes nothing.
+//
+// Primary key columns are the exception. The keys of a table are distinct by their bytes, so 'a' and 'A' can both be
+// keys of it, and key columns keep comparing by bytes, as do their index lookups and the ranges of keys read for them.
+func ApplyCollations(ctx *sql.Context, a *analyzer.Analyzer, n sql.Node) (sql.Node, error) {
+	c := collator{keyColumns(n)}
+	n, err := plan.TransformExpressionsUp(n, c.collateExpression)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.TransformUp(n, func(n sql.Node) (sql.Node, error) {
+		sort, ok := n.(*plan.Sort)
+
+		if !ok {
+			return n, nil
+		}
+
+		fields := make([]plan.SortField, len(sort.SortFields))
+		changed := false
+		for i, field := range sort.SortFields {
+			fields[i] = field
+
+			if coll, ok := c.columnCollation(field.Column); ok && !ByteOrdered(coll) {
+				fields[i].Column = NewWeightString(coll, field.Column)
+				changed = true
+			}
+		}
+
+		if !changed {
+			return n, nil
+		}
+
+		return plan.NewSort(fields, sort.Child), nil
+	})
+}
+
+// keyColumns returns the primary key columns of the tables in |n|, by their table and column names
+func keyColumns(n sql.Node) map[string]bool {
+	keys := make(map[string]bool)
+	plan.Inspect(n, func(n sql.Node) bool {
+		switch n.(type) {
+		case *plan.ResolvedTable, *plan.TableAlias:
+			for _, col := range n.Schema() {
+				if col.PrimaryKey {
+					keys[columnKey(col.Source, col.Name)] = true
+				}
+			}
+		}
+
+		return true
+	})
+
+	return keys
+}
+
+func columnKey(table, column string) string {
+	return strings.ToLower(table) + "." + strings.ToLower(column)
+}
+
+type collator struct {
+	keys map[string]bool
+}
+
+func (c collator) collateExpression(e sql.Expression) (sql.Expression, error) {
+	switch e := e.(type) {
+	case *expression.Equals:
+		return c.collateComparison(e, "=", e.Left(), e.Right())
+	case *expression.GreaterThan:
+		return c.collateComparison(e, ">", e.Left(), e.Right())
+	case *expression.GreaterThanOrEqual:
+		return c.collateComparison(e, ">=", e.Left(), e.Right())
+	case *expression.LessThan:
+		return c.collateComparison(e, "<", e.Left(), e.Right())
+	case *expression.LessThanOrEqual:
+		return c.collateComparison(e, "<=", e.Left(), e.Right())
+	case *expression.Between:
+		return c.collateComparison(e, "between", e.Val, e.Lower, e.Upper)
+	case *expression.In:
+		return c.collateIn(e, "in", e.Left(), e.Right())
+	case *expression.NotIn:
+		return c.collateIn(e, "not in", e.Left(), e.Right())
+	case *expression.Like:
+		coll, ok, err := c.operationCollation("like", e.Left, e.Right)
+
+		if err != nil || !ok {
+			return e, err
+		}
+
+		return NewLike(coll, e.Left, e.Right), nil
+	}
+
+	return e, nil
+}
+
+// collateComparison replaces the |operands| of |e| with their weight strings if they are compared in a collation
+// which doesn't order strings by their bytes.
+func (c collator) collateComparison(e sql.Expression, op string, operands ...sql.Expression) (sql.Expression, error) {
+	coll, ok, err := c.operationCollation(op, operands...)
+
+	if err != nil || !ok {
+		return e, err
+	}
+
+	weights := make([]sql.Expression, len(operands))
+	for i, operand := range operands {
+		weights[i] = NewWeightString(coll, operand)
+	}
+
+	return e.WithChildren(weights...)
+}
+
+// collateIn collates an IN or NOT IN expression with a tuple of values. The values of subqueries aren't collated.
+func (c collator) collateIn(e sql.Expression, op string, left, right sql.Expression) (sql.Expression, error) {
+	tuple, ok := right.(expression.Tuple)
+
+	if !ok {
+		return e, nil
+	}
+
+	coll, ok, err := c.operationCollation(op, append([]sql.Expression{left}, tuple...)...)
+
+	if err != nil || !ok {
+		return e, err
+	}
+
+	weights := make(expression.Tuple, len(tuple))
+	for i, el := range tuple {
+		weights[i] = NewWeightString(coll, el)
+	}
+
+	return e.WithChildren(NewWeightString(coll, left), weights)
+}
+
+// operationCollation returns the collation the strings |operands| are compared in, and whether it's one the
+// expression needs to be rewritten for. Strings are compared in the collation of the columns among the operands, as
+// the collations of literals and other expressions are coercible to it.
+func (c collator) operationCollation(op string, operands ...sql.Expression) (sql.Collation, bool, error) {
+	var coll sql.Collation
+	found := false
+	for _, operand := range operands {
+		if _, ok := operand.Type().(sql.StringType); !ok || !sql.IsText(operand.Type()) {
+			return "", false, nil
+		}
+
+		colColl, ok := c.columnCollation(operand)
+
+		if !ok || (found && colColl == coll) {
+			continue
+		}
+
+		if !found {
+			coll, found = colColl, true
+			continue
+		}
+
+		switch {
+		case behaviorOf(coll).strength == binaryStrength:
+		case behaviorOf(colColl).strength == binaryStrength:
+			coll = colColl
+		default:
+			return "", false, ErrIllegalMixOfCollations.New(coll, colColl, op)
+		}
+	}
+
+	if !found || ByteOrdered(coll) {
+		return "", false, nil
+	}
+
+	return coll, true, nil
+}
+
+// columnCollation returns the collation of |e| if it's a string column. Key columns compare by bytes, as with the
+// binary collation.
+func (c collator) columnCollation(e sql.Expression) (sql.Collation, bool) {
+	gf, ok := e.(*expression.GetField)
+
+	if !ok {
+		return "", false
+	}
+
+	st, ok := gf.Type().(sql.StringType)
+
+	if !ok || !sql.IsText(st) {
+		return "", false
+	}
+
+	if c.keys[columnKey(gf.Table(), gf.Name())] {
+		return sql.Collation_binary, true
+	}
+
+	return st.Collation(), true
+}
@@ -0,0 +1,143 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle/collation"
+)
+
+// collationTestRows are the values of the words table, keyed by their pk
+var collationTestRows = []string{"a", "A", "á", "b", "B", "a "}
+
+func createCollationTestTable(t *testing.T, c sql.Collation) (*env.DoltEnv, *doltdb.RootValue) {
+	dEnv := dtestutils.CreateTestEnv()
+	root, err := dEnv.WorkingRoot(context.Background())
+	require.NoError(t, err)
+
+	query := fmt.Sprintf("create table words (pk int primary key, word varchar(20) collate %s, key_word varchar(20))", c)
+	for i, word := range collationTestRows {
+		query += fmt.Sprintf(";\ninsert into words (pk, word, key_word) values (%d, '%s', '%s')", i, word, word)
+	}
+
+	root, err = ExecuteSql(dEnv, root, query)
+	require.NoError(t, err)
+
+	return dEnv, root
+}
+
+func selectPks(t *testing.T, dEnv *env.DoltEnv, root *doltdb.RootValue, query string) []int32 {
+	rows, err := ExecuteSelect(dEnv, dEnv.DoltDB, root, query)
+	require.NoError(t, err)
+
+	pks := make([]int32, len(rows))
+	for i, r := range rows {
+		pks[i] = r[0].(int32)
+	}
+
+	return pks
+}
+
+// The expected results of the queries are those of MySQL 8.0.
+func TestCollations(t *testing.T) {
+	tests := []struct {
+		collation sql.Collation
+		query     string
+		expected  []int32
+	}{
+		{sql.Collation_utf8mb4_0900_ai_ci, "select pk from words where word = 'a' order by pk", []int32{0, 1, 2}},
+		{sql.Collation_utf8mb4_0900_ai_ci, "select pk from words where word <> 'a' order by pk", []int32{3, 4, 5}},
+		{sql.Collation_utf8mb4_0900_ai_ci, "select pk from words where word > 'a' order by pk", []int32{3, 4, 5}},
+		{sql.Collation_utf8mb4_0900_ai_ci, "select pk from words where word <= 'A' order by pk", []int32{0, 1, 2}},
+		{sql.Collation_utf8mb4_0900_ai_ci, "select pk from words where word in ('B', 'x') order by pk", []int32{3, 4}},
+		{sql.Collation_utf8mb4_0900_ai_ci, "select pk from words where word not in ('A', 'b') order by pk", []int32{5}},
+		{sql.Collation_utf8mb4_0900_ai_ci, "select pk from words where word between 'A' and 'a' order by pk", []int32{0, 1, 2}},
+		{sql.Collation_utf8mb4_0900_ai_ci, "select pk from words where word like 'A%' order by pk", []int32{0, 1, 2, 5}},
+		{sql.Collation_utf8mb4_0900_ai_ci, "select pk from words where word like '_' order by pk", []int32{0, 1, 2, 3, 4}},
+		{sql.Collation_utf8mb4_0900_ai_ci, "select pk from words where word < 'b' order by word, pk", []int32{0, 1, 2, 5}},
+		{sql.Collation_utf8mb4_0900_ai_ci, "select pk from words order by word desc, pk", []int32{3, 4, 5, 0, 1, 2}},
+		{sql.Collation_utf8mb4_0900_ai_ci, "select pk from words where key_word = word order by pk", []int32{0, 1, 2, 3, 4, 5}},
+
+		{sql.Collation_utf8mb4_0900_as_ci, "select pk from words where word = 'a' order by pk", []int32{0, 1}},
+		{sql.Collation_utf8mb4_0900_as_ci, "select pk from words where word like 'a%' order by pk", []int32{0, 1, 5}},
+
+		{sql.Collation_utf8mb4_0900_as_cs, "select pk from words where word = 'a' order by pk", []int32{0}},
+		{sql.Collation_utf8mb4_0900_as_cs, "select pk from words where word like 'B' order by pk", []int32{4}},
+		{sql.Collation_utf8mb4_0900_as_cs, "select pk from words order by word, pk", []int32{0, 1, 2, 5, 3, 4}},
+
+		{sql.Collation_utf8mb4_general_ci, "select pk from words where word = 'a' order by pk", []int32{0, 1, 2, 5}},
+		{sql.Collation_utf8mb4_general_ci, "select pk from words where word like 'a' order by pk", []int32{0, 1, 2}},
+
+		{sql.Collation_utf8mb4_bin, "select pk from words where word = 'a' order by pk", []int32{0, 5}},
+		{sql.Collation_utf8mb4_bin, "select pk from words where word like 'a%' order by pk", []int32{0, 5}},
+		{sql.Collation_utf8mb4_bin, "select pk from words where word in ('B') order by pk", []int32{4}},
+		{sql.Collation_utf8mb4_bin, "select pk from words order by word, pk", []int32{1, 4, 0, 5, 3, 2}},
+
+		{sql.Collation_utf8mb4_0900_bin, "select pk from words where word = 'a' order by pk", []int32{0}},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.collation)+" "+test.query, func(t *testing.T) {
+			dEnv, root := createCollationTestTable(t, test.collation)
+			assert.Equal(t, test.expected, selectPks(t, dEnv, root, test.query))
+		})
+	}
+}
+
+func TestCollationOfKeyColumn(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+	root, err := dEnv.WorkingRoot(context.Background())
+	require.NoError(t, err)
+
+	root, err = ExecuteSql(dEnv, root, `create table names (name varchar(20) collate utf8mb4_0900_ai_ci primary key, pk int, alias varchar(20) collate utf8mb4_0900_ai_ci);
+insert into names values ('Alice', 0, 'alice'), ('alice', 1, 'alice'), ('bob', 2, 'Bob')`)
+	require.NoError(t, err)
+
+	// keys are distinct by their bytes, so key columns compare by them
+	assert.Equal(t, []int32{0}, selectPks(t, dEnv, root, "select pk, name from names where name = 'Alice'"))
+	assert.Empty(t, selectPks(t, dEnv, root, "select pk, name from names where name = 'ALICE'"))
+	assert.Equal(t, []int32{0, 2}, selectPks(t, dEnv, root, "select pk, name from names where name in ('Alice', 'bob') order by pk"))
+	assert.Equal(t, []int32{2, 1, 0}, selectPks(t, dEnv, root, "select pk, name from names order by name desc"))
+	assert.Equal(t, []int32{1}, selectPks(t, dEnv, root, "select pk, name from names where name = alias"))
+
+	// other columns compare in their collations
+	assert.Equal(t, []int32{0, 1}, selectPks(t, dEnv, root, "select pk, name from names where alias = 'Alice' order by pk"))
+}
+
+func TestIllegalMixOfCollations(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+	root, err := dEnv.WorkingRoot(context.Background())
+	require.NoError(t, err)
+
+	root, err = ExecuteSql(dEnv, root, `create table mixed (pk int primary key, ci varchar(20) collate utf8mb4_0900_ai_ci, cs varchar(20) collate utf8mb4_0900_as_cs, bin varchar(20) collate utf8mb4_bin);
+insert into mixed values (0, 'a', 'A', 'A')`)
+	require.NoError(t, err)
+
+	_, err = ExecuteSelect(dEnv, dEnv.DoltDB, root, "select pk from mixed where ci = cs")
+	assert.True(t, collation.ErrIllegalMixOfCollations.Is(err))
+
+	// a binary collation is used when mixed with another one
+	assert.Empty(t, selectPks(t, dEnv, root, "select pk from mixed where ci = bin"))
+}
@@ -0,0 +1,196 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"io"
+
+	"github.com/src-d/go-mysql-server/sql"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions/commitwalk"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+const (
+	// CommitAncestorsTableName is the system table name
+	CommitAncestorsTableName = "dolt_commit_ancestors"
+)
+
+var _ sql.Table = (*CommitAncestorsTable)(nil)
+var _ sql.FilteredTable = (*CommitAncestorsTable)(nil)
+
+// CommitAncestorsTable is a sql.Table implementation that implements a system table which has a row for each parent of
+// each commit reachable from the session's head commit, so that the commit graph can be traversed by joining the
+// table to itself. parent_index is the position of the parent in the commit's parents, where the first parent of a
+// merge commit is the head of the branch it was merged into. A commit without parents has a single row whose
+// parent_hash is NULL. A filter on commit_hash reads the commits it names directly, without walking the graph.
+type CommitAncestorsTable struct {
+	dbName  string
+	ddb     *doltdb.DoltDB
+	filters []sql.Expression
+}
+
+// NewCommitAncestorsTable creates a CommitAncestorsTable
+func NewCommitAncestorsTable(ctx *sql.Context, dbName string) (*CommitAncestorsTable, error) {
+	ddb, ok := DSessFromSess(ctx.Session).GetDoltDB(dbName)
+
+	if !ok {
+		return nil, sql.ErrDatabaseNotFound.New(dbName)
+	}
+
+	return &CommitAncestorsTable{dbName: dbName, ddb: ddb}, nil
+}
+
+// Name is a sql.Table interface function which returns the name of the table which is defined by the constant
+// CommitAncestorsTableName
+func (ct *CommitAncestorsTable) Name() string {
+	return CommitAncestorsTableName
+}
+
+// String is a sql.Table interface function which returns the name of the table which is defined by the constant
+// CommitAncestorsTableName
+func (ct *CommitAncestorsTable) String() string {
+	return CommitAncestorsTableName
+}
+
+// Schema is a sql.Table interface function that gets the sql.Schema of the commit ancestors system table.
+func (ct *CommitAncestorsTable) Schema() sql.Schema {
+	return []*sql.Column{
+		{Name: "commit_hash", Type: sql.Text, Source: CommitAncestorsTableName, PrimaryKey: true, Nullable: false},
+		{Name: "parent_hash", Type: sql.Text, Source: CommitAncestorsTableName, PrimaryKey: false, Nullable: true},
+		{Name: "parent_index", Type: sql.Int32, Source: CommitAncestorsTableName, PrimaryKey: true, Nullable: false},
+	}
+}
+
+// HandledFilters returns the filters which the table evaluates while reading the commits.
+func (ct *CommitAncestorsTable) HandledFilters(filters []sql.Expression) []sql.Expression {
+	return handledSystemTableFilters(filters)
+}
+
+// Filters returns the filters applied to this table.
+func (ct *CommitAncestorsTable) Filters() []sql.Expression {
+	return ct.filters
+}
+
+// WithFilters returns a copy of the table which only has the rows satisfying |filters|.
+func (ct *CommitAncestorsTable) WithFilters(filters []sql.Expression) sql.Table {
+	filtered := *ct
+	filtered.filters = filters
+	return &filtered
+}
+
+// Partitions is a sql.Table interface function that returns a partition of the data.  Currently the data is unpartitioned.
+func (ct *CommitAncestorsTable) Partitions(*sql.Context) (sql.PartitionIter, error) {
+	return &doltTablePartitionIter{}, nil
+}
+
+// PartitionRows is a sql.Table interface function that gets a row iterator for a partition
+func (ct *CommitAncestorsTable) PartitionRows(sqlCtx *sql.Context, part sql.Partition) (sql.RowIter, error) {
+	bounds, err := getCommitWalkBounds(sqlCtx, ct.filters, "commit_hash", "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []sql.Row
+	addRows := func(h hash.Hash, cm *doltdb.Commit) error {
+		parents, err := cm.ParentHashes(sqlCtx)
+
+		if err != nil {
+			return err
+		}
+
+		candidates := []sql.Row{sql.NewRow(h.String(), nil, int32(0))}
+		if len(parents) > 0 {
+			candidates = candidates[:0]
+			for i, parent := range parents {
+				candidates = append(candidates, sql.NewRow(h.String(), parent.String(), int32(i)))
+			}
+		}
+
+		for _, r := range candidates {
+			ok, err := filtersMatch(sqlCtx, ct.filters, r)
+
+			if err != nil {
+				return err
+			}
+
+			if ok {
+				rows = append(rows, r)
+			}
+		}
+
+		return nil
+	}
+
+	if bounds.hashes != nil {
+		for h := range bounds.hashes {
+			cs, err := doltdb.NewCommitSpec(h.String(), "")
+
+			if err != nil {
+				return nil, err
+			}
+
+			cm, err := ct.ddb.Resolve(sqlCtx, cs)
+
+			if err == doltdb.ErrHashNotFound || err == doltdb.ErrFoundHashNotACommit {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+
+			if err := addRows(h, cm); err != nil {
+				return nil, err
+			}
+		}
+
+		return sql.RowsToRowIter(rows...), nil
+	}
+
+	head, err := DSessFromSess(sqlCtx.Session).GetParentCommit(sqlCtx, ct.dbName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	headHash, err := head.HashOf()
+
+	if err != nil {
+		return nil, err
+	}
+
+	cmItr, err := commitwalk.GetTopologicalOrderIterator(sqlCtx, ct.ddb, headHash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		h, cm, err := cmItr.Next(sqlCtx)
+
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if err := addRows(h, cm); err != nil {
+			return nil, err
+		}
+	}
+
+	return sql.RowsToRowIter(rows...), nil
+}
@@ -93,3 +93,9 @@ func schemaNewColumn(t *testing.T, name string, tag uint64, sqlType sql.Type, pa
 	require.NoError(t, err)
 	return col
 }
+
+func schemaNewColumnWithComment(t *testing.T, name string, tag uint64, sqlType sql.Type, partOfPK bool, comment string, constraints ...schema.ColConstraint) schema.Column {
+	col := schemaNewColumn(t, name, tag, sqlType, partOfPK, constraints...)
+	col.Comment = comment
+	return col
+}
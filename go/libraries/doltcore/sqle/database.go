@@ -35,11 +35,12 @@ import (
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/alterschema"
 	dsql "github.com/liquidata-inc/dolt/go/libraries/doltcore/sql"
 	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/util/cacheregistry"
 )
 
 type commitBehavior int8
 
-var ErrInvalidTableName = errors.NewKind("Invalid table name %s. Table names must match the regular expression " + doltdb.TableNameRegexStr)
+var ErrInvalidTableName = errors.NewKind("Invalid table name %s: %s")
 var ErrReservedTableName = errors.NewKind("Invalid table name %s. Table names beginning with `dolt_` are reserved for internal use")
 var ErrSystemTableAlter = errors.NewKind("Cannot alter table %s: system tables cannot be dropped or altered")
 
@@ -121,6 +122,14 @@ func (tc *tableCache) AllForRoot(root *doltdb.RootValue) (map[string]sql.Table,
 	return nil, false
 }
 
+// Remove evicts the tables cached for the given root, along with any edits to them that have not been flushed.
+func (tc *tableCache) Remove(root *doltdb.RootValue) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	delete(tc.tables, root)
+}
+
 // Database implements sql.Database for a dolt DB.
 type Database struct {
 	name      string
@@ -129,6 +138,10 @@ type Database struct {
 	rsw       env.RepoStateWriter
 	batchMode commitBehavior
 	tc        *tableCache
+	trc       *triggerCache
+	dc        *diffCache
+	// wsMu is held by sessions while they update the working set of the database from a transaction
+	wsMu *sync.Mutex
 }
 
 var _ sql.Database = Database{}
@@ -146,6 +159,9 @@ func NewDatabase(name string, ddb *doltdb.DoltDB, rsr env.RepoStateReader, rsw e
 		rsw:       rsw,
 		batchMode: single,
 		tc:        &tableCache{&sync.Mutex{}, make(map[*doltdb.RootValue]map[string]sql.Table)},
+		trc:       newTriggerCache(),
+		dc:        newDiffCache(DefaultDiffCacheSize),
+		wsMu:      &sync.Mutex{},
 	}
 }
 
@@ -159,6 +175,9 @@ func NewBatchedDatabase(name string, ddb *doltdb.DoltDB, rsr env.RepoStateReader
 		rsw:       rsw,
 		batchMode: batched,
 		tc:        &tableCache{&sync.Mutex{}, make(map[*doltdb.RootValue]map[string]sql.Table)},
+		trc:       newTriggerCache(),
+		dc:        newDiffCache(DefaultDiffCacheSize),
+		wsMu:      &sync.Mutex{},
 	}
 }
 
@@ -177,6 +196,30 @@ func (db Database) GetStateReader() env.RepoStateReader {
 	return db.rsr
 }
 
+// DiffCacheStats returns the counts of the lookups of the cache of the diffs computed by the database's dolt_diff_
+// tables.
+func (db Database) DiffCacheStats() DiffCacheStats {
+	return db.dc.stats()
+}
+
+// RegisterCaches registers the caches of the database, and of its DoltDB, with the cacheregistry so that they can be
+// reported and flushed through it. The function returned unregisters them, and should be called once the database is
+// no longer served. Copies of a database share its caches, so registering more than one of them counts the caches
+// once.
+func (db Database) RegisterCaches() (unregister func()) {
+	unregisterFuncs := []func(){
+		cacheregistry.Register(DiffCacheName, db.dc.cache),
+		cacheregistry.Register(TriggersCacheName, db.trc),
+		db.ddb.RegisterCaches(),
+	}
+
+	return func() {
+		for _, f := range unregisterFuncs {
+			f()
+		}
+	}
+}
+
 // GetStateWriter gets the RepoStateWriter for a Database
 func (db Database) GetStateWriter() env.RepoStateWriter {
 	return db.rsw
@@ -198,7 +241,7 @@ func (db Database) GetTableInsensitiveWithRoot(ctx *sql.Context, root *doltdb.Ro
 	lwrName := strings.ToLower(tblName)
 	if strings.HasPrefix(lwrName, DoltDiffTablePrefix) {
 		tblName = tblName[len(DoltDiffTablePrefix):]
-		dt, err := NewDiffTable(ctx, db.Name(), tblName)
+		dt, err := NewDiffTable(ctx, db.Name(), tblName, db.dc)
 
 		if err != nil {
 			return nil, false, err
@@ -228,6 +271,50 @@ func (db Database) GetTableInsensitiveWithRoot(ctx *sql.Context, root *doltdb.Ro
 		return lt, true, nil
 	}
 
+	if strings.HasPrefix(lwrName, DoltLogTablePrefix) {
+		lt, err := NewTableLogTable(ctx, db.Name(), tblName[len(DoltLogTablePrefix):])
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		return lt, true, nil
+	}
+
+	if lwrName == CommitAncestorsTableName {
+		ct, err := NewCommitAncestorsTable(ctx, db.Name())
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		return ct, true, nil
+	}
+
+	if lwrName == RemotesTableName {
+		rt, err := NewRemotesTable(ctx, db.Name())
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		return rt, true, nil
+	}
+
+	if lwrName == BackgroundTasksTableName {
+		return NewBackgroundTasksTable(), true, nil
+	}
+
+	if lwrName == StatusTableName {
+		st, err := NewStatusTable(ctx, db.Name())
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		return st, true, nil
+	}
+
 	if lwrName == BranchesTableName {
 		bt, err := NewBranchesTable(ctx, db.Name())
 
@@ -238,11 +325,55 @@ func (db Database) GetTableInsensitiveWithRoot(ctx *sql.Context, root *doltdb.Ro
 		return bt, true, nil
 	}
 
+	if lwrName == WorkspacesTableName {
+		wt, err := NewWorkspacesTable(ctx, db.Name())
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		return wt, true, nil
+	}
+
+	if lwrName == doltdb.IgnoreTableName {
+		return db.getIgnoreTable(ctx, root)
+	}
+
+	if lwrName == doltdb.PermissionsTableName {
+		return db.getPermissionsTable(ctx, root)
+	}
+
 	return db.getTable(ctx, root, tblName)
 }
 
+// getIgnoreTable returns the dolt_ignore table of the root given. If the root has no dolt_ignore table an empty one is
+// returned, which is added to the root when its first row is written. The empty table is cached for the root like any
+// other, so that the edits batched on it are flushed.
+func (db Database) getIgnoreTable(ctx context.Context, root *doltdb.RootValue) (sql.Table, bool, error) {
+	if table, ok, err := db.getTable(ctx, root, doltdb.IgnoreTableName); err != nil || ok {
+		return table, ok, err
+	}
+
+	tbl, err := doltdb.NewEmptyIgnoreTable(ctx, root.VRW())
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	table := &WritableDoltTable{DoltTable: DoltTable{name: doltdb.IgnoreTableName, table: tbl, sch: doltdb.IgnoreSchema, db: db}}
+	db.tc.Put(doltdb.IgnoreTableName, root, table)
+
+	return table, true, nil
+}
+
 // GetTableInsensitiveAsOf implements sql.VersionedDatabase
 func (db Database) GetTableInsensitiveAsOf(ctx *sql.Context, tableName string, asOf interface{}) (sql.Table, bool, error) {
+	if tbl, ok, err := db.tableForTableRef(ctx, tableName, asOf); err != nil {
+		return nil, false, err
+	} else if ok {
+		return tbl, true, nil
+	}
+
 	root, err := db.rootAsOf(ctx, asOf)
 	if err != nil {
 		return nil, false, err
@@ -253,6 +384,34 @@ func (db Database) GetTableInsensitiveAsOf(ctx *sql.Context, tableName string, a
 	return db.getTable(ctx, root, tableName)
 }
 
+// tableForTableRef returns a read-only table named |tableName| for the table state with the table ref |asOf|, or false
+// if |asOf| isn't the hash of a table, in which case it may still refer to a commit.
+func (db Database) tableForTableRef(ctx *sql.Context, tableName string, asOf interface{}) (sql.Table, bool, error) {
+	s, ok := asOf.(string)
+	if !ok {
+		return nil, false, nil
+	}
+
+	h, ok := hash.MaybeParse(s)
+	if !ok {
+		return nil, false, nil
+	}
+
+	tbl, err := db.ddb.ReadTableFromHash(ctx, h)
+	if err == doltdb.ErrHashNotFound || err == doltdb.ErrFoundHashNotATable {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	sch, err := tbl.GetSchema(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &DoltTable{name: tableName, table: tbl, sch: sch, db: db}, true, nil
+}
+
 // rootAsOf returns the root of the DB as of the expression given, which may be nil in the case that it refers to an
 // expression before the first commit.
 func (db Database) rootAsOf(ctx *sql.Context, asOf interface{}) (*doltdb.RootValue, error) {
@@ -518,7 +677,13 @@ func (db Database) LoadRootFromRepoState(ctx *sql.Context) error {
 		return err
 	}
 
-	return db.SetRoot(ctx, root)
+	err = db.SetRoot(ctx, root)
+	if err != nil {
+		return err
+	}
+
+	DSessFromSess(ctx.Session).persistedHashes[db.name] = workingHash.String()
+	return nil
 }
 
 // DropTable drops the table with the name given
@@ -542,12 +707,24 @@ func (db Database) DropTable(ctx *sql.Context, tableName string) error {
 		return sql.ErrTableNotFound.New(tableName)
 	}
 
+	if err := checkRowPolicyTableAccess(ctx, root, tableName, "drop"); err != nil {
+		return err
+	}
+
+	if err := db.checkWritePermission(ctx, root, tableName); err != nil {
+		return err
+	}
+
 	newRoot, err := root.RemoveTables(ctx, tableName)
 	if err != nil {
 		return err
 	}
 
-	return db.SetRoot(ctx, newRoot)
+	if err := db.SetRoot(ctx, newRoot); err != nil {
+		return err
+	}
+
+	return db.moveRowPolicies(ctx, tableName, "")
 }
 
 // CreateTable creates a table with the name and schema given.
@@ -556,8 +733,22 @@ func (db Database) CreateTable(ctx *sql.Context, tableName string, sch sql.Schem
 		return ErrReservedTableName.New(tableName)
 	}
 
-	if !doltdb.IsValidTableName(tableName) {
-		return ErrInvalidTableName.New(tableName)
+	root, err := db.GetRoot(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if err := validateNewTableName(ctx, root, tableName, ""); err != nil {
+		return err
+	}
+
+	if err := validateColumnNames(sch); err != nil {
+		return err
+	}
+
+	if err := db.checkWritePermission(ctx, root, tableName); err != nil {
+		return err
 	}
 
 	for _, col := range sch {
@@ -574,6 +765,37 @@ func (db Database) CreateTable(ctx *sql.Context, tableName string, sch sql.Schem
 	return db.createTable(ctx, tableName, sch)
 }
 
+// validateNewTableName returns an ErrInvalidTableName if |tableName| breaks the identifier rules of doltdb, or differs
+// only by case from the name of a table of |root| other than |oldName|.
+func validateNewTableName(ctx context.Context, root *doltdb.RootValue, tableName, oldName string) error {
+	err := doltdb.ValidateNewTableName(ctx, root, tableName, oldName)
+
+	if idErr, ok := err.(doltdb.IdentifierError); ok {
+		return ErrInvalidTableName.New(tableName, idErr.Reason)
+	}
+
+	return err
+}
+
+// validateColumnNames returns an error if any column of |sch| breaks the identifier rules of doltdb, or if two of its
+// columns have names which differ only by case.
+func validateColumnNames(sch sql.Schema) error {
+	names := make([]string, len(sch))
+	for i, col := range sch {
+		if err := doltdb.ValidateColumnName(col.Name); err != nil {
+			return err
+		}
+
+		names[i] = col.Name
+	}
+
+	if collisions := doltdb.CaseCollisions(names); len(collisions) > 0 {
+		return fmt.Errorf("column names are case insensitive, and columns '%s' and '%s' differ only by case", collisions[0][0], collisions[0][1])
+	}
+
+	return nil
+}
+
 // Unlike the exported version, createTable doesn't enforce any table name checks.
 func (db Database) createTable(ctx *sql.Context, tableName string, sch sql.Schema) error {
 	root, err := db.GetRoot(ctx)
@@ -632,8 +854,18 @@ func (db Database) RenameTable(ctx *sql.Context, oldName, newName string) error
 		return ErrReservedTableName.New(newName)
 	}
 
-	if !doltdb.IsValidTableName(newName) {
-		return ErrInvalidTableName.New(newName)
+	if err := validateNewTableName(ctx, root, newName, oldName); err != nil {
+		return err
+	}
+
+	if err := checkRowPolicyTableAccess(ctx, root, oldName, "rename"); err != nil {
+		return err
+	}
+
+	for _, name := range []string{oldName, newName} {
+		if err := db.checkWritePermission(ctx, root, name); err != nil {
+			return err
+		}
 	}
 
 	newRoot, err := alterschema.RenameTable(ctx, root, oldName, newName)
@@ -642,7 +874,11 @@ func (db Database) RenameTable(ctx *sql.Context, oldName, newName string) error
 		return err
 	}
 
-	return db.SetRoot(ctx, newRoot)
+	if err := db.SetRoot(ctx, newRoot); err != nil {
+		return err
+	}
+
+	return db.moveRowPolicies(ctx, oldName, newName)
 }
 
 // Flush flushes the current batch of outstanding changes and returns any errors.
@@ -686,7 +922,7 @@ func (db Database) CreateView(ctx *sql.Context, name string, definition string)
 		return err
 	}
 	if exists {
-		return sql.ErrExistingView.New(name)
+		return sql.ErrExistingView.New(db.name, name)
 	}
 
 	// It does not exist; insert it.
@@ -708,7 +944,7 @@ func (db Database) DropView(ctx *sql.Context, name string) error {
 		return err
 	}
 	if !found {
-		return sql.ErrNonExistingView.New(name)
+		return sql.ErrNonExistingView.New(db.name, name)
 	}
 
 	tbl := stbl.(*WritableDoltTable)
@@ -717,7 +953,7 @@ func (db Database) DropView(ctx *sql.Context, name string) error {
 		return err
 	}
 	if !exists {
-		return sql.ErrNonExistingView.New(name)
+		return sql.ErrNonExistingView.New(db.name, name)
 	}
 
 	// It exists. delete it from the table.
@@ -732,7 +968,8 @@ func (db Database) DropView(ctx *sql.Context, name string) error {
 }
 
 // RegisterSchemaFragments register SQL schema fragments that are persisted in the given
-// `Database` with the provided `sql.ViewRegistry`. Returns an error if
+// `Database` with the provided `sql.ViewRegistry`, and loads the triggers defined in
+// the database so that they fire on the first write. Returns an error if
 // there are I/O issues, but currently silently fails to register some
 // schema fragments if they don't parse, or if registries within the
 // `catalog` return errors.
@@ -782,5 +1019,6 @@ func RegisterSchemaFragments(ctx *sql.Context, db Database, root *doltdb.RootVal
 		// TODO: Warning for uncreated views...
 	}
 
-	return nil
+	_, err = db.getTriggers(ctx, root)
+	return err
 }
@@ -15,9 +15,14 @@
 package sqle
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	. "github.com/liquidata-inc/dolt/go/libraries/doltcore/sql/sqltestutil"
 )
 
 func testKeyFunc(t *testing.T, keyFunc func(string) (bool, string), testVal string, expectedIsKey bool, expectedDBName string) {
@@ -34,3 +39,103 @@ func TestIsKeyFuncs(t *testing.T) {
 	testKeyFunc(t, IsHeadKey, "dolt_working", false, "")
 	testKeyFunc(t, IsWorkingKey, "dolt_working", true, "dolt")
 }
+
+func TestDoltSessionRelease(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+	ctx := context.Background()
+
+	CreateTestDatabase(dEnv, t)
+	root, _ := dEnv.WorkingRoot(ctx)
+
+	db := NewBatchedDatabase("dolt", dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
+	engine, sqlCtx, err := NewTestEngine(ctx, db, root)
+	require.NoError(t, err)
+
+	_, rowIter, err := engine.Query(sqlCtx, `insert into people (id, first_name, last_name) values (100, "Bart", "Simpson")`)
+	require.NoError(t, err)
+	require.NoError(t, drainIter(rowIter))
+
+	sessRoot, err := db.GetRoot(sqlCtx)
+	require.NoError(t, err)
+	_, ok := db.tc.AllForRoot(sessRoot)
+	require.True(t, ok)
+
+	dsess := DSessFromSess(sqlCtx.Session)
+	dsess.Release(db)
+
+	_, ok = db.tc.AllForRoot(sessRoot)
+	assert.False(t, ok)
+	_, ok = dsess.GetRoot(db.Name())
+	assert.False(t, ok)
+	_, ok = dsess.GetDoltDB(db.Name())
+	assert.False(t, ok)
+}
+
+func TestDoltSessionPersistWorkingSets(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+	ctx := context.Background()
+
+	CreateTestDatabase(dEnv, t)
+	root, _ := dEnv.WorkingRoot(ctx)
+
+	db := NewDatabase("dolt", dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
+	engine, sqlCtx, err := NewTestEngine(ctx, db, root)
+	require.NoError(t, err)
+
+	dsess := DSessFromSess(sqlCtx.Session)
+	require.NoError(t, db.LoadRootFromRepoState(sqlCtx))
+
+	_, rowIter, err := engine.Query(sqlCtx, `insert into people (id, first_name, last_name) values (100, "Bart", "Simpson")`)
+	require.NoError(t, err)
+	require.NoError(t, drainIter(rowIter))
+
+	sessRoot, err := db.GetRoot(sqlCtx)
+	require.NoError(t, err)
+	sessHash, err := sessRoot.HashOf()
+	require.NoError(t, err)
+	assert.NotEqual(t, sessHash, dEnv.RepoState.WorkingHash())
+
+	require.NoError(t, dsess.PersistWorkingSets(ctx))
+	assert.Equal(t, sessHash, dEnv.RepoState.WorkingHash())
+
+	// a working set which hasn't changed since it was persisted is not written again, so changes made by others are kept
+	rootHash, err := root.HashOf()
+	require.NoError(t, err)
+	require.NoError(t, dEnv.RepoStateWriter().SetWorkingHash(ctx, rootHash))
+	require.NoError(t, dsess.PersistWorkingSets(ctx))
+	assert.Equal(t, rootHash, dEnv.RepoState.WorkingHash())
+}
+
+func TestAsOfTableRef(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+	ctx := context.Background()
+
+	CreateTestDatabase(dEnv, t)
+	root, _ := dEnv.WorkingRoot(ctx)
+
+	h, ok, err := root.GetTableHash(ctx, PeopleTableName)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	expected, err := ExecuteSelect(dEnv, dEnv.DoltDB, root, "select id, first_name from people order by id")
+	require.NoError(t, err)
+	require.NotEmpty(t, expected)
+
+	// the table ref can be queried by any name, whether or not the table is in the root
+	root, err = root.RemoveTables(ctx, PeopleTableName)
+	require.NoError(t, err)
+
+	rows, err := ExecuteSelect(dEnv, dEnv.DoltDB, root, "select id, first_name from pinned as of '"+h.String()+"' order by id")
+	require.NoError(t, err)
+	assert.Equal(t, expected, rows)
+
+	rows, err = ExecuteSelect(dEnv, dEnv.DoltDB, root, "select id from pinned as of '"+h.String()+"' where id = 0")
+	require.NoError(t, err)
+	assert.Len(t, rows, 1)
+
+	// hashes which aren't table refs are resolved as commits
+	rootHash, err := root.HashOf()
+	require.NoError(t, err)
+	_, err = ExecuteSelect(dEnv, dEnv.DoltDB, root, "select id from people as of '"+rootHash.String()+"'")
+	assert.Error(t, err)
+}
@@ -0,0 +1,97 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dfunctions
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
+)
+
+const DoltCommitFuncName = "dolt_commit"
+
+// DoltCommitFunc commits the changes of the session's workspace of the current database to the database's branch,
+// merging them with the commits made to the branch since the workspace's changes were made. It returns the hash of
+// the new commit.
+type DoltCommitFunc struct {
+	expression.UnaryExpression
+}
+
+// NewDoltCommitFunc creates a new DoltCommitFunc expression.
+func NewDoltCommitFunc(e sql.Expression) sql.Expression {
+	return &DoltCommitFunc{expression.UnaryExpression{Child: e}}
+}
+
+// Eval implements the Expression interface.
+func (dcf *DoltCommitFunc) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	val, err := dcf.Child.Eval(ctx, row)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if val == nil {
+		return nil, nil
+	}
+
+	commitMessage, ok := val.(string)
+
+	if !ok {
+		return nil, errors.New("commit message is not a string")
+	}
+
+	dSess := sqle.DSessFromSess(ctx.Session)
+	cm, err := dSess.CommitWorkspace(ctx, ctx.GetCurrentDatabase(), commitMessage)
+
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := cm.HashOf()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return h.String(), nil
+}
+
+// String implements the Stringer interface.
+func (dcf *DoltCommitFunc) String() string {
+	return fmt.Sprintf("DOLT_COMMIT(%s)", dcf.Child.String())
+}
+
+// IsNullable implements the Expression interface.
+func (dcf *DoltCommitFunc) IsNullable() bool {
+	return dcf.Child.IsNullable()
+}
+
+// WithChildren implements the Expression interface.
+func (dcf *DoltCommitFunc) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(dcf, len(children), 1)
+	}
+
+	return NewDoltCommitFunc(children[0]), nil
+}
+
+// Type implements the Expression interface.
+func (dcf *DoltCommitFunc) Type() sql.Type {
+	return sql.Text
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dfunctions
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/src-d/go-mysql-server/sql"
+
+	"github.com/liquidata-inc/dolt/go/store/util/cacheregistry"
+)
+
+const FlushCacheFuncName = "dolt_flush_cache"
+
+// FlushCacheFunc flushes the in-memory caches of the process, or the caches with the name given, and returns the
+// number of entries flushed. Queries running while the caches are flushed read what they need again.
+type FlushCacheFunc struct {
+	name sql.Expression
+}
+
+// NewFlushCacheFunc creates a new FlushCacheFunc expression, which takes the name of the caches to flush, or no
+// argument to flush all of them.
+func NewFlushCacheFunc(args ...sql.Expression) (sql.Expression, error) {
+	if len(args) > 1 {
+		return nil, sql.ErrInvalidArgumentNumber.New(FlushCacheFuncName, "0 or 1", len(args))
+	}
+
+	f := &FlushCacheFunc{}
+	if len(args) == 1 {
+		f.name = args[0]
+	}
+
+	return f, nil
+}
+
+// Eval implements the Expression interface.
+func (f *FlushCacheFunc) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	var name string
+
+	if f.name != nil {
+		val, err := f.name.Eval(ctx, row)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var ok bool
+		name, ok = val.(string)
+
+		if !ok {
+			return nil, errors.New("cache name is not a string")
+		}
+	}
+
+	flushed, err := cacheregistry.Flush(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var entries int64
+	for _, stats := range flushed {
+		entries += int64(stats.Entries)
+	}
+
+	return entries, nil
+}
+
+// String implements the Stringer interface.
+func (f *FlushCacheFunc) String() string {
+	if f.name == nil {
+		return "DOLT_FLUSH_CACHE()"
+	}
+
+	return fmt.Sprintf("DOLT_FLUSH_CACHE(%s)", f.name.String())
+}
+
+// IsNullable implements the Expression interface.
+func (f *FlushCacheFunc) IsNullable() bool {
+	return false
+}
+
+// Resolved implements the Expression interface.
+func (f *FlushCacheFunc) Resolved() bool {
+	return f.name == nil || f.name.Resolved()
+}
+
+// Children implements the Expression interface.
+func (f *FlushCacheFunc) Children() []sql.Expression {
+	if f.name == nil {
+		return nil
+	}
+
+	return []sql.Expression{f.name}
+}
+
+// WithChildren implements the Expression interface.
+func (f *FlushCacheFunc) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) > 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(f, len(children), 1)
+	}
+
+	return NewFlushCacheFunc(children...)
+}
+
+// Type implements the Expression interface.
+func (f *FlushCacheFunc) Type() sql.Type {
+	return sql.Int64
+}
@@ -0,0 +1,209 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"expvar"
+	"io"
+	"sync/atomic"
+
+	"github.com/src-d/go-mysql-server/sql"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/util/sizecache"
+)
+
+// DefaultDiffCacheSize is the number of bytes of diff rows cached for each database.
+const DefaultDiffCacheSize = 64 * 1024 * 1024
+
+// DiffCacheName is the name the diff caches of databases are registered with in the cacheregistry.
+const DiffCacheName = "sql_diff"
+
+var (
+	// diffCacheMetrics are the counters of the diff caches of all databases in this process, published through expvar.
+	diffCacheMetrics   = expvar.NewMap("dolt_sql_diff_cache")
+	diffCacheHits      = new(expvar.Int)
+	diffCacheMisses    = new(expvar.Int)
+	diffCacheEvictions = new(expvar.Int)
+)
+
+func init() {
+	diffCacheMetrics.Set("hits", diffCacheHits)
+	diffCacheMetrics.Set("misses", diffCacheMisses)
+	diffCacheMetrics.Set("evictions", diffCacheEvictions)
+}
+
+// diffCacheKey identifies a diff of a table between two commits. The rows of the diff include the commit names it was
+// queried with and the columns of the table's super schema, so both are part of the key along with the root hashes.
+type diffCacheKey struct {
+	table    string
+	fromName string
+	toName   string
+	fromRoot hash.Hash
+	toRoot   hash.Hash
+	schema   string
+	// keyRange is the description of the filters the diff's keys were limited by, or empty if they weren't limited.
+	keyRange string
+}
+
+// DiffCacheStats are the counts of the lookups of a diff cache.
+type DiffCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// diffCache holds the rows of the diffs computed by the dolt_diff_ tables of a database, so that queries of the same
+// diff between commits don't recompute it. Only diffs between commits are cached, as the roots of commits never
+// change; a diff from or to a working or staged root is always recomputed. The cached rows are never modified, so they
+// are shared by the queries of all sessions.
+type diffCache struct {
+	cache     *sizecache.SizeCache
+	maxSize   uint64
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+func newDiffCache(maxSize uint64) *diffCache {
+	dc := &diffCache{maxSize: maxSize}
+	dc.cache = sizecache.NewWithExpireCallback(maxSize, func(interface{}) {
+		atomic.AddUint64(&dc.evictions, 1)
+		diffCacheEvictions.Add(1)
+	})
+
+	return dc
+}
+
+// get returns the cached rows of the diff identified by |key|, counting the lookup as a hit or a miss.
+func (dc *diffCache) get(key diffCacheKey) ([]sql.Row, bool) {
+	v, ok := dc.cache.Get(key)
+
+	if !ok {
+		atomic.AddUint64(&dc.misses, 1)
+		diffCacheMisses.Add(1)
+		return nil, false
+	}
+
+	atomic.AddUint64(&dc.hits, 1)
+	diffCacheHits.Add(1)
+	return v.([]sql.Row), true
+}
+
+// stats returns the counts of the lookups of the cache.
+func (dc *diffCache) stats() DiffCacheStats {
+	return DiffCacheStats{
+		Hits:      atomic.LoadUint64(&dc.hits),
+		Misses:    atomic.LoadUint64(&dc.misses),
+		Evictions: atomic.LoadUint64(&dc.evictions),
+	}
+}
+
+// cachingIter returns an iterator over the rows of |iter| which adds them to the cache under |key| once all of them
+// have been read. The rows aren't cached if the iterator is closed before then, or if they don't fit in the cache.
+func (dc *diffCache) cachingIter(key diffCacheKey, iter sql.RowIter) sql.RowIter {
+	return &diffCachingIter{dc: dc, key: key, iter: iter}
+}
+
+type diffCachingIter struct {
+	dc      *diffCache
+	key     diffCacheKey
+	iter    sql.RowIter
+	rows    []sql.Row
+	size    uint64
+	tooLong bool
+}
+
+var _ sql.RowIter = (*diffCachingIter)(nil)
+
+// Next returns the next row
+func (itr *diffCachingIter) Next() (sql.Row, error) {
+	r, err := itr.iter.Next()
+
+	if err == io.EOF {
+		if !itr.tooLong {
+			itr.dc.cache.Add(itr.key, itr.size, itr.rows)
+			itr.tooLong = true
+		}
+
+		return nil, err
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !itr.tooLong {
+		itr.size += estimateRowSize(r)
+
+		if itr.size > itr.dc.maxSize {
+			itr.rows = nil
+			itr.tooLong = true
+		} else {
+			// the row is copied as the caller is free to modify the row it's given
+			itr.rows = append(itr.rows, r.Copy())
+		}
+	}
+
+	return r, nil
+}
+
+// Close closes the iterator
+func (itr *diffCachingIter) Close() error {
+	return itr.iter.Close()
+}
+
+// cachedDiffIter iterates over the rows of a cached diff, returning copies of them so that the cached rows can't be
+// modified.
+type cachedDiffIter struct {
+	rows []sql.Row
+	idx  int
+}
+
+var _ sql.RowIter = (*cachedDiffIter)(nil)
+
+// Next returns the next row
+func (itr *cachedDiffIter) Next() (sql.Row, error) {
+	if itr.idx >= len(itr.rows) {
+		return nil, io.EOF
+	}
+
+	r := itr.rows[itr.idx].Copy()
+	itr.idx++
+
+	return r, nil
+}
+
+// Close closes the iterator
+func (itr *cachedDiffIter) Close() error {
+	return nil
+}
+
+// estimateRowSize returns an estimate of the number of bytes of memory held by |r|.
+func estimateRowSize(r sql.Row) uint64 {
+	// each value is held in an interface
+	size := uint64(24 + 16*len(r))
+
+	for _, v := range r {
+		switch v := v.(type) {
+		case string:
+			size += uint64(len(v))
+		case []byte:
+			size += uint64(len(v))
+		default:
+			size += 8
+		}
+	}
+
+	return size
+}
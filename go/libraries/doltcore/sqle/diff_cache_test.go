@@ -0,0 +1,118 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions"
+)
+
+// commitAll commits the working set of the test's environment, returning the hash of the commit.
+func (tt *transactionTest) commitAll(msg string) string {
+	return tt.commitAllAt(msg, time.Now())
+}
+
+// commitAllAt commits the working set of the test's environment with the commit date |date|, returning the hash of the
+// commit.
+func (tt *transactionTest) commitAllAt(msg string, date time.Time) string {
+	ctx := context.Background()
+	require.NoError(tt.t, actions.StageAllTables(ctx, tt.dEnv, false))
+	require.NoError(tt.t, actions.CommitStaged(ctx, tt.dEnv, msg, date, false))
+
+	cm, err := tt.dEnv.DoltDB.Resolve(ctx, tt.dEnv.RepoState.CWBHeadSpec())
+	require.NoError(tt.t, err)
+	h, err := cm.HashOf()
+	require.NoError(tt.t, err)
+
+	return h.String()
+}
+
+func TestDiffTableCache(t *testing.T) {
+	tt := newTransactionTest(t)
+	from := tt.commitAll("initial")
+	tt.mustExec(tt.newSession(), "update people set age = 99 where id = 0")
+	to := tt.commitAll("update age")
+
+	s := tt.newSession()
+	query := fmt.Sprintf("select to_id, to_age, from_age, diff_type from dolt_diff_people where from_commit = '%s' and to_commit = '%s'", from, to)
+	expected := []sql.Row{{int64(0), int64(99), int64(40), diffTypeModified}}
+
+	rows := tt.mustExec(s, query)
+	assert.Equal(t, expected, rows)
+	assert.Equal(t, DiffCacheStats{Misses: 1}, tt.db.DiffCacheStats())
+
+	// the same diff is read from the cache, by any session, and modifying the rows returned doesn't modify the cache
+	rows[0][1] = int64(0)
+	assert.Equal(t, expected, tt.mustExec(tt.newSession(), query))
+	assert.Equal(t, DiffCacheStats{Hits: 1, Misses: 1}, tt.db.DiffCacheStats())
+
+	// diffs limited to other keys are cached separately
+	keyQuery := fmt.Sprintf("select to_id from dolt_diff_people where from_commit = '%s' and to_commit = '%s' and to_id = 1", from, to)
+	assert.Empty(t, tt.mustExec(s, keyQuery))
+	assert.Equal(t, DiffCacheStats{Hits: 1, Misses: 2}, tt.db.DiffCacheStats())
+	assert.Empty(t, tt.mustExec(s, keyQuery))
+	assert.Equal(t, DiffCacheStats{Hits: 2, Misses: 2}, tt.db.DiffCacheStats())
+
+	// diffs to the working set aren't cached
+	tt.mustExec(s, "update people set age = 100 where id = 0")
+	workingQuery := fmt.Sprintf("select to_id, to_age from dolt_diff_people where from_commit = '%s'", to)
+	assert.Equal(t, []sql.Row{{int64(0), int64(100)}}, tt.mustExec(s, workingQuery))
+	tt.mustExec(s, "update people set age = 101 where id = 0")
+	assert.Equal(t, []sql.Row{{int64(0), int64(101)}}, tt.mustExec(s, workingQuery))
+	assert.Equal(t, DiffCacheStats{Hits: 2, Misses: 2}, tt.db.DiffCacheStats())
+}
+
+func TestDiffCacheBudget(t *testing.T) {
+	small := sql.NewRow(int64(1), "a")
+	dc := newDiffCache(3 * estimateRowSize(small))
+	readAll := func(iter sql.RowIter) []sql.Row {
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(t, err)
+		return rows
+	}
+
+	// rows are only cached once all of them have been read
+	iter := dc.cachingIter(diffCacheKey{table: "t1"}, sql.RowsToRowIter(small, small))
+	_, err := iter.Next()
+	require.NoError(t, err)
+	_, ok := dc.get(diffCacheKey{table: "t1"})
+	assert.False(t, ok)
+	readAll(iter)
+	rows, ok := dc.get(diffCacheKey{table: "t1"})
+	require.True(t, ok)
+	assert.Equal(t, []sql.Row{small, small}, rows)
+
+	// diffs larger than the budget aren't cached
+	readAll(dc.cachingIter(diffCacheKey{table: "t2"}, sql.RowsToRowIter(small, small, small, small)))
+	_, ok = dc.get(diffCacheKey{table: "t2"})
+	assert.False(t, ok)
+
+	// the least recently used diffs are evicted to keep to the budget
+	readAll(dc.cachingIter(diffCacheKey{table: "t3"}, sql.RowsToRowIter(small, small)))
+	_, ok = dc.get(diffCacheKey{table: "t1"})
+	assert.False(t, ok)
+	rows, ok = dc.get(diffCacheKey{table: "t3"})
+	assert.True(t, ok)
+	assert.Equal(t, rows, readAll(&cachedDiffIter{rows: rows}))
+	assert.Equal(t, uint64(1), dc.stats().Evictions)
+}
@@ -0,0 +1,438 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/parse"
+	"github.com/src-d/go-mysql-server/sql/plan"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/merge"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/typeinfo"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle/expreval"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle/setalgebra"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// legacyWhereRegex matches the key=value where clauses which were supported before dolt diff accepted sql predicates.
+var legacyWhereRegex = regexp.MustCompile(`^\s*(\w+)\s*=\s*([^\s'"=<>!()]+)\s*$`)
+
+// DiffFilter limits the rows of a diff to those which satisfy a where clause.
+type DiffFilter struct {
+	// KeyRanges are the ranges of keys which could satisfy the where clause, or nil if any key could. Only the parts
+	// of the row maps within these ranges need to be diffed.
+	KeyRanges []diff.KeyRange
+
+	filter expreval.ExpressionFunc
+}
+
+// Matches returns whether the joined diff row |r| satisfies the where clause.
+func (df *DiffFilter) Matches(ctx context.Context, r row.Row) (bool, error) {
+	if df.filter == nil {
+		return true, nil
+	}
+
+	vals, err := row.GetTaggedVals(r)
+
+	if err != nil {
+		return false, err
+	}
+
+	return df.filter(ctx, vals)
+}
+
+// ParseDiffWhere parses a sql where clause over the joined rows of a diff from |fromSch| to |toSch|, whose columns are
+// described by |joinSch|. Columns may be referred to by their names in |joinSch|, such as to_pk or from_pk, or by their
+// names in the diffed table, in which case a row matches if either its old or new values satisfy the clause. The
+// predicates on the first primary key column are converted into key ranges so that only the parts of the diff which
+// could match are compared, while the whole clause is applied to each diff row. Predicates on other columns don't
+// limit the key ranges, so they still require walking every changed row.
+func ParseDiffWhere(nbf *types.NomsBinFormat, fromSch, toSch, joinSch schema.Schema, whereClause string) (*DiffFilter, error) {
+	return parseVersionsWhere(nbf, []string{diff.To, diff.From}, []schema.Schema{toSch, fromSch}, joinSch, whereClause)
+}
+
+// ParseConflictWhere parses a sql where clause over the joined versions of the conflicting rows of a table, whose
+// versions have the schemas |baseSch|, |oursSch| and |theirsSch| and whose columns are described by |joinSch|. Columns
+// may be referred to by their names in |joinSch|, such as base_pk, ours_pk or theirs_pk, or by their names in the table,
+// in which case a conflict matches if any of its versions satisfies the clause. As with ParseDiffWhere, predicates on
+// the first primary key column limit the ranges of keys whose conflicts are read.
+func ParseConflictWhere(nbf *types.NomsBinFormat, baseSch, oursSch, theirsSch, joinSch schema.Schema, whereClause string) (*DiffFilter, error) {
+	prefixes := []string{merge.BaseVersionName, merge.OursVersionName, merge.TheirsVersionName}
+	return parseVersionsWhere(nbf, prefixes, []schema.Schema{baseSch, oursSch, theirsSch}, joinSch, whereClause)
+}
+
+// parseVersionsWhere parses a where clause over rows joining several versions of a row, where the columns of the
+// version with the schema schs[i] are named with the prefix prefixes[i].
+func parseVersionsWhere(nbf *types.NomsBinFormat, prefixes []string, schs []schema.Schema, joinSch schema.Schema, whereClause string) (*DiffFilter, error) {
+	if strings.TrimSpace(whereClause) == "" {
+		return &DiffFilter{}, nil
+	}
+
+	whereClause = quoteLegacyWhereValue(joinSch, prefixes[0], whereClause)
+	expr, err := parseWhereExpression(whereClause)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sqlSch, err := doltSchemaToSqlSchema("", joinSch)
+
+	if err != nil {
+		return nil, err
+	}
+
+	firstExpr, unprefixed, err := resolveVersionColumns(sqlSch, expr, prefixes, prefixes[0])
+
+	if err != nil {
+		return nil, err
+	}
+
+	exprs := []sql.Expression{firstExpr}
+	if unprefixed {
+		for _, prefix := range prefixes[1:] {
+			versionExpr, _, err := resolveVersionColumns(sqlSch, expr, prefixes, prefix)
+
+			if err != nil {
+				return nil, err
+			}
+
+			exprs = append(exprs, versionExpr)
+		}
+	}
+
+	keyRanges, err := versionsKeyRanges(nbf, prefixes, schs, exprs...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	filter := exprs[0]
+	for _, versionExpr := range exprs[1:] {
+		filter = expression.NewOr(filter, versionExpr)
+	}
+
+	filterFunc, err := expreval.ExpressionFuncFromSQLExpressions(nbf, joinSch, []sql.Expression{filter})
+
+	if err != nil {
+		return nil, fmt.Errorf("'%s' is not supported in a where clause: %v", whereClause, err)
+	}
+
+	return &DiffFilter{KeyRanges: keyRanges, filter: filterFunc}, nil
+}
+
+// quoteLegacyWhereValue quotes the value of a key=value where clause on a string column, which older versions of dolt
+// accepted without quotes. An unprefixed column is looked up with the name it has in the version with |prefix|.
+func quoteLegacyWhereValue(joinSch schema.Schema, prefix, whereClause string) string {
+	matches := legacyWhereRegex.FindStringSubmatch(whereClause)
+
+	if matches == nil {
+		return whereClause
+	}
+
+	allCols := joinSch.GetAllCols()
+	col, ok := allCols.GetByNameCaseInsensitive(matches[1])
+
+	if !ok {
+		col, ok = allCols.GetByNameCaseInsensitive(prefix + "_" + matches[1])
+	}
+
+	if ok && typeinfo.IsStringType(col.TypeInfo) {
+		return fmt.Sprintf("%s = '%s'", matches[1], matches[2])
+	}
+
+	return whereClause
+}
+
+func parseWhereExpression(whereClause string) (sql.Expression, error) {
+	node, err := parse.Parse(sql.NewEmptyContext(), "SELECT * FROM dual WHERE "+whereClause)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var expr sql.Expression
+	plan.Inspect(node, func(n sql.Node) bool {
+		if filter, ok := n.(*plan.Filter); ok {
+			expr = filter.Expression
+			return false
+		}
+
+		return true
+	})
+
+	if expr == nil {
+		return nil, errors.New("'" + whereClause + "' is not a valid where clause")
+	}
+
+	return expr, nil
+}
+
+// resolveVersionColumns replaces the columns referenced by |expr| with fields of |sqlSch|. A column which isn't in
+// |sqlSch|, but which every version in |prefixes| has, is resolved by prefixing its name with |prefix|, in which case
+// |unprefixed| is true.
+func resolveVersionColumns(sqlSch sql.Schema, expr sql.Expression, prefixes []string, prefix string) (resolved sql.Expression, unprefixed bool, err error) {
+	resolved, err = expression.TransformUp(expr, func(e sql.Expression) (sql.Expression, error) {
+		uc, ok := e.(*expression.UnresolvedColumn)
+
+		if !ok {
+			return e, nil
+		}
+
+		name := uc.Name()
+		idx := sqlSch.IndexOf(name, "")
+
+		if idx == -1 && inEveryVersion(sqlSch, prefixes, name) {
+			unprefixed = true
+			name = prefix + "_" + name
+			idx = sqlSch.IndexOf(name, "")
+		}
+
+		if idx == -1 {
+			return nil, errors.New("where clause is invalid. '" + uc.Name() + "' is not a known column.")
+		}
+
+		col := sqlSch[idx]
+		return expression.NewGetField(idx, col.Type, col.Name, col.Nullable), nil
+	})
+
+	return resolved, unprefixed, err
+}
+
+func inEveryVersion(sqlSch sql.Schema, prefixes []string, name string) bool {
+	for _, prefix := range prefixes {
+		if sqlSch.IndexOf(prefix+"_"+name, "") == -1 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// DiffKeyRanges returns the ranges of keys which a diff from |fromSch| to |toSch| must be limited to in order to only
+// include the rows which could satisfy any one of |filters|. The filters refer to the columns of the joined diff rows,
+// so a predicate on the first primary key column pk must refer to to_pk or from_pk. A nil slice is returned when the
+// filters don't limit the keys.
+func DiffKeyRanges(nbf *types.NomsBinFormat, fromSch, toSch schema.Schema, filters ...sql.Expression) ([]diff.KeyRange, error) {
+	return versionsKeyRanges(nbf, []string{diff.To, diff.From}, []schema.Schema{toSch, fromSch}, filters...)
+}
+
+// versionsKeyRanges returns the ranges of keys which rows joining the versions of a row with the schemas |schs| must be
+// limited to in order to only include the rows which could satisfy any one of |filters|. The columns of the version
+// with the schema schs[i] are named with the prefix prefixes[i].
+func versionsKeyRanges(nbf *types.NomsBinFormat, prefixes []string, schs []schema.Schema, filters ...sql.Expression) ([]diff.KeyRange, error) {
+	pkCol, ok := versionsKeyColumn(schs)
+
+	if !ok {
+		return nil, nil
+	}
+
+	var keySet setalgebra.Set = setalgebra.EmptySet{}
+	for _, filter := range filters {
+		setForFilter, err := versionsKeySet(nbf, prefixes, pkCol, filter)
+
+		if err != nil {
+			// should probably log this to some debug logger. don't fail, just fall back on diffing every key.
+			return nil, nil
+		}
+
+		keySet, err = keySet.Union(setForFilter)
+
+		if err != nil {
+			return nil, nil
+		}
+	}
+
+	return keyRangesForSet(nbf, types.Uint(pkCol.Tag), keySet)
+}
+
+// versionsKeySet returns the set of values of |pkCol| which the keys of the joined rows satisfying |filter| must be in.
+// Every version of a joined row has the same key, so a key must be in the sets of values allowed for each version.
+func versionsKeySet(nbf *types.NomsBinFormat, prefixes []string, pkCol schema.Column, filter sql.Expression) (setalgebra.Set, error) {
+	var keySet setalgebra.Set = setalgebra.UniversalSet{}
+	for _, prefix := range prefixes {
+		col := pkCol
+		col.Name = prefix + "_" + pkCol.Name
+
+		setForCol, err := getSetForKeyColumn(nbf, col, filter)
+
+		if err != nil {
+			return nil, err
+		}
+
+		keySet, err = keySet.Intersect(setForCol)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return keySet, nil
+}
+
+// versionsKeyColumn returns the first primary key column of the versions of a table, if all of them agree on it.
+func versionsKeyColumn(schs []schema.Schema) (schema.Column, bool) {
+	var cols []schema.Column
+	for _, sch := range schs {
+		if sch != nil && sch.GetPKCols().Size() > 0 {
+			cols = append(cols, sch.GetPKCols().GetByIndex(0))
+		}
+	}
+
+	if len(cols) == 0 {
+		return schema.Column{}, false
+	}
+
+	for _, col := range cols[1:] {
+		if col.Tag != cols[0].Tag || col.Kind != cols[0].Kind || !strings.EqualFold(col.Name, cols[0].Name) {
+			return schema.Column{}, false
+		}
+	}
+
+	return cols[0], true
+}
+
+// keyRangesForSet converts a set of values of the first primary key column into sorted ranges of map keys
+func keyRangesForSet(nbf *types.NomsBinFormat, tag types.Uint, keySet setalgebra.Set) ([]diff.KeyRange, error) {
+	var ranges []diff.KeyRange
+	switch typedSet := keySet.(type) {
+	case setalgebra.UniversalSet:
+		return nil, nil
+
+	case setalgebra.EmptySet:
+		return []diff.KeyRange{}, nil
+
+	case setalgebra.FiniteSet:
+		return keyRangesForFiniteSet(nbf, tag, typedSet)
+
+	case setalgebra.Interval:
+		r, err := keyRangeForInterval(nbf, tag, typedSet)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ranges = []diff.KeyRange{r}
+
+	case setalgebra.CompositeSet:
+		for _, interval := range typedSet.Intervals {
+			r, err := keyRangeForInterval(nbf, tag, interval)
+
+			if err != nil {
+				return nil, err
+			}
+
+			ranges = append(ranges, r)
+		}
+
+		rangesForFS, err := keyRangesForFiniteSet(nbf, tag, typedSet.Set)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ranges = append(ranges, rangesForFS...)
+
+	default:
+		return nil, nil
+	}
+
+	sortKeyRanges(nbf, ranges)
+	return ranges, nil
+}
+
+func keyRangesForFiniteSet(nbf *types.NomsBinFormat, tag types.Uint, fs setalgebra.FiniteSet) ([]diff.KeyRange, error) {
+	ranges := make([]diff.KeyRange, 0, len(fs.HashToVal))
+	for _, v := range fs.HashToVal {
+		start, err := types.NewTuple(nbf, tag, v)
+
+		if err != nil {
+			return nil, err
+		}
+
+		val := v
+		ranges = append(ranges, diff.KeyRange{Start: start, InRange: func(key types.Value) (bool, error) {
+			keyVal, err := key.(types.Tuple).Get(1)
+
+			if err != nil {
+				return false, err
+			}
+
+			return val.Equals(keyVal), nil
+		}})
+	}
+
+	sortKeyRanges(nbf, ranges)
+	return ranges, nil
+}
+
+func keyRangeForInterval(nbf *types.NomsBinFormat, tag types.Uint, in setalgebra.Interval) (diff.KeyRange, error) {
+	var r diff.KeyRange
+	if in.Start != nil {
+		var err error
+		if in.Start.Inclusive {
+			r.Start, err = types.NewTuple(nbf, tag, in.Start.Val)
+		} else {
+			// sorts after every key whose first value is the start value
+			r.Start, err = types.NewTuple(nbf, tag, in.Start.Val, types.Uint(uint64(0xffffffffffffffff)))
+		}
+
+		if err != nil {
+			return diff.KeyRange{}, err
+		}
+	}
+
+	if in.End != nil {
+		end := *in.End
+		r.InRange = func(key types.Value) (bool, error) {
+			keyVal, err := key.(types.Tuple).Get(1)
+
+			if err != nil {
+				return false, err
+			}
+
+			if end.Inclusive && keyVal.Equals(end.Val) {
+				return true, nil
+			}
+
+			return keyVal.Less(nbf, end.Val)
+		}
+	}
+
+	return r, nil
+}
+
+func sortKeyRanges(nbf *types.NomsBinFormat, ranges []diff.KeyRange) {
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].Start == nil {
+			return ranges[j].Start != nil
+		} else if ranges[j].Start == nil {
+			return false
+		}
+
+		less, _ := ranges[i].Start.Less(nbf, ranges[j].Start)
+		return less
+	})
+}
@@ -0,0 +1,303 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/merge"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/rowconv"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+var diffFilterSch = schema.SchemaFromCols(mustColColl(schema.NewColCollection(
+	schema.NewColumn(pk0Name, pk0Tag, types.IntKind, true),
+	schema.NewColumn(c1Name, c1Tag, types.IntKind, false),
+	schema.NewColumn("name", 3, types.StringKind, false))))
+
+func newDiffFilterJoiner(t *testing.T) *rowconv.Joiner {
+	joiner, err := rowconv.NewJoiner(
+		[]rowconv.NamedSchema{
+			{Name: diff.From, Sch: diffFilterSch},
+			{Name: diff.To, Sch: diffFilterSch},
+		},
+		map[string]rowconv.ColNamingFunc{diff.To: toNamer, diff.From: fromNamer},
+	)
+	require.NoError(t, err)
+
+	return joiner
+}
+
+// joinDiffFilterRows joins the rows of a diff from |from| to |to|. A nil |from| or |to| is an added or removed row.
+func joinDiffFilterRows(t *testing.T, joiner *rowconv.Joiner, from, to *int64) row.Row {
+	namedRows := make(map[string]row.Row)
+	for name, pk := range map[string]*int64{diff.From: from, diff.To: to} {
+		if pk == nil {
+			continue
+		}
+
+		taggedVals := row.TaggedValues{pk0Tag: types.Int(*pk), c1Tag: types.Int(*pk * 10), 3: types.String(name)}
+		r, err := row.New(types.Format_Default, diffFilterSch, taggedVals)
+		require.NoError(t, err)
+
+		namedRows[name] = r
+	}
+
+	r, err := joiner.Join(namedRows)
+	require.NoError(t, err)
+
+	return r
+}
+
+// pksInKeyRanges returns the values of |pks| whose keys are within one of |ranges|.
+func pksInKeyRanges(t *testing.T, ranges []diff.KeyRange, pks ...int64) []int64 {
+	var inRanges []int64
+	for _, pk := range pks {
+		key, err := types.NewTuple(types.Format_Default, types.Uint(pk0Tag), types.Int(pk))
+		require.NoError(t, err)
+
+		for _, r := range ranges {
+			if r.Start != nil {
+				isLess, err := key.Less(types.Format_Default, r.Start)
+				require.NoError(t, err)
+
+				if isLess {
+					continue
+				}
+			}
+
+			inRange := true
+			if r.InRange != nil {
+				inRange, err = r.InRange(key)
+				require.NoError(t, err)
+			}
+
+			if inRange {
+				inRanges = append(inRanges, pk)
+				break
+			}
+		}
+	}
+
+	return inRanges
+}
+
+func TestParseDiffWhere(t *testing.T) {
+	allPKs := int64Range(0, 10, 1)
+
+	tests := []struct {
+		name          string
+		where         string
+		expectedPKs   []int64
+		allKeys       bool
+		matches       [][2]int64
+		doesNotMatch  [][2]int64
+		expectedError bool
+	}{
+		{
+			name:        "empty",
+			where:       "",
+			allKeys:     true,
+			matches:     [][2]int64{{1, 1}},
+			expectedPKs: allPKs,
+		},
+		{
+			name:         "pk equality",
+			where:        "to_pk0 = 2",
+			expectedPKs:  []int64{2},
+			matches:      [][2]int64{{2, 2}, {3, 2}},
+			doesNotMatch: [][2]int64{{2, 3}},
+		},
+		{
+			name:         "pk range",
+			where:        "to_pk0 >= 3 AND to_pk0 < 6",
+			expectedPKs:  []int64{3, 4, 5},
+			matches:      [][2]int64{{3, 3}, {5, 5}},
+			doesNotMatch: [][2]int64{{6, 6}, {2, 2}},
+		},
+		{
+			name:         "exclusive pk range",
+			where:        "from_pk0 > 3 AND from_pk0 <= 5",
+			expectedPKs:  []int64{4, 5},
+			matches:      [][2]int64{{4, 4}},
+			doesNotMatch: [][2]int64{{3, 3}},
+		},
+		{
+			name:         "pk in list",
+			where:        "to_pk0 in (1, 7)",
+			expectedPKs:  []int64{1, 7},
+			matches:      [][2]int64{{1, 1}, {7, 7}},
+			doesNotMatch: [][2]int64{{2, 2}},
+		},
+		{
+			name:         "unprefixed pk",
+			where:        "pk0 = 4 OR pk0 = 8",
+			expectedPKs:  []int64{4, 8},
+			matches:      [][2]int64{{4, 4}, {8, 8}},
+			doesNotMatch: [][2]int64{{5, 5}},
+		},
+		{
+			name:         "pk range and non-key predicate",
+			where:        "to_pk0 > 6 AND to_c1 > 80",
+			expectedPKs:  []int64{7, 8, 9},
+			matches:      [][2]int64{{9, 9}},
+			doesNotMatch: [][2]int64{{7, 7}, {8, 8}},
+		},
+		{
+			name:         "non-key predicate",
+			where:        "from_c1 = 30",
+			allKeys:      true,
+			expectedPKs:  allPKs,
+			matches:      [][2]int64{{3, 4}},
+			doesNotMatch: [][2]int64{{4, 3}},
+		},
+		{
+			name:         "legacy unquoted string",
+			where:        "to_name=to",
+			allKeys:      true,
+			expectedPKs:  allPKs,
+			matches:      [][2]int64{{1, 1}},
+			doesNotMatch: [][2]int64{},
+		},
+		{
+			name:          "unknown column",
+			where:         "to_missing = 1",
+			expectedError: true,
+		},
+		{
+			name:          "invalid sql",
+			where:         "to_pk0 = = 1",
+			expectedError: true,
+		},
+	}
+
+	joiner := newDiffFilterJoiner(t)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			df, err := ParseDiffWhere(types.Format_Default, diffFilterSch, diffFilterSch, joiner.GetSchema(), test.where)
+
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			if test.allKeys {
+				assert.Nil(t, df.KeyRanges)
+			} else {
+				require.NotNil(t, df.KeyRanges)
+				assert.Equal(t, test.expectedPKs, pksInKeyRanges(t, df.KeyRanges, allPKs...))
+			}
+
+			for _, pks := range test.matches {
+				matches, err := df.Matches(context.Background(), joinDiffFilterRows(t, joiner, &pks[0], &pks[1]))
+				require.NoError(t, err)
+				assert.True(t, matches, "expected %v to match", pks)
+			}
+
+			for _, pks := range test.doesNotMatch {
+				matches, err := df.Matches(context.Background(), joinDiffFilterRows(t, joiner, &pks[0], &pks[1]))
+				require.NoError(t, err)
+				assert.False(t, matches, "expected %v not to match", pks)
+			}
+		})
+	}
+}
+
+func TestParseDiffWhereAddedAndRemovedRows(t *testing.T) {
+	joiner := newDiffFilterJoiner(t)
+	df, err := ParseDiffWhere(types.Format_Default, diffFilterSch, diffFilterSch, joiner.GetSchema(), "pk0 = 5")
+	require.NoError(t, err)
+
+	pk := int64(5)
+	added := joinDiffFilterRows(t, joiner, nil, &pk)
+	removed := joinDiffFilterRows(t, joiner, &pk, nil)
+
+	for _, r := range []row.Row{added, removed} {
+		matches, err := df.Matches(context.Background(), r)
+		require.NoError(t, err)
+		assert.True(t, matches)
+	}
+
+	assert.Equal(t, []int64{5}, pksInKeyRanges(t, df.KeyRanges, int64Range(0, 10, 1)...))
+}
+
+func TestDiffKeyRangesEmpty(t *testing.T) {
+	df, err := ParseDiffWhere(types.Format_Default, diffFilterSch, diffFilterSch, newDiffFilterJoiner(t).GetSchema(), "to_pk0 > 5 AND to_pk0 < 3")
+	require.NoError(t, err)
+
+	require.NotNil(t, df.KeyRanges)
+	assert.Empty(t, df.KeyRanges)
+}
+
+func TestParseConflictWhere(t *testing.T) {
+	joiner, err := merge.NewConflictJoiner(diffFilterSch, diffFilterSch, diffFilterSch)
+	require.NoError(t, err)
+
+	// the conflict of the row with the key 5, where ours set c1 to 60 and theirs deleted the row
+	pk := int64(5)
+	versions := map[string]row.Row{}
+	for name, c1 := range map[string]int64{merge.BaseVersionName: 50, merge.OursVersionName: 60} {
+		r, err := row.New(types.Format_Default, diffFilterSch, row.TaggedValues{pk0Tag: types.Int(pk), c1Tag: types.Int(c1)})
+		require.NoError(t, err)
+		versions[name] = r
+	}
+
+	cnf, err := joiner.Join(versions)
+	require.NoError(t, err)
+
+	tests := []struct {
+		where       string
+		matches     bool
+		expectedPKs []int64
+	}{
+		{where: "c1 = 60", matches: true},
+		{where: "c1 = 50", matches: true},
+		{where: "c1 = 70", matches: false},
+		{where: "base_c1 = 60", matches: false},
+		{where: "ours_c1 = 60", matches: true},
+		{where: "pk0 = 5", matches: true, expectedPKs: []int64{5}},
+		{where: "ours_pk0 > 3 AND ours_pk0 < 6", matches: true, expectedPKs: []int64{4, 5}},
+		{where: "pk0 > 5 OR c1 = 60", matches: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.where, func(t *testing.T) {
+			cf, err := ParseConflictWhere(types.Format_Default, diffFilterSch, diffFilterSch, diffFilterSch, joiner.GetSchema(), test.where)
+			require.NoError(t, err)
+
+			matches, err := cf.Matches(context.Background(), cnf)
+			require.NoError(t, err)
+			assert.Equal(t, test.matches, matches)
+
+			if test.expectedPKs == nil {
+				assert.Nil(t, cf.KeyRanges)
+			} else {
+				assert.Equal(t, test.expectedPKs, pksInKeyRanges(t, cf.KeyRanges, int64Range(0, 10, 1)...))
+			}
+		})
+	}
+
+	_, err = ParseConflictWhere(types.Format_Default, diffFilterSch, diffFilterSch, diffFilterSch, joiner.GetSchema(), "to_c1 = 1")
+	assert.Error(t, err)
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/rowconv"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/tablediff"
 	"github.com/liquidata-inc/dolt/go/store/hash"
 	"github.com/liquidata-inc/dolt/go/store/types"
 )
@@ -56,9 +57,19 @@ type DiffTable struct {
 	fromCommitVal string
 	toCommitVal   string
 	filters       []sql.Expression
+	rowFilters    []sql.Expression
+
+	// policyFilter limits the rows to those the session's row policies permit it to read
+	policyFilter sql.Expression
+
+	// dc caches the rows of diffs between commits. fromIsCommit and toIsCommit are whether fromRoot and toRoot are the
+	// roots of commits, as diffs from or to working roots can't be cached.
+	dc           *diffCache
+	fromIsCommit bool
+	toIsCommit   bool
 }
 
-func NewDiffTable(ctx *sql.Context, dbName, tblName string) (*DiffTable, error) {
+func NewDiffTable(ctx *sql.Context, dbName, tblName string, dc *diffCache) (*DiffTable, error) {
 	sess := DSessFromSess(ctx.Session)
 	ddb, ok := sess.GetDoltDB(dbName)
 
@@ -135,7 +146,13 @@ func NewDiffTable(ctx *sql.Context, dbName, tblName string) (*DiffTable, error)
 		Source:   diffTblName,
 	})
 
-	return &DiffTable{tblName, ddb, ss, j, sqlSch, root2, root1, "current", "HEAD", nil}, nil
+	policyFilter, err := diffRowPolicyFilter(ctx, root1, tblName, sqlSch)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &DiffTable{tblName, ddb, ss, j, sqlSch, root2, root1, "current", "HEAD", nil, nil, policyFilter, dc, true, false}, nil
 }
 
 func (dt *DiffTable) Name() string {
@@ -195,6 +212,8 @@ func tableData(ctx *sql.Context, root *doltdb.RootValue, tblName string, ddb *do
 }
 
 func (dt *DiffTable) PartitionRows(ctx *sql.Context, part sql.Partition) (sql.RowIter, error) {
+	ctx = withQueryStats(ctx)
+
 	fromData, fromSch, err := tableData(ctx, dt.fromRoot, dt.name, dt.ddb)
 
 	if err != nil {
@@ -232,13 +251,75 @@ func (dt *DiffTable) PartitionRows(ctx *sql.Context, part sql.Partition) (sql.Ro
 		panic("missing required column")
 	}
 
-	return newDiffRowItr(ctx, dt.joiner, fromData, toData, fromConv, toConv, dt.fromCommitVal, dt.toCommitVal, fromCol.Tag, toCol.Tag), nil
+	// the row filters are still applied by the engine, but the diff only needs to compare the keys they could match
+	var keyRanges []diff.KeyRange
+	if len(dt.rowFilters) > 0 {
+		keyRanges, err = DiffKeyRanges(dt.ddb.Format(), fromSch, toSch, expression.JoinAnd(dt.rowFilters...))
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var iter sql.RowIter
+	if dt.dc != nil && dt.fromIsCommit && dt.toIsCommit {
+		key, err := dt.cacheKey(keyRanges != nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if rows, ok := dt.dc.get(key); ok {
+			iter = &cachedDiffIter{rows: rows}
+		} else {
+			iter = dt.dc.cachingIter(key, newDiffRowItr(ctx, dt.joiner, fromData, toData, fromSch, toSch, fromConv, toConv, dt.fromCommitVal, dt.toCommitVal, fromCol.Tag, toCol.Tag, keyRanges))
+		}
+	} else {
+		iter = newDiffRowItr(ctx, dt.joiner, fromData, toData, fromSch, toSch, fromConv, toConv, dt.fromCommitVal, dt.toCommitVal, fromCol.Tag, toCol.Tag, keyRanges)
+	}
+
+	return withRowPolicyFilter(ctx, iter, dt.policyFilter), nil
+}
+
+// cacheKey returns the key of the table's diff in the diff cache. |keysLimited| is whether the keys of the diff are
+// limited by the table's row filters.
+func (dt *DiffTable) cacheKey(keysLimited bool) (diffCacheKey, error) {
+	fromHash, err := dt.fromRoot.HashOf()
+
+	if err != nil {
+		return diffCacheKey{}, err
+	}
+
+	toHash, err := dt.toRoot.HashOf()
+
+	if err != nil {
+		return diffCacheKey{}, err
+	}
+
+	cols := make([]string, len(dt.sqlSch))
+	for i, col := range dt.sqlSch {
+		cols[i] = col.Name + " " + col.Type.String()
+	}
+
+	var keyRange string
+	if keysLimited {
+		keyRange = expression.JoinAnd(dt.rowFilters...).String()
+	}
+
+	return diffCacheKey{
+		table:    strings.ToLower(dt.name),
+		fromName: dt.fromCommitVal,
+		toName:   dt.toCommitVal,
+		fromRoot: fromHash,
+		toRoot:   toHash,
+		schema:   strings.Join(cols, ","),
+		keyRange: keyRange,
+	}, nil
 }
 
 var _ sql.RowIter = (*diffRowItr)(nil)
 
 type diffRowItr struct {
-	ad      *diff.AsyncDiffer
 	diffSrc *diff.RowDiffSource
 	joiner  *rowconv.Joiner
 	sch     schema.Schema
@@ -248,14 +329,12 @@ type diffRowItr struct {
 	toTag   uint64
 }
 
-func newDiffRowItr(ctx context.Context, joiner *rowconv.Joiner, rowDataFrom, rowDataTo types.Map, convFrom, convTo *rowconv.RowConverter, from, to string, fromTag, toTag uint64) *diffRowItr {
-	ad := diff.NewAsyncDiffer(1024)
-	ad.Start(ctx, rowDataTo, rowDataFrom)
-
-	src := diff.NewRowDiffSource(ad, joiner)
+func newDiffRowItr(ctx context.Context, joiner *rowconv.Joiner, rowDataFrom, rowDataTo types.Map, fromSch, toSch schema.Schema, convFrom, convTo *rowconv.RowConverter, from, to string, fromTag, toTag uint64, keyRanges []diff.KeyRange) *diffRowItr {
+	itr := tablediff.NewRowDiffIteratorForRows(ctx, rowDataFrom, rowDataTo, fromSch, toSch, keyRanges)
+	src := diff.NewRowDiffSource(ctx, itr, joiner)
 	src.AddInputRowConversion(convFrom, convTo)
 
-	return &diffRowItr{ad, src, joiner, joiner.GetSchema(), to, from, fromTag, toTag}
+	return &diffRowItr{src, joiner, joiner.GetSchema(), to, from, fromTag, toTag}
 }
 
 // Next returns the next row
@@ -300,36 +379,33 @@ func (itr *diffRowItr) Next() (sql.Row, error) {
 }
 
 // Close closes the iterator
-func (itr *diffRowItr) Close() (err error) {
-	defer itr.ad.Close()
-	defer func() {
-		closeErr := itr.diffSrc.Close()
-
-		if err == nil {
-			err = closeErr
-		}
-	}()
-
-	return nil
+func (itr *diffRowItr) Close() error {
+	return itr.diffSrc.Close()
 }
 
 // HandledFilters returns the list of filters that will be handled by the table itself
 func (dt *DiffTable) HandledFilters(filters []sql.Expression) []sql.Expression {
 	handled := make([]sql.Expression, 0, len(filters))
+	dt.rowFilters = make([]sql.Expression, 0, len(filters))
 	for _, f := range filters {
-		if _, ok := f.(*expression.Equals); !ok {
-			continue
+		isHandled := false
+		if _, ok := f.(*expression.Equals); ok {
+			sql.Inspect(f, func(e sql.Expression) bool {
+				if e, ok := e.(*expression.GetField); ok {
+					if e.Table() == dt.Name() && e.Name() == toCommit || e.Name() == fromCommit {
+						isHandled = true
+						return false
+					}
+				}
+				return true
+			})
 		}
 
-		sql.Inspect(f, func(e sql.Expression) bool {
-			if e, ok := e.(*expression.GetField); ok {
-				if e.Table() == dt.Name() && e.Name() == toCommit || e.Name() == fromCommit {
-					handled = append(handled, f)
-					return false
-				}
-			}
-			return true
-		})
+		if isHandled {
+			handled = append(handled, f)
+		} else {
+			dt.rowFilters = append(dt.rowFilters, f)
+		}
 	}
 
 	return handled
@@ -384,9 +460,11 @@ func (dt *DiffTable) WithFilters(filters []sql.Expression) sql.Table {
 		case toCommit:
 			dt.toRoot = root
 			dt.toCommitVal = value
+			dt.toIsCommit = true
 		case fromCommit:
 			dt.fromRoot = root
 			dt.fromCommitVal = value
+			dt.fromIsCommit = true
 		}
 	}
 
@@ -16,11 +16,13 @@ package sqle
 
 import (
 	"context"
+	"sync"
 
 	"github.com/src-d/go-mysql-server/sql"
 
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/store/chunks"
 	"github.com/liquidata-inc/dolt/go/store/hash"
 )
 
@@ -30,8 +32,15 @@ type dbRoot struct {
 }
 
 type dbData struct {
-	ddb *doltdb.DoltDB
-	rsw env.RepoStateWriter
+	ddb  *doltdb.DoltDB
+	rsr  env.RepoStateReader
+	rsw  env.RepoStateWriter
+	tc   *tableCache
+	wsMu *sync.Mutex
+}
+
+func newDBData(db Database) dbData {
+	return dbData{ddb: db.ddb, rsr: db.rsr, rsw: db.rsw, tc: db.tc, wsMu: db.wsMu}
 }
 
 var _ sql.Session = &DoltSession{}
@@ -42,13 +51,37 @@ type DoltSession struct {
 	dbRoots map[string]dbRoot
 	dbDatas map[string]dbData
 
+	// persistedHashes holds the hash of the working root of each database as of when it was last read from or written
+	// to the repo state
+	persistedHashes map[string]string
+
+	// txRoots holds the root of each database as of when the open transaction began, or is nil if no transaction is
+	// open
+	txRoots map[string]dbRoot
+
+	// savepoints are the savepoints set in the open transaction, in the order they were set
+	savepoints []savepoint
+
 	Username string
 	Email    string
+
+	// BypassRowPolicies is true for sessions which can read and write every row of every table, and manage the row
+	// policies which limit the rows other sessions can access
+	BypassRowPolicies bool
+
+	// Principal is the user and roles which the permission policies limiting the tables the session can write apply to
+	Principal doltdb.Principal
+
+	// queryStats collects the chunk read statistics of the query currently being run by the session, if any
+	queryStats *chunks.ReadStats
+
+	// workspaces tracks the workspaces of the server's sessions, or is nil if the session doesn't use a workspace
+	workspaces *Workspaces
 }
 
 // DefaultDoltSession creates a DoltSession object with default values
 func DefaultDoltSession() *DoltSession {
-	sess := &DoltSession{sql.NewBaseSession(), make(map[string]dbRoot), make(map[string]dbData), "", ""}
+	sess := &DoltSession{sql.NewBaseSession(), make(map[string]dbRoot), make(map[string]dbData), make(map[string]string), nil, nil, "", "", false, doltdb.Principal{}, nil, nil}
 	return sess
 }
 
@@ -57,10 +90,10 @@ func NewDoltSession(ctx context.Context, sqlSess sql.Session, username, email st
 	dbRoots := make(map[string]dbRoot)
 	dbDatas := make(map[string]dbData)
 	for _, db := range dbs {
-		dbDatas[db.Name()] = dbData{rsw: db.rsw, ddb: db.ddb}
+		dbDatas[db.Name()] = newDBData(db)
 	}
 
-	sess := &DoltSession{sqlSess, dbRoots, dbDatas, username, email}
+	sess := &DoltSession{sqlSess, dbRoots, dbDatas, make(map[string]string), nil, nil, username, email, false, doltdb.Principal{}, nil, nil}
 	for _, db := range dbs {
 		err := sess.AddDB(ctx, db)
 
@@ -72,11 +105,40 @@ func NewDoltSession(ctx context.Context, sqlSess sql.Session, username, email st
 	return sess, nil
 }
 
+// StartQueryStats begins collecting chunk read statistics for the next query run by the session, replacing the
+// statistics of any previous query. When detailed is true the time spent in storage is measured as well.
+func (sess *DoltSession) StartQueryStats(detailed bool) *chunks.ReadStats {
+	sess.queryStats = chunks.NewReadStats(detailed)
+	return sess.queryStats
+}
+
+// QueryStats returns the chunk read statistics of the last query started by the session, or nil if the session is not
+// collecting statistics.
+func (sess *DoltSession) QueryStats() *chunks.ReadStats {
+	return sess.queryStats
+}
+
+// withQueryStats returns a context which records chunk reads in the query statistics of the session running the query.
+// If the session is not collecting statistics the context is returned unchanged.
+func withQueryStats(ctx *sql.Context) *sql.Context {
+	sess, ok := ctx.Session.(*DoltSession)
+
+	if !ok || sess.queryStats == nil || chunks.GetReadStats(ctx) == sess.queryStats {
+		return ctx
+	}
+
+	return ctx.WithContext(chunks.WithReadStats(ctx.Context, sess.queryStats))
+}
+
 // DSessFromSess retrieves a dolt session from a standard sql.Session
 func DSessFromSess(sess sql.Session) *DoltSession {
 	return sess.(*DoltSession)
 }
 
+// CommitTransaction commits the changes the session has made to its current database to the database's working set,
+// merging them with any changes committed by other sessions since the session's root was read. It's called by the
+// handler after each write when autocommit is on, and does nothing while a transaction begun with BeginTransaction is
+// open, as the changes of a transaction are committed together by EndTransaction.
 func (sess *DoltSession) CommitTransaction(ctx *sql.Context) error {
 	currentDb := sess.GetCurrentDatabase()
 	if currentDb == "" {
@@ -88,15 +150,74 @@ func (sess *DoltSession) CommitTransaction(ctx *sql.Context) error {
 		return sql.ErrDatabaseNotFound.New(currentDb)
 	}
 
-	dbData := sess.dbDatas[currentDb]
+	if sess.InTransaction() {
+		return nil
+	}
+
+	if _, ok := sess.persistedHashes[currentDb]; !ok {
+		return sess.persistWorkingSet(ctx, currentDb, dbRoot.root)
+	}
+
+	return sess.commitWorkingSet(ctx, currentDb)
+}
+
+func (sess *DoltSession) persistWorkingSet(ctx context.Context, dbName string, root *doltdb.RootValue) error {
+	dbData := sess.dbDatas[dbName]
 
-	root := dbRoot.root
 	h, err := dbData.ddb.WriteRootValue(ctx, root)
 	if err != nil {
 		return err
 	}
 
-	return dbData.rsw.SetWorkingHash(ctx, h)
+	err = dbData.rsw.SetWorkingHash(ctx, h)
+	if err != nil {
+		return err
+	}
+
+	sess.persistedHashes[dbName] = h.String()
+	return nil
+}
+
+// PersistWorkingSets commits the working root of every database which the session has changed since the root was
+// last read from or written to the repo state, including changes made in a transaction which has not been committed.
+// Databases whose working root was never read from the repo state are skipped, as it is not known whether the session
+// changed them.
+func (sess *DoltSession) PersistWorkingSets(ctx context.Context) error {
+	for dbName, dbRoot := range sess.dbRoots {
+		persistedHash, ok := sess.persistedHashes[dbName]
+
+		if !ok || persistedHash == dbRoot.hashStr {
+			continue
+		}
+
+		err := sess.commitWorkingSet(ctx, dbName)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Release drops the state the session holds for each of the given databases. Tables cached for the session's roots
+// are evicted along with any edits to them which have not been flushed, and the session releases its references to the
+// roots so that the chunks read through them can be reclaimed. The session should not be used after being released.
+func (sess *DoltSession) Release(dbs ...Database) {
+	for _, db := range dbs {
+		if dbRoot, ok := sess.dbRoots[db.Name()]; ok {
+			db.tc.Remove(dbRoot.root)
+		}
+
+		if _, ok := sess.dbDatas[db.Name()]; ok {
+			sess.releaseWorkspace(db.Name())
+		}
+
+		delete(sess.dbRoots, db.Name())
+		delete(sess.dbDatas, db.Name())
+		delete(sess.persistedHashes, db.Name())
+		delete(sess.txRoots, db.Name())
+	}
 }
 
 // GetDoltDB returns the *DoltDB for a given database by name
@@ -212,10 +333,9 @@ func (sess *DoltSession) Set(ctx context.Context, key string, typ sql.Type, valu
 func (sess *DoltSession) AddDB(ctx context.Context, db Database) error {
 	name := db.Name()
 	rsr := db.GetStateReader()
-	rsw := db.GetStateWriter()
 	ddb := db.GetDoltDB()
 
-	sess.dbDatas[db.Name()] = dbData{rsw: rsw, ddb: ddb}
+	sess.dbDatas[db.Name()] = newDBData(db)
 
 	cs := rsr.CWBHeadSpec()
 
@@ -0,0 +1,106 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// doltStatementExecutor executes a statement the engine can't parse or ignores.
+type doltStatementExecutor func(ctx *sql.Context, e *sqle.Engine, query string) (sql.Schema, sql.RowIter, error)
+
+// doltStatements are the kinds of statements the engine can't parse or ignores, in the order they're checked for.
+var doltStatements = []struct {
+	is      func(query string) bool
+	execute doltStatementExecutor
+}{
+	{IsTriggerStatement, inCurrentDatabase(ExecuteTriggerStatement)},
+	{IsTableCommentStatement, func(ctx *sql.Context, e *sqle.Engine, query string) (sql.Schema, sql.RowIter, error) {
+		db, err := currentDatabase(ctx, e)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return ExecuteTableCommentStatement(ctx, e, db, query)
+	}},
+	{IsTransactionStatement, func(ctx *sql.Context, e *sqle.Engine, query string) (sql.Schema, sql.RowIter, error) {
+		return nil, nil, ExecuteTransactionStatement(ctx, query)
+	}},
+	{IsStatisticsStatement, inCurrentDatabase(ExecuteStatisticsStatement)},
+	{IsRowPolicyStatement, inCurrentDatabase(ExecuteRowPolicyStatement)},
+	{IsPrimaryKeyStatement, inCurrentDatabase(ExecutePrimaryKeyStatement)},
+}
+
+// IsDoltStatement returns whether the query given is one of the statements the engine can't parse or ignores, which
+// are trigger, table comment, transaction, statistics, row policy and primary key statements. Integrators must check
+// for them before running a query and run them with ExecuteDoltStatement.
+func IsDoltStatement(query string) bool {
+	for _, st := range doltStatements {
+		if st.is(query) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ExecuteDoltStatement executes the query given if it's a statement identified by IsDoltStatement, and returns false
+// otherwise, in which case the query is left to the engine. Statements are executed against the current database of
+// the context, which is looked up in the catalog of the engine given. Transaction statements have no results, so the
+// schema and row iter returned for them are nil.
+func ExecuteDoltStatement(ctx *sql.Context, e *sqle.Engine, query string) (sql.Schema, sql.RowIter, bool, error) {
+	for _, st := range doltStatements {
+		if st.is(query) {
+			sch, iter, err := st.execute(ctx, e, query)
+			return sch, iter, true, err
+		}
+	}
+
+	return nil, nil, false, nil
+}
+
+// inCurrentDatabase returns a doltStatementExecutor which executes statements with execute against the current
+// database of the context.
+func inCurrentDatabase(execute func(ctx *sql.Context, db Database, query string) (sql.Schema, sql.RowIter, error)) doltStatementExecutor {
+	return func(ctx *sql.Context, e *sqle.Engine, query string) (sql.Schema, sql.RowIter, error) {
+		db, err := currentDatabase(ctx, e)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return execute(ctx, db, query)
+	}
+}
+
+// currentDatabase returns the current database of the context, which must be a dolt database in the catalog of the
+// engine given.
+func currentDatabase(ctx *sql.Context, e *sqle.Engine) (Database, error) {
+	sqlDB, err := e.Catalog.Database(ctx.GetCurrentDatabase())
+
+	if err != nil {
+		return Database{}, err
+	}
+
+	db, ok := sqlDB.(Database)
+
+	if !ok {
+		return Database{}, sql.ErrDatabaseNotFound.New(ctx.GetCurrentDatabase())
+	}
+
+	return db, nil
+}
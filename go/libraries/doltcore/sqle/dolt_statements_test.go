@@ -0,0 +1,79 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+)
+
+func TestIsDoltStatement(t *testing.T) {
+	for _, query := range []string{
+		"create trigger trig before insert on test for each row set new.v = 1",
+		"alter table test comment = 'a comment'",
+		"begin",
+		"analyze table test",
+		"show policies",
+		"alter table test drop primary key",
+	} {
+		assert.True(t, IsDoltStatement(query), query)
+	}
+
+	for _, query := range []string{"select * from test", "create table test (pk bigint primary key)", "show tables"} {
+		assert.False(t, IsDoltStatement(query), query)
+	}
+}
+
+func TestExecuteDoltStatement(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+
+	ctx := context.Background()
+	root, _ := dEnv.WorkingRoot(ctx)
+
+	db := NewDatabase("dolt", dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
+	engine, sqlCtx, err := NewTestEngine(ctx, db, root)
+	require.NoError(t, err)
+
+	mustQuery(t, sqlCtx, engine, "create table test (pk bigint primary key, v bigint)")
+
+	_, _, ok, err := ExecuteDoltStatement(sqlCtx, engine, "select * from test")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	sch, iter, ok, err := ExecuteDoltStatement(sqlCtx, engine, "alter table test comment = 'a comment'")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, sql.OkResultSchema, sch)
+	_, err = sql.RowIterToRows(iter)
+	require.NoError(t, err)
+
+	sch, iter, ok, err = ExecuteDoltStatement(sqlCtx, engine, "begin")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Nil(t, sch)
+	assert.Nil(t, iter)
+	assert.True(t, DSessFromSess(sqlCtx.Session).InTransaction())
+
+	sqlCtx.SetCurrentDatabase("missing")
+	_, _, ok, err = ExecuteDoltStatement(sqlCtx, engine, "show policies")
+	assert.True(t, ok)
+	assert.Error(t, err)
+}
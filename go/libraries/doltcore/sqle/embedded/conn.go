@@ -0,0 +1,428 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embedded
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+
+	dsqle "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
+)
+
+// conn is a connection of a Connector. database/sql uses a connection from one goroutine at a time, so the session
+// of a connection is never used concurrently, while the sessions of different connections are isolated from each
+// other as the sessions of a sql-server are.
+type conn struct {
+	c    *Connector
+	sess *dsqle.DoltSession
+	ir   *sql.IndexRegistry
+	vr   *sql.ViewRegistry
+
+	// engine shares the catalog of the Connector's engine, but has an analyzer of its own, as an analyzer can't
+	// analyze queries in more than one goroutine at a time
+	engine *sqle.Engine
+}
+
+var _ driver.Conn = (*conn)(nil)
+var _ driver.ConnBeginTx = (*conn)(nil)
+var _ driver.QueryerContext = (*conn)(nil)
+var _ driver.ExecerContext = (*conn)(nil)
+
+// init reads the session's root of the database, from its workspace if the sessions use workspaces, and loads the
+// views, triggers and indexes of the database, as a sql-server does for a new session.
+func (cn *conn) init(ctx *sql.Context, connID uint32) error {
+	c := cn.c
+
+	var err error
+	if c.workspaces.Mode() == dsqle.NoWorkspaces {
+		err = c.db.LoadRootFromRepoState(ctx)
+	} else {
+		err = cn.sess.UseWorkspace(ctx, c.db, c.workspaces, c.workspaces.Name(c.cfg.User, connID))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	root, err := c.db.GetRoot(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	err = dsqle.RegisterSchemaFragments(ctx, c.db, root)
+
+	if err != nil {
+		return err
+	}
+
+	ctx.RegisterIndexDriver(dsqle.NewDoltIndexDriver(c.db))
+	return cn.ir.LoadIndexes(ctx, c.engine.Catalog.AllDatabases())
+}
+
+// newContext returns the context for running |query| in the connection's session, which is canceled along with |ctx|.
+func (cn *conn) newContext(ctx context.Context, query string) *sql.Context {
+	return sql.NewContext(
+		ctx,
+		sql.WithSession(cn.sess),
+		sql.WithPid(cn.c.nextPid()),
+		sql.WithQuery(query),
+		sql.WithIndexRegistry(cn.ir),
+		sql.WithViewRegistry(cn.vr))
+}
+
+// Prepare implements driver.Conn. Statements aren't prepared by the engine, so the statement returned runs the query
+// as it's given each time it's executed.
+func (cn *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{cn, query}, nil
+}
+
+// Close implements driver.Conn. The changes of a transaction which is still open are discarded.
+func (cn *conn) Close() error {
+	cn.sess.Release(cn.c.db)
+	return nil
+}
+
+// Begin implements driver.Conn.
+func (cn *conn) Begin() (driver.Tx, error) {
+	return cn.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx implements driver.ConnBeginTx. Transactions read a snapshot of each database, which includes their own
+// writes, and their changes are merged with the changes committed by other sessions when they're committed. Commit
+// returns an error for which dsqle.ErrTransactionConflict.Is is true if the changes can't be merged, in which case they
+// are discarded and the transaction can be retried.
+func (cn *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if opts.Isolation != driver.IsolationLevel(0) {
+		return nil, errors.New("embedded: only the default isolation level is supported")
+	}
+
+	_, err := cn.ExecContext(ctx, "BEGIN", nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tx{cn}, nil
+}
+
+// QueryContext implements driver.QueryerContext.
+func (cn *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if len(args) > 0 {
+		return nil, ErrArgsNotSupported
+	}
+
+	sqlCtx := cn.newContext(ctx, query)
+	sch, iter, err := cn.query(sqlCtx, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if sch.Equals(sql.OkResultSchema) || len(sch) == 0 {
+		_, err = cn.finish(sqlCtx, iter)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &rows{cn: cn, ctx: sqlCtx, iter: sql.RowsToRowIter()}, nil
+	}
+
+	return &rows{cn: cn, ctx: sqlCtx, sch: sch, iter: iter}, nil
+}
+
+// ExecContext implements driver.ExecerContext. The rows of statements which return rows are read and discarded.
+func (cn *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if len(args) > 0 {
+		return nil, ErrArgsNotSupported
+	}
+
+	sqlCtx := cn.newContext(ctx, query)
+	_, iter, err := cn.query(sqlCtx, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cn.finish(sqlCtx, iter)
+}
+
+// query runs a query in the connection's session as a sql-server runs the queries of its connections. When
+// autocommit is on, each statement outside of a transaction first reads the changes committed by other sessions. The
+// statements the engine can't parse are run by the functions which implement them.
+func (cn *conn) query(ctx *sql.Context, query string) (sql.Schema, sql.RowIter, error) {
+	if isAutocommit(ctx) && !cn.sess.InTransaction() {
+		err := cn.sess.CommitWorkingSets(ctx)
+
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	sch, iter, ok, err := dsqle.ExecuteDoltStatement(ctx, cn.engine, query)
+
+	if !ok {
+		return cn.engine.Query(ctx, query)
+	} else if err != nil {
+		return nil, nil, err
+	} else if iter == nil {
+		// transaction statements have no results
+		return nil, sql.RowsToRowIter(), nil
+	}
+
+	return sch, iter, nil
+}
+
+// finish reads the rest of the rows of a statement and closes them. When autocommit is on, the session's changes are
+// then committed to the working set unless a transaction is open. It returns the result of the statement.
+func (cn *conn) finish(ctx *sql.Context, iter sql.RowIter) (driver.Result, error) {
+	var res result
+	for {
+		r, err := iter.Next()
+
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			_ = iter.Close()
+			return nil, err
+		}
+
+		if len(r) == 1 {
+			if ok, isOk := r[0].(sql.OkResult); isOk {
+				res.rowsAffected += int64(ok.RowsAffected)
+				res.insertID = int64(ok.InsertID)
+			}
+		}
+	}
+
+	err := iter.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res, cn.commit(ctx)
+}
+
+// commit commits the session's changes to its current database to the working set if autocommit is on.
+func (cn *conn) commit(ctx *sql.Context) error {
+	if !isAutocommit(ctx) {
+		return nil
+	}
+
+	return cn.sess.CommitTransaction(ctx)
+}
+
+func isAutocommit(ctx *sql.Context) bool {
+	typ, val := ctx.Get(sql.AutoCommitSessionVar)
+
+	if val == nil {
+		return false
+	}
+
+	switch typ {
+	case sql.Int64:
+		return val.(int64) == 1
+	case sql.Boolean:
+		autocommit, _ := sql.ConvertToBool(val)
+		return autocommit
+	default:
+		return false
+	}
+}
+
+type tx struct {
+	cn *conn
+}
+
+// Commit implements driver.Tx.
+func (t tx) Commit() error {
+	_, err := t.cn.ExecContext(context.Background(), "COMMIT", nil)
+	return err
+}
+
+// Rollback implements driver.Tx.
+func (t tx) Rollback() error {
+	_, err := t.cn.ExecContext(context.Background(), "ROLLBACK", nil)
+	return err
+}
+
+type stmt struct {
+	cn    *conn
+	query string
+}
+
+var _ driver.StmtExecContext = (*stmt)(nil)
+var _ driver.StmtQueryContext = (*stmt)(nil)
+
+// Close implements driver.Stmt.
+func (s *stmt) Close() error {
+	return nil
+}
+
+// NumInput implements driver.Stmt. Queries can't have placeholders, so any arguments given are rejected when the
+// statement is executed.
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+// Exec implements driver.Stmt.
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	if len(args) > 0 {
+		return nil, ErrArgsNotSupported
+	}
+
+	return s.cn.ExecContext(context.Background(), s.query, nil)
+}
+
+// Query implements driver.Stmt.
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	if len(args) > 0 {
+		return nil, ErrArgsNotSupported
+	}
+
+	return s.cn.QueryContext(context.Background(), s.query, nil)
+}
+
+// ExecContext implements driver.StmtExecContext.
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.cn.ExecContext(ctx, s.query, args)
+}
+
+// QueryContext implements driver.StmtQueryContext.
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.cn.QueryContext(ctx, s.query, args)
+}
+
+type result struct {
+	rowsAffected int64
+	insertID     int64
+}
+
+// LastInsertId implements driver.Result.
+func (r result) LastInsertId() (int64, error) {
+	return r.insertID, nil
+}
+
+// RowsAffected implements driver.Result.
+func (r result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+// rows are the rows of a query. The statement is finished as by conn.finish when they're closed, and the rows must be
+// closed before another statement is run in the connection's session, as database/sql ensures.
+type rows struct {
+	cn   *conn
+	ctx  *sql.Context
+	sch  sql.Schema
+	iter sql.RowIter
+
+	// err is the error returned when reading the rows, if any
+	err error
+}
+
+var _ driver.RowsColumnTypeDatabaseTypeName = (*rows)(nil)
+
+// Columns implements driver.Rows.
+func (r *rows) Columns() []string {
+	names := make([]string, len(r.sch))
+	for i, col := range r.sch {
+		names[i] = col.Name
+	}
+
+	return names
+}
+
+// ColumnTypeDatabaseTypeName implements driver.RowsColumnTypeDatabaseTypeName.
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	return r.sch[index].Type.String()
+}
+
+// Next implements driver.Rows.
+func (r *rows) Next(dest []driver.Value) error {
+	row, err := r.iter.Next()
+
+	if err != nil {
+		if err != io.EOF {
+			r.err = err
+		}
+
+		return err
+	}
+
+	for i, val := range row {
+		dest[i] = driverValue(val)
+	}
+
+	return nil
+}
+
+// Close implements driver.Rows. The session's changes are committed unless reading the rows failed.
+func (r *rows) Close() error {
+	if r.iter == nil {
+		return nil
+	}
+
+	err := r.iter.Close()
+	r.iter = nil
+
+	if err != nil || r.err != nil {
+		return err
+	}
+
+	return r.cn.commit(r.ctx)
+}
+
+// driverValue returns the driver.Value of a value returned by the engine.
+func driverValue(val interface{}) driver.Value {
+	switch v := val.(type) {
+	case nil, int64, float64, bool, []byte, string, time.Time:
+		return v
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int:
+		return int64(v)
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case uint64:
+		if v > math.MaxInt64 {
+			return strconv.FormatUint(v, 10)
+		}
+
+		return int64(v)
+	case float32:
+		return float64(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
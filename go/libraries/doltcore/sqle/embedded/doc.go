@@ -0,0 +1,20 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package embedded runs SQL against a dolt repository in the current process, through database/sql, without a
+// sql-server or the CLI. OpenEmbedded returns a driver.Connector whose connections each have a session of their own,
+// which behaves as a session of a sql-server with the same configuration: statements autocommit to the working set
+// unless a transaction is open, AS OF queries and the dolt system tables are supported, and the dolt SQL functions such
+// as COMMIT, DOLT_COMMIT and HASHOF are available.
+package embedded
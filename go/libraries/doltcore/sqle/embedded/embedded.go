@@ -0,0 +1,245 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embedded
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	dsqle "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
+	_ "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle/dfunctions"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/earl"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+// embeddedLockCommand is the command recorded in the lock of the repository of a Connector.
+const embeddedLockCommand = "embedded sql"
+
+// defaultUser is the user of the sessions of a Connector whose Config doesn't give one.
+const defaultUser = "root"
+
+// ErrConnectorClosed is returned when a connection is opened by a Connector which has been closed.
+var ErrConnectorClosed = errors.New("embedded: the connector is closed")
+
+// ErrArgsNotSupported is returned for queries given arguments. The engine doesn't support placeholders, so values must
+// be written into the query.
+var ErrArgsNotSupported = errors.New("embedded: query arguments are not supported")
+
+// Config configures the sessions of the connections of a Connector.
+type Config struct {
+	// User is the user of the sessions, which names their workspaces. Defaults to root.
+	User string
+
+	// Name and Email are the author of the commits made by the sessions. They default to the user.name and user.email
+	// of the environment's config.
+	Name  string
+	Email string
+
+	// Workspaces is the mode in which the sessions use workspaces, as with the workspaces setting of a sql-server.
+	// Defaults to dsqle.NoWorkspaces.
+	Workspaces dsqle.WorkspaceMode
+
+	// Version is the version of dolt reported to remotes by an environment loaded by OpenEmbeddedPath.
+	Version string
+}
+
+// Connector runs SQL against the repository of a DoltEnv in the current process. It implements driver.Connector, so
+// it's used through database/sql by passing it to sql.OpenDB. Each connection has a session of its own, and
+// connections can be used from multiple goroutines at the same time, as database/sql does. The repository is locked
+// until the Connector is closed, which sql.DB.Close does, so that CLI commands and servers don't write to it at the
+// same time.
+type Connector struct {
+	engine     *sqle.Engine
+	db         dsqle.Database
+	cfg        Config
+	workspaces *dsqle.Workspaces
+
+	connIDs uint32
+	pids    uint64
+
+	mu   *sync.Mutex
+	lock *env.RepoLock
+}
+
+var _ driver.Connector = (*Connector)(nil)
+
+// OpenEmbedded returns a Connector for the repository of |dEnv|, which is locked until the Connector is closed. The
+// repository is given the name a sql-server would give it, which is the current database of each connection.
+func OpenEmbedded(ctx context.Context, dEnv *env.DoltEnv, cfg Config) (*Connector, error) {
+	if cfg.Workspaces == "" {
+		cfg.Workspaces = dsqle.NoWorkspaces
+	} else if !cfg.Workspaces.IsValid() {
+		return nil, fmt.Errorf("embedded: invalid workspaces mode '%s'", cfg.Workspaces)
+	}
+
+	if cfg.User == "" {
+		cfg.User = defaultUser
+	}
+
+	if cfg.Name == "" {
+		cfg.Name = *dEnv.Config.GetStringOrDefault(env.UserNameKey, "")
+	}
+
+	if cfg.Email == "" {
+		cfg.Email = *dEnv.Config.GetStringOrDefault(env.UserEmailKey, "")
+	}
+
+	lock, err := dEnv.Lock(env.NewLockInfo(embeddedLockCommand, 0))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var db dsqle.Database
+	_ = env.DoltEnvAsMultiEnv(dEnv).Iter(func(name string, dEnv *env.DoltEnv) (stop bool, err error) {
+		db = dsqle.NewDatabase(name, dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
+		return true, nil
+	})
+
+	engine := dsqle.NewEngine()
+	engine.AddDatabase(db)
+	engine.AddDatabase(dsqle.NewInformationSchemaDatabase(engine.Catalog))
+
+	return &Connector{
+		engine:     engine,
+		db:         db,
+		cfg:        cfg,
+		workspaces: dsqle.NewWorkspaces(cfg.Workspaces),
+		mu:         &sync.Mutex{},
+		lock:       lock,
+	}, nil
+}
+
+// OpenEmbeddedPath loads the environment of the repository in the directory given and returns a Connector for it, as
+// OpenEmbedded does.
+func OpenEmbeddedPath(ctx context.Context, path string, cfg Config) (*Connector, error) {
+	absPath, err := filepath.Abs(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fs, err := filesys.LocalFilesysWithWorkingDir(absPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	urlStr := earl.FileUrlFromPath(filepath.Join(absPath, dbfactory.DoltDataDir), os.PathSeparator)
+	dEnv := env.Load(ctx, env.GetCurrentUserHomeDir, fs, urlStr, cfg.Version)
+
+	if !dEnv.HasDoltDir() {
+		return nil, fmt.Errorf("embedded: '%s' is not a dolt repository", absPath)
+	} else if dEnv.RSLoadErr != nil {
+		return nil, fmt.Errorf("embedded: error loading repository at '%s': %w", absPath, dEnv.RSLoadErr)
+	} else if dEnv.DBLoadError != nil {
+		return nil, fmt.Errorf("embedded: error loading repository at '%s': %w", absPath, dEnv.DBLoadError)
+	} else if dEnv.CfgLoadErr != nil {
+		return nil, fmt.Errorf("embedded: error loading repository at '%s': %w", absPath, dEnv.CfgLoadErr)
+	}
+
+	return OpenEmbedded(ctx, dEnv, cfg)
+}
+
+// DatabaseName returns the name of the repository's database, which is the current database of each connection.
+func (c *Connector) DatabaseName() string {
+	return c.db.Name()
+}
+
+// Connect implements driver.Connector. It returns a connection with a new session.
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	c.mu.Lock()
+	closed := c.lock == nil
+	c.mu.Unlock()
+
+	if closed {
+		return nil, ErrConnectorClosed
+	}
+
+	connID := atomic.AddUint32(&c.connIDs, 1)
+	mysqlSess := sql.NewSession("", "", c.cfg.User, connID)
+	sess, err := dsqle.NewDoltSession(ctx, mysqlSess, c.cfg.Name, c.cfg.Email, c.db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the code embedding the repository has access to all of its data, so row and permission policies don't apply to it
+	sess.BypassRowPolicies = true
+	sess.Principal = doltdb.Principal{User: c.cfg.User, Roles: []string{doltdb.AdminRole}}
+
+	err = sess.Set(ctx, sql.AutoCommitSessionVar, sql.Boolean, true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sess.SetCurrentDatabase(c.db.Name())
+	engine := &sqle.Engine{Catalog: c.engine.Catalog, Analyzer: dsqle.NewAnalyzer(c.engine.Catalog), Auth: c.engine.Auth}
+	conn := &conn{c: c, sess: sess, ir: sql.NewIndexRegistry(), vr: sql.NewViewRegistry(), engine: engine}
+	err = conn.init(conn.newContext(ctx, ""), connID)
+
+	if err != nil {
+		sess.Release(c.db)
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// Driver implements driver.Connector. The driver returned can't open connections by name, as the connections of a
+// Connector are opened through it.
+func (c *Connector) Driver() driver.Driver {
+	return embeddedDriver{}
+}
+
+// Close unlocks the repository. Connections opened by the Connector should be closed first, and no more can be
+// opened.
+func (c *Connector) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.lock == nil {
+		return nil
+	}
+
+	err := c.lock.Unlock()
+	c.lock = nil
+
+	return err
+}
+
+func (c *Connector) nextPid() uint64 {
+	return atomic.AddUint64(&c.pids, 1)
+}
+
+type embeddedDriver struct{}
+
+// Open implements driver.Driver.
+func (embeddedDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("embedded: connections are opened by passing the Connector returned by OpenEmbedded to sql.OpenDB")
+}
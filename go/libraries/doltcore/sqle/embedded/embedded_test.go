@@ -0,0 +1,325 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embedded
+
+import (
+	"context"
+	gosql "database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	dsqle "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/earl"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const createPeopleTable = "create table people (id bigint not null, name varchar(64), age int unsigned, primary key (id))"
+
+func openTestDB(t *testing.T, dEnv *env.DoltEnv, cfg Config) *gosql.DB {
+	connector, err := OpenEmbedded(context.Background(), dEnv, cfg)
+	require.NoError(t, err)
+	db := gosql.OpenDB(connector)
+	t.Cleanup(func() {
+		assert.NoError(t, db.Close())
+	})
+
+	return db
+}
+
+func mustExec(t *testing.T, db interface {
+	Exec(query string, args ...interface{}) (gosql.Result, error)
+}, queries ...string) {
+	for _, query := range queries {
+		_, err := db.Exec(query)
+		require.NoError(t, err, query)
+	}
+}
+
+func queryStrings(t *testing.T, db *gosql.DB, query string) []string {
+	rows, err := db.Query(query)
+	require.NoError(t, err, query)
+	defer rows.Close()
+
+	var strs []string
+	for rows.Next() {
+		var str string
+		require.NoError(t, rows.Scan(&str))
+		strs = append(strs, str)
+	}
+
+	require.NoError(t, rows.Err())
+	return strs
+}
+
+func queryString(t *testing.T, db *gosql.DB, query string) string {
+	var str string
+	require.NoError(t, db.QueryRow(query).Scan(&str), query)
+	return str
+}
+
+func branchCommitMessage(t *testing.T, dEnv *env.DoltEnv, branch string) string {
+	ctx := context.Background()
+	cs, err := doltdb.NewCommitSpec("HEAD", branch)
+	require.NoError(t, err)
+	cm, err := dEnv.DoltDB.Resolve(ctx, cs)
+	require.NoError(t, err)
+	meta, err := cm.GetCommitMeta()
+	require.NoError(t, err)
+
+	return meta.Description
+}
+
+func TestQueryAndExec(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+	db := openTestDB(t, dEnv, Config{})
+
+	mustExec(t, db, createPeopleTable)
+	res, err := db.Exec(`insert into people values (1, "Homer", 38), (2, "Marge", 36)`)
+	require.NoError(t, err)
+	affected, err := res.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), affected)
+
+	rows, err := db.Query("select id, name, age from people order by id")
+	require.NoError(t, err)
+	cols, err := rows.ColumnTypes()
+	require.NoError(t, err)
+	require.Len(t, cols, 3)
+	assert.Equal(t, "name", cols[1].Name())
+	assert.Equal(t, "INT UNSIGNED", cols[2].DatabaseTypeName())
+
+	var ids []int64
+	var names []string
+	var ages []uint32
+	for rows.Next() {
+		var id int64
+		var name string
+		var age uint32
+		require.NoError(t, rows.Scan(&id, &name, &age))
+		ids, names, ages = append(ids, id), append(names, name), append(ages, age)
+	}
+	require.NoError(t, rows.Err())
+	require.NoError(t, rows.Close())
+	assert.Equal(t, []int64{1, 2}, ids)
+	assert.Equal(t, []string{"Homer", "Marge"}, names)
+	assert.Equal(t, []uint32{38, 36}, ages)
+
+	// writes are committed to the working set of the repository
+	root, err := dEnv.WorkingRoot(context.Background())
+	require.NoError(t, err)
+	has, err := root.HasTable(context.Background(), "people")
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	_, err = db.Query("select * from people where id = ?", 1)
+	assert.Equal(t, ErrArgsNotSupported, err)
+	_, err = db.Exec("select * from missing")
+	assert.Error(t, err)
+}
+
+func TestTransactions(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+	db := openTestDB(t, dEnv, Config{})
+	mustExec(t, db, createPeopleTable)
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	mustExec(t, tx, `insert into people (id, name) values (1, "Homer")`)
+	assert.Empty(t, queryStrings(t, db, "select name from people"))
+	require.NoError(t, tx.Rollback())
+	assert.Empty(t, queryStrings(t, db, "select name from people"))
+
+	tx, err = db.Begin()
+	require.NoError(t, err)
+	mustExec(t, tx, `insert into people (id, name) values (1, "Homer")`)
+	require.NoError(t, tx.Commit())
+	assert.Equal(t, []string{"Homer"}, queryStrings(t, db, "select name from people"))
+
+	// transactions which write the same rows conflict
+	tx1, err := db.Begin()
+	require.NoError(t, err)
+	tx2, err := db.Begin()
+	require.NoError(t, err)
+	mustExec(t, tx1, `update people set name = "Bart" where id = 1`)
+	mustExec(t, tx2, `update people set name = "Lisa" where id = 1`)
+	require.NoError(t, tx1.Commit())
+	err = tx2.Commit()
+	assert.True(t, dsqle.ErrTransactionConflict.Is(err), "unexpected error %v", err)
+	assert.Equal(t, []string{"Bart"}, queryStrings(t, db, "select name from people"))
+}
+
+func TestCommitsAndBranches(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+	connector, err := OpenEmbedded(context.Background(), dEnv, Config{})
+	require.NoError(t, err)
+	db := gosql.OpenDB(connector)
+	defer db.Close()
+
+	head := fmt.Sprintf("@@%s_head", connector.DatabaseName())
+
+	// a single connection is used, as the head of a session is a session variable
+	conn, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	defer conn.Close()
+	exec := func(query string) {
+		_, err := conn.ExecContext(context.Background(), query)
+		require.NoError(t, err, query)
+	}
+
+	exec(createPeopleTable)
+	exec(`insert into people (id, name) values (1, "Homer")`)
+	exec(fmt.Sprintf("set %s = commit('added people')", head))
+	exec(fmt.Sprintf("insert into dolt_branches (name, hash) values ('people', %s)", head))
+	assert.Equal(t, "added people", branchCommitMessage(t, dEnv, "people"))
+	assert.Equal(t, []string{"master", "people"}, queryStrings(t, db, "select name from dolt_branches order by name"))
+
+	// the commit is read as of the branch and its hash, and master doesn't have it
+	hash := queryString(t, db, "select hashof('people')")
+	assert.Equal(t, []string{"Homer"}, queryStrings(t, db, "select name from people as of 'people'"))
+	assert.Equal(t, []string{"Homer"}, queryStrings(t, db, fmt.Sprintf("select name from people as of '%s'", hash)))
+	_, err = db.Query("select name from people as of 'master'")
+	assert.Error(t, err)
+
+	exec("delete from dolt_branches where name = 'people'")
+	assert.Equal(t, []string{"master"}, queryStrings(t, db, "select name from dolt_branches"))
+}
+
+func TestDoltCommitInWorkspaces(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+	db := openTestDB(t, dEnv, Config{User: "bart", Workspaces: dsqle.UserWorkspaces})
+
+	mustExec(t, db, createPeopleTable, `insert into people (id, name) values (1, "Homer")`)
+	hash := queryString(t, db, "select dolt_commit('added people')")
+	assert.Equal(t, "added people", branchCommitMessage(t, dEnv, "master"))
+	assert.Equal(t, hash, queryString(t, db, "select hashof('master')"))
+	assert.Equal(t, []string{"added people", "Initialize data repository"}, queryStrings(t, db, "select message from dolt_log"))
+
+	mustExec(t, db, `insert into people (id, name) values (2, "Marge")`)
+	assert.Equal(t, []string{"Homer"}, queryStrings(t, db, "select name from people as of 'master' order by id"))
+	assert.Equal(t, []string{"Homer", "Marge"}, queryStrings(t, db, "select name from people order by id"))
+
+	_, err := db.Exec("select dolt_commit('added marge')")
+	assert.NoError(t, err)
+	assert.Equal(t, "added marge", branchCommitMessage(t, dEnv, "master"))
+}
+
+func TestConcurrentConnections(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+	db := openTestDB(t, dEnv, Config{})
+	db.SetMaxOpenConns(4)
+	mustExec(t, db, createPeopleTable)
+
+	const writers = 8
+	const rowsPerWriter = 10
+
+	wg := &sync.WaitGroup{}
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < rowsPerWriter; j++ {
+				id := i*rowsPerWriter + j
+				_, err := db.Exec(fmt.Sprintf(`insert into people (id, name) values (%d, "person %d")`, id, id))
+
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				var count int
+				err = db.QueryRow(fmt.Sprintf("select count(*) from people where id = %d", id)).Scan(&count)
+
+				if err != nil {
+					errs <- err
+					return
+				} else if count != 1 {
+					errs <- fmt.Errorf("row %d was not read after it was written", id)
+					return
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	var count int
+	require.NoError(t, db.QueryRow("select count(*) from people").Scan(&count))
+	assert.Equal(t, writers*rowsPerWriter, count)
+}
+
+func TestConnectorLocksRepository(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+	connector, err := OpenEmbedded(context.Background(), dEnv, Config{})
+	require.NoError(t, err)
+
+	_, err = OpenEmbedded(context.Background(), dEnv, Config{})
+	assert.True(t, env.IsRepoLocked(err), "unexpected error %v", err)
+
+	require.NoError(t, connector.Close())
+	_, err = connector.Connect(context.Background())
+	assert.Equal(t, ErrConnectorClosed, err)
+
+	connector, err = OpenEmbedded(context.Background(), dEnv, Config{})
+	require.NoError(t, err)
+	require.NoError(t, connector.Close())
+
+	_, err = OpenEmbedded(context.Background(), dEnv, Config{Workspaces: "bogus"})
+	assert.Error(t, err)
+}
+
+func TestOpenEmbeddedPath(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "test_repo")
+	require.NoError(t, os.Mkdir(dir, os.ModePerm))
+
+	_, err := OpenEmbeddedPath(context.Background(), dir, Config{})
+	assert.Error(t, err)
+
+	fs, err := filesys.LocalFilesysWithWorkingDir(dir)
+	require.NoError(t, err)
+	urlStr := earl.FileUrlFromPath(filepath.Join(dir, ".dolt", "noms"), os.PathSeparator)
+	dEnv := env.Load(context.Background(), func() (string, error) { return root, nil }, fs, urlStr, "test")
+	require.NoError(t, dEnv.InitRepo(context.Background(), types.Format_7_18, "Homer Simpson", "homer@fake.horse"))
+
+	cfg := Config{Name: "Homer Simpson", Email: "homer@fake.horse"}
+	connector, err := OpenEmbeddedPath(context.Background(), dir, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "test_repo", connector.DatabaseName())
+	db := gosql.OpenDB(connector)
+	mustExec(t, db, createPeopleTable, `insert into people (id, name) values (1, "Homer")`)
+	require.NoError(t, db.Close())
+
+	// the writes were persisted to the repository on disk
+	connector, err = OpenEmbeddedPath(context.Background(), dir, cfg)
+	require.NoError(t, err)
+	db = gosql.OpenDB(connector)
+	defer db.Close()
+	assert.Equal(t, []string{"Homer"}, queryStrings(t, db, "select name from people"))
+}
@@ -0,0 +1,63 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embedded_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	dsqle "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle/embedded"
+)
+
+func Example() {
+	dEnv := dtestutils.CreateTestEnv()
+
+	// with workspaces, DOLT_COMMIT commits the changes of a session's workspace to the branch
+	connector, err := embedded.OpenEmbedded(context.Background(), dEnv, embedded.Config{Workspaces: dsqle.UserWorkspaces})
+
+	if err != nil {
+		panic(err)
+	}
+
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	for _, query := range []string{
+		"create table people (id bigint not null, name varchar(64), primary key (id))",
+		`insert into people values (1, "Homer"), (2, "Marge")`,
+		"select dolt_commit('added people')",
+		"insert into dolt_branches (name, hash) values ('feature', hashof('master'))",
+		`insert into people values (3, "Bart")`,
+	} {
+		if _, err := db.Exec(query); err != nil {
+			panic(err)
+		}
+	}
+
+	var count, committed int
+	if err := db.QueryRow("select count(*) from people").Scan(&count); err != nil {
+		panic(err)
+	}
+
+	if err := db.QueryRow("select count(*) from people as of 'feature'").Scan(&committed); err != nil {
+		panic(err)
+	}
+
+	fmt.Println(count, committed)
+	// Output: 3 2
+}
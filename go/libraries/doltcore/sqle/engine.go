@@ -0,0 +1,39 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/analyzer"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle/collation"
+)
+
+// NewAnalyzer returns an analyzer with the default rules, followed by the rules dolt adds to them. The rules are
+// registered on the analyzer returned rather than on the default rules of go-mysql-server, so engines which aren't
+// made by NewEngine don't run them.
+func NewAnalyzer(c *sql.Catalog) *analyzer.Analyzer {
+	return analyzer.NewBuilder(c).
+		AddPostAnalyzeRule(collation.RuleName, collation.ApplyCollations).
+		AddPostAnalyzeRule(keyRangeDeleteRule, planKeyRangeDeletes).
+		Build()
+}
+
+// NewEngine returns an engine with a new catalog, which analyzes queries with the analyzer of NewAnalyzer.
+func NewEngine() *sqle.Engine {
+	c := sql.NewCatalog()
+	return sqle.New(c, NewAnalyzer(c), nil)
+}
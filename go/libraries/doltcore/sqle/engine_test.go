@@ -0,0 +1,51 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/analyzer"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle/collation"
+)
+
+func ruleNames(a *analyzer.Analyzer) map[string]bool {
+	names := make(map[string]bool)
+	for _, b := range a.Batches {
+		for _, r := range b.Rules {
+			names[r.Name] = true
+		}
+	}
+
+	return names
+}
+
+func TestNewAnalyzer(t *testing.T) {
+	doltRules := []string{collation.RuleName, keyRangeDeleteRule}
+
+	names := ruleNames(NewAnalyzer(sql.NewCatalog()))
+	for _, name := range doltRules {
+		assert.True(t, names[name], name)
+	}
+
+	// the rules aren't added to the default rules of go-mysql-server
+	names = ruleNames(analyzer.NewDefault(sql.NewCatalog()))
+	for _, name := range doltRules {
+		assert.False(t, names[name], name)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// ExplainAnalyze runs the statement of an EXPLAIN ANALYZE query, discarding its rows, and returns the plan of the
+// statement followed by the number of rows it returned, the time it took and the chunk reads it made. The returned
+// schema is the same as that of an EXPLAIN query.
+func ExplainAnalyze(ctx *sql.Context, engine *sqle.Engine, explain *sqlparser.Explain) (sql.Schema, sql.RowIter, error) {
+	switch explain.Statement.(type) {
+	case *sqlparser.Select, *sqlparser.Union, *sqlparser.ParenSelect:
+	default:
+		return nil, nil, fmt.Errorf("EXPLAIN ANALYZE is only supported for SELECT statements")
+	}
+
+	query := sqlparser.String(explain.Statement)
+	_, planIter, err := engine.Query(ctx, "explain "+query)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := sql.RowIterToRows(planIter)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stats := DSessFromSess(ctx.Session).StartQueryStats(true)
+	start := time.Now()
+	numRows, err := countRows(ctx, engine, query)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	elapsed := time.Since(start)
+	snapshot := stats.Snapshot()
+
+	// chunks are read concurrently by some iterators, so the time spent in storage can exceed the elapsed time
+	execTime := elapsed - snapshot.StorageTime
+	if execTime < 0 {
+		execTime = 0
+	}
+
+	rows = append(rows,
+		sql.NewRow(""),
+		sql.NewRow(fmt.Sprintf("rows: %d, total time: %v, execution time: %v", numRows, elapsed, execTime)),
+		sql.NewRow(snapshot.String()),
+	)
+
+	return plan.DescribeSchema, sql.RowsToRowIter(rows...), nil
+}
+
+func countRows(ctx *sql.Context, engine *sqle.Engine, query string) (int, error) {
+	_, iter, err := engine.Query(ctx, query)
+
+	if err != nil {
+		return 0, err
+	}
+
+	numRows := 0
+	for {
+		_, err = iter.Next()
+
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			_ = iter.Close()
+			return 0, err
+		}
+
+		numRows++
+	}
+
+	return numRows, iter.Close()
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+)
+
+func TestExplainAnalyze(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+	ctx := context.Background()
+	root, _ := dEnv.WorkingRoot(ctx)
+
+	root, err := ExecuteSql(dEnv, root, "create table test (a int primary key);\ninsert into test values (1), (2), (3)")
+	require.NoError(t, err)
+
+	db := NewDatabase("dolt", dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
+	engine, sqlCtx, err := NewTestEngine(ctx, db, root)
+	require.NoError(t, err)
+
+	parse := func(query string) *sqlparser.Explain {
+		stmt, err := sqlparser.Parse(query)
+		require.NoError(t, err)
+		explain, ok := stmt.(*sqlparser.Explain)
+		require.True(t, ok)
+		require.True(t, explain.Analyze)
+		return explain
+	}
+
+	sch, iter, err := ExplainAnalyze(sqlCtx, engine, parse("explain analyze select * from test where a > 1"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(sch))
+
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(t, err)
+	require.True(t, len(rows) > 3)
+	assert.Contains(t, rows[len(rows)-2][0], "rows: 2, total time: ")
+	assert.Contains(t, rows[len(rows)-1][0], "storage time: ")
+
+	stats := DSessFromSess(sqlCtx.Session).QueryStats().Snapshot()
+	assert.True(t, stats.Detailed)
+	assert.True(t, stats.ChunksRead+stats.CacheHits > 0)
+
+	insert, err := sqlparser.Parse("insert into test values (4)")
+	require.NoError(t, err)
+	_, _, err = ExplainAnalyze(sqlCtx, engine, &sqlparser.Explain{Statement: insert, Analyze: true})
+	assert.Error(t, err)
+}
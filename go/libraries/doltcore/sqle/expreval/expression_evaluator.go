@@ -66,6 +66,8 @@ func getExpFunc(nbf *types.NomsBinFormat, sch schema.Schema, exp sql.Expression)
 		return newComparisonFunc(LessOp{nbf}, typedExpr.BinaryExpression, sch)
 	case *expression.LessThanOrEqual:
 		return newComparisonFunc(LessEqualOp{nbf}, typedExpr.BinaryExpression, sch)
+	case *expression.In:
+		return newInFunc(typedExpr.BinaryExpression, sch)
 	case *expression.Or:
 		leftFunc, err := getExpFunc(nbf, sch, typedExpr.Left)
 
@@ -272,3 +274,49 @@ func newComparisonFunc(op CompareOp, exp expression.BinaryExpression, sch schema
 		return nil, errUnsupportedComparisonType.New()
 	}
 }
+
+// newInFunc returns an ExpressionFunc which tests whether a column's value is one of a list of literals
+func newInFunc(exp expression.BinaryExpression, sch schema.Schema) (ExpressionFunc, error) {
+	vars, consts, compType, err := GetComparisonType(exp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if compType != VariableInLiteralList && compType != VariableConstCompare {
+		return nil, errUnsupportedComparisonType.New()
+	}
+
+	colName := vars[0].Name()
+	col, ok := sch.GetAllCols().GetByNameCaseInsensitive(colName)
+
+	if !ok {
+		return nil, errUnknownColumn.New(colName)
+	}
+
+	nomsVals := make([]types.Value, len(consts))
+	for i, c := range consts {
+		nomsVals[i], err = LiteralToNomsValue(col.Kind, c)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tag := col.Tag
+	return func(ctx context.Context, vals map[uint64]types.Value) (b bool, err error) {
+		colVal, ok := vals[tag]
+
+		if !ok || types.IsNull(colVal) {
+			return false, nil
+		}
+
+		for _, v := range nomsVals {
+			if v.Equals(colVal) {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}, nil
+}
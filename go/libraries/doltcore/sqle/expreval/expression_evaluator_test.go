@@ -515,3 +515,39 @@ func TestNewComparisonFunc(t *testing.T) {
 		})
 	}
 }
+
+func TestNewInFunc(t *testing.T) {
+	colColl, _ := schema.NewColCollection(
+		schema.NewColumn("col0", 0, types.IntKind, true),
+		schema.NewColumn("col1", 1, types.IntKind, false),
+	)
+	testSch := schema.SchemaFromCols(colColl)
+
+	getCol1 := expression.NewGetField(1, sql.Int64, "col1", true)
+	in := expression.NewIn(getCol1, expression.NewTuple(
+		expression.NewLiteral(int8(1), sql.Int8),
+		expression.NewLiteral(int64(3), sql.Int64),
+	))
+
+	f, err := getExpFunc(types.Format_7_18, testSch, in)
+	require.NoError(t, err)
+
+	tests := []struct {
+		vals     map[uint64]types.Value
+		expected bool
+	}{
+		{map[uint64]types.Value{0: types.Int(0), 1: types.Int(1)}, true},
+		{map[uint64]types.Value{0: types.Int(0), 1: types.Int(2)}, false},
+		{map[uint64]types.Value{0: types.Int(0), 1: types.Int(3)}, true},
+		{map[uint64]types.Value{0: types.Int(0)}, false},
+	}
+
+	for _, test := range tests {
+		res, err := f(context.Background(), test.vals)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, res)
+	}
+
+	_, err = getExpFunc(types.Format_7_18, testSch, expression.NewIn(expression.NewGetField(2, sql.Int64, "unknown", true), expression.NewTuple(expression.NewLiteral(int8(1), sql.Int8))))
+	assert.Error(t, err)
+}
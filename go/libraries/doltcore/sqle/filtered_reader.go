@@ -77,6 +77,10 @@ func getSetForKeyColumn(nbf *types.NomsBinFormat, col schema.Column, filter sql.
 		return setForComparisonExp(nbf, col, typedExpr.BinaryExpression, lteOp, setForLteOp)
 	case *expression.In:
 		return setForInExp(nbf, col, typedExpr.BinaryExpression)
+	case *expression.Between:
+		lower := expression.NewGreaterThanOrEqual(typedExpr.Val, typedExpr.Lower)
+		upper := expression.NewLessThanOrEqual(typedExpr.Val, typedExpr.Upper)
+		return getSetForAndExpression(nbf, col, lower, upper)
 		// case *expression.Subquery:
 	}
 
@@ -60,6 +60,10 @@ type HistoryTable struct {
 	cmItr                 doltdb.CommitItr
 	indCmItr              *doltdb.CommitIndexingCommitItr
 	readerCreateFuncCache map[hash.Hash]CreateReaderFunc
+
+	// policyFilter limits the rows to those the session's row policies permit it to read. The policies of the
+	// session's root apply to the rows of every commit.
+	policyFilter sql.Expression
 }
 
 // NewHistoryTable creates a history table
@@ -112,6 +116,12 @@ func NewHistoryTable(ctx *sql.Context, dbName, tblName string) (*HistoryTable, e
 		return nil, err
 	}
 
+	policyFilter, err := rowPolicyReadFilter(ctx, root, tblName, schemaColumnResolver(sqlSch, nil))
+
+	if err != nil {
+		return nil, err
+	}
+
 	return &HistoryTable{
 		name:                  tblName,
 		ddb:                   ddb,
@@ -120,6 +130,7 @@ func NewHistoryTable(ctx *sql.Context, dbName, tblName string) (*HistoryTable, e
 		cmItr:                 indCmItr.Unfiltered(),
 		indCmItr:              indCmItr,
 		readerCreateFuncCache: make(map[hash.Hash]CreateReaderFunc),
+		policyFilter:          policyFilter,
 	}, nil
 }
 
@@ -259,7 +270,13 @@ func (ht *HistoryTable) Partitions(ctx *sql.Context) (sql.PartitionIter, error)
 func (ht *HistoryTable) PartitionRows(ctx *sql.Context, part sql.Partition) (sql.RowIter, error) {
 	cp := part.(*commitPartition)
 
-	return newRowItrForTableAtCommit(ctx, cp.h, cp.cm, ht.name, ht.ss, ht.rowFilters, ht.readerCreateFuncCache)
+	iter, err := newRowItrForTableAtCommit(withQueryStats(ctx), cp.h, cp.cm, ht.name, ht.ss, ht.rowFilters, ht.readerCreateFuncCache)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return withRowPolicyFilter(ctx, iter, ht.policyFilter), nil
 }
 
 // commitPartition is a single commit
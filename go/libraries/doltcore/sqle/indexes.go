@@ -21,6 +21,8 @@ import (
 
 	"github.com/src-d/go-mysql-server/sql"
 
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/indexcheck"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
 )
@@ -78,6 +80,11 @@ func (i *DoltIndexDriver) LoadAll(ctx *sql.Context, db, table string) ([]sql.Ind
 		return nil, nil
 	}
 
+	// an index which failed verification isn't used until it's rebuilt, so its lookups can't return wrong rows
+	if br, ok := database.rsr.(env.BrokenIndexesReader); ok && br.IsBrokenIndex(table, indexcheck.PrimaryKeyIndexName) {
+		return nil, nil
+	}
+
 	sch, err := tbl.GetSchema(ctx)
 
 	if err != nil {
@@ -205,7 +212,17 @@ func (idt *IndexedDoltTable) Partitions(ctx *sql.Context) (sql.PartitionIter, er
 }
 
 func (idt *IndexedDoltTable) PartitionRows(ctx *sql.Context, _ sql.Partition) (sql.RowIter, error) {
-	return idt.indexLookup.RowIter(ctx)
+	filter, err := idt.table.rowPolicyReadFilter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	iter, err := idt.indexLookup.RowIter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return withRowPolicyFilter(ctx, iter, filter), nil
 }
 
 type doltIndexLookup struct {
@@ -226,7 +243,7 @@ func (il *doltIndexLookup) Values(p sql.Partition) (sql.IndexValueIter, error) {
 
 // RowIter returns a row iterator for this index lookup. The iterator will return the single matching row for the index.
 func (il *doltIndexLookup) RowIter(ctx *sql.Context) (sql.RowIter, error) {
-	return &indexLookupRowIterAdapter{indexLookup: il, ctx: ctx}, nil
+	return &indexLookupRowIterAdapter{indexLookup: il, ctx: withQueryStats(ctx)}, nil
 }
 
 type indexLookupRowIterAdapter struct {
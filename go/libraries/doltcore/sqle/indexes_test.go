@@ -0,0 +1,58 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/indexcheck"
+	. "github.com/liquidata-inc/dolt/go/libraries/doltcore/sql/sqltestutil"
+)
+
+func TestLoadAllSkipsBrokenIndexes(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+	CreateTestDatabase(dEnv, t)
+
+	db := NewDatabase("dolt", dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
+	ctx := NewTestSQLCtx(context.Background())
+	require.NoError(t, db.LoadRootFromRepoState(ctx))
+	driver := NewDoltIndexDriver(db)
+
+	indexes, err := driver.LoadAll(ctx, "dolt", PeopleTableName)
+	require.NoError(t, err)
+	assert.Len(t, indexes, 1)
+
+	dEnv.RepoState.MarkBrokenIndex(PeopleTableName, indexcheck.PrimaryKeyIndexName, true)
+
+	indexes, err = driver.LoadAll(ctx, "dolt", PeopleTableName)
+	require.NoError(t, err)
+	assert.Empty(t, indexes)
+
+	// only the broken index is left out
+	indexes, err = driver.LoadAll(ctx, "dolt", EpisodesTableName)
+	require.NoError(t, err)
+	assert.Len(t, indexes, 1)
+
+	dEnv.RepoState.MarkBrokenIndex(PeopleTableName, indexcheck.PrimaryKeyIndexName, false)
+
+	indexes, err = driver.LoadAll(ctx, "dolt", PeopleTableName)
+	require.NoError(t, err)
+	assert.Len(t, indexes, 1)
+}
@@ -0,0 +1,150 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+
+	dsql "github.com/liquidata-inc/dolt/go/libraries/doltcore/sql"
+)
+
+// informationSchemaDatabase is the engine's information_schema database, with the comments of tables and their columns
+// filled in. The engine's information_schema database leaves them empty.
+type informationSchemaDatabase struct {
+	sql.Database
+	catalog *sql.Catalog
+}
+
+var _ sql.Database = (*informationSchemaDatabase)(nil)
+
+// NewInformationSchemaDatabase returns the information_schema database for the catalog given.
+func NewInformationSchemaDatabase(cat *sql.Catalog) sql.Database {
+	return &informationSchemaDatabase{sql.NewInformationSchemaDatabase(cat), cat}
+}
+
+// GetTableInsensitive implements the sql.Database interface.
+func (db *informationSchemaDatabase) GetTableInsensitive(ctx *sql.Context, tblName string) (sql.Table, bool, error) {
+	tbl, ok, err := db.Database.GetTableInsensitive(ctx, tblName)
+
+	if err != nil || !ok {
+		return tbl, ok, err
+	}
+
+	return db.withComments(tbl), true, nil
+}
+
+func (db *informationSchemaDatabase) withComments(tbl sql.Table) sql.Table {
+	switch strings.ToLower(tbl.Name()) {
+	case sql.TablesTableName:
+		return &commentsTable{tbl, db.catalog, tbl.Schema().IndexOf("table_comment", sql.TablesTableName), -1}
+	case sql.ColumnsTableName:
+		return &commentsTable{tbl, db.catalog, tbl.Schema().IndexOf("column_comment", sql.ColumnsTableName), 3}
+	default:
+		return tbl
+	}
+}
+
+// commentsTable is an information_schema table with a comment column, whose rows all begin with the table_catalog,
+// table_schema and table_name columns. Column comments are filled in when the table has a column name column.
+type commentsTable struct {
+	sql.Table
+	catalog    *sql.Catalog
+	commentIdx int
+	colNameIdx int
+}
+
+// PartitionRows implements the sql.Table interface.
+func (t *commentsTable) PartitionRows(ctx *sql.Context, partition sql.Partition) (sql.RowIter, error) {
+	iter, err := t.Table.PartitionRows(ctx, partition)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &commentsRowIter{ctx, t, iter, make(map[string]sql.Table)}, nil
+}
+
+type commentsRowIter struct {
+	ctx    *sql.Context
+	t      *commentsTable
+	iter   sql.RowIter
+	tables map[string]sql.Table
+}
+
+// Next implements the sql.RowIter interface.
+func (itr *commentsRowIter) Next() (sql.Row, error) {
+	r, err := itr.iter.Next()
+
+	if err != nil {
+		return nil, err
+	}
+
+	dbName, _ := r[1].(string)
+	tblName, _ := r[2].(string)
+	tbl, err := itr.table(dbName, tblName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ct, ok := tbl.(commentedTable)
+
+	if !ok {
+		return r, nil
+	}
+
+	comment := ct.Comment()
+	if itr.t.colNameIdx >= 0 {
+		comment = ""
+		colName, _ := r[itr.t.colNameIdx].(string)
+		for _, col := range tbl.Schema() {
+			if col.Name == colName {
+				comment, _ = dsql.ParseColComment(col.Comment)
+				break
+			}
+		}
+	}
+
+	r = r.Copy()
+	r[itr.t.commentIdx] = comment
+	return r, nil
+}
+
+// table returns the table named by a row of the information_schema table, or nil if there is no such table.
+func (itr *commentsRowIter) table(dbName, tblName string) (sql.Table, error) {
+	key := dbName + "." + tblName
+	if tbl, ok := itr.tables[key]; ok {
+		return tbl, nil
+	}
+
+	var tbl sql.Table
+	if db, err := itr.t.catalog.Database(dbName); err == nil {
+		tbl, _, err = db.GetTableInsensitive(itr.ctx, tblName)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	itr.tables[key] = tbl
+	return tbl, nil
+}
+
+// Close implements the sql.RowIter interface.
+func (itr *commentsRowIter) Close() error {
+	return itr.iter.Close()
+}
@@ -0,0 +1,258 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/analyzer"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle/setalgebra"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// keyRangeDeleteRule is the name of the analyzer rule planning the deletes of ranges of keys.
+const keyRangeDeleteRule = "delete_key_ranges"
+
+// planKeyRangeDeletes replaces the DELETE statements whose filter selects a range of values of the first primary key
+// column of a table with a keyRangeDelete.
+func planKeyRangeDeletes(ctx *sql.Context, a *analyzer.Analyzer, n sql.Node) (sql.Node, error) {
+	return plan.TransformUp(n, func(n sql.Node) (sql.Node, error) {
+		del, ok := n.(*plan.DeleteFrom)
+		if !ok {
+			return n, nil
+		}
+
+		filter, ok := del.Node.(*plan.Filter)
+		if !ok {
+			return n, nil
+		}
+
+		rt, ok := filter.Child.(*plan.ResolvedTable)
+		if !ok {
+			return n, nil
+		}
+
+		var t *WritableDoltTable
+		switch tbl := rt.Table.(type) {
+		case *WritableDoltTable:
+			t = tbl
+		case *AlterableDoltTable:
+			t = &tbl.WritableDoltTable
+		default:
+			return n, nil
+		}
+
+		// the edits of system tables are checked row by row
+		if doltdb.HasDoltPrefix(t.name) {
+			return n, nil
+		}
+
+		col := t.sch.GetPKCols().GetByIndex(0)
+		if !isKeyRangeFilter(col, filter.Expression) {
+			return n, nil
+		}
+
+		nbf := t.table.Format()
+		keySet, err := getSetForKeyColumn(nbf, col, filter.Expression)
+		if err != nil {
+			// the literals can't be converted to keys, so the filter is evaluated for every row instead
+			return n, nil
+		}
+
+		interval, ok := keySet.(setalgebra.Interval)
+		if !ok {
+			return n, nil
+		}
+
+		start, end, err := keyBoundsForInterval(nbf, types.Uint(col.Tag), interval)
+		if err != nil {
+			return nil, err
+		}
+
+		return &keyRangeDelete{t: t, start: start, end: end, filter: filter.Expression, fallback: del}, nil
+	})
+}
+
+// isKeyRangeFilter returns whether |filter| only compares the key column |col| with literals, combined with AND, so the
+// set of key values getSetForKeyColumn returns for it is exactly the set of values satisfying it.
+func isKeyRangeFilter(col schema.Column, filter sql.Expression) bool {
+	switch e := filter.(type) {
+	case *expression.And:
+		return isKeyRangeFilter(col, e.Left) && isKeyRangeFilter(col, e.Right)
+	case *expression.Between:
+		return isKeyColumn(col, e.Val) && isLiteral(e.Lower) && isLiteral(e.Upper)
+	case *expression.Equals, *expression.GreaterThan, *expression.GreaterThanOrEqual, *expression.LessThan, *expression.LessThanOrEqual:
+		cmp := e.(expression.Comparer)
+		return isKeyColumn(col, cmp.Left()) && isLiteral(cmp.Right())
+	}
+
+	return false
+}
+
+func isKeyColumn(col schema.Column, e sql.Expression) bool {
+	gf, ok := e.(*expression.GetField)
+	return ok && strings.EqualFold(gf.Name(), col.Name)
+}
+
+func isLiteral(e sql.Expression) bool {
+	_, ok := e.(*expression.Literal)
+	return ok
+}
+
+// keyBoundsForInterval returns the start and end of the range of keys whose first primary key value is in |in|, for
+// Map.RemoveRange. As in rangeForInterval, the keys with a value sort after the tuple of the tag and the value, and
+// before that tuple followed by the largest tag.
+func keyBoundsForInterval(nbf *types.NomsBinFormat, tag types.Uint, in setalgebra.Interval) (start, end types.Value, err error) {
+	if in.Start != nil {
+		start, err = keyBound(nbf, tag, in.Start.Val, !in.Start.Inclusive)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if in.End != nil {
+		end, err = keyBound(nbf, tag, in.End.Val, in.End.Inclusive)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return start, end, nil
+}
+
+// keyBound returns the tuple sorting before the keys whose first primary key value is |val|, or after them if |after|
+// is true.
+func keyBound(nbf *types.NomsBinFormat, tag types.Uint, val types.Value, after bool) (types.Value, error) {
+	if after {
+		return types.NewTuple(nbf, tag, val, types.Uint(uint64(0xffffffffffffffff)))
+	}
+
+	return types.NewTuple(nbf, tag, val)
+}
+
+// keyRangeDelete deletes the rows of a table whose keys are in a range by removing the range from the maps of the
+// table's rows, which drops the chunks of rows entirely within it without reading them, rather than deleting the rows
+// one at a time. When the session's row policies limit the rows of the table it can edit, it runs the DELETE it was
+// planned for instead.
+type keyRangeDelete struct {
+	t *WritableDoltTable
+	// start and end are the bounds of the keys removed, as for Map.RemoveRange
+	start, end types.Value
+	filter     sql.Expression
+	fallback   *plan.DeleteFrom
+}
+
+var _ sql.Node = (*keyRangeDelete)(nil)
+
+// Resolved implements the Resolvable interface.
+func (n *keyRangeDelete) Resolved() bool {
+	return true
+}
+
+// Schema implements the Node interface.
+func (n *keyRangeDelete) Schema() sql.Schema {
+	return sql.OkResultSchema
+}
+
+// Children implements the Node interface. The table is deleted from directly, so it isn't a child.
+func (n *keyRangeDelete) Children() []sql.Node {
+	return nil
+}
+
+// WithChildren implements the Node interface.
+func (n *keyRangeDelete) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 0 {
+		return nil, sql.ErrInvalidChildrenNumber.New(n, len(children), 0)
+	}
+
+	return n, nil
+}
+
+// RowIter implements the Node interface.
+func (n *keyRangeDelete) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	limited, err := n.limitedByRowPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if limited {
+		return n.fallback.RowIter(ctx)
+	}
+
+	root, err := n.t.db.GetRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = n.t.db.checkWritePermission(ctx, root, n.t.name)
+	if err != nil {
+		return nil, err
+	}
+
+	// The edits batched by earlier statements must be applied before the range is removed
+	err = n.t.flushBatchedEdits(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ed, err := n.t.newRowsEditor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	removed, err := ed.RemoveRange(ctx, n.start, n.end)
+	if err != nil {
+		return nil, errhand.BuildDError("failed to delete rows").AddCause(err).Build()
+	}
+
+	if removed > 0 {
+		err = n.t.updateTable(ctx, ed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sql.RowsToRowIter(sql.NewRow(sql.OkResult{RowsAffected: removed})), nil
+}
+
+// limitedByRowPolicies returns whether the session's row policies limit the rows of the table it can read or edit.
+func (n *keyRangeDelete) limitedByRowPolicies(ctx *sql.Context) (bool, error) {
+	readFilter, err := n.t.rowPolicyReadFilter(ctx)
+	if err != nil || readFilter != nil {
+		return readFilter != nil, err
+	}
+
+	te := newTableEditor(n.t)
+	err = te.loadRowPolicies(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return te.policyFilter != nil, nil
+}
+
+func (n *keyRangeDelete) String() string {
+	pr := sql.NewTreePrinter()
+	_ = pr.WriteNode("KeyRangeDelete(%s)", n.filter)
+	_ = pr.WriteChildren(n.t.Name())
+	return pr.String()
+}
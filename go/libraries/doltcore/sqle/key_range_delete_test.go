@@ -0,0 +1,151 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/parse"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+)
+
+func createKeyRangeDeleteTestTable(t testing.TB, numRows int) (*env.DoltEnv, *doltdb.RootValue) {
+	dEnv := dtestutils.CreateTestEnv()
+	root, err := dEnv.WorkingRoot(context.Background())
+	require.NoError(t, err)
+
+	root, err = ExecuteSql(dEnv, root, "create table test (pk int primary key, v int)")
+	require.NoError(t, err)
+
+	var sb strings.Builder
+	for i := 0; i < numRows; i++ {
+		if i%1000 == 0 {
+			if i > 0 {
+				sb.WriteString(";\n")
+			}
+			sb.WriteString("insert into test values ")
+		} else {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(fmt.Sprintf("(%d, %d)", i, i))
+	}
+
+	root, err = ExecuteSql(dEnv, root, sb.String())
+	require.NoError(t, err)
+
+	return dEnv, root
+}
+
+func TestKeyRangeDeletePlan(t *testing.T) {
+	dEnv, root := createKeyRangeDeleteTestTable(t, 10)
+	db := NewDatabase("dolt", dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
+	engine, sqlCtx, err := NewTestEngine(context.Background(), db, root)
+	require.NoError(t, err)
+
+	tests := []struct {
+		query    string
+		keyRange bool
+	}{
+		{"delete from test where pk > 1", true},
+		{"delete from test where pk >= 1 and pk < 5", true},
+		{"delete from test where pk between 2 and 4", true},
+		{"delete from test where pk = 1", false},
+		{"delete from test where pk > 1 and v = 2", false},
+		{"delete from test where 2 < pk", false},
+		{"delete from test where pk in (1, 2)", false},
+		{"delete from test where pk < 2 or pk > 5", false},
+		{"delete from test where v > 1", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			parsed, err := parse.Parse(sqlCtx, test.query)
+			require.NoError(t, err)
+			analyzed, err := engine.Analyzer.Analyze(sqlCtx, parsed)
+			require.NoError(t, err)
+
+			keyRange := false
+			plan.Inspect(analyzed, func(n sql.Node) bool {
+				_, ok := n.(*keyRangeDelete)
+				keyRange = keyRange || ok
+				return true
+			})
+			assert.Equal(t, test.keyRange, keyRange, "unexpected plan %v", analyzed)
+		})
+	}
+}
+
+func TestKeyRangeDelete(t *testing.T) {
+	dEnv, root := createKeyRangeDeleteTestTable(t, 100)
+	db := NewDatabase("dolt", dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
+	engine, sqlCtx, err := NewTestEngine(context.Background(), db, root)
+	require.NoError(t, err)
+
+	rows := mustQuery(t, sqlCtx, engine, "delete from test where pk >= 10 and pk < 90")
+	assert.Equal(t, []sql.Row{{sql.OkResult{RowsAffected: 80}}}, rows)
+	rows = mustQuery(t, sqlCtx, engine, "delete from test where pk between 95 and 200")
+	assert.Equal(t, []sql.Row{{sql.OkResult{RowsAffected: 5}}}, rows)
+	rows = mustQuery(t, sqlCtx, engine, "delete from test where pk > 50 and pk < 60")
+	assert.Equal(t, []sql.Row{{sql.OkResult{RowsAffected: 0}}}, rows)
+
+	rows = mustQuery(t, sqlCtx, engine, "select count(*), min(pk), max(pk) from test where pk >= 10")
+	assert.Equal(t, []sql.Row{{int64(5), int32(90), int32(94)}}, rows)
+	rows = mustQuery(t, sqlCtx, engine, "select count(*) from test")
+	assert.Equal(t, []sql.Row{{int64(15)}}, rows)
+}
+
+// BenchmarkDeleteKeyRange deletes a tenth of the rows of a table of 100,000 rows by the range of their keys, and with
+// a filter which also tests a non-key column, so that the rows are deleted one at a time.
+func BenchmarkDeleteKeyRange(b *testing.B) {
+	dEnv, root := createKeyRangeDeleteTestTable(b, 100000)
+
+	benchmarkDelete := func(b *testing.B, query string) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			db := NewDatabase("dolt", dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
+			engine, sqlCtx, err := NewTestEngine(context.Background(), db, root)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.StartTimer()
+
+			_, iter, err := engine.Query(sqlCtx, query)
+			if err == nil {
+				_, err = sql.RowIterToRows(iter)
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	b.Run("key range", func(b *testing.B) {
+		benchmarkDelete(b, "delete from test where pk >= 45000 and pk < 55000")
+	})
+
+	b.Run("row by row", func(b *testing.B) {
+		benchmarkDelete(b, "delete from test where pk >= 45000 and pk < 55000 and v is not null")
+	})
+}
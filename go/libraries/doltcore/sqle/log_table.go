@@ -16,24 +16,38 @@ package sqle
 
 import (
 	"io"
+	"sort"
+	"time"
 
 	"github.com/src-d/go-mysql-server/sql"
 
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
-	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions/commitwalk"
 )
 
 const (
 	// LogTableName is the system table name
 	LogTableName = "dolt_log"
+
+	// DoltLogTablePrefix is the name prefix of the log tables of each user table, which only have the commits which
+	// changed the table
+	DoltLogTablePrefix = "dolt_log_"
 )
 
 var _ sql.Table = (*LogTable)(nil)
+var _ sql.FilteredTable = (*LogTable)(nil)
 
-// LogTable is a sql.Table implementation that implements a system table which shows the dolt commit log
+// LogTable is a sql.Table implementation that implements a system table which shows the dolt commit log. Its rows are
+// the commits reachable from the session's head commit, newest first. Filters on its columns are evaluated while the
+// commit graph is walked: a filter on commit_hash stops the walk once the commits it names are found, and a lower bound
+// on date stops the walk at the commits made before it, so neither reads more of the history than it needs to.
 type LogTable struct {
 	dbName string
 	ddb    *doltdb.DoltDB
+
+	// tblName is the user table whose changes the log is limited to, or empty for the whole log
+	tblName string
+	filters []sql.Expression
 }
 
 // NewLogTable creates a LogTable
@@ -47,29 +61,78 @@ func NewLogTable(ctx *sql.Context, dbName string) (*LogTable, error) {
 	return &LogTable{dbName: dbName, ddb: ddb}, nil
 }
 
+// NewTableLogTable creates a LogTable which only has the commits which changed the table |tblName|, with the same
+// semantics as `dolt log <table>`.
+func NewTableLogTable(ctx *sql.Context, dbName, tblName string) (*LogTable, error) {
+	lt, err := NewLogTable(ctx, dbName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	root, ok := DSessFromSess(ctx.Session).GetRoot(dbName)
+
+	if !ok {
+		return nil, sql.ErrDatabaseNotFound.New(dbName)
+	}
+
+	_, exactName, ok, err := root.GetTableInsensitive(ctx, tblName)
+
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, sql.ErrTableNotFound.New(DoltLogTablePrefix + tblName)
+	}
+
+	lt.tblName = exactName
+	return lt, nil
+}
+
 // Name is a sql.Table interface function which returns the name of the table which is defined by the constant
 // LogTableName
 func (dt *LogTable) Name() string {
+	if dt.tblName != "" {
+		return DoltLogTablePrefix + dt.tblName
+	}
+
 	return LogTableName
 }
 
 // String is a sql.Table interface function which returns the name of the table which is defined by the constant
 // LogTableName
 func (dt *LogTable) String() string {
-	return LogTableName
+	return dt.Name()
 }
 
 // Schema is a sql.Table interface function that gets the sql.Schema of the log system table.
 func (dt *LogTable) Schema() sql.Schema {
+	name := dt.Name()
 	return []*sql.Column{
-		{Name: "commit_hash", Type: sql.Text, Source: LogTableName, PrimaryKey: true},
-		{Name: "committer", Type: sql.Text, Source: LogTableName, PrimaryKey: false},
-		{Name: "email", Type: sql.Text, Source: LogTableName, PrimaryKey: false},
-		{Name: "date", Type: sql.Datetime, Source: LogTableName, PrimaryKey: false},
-		{Name: "message", Type: sql.Text, Source: LogTableName, PrimaryKey: false},
+		{Name: "commit_hash", Type: sql.Text, Source: name, PrimaryKey: true},
+		{Name: "committer", Type: sql.Text, Source: name, PrimaryKey: false},
+		{Name: "email", Type: sql.Text, Source: name, PrimaryKey: false},
+		{Name: "date", Type: sql.Datetime, Source: name, PrimaryKey: false},
+		{Name: "message", Type: sql.Text, Source: name, PrimaryKey: false},
 	}
 }
 
+// HandledFilters returns the filters which the table evaluates while walking the commits.
+func (dt *LogTable) HandledFilters(filters []sql.Expression) []sql.Expression {
+	return handledSystemTableFilters(filters)
+}
+
+// Filters returns the filters applied to this table.
+func (dt *LogTable) Filters() []sql.Expression {
+	return dt.filters
+}
+
+// WithFilters returns a copy of the table which only has the rows satisfying |filters|.
+func (dt *LogTable) WithFilters(filters []sql.Expression) sql.Table {
+	filtered := *dt
+	filtered.filters = filters
+	return &filtered
+}
+
 // Partitions is a sql.Table interface function that returns a partition of the data.  Currently the data is unpartitioned.
 func (dt *LogTable) Partitions(*sql.Context) (sql.PartitionIter, error) {
 	return &doltTablePartitionIter{}, nil
@@ -77,37 +140,102 @@ func (dt *LogTable) Partitions(*sql.Context) (sql.PartitionIter, error) {
 
 // PartitionRows is a sql.Table interface function that gets a row iterator for a partition
 func (dt *LogTable) PartitionRows(sqlCtx *sql.Context, part sql.Partition) (sql.RowIter, error) {
-	return NewLogItr(sqlCtx, dt.dbName, dt.ddb)
+	return NewLogItr(sqlCtx, dt)
 }
 
 // LogItr is a sql.RowItr implementation which iterates over each commit as if it's a row in the table.
 type LogItr struct {
-	commits []*doltdb.Commit
-	idx     int
+	rows []sql.Row
+	idx  int
 }
 
-// NewLogItr creates a LogItr from the current environment.
-func NewLogItr(sqlCtx *sql.Context, dbName string, ddb *doltdb.DoltDB) (*LogItr, error) {
+// NewLogItr creates a LogItr for the rows of |dt| from the current environment.
+func NewLogItr(sqlCtx *sql.Context, dt *LogTable) (*LogItr, error) {
 	sess := DSessFromSess(sqlCtx.Session)
-	commit, err := sess.GetParentCommit(sqlCtx, dbName)
+	commit, err := sess.GetParentCommit(sqlCtx, dt.dbName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := commit.HashOf()
+
+	if err != nil {
+		return nil, err
+	}
+
+	bounds, err := getCommitWalkBounds(sqlCtx, dt.filters, "commit_hash", "date")
 
 	if err != nil {
 		return nil, err
 	}
 
-	commits, err := actions.TimeSortedCommits(sqlCtx, ddb, commit, -1)
+	var cmItr doltdb.CommitItr
+	if dt.tblName != "" {
+		cmItr, err = commitwalk.GetTableHistoryIteratorSince(sqlCtx, dt.ddb, h, []string{dt.tblName}, false, bounds.since)
+	} else if !bounds.since.IsZero() {
+		cmItr, err = commitwalk.GetTopologicalOrderIteratorWithParents(sqlCtx, dt.ddb, h, commitwalk.MadeSince(commitwalk.AllParents, bounds.since))
+	} else {
+		cmItr, err = commitwalk.GetTopologicalOrderIterator(sqlCtx, dt.ddb, h)
+	}
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &LogItr{commits, 0}, nil
+	// the number of commits the filters name which haven't been found yet, or -1 if they don't name any
+	remaining := -1
+	if bounds.hashes != nil {
+		remaining = len(bounds.hashes)
+	}
+
+	var rows []sql.Row
+	for remaining != 0 {
+		ch, cm, err := cmItr.Next(sqlCtx)
+
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if bounds.hashes != nil {
+			if !bounds.hashes.Has(ch) {
+				continue
+			}
+
+			remaining--
+		}
+
+		meta, err := cm.GetCommitMeta()
+
+		if err != nil {
+			return nil, err
+		}
+
+		r := sql.NewRow(ch.String(), meta.Name, meta.Email, meta.Time(), meta.Description)
+		ok, err := filtersMatch(sqlCtx, dt.filters, r)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			rows = append(rows, r)
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i][3].(time.Time).After(rows[j][3].(time.Time))
+	})
+
+	return &LogItr{rows, 0}, nil
 }
 
 // Next retrieves the next row. It will return io.EOF if it's the last row.
 // After retrieving the last row, Close will be automatically closed.
 func (itr *LogItr) Next() (sql.Row, error) {
-	if itr.idx >= len(itr.commits) {
+	if itr.idx >= len(itr.rows) {
 		return nil, io.EOF
 	}
 
@@ -115,20 +243,7 @@ func (itr *LogItr) Next() (sql.Row, error) {
 		itr.idx++
 	}()
 
-	cm := itr.commits[itr.idx]
-	meta, err := cm.GetCommitMeta()
-
-	if err != nil {
-		return nil, err
-	}
-
-	h, err := cm.HashOf()
-
-	if err != nil {
-		return nil, err
-	}
-
-	return sql.NewRow(h.String(), meta.Name, meta.Email, meta.Time(), meta.Description), nil
+	return itr.rows[itr.idx], nil
 }
 
 // Close closes the iterator.
@@ -340,7 +340,7 @@ func resetEnv(root *doltdb.RootValue) *doltdb.RootValue {
 
 func sqlNewEngine(dEnv *env.DoltEnv) (*sqle.Engine, error) {
 	db := dsql.NewDatabase("dolt", dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
-	engine := sqle.NewDefault()
+	engine := dsql.NewEngine()
 	engine.AddDatabase(db)
 
 	return engine, nil
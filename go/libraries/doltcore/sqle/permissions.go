@@ -0,0 +1,63 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+
+	"github.com/src-d/go-mysql-server/sql"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+)
+
+// sessionPrincipal returns the user and roles which the permission policies limiting the session's writes apply to.
+func sessionPrincipal(ctx *sql.Context) doltdb.Principal {
+	if sess, ok := ctx.Session.(*DoltSession); ok {
+		return sess.Principal
+	}
+
+	return doltdb.Principal{User: ctx.Client().User}
+}
+
+// checkWritePermission returns an error if the permission policies of |root| don't let the session write the table
+// given on the database's branch. Writes to a table include its creation, and changes to its schema.
+func (db Database) checkWritePermission(ctx *sql.Context, root *doltdb.RootValue, tableName string) error {
+	principal := sessionPrincipal(ctx)
+	if principal.IsAdmin() {
+		return nil
+	}
+
+	return doltdb.CheckWritePermission(ctx, root, principal, db.rsr.CWBHeadRef().GetPath(), tableName)
+}
+
+// getPermissionsTable returns the dolt_permissions table of the root given. If the root has no dolt_permissions table
+// an empty one is returned, which is added to the root when its first row is written. The empty table is cached for
+// the root like any other, so that the edits batched on it are flushed.
+func (db Database) getPermissionsTable(ctx context.Context, root *doltdb.RootValue) (sql.Table, bool, error) {
+	if table, ok, err := db.getTable(ctx, root, doltdb.PermissionsTableName); err != nil || ok {
+		return table, ok, err
+	}
+
+	tbl, err := doltdb.NewEmptyPermissionsTable(ctx, root.VRW())
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	table := &WritableDoltTable{DoltTable: DoltTable{name: doltdb.PermissionsTableName, table: tbl, sch: doltdb.PermissionsSchema, db: db}}
+	db.tc.Put(doltdb.PermissionsTableName, root, table)
+
+	return table, true, nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+)
+
+func TestPermissionPolicies(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+
+	ctx := context.Background()
+	root, _ := dEnv.WorkingRoot(ctx)
+
+	db := NewDatabase("dolt", dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
+	engine, sqlCtx, err := NewTestEngine(ctx, db, root)
+	require.NoError(t, err)
+
+	run := func(query string) ([]sql.Row, error) {
+		_, iter, err := engine.Query(sqlCtx, query)
+		if err != nil {
+			return nil, err
+		}
+
+		return sql.RowIterToRows(iter)
+	}
+
+	sess := DSessFromSess(sqlCtx.Session)
+	sess.Principal = doltdb.Principal{User: "root", Roles: []string{doltdb.AdminRole}}
+	for _, query := range []string{
+		"create table staging_people (id int primary key, name varchar(20))",
+		"create table people (id int primary key, name varchar(20))",
+		"insert into people values (1, 'amy')",
+		"insert into dolt_permissions values ('analysts_staging', 'analyst', 'master', 'staging_*', 'write'), " +
+			"('analysts_read_only', 'analyst', '*', '*', 'read')",
+	} {
+		_, err := run(query)
+		require.NoError(t, err, query)
+	}
+
+	rows, err := run("select policy_name from dolt_permissions order by policy_name")
+	require.NoError(t, err)
+	assert.Equal(t, []sql.Row{{"analysts_read_only"}, {"analysts_staging"}}, rows)
+
+	sess.Principal = doltdb.Principal{User: "amy", Roles: []string{"analyst"}}
+
+	_, err = run("insert into staging_people values (1, 'amy')")
+	assert.NoError(t, err)
+	_, err = run("update staging_people set name = 'bob'")
+	assert.NoError(t, err)
+	_, err = run("alter table staging_people add column age int")
+	assert.NoError(t, err)
+
+	for _, query := range []string{
+		"insert into people values (2, 'bob')",
+		"update people set name = 'bob'",
+		"delete from people where id = 1",
+		"delete from people",
+		"alter table people add column age int",
+		"create table people2 (id int primary key)",
+		"rename table staging_people to people2",
+		"drop table people",
+		"delete from dolt_permissions",
+		"drop table dolt_permissions",
+	} {
+		_, err := run(query)
+		if assert.Error(t, err, query) {
+			assert.True(t, errors.Is(err, doltdb.ErrPermissionDenied), "unexpected error %v", err)
+		}
+	}
+
+	_, err = run("insert into people values (2, 'bob')")
+	assert.Contains(t, err.Error(), "'analysts_read_only'")
+
+	// users without any policies aren't limited
+	sess.Principal = doltdb.Principal{User: "bob"}
+	_, err = run("insert into people values (2, 'bob')")
+	assert.NoError(t, err)
+	_, err = run("insert into dolt_permissions values ('bob', 'bob', '*', '*', 'write')")
+	assert.True(t, errors.Is(err, doltdb.ErrPermissionDenied), "unexpected error %v", err)
+
+	rows, err = run("select id, name from people order by id")
+	require.NoError(t, err)
+	assert.Equal(t, []sql.Row{{int32(1), "amy"}, {int32(2), "bob"}}, rows)
+}
@@ -0,0 +1,158 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/alterschema"
+)
+
+var alterPrimaryKeyRegex = regexp.MustCompile(`(?is)^\s*alter\s+table\s+` + triggerIdentRegexStr +
+	`\s+(?:(drop\s+primary\s+key)\s*(?:,\s*add\s+primary\s+key\s*\(([^)]*)\))?|add\s+primary\s+key\s*\([^)]*\))[\s;]*$`)
+
+// ErrPrimaryKeyRequired is returned for an ALTER TABLE statement which drops a table's primary key without adding a
+// new one, as dolt tables must have a primary key.
+var ErrPrimaryKeyRequired = errors.New("dolt tables require a primary key, use DROP PRIMARY KEY, ADD PRIMARY KEY (...) to change it")
+
+// ErrPrimaryKeyExists is returned for an ALTER TABLE statement which adds a primary key without dropping the table's
+// existing one.
+var ErrPrimaryKeyExists = errors.New("table already has a primary key, use DROP PRIMARY KEY, ADD PRIMARY KEY (...) to change it")
+
+// IsPrimaryKeyStatement returns whether the query given is an ALTER TABLE statement which drops or adds a table's
+// primary key. The SQL engine ignores these statements, so integrators must check for them before parsing a query and
+// run them with ExecutePrimaryKeyStatement.
+func IsPrimaryKeyStatement(query string) bool {
+	return alterPrimaryKeyRegex.MatchString(query)
+}
+
+// ExecutePrimaryKeyStatement executes a primary key statement, as identified by IsPrimaryKeyStatement, against the
+// database given. As every dolt table has a primary key, the only change supported is replacing it, with
+// ALTER TABLE t DROP PRIMARY KEY, ADD PRIMARY KEY (...). The rows of the table are rebuilt with their new keys, and the
+// statement fails without changing the table if the new key isn't unique. See alterschema.ChangePrimaryKey.
+func ExecutePrimaryKeyStatement(ctx *sql.Context, db Database, query string) (sql.Schema, sql.RowIter, error) {
+	m := alterPrimaryKeyRegex.FindStringSubmatch(query)
+
+	if m == nil {
+		return nil, nil, fmt.Errorf("Unsupported primary key statement: '%v'.", query)
+	}
+
+	tableName, drop, pkColNames := unquoteTriggerIdent(m[1]), m[2] != "", primaryKeyColumnNames(m[3])
+
+	name, err := tableForPrimaryKey(ctx, db, tableName)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch {
+	case !drop:
+		return nil, nil, ErrPrimaryKeyExists
+	case len(pkColNames) == 0:
+		return nil, nil, ErrPrimaryKeyRequired
+	}
+
+	return changePrimaryKey(ctx, db, name, pkColNames)
+}
+
+// primaryKeyColumnNames returns the names of the columns in the column list of an ADD PRIMARY KEY clause.
+func primaryKeyColumnNames(colList string) []string {
+	var names []string
+	for _, name := range strings.Split(colList, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, unquoteTriggerIdent(name))
+		}
+	}
+
+	return names
+}
+
+// tableForPrimaryKey returns the name of the table of the database given, matched case insensitively, whose primary
+// key is being changed.
+func tableForPrimaryKey(ctx *sql.Context, db Database, tableName string) (string, error) {
+	root, err := db.GetRoot(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	tableNames, err := getAllTableNames(ctx, root)
+
+	if err != nil {
+		return "", err
+	}
+
+	name, ok := sql.GetTableNameInsensitive(tableName, tableNames)
+
+	if !ok {
+		return "", sql.ErrTableNotFound.New(tableName)
+	} else if doltdb.IsSystemTable(name) {
+		return "", ErrSystemTableAlter.New(name)
+	}
+
+	return name, nil
+}
+
+func changePrimaryKey(ctx *sql.Context, db Database, name string, pkColNames []string) (sql.Schema, sql.RowIter, error) {
+	root, err := db.GetRoot(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tbl, ok, err := root.GetTable(ctx, name)
+
+	if err != nil {
+		return nil, nil, err
+	} else if !ok {
+		return nil, nil, sql.ErrTableNotFound.New(name)
+	}
+
+	if err := db.checkWritePermission(ctx, root, name); err != nil {
+		return nil, nil, err
+	}
+
+	tbl, err = alterschema.ChangePrimaryKey(ctx, tbl, pkColNames)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rowData, err := tbl.GetHotRowData(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	newRoot, err := root.PutTable(ctx, name, tbl)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = db.SetRoot(ctx, newRoot)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return sql.OkResultSchema, sql.RowsToRowIter(sql.NewRow(sql.NewOkResult(int(rowData.Len())))), nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/alterschema"
+)
+
+func TestIsPrimaryKeyStatement(t *testing.T) {
+	assert.True(t, IsPrimaryKeyStatement("alter table test drop primary key, add primary key (a, b)"))
+	assert.True(t, IsPrimaryKeyStatement("ALTER TABLE `test` DROP PRIMARY KEY,ADD PRIMARY KEY(`a`);"))
+	assert.True(t, IsPrimaryKeyStatement("alter table test drop primary key"))
+	assert.True(t, IsPrimaryKeyStatement("alter table test add primary key (a)"))
+	assert.False(t, IsPrimaryKeyStatement("alter table test add column b int primary key"))
+	assert.False(t, IsPrimaryKeyStatement("alter table test drop column a"))
+	assert.False(t, IsPrimaryKeyStatement("create table test (a int, primary key (a))"))
+}
+
+func TestPrimaryKeyStatements(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+
+	ctx := context.Background()
+	root, _ := dEnv.WorkingRoot(ctx)
+
+	db := NewDatabase("dolt", dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
+	engine, sqlCtx, err := NewTestEngine(ctx, db, root)
+	require.NoError(t, err)
+
+	for _, query := range []string{
+		"create table test (id int primary key, a int, b varchar(20))",
+		"insert into test values (1, 20, 'x'), (2, 10, 'y'), (3, 10, 'x')",
+	} {
+		_, iter, err := engine.Query(sqlCtx, query)
+		require.NoError(t, err)
+		_, err = sql.RowIterToRows(iter)
+		require.NoError(t, err)
+	}
+
+	_, _, err = ExecutePrimaryKeyStatement(sqlCtx, db, "alter table test drop primary key")
+	assert.Equal(t, ErrPrimaryKeyRequired, err)
+	_, _, err = ExecutePrimaryKeyStatement(sqlCtx, db, "alter table test add primary key (a)")
+	assert.Equal(t, ErrPrimaryKeyExists, err)
+	_, _, err = ExecutePrimaryKeyStatement(sqlCtx, db, "alter table missing drop primary key, add primary key (a)")
+	assert.True(t, sql.ErrTableNotFound.Is(err), "unexpected error %v", err)
+
+	_, _, err = ExecutePrimaryKeyStatement(sqlCtx, db, "alter table test drop primary key, add primary key (a)")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, alterschema.ErrDuplicatePrimaryKey))
+	assert.Contains(t, err.Error(), "(10)")
+
+	_, iter, err := ExecutePrimaryKeyStatement(sqlCtx, db, "ALTER TABLE TEST DROP PRIMARY KEY, ADD PRIMARY KEY (`b`, a)")
+	require.NoError(t, err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(t, err)
+	assert.Equal(t, []sql.Row{{sql.NewOkResult(3)}}, rows)
+
+	// the rows are now ordered by the new key, whose columns are in the order of the table
+	_, iter, err = engine.Query(sqlCtx, "select id, a, b from test")
+	require.NoError(t, err)
+	rows, err = sql.RowIterToRows(iter)
+	require.NoError(t, err)
+	assert.Equal(t, []sql.Row{{int32(3), int32(10), "x"}, {int32(2), int32(10), "y"}, {int32(1), int32(20), "x"}}, rows)
+
+	_, iter, err = engine.Query(sqlCtx, "insert into test values (4, 20, 'x')")
+	if err == nil {
+		_, err = sql.RowIterToRows(iter)
+	}
+	assert.Error(t, err)
+
+	_, iter, err = engine.Query(sqlCtx, "insert into test values (1, 30, 'x')")
+	require.NoError(t, err)
+	_, err = sql.RowIterToRows(iter)
+	require.NoError(t, err)
+}
@@ -0,0 +1,144 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+)
+
+const slowStorageRows = 5000
+
+// slowStorageEnv returns an environment whose database has a table of slowStorageRows rows, whose reads are delayed
+// according to the returned LatencySchedule.
+func slowStorageEnv(t *testing.T) (*env.DoltEnv, *chunks.LatencySchedule) {
+	ctx := context.Background()
+	storage := &chunks.MemoryStorage{}
+	dEnv := dtestutils.CreateTestEnv()
+	dEnv.DoltDB = doltdb.DoltDBFromCS(storage.NewView())
+	require.NoError(t, dEnv.DoltDB.WriteEmptyRepo(ctx, "billy bob", "bigbillieb@fake.horse"))
+
+	root := headRoot(t, dEnv.DoltDB)
+	values := make([]string, slowStorageRows)
+	for i := range values {
+		values[i] = fmt.Sprintf("(%d, %d)", i, i%10)
+	}
+
+	root, err := ExecuteSql(dEnv, root, "create table test (pk int primary key, c1 int);\ninsert into test values "+strings.Join(values, ","))
+	require.NoError(t, err)
+
+	h, err := dEnv.DoltDB.WriteRootValue(ctx, root)
+	require.NoError(t, err)
+	meta, err := doltdb.NewCommitMeta("billy bob", "bigbillieb@fake.horse", "add test")
+	require.NoError(t, err)
+	_, err = dEnv.DoltDB.Commit(ctx, h, ref.NewBranchRef("master"), meta)
+	require.NoError(t, err)
+
+	// a new database on the same storage, so nothing read by the query is cached
+	latency := chunks.NewLatencySchedule(nil)
+	dEnv.DoltDB = doltdb.DoltDBFromCS(chunks.NewLatencyChunkStore(storage.NewView(), latency))
+
+	return dEnv, latency
+}
+
+func headRoot(t *testing.T, ddb *doltdb.DoltDB) *doltdb.RootValue {
+	cs, err := doltdb.NewCommitSpec("HEAD", "refs/heads/master")
+	require.NoError(t, err)
+	cm, err := ddb.Resolve(context.Background(), cs)
+	require.NoError(t, err)
+	root, err := cm.GetRootValue()
+	require.NoError(t, err)
+
+	return root
+}
+
+func newEngine(t *testing.T, ctx context.Context, dEnv *env.DoltEnv) (*sqle.Engine, *sql.Context) {
+	db := NewDatabase("dolt", dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
+	engine, sqlCtx, err := NewTestEngine(ctx, db, headRoot(t, dEnv.DoltDB))
+	require.NoError(t, err)
+
+	return engine, sqlCtx
+}
+
+// queryWithTimeout runs |query| and returns its rows, failing the test if it doesn't return.
+func queryWithTimeout(t *testing.T, engine *sqle.Engine, sqlCtx *sql.Context, query string) ([]sql.Row, error) {
+	type result struct {
+		rows []sql.Row
+		err  error
+	}
+
+	resCh := make(chan result, 1)
+	go func() {
+		_, iter, err := engine.Query(sqlCtx, query)
+		if err != nil {
+			resCh <- result{nil, err}
+			return
+		}
+
+		rows, err := sql.RowIterToRows(iter)
+		resCh <- result{rows, err}
+	}()
+
+	select {
+	case res := <-resCh:
+		return res.rows, res.err
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "the query didn't return")
+		return nil, nil
+	}
+}
+
+func TestQueryOnSlowStorage(t *testing.T) {
+	dEnv, latency := slowStorageEnv(t)
+	latency.SetLatency(chunks.OpGet, chunks.NewUniformLatency(0, time.Millisecond, 1))
+	latency.SetLatency(chunks.OpGetMany, chunks.NewSpikyLatency(0, 20*time.Millisecond, 0.1, 1))
+
+	engine, sqlCtx := newEngine(t, context.Background(), dEnv)
+	rows, err := queryWithTimeout(t, engine, sqlCtx, "select count(*), sum(c1) from test")
+	require.NoError(t, err)
+	assert.Equal(t, []sql.Row{{int64(slowStorageRows), float64(slowStorageRows / 10 * 45)}}, rows)
+}
+
+func TestQueryCancellation(t *testing.T) {
+	dEnv, latency := slowStorageEnv(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// reads made while the engine is set up aren't delayed, so the query itself is canceled
+	engine, sqlCtx := newEngine(t, ctx, dEnv)
+
+	latency.SetLatency(chunks.OpGet, chunks.FixedLatency(time.Minute))
+	latency.SetLatency(chunks.OpGetMany, chunks.FixedLatency(time.Minute))
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	_, err := queryWithTimeout(t, engine, sqlCtx, "select sum(c1) from test")
+	assert.True(t, errors.Is(err, context.Canceled), "unexpected error: %v", err)
+}
@@ -0,0 +1,125 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/src-d/go-mysql-server/sql"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+)
+
+const (
+	// RemotesTableName is the system table name
+	RemotesTableName = "dolt_remotes"
+)
+
+var _ sql.Table = (*RemotesTable)(nil)
+
+// RemotesTable is a sql.Table implementation that implements a system table which shows the remotes of the repository.
+// The fetch specs and params of each remote are JSON encoded, as an array of strings and an object respectively. A
+// database whose repo state can't list its remotes has none.
+type RemotesTable struct {
+	dbd dbData
+}
+
+// NewRemotesTable creates a RemotesTable
+func NewRemotesTable(sqlCtx *sql.Context, dbName string) (*RemotesTable, error) {
+	dbd, ok := DSessFromSess(sqlCtx.Session).dbDatas[dbName]
+
+	if !ok {
+		return nil, sql.ErrDatabaseNotFound.New(dbName)
+	}
+
+	return &RemotesTable{dbd}, nil
+}
+
+// Name is a sql.Table interface function which returns the name of the table which is defined by the constant
+// RemotesTableName
+func (rt *RemotesTable) Name() string {
+	return RemotesTableName
+}
+
+// String is a sql.Table interface function which returns the name of the table which is defined by the constant
+// RemotesTableName
+func (rt *RemotesTable) String() string {
+	return RemotesTableName
+}
+
+// Schema is a sql.Table interface function that gets the sql.Schema of the remotes system table
+func (rt *RemotesTable) Schema() sql.Schema {
+	return []*sql.Column{
+		{Name: "name", Type: sql.Text, Source: RemotesTableName, PrimaryKey: true, Nullable: false},
+		{Name: "url", Type: sql.Text, Source: RemotesTableName, PrimaryKey: false, Nullable: false},
+		{Name: "fetch_specs", Type: sql.Text, Source: RemotesTableName, PrimaryKey: false, Nullable: false},
+		{Name: "params", Type: sql.Text, Source: RemotesTableName, PrimaryKey: false, Nullable: false},
+	}
+}
+
+// Partitions is a sql.Table interface function that returns a partition of the data.  Currently the data is unpartitioned.
+func (rt *RemotesTable) Partitions(*sql.Context) (sql.PartitionIter, error) {
+	return &doltTablePartitionIter{}, nil
+}
+
+// PartitionRows is a sql.Table interface function that gets a row iterator for a partition. The remotes are ordered by
+// name.
+func (rt *RemotesTable) PartitionRows(sqlCtx *sql.Context, part sql.Partition) (sql.RowIter, error) {
+	rr, ok := rt.dbd.rsr.(env.RemotesReader)
+
+	if !ok {
+		return sql.RowsToRowIter(), nil
+	}
+
+	remotes := rr.GetRemotes()
+	names := make([]string, 0, len(remotes))
+	for name := range remotes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	rows := make([]sql.Row, len(names))
+	for i, name := range names {
+		r := remotes[name]
+
+		fetchSpecs := r.FetchSpecs
+		if fetchSpecs == nil {
+			fetchSpecs = []string{}
+		}
+
+		params := r.Params
+		if params == nil {
+			params = map[string]string{}
+		}
+
+		fetchSpecsJSON, err := json.Marshal(fetchSpecs)
+
+		if err != nil {
+			return nil, err
+		}
+
+		paramsJSON, err := json.Marshal(params)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rows[i] = sql.NewRow(r.Name, r.Url, string(fetchSpecsJSON), string(paramsJSON))
+	}
+
+	return sql.RowsToRowIter(rows...), nil
+}
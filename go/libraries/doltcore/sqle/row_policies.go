@@ -0,0 +1,737 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/expression/function"
+	"gopkg.in/src-d/go-errors.v1"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	dsql "github.com/liquidata-inc/dolt/go/libraries/doltcore/sql"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+var ErrRowPolicyExists = errors.NewKind("Row policy %s already exists on table %s")
+var ErrRowPolicyNotFound = errors.NewKind("Row policy %s does not exist on table %s")
+var ErrInvalidRowPolicy = errors.NewKind("Invalid row policy %s on table %s: %s")
+var ErrRowPolicySystemTable = errors.NewKind("Row policies cannot be defined on system table %s")
+var ErrRowPolicyViolation = errors.NewKind("The row is not permitted by the row policies of table %s for user '%s'")
+var ErrRowPolicyAccessDenied = errors.NewKind("User '%s' is limited by row policies and cannot %s")
+
+// The user of a policy may be PUBLIC, which applies the policy to every user, a quoted string or an identifier.
+const policyUserRegexStr = "(public|'[^']*'|`[^`]+`|[A-Za-z0-9_$@.%-]+)"
+
+var createPolicyRegex = regexp.MustCompile(`(?is)^\s*create\s+policy\s+(if\s+not\s+exists\s+)?` + triggerIdentRegexStr +
+	`\s+on\s+` + triggerIdentRegexStr + `(\s+to\s+` + policyUserRegexStr + `)?\s+using\s*\((.+)\)[\s;]*$`)
+var dropPolicyRegex = regexp.MustCompile(`(?is)^\s*drop\s+policy\s+(if\s+exists\s+)?` + triggerIdentRegexStr + `\s+on\s+` +
+	triggerIdentRegexStr + `[\s;]*$`)
+var showPoliciesRegex = regexp.MustCompile(`(?is)^\s*show\s+policies(\s+(on|for|from)\s+` + triggerIdentRegexStr + `)?[\s;]*$`)
+var rowPolicyStatementRegex = regexp.MustCompile(`(?is)^\s*((create|drop)\s+policy|show\s+policies)\b`)
+
+var showPoliciesSchema = sql.Schema{
+	{Name: "Table", Type: sql.LongText},
+	{Name: "Policy", Type: sql.LongText},
+	{Name: "User", Type: sql.LongText},
+	{Name: "Predicate", Type: sql.LongText},
+}
+
+// rowPolicyFunctions are the functions which the predicates of row policies can call.
+var rowPolicyFunctions = func() sql.FunctionRegistry {
+	r := sql.NewFunctionRegistry()
+	r.MustRegister(function.Defaults...)
+	return r
+}()
+
+// The fixed schema for the `dolt_row_policies` table.
+func RowPoliciesTableSchema() sql.Schema {
+	return []*sql.Column{
+		{Name: doltdb.RowPoliciesTableCol, Type: sql.Text, Source: doltdb.RowPoliciesTableName, PrimaryKey: true, Comment: dsql.FmtColTagComment(doltdb.RowPoliciesTableTag)},
+		{Name: doltdb.RowPoliciesNameCol, Type: sql.Text, Source: doltdb.RowPoliciesTableName, PrimaryKey: true, Comment: dsql.FmtColTagComment(doltdb.RowPoliciesNameTag)},
+		{Name: doltdb.RowPoliciesUserCol, Type: sql.Text, Source: doltdb.RowPoliciesTableName, Comment: dsql.FmtColTagComment(doltdb.RowPoliciesUserTag)},
+		{Name: doltdb.RowPoliciesPredicateCol, Type: sql.LongText, Source: doltdb.RowPoliciesTableName, Comment: dsql.FmtColTagComment(doltdb.RowPoliciesPredicateTag)},
+	}
+}
+
+// RowPolicy limits the rows of a table which a user can read and write to those satisfying a predicate. Row policies
+// are persisted in the dolt_row_policies table, so they're versioned, diffed and merged along with the rest of the
+// database.
+//
+// Once a table has a row policy, each session limited by row policies can only access the rows of the table which
+// satisfy the predicate of at least one of the table's policies for its user, or for every user. A table with policies,
+// none of which are for the session's user, permits no rows. The rows of the table's dolt_history_ and dolt_diff_
+// tables are limited by the same predicates, so that the rows which can't be read aren't revealed by past versions of
+// them. Sessions which bypass row policies, such as those of the dolt CLI and the primary user of sql-server, can
+// access every row and are the only sessions which can create and drop policies.
+type RowPolicy struct {
+	Table     string
+	Name      string
+	User      string
+	Predicate string
+
+	// the parsed predicate, or nil if it doesn't parse, in which case the policy permits no rows
+	expr sql.Expression
+}
+
+// IsRowPolicyStatement returns whether the query given is a CREATE POLICY, DROP POLICY or SHOW POLICIES statement.
+// The SQL parser doesn't support these statements, so integrators must check for them before parsing a query and run
+// them with ExecuteRowPolicyStatement.
+func IsRowPolicyStatement(query string) bool {
+	return rowPolicyStatementRegex.MatchString(query)
+}
+
+// ExecuteRowPolicyStatement executes a row policy statement, as identified by IsRowPolicyStatement, against the
+// database given.
+func ExecuteRowPolicyStatement(ctx *sql.Context, db Database, query string) (sql.Schema, sql.RowIter, error) {
+	switch {
+	case createPolicyRegex.MatchString(query):
+		return db.createRowPolicy(ctx, query)
+	case dropPolicyRegex.MatchString(query):
+		m := dropPolicyRegex.FindStringSubmatch(query)
+		return db.dropRowPolicy(ctx, unquoteTriggerIdent(m[2]), unquoteTriggerIdent(m[3]), m[1] != "")
+	case showPoliciesRegex.MatchString(query):
+		m := showPoliciesRegex.FindStringSubmatch(query)
+		return db.showRowPolicies(ctx, unquoteTriggerIdent(m[3]))
+	default:
+		return nil, nil, fmt.Errorf("Unsupported row policy statement: '%v'.", query)
+	}
+}
+
+// parsePolicyUser returns the user named in the TO clause of a CREATE POLICY statement.
+func parsePolicyUser(s string) string {
+	if s == "" || strings.EqualFold(s, "public") {
+		return doltdb.RowPoliciesAllUsers
+	} else if strings.HasPrefix(s, "'") {
+		return strings.TrimSuffix(strings.TrimPrefix(s, "'"), "'")
+	}
+
+	return unquoteTriggerIdent(s)
+}
+
+// rowPolicyUser returns the user of the session, and whether the session is limited by row policies.
+func rowPolicyUser(ctx *sql.Context) (string, bool) {
+	if sess, ok := ctx.Session.(*DoltSession); ok && sess.BypassRowPolicies {
+		return "", false
+	}
+
+	return ctx.Client().User, true
+}
+
+// checkRowPolicyManagement returns an error if the session is limited by row policies, and so can't manage them.
+func checkRowPolicyManagement(ctx *sql.Context) error {
+	if user, limited := rowPolicyUser(ctx); limited {
+		return ErrRowPolicyAccessDenied.New(user, "manage row policies")
+	}
+
+	return nil
+}
+
+// checkRowPolicyTableAccess returns an error if the session is limited by row policies and the table given, which the
+// session is about to drop or rename, is either dolt_row_policies or a table with row policies.
+func checkRowPolicyTableAccess(ctx *sql.Context, root *doltdb.RootValue, tableName, op string) error {
+	user, limited := rowPolicyUser(ctx)
+	if !limited {
+		return nil
+	}
+
+	if strings.EqualFold(tableName, doltdb.RowPoliciesTableName) {
+		return ErrRowPolicyAccessDenied.New(user, op+" table "+tableName)
+	}
+
+	ok, err := hasRowPolicies(ctx, root, tableName)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return ErrRowPolicyAccessDenied.New(user, op+" table "+tableName)
+	}
+
+	return nil
+}
+
+// hasRowPolicies returns whether the table given has any row policies in the root given.
+func hasRowPolicies(ctx context.Context, root *doltdb.RootValue, tableName string) (bool, error) {
+	policies, err := getRowPolicies(ctx, root)
+	if err != nil {
+		return false, err
+	}
+
+	for _, p := range policies {
+		if strings.EqualFold(p.Table, tableName) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// rowPolicyCache holds the row policies parsed from the most recently read dolt_row_policies table. Every root with the
+// same dolt_row_policies table has the same policies, so the parsed policies are keyed by the hash of that table.
+var rowPolicyCache = struct {
+	mu       *sync.Mutex
+	h        hash.Hash
+	policies []*RowPolicy
+}{mu: &sync.Mutex{}}
+
+// getRowPolicies returns the row policies defined in the root given, ordered by table and name.
+func getRowPolicies(ctx context.Context, root *doltdb.RootValue) ([]*RowPolicy, error) {
+	h, ok, err := root.GetTableHash(ctx, doltdb.RowPoliciesTableName)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	rowPolicyCache.mu.Lock()
+	if rowPolicyCache.h == h {
+		policies := rowPolicyCache.policies
+		rowPolicyCache.mu.Unlock()
+		return policies, nil
+	}
+	rowPolicyCache.mu.Unlock()
+
+	tbl, ok, err := root.GetTable(ctx, doltdb.RowPoliciesTableName)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, doltdb.ErrTableNotFound
+	}
+
+	sch, err := tbl.GetSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rowData, err := tbl.GetRowData(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var policies []*RowPolicy
+	err = rowData.IterAll(ctx, func(key, val types.Value) error {
+		r, err := row.FromNoms(sch, key.(types.Tuple), val.(types.Tuple))
+		if err != nil {
+			return err
+		}
+
+		p := &RowPolicy{
+			Table:     policyColString(r, doltdb.RowPoliciesTableTag),
+			Name:      policyColString(r, doltdb.RowPoliciesNameTag),
+			User:      policyColString(r, doltdb.RowPoliciesUserTag),
+			Predicate: policyColString(r, doltdb.RowPoliciesPredicateTag),
+		}
+
+		// Policies edited into the table directly may not parse, and are left to permit no rows rather than failing.
+		p.expr, _ = parseWhereExpression(p.Predicate)
+		policies = append(policies, p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rowPolicyCache.mu.Lock()
+	rowPolicyCache.h, rowPolicyCache.policies = h, policies
+	rowPolicyCache.mu.Unlock()
+
+	return policies, nil
+}
+
+func policyColString(r row.Row, tag uint64) string {
+	val, ok := r.GetColVal(tag)
+	if !ok || types.IsNull(val) {
+		return ""
+	}
+
+	return string(val.(types.String))
+}
+
+// columnResolver returns the expression which evaluates to the value of the column named in the rows of a table.
+type columnResolver func(name string) (sql.Expression, error)
+
+// schemaColumnResolver returns a columnResolver for the fields of the rows of |sch|. When |colName| isn't nil, it maps
+// the names of the columns of the table to the names of the fields which hold them.
+func schemaColumnResolver(sch sql.Schema, colName func(string) string) columnResolver {
+	return func(name string) (sql.Expression, error) {
+		if colName != nil {
+			name = colName(name)
+		}
+
+		for i, col := range sch {
+			if strings.EqualFold(col.Name, name) {
+				return expression.NewGetField(i, col.Type, col.Name, col.Nullable), nil
+			}
+		}
+
+		return nil, fmt.Errorf("unknown column '%s'", name)
+	}
+}
+
+// resolveRowPolicy resolves the columns referred to by |expr| with |resolve|, and the functions it calls with their
+// definitions.
+func resolveRowPolicy(expr sql.Expression, resolve columnResolver) (sql.Expression, error) {
+	resolved, err := expression.TransformUp(expr, func(e sql.Expression) (sql.Expression, error) {
+		switch e := e.(type) {
+		case *expression.UnresolvedColumn:
+			return resolve(e.Name())
+		case *expression.UnresolvedFunction:
+			if e.IsAggregate {
+				return nil, fmt.Errorf("aggregate function %s cannot be used in a row policy", e.Name())
+			}
+
+			fn, err := rowPolicyFunctions.Function(e.Name())
+			if err != nil {
+				return nil, err
+			}
+
+			return fn.Call(e.Arguments...)
+		default:
+			return e, nil
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !resolved.Resolved() {
+		return nil, fmt.Errorf("%s is not supported in a row policy", expr)
+	}
+
+	return resolved, nil
+}
+
+// rowPolicyFilter returns the filter which the rows of |tableName| accessed by the session must satisfy, combining the
+// predicates of the table's row policies for the session's user. nil is returned if the session can access every row,
+// either because it bypasses row policies or because the table has none.
+func rowPolicyFilter(ctx *sql.Context, root *doltdb.RootValue, tableName string, resolve columnResolver) (sql.Expression, error) {
+	user, limited := rowPolicyUser(ctx)
+	if !limited {
+		return nil, nil
+	}
+
+	policies, err := getRowPolicies(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+
+	var filter sql.Expression
+	hasPolicies := false
+	for _, p := range policies {
+		if !strings.EqualFold(p.Table, tableName) {
+			continue
+		}
+
+		hasPolicies = true
+		if p.User != doltdb.RowPoliciesAllUsers && p.User != user {
+			continue
+		}
+
+		var pred sql.Expression = expression.NewLiteral(false, sql.Boolean)
+		if p.expr != nil {
+			pred, err = resolveRowPolicy(p.expr, resolve)
+			if err != nil {
+				return nil, ErrInvalidRowPolicy.New(p.Name, p.Table, err.Error())
+			}
+		}
+
+		if filter == nil {
+			filter = pred
+		} else {
+			filter = expression.NewOr(filter, pred)
+		}
+	}
+
+	if hasPolicies && filter == nil {
+		return expression.NewLiteral(false, sql.Boolean), nil
+	}
+
+	return filter, nil
+}
+
+// rowPolicyReadFilter returns the filter which the rows of |tableName| read by the session must satisfy, or nil if the
+// session can read every row. On top of the table's own row policies, sessions limited by row policies can only read
+// the policies for their own user from dolt_row_policies, and can't read the statistics of tables with row policies
+// from dolt_statistics, which would reveal the values of rows they can't read.
+func rowPolicyReadFilter(ctx *sql.Context, root *doltdb.RootValue, tableName string, resolve columnResolver) (sql.Expression, error) {
+	filter, err := rowPolicyFilter(ctx, root, tableName, resolve)
+	if err != nil {
+		return nil, err
+	}
+
+	user, limited := rowPolicyUser(ctx)
+	if !limited {
+		return filter, nil
+	}
+
+	var pred sql.Expression
+	switch strings.ToLower(tableName) {
+	case doltdb.RowPoliciesTableName:
+		pred = expression.NewIn(expression.NewUnresolvedColumn(doltdb.RowPoliciesUserCol), expression.NewTuple(
+			expression.NewLiteral(user, sql.LongText), expression.NewLiteral(doltdb.RowPoliciesAllUsers, sql.LongText)))
+	case doltdb.StatisticsTableName:
+		policies, err := getRowPolicies(ctx, root)
+		if err != nil {
+			return nil, err
+		}
+
+		var tables []sql.Expression
+		for i, p := range policies {
+			if i == 0 || p.Table != policies[i-1].Table {
+				tables = append(tables, expression.NewLiteral(p.Table, sql.LongText))
+			}
+		}
+
+		if len(tables) > 0 {
+			pred = expression.NewNotIn(expression.NewUnresolvedColumn(doltdb.StatisticsTableCol), expression.NewTuple(tables...))
+		}
+	}
+
+	if pred == nil {
+		return filter, nil
+	}
+
+	pred, err = resolveRowPolicy(pred, resolve)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter == nil {
+		return pred, nil
+	}
+
+	return expression.NewAnd(filter, pred), nil
+}
+
+// diffRowPolicyFilter returns the filter which the rows of the dolt_diff_ table of |tableName|, whose schema is
+// |sqlSch|, must satisfy to be read by the session, or nil if it can read every row. A diff row can only be read if
+// the session can read both its old and new rows, when it has them.
+func diffRowPolicyFilter(ctx *sql.Context, root *doltdb.RootValue, tableName string, sqlSch sql.Schema) (sql.Expression, error) {
+	toFilter, err := rowPolicyReadFilter(ctx, root, tableName, schemaColumnResolver(sqlSch, toNamer))
+	if err != nil || toFilter == nil {
+		return nil, err
+	}
+
+	fromFilter, err := rowPolicyReadFilter(ctx, root, tableName, schemaColumnResolver(sqlSch, fromNamer))
+	if err != nil {
+		return nil, err
+	}
+
+	diffType := expression.NewGetField(len(sqlSch)-1, sql.Text, diffTypeColName, false)
+	return expression.NewAnd(
+		expression.NewOr(expression.NewEquals(diffType, expression.NewLiteral(diffTypeRemoved, sql.Text)), toFilter),
+		expression.NewOr(expression.NewEquals(diffType, expression.NewLiteral(diffTypeAdded, sql.Text)), fromFilter),
+	), nil
+}
+
+// rowPolicyReadFilter returns the filter which the rows of the table read by the session must satisfy, or nil if the
+// session can read every row.
+func (t *DoltTable) rowPolicyReadFilter(ctx *sql.Context) (sql.Expression, error) {
+	if _, limited := rowPolicyUser(ctx); !limited {
+		return nil, nil
+	}
+
+	root, err := t.db.GetRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return rowPolicyReadFilter(ctx, root, t.name, schemaColumnResolver(t.sqlSchema(), nil))
+}
+
+// rowPolicyIter is a sql.RowIter which skips the rows which don't satisfy a row policy filter.
+type rowPolicyIter struct {
+	ctx    *sql.Context
+	iter   sql.RowIter
+	filter sql.Expression
+}
+
+// withRowPolicyFilter returns a sql.RowIter of the rows of |iter| which satisfy |filter|, which may be nil.
+func withRowPolicyFilter(ctx *sql.Context, iter sql.RowIter, filter sql.Expression) sql.RowIter {
+	if filter == nil {
+		return iter
+	}
+
+	return &rowPolicyIter{ctx: ctx, iter: iter, filter: filter}
+}
+
+// Next returns the next row which satisfies the filter, or io.EOF if there aren't any more.
+func (itr *rowPolicyIter) Next() (sql.Row, error) {
+	for {
+		r, err := itr.iter.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		ok, err := sql.EvaluateCondition(itr.ctx, itr.filter, r)
+		if err != nil {
+			return nil, err
+		} else if ok {
+			return r, nil
+		}
+	}
+}
+
+// Close closes the underlying iterator.
+func (itr *rowPolicyIter) Close() error {
+	return itr.iter.Close()
+}
+
+// getRowPolicy returns the row policy of the table given with the name given, or nil if there isn't one.
+func (db Database) getRowPolicy(ctx *sql.Context, tableName, name string) (*RowPolicy, error) {
+	root, err := db.GetRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := getRowPolicies(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range policies {
+		if strings.EqualFold(p.Table, tableName) && strings.EqualFold(p.Name, name) {
+			return p, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (db Database) createRowPolicy(ctx *sql.Context, query string) (sql.Schema, sql.RowIter, error) {
+	if err := checkRowPolicyManagement(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	m := createPolicyRegex.FindStringSubmatch(query)
+	name, tableName, user, predicate := unquoteTriggerIdent(m[2]), unquoteTriggerIdent(m[3]), parsePolicyUser(m[5]), strings.TrimSpace(m[6])
+
+	tbl, ok, err := db.GetTableInsensitive(ctx, tableName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, sql.ErrTableNotFound.New(tableName)
+	}
+	if doltdb.HasDoltPrefix(tbl.Name()) {
+		return nil, nil, ErrRowPolicySystemTable.New(tbl.Name())
+	}
+
+	expr, err := parseWhereExpression(predicate)
+	if err == nil {
+		_, err = resolveRowPolicy(expr, schemaColumnResolver(tbl.Schema(), nil))
+	}
+	if err != nil {
+		return nil, nil, ErrInvalidRowPolicy.New(name, tbl.Name(), err.Error())
+	}
+
+	existing, err := db.getRowPolicy(ctx, tbl.Name(), name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if existing != nil {
+		if m[1] != "" {
+			return okTriggerResult()
+		}
+		return nil, nil, ErrRowPolicyExists.New(name, tbl.Name())
+	}
+
+	ptbl, err := getOrCreateRowPoliciesTable(ctx, db)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	inserter := ptbl.Inserter(ctx)
+	err = inserter.Insert(ctx, sql.NewRow(tbl.Name(), name, user, predicate))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = inserter.Close(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return okTriggerResult()
+}
+
+func (db Database) dropRowPolicy(ctx *sql.Context, name, tableName string, ifExists bool) (sql.Schema, sql.RowIter, error) {
+	if err := checkRowPolicyManagement(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	p, err := db.getRowPolicy(ctx, tableName, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p == nil {
+		if ifExists {
+			return okTriggerResult()
+		}
+		return nil, nil, ErrRowPolicyNotFound.New(name, tableName)
+	}
+
+	ptbl, _, err := db.GetTableInsensitive(ctx, doltdb.RowPoliciesTableName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deleter := ptbl.(*WritableDoltTable).Deleter(ctx)
+	err = deleter.Delete(ctx, p.sqlRow())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = deleter.Close(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return okTriggerResult()
+}
+
+// showRowPolicies shows the row policies of the table given, or of every table if |tableName| is empty. Sessions
+// limited by row policies are only shown the policies for their own user.
+func (db Database) showRowPolicies(ctx *sql.Context, tableName string) (sql.Schema, sql.RowIter, error) {
+	if tableName != "" {
+		tbl, ok, err := db.GetTableInsensitive(ctx, tableName)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			return nil, nil, sql.ErrTableNotFound.New(tableName)
+		}
+		tableName = tbl.Name()
+	}
+
+	root, err := db.GetRoot(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	policies, err := getRowPolicies(ctx, root)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, limited := rowPolicyUser(ctx)
+
+	var rows []sql.Row
+	for _, p := range policies {
+		if tableName != "" && !strings.EqualFold(p.Table, tableName) {
+			continue
+		}
+		if limited && p.User != doltdb.RowPoliciesAllUsers && p.User != user {
+			continue
+		}
+
+		userStr := p.User
+		if userStr == doltdb.RowPoliciesAllUsers {
+			userStr = "PUBLIC"
+		}
+
+		rows = append(rows, sql.NewRow(p.Table, p.Name, userStr, p.Predicate))
+	}
+
+	return showPoliciesSchema, sql.RowsToRowIter(rows...), nil
+}
+
+func (p *RowPolicy) sqlRow() sql.Row {
+	return sql.NewRow(p.Table, p.Name, p.User, p.Predicate)
+}
+
+// moveRowPolicies moves the row policies of the table |from| to the table |to| after the table is renamed, or removes
+// them after it's dropped, in which case |to| is empty.
+func (db Database) moveRowPolicies(ctx *sql.Context, from, to string) error {
+	root, err := db.GetRoot(ctx)
+	if err != nil {
+		return err
+	}
+
+	policies, err := getRowPolicies(ctx, root)
+	if err != nil {
+		return err
+	}
+
+	var moved []*RowPolicy
+	for _, p := range policies {
+		if strings.EqualFold(p.Table, from) {
+			moved = append(moved, p)
+		}
+	}
+
+	if len(moved) == 0 {
+		return nil
+	}
+
+	ptbl, _, err := db.GetTableInsensitive(ctx, doltdb.RowPoliciesTableName)
+	if err != nil {
+		return err
+	}
+
+	deleter := ptbl.(*WritableDoltTable).Deleter(ctx)
+	for _, p := range moved {
+		if err = deleter.Delete(ctx, p.sqlRow()); err != nil {
+			return err
+		}
+	}
+
+	if err = deleter.Close(ctx); err != nil {
+		return err
+	}
+
+	if to == "" {
+		return nil
+	}
+
+	inserter := ptbl.(*WritableDoltTable).Inserter(ctx)
+	for _, p := range moved {
+		if err = inserter.Insert(ctx, sql.NewRow(to, p.Name, p.User, p.Predicate)); err != nil {
+			return err
+		}
+	}
+
+	return inserter.Close(ctx)
+}
+
+// getOrCreateRowPoliciesTable returns the `dolt_row_policies` table in `db`, creating it if it does not already exist.
+func getOrCreateRowPoliciesTable(ctx *sql.Context, db Database) (*WritableDoltTable, error) {
+	tbl, found, err := db.GetTableInsensitive(ctx, doltdb.RowPoliciesTableName)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		err = db.createTable(ctx, doltdb.RowPoliciesTableName, RowPoliciesTableSchema())
+		if err != nil {
+			return nil, err
+		}
+
+		tbl, found, err = db.GetTableInsensitive(ctx, doltdb.RowPoliciesTableName)
+		if err != nil {
+			return nil, err
+		} else if !found {
+			return nil, sql.ErrTableNotFound.New(doltdb.RowPoliciesTableName)
+		}
+	}
+
+	return tbl.(*WritableDoltTable), nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+)
+
+func TestIsRowPolicyStatement(t *testing.T) {
+	for _, query := range []string{"create policy p on test using (a = 1)", "CREATE POLICY IF NOT EXISTS `p` ON `test` TO 'bob' USING (a > 1);", "drop policy p on test", "show policies", "SHOW POLICIES ON test"} {
+		assert.True(t, IsRowPolicyStatement(query), query)
+	}
+
+	for _, query := range []string{"create table policy (a int)", "select * from dolt_row_policies", "show tables", "drop table policies"} {
+		assert.False(t, IsRowPolicyStatement(query), query)
+	}
+}
+
+func TestRowPolicies(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+
+	ctx := context.Background()
+	root, _ := dEnv.WorkingRoot(ctx)
+
+	db := NewDatabase("dolt", dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
+	engine, sqlCtx, err := NewTestEngine(ctx, db, root)
+	require.NoError(t, err)
+	DSessFromSess(sqlCtx.Session).BypassRowPolicies = true
+
+	mustQuery(t, sqlCtx, engine, "create table test (pk bigint primary key, owner varchar(20), v bigint)")
+	mustQuery(t, sqlCtx, engine, `insert into test values (1, "alice", 10), (2, "bob", 20), (3, "alice", 30), (4, "carol", 40)`)
+	mustQuery(t, sqlCtx, engine, "create table other (pk bigint primary key)")
+	mustQuery(t, sqlCtx, engine, "insert into other values (1), (2)")
+
+	executeRowPolicyStatement(t, sqlCtx, db, "create policy own on test using (owner = user())")
+	executeRowPolicyStatement(t, sqlCtx, db, "create policy audit on TEST to 'carol' using (v >= 30)")
+	executeRowPolicyStatement(t, sqlCtx, db, "create policy if not exists own on test using (false)")
+
+	rows := executeRowPolicyStatement(t, sqlCtx, db, "show policies")
+	assert.Equal(t, []sql.Row{
+		{"test", "audit", "carol", "v >= 30"},
+		{"test", "own", "PUBLIC", "owner = user()"},
+	}, rows)
+
+	// sessions which bypass row policies read every row
+	rows = mustQuery(t, sqlCtx, engine, "select pk from test order by pk")
+	assert.Equal(t, []sql.Row{{int64(1)}, {int64(2)}, {int64(3)}, {int64(4)}}, rows)
+
+	alice := newRowPolicyTestCtx(sqlCtx, "alice")
+	carol := newRowPolicyTestCtx(sqlCtx, "carol")
+
+	rows = mustQuery(t, alice, engine, "select pk from test order by pk")
+	assert.Equal(t, []sql.Row{{int64(1)}, {int64(3)}}, rows)
+	rows = mustQuery(t, carol, engine, "select pk from test order by pk")
+	assert.Equal(t, []sql.Row{{int64(3)}, {int64(4)}}, rows)
+	rows = mustQuery(t, alice, engine, "select pk from test where pk = 2")
+	assert.Empty(t, rows)
+	rows = mustQuery(t, alice, engine, "select count(*) from test where owner = 'bob'")
+	assert.Equal(t, []sql.Row{{int64(0)}}, rows)
+	rows = mustQuery(t, alice, engine, "select count(*) from other")
+	assert.Equal(t, []sql.Row{{int64(2)}}, rows)
+
+	// limited sessions only see the policies which apply to them, and the statistics of tables without policies
+	rows = mustQuery(t, alice, engine, "select policy_name from dolt_row_policies")
+	assert.Equal(t, []sql.Row{{"own"}}, rows)
+	rows = executeRowPolicyStatement(t, alice, db, "show policies on test")
+	assert.Equal(t, []sql.Row{{"test", "own", "PUBLIC", "owner = user()"}}, rows)
+	executeStatisticsStatement(t, sqlCtx, db, "analyze table test, other")
+	rows = mustQuery(t, alice, engine, "select distinct table_name from dolt_statistics")
+	assert.Equal(t, []sql.Row{{"other"}}, rows)
+	rows = executeStatisticsStatement(t, alice, db, "show stats")
+	require.Len(t, rows, 1)
+	assert.Equal(t, "other", rows[0][0])
+
+	// writes must leave the rows permitted by the policies
+	_, _, err = engine.Query(alice, `insert into test values (5, "bob", 50)`)
+	assert.True(t, ErrRowPolicyViolation.Is(err), "unexpected error %v", err)
+	mustQuery(t, alice, engine, `insert into test values (5, "alice", 50)`)
+	_, _, err = engine.Query(alice, `update test set owner = "bob" where pk = 1`)
+	assert.True(t, ErrRowPolicyViolation.Is(err), "unexpected error %v", err)
+	_, _, err = engine.Query(alice, `replace into test values (2, "alice", 20)`)
+	assert.True(t, ErrRowPolicyViolation.Is(err), "unexpected error %v", err)
+	mustQuery(t, alice, engine, "update test set v = 0")
+	mustQuery(t, alice, engine, "delete from test where pk in (2, 5)")
+	rows = mustQuery(t, sqlCtx, engine, "select pk, v from test order by pk")
+	assert.Equal(t, []sql.Row{{int64(1), int64(0)}, {int64(2), int64(20)}, {int64(3), int64(0)}, {int64(4), int64(40)}}, rows)
+
+	// only sessions which bypass row policies can manage them
+	_, _, err = ExecuteRowPolicyStatement(alice, db, "create policy mine on other using (pk = 1)")
+	assert.True(t, ErrRowPolicyAccessDenied.Is(err), "unexpected error %v", err)
+	_, _, err = ExecuteRowPolicyStatement(alice, db, "drop policy own on test")
+	assert.True(t, ErrRowPolicyAccessDenied.Is(err), "unexpected error %v", err)
+	_, _, err = engine.Query(alice, `insert into dolt_row_policies values ("other", "mine", "alice", "true")`)
+	assert.True(t, ErrRowPolicyAccessDenied.Is(err), "unexpected error %v", err)
+	_, _, err = engine.Query(alice, "drop table test")
+	assert.True(t, ErrRowPolicyAccessDenied.Is(err), "unexpected error %v", err)
+	_, _, err = engine.Query(alice, "rename table test to mine")
+	assert.True(t, ErrRowPolicyAccessDenied.Is(err), "unexpected error %v", err)
+
+	_, _, err = ExecuteRowPolicyStatement(sqlCtx, db, "create policy own on test using (true)")
+	assert.True(t, ErrRowPolicyExists.Is(err), "unexpected error %v", err)
+	_, _, err = ExecuteRowPolicyStatement(sqlCtx, db, "create policy p on missing using (true)")
+	assert.True(t, sql.ErrTableNotFound.Is(err), "unexpected error %v", err)
+	_, _, err = ExecuteRowPolicyStatement(sqlCtx, db, "create policy p on test using (missing = 1)")
+	assert.True(t, ErrInvalidRowPolicy.Is(err), "unexpected error %v", err)
+	_, _, err = ExecuteRowPolicyStatement(sqlCtx, db, "create policy p on test using (count(*) > 1)")
+	assert.True(t, ErrInvalidRowPolicy.Is(err), "unexpected error %v", err)
+	_, _, err = ExecuteRowPolicyStatement(sqlCtx, db, "create policy p on dolt_row_policies using (true)")
+	assert.True(t, ErrRowPolicySystemTable.Is(err), "unexpected error %v", err)
+	_, _, err = ExecuteRowPolicyStatement(sqlCtx, db, "drop policy p on test")
+	assert.True(t, ErrRowPolicyNotFound.Is(err), "unexpected error %v", err)
+	executeRowPolicyStatement(t, sqlCtx, db, "drop policy if exists p on test")
+
+	// policies follow their table when it's renamed, and are removed when it's dropped
+	mustQuery(t, sqlCtx, engine, "rename table test to renamed")
+	rows = executeRowPolicyStatement(t, sqlCtx, db, "show policies")
+	assert.Equal(t, []sql.Row{
+		{"renamed", "audit", "carol", "v >= 30"},
+		{"renamed", "own", "PUBLIC", "owner = user()"},
+	}, rows)
+	rows = mustQuery(t, carol, engine, "select pk from renamed order by pk")
+	assert.Equal(t, []sql.Row{{int64(4)}}, rows)
+
+	executeRowPolicyStatement(t, sqlCtx, db, "drop policy own on renamed")
+	rows = mustQuery(t, alice, engine, "select count(*) from renamed")
+	assert.Equal(t, []sql.Row{{int64(0)}}, rows)
+
+	mustQuery(t, sqlCtx, engine, "drop table renamed")
+	rows = executeRowPolicyStatement(t, sqlCtx, db, "show policies")
+	assert.Empty(t, rows)
+}
+
+// newRowPolicyTestCtx returns a context for a session of |user| which is limited by row policies, and which shares the
+// state of the session of |ctx|.
+func newRowPolicyTestCtx(ctx *sql.Context, user string) *sql.Context {
+	dsess := *DSessFromSess(ctx.Session)
+	dsess.Session = rowPolicyTestSession{dsess.Session, user}
+	dsess.BypassRowPolicies = false
+
+	return sql.NewContext(
+		ctx,
+		sql.WithSession(&dsess),
+		sql.WithIndexRegistry(ctx.IndexRegistry),
+		sql.WithViewRegistry(ctx.ViewRegistry),
+	).WithCurrentDB(ctx.GetCurrentDatabase())
+}
+
+// rowPolicyTestSession is a sql.Session whose client is a different user than that of the session it wraps.
+type rowPolicyTestSession struct {
+	sql.Session
+	user string
+}
+
+func (s rowPolicyTestSession) Client() sql.Client {
+	return sql.Client{User: s.user, Address: "localhost"}
+}
+
+func executeRowPolicyStatement(t *testing.T, ctx *sql.Context, db Database, query string) []sql.Row {
+	_, iter, err := ExecuteRowPolicyStatement(ctx, db, query)
+	require.NoError(t, err, query)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(t, err, query)
+	return rows
+}
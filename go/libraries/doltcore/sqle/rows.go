@@ -20,6 +20,7 @@ import (
 
 	"github.com/src-d/go-mysql-server/sql"
 
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
 	"github.com/liquidata-inc/dolt/go/store/types"
@@ -36,13 +37,27 @@ type doltTableRowIter struct {
 
 // Returns a new row iterator for the table given
 func newRowIterator(tbl *DoltTable, ctx *sql.Context) (*doltTableRowIter, error) {
-	rowData, err := tbl.table.GetRowData(ctx)
+	ctx = withQueryStats(ctx)
+
+	rowData, err := tbl.table.GetHotRowData(ctx)
 
 	if err != nil {
 		return nil, err
 	}
 
-	mapIter, err := rowData.BufferedIterator(ctx)
+	coldData, hasCold, err := tbl.table.GetColdRowData(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// when the query doesn't need the cold columns their values are left null, and no chunk of the cold map is read
+	var mapIter types.MapIterator
+	if hasCold && tbl.projectsColdCols() {
+		mapIter, err = doltdb.NewJoinedRowIterator(ctx, rowData, coldData)
+	} else {
+		mapIter, err = rowData.BufferedIterator(ctx)
+	}
 
 	if err != nil {
 		return nil, err
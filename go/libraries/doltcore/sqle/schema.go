@@ -17,8 +17,6 @@ package sqle
 import (
 	"context"
 	"fmt"
-	"strconv"
-	"strings"
 
 	"github.com/src-d/go-mysql-server/sql"
 
@@ -145,7 +143,7 @@ func doltColToSqlCol(tableName string, col schema.Column) (*sql.Column, error) {
 		Nullable:   col.IsNullable(),
 		Source:     tableName,
 		PrimaryKey: col.IsPartOfPK,
-		Comment:    fmt.Sprintf("tag:%d", col.Tag),
+		Comment:    dsql.FmtColComment(col),
 	}, nil
 }
 
@@ -160,24 +158,17 @@ func SqlColToDoltCol(tag uint64, col *sql.Column) (schema.Column, error) {
 		return schema.Column{}, err
 	}
 
-	return schema.NewColumnWithTypeInfo(col.Name, tag, typeInfo, col.PrimaryKey, constraints...)
+	doltCol, err := schema.NewColumnWithTypeInfo(col.Name, tag, typeInfo, col.PrimaryKey, constraints...)
+	if err != nil {
+		return schema.Column{}, err
+	}
+
+	doltCol.Comment, _ = dsql.ParseColComment(col.Comment)
+	return doltCol, nil
 }
 
 // Extracts the optional comment tag from a column type defn, or InvalidTag if it can't be extracted
 func extractTag(col *sql.Column) uint64 {
-	if len(col.Comment) == 0 {
-		return schema.InvalidTag
-	}
-
-	i := strings.Index(col.Comment, dsql.TagCommentPrefix)
-	if i >= 0 {
-		startIdx := i + len(dsql.TagCommentPrefix)
-		tag, err := strconv.ParseUint(col.Comment[startIdx:], 10, 64)
-		if err != nil {
-			return schema.InvalidTag
-		}
-		return tag
-	}
-
-	return schema.InvalidTag
+	_, tag := dsql.ParseColComment(col.Comment)
+	return tag
 }
@@ -24,7 +24,7 @@ import (
 // The fixed schema for the `dolt_schemas` table.
 func SchemasTableSchema() sql.Schema {
 	return []*sql.Column{
-		// Currently: `view`.
+		// Currently: `view` or `trigger`.
 		{Name: doltdb.SchemasTablesTypeCol, Type: sql.Text, Source: doltdb.SchemasTableName, PrimaryKey: true, Comment: dsql.FmtColTagComment(doltdb.DoltSchemasTypeTag)},
 		// The name of the database entity.
 		{Name: doltdb.SchemasTablesNameCol, Type: sql.Text, Source: doltdb.SchemasTableName, PrimaryKey: true, Comment: dsql.FmtColTagComment(doltdb.DoltSchemasNameTag)},
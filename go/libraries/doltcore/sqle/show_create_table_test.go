@@ -0,0 +1,181 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"testing"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	dsql "github.com/liquidata-inc/dolt/go/libraries/doltcore/sql"
+)
+
+func TestShowCreateTableRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		create string
+	}{
+		{
+			name: "integers",
+			create: "create table test (" +
+				"a bigint primary key, b tinyint, c smallint unsigned not null, d mediumint, e int unsigned, " +
+				"f bigint unsigned not null, g int)",
+		},
+		{
+			name: "decimals",
+			create: "create table test (" +
+				"pk int primary key, a float, b double not null, c decimal(10,3), d decimal(65,30) not null, e bit(7), " +
+				"f bit(64), g boolean)",
+		},
+		{
+			name:   "dates",
+			create: "create table test (pk int primary key, a date, b datetime not null, c timestamp, d time, e year)",
+		},
+		{
+			name: "strings",
+			create: "create table test (" +
+				"pk varchar(20) collate utf8mb4_bin primary key, a char(10), b varchar(255) character set latin1, " +
+				"c text, d tinytext, e mediumtext collate utf8mb4_bin, f longtext not null, g char(1) character set ascii)",
+		},
+		{
+			name: "enums and sets",
+			create: "create table test (" +
+				"pk int primary key, a enum('a','it''s','back\\\\slash') not null, b set('x','y','z'), " +
+				"c enum('1','2') character set latin1, d set('p','q') collate utf8mb4_bin)",
+		},
+		{
+			name: "keys and comments",
+			create: "create table `Quoted_Table` (" +
+				"a int not null comment 'first', `b c` varchar(10) not null comment 'it''s \\\\ the key', d int, " +
+				"primary key (d, `b c`, a)) comment = 'it''s a \\\\ table'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dEnv := dtestutils.CreateTestEnv()
+			e, ctx, db := newShowCreateTableTestEngine(t, dEnv)
+			executeShowCreateTableQuery(t, ctx, e, db, test.create)
+
+			assertShowCreateTableRoundTrips(t, ctx, e, db, true)
+		})
+	}
+
+	t.Run("dolt types", func(t *testing.T) {
+		dEnv := dtestutils.CreateTestEnv()
+		dtestutils.CreateTestTable(t, dEnv, "people", dtestutils.TypedSchema)
+		e, ctx, db := newShowCreateTableTestEngine(t, dEnv)
+
+		// uuid columns are created as CHAR(36), so only their SQL types match
+		assertShowCreateTableRoundTrips(t, ctx, e, db, false)
+	})
+}
+
+// assertShowCreateTableRoundTrips runs SHOW CREATE TABLE for each table of the database given, executes the statements
+// shown against a fresh database, and asserts that the tables created have the same schemas, and are shown by SHOW
+// CREATE TABLE the same way. If |sameTypes| is true, the columns' dolt types must be the same, rather than only their
+// SQL types.
+func assertShowCreateTableRoundTrips(t *testing.T, ctx *sql.Context, e *sqle.Engine, db Database, sameTypes bool) {
+	root, err := db.GetRoot(ctx)
+	require.NoError(t, err)
+	tableNames, err := root.GetTableNames(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, tableNames)
+
+	freshEnv := dtestutils.CreateTestEnv()
+	freshE, freshCtx, freshDB := newShowCreateTableTestEngine(t, freshEnv)
+
+	for _, tableName := range tableNames {
+		stmt := showCreateTableStmt(t, ctx, e, db, tableName)
+		executeShowCreateTableQuery(t, freshCtx, freshE, freshDB, stmt)
+		assert.Equal(t, stmt, showCreateTableStmt(t, freshCtx, freshE, freshDB, tableName))
+
+		sch := tableSchema(t, ctx, db, tableName)
+		freshSch := tableSchema(t, freshCtx, freshDB, tableName)
+
+		assert.Equal(t, sch.GetComment(), freshSch.GetComment())
+		assert.Equal(t, sch.GetPKCols().Tags, freshSch.GetPKCols().Tags)
+		assert.Equal(t, sch.GetAllCols().Tags, freshSch.GetAllCols().Tags)
+
+		_ = sch.GetAllCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+			freshCol, ok := freshSch.GetAllCols().GetByTag(tag)
+			require.True(t, ok, "column %s is missing", col.Name)
+
+			assert.Equal(t, col.Name, freshCol.Name)
+			assert.Equal(t, col.IsPartOfPK, freshCol.IsPartOfPK)
+			assert.Equal(t, col.IsNullable(), freshCol.IsNullable())
+			assert.Equal(t, col.Comment, freshCol.Comment)
+			assert.Equal(t, dsql.SqlTypeString(col.TypeInfo.ToSqlType()), dsql.SqlTypeString(freshCol.TypeInfo.ToSqlType()))
+
+			if sameTypes {
+				assert.True(t, col.TypeInfo.Equals(freshCol.TypeInfo), "column %s has the type %s, not %s", col.Name, freshCol.TypeInfo, col.TypeInfo)
+			}
+
+			return false, nil
+		})
+	}
+}
+
+func newShowCreateTableTestEngine(t *testing.T, dEnv *env.DoltEnv) (*sqle.Engine, *sql.Context, Database) {
+	ctx := context.Background()
+	root, err := dEnv.WorkingRoot(ctx)
+	require.NoError(t, err)
+
+	db := NewDatabase("dolt", dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
+	e, sqlCtx, err := NewTestEngine(ctx, db, root)
+	require.NoError(t, err)
+
+	return e, sqlCtx, db
+}
+
+func executeShowCreateTableQuery(t *testing.T, ctx *sql.Context, e *sqle.Engine, db Database, query string) []sql.Row {
+	if IsTableCommentStatement(query) {
+		return executeTableCommentStatement(t, ctx, e, db, query)
+	}
+
+	_, iter, err := e.Query(ctx, query)
+	require.NoError(t, err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(t, err)
+	return rows
+}
+
+func showCreateTableStmt(t *testing.T, ctx *sql.Context, e *sqle.Engine, db Database, tableName string) string {
+	rows := executeShowCreateTableQuery(t, ctx, e, db, "show create table "+dsql.QuoteIdentifier(tableName))
+	require.Len(t, rows, 1)
+	require.Len(t, rows[0], 2)
+
+	stmt, ok := rows[0][1].(string)
+	require.True(t, ok)
+	return stmt
+}
+
+func tableSchema(t *testing.T, ctx *sql.Context, db Database, tableName string) schema.Schema {
+	root, err := db.GetRoot(ctx)
+	require.NoError(t, err)
+	tbl, ok, err := root.GetTable(ctx, tableName)
+	require.NoError(t, err)
+	require.True(t, ok, "table %s is missing", tableName)
+	sch, err := tbl.GetSchema(ctx)
+	require.NoError(t, err)
+	return sch
+}
@@ -0,0 +1,805 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqlarrow writes the results of SQL queries as Apache Arrow IPC streams of record batches.
+//
+// The columns of a result have these Arrow types:
+//
+//	tinyint, smallint, int, bigint       int8, int16, int32, int64, or the unsigned types of the same widths
+//	mediumint                            int32, or uint32 if it's unsigned
+//	float, double                        float32, float64
+//	decimal                              decimal128 of the same precision and scale, or utf8 if the precision is over 38
+//	date                                 date32
+//	datetime, timestamp                  timestamp[us, tz=UTC]
+//	year                                 int16
+//	bit                                  uint64
+//	char, varchar, text, enum, set       utf8
+//	time, json                           utf8
+//	binary, varbinary, blob              binary
+//	null                                 null
+//
+// Columns of any other type are written as utf8 of their formatted values.
+package sqlarrow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/decimal128"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/apache/arrow/go/arrow/memory"
+	"github.com/shopspring/decimal"
+	"github.com/src-d/go-mysql-server/sql"
+	"vitess.io/vitess/go/sqltypes"
+)
+
+// ContentType is the media type of an Arrow IPC stream.
+const ContentType = "application/vnd.apache.arrow.stream"
+
+// DefaultBatchSize is the number of rows in each record batch written by a Writer, unless it's given another.
+const DefaultBatchSize = 8192
+
+// maxDecimalPrecision is the precision of the largest decimal which fits in a decimal128.
+const maxDecimalPrecision = 38
+
+// columnFunc returns the values of column |i| of |rows| as an Arrow array, converting them all at once.
+type columnFunc func(mem memory.Allocator, rows []sql.Row, i int) (array.Interface, error)
+
+// Writer writes sql.Rows to an io.Writer as an Arrow IPC stream. Rows are buffered until there's a batch of them,
+// which is then converted to Arrow arrays a column at a time and written as one record batch.
+type Writer struct {
+	wr        *ipc.Writer
+	mem       memory.Allocator
+	sch       *arrow.Schema
+	cols      []columnFunc
+	batch     []sql.Row
+	batchSize int
+	closed    bool
+}
+
+// NewWriter returns a Writer of rows of the schema |sch| to |w|, in record batches of up to |batchSize| rows. A
+// |batchSize| of 0 or less writes batches of DefaultBatchSize rows.
+func NewWriter(w io.Writer, sch sql.Schema, batchSize int) *Writer {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	arrowSch, cols := convertSchema(sch)
+	mem := memory.NewGoAllocator()
+	return &Writer{
+		wr:        ipc.NewWriter(w, ipc.WithSchema(arrowSch), ipc.WithAllocator(mem)),
+		mem:       mem,
+		sch:       arrowSch,
+		cols:      cols,
+		batch:     make([]sql.Row, 0, batchSize),
+		batchSize: batchSize,
+	}
+}
+
+// Schema returns the Arrow schema of the results of the schema |sch|.
+func Schema(sch sql.Schema) *arrow.Schema {
+	arrowSch, _ := convertSchema(sch)
+	return arrowSch
+}
+
+// WriteRow buffers |r|, and writes the batch it completes, if it does.
+func (w *Writer) WriteRow(r sql.Row) error {
+	w.batch = append(w.batch, r)
+
+	if len(w.batch) < w.batchSize {
+		return nil
+	}
+
+	return w.flush()
+}
+
+// flush writes the rows which are buffered as a record batch.
+func (w *Writer) flush() error {
+	if len(w.batch) == 0 {
+		return nil
+	}
+
+	arrs := make([]array.Interface, 0, len(w.cols))
+	defer func() {
+		for _, arr := range arrs {
+			arr.Release()
+		}
+	}()
+
+	for i, col := range w.cols {
+		arr, err := col(w.mem, w.batch, i)
+
+		if err != nil {
+			return err
+		}
+
+		arrs = append(arrs, arr)
+	}
+
+	rec := array.NewRecord(w.sch, arrs, int64(len(w.batch)))
+	defer rec.Release()
+
+	for i := range w.batch {
+		w.batch[i] = nil
+	}
+	w.batch = w.batch[:0]
+
+	return w.wr.Write(rec)
+}
+
+// Close writes the rows which are buffered, and the end of the stream. A stream with no rows has only its schema. The
+// io.Writer is left for the caller to close.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+
+	w.closed = true
+	err := w.flush()
+
+	if closeErr := w.wr.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// WriteRows writes all of the rows of |iter|, which are of the schema |sch|, to |w| as an Arrow IPC stream of record
+// batches of DefaultBatchSize rows. It returns the number of rows written. |iter| is left for the caller to close.
+func WriteRows(ctx context.Context, w io.Writer, sch sql.Schema, iter sql.RowIter) (int64, error) {
+	aw := NewWriter(w, sch, DefaultBatchSize)
+
+	var err error
+	var n int64
+	for {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+
+		var r sql.Row
+		r, err = iter.Next()
+
+		if err != nil {
+			break
+		}
+
+		if err = aw.WriteRow(r); err != nil {
+			break
+		}
+
+		n++
+	}
+
+	if err != io.EOF {
+		_ = aw.Close()
+		return n, err
+	}
+
+	return n, aw.Close()
+}
+
+// convertSchema returns the Arrow schema of |sch|, and the columnFuncs of its columns.
+func convertSchema(sch sql.Schema) (*arrow.Schema, []columnFunc) {
+	fields := make([]arrow.Field, len(sch))
+	cols := make([]columnFunc, len(sch))
+	for i, col := range sch {
+		fields[i].Name = col.Name
+		fields[i].Nullable = col.Nullable
+		fields[i].Type, cols[i] = convertType(col.Type)
+	}
+
+	return arrow.NewSchema(fields, nil), cols
+}
+
+// convertType returns the Arrow type of the values of the SQL type |t|, and the columnFunc of a column of it.
+func convertType(t sql.Type) (arrow.DataType, columnFunc) {
+	switch t.Type() {
+	case sqltypes.Int8:
+		return arrow.PrimitiveTypes.Int8, int8Column(t)
+	case sqltypes.Int16, sqltypes.Year:
+		return arrow.PrimitiveTypes.Int16, int16Column(t)
+	case sqltypes.Int24, sqltypes.Int32:
+		return arrow.PrimitiveTypes.Int32, int32Column(t)
+	case sqltypes.Int64:
+		return arrow.PrimitiveTypes.Int64, int64Column(t)
+	case sqltypes.Uint8:
+		return arrow.PrimitiveTypes.Uint8, uint8Column(t)
+	case sqltypes.Uint16:
+		return arrow.PrimitiveTypes.Uint16, uint16Column(t)
+	case sqltypes.Uint24, sqltypes.Uint32:
+		return arrow.PrimitiveTypes.Uint32, uint32Column(t)
+	case sqltypes.Uint64, sqltypes.Bit:
+		return arrow.PrimitiveTypes.Uint64, uint64Column(t)
+	case sqltypes.Float32:
+		return arrow.PrimitiveTypes.Float32, float32Column(t)
+	case sqltypes.Float64:
+		return arrow.PrimitiveTypes.Float64, float64Column(t)
+	case sqltypes.Decimal:
+		if dt, ok := t.(sql.DecimalType); ok && dt.Precision() <= maxDecimalPrecision {
+			arrowType := &arrow.Decimal128Type{Precision: int32(dt.Precision()), Scale: int32(dt.Scale())}
+			return arrowType, decimalColumn(dt, arrowType)
+		}
+	case sqltypes.Date:
+		if dt, ok := t.(sql.DatetimeType); ok {
+			return arrow.FixedWidthTypes.Date32, dateColumn(dt)
+		}
+	case sqltypes.Datetime, sqltypes.Timestamp:
+		if dt, ok := t.(sql.DatetimeType); ok {
+			return timestampType, timestampColumn(dt)
+		}
+	case sqltypes.Binary, sqltypes.VarBinary, sqltypes.Blob:
+		return arrow.BinaryTypes.Binary, binaryColumn(t)
+	case sqltypes.Null:
+		return arrow.Null, nullColumn
+	}
+
+	return arrow.BinaryTypes.String, stringColumn(t)
+}
+
+// errUnexpectedValue is returned for a value which can't be converted to the Arrow type of its column.
+var errUnexpectedValue = errors.New("unexpected value")
+
+func unexpectedValue(t sql.Type, v interface{}) error {
+	return fmt.Errorf("%w %v of type %T in a column of type %s", errUnexpectedValue, v, v, t.String())
+}
+
+// toInt64 returns |v| as an int64, converting it to |t| first if it isn't an integer.
+func toInt64(t sql.Type, v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case int8:
+		return int64(n), nil
+	case int16:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case int:
+		return int64(n), nil
+	case uint8:
+		return int64(n), nil
+	case uint16:
+		return int64(n), nil
+	case uint32:
+		return int64(n), nil
+	case uint64:
+		return int64(n), nil
+	case uint:
+		return int64(n), nil
+	case bool:
+		if n {
+			return 1, nil
+		}
+		return 0, nil
+	}
+
+	converted, err := t.Convert(v)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if _, ok := converted.(string); ok || converted == nil {
+		return 0, unexpectedValue(t, v)
+	}
+
+	return toInt64(t, converted)
+}
+
+// toUint64 returns |v| as a uint64, converting it to |t| first if it isn't an integer.
+func toUint64(t sql.Type, v interface{}) (uint64, error) {
+	if n, ok := v.(uint64); ok {
+		return n, nil
+	}
+
+	n, err := toInt64(t, v)
+	return uint64(n), err
+}
+
+// toFloat64 returns |v| as a float64, converting it to |t| first if it isn't a number.
+func toFloat64(t sql.Type, v interface{}) (float64, error) {
+	switch f := v.(type) {
+	case float64:
+		return f, nil
+	case float32:
+		return float64(f), nil
+	}
+
+	converted, err := t.Convert(v)
+
+	if err != nil {
+		return 0, err
+	}
+
+	switch f := converted.(type) {
+	case float64:
+		return f, nil
+	case float32:
+		return float64(f), nil
+	}
+
+	return 0, unexpectedValue(t, v)
+}
+
+func int8Column(t sql.Type) columnFunc {
+	return func(mem memory.Allocator, rows []sql.Row, i int) (array.Interface, error) {
+		vals, valid := make([]int8, len(rows)), make([]bool, len(rows))
+		for j, r := range rows {
+			if r[i] == nil {
+				continue
+			}
+
+			n, err := toInt64(t, r[i])
+
+			if err != nil {
+				return nil, err
+			}
+
+			vals[j], valid[j] = int8(n), true
+		}
+
+		b := array.NewInt8Builder(mem)
+		defer b.Release()
+
+		b.AppendValues(vals, valid)
+		return b.NewArray(), nil
+	}
+}
+
+func int16Column(t sql.Type) columnFunc {
+	return func(mem memory.Allocator, rows []sql.Row, i int) (array.Interface, error) {
+		vals, valid := make([]int16, len(rows)), make([]bool, len(rows))
+		for j, r := range rows {
+			if r[i] == nil {
+				continue
+			}
+
+			n, err := toInt64(t, r[i])
+
+			if err != nil {
+				return nil, err
+			}
+
+			vals[j], valid[j] = int16(n), true
+		}
+
+		b := array.NewInt16Builder(mem)
+		defer b.Release()
+
+		b.AppendValues(vals, valid)
+		return b.NewArray(), nil
+	}
+}
+
+func int32Column(t sql.Type) columnFunc {
+	return func(mem memory.Allocator, rows []sql.Row, i int) (array.Interface, error) {
+		vals, valid := make([]int32, len(rows)), make([]bool, len(rows))
+		for j, r := range rows {
+			if r[i] == nil {
+				continue
+			}
+
+			n, err := toInt64(t, r[i])
+
+			if err != nil {
+				return nil, err
+			}
+
+			vals[j], valid[j] = int32(n), true
+		}
+
+		b := array.NewInt32Builder(mem)
+		defer b.Release()
+
+		b.AppendValues(vals, valid)
+		return b.NewArray(), nil
+	}
+}
+
+func int64Column(t sql.Type) columnFunc {
+	return func(mem memory.Allocator, rows []sql.Row, i int) (array.Interface, error) {
+		vals, valid := make([]int64, len(rows)), make([]bool, len(rows))
+		for j, r := range rows {
+			if r[i] == nil {
+				continue
+			}
+
+			n, err := toInt64(t, r[i])
+
+			if err != nil {
+				return nil, err
+			}
+
+			vals[j], valid[j] = n, true
+		}
+
+		b := array.NewInt64Builder(mem)
+		defer b.Release()
+
+		b.AppendValues(vals, valid)
+		return b.NewArray(), nil
+	}
+}
+
+func uint8Column(t sql.Type) columnFunc {
+	return func(mem memory.Allocator, rows []sql.Row, i int) (array.Interface, error) {
+		vals, valid := make([]uint8, len(rows)), make([]bool, len(rows))
+		for j, r := range rows {
+			if r[i] == nil {
+				continue
+			}
+
+			n, err := toUint64(t, r[i])
+
+			if err != nil {
+				return nil, err
+			}
+
+			vals[j], valid[j] = uint8(n), true
+		}
+
+		b := array.NewUint8Builder(mem)
+		defer b.Release()
+
+		b.AppendValues(vals, valid)
+		return b.NewArray(), nil
+	}
+}
+
+func uint16Column(t sql.Type) columnFunc {
+	return func(mem memory.Allocator, rows []sql.Row, i int) (array.Interface, error) {
+		vals, valid := make([]uint16, len(rows)), make([]bool, len(rows))
+		for j, r := range rows {
+			if r[i] == nil {
+				continue
+			}
+
+			n, err := toUint64(t, r[i])
+
+			if err != nil {
+				return nil, err
+			}
+
+			vals[j], valid[j] = uint16(n), true
+		}
+
+		b := array.NewUint16Builder(mem)
+		defer b.Release()
+
+		b.AppendValues(vals, valid)
+		return b.NewArray(), nil
+	}
+}
+
+func uint32Column(t sql.Type) columnFunc {
+	return func(mem memory.Allocator, rows []sql.Row, i int) (array.Interface, error) {
+		vals, valid := make([]uint32, len(rows)), make([]bool, len(rows))
+		for j, r := range rows {
+			if r[i] == nil {
+				continue
+			}
+
+			n, err := toUint64(t, r[i])
+
+			if err != nil {
+				return nil, err
+			}
+
+			vals[j], valid[j] = uint32(n), true
+		}
+
+		b := array.NewUint32Builder(mem)
+		defer b.Release()
+
+		b.AppendValues(vals, valid)
+		return b.NewArray(), nil
+	}
+}
+
+func uint64Column(t sql.Type) columnFunc {
+	return func(mem memory.Allocator, rows []sql.Row, i int) (array.Interface, error) {
+		vals, valid := make([]uint64, len(rows)), make([]bool, len(rows))
+		for j, r := range rows {
+			if r[i] == nil {
+				continue
+			}
+
+			n, err := toUint64(t, r[i])
+
+			if err != nil {
+				return nil, err
+			}
+
+			vals[j], valid[j] = n, true
+		}
+
+		b := array.NewUint64Builder(mem)
+		defer b.Release()
+
+		b.AppendValues(vals, valid)
+		return b.NewArray(), nil
+	}
+}
+
+func float32Column(t sql.Type) columnFunc {
+	return func(mem memory.Allocator, rows []sql.Row, i int) (array.Interface, error) {
+		vals, valid := make([]float32, len(rows)), make([]bool, len(rows))
+		for j, r := range rows {
+			if r[i] == nil {
+				continue
+			}
+
+			f, err := toFloat64(t, r[i])
+
+			if err != nil {
+				return nil, err
+			}
+
+			vals[j], valid[j] = float32(f), true
+		}
+
+		b := array.NewFloat32Builder(mem)
+		defer b.Release()
+
+		b.AppendValues(vals, valid)
+		return b.NewArray(), nil
+	}
+}
+
+func float64Column(t sql.Type) columnFunc {
+	return func(mem memory.Allocator, rows []sql.Row, i int) (array.Interface, error) {
+		vals, valid := make([]float64, len(rows)), make([]bool, len(rows))
+		for j, r := range rows {
+			if r[i] == nil {
+				continue
+			}
+
+			f, err := toFloat64(t, r[i])
+
+			if err != nil {
+				return nil, err
+			}
+
+			vals[j], valid[j] = f, true
+		}
+
+		b := array.NewFloat64Builder(mem)
+		defer b.Release()
+
+		b.AppendValues(vals, valid)
+		return b.NewArray(), nil
+	}
+}
+
+// twoTo128 is added to a negative decimal's unscaled value to get its two's complement.
+var twoTo128 = new(big.Int).Lsh(big.NewInt(1), 128)
+
+var maxUint64 = new(big.Int).SetUint64(^uint64(0))
+
+// maxInt64Precision is the precision of the largest decimal whose unscaled value fits in an int64.
+const maxInt64Precision = 18
+
+// toDecimal128 returns the unscaled value of |d| at |scale| as a decimal128.Num. |precision| is the precision of the
+// column of |d|.
+func toDecimal128(d decimal.Decimal, precision, scale int32) decimal128.Num {
+	unscaled := d.Shift(scale).Round(0)
+
+	if precision <= maxInt64Precision {
+		n := unscaled.IntPart()
+		// the high bits are the sign extension of the low bits
+		return decimal128.New(n>>63, uint64(n))
+	}
+
+	n := unscaled.Coefficient()
+
+	if n.Sign() < 0 {
+		n.Add(n, twoTo128)
+	}
+
+	lo := new(big.Int).And(n, maxUint64).Uint64()
+	hi := new(big.Int).Rsh(n, 64).Uint64()
+
+	return decimal128.New(int64(hi), lo)
+}
+
+// parseUnscaled returns the unscaled value of the decimal string |s|, if it has exactly |scale| digits after its point
+// and fits in an int64.
+func parseUnscaled(s string, scale int) (int64, bool) {
+	point := strings.IndexByte(s, '.')
+
+	if point == -1 {
+		if scale != 0 {
+			return 0, false
+		}
+	} else if len(s)-point-1 != scale {
+		return 0, false
+	} else {
+		s = s[:point] + s[point+1:]
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	return n, err == nil
+}
+
+func decimalColumn(t sql.DecimalType, dtype *arrow.Decimal128Type) columnFunc {
+	return func(mem memory.Allocator, rows []sql.Row, i int) (array.Interface, error) {
+		vals, valid := make([]decimal128.Num, len(rows)), make([]bool, len(rows))
+		for j, r := range rows {
+			if r[i] == nil {
+				continue
+			}
+
+			// the engine's decimals are strings of the scale of their column, which are parsed without a decimal.Decimal
+			if str, ok := r[i].(string); ok && dtype.Precision <= maxInt64Precision {
+				if n, ok := parseUnscaled(str, int(dtype.Scale)); ok {
+					vals[j], valid[j] = decimal128.New(n>>63, uint64(n)), true
+					continue
+				}
+			}
+
+			d, err := t.ConvertToDecimal(r[i])
+
+			if err != nil {
+				return nil, err
+			}
+
+			if d.Valid {
+				vals[j], valid[j] = toDecimal128(d.Decimal, dtype.Precision, dtype.Scale), true
+			}
+		}
+
+		b := array.NewDecimal128Builder(mem, dtype)
+		defer b.Release()
+
+		b.AppendValues(vals, valid)
+		return b.NewArray(), nil
+	}
+}
+
+// secondsPerDay is the number of seconds in the days of a date32.
+const secondsPerDay = 24 * 60 * 60
+
+func dateColumn(t sql.DatetimeType) columnFunc {
+	return func(mem memory.Allocator, rows []sql.Row, i int) (array.Interface, error) {
+		vals, valid := make([]arrow.Date32, len(rows)), make([]bool, len(rows))
+		for j, r := range rows {
+			if r[i] == nil {
+				continue
+			}
+
+			tm, err := toTime(t, r[i])
+
+			if err != nil {
+				return nil, err
+			}
+
+			secs := tm.Unix()
+			days := secs / secondsPerDay
+
+			// the days before the epoch are rounded down, rather than toward it
+			if secs%secondsPerDay < 0 {
+				days--
+			}
+
+			vals[j], valid[j] = arrow.Date32(days), true
+		}
+
+		b := array.NewDate32Builder(mem)
+		defer b.Release()
+
+		b.AppendValues(vals, valid)
+		return b.NewArray(), nil
+	}
+}
+
+// timestampType is the Arrow type of datetimes and timestamps, which are microseconds since the epoch in UTC.
+var timestampType = &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}
+
+func timestampColumn(t sql.DatetimeType) columnFunc {
+	return func(mem memory.Allocator, rows []sql.Row, i int) (array.Interface, error) {
+		vals, valid := make([]arrow.Timestamp, len(rows)), make([]bool, len(rows))
+		for j, r := range rows {
+			if r[i] == nil {
+				continue
+			}
+
+			tm, err := toTime(t, r[i])
+
+			if err != nil {
+				return nil, err
+			}
+
+			vals[j], valid[j] = arrow.Timestamp(tm.Unix()*int64(time.Second/time.Microsecond)+int64(tm.Nanosecond()/1000)), true
+		}
+
+		b := array.NewTimestampBuilder(mem, timestampType)
+		defer b.Release()
+
+		b.AppendValues(vals, valid)
+		return b.NewArray(), nil
+	}
+}
+
+func toTime(t sql.DatetimeType, v interface{}) (time.Time, error) {
+	if tm, ok := v.(time.Time); ok {
+		return tm.UTC(), nil
+	}
+
+	tm, err := t.ConvertWithoutRangeCheck(v)
+	return tm.UTC(), err
+}
+
+func binaryColumn(t sql.Type) columnFunc {
+	return func(mem memory.Allocator, rows []sql.Row, i int) (array.Interface, error) {
+		vals, valid := make([][]byte, len(rows)), make([]bool, len(rows))
+		for j, r := range rows {
+			switch v := r[i].(type) {
+			case nil:
+				continue
+			case []byte:
+				vals[j] = v
+			case string:
+				vals[j] = []byte(v)
+			default:
+				return nil, unexpectedValue(t, v)
+			}
+
+			valid[j] = true
+		}
+
+		b := array.NewBinaryBuilder(mem, arrow.BinaryTypes.Binary)
+		defer b.Release()
+
+		b.AppendValues(vals, valid)
+		return b.NewArray(), nil
+	}
+}
+
+func stringColumn(t sql.Type) columnFunc {
+	return func(mem memory.Allocator, rows []sql.Row, i int) (array.Interface, error) {
+		vals, valid := make([]string, len(rows)), make([]bool, len(rows))
+		for j, r := range rows {
+			switch v := r[i].(type) {
+			case nil:
+				continue
+			case string:
+				vals[j] = v
+			case []byte:
+				vals[j] = string(v)
+			default:
+				vals[j] = fmt.Sprintf("%v", v)
+			}
+
+			valid[j] = true
+		}
+
+		b := array.NewStringBuilder(mem)
+		defer b.Release()
+
+		b.AppendValues(vals, valid)
+		return b.NewArray(), nil
+	}
+}
+
+func nullColumn(mem memory.Allocator, rows []sql.Row, i int) (array.Interface, error) {
+	return array.NewNull(len(rows)), nil
+}
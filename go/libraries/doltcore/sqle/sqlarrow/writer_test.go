@@ -0,0 +1,291 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlarrow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/decimal128"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	dsqle "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/untyped"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/untyped/csv"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/iohelp"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+var testSch = sql.Schema{
+	{Name: "id", Type: sql.Int64},
+	{Name: "small", Type: sql.Int8, Nullable: true},
+	{Name: "big", Type: sql.Uint64, Nullable: true},
+	{Name: "price", Type: sql.MustCreateDecimalType(10, 2), Nullable: true},
+	{Name: "at", Type: sql.Datetime, Nullable: true},
+	{Name: "day", Type: sql.Date, Nullable: true},
+	{Name: "name", Type: sql.Text, Nullable: true},
+	{Name: "data", Type: sql.Blob, Nullable: true},
+	{Name: "ratio", Type: sql.Float64, Nullable: true},
+	{Name: "huge", Type: sql.MustCreateDecimalType(30, 4), Nullable: true},
+}
+
+// readStream reads the schema and all of the records of the Arrow IPC stream |data|.
+func readStream(t *testing.T, data []byte) (*arrow.Schema, []array.Record) {
+	r, err := ipc.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	defer r.Release()
+
+	var recs []array.Record
+	for r.Next() {
+		rec := r.Record()
+		rec.Retain()
+		recs = append(recs, rec)
+	}
+
+	return r.Schema(), recs
+}
+
+func TestSchema(t *testing.T) {
+	sch := Schema(testSch)
+
+	expected := []arrow.DataType{
+		arrow.PrimitiveTypes.Int64,
+		arrow.PrimitiveTypes.Int8,
+		arrow.PrimitiveTypes.Uint64,
+		&arrow.Decimal128Type{Precision: 10, Scale: 2},
+		&arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"},
+		arrow.FixedWidthTypes.Date32,
+		arrow.BinaryTypes.String,
+		arrow.BinaryTypes.Binary,
+		arrow.PrimitiveTypes.Float64,
+		&arrow.Decimal128Type{Precision: 30, Scale: 4},
+	}
+
+	require.Len(t, sch.Fields(), len(expected))
+	for i, f := range sch.Fields() {
+		assert.Equal(t, testSch[i].Name, f.Name)
+		assert.Equal(t, testSch[i].Nullable, f.Nullable)
+		assert.True(t, arrow.TypeEqual(expected[i], f.Type), "%s is %s", f.Name, f.Type)
+	}
+
+	wide := Schema(sql.Schema{{Name: "d", Type: sql.MustCreateDecimalType(65, 10)}})
+	assert.Equal(t, arrow.BinaryTypes.String, wide.Field(0).Type)
+}
+
+func TestWriter(t *testing.T) {
+	at := time.Date(2020, 6, 1, 12, 30, 15, 123456000, time.UTC)
+	day := time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC)
+	rows := []sql.Row{
+		{int64(1), int8(-3), uint64(1 << 63), "10.25", at, day, "bill", []byte{0, 1, 2}, 0.5, "-1.5000"},
+		{int64(2), nil, nil, nil, nil, nil, nil, nil, nil, nil},
+		{int64(3), int8(7), uint64(5), "-12345678.99", at.Add(-time.Hour), at, "", []byte{}, -1.0, 2.25},
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, testSch, 2)
+	for _, r := range rows {
+		require.NoError(t, w.WriteRow(r))
+	}
+	require.NoError(t, w.Close())
+
+	_, recs := readStream(t, buf.Bytes())
+
+	// the rows are written in batches of 2
+	require.Len(t, recs, 2)
+	assert.Equal(t, int64(2), recs[0].NumRows())
+	assert.Equal(t, int64(1), recs[1].NumRows())
+
+	first, second := recs[0], recs[1]
+
+	assert.Equal(t, []int64{1, 2}, first.Column(0).(*array.Int64).Int64Values())
+	assert.Equal(t, int8(-3), first.Column(1).(*array.Int8).Value(0))
+	assert.Equal(t, uint64(1<<63), first.Column(2).(*array.Uint64).Value(0))
+	assert.Equal(t, decimal128.New(0, 1025), first.Column(3).(*array.Decimal128).Value(0))
+	assert.Equal(t, arrow.Timestamp(at.UnixNano()/1000), first.Column(4).(*array.Timestamp).Value(0))
+	assert.Equal(t, arrow.Date32(-1), first.Column(5).(*array.Date32).Value(0))
+	assert.Equal(t, "bill", first.Column(6).(*array.String).Value(0))
+	assert.Equal(t, []byte{0, 1, 2}, first.Column(7).(*array.Binary).Value(0))
+	assert.Equal(t, 0.5, first.Column(8).(*array.Float64).Value(0))
+	assert.Equal(t, decimal128.New(-1, uint64(1<<64-15000)), first.Column(9).(*array.Decimal128).Value(0))
+
+	// every nullable column of the second row is null
+	for i := 1; i < len(testSch); i++ {
+		assert.True(t, first.Column(i).IsNull(1), "column %s", testSch[i].Name)
+		assert.Equal(t, 1, first.Column(i).NullN(), "column %s", testSch[i].Name)
+	}
+
+	// -1234567899 in two's complement
+	assert.Equal(t, decimal128.New(-1, uint64(1<<64-1234567899)), second.Column(3).(*array.Decimal128).Value(0))
+	assert.Equal(t, arrow.Timestamp(at.Add(-time.Hour).UnixNano()/1000), second.Column(4).(*array.Timestamp).Value(0))
+	assert.Equal(t, arrow.Date32(18414), second.Column(5).(*array.Date32).Value(0))
+	assert.False(t, second.Column(6).IsNull(0))
+	assert.Equal(t, "", second.Column(6).(*array.String).Value(0))
+	assert.Equal(t, decimal128.New(0, 22500), second.Column(9).(*array.Decimal128).Value(0))
+}
+
+func TestWriteRows(t *testing.T) {
+	sch := sql.Schema{{Name: "n", Type: sql.Int32}, {Name: "nothing", Type: sql.Null, Nullable: true}}
+
+	var buf bytes.Buffer
+	ctx := context.Background()
+	n, err := WriteRows(ctx, &buf, sch, sql.RowsToRowIter())
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	// a stream without rows has the schema of the result
+	arrowSch, recs := readStream(t, buf.Bytes())
+	assert.Empty(t, recs)
+	assert.Equal(t, "n", arrowSch.Field(0).Name)
+	assert.Equal(t, arrow.Null, arrowSch.Field(1).Type)
+
+	rows := make([]sql.Row, DefaultBatchSize+1)
+	for i := range rows {
+		rows[i] = sql.Row{int32(i), nil}
+	}
+
+	buf.Reset()
+	n, err = WriteRows(ctx, &buf, sch, sql.RowsToRowIter(rows...))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(rows)), n)
+
+	_, recs = readStream(t, buf.Bytes())
+	require.Len(t, recs, 2)
+	assert.Equal(t, int64(DefaultBatchSize), recs[0].NumRows())
+	assert.Equal(t, int32(DefaultBatchSize), recs[1].Column(0).(*array.Int32).Value(0))
+	assert.Equal(t, 1, recs[1].Column(1).NullN())
+}
+
+func TestWriteRowsUnexpectedValue(t *testing.T) {
+	sch := sql.Schema{{Name: "b", Type: sql.Blob}}
+
+	_, err := WriteRows(context.Background(), ioutil.Discard, sch, sql.RowsToRowIter(sql.Row{1.5}))
+	assert.True(t, errors.Is(err, errUnexpectedValue))
+}
+
+// benchmarkRows is the number of rows of the results written by the benchmarks.
+const benchmarkRows = 10 * 1000 * 1000
+
+var benchmarkSch = sql.Schema{
+	{Name: "id", Type: sql.Int64},
+	{Name: "name", Type: sql.Text, Nullable: true},
+	{Name: "price", Type: sql.MustCreateDecimalType(10, 2), Nullable: true},
+	{Name: "at", Type: sql.Datetime, Nullable: true},
+	{Name: "ratio", Type: sql.Float64, Nullable: true},
+}
+
+// benchmarkIter is a sql.RowIter of |n| rows of benchmarkSch, generated as they're read.
+type benchmarkIter struct {
+	i, n int
+	at   time.Time
+}
+
+func (it *benchmarkIter) Next() (sql.Row, error) {
+	if it.i >= it.n {
+		return nil, io.EOF
+	}
+
+	it.i++
+	if it.i%10 == 0 {
+		return sql.Row{int64(it.i), nil, nil, nil, nil}, nil
+	}
+
+	return sql.Row{int64(it.i), fmt.Sprintf("name %d", it.i), fmt.Sprintf("%d.%02d", it.i, it.i%100), it.at.Add(time.Duration(it.i) * time.Second), float64(it.i) / 3}, nil
+}
+
+func (it *benchmarkIter) Close() error {
+	return nil
+}
+
+// BenchmarkWriteRows writes a result of 10 million rows as an Arrow stream, and as CSV, the way dolt sql --result-format
+// csv does.
+func BenchmarkWriteRows(b *testing.B) {
+	at := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	b.Run("arrow", func(b *testing.B) {
+		ctx := context.Background()
+		for i := 0; i < b.N; i++ {
+			_, err := WriteRows(ctx, ioutil.Discard, benchmarkSch, &benchmarkIter{n: benchmarkRows, at: at})
+
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("csv", func(b *testing.B) {
+		ctx := context.Background()
+		doltSch, err := dsqle.SqlSchemaToDoltResultSchema(benchmarkSch)
+
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		untypedSch, err := untyped.UntypeUnkeySchema(doltSch)
+
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		for i := 0; i < b.N; i++ {
+			wr, err := csv.NewCSVWriter(iohelp.NopWrCloser(ioutil.Discard), untypedSch, csv.NewCSVInfo())
+
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			iter := &benchmarkIter{n: benchmarkRows, at: at}
+			for {
+				r, err := iter.Next()
+
+				if err == io.EOF {
+					break
+				}
+
+				taggedVals := make(row.TaggedValues)
+				for j, col := range r {
+					if col != nil {
+						taggedVals[uint64(j)] = types.String(fmt.Sprintf("%v", col))
+					}
+				}
+
+				dRow, err := row.New(types.Format_Default, untypedSch, taggedVals)
+
+				if err == nil {
+					err = wr.WriteRow(ctx, dRow)
+				}
+
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			if err := wr.Close(ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
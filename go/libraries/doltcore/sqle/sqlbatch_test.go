@@ -193,19 +193,18 @@ func TestSqlBatchInsertErrors(t *testing.T) {
 	engine, sqlCtx, err := NewTestEngine(ctx, db, root)
 	require.NoError(t, err)
 
-	_, rowIter, err := engine.Query(sqlCtx, `insert into people (id, first_name, last_name, is_married, age, rating, uuid, num_episodes) values
+	_, _, err = engine.Query(sqlCtx, `insert into people (id, first_name, last_name, is_married, age, rating, uuid, num_episodes) values
 					(0, "Maggie", "Simpson", false, 1, 5.1, '00000000-0000-0000-0000-000000000007', 677)`)
-	// This won't generate an error until we commit the batch (duplicate key)
-	assert.NoError(t, err)
-	assert.NoError(t, drainIter(rowIter))
+	// The duplicate key is found as the row is inserted, rather than when the batch is flushed
+	assert.Error(t, err)
 
 	// This generates an error at insert time because of the bad type for the uuid column
 	_, _, err = engine.Query(sqlCtx, `insert into people values
 					(2, "Milhouse", "VanHouten", false, 1, 5.1, true, 677)`)
 	assert.Error(t, err)
 
-	// Error from the first statement appears here
-	assert.Error(t, db.Flush(sqlCtx))
+	// Neither of the rows was added to the batch
+	assert.NoError(t, db.Flush(sqlCtx))
 }
 
 func assertRowSetsEqual(t *testing.T, expected, actual []row.Row) {
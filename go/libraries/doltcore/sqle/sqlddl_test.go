@@ -225,8 +225,8 @@ func TestCreateTable(t *testing.T) {
 								id int primary key comment 'tag:a', age int comment 'this is my personal area')`,
 			expectedTable: "testTable",
 			expectedSchema: dtestutils.CreateSchema(
-				schemaNewColumn(t, "id", 4817, sql.Int32, true, schema.NotNullConstraint{}),
-				schemaNewColumn(t, "age", 7208, sql.Int32, false)),
+				schemaNewColumnWithComment(t, "id", 4817, sql.Int32, true, "tag:a", schema.NotNullConstraint{}),
+				schemaNewColumnWithComment(t, "age", 7208, sql.Int32, false, "this is my personal area")),
 		},
 		// Real world examples for regression testing
 		{
@@ -24,9 +24,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dolttestutils"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
-	"github.com/liquidata-inc/dolt/go/libraries/doltcore/envtestutils"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
 	. "github.com/liquidata-inc/dolt/go/libraries/doltcore/sql/sqltestutil"
 	"github.com/liquidata-inc/dolt/go/store/types"
@@ -146,6 +146,54 @@ func (tcc *testCommitClock) Now() time.Time {
 	return now
 }
 
+const historyTableName = "test_table"
+
+// historySteps returns the steps which build the history of test_table that the diff and as of tests query. The seed
+// branch creates the table, add-age adds an int age column to it, and master adds an address column and then re-adds age
+// as a uint with a different tag. The last step adds a row to the working set without committing it.
+func historySteps() []dolttestutils.Step {
+	return []dolttestutils.Step{
+		dolttestutils.Branch{Name: "seed"},
+		dolttestutils.Checkout{Branch: "seed"},
+		dolttestutils.PutTable{Name: historyTableName, Schema: InitialHistSch, Rows: dolttestutils.Rows{
+			{types.Int(0), types.String("Aaron"), types.String("Son")},
+			{types.Int(1), types.String("Brian"), types.String("Hendriks")},
+			{types.Int(2), types.String("Tim"), types.String("Sehn")},
+		}},
+		dolttestutils.Commit{Message: "Seeding with initial user data"},
+		dolttestutils.Branch{Name: "add-age"},
+		dolttestutils.Checkout{Branch: "add-age"},
+		dolttestutils.PutTable{Name: historyTableName, Schema: AddAgeAt4HistSch, Rows: dolttestutils.Rows{
+			{types.Int(0), types.String("Aaron"), types.String("Son"), types.Int(35)},
+			{types.Int(1), types.String("Brian"), types.String("Hendriks"), types.Int(38)},
+			{types.Int(2), types.String("Tim"), types.String("Sehn"), types.Int(37)},
+			{types.Int(3), types.String("Zach"), types.String("Musgrave"), types.Int(37)},
+		}},
+		dolttestutils.Commit{Message: "Adding int age to users with tag 3"},
+		dolttestutils.Checkout{Branch: "master"},
+		dolttestutils.PutTable{Name: historyTableName, Schema: AddAddrAt3HistSch, Rows: dolttestutils.Rows{
+			{types.Int(0), types.String("Aaron"), types.String("Son"), types.String("123 Fake St")},
+			{types.Int(1), types.String("Brian"), types.String("Hendriks"), types.String("456 Bull Ln")},
+			{types.Int(2), types.String("Tim"), types.String("Sehn"), types.String("789 Not Real Ct")},
+			{types.Int(3), types.String("Zach"), types.String("Musgrave"), nil},
+			{types.Int(4), types.String("Matt"), types.String("Jesuele"), nil},
+		}},
+		dolttestutils.Commit{Message: "Adding string address to users with tag 3"},
+		dolttestutils.PutTable{Name: historyTableName, Schema: ReaddAgeAt5HistSch, Rows: dolttestutils.Rows{
+			{types.Int(0), types.String("Aaron"), types.String("Son"), types.String("123 Fake St"), types.Uint(35)},
+			{types.Int(1), types.String("Brian"), types.String("Hendriks"), types.String("456 Bull Ln"), types.Uint(38)},
+			{types.Int(2), types.String("Tim"), types.String("Sehn"), types.String("789 Not Real Ct"), types.Uint(37)},
+			{types.Int(3), types.String("Zach"), types.String("Musgrave"), types.String("-1 Imaginary Wy"), types.Uint(37)},
+			{types.Int(4), types.String("Matt"), types.String("Jesuele"), nil, nil},
+			{types.Int(5), types.String("Daylon"), types.String("Wilkins"), nil, nil},
+		}},
+		dolttestutils.Commit{Message: "Re-add age as a uint with tag 4"},
+		dolttestutils.UpsertRows{Table: historyTableName, Rows: dolttestutils.Rows{
+			{types.Int(6), types.String("Katie"), types.String("McCulloch"), nil, nil},
+		}},
+	}
+}
+
 // Tests the given query on a freshly created dataset, asserting that the result has the given schema and rows. If
 // expectedErr is set, asserts instead that the execution returns an error that matches.
 func testSelectQuery(t *testing.T, test SelectTest) {
@@ -203,37 +251,15 @@ func testSelectDiffQuery(t *testing.T, test SelectTest) {
 	}
 
 	ctx := context.Background()
-	tcc := &testCommitClock{}
-	doltdb.CommitNowFunc = tcc.Now
-	doltdb.CommitLoc = time.UTC
+	f, err := dolttestutils.Build(ctx, historySteps()...)
+	require.NoError(t, err)
 
-	dEnv := dtestutils.CreateTestEnv()
-	envtestutils.InitializeWithHistory(t, ctx, dEnv, CreateHistory(ctx, dEnv, t)...)
+	dEnv := f.DEnv
 	if test.AdditionalSetup != nil {
 		test.AdditionalSetup(t, dEnv)
 	}
 
-	cs, err := doltdb.NewCommitSpec("HEAD", "master")
-	require.NoError(t, err)
-
-	cm, err := dEnv.DoltDB.Resolve(ctx, cs)
-	require.NoError(t, err)
-
-	root, err := cm.GetRootValue()
-	require.NoError(t, err)
-
-	_, err = dEnv.UpdateStagedRoot(ctx, root)
-	require.NoError(t, err)
-
-	err = dEnv.UpdateWorkingRoot(ctx, root)
-	require.NoError(t, err)
-
-	root, err = dEnv.WorkingRoot(context.Background())
-	require.NoError(t, err)
-
-	root = envtestutils.UpdateTables(t, ctx, root, CreateWorkingRootUpdate())
-
-	err = dEnv.UpdateWorkingRoot(ctx, root)
+	root, err := dEnv.WorkingRoot(ctx)
 	require.NoError(t, err)
 
 	actualRows, sch, err := executeSelect(ctx, dEnv, test.ExpectedSchema, root, test.Query)
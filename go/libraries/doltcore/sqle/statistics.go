@@ -0,0 +1,529 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"gopkg.in/src-d/go-errors.v1"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	dsql "github.com/liquidata-inc/dolt/go/libraries/doltcore/sql"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/stats"
+	"github.com/liquidata-inc/dolt/go/store/background"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+var ErrAnalyzeSystemTable = errors.NewKind("Statistics cannot be built for system table %s")
+
+var analyzeTableRegex = regexp.MustCompile(`(?is)^\s*analyze\s+((no_write_to_binlog|local)\s+)?tables?\s+(` +
+	triggerIdentRegexStr + `(\s*,\s*` + triggerIdentRegexStr + `)*)[\s;]*$`)
+var showStatsRegex = regexp.MustCompile(`(?is)^\s*show\s+stats(\s+(for|from)\s+` + triggerIdentRegexStr + `)?[\s;]*$`)
+var statsIdentRegex = regexp.MustCompile(triggerIdentRegexStr)
+
+var analyzeTableSchema = sql.Schema{
+	{Name: "Table", Type: sql.LongText},
+	{Name: "Op", Type: sql.LongText},
+	{Name: "Msg_type", Type: sql.LongText},
+	{Name: "Msg_text", Type: sql.LongText},
+}
+
+var showStatsSchema = sql.Schema{
+	{Name: "Table", Type: sql.LongText},
+	{Name: "Column", Type: sql.LongText},
+	{Name: "Rows", Type: sql.Uint64},
+	{Name: "Null_fraction", Type: sql.Float64},
+	{Name: "Distinct", Type: sql.Uint64},
+	{Name: "Min", Type: sql.LongText, Nullable: true},
+	{Name: "Max", Type: sql.LongText, Nullable: true},
+	{Name: "Buckets", Type: sql.Int64},
+	{Name: "Stale", Type: sql.LongText},
+}
+
+// The fixed schema for the `dolt_statistics` table.
+func StatisticsTableSchema() sql.Schema {
+	return []*sql.Column{
+		{Name: doltdb.StatisticsTableCol, Type: sql.Text, Source: doltdb.StatisticsTableName, PrimaryKey: true, Comment: dsql.FmtColTagComment(doltdb.StatisticsTableTag)},
+		{Name: doltdb.StatisticsColumnCol, Type: sql.Text, Source: doltdb.StatisticsTableName, PrimaryKey: true, Comment: dsql.FmtColTagComment(doltdb.StatisticsColumnTag)},
+		{Name: doltdb.StatisticsRowCountCol, Type: sql.Uint64, Source: doltdb.StatisticsTableName, Comment: dsql.FmtColTagComment(doltdb.StatisticsRowCountTag)},
+		{Name: doltdb.StatisticsNullCountCol, Type: sql.Uint64, Source: doltdb.StatisticsTableName, Comment: dsql.FmtColTagComment(doltdb.StatisticsNullCountTag)},
+		{Name: doltdb.StatisticsDistinctCountCol, Type: sql.Uint64, Source: doltdb.StatisticsTableName, Comment: dsql.FmtColTagComment(doltdb.StatisticsDistinctCountTag)},
+		{Name: doltdb.StatisticsMinCol, Type: sql.LongText, Source: doltdb.StatisticsTableName, Nullable: true, Comment: dsql.FmtColTagComment(doltdb.StatisticsMinTag)},
+		{Name: doltdb.StatisticsMaxCol, Type: sql.LongText, Source: doltdb.StatisticsTableName, Nullable: true, Comment: dsql.FmtColTagComment(doltdb.StatisticsMaxTag)},
+		// The buckets of the column's histogram, as a JSON array of objects with upper_bound and row_count fields.
+		{Name: doltdb.StatisticsHistogramCol, Type: sql.LongText, Source: doltdb.StatisticsTableName, Comment: dsql.FmtColTagComment(doltdb.StatisticsHistogramTag)},
+		{Name: doltdb.StatisticsSampleSizeCol, Type: sql.Uint64, Source: doltdb.StatisticsTableName, Comment: dsql.FmtColTagComment(doltdb.StatisticsSampleSizeTag)},
+		{Name: doltdb.StatisticsRowDataCol, Type: sql.Text, Source: doltdb.StatisticsTableName, Comment: dsql.FmtColTagComment(doltdb.StatisticsRowDataTag)},
+	}
+}
+
+// StatisticsTable is a table which can return the statistics built for it by ANALYZE TABLE, for estimating the number
+// of rows matched by the filters of a query plan.
+type StatisticsTable interface {
+	sql.Table
+	// Statistics returns the statistics of the table in the session's root, or false if the table hasn't been
+	// analyzed or its statistics are stale.
+	Statistics(ctx *sql.Context) (*stats.TableStatistics, bool, error)
+}
+
+var _ StatisticsTable = (*DoltTable)(nil)
+
+// Statistics implements StatisticsTable.
+func (t *DoltTable) Statistics(ctx *sql.Context) (*stats.TableStatistics, bool, error) {
+	root, err := t.db.GetRoot(ctx)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	ts, stale, err := t.db.tableStatistics(ctx, root, t.name, t.table)
+
+	if err != nil || ts == nil || stale {
+		return nil, false, err
+	}
+
+	return ts, true, nil
+}
+
+// IsStatisticsStatement returns whether the query given is an ANALYZE TABLE statement, which builds the statistics of
+// tables and persists them in the dolt_statistics table, or a SHOW STATS statement, which shows them. The SQL parser
+// doesn't support these statements, so integrators must check for them before parsing a query and run them with
+// ExecuteStatisticsStatement.
+func IsStatisticsStatement(query string) bool {
+	return analyzeTableRegex.MatchString(query) || showStatsRegex.MatchString(query)
+}
+
+// ExecuteStatisticsStatement executes a statistics statement, as identified by IsStatisticsStatement, against the
+// database given.
+func ExecuteStatisticsStatement(ctx *sql.Context, db Database, query string) (sql.Schema, sql.RowIter, error) {
+	switch {
+	case analyzeTableRegex.MatchString(query):
+		m := analyzeTableRegex.FindStringSubmatch(query)
+		var tableNames []string
+		for _, ident := range statsIdentRegex.FindAllString(m[3], -1) {
+			tableNames = append(tableNames, unquoteTriggerIdent(ident))
+		}
+
+		return db.analyzeTables(ctx, tableNames)
+	case showStatsRegex.MatchString(query):
+		m := showStatsRegex.FindStringSubmatch(query)
+		return db.showStats(ctx, unquoteTriggerIdent(m[3]))
+	default:
+		return nil, nil, fmt.Errorf("Unsupported statistics statement: '%v'.", query)
+	}
+}
+
+func (db Database) analyzeTables(ctx *sql.Context, tableNames []string) (sql.Schema, sql.RowIter, error) {
+	type analyzed struct {
+		name string
+		sch  schema.Schema
+		ts   *stats.TableStatistics
+	}
+
+	// the tables are analyzed as a background task, which waits for the tasks of higher priorities, and while the
+	// background work of the process is paused
+	task := background.DefaultScheduler.Start("analyze "+strings.Join(tableNames, ", "), background.StatisticsPriority)
+	defer task.Done()
+
+	// every table is analyzed before any statistics are written, so that they aren't written if a table is missing
+	var tables []analyzed
+	for _, tableName := range tableNames {
+		err := task.Wait(ctx, 0)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		tbl, ok, err := db.GetTableInsensitive(ctx, tableName)
+
+		if err != nil {
+			return nil, nil, err
+		} else if !ok {
+			return nil, nil, sql.ErrTableNotFound.New(tableName)
+		} else if doltdb.HasDoltPrefix(tbl.Name()) {
+			return nil, nil, ErrAnalyzeSystemTable.New(tbl.Name())
+		}
+
+		var dt *DoltTable
+		switch tbl := tbl.(type) {
+		case *AlterableDoltTable:
+			dt = &tbl.DoltTable
+		case *WritableDoltTable:
+			dt = &tbl.DoltTable
+		case *DoltTable:
+			dt = tbl
+		default:
+			return nil, nil, ErrAnalyzeSystemTable.New(tbl.Name())
+		}
+
+		ts, err := stats.Analyze(ctx, dt.table)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		tables = append(tables, analyzed{dt.name, dt.sch, ts})
+	}
+
+	stbl, err := getOrCreateStatisticsTable(ctx, db)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rows []sql.Row
+	for _, t := range tables {
+		err = writeStatistics(ctx, stbl, t.name, t.sch, t.ts)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		rows = append(rows, sql.NewRow(db.name+"."+t.name, "analyze", "status", "OK"))
+	}
+
+	return analyzeTableSchema, sql.RowsToRowIter(rows...), nil
+}
+
+// getOrCreateStatisticsTable returns the `dolt_statistics` table in `db`, creating it if it does not already exist.
+func getOrCreateStatisticsTable(ctx *sql.Context, db Database) (*WritableDoltTable, error) {
+	tbl, found, err := db.GetTableInsensitive(ctx, doltdb.StatisticsTableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		err = db.createTable(ctx, doltdb.StatisticsTableName, StatisticsTableSchema())
+
+		if err != nil {
+			return nil, err
+		}
+
+		tbl, found, err = db.GetTableInsensitive(ctx, doltdb.StatisticsTableName)
+
+		if err != nil {
+			return nil, err
+		} else if !found {
+			return nil, sql.ErrTableNotFound.New(doltdb.StatisticsTableName)
+		}
+	}
+
+	return tbl.(*WritableDoltTable), nil
+}
+
+// writeStatistics replaces the statistics of the table given in the dolt_statistics table with |ts|.
+func writeStatistics(ctx *sql.Context, stbl *WritableDoltTable, tableName string, sch schema.Schema, ts *stats.TableStatistics) error {
+	oldRows, err := statisticsRows(ctx, stbl, tableName)
+
+	if err != nil {
+		return err
+	}
+
+	deleter := stbl.Deleter(ctx)
+	for _, r := range oldRows {
+		err = deleter.Delete(ctx, r)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	err = deleter.Close(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	inserter := stbl.Inserter(ctx)
+	for _, cs := range ts.Columns {
+		col, _ := sch.GetAllCols().GetByTag(cs.Tag)
+		min, err := stats.FormatValue(col, cs.Min)
+
+		if err != nil {
+			return err
+		}
+
+		max, err := stats.FormatValue(col, cs.Max)
+
+		if err != nil {
+			return err
+		}
+
+		histogram, err := stats.EncodeHistogram(col, cs.Histogram)
+
+		if err != nil {
+			return err
+		}
+
+		err = inserter.Insert(ctx, sql.NewRow(tableName, cs.Name, ts.RowCount, cs.NullCount, cs.DistinctCount,
+			nullableString(min), nullableString(max), histogram, ts.SampleSize, ts.RowData.String()))
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return inserter.Close(ctx)
+}
+
+func nullableString(str *string) interface{} {
+	if str == nil {
+		return nil
+	}
+
+	return *str
+}
+
+// statisticsRows returns the rows of the dolt_statistics table given which hold the statistics of the table named, or
+// of every table if |tableName| is empty.
+func statisticsRows(ctx *sql.Context, stbl *WritableDoltTable, tableName string) ([]sql.Row, error) {
+	iter, err := newRowIterator(&stbl.DoltTable, ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer iter.Close()
+
+	var rows []sql.Row
+	for {
+		r, err := iter.Next()
+
+		if err == io.EOF {
+			return rows, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		if tableName == "" || r[0] == tableName {
+			rows = append(rows, r)
+		}
+	}
+}
+
+// tableStatistics returns the statistics of the table given read from the dolt_statistics table of |root|, and whether
+// they're stale, or nil if the table hasn't been analyzed.
+func (db Database) tableStatistics(ctx *sql.Context, root *doltdb.RootValue, tableName string, tbl *doltdb.Table) (*stats.TableStatistics, bool, error) {
+	stbl, ok, err := db.GetTableInsensitiveWithRoot(ctx, root, doltdb.StatisticsTableName)
+
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	rows, err := statisticsRows(ctx, stbl.(*WritableDoltTable), tableName)
+
+	if err != nil || len(rows) == 0 {
+		return nil, false, err
+	}
+
+	sch, err := tbl.GetSchema(ctx)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	ts, ok, err := statisticsFromRows(sch, rows)
+
+	if err != nil {
+		return nil, false, err
+	} else if !ok {
+		// a column which was analyzed has since been dropped
+		return ts, true, nil
+	}
+
+	stale, err := ts.IsStale(ctx, tbl)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return ts, stale, nil
+}
+
+// statisticsFromRows returns the statistics held by the dolt_statistics rows given, which are all of the same table,
+// or false if any of the columns they describe aren't in |sch|. The columns missing from the schema are skipped.
+func statisticsFromRows(sch schema.Schema, rows []sql.Row) (*stats.TableStatistics, bool, error) {
+	ts := &stats.TableStatistics{}
+	ok := true
+
+	for _, r := range rows {
+		ts.RowCount = r[2].(uint64)
+		ts.SampleSize = r[8].(uint64)
+		ts.RowData = hash.Parse(r[9].(string))
+
+		col, found := sch.GetAllCols().GetByName(r[1].(string))
+
+		if !found {
+			ok = false
+			continue
+		}
+
+		min, err := stats.ParseValue(col, optionalString(r[5]))
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		max, err := stats.ParseValue(col, optionalString(r[6]))
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		histogram, err := stats.DecodeHistogram(col, r[7].(string))
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		ts.Columns = append(ts.Columns, &stats.ColumnStatistics{
+			Name:          col.Name,
+			Tag:           col.Tag,
+			NullCount:     r[3].(uint64),
+			DistinctCount: r[4].(uint64),
+			Min:           min,
+			Max:           max,
+			Histogram:     histogram,
+		})
+	}
+
+	// the columns are kept in schema order, as by stats.Analyze
+	tags := sch.GetAllCols().Tags
+	sort.SliceStable(ts.Columns, func(i, j int) bool {
+		return indexOfTag(tags, ts.Columns[i].Tag) < indexOfTag(tags, ts.Columns[j].Tag)
+	})
+
+	return ts, ok, nil
+}
+
+func optionalString(val interface{}) *string {
+	if val == nil {
+		return nil
+	}
+
+	str := val.(string)
+	return &str
+}
+
+func indexOfTag(tags []uint64, tag uint64) int {
+	for i, t := range tags {
+		if t == tag {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (db Database) showStats(ctx *sql.Context, tableName string) (sql.Schema, sql.RowIter, error) {
+	root, err := db.GetRoot(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var tableNames []string
+	if tableName != "" {
+		tbl, ok, err := db.GetTableInsensitive(ctx, tableName)
+
+		if err != nil {
+			return nil, nil, err
+		} else if !ok {
+			return nil, nil, sql.ErrTableNotFound.New(tableName)
+		}
+
+		tableNames = []string{tbl.Name()}
+	} else {
+		tableNames, err = root.GetTableNames(ctx)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		sort.Slice(tableNames, func(i, j int) bool {
+			return strings.ToLower(tableNames[i]) < strings.ToLower(tableNames[j])
+		})
+	}
+
+	_, limited := rowPolicyUser(ctx)
+
+	var rows []sql.Row
+	for _, name := range tableNames {
+		tbl, ok, err := root.GetTable(ctx, name)
+
+		if err != nil {
+			return nil, nil, err
+		} else if !ok || doltdb.HasDoltPrefix(name) {
+			continue
+		}
+
+		// the statistics of a table with row policies would reveal the values of rows the session may not be able to read
+		if limited {
+			hidden, err := hasRowPolicies(ctx, root, name)
+
+			if err != nil {
+				return nil, nil, err
+			} else if hidden {
+				continue
+			}
+		}
+
+		ts, stale, err := db.tableStatistics(ctx, root, name, tbl)
+
+		if err != nil {
+			return nil, nil, err
+		} else if ts == nil {
+			continue
+		}
+
+		sch, err := tbl.GetSchema(ctx)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		staleStr := "NO"
+		if stale {
+			staleStr = "YES"
+		}
+
+		for _, cs := range ts.Columns {
+			col, _ := sch.GetAllCols().GetByTag(cs.Tag)
+			min, err := stats.FormatValue(col, cs.Min)
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			max, err := stats.FormatValue(col, cs.Max)
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			rows = append(rows, sql.NewRow(name, cs.Name, ts.RowCount, ts.NullFraction(cs), cs.DistinctCount,
+				nullableString(min), nullableString(max), int64(len(cs.Histogram)), staleStr))
+		}
+	}
+
+	return showStatsSchema, sql.RowsToRowIter(rows...), nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"testing"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+func TestIsStatisticsStatement(t *testing.T) {
+	for _, query := range []string{"analyze table test", "ANALYZE LOCAL TABLE `test`, other;", "analyze tables a,b", "show stats", "SHOW STATS FOR test", "show stats from `test`;"} {
+		assert.True(t, IsStatisticsStatement(query), query)
+	}
+
+	for _, query := range []string{"analyze test", "explain analyze select 1", "show status", "show stats for a, b", "select * from dolt_statistics"} {
+		assert.False(t, IsStatisticsStatement(query), query)
+	}
+}
+
+func TestStatisticsStatements(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+
+	ctx := context.Background()
+	root, _ := dEnv.WorkingRoot(ctx)
+
+	db := NewDatabase("dolt", dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
+	engine, sqlCtx, err := NewTestEngine(ctx, db, root)
+	require.NoError(t, err)
+
+	mustQuery(t, sqlCtx, engine, "create table test (pk int primary key, category varchar(20), score int)")
+	mustQuery(t, sqlCtx, engine, `insert into test values (1, "a", 10), (2, "b", 20), (3, "a", 30), (4, null, 40), (5, "a", 50),
+		(6, "b", 60), (7, "a", 70), (8, null, 80), (9, "a", 90), (10, "b", 100), (11, "a", 110), (12, "c", 120)`)
+
+	rows := executeStatisticsStatement(t, sqlCtx, db, "show stats")
+	assert.Empty(t, rows)
+
+	rows = executeStatisticsStatement(t, sqlCtx, db, "analyze table TEST")
+	assert.Equal(t, []sql.Row{{"dolt.test", "analyze", "status", "OK"}}, rows)
+
+	rows = executeStatisticsStatement(t, sqlCtx, db, "show stats for test")
+	assert.Equal(t, []sql.Row{
+		{"test", "pk", uint64(12), float64(0), uint64(12), "1", "12", int64(12), "NO"},
+		{"test", "category", uint64(12), float64(2) / 12, uint64(3), "a", "c", int64(3), "NO"},
+		{"test", "score", uint64(12), float64(0), uint64(12), "10", "120", int64(12), "NO"},
+	}, rows)
+
+	// the statistics are kept in a table of their own, which is versioned along with the rest of the root
+	rows = mustQuery(t, sqlCtx, engine, "select column_name, null_count, histogram from dolt_statistics where table_name = 'test' and column_name = 'category'")
+	assert.Equal(t, []sql.Row{{"category", uint64(2), `[{"upper_bound":"a","row_count":6},{"upper_bound":"b","row_count":3},{"upper_bound":"c","row_count":1}]`}}, rows)
+	assert.False(t, doltdb.IsSystemTable(doltdb.StatisticsTableName))
+
+	tbl, ok, err := db.GetTableInsensitive(sqlCtx, "test")
+	require.NoError(t, err)
+	require.True(t, ok)
+	ts, ok, err := tbl.(StatisticsTable).Statistics(sqlCtx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	rowCount, err := ts.EstimateEqual(types.Format_Default, ts.Columns[1], types.String("b"))
+	require.NoError(t, err)
+	assert.Equal(t, float64(10)/3, rowCount)
+
+	// analyzing again replaces the statistics, and they're stale once enough rows change
+	mustQuery(t, sqlCtx, engine, "update test set score = 0 where pk = 1")
+	rows = executeStatisticsStatement(t, sqlCtx, db, "show stats")
+	assert.Equal(t, "NO", rows[0][8])
+	mustQuery(t, sqlCtx, engine, "delete from test where pk > 10")
+	rows = executeStatisticsStatement(t, sqlCtx, db, "show stats")
+	assert.Equal(t, "YES", rows[0][8])
+	tbl, _, err = db.GetTableInsensitive(sqlCtx, "test")
+	require.NoError(t, err)
+	_, ok, err = tbl.(StatisticsTable).Statistics(sqlCtx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	executeStatisticsStatement(t, sqlCtx, db, "analyze table test")
+	rows = executeStatisticsStatement(t, sqlCtx, db, "show stats")
+	require.Len(t, rows, 3)
+	assert.Equal(t, []interface{}{uint64(10), "NO"}, []interface{}{rows[0][2], rows[0][8]})
+	rows = mustQuery(t, sqlCtx, engine, "select count(*) from dolt_statistics")
+	assert.Equal(t, []sql.Row{{int64(3)}}, rows)
+
+	// dropping a column leaves the statistics stale
+	mustQuery(t, sqlCtx, engine, "alter table test drop column score")
+	rows = executeStatisticsStatement(t, sqlCtx, db, "show stats")
+	require.Len(t, rows, 2)
+	assert.Equal(t, "YES", rows[0][8])
+
+	_, _, err = ExecuteStatisticsStatement(sqlCtx, db, "analyze table test, missing")
+	assert.True(t, sql.ErrTableNotFound.Is(err), "unexpected error %v", err)
+	_, _, err = ExecuteStatisticsStatement(sqlCtx, db, "analyze table dolt_statistics")
+	assert.True(t, ErrAnalyzeSystemTable.Is(err), "unexpected error %v", err)
+}
+
+func executeStatisticsStatement(t *testing.T, ctx *sql.Context, db Database, query string) []sql.Row {
+	_, iter, err := ExecuteStatisticsStatement(ctx, db, query)
+	require.NoError(t, err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(t, err)
+	return rows
+}
+
+func mustQuery(t *testing.T, ctx *sql.Context, e *sqle.Engine, query string) []sql.Row {
+	_, iter, err := e.Query(ctx, query)
+	require.NoError(t, err, query)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(t, err, query)
+	return rows
+}
@@ -0,0 +1,165 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"github.com/src-d/go-mysql-server/sql"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+)
+
+const (
+	// StatusTableName is the system table name
+	StatusTableName = "dolt_status"
+)
+
+var statusLabels = map[diff.TableDiffType]string{
+	diff.AddedTable:    "new table",
+	diff.ModifiedTable: "modified",
+	diff.RemovedTable:  "deleted",
+}
+
+// conflictStatus is the status of a working table which has merge conflicts
+const conflictStatus = "conflict"
+
+var _ sql.Table = (*StatusTable)(nil)
+
+// StatusTable is a sql.Table implementation that implements a system table which shows the tables which differ between
+// the session's head commit, the staged root and the session's working root, as `dolt status` does. Each changed table
+// has a row with staged = 1 if it differs between the head and the staged root, and a row with staged = 0 if it differs
+// between the staged and working roots. Like `dolt status`, new tables which are ignored by dolt_ignore are left out,
+// and tables with conflicts in the working root have the status "conflict".
+type StatusTable struct {
+	dbName string
+	dbd    dbData
+}
+
+// NewStatusTable creates a StatusTable
+func NewStatusTable(sqlCtx *sql.Context, dbName string) (*StatusTable, error) {
+	dbd, ok := DSessFromSess(sqlCtx.Session).dbDatas[dbName]
+
+	if !ok {
+		return nil, sql.ErrDatabaseNotFound.New(dbName)
+	}
+
+	return &StatusTable{dbName, dbd}, nil
+}
+
+// Name is a sql.Table interface function which returns the name of the table which is defined by the constant
+// StatusTableName
+func (st *StatusTable) Name() string {
+	return StatusTableName
+}
+
+// String is a sql.Table interface function which returns the name of the table which is defined by the constant
+// StatusTableName
+func (st *StatusTable) String() string {
+	return StatusTableName
+}
+
+// Schema is a sql.Table interface function that gets the sql.Schema of the status system table
+func (st *StatusTable) Schema() sql.Schema {
+	return []*sql.Column{
+		{Name: "table_name", Type: sql.Text, Source: StatusTableName, PrimaryKey: true, Nullable: false},
+		{Name: "staged", Type: sql.Boolean, Source: StatusTableName, PrimaryKey: true, Nullable: false},
+		{Name: "status", Type: sql.Text, Source: StatusTableName, PrimaryKey: false, Nullable: false},
+	}
+}
+
+// Partitions is a sql.Table interface function that returns a partition of the data.  Currently the data is unpartitioned.
+func (st *StatusTable) Partitions(*sql.Context) (sql.PartitionIter, error) {
+	return &doltTablePartitionIter{}, nil
+}
+
+// PartitionRows is a sql.Table interface function that gets a row iterator for a partition. The staged changes come
+// first, and the changes of each kind are ordered by table name.
+func (st *StatusTable) PartitionRows(sqlCtx *sql.Context, part sql.Partition) (sql.RowIter, error) {
+	sess := DSessFromSess(sqlCtx.Session)
+	head, err := sess.GetParentCommit(sqlCtx, st.dbName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	headRoot, err := head.GetRootValue()
+
+	if err != nil {
+		return nil, err
+	}
+
+	stagedRoot, err := st.dbd.ddb.ReadRootValue(sqlCtx, st.dbd.rsr.StagedHash())
+
+	if err != nil {
+		return nil, err
+	}
+
+	workingRoot, ok := sess.GetRoot(st.dbName)
+
+	if !ok {
+		return nil, sql.ErrDatabaseNotFound.New(st.dbName)
+	}
+
+	stagedDiffs, err := diff.NewTableDiffs(sqlCtx, stagedRoot, headRoot)
+
+	if err != nil {
+		return nil, err
+	}
+
+	notStagedDiffs, err := diff.NewTableDiffs(sqlCtx, workingRoot, stagedRoot)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ips, err := doltdb.GetIgnorePatterns(sqlCtx, workingRoot)
+
+	if err != nil {
+		return nil, err
+	}
+
+	inConflict, err := workingRoot.TablesInConflict(sqlCtx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := make(map[string]bool)
+	for _, tblName := range inConflict {
+		conflicts[tblName] = true
+	}
+
+	var rows []sql.Row
+	for _, tblName := range stagedDiffs.Tables {
+		rows = append(rows, sql.NewRow(tblName, true, statusLabels[stagedDiffs.TableToType[tblName]]))
+	}
+
+	for _, tblName := range notStagedDiffs.Tables {
+		tdt := notStagedDiffs.TableToType[tblName]
+
+		if tdt == diff.AddedTable && ips.IsTableNameIgnored(tblName) {
+			continue
+		}
+
+		status := statusLabels[tdt]
+		if conflicts[tblName] {
+			status = conflictStatus
+		}
+
+		rows = append(rows, sql.NewRow(tblName, false, status))
+	}
+
+	return sql.RowsToRowIter(rows...), nil
+}
@@ -0,0 +1,263 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"strings"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+
+	"github.com/liquidata-inc/dolt/go/libraries/utils/set"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// handledSystemTableFilters returns the filters which a system table evaluates itself. The system tables which walk
+// the commit graph or the refs of a database build each row in memory, so they can evaluate any filter on their own
+// columns. Filters with subqueries are left to the engine.
+func handledSystemTableFilters(filters []sql.Expression) []sql.Expression {
+	var handled []sql.Expression
+	for _, f := range filters {
+		hasSubquery := false
+		sql.Inspect(f, func(e sql.Expression) bool {
+			if _, ok := e.(*expression.Subquery); ok {
+				hasSubquery = true
+			}
+
+			return !hasSubquery
+		})
+
+		if !hasSubquery {
+			handled = append(handled, f)
+		}
+	}
+
+	return handled
+}
+
+// filtersMatch returns whether the row |r| satisfies all of |filters|, whose fields index into |r|.
+func filtersMatch(ctx *sql.Context, filters []sql.Expression, r sql.Row) (bool, error) {
+	for _, f := range filters {
+		ok, err := sql.EvaluateCondition(ctx, f, r)
+
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// splitConjunctions returns the expressions which are and-ed together by |filters|.
+func splitConjunctions(filters []sql.Expression) []sql.Expression {
+	var split []sql.Expression
+	for _, f := range filters {
+		if and, ok := f.(*expression.And); ok {
+			split = append(split, splitConjunctions([]sql.Expression{and.Left, and.Right})...)
+		} else {
+			split = append(split, f)
+		}
+	}
+
+	return split
+}
+
+// isConstant returns whether |e| evaluates to the same value for every row.
+func isConstant(e sql.Expression) bool {
+	constant := true
+	sql.Inspect(e, func(e sql.Expression) bool {
+		switch e.(type) {
+		case *expression.GetField, *expression.Subquery:
+			constant = false
+		}
+
+		return constant
+	})
+
+	return constant
+}
+
+// columnComparison splits the binary comparison |l| <op> |r| into the column it compares, by name, and the constant it
+// compares the column to. flipped is true if the column is on the right side. ok is false if the comparison isn't
+// between a column and a constant.
+func columnComparison(l, r sql.Expression) (colName string, constant sql.Expression, flipped, ok bool) {
+	if gf, isField := l.(*expression.GetField); isField && isConstant(r) {
+		return strings.ToLower(gf.Name()), r, false, true
+	} else if gf, isField := r.(*expression.GetField); isField && isConstant(l) {
+		return strings.ToLower(gf.Name()), l, true, true
+	}
+
+	return "", nil, false, false
+}
+
+// unwrapConvert returns the expression converted by |e|, if it's a conversion, which the analyzer adds around the
+// columns compared to values of other types. Only the bounds on dates look through conversions, as the bound is
+// converted to a time anyway.
+func unwrapConvert(e sql.Expression) sql.Expression {
+	if conv, ok := e.(*expression.Convert); ok {
+		return unwrapConvert(conv.Child)
+	}
+
+	return e
+}
+
+// equalityValues returns the string values which the conjunction of |filters| limits the column |colName| to, from the
+// predicates like col = 'x' and col IN ('x', 'y') among them. ok is false if none of the predicates limit the column.
+func equalityValues(ctx *sql.Context, filters []sql.Expression, colName string) (vals *set.StrSet, ok bool, err error) {
+	for _, f := range splitConjunctions(filters) {
+		var candidates []interface{}
+		switch typed := f.(type) {
+		case *expression.Equals:
+			name, constant, _, isCmp := columnComparison(typed.Left(), typed.Right())
+
+			if !isCmp || name != colName {
+				continue
+			}
+
+			v, err := constant.Eval(ctx, nil)
+
+			if err != nil {
+				return nil, false, err
+			}
+
+			candidates = []interface{}{v}
+
+		case *expression.In:
+			gf, isField := typed.Left().(*expression.GetField)
+			tuple, isTuple := typed.Right().(expression.Tuple)
+
+			if !isField || !isTuple || strings.ToLower(gf.Name()) != colName || !isConstant(tuple) {
+				continue
+			}
+
+			for _, e := range tuple {
+				v, err := e.Eval(ctx, nil)
+
+				if err != nil {
+					return nil, false, err
+				}
+
+				candidates = append(candidates, v)
+			}
+
+		default:
+			continue
+		}
+
+		strs := set.NewStrSet(nil)
+		for _, v := range candidates {
+			if str, isStr := v.(string); isStr && (!ok || vals.Contains(str)) {
+				strs.Add(str)
+			}
+		}
+
+		vals, ok = strs, true
+	}
+
+	return vals, ok, nil
+}
+
+// commitWalkBounds are the limits which a where clause on the commits of a system table puts on the walk of the commit
+// graph which produces its rows.
+type commitWalkBounds struct {
+	// hashes are the only commits the rows can be for, or nil if the rows can be for any commit
+	hashes hash.HashSet
+
+	// since is the time the commits of the rows must be made at or after, or zero if they can be made any time
+	since time.Time
+}
+
+// getCommitWalkBounds returns the bounds which the conjunction of |filters| puts on the commits of the rows of a system
+// table, whose column |hashCol| holds the hash of the commit and whose column |dateCol| holds the time it was made.
+// Either column name may be empty if the table has no such column.
+func getCommitWalkBounds(ctx *sql.Context, filters []sql.Expression, hashCol, dateCol string) (commitWalkBounds, error) {
+	var bounds commitWalkBounds
+	if hashCol != "" {
+		hashStrs, ok, err := equalityValues(ctx, filters, hashCol)
+
+		if err != nil {
+			return commitWalkBounds{}, err
+		}
+
+		if ok {
+			bounds.hashes = hash.NewHashSet()
+			hashStrs.Iterate(func(s string) bool {
+				if h, valid := hash.MaybeParse(s); valid {
+					bounds.hashes.Insert(h)
+				}
+
+				return true
+			})
+		}
+	}
+
+	if dateCol == "" {
+		return bounds, nil
+	}
+
+	for _, f := range splitConjunctions(filters) {
+		var lower sql.Expression
+		switch typed := f.(type) {
+		case *expression.GreaterThan:
+			if name, constant, flipped, ok := columnComparison(unwrapConvert(typed.Left()), unwrapConvert(typed.Right())); ok && name == dateCol && !flipped {
+				lower = constant
+			}
+		case *expression.GreaterThanOrEqual:
+			if name, constant, flipped, ok := columnComparison(unwrapConvert(typed.Left()), unwrapConvert(typed.Right())); ok && name == dateCol && !flipped {
+				lower = constant
+			}
+		case *expression.LessThan:
+			if name, constant, flipped, ok := columnComparison(unwrapConvert(typed.Left()), unwrapConvert(typed.Right())); ok && name == dateCol && flipped {
+				lower = constant
+			}
+		case *expression.LessThanOrEqual:
+			if name, constant, flipped, ok := columnComparison(unwrapConvert(typed.Left()), unwrapConvert(typed.Right())); ok && name == dateCol && flipped {
+				lower = constant
+			}
+		case *expression.Between:
+			if gf, ok := unwrapConvert(typed.Val).(*expression.GetField); ok && strings.ToLower(gf.Name()) == dateCol && isConstant(typed.Lower) {
+				lower = typed.Lower
+			}
+		}
+
+		if lower == nil {
+			continue
+		}
+
+		v, err := lower.Eval(ctx, nil)
+
+		if err != nil {
+			return commitWalkBounds{}, err
+		}
+
+		if v == nil {
+			continue
+		}
+
+		t, err := sql.Datetime.Convert(v)
+
+		if err != nil {
+			// don't limit the walk on a bound which isn't a time
+			continue
+		}
+
+		if tm := t.(time.Time); tm.After(bounds.since) {
+			bounds.since = tm
+		}
+	}
+
+	return bounds, nil
+}
@@ -0,0 +1,197 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	"github.com/liquidata-inc/dolt/go/store/background"
+)
+
+// newHistoryTest returns a test whose repository was initialized long before the commits the test makes, so that the
+// dates of its commits increase from the first commit on.
+func newHistoryTest(t *testing.T) *transactionTest {
+	doltdb.CommitNowFunc = (&testCommitClock{}).Now
+	defer func() {
+		doltdb.CommitNowFunc = time.Now
+	}()
+
+	return newTransactionTest(t)
+}
+
+// newOrdersHistory returns a test whose history has an orders table created a month ago, and changes to orders and
+// people since then. The hashes of the commits are returned by message.
+func newOrdersHistory(t *testing.T) (*transactionTest, map[string]string) {
+	tt := newHistoryTest(t)
+	now := time.Now()
+	day := 24 * time.Hour
+
+	hashes := make(map[string]string)
+	commit := func(msg string, age time.Duration, queries ...string) {
+		tt.mustExec(tt.newSession(), queries...)
+		hashes[msg] = tt.commitAllAt(msg, now.Add(-age))
+	}
+
+	commit("create orders", 30*day, "create table orders (id int primary key, person_id int)")
+	commit("first order", 20*day, "insert into orders values (1, 0)")
+	commit("update people", 10*day, "update people set age = 41 where id = 0")
+	commit("second order", 3*day, "insert into orders values (2, 1)")
+	commit("update people again", 2*day, "update people set age = 42 where id = 0")
+	commit("third order", day, "insert into orders values (3, 2)")
+
+	return tt, hashes
+}
+
+func TestLogTableFilters(t *testing.T) {
+	tt, hashes := newOrdersHistory(t)
+	s := tt.newSession()
+
+	// commits to the session's branch in the last 7 days touching table orders
+	assert.Equal(t, []sql.Row{{"third order"}, {"second order"}},
+		tt.mustExec(s, "select message from dolt_log_orders where date > now() - interval 7 day"))
+	assert.Equal(t, []sql.Row{{"third order"}, {"update people again"}, {"second order"}},
+		tt.mustExec(s, "select message from dolt_log where date > now() - interval 7 day"))
+	assert.Equal(t, []sql.Row{{"third order"}, {"second order"}, {"first order"}, {"create orders"}},
+		tt.mustExec(s, "select message from dolt_log_ORDERS"))
+
+	query := fmt.Sprintf("select message from dolt_log where commit_hash in ('%s', '%s')", hashes["update people"], hashes["create orders"])
+	assert.Equal(t, []sql.Row{{"update people"}, {"create orders"}}, tt.mustExec(s, query))
+	query = fmt.Sprintf("select message from dolt_log where commit_hash = '%s' and committer = 'nobody'", hashes["update people"])
+	assert.Empty(t, tt.mustExec(s, query))
+	assert.Empty(t, tt.mustExec(s, "select message from dolt_log where commit_hash = 'not a hash'"))
+
+	// filters the table can't evaluate itself are still applied
+	query = "select message from dolt_log where commit_hash in (select commit_hash from dolt_log where message = 'first order')"
+	assert.Equal(t, []sql.Row{{"first order"}}, tt.mustExec(s, query))
+
+	_, err := tt.exec(s, "select * from dolt_log_not_a_table")
+	assert.True(t, sql.ErrTableNotFound.Is(err), "unexpected error: %v", err)
+}
+
+func TestLogTableDateBoundStopsWalk(t *testing.T) {
+	tt := newHistoryTest(t)
+	now := time.Now()
+
+	// a commit made by a machine whose clock was ahead, followed by one whose clock was behind
+	tt.mustExec(tt.newSession(), "update people set age = 1 where id = 0")
+	tt.commitAllAt("clock ahead", now.Add(-time.Hour))
+	tt.mustExec(tt.newSession(), "update people set age = 2 where id = 0")
+	tt.commitAllAt("clock behind", now.Add(-30*24*time.Hour))
+	tt.mustExec(tt.newSession(), "update people set age = 3 where id = 0")
+	tt.commitAllAt("latest", now)
+
+	// the walk stops at the first commit made before the bound, so its ancestors aren't read
+	s := tt.newSession()
+	assert.Equal(t, []sql.Row{{"latest"}}, tt.mustExec(s, "select message from dolt_log where date >= now() - interval 1 day"))
+	assert.Equal(t, []sql.Row{{"latest"}, {"clock ahead"}}, tt.mustExec(s, "select message from dolt_log where message <> 'clock behind' and date >= '2000-01-01'"))
+}
+
+func TestBranchesTableFilters(t *testing.T) {
+	tt := newTransactionTest(t)
+	ctx := context.Background()
+	head, err := tt.dEnv.DoltDB.Resolve(ctx, tt.dEnv.RepoState.CWBHeadSpec())
+	require.NoError(t, err)
+	require.NoError(t, tt.dEnv.DoltDB.NewBranchAtCommit(ctx, ref.NewBranchRef("feature"), head))
+
+	s := tt.newSession()
+	assert.Equal(t, []sql.Row{{"feature"}, {"master"}}, tt.mustExec(s, "select name from dolt_branches order by name"))
+	assert.Equal(t, []sql.Row{{"feature"}}, tt.mustExec(s, "select name from dolt_branches where name = 'feature'"))
+	assert.Equal(t, []sql.Row{{"master"}}, tt.mustExec(s, "select name from dolt_branches where name in ('master', 'main')"))
+	assert.Empty(t, tt.mustExec(s, "select name from dolt_branches where name = 'feature' and name = 'master'"))
+}
+
+func TestCommitAncestorsTable(t *testing.T) {
+	tt, hashes := newOrdersHistory(t)
+	s := tt.newSession()
+
+	query := fmt.Sprintf("select parent_hash, parent_index from dolt_commit_ancestors where commit_hash = '%s'", hashes["second order"])
+	assert.Equal(t, []sql.Row{{hashes["update people"], int32(0)}}, tt.mustExec(s, query))
+
+	// the children of a commit
+	query = fmt.Sprintf("select l.message from dolt_commit_ancestors a join dolt_log l on a.commit_hash = l.commit_hash where a.parent_hash = '%s'", hashes["update people"])
+	assert.Equal(t, []sql.Row{{"second order"}}, tt.mustExec(s, query))
+
+	// every commit but the first has a parent
+	rows := tt.mustExec(s, "select count(*) from dolt_commit_ancestors where parent_hash is null")
+	assert.Equal(t, []sql.Row{{int64(1)}}, rows)
+	rows = tt.mustExec(s, "select count(*) from dolt_commit_ancestors")
+	assert.Equal(t, tt.mustExec(s, "select count(*) from dolt_log"), rows)
+}
+
+func TestRemotesTable(t *testing.T) {
+	tt := newTransactionTest(t)
+	s := tt.newSession()
+	assert.Empty(t, tt.mustExec(s, "select * from dolt_remotes"))
+
+	tt.dEnv.RepoState.AddRemote(env.NewRemote("origin", "file:///tmp/origin", map[string]string{"insecure": "true"}))
+	tt.dEnv.RepoState.AddRemote(env.NewRemote("backup", "file:///tmp/backup", nil))
+	assert.Equal(t, []sql.Row{
+		{"backup", "file:///tmp/backup", `["refs/heads/*:refs/remotes/backup/*"]`, `{}`},
+		{"origin", "file:///tmp/origin", `["refs/heads/*:refs/remotes/origin/*"]`, `{"insecure":"true"}`},
+	}, tt.mustExec(s, "select * from dolt_remotes"))
+	assert.Equal(t, []sql.Row{{"file:///tmp/origin"}}, tt.mustExec(s, "select url from dolt_remotes where name = 'origin'"))
+}
+
+func TestBackgroundTasksTable(t *testing.T) {
+	tt := newTransactionTest(t)
+	s := tt.newSession()
+	query := "select name, priority, state, bytes_done, bytes_total from dolt_background_tasks where name like 'test %'"
+	assert.Empty(t, tt.mustExec(s, query))
+
+	task := background.DefaultScheduler.Start("test conjoin", background.ConjoinPriority)
+	require.NoError(t, task.Wait(context.Background(), 10))
+	push := background.DefaultScheduler.Start("test push", background.ReplicationPriority)
+	push.SetTotal(100)
+
+	assert.Equal(t, []sql.Row{
+		{"test conjoin", "conjoin", "running", int64(10), nil},
+		{"test push", "replication", "running", int64(0), int64(100)},
+	}, tt.mustExec(s, query))
+
+	task.Done()
+	push.Done()
+	assert.Empty(t, tt.mustExec(s, query))
+}
+
+func TestStatusTable(t *testing.T) {
+	tt := newTransactionTest(t)
+	ctx := context.Background()
+	tt.commitAll("initial")
+
+	s := tt.newSession()
+	assert.Empty(t, tt.mustExec(s, "select * from dolt_status"))
+
+	tt.mustExec(s, "create table orders (id int primary key)", "update people set age = 99 where id = 0")
+	require.NoError(t, actions.StageTables(ctx, tt.dEnv, []string{"people"}, false))
+	tt.mustExec(s, "update people set age = 100 where id = 0")
+
+	assert.Equal(t, []sql.Row{
+		{"people", true, "modified"},
+		{"orders", false, "new table"},
+		{"people", false, "modified"},
+	}, tt.mustExec(tt.newSession(), "select * from dolt_status"))
+	assert.Equal(t, []sql.Row{{"people"}}, tt.mustExec(tt.newSession(), "select table_name from dolt_status where staged = 1"))
+}
@@ -0,0 +1,298 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"fmt"
+	"regexp"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/alterschema"
+	dsql "github.com/liquidata-inc/dolt/go/libraries/doltcore/sql"
+)
+
+var alterTableCommentRegex = regexp.MustCompile(`(?is)^\s*alter\s+table\s+` + triggerIdentRegexStr +
+	`\s+comment\s*=?\s*'(?:[^'\\]|\\.|'')*'[\s;]*$`)
+var createTableRegex = regexp.MustCompile(`(?is)^\s*create\s+table\b`)
+var showCreateTableRegex = regexp.MustCompile(`(?is)^\s*show\s+create\s+table\s+(?:` + triggerIdentRegexStr + `\s*\.\s*)?` +
+	triggerIdentRegexStr + `[\s;]*$`)
+
+// commentedTable is a table with a comment describing it.
+type commentedTable interface {
+	Comment() string
+}
+
+// schemaTable is a table with a dolt schema.
+type schemaTable interface {
+	doltSchema() schema.Schema
+}
+
+// IsTableCommentStatement returns whether the query given is an ALTER TABLE statement which sets the table's comment, a
+// CREATE TABLE statement with a COMMENT table option, or a SHOW CREATE TABLE statement. The SQL engine ignores table
+// comments, so integrators must check for these statements before parsing a query and run them with
+// ExecuteTableCommentStatement.
+func IsTableCommentStatement(query string) bool {
+	switch {
+	case alterTableCommentRegex.MatchString(query), showCreateTableRegex.MatchString(query):
+		return true
+	case createTableRegex.MatchString(query):
+		_, ok := tableCommentOption(query)
+		return ok
+	default:
+		return false
+	}
+}
+
+// ExecuteTableCommentStatement executes a table comment statement, as identified by IsTableCommentStatement, against
+// the database given. CREATE TABLE and SHOW CREATE TABLE statements are run by the engine given, with the table's
+// comment set afterwards. SHOW CREATE TABLE statements show the statement which creates each table's dolt schema, in
+// place of the engine's, which leaves out the tables' comments and can't be executed to recreate them.
+func ExecuteTableCommentStatement(ctx *sql.Context, e *sqle.Engine, db Database, query string) (sql.Schema, sql.RowIter, error) {
+	switch {
+	case alterTableCommentRegex.MatchString(query):
+		m := alterTableCommentRegex.FindStringSubmatch(query)
+		comment, _ := tableCommentOption(query)
+		return setTableComment(ctx, db, unquoteTriggerIdent(m[1]), comment)
+	case showCreateTableRegex.MatchString(query):
+		m := showCreateTableRegex.FindStringSubmatch(query)
+		return showCreateTable(ctx, e, db, unquoteTriggerIdent(m[1]), query)
+	case createTableRegex.MatchString(query):
+		return createTableWithComment(ctx, e, db, query)
+	default:
+		return nil, nil, fmt.Errorf("Unsupported table comment statement: '%v'.", query)
+	}
+}
+
+// tableCommentOption returns the value of the COMMENT table option of the CREATE TABLE or ALTER TABLE statement given.
+// Column comments are inside of the column definitions' parentheses, so they're skipped.
+func tableCommentOption(query string) (string, bool) {
+	tkn := sqlparser.NewStringTokenizer(query)
+	depth := 0
+
+	for {
+		typ, _ := tkn.Scan()
+		switch typ {
+		case 0, sqlparser.LEX_ERROR:
+			return "", false
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case sqlparser.COMMENT_KEYWORD:
+			if depth != 0 {
+				continue
+			}
+
+			typ, val := tkn.Scan()
+			if typ == '=' {
+				typ, val = tkn.Scan()
+			}
+
+			if typ != sqlparser.STRING {
+				return "", false
+			}
+
+			return string(val), true
+		}
+	}
+}
+
+// commentDatabase returns the database named by the qualifier of a table name, or the database given if the table name
+// isn't qualified.
+func commentDatabase(e *sqle.Engine, db Database, qualifier string) (Database, error) {
+	if qualifier == "" {
+		return db, nil
+	}
+
+	sqlDB, err := e.Catalog.Database(qualifier)
+
+	if err != nil {
+		return Database{}, err
+	}
+
+	qualifiedDB, ok := sqlDB.(Database)
+
+	if !ok {
+		return Database{}, sql.ErrDatabaseNotFound.New(qualifier)
+	}
+
+	return qualifiedDB, nil
+}
+
+func setTableComment(ctx *sql.Context, db Database, tableName, comment string) (sql.Schema, sql.RowIter, error) {
+	root, err := db.GetRoot(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tableNames, err := getAllTableNames(ctx, root)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	name, ok := sql.GetTableNameInsensitive(tableName, tableNames)
+
+	if !ok {
+		return nil, nil, sql.ErrTableNotFound.New(tableName)
+	} else if doltdb.IsSystemTable(name) {
+		return nil, nil, ErrSystemTableAlter.New(name)
+	}
+
+	tbl, ok, err := root.GetTable(ctx, name)
+
+	if err != nil {
+		return nil, nil, err
+	} else if !ok {
+		return nil, nil, sql.ErrTableNotFound.New(tableName)
+	}
+
+	if err := db.checkWritePermission(ctx, root, name); err != nil {
+		return nil, nil, err
+	}
+
+	tbl, err = alterschema.SetTableComment(ctx, tbl, comment)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	newRoot, err := root.PutTable(ctx, name, tbl)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = db.SetRoot(ctx, newRoot)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return okTableCommentResult()
+}
+
+func createTableWithComment(ctx *sql.Context, e *sqle.Engine, db Database, query string) (sql.Schema, sql.RowIter, error) {
+	stmt, err := sqlparser.Parse(query)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ddl, ok := stmt.(*sqlparser.DDL)
+
+	if !ok {
+		return nil, nil, fmt.Errorf("Unsupported table comment statement: '%v'.", query)
+	}
+
+	db, err = commentDatabase(e, db, ddl.Table.Qualifier.String())
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tableName := ddl.Table.Name.String()
+	_, exists, err := db.GetTableInsensitive(ctx, tableName)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, iter, err := e.Query(ctx, query)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, err = sql.RowIterToRows(iter)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// CREATE TABLE IF NOT EXISTS leaves an existing table, and its comment, as it is
+	if exists {
+		return okTableCommentResult()
+	}
+
+	comment, _ := tableCommentOption(query)
+	return setTableComment(ctx, db, tableName, comment)
+}
+
+// showCreateTable runs a SHOW CREATE TABLE statement with the engine given, replacing the statement shown for each
+// table with a dolt schema with dsql.ShowCreateTableStmt. Views and other tables are shown as the engine shows them.
+func showCreateTable(ctx *sql.Context, e *sqle.Engine, db Database, qualifier, query string) (sql.Schema, sql.RowIter, error) {
+	db, err := commentDatabase(e, db, qualifier)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sch, iter, err := e.Query(ctx, query)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := sql.RowIterToRows(iter)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, r := range rows {
+		if len(r) != 2 {
+			continue
+		}
+
+		name, ok := r[0].(string)
+
+		if !ok {
+			continue
+		}
+
+		tbl, ok, err := db.GetTableInsensitive(ctx, name)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		st, isSchemaTable := tbl.(schemaTable)
+
+		if !ok || !isSchemaTable {
+			continue
+		}
+
+		stmt, err := dsql.ShowCreateTableStmt(tbl.Name(), st.doltSchema())
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		rows[i] = sql.NewRow(name, stmt)
+	}
+
+	return sch, sql.RowsToRowIter(rows...), nil
+}
+
+func okTableCommentResult() (sql.Schema, sql.RowIter, error) {
+	return sql.OkResultSchema, sql.RowsToRowIter(sql.NewRow(sql.NewOkResult(0))), nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"testing"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+)
+
+func TestIsTableCommentStatement(t *testing.T) {
+	assert.True(t, IsTableCommentStatement("alter table test comment 'people'"))
+	assert.True(t, IsTableCommentStatement("ALTER TABLE `test` COMMENT = 'it''s \\'quoted\\'';"))
+	assert.True(t, IsTableCommentStatement("create table test (a int primary key) comment='people'"))
+	assert.True(t, IsTableCommentStatement("show create table test"))
+	assert.True(t, IsTableCommentStatement("SHOW CREATE TABLE dolt.`test`;"))
+	assert.False(t, IsTableCommentStatement("create table test (a int primary key comment 'tag:1')"))
+	assert.False(t, IsTableCommentStatement("alter table test add column b int comment 'b'"))
+	assert.False(t, IsTableCommentStatement("show tables"))
+	assert.False(t, IsTableCommentStatement("select * from comments"))
+}
+
+func TestTableCommentOption(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+		ok       bool
+	}{
+		{"create table test (a int primary key) comment 'people'", "people", true},
+		{"create table test (a int primary key comment 'a') comment = 'it\\'s'", "it's", true},
+		{"create table test (a int primary key comment 'a', b varchar(10) comment 'b')", "", false},
+		{"alter table test comment=''", "", true},
+		{"alter table test comment = 1", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			comment, ok := tableCommentOption(test.query)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.expected, comment)
+		})
+	}
+}
+
+func TestTableCommentStatements(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+
+	ctx := context.Background()
+	root, _ := dEnv.WorkingRoot(ctx)
+
+	db := NewDatabase("dolt", dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
+	engine, sqlCtx, err := NewTestEngine(ctx, db, root)
+	require.NoError(t, err)
+	engine.AddDatabase(NewInformationSchemaDatabase(engine.Catalog))
+
+	executeTableCommentStatement(t, sqlCtx, engine, db, "create table test (a int primary key comment 'the key', b varchar(20)) comment='people'")
+
+	root, err = db.GetRoot(sqlCtx)
+	require.NoError(t, err)
+	tbl, ok, err := root.GetTable(ctx, "test")
+	require.NoError(t, err)
+	require.True(t, ok)
+	sch, err := tbl.GetSchema(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "people", sch.GetComment())
+	col, ok := sch.GetAllCols().GetByName("a")
+	require.True(t, ok)
+	assert.Equal(t, "the key", col.Comment)
+
+	rows := executeTableCommentStatement(t, sqlCtx, engine, db, "show create table TEST")
+	require.Len(t, rows, 1)
+	assert.Contains(t, rows[0][1], "COMMENT 'the key tag:")
+	assert.Regexp(t, ` COMMENT='people'$`, rows[0][1])
+
+	executeTableCommentStatement(t, sqlCtx, engine, db, "alter table test comment 'people''s names'")
+	executeTableCommentStatement(t, sqlCtx, engine, db, "create table if not exists test (a int primary key) comment 'ignored'")
+
+	_, iter, err := engine.Query(sqlCtx, "select table_comment from information_schema.tables where table_name = 'test'")
+	require.NoError(t, err)
+	rows, err = sql.RowIterToRows(iter)
+	require.NoError(t, err)
+	assert.Equal(t, []sql.Row{{"people's names"}}, rows)
+
+	_, iter, err = engine.Query(sqlCtx, "select column_name, column_comment from information_schema.columns where table_name = 'test' order by column_name")
+	require.NoError(t, err)
+	rows, err = sql.RowIterToRows(iter)
+	require.NoError(t, err)
+	assert.Equal(t, []sql.Row{{"a", "the key"}, {"b", ""}}, rows)
+
+	_, _, err = ExecuteTableCommentStatement(sqlCtx, engine, db, "alter table missing comment 'x'")
+	assert.True(t, sql.ErrTableNotFound.Is(err), "unexpected error %v", err)
+}
+
+func executeTableCommentStatement(t *testing.T, ctx *sql.Context, e *sqle.Engine, db Database, query string) []sql.Row {
+	_, iter, err := ExecuteTableCommentStatement(ctx, e, db, query)
+	require.NoError(t, err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(t, err)
+	return rows
+}
@@ -17,10 +17,14 @@ package sqle
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/src-d/go-mysql-server/sql"
 
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/set"
 	"github.com/liquidata-inc/dolt/go/store/hash"
 	"github.com/liquidata-inc/dolt/go/store/types"
 )
@@ -28,7 +32,9 @@ import (
 var ErrDuplicatePrimaryKeyFmt = "duplicate primary key given: (%v)"
 
 // tableEditor supports making multiple row edits (inserts, updates, deletes) to a table. It does error checking for key
-// collision etc. in the Close() method, as well as during Insert / Update.
+// collision etc. in the Close() method, as well as during Insert / Update. In batch mode, inserted keys are checked for
+// collisions with the table's rows as they're inserted instead, so that the statement inserting a duplicate key fails
+// rather than the flush of the batch.
 //
 // The tableEditor has two levels of batching: one supported at the SQL engine layer where a single UPDATE, DELETE or
 // INSERT statement will touch many rows, and we want to avoid unnecessary intermediate writes; and one at the dolt
@@ -38,12 +44,33 @@ var ErrDuplicatePrimaryKeyFmt = "duplicate primary key given: (%v)"
 // support REPLACE statements, which are implemented as a DELETE followed by an INSERT. In general, not flushing the
 // editor after every SQL statement is incorrect and will return incorrect results. The single reliable exception is an
 // unbroken chain of INSERT statements, where we have taken pains to batch writes to speed things up.
+//
+// BEFORE INSERT triggers on the table are applied to each row as it is inserted. AFTER INSERT triggers fire for the
+// inserted rows once the edits are flushed, and if any of them fail the edits of the statement are undone.
+//
+// When the table has row policies, the rows inserted and the new values of the rows updated must satisfy the session's
+// policies, as must the rows deleted, which REPLACE statements can delete without reading them first.
+//
+// Before its first edit, the editor checks that the permission policies of the database let the session write the
+// table on the database's branch.
 type tableEditor struct {
 	t            *WritableDoltTable
-	ed           *types.MapEditor
+	ed           *rowsEditor
 	insertedKeys map[hash.Hash]types.Value
 	addedKeys    map[hash.Hash]types.Value
 	removedKeys  map[hash.Hash]types.Value
+	// checkedKeys are the added keys which were checked for collisions as they were inserted, in batch mode
+	checkedKeys map[hash.Hash]struct{}
+
+	triggersLoaded bool
+	beforeInsert   []*Trigger
+	afterInsert    []*Trigger
+	insertedRows   []sql.Row
+
+	policiesLoaded bool
+	policyFilter   sql.Expression
+
+	permissionChecked bool
 }
 
 var _ sql.RowReplacer = (*tableEditor)(nil)
@@ -57,10 +84,33 @@ func newTableEditor(t *WritableDoltTable) *tableEditor {
 		insertedKeys: make(map[hash.Hash]types.Value),
 		addedKeys:    make(map[hash.Hash]types.Value),
 		removedKeys:  make(map[hash.Hash]types.Value),
+		checkedKeys:  make(map[hash.Hash]struct{}),
 	}
 }
 
 func (te *tableEditor) Insert(ctx *sql.Context, sqlRow sql.Row) error {
+	err := te.checkPermission(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = te.loadTriggers(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, tr := range te.beforeInsert {
+		sqlRow, err = tr.fireBefore(ctx, te.t.db, te.t.sqlSchema(), sqlRow)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = te.checkRowPolicies(ctx, sqlRow)
+	if err != nil {
+		return err
+	}
+
 	dRow, err := SqlRowToDoltRow(te.t.table.Format(), sqlRow, te.t.sch)
 	if err != nil {
 		return err
@@ -85,21 +135,163 @@ func (te *tableEditor) Insert(ctx *sql.Context, sqlRow sql.Row) error {
 		}
 		return fmt.Errorf(ErrDuplicatePrimaryKeyFmt, value)
 	}
+	if te.t.db.batchMode == batched {
+		if err := te.checkKeyCollision(ctx, hash, key); err != nil {
+			return err
+		}
+		te.checkedKeys[hash] = struct{}{}
+	}
 	te.insertedKeys[hash] = key
 	te.addedKeys[hash] = key
 
 	if te.ed == nil {
-		te.ed, err = te.t.newMapEditor(ctx)
+		te.ed, err = te.t.newRowsEditor(ctx)
 		if err != nil {
 			return err
 		}
 	}
 
-	te.ed = te.ed.Set(key, dRow.NomsMapValue(te.t.sch))
+	err = te.ed.Set(ctx, key, dRow.NomsMapValue(te.t.sch))
+	if err != nil {
+		return err
+	}
+
+	if len(te.afterInsert) > 0 {
+		te.insertedRows = append(te.insertedRows, sqlRow)
+	}
+
+	return nil
+}
+
+// loadTriggers loads the INSERT triggers defined on the table being edited.
+func (te *tableEditor) loadTriggers(ctx *sql.Context) error {
+	if te.triggersLoaded || doltdb.HasDoltPrefix(te.t.name) {
+		return nil
+	}
+
+	root, err := te.t.db.GetRoot(ctx)
+	if err != nil {
+		return err
+	}
+
+	triggers, err := te.t.db.getTriggers(ctx, root)
+	if err != nil {
+		return err
+	}
+
+	for _, tr := range triggers {
+		if !strings.EqualFold(tr.Table, te.t.name) || tr.Event != TriggerInsert {
+			continue
+		}
+
+		if tr.Timing == TriggerBefore {
+			te.beforeInsert = append(te.beforeInsert, tr)
+		} else {
+			te.afterInsert = append(te.afterInsert, tr)
+		}
+	}
+
+	te.triggersLoaded = true
 	return nil
 }
 
+// checkPermission returns an error if the permission policies of the database don't let the session write the table.
+func (te *tableEditor) checkPermission(ctx *sql.Context) error {
+	if te.permissionChecked {
+		return nil
+	}
+
+	root, err := te.t.db.GetRoot(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = te.t.db.checkWritePermission(ctx, root, te.t.name)
+	if err != nil {
+		return err
+	}
+
+	te.permissionChecked = true
+	return nil
+}
+
+// loadRowPolicies loads the filter which the rows written by the session must satisfy. Sessions limited by row policies
+// can't edit the row policies themselves.
+func (te *tableEditor) loadRowPolicies(ctx *sql.Context) error {
+	if te.policiesLoaded {
+		return nil
+	}
+
+	user, limited := rowPolicyUser(ctx)
+	if limited && strings.EqualFold(te.t.name, doltdb.RowPoliciesTableName) {
+		return ErrRowPolicyAccessDenied.New(user, "edit row policies")
+	}
+
+	if limited {
+		root, err := te.t.db.GetRoot(ctx)
+		if err != nil {
+			return err
+		}
+
+		te.policyFilter, err = rowPolicyFilter(ctx, root, te.t.name, schemaColumnResolver(te.t.sqlSchema(), nil))
+		if err != nil {
+			return err
+		}
+	}
+
+	te.policiesLoaded = true
+	return nil
+}
+
+// checkRowPolicies returns an error if the row given isn't permitted by the session's row policies.
+func (te *tableEditor) checkRowPolicies(ctx *sql.Context, sqlRow sql.Row) error {
+	err := te.loadRowPolicies(ctx)
+	if err != nil || te.policyFilter == nil {
+		return err
+	}
+
+	ok, err := sql.EvaluateCondition(ctx, te.policyFilter, sqlRow)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		user, _ := rowPolicyUser(ctx)
+		return ErrRowPolicyViolation.New(te.t.name, user)
+	}
+
+	return nil
+}
+
+// checkStoredRowPolicies returns an error if the row stored with the key given isn't permitted by the session's row
+// policies.
+func (te *tableEditor) checkStoredRowPolicies(ctx *sql.Context, key types.Value) error {
+	err := te.loadRowPolicies(ctx)
+	if err != nil || te.policyFilter == nil {
+		return err
+	}
+
+	stored, ok, err := te.t.table.GetRow(ctx, key.(types.Tuple), te.t.sch)
+	if err != nil {
+		return errhand.BuildDError("failed to read table").AddCause(err).Build()
+	}
+	if !ok {
+		return nil
+	}
+
+	storedRow, err := doltRowToSqlRow(stored, te.t.sch)
+	if err != nil {
+		return err
+	}
+
+	return te.checkRowPolicies(ctx, storedRow)
+}
+
 func (te *tableEditor) Delete(ctx *sql.Context, sqlRow sql.Row) error {
+	err := te.checkPermission(ctx)
+	if err != nil {
+		return err
+	}
+
 	dRow, err := SqlRowToDoltRow(te.t.table.Format(), sqlRow, te.t.sch)
 	if err != nil {
 		return err
@@ -114,30 +306,123 @@ func (te *tableEditor) Delete(ctx *sql.Context, sqlRow sql.Row) error {
 		return err
 	}
 
+	err = te.checkStoredRowPolicies(ctx, key)
+	if err != nil {
+		return err
+	}
+
 	delete(te.addedKeys, hash)
 	te.removedKeys[hash] = key
 
 	if te.ed == nil {
-		te.ed, err = te.t.newMapEditor(ctx)
+		te.ed, err = te.t.newRowsEditor(ctx)
 		if err != nil {
 			return err
 		}
 	}
 
-	te.ed = te.ed.Remove(key)
+	te.ed.Remove(key)
 	return nil
 }
 
-func (t *DoltTable) newMapEditor(ctx context.Context) (*types.MapEditor, error) {
-	typesMap, err := t.table.GetRowData(ctx)
+// rowsEditor edits the rows of a table. When the table has cold columns, it edits the map of their values alongside the
+// map of the rest of the row data.
+type rowsEditor struct {
+	nbf      *types.NomsBinFormat
+	hot      *types.MapEditor
+	cold     *types.MapEditor
+	coldTags *set.Uint64Set
+}
+
+func (t *DoltTable) newRowsEditor(ctx context.Context) (*rowsEditor, error) {
+	typesMap, err := t.table.GetHotRowData(ctx)
 	if err != nil {
 		return nil, errhand.BuildDError("failed to get row data.").AddCause(err).Build()
 	}
 
-	return typesMap.Edit(), nil
+	coldMap, hasCold, err := t.table.GetColdRowData(ctx)
+	if err != nil {
+		return nil, errhand.BuildDError("failed to get row data.").AddCause(err).Build()
+	}
+
+	ed := &rowsEditor{nbf: t.table.Format(), hot: typesMap.Edit()}
+	if hasCold {
+		ed.cold = coldMap.Edit()
+		ed.coldTags = set.NewUint64Set(schema.ColdColTags(t.sch))
+	}
+
+	return ed, nil
+}
+
+// Set sets the value of the row with the key given.
+func (ed *rowsEditor) Set(ctx context.Context, key types.Value, val types.Valuable) error {
+	if ed.cold == nil {
+		ed.hot.Set(key, val)
+		return nil
+	}
+
+	tuple, err := val.Value(ctx)
+	if err != nil {
+		return err
+	}
+
+	hot, cold, err := doltdb.SplitRowValue(ed.nbf, tuple.(types.Tuple), ed.coldTags)
+	if err != nil {
+		return err
+	}
+
+	ed.hot.Set(key, hot)
+	if cold.Len() > 0 {
+		ed.cold.Set(key, cold)
+	} else {
+		ed.cold.Remove(key)
+	}
+
+	return nil
+}
+
+// Remove removes the row with the key given.
+func (ed *rowsEditor) Remove(key types.Value) {
+	ed.hot.Remove(key)
+	if ed.cold != nil {
+		ed.cold.Remove(key)
+	}
+}
+
+// RemoveRange removes the rows with keys from |start|, inclusive, to |end|, exclusive, and returns the number removed.
+// A nil |start| or |end| leaves the range unbounded on that side.
+func (ed *rowsEditor) RemoveRange(ctx context.Context, start, end types.Value) (uint64, error) {
+	removed, err := ed.hot.RemoveRange(ctx, start, end)
+	if err != nil || ed.cold == nil {
+		return removed, err
+	}
+
+	_, err = ed.cold.RemoveRange(ctx, start, end)
+	return removed, err
+}
+
+// Maps returns the edited map of the rows, and the edited map of the values of their cold columns if the table has
+// cold columns.
+func (ed *rowsEditor) Maps(ctx context.Context) (types.Map, *types.Map, error) {
+	hot, err := ed.hot.Map(ctx)
+	if err != nil || ed.cold == nil {
+		return hot, nil, err
+	}
+
+	cold, err := ed.cold.Map(ctx)
+	if err != nil {
+		return types.EmptyMap, nil, err
+	}
+
+	return hot, &cold, nil
 }
 
 func (te *tableEditor) Update(ctx *sql.Context, oldRow sql.Row, newRow sql.Row) error {
+	err := te.checkPermission(ctx)
+	if err != nil {
+		return err
+	}
+
 	dOldRow, err := SqlRowToDoltRow(te.t.table.Format(), oldRow, te.t.sch)
 	if err != nil {
 		return err
@@ -147,6 +432,11 @@ func (te *tableEditor) Update(ctx *sql.Context, oldRow sql.Row, newRow sql.Row)
 		return err
 	}
 
+	err = te.checkRowPolicies(ctx, newRow)
+	if err != nil {
+		return err
+	}
+
 	// If the PK is changed then we need to delete the old value and insert the new one
 	dOldKey := dOldRow.NomsMapKey(te.t.sch)
 	dOldKeyVal, err := dOldKey.Value(ctx)
@@ -177,17 +467,17 @@ func (te *tableEditor) Update(ctx *sql.Context, oldRow sql.Row, newRow sql.Row)
 
 		te.addedKeys[newHash] = dNewKeyVal
 		te.removedKeys[oldHash] = dOldKeyVal
+		delete(te.checkedKeys, newHash)
 	}
 
 	if te.ed == nil {
-		te.ed, err = te.t.newMapEditor(ctx)
+		te.ed, err = te.t.newRowsEditor(ctx)
 		if err != nil {
 			return err
 		}
 	}
 
-	te.ed.Set(dNewKeyVal, dNewRow.NomsMapValue(te.t.sch))
-	return nil
+	return te.ed.Set(ctx, dNewKeyVal, dNewRow.NomsMapValue(te.t.sch))
 }
 
 // Close implements Closer
@@ -199,21 +489,36 @@ func (te *tableEditor) Close(ctx *sql.Context) error {
 	return te.flush(ctx)
 }
 
+// checkKeyCollision returns an error if |key|, with the hash |hash|, is the key of a row of the table which the editor
+// hasn't removed.
+func (te *tableEditor) checkKeyCollision(ctx *sql.Context, hash hash.Hash, key types.Value) error {
+	if _, ok := te.removedKeys[hash]; ok {
+		return nil
+	}
+
+	_, rowExists, err := te.t.table.GetRow(ctx, key.(types.Tuple), te.t.sch)
+	if err != nil {
+		return errhand.BuildDError("failed to read table").AddCause(err).Build()
+	}
+	if rowExists {
+		value, err := types.EncodedValue(ctx, key)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf(ErrDuplicatePrimaryKeyFmt, value)
+	}
+
+	return nil
+}
+
 func (te *tableEditor) flush(ctx *sql.Context) error {
-	// For all added keys, check for and report a collision
+	// For all added keys which weren't checked as they were inserted, check for and report a collision
 	for hash, addedKey := range te.addedKeys {
-		if _, ok := te.removedKeys[hash]; !ok {
-			_, rowExists, err := te.t.table.GetRow(ctx, addedKey.(types.Tuple), te.t.sch)
-			if err != nil {
-				return errhand.BuildDError("failed to read table").AddCause(err).Build()
-			}
-			if rowExists {
-				value, err := types.EncodedValue(ctx, addedKey)
-				if err != nil {
-					return err
-				}
-				return fmt.Errorf(ErrDuplicatePrimaryKeyFmt, value)
-			}
+		if _, ok := te.checkedKeys[hash]; ok {
+			continue
+		}
+		if err := te.checkKeyCollision(ctx, hash, addedKey); err != nil {
+			return err
 		}
 	}
 	// For all removed keys, remove the map entries that weren't added elsewhere by other updates
@@ -223,8 +528,42 @@ func (te *tableEditor) flush(ctx *sql.Context) error {
 		}
 	}
 
-	if te.ed != nil {
+	if te.ed == nil {
+		return nil
+	}
+
+	if len(te.insertedRows) == 0 {
 		return te.t.updateTable(ctx, te.ed)
 	}
+
+	root, err := te.t.db.GetRoot(ctx)
+	if err != nil {
+		return err
+	}
+
+	table := te.t.table
+	err = te.t.updateTable(ctx, te.ed)
+	if err != nil {
+		return err
+	}
+
+	rows := te.insertedRows
+	te.insertedRows = nil
+
+	for _, row := range rows {
+		for _, tr := range te.afterInsert {
+			err = tr.fireAfter(ctx, te.t.db, te.t.sqlSchema(), row)
+			if err != nil {
+				// Undo the edits of the statement, along with those of any triggers which already fired
+				te.t.table = table
+				if rollbackErr := te.t.db.SetRoot(ctx, root); rollbackErr != nil {
+					return rollbackErr
+				}
+
+				return err
+			}
+		}
+	}
+
 	return nil
 }
@@ -36,9 +36,41 @@ type DoltTable struct {
 	sch    schema.Schema
 	sqlSch sql.Schema
 	db     Database
+
+	// projectedCols are the names of the columns a query reads, or nil if it hasn't told us.
+	projectedCols []string
 }
 
 var _ sql.Table = (*DoltTable)(nil)
+var _ sql.ProjectedTable = (*DoltTable)(nil)
+
+// WithProjection implements sql.ProjectedTable. The table returned has the same schema and returns rows of the same
+// width, but the values of cold columns which aren't projected are null, and aren't read.
+func (t *DoltTable) WithProjection(colNames []string) sql.Table {
+	projected := *t
+	projected.projectedCols = colNames
+	return &projected
+}
+
+// Projection implements sql.ProjectedTable
+func (t *DoltTable) Projection() []string {
+	return t.projectedCols
+}
+
+// projectsColdCols returns whether the rows the table returns need the values of any of its cold columns.
+func (t *DoltTable) projectsColdCols() bool {
+	if t.projectedCols == nil {
+		return true
+	}
+
+	for _, name := range t.projectedCols {
+		if col, ok := t.sch.GetAllCols().GetByNameCaseInsensitive(name); !ok || col.Cold {
+			return true
+		}
+	}
+
+	return false
+}
 
 // Implements sql.IndexableTable
 func (t *DoltTable) WithIndexLookup(lookup sql.IndexLookup) sql.Table {
@@ -68,6 +100,16 @@ func (t *DoltTable) Name() string {
 	return t.name
 }
 
+// Comment returns the comment describing the table.
+func (t *DoltTable) Comment() string {
+	return t.sch.GetComment()
+}
+
+// doltSchema returns the dolt schema of the table.
+func (t *DoltTable) doltSchema() schema.Schema {
+	return t.sch
+}
+
 // Not sure what the purpose of this method is, so returning the name for now.
 func (t *DoltTable) String() string {
 	return t.name
@@ -99,9 +141,21 @@ func (t *DoltTable) Partitions(*sql.Context) (sql.PartitionIter, error) {
 	return &doltTablePartitionIter{}, nil
 }
 
-// Returns the table rows for the partition given (all rows of the table).
+// Returns the table rows for the partition given (all rows of the table which the session's row policies permit).
 func (t *DoltTable) PartitionRows(ctx *sql.Context, _ sql.Partition) (sql.RowIter, error) {
-	return newRowIterator(t, ctx)
+	filter, err := t.rowPolicyReadFilter(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	iter, err := newRowIterator(t, ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return withRowPolicyFilter(ctx, iter, filter), nil
 }
 
 // WritableDoltTable allows updating, deleting, and inserting new rows. It implements sql.UpdatableTable and friends.
@@ -189,19 +243,19 @@ func (p doltTablePartition) Key() []byte {
 	return []byte(partitionName)
 }
 
-func (t *DoltTable) updateTable(ctx *sql.Context, mapEditor *types.MapEditor) error {
+func (t *DoltTable) updateTable(ctx *sql.Context, rowsEditor *rowsEditor) error {
 	root, err := t.db.GetRoot(ctx)
 
 	if err != nil {
 		return err
 	}
 
-	updated, err := mapEditor.Map(ctx)
+	updated, updatedCold, err := rowsEditor.Maps(ctx)
 	if err != nil {
 		return errhand.BuildDError("failed to modify table").AddCause(err).Build()
 	}
 
-	newTable, err := t.table.UpdateRows(ctx, updated)
+	newTable, err := t.table.UpdateHotAndColdRows(ctx, updated, updatedCold)
 	if err != nil {
 		return errhand.BuildDError("failed to update rows").AddCause(err).Build()
 	}
@@ -230,6 +284,10 @@ func (t *AlterableDoltTable) AddColumn(ctx *sql.Context, column *sql.Column, ord
 		return err
 	}
 
+	if err := t.db.checkWritePermission(ctx, root, t.name); err != nil {
+		return err
+	}
+
 	table, _, err := root.GetTable(ctx, t.name)
 	if err != nil {
 		return err
@@ -259,6 +317,15 @@ func (t *AlterableDoltTable) AddColumn(ctx *sql.Context, column *sql.Column, ord
 		return errors.New("adding primary keys is not supported")
 	}
 
+	sch, err := table.GetSchema(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := doltdb.ValidateNewColumnName(sch, col.Tag, col.Name); err != nil {
+		return err
+	}
+
 	nullable := alterschema.NotNull
 	if col.IsNullable() {
 		nullable = alterschema.Null
@@ -272,7 +339,7 @@ func (t *AlterableDoltTable) AddColumn(ctx *sql.Context, column *sql.Column, ord
 		}
 	}
 
-	updatedTable, err := alterschema.AddColumnToTable(ctx, root, table, t.name, col.Tag, col.Name, col.TypeInfo, nullable, defaultVal, orderToOrder(order))
+	updatedTable, err := alterschema.AddColumnToTable(ctx, root, table, t.name, col.Tag, col.Name, col.TypeInfo, nullable, defaultVal, col.Comment, orderToOrder(order))
 	if err != nil {
 		return err
 	}
@@ -302,6 +369,10 @@ func (t *AlterableDoltTable) DropColumn(ctx *sql.Context, columnName string) err
 		return err
 	}
 
+	if err := t.db.checkWritePermission(ctx, root, t.name); err != nil {
+		return err
+	}
+
 	table, _, err := root.GetTable(ctx, t.name)
 	if err != nil {
 		return err
@@ -328,6 +399,10 @@ func (t *AlterableDoltTable) ModifyColumn(ctx *sql.Context, columnName string, c
 		return err
 	}
 
+	if err := t.db.checkWritePermission(ctx, root, t.name); err != nil {
+		return err
+	}
+
 	table, _, err := root.GetTable(ctx, t.name)
 	if err != nil {
 		return err
@@ -353,6 +428,15 @@ func (t *AlterableDoltTable) ModifyColumn(ctx *sql.Context, columnName string, c
 		return err
 	}
 
+	// column definitions don't say whether the column is cold, so it stays as it was
+	col.Cold = existingCol.Cold
+
+	if col.Name != existingCol.Name {
+		if err := doltdb.ValidateNewColumnName(sch, existingCol.Tag, col.Name); err != nil {
+			return err
+		}
+	}
+
 	var defVal types.Value
 	if column.Default != nil {
 		defVal, err = col.TypeInfo.ConvertValueToNomsValue(column.Default)
@@ -44,6 +44,20 @@ func ExecuteSql(dEnv *env.DoltEnv, root *doltdb.RootValue, statements string) (*
 			continue
 		}
 
+		if IsTriggerStatement(query) {
+			_, rowIter, err := ExecuteTriggerStatement(ctx, db, query)
+			if err == nil {
+				err = drainIter(rowIter)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if err = db.Flush(ctx); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
 		sqlStatement, err := sqlparser.Parse(query)
 		if err != nil {
 			return nil, err
@@ -100,7 +114,7 @@ func NewTestSQLCtx(ctx context.Context) *sql.Context {
 
 // NewTestEngine creates a new default engine, and a *sql.Context and initializes indexes and schema fragments.
 func NewTestEngine(ctx context.Context, db Database, root *doltdb.RootValue) (*sqle.Engine, *sql.Context, error) {
-	engine := sqle.NewDefault()
+	engine := NewEngine()
 	engine.AddDatabase(db)
 
 	sqlCtx := NewTestSQLCtx(ctx)
@@ -0,0 +1,653 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"gopkg.in/src-d/go-errors.v1"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/merge"
+	"github.com/liquidata-inc/dolt/go/store/atomicerr"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// The number of times a session tries to commit to a working set without holding its lock, merging again each time
+// another session commits first. The final attempt holds the lock while merging, so that it can't be beaten.
+const maxOptimisticCommits = 3
+
+// ErrTransactionConflict is returned when a transaction can't be committed because another session committed changes
+// which conflict with it after it began.
+var ErrTransactionConflict = errors.NewKind("Serialization failure: %s. Try restarting the transaction")
+
+var beginRegex = regexp.MustCompile(`(?is)^\s*(begin(\s+work)?|start\s+transaction(\s+with\s+consistent\s+snapshot)?)[\s;]*$`)
+var commitRegex = regexp.MustCompile(`(?is)^\s*commit(\s+work)?[\s;]*$`)
+var rollbackRegex = regexp.MustCompile(`(?is)^\s*rollback(\s+work)?[\s;]*$`)
+
+// savepointNamePattern matches the name of a savepoint, which may be quoted with backticks.
+const savepointNamePattern = "([a-z0-9_$]+|`[^`]+`)"
+
+var savepointRegex = regexp.MustCompile(`(?is)^\s*savepoint\s+` + savepointNamePattern + `[\s;]*$`)
+var rollbackToRegex = regexp.MustCompile(`(?is)^\s*rollback(\s+work)?\s+to\s+(savepoint\s+)?` + savepointNamePattern + `[\s;]*$`)
+var releaseSavepointRegex = regexp.MustCompile(`(?is)^\s*release\s+savepoint\s+` + savepointNamePattern + `[\s;]*$`)
+
+// ErrSavepointDoesNotExist is returned by ROLLBACK TO and RELEASE SAVEPOINT statements naming a savepoint which
+// wasn't set in the open transaction.
+var ErrSavepointDoesNotExist = errors.NewKind("SAVEPOINT %s does not exist")
+
+// IsTransactionStatement returns whether the query given is a BEGIN, START TRANSACTION, COMMIT, ROLLBACK, SAVEPOINT,
+// ROLLBACK TO or RELEASE SAVEPOINT statement. The engine treats these statements as no-ops, so integrators must check
+// for them before running a query and run them with ExecuteTransactionStatement.
+func IsTransactionStatement(query string) bool {
+	return beginRegex.MatchString(query) || commitRegex.MatchString(query) || rollbackRegex.MatchString(query) ||
+		savepointRegex.MatchString(query) || rollbackToRegex.MatchString(query) || releaseSavepointRegex.MatchString(query)
+}
+
+// ExecuteTransactionStatement executes a transaction statement, as identified by IsTransactionStatement, in the
+// session of the context given.
+func ExecuteTransactionStatement(ctx *sql.Context, query string) error {
+	sess := DSessFromSess(ctx.Session)
+
+	switch {
+	case beginRegex.MatchString(query):
+		return sess.BeginTransaction(ctx)
+	case commitRegex.MatchString(query):
+		return sess.EndTransaction(ctx, true)
+	case rollbackRegex.MatchString(query):
+		return sess.EndTransaction(ctx, false)
+	}
+
+	if m := savepointRegex.FindStringSubmatch(query); m != nil {
+		return sess.CreateSavepoint(ctx, savepointName(m[1]))
+	} else if m := rollbackToRegex.FindStringSubmatch(query); m != nil {
+		return sess.RollbackToSavepoint(ctx, savepointName(m[3]))
+	} else if m := releaseSavepointRegex.FindStringSubmatch(query); m != nil {
+		return sess.ReleaseSavepoint(savepointName(m[1]))
+	}
+
+	return nil
+}
+
+func savepointName(name string) string {
+	return strings.Trim(name, "`")
+}
+
+// InTransaction returns whether the session has a transaction open which was begun with BeginTransaction.
+func (sess *DoltSession) InTransaction() bool {
+	return sess.txRoots != nil
+}
+
+// BeginTransaction begins a transaction. As in MySQL, the changes the session has made which haven't been committed
+// are committed first. The session's root of each database is then pinned to the current working root of the database,
+// so statements in the transaction read a snapshot which includes the transaction's own writes but not changes
+// committed by other sessions. The writes are kept in the session until the transaction is ended with EndTransaction.
+func (sess *DoltSession) BeginTransaction(ctx *sql.Context) error {
+	sess.txRoots = nil
+	sess.savepoints = nil
+	err := sess.CommitWorkingSets(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	sess.txRoots = make(map[string]dbRoot, len(sess.dbRoots))
+	for dbName, dbRoot := range sess.dbRoots {
+		sess.txRoots[dbName] = dbRoot
+	}
+
+	return nil
+}
+
+// EndTransaction ends the open transaction, or the implicit transaction of a session which doesn't autocommit. When
+// |commit| is true the changes made in the transaction are committed to the working set of each database, merged with
+// any changes committed by other sessions since the transaction began. Each database is committed separately. If
+// another session committed changes to the same rows, or changes to the same table's schema which can't be merged, an
+// ErrTransactionConflict is returned and the transaction's changes to that database are discarded. When |commit| is
+// false all of the transaction's changes are discarded.
+func (sess *DoltSession) EndTransaction(ctx *sql.Context, commit bool) error {
+	txRoots := sess.txRoots
+	sess.txRoots = nil
+	sess.savepoints = nil
+
+	if commit {
+		return sess.CommitWorkingSets(ctx)
+	}
+
+	for dbName := range sess.dbRoots {
+		var root *doltdb.RootValue
+		if persistedHash, ok := sess.persistedHashes[dbName]; ok {
+			var err error
+			root, err = sess.dbDatas[dbName].ddb.ReadRootValue(ctx, hash.Parse(persistedHash))
+
+			if err != nil {
+				return err
+			}
+		} else if txRoot, ok := txRoots[dbName]; ok {
+			root = txRoot.root
+		} else {
+			continue
+		}
+
+		// tables cached for the root the transaction began with may have been edited in batch mode since
+		sess.dbDatas[dbName].tc.Remove(root)
+		err := sess.setRoot(ctx, dbName, root)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// savepoint is a snapshot of the session's roots which the open transaction can be rolled back to.
+type savepoint struct {
+	name  string
+	roots map[string]dbRoot
+}
+
+// findSavepoint returns the index of the savepoint named |name|, or -1 if there isn't one. As in MySQL, savepoint
+// names aren't case sensitive.
+func (sess *DoltSession) findSavepoint(name string) int {
+	for i, sp := range sess.savepoints {
+		if strings.EqualFold(sp.name, name) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// CreateSavepoint sets a savepoint named |name| in the open transaction, recording the session's root of each
+// database so that the transaction can be rolled back to it with RollbackToSavepoint. A savepoint with the same name
+// is replaced. As in MySQL with autocommit on, savepoints set outside of a transaction are discarded at once, as
+// there's nothing to roll back.
+func (sess *DoltSession) CreateSavepoint(ctx *sql.Context, name string) error {
+	if !sess.InTransaction() {
+		return nil
+	}
+
+	if i := sess.findSavepoint(name); i >= 0 {
+		sess.savepoints = append(sess.savepoints[:i], sess.savepoints[i+1:]...)
+	}
+
+	roots := make(map[string]dbRoot, len(sess.dbRoots))
+	for dbName, dbRoot := range sess.dbRoots {
+		roots[dbName] = dbRoot
+	}
+
+	sess.savepoints = append(sess.savepoints, savepoint{name, roots})
+	return nil
+}
+
+// RollbackToSavepoint discards the changes the open transaction made after the savepoint named |name| was set, setting
+// the session's root of each database back to its root at the savepoint. The savepoint is kept, and the savepoints set
+// after it are released. The transaction stays open, and is committed or rolled back as before by EndTransaction.
+func (sess *DoltSession) RollbackToSavepoint(ctx *sql.Context, name string) error {
+	i := sess.findSavepoint(name)
+
+	if i < 0 {
+		return ErrSavepointDoesNotExist.New(name)
+	}
+
+	sp := sess.savepoints[i]
+	sess.savepoints = sess.savepoints[:i+1]
+
+	for dbName, spRoot := range sp.roots {
+		if _, ok := sess.dbRoots[dbName]; !ok {
+			continue
+		}
+
+		// tables cached for the savepoint's root may have been edited in batch mode since
+		sess.dbDatas[dbName].tc.Remove(spRoot.root)
+		err := sess.setRoot(ctx, dbName, spRoot.root)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReleaseSavepoint releases the savepoint named |name| and the savepoints set after it, without changing the session's
+// roots.
+func (sess *DoltSession) ReleaseSavepoint(name string) error {
+	i := sess.findSavepoint(name)
+
+	if i < 0 {
+		return ErrSavepointDoesNotExist.New(name)
+	}
+
+	sess.savepoints = sess.savepoints[:i]
+	return nil
+}
+
+// CommitWorkingSets commits the session's root of every database which was read from the repo state. The roots of
+// databases which the session hasn't changed are updated to the current working roots.
+func (sess *DoltSession) CommitWorkingSets(ctx context.Context) error {
+	dbNames := make([]string, 0, len(sess.persistedHashes))
+	for dbName := range sess.persistedHashes {
+		if _, ok := sess.dbRoots[dbName]; ok {
+			dbNames = append(dbNames, dbName)
+		}
+	}
+
+	sort.Strings(dbNames)
+
+	for _, dbName := range dbNames {
+		err := sess.commitWorkingSet(ctx, dbName)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// commitWorkingSet commits the session's root of the database given to its working set. The session's root was derived
+// from the working root as of when it was last read or written by the session, so if another session has committed to
+// the working set since, the changes made on both sides are merged. The working set is only updated if no other session
+// committed to it during the merge, otherwise the merge is retried. On success the session's root becomes the new
+// working root, and on a conflict the session's changes are discarded and its root becomes the current working root.
+func (sess *DoltSession) commitWorkingSet(ctx context.Context, dbName string) error {
+	dbd := sess.dbDatas[dbName]
+	base := hash.Parse(sess.persistedHashes[dbName])
+	root := sess.dbRoots[dbName].root
+
+	h := base
+	if sess.dbRoots[dbName].hashStr != base.String() {
+		var err error
+		h, err = dbd.ddb.WriteRootValue(ctx, root)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		locked := attempt >= maxOptimisticCommits
+
+		if locked {
+			dbd.wsMu.Lock()
+		}
+
+		newRoot, newHash, ok, err := dbd.mergeWorkingSet(ctx, base, h, root, locked)
+
+		if locked {
+			dbd.wsMu.Unlock()
+		}
+
+		if ErrTransactionConflict.Is(err) {
+			return sess.discardWorkingSetChanges(ctx, dbName, err)
+		} else if err != nil {
+			return err
+		}
+
+		if ok {
+			sess.persistedHashes[dbName] = newHash.String()
+			return sess.setRoot(ctx, dbName, newRoot)
+		}
+	}
+}
+
+// discardWorkingSetChanges resets the session's root of the database given to the current working root after its
+// changes couldn't be committed, returning |cause|.
+func (sess *DoltSession) discardWorkingSetChanges(ctx context.Context, dbName string, cause error) error {
+	dbd := sess.dbDatas[dbName]
+	current := dbd.workingHash(false)
+	root, err := dbd.ddb.ReadRootValue(ctx, current)
+
+	if err != nil {
+		return err
+	}
+
+	sess.persistedHashes[dbName] = current.String()
+	err = sess.setRoot(ctx, dbName, root)
+
+	if err != nil {
+		return err
+	}
+
+	return cause
+}
+
+// setRoot sets the session's root of the database given, evicting the tables cached for the root it replaces.
+func (sess *DoltSession) setRoot(ctx context.Context, dbName string, root *doltdb.RootValue) error {
+	h, err := root.HashOf()
+
+	if err != nil {
+		return err
+	}
+
+	hashStr := h.String()
+	err = sess.Session.Set(ctx, dbName+WorkingKeySuffix, hashType, hashStr)
+
+	if err != nil {
+		return err
+	}
+
+	if prev, ok := sess.dbRoots[dbName]; ok && prev.root != root {
+		sess.dbDatas[dbName].tc.Remove(prev.root)
+	}
+
+	sess.dbRoots[dbName] = dbRoot{hashStr, root}
+	return nil
+}
+
+// mergeWorkingSet commits |root|, whose hash is |h|, to the working set of the database. |base| is the hash of the
+// working root which |root| was derived from, and if the working set has been changed since then the changes are
+// merged. It returns the new working root and its hash, or false if another session committed to the working set
+// during the merge, in which case nothing is changed. |locked| is whether the caller holds the working set's lock.
+func (dbd dbData) mergeWorkingSet(ctx context.Context, base, h hash.Hash, root *doltdb.RootValue, locked bool) (*doltdb.RootValue, hash.Hash, bool, error) {
+	current := dbd.workingHash(locked)
+
+	if current == base && h == base {
+		return root, h, true, nil
+	}
+
+	if h == base {
+		// there's nothing to merge, the session just needs the changes made by others
+		currentRoot, err := dbd.ddb.ReadRootValue(ctx, current)
+
+		if err != nil {
+			return nil, hash.Hash{}, false, err
+		}
+
+		return currentRoot, current, true, nil
+	}
+
+	merged, mergedHash := root, h
+	if current != base {
+		currentRoot, err := dbd.ddb.ReadRootValue(ctx, current)
+
+		if err != nil {
+			return nil, hash.Hash{}, false, err
+		}
+
+		baseRoot, err := dbd.ddb.ReadRootValue(ctx, base)
+
+		if err != nil {
+			return nil, hash.Hash{}, false, err
+		}
+
+		merged, err = mergeTransactionRoots(ctx, dbd.ddb, currentRoot, root, baseRoot)
+
+		if err != nil {
+			return nil, hash.Hash{}, false, err
+		}
+
+		mergedHash, err = dbd.ddb.WriteRootValue(ctx, merged)
+
+		if err != nil {
+			return nil, hash.Hash{}, false, err
+		}
+	}
+
+	ok, err := dbd.compareAndSetWorkingHash(ctx, current, mergedHash, locked)
+
+	if err != nil || !ok {
+		return nil, hash.Hash{}, false, err
+	}
+
+	return merged, mergedHash, true, nil
+}
+
+// workingHash returns the hash of the working root of the database. |locked| is whether the caller holds the working
+// set's lock.
+func (dbd dbData) workingHash(locked bool) hash.Hash {
+	if !locked {
+		dbd.wsMu.Lock()
+		defer dbd.wsMu.Unlock()
+	}
+
+	return dbd.rsr.WorkingHash()
+}
+
+// compareAndSetWorkingHash sets the working hash of the database to |h| if it's still |expected|, returning whether it
+// was set. |locked| is whether the caller holds the working set's lock.
+func (dbd dbData) compareAndSetWorkingHash(ctx context.Context, expected, h hash.Hash, locked bool) (bool, error) {
+	if !locked {
+		dbd.wsMu.Lock()
+		defer dbd.wsMu.Unlock()
+	}
+
+	if dbd.rsr.WorkingHash() != expected {
+		return false, nil
+	}
+
+	return true, dbd.rsw.SetWorkingHash(ctx, h)
+}
+
+// mergeTransactionRoots merges the changes made by a transaction since |baseRoot| into |workingRoot|. As the
+// transaction read a snapshot, it returns an ErrTransactionConflict if the transaction wrote any of the rows written by
+// the changes committed since |baseRoot|, even if both made the same change, as otherwise one of two concurrent
+// updates computed from the same snapshot would be lost.
+func mergeTransactionRoots(ctx context.Context, ddb *doltdb.DoltDB, workingRoot, txRoot, baseRoot *doltdb.RootValue) (*doltdb.RootValue, error) {
+	conflicted, err := writeConflicts(ctx, workingRoot, txRoot, baseRoot)
+
+	if err != nil {
+		return nil, err
+	}
+
+	merged, tblToStats, err := merge.MergeRoots(ctx, workingRoot, txRoot, baseRoot, ddb.ValueReadWriter())
+
+	if err == merge.ErrSameTblAddedTwice {
+		return nil, ErrTransactionConflict.New("a table created by the transaction was also created by another transaction")
+	} else if err == merge.ErrCommentConflict {
+		return nil, ErrTransactionConflict.New("a table comment changed by the transaction was also changed by another transaction")
+	} else if err != nil && len(conflicted) == 0 {
+		return nil, err
+	}
+
+	for tblName, stats := range tblToStats {
+		if stats.Conflicts > 0 && !containsString(conflicted, tblName) {
+			conflicted = append(conflicted, tblName)
+		}
+	}
+
+	if len(conflicted) > 0 {
+		sort.Strings(conflicted)
+		return nil, ErrTransactionConflict.New("rows of " + strings.Join(conflicted, ", ") + " written by the transaction were also written by another transaction")
+	}
+
+	return merged, nil
+}
+
+// writeConflicts returns the names of the tables which were changed both in |workingRoot| and in |txRoot| since
+// |baseRoot|, where the same rows were written in both or the table was created, or dropped and changed.
+func writeConflicts(ctx context.Context, workingRoot, txRoot, baseRoot *doltdb.RootValue) ([]string, error) {
+	tblNames, err := doltdb.UnionTableNames(ctx, workingRoot, txRoot, baseRoot)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicted []string
+	for _, tblName := range tblNames {
+		baseTbl, baseOk, err := baseRoot.GetTable(ctx, tblName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		txTbl, txOk, err := txRoot.GetTable(ctx, tblName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		workingTbl, workingOk, err := workingRoot.GetTable(ctx, tblName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		txChanged, err := tableChanged(baseTbl, baseOk, txTbl, txOk)
+
+		if err != nil {
+			return nil, err
+		}
+
+		workingChanged, err := tableChanged(baseTbl, baseOk, workingTbl, workingOk)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !txChanged || !workingChanged {
+			continue
+		}
+
+		if !baseOk || !txOk || !workingOk {
+			// a table dropped on both sides isn't a conflict
+			if txOk || workingOk {
+				conflicted = append(conflicted, tblName)
+			}
+
+			continue
+		}
+
+		overlap, err := rowWritesOverlap(ctx, baseTbl, txTbl, workingTbl)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if overlap {
+			conflicted = append(conflicted, tblName)
+		}
+	}
+
+	return conflicted, nil
+}
+
+func tableChanged(tbl *doltdb.Table, ok bool, otherTbl *doltdb.Table, otherOk bool) (bool, error) {
+	if ok != otherOk {
+		return true, nil
+	} else if !ok {
+		return false, nil
+	}
+
+	h, err := tbl.HashOf()
+
+	if err != nil {
+		return false, err
+	}
+
+	otherH, err := otherTbl.HashOf()
+
+	if err != nil {
+		return false, err
+	}
+
+	return h != otherH, nil
+}
+
+// rowWritesOverlap returns whether any row of |baseTbl| was written in both |tbl| and |otherTbl|.
+func rowWritesOverlap(ctx context.Context, baseTbl, tbl, otherTbl *doltdb.Table) (bool, error) {
+	baseRows, err := baseTbl.GetRowData(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := tbl.GetRowData(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	otherRows, err := otherTbl.GetRowData(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	keys, err := changedRowKeys(ctx, rows, baseRows)
+
+	if err != nil {
+		return false, err
+	}
+
+	otherKeys, err := changedRowKeys(ctx, otherRows, baseRows)
+
+	if err != nil {
+		return false, err
+	}
+
+	if len(otherKeys) < len(keys) {
+		keys, otherKeys = otherKeys, keys
+	}
+
+	for h := range keys {
+		if _, ok := otherKeys[h]; ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// changedRowKeys returns the hashes of the keys of the rows which were added, changed or removed in |rows| since
+// |baseRows|.
+func changedRowKeys(ctx context.Context, rows, baseRows types.Map) (map[hash.Hash]struct{}, error) {
+	ae := atomicerr.New()
+	changes := make(chan types.ValueChanged, 32)
+	stop := make(chan struct{})
+
+	go func() {
+		defer close(changes)
+		rows.Diff(ctx, baseRows, ae, changes, stop)
+	}()
+
+	keys := make(map[hash.Hash]struct{})
+	for change := range changes {
+		h, err := change.Key.Hash(rows.Format())
+
+		if ae.SetIfErrAndCheck(err) {
+			close(stop)
+			for range changes {
+			}
+
+			break
+		}
+
+		keys[h] = struct{}{}
+	}
+
+	if err := ae.Get(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,268 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"testing"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	. "github.com/liquidata-inc/dolt/go/libraries/doltcore/sql/sqltestutil"
+)
+
+func TestIsTransactionStatement(t *testing.T) {
+	for _, query := range []string{"begin", "BEGIN WORK;", "start transaction", "START TRANSACTION WITH CONSISTENT SNAPSHOT", " commit ", "commit work", "rollback;", "savepoint a", "ROLLBACK TO SAVEPOINT a;", "rollback work to `my point`", "release savepoint a"} {
+		assert.True(t, IsTransactionStatement(query), query)
+	}
+
+	for _, query := range []string{"select 1", "begin transaction now", "rollback to", "savepoint a b", "release a", "commit and chain", "create table commit (a int)"} {
+		assert.False(t, IsTransactionStatement(query), query)
+	}
+}
+
+// transactionTest is a database served to several sessions which read their roots from the repo state, as in the SQL
+// server.
+type transactionTest struct {
+	t      *testing.T
+	dEnv   *env.DoltEnv
+	db     Database
+	engine *sqle.Engine
+}
+
+func newTransactionTest(t *testing.T) *transactionTest {
+	dEnv := dtestutils.CreateTestEnv()
+	CreateTestDatabase(dEnv, t)
+
+	db := NewDatabase("dolt", dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
+	engine := NewEngine()
+	engine.AddDatabase(db)
+
+	return &transactionTest{t, dEnv, db, engine}
+}
+
+func (tt *transactionTest) newSession() *sql.Context {
+	ctx := NewTestSQLCtx(context.Background())
+	require.NoError(tt.t, DSessFromSess(ctx.Session).AddDB(ctx, tt.db))
+	ctx.SetCurrentDatabase(tt.db.Name())
+	require.NoError(tt.t, tt.db.LoadRootFromRepoState(ctx))
+
+	return ctx
+}
+
+// exec runs the query given in the session of |ctx|, committing after writes as the SQL server does when autocommit is
+// on.
+func (tt *transactionTest) exec(ctx *sql.Context, query string) ([]sql.Row, error) {
+	if IsTransactionStatement(query) {
+		return nil, ExecuteTransactionStatement(ctx, query)
+	}
+
+	_, iter, err := tt.engine.Query(ctx, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := sql.RowIterToRows(iter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, ctx.Session.CommitTransaction(ctx)
+}
+
+func (tt *transactionTest) mustExec(ctx *sql.Context, queries ...string) []sql.Row {
+	var rows []sql.Row
+	for _, query := range queries {
+		var err error
+		rows, err = tt.exec(ctx, query)
+		require.NoError(tt.t, err, query)
+	}
+
+	return rows
+}
+
+// workingRows returns the rows of the query given against the working root of the repo state.
+func (tt *transactionTest) workingRows(query string) []sql.Row {
+	ctx := tt.newSession()
+	return tt.mustExec(ctx, query)
+}
+
+func TestTransactionsAreIsolated(t *testing.T) {
+	tt := newTransactionTest(t)
+	s1, s2 := tt.newSession(), tt.newSession()
+	query := "select id from people where id >= 100 order by id"
+
+	tt.mustExec(s1, "begin", `insert into people (id, first_name, last_name) values (100, "Bart", "Simpson")`)
+	tt.mustExec(s2, "start transaction", `insert into people (id, first_name, last_name) values (101, "Lisa", "Simpson")`)
+	assert.Equal(t, []sql.Row{{int64(100)}}, tt.mustExec(s1, query))
+	assert.Empty(t, tt.workingRows(query))
+
+	tt.mustExec(s1, "commit")
+	assert.Equal(t, []sql.Row{{int64(100)}}, tt.workingRows(query))
+	assert.Equal(t, []sql.Row{{int64(101)}}, tt.mustExec(s2, query))
+
+	// the transactions wrote different rows, so they're merged
+	tt.mustExec(s2, "commit")
+	assert.Equal(t, []sql.Row{{int64(100)}, {int64(101)}}, tt.workingRows(query))
+	assert.Equal(t, []sql.Row{{int64(100)}, {int64(101)}}, tt.mustExec(s2, query))
+	assert.False(t, DSessFromSess(s2.Session).InTransaction())
+}
+
+func TestTransactionConflict(t *testing.T) {
+	tt := newTransactionTest(t)
+	s1, s2 := tt.newSession(), tt.newSession()
+	query := "select age from people where id = 0"
+	before := tt.workingRows(query)
+
+	// both transactions increment the same row from the same snapshot, so one of the increments would be lost
+	tt.mustExec(s1, "begin", "update people set age = age + 1 where id = 0")
+	tt.mustExec(s2, "begin", "update people set age = age + 1 where id = 0", `insert into people (id, first_name, last_name) values (101, "Lisa", "Simpson")`)
+	tt.mustExec(s1, "commit")
+
+	_, err := tt.exec(s2, "commit")
+	require.Error(t, err)
+	assert.True(t, ErrTransactionConflict.Is(err))
+
+	after := tt.workingRows(query)
+	assert.Equal(t, before[0][0].(int64)+1, after[0][0])
+	assert.Empty(t, tt.workingRows("select * from people where id = 101"))
+
+	// the conflicting transaction is discarded, and the session reads the working root
+	assert.False(t, DSessFromSess(s2.Session).InTransaction())
+	assert.Equal(t, after, tt.mustExec(s2, query))
+	tt.mustExec(s2, "begin", "update people set age = age + 1 where id = 0", "commit")
+	assert.Equal(t, before[0][0].(int64)+2, tt.workingRows(query)[0][0])
+}
+
+func TestTransactionRollback(t *testing.T) {
+	tt := newTransactionTest(t)
+	s1 := tt.newSession()
+	workingHash := tt.dEnv.RepoState.WorkingHash()
+
+	tt.mustExec(s1, "begin", `insert into people (id, first_name, last_name) values (100, "Bart", "Simpson")`, "delete from people where id = 0")
+	assert.Len(t, tt.mustExec(s1, "select * from people where id = 100"), 1)
+	tt.mustExec(s1, "rollback")
+
+	assert.Empty(t, tt.mustExec(s1, "select * from people where id = 100"))
+	assert.Len(t, tt.mustExec(s1, "select * from people where id = 0"), 1)
+	assert.Equal(t, workingHash, tt.dEnv.RepoState.WorkingHash())
+}
+
+func TestAutocommitMergesConcurrentWrites(t *testing.T) {
+	tt := newTransactionTest(t)
+	s1, s2 := tt.newSession(), tt.newSession()
+
+	// both sessions read the working root before either wrote, and neither write is lost
+	tt.mustExec(s1, `insert into people (id, first_name, last_name) values (100, "Bart", "Simpson")`)
+	tt.mustExec(s2, `insert into people (id, first_name, last_name) values (101, "Lisa", "Simpson")`)
+	assert.Equal(t, []sql.Row{{int64(100)}, {int64(101)}}, tt.workingRows("select id from people where id >= 100 order by id"))
+
+	// within a transaction, writes aren't committed until the transaction is
+	tt.mustExec(s1, "begin", "delete from people where id = 100")
+	assert.Len(t, tt.workingRows("select * from people where id = 100"), 1)
+	tt.mustExec(s1, "commit")
+	assert.Empty(t, tt.workingRows("select * from people where id = 100"))
+}
+
+func TestSavepoints(t *testing.T) {
+	tt := newTransactionTest(t)
+	s1 := tt.newSession()
+	workingHash := tt.dEnv.RepoState.WorkingHash()
+	people := "select id from people where id >= 100 order by id"
+	episodes := "select id from episodes where id >= 100 order by id"
+
+	tt.mustExec(s1,
+		"begin",
+		`insert into people (id, first_name, last_name) values (100, "Bart", "Simpson")`,
+		"savepoint a",
+		`insert into episodes (id, name) values (100, "Bart the Genius")`,
+		`insert into people (id, first_name, last_name) values (101, "Lisa", "Simpson")`,
+		"SAVEPOINT b",
+		"delete from people where id = 100",
+		`insert into episodes (id, name) values (101, "Lisa's Substitute")`,
+	)
+	assert.Equal(t, []sql.Row{{int64(101)}}, tt.mustExec(s1, people))
+	assert.Equal(t, []sql.Row{{int64(100)}, {int64(101)}}, tt.mustExec(s1, episodes))
+
+	// rolling back to b undoes the edits to both tables since, and keeps b
+	tt.mustExec(s1, "rollback to savepoint b")
+	assert.Equal(t, []sql.Row{{int64(100)}, {int64(101)}}, tt.mustExec(s1, people))
+	assert.Equal(t, []sql.Row{{int64(100)}}, tt.mustExec(s1, episodes))
+
+	tt.mustExec(s1, `insert into episodes (id, name) values (102, "Homer's Odyssey")`, "rollback to b")
+	assert.Equal(t, []sql.Row{{int64(100)}}, tt.mustExec(s1, episodes))
+
+	// rolling back to a releases b, and the savepoints set after a are gone
+	tt.mustExec(s1, "rollback work to savepoint `A`")
+	assert.Equal(t, []sql.Row{{int64(100)}}, tt.mustExec(s1, people))
+	assert.Empty(t, tt.mustExec(s1, episodes))
+	_, err := tt.exec(s1, "rollback to savepoint b")
+	assert.True(t, ErrSavepointDoesNotExist.Is(err), "unexpected error %v", err)
+
+	// a savepoint set again with the same name is moved, and released savepoints can't be rolled back to
+	tt.mustExec(s1,
+		`insert into people (id, first_name, last_name) values (102, "Maggie", "Simpson")`,
+		"savepoint a",
+		`insert into people (id, first_name, last_name) values (103, "Abe", "Simpson")`,
+		"rollback to a",
+		"release savepoint a",
+	)
+	_, err = tt.exec(s1, "rollback to a")
+	assert.True(t, ErrSavepointDoesNotExist.Is(err), "unexpected error %v", err)
+	assert.Equal(t, workingHash, tt.dEnv.RepoState.WorkingHash())
+
+	// the transaction is committed as it stands after the rollbacks
+	tt.mustExec(s1, "commit")
+	assert.Equal(t, []sql.Row{{int64(100)}, {int64(102)}}, tt.workingRows(people))
+	assert.Empty(t, tt.workingRows(episodes))
+	assert.Empty(t, DSessFromSess(s1.Session).savepoints)
+}
+
+func TestSavepointsWithConcurrentCommits(t *testing.T) {
+	tt := newTransactionTest(t)
+	s1, s2 := tt.newSession(), tt.newSession()
+	query := "select id from people where id >= 100 order by id"
+
+	tt.mustExec(s1,
+		"begin",
+		"savepoint a",
+		`insert into people (id, first_name, last_name) values (100, "Bart", "Simpson")`,
+		"update people set age = age + 1 where id = 0",
+		"rollback to savepoint a",
+		`insert into people (id, first_name, last_name) values (101, "Lisa", "Simpson")`,
+	)
+
+	// the row rolled back isn't a conflict, and the transaction's remaining writes are merged as before
+	tt.mustExec(s2, "update people set age = age + 1 where id = 0")
+	tt.mustExec(s1, "commit")
+	assert.Equal(t, []sql.Row{{int64(101)}}, tt.workingRows(query))
+
+	// savepoints set outside a transaction are discarded, and rollbacks to them fail
+	tt.mustExec(s1, "savepoint b")
+	_, err := tt.exec(s1, "rollback to b")
+	assert.True(t, ErrSavepointDoesNotExist.Is(err), "unexpected error %v", err)
+
+	// savepoints don't outlive the transaction they were set in
+	tt.mustExec(s1, "begin", "savepoint c", "rollback")
+	_, err = tt.exec(s1, "release savepoint c")
+	assert.True(t, ErrSavepointDoesNotExist.Is(err), "unexpected error %v", err)
+}
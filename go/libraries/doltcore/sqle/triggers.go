@@ -0,0 +1,640 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/parse"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"gopkg.in/src-d/go-errors.v1"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	dsql "github.com/liquidata-inc/dolt/go/libraries/doltcore/sql"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+const (
+	TriggerBefore = "BEFORE"
+	TriggerAfter  = "AFTER"
+	TriggerInsert = "INSERT"
+
+	// The type of the rows in the dolt_schemas table which hold trigger definitions.
+	triggerFragmentType = "trigger"
+
+	// The maximum nesting of triggers which fire other triggers through the statements they execute.
+	maxTriggerDepth = 16
+)
+
+var ErrTriggerExists = errors.NewKind("Trigger %s already exists")
+var ErrTriggerNotFound = errors.NewKind("Trigger %s does not exist")
+var ErrInvalidTrigger = errors.NewKind("Invalid trigger %s: %s")
+var ErrTriggerFailed = errors.NewKind("Trigger %s failed: %s")
+var ErrTriggerDepth = errors.NewKind("Trigger %s exceeded the maximum trigger nesting depth of %d")
+
+const triggerIdentRegexStr = "(`[^`]+`|[A-Za-z0-9_$]+)"
+
+var createTriggerRegex = regexp.MustCompile(`(?is)^\s*create\s+trigger\s+(if\s+not\s+exists\s+)?` + triggerIdentRegexStr +
+	`\s+(before|after)\s+(insert)\s+on\s+` + triggerIdentRegexStr + `\s+for\s+each\s+row\s+(.+?)[\s;]*$`)
+var dropTriggerRegex = regexp.MustCompile(`(?is)^\s*drop\s+trigger\s+(if\s+exists\s+)?` + triggerIdentRegexStr + `[\s;]*$`)
+var showTriggersRegex = regexp.MustCompile(`(?is)^\s*show\s+triggers[\s;]*$`)
+var showCreateTriggerRegex = regexp.MustCompile(`(?is)^\s*show\s+create\s+trigger\s+` + triggerIdentRegexStr + `[\s;]*$`)
+var triggerStatementRegex = regexp.MustCompile(`(?is)^\s*((create|drop|show\s+create)\s+trigger|show\s+triggers)\b`)
+var setBodyRegex = regexp.MustCompile(`(?is)^set\s+(.+)$`)
+
+var showTriggersSchema = sql.Schema{
+	{Name: "Trigger", Type: sql.LongText},
+	{Name: "Event", Type: sql.LongText},
+	{Name: "Table", Type: sql.LongText},
+	{Name: "Statement", Type: sql.LongText},
+	{Name: "Timing", Type: sql.LongText},
+}
+
+var showCreateTriggerSchema = sql.Schema{
+	{Name: "Trigger", Type: sql.LongText},
+	{Name: "SQL Original Statement", Type: sql.LongText},
+}
+
+// Trigger is a BEFORE or AFTER INSERT trigger defined on a table. Triggers are persisted in the dolt_schemas table as
+// their CREATE TRIGGER statement, so they are versioned, diffed and merged along with the rest of the database.
+//
+// A BEFORE trigger is a list of assignments to the columns of the row being inserted, e.g.
+// `SET NEW.updated_at = NOW()`. An AFTER trigger is a single INSERT, REPLACE, UPDATE or DELETE statement against
+// another table. Both may refer to the columns of the inserted row as NEW.<column>.
+type Trigger struct {
+	Name   string
+	Timing string
+	Event  string
+	Table  string
+	// Body is the statement the trigger executes for each row.
+	Body string
+	// Definition is the CREATE TRIGGER statement which defines the trigger.
+	Definition string
+
+	assignments []*expression.SetField
+	stmt        sql.Node
+}
+
+// IsTriggerStatement returns whether the query given is a CREATE TRIGGER, DROP TRIGGER, SHOW TRIGGERS or SHOW CREATE
+// TRIGGER statement. The SQL parser doesn't support these statements, so integrators must check for them before
+// parsing a query and run them with ExecuteTriggerStatement.
+func IsTriggerStatement(query string) bool {
+	return triggerStatementRegex.MatchString(query)
+}
+
+// ExecuteTriggerStatement executes a trigger statement, as identified by IsTriggerStatement, against the database
+// given.
+func ExecuteTriggerStatement(ctx *sql.Context, db Database, query string) (sql.Schema, sql.RowIter, error) {
+	switch {
+	case createTriggerRegex.MatchString(query):
+		return db.createTrigger(ctx, query)
+	case dropTriggerRegex.MatchString(query):
+		m := dropTriggerRegex.FindStringSubmatch(query)
+		return db.dropTrigger(ctx, unquoteTriggerIdent(m[2]), m[1] != "")
+	case showTriggersRegex.MatchString(query):
+		return db.showTriggers(ctx)
+	case showCreateTriggerRegex.MatchString(query):
+		m := showCreateTriggerRegex.FindStringSubmatch(query)
+		return db.showCreateTrigger(ctx, unquoteTriggerIdent(m[1]))
+	default:
+		return nil, nil, fmt.Errorf("Unsupported trigger statement: '%v'.", query)
+	}
+}
+
+func unquoteTriggerIdent(s string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(s, "`"), "`")
+}
+
+// parseTrigger parses a CREATE TRIGGER statement, returning the trigger and whether the statement has an IF NOT
+// EXISTS clause.
+func parseTrigger(ctx *sql.Context, query string) (*Trigger, bool, error) {
+	m := createTriggerRegex.FindStringSubmatch(query)
+	if m == nil {
+		return nil, false, fmt.Errorf("Error parsing trigger: '%v'.", query)
+	}
+
+	tr := &Trigger{
+		Name:   unquoteTriggerIdent(m[2]),
+		Timing: strings.ToUpper(m[3]),
+		Event:  strings.ToUpper(m[4]),
+		Table:  unquoteTriggerIdent(m[5]),
+		Body:   m[6],
+	}
+	tr.Definition = fmt.Sprintf("CREATE TRIGGER %s %s %s ON %s FOR EACH ROW %s",
+		dsql.QuoteIdentifier(tr.Name), tr.Timing, tr.Event, dsql.QuoteIdentifier(tr.Table), tr.Body)
+
+	if tr.Timing == TriggerBefore {
+		sm := setBodyRegex.FindStringSubmatch(tr.Body)
+		if sm == nil {
+			return nil, false, ErrInvalidTrigger.New(tr.Name, "BEFORE triggers must be of the form SET NEW.<column> = <expression>, ...")
+		}
+
+		n, err := parse.Parse(ctx, fmt.Sprintf("update %s set %s", dsql.QuoteIdentifier(tr.Table), sm[1]))
+		if err != nil {
+			return nil, false, ErrInvalidTrigger.New(tr.Name, err.Error())
+		}
+
+		update, ok := n.(*plan.Update)
+		if !ok {
+			return nil, false, ErrInvalidTrigger.New(tr.Name, "BEFORE triggers must be of the form SET NEW.<column> = <expression>, ...")
+		}
+		if _, ok := update.Node.(*plan.UnresolvedTable); !ok {
+			return nil, false, ErrInvalidTrigger.New(tr.Name, "BEFORE triggers must be of the form SET NEW.<column> = <expression>, ...")
+		}
+
+		for _, e := range update.UpdateExprs {
+			sf, ok := e.(*expression.SetField)
+			if !ok {
+				return nil, false, ErrInvalidTrigger.New(tr.Name, "unsupported assignment "+e.String())
+			}
+
+			col, ok := sf.Left.(*expression.UnresolvedColumn)
+			if !ok || !strings.EqualFold(col.Table(), "new") {
+				return nil, false, ErrInvalidTrigger.New(tr.Name, "only NEW columns can be assigned, found "+sf.Left.String())
+			}
+
+			tr.assignments = append(tr.assignments, sf)
+		}
+	} else {
+		n, err := parse.Parse(ctx, tr.Body)
+		if err != nil {
+			return nil, false, ErrInvalidTrigger.New(tr.Name, err.Error())
+		}
+
+		var target string
+		switch n := n.(type) {
+		case *plan.InsertInto:
+			target = unresolvedTableName(n.Left)
+		case *plan.Update, *plan.DeleteFrom:
+			target = unresolvedTableName(n)
+		default:
+			return nil, false, ErrInvalidTrigger.New(tr.Name, "AFTER triggers must execute an INSERT, REPLACE, UPDATE or DELETE statement")
+		}
+
+		if strings.EqualFold(target, tr.Table) {
+			return nil, false, ErrInvalidTrigger.New(tr.Name, "the trigger statement cannot modify the table the trigger is defined on")
+		}
+
+		tr.stmt = n
+	}
+
+	return tr, m[1] != "", nil
+}
+
+// unresolvedTableName returns the name of the first table found by descending through the first child of each node.
+func unresolvedTableName(n sql.Node) string {
+	for n != nil {
+		if t, ok := n.(*plan.UnresolvedTable); ok {
+			return t.Name()
+		}
+
+		children := n.Children()
+		if len(children) == 0 {
+			break
+		}
+		n = children[0]
+	}
+
+	return ""
+}
+
+// validate checks that the columns of the trigger's table referenced by the trigger exist in the schema given.
+func (tr *Trigger) validate(sch sql.Schema) error {
+	row := make(sql.Row, len(sch))
+
+	var err error
+	if tr.Timing == TriggerBefore {
+		for _, sf := range tr.assignments {
+			if _, err = newRowColumn(sch, sf.Left.(*expression.UnresolvedColumn)); err != nil {
+				break
+			}
+			if _, err = substituteNewRow(sf.Right, sch, row); err != nil {
+				break
+			}
+		}
+	} else {
+		_, err = plan.TransformExpressionsUp(tr.stmt, func(e sql.Expression) (sql.Expression, error) {
+			return substituteNewRow(e, sch, row)
+		})
+	}
+
+	if err != nil {
+		return ErrInvalidTrigger.New(tr.Name, err.Error())
+	}
+
+	return nil
+}
+
+// newRowColumn returns the index in sch of the NEW column given.
+func newRowColumn(sch sql.Schema, col *expression.UnresolvedColumn) (int, error) {
+	for i, c := range sch {
+		if strings.EqualFold(c.Name, col.Name()) {
+			return i, nil
+		}
+	}
+
+	return -1, fmt.Errorf("Unknown column '%s' in 'NEW'", col.Name())
+}
+
+// substituteNewRow replaces the NEW column references in the expression given with the values of those columns in row.
+func substituteNewRow(e sql.Expression, sch sql.Schema, row sql.Row) (sql.Expression, error) {
+	return expression.TransformUp(e, func(e sql.Expression) (sql.Expression, error) {
+		col, ok := e.(*expression.UnresolvedColumn)
+		if !ok {
+			return e, nil
+		}
+
+		if strings.EqualFold(col.Table(), "old") {
+			return nil, fmt.Errorf("there is no OLD row in an INSERT trigger")
+		} else if !strings.EqualFold(col.Table(), "new") {
+			return e, nil
+		}
+
+		idx, err := newRowColumn(sch, col)
+		if err != nil {
+			return nil, err
+		}
+
+		return expression.NewLiteral(row[idx], sch[idx].Type), nil
+	})
+}
+
+// fireBefore applies the assignments of a BEFORE trigger to the row given, returning the row to insert.
+func (tr *Trigger) fireBefore(ctx *sql.Context, db Database, sch sql.Schema, row sql.Row) (sql.Row, error) {
+	ctx, done, err := enterTrigger(ctx, db, tr)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	e := db.triggerEngine()
+	row = row.Copy()
+
+	// Assignments are applied in order, so that each sees the values assigned before it.
+	for _, sf := range tr.assignments {
+		idx, err := newRowColumn(sch, sf.Left.(*expression.UnresolvedColumn))
+		if err != nil {
+			return nil, ErrTriggerFailed.New(tr.Name, err.Error())
+		}
+
+		val, err := substituteNewRow(sf.Right, sch, row)
+		if err != nil {
+			return nil, ErrTriggerFailed.New(tr.Name, err.Error())
+		}
+
+		rows, err := runTriggerNode(ctx, e, plan.NewProject([]sql.Expression{val}, plan.NewUnresolvedTable("dual", "")))
+		if err != nil {
+			return nil, ErrTriggerFailed.New(tr.Name, err.Error())
+		}
+
+		row[idx], err = sch[idx].Type.Convert(rows[0][0])
+		if err != nil {
+			return nil, ErrTriggerFailed.New(tr.Name, err.Error())
+		}
+	}
+
+	return row, nil
+}
+
+// fireAfter executes the statement of an AFTER trigger for the row given.
+func (tr *Trigger) fireAfter(ctx *sql.Context, db Database, sch sql.Schema, row sql.Row) error {
+	ctx, done, err := enterTrigger(ctx, db, tr)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	n, err := plan.TransformExpressionsUp(tr.stmt, func(e sql.Expression) (sql.Expression, error) {
+		return substituteNewRow(e, sch, row)
+	})
+	if err != nil {
+		return ErrTriggerFailed.New(tr.Name, err.Error())
+	}
+
+	_, err = runTriggerNode(ctx, db.triggerEngine(), n)
+	if err != nil {
+		return ErrTriggerFailed.New(tr.Name, err.Error())
+	}
+
+	return nil
+}
+
+func runTriggerNode(ctx *sql.Context, e *sqle.Engine, n sql.Node) ([]sql.Row, error) {
+	analyzed, err := e.Analyzer.Analyze(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+
+	iter, err := analyzed.RowIter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return sql.RowIterToRows(iter)
+}
+
+type triggerDepthKey struct{}
+
+// enterTrigger returns the context to execute a trigger defined in db with, along with a function to call once the
+// trigger has executed. Trigger statements refer to the tables of the database the trigger is defined in, so that
+// database is made the current database while the trigger executes.
+func enterTrigger(ctx *sql.Context, db Database, tr *Trigger) (*sql.Context, func(), error) {
+	depth, _ := ctx.Value(triggerDepthKey{}).(int)
+	if depth >= maxTriggerDepth {
+		return nil, nil, ErrTriggerDepth.New(tr.Name, maxTriggerDepth)
+	}
+
+	currDB := ctx.GetCurrentDatabase()
+	trCtx := ctx.WithContext(context.WithValue(ctx.Context, triggerDepthKey{}, depth+1))
+	trCtx.SetCurrentDatabase(db.name)
+
+	return trCtx, func() { trCtx.SetCurrentDatabase(currDB) }, nil
+}
+
+// triggerCache holds the triggers parsed from a database's dolt_schemas table, along with the engine the triggers are
+// executed with. Every root with the same dolt_schemas table has the same triggers, so the parsed triggers are keyed
+// by the hash of that table.
+type triggerCache struct {
+	mu       *sync.Mutex
+	h        hash.Hash
+	triggers []*Trigger
+	engine   *sqle.Engine
+}
+
+// TriggersCacheName is the name the trigger caches of databases are registered with in the cacheregistry.
+const TriggersCacheName = "sql_triggers"
+
+func newTriggerCache() *triggerCache {
+	return &triggerCache{mu: &sync.Mutex{}}
+}
+
+// Len returns the number of parsed triggers in the cache.
+func (trc *triggerCache) Len() int {
+	trc.mu.Lock()
+	defer trc.mu.Unlock()
+
+	return len(trc.triggers)
+}
+
+// Size returns an estimate of the bytes held by the cache, which is the length of the definitions of its triggers.
+func (trc *triggerCache) Size() uint64 {
+	trc.mu.Lock()
+	defer trc.mu.Unlock()
+
+	var size uint64
+	for _, tr := range trc.triggers {
+		size += uint64(len(tr.Definition))
+	}
+
+	return size
+}
+
+// Purge drops the parsed triggers, so that they're parsed again the next time they're needed.
+func (trc *triggerCache) Purge() {
+	trc.mu.Lock()
+	defer trc.mu.Unlock()
+
+	trc.h, trc.triggers = hash.Hash{}, nil
+}
+
+// triggerEngine returns the engine used to execute the statements of triggers defined in this database. Trigger
+// statements are executed against a copy of the database which isn't in batch mode, so that their edits are flushed
+// along with the edits of the statement which fired them.
+func (db Database) triggerEngine() *sqle.Engine {
+	db.trc.mu.Lock()
+	defer db.trc.mu.Unlock()
+
+	if db.trc.engine == nil {
+		trDB := db
+		trDB.batchMode = single
+		trDB.tc = &tableCache{&sync.Mutex{}, make(map[*doltdb.RootValue]map[string]sql.Table)}
+
+		db.trc.engine = NewEngine()
+		db.trc.engine.AddDatabase(trDB)
+	}
+
+	return db.trc.engine
+}
+
+// getTriggers returns the triggers defined in the root given, ordered by name.
+func (db Database) getTriggers(ctx *sql.Context, root *doltdb.RootValue) ([]*Trigger, error) {
+	h, ok, err := root.GetTableHash(ctx, doltdb.SchemasTableName)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	db.trc.mu.Lock()
+	if db.trc.h == h {
+		triggers := db.trc.triggers
+		db.trc.mu.Unlock()
+		return triggers, nil
+	}
+	db.trc.mu.Unlock()
+
+	stbl, found, err := db.GetTableInsensitiveWithRoot(ctx, root, doltdb.SchemasTableName)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, nil
+	}
+
+	iter, err := newRowIterator(&stbl.(*WritableDoltTable).DoltTable, ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var triggers []*Trigger
+	for {
+		r, err := iter.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if r[0] != triggerFragmentType {
+			continue
+		}
+
+		// Like views, triggers which no longer parse are skipped rather than failing every statement.
+		tr, _, err := parseTrigger(ctx, r[2].(string))
+		if err == nil {
+			triggers = append(triggers, tr)
+		}
+	}
+
+	db.trc.mu.Lock()
+	db.trc.h, db.trc.triggers = h, triggers
+	db.trc.mu.Unlock()
+
+	return triggers, nil
+}
+
+func (db Database) getTrigger(ctx *sql.Context, name string) (*Trigger, error) {
+	root, err := db.GetRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	triggers, err := db.getTriggers(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tr := range triggers {
+		if strings.EqualFold(tr.Name, name) {
+			return tr, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (db Database) createTrigger(ctx *sql.Context, query string) (sql.Schema, sql.RowIter, error) {
+	tr, ifNotExists, err := parseTrigger(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tbl, ok, err := db.GetTableInsensitive(ctx, tr.Table)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, sql.ErrTableNotFound.New(tr.Table)
+	}
+	if doltdb.HasDoltPrefix(tbl.Name()) {
+		return nil, nil, ErrInvalidTrigger.New(tr.Name, "triggers cannot be defined on system tables")
+	}
+
+	if err := tr.validate(tbl.Schema()); err != nil {
+		return nil, nil, err
+	}
+
+	existing, err := db.getTrigger(ctx, tr.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if existing != nil {
+		if ifNotExists {
+			return okTriggerResult()
+		}
+		return nil, nil, ErrTriggerExists.New(tr.Name)
+	}
+
+	stbl, err := GetOrCreateDoltSchemasTable(ctx, db)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	inserter := stbl.Inserter(ctx)
+	err = inserter.Insert(ctx, sql.Row{triggerFragmentType, tr.Name, tr.Definition})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = inserter.Close(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return okTriggerResult()
+}
+
+func (db Database) dropTrigger(ctx *sql.Context, name string, ifExists bool) (sql.Schema, sql.RowIter, error) {
+	tr, err := db.getTrigger(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tr == nil {
+		if ifExists {
+			return okTriggerResult()
+		}
+		return nil, nil, ErrTriggerNotFound.New(name)
+	}
+
+	stbl, _, err := db.GetTableInsensitive(ctx, doltdb.SchemasTableName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deleter := stbl.(*WritableDoltTable).Deleter(ctx)
+	err = deleter.Delete(ctx, sql.Row{triggerFragmentType, tr.Name, tr.Definition})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = deleter.Close(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return okTriggerResult()
+}
+
+func (db Database) showTriggers(ctx *sql.Context) (sql.Schema, sql.RowIter, error) {
+	root, err := db.GetRoot(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	triggers, err := db.getTriggers(ctx, root)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sorted := make([]*Trigger, len(triggers))
+	copy(sorted, triggers)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return strings.ToLower(sorted[i].Table) < strings.ToLower(sorted[j].Table)
+	})
+
+	rows := make([]sql.Row, len(sorted))
+	for i, tr := range sorted {
+		rows[i] = sql.NewRow(tr.Name, tr.Event, tr.Table, tr.Body, tr.Timing)
+	}
+
+	return showTriggersSchema, sql.RowsToRowIter(rows...), nil
+}
+
+func (db Database) showCreateTrigger(ctx *sql.Context, name string) (sql.Schema, sql.RowIter, error) {
+	tr, err := db.getTrigger(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tr == nil {
+		return nil, nil, ErrTriggerNotFound.New(name)
+	}
+
+	return showCreateTriggerSchema, sql.RowsToRowIter(sql.NewRow(tr.Name, tr.Definition)), nil
+}
+
+func okTriggerResult() (sql.Schema, sql.RowIter, error) {
+	return sql.OkResultSchema, sql.RowsToRowIter(sql.NewRow(sql.NewOkResult(0))), nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+)
+
+func TestIsTriggerStatement(t *testing.T) {
+	assert.True(t, IsTriggerStatement("create trigger t1 before insert on test for each row set new.a = 1"))
+	assert.True(t, IsTriggerStatement("  CREATE TRIGGER `t1` AFTER INSERT ON test FOR EACH ROW delete from other"))
+	assert.True(t, IsTriggerStatement("drop trigger if exists t1"))
+	assert.True(t, IsTriggerStatement("show triggers"))
+	assert.True(t, IsTriggerStatement("show create trigger t1"))
+	assert.False(t, IsTriggerStatement("select * from triggers"))
+	assert.False(t, IsTriggerStatement("create table triggers (a int primary key)"))
+	assert.False(t, IsTriggerStatement("show tables"))
+}
+
+func TestParseTrigger(t *testing.T) {
+	ctx := NewTestSQLCtx(context.Background())
+
+	tr, ifNotExists, err := parseTrigger(ctx, "create trigger if not exists `stamp` BEFORE insert on `test` for each row set new.b = concat(new.a, 'x'), new.c = 1;")
+	require.NoError(t, err)
+	assert.True(t, ifNotExists)
+	assert.Equal(t, "stamp", tr.Name)
+	assert.Equal(t, TriggerBefore, tr.Timing)
+	assert.Equal(t, TriggerInsert, tr.Event)
+	assert.Equal(t, "test", tr.Table)
+	assert.Equal(t, "set new.b = concat(new.a, 'x'), new.c = 1", tr.Body)
+	assert.Equal(t, "CREATE TRIGGER `stamp` BEFORE INSERT ON `test` FOR EACH ROW set new.b = concat(new.a, 'x'), new.c = 1", tr.Definition)
+	assert.Len(t, tr.assignments, 2)
+
+	tr, ifNotExists, err = parseTrigger(ctx, "create trigger audit after insert on test for each row insert into log values (new.a)")
+	require.NoError(t, err)
+	assert.False(t, ifNotExists)
+	assert.Equal(t, TriggerAfter, tr.Timing)
+	assert.NotNil(t, tr.stmt)
+
+	invalid := []string{
+		"create trigger t1 before insert on test for each row insert into log values (new.a)",
+		"create trigger t1 before insert on test for each row set b = 1",
+		"create trigger t1 before insert on test for each row set new.b = 1 where a = 2",
+		"create trigger t1 after insert on test for each row select 1",
+		"create trigger t1 after insert on test for each row insert into test values (new.a + 1)",
+		"create trigger t1 after insert on test for each row update `TEST` set b = 1",
+	}
+	for _, query := range invalid {
+		_, _, err = parseTrigger(ctx, query)
+		assert.True(t, ErrInvalidTrigger.Is(err), "query: %s, err: %v", query, err)
+	}
+}
+
+func TestTriggers(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+
+	ctx := context.Background()
+	root, _ := dEnv.WorkingRoot(ctx)
+
+	var err error
+	root, err = ExecuteSql(dEnv, root, `create table test (a int primary key, b varchar(20), c int);
+create table log (a int primary key, b varchar(20));
+create trigger stamp before insert on test for each row set new.b = concat('row ', new.a), new.c = new.a * 10;
+create trigger audit after insert on test for each row insert into log values (new.a, new.b)`)
+	require.NoError(t, err)
+
+	// batched database, where the AFTER trigger fires when the edits are flushed
+	root, err = ExecuteSql(dEnv, root, "insert into test (a) values (1), (2)")
+	require.NoError(t, err)
+
+	assertRows(t, dEnv, root, "select * from test", []sql.Row{{int32(1), "row 1", int32(10)}, {int32(2), "row 2", int32(20)}})
+	assertRows(t, dEnv, root, "select * from log", []sql.Row{{int32(1), "row 1"}, {int32(2), "row 2"}})
+
+	// non-batched database
+	db := NewDatabase("dolt", dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
+	engine, sqlCtx, err := NewTestEngine(ctx, db, root)
+	require.NoError(t, err)
+
+	_, iter, err := engine.Query(sqlCtx, "replace into test (a, b) values (3, 'ignored')")
+	require.NoError(t, err)
+	_, err = sql.RowIterToRows(iter)
+	require.NoError(t, err)
+
+	root, err = db.GetRoot(sqlCtx)
+	require.NoError(t, err)
+	assertRows(t, dEnv, root, "select * from test where a = 3", []sql.Row{{int32(3), "row 3", int32(30)}})
+	assertRows(t, dEnv, root, "select * from log where a = 3", []sql.Row{{int32(3), "row 3"}})
+
+	// the AFTER trigger fails on the duplicate key in log, which fails the statement
+	root, err = ExecuteSql(dEnv, root, "insert into log values (4, 'x'), (5, 'x')")
+	require.NoError(t, err)
+	_, err = ExecuteSql(dEnv, root, "insert into test (a) values (4)")
+	require.Error(t, err)
+	assert.True(t, ErrTriggerFailed.Is(err), "unexpected error %v", err)
+
+	// and rolls back the insert into test
+	engine, sqlCtx, err = NewTestEngine(ctx, db, root)
+	require.NoError(t, err)
+	_, _, err = engine.Query(sqlCtx, "insert into test (a) values (6), (5)")
+	require.Error(t, err)
+	assert.True(t, ErrTriggerFailed.Is(err), "unexpected error %v", err)
+
+	root, err = db.GetRoot(sqlCtx)
+	require.NoError(t, err)
+	assertRows(t, dEnv, root, "select a from test where a > 3", nil)
+	assertRows(t, dEnv, root, "select a from log where a > 3", []sql.Row{{int32(4)}, {int32(5)}})
+}
+
+func TestTriggerStatements(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+
+	ctx := context.Background()
+	root, _ := dEnv.WorkingRoot(ctx)
+
+	var err error
+	root, err = ExecuteSql(dEnv, root, `create table test (a int primary key, b int);
+create table other (a int primary key);
+create trigger t2 before insert on test for each row set new.b = 2;
+create trigger t1 before insert on test for each row set new.b = new.b + 1;
+create trigger t3 after insert on other for each row delete from test where a = new.a`)
+	require.NoError(t, err)
+
+	db := NewDatabase("dolt", dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
+	_, sqlCtx, err := NewTestEngine(ctx, db, root)
+	require.NoError(t, err)
+
+	rows := executeTriggerStatement(t, sqlCtx, db, "show triggers")
+	assert.Equal(t, []sql.Row{
+		{"t3", "INSERT", "other", "delete from test where a = new.a", "AFTER"},
+		{"t1", "INSERT", "test", "set new.b = new.b + 1", "BEFORE"},
+		{"t2", "INSERT", "test", "set new.b = 2", "BEFORE"},
+	}, rows)
+
+	rows = executeTriggerStatement(t, sqlCtx, db, "show create trigger T1")
+	assert.Equal(t, []sql.Row{{"t1", "CREATE TRIGGER `t1` BEFORE INSERT ON `test` FOR EACH ROW set new.b = new.b + 1"}}, rows)
+
+	_, _, err = ExecuteTriggerStatement(sqlCtx, db, "create trigger t1 before insert on test for each row set new.b = 3")
+	assert.True(t, ErrTriggerExists.Is(err), "unexpected error %v", err)
+	executeTriggerStatement(t, sqlCtx, db, "create trigger if not exists t1 before insert on test for each row set new.b = 3")
+
+	_, _, err = ExecuteTriggerStatement(sqlCtx, db, "create trigger t4 before insert on missing for each row set new.b = 3")
+	assert.True(t, sql.ErrTableNotFound.Is(err), "unexpected error %v", err)
+	_, _, err = ExecuteTriggerStatement(sqlCtx, db, "create trigger t4 before insert on test for each row set new.missing = 3")
+	assert.True(t, ErrInvalidTrigger.Is(err), "unexpected error %v", err)
+	_, _, err = ExecuteTriggerStatement(sqlCtx, db, "create trigger t4 after insert on other for each row delete from test where a = old.a")
+	assert.True(t, ErrInvalidTrigger.Is(err), "unexpected error %v", err)
+	_, _, err = ExecuteTriggerStatement(sqlCtx, db, "create trigger t4 before insert on dolt_schemas for each row set new.name = 'x'")
+	assert.True(t, ErrInvalidTrigger.Is(err), "unexpected error %v", err)
+
+	executeTriggerStatement(t, sqlCtx, db, "drop trigger t2")
+	_, _, err = ExecuteTriggerStatement(sqlCtx, db, "drop trigger t2")
+	assert.True(t, ErrTriggerNotFound.Is(err), "unexpected error %v", err)
+	executeTriggerStatement(t, sqlCtx, db, "drop trigger if exists t2")
+
+	rows = executeTriggerStatement(t, sqlCtx, db, "show triggers")
+	assert.Len(t, rows, 2)
+
+	// the remaining triggers are persisted in the root
+	root, err = db.GetRoot(sqlCtx)
+	require.NoError(t, err)
+	tbl, ok, err := root.GetTable(ctx, doltdb.SchemasTableName)
+	require.NoError(t, err)
+	require.True(t, ok)
+	rowData, err := tbl.GetRowData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), rowData.Len())
+}
+
+func executeTriggerStatement(t *testing.T, ctx *sql.Context, db Database, query string) []sql.Row {
+	_, iter, err := ExecuteTriggerStatement(ctx, db, query)
+	require.NoError(t, err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(t, err)
+	return rows
+}
+
+func assertRows(t *testing.T, dEnv *env.DoltEnv, root *doltdb.RootValue, query string, expected []sql.Row) {
+	db := NewDatabase("dolt", dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
+	engine, sqlCtx, err := NewTestEngine(context.Background(), db, root)
+	require.NoError(t, err)
+
+	_, iter, err := engine.Query(sqlCtx, query)
+	require.NoError(t, err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(t, err)
+	assert.Equal(t, expected, rows)
+}
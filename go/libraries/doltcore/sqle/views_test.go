@@ -18,6 +18,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/src-d/go-mysql-server/sql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -57,3 +58,52 @@ func TestViews(t *testing.T) {
 	root, err = ExecuteSql(dEnv, root, "drop view plus1")
 	require.NoError(t, err)
 }
+
+func TestPersistedViews(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+
+	ctx := context.Background()
+	root, _ := dEnv.WorkingRoot(ctx)
+
+	var err error
+	root, err = ExecuteSql(dEnv, root, "create table test (a int primary key);\ninsert into test values (1), (2), (3)")
+	require.NoError(t, err)
+
+	// each call creates a new engine and session, which loads the views from the root
+	root, err = ExecuteSql(dEnv, root, "create view plus1 as select a + 1 from test")
+	require.NoError(t, err)
+
+	db := NewDatabase("dolt", dEnv.DoltDB, dEnv.RepoState, dEnv.RepoStateWriter())
+	engine, sqlCtx, err := NewTestEngine(ctx, db, root)
+	require.NoError(t, err)
+
+	_, iter, err := engine.Query(sqlCtx, "show create view plus1")
+	require.NoError(t, err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(t, err)
+	assert.Equal(t, []sql.Row{{"plus1", "CREATE VIEW `plus1` AS select a + 1 from test"}}, rows)
+
+	_, _, err = engine.Query(sqlCtx, "drop view not_a_view")
+	require.Error(t, err)
+	assert.True(t, sql.ErrNonExistingView.Is(err))
+	assert.Equal(t, "the view dolt.not_a_view does not exist in the registry", err.Error())
+
+	// a view whose table has been dropped still loads, and fails when it is queried
+	root, err = ExecuteSql(dEnv, root, "drop table test")
+	require.NoError(t, err)
+
+	engine, sqlCtx, err = NewTestEngine(ctx, db, root)
+	require.NoError(t, err)
+
+	_, _, err = engine.Query(sqlCtx, "select * from plus1")
+	assert.True(t, sql.ErrTableNotFound.Is(err), "unexpected error %v", err)
+
+	root, err = ExecuteSql(dEnv, root, "drop view plus1")
+	require.NoError(t, err)
+
+	engine, sqlCtx, err = NewTestEngine(ctx, db, root)
+	require.NoError(t, err)
+
+	_, _, err = engine.Query(sqlCtx, "show create view plus1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,526 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"gopkg.in/src-d/go-errors.v1"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/merge"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// WorkspaceMode determines which of the sessions of a sql-server share a workspace.
+type WorkspaceMode string
+
+const (
+	// NoWorkspaces is the mode in which every session reads and writes the shared working set of each database.
+	NoWorkspaces WorkspaceMode = "none"
+
+	// SessionWorkspaces is the mode in which each session has a workspace of its own.
+	SessionWorkspaces WorkspaceMode = "session"
+
+	// UserWorkspaces is the mode in which the sessions of each user share a workspace.
+	UserWorkspaces WorkspaceMode = "user"
+)
+
+// IsValid returns whether the mode is one of the supported workspace modes.
+func (mode WorkspaceMode) IsValid() bool {
+	switch mode {
+	case NoWorkspaces, SessionWorkspaces, UserWorkspaces:
+		return true
+	default:
+		return false
+	}
+}
+
+// ErrNoWorkspace is returned by CommitWorkspace for a database which the session doesn't access through a workspace.
+var ErrNoWorkspace = errors.NewKind("the session has no workspace for database '%s'")
+
+// ErrInvalidWorkspaceName is returned when the name of a workspace can't be used as the name of a ref.
+var ErrInvalidWorkspaceName = errors.NewKind("'%s' is not a valid workspace name")
+
+// ErrWorkspaceInUse is returned when a workspace which is used by sessions is deleted.
+var ErrWorkspaceInUse = errors.NewKind("workspace '%s' is in use by %d session(s)")
+
+// ErrWorkspaceConflict is returned when the changes of a workspace can't be merged with the commits made to its branch.
+var ErrWorkspaceConflict = errors.NewKind("the changes of workspace '%s' conflict with changes committed to %s: %s")
+
+// ErrNothingToCommit is returned when a workspace without changes is committed.
+var ErrNothingToCommit = errors.NewKind("nothing to commit, workspace '%s' has no changes")
+
+// ErrCommitInTransaction is returned when a workspace is committed while a transaction is open.
+var ErrCommitInTransaction = errors.NewKind("a workspace can't be committed while a transaction is open")
+
+// Workspaces tracks the workspaces used by the sessions of a sql-server. A session with a workspace reads and writes
+// each database through its workspace rather than the database's shared working set. The changes it writes are
+// committed to a refs/workspaces/<name> ref of the database, on top of the head of the database's branch, and are
+// committed to the branch by CommitWorkspace. A workspace ref keeps the workspace's changes reachable while its sessions
+// exist, and after they have ended until it's deleted from the dolt_workspaces table or by dolt workspace -d. Workspaces
+// which are in use by a session can't be deleted from the dolt_workspaces table.
+type Workspaces struct {
+	mode    WorkspaceMode
+	started time.Time
+	mu      *sync.Mutex
+	open    map[workspaceKey]*workspace
+}
+
+type workspaceKey struct {
+	dbName string
+	name   string
+}
+
+// NewWorkspaces returns the Workspaces of a server whose sessions use workspaces according to |mode|.
+func NewWorkspaces(mode WorkspaceMode) *Workspaces {
+	return &Workspaces{mode, time.Now(), &sync.Mutex{}, make(map[workspaceKey]*workspace)}
+}
+
+// Mode returns the mode which determines which sessions share a workspace.
+func (w *Workspaces) Mode() WorkspaceMode {
+	return w.mode
+}
+
+// Name returns the name of the workspace of a session of |user| whose connection has the id given.
+func (w *Workspaces) Name(user string, connID uint32) string {
+	if w.mode == SessionWorkspaces {
+		// connection ids start again from 1 when a server restarts, so the workspaces of sessions of an earlier run,
+		// which are kept until they're deleted, are told apart by the time their server started.
+		return fmt.Sprintf("%s/%s-%d", user, w.started.UTC().Format("20060102T150405"), connID)
+	}
+
+	return user
+}
+
+// Sessions returns the number of sessions which are using the workspace of the database given.
+func (w *Workspaces) Sessions(dbName, name string) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if ws, ok := w.open[workspaceKey{dbName, name}]; ok {
+		return ws.sessions
+	}
+
+	return 0
+}
+
+// inUse returns the workspaces of the database given which are in use by a session.
+func (w *Workspaces) inUse(dbName string) []*workspace {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var inUse []*workspace
+	for key, ws := range w.open {
+		if key.dbName == dbName {
+			inUse = append(inUse, ws)
+		}
+	}
+
+	return inUse
+}
+
+// acquire returns the workspace of the database given for a new session, opening it if no other session is using it.
+// Changes committed to an opened workspace are attributed to |author|.
+func (w *Workspaces) acquire(ctx context.Context, db Database, name, author, email string) (*workspace, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	key := workspaceKey{db.Name(), name}
+	if ws, ok := w.open[key]; ok {
+		ws.sessions++
+		return ws, nil
+	}
+
+	ws, err := openWorkspace(ctx, db, name, author, email)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ws.sessions = 1
+	w.open[key] = ws
+	return ws, nil
+}
+
+// release records that a session has stopped using a workspace. Its ref is kept after its last session has ended.
+func (w *Workspaces) release(ws *workspace) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	ws.sessions--
+	if ws.sessions <= 0 {
+		delete(w.open, workspaceKey{ws.dbName, ws.ref.GetPath()})
+	}
+}
+
+// workspace is a workspace of a database which is in use by sessions. It stands in for the repo state of the database
+// in those sessions, so that the changes of sessions which share the workspace are merged like the changes of sessions
+// which share the working set. The ref of the workspace is only written once a session has changed the database. Its
+// fields, other than sessions, are guarded by the working set lock of the database.
+type workspace struct {
+	dbName   string
+	ref      ref.WorkspaceRef
+	branch   ref.DoltRef
+	ddb      *doltdb.DoltDB
+	author   string
+	email    string
+	base     *doltdb.Commit
+	working  hash.Hash
+	sessions int
+}
+
+var _ env.RepoStateReader = (*workspace)(nil)
+var _ env.RepoStateWriter = (*workspace)(nil)
+
+// openWorkspace reads the workspace of the database given from its ref. A workspace without a ref starts without
+// changes on top of the head of the database's branch.
+func openWorkspace(ctx context.Context, db Database, name, author, email string) (*workspace, error) {
+	if !doltdb.IsValidUserBranchName(name) {
+		return nil, ErrInvalidWorkspaceName.New(name)
+	}
+
+	ws := &workspace{dbName: db.Name(), ref: ref.NewWorkspaceRef(name), branch: db.rsr.CWBHeadRef(), ddb: db.ddb, author: author, email: email}
+	existing, err := actions.GetWorkspace(ctx, db.ddb, name)
+
+	var cm *doltdb.Commit
+	if err == nil {
+		ws.base, cm = existing.Base, existing.Commit
+	} else if err == doltdb.ErrWorkspaceNotFound {
+		ws.base, err = db.ddb.Resolve(ctx, db.rsr.CWBHeadSpec())
+
+		if err != nil {
+			return nil, err
+		}
+
+		cm = ws.base
+	} else {
+		return nil, err
+	}
+
+	root, err := cm.GetRootValue()
+
+	if err != nil {
+		return nil, err
+	}
+
+	ws.working, err = root.HashOf()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ws, nil
+}
+
+// CWBHeadRef returns the branch which the changes of the workspace are committed to.
+func (ws *workspace) CWBHeadRef() ref.DoltRef {
+	return ws.branch
+}
+
+// CWBHeadSpec returns the commit spec of the head of the workspace's branch.
+func (ws *workspace) CWBHeadSpec() *doltdb.CommitSpec {
+	spec, _ := doltdb.NewCommitSpec("HEAD", ws.branch.String())
+	return spec
+}
+
+// WorkingHash returns the hash of the root holding the changes of the workspace.
+func (ws *workspace) WorkingHash() hash.Hash {
+	return ws.working
+}
+
+// StagedHash returns the same hash as WorkingHash, as changes aren't staged in a workspace.
+func (ws *workspace) StagedHash() hash.Hash {
+	return ws.working
+}
+
+// SetWorkingHash sets the root holding the changes of the workspace, committing it to the workspace's ref on top of
+// the workspace's base commit.
+func (ws *workspace) SetWorkingHash(ctx context.Context, h hash.Hash) error {
+	meta, err := doltdb.NewCommitMeta(ws.author, ws.email, "changes of workspace "+ws.ref.GetPath())
+
+	if err != nil {
+		return err
+	}
+
+	cm, err := ws.ddb.CommitDanglingWithParentCommits(ctx, h, []*doltdb.Commit{ws.base}, meta)
+
+	if err != nil {
+		return err
+	}
+
+	err = ws.ddb.SetHead(ctx, ws.ref, cm)
+
+	if err != nil {
+		return err
+	}
+
+	ws.working = h
+	return nil
+}
+
+// commit commits the changes of the workspace to its branch, merged with the commits made to the branch since the
+// workspace's base commit. The new commit becomes the base commit of the workspace.
+func (ws *workspace) commit(ctx context.Context, meta *doltdb.CommitMeta) (*doltdb.Commit, error) {
+	baseRoot, err := ws.base.GetRootValue()
+
+	if err != nil {
+		return nil, err
+	}
+
+	baseRootHash, err := baseRoot.HashOf()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if baseRootHash == ws.working {
+		return nil, ErrNothingToCommit.New(ws.ref.GetPath())
+	}
+
+	root, err := ws.ddb.ReadRootValue(ctx, ws.working)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		head, err := ws.ddb.Resolve(ctx, ws.CWBHeadSpec())
+
+		if err != nil {
+			return nil, err
+		}
+
+		merged, err := ws.mergeWithHead(ctx, root, baseRoot, head)
+
+		if err != nil {
+			return nil, err
+		}
+
+		h, err := ws.ddb.WriteRootValue(ctx, merged)
+
+		if err != nil {
+			return nil, err
+		}
+
+		cm, err := ws.ddb.CommitWithExpectedHead(ctx, h, ws.branch, head, nil, meta)
+
+		if err == doltdb.ErrHeadMoved && attempt < maxOptimisticCommits {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		ws.base = cm
+		err = ws.SetWorkingHash(ctx, h)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return cm, nil
+	}
+}
+
+// mergeWithHead merges the changes made in |root| since |baseRoot|, the root of the workspace's base commit, with the
+// changes committed to the branch since, up to |head|.
+func (ws *workspace) mergeWithHead(ctx context.Context, root, baseRoot *doltdb.RootValue, head *doltdb.Commit) (*doltdb.RootValue, error) {
+	headHash, err := head.HashOf()
+
+	if err != nil {
+		return nil, err
+	}
+
+	baseHash, err := ws.base.HashOf()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if headHash == baseHash {
+		return root, nil
+	}
+
+	headRoot, err := head.GetRootValue()
+
+	if err != nil {
+		return nil, err
+	}
+
+	merged, tblToStats, err := merge.MergeRoots(ctx, root, headRoot, baseRoot, ws.ddb.ValueReadWriter())
+
+	if err == merge.ErrSameTblAddedTwice {
+		return nil, ErrWorkspaceConflict.New(ws.ref.GetPath(), ws.branch.GetPath(), "a table created in the workspace was also created on the branch")
+	} else if err == merge.ErrCommentConflict {
+		return nil, ErrWorkspaceConflict.New(ws.ref.GetPath(), ws.branch.GetPath(), "a table comment changed in the workspace was also changed on the branch")
+	} else if err != nil {
+		return nil, err
+	}
+
+	var conflicted []string
+	for tblName, stats := range tblToStats {
+		if stats.Conflicts > 0 {
+			conflicted = append(conflicted, tblName)
+		}
+	}
+
+	if len(conflicted) > 0 {
+		sort.Strings(conflicted)
+		return nil, ErrWorkspaceConflict.New(ws.ref.GetPath(), ws.branch.GetPath(), "rows of "+strings.Join(conflicted, ", ")+" were changed in both")
+	}
+
+	return merged, nil
+}
+
+// UseWorkspace makes the session read and write the database given through the workspace with the name given,
+// instead of the database's shared working set. It's called in place of LoadRootFromRepoState for each database when
+// the session is created.
+func (sess *DoltSession) UseWorkspace(ctx *sql.Context, db Database, workspaces *Workspaces, name string) error {
+	author, email := sess.Client().User, sess.Email
+	if email == "" {
+		email = author
+	}
+
+	ws, err := workspaces.acquire(ctx, db, name, author, email)
+
+	if err != nil {
+		return err
+	}
+
+	dbd := newDBData(db)
+	dbd.rsr, dbd.rsw = ws, ws
+	sess.dbDatas[db.Name()] = dbd
+	sess.workspaces = workspaces
+
+	dbd.wsMu.Lock()
+	baseHash, err := ws.base.HashOf()
+	working := ws.working
+	dbd.wsMu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	err = sess.Set(ctx, db.HeadKey(), hashType, baseHash.String())
+
+	if err != nil {
+		return err
+	}
+
+	root, err := db.ddb.ReadRootValue(ctx, working)
+
+	if err != nil {
+		return err
+	}
+
+	err = sess.setRoot(ctx, db.Name(), root)
+
+	if err != nil {
+		return err
+	}
+
+	sess.persistedHashes[db.Name()] = working.String()
+	return nil
+}
+
+// releaseWorkspace records that the session has stopped using its workspace of the database given, if it has one.
+func (sess *DoltSession) releaseWorkspace(dbName string) {
+	if ws, ok := sess.dbDatas[dbName].rsr.(*workspace); ok {
+		sess.workspaces.release(ws)
+	}
+}
+
+// CommitWorkspace commits the changes of the session's workspace of the database given to the database's branch, along
+// with the changes the session hasn't committed to its workspace yet. If commits have been made to the branch since
+// the workspace's changes were made on top of it, the changes are merged with them. The changes of other sessions
+// which share the workspace are committed as well.
+func (sess *DoltSession) CommitWorkspace(ctx *sql.Context, dbName, message string) (*doltdb.Commit, error) {
+	dbd, ok := sess.dbDatas[dbName]
+
+	if !ok {
+		return nil, sql.ErrDatabaseNotFound.New(dbName)
+	}
+
+	ws, ok := dbd.rsr.(*workspace)
+
+	if !ok {
+		return nil, ErrNoWorkspace.New(dbName)
+	}
+
+	if sess.InTransaction() {
+		return nil, ErrCommitInTransaction.New()
+	}
+
+	if sess.Username == "" || sess.Email == "" {
+		return nil, fmt.Errorf("commit failure: Username and/or email not configured")
+	}
+
+	meta, err := doltdb.NewCommitMeta(sess.Username, sess.Email, message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = sess.commitWorkingSet(ctx, dbName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	dbd.wsMu.Lock()
+	cm, err := ws.commit(ctx, meta)
+	working := ws.working
+	dbd.wsMu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := cm.HashOf()
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = sess.Session.Set(ctx, dbName+HeadKeySuffix, hashType, h.String())
+
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := dbd.ddb.ReadRootValue(ctx, working)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sess.persistedHashes[dbName] = working.String()
+	err = sess.setRoot(ctx, dbName, root)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cm, nil
+}
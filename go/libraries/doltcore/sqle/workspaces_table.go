@@ -0,0 +1,216 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions"
+)
+
+const (
+	// WorkspacesTableName is the system table name
+	WorkspacesTableName = "dolt_workspaces"
+)
+
+var _ sql.Table = (*WorkspacesTable)(nil)
+var _ sql.DeletableTable = (*WorkspacesTable)(nil)
+
+// WorkspacesTable is a sql.Table implementation that implements a system table which shows the workspaces of the
+// sql-server's sessions and how they have diverged from the head of the branch. Deleting a row deletes the workspace,
+// unless it's in use by a session.
+type WorkspacesTable struct {
+	dbName     string
+	dbd        dbData
+	workspaces *Workspaces
+}
+
+// NewWorkspacesTable creates a WorkspacesTable
+func NewWorkspacesTable(sqlCtx *sql.Context, dbName string) (*WorkspacesTable, error) {
+	sess := DSessFromSess(sqlCtx.Session)
+	dbd, ok := sess.dbDatas[dbName]
+
+	if !ok {
+		return nil, sql.ErrDatabaseNotFound.New(dbName)
+	}
+
+	return &WorkspacesTable{dbName, dbd, sess.workspaces}, nil
+}
+
+// Name is a sql.Table interface function which returns the name of the table which is defined by the constant
+// WorkspacesTableName
+func (wt *WorkspacesTable) Name() string {
+	return WorkspacesTableName
+}
+
+// String is a sql.Table interface function which returns the name of the table which is defined by the constant
+// WorkspacesTableName
+func (wt *WorkspacesTable) String() string {
+	return WorkspacesTableName
+}
+
+// Schema is a sql.Table interface function that gets the sql.Schema of the workspaces system table
+func (wt *WorkspacesTable) Schema() sql.Schema {
+	return []*sql.Column{
+		{Name: "name", Type: sql.Text, Source: WorkspacesTableName, PrimaryKey: true, Nullable: false},
+		{Name: "hash", Type: sql.Text, Source: WorkspacesTableName, PrimaryKey: false, Nullable: true},
+		{Name: "base", Type: sql.Text, Source: WorkspacesTableName, PrimaryKey: false, Nullable: false},
+		{Name: "changed_tables", Type: sql.Text, Source: WorkspacesTableName, PrimaryKey: false, Nullable: false},
+		{Name: "commits_behind", Type: sql.Int64, Source: WorkspacesTableName, PrimaryKey: false, Nullable: false},
+		{Name: "sessions", Type: sql.Int64, Source: WorkspacesTableName, PrimaryKey: false, Nullable: false},
+		{Name: "last_updated", Type: sql.Datetime, Source: WorkspacesTableName, PrimaryKey: false, Nullable: true},
+	}
+}
+
+// Partitions is a sql.Table interface function that returns a partition of the data.  Currently the data is unpartitioned.
+func (wt *WorkspacesTable) Partitions(*sql.Context) (sql.PartitionIter, error) {
+	return &doltTablePartitionIter{}, nil
+}
+
+// PartitionRows is a sql.Table interface function that gets a row iterator for a partition. There's a row for each
+// workspace with a ref, and for each workspace in use by a session which hasn't changed it yet.
+func (wt *WorkspacesTable) PartitionRows(sqlCtx *sql.Context, part sql.Partition) (sql.RowIter, error) {
+	ddb := wt.dbd.ddb
+	head, err := ddb.Resolve(sqlCtx, wt.dbd.rsr.CWBHeadSpec())
+
+	if err != nil {
+		return nil, err
+	}
+
+	workspaces, err := actions.GetWorkspaces(sqlCtx, ddb)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []sql.Row
+	names := make(map[string]bool)
+	for _, ws := range workspaces {
+		div, err := ws.Divergence(sqlCtx, ddb, head)
+
+		if err != nil {
+			return nil, err
+		}
+
+		h, err := ws.Commit.HashOf()
+
+		if err != nil {
+			return nil, err
+		}
+
+		baseHash, err := ws.Base.HashOf()
+
+		if err != nil {
+			return nil, err
+		}
+
+		meta, err := ws.Commit.GetCommitMeta()
+
+		if err != nil {
+			return nil, err
+		}
+
+		name := ws.Ref.GetPath()
+		names[name] = true
+		rows = append(rows, sql.NewRow(name, h.String(), baseHash.String(), strings.Join(div.ChangedTables, ","), int64(div.CommitsBehind), int64(wt.sessions(name)), meta.Time()))
+	}
+
+	if wt.workspaces != nil {
+		for _, ws := range wt.workspaces.inUse(wt.dbName) {
+			name := ws.ref.GetPath()
+
+			if names[name] {
+				continue
+			}
+
+			wt.dbd.wsMu.Lock()
+			base := ws.base
+			wt.dbd.wsMu.Unlock()
+
+			root, err := base.GetRootValue()
+
+			if err != nil {
+				return nil, err
+			}
+
+			div, err := actions.GetWorkspaceDivergence(sqlCtx, ddb, root, base, head)
+
+			if err != nil {
+				return nil, err
+			}
+
+			baseHash, err := base.HashOf()
+
+			if err != nil {
+				return nil, err
+			}
+
+			rows = append(rows, sql.NewRow(name, nil, baseHash.String(), "", int64(div.CommitsBehind), int64(wt.sessions(name)), nil))
+		}
+	}
+
+	return sql.RowsToRowIter(rows...), nil
+}
+
+func (wt *WorkspacesTable) sessions(name string) int {
+	if wt.workspaces == nil {
+		return 0
+	}
+
+	return wt.workspaces.Sessions(wt.dbName, name)
+}
+
+// Deleter returns a RowDeleter for this table. The RowDeleter will get one call to Delete for each row to be deleted,
+// and will end with a call to Close() to finalize the delete operation.
+func (wt *WorkspacesTable) Deleter(*sql.Context) sql.RowDeleter {
+	return workspaceDeleter{wt}
+}
+
+var _ sql.RowDeleter = workspaceDeleter{nil}
+
+type workspaceDeleter struct {
+	wt *WorkspacesTable
+}
+
+// Delete deletes the workspace of the given row, returning ErrWorkspaceInUse if a session is using it. Returns
+// ErrDeleteRowNotFound if the workspace has no ref.
+func (wd workspaceDeleter) Delete(ctx *sql.Context, r sql.Row) error {
+	name, ok := r[0].(string)
+
+	if !ok {
+		return errors.New("invalid value type for workspace")
+	}
+
+	if sessions := wd.wt.sessions(name); sessions > 0 {
+		return ErrWorkspaceInUse.New(name, sessions)
+	}
+
+	err := actions.DeleteWorkspace(ctx, wd.wt.dbd.ddb, name)
+
+	if err == doltdb.ErrWorkspaceNotFound {
+		return sql.ErrDeleteRowNotFound
+	}
+
+	return err
+}
+
+// Close finalizes the delete operation, persisting the result.
+func (wd workspaceDeleter) Close(*sql.Context) error {
+	return nil
+}
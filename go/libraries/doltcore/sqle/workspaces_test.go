@@ -0,0 +1,94 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+)
+
+func TestWorkspaceMode(t *testing.T) {
+	for _, mode := range []WorkspaceMode{NoWorkspaces, SessionWorkspaces, UserWorkspaces} {
+		assert.True(t, mode.IsValid(), string(mode))
+	}
+	assert.False(t, WorkspaceMode("everyone").IsValid())
+
+	assert.Equal(t, "alice", NewWorkspaces(UserWorkspaces).Name("alice", 7))
+	name := NewWorkspaces(SessionWorkspaces).Name("alice", 7)
+	assert.True(t, strings.HasPrefix(name, "alice/"), name)
+	assert.True(t, strings.HasSuffix(name, "-7"), name)
+	assert.True(t, doltdb.IsValidUserBranchName(name), name)
+}
+
+// newWorkspaceSession returns the context of a session of |user| which accesses the test's database through its
+// workspace.
+func (tt *transactionTest) newWorkspaceSession(workspaces *Workspaces, user string, connID uint32) *sql.Context {
+	mysqlSess := sql.NewSession("localhost", "127.0.0.1", user, connID)
+	sess, err := NewDoltSession(context.Background(), mysqlSess, "billy bob", "bigbillieb@fake.horse", tt.db)
+	require.NoError(tt.t, err)
+
+	ctx := sql.NewContext(context.Background(), sql.WithSession(sess), sql.WithIndexRegistry(sql.NewIndexRegistry()), sql.WithViewRegistry(sql.NewViewRegistry()))
+	ctx.SetCurrentDatabase(tt.db.Name())
+	require.NoError(tt.t, sess.UseWorkspace(ctx, tt.db, workspaces, workspaces.Name(user, connID)))
+
+	return ctx
+}
+
+func TestSessionWorkspaces(t *testing.T) {
+	tt := newTransactionTest(t)
+	workspaces := NewWorkspaces(SessionWorkspaces)
+
+	s1 := tt.newWorkspaceSession(workspaces, "alice", 1)
+	tt.mustExec(s1, "create table t (pk int primary key, c1 int)")
+	tt.mustExec(s1, "insert into t values (1, 1), (2, 2)")
+	_, err := DSessFromSess(s1.Session).CommitWorkspace(s1, tt.db.Name(), "add t")
+	require.NoError(t, err)
+	_, err = DSessFromSess(s1.Session).CommitWorkspace(s1, tt.db.Name(), "again")
+	assert.True(t, ErrNothingToCommit.Is(err), "unexpected error: %v", err)
+
+	// sessions of the same user don't see each other's changes
+	s2 := tt.newWorkspaceSession(workspaces, "bob", 2)
+	s3 := tt.newWorkspaceSession(workspaces, "bob", 3)
+	tt.mustExec(s2, "update t set c1 = 10 where pk = 1")
+	assert.Equal(t, []sql.Row{{int32(1), int32(1)}}, tt.mustExec(s3, "select * from t where pk = 1"))
+	tt.mustExec(s3, "insert into t values (3, 3)")
+
+	// changes to different rows are merged, and changes to the same row conflict
+	_, err = DSessFromSess(s2.Session).CommitWorkspace(s2, tt.db.Name(), "update 1")
+	require.NoError(t, err)
+	_, err = DSessFromSess(s3.Session).CommitWorkspace(s3, tt.db.Name(), "insert 3")
+	require.NoError(t, err)
+	assert.Equal(t, []sql.Row{{int32(1), int32(10)}, {int32(2), int32(2)}, {int32(3), int32(3)}}, tt.mustExec(s3, "select * from t order by pk"))
+
+	s4 := tt.newWorkspaceSession(workspaces, "carol", 4)
+	tt.mustExec(s4, "update t set c1 = 20 where pk = 2")
+	tt.mustExec(s2, "update t set c1 = 30 where pk = 2")
+	_, err = DSessFromSess(s2.Session).CommitWorkspace(s2, tt.db.Name(), "update 2")
+	require.NoError(t, err)
+	_, err = DSessFromSess(s4.Session).CommitWorkspace(s4, tt.db.Name(), "conflicting update 2")
+	assert.True(t, ErrWorkspaceConflict.Is(err), "unexpected error: %v", err)
+
+	// workspaces used by sessions can't be deleted
+	assert.Equal(t, 1, workspaces.Sessions(tt.db.Name(), workspaces.Name("carol", 4)))
+	_, err = tt.exec(s1, "delete from dolt_workspaces where name = '"+workspaces.Name("carol", 4)+"'")
+	assert.True(t, ErrWorkspaceInUse.Is(err), "unexpected error: %v", err)
+}
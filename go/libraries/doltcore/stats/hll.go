@@ -0,0 +1,66 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stats
+
+import (
+	"math"
+	"math/bits"
+)
+
+// hllPrecision is the number of bits of each hash used to choose a register. The standard error of the estimate is
+// about 1.04 / sqrt(2^hllPrecision), or 0.8%.
+const hllPrecision = 14
+
+// hyperLogLog estimates the number of distinct hashes added to it in a fixed amount of memory.
+type hyperLogLog struct {
+	registers [1 << hllPrecision]uint8
+}
+
+// add adds a uniformly distributed hash to the estimate.
+func (hll *hyperLogLog) add(h uint64) {
+	idx := h >> (64 - hllPrecision)
+
+	// the bit set below the remaining bits bounds the rank when they're all zero
+	rank := uint8(bits.LeadingZeros64(h<<hllPrecision|1<<(hllPrecision-1)) + 1)
+
+	if rank > hll.registers[idx] {
+		hll.registers[idx] = rank
+	}
+}
+
+// estimate returns the estimated number of distinct hashes added.
+func (hll *hyperLogLog) estimate() uint64 {
+	m := float64(len(hll.registers))
+	alpha := 0.7213 / (1 + 1.079/m)
+
+	sum := 0.0
+	zeros := 0
+	for _, r := range hll.registers {
+		sum += math.Ldexp(1, -int(r))
+
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	est := alpha * m * m / sum
+
+	// small cardinalities are estimated much more accurately by counting the registers which are still empty
+	if est <= 2.5*m && zeros > 0 {
+		est = m * math.Log(m/float64(zeros))
+	}
+
+	return uint64(est + 0.5)
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stats
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+func TestHyperLogLog(t *testing.T) {
+	tests := []struct {
+		distinct  int
+		tolerance float64
+	}{
+		{0, 0},
+		{1, 0},
+		{100, 0.02},
+		{10000, 0.03},
+		{200000, 0.03},
+	}
+
+	for _, test := range tests {
+		hll := &hyperLogLog{}
+		for i := 0; i < test.distinct; i++ {
+			buf := make([]byte, 8)
+			binary.BigEndian.PutUint64(buf, uint64(i))
+			h := hash.Of(buf)
+
+			// adding a value again doesn't change the estimate
+			hll.add(binary.BigEndian.Uint64(h[:8]))
+			hll.add(binary.BigEndian.Uint64(h[:8]))
+		}
+
+		est := float64(hll.estimate())
+		assert.LessOrEqual(t, math.Abs(est-float64(test.distinct)), test.tolerance*float64(test.distinct), "estimated %v distinct values of %d", est, test.distinct)
+	}
+}
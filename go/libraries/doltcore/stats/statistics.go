@@ -0,0 +1,551 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stats
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"math/rand"
+	"sort"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/atomicerr"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const (
+	// MaxSampleRows is the maximum number of rows sampled to build the histograms of a table's columns. Every row is
+	// read to count nulls and distinct values, but only the sampled rows are sorted.
+	MaxSampleRows = 10000
+
+	// HistogramBuckets is the maximum number of buckets in a column's histogram.
+	HistogramBuckets = 16
+
+	// StaleFraction is the fraction of a table's rows which can be inserted, updated or deleted before the statistics
+	// of the table are stale.
+	StaleFraction = 0.1
+)
+
+// Bucket is a bucket of an equi-depth histogram, holding the values greater than the upper bound of the previous
+// bucket and at most UpperBound.
+type Bucket struct {
+	UpperBound types.Value
+
+	// RowCount is the estimated number of rows with values in the bucket.
+	RowCount uint64
+}
+
+// ColumnStatistics describes the distribution of the values of a column.
+type ColumnStatistics struct {
+	Name string
+	Tag  uint64
+
+	NullCount uint64
+
+	// DistinctCount is the estimated number of distinct non-null values.
+	DistinctCount uint64
+
+	// Min and Max are the least and greatest non-null values, or nil if every value is null.
+	Min types.Value
+	Max types.Value
+
+	// Histogram divides the non-null values into buckets holding about the same number of rows. A value is never split
+	// across buckets, so frequent values make for fewer, larger buckets.
+	Histogram []Bucket
+}
+
+// TableStatistics describes the rows of a table, as built by Analyze. They're used to estimate how many rows a filter
+// on a column matches.
+type TableStatistics struct {
+	RowCount uint64
+
+	// RowData is the hash of the row data the statistics were built from.
+	RowData hash.Hash
+
+	// SampleSize is the number of rows the histograms were built from.
+	SampleSize uint64
+
+	// Columns are the statistics of each column, in schema order.
+	Columns []*ColumnStatistics
+}
+
+type columnBuilder struct {
+	hll    *hyperLogLog
+	nulls  uint64
+	min    types.Value
+	max    types.Value
+	sample []types.Value
+}
+
+// Analyze builds the statistics of the rows of |tbl|. Null and distinct counts and the least and greatest values are
+// computed from every row, and histograms from a sample of at most MaxSampleRows rows. The sample is seeded with the
+// hash of the row data, so the same rows always produce the same statistics.
+func Analyze(ctx context.Context, tbl *doltdb.Table) (*TableStatistics, error) {
+	sch, err := tbl.GetSchema(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rowData, err := tbl.GetRowData(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	nbf := tbl.Format()
+	rowDataHash, err := rowData.Hash(nbf)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cols := sch.GetAllCols().GetColumns()
+	builders := make([]*columnBuilder, len(cols))
+	for i := range builders {
+		builders[i] = &columnBuilder{hll: &hyperLogLog{}}
+	}
+
+	rng := rand.New(rand.NewSource(int64(binary.BigEndian.Uint64(rowDataHash[:8]))))
+
+	var sample []row.Row
+	var rowCount uint64
+	err = rowData.IterAll(ctx, func(k, v types.Value) error {
+		r, err := row.FromNoms(sch, k.(types.Tuple), v.(types.Tuple))
+
+		if err != nil {
+			return err
+		}
+
+		rowCount++
+		for i, col := range cols {
+			err = builders[i].add(nbf, r, col.Tag)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		// reservoir sampling keeps each row with the same probability without knowing the number of rows up front
+		if len(sample) < MaxSampleRows {
+			sample = append(sample, r)
+		} else if j := rng.Int63n(int64(rowCount)); j < MaxSampleRows {
+			sample[j] = r
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	ts := &TableStatistics{RowCount: rowCount, RowData: rowDataHash, SampleSize: uint64(len(sample))}
+	for i, col := range cols {
+		b := builders[i]
+		for _, r := range sample {
+			if val, ok := r.GetColVal(col.Tag); ok && !types.IsNull(val) {
+				b.sample = append(b.sample, val)
+			}
+		}
+
+		nonNull := rowCount - b.nulls
+		distinct := b.hll.estimate()
+
+		if distinct > nonNull || (sch.GetPKCols().Size() == 1 && col.IsPartOfPK) {
+			distinct = nonNull
+		} else if distinct == 0 && nonNull > 0 {
+			distinct = 1
+		}
+
+		histogram, err := buildHistogram(nbf, b.sample, nonNull)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ts.Columns = append(ts.Columns, &ColumnStatistics{
+			Name:          col.Name,
+			Tag:           col.Tag,
+			NullCount:     b.nulls,
+			DistinctCount: distinct,
+			Min:           b.min,
+			Max:           b.max,
+			Histogram:     histogram,
+		})
+	}
+
+	return ts, nil
+}
+
+func (b *columnBuilder) add(nbf *types.NomsBinFormat, r row.Row, tag uint64) error {
+	val, ok := r.GetColVal(tag)
+
+	if !ok || types.IsNull(val) {
+		b.nulls++
+		return nil
+	}
+
+	h, err := val.Hash(nbf)
+
+	if err != nil {
+		return err
+	}
+
+	b.hll.add(binary.BigEndian.Uint64(h[:8]))
+
+	if b.min == nil {
+		b.min, b.max = val, val
+		return nil
+	}
+
+	if less, err := val.Less(nbf, b.min); err != nil {
+		return err
+	} else if less {
+		b.min = val
+	}
+
+	if less, err := b.max.Less(nbf, val); err != nil {
+		return err
+	} else if less {
+		b.max = val
+	}
+
+	return nil
+}
+
+// buildHistogram returns the equi-depth histogram of the sampled values given, scaled to |nonNull| rows.
+func buildHistogram(nbf *types.NomsBinFormat, vals []types.Value, nonNull uint64) ([]Bucket, error) {
+	if len(vals) == 0 {
+		return nil, nil
+	}
+
+	var err error
+	sort.Slice(vals, func(i, j int) bool {
+		if err != nil {
+			return false
+		}
+
+		var less bool
+		less, err = vals[i].Less(nbf, vals[j])
+		return less
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	numBuckets := HistogramBuckets
+	if len(vals) < numBuckets {
+		numBuckets = len(vals)
+	}
+
+	scale := float64(nonNull) / float64(len(vals))
+
+	var buckets []Bucket
+	prev := 0
+	for i := 1; i <= numBuckets; i++ {
+		end := i * len(vals) / numBuckets
+
+		if end <= prev {
+			continue
+		}
+
+		for end < len(vals) && vals[end].Equals(vals[end-1]) {
+			end++
+		}
+
+		buckets = append(buckets, Bucket{UpperBound: vals[end-1], RowCount: uint64(float64(end-prev)*scale + 0.5)})
+		prev = end
+	}
+
+	return buckets, nil
+}
+
+// Column returns the statistics of the column with the tag given, or nil if the column wasn't analyzed.
+func (ts *TableStatistics) Column(tag uint64) *ColumnStatistics {
+	for _, cs := range ts.Columns {
+		if cs.Tag == tag {
+			return cs
+		}
+	}
+
+	return nil
+}
+
+// NullFraction returns the fraction of the rows of the table in which the column given is null.
+func (ts *TableStatistics) NullFraction(cs *ColumnStatistics) float64 {
+	if ts.RowCount == 0 {
+		return 0
+	}
+
+	return float64(cs.NullCount) / float64(ts.RowCount)
+}
+
+// EstimateEqual returns the estimated number of rows in which the column given is equal to |val|.
+func (ts *TableStatistics) EstimateEqual(nbf *types.NomsBinFormat, cs *ColumnStatistics, val types.Value) (float64, error) {
+	if types.IsNull(val) {
+		return float64(cs.NullCount), nil
+	} else if cs.DistinctCount == 0 {
+		return 0, nil
+	}
+
+	if outside, err := cs.outsideRange(nbf, val); err != nil || outside {
+		return 0, err
+	}
+
+	return float64(ts.RowCount-cs.NullCount) / float64(cs.DistinctCount), nil
+}
+
+// EstimateRange returns the estimated number of rows in which the column given is at least |lower| and at most
+// |upper|. A nil bound leaves that end of the range unbounded. Buckets which are partially in the range are assumed to
+// have half of their rows in it.
+func (ts *TableStatistics) EstimateRange(nbf *types.NomsBinFormat, cs *ColumnStatistics, lower, upper types.Value) (float64, error) {
+	var rows float64
+	var bucketLower types.Value
+	for _, b := range cs.Histogram {
+		if bucketLower == nil {
+			bucketLower = cs.Min
+		}
+
+		// the bucket is below the range
+		if lower != nil {
+			if less, err := b.UpperBound.Less(nbf, lower); err != nil {
+				return 0, err
+			} else if less {
+				bucketLower = b.UpperBound
+				continue
+			}
+		}
+
+		// the bucket is above the range
+		if upper != nil && bucketLower != nil {
+			if less, err := upper.Less(nbf, bucketLower); err != nil {
+				return 0, err
+			} else if less {
+				break
+			}
+		}
+
+		contained := true
+		if lower != nil && bucketLower != nil {
+			if less, err := bucketLower.Less(nbf, lower); err != nil {
+				return 0, err
+			} else if less {
+				contained = false
+			}
+		}
+
+		if upper != nil {
+			if less, err := upper.Less(nbf, b.UpperBound); err != nil {
+				return 0, err
+			} else if less {
+				contained = false
+			}
+		}
+
+		if contained {
+			rows += float64(b.RowCount)
+		} else {
+			rows += float64(b.RowCount) / 2
+		}
+
+		bucketLower = b.UpperBound
+	}
+
+	return rows, nil
+}
+
+func (cs *ColumnStatistics) outsideRange(nbf *types.NomsBinFormat, val types.Value) (bool, error) {
+	if cs.Min == nil {
+		return true, nil
+	}
+
+	if less, err := val.Less(nbf, cs.Min); err != nil || less {
+		return less, err
+	}
+
+	return cs.Max.Less(nbf, val)
+}
+
+// IsStale returns whether the statistics no longer describe the rows of |tbl|. They're stale once more than
+// StaleFraction of the rows have been inserted, updated or deleted since they were built, or after the table's columns
+// have changed.
+func (ts *TableStatistics) IsStale(ctx context.Context, tbl *doltdb.Table) (bool, error) {
+	sch, err := tbl.GetSchema(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	if sch.GetAllCols().Size() != len(ts.Columns) {
+		return true, nil
+	}
+
+	for _, cs := range ts.Columns {
+		if _, ok := sch.GetAllCols().GetByTag(cs.Tag); !ok {
+			return true, nil
+		}
+	}
+
+	rowData, err := tbl.GetRowData(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	h, err := rowData.Hash(tbl.Format())
+
+	if err != nil {
+		return false, err
+	} else if h == ts.RowData {
+		return false, nil
+	}
+
+	val, err := tbl.ValueReadWriter().ReadValue(ctx, ts.RowData)
+
+	if err != nil {
+		return false, err
+	}
+
+	analyzed, ok := val.(types.Map)
+
+	if !ok {
+		return true, nil
+	}
+
+	rowCount := ts.RowCount
+	if rowData.Len() > rowCount {
+		rowCount = rowData.Len()
+	}
+
+	changed, err := countChanges(ctx, rowData, analyzed, uint64(StaleFraction*float64(rowCount))+1)
+
+	if err != nil {
+		return false, err
+	}
+
+	return float64(changed) > StaleFraction*float64(rowCount), nil
+}
+
+// countChanges returns the number of rows which differ between the row data given, counting no more than |limit|.
+func countChanges(ctx context.Context, rows, fromRows types.Map, limit uint64) (uint64, error) {
+	ae := atomicerr.New()
+	changes := make(chan types.ValueChanged, 32)
+	stop := make(chan struct{})
+
+	go func() {
+		defer close(changes)
+		rows.Diff(ctx, fromRows, ae, changes, stop)
+	}()
+
+	var count uint64
+	for range changes {
+		count++
+
+		if count >= limit {
+			close(stop)
+			for range changes {
+			}
+
+			break
+		}
+	}
+
+	if err := ae.Get(); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+type encodedBucket struct {
+	UpperBound string `json:"upper_bound"`
+	RowCount   uint64 `json:"row_count"`
+}
+
+// FormatValue returns the value given as a string of the column's type, or nil if it's nil.
+func FormatValue(col schema.Column, val types.Value) (*string, error) {
+	if val == nil {
+		return nil, nil
+	}
+
+	return col.TypeInfo.FormatValue(val)
+}
+
+// ParseValue parses a string formatted by FormatValue.
+func ParseValue(col schema.Column, str *string) (types.Value, error) {
+	if str == nil {
+		return nil, nil
+	}
+
+	val, err := col.TypeInfo.ParseValue(str)
+
+	if err != nil || types.IsNull(val) {
+		return nil, err
+	}
+
+	return val, nil
+}
+
+// EncodeHistogram returns the histogram given as JSON, with the upper bounds of its buckets formatted as strings of
+// the column's type.
+func EncodeHistogram(col schema.Column, histogram []Bucket) (string, error) {
+	encoded := make([]encodedBucket, len(histogram))
+	for i, b := range histogram {
+		str, err := FormatValue(col, b.UpperBound)
+
+		if err != nil {
+			return "", err
+		}
+
+		encoded[i] = encodedBucket{*str, b.RowCount}
+	}
+
+	data, err := json.Marshal(encoded)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// DecodeHistogram parses a histogram encoded by EncodeHistogram.
+func DecodeHistogram(col schema.Column, data string) ([]Bucket, error) {
+	var encoded []encodedBucket
+	err := json.Unmarshal([]byte(data), &encoded)
+
+	if err != nil {
+		return nil, err
+	}
+
+	histogram := make([]Bucket, len(encoded))
+	for i, b := range encoded {
+		val, err := ParseValue(col, &b.UpperBound)
+
+		if err != nil {
+			return nil, err
+		}
+
+		histogram[i] = Bucket{val, b.RowCount}
+	}
+
+	return histogram, nil
+}
@@ -0,0 +1,250 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stats
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const (
+	idTag uint64 = iota
+	categoryTag
+	scoreTag
+)
+
+var testSch = dtestutils.MustSchema(
+	schema.NewColumn("id", idTag, types.IntKind, true, schema.NotNullConstraint{}),
+	schema.NewColumn("category", categoryTag, types.StringKind, false),
+	schema.NewColumn("score", scoreTag, types.IntKind, false),
+)
+
+// testRow returns the row with the id given. One of every 10 rows has a null category, and the other rows have one of
+// 5 categories. Scores count up from 0.
+func testRow(id int) row.Row {
+	var category types.Value = types.NullValue
+	if id%10 != 0 {
+		category = types.String(fmt.Sprintf("category%d", id%5))
+	}
+
+	return dtestutils.NewRow(testSch, types.Int(id), category, types.Int(id))
+}
+
+func createTestTable(t *testing.T, numRows int) *doltdb.Table {
+	dEnv := dtestutils.CreateTestEnv()
+	dtestutils.CreateTestTable(t, dEnv, "test", testSch)
+	root, err := dEnv.WorkingRoot(context.Background())
+	require.NoError(t, err)
+
+	tbl, ok, err := root.GetTable(context.Background(), "test")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ids := make([]int, numRows)
+	for i := range ids {
+		ids[i] = i
+	}
+
+	return editRows(t, tbl, ids, func(r row.Row) row.Row { return r })
+}
+
+// editRows sets the rows of |tbl| with the ids given to the result of |edit|, or removes them if it returns nil.
+func editRows(t *testing.T, tbl *doltdb.Table, ids []int, edit func(row.Row) row.Row) *doltdb.Table {
+	ctx := context.Background()
+	rowData, err := tbl.GetRowData(ctx)
+	require.NoError(t, err)
+
+	ed := rowData.Edit()
+	for _, id := range ids {
+		r := testRow(id)
+		if edited := edit(r); edited != nil {
+			ed.Set(edited.NomsMapKey(testSch), edited.NomsMapValue(testSch))
+		} else {
+			ed.Remove(r.NomsMapKey(testSch))
+		}
+	}
+
+	rowData, err = ed.Map(ctx)
+	require.NoError(t, err)
+	tbl, err = tbl.UpdateRows(ctx, rowData)
+	require.NoError(t, err)
+
+	return tbl
+}
+
+func TestAnalyze(t *testing.T) {
+	ctx := context.Background()
+	tbl := createTestTable(t, 1000)
+	ts, err := Analyze(ctx, tbl)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(1000), ts.RowCount)
+	assert.Equal(t, uint64(1000), ts.SampleSize)
+	require.Len(t, ts.Columns, 3)
+
+	id := ts.Column(idTag)
+	assert.Equal(t, "id", id.Name)
+	assert.Equal(t, uint64(0), id.NullCount)
+	assert.Equal(t, uint64(1000), id.DistinctCount)
+	assert.Equal(t, types.Int(0), id.Min)
+	assert.Equal(t, types.Int(999), id.Max)
+	assert.Len(t, id.Histogram, HistogramBuckets)
+
+	var total uint64
+	for _, b := range id.Histogram {
+		assert.InDelta(t, 1000/HistogramBuckets, b.RowCount, 1, "buckets hold about the same number of rows")
+		total += b.RowCount
+	}
+	assert.Equal(t, uint64(1000), total)
+	assert.Equal(t, types.Int(999), id.Histogram[len(id.Histogram)-1].UpperBound)
+
+	category := ts.Column(categoryTag)
+	assert.Equal(t, uint64(100), category.NullCount)
+	assert.Equal(t, uint64(5), category.DistinctCount)
+	assert.InDelta(t, 0.1, ts.NullFraction(category), 0.0001)
+	assert.Equal(t, types.String("category0"), category.Min)
+	assert.Equal(t, types.String("category4"), category.Max)
+
+	// values aren't split across buckets, so each category has a bucket of its own
+	require.Len(t, category.Histogram, 5)
+	for i, b := range category.Histogram {
+		assert.Equal(t, types.String(fmt.Sprintf("category%d", i)), b.UpperBound)
+	}
+
+	again, err := Analyze(ctx, tbl)
+	require.NoError(t, err)
+	assert.Equal(t, ts, again)
+}
+
+func TestAnalyzeSamplesLargeTables(t *testing.T) {
+	tbl := createTestTable(t, MaxSampleRows*3)
+	ts, err := Analyze(context.Background(), tbl)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(MaxSampleRows*3), ts.RowCount)
+	assert.Equal(t, uint64(MaxSampleRows), ts.SampleSize)
+
+	score := ts.Column(scoreTag)
+	assert.Equal(t, types.Int(0), score.Min)
+	assert.Equal(t, types.Int(MaxSampleRows*3-1), score.Max)
+	assert.InDelta(t, MaxSampleRows*3, score.DistinctCount, MaxSampleRows*3*0.03)
+
+	var total uint64
+	for _, b := range score.Histogram {
+		total += b.RowCount
+	}
+	assert.InDelta(t, MaxSampleRows*3, total, float64(len(score.Histogram)))
+}
+
+func TestEstimates(t *testing.T) {
+	nbf := types.Format_Default
+	tbl := createTestTable(t, 1000)
+	ts, err := Analyze(context.Background(), tbl)
+	require.NoError(t, err)
+
+	category := ts.Column(categoryTag)
+	rows, err := ts.EstimateEqual(nbf, category, types.String("category1"))
+	require.NoError(t, err)
+	assert.Equal(t, float64(180), rows)
+
+	rows, err = ts.EstimateEqual(nbf, category, types.String("other"))
+	require.NoError(t, err)
+	assert.Equal(t, float64(0), rows)
+
+	rows, err = ts.EstimateEqual(nbf, category, types.NullValue)
+	require.NoError(t, err)
+	assert.Equal(t, float64(100), rows)
+
+	score := ts.Column(scoreTag)
+	tests := []struct {
+		lower, upper types.Value
+		expected     float64
+	}{
+		{nil, nil, 1000},
+		{types.Int(0), types.Int(999), 1000},
+		{types.Int(500), nil, 500},
+		{nil, types.Int(250), 250},
+		{types.Int(1000), nil, 0},
+	}
+
+	for _, test := range tests {
+		rows, err = ts.EstimateRange(nbf, score, test.lower, test.upper)
+		require.NoError(t, err)
+		assert.InDelta(t, test.expected, rows, 1000/HistogramBuckets, "%v to %v", test.lower, test.upper)
+	}
+}
+
+func TestIsStale(t *testing.T) {
+	ctx := context.Background()
+	tbl := createTestTable(t, 100)
+	ts, err := Analyze(ctx, tbl)
+	require.NoError(t, err)
+
+	stale, err := ts.IsStale(ctx, tbl)
+	require.NoError(t, err)
+	assert.False(t, stale)
+
+	// up to 10% of the rows can change
+	tbl = editRows(t, tbl, []int{1, 2, 3, 4, 5}, func(r row.Row) row.Row {
+		r, err := r.SetColVal(scoreTag, types.Int(-1), testSch)
+		require.NoError(t, err)
+		return r
+	})
+	tbl = editRows(t, tbl, []int{6, 7, 8, 9, 10}, func(row.Row) row.Row { return nil })
+	stale, err = ts.IsStale(ctx, tbl)
+	require.NoError(t, err)
+	assert.False(t, stale)
+
+	tbl = editRows(t, tbl, []int{11}, func(row.Row) row.Row { return nil })
+	stale, err = ts.IsStale(ctx, tbl)
+	require.NoError(t, err)
+	assert.True(t, stale)
+}
+
+func TestHistogramEncoding(t *testing.T) {
+	tbl := createTestTable(t, 100)
+	ts, err := Analyze(context.Background(), tbl)
+	require.NoError(t, err)
+
+	for _, col := range testSch.GetAllCols().GetColumns() {
+		cs := ts.Column(col.Tag)
+		encoded, err := EncodeHistogram(col, cs.Histogram)
+		require.NoError(t, err)
+
+		decoded, err := DecodeHistogram(col, encoded)
+		require.NoError(t, err)
+		assert.Equal(t, cs.Histogram, decoded)
+
+		min, err := FormatValue(col, cs.Min)
+		require.NoError(t, err)
+		parsed, err := ParseValue(col, min)
+		require.NoError(t, err)
+		assert.Equal(t, cs.Min, parsed)
+	}
+
+	encoded, err := EncodeHistogram(testSch.GetAllCols().GetByIndex(0), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "[]", encoded)
+}
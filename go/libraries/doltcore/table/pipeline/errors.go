@@ -15,6 +15,7 @@
 package pipeline
 
 import (
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/errcat"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
 )
 
@@ -31,6 +32,11 @@ func (trf *TransformRowFailure) Error() string {
 	return trf.TransformName + " failed processing"
 }
 
+// Category implements errcat.Categorized. A row which fails to transform doesn't fit the schema it's being moved to.
+func (trf *TransformRowFailure) Category() errcat.Category {
+	return errcat.ConstraintViolation
+}
+
 // IsTransformFailure will return true if the error is an instance of a TransformRowFailure
 func IsTransformFailure(err error) bool {
 	_, ok := err.(*TransformRowFailure)
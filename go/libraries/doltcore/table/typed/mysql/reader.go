@@ -0,0 +1,222 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/typeinfo"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// ReadOptions limit the rows read by a MySQLReader.
+type ReadOptions struct {
+	// Where is a condition which is added to the query, so that only the matching rows are sent by the server.
+	Where string
+
+	// After holds the primary key values of a row, in primary key order. Only the rows after it are read.
+	After []string
+
+	// Limit is the maximum number of rows to read, or 0 to read every row.
+	Limit uint64
+}
+
+// MySQLReader is a TableReadCloser which reads the rows of a MySQL table in primary key order.
+type MySQLReader struct {
+	nbf    *types.NomsBinFormat
+	sch    schema.Schema
+	cols   []schema.Column
+	pkIdxs []int
+	rows   *sql.Rows
+	vals   []sql.NullString
+	dest   []interface{}
+
+	lastKey []string
+	numRows uint64
+}
+
+// NewMySQLReader queries the rows of |tblName| matching |opts|. |sch| and |pkCols| are the schema and primary key of the
+// table read by ReadSchema.
+func NewMySQLReader(ctx context.Context, nbf *types.NomsBinFormat, db *sql.DB, tblName string, sch schema.Schema, pkCols []string, opts ReadOptions) (*MySQLReader, error) {
+	cols := sch.GetAllCols().GetColumns()
+	colNames := make([]string, len(cols))
+	colIdx := make(map[string]int)
+	for i, col := range cols {
+		colNames[i] = QuoteIdentifier(col.Name)
+		colIdx[strings.ToLower(col.Name)] = i
+	}
+
+	pkIdxs := make([]int, len(pkCols))
+	quotedPks := make([]string, len(pkCols))
+	for i, pkCol := range pkCols {
+		idx, ok := colIdx[strings.ToLower(pkCol)]
+
+		if !ok {
+			return nil, fmt.Errorf("primary key column %s is not a column of %s", pkCol, tblName)
+		}
+
+		pkIdxs[i] = idx
+		quotedPks[i] = QuoteIdentifier(pkCol)
+	}
+
+	var conditions []string
+	var args []interface{}
+	if opts.Where != "" {
+		conditions = append(conditions, "("+opts.Where+")")
+	}
+
+	if len(opts.After) > 0 {
+		if len(opts.After) != len(pkCols) {
+			return nil, fmt.Errorf("expected %d primary key values to resume %s after, got %d", len(pkCols), tblName, len(opts.After))
+		}
+
+		// (a, b) > (x, y) expanded to a > x OR (a = x AND b > y), as not every server can compare rows
+		var disjuncts []string
+		for i := range quotedPks {
+			var conjuncts []string
+			for j := 0; j < i; j++ {
+				conjuncts = append(conjuncts, quotedPks[j]+" = ?")
+				args = append(args, opts.After[j])
+			}
+
+			conjuncts = append(conjuncts, quotedPks[i]+" > ?")
+			args = append(args, opts.After[i])
+			disjuncts = append(disjuncts, "("+strings.Join(conjuncts, " AND ")+")")
+		}
+
+		conditions = append(conditions, "("+strings.Join(disjuncts, " OR ")+")")
+	}
+
+	query := "SELECT " + strings.Join(colNames, ", ") + " FROM " + QuoteIdentifier(tblName)
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query += " ORDER BY " + strings.Join(quotedPks, ", ")
+
+	if opts.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", opts.Limit)
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	vals := make([]sql.NullString, len(cols))
+	dest := make([]interface{}, len(cols))
+	for i := range vals {
+		dest[i] = &vals[i]
+	}
+
+	return &MySQLReader{nbf: nbf, sch: sch, cols: cols, pkIdxs: pkIdxs, rows: rows, vals: vals, dest: dest}, nil
+}
+
+// GetSchema gets the schema of the rows that this reader will return
+func (rd *MySQLReader) GetSchema() schema.Schema {
+	return rd.sch
+}
+
+// ReadRow reads a row from a table.  If there is a bad row the returned error will be non nil, and calling IsBadRow(err)
+// will be return true. This is a potentially non-fatal error and callers can decide if they want to continue on a bad row, or fail.
+func (rd *MySQLReader) ReadRow(ctx context.Context) (row.Row, error) {
+	if !rd.rows.Next() {
+		if err := rd.rows.Err(); err != nil {
+			return nil, err
+		}
+
+		return nil, io.EOF
+	}
+
+	err := rd.rows.Scan(rd.dest...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rd.numRows++
+	rd.lastKey = make([]string, len(rd.pkIdxs))
+	for i, idx := range rd.pkIdxs {
+		rd.lastKey[i] = rd.vals[idx].String
+	}
+
+	taggedVals := make(row.TaggedValues)
+	for i, col := range rd.cols {
+		if !rd.vals[i].Valid {
+			continue
+		}
+
+		val, err := convertValue(col.TypeInfo, rd.vals[i].String)
+
+		if err != nil {
+			return nil, table.NewBadRow(nil, fmt.Sprintf("column %s: %s", col.Name, err.Error()))
+		}
+
+		taggedVals[col.Tag] = val
+	}
+
+	r, err := row.New(rd.nbf, rd.sch, taggedVals)
+
+	if err != nil {
+		return nil, table.NewBadRow(nil, err.Error())
+	}
+
+	return r, nil
+}
+
+// LastKey returns the primary key values of the last row read, in primary key order, or nil if no rows were read. It
+// can be used as ReadOptions.After to continue reading where this reader stopped.
+func (rd *MySQLReader) LastKey() []string {
+	return rd.lastKey
+}
+
+// NumRows returns the number of rows read.
+func (rd *MySQLReader) NumRows() uint64 {
+	return rd.numRows
+}
+
+// VerifySchema checks that the incoming schema matches the schema from the existing table
+func (rd *MySQLReader) VerifySchema(outSch schema.Schema) (bool, error) {
+	return schema.VerifyInSchema(rd.sch, outSch)
+}
+
+// Close should release resources being held
+func (rd *MySQLReader) Close(ctx context.Context) error {
+	return rd.rows.Close()
+}
+
+// convertValue converts a value sent by a MySQL server to a noms value of the type |ti|.
+func convertValue(ti typeinfo.TypeInfo, val string) (types.Value, error) {
+	if ti.GetTypeIdentifier() == typeinfo.BitTypeIdentifier {
+		// bits are sent as big endian binary
+		var bits uint64
+		for i := 0; i < len(val); i++ {
+			bits = bits<<8 | uint64(val[i])
+		}
+
+		return ti.ConvertValueToNomsValue(bits)
+	}
+
+	return ti.ConvertValueToNomsValue(val)
+}
@@ -0,0 +1,270 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mysql reads the schemas and rows of tables from a MySQL server.
+//
+// The MySQL type of each column is converted to a dolt type as follows:
+//
+//	tinyint, smallint, mediumint, int, bigint (signed or unsigned)  the same integer type
+//	bool, boolean                                                   tinyint
+//	float, double, real                                             float, double
+//	decimal, numeric                                                decimal with the same precision and scale
+//	bit                                                             bit of the same width
+//	date, time, datetime, timestamp, year                           the same type
+//	char, varchar, tinytext, text, mediumtext, longtext             the same type and collation
+//	enum, set                                                       the same type, values and collation
+//	json                                                            longtext holding the JSON document
+//
+// Binary strings (binary, varbinary and the blob types) and spatial types aren't supported by dolt yet, and tables with
+// columns of these types can't be read.
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	gmssql "github.com/src-d/go-mysql-server/sql"
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/typeinfo"
+	dsql "github.com/liquidata-inc/dolt/go/libraries/doltcore/sql"
+)
+
+// ErrNoPrimaryKey is returned when reading the schema of a table without a primary key.
+var ErrNoPrimaryKey = errors.New("table has no primary key")
+
+var yearWidthRegex = regexp.MustCompile(`(?i)^year\(\d+\)`)
+
+// QuoteIdentifier quotes a MySQL identifier with backticks.
+func QuoteIdentifier(s string) string {
+	return "`" + strings.ReplaceAll(s, "`", "``") + "`"
+}
+
+// ReadTableNames returns the names of the base tables of the database |db| is connected to, in sorted order. Views
+// aren't included.
+func ReadTableNames(ctx context.Context, db *sql.DB) ([]string, error) {
+	rows, err := queryByColumnName(ctx, db, "SHOW FULL TABLES")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, r := range rows {
+		if tblType, ok := r["Table_type"]; ok && !strings.EqualFold(tblType.String, "BASE TABLE") {
+			continue
+		}
+
+		// MySQL names the column after the database, other servers may just call it Table
+		for colName, val := range r {
+			if strings.HasPrefix(colName, "Tables_in_") || colName == "Table" {
+				names = append(names, val.String)
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// ReadSchema reads the schema of the table |tblName|, along with the names of its primary key columns in the order of
+// the table's primary key. The tags of the returned schema's columns are their positions in the table.
+func ReadSchema(ctx context.Context, db *sql.DB, tblName string) (schema.Schema, []string, error) {
+	colRows, err := queryByColumnName(ctx, db, "SHOW FULL COLUMNS FROM "+QuoteIdentifier(tblName))
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pkCols, err := readPrimaryKey(ctx, db, tblName)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(pkCols) == 0 {
+		for _, r := range colRows {
+			if r["Key"].String == "PRI" {
+				pkCols = append(pkCols, r["Field"].String)
+			}
+		}
+	}
+
+	if len(pkCols) == 0 {
+		return nil, nil, fmt.Errorf("%w: %s", ErrNoPrimaryKey, tblName)
+	}
+
+	isPk := make(map[string]bool)
+	for _, pkCol := range pkCols {
+		isPk[strings.ToLower(pkCol)] = true
+	}
+
+	cols := make([]schema.Column, 0, len(colRows))
+	for i, r := range colRows {
+		name := r["Field"].String
+		colType := r["Type"].String
+
+		if collation := r["Collation"]; collation.Valid && collation.String != "" && !strings.Contains(strings.ToLower(colType), "collate") {
+			colType += " COLLATE " + collation.String
+		}
+
+		ti, err := TypeInfoFromColumnType(colType)
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("column %s of table %s: %w", name, tblName, err)
+		}
+
+		var constraints []schema.ColConstraint
+		if isPk[strings.ToLower(name)] || strings.EqualFold(r["Null"].String, "NO") {
+			constraints = append(constraints, schema.NotNullConstraint{})
+		}
+
+		col, err := schema.NewColumnWithTypeInfo(name, uint64(i), ti, isPk[strings.ToLower(name)], constraints...)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// a dolt server reports the tag of each column in its comment, which dolt adds back when the comment is shown
+		col.Comment, _ = dsql.ParseColComment(r["Comment"].String)
+		cols = append(cols, col)
+	}
+
+	colColl, err := schema.NewColCollection(cols...)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return schema.SchemaFromCols(colColl), pkCols, nil
+}
+
+// TypeInfoFromColumnType returns the dolt type of a column with the MySQL type |colType|, such as "int(11) unsigned" or
+// "varchar(32) COLLATE utf8mb4_bin".
+func TypeInfoFromColumnType(colType string) (typeinfo.TypeInfo, error) {
+	// MySQL 5 reports the deprecated display width of year columns, which the parser doesn't accept
+	stmt, err := sqlparser.Parse("CREATE TABLE t (c " + yearWidthRegex.ReplaceAllString(colType, "year") + ")")
+
+	if err != nil {
+		return nil, fmt.Errorf("unsupported type %s: %w", colType, err)
+	}
+
+	ddl, ok := stmt.(*sqlparser.DDL)
+
+	if !ok || ddl.TableSpec == nil || len(ddl.TableSpec.Columns) != 1 {
+		return nil, fmt.Errorf("unsupported type %s", colType)
+	}
+
+	ct := ddl.TableSpec.Columns[0].Type
+
+	switch strings.ToLower(ct.Type) {
+	case "json":
+		return typeinfo.FromSqlType(gmssql.LongText)
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob":
+		return nil, fmt.Errorf("unsupported type %s: binary strings are not supported", colType)
+	}
+
+	sqlType, err := gmssql.ColumnTypeToType(&ct)
+
+	if err != nil {
+		return nil, fmt.Errorf("unsupported type %s: %w", colType, err)
+	}
+
+	return typeinfo.FromSqlType(sqlType)
+}
+
+// readPrimaryKey returns the names of the primary key columns of |tblName| in the order of the primary key index.
+func readPrimaryKey(ctx context.Context, db *sql.DB, tblName string) ([]string, error) {
+	idxRows, err := queryByColumnName(ctx, db, "SHOW INDEX FROM "+QuoteIdentifier(tblName))
+
+	if err != nil {
+		return nil, err
+	}
+
+	type pkCol struct {
+		name string
+		seq  int
+	}
+
+	var pkCols []pkCol
+	for _, r := range idxRows {
+		if r["Key_name"].String == "PRIMARY" {
+			seq, err := strconv.Atoi(r["Seq_in_index"].String)
+
+			if err != nil {
+				return nil, err
+			}
+
+			pkCols = append(pkCols, pkCol{r["Column_name"].String, seq})
+		}
+	}
+
+	sort.Slice(pkCols, func(i, j int) bool {
+		return pkCols[i].seq < pkCols[j].seq
+	})
+
+	names := make([]string, len(pkCols))
+	for i, col := range pkCols {
+		names[i] = col.name
+	}
+
+	return names, nil
+}
+
+// queryByColumnName runs |query| and returns its rows as maps from column name to value. The columns returned by SHOW
+// statements differ between MySQL versions, so they're read by name rather than position.
+func queryByColumnName(ctx context.Context, db *sql.DB, query string) ([]map[string]sql.NullString, error) {
+	rows, err := db.QueryContext(ctx, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	colNames, err := rows.Columns()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var results []map[string]sql.NullString
+	for rows.Next() {
+		vals := make([]sql.NullString, len(colNames))
+		dest := make([]interface{}, len(colNames))
+		for i := range vals {
+			dest[i] = &vals[i]
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		r := make(map[string]sql.NullString, len(colNames))
+		for i, colName := range colNames {
+			r[colName] = vals[i]
+		}
+
+		results = append(results, r)
+	}
+
+	return results, rows.Err()
+}
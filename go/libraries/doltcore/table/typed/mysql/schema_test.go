@@ -0,0 +1,98 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/typeinfo"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+func TestTypeInfoFromColumnType(t *testing.T) {
+	tests := []struct {
+		colType    string
+		expectedId typeinfo.Identifier
+		expectedTi typeinfo.TypeInfo
+		expectErr  bool
+	}{
+		{colType: "int(11)", expectedTi: typeinfo.Int32Type},
+		{colType: "int(10) unsigned", expectedTi: typeinfo.Uint32Type},
+		{colType: "bigint(20)", expectedTi: typeinfo.Int64Type},
+		{colType: "tinyint(1)", expectedTi: typeinfo.Int8Type},
+		{colType: "mediumint unsigned", expectedTi: typeinfo.Uint24Type},
+		{colType: "double", expectedTi: typeinfo.Float64Type},
+		{colType: "float", expectedTi: typeinfo.Float32Type},
+		{colType: "datetime", expectedTi: typeinfo.DatetimeType},
+		{colType: "date", expectedTi: typeinfo.DateType},
+		{colType: "datetime(6)", expectedTi: typeinfo.DatetimeType},
+		{colType: "int(10) unsigned zerofill", expectedTi: typeinfo.Uint32Type},
+		{colType: "year(4)", expectedTi: typeinfo.YearType},
+		{colType: "decimal(10,2)", expectedId: typeinfo.DecimalTypeIdentifier},
+		{colType: "bit(8)", expectedId: typeinfo.BitTypeIdentifier},
+		{colType: "varchar(32) COLLATE utf8mb4_bin", expectedId: typeinfo.VarStringTypeIdentifier},
+		{colType: "text", expectedId: typeinfo.VarStringTypeIdentifier},
+		{colType: "enum('a','b')", expectedId: typeinfo.EnumTypeIdentifier},
+		{colType: "set('a','b')", expectedId: typeinfo.SetTypeIdentifier},
+		{colType: "json", expectedId: typeinfo.VarStringTypeIdentifier},
+		{colType: "varbinary(16)", expectErr: true},
+		{colType: "blob", expectErr: true},
+		{colType: "point", expectErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.colType, func(t *testing.T) {
+			ti, err := TypeInfoFromColumnType(test.colType)
+
+			if test.expectErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			if test.expectedTi != nil {
+				assert.True(t, test.expectedTi.Equals(ti), "expected %s, got %s", test.expectedTi.String(), ti.String())
+			} else {
+				assert.Equal(t, test.expectedId, ti.GetTypeIdentifier())
+			}
+		})
+	}
+}
+
+func TestConvertValue(t *testing.T) {
+	ti, err := TypeInfoFromColumnType("bit(16)")
+	require.NoError(t, err)
+	val, err := convertValue(ti, "\x01\x02")
+	require.NoError(t, err)
+	assert.Equal(t, types.Uint(258), val)
+
+	val, err = convertValue(typeinfo.Int32Type, "-12")
+	require.NoError(t, err)
+	assert.Equal(t, types.Int(-12), val)
+
+	ti, err = TypeInfoFromColumnType("varchar(10)")
+	require.NoError(t, err)
+	val, err = convertValue(ti, "")
+	require.NoError(t, err)
+	assert.Equal(t, types.String(""), val)
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	assert.Equal(t, "`tbl`", QuoteIdentifier("tbl"))
+	assert.Equal(t, "`a``b`", QuoteIdentifier("a`b"))
+}
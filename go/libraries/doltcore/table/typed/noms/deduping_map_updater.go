@@ -0,0 +1,159 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package noms
+
+import (
+	"context"
+	"errors"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// DedupeStats counts how the rows written to a DedupingMapUpdater changed the map being updated.
+type DedupeStats struct {
+	// Unchanged counts the rows which were identical to the existing row with the same key
+	Unchanged int64
+
+	// Updated counts the rows which replaced an existing row with the same key
+	Updated int64
+
+	// Added counts the rows whose key was not in the map
+	Added int64
+
+	// Deleted counts the existing rows which were removed because they were not written when replacing the map
+	Deleted int64
+}
+
+// DedupingMapUpdater is a TableWriter that updates an existing noms types.Map, skipping every row which is identical
+// to the row already stored with the same key so that only the rows which actually changed are edited. When replacing,
+// the rows of the original map which were not written are deleted when it is closed, leaving the untouched rows and
+// the chunks storing them as they were. Once all rows are written Close() should be called and GetMap will then return
+// the new map.
+type DedupingMapUpdater struct {
+	nmu     *NomsMapUpdater
+	orig    types.Map
+	replace bool
+	written map[hash.Hash]struct{}
+	stats   DedupeStats
+}
+
+// NewDedupingMapUpdater creates a new DedupingMapUpdater for a given map. If |replace| is true then the rows of |m|
+// which aren't written are deleted on Close.
+func NewDedupingMapUpdater(ctx context.Context, vrw types.ValueReadWriter, m types.Map, sch schema.Schema, replace bool, statsCB StatsCB) *DedupingMapUpdater {
+	var written map[hash.Hash]struct{}
+	if replace {
+		written = make(map[hash.Hash]struct{})
+	}
+
+	return &DedupingMapUpdater{NewNomsMapUpdater(ctx, vrw, m, sch, statsCB), m, replace, written, DedupeStats{}}
+}
+
+// GetSchema gets the schema of the rows that this writer writes
+func (dmu *DedupingMapUpdater) GetSchema() schema.Schema {
+	return dmu.nmu.GetSchema()
+}
+
+// WriteRow will write a row to a table unless it is identical to the existing row with the same key
+func (dmu *DedupingMapUpdater) WriteRow(ctx context.Context, r row.Row) error {
+	if dmu.nmu.acc == nil {
+		return errors.New("Attempting to write after closing.")
+	}
+
+	sch := dmu.nmu.GetSchema()
+	key, err := r.NomsMapKey(sch).Value(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	val, err := r.NomsMapValue(sch).Value(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if dmu.replace {
+		h, err := key.Hash(dmu.orig.Format())
+
+		if err != nil {
+			return err
+		}
+
+		dmu.written[h] = struct{}{}
+	}
+
+	existing, ok, err := dmu.orig.MaybeGet(ctx, key)
+
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		dmu.stats.Added++
+	} else if existing.Equals(val) {
+		dmu.stats.Unchanged++
+		return nil
+	} else {
+		dmu.stats.Updated++
+	}
+
+	return dmu.nmu.WriteEdit(ctx, key, val)
+}
+
+// Close deletes the rows of the original map which weren't written when replacing, then flushes all writes and
+// releases resources being held
+func (dmu *DedupingMapUpdater) Close(ctx context.Context) error {
+	if dmu.nmu.result != nil {
+		return errors.New("Already closed.")
+	}
+
+	if dmu.replace {
+		err := dmu.orig.IterAll(ctx, func(key, _ types.Value) error {
+			h, err := key.Hash(dmu.orig.Format())
+
+			if err != nil {
+				return err
+			}
+
+			if _, ok := dmu.written[h]; ok {
+				return nil
+			}
+
+			dmu.stats.Deleted++
+			return dmu.nmu.WriteEdit(ctx, key, nil)
+		})
+
+		dmu.written = nil
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return dmu.nmu.Close(ctx)
+}
+
+// GetMap retrieves the resulting types.Map once close is called
+func (dmu *DedupingMapUpdater) GetMap() *types.Map {
+	return dmu.nmu.GetMap()
+}
+
+// GetDedupeStats returns the counts of the unchanged, updated, added and deleted rows
+func (dmu *DedupingMapUpdater) GetDedupeStats() DedupeStats {
+	return dmu.stats
+}
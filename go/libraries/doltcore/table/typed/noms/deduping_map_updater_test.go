@@ -0,0 +1,57 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package noms
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+func TestDedupingMapUpdater(t *testing.T) {
+	ctx := context.Background()
+	db, err := dbfactory.MemFactory{}.CreateDB(ctx, types.Format_7_18, nil, nil)
+	require.NoError(t, err)
+
+	initialMap := testNomsMapCreator(t, db, createRows(t, false, false))
+
+	// updating with the same rows leaves the map unchanged
+	dmu := NewDedupingMapUpdater(ctx, db, *initialMap, sch, false, nil)
+	m := testNomsWriteCloser(t, dmu, createRows(t, false, false))
+	assert.True(t, m.Equals(*initialMap))
+	assert.Equal(t, DedupeStats{Unchanged: 3}, dmu.GetDedupeStats())
+
+	dmu = NewDedupingMapUpdater(ctx, db, *initialMap, sch, false, nil)
+	m = testNomsWriteCloser(t, dmu, createRows(t, false, true))
+	testReadAndCompare(t, m, createRows(t, false, true))
+	assert.Equal(t, DedupeStats{Unchanged: 1, Updated: 2}, dmu.GetDedupeStats())
+
+	// replacing with only the updated rows deletes the row which wasn't written
+	dmu = NewDedupingMapUpdater(ctx, db, *initialMap, sch, true, nil)
+	m = testNomsWriteCloser(t, dmu, createRows(t, true, true))
+	testReadAndCompare(t, m, createRows(t, true, true))
+	assert.Equal(t, DedupeStats{Updated: 2, Deleted: 1}, dmu.GetDedupeStats())
+
+	onlyUpdated := testNomsMapCreator(t, db, createRows(t, true, false))
+	dmu = NewDedupingMapUpdater(ctx, db, *onlyUpdated, sch, true, nil)
+	m = testNomsWriteCloser(t, dmu, createRows(t, false, false))
+	assert.True(t, m.Equals(*initialMap))
+	assert.Equal(t, DedupeStats{Unchanged: 2, Added: 1}, dmu.GetDedupeStats())
+}
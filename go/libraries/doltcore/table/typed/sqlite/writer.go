@@ -0,0 +1,315 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqlite exports dolt tables to the tables of a SQLite database file.
+//
+// The columns of a dolt table are created with these SQLite types:
+//
+//	bit, bool, int, uint, year    INTEGER (bools are 0 or 1)
+//	float                         REAL
+//	varchar, text, uuid           TEXT
+//	enum, set, time               TEXT, of the strings the values represent
+//	datetime                      TEXT, as an ISO 8601 timestamp in UTC, which the SQLite date functions understand
+//	decimal                       TEXT, as SQLite has no exact decimal type
+//	varbinary, blob, inlineblob   BLOB
+//
+// Columns of any other type are exported as TEXT of their formatted values, with a warning. A uint greater than the
+// largest SQLite INTEGER is exported as a REAL, and a value larger than MaxValueSize bytes as NULL, also with a
+// warning.
+// The primary key of a dolt table, which is its only index, is the primary key of its SQLite table.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	// registers the sqlite3 driver
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/shopspring/decimal"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/typeinfo"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// MaxValueSize is the size in bytes of the largest value which is exported, the default limit on the length of a
+// string or blob in SQLite.
+var MaxValueSize = 1000 * 1000 * 1000
+
+// WarnFunc is called with a warning about a value or a column which can't be exported as it is.
+type WarnFunc func(msg string)
+
+// SQLiteWriter is a TableWriteCloser which writes the rows of a table to a table of the same name in a SQLite
+// database file. The table is dropped and created again, and all of its rows are inserted in a single transaction,
+// which is committed when the writer is closed.
+type SQLiteWriter struct {
+	db        *sql.DB
+	tx        *sql.Tx
+	stmt      *sql.Stmt
+	tableName string
+	sch       schema.Schema
+	cols      []schema.Column
+	warn      WarnFunc
+}
+
+// OpenSQLiteWriter opens the SQLite database at |path|, creating it if it doesn't exist, and returns a SQLiteWriter
+// of the table |tableName| with the schema |sch|. |warn| may be nil.
+func OpenSQLiteWriter(ctx context.Context, path, tableName string, sch schema.Schema, warn WarnFunc) (*SQLiteWriter, error) {
+	db, err := sql.Open("sqlite3", path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// all of the statements are made on the transaction's connection
+	db.SetMaxOpenConns(1)
+
+	w, err := NewSQLiteWriter(ctx, db, tableName, sch, warn)
+
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// NewSQLiteWriter returns a SQLiteWriter of the table |tableName| with the schema |sch| in |db|, which is closed when
+// the writer is.
+func NewSQLiteWriter(ctx context.Context, db *sql.DB, tableName string, sch schema.Schema, warn WarnFunc) (*SQLiteWriter, error) {
+	if warn == nil {
+		warn = func(string) {}
+	}
+
+	w := &SQLiteWriter{db: db, tableName: tableName, sch: sch, cols: sch.GetAllCols().GetColumns(), warn: warn}
+	for _, col := range w.cols {
+		if _, ok := sqliteTypes[col.TypeInfo.GetTypeIdentifier()]; !ok {
+			warn(fmt.Sprintf("column %s.%s of type %s is exported as TEXT", tableName, col.Name, col.TypeInfo.String()))
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, "DROP TABLE IF EXISTS "+quoteIdentifier(tableName))
+
+	if err == nil {
+		_, err = tx.ExecContext(ctx, w.createStmt())
+	}
+
+	if err == nil {
+		w.stmt, err = tx.PrepareContext(ctx, w.insertStmt())
+	}
+
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	w.tx = tx
+
+	return w, nil
+}
+
+var sqliteTypes = map[typeinfo.Identifier]string{
+	typeinfo.BitTypeIdentifier:        "INTEGER",
+	typeinfo.BoolTypeIdentifier:       "INTEGER",
+	typeinfo.IntTypeIdentifier:        "INTEGER",
+	typeinfo.UintTypeIdentifier:       "INTEGER",
+	typeinfo.YearTypeIdentifier:       "INTEGER",
+	typeinfo.FloatTypeIdentifier:      "REAL",
+	typeinfo.VarStringTypeIdentifier:  "TEXT",
+	typeinfo.UuidTypeIdentifier:       "TEXT",
+	typeinfo.EnumTypeIdentifier:       "TEXT",
+	typeinfo.SetTypeIdentifier:        "TEXT",
+	typeinfo.TimeTypeIdentifier:       "TEXT",
+	typeinfo.DatetimeTypeIdentifier:   "TEXT",
+	typeinfo.DecimalTypeIdentifier:    "TEXT",
+	typeinfo.VarBinaryTypeIdentifier:  "BLOB",
+	typeinfo.InlineBlobTypeIdentifier: "BLOB",
+}
+
+func (w *SQLiteWriter) createStmt() string {
+	var defs, pks []string
+	for _, col := range w.cols {
+		sqliteType, ok := sqliteTypes[col.TypeInfo.GetTypeIdentifier()]
+
+		if !ok {
+			sqliteType = "TEXT"
+		}
+
+		def := "  " + quoteIdentifier(col.Name) + " " + sqliteType
+
+		if !col.IsNullable() {
+			def += " NOT NULL"
+		}
+
+		defs = append(defs, def)
+
+		if col.IsPartOfPK {
+			pks = append(pks, quoteIdentifier(col.Name))
+		}
+	}
+
+	if len(pks) > 0 {
+		defs = append(defs, "  PRIMARY KEY ("+strings.Join(pks, ", ")+")")
+	}
+
+	return fmt.Sprintf("CREATE TABLE %s (\n%s\n)", quoteIdentifier(w.tableName), strings.Join(defs, ",\n"))
+}
+
+func (w *SQLiteWriter) insertStmt() string {
+	names := make([]string, len(w.cols))
+	params := make([]string, len(w.cols))
+	for i, col := range w.cols {
+		names[i] = quoteIdentifier(col.Name)
+		params[i] = "?"
+	}
+
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoteIdentifier(w.tableName), strings.Join(names, ", "), strings.Join(params, ", "))
+}
+
+// GetSchema gets the schema of the rows that this writer writes
+func (w *SQLiteWriter) GetSchema() schema.Schema {
+	return w.sch
+}
+
+// WriteRow will write a row to a table
+func (w *SQLiteWriter) WriteRow(ctx context.Context, r row.Row) error {
+	args := make([]interface{}, len(w.cols))
+	for i, col := range w.cols {
+		val, ok := r.GetColVal(col.Tag)
+
+		if !ok || types.IsNull(val) {
+			continue
+		}
+
+		args[i] = w.sqliteValue(r, col, val)
+	}
+
+	_, err := w.stmt.ExecContext(ctx, args...)
+
+	return err
+}
+
+// sqliteValue returns the value of |col| in |r| as the go value it's bound to the insert as.
+func (w *SQLiteWriter) sqliteValue(r row.Row, col schema.Column, val types.Value) interface{} {
+	var arg interface{}
+	size := 0
+
+	switch col.TypeInfo.GetTypeIdentifier() {
+	case typeinfo.VarBinaryTypeIdentifier:
+		if v, ok := val.(types.String); ok {
+			arg, size = []byte(v), len(v)
+		}
+	case typeinfo.EnumTypeIdentifier, typeinfo.SetTypeIdentifier, typeinfo.TimeTypeIdentifier:
+		// these are stored as numbers, but are exported as the strings they represent
+	default:
+		switch v := val.(type) {
+		case types.Bool:
+			arg = bool(v)
+		case types.Int:
+			arg = int64(v)
+		case types.Uint:
+			if uint64(v) > math.MaxInt64 {
+				w.warnValue(r, col, "is greater than the largest SQLite INTEGER, and is exported as REAL")
+				arg = float64(v)
+			} else {
+				arg = int64(v)
+			}
+		case types.Float:
+			arg = float64(v)
+		case types.String:
+			arg, size = string(v), len(v)
+		case types.UUID:
+			arg = uuid.UUID(v).String()
+		case types.Timestamp:
+			arg = time.Time(v).UTC().Format("2006-01-02 15:04:05.999999")
+		case types.Decimal:
+			arg = decimal.Decimal(v).String()
+		case types.InlineBlob:
+			arg, size = []byte(v), len(v)
+		}
+	}
+
+	if arg == nil {
+		str, err := col.TypeInfo.FormatValue(val)
+
+		if err != nil || str == nil {
+			w.warnValue(r, col, "can't be formatted, and is exported as NULL")
+			return nil
+		}
+
+		arg, size = *str, len(*str)
+	}
+
+	if size > MaxValueSize {
+		w.warnValue(r, col, fmt.Sprintf("is %d bytes, larger than the largest value exported, and is exported as NULL", size))
+		return nil
+	}
+
+	return arg
+}
+
+func (w *SQLiteWriter) warnValue(r row.Row, col schema.Column, msg string) {
+	var key []string
+	_ = w.sch.GetPKCols().Iter(func(tag uint64, pkCol schema.Column) (stop bool, err error) {
+		val, _ := r.GetColVal(tag)
+		str, err := pkCol.TypeInfo.FormatValue(val)
+
+		if err == nil && str != nil {
+			key = append(key, *str)
+		}
+
+		return false, nil
+	})
+
+	w.warn(fmt.Sprintf("the value of %s.%s in the row with key (%s) %s", w.tableName, col.Name, strings.Join(key, ", "), msg))
+}
+
+// Close commits the rows written, and closes the database.
+func (w *SQLiteWriter) Close(ctx context.Context) error {
+	if w.tx == nil {
+		return nil
+	}
+
+	err := w.stmt.Close()
+
+	if err == nil {
+		err = w.tx.Commit()
+	} else {
+		_ = w.tx.Rollback()
+	}
+
+	w.tx = nil
+
+	if closeErr := w.db.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+func quoteIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
@@ -0,0 +1,191 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlite
+
+import (
+	"context"
+	dbsql "database/sql"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/typeinfo"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+var testSch = dtestutils.MustSchema(
+	schema.NewColumn("id", 0, types.IntKind, true, schema.NotNullConstraint{}),
+	schema.NewColumn("name", 1, types.StringKind, false),
+	schema.NewColumn("big", 2, types.UintKind, false),
+	decimalColumn("price", 3),
+	schema.NewColumn("at", 4, types.TimestampKind, false),
+	schema.NewColumn("uid", 5, types.UUIDKind, false),
+	schema.NewColumn("ok", 6, types.BoolKind, false),
+)
+
+func decimalColumn(name string, tag uint64) schema.Column {
+	ti, err := typeinfo.FromSqlType(sql.MustCreateDecimalType(10, 2))
+
+	if err != nil {
+		panic(err)
+	}
+
+	col, err := schema.NewColumnWithTypeInfo(name, tag, ti, false)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return col
+}
+
+func testRow(t *testing.T, vals row.TaggedValues) row.Row {
+	r, err := row.New(types.Format_Default, testSch, vals)
+	require.NoError(t, err)
+
+	return r
+}
+
+
+func TestSQLiteWriter(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "sqlite_writer_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out.db")
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	id := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+	var warnings []string
+	warn := func(msg string) {
+		warnings = append(warnings, msg)
+	}
+
+	// the table is created again by a second writer
+	for i := 0; i < 2; i++ {
+		w, err := OpenSQLiteWriter(ctx, path, "people", testSch, warn)
+		require.NoError(t, err)
+		require.NoError(t, w.WriteRow(ctx, testRow(t, row.TaggedValues{
+			0: types.Int(1),
+			1: types.String("bill"),
+			2: types.Uint(7),
+			3: types.Decimal(decimal.RequireFromString("10.25")),
+			4: types.Timestamp(at),
+			5: types.UUID(id),
+			6: types.Bool(true),
+		})))
+		require.NoError(t, w.WriteRow(ctx, testRow(t, row.TaggedValues{0: types.Int(2), 2: types.Uint(math.MaxUint64)})))
+		require.NoError(t, w.Close(ctx))
+	}
+
+	require.Len(t, warnings, 2)
+	assert.Contains(t, warnings[0], "people.big in the row with key (2)")
+
+	db, err := dbsql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var createStmt string
+	require.NoError(t, db.QueryRow(`SELECT sql FROM sqlite_master WHERE name = 'people'`).Scan(&createStmt))
+	assert.Equal(t, `CREATE TABLE "people" (
+  "id" INTEGER NOT NULL,
+  "name" TEXT,
+  "big" INTEGER,
+  "price" TEXT,
+  "at" TEXT,
+  "uid" TEXT,
+  "ok" INTEGER,
+  PRIMARY KEY ("id")
+)`, createStmt)
+
+	rows, err := db.Query(`SELECT id, name, big, price, at, uid, ok, typeof(big) FROM people ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	type result struct {
+		id               int64
+		name, price, uid dbsql.NullString
+		at               dbsql.NullString
+		big              dbsql.NullFloat64
+		ok               dbsql.NullBool
+		bigType          string
+	}
+
+	var results []result
+	for rows.Next() {
+		var res result
+		require.NoError(t, rows.Scan(&res.id, &res.name, &res.big, &res.price, &res.at, &res.uid, &res.ok, &res.bigType))
+		results = append(results, res)
+	}
+	require.NoError(t, rows.Err())
+	require.Len(t, results, 2)
+
+	assert.Equal(t, "bill", results[0].name.String)
+	assert.Equal(t, float64(7), results[0].big.Float64)
+	assert.Equal(t, "integer", results[0].bigType)
+	assert.Equal(t, "10.25", results[0].price.String)
+	assert.Equal(t, "2020-01-02 03:04:05", results[0].at.String)
+	assert.Equal(t, id.String(), results[0].uid.String)
+	assert.True(t, results[0].ok.Bool)
+
+	assert.False(t, results[1].name.Valid)
+	assert.Equal(t, "real", results[1].bigType)
+	assert.False(t, results[1].ok.Valid)
+}
+
+func TestSQLiteWriterLargeValues(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "sqlite_writer_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out.db")
+
+	defer func(size int) {
+		MaxValueSize = size
+	}(MaxValueSize)
+	MaxValueSize = 4
+
+	var warnings []string
+	w, err := OpenSQLiteWriter(ctx, path, "people", testSch, func(msg string) {
+		warnings = append(warnings, msg)
+	})
+	require.NoError(t, err)
+	require.NoError(t, w.WriteRow(ctx, testRow(t, row.TaggedValues{0: types.Int(1), 1: types.String("abcd")})))
+	require.NoError(t, w.WriteRow(ctx, testRow(t, row.TaggedValues{0: types.Int(2), 1: types.String("abcde")})))
+	require.NoError(t, w.Close(ctx))
+
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "people.name in the row with key (2) is 5 bytes")
+
+	db, err := dbsql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM people WHERE name IS NULL`).Scan(&count))
+	assert.Equal(t, 1, count)
+}
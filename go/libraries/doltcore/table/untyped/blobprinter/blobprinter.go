@@ -0,0 +1,150 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobprinter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/typeinfo"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/pipeline"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const BlobPrintingStage = "blob printing"
+
+// MaxTextLength is the length in bytes of the longest binary value which is printed as text.
+const MaxTextLength = 1024
+
+// shortHashLength is the number of hex digits of the sha256 of a binary value which are printed.
+const shortHashLength = 12
+
+// IsText returns whether the binary value |data| looks like text, which is the case when it's valid UTF-8 without any
+// control characters other than whitespace, and isn't longer than MaxTextLength.
+func IsText(data string) bool {
+	if len(data) > MaxTextLength || !utf8.ValidString(data) {
+		return false
+	}
+
+	for _, r := range data {
+		if unicode.IsControl(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Describe returns a short description of the binary value |data| giving its size and its sha256, such as
+// <binary: 1.2 MB, sha256:9f86d081884c…>
+func Describe(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return fmt.Sprintf("<binary: %s, sha256:%s…>", humanize.Bytes(uint64(len(data))), hex.EncodeToString(sum[:])[:shortHashLength])
+}
+
+// Format returns the binary value |data| as it's printed: quoted if it looks like text, and described otherwise.
+func Format(data string) string {
+	if IsText(data) {
+		return strconv.Quote(data)
+	}
+
+	return Describe(data)
+}
+
+// IsBlobColumn returns whether the column holds binary values.
+func IsBlobColumn(col schema.Column) bool {
+	if col.TypeInfo == nil {
+		return false
+	}
+
+	switch col.TypeInfo.GetTypeIdentifier() {
+	case typeinfo.VarBinaryTypeIdentifier, typeinfo.InlineBlobTypeIdentifier:
+		return true
+	default:
+		return false
+	}
+}
+
+// BlobTags returns the tags of the columns of the schemas given which hold binary values. Nil schemas are skipped.
+func BlobTags(schemas ...schema.Schema) map[uint64]bool {
+	tags := make(map[uint64]bool)
+	for _, sch := range schemas {
+		if sch == nil {
+			continue
+		}
+
+		_ = sch.GetAllCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+			if IsBlobColumn(col) {
+				tags[tag] = true
+			}
+
+			return false, nil
+		})
+	}
+
+	return tags
+}
+
+// BlobPrinter is a utility to convert the binary values in rows to their printed form with Format.
+type BlobPrinter struct {
+	Sch      schema.Schema
+	blobTags map[uint64]bool
+}
+
+// NewBlobPrinter returns a new blob printer for the schema given, which must be string-typed (untyped), which formats
+// the values of the columns with the tags |blobTags|.
+func NewBlobPrinter(sch schema.Schema, blobTags map[uint64]bool) *BlobPrinter {
+	return &BlobPrinter{Sch: sch, blobTags: blobTags}
+}
+
+// Function to convert the binary values of a row with the schema given to their printed form. Used as the transform
+// function in a NamedTransform.
+func (bp *BlobPrinter) ProcessRow(inRow row.Row, props pipeline.ReadableMap) (rowData []*pipeline.TransformedRowResult, badRowDetails string) {
+	if len(bp.blobTags) == 0 {
+		return []*pipeline.TransformedRowResult{{RowData: inRow}}, ""
+	}
+
+	taggedVals := make(row.TaggedValues)
+
+	_, err := inRow.IterSchema(bp.Sch, func(tag uint64, val types.Value) (stop bool, err error) {
+		if str, ok := val.(types.String); ok && bp.blobTags[tag] {
+			taggedVals[tag] = types.String(Format(string(str)))
+		} else if !types.IsNull(val) {
+			taggedVals[tag] = val
+		}
+
+		return false, nil
+	})
+
+	if err != nil {
+		return nil, err.Error()
+	}
+
+	r, err := row.New(inRow.Format(), bp.Sch, taggedVals)
+
+	if err != nil {
+		return nil, err.Error()
+	}
+
+	return []*pipeline.TransformedRowResult{{RowData: r}}, ""
+}
@@ -0,0 +1,89 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobprinter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/typeinfo"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/untyped"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		data     string
+		expected string
+	}{
+		{"", `""`},
+		{"hello", `"hello"`},
+		{"two\nlines", `"two\nlines"`},
+		{"héllo wörld", `"héllo wörld"`},
+		{"a\x00b", "<binary: 3 B, sha256:59b271ae1bbc…>"},
+		{"\xff\xfe", "<binary: 2 B, sha256:"},
+		{strings.Repeat("a", MaxTextLength+1), "<binary: 1.0 kB, sha256:"},
+	}
+
+	for _, test := range tests {
+		actual := Format(test.data)
+		assert.True(t, strings.HasPrefix(actual, test.expected), "%q formatted as %s", test.data, actual)
+	}
+
+	assert.True(t, IsText(strings.Repeat("a", MaxTextLength)))
+	assert.NotEqual(t, Describe("a\x00b"), Describe("a\x00c"))
+}
+
+func TestBlobPrinter(t *testing.T) {
+	colColl, err := schema.NewColCollection(
+		schema.NewColumn("pk", 0, types.IntKind, true),
+		schema.NewColumn("name", 1, types.StringKind, false),
+		schema.NewColumn("data", 2, types.InlineBlobKind, false),
+	)
+	require.NoError(t, err)
+	sch := schema.SchemaFromCols(colColl)
+	require.Equal(t, typeinfo.InlineBlobTypeIdentifier, sch.GetAllCols().GetByIndex(2).TypeInfo.GetTypeIdentifier())
+
+	blobTags := BlobTags(sch, nil)
+	assert.Equal(t, map[uint64]bool{2: true}, blobTags)
+
+	untypedSch, err := untyped.UntypeSchema(sch)
+	require.NoError(t, err)
+	bp := NewBlobPrinter(untypedSch, blobTags)
+
+	in, err := untyped.NewRowFromStrings(types.Format_Default, untypedSch, []string{"1", "a\x00b", "a\x00b"})
+	require.NoError(t, err)
+	results, badRowDetails := bp.ProcessRow(in, nil)
+	require.Empty(t, badRowDetails)
+	require.Len(t, results, 1)
+
+	name, _ := results[0].RowData.GetColVal(1)
+	assert.Equal(t, types.String("a\x00b"), name)
+	data, _ := results[0].RowData.GetColVal(2)
+	assert.Equal(t, types.String(Describe("a\x00b")), data)
+
+	// nulls are left for the null printer
+	in, err = row.New(types.Format_Default, untypedSch, row.TaggedValues{0: types.String("2")})
+	require.NoError(t, err)
+	results, badRowDetails = bp.ProcessRow(in, nil)
+	require.Empty(t, badRowDetails)
+	_, ok := results[0].RowData.GetColVal(2)
+	assert.False(t, ok)
+}
@@ -130,8 +130,14 @@ func (ttw *TextTableWriter) writeTableHeader(r row.Row) error {
 
 // writeTableFooter writes the final separator line for a table
 func (ttw *TextTableWriter) writeTableFooter() error {
+	return ttw.WriteSeparator()
+}
+
+// WriteSeparator writes a separator line like those around the header, so that the rows written before it are set
+// apart from those written after it. At least one row must have been written.
+func (ttw *TextTableWriter) WriteSeparator() error {
 	if ttw.lastWritten == nil {
-		return errors.New("No rows written, cannot write footer")
+		return errors.New("No rows written, cannot write separator")
 	}
 
 	allCols := ttw.sch.GetAllCols()
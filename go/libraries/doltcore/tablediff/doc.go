@@ -0,0 +1,32 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tablediff reads the differences between two versions of a table. A RowDiffIterator yields the rows which
+// were added, removed and modified between two roots, and SchemaDiff describes the changes to a table's columns,
+// indexes and constraints.
+//
+// It's the supported way for programs built on dolt to read diffs, and `dolt diff` and the dolt_diff_$TABLE system
+// tables are built on it.
+//
+// Compatibility
+//
+// Unlike the rest of the doltcore packages, which change as dolt needs them to, this package is kept compatible
+// within a major version of dolt. The exported identifiers of the package won't be removed or renamed, and the
+// signatures of its functions and methods won't change, apart from the addition of methods to its types and fields
+// to its structs. Programs should set the fields of its structs by name, and should handle ChangeType values which
+// they don't know of, as new kinds of changes may be reported as dolt's schemas gain new features.
+//
+// The rows and schemas the package returns are those of the row and schema packages, which aren't covered by this
+// guarantee.
+package tablediff
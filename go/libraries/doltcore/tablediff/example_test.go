@@ -0,0 +1,151 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tablediff_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/tablediff"
+)
+
+func ExampleRowDiffIterator() {
+	ctx := context.Background()
+	dEnv := dtestutils.CreateTestEnv()
+	root, err := dEnv.WorkingRoot(ctx)
+
+	if err != nil {
+		panic(err)
+	}
+
+	from, err := sqle.ExecuteSql(dEnv, root, `create table people (id bigint not null, name varchar(64), primary key (id));
+insert into people values (1, "Homer"), (2, "Marge"), (3, "Bart")`)
+
+	if err != nil {
+		panic(err)
+	}
+
+	to, err := sqle.ExecuteSql(dEnv, from, `replace into people values (3, "Lisa");
+insert into people values (4, "Maggie")`)
+
+	if err != nil {
+		panic(err)
+	}
+
+	itr, err := tablediff.NewRowDiffIterator(ctx, from, to, "people", nil)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer itr.Close()
+
+	fromName, _ := itr.FromSchema().GetAllCols().GetByName("name")
+	toName, _ := itr.ToSchema().GetAllCols().GetByName("name")
+
+	for {
+		rd, err := itr.Next(ctx)
+
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			panic(err)
+		}
+
+		switch rd.Type {
+		case tablediff.Added:
+			name, _ := rd.New.GetColVal(toName.Tag)
+			fmt.Println(rd.Type, name)
+		case tablediff.Removed:
+			name, _ := rd.Old.GetColVal(fromName.Tag)
+			fmt.Println(rd.Type, name)
+		case tablediff.Modified:
+			oldName, _ := rd.Old.GetColVal(fromName.Tag)
+			newName, _ := rd.New.GetColVal(toName.Tag)
+			fmt.Println(rd.Type, oldName, "->", newName)
+		}
+	}
+
+	// Output:
+	// modified Bart -> Lisa
+	// added Maggie
+}
+
+func ExampleSchemaDiff() {
+	ctx := context.Background()
+	dEnv := dtestutils.CreateTestEnv()
+	root, err := dEnv.WorkingRoot(ctx)
+
+	if err != nil {
+		panic(err)
+	}
+
+	from, err := sqle.ExecuteSql(dEnv, root, "create table people (id bigint not null, name varchar(64), primary key (id))")
+
+	if err != nil {
+		panic(err)
+	}
+
+	to, err := sqle.ExecuteSql(dEnv, from, `alter table people add column age bigint;
+alter table people rename column name to first_name`)
+
+	if err != nil {
+		panic(err)
+	}
+
+	fromTbl, _, err := from.GetTable(ctx, "people")
+
+	if err != nil {
+		panic(err)
+	}
+
+	toTbl, _, err := to.GetTable(ctx, "people")
+
+	if err != nil {
+		panic(err)
+	}
+
+	fromSch, err := fromTbl.GetSchema(ctx)
+
+	if err != nil {
+		panic(err)
+	}
+
+	toSch, err := toTbl.GetSchema(ctx)
+
+	if err != nil {
+		panic(err)
+	}
+
+	for _, cd := range tablediff.SchemaDiff(fromSch, toSch).Columns {
+		switch cd.Type {
+		case tablediff.Added:
+			fmt.Println(cd.Type, cd.New.Name)
+		case tablediff.Removed:
+			fmt.Println(cd.Type, cd.Old.Name)
+		case tablediff.Modified:
+			if cd.NameChanged {
+				fmt.Println("renamed", cd.Old.Name, "to", cd.New.Name)
+			}
+		}
+	}
+
+	// Output:
+	// renamed name to first_name
+	// added age
+}
@@ -0,0 +1,262 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tablediff
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/atomicerr"
+	"github.com/liquidata-inc/dolt/go/store/diff"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// ChangeType is the kind of change a diff describes.
+type ChangeType int
+
+const (
+	// Added is the ChangeType of a row, column, index or constraint which is only in the newer version
+	Added ChangeType = iota + 1
+	// Removed is the ChangeType of a row, column, index or constraint which is only in the older version
+	Removed
+	// Modified is the ChangeType of a row, column, index or constraint which is in both versions, and differs
+	Modified
+)
+
+// String returns "added", "removed" or "modified".
+func (ct ChangeType) String() string {
+	switch ct {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	}
+
+	return "unknown"
+}
+
+// ErrTableNotFound is returned by NewRowDiffIterator when the table isn't in either of the roots.
+var ErrTableNotFound = errors.New("table not found in either root")
+
+// diffBufferSize is the number of diffs which are read ahead of the iterator's caller.
+const diffBufferSize = 1024
+
+// RowDiff is a row which was added, removed or modified. Old is the row as it was in the older version, with the
+// iterator's FromSchema, and is nil for added rows. New is the row as it is in the newer version, with the iterator's
+// ToSchema, and is nil for removed rows.
+type RowDiff struct {
+	Type ChangeType
+	Key  types.Tuple
+	Old  row.Row
+	New  row.Row
+}
+
+// KeyRange is a range of row keys which begins at Start, or at the first key when Start is nil, and continues while
+// InRange returns true, or through the last key when InRange is nil.
+type KeyRange struct {
+	Start   types.Value
+	InRange func(types.Value) (bool, error)
+}
+
+// RowDiffIterator yields the differences between the rows of two versions of a table, in key order when its key
+// ranges are sorted and don't overlap. The diff is read in the background, ahead of calls to Next, until the iterator
+// is closed.
+type RowDiffIterator struct {
+	fromSch schema.Schema
+	toSch   schema.Schema
+
+	ae        *atomicerr.AtomicError
+	diffs     chan diff.Difference
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewRowDiffIterator returns a RowDiffIterator over the differences between the rows of the table |tableName| in
+// |fromRoot| and in |toRoot|. A table which is only in one of the roots is diffed against an empty table, so its rows
+// are all added or all removed, and ErrTableNotFound is returned if it's in neither. When |keyRanges| isn't nil, only
+// the rows with keys within them are diffed.
+func NewRowDiffIterator(ctx context.Context, fromRoot, toRoot *doltdb.RootValue, tableName string, keyRanges []KeyRange) (*RowDiffIterator, error) {
+	fromRows, fromSch, fromOk, err := tableRows(ctx, fromRoot, tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	toRows, toSch, toOk, err := tableRows(ctx, toRoot, tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !fromOk && !toOk {
+		return nil, ErrTableNotFound
+	} else if !fromOk {
+		fromSch = toSch
+	} else if !toOk {
+		toSch = fromSch
+	}
+
+	return NewRowDiffIteratorForRows(ctx, fromRows, toRows, fromSch, toSch, keyRanges), nil
+}
+
+// tableRows returns the rows and schema of the table |tableName| in |root|, or an empty map and false if it isn't in
+// the root.
+func tableRows(ctx context.Context, root *doltdb.RootValue, tableName string) (types.Map, schema.Schema, bool, error) {
+	tbl, ok, err := root.GetTable(ctx, tableName)
+
+	if err != nil {
+		return types.EmptyMap, nil, false, err
+	}
+
+	if !ok {
+		rows, err := types.NewMap(ctx, root.VRW())
+		return rows, nil, false, err
+	}
+
+	rows, err := tbl.GetRowData(ctx)
+
+	if err != nil {
+		return types.EmptyMap, nil, false, err
+	}
+
+	sch, err := tbl.GetSchema(ctx)
+
+	if err != nil {
+		return types.EmptyMap, nil, false, err
+	}
+
+	return rows, sch, true, nil
+}
+
+// NewRowDiffIteratorForRows returns a RowDiffIterator over the differences between |fromRows|, the row data of a table
+// with the schema |fromSch|, and |toRows|, the row data of a table with the schema |toSch|. When |keyRanges| isn't
+// nil, only the rows with keys within them are diffed.
+func NewRowDiffIteratorForRows(ctx context.Context, fromRows, toRows types.Map, fromSch, toSch schema.Schema, keyRanges []KeyRange) *RowDiffIterator {
+	itr := &RowDiffIterator{
+		fromSch: fromSch,
+		toSch:   toSch,
+		ae:      atomicerr.New(),
+		diffs:   make(chan diff.Difference, diffBufferSize),
+		stop:    make(chan struct{}),
+	}
+
+	go func() {
+		defer close(itr.diffs)
+		defer func() {
+			// Diff panics to stop when the iterator is closed
+			recover()
+		}()
+
+		if keyRanges == nil {
+			diff.Diff(ctx, itr.ae, fromRows, toRows, itr.diffs, itr.stop, true, dontDescendRows)
+			return
+		}
+
+		for _, r := range keyRanges {
+			select {
+			case <-itr.stop:
+				return
+			default:
+			}
+
+			if itr.ae.IsSet() {
+				return
+			}
+
+			diff.DiffMapRange(ctx, itr.ae, fromRows, toRows, r.Start, r.InRange, itr.diffs, itr.stop, dontDescendRows)
+		}
+	}()
+
+	return itr
+}
+
+// dontDescendRows keeps the diff from descending into the tuples of rows, so that a modified row is a single diff.
+func dontDescendRows(v1, v2 types.Value) bool {
+	kind := v1.Kind()
+	return !types.IsPrimitiveKind(kind) && kind != types.TupleKind && kind == v2.Kind() && kind != types.RefKind
+}
+
+// FromSchema returns the schema of the older version of the table, which the Old rows of its RowDiffs have.
+func (itr *RowDiffIterator) FromSchema() schema.Schema {
+	return itr.fromSch
+}
+
+// ToSchema returns the schema of the newer version of the table, which the New rows of its RowDiffs have.
+func (itr *RowDiffIterator) ToSchema() schema.Schema {
+	return itr.toSch
+}
+
+// Next returns the next RowDiff, or io.EOF once every diff has been returned.
+func (itr *RowDiffIterator) Next(ctx context.Context) (RowDiff, error) {
+	select {
+	case d, ok := <-itr.diffs:
+		if !ok {
+			if err := itr.ae.Get(); err != nil {
+				return RowDiff{}, err
+			}
+
+			return RowDiff{}, io.EOF
+		}
+
+		return itr.rowDiff(d)
+	case <-ctx.Done():
+		return RowDiff{}, ctx.Err()
+	}
+}
+
+func (itr *RowDiffIterator) rowDiff(d diff.Difference) (RowDiff, error) {
+	key := d.KeyValue.(types.Tuple)
+	rd := RowDiff{Type: Modified, Key: key}
+
+	if d.OldValue == nil {
+		rd.Type = Added
+	} else {
+		var err error
+		rd.Old, err = row.FromNoms(itr.fromSch, key, d.OldValue.(types.Tuple))
+
+		if err != nil {
+			return RowDiff{}, err
+		}
+	}
+
+	if d.NewValue == nil {
+		rd.Type = Removed
+	} else {
+		var err error
+		rd.New, err = row.FromNoms(itr.toSch, key, d.NewValue.(types.Tuple))
+
+		if err != nil {
+			return RowDiff{}, err
+		}
+	}
+
+	return rd, nil
+}
+
+// Close stops the diff. Next shouldn't be called after the iterator is closed.
+func (itr *RowDiffIterator) Close() error {
+	itr.closeOnce.Do(func() {
+		close(itr.stop)
+	})
+
+	return nil
+}
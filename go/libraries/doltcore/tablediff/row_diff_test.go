@@ -0,0 +1,229 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tablediff_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/tablediff"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// testRoots returns an empty root, a root with the table people, and a root in which some of its rows are modified and
+// rows are added.
+func testRoots(t *testing.T) (empty, from, to *doltdb.RootValue) {
+	ctx := context.Background()
+	dEnv := dtestutils.CreateTestEnv()
+	empty, err := dEnv.WorkingRoot(ctx)
+	require.NoError(t, err)
+
+	from, err = sqle.ExecuteSql(dEnv, empty, `create table people (id bigint not null, name varchar(64), primary key (id));
+insert into people values (1, "Homer"), (2, "Marge"), (3, "Bart")`)
+	require.NoError(t, err)
+
+	to, err = sqle.ExecuteSql(dEnv, from, `replace into people values (1, "Abe"), (3, "Lisa");
+insert into people values (4, "Maggie"), (5, "Patty")`)
+	require.NoError(t, err)
+
+	return empty, from, to
+}
+
+type testDiff struct {
+	Type tablediff.ChangeType
+	ID   int64
+}
+
+// readDiffs reads every diff of |itr|, checking that its rows have the iterator's schemas.
+func readDiffs(t *testing.T, itr *tablediff.RowDiffIterator) []testDiff {
+	ctx := context.Background()
+	fromID, _ := itr.FromSchema().GetAllCols().GetByName("id")
+	toID, _ := itr.ToSchema().GetAllCols().GetByName("id")
+
+	var diffs []testDiff
+	for {
+		rd, err := itr.Next(ctx)
+
+		if err == io.EOF {
+			break
+		}
+
+		require.NoError(t, err)
+
+		var id types.Value
+		var ok bool
+		if rd.Type == tablediff.Added {
+			assert.Nil(t, rd.Old)
+			id, ok = rd.New.GetColVal(toID.Tag)
+		} else {
+			if rd.Type == tablediff.Removed {
+				assert.Nil(t, rd.New)
+			} else {
+				assert.NotNil(t, rd.New)
+			}
+
+			id, ok = rd.Old.GetColVal(fromID.Tag)
+		}
+
+		require.True(t, ok)
+		diffs = append(diffs, testDiff{rd.Type, int64(id.(types.Int))})
+	}
+
+	return diffs
+}
+
+func TestRowDiffIterator(t *testing.T) {
+	ctx := context.Background()
+	empty, from, to := testRoots(t)
+
+	tests := []struct {
+		name      string
+		from      *doltdb.RootValue
+		to        *doltdb.RootValue
+		keyRanges []tablediff.KeyRange
+		expected  []testDiff
+	}{
+		{
+			name:     "modified and added",
+			from:     from,
+			to:       to,
+			expected: []testDiff{{tablediff.Modified, 1}, {tablediff.Modified, 3}, {tablediff.Added, 4}, {tablediff.Added, 5}},
+		},
+		{
+			name:     "modified and removed",
+			from:     to,
+			to:       from,
+			expected: []testDiff{{tablediff.Modified, 1}, {tablediff.Modified, 3}, {tablediff.Removed, 4}, {tablediff.Removed, 5}},
+		},
+		{
+			name:     "table added",
+			from:     empty,
+			to:       from,
+			expected: []testDiff{{tablediff.Added, 1}, {tablediff.Added, 2}, {tablediff.Added, 3}},
+		},
+		{
+			name:     "table removed",
+			from:     from,
+			to:       empty,
+			expected: []testDiff{{tablediff.Removed, 1}, {tablediff.Removed, 2}, {tablediff.Removed, 3}},
+		},
+		{
+			name:     "unchanged",
+			from:     to,
+			to:       to,
+			expected: nil,
+		},
+		{
+			name: "key ranges",
+			from: from,
+			to:   to,
+			keyRanges: []tablediff.KeyRange{
+				{InRange: lessThanKey(t, to, 2)},
+				{Start: key(t, to, 4)},
+			},
+			expected: []testDiff{{tablediff.Modified, 1}, {tablediff.Added, 4}, {tablediff.Added, 5}},
+		},
+		{
+			name:      "no key ranges",
+			from:      from,
+			to:        to,
+			keyRanges: []tablediff.KeyRange{},
+			expected:  nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			itr, err := tablediff.NewRowDiffIterator(ctx, test.from, test.to, "people", test.keyRanges)
+			require.NoError(t, err)
+			defer itr.Close()
+
+			assert.Equal(t, test.expected, readDiffs(t, itr))
+		})
+	}
+}
+
+func TestRowDiffIteratorTableNotFound(t *testing.T) {
+	ctx := context.Background()
+	empty, from, _ := testRoots(t)
+
+	_, err := tablediff.NewRowDiffIterator(ctx, empty, from, "not_a_table", nil)
+	assert.Equal(t, tablediff.ErrTableNotFound, err)
+}
+
+func TestRowDiffIteratorClose(t *testing.T) {
+	ctx := context.Background()
+	_, from, to := testRoots(t)
+
+	itr, err := tablediff.NewRowDiffIterator(ctx, from, to, "people", nil)
+	require.NoError(t, err)
+
+	_, err = itr.Next(ctx)
+	require.NoError(t, err)
+
+	// closing an iterator which hasn't been read to the end stops its diff, and closing it again is harmless
+	assert.NoError(t, itr.Close())
+	assert.NoError(t, itr.Close())
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	itr, err = tablediff.NewRowDiffIterator(ctx, from, to, "people", nil)
+	require.NoError(t, err)
+	defer itr.Close()
+
+	// Next returns the error of a canceled context, unless the diff has already been read to the end
+	for {
+		if _, err = itr.Next(canceled); err != nil {
+			break
+		}
+	}
+
+	assert.True(t, err == context.Canceled || err == io.EOF)
+}
+
+func key(t *testing.T, root *doltdb.RootValue, id int64) types.Value {
+	k, err := types.NewTuple(root.VRW().Format(), types.Uint(idTag(t, root)), types.Int(id))
+	require.NoError(t, err)
+
+	return k
+}
+
+func lessThanKey(t *testing.T, root *doltdb.RootValue, id int64) func(types.Value) (bool, error) {
+	limit := key(t, root, id)
+	return func(k types.Value) (bool, error) {
+		return k.Less(root.VRW().Format(), limit)
+	}
+}
+
+func idTag(t *testing.T, root *doltdb.RootValue) uint64 {
+	tbl, _, err := root.GetTable(context.Background(), "people")
+	require.NoError(t, err)
+
+	sch, err := tbl.GetSchema(context.Background())
+	require.NoError(t, err)
+
+	col, ok := sch.GetAllCols().GetByName("id")
+	require.True(t, ok)
+
+	return col.Tag
+}
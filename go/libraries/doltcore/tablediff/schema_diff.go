@@ -0,0 +1,186 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tablediff
+
+import (
+	"reflect"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+)
+
+// PrimaryKeyIndexName is the name of the index of a table's primary key. Tables have no other indexes in this version
+// of dolt.
+const PrimaryKeyIndexName = "PRIMARY"
+
+// SchemaChanges are the changes made to the schema of a table between two versions of it.
+type SchemaChanges struct {
+	// Columns are the columns which were added, removed or modified, in the order of the older schema's columns,
+	// followed by the columns which were added. Columns are matched by their tags, so a renamed column is modified.
+	Columns []ColumnDiff
+
+	// Indexes are the indexes which were added, removed or modified
+	Indexes []IndexDiff
+
+	// Constraints are the constraints which were added, removed or modified on the columns in both versions. The
+	// constraints of added and removed columns are those of the columns.
+	Constraints []ConstraintDiff
+
+	// OldComment and NewComment are the table's comment in the older and newer versions
+	OldComment string
+	NewComment string
+}
+
+// IsEmpty returns whether the schemas are the same.
+func (sc SchemaChanges) IsEmpty() bool {
+	return len(sc.Columns) == 0 && len(sc.Indexes) == 0 && len(sc.Constraints) == 0 && !sc.CommentChanged()
+}
+
+// CommentChanged returns whether the table's comment changed.
+func (sc SchemaChanges) CommentChanged() bool {
+	return sc.OldComment != sc.NewComment
+}
+
+// ColumnDiff is a column which was added, removed or modified. Old is nil for added columns and New is nil for
+// removed columns. The remaining fields say which properties of a modified column changed.
+type ColumnDiff struct {
+	Type ChangeType
+	Tag  uint64
+	Old  *schema.Column
+	New  *schema.Column
+
+	NameChanged        bool
+	TypeChanged        bool
+	PrimaryKeyChanged  bool
+	ConstraintsChanged bool
+	CommentChanged     bool
+	StorageChanged     bool
+}
+
+// IndexDiff is an index which was added, removed or modified. OldColumns and NewColumns are the names of the columns
+// of the index, in order, in the older and newer versions, and are nil for added and removed indexes respectively.
+type IndexDiff struct {
+	Type       ChangeType
+	Name       string
+	OldColumns []string
+	NewColumns []string
+}
+
+// ConstraintDiff is a constraint on a column which was added, removed or modified. Old is nil for added constraints
+// and New is nil for removed constraints.
+type ConstraintDiff struct {
+	Type ChangeType
+
+	// Tag is the tag of the column the constraint is on, and Column is its name in the newer version
+	Tag    uint64
+	Column string
+
+	// ConstraintType is the type of the constraint, such as schema.NotNullConstraintType
+	ConstraintType string
+	Old            schema.ColConstraint
+	New            schema.ColConstraint
+}
+
+// SchemaDiff returns the changes made to a table's schema between |fromSch| and |toSch|. The schema of a table which
+// doesn't exist in one of the versions can be given as schema.EmptySchema.
+func SchemaDiff(fromSch, toSch schema.Schema) SchemaChanges {
+	changes := SchemaChanges{OldComment: fromSch.GetComment(), NewComment: toSch.GetComment()}
+
+	toCols := toSch.GetAllCols()
+	_ = fromSch.GetAllCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+		fromCol := col
+		toCol, ok := toCols.GetByTag(tag)
+
+		if !ok {
+			changes.Columns = append(changes.Columns, ColumnDiff{Type: Removed, Tag: tag, Old: &fromCol})
+			return false, nil
+		}
+
+		if cd, modified := diffColumns(fromCol, toCol); modified {
+			changes.Columns = append(changes.Columns, cd)
+		}
+
+		changes.Constraints = append(changes.Constraints, diffConstraints(fromCol, toCol)...)
+		return false, nil
+	})
+
+	fromCols := fromSch.GetAllCols()
+	_ = toCols.Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+		toCol := col
+		if _, ok := fromCols.GetByTag(tag); !ok {
+			changes.Columns = append(changes.Columns, ColumnDiff{Type: Added, Tag: tag, New: &toCol})
+		}
+
+		return false, nil
+	})
+
+	fromPKs := fromSch.GetPKCols().GetColumnNames()
+	toPKs := toSch.GetPKCols().GetColumnNames()
+	switch {
+	case len(fromPKs) == 0 && len(toPKs) > 0:
+		changes.Indexes = append(changes.Indexes, IndexDiff{Type: Added, Name: PrimaryKeyIndexName, NewColumns: toPKs})
+	case len(fromPKs) > 0 && len(toPKs) == 0:
+		changes.Indexes = append(changes.Indexes, IndexDiff{Type: Removed, Name: PrimaryKeyIndexName, OldColumns: fromPKs})
+	case !reflect.DeepEqual(fromSch.GetPKCols().Tags, toSch.GetPKCols().Tags):
+		changes.Indexes = append(changes.Indexes, IndexDiff{Type: Modified, Name: PrimaryKeyIndexName, OldColumns: fromPKs, NewColumns: toPKs})
+	}
+
+	return changes
+}
+
+// diffColumns returns the ColumnDiff of two versions of a column, and whether they differ.
+func diffColumns(fromCol, toCol schema.Column) (ColumnDiff, bool) {
+	cd := ColumnDiff{
+		Type:               Modified,
+		Tag:                fromCol.Tag,
+		Old:                &fromCol,
+		New:                &toCol,
+		NameChanged:        fromCol.Name != toCol.Name,
+		TypeChanged:        fromCol.Kind != toCol.Kind || !fromCol.TypeInfo.Equals(toCol.TypeInfo),
+		PrimaryKeyChanged:  fromCol.IsPartOfPK != toCol.IsPartOfPK,
+		ConstraintsChanged: !schema.ColConstraintsAreEqual(fromCol.Constraints, toCol.Constraints),
+		CommentChanged:     fromCol.Comment != toCol.Comment,
+		StorageChanged:     fromCol.Cold != toCol.Cold,
+	}
+
+	modified := cd.NameChanged || cd.TypeChanged || cd.PrimaryKeyChanged || cd.ConstraintsChanged || cd.CommentChanged || cd.StorageChanged
+	return cd, modified
+}
+
+// diffConstraints returns the constraints which were added, removed or modified between two versions of a column.
+// Constraints are matched by their types.
+func diffConstraints(fromCol, toCol schema.Column) []ConstraintDiff {
+	var diffs []ConstraintDiff
+	for _, fromCnst := range fromCol.Constraints {
+		cd := ConstraintDiff{Tag: toCol.Tag, Column: toCol.Name, ConstraintType: fromCnst.GetConstraintType(), Old: fromCnst}
+		idx := schema.IndexOfConstraint(toCol.Constraints, cd.ConstraintType)
+
+		if idx < 0 {
+			cd.Type = Removed
+			diffs = append(diffs, cd)
+		} else if toCnst := toCol.Constraints[idx]; !reflect.DeepEqual(fromCnst.GetConstraintParams(), toCnst.GetConstraintParams()) {
+			cd.Type = Modified
+			cd.New = toCnst
+			diffs = append(diffs, cd)
+		}
+	}
+
+	for _, toCnst := range toCol.Constraints {
+		if schema.IndexOfConstraint(fromCol.Constraints, toCnst.GetConstraintType()) < 0 {
+			diffs = append(diffs, ConstraintDiff{Type: Added, Tag: toCol.Tag, Column: toCol.Name, ConstraintType: toCnst.GetConstraintType(), New: toCnst})
+		}
+	}
+
+	return diffs
+}
@@ -0,0 +1,104 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tablediff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+func schemaWithCols(t *testing.T, comment string, cols ...schema.Column) schema.Schema {
+	colColl, err := schema.NewColCollection(cols...)
+	require.NoError(t, err)
+
+	return schema.SchemaWithComment(schema.SchemaFromCols(colColl), comment)
+}
+
+func TestSchemaDiff(t *testing.T) {
+	oldCols := []schema.Column{
+		schema.NewColumn("unchanged", 0, types.StringKind, true, schema.NotNullConstraint{}),
+		schema.NewColumn("removed", 1, types.StringKind, false),
+		schema.NewColumn("renamed", 2, types.StringKind, false),
+		schema.NewColumn("type_changed", 3, types.StringKind, false),
+		schema.NewColumn("moved_to_pk", 4, types.StringKind, false, schema.NotNullConstraint{}),
+		schema.NewColumn("constraint_added", 5, types.StringKind, false),
+		schema.NewColumn("constraint_removed", 6, types.StringKind, false, schema.NotNullConstraint{}),
+	}
+
+	newCols := []schema.Column{
+		schema.NewColumn("unchanged", 0, types.StringKind, true, schema.NotNullConstraint{}),
+		schema.NewColumn("renamed_new", 2, types.StringKind, false),
+		schema.NewColumn("type_changed", 3, types.IntKind, false),
+		schema.NewColumn("moved_to_pk", 4, types.StringKind, true, schema.NotNullConstraint{}),
+		schema.NewColumn("constraint_added", 5, types.StringKind, false, schema.NotNullConstraint{}),
+		schema.NewColumn("constraint_removed", 6, types.StringKind, false),
+		schema.NewColumn("added", 7, types.StringKind, false),
+	}
+
+	changes := SchemaDiff(schemaWithCols(t, "old", oldCols...), schemaWithCols(t, "new", newCols...))
+
+	expectedCols := []ColumnDiff{
+		{Type: Removed, Tag: 1, Old: &oldCols[1]},
+		{Type: Modified, Tag: 2, Old: &oldCols[2], New: &newCols[1], NameChanged: true},
+		{Type: Modified, Tag: 3, Old: &oldCols[3], New: &newCols[2], TypeChanged: true},
+		{Type: Modified, Tag: 4, Old: &oldCols[4], New: &newCols[3], PrimaryKeyChanged: true},
+		{Type: Modified, Tag: 5, Old: &oldCols[5], New: &newCols[4], ConstraintsChanged: true},
+		{Type: Modified, Tag: 6, Old: &oldCols[6], New: &newCols[5], ConstraintsChanged: true},
+		{Type: Added, Tag: 7, New: &newCols[6]},
+	}
+	assert.Equal(t, expectedCols, changes.Columns)
+
+	expectedConstraints := []ConstraintDiff{
+		{Type: Added, Tag: 5, Column: "constraint_added", ConstraintType: schema.NotNullConstraintType, New: schema.NotNullConstraint{}},
+		{Type: Removed, Tag: 6, Column: "constraint_removed", ConstraintType: schema.NotNullConstraintType, Old: schema.NotNullConstraint{}},
+	}
+	assert.Equal(t, expectedConstraints, changes.Constraints)
+
+	expectedIndexes := []IndexDiff{
+		{Type: Modified, Name: PrimaryKeyIndexName, OldColumns: []string{"unchanged"}, NewColumns: []string{"unchanged", "moved_to_pk"}},
+	}
+	assert.Equal(t, expectedIndexes, changes.Indexes)
+
+	assert.True(t, changes.CommentChanged())
+	assert.False(t, changes.IsEmpty())
+}
+
+func TestSchemaDiffUnchanged(t *testing.T) {
+	cols := []schema.Column{
+		schema.NewColumn("id", 0, types.IntKind, true, schema.NotNullConstraint{}),
+		schema.NewColumn("name", 1, types.StringKind, false),
+	}
+
+	changes := SchemaDiff(schemaWithCols(t, "comment", cols...), schemaWithCols(t, "comment", cols...))
+	assert.True(t, changes.IsEmpty())
+}
+
+func TestSchemaDiffTableAdded(t *testing.T) {
+	cols := []schema.Column{
+		schema.NewColumn("id", 0, types.IntKind, true, schema.NotNullConstraint{}),
+		schema.NewColumn("name", 1, types.StringKind, false),
+	}
+
+	changes := SchemaDiff(schema.EmptySchema, schemaWithCols(t, "", cols...))
+
+	assert.Equal(t, []ColumnDiff{{Type: Added, Tag: 0, New: &cols[0]}, {Type: Added, Tag: 1, New: &cols[1]}}, changes.Columns)
+	assert.Equal(t, []IndexDiff{{Type: Added, Name: PrimaryKeyIndexName, NewColumns: []string{"id"}}}, changes.Indexes)
+	assert.Empty(t, changes.Constraints)
+}
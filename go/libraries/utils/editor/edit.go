@@ -15,18 +15,54 @@
 package editor
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"unicode"
 
 	"github.com/google/uuid"
+
+	"github.com/liquidata-inc/dolt/go/libraries/utils/osutil"
+)
+
+const (
+	visualEnvVar = "VISUAL"
+	editorEnvVar = "EDITOR"
+
+	defaultEditor        = "vim"
+	defaultWindowsEditor = "notepad"
 )
 
+var ErrNoEditor = errors.New("no editor specified")
+
+// DefaultEditor returns the editor that should be used when one has not been configured explicitly.  The VISUAL
+// environment variable takes precedence over EDITOR, and if neither is set a platform specific default is used.
+func DefaultEditor() string {
+	for _, envVar := range []string{visualEnvVar, editorEnvVar} {
+		if ed, ok := os.LookupEnv(envVar); ok && strings.TrimSpace(ed) != "" {
+			return ed
+		}
+	}
+
+	if osutil.IsWindows {
+		return defaultWindowsEditor
+	}
+
+	return defaultEditor
+}
+
 //OpenCommitEditor allows user to write/edit commit message in temporary file
 func OpenCommitEditor(ed string, initialContents string) (string, error) {
+	cmdName, cmdArgs := getCmdNameAndArgsForEditor(ed)
+
+	if cmdName == "" {
+		return "", ErrNoEditor
+	}
+
 	filename := filepath.Join(os.TempDir(), uuid.New().String())
 	err := ioutil.WriteFile(filename, []byte(initialContents), os.ModePerm)
 
@@ -34,11 +70,7 @@ func OpenCommitEditor(ed string, initialContents string) (string, error) {
 		return "", err
 	}
 
-	cmdName, cmdArgs := getCmdNameAndArgsForEditor(ed)
-
-	if cmdName == "" {
-		panic("No editor specified: " + ed)
-	}
+	defer os.Remove(filename)
 
 	cmdArgs = append(cmdArgs, filename)
 
@@ -53,13 +85,18 @@ func OpenCommitEditor(ed string, initialContents string) (string, error) {
 	fmt.Printf("Waiting for command to finish.\n")
 	err = cmd.Wait()
 
+	if err != nil {
+		return "", fmt.Errorf("editor '%s' exited with an error: %w", cmdName, err)
+	}
+
 	data, err := ioutil.ReadFile(filename)
 
 	if err != nil {
 		return "", err
 	}
 
-	return string(data), nil
+	// editors on windows will frequently save with CRLF line endings
+	return strings.ReplaceAll(string(data), "\r\n", "\n"), nil
 }
 
 func getCmdNameAndArgsForEditor(es string) (string, []string) {
@@ -111,6 +148,10 @@ func getCmdNameAndArgsForEditor(es string) (string, []string) {
 		spans = append(spans, span{start, len(es)})
 	}
 
+	if len(spans) == 0 {
+		return "", nil
+	}
+
 	results := make([]string, len(spans))
 	for i, span := range spans {
 		results[i] = es[span.start:span.end]
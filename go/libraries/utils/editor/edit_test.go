@@ -45,6 +45,22 @@ func TestGetCmdNameAndArgsForEditor(t *testing.T) {
 	}
 }
 
+func TestNoEditor(t *testing.T) {
+	for _, edStr := range []string{"", "   "} {
+		cmd, _ := getCmdNameAndArgsForEditor(edStr)
+
+		if cmd != "" {
+			t.Error(cmd, "!= \"\"")
+		}
+
+		_, err := OpenCommitEditor(edStr, "")
+
+		if err != ErrNoEditor {
+			t.Error(err, "!=", ErrNoEditor)
+		}
+	}
+}
+
 func TestOpenCommitEditor(t *testing.T) {
 	if osutil.IsWindows {
 		t.Skip("Invalid test on Windows as /bin/sh does not exist")
@@ -56,6 +72,7 @@ func TestOpenCommitEditor(t *testing.T) {
 	}{
 		{`/bin/sh -c 'printf "this is a test" > $1' -- `, "", "this is a test"},
 		{`/bin/sh -c 'printf "this is a test" > $1' -- `, "Initial contents: ", "this is a test"},
+		{`/bin/sh -c 'printf "line one\r\nline two\r\n" > $1' -- `, "", "line one\nline two\n"},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,62 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package background
+
+import (
+	"context"
+	"io"
+)
+
+type taskKey struct{}
+
+// WithTask returns a copy of |ctx| which carries |t|, so that the IO done with it can be scheduled without the Task
+// being passed down explicitly.
+func WithTask(ctx context.Context, t *Task) context.Context {
+	return context.WithValue(ctx, taskKey{}, t)
+}
+
+// TaskFromContext returns the Task carried by |ctx|, or nil if there isn't one.
+func TaskFromContext(ctx context.Context) *Task {
+	t, _ := ctx.Value(taskKey{}).(*Task)
+	return t
+}
+
+// NewWriter returns an io.Writer which writes to |w|, waiting before each write for the Task carried by |ctx| to be
+// able to do it. If |ctx| doesn't carry a Task, |w| is returned.
+func NewWriter(ctx context.Context, w io.Writer) io.Writer {
+	t := TaskFromContext(ctx)
+
+	if t == nil {
+		return w
+	}
+
+	return &taskWriter{ctx, t, w}
+}
+
+type taskWriter struct {
+	ctx context.Context
+	t   *Task
+	w   io.Writer
+}
+
+func (tw *taskWriter) Write(p []byte) (int, error) {
+	err := tw.t.Wait(tw.ctx, len(p))
+
+	if err != nil {
+		return 0, err
+	}
+
+	return tw.w.Write(p)
+}
@@ -0,0 +1,361 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package background schedules the IO of the work a store does in the background of the queries of a server, such as
+// conjoining table files, so that it doesn't starve them.
+package background
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Priority is the priority of a Task. The IO of a task waits while a task of a higher priority is running.
+type Priority int
+
+const (
+	// ConjoinPriority is the priority of the conjoins of the table files of a store.
+	ConjoinPriority Priority = iota
+
+	// StatisticsPriority is the priority of the collection of table statistics.
+	StatisticsPriority
+
+	// ReplicationPriority is the priority of pushes to remotes.
+	ReplicationPriority
+)
+
+// String returns the name of the priority.
+func (p Priority) String() string {
+	switch p {
+	case ConjoinPriority:
+		return "conjoin"
+	case StatisticsPriority:
+		return "statistics"
+	case ReplicationPriority:
+		return "replication"
+	default:
+		return "unknown"
+	}
+}
+
+// TaskState is what a Task is doing.
+type TaskState string
+
+const (
+	// TaskRunning is the state of a task which isn't waiting to do IO.
+	TaskRunning TaskState = "running"
+
+	// TaskThrottled is the state of a task which is waiting for the IO budget of its scheduler.
+	TaskThrottled TaskState = "throttled"
+
+	// TaskPaused is the state of a task which is waiting for its scheduler to be resumed.
+	TaskPaused TaskState = "paused"
+
+	// TaskWaiting is the state of a task which is waiting for the tasks of a higher priority to finish.
+	TaskWaiting TaskState = "waiting"
+)
+
+// DefaultScheduler is the Scheduler of the background work of the process. It doesn't limit IO until it's configured
+// to.
+var DefaultScheduler = NewScheduler()
+
+// Scheduler throttles the IO of the background tasks of a process to a budget of bytes per second, pauses it while
+// the queries served by the process are slow, and holds back the IO of tasks while tasks of a higher priority are
+// running. Tasks report the bytes they are about to read or write to it with Task.Wait.
+type Scheduler struct {
+	mu          *sync.Mutex
+	bytesPerSec int64
+	next        time.Time
+
+	paused         bool
+	pausedUntil    time.Time
+	pauseThreshold time.Duration
+	pauseCooldown  time.Duration
+
+	tasks  map[uint64]*Task
+	nextID uint64
+
+	// changed is closed, and replaced, whenever a waiting task may be able to proceed
+	changed chan struct{}
+}
+
+// NewScheduler returns a Scheduler which doesn't limit IO until it's configured to.
+func NewScheduler() *Scheduler {
+	return &Scheduler{mu: &sync.Mutex{}, tasks: make(map[uint64]*Task), changed: make(chan struct{})}
+}
+
+// SetBytesPerSecond sets the budget of bytes per second shared by the IO of all tasks. 0 doesn't limit it.
+func (s *Scheduler) SetBytesPerSecond(bytesPerSec int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.bytesPerSec = bytesPerSec
+	s.next = time.Time{}
+	s.notify()
+}
+
+// SetPauseLatency pauses the IO of all tasks for |cooldown| whenever a query which took longer than |threshold| is
+// observed. A threshold of 0 never pauses it.
+func (s *Scheduler) SetPauseLatency(threshold, cooldown time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pauseThreshold = threshold
+	s.pauseCooldown = cooldown
+
+	if threshold == 0 {
+		s.pausedUntil = time.Time{}
+		s.notify()
+	}
+}
+
+// ObserveLatency reports the latency of a query served by the process.
+func (s *Scheduler) ObserveLatency(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pauseThreshold == 0 || latency <= s.pauseThreshold {
+		return
+	}
+
+	until := time.Now().Add(s.pauseCooldown)
+	if until.After(s.pausedUntil) {
+		s.pausedUntil = until
+	}
+}
+
+// Pause pauses the IO of all tasks until Resume is called.
+func (s *Scheduler) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.paused = true
+}
+
+// Resume resumes the IO of the tasks paused by Pause, or by a slow query.
+func (s *Scheduler) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.paused = false
+	s.pausedUntil = time.Time{}
+	s.notify()
+}
+
+// Start starts a Task named |name| of priority |priority|. Done must be called once it has finished.
+func (s *Scheduler) Start(name string, priority Priority) *Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	t := &Task{s: s, id: s.nextID, name: name, priority: priority, started: time.Now(), state: TaskRunning}
+	s.tasks[t.id] = t
+
+	return t
+}
+
+// Tasks returns the tasks which are running, in the order they were started.
+func (s *Scheduler) Tasks() []TaskInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	infos := make([]TaskInfo, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		infos = append(infos, TaskInfo{
+			ID:       t.id,
+			Name:     t.name,
+			Priority: t.priority,
+			State:    t.state,
+			Started:  t.started,
+			Done:     atomic.LoadInt64(&t.done),
+			Total:    atomic.LoadInt64(&t.total),
+		})
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ID < infos[j].ID
+	})
+
+	return infos
+}
+
+// notify wakes the waiting tasks. It must be called with the lock held.
+func (s *Scheduler) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// blocked returns the state a task of priority |p| waits in, and until when, or TaskRunning if it can do IO. It must
+// be called with the lock held.
+func (s *Scheduler) blocked(p Priority, now time.Time) (TaskState, time.Time) {
+	if s.paused {
+		return TaskPaused, time.Time{}
+	}
+
+	if now.Before(s.pausedUntil) {
+		return TaskPaused, s.pausedUntil
+	}
+
+	for _, t := range s.tasks {
+		if t.priority > p {
+			return TaskWaiting, time.Time{}
+		}
+	}
+
+	return TaskRunning, time.Time{}
+}
+
+// reserve reserves |bytes| of the budget, and returns how long to wait before doing the IO. It must be called with
+// the lock held.
+func (s *Scheduler) reserve(bytes int, now time.Time) time.Duration {
+	if s.bytesPerSec <= 0 {
+		return 0
+	}
+
+	start := s.next
+	if start.Before(now) {
+		start = now
+	}
+
+	s.next = start.Add(time.Duration(float64(bytes) / float64(s.bytesPerSec) * float64(time.Second)))
+
+	return start.Sub(now)
+}
+
+// TaskInfo describes a Task, and its progress.
+type TaskInfo struct {
+	ID       uint64
+	Name     string
+	Priority Priority
+	State    TaskState
+	Started  time.Time
+
+	// Done is the number of bytes of IO the task has done
+	Done int64
+
+	// Total is the number of bytes of IO the task expects to do, or 0 if it isn't known
+	Total int64
+}
+
+// Task is a piece of background work, whose IO is scheduled by a Scheduler.
+type Task struct {
+	// done and total are first so that they are aligned for atomic operations on 32 bit platforms
+	done  int64
+	total int64
+
+	s        *Scheduler
+	id       uint64
+	name     string
+	priority Priority
+	started  time.Time
+
+	// state is guarded by the lock of the scheduler
+	state TaskState
+}
+
+// SetTotal sets the number of bytes of IO the task expects to do.
+func (t *Task) SetTotal(bytes int64) {
+	atomic.StoreInt64(&t.total, bytes)
+}
+
+// Wait waits until the task can read or write |bytes|, and counts them as done. It returns the error of |ctx| if it's
+// canceled first.
+func (t *Task) Wait(ctx context.Context, bytes int) error {
+	s := t.s
+	for {
+		now := time.Now()
+		s.mu.Lock()
+		state, until := s.blocked(t.priority, now)
+
+		if state == TaskRunning {
+			delay := s.reserve(bytes, now)
+
+			if delay > 0 {
+				t.state = TaskThrottled
+			}
+
+			s.mu.Unlock()
+
+			err := sleep(ctx, delay)
+
+			s.mu.Lock()
+			t.state = TaskRunning
+			s.mu.Unlock()
+
+			if err != nil {
+				return err
+			}
+
+			atomic.AddInt64(&t.done, int64(bytes))
+			return nil
+		}
+
+		t.state = state
+		changed := s.changed
+		s.mu.Unlock()
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if !until.IsZero() {
+			timer = time.NewTimer(until.Sub(now))
+			timeout = timer.C
+		}
+
+		select {
+		case <-changed:
+		case <-timeout:
+		case <-ctx.Done():
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+
+		if ctx.Err() != nil {
+			s.mu.Lock()
+			t.state = TaskRunning
+			s.mu.Unlock()
+
+			return ctx.Err()
+		}
+	}
+}
+
+// Done removes the task from its scheduler, letting the tasks of lower priorities proceed.
+func (t *Task) Done() {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+
+	delete(t.s.tasks, t.id)
+	t.s.notify()
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package background
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitForState waits for the task with the id given to be in |state|.
+func waitForState(t *testing.T, s *Scheduler, id uint64, state TaskState) {
+	require.Eventually(t, func() bool {
+		for _, info := range s.Tasks() {
+			if info.ID == id {
+				return info.State == state
+			}
+		}
+
+		return false
+	}, time.Second, time.Millisecond)
+}
+
+func TestSchedulerThrottles(t *testing.T) {
+	ctx := context.Background()
+	s := NewScheduler()
+	s.SetBytesPerSecond(100 * 1024)
+
+	task := s.Start("conjoin", ConjoinPriority)
+	defer task.Done()
+	task.SetTotal(30 * 1024)
+
+	// the first 10k are written right away, and the next 20k at 100k a second
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, task.Wait(ctx, 10*1024))
+	}
+
+	assert.True(t, time.Since(start) >= 200*time.Millisecond)
+
+	infos := s.Tasks()
+	require.Len(t, infos, 1)
+	assert.Equal(t, "conjoin", infos[0].Name)
+	assert.Equal(t, ConjoinPriority, infos[0].Priority)
+	assert.Equal(t, TaskRunning, infos[0].State)
+	assert.Equal(t, int64(30*1024), infos[0].Done)
+	assert.Equal(t, int64(30*1024), infos[0].Total)
+}
+
+func TestSchedulerUnlimited(t *testing.T) {
+	ctx := context.Background()
+	s := NewScheduler()
+	task := s.Start("conjoin", ConjoinPriority)
+
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		require.NoError(t, task.Wait(ctx, 1<<30))
+	}
+
+	assert.True(t, time.Since(start) < time.Second)
+
+	task.Done()
+	assert.Empty(t, s.Tasks())
+}
+
+func TestSchedulerPriorities(t *testing.T) {
+	ctx := context.Background()
+	s := NewScheduler()
+	push := s.Start("push", ReplicationPriority)
+	conjoin := s.Start("conjoin", ConjoinPriority)
+	defer conjoin.Done()
+
+	// the push isn't held back by the conjoin
+	require.NoError(t, push.Wait(ctx, 10))
+
+	done := make(chan error)
+	go func() {
+		done <- conjoin.Wait(ctx, 10)
+	}()
+
+	waitForState(t, s, conjoin.id, TaskWaiting)
+	push.Done()
+	require.NoError(t, <-done)
+}
+
+func TestSchedulerPause(t *testing.T) {
+	ctx := context.Background()
+	s := NewScheduler()
+	task := s.Start("conjoin", ConjoinPriority)
+	defer task.Done()
+
+	s.Pause()
+	done := make(chan error)
+	go func() {
+		done <- task.Wait(ctx, 10)
+	}()
+
+	waitForState(t, s, task.id, TaskPaused)
+	s.Resume()
+	require.NoError(t, <-done)
+
+	// a slow query pauses the work for the cooldown
+	s.SetPauseLatency(10*time.Millisecond, 100*time.Millisecond)
+	s.ObserveLatency(5 * time.Millisecond)
+	start := time.Now()
+	require.NoError(t, task.Wait(ctx, 10))
+	assert.True(t, time.Since(start) < 100*time.Millisecond)
+
+	s.ObserveLatency(20 * time.Millisecond)
+	start = time.Now()
+	require.NoError(t, task.Wait(ctx, 10))
+	assert.True(t, time.Since(start) >= 90*time.Millisecond)
+}
+
+func TestSchedulerCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewScheduler()
+	task := s.Start("conjoin", ConjoinPriority)
+	defer task.Done()
+
+	s.Pause()
+	done := make(chan error)
+	go func() {
+		done <- task.Wait(ctx, 10)
+	}()
+
+	waitForState(t, s, task.id, TaskPaused)
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+	assert.Equal(t, TaskRunning, s.Tasks()[0].State)
+	assert.Equal(t, int64(0), s.Tasks()[0].Done)
+}
+
+func TestNewWriter(t *testing.T) {
+	ctx := context.Background()
+	buf := &bytes.Buffer{}
+	assert.Equal(t, buf, NewWriter(ctx, buf))
+
+	s := NewScheduler()
+	task := s.Start("conjoin", ConjoinPriority)
+	defer task.Done()
+	assert.Equal(t, task, TaskFromContext(WithTask(ctx, task)))
+
+	w := NewWriter(WithTask(ctx, task), buf)
+	_, err := w.Write([]byte("abc"))
+	require.NoError(t, err)
+	assert.Equal(t, "abc", buf.String())
+	assert.Equal(t, int64(3), s.Tasks()[0].Done)
+}
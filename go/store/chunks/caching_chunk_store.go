@@ -0,0 +1,195 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// ChunkCache is the part of ChunkStore used to cache the chunks of another store. It has no root, so a ChunkStore can
+// be used as a ChunkCache, but a ChunkCache such as an LRUChunkCache needn't keep every chunk put to it.
+type ChunkCache interface {
+	Get(ctx context.Context, h hash.Hash) (Chunk, error)
+	GetMany(ctx context.Context, hashes hash.HashSet, foundChunks chan<- *Chunk) error
+	GetManyF(ctx context.Context, hashes hash.HashSet, found func(*Chunk)) error
+	Has(ctx context.Context, h hash.Hash) (bool, error)
+	HasMany(ctx context.Context, hashes hash.HashSet) (absent hash.HashSet, err error)
+	Put(ctx context.Context, c Chunk) error
+	PutMany(ctx context.Context, chunks []Chunk) error
+	Close() error
+}
+
+// cachingChunkStore is a ChunkStore which reads through a fast cache to a slow backing store. The cache is only ever
+// an optimization: a failure to read from or write to it turns into a miss, and never fails a call.
+type cachingChunkStore struct {
+	cache   ChunkCache
+	backing ChunkStore
+}
+
+// NewCachingChunkStore returns a ChunkStore which reads chunks from |cache| first, reading the chunks it misses from
+// |backing| and putting them to |cache| for the next read. Chunks are put to both stores, while the root is only read,
+// rebased and committed on |backing|. A chunk which is in |cache| but not in |backing| is still read from the cache, so
+// |cache| should only ever be filled with chunks of |backing|.
+func NewCachingChunkStore(cache ChunkCache, backing ChunkStore) ChunkStore {
+	return cachingChunkStore{cache, backing}
+}
+
+// populate puts |c| to the cache, ignoring any failure, as the chunk has been read from the backing store already.
+func (ccs cachingChunkStore) populate(ctx context.Context, c Chunk) {
+	_ = ccs.cache.Put(ctx, c)
+}
+
+// Get reads |h| from the cache if the cache has it, so that a chunk with no data which is in the cache is a hit like any
+// other, rather than being read from the backing store again.
+func (ccs cachingChunkStore) Get(ctx context.Context, h hash.Hash) (Chunk, error) {
+	c, err := ccs.cache.Get(ctx, h)
+
+	if ok, _ := isFound(h, c, err); ok {
+		return c, nil
+	}
+
+	c, err = ccs.backing.Get(ctx, h)
+
+	if ok, _ := isFound(h, c, err); !ok {
+		return c, err
+	}
+
+	ccs.populate(ctx, c)
+
+	return c, nil
+}
+
+func (ccs cachingChunkStore) GetMany(ctx context.Context, hashes hash.HashSet, foundChunks chan<- *Chunk) error {
+	return GetManyFromF(ctx, hashes, foundChunks, ccs.GetManyF)
+}
+
+// GetManyF gets the chunks it can from the cache, and only asks the backing store for the chunks the cache missed. If
+// the cache fails part way through, the chunks it already found aren't asked for again.
+func (ccs cachingChunkStore) GetManyF(ctx context.Context, hashes hash.HashSet, found func(*Chunk)) error {
+	mu := &sync.Mutex{}
+	hits := hash.NewHashSet()
+	_ = ccs.cache.GetManyF(ctx, hashes, func(c *Chunk) {
+		mu.Lock()
+		hits.Insert(c.Hash())
+		mu.Unlock()
+
+		found(c)
+	})
+
+	misses := hash.NewHashSet()
+	for h := range hashes {
+		if !hits.Has(h) {
+			misses.Insert(h)
+		}
+	}
+
+	if len(misses) == 0 {
+		return nil
+	}
+
+	return ccs.backing.GetManyF(ctx, misses, func(c *Chunk) {
+		ccs.populate(ctx, *c)
+		found(c)
+	})
+}
+
+// GetManyCompressed compresses the chunks found in the cache or the backing store as they're sent, so that the chunks
+// read from the backing store are cached.
+func (ccs cachingChunkStore) GetManyCompressed(ctx context.Context, hashes hash.HashSet, foundCmpChunks chan<- CompressedChunk) error {
+	return GetManyCompressedFromF(ctx, hashes, foundCmpChunks, ccs.GetManyF)
+}
+
+func (ccs cachingChunkStore) Has(ctx context.Context, h hash.Hash) (bool, error) {
+	if has, err := ccs.cache.Has(ctx, h); err == nil && has {
+		return true, nil
+	}
+
+	return ccs.backing.Has(ctx, h)
+}
+
+// HasMany only asks the backing store about the hashes which are absent from the cache.
+func (ccs cachingChunkStore) HasMany(ctx context.Context, hashes hash.HashSet) (absent hash.HashSet, err error) {
+	misses, err := ccs.cache.HasMany(ctx, hashes)
+
+	if err != nil {
+		misses = hashes
+	}
+
+	if len(misses) == 0 {
+		return hash.NewHashSet(), nil
+	}
+
+	return ccs.backing.HasMany(ctx, misses)
+}
+
+// Put puts |c| to the backing store, then to the cache. A failure to put the chunk to the cache is ignored.
+func (ccs cachingChunkStore) Put(ctx context.Context, c Chunk) error {
+	if err := ccs.backing.Put(ctx, c); err != nil {
+		return err
+	}
+
+	ccs.populate(ctx, c)
+
+	return nil
+}
+
+// PutMany puts |chunks| to the backing store, then to the cache. A failure to put the chunks to the cache is ignored.
+func (ccs cachingChunkStore) PutMany(ctx context.Context, chunks []Chunk) error {
+	if err := ccs.backing.PutMany(ctx, chunks); err != nil {
+		return err
+	}
+
+	_ = ccs.cache.PutMany(ctx, chunks)
+
+	return nil
+}
+
+func (ccs cachingChunkStore) Version() string {
+	return ccs.backing.Version()
+}
+
+func (ccs cachingChunkStore) Rebase(ctx context.Context) error {
+	return ccs.backing.Rebase(ctx)
+}
+
+func (ccs cachingChunkStore) Root(ctx context.Context) (hash.Hash, error) {
+	return ccs.backing.Root(ctx)
+}
+
+func (ccs cachingChunkStore) Commit(ctx context.Context, current, last hash.Hash) (bool, error) {
+	return ccs.backing.Commit(ctx, current, last)
+}
+
+func (ccs cachingChunkStore) Stats() interface{} {
+	return ccs.backing.Stats()
+}
+
+func (ccs cachingChunkStore) StatsSummary() string {
+	return ccs.backing.StatsSummary()
+}
+
+// Close closes both stores, returning the error of the backing store if both fail.
+func (ccs cachingChunkStore) Close() error {
+	cacheErr := ccs.cache.Close()
+
+	if err := ccs.backing.Close(); err != nil {
+		return err
+	}
+
+	return cacheErr
+}
@@ -0,0 +1,184 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+func TestCachingChunkStoreGet(t *testing.T) {
+	ctx := context.Background()
+	cache := (&TestStorage{}).NewView()
+	backing := (&TestStorage{}).NewView()
+	hashes := putChunks(t, backing, "abc", "def")
+	cs := NewCachingChunkStore(cache, backing)
+
+	for h := range hashes {
+		c, err := cs.Get(ctx, h)
+		require.NoError(t, err)
+		assert.Equal(t, h, c.Hash())
+	}
+
+	assert.Equal(t, 2, backing.Reads())
+
+	// the second reads are served by the cache
+	for h := range hashes {
+		c, err := cs.Get(ctx, h)
+		require.NoError(t, err)
+		assert.Equal(t, h, c.Hash())
+	}
+
+	assert.Equal(t, 2, backing.Reads())
+
+	c, err := cs.Get(ctx, hash.Of([]byte("missing")))
+	assert.True(t, errors.Is(err, ErrChunkNotFound))
+	assert.True(t, c.IsEmpty())
+}
+
+func TestCachingChunkStoreGetEmptyChunk(t *testing.T) {
+	ctx := context.Background()
+	cache := (&TestStorage{}).NewView()
+	backing := (&TestStorage{}).NewView()
+	require.NoError(t, backing.Put(ctx, EmptyChunk))
+	cs := NewCachingChunkStore(cache, backing)
+
+	// a chunk with no data is cached, and read from the cache, like any other chunk
+	for i := 0; i < 2; i++ {
+		c, err := cs.Get(ctx, EmptyChunk.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, EmptyChunk.Hash(), c.Hash())
+	}
+
+	assert.Equal(t, 1, backing.Reads())
+}
+
+func TestCachingChunkStoreGetMany(t *testing.T) {
+	cache := (&TestStorage{}).NewView()
+	backing := (&TestStorage{}).NewView()
+	hashes := putChunks(t, backing, "abc", "def", "ghi")
+	cs := NewCachingChunkStore(cache, backing)
+
+	// warm the cache with one of the chunks
+	one := hash.NewHashSet(NewChunk([]byte("abc")).Hash())
+	got, err := getMany(cs, one)
+	require.NoError(t, err)
+	assert.Equal(t, one, got)
+	assert.Equal(t, 1, backing.Reads())
+
+	// only the misses are read from the backing store
+	got, err = getMany(cs, hashes)
+	require.NoError(t, err)
+	assert.Equal(t, hashes, got)
+	assert.Equal(t, 3, backing.Reads())
+
+	got, err = getMany(cs, hashes)
+	require.NoError(t, err)
+	assert.Equal(t, hashes, got)
+	assert.Equal(t, 3, backing.Reads())
+}
+
+func TestCachingChunkStoreCacheOnlyChunk(t *testing.T) {
+	ctx := context.Background()
+	cache := (&TestStorage{}).NewView()
+	backing := (&TestStorage{}).NewView()
+	cs := NewCachingChunkStore(cache, backing)
+
+	hashes := putChunks(t, cache, "abc")
+
+	for h := range hashes {
+		c, err := cs.Get(ctx, h)
+		require.NoError(t, err)
+		assert.Equal(t, h, c.Hash())
+
+		has, err := cs.Has(ctx, h)
+		require.NoError(t, err)
+		assert.True(t, has)
+	}
+
+	got, err := getMany(cs, hashes)
+	require.NoError(t, err)
+	assert.Equal(t, hashes, got)
+
+	absent, err := cs.HasMany(ctx, hashes)
+	require.NoError(t, err)
+	assert.Empty(t, absent)
+	assert.Equal(t, 0, backing.Reads())
+	assert.Equal(t, 0, backing.Hases())
+}
+
+func TestCachingChunkStoreCacheFailures(t *testing.T) {
+	ctx := context.Background()
+	schedule := NewFaultSchedule(0,
+		FailFrom(OpGet, 1),
+		FailFrom(OpGetMany, 1),
+		FailFrom(OpHasMany, 1),
+		FailFrom(OpPut, 1),
+		FailFrom(OpPutMany, 1),
+	)
+	cache := NewFaultChunkStore((&MemoryStorage{}).NewView(), schedule)
+	backing := (&TestStorage{}).NewView()
+	cs := NewCachingChunkStore(cache, backing)
+
+	hashes := putChunks(t, cs, "abc", "def")
+	require.NoError(t, cs.PutMany(ctx, []Chunk{NewChunk([]byte("ghi"))}))
+	hashes.Insert(NewChunk([]byte("ghi")).Hash())
+
+	for h := range hashes {
+		c, err := cs.Get(ctx, h)
+		require.NoError(t, err)
+		assert.Equal(t, h, c.Hash())
+	}
+
+	got, err := getMany(cs, hashes)
+	require.NoError(t, err)
+	assert.Equal(t, hashes, got)
+
+	absent, err := cs.HasMany(ctx, hashes)
+	require.NoError(t, err)
+	assert.Empty(t, absent)
+	assert.True(t, schedule.Injected(OpPut) > 0)
+	assert.True(t, schedule.Injected(OpGetMany) > 0)
+}
+
+func TestCachingChunkStoreRoot(t *testing.T) {
+	ctx := context.Background()
+	cacheStorage := &MemoryStorage{}
+	backing := (&TestStorage{}).NewView()
+	cs := NewCachingChunkStore(cacheStorage.NewView(), backing)
+
+	hashes := putChunks(t, cs, "abc")
+	root, err := cs.Root(ctx)
+	require.NoError(t, err)
+
+	for h := range hashes {
+		ok, err := cs.Commit(ctx, h, root)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		root, err = backing.Root(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, h, root)
+	}
+
+	// the root of the cache is left alone
+	assert.Equal(t, hash.Hash{}, storageRoot(t, cacheStorage))
+}
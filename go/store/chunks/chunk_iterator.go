@@ -0,0 +1,74 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrIterationUnsupported is returned by the helpers built on ChunkStoreIterator for a store which doesn't implement it.
+var ErrIterationUnsupported = errors.New("the chunk store can't iterate over its chunks")
+
+// ChunkStoreIterator is implemented by the ChunkStores which can enumerate all of their chunks, for tooling such as
+// garbage collection, export and integrity checks.
+type ChunkStoreIterator interface {
+	// IterateAllChunks calls |cb| once for each chunk of the store, pending or persisted, in no particular order. The
+	// store isn't locked while |cb| runs, so it can use the store, and chunks added during the iteration may or may not
+	// be visited. The first error returned by |cb| stops the iteration and is returned.
+	IterateAllChunks(ctx context.Context, cb func(Chunk) error) error
+}
+
+// IterateAllChunks calls |cb| with every chunk of |cs|, or returns ErrIterationUnsupported if |cs| isn't a
+// ChunkStoreIterator.
+func IterateAllChunks(ctx context.Context, cs ChunkStore, cb func(Chunk) error) error {
+	iter, ok := cs.(ChunkStoreIterator)
+
+	if !ok {
+		return ErrIterationUnsupported
+	}
+
+	return iter.IterateAllChunks(ctx, cb)
+}
+
+// CountChunks returns the number of chunks in |cs|.
+func CountChunks(ctx context.Context, cs ChunkStore) (int, error) {
+	count := 0
+	err := IterateAllChunks(ctx, cs, func(Chunk) error {
+		count++
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// TotalChunkBytes returns the size in bytes of the data of all of the chunks in |cs|.
+func TotalChunkBytes(ctx context.Context, cs ChunkStore) (uint64, error) {
+	var total uint64
+	err := IterateAllChunks(ctx, cs, func(c Chunk) error {
+		total += uint64(len(c.Data()))
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// iteratorStore returns a view with 3 persisted chunks and 3 pending ones, one of which is also persisted.
+func iteratorStore(t *testing.T) (ChunkStore, []Chunk) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+	chunks := []Chunk{
+		NewChunk([]byte("a")),
+		NewChunk([]byte("bb")),
+		NewChunk([]byte("ccc")),
+		NewChunk([]byte("dddd")),
+		NewChunk([]byte("eeeee")),
+	}
+
+	persisted := storage.NewView()
+	require.NoError(t, persisted.PutMany(ctx, chunks[:3]))
+	ok, err := persisted.Commit(ctx, chunks[0].Hash(), hash.Hash{})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	view := storage.NewView()
+	require.NoError(t, view.PutMany(ctx, []Chunk{chunks[2], chunks[3], chunks[4]}))
+
+	return view, chunks
+}
+
+func TestMemoryStoreViewIterateAllChunks(t *testing.T) {
+	ctx := context.Background()
+	view, chunks := iteratorStore(t)
+
+	visited := map[hash.Hash]int{}
+	err := IterateAllChunks(ctx, view, func(c Chunk) error {
+		visited[c.Hash()]++
+
+		// the store can be used from the callback
+		return view.Put(ctx, NewChunk(append([]byte("new "), c.Data()...)))
+	})
+	require.NoError(t, err)
+
+	require.Len(t, visited, len(chunks))
+	for _, c := range chunks {
+		assert.Equal(t, 1, visited[c.Hash()], "chunk %s", c.Data())
+	}
+
+	count, err := CountChunks(ctx, view)
+	require.NoError(t, err)
+	assert.Equal(t, 2*len(chunks), count)
+}
+
+func TestMemoryStoreViewIterateAllChunksError(t *testing.T) {
+	ctx := context.Background()
+	view, _ := iteratorStore(t)
+	stop := errors.New("stop")
+
+	calls := 0
+	err := IterateAllChunks(ctx, view, func(Chunk) error {
+		calls++
+		if calls == 2 {
+			return stop
+		}
+		return nil
+	})
+	assert.True(t, errors.Is(err, stop))
+	assert.Equal(t, 2, calls)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = IterateAllChunks(canceled, view, func(Chunk) error {
+		t.Fatal("called after the context was canceled")
+		return nil
+	})
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestCountChunks(t *testing.T) {
+	ctx := context.Background()
+	view, chunks := iteratorStore(t)
+
+	count, err := CountChunks(ctx, view)
+	require.NoError(t, err)
+	assert.Equal(t, len(chunks), count)
+
+	total, err := TotalChunkBytes(ctx, view)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1+2+3+4+5), total)
+
+	empty := (&MemoryStorage{}).NewView()
+	count, err = CountChunks(ctx, empty)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	_, err = CountChunks(ctx, (&TestStorage{}).NewView())
+	assert.True(t, errors.Is(err, ErrIterationUnsupported))
+	_, err = TotalChunkBytes(ctx, (&TestStorage{}).NewView())
+	assert.True(t, errors.Is(err, ErrIterationUnsupported))
+}
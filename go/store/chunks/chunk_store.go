@@ -23,16 +23,37 @@ package chunks
 
 import (
 	"context"
+	"errors"
 	"io"
 
 	"github.com/liquidata-inc/dolt/go/store/hash"
 )
 
+// ErrChunkNotFound is returned by ChunkStore.Get, along with EmptyChunk, by stores which tell a chunk which is absent
+// apart from a chunk which is present but has no data.
+var ErrChunkNotFound = errors.New("chunk not found")
+
+// isFound reports whether |c|, returned with |err| by a Get of |h|, was found. Stores which don't return
+// ErrChunkNotFound return EmptyChunk for an absent chunk, so a chunk with no data is only found if it's the chunk which
+// was asked for.
+func isFound(h hash.Hash, c Chunk, err error) (bool, error) {
+	if errors.Is(err, ErrChunkNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return !c.IsEmpty() || h == EmptyChunk.Hash(), nil
+}
+
 // ChunkStore is the core storage abstraction in noms. We can put data
 // anyplace we have a ChunkStore implementation for.
 type ChunkStore interface {
 	// Get the Chunk for the value of the hash in the store. If the hash is
-	// absent from the store EmptyChunk is returned.
+	// absent from the store EmptyChunk is returned, and stores which can
+	// hold chunks with no data also return ErrChunkNotFound. Callers should
+	// check for ErrChunkNotFound with errors.Is rather than relying on
+	// Chunk.IsEmpty() alone.
 	Get(ctx context.Context, h hash.Hash) (Chunk, error)
 
 	// GetMany gets the Chunks with |hashes| from the store. On return,
@@ -40,6 +61,19 @@ type ChunkStore interface {
 	// found. Any non-present chunks will silently be ignored.
 	GetMany(ctx context.Context, hashes hash.HashSet, foundChunks chan<- *Chunk) error
 
+	// GetManyF gets the Chunks with |hashes| from the store, calling |found|
+	// with each one that's found. |found| may be called concurrently from
+	// multiple goroutines, but is never called after GetManyF returns. Any
+	// non-present chunks will silently be ignored.
+	GetManyF(ctx context.Context, hashes hash.HashSet, found func(*Chunk)) error
+
+	// GetManyCompressed gets the Chunks with |hashes| from the store as
+	// CompressedChunks, sending each one that's found to |foundCmpChunks|,
+	// like GetMany. Stores which hold their chunks compressed send them as
+	// they're stored, so that they can be passed along without being
+	// decoded; others compress them on demand.
+	GetManyCompressed(ctx context.Context, hashes hash.HashSet, foundCmpChunks chan<- CompressedChunk) error
+
 	// Returns true iff the value at the address |h| is contained in the
 	// store
 	Has(ctx context.Context, h hash.Hash) (bool, error)
@@ -54,6 +88,12 @@ type ChunkStore interface {
 	// Get(), GetMany(), Has() and HasMany().
 	Put(ctx context.Context, c Chunk) error
 
+	// PutMany caches all of |chunks| like Put, giving the store the chance to
+	// add them in a single batch. Chunks with the same hash are only added
+	// once. PutMany may be called concurrently with the other methods of the
+	// store.
+	PutMany(ctx context.Context, chunks []Chunk) error
+
 	// Returns the NomsVersion with which this ChunkSource is compatible.
 	Version() string
 
@@ -23,6 +23,8 @@ package chunks
 
 import (
 	"context"
+	"errors"
+	"sync"
 
 	"github.com/stretchr/testify/suite"
 
@@ -32,7 +34,7 @@ import (
 
 type ChunkStoreTestSuite struct {
 	suite.Suite
-	Factory *memoryStoreFactory
+	Factory *MemoryStoreFactory
 }
 
 func (suite *ChunkStoreTestSuite) TestChunkStorePut() {
@@ -47,6 +49,39 @@ func (suite *ChunkStoreTestSuite) TestChunkStorePut() {
 	assertInputInStore(input, h, store, suite.Assert())
 }
 
+func (suite *ChunkStoreTestSuite) TestChunkStoreCanceledContext() {
+	store := suite.Factory.CreateStore(context.Background(), "ns")
+	c := NewChunk([]byte("abc"))
+	err := store.Put(context.Background(), c)
+	suite.NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = store.Put(ctx, NewChunk([]byte("def")))
+	suite.Equal(context.Canceled, err)
+	_, err = store.Get(ctx, c.Hash())
+	suite.Equal(context.Canceled, err)
+	_, err = store.Has(ctx, c.Hash())
+	suite.Equal(context.Canceled, err)
+	_, err = store.HasMany(ctx, hash.NewHashSet(c.Hash()))
+	suite.Equal(context.Canceled, err)
+	err = store.Rebase(ctx)
+	suite.Equal(context.Canceled, err)
+	_, err = store.Root(ctx)
+	suite.Equal(context.Canceled, err)
+	_, err = store.Commit(ctx, c.Hash(), hash.Hash{})
+	suite.Equal(context.Canceled, err)
+
+	// nothing done with the canceled context should have been persisted
+	root, err := store.Root(context.Background())
+	suite.NoError(err)
+	suite.True(root.IsEmpty())
+	has, err := store.Has(context.Background(), NewChunk([]byte("def")).Hash())
+	suite.NoError(err)
+	suite.False(has)
+}
+
 func (suite *ChunkStoreTestSuite) TestChunkStoreRoot() {
 	store := suite.Factory.CreateStore(context.Background(), "ns")
 	oldRoot, err := store.Root(context.Background())
@@ -93,10 +128,125 @@ func (suite *ChunkStoreTestSuite) TestChunkStoreGetNonExisting() {
 	store := suite.Factory.CreateStore(context.Background(), "ns")
 	h := hash.Parse("11111111111111111111111111111111")
 	c, err := store.Get(context.Background(), h)
+	suite.True(errors.Is(err, ErrChunkNotFound))
+	suite.True(c.IsEmpty())
+}
+
+func (suite *ChunkStoreTestSuite) TestChunkStoreGetEmptyChunk() {
+	ctx := context.Background()
+	store := suite.Factory.CreateStore(ctx, "ns")
+	err := store.Put(ctx, EmptyChunk)
+	suite.NoError(err)
+
+	// a chunk with no data which is present is told apart from a chunk which is absent
+	c, err := store.Get(ctx, EmptyChunk.Hash())
+	suite.NoError(err)
+	suite.True(c.IsEmpty())
+	suite.Equal(EmptyChunk.Hash(), c.Hash())
+
+	ok, err := store.Has(ctx, EmptyChunk.Hash())
+	suite.NoError(err)
+	suite.True(ok)
+
+	var found []*Chunk
+	err = store.GetManyF(ctx, hash.NewHashSet(EmptyChunk.Hash(), hash.Of([]byte("absent"))), func(c *Chunk) {
+		found = append(found, c)
+	})
+	suite.NoError(err)
+	suite.Require().Len(found, 1)
+	suite.Equal(EmptyChunk.Hash(), found[0].Hash())
+
+	rt, err := store.Root(ctx)
+	suite.NoError(err)
+	_, err = store.Commit(ctx, rt, rt)
+	suite.NoError(err)
+
+	// and once it's persisted
+	store = suite.Factory.CreateStore(ctx, "ns")
+	c, err = store.Get(ctx, EmptyChunk.Hash())
 	suite.NoError(err)
 	suite.True(c.IsEmpty())
 }
 
+func (suite *ChunkStoreTestSuite) TestChunkStoreGetMany() {
+	ctx := context.Background()
+	store := suite.Factory.CreateStore(ctx, "ns")
+	committed := NewChunk([]byte("abc"))
+	pending := NewChunk([]byte("def"))
+	absent := hash.Parse("11111111111111111111111111111111")
+
+	err := store.Put(ctx, committed)
+	suite.NoError(err)
+	r, err := store.Root(ctx)
+	suite.NoError(err)
+	_, err = store.Commit(ctx, committed.Hash(), r)
+	suite.NoError(err)
+	err = store.Put(ctx, pending)
+	suite.NoError(err)
+
+	hashes := hash.NewHashSet(committed.Hash(), pending.Hash(), absent)
+	expected := hash.NewHashSet(committed.Hash(), pending.Hash())
+
+	found := hash.HashSet{}
+	err = store.GetManyF(ctx, hashes, func(c *Chunk) {
+		found.Insert(c.Hash())
+	})
+	suite.NoError(err)
+	suite.Equal(expected, found)
+
+	foundChunks := make(chan *Chunk, len(hashes))
+	err = store.GetMany(ctx, hashes, foundChunks)
+	suite.NoError(err)
+	close(foundChunks)
+
+	found = hash.HashSet{}
+	for c := range foundChunks {
+		found.Insert(c.Hash())
+	}
+	suite.Equal(expected, found)
+}
+
+func (suite *ChunkStoreTestSuite) TestChunkStorePutMany() {
+	ctx := context.Background()
+	store := suite.Factory.CreateStore(ctx, "ns")
+	_, byHash := testChunks(100)
+
+	var batch []Chunk
+	for _, c := range byHash {
+		batch = append(batch, c, c)
+	}
+
+	// chunks are visible to Get and Has while the batch is put
+	wg := &sync.WaitGroup{}
+	for _, c := range batch[:10] {
+		wg.Add(1)
+		go func(h hash.Hash) {
+			defer wg.Done()
+			_, err := store.Has(ctx, h)
+			suite.NoError(err)
+			got, err := store.Get(ctx, h)
+			suite.True(err == nil || errors.Is(err, ErrChunkNotFound))
+			suite.True(got.IsEmpty() || got.Hash() == h)
+		}(c.Hash())
+	}
+
+	err := store.PutMany(ctx, batch)
+	suite.NoError(err)
+	wg.Wait()
+
+	for h, c := range byHash {
+		assertInputInStore(string(c.Data()), h, store, suite.Assert())
+	}
+
+	err = store.PutMany(ctx, nil)
+	suite.NoError(err)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = store.PutMany(canceled, []Chunk{NewChunk([]byte("canceled"))})
+	suite.Error(err)
+}
+
 func (suite *ChunkStoreTestSuite) TestChunkStoreVersion() {
 	store := suite.Factory.CreateStore(context.Background(), "ns")
 	oldRoot, err := store.Root(context.Background())
@@ -0,0 +1,74 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"errors"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// ErrCommitAttemptsExhausted is returned by CommitWithRetry when the root of the store moved before every attempt to
+// commit could land.
+var ErrCommitAttemptsExhausted = errors.New("the root of the chunk store changed on every attempt to commit")
+
+// ResolveFunc computes the root to commit on top of |upstreamRoot|, putting any chunks the new root needs into the
+// store first.
+type ResolveFunc func(upstreamRoot hash.Hash) (hash.Hash, error)
+
+// CommitWithRetry commits the root |resolve| computes on top of the current root of |cs|. If another writer moved
+// the root first, |cs| is rebased and |resolve| is called again with the new root, for up to |attempts| commits in
+// all. It returns the root which was committed.
+func CommitWithRetry(ctx context.Context, cs ChunkStore, attempts int, resolve ResolveFunc) (hash.Hash, error) {
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return hash.Hash{}, err
+		}
+
+		last, err := cs.Root(ctx)
+
+		if err != nil {
+			return hash.Hash{}, err
+		}
+
+		current, err := resolve(last)
+
+		if err != nil {
+			return hash.Hash{}, err
+		}
+
+		success, err := cs.Commit(ctx, current, last)
+
+		if err != nil {
+			return hash.Hash{}, err
+		} else if success {
+			return current, nil
+		}
+
+		if attempt >= attempts {
+			return hash.Hash{}, ErrCommitAttemptsExhausted
+		}
+
+		if err := ctx.Err(); err != nil {
+			return hash.Hash{}, err
+		}
+
+		// the root must be read again after the rebase, which is done at the top of the loop
+		if err := cs.Rebase(ctx); err != nil {
+			return hash.Hash{}, err
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// putChild puts a chunk which refers to |parent| into |cs|, returning its hash.
+func putChild(t *testing.T, cs ChunkStore, parent hash.Hash, name string) hash.Hash {
+	c := NewChunk([]byte(parent.String() + name))
+	require.NoError(t, cs.Put(context.Background(), c))
+	return c.Hash()
+}
+
+func TestCommitWithRetry(t *testing.T) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+	ours, theirs := storage.NewView(), storage.NewView()
+
+	var upstreams []hash.Hash
+	var theirRoot hash.Hash
+	root, err := CommitWithRetry(ctx, ours, 3, func(upstream hash.Hash) (hash.Hash, error) {
+		upstreams = append(upstreams, upstream)
+
+		// the other view commits first, the first time around
+		if len(upstreams) == 1 {
+			theirRoot = putChild(t, theirs, upstream, "theirs")
+			success, err := theirs.Commit(ctx, theirRoot, upstream)
+			require.NoError(t, err)
+			require.True(t, success)
+		}
+
+		return putChild(t, ours, upstream, "ours"), nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []hash.Hash{{}, theirRoot}, upstreams)
+	assert.Equal(t, putChild(t, ours, theirRoot, "ours"), root)
+	persisted, err := storage.Root(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, root, persisted)
+}
+
+func TestCommitWithRetryAttemptsExhausted(t *testing.T) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+	ours, theirs := storage.NewView(), storage.NewView()
+
+	calls := 0
+	_, err := CommitWithRetry(ctx, ours, 3, func(upstream hash.Hash) (hash.Hash, error) {
+		calls++
+		require.NoError(t, theirs.Rebase(ctx))
+		theirRoot, err := theirs.Root(ctx)
+		require.NoError(t, err)
+		success, err := theirs.Commit(ctx, putChild(t, theirs, theirRoot, fmt.Sprint(calls)), theirRoot)
+		require.NoError(t, err)
+		require.True(t, success)
+
+		return putChild(t, ours, upstream, "ours"), nil
+	})
+	assert.Equal(t, ErrCommitAttemptsExhausted, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestCommitWithRetryCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	storage := &MemoryStorage{}
+	ours, theirs := storage.NewView(), storage.NewView()
+
+	calls := 0
+	_, err := CommitWithRetry(ctx, ours, 10, func(upstream hash.Hash) (hash.Hash, error) {
+		calls++
+		success, err := theirs.Commit(ctx, putChild(t, theirs, upstream, "theirs"), upstream)
+		require.NoError(t, err)
+		require.True(t, success)
+		cancel()
+
+		return putChild(t, ours, upstream, "ours"), nil
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestCommitWithRetryConcurrent(t *testing.T) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+
+	const writers = 8
+	var mu sync.Mutex
+	parents := map[hash.Hash]hash.Hash{}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			view := storage.NewView()
+			_, err := CommitWithRetry(ctx, view, 100, func(upstream hash.Hash) (hash.Hash, error) {
+				c := NewChunk([]byte(fmt.Sprintf("%s %d", upstream.String(), i)))
+				if err := view.Put(ctx, c); err != nil {
+					return hash.Hash{}, err
+				}
+
+				mu.Lock()
+				parents[c.Hash()] = upstream
+				mu.Unlock()
+				return c.Hash(), nil
+			})
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	// every writer's root was committed on top of the one before it
+	root, err := storage.Root(ctx)
+	require.NoError(t, err)
+	committed := 0
+	for ; !root.IsEmpty(); root = parents[root] {
+		committed++
+	}
+	assert.Equal(t, writers, committed)
+}
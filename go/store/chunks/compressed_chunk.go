@@ -0,0 +1,118 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/golang/snappy"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// ErrDictionaryCompressedChunk is returned by CompressedChunk.ToChunk for chunks compressed with a table file's zstd
+// dictionary, which can only be decoded by the table file they were read from.
+var ErrDictionaryCompressedChunk = errors.New("chunk is compressed with a table file dictionary")
+
+// ErrCorruptCompressedChunk is returned by CompressedChunk.ToChunk when the data of a compressed chunk can't be decoded,
+// or doesn't have the chunk's hash.
+var ErrCorruptCompressedChunk = errors.New("corrupt compressed chunk")
+
+// zstdMagic begins every zstd frame, and so every chunk compressed with a table file's dictionary. No snappy encoding
+// begins with these bytes.
+const zstdMagic = "\x28\xb5\x2f\xfd"
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// CompressedChunk is a chunk whose data is snappy encoded, as it's stored in table files and sent to and from remotes,
+// so that it can be passed along without being decoded and encoded again.
+type CompressedChunk struct {
+	// H is the hash of the chunk
+	H hash.Hash
+
+	// FullCompressedChunk is the entirety of the compressed chunk data including the crc
+	FullCompressedChunk []byte
+
+	// CompressedData is just the snappy encoded byte buffer that stores the chunk data
+	CompressedData []byte
+}
+
+// NewCompressedChunk snappy encodes the data of |c|.
+func NewCompressedChunk(c Chunk) CompressedChunk {
+	compressed := snappy.Encode(nil, c.Data())
+	length := len(compressed)
+	compressed = append(compressed, []byte{0, 0, 0, 0}...)
+	binary.BigEndian.PutUint32(compressed[length:], crc32.Update(0, crcTable, compressed[:length]))
+	return CompressedChunk{H: c.Hash(), FullCompressedChunk: compressed, CompressedData: compressed[:length]}
+}
+
+// ToChunk snappy decodes the compressed data and returns a Chunk, after checking that the decoded data has the chunk's
+// hash. It returns an error wrapping ErrCorruptCompressedChunk if it doesn't. Chunks compressed with a table file's
+// dictionary can't be decoded by ToChunk, and return ErrDictionaryCompressedChunk.
+func (cmp CompressedChunk) ToChunk() (Chunk, error) {
+	if len(cmp.CompressedData) >= len(zstdMagic) && string(cmp.CompressedData[:len(zstdMagic)]) == zstdMagic {
+		return Chunk{}, ErrDictionaryCompressedChunk
+	}
+
+	data, err := snappy.Decode(nil, cmp.CompressedData)
+
+	if err != nil {
+		return Chunk{}, fmt.Errorf("%w: %s: %s", ErrCorruptCompressedChunk, cmp.H.String(), err.Error())
+	}
+
+	if h := hash.Of(data); h != cmp.H {
+		return Chunk{}, fmt.Errorf("%w: %s decodes to data with the hash %s", ErrCorruptCompressedChunk, cmp.H.String(), h.String())
+	}
+
+	return NewChunkWithHash(cmp.H, data), nil
+}
+
+// Hash returns the hash of the data
+func (cmp CompressedChunk) Hash() hash.Hash {
+	return cmp.H
+}
+
+// IsEmpty returns true if the chunk contains no data.
+func (cmp CompressedChunk) IsEmpty() bool {
+	return len(cmp.CompressedData) == 0 || (len(cmp.CompressedData) == 1 && cmp.CompressedData[0] == 0)
+}
+
+// GetManyCompressedFromF implements GetManyCompressed with |getManyF|, for stores which don't hold their chunks
+// compressed. Each chunk found is compressed and sent to |foundCmpChunks|. If |ctx| is canceled while a chunk is
+// being sent, no more chunks are sent and the error of |ctx| is returned.
+func GetManyCompressedFromF(ctx context.Context, hashes hash.HashSet, foundCmpChunks chan<- CompressedChunk, getManyF GetManyFFunc) error {
+	var canceled error
+	err := getManyF(ctx, hashes, func(c *Chunk) {
+		if canceled != nil {
+			return
+		}
+
+		select {
+		case foundCmpChunks <- NewCompressedChunk(*c):
+		case <-ctx.Done():
+			canceled = ctx.Err()
+		}
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return canceled
+}
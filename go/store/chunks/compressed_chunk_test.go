@@ -0,0 +1,89 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+func TestCompressedChunkRoundTrip(t *testing.T) {
+	for _, data := range [][]byte{{}, []byte("abc"), make([]byte, 64*1024)} {
+		c := NewChunk(data)
+		cmp := NewCompressedChunk(c)
+		assert.Equal(t, c.Hash(), cmp.Hash())
+		assert.Equal(t, len(data) == 0, cmp.IsEmpty())
+
+		decoded, err := cmp.ToChunk()
+		require.NoError(t, err)
+		assert.Equal(t, c.Hash(), decoded.Hash())
+		assert.Equal(t, len(c.Data()), len(decoded.Data()))
+		assert.Equal(t, string(c.Data()), string(decoded.Data()))
+	}
+}
+
+func TestCompressedChunkCorrupt(t *testing.T) {
+	c := NewChunk([]byte("the quick brown fox"))
+
+	// data which isn't the chunk's is caught by the hash check
+	cmp := NewCompressedChunk(NewChunk([]byte("jumps over the lazy dog")))
+	cmp.H = c.Hash()
+	_, err := cmp.ToChunk()
+	assert.True(t, errors.Is(err, ErrCorruptCompressedChunk), "unexpected error %v", err)
+
+	// data which isn't snappy encoded can't be decoded
+	cmp = CompressedChunk{H: c.Hash(), CompressedData: []byte{0xff, 0xff, 0xff, 0xff, 0xff}}
+	_, err = cmp.ToChunk()
+	assert.True(t, errors.Is(err, ErrCorruptCompressedChunk), "unexpected error %v", err)
+
+	cmp = CompressedChunk{H: c.Hash(), CompressedData: []byte(zstdMagic + "frame")}
+	_, err = cmp.ToChunk()
+	assert.Equal(t, ErrDictionaryCompressedChunk, err)
+}
+
+func TestMemoryStoreViewGetManyCompressed(t *testing.T) {
+	ctx := context.Background()
+	hashes, chunks := testChunks(100)
+	absent := hash.Parse("11111111111111111111111111111111")
+
+	cs := (&MemoryStorage{}).NewView()
+	for _, c := range chunks {
+		require.NoError(t, cs.Put(ctx, c))
+	}
+
+	requested := hash.NewHashSet(absent)
+	for h := range hashes {
+		requested.Insert(h)
+	}
+
+	found := make(chan CompressedChunk, len(requested))
+	require.NoError(t, cs.GetManyCompressed(ctx, requested, found))
+	close(found)
+
+	foundHashes := hash.HashSet{}
+	for cmp := range found {
+		c, err := cmp.ToChunk()
+		require.NoError(t, err)
+		assert.Equal(t, chunks[cmp.Hash()].Data(), c.Data())
+		foundHashes.Insert(cmp.Hash())
+	}
+	assert.Equal(t, hashes, foundHashes)
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// copyChunksBatchSize is the number of chunks CopyChunks holds in memory at a time.
+const copyChunksBatchSize = 256
+
+// ErrMissingChunk is returned by CopyChunks when a chunk to be copied is absent from the source store.
+type ErrMissingChunk struct {
+	Hash hash.Hash
+}
+
+func (e *ErrMissingChunk) Error() string {
+	return fmt.Sprintf("chunk %s is missing from the source store", e.Hash.String())
+}
+
+// CopyProgressFunc is called by CopyChunks after each batch of chunks is copied, with the number of chunks copied so
+// far, the number of chunks being copied, and the size of the data copied so far.
+type CopyProgressFunc func(copied, total int, bytes uint64)
+
+// CopyChunks copies the chunks of |hashes| from |src| to |dst|. Chunks which |dst| already has aren't read from |src|,
+// and the rest are copied in batches, so that only a batch of chunks is held in memory at a time. |progress| may be
+// nil. The chunks are only put to |dst|, and the caller is responsible for committing them. If |src| doesn't have one
+// of the chunks an *ErrMissingChunk is returned, naming the first missing chunk in hash order.
+func CopyChunks(ctx context.Context, src, dst ChunkStore, hashes hash.HashSet, progress CopyProgressFunc) error {
+	absent, err := dst.HasMany(ctx, hashes)
+
+	if err != nil {
+		return err
+	}
+
+	// copying the chunks in hash order makes which of several missing chunks is reported deterministic
+	toCopy := make(hash.HashSlice, 0, len(absent))
+	for h := range absent {
+		toCopy = append(toCopy, h)
+	}
+	sort.Sort(toCopy)
+
+	var copied int
+	var bytes uint64
+	for start := 0; start < len(toCopy); start += copyChunksBatchSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		end := start + copyChunksBatchSize
+		if end > len(toCopy) {
+			end = len(toCopy)
+		}
+
+		batch := toCopy[start:end]
+		chunks, err := getBatch(ctx, src, batch)
+
+		if err != nil {
+			return err
+		}
+
+		if err := dst.PutMany(ctx, chunks); err != nil {
+			return err
+		}
+
+		copied += len(chunks)
+		for _, c := range chunks {
+			bytes += uint64(len(c.Data()))
+		}
+
+		if progress != nil {
+			progress(copied, len(toCopy), bytes)
+		}
+	}
+
+	return nil
+}
+
+// getBatch reads the chunks of |batch| from |src|, returning them in the order of |batch|.
+func getBatch(ctx context.Context, src ChunkStore, batch hash.HashSlice) ([]Chunk, error) {
+	var mu sync.Mutex
+	found := make(map[hash.Hash]Chunk, len(batch))
+	err := src.GetManyF(ctx, batch.HashSet(), func(c *Chunk) {
+		mu.Lock()
+		defer mu.Unlock()
+		found[c.Hash()] = *c
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make([]Chunk, len(batch))
+	for i, h := range batch {
+		c, ok := found[h]
+
+		if !ok {
+			return nil, &ErrMissingChunk{Hash: h}
+		}
+
+		chunks[i] = c
+	}
+
+	return chunks, nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// copyTestStores returns a view of a store holding every chunk of |chunks|, and a view of a store which already has
+// every |shared|th one of them.
+func copyTestStores(t *testing.T, chunks map[hash.Hash]Chunk, shared int) (src, dst *MemoryStoreView) {
+	ctx := context.Background()
+	src = (&MemoryStorage{}).NewView().(*MemoryStoreView)
+	dst = (&MemoryStorage{}).NewView().(*MemoryStoreView)
+
+	i := 0
+	for _, c := range chunks {
+		require.NoError(t, src.Put(ctx, c))
+
+		if i%shared == 0 {
+			require.NoError(t, dst.Put(ctx, c))
+		}
+
+		i++
+	}
+
+	return src, dst
+}
+
+func TestCopyChunks(t *testing.T) {
+	ctx := context.Background()
+	hashes, chunks := testChunks(1000)
+	src, dst := copyTestStores(t, chunks, 4)
+	rootBefore, err := dst.Root(ctx)
+	require.NoError(t, err)
+
+	toCopy, err := dst.HasMany(ctx, hashes)
+	require.NoError(t, err)
+	srcStats := src.Stats().(MemoryStoreStats)
+	dstStats := dst.Stats().(MemoryStoreStats)
+
+	var calls, lastCopied, lastTotal int
+	var lastBytes uint64
+	err = CopyChunks(ctx, src, dst, hashes, func(copied, total int, bytes uint64) {
+		assert.True(t, copied > lastCopied)
+		assert.True(t, bytes > lastBytes)
+		calls++
+		lastCopied, lastTotal, lastBytes = copied, total, bytes
+	})
+	require.NoError(t, err)
+
+	// the chunks dst already had are skipped, and the rest are copied a batch at a time
+	assert.Equal(t, len(toCopy), lastTotal)
+	assert.Equal(t, len(toCopy), lastCopied)
+	assert.Equal(t, uint64(2*len(toCopy)), lastBytes)
+	assert.Equal(t, (len(toCopy)+copyChunksBatchSize-1)/copyChunksBatchSize, calls)
+
+	absent, err := dst.HasMany(ctx, hashes)
+	require.NoError(t, err)
+	assert.Empty(t, absent)
+
+	for h := range hashes {
+		c, err := dst.Get(ctx, h)
+		require.NoError(t, err)
+		assert.Equal(t, chunks[h].Data(), c.Data())
+	}
+
+	// only the chunks dst lacked were read from src
+	srcRead := src.Stats().(MemoryStoreStats)
+	assert.Equal(t, uint64(len(toCopy)), srcRead.ChunksRead-srcStats.ChunksRead)
+	assert.Equal(t, uint64(0), srcRead.Gets-srcStats.Gets)
+	assert.Equal(t, uint64(calls), srcRead.GetManys-srcStats.GetManys)
+
+	dstWritten := dst.Stats().(MemoryStoreStats)
+	assert.Equal(t, uint64(len(toCopy)), dstWritten.ChunksWritten-dstStats.ChunksWritten)
+
+	// the copied chunks are pending until the caller commits them
+	rootAfter, err := dst.Root(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, rootBefore, rootAfter)
+	assert.Equal(t, uint64(len(hashes)), dstWritten.PendingChunks)
+}
+
+func TestCopyChunksNothingToCopy(t *testing.T) {
+	ctx := context.Background()
+	hashes, chunks := testChunks(100)
+	src, dst := copyTestStores(t, chunks, 1)
+	srcStats := src.Stats().(MemoryStoreStats)
+
+	called := false
+	err := CopyChunks(ctx, src, dst, hashes, func(copied, total int, bytes uint64) {
+		called = true
+	})
+	require.NoError(t, err)
+
+	assert.False(t, called)
+	assert.Equal(t, srcStats.ChunksRead, src.Stats().(MemoryStoreStats).ChunksRead)
+}
+
+func TestCopyChunksMissingChunk(t *testing.T) {
+	ctx := context.Background()
+	hashes, chunks := testChunks(100)
+	src, dst := copyTestStores(t, chunks, 2)
+
+	missing := hash.HashSlice{hash.Parse("22222222222222222222222222222222"), hash.Parse("11111111111111111111111111111111")}
+	for _, h := range missing {
+		hashes.Insert(h)
+	}
+
+	err := CopyChunks(ctx, src, dst, hashes, nil)
+	require.IsType(t, &ErrMissingChunk{}, err)
+	assert.Equal(t, missing[1], err.(*ErrMissingChunk).Hash)
+}
+
+func TestCopyChunksCanceled(t *testing.T) {
+	hashes, chunks := testChunks(1000)
+	src, dst := copyTestStores(t, chunks, 1000)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := CopyChunks(ctx, src, dst, hashes, func(copied, total int, bytes uint64) {
+		calls++
+		cancel()
+	})
+
+	// the copy stops after the batch during which the context was canceled
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 1, calls)
+
+	absent, err := dst.HasMany(context.Background(), hashes)
+	require.NoError(t, err)
+	assert.Equal(t, len(hashes)-1-copyChunksBatchSize, len(absent))
+}
@@ -82,6 +82,21 @@ func (csMW *CSMetricWrapper) GetMany(ctx context.Context, hashes hash.HashSet, f
 	return csMW.cs.GetMany(ctx, hashes, foundChunks)
 }
 
+// GetManyF gets the Chunks with |hashes| from the store, calling |found|
+// with each one that's found. Any non-present chunks will silently be
+// ignored.
+func (csMW *CSMetricWrapper) GetManyF(ctx context.Context, hashes hash.HashSet, found func(*Chunk)) error {
+	atomic.AddInt32(&csMW.TotalChunkGets, int32(len(hashes)))
+	return csMW.cs.GetManyF(ctx, hashes, found)
+}
+
+// GetManyCompressed gets the compressed Chunks with |hashes| from the store. On return, |foundCmpChunks| will have been
+// fully sent all chunks which have been found. Any non-present chunks will silently be ignored.
+func (csMW *CSMetricWrapper) GetManyCompressed(ctx context.Context, hashes hash.HashSet, foundCmpChunks chan<- CompressedChunk) error {
+	atomic.AddInt32(&csMW.TotalChunkGets, int32(len(hashes)))
+	return csMW.cs.GetManyCompressed(ctx, hashes, foundCmpChunks)
+}
+
 // Returns true iff the value at the address |h| is contained in the
 // store
 func (csMW *CSMetricWrapper) Has(ctx context.Context, h hash.Hash) (bool, error) {
@@ -105,6 +120,13 @@ func (csMW *CSMetricWrapper) Put(ctx context.Context, c Chunk) error {
 	return csMW.cs.Put(ctx, c)
 }
 
+// PutMany caches all of |chunks| like Put. It may be called concurrently with
+// the other methods of the store.
+func (csMW *CSMetricWrapper) PutMany(ctx context.Context, chunks []Chunk) error {
+	atomic.AddInt32(&csMW.TotalChunkPuts, int32(len(chunks)))
+	return csMW.cs.PutMany(ctx, chunks)
+}
+
 // Returns the NomsVersion with which this ChunkSource is compatible.
 func (csMW *CSMetricWrapper) Version() string {
 	return csMW.cs.Version()
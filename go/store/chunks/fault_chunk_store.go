@@ -0,0 +1,439 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// ErrInjectedFault is the error returned by the calls a FaultSchedule fails, unless their Fault has its own error.
+var ErrInjectedFault = errors.New("injected fault")
+
+// ErrInjectedStaleRoot is returned by FaultSchedule.Inject for the calls of a Fault with StaleRoot. A FaultChunkStore
+// doesn't return it, its Commit reports that the root moved instead.
+var ErrInjectedStaleRoot = errors.New("injected stale root")
+
+// Fault describes which calls of an Operation a FaultSchedule fails.
+type Fault struct {
+	// Op is the operation which fails.
+	Op Operation
+
+	// Call is the number of the first call of Op which fails, counting from 1. When it is 0 any call of Op can fail,
+	// with a chance of Probability.
+	Call int
+
+	// Count is the number of consecutive calls of Op which fail, starting with Call. 0 fails a single call, and a
+	// negative Count fails every call from Call on.
+	Count int
+
+	// Probability is the chance that a call of Op fails when Call is 0.
+	Probability float64
+
+	// Err is the error returned by the failed calls. ErrInjectedFault is returned when it is nil.
+	Err error
+
+	// Partial makes the failed calls do some or all of their work before returning their error. A partial GetMany
+	// gets half of its chunks, a partial PutMany puts the first half of its chunks, and any other partial call is
+	// made in full, like a Commit which succeeds but whose response is lost.
+	Partial bool
+
+	// StaleRoot makes the failed calls of Commit return false without an error, as if another writer had moved the
+	// root since it was read, whatever the actual root is. Err is ignored. It only applies to a Fault of OpCommit.
+	StaleRoot bool
+}
+
+// FailNth returns a Fault which fails the |n|th call of |op|.
+func FailNth(op Operation, n int) Fault {
+	return Fault{Op: op, Call: n}
+}
+
+// FailFrom returns a Fault which fails every call of |op| starting with the |n|th.
+func FailFrom(op Operation, n int) Fault {
+	return Fault{Op: op, Call: n, Count: -1}
+}
+
+// FailRandomly returns a Fault which fails each call of |op| with a chance of |probability|.
+func FailRandomly(op Operation, probability float64) Fault {
+	return Fault{Op: op, Probability: probability}
+}
+
+// RejectNthCommit returns a Fault which makes the |n|th call of Commit report that the root moved, without committing.
+func RejectNthCommit(n int) Fault {
+	return Fault{Op: OpCommit, Call: n, StaleRoot: true}
+}
+
+func (f Fault) fails(call int, rng *rand.Rand) bool {
+	if f.Call == 0 {
+		return rng.Float64() < f.Probability
+	}
+
+	if call < f.Call {
+		return false
+	}
+
+	count := f.Count
+
+	if count == 0 {
+		count = 1
+	}
+
+	return count < 0 || call < f.Call+count
+}
+
+// FaultSchedule decides which calls fail according to a list of Faults. Its random failures are chosen by a seeded
+// random number generator, so a schedule fails the same calls each time a test makes the same calls. It is shared by
+// the wrappers which inject faults into calls to a store, and is safe for concurrent use.
+type FaultSchedule struct {
+	mu       *sync.Mutex
+	rng      *rand.Rand
+	faults   []Fault
+	calls    map[Operation]int
+	injected map[Operation]int
+}
+
+// NewFaultSchedule returns a FaultSchedule which fails the calls described by |faults|, choosing its random failures
+// with a random number generator seeded with |seed|.
+func NewFaultSchedule(seed int64, faults ...Fault) *FaultSchedule {
+	fs := &FaultSchedule{mu: &sync.Mutex{}, rng: rand.New(rand.NewSource(seed))}
+	fs.SetFaults(faults...)
+
+	return fs
+}
+
+// SetFaults replaces the faults of the schedule with |faults|, and starts counting the calls of each Operation from 0
+// again.
+func (fs *FaultSchedule) SetFaults(faults ...Fault) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.faults = append([]Fault(nil), faults...)
+	fs.calls = make(map[Operation]int)
+	fs.injected = make(map[Operation]int)
+}
+
+// Calls returns the number of calls of |op| since the faults were set.
+func (fs *FaultSchedule) Calls(op Operation) int {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	return fs.calls[op]
+}
+
+// Injected returns the number of calls of |op| which have failed since the faults were set.
+func (fs *FaultSchedule) Injected(op Operation) int {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	return fs.injected[op]
+}
+
+// InjectedTotal returns the number of calls of all operations which have failed since the faults were set.
+func (fs *FaultSchedule) InjectedTotal() int {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	total := 0
+	for _, n := range fs.injected {
+		total += n
+	}
+
+	return total
+}
+
+// Inject counts a call of |op| and returns the error it should fail with, or nil if it shouldn't fail. The returned
+// bool is true when the call should be partially made before failing. ErrInjectedStaleRoot is returned for a Fault
+// with StaleRoot.
+func (fs *FaultSchedule) Inject(op Operation) (bool, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.calls[op]++
+	call := fs.calls[op]
+
+	for _, f := range fs.faults {
+		if f.Op != op || !f.fails(call, fs.rng) {
+			continue
+		}
+
+		fs.injected[op]++
+
+		if f.StaleRoot {
+			return f.Partial, ErrInjectedStaleRoot
+		}
+
+		if f.Err != nil {
+			return f.Partial, f.Err
+		}
+
+		return f.Partial, ErrInjectedFault
+	}
+
+	return false, nil
+}
+
+// FaultChunkStore is a ChunkStore implementation that wraps a ChunkStore, and fails its calls according to a
+// FaultSchedule. To delay the calls as well, wrap a LatencyChunkStore:
+//
+//	fcs := NewFaultChunkStore(NewLatencyChunkStore(cs, latencies), faults)
+type FaultChunkStore struct {
+	*FaultSchedule
+	cs ChunkStore
+}
+
+var _ ChunkStore = &FaultChunkStore{}
+
+// NewFaultChunkStore returns a FaultChunkStore which fails the calls made to |cs| according to |schedule|.
+func NewFaultChunkStore(cs ChunkStore, schedule *FaultSchedule) *FaultChunkStore {
+	return &FaultChunkStore{schedule, cs}
+}
+
+// call makes a call of |op| with |f| unless it should fail. A partial failure makes the call before returning the
+// injected error.
+func (fcs *FaultChunkStore) call(op Operation, f func() error) error {
+	partial, err := fcs.Inject(op)
+
+	if err == nil {
+		return f()
+	}
+
+	if partial {
+		if callErr := f(); callErr != nil {
+			return callErr
+		}
+	}
+
+	return err
+}
+
+// Get the Chunk for the value of the hash in the store. If the hash is
+// absent from the store EmptyChunk is returned, along with the error of the
+// underlying store, if any.
+func (fcs *FaultChunkStore) Get(ctx context.Context, h hash.Hash) (Chunk, error) {
+	c := EmptyChunk
+	err := fcs.call(OpGet, func() (err error) {
+		c, err = fcs.cs.Get(ctx, h)
+		return err
+	})
+
+	if err != nil {
+		return EmptyChunk, err
+	}
+
+	return c, nil
+}
+
+// GetMany gets the Chunks with |hashes| from the store. On return,
+// |foundChunks| will have been fully sent all chunks which have been
+// found. Any non-present chunks will silently be ignored.
+func (fcs *FaultChunkStore) GetMany(ctx context.Context, hashes hash.HashSet, foundChunks chan<- *Chunk) error {
+	partial, err := fcs.Inject(OpGetMany)
+
+	if err == nil {
+		return fcs.cs.GetMany(ctx, hashes, foundChunks)
+	}
+
+	if partial {
+		if getErr := fcs.cs.GetMany(ctx, HalfOf(hashes), foundChunks); getErr != nil {
+			return getErr
+		}
+	}
+
+	return err
+}
+
+// GetManyF gets the Chunks with |hashes| from the store, calling |found|
+// with each one that's found. Any non-present chunks will silently be
+// ignored. It fails like GetMany.
+func (fcs *FaultChunkStore) GetManyF(ctx context.Context, hashes hash.HashSet, found func(*Chunk)) error {
+	partial, err := fcs.Inject(OpGetMany)
+
+	if err == nil {
+		return fcs.cs.GetManyF(ctx, hashes, found)
+	}
+
+	if partial {
+		if getErr := fcs.cs.GetManyF(ctx, HalfOf(hashes), found); getErr != nil {
+			return getErr
+		}
+	}
+
+	return err
+}
+
+func (fcs *FaultChunkStore) GetManyCompressed(ctx context.Context, hashes hash.HashSet, foundCmpChunks chan<- CompressedChunk) error {
+	partial, err := fcs.Inject(OpGetMany)
+
+	if err == nil {
+		return fcs.cs.GetManyCompressed(ctx, hashes, foundCmpChunks)
+	}
+
+	if partial {
+		if getErr := fcs.cs.GetManyCompressed(ctx, HalfOf(hashes), foundCmpChunks); getErr != nil {
+			return getErr
+		}
+	}
+
+	return err
+}
+
+// HalfOf returns the half of |hashes| which a partial failure of a call taking them makes. The same half of a set is
+// returned every time.
+func HalfOf(hashes hash.HashSet) hash.HashSet {
+	sorted := make(hash.HashSlice, 0, len(hashes))
+	for h := range hashes {
+		sorted = append(sorted, h)
+	}
+
+	sort.Sort(sorted)
+
+	return sorted[:len(sorted)/2].HashSet()
+}
+
+// Returns true iff the value at the address |h| is contained in the
+// store
+func (fcs *FaultChunkStore) Has(ctx context.Context, h hash.Hash) (bool, error) {
+	var has bool
+	err := fcs.call(OpHas, func() (err error) {
+		has, err = fcs.cs.Has(ctx, h)
+		return err
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return has, nil
+}
+
+// Returns a new HashSet containing any members of |hashes| that are
+// absent from the store.
+func (fcs *FaultChunkStore) HasMany(ctx context.Context, hashes hash.HashSet) (hash.HashSet, error) {
+	var absent hash.HashSet
+	err := fcs.call(OpHasMany, func() (err error) {
+		absent, err = fcs.cs.HasMany(ctx, hashes)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return absent, nil
+}
+
+// Put caches c in the ChunkSource. Upon return, c must be visible to
+// subsequent Get and Has calls, but must not be persistent until a call
+// to Flush(). Put may be called concurrently with other calls to Put(),
+// Get(), GetMany(), Has() and HasMany().
+func (fcs *FaultChunkStore) Put(ctx context.Context, c Chunk) error {
+	return fcs.call(OpPut, func() error {
+		return fcs.cs.Put(ctx, c)
+	})
+}
+
+// PutMany caches all of |chunks| like Put. It may be called concurrently with
+// the other methods of the store.
+func (fcs *FaultChunkStore) PutMany(ctx context.Context, chunks []Chunk) error {
+	partial, err := fcs.Inject(OpPutMany)
+
+	if err == nil {
+		return fcs.cs.PutMany(ctx, chunks)
+	}
+
+	if partial {
+		if putErr := fcs.cs.PutMany(ctx, chunks[:len(chunks)/2]); putErr != nil {
+			return putErr
+		}
+	}
+
+	return err
+}
+
+// Returns the NomsVersion with which this ChunkSource is compatible.
+func (fcs *FaultChunkStore) Version() string {
+	return fcs.cs.Version()
+}
+
+// Rebase brings this ChunkStore into sync with the persistent storage's
+// current root.
+func (fcs *FaultChunkStore) Rebase(ctx context.Context) error {
+	return fcs.call(OpRebase, func() error {
+		return fcs.cs.Rebase(ctx)
+	})
+}
+
+// Root returns the root of the database as of the time the ChunkStore
+// was opened or the most recent call to Rebase.
+func (fcs *FaultChunkStore) Root(ctx context.Context) (hash.Hash, error) {
+	var root hash.Hash
+	err := fcs.call(OpRoot, func() (err error) {
+		root, err = fcs.cs.Root(ctx)
+		return err
+	})
+
+	if err != nil {
+		return hash.Hash{}, err
+	}
+
+	return root, nil
+}
+
+// Commit atomically attempts to persist all novel Chunks and update the
+// persisted root hash from last to current (or keeps it the same).
+// If last doesn't match the root in persistent storage, returns false.
+// A Commit failed by a Fault with StaleRoot returns false and no error.
+func (fcs *FaultChunkStore) Commit(ctx context.Context, current, last hash.Hash) (bool, error) {
+	var success bool
+	err := fcs.call(OpCommit, func() (err error) {
+		success, err = fcs.cs.Commit(ctx, current, last)
+		return err
+	})
+
+	if err == ErrInjectedStaleRoot {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return success, nil
+}
+
+// Stats may return some kind of struct that reports statistics about the
+// ChunkStore instance. The type is implementation-dependent, and impls
+// may return nil
+func (fcs *FaultChunkStore) Stats() interface{} {
+	return fcs.cs.Stats()
+}
+
+// StatsSummary may return a string containing summarized statistics for
+// this ChunkStore. It must return "Unsupported" if this operation is not
+// supported.
+func (fcs *FaultChunkStore) StatsSummary() string {
+	return fcs.cs.StatsSummary()
+}
+
+// Close tears down any resources in use by the implementation. After
+// Close(), the ChunkStore may not be used again. It is NOT SAFE to call
+// Close() concurrently with any other ChunkStore method; behavior is
+// undefined and probably crashy.
+func (fcs *FaultChunkStore) Close() error {
+	return fcs.cs.Close()
+}
@@ -0,0 +1,232 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+func putChunks(t *testing.T, cs ChunkStore, data ...string) hash.HashSet {
+	hashes := hash.NewHashSet()
+	for _, d := range data {
+		c := NewChunk([]byte(d))
+		require.NoError(t, cs.Put(context.Background(), c))
+		hashes.Insert(c.Hash())
+	}
+
+	return hashes
+}
+
+func getMany(cs ChunkStore, hashes hash.HashSet) (hash.HashSet, error) {
+	found := make(chan *Chunk, len(hashes))
+	err := cs.GetMany(context.Background(), hashes, found)
+	close(found)
+
+	got := hash.NewHashSet()
+	for c := range found {
+		got.Insert(c.Hash())
+	}
+
+	return got, err
+}
+
+func storageRoot(t *testing.T, storage *MemoryStorage) hash.Hash {
+	root, err := storage.Root(context.Background())
+	require.NoError(t, err)
+
+	return root
+}
+
+func TestFaultChunkStoreFailNth(t *testing.T) {
+	storage := &MemoryStorage{}
+	fcs := NewFaultChunkStore(storage.NewView(), NewFaultSchedule(0))
+	hashes := putChunks(t, fcs, "a", "b", "c", "d")
+
+	fcs.SetFaults(FailNth(OpGetMany, 2))
+
+	got, err := getMany(fcs, hashes)
+	require.NoError(t, err)
+	assert.Equal(t, hashes, got)
+
+	got, err = getMany(fcs, hashes)
+	assert.Equal(t, ErrInjectedFault, err)
+	assert.Empty(t, got)
+
+	got, err = getMany(fcs, hashes)
+	require.NoError(t, err)
+	assert.Equal(t, hashes, got)
+
+	assert.Equal(t, 3, fcs.Calls(OpGetMany))
+	assert.Equal(t, 1, fcs.Injected(OpGetMany))
+}
+
+func TestFaultChunkStoreFailCommitOnce(t *testing.T) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+	commitErr := errors.New("connection reset")
+	fcs := NewFaultChunkStore(storage.NewView(), NewFaultSchedule(0, Fault{Op: OpCommit, Call: 1, Err: commitErr}))
+
+	c := NewChunk([]byte("root"))
+	require.NoError(t, fcs.Put(ctx, c))
+
+	_, err := fcs.Commit(ctx, c.Hash(), hash.Hash{})
+	assert.Equal(t, commitErr, err)
+	assert.True(t, storageRoot(t, storage).IsEmpty())
+
+	success, err := fcs.Commit(ctx, c.Hash(), hash.Hash{})
+	require.NoError(t, err)
+	assert.True(t, success)
+	assert.Equal(t, c.Hash(), storageRoot(t, storage))
+}
+
+func TestFaultChunkStorePartialFailures(t *testing.T) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+	fcs := NewFaultChunkStore(storage.NewView(), NewFaultSchedule(0))
+	hashes := putChunks(t, fcs, "a", "b", "c", "d")
+
+	fcs.SetFaults(
+		Fault{Op: OpGetMany, Call: 1, Partial: true},
+		Fault{Op: OpCommit, Call: 1, Partial: true},
+	)
+
+	got, err := getMany(fcs, hashes)
+	assert.Equal(t, ErrInjectedFault, err)
+	assert.Equal(t, HalfOf(hashes), got)
+	assert.Len(t, got, 2)
+
+	c := NewChunk([]byte("root"))
+	require.NoError(t, fcs.Put(ctx, c))
+
+	// the commit is made, but its caller sees an error
+	_, err = fcs.Commit(ctx, c.Hash(), hash.Hash{})
+	assert.Equal(t, ErrInjectedFault, err)
+	assert.Equal(t, c.Hash(), storageRoot(t, storage))
+}
+
+func TestFaultChunkStorePartialPutMany(t *testing.T) {
+	ctx := context.Background()
+	fcs := NewFaultChunkStore((&MemoryStorage{}).NewView(), NewFaultSchedule(0, Fault{Op: OpPutMany, Call: 1, Partial: true}))
+	batch := []Chunk{NewChunk([]byte("a")), NewChunk([]byte("b")), NewChunk([]byte("c")), NewChunk([]byte("d"))}
+
+	// only the first half of the batch is put
+	err := fcs.PutMany(ctx, batch)
+	assert.Equal(t, ErrInjectedFault, err)
+	for i, c := range batch {
+		has, err := fcs.Has(ctx, c.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, i < len(batch)/2, has)
+	}
+
+	require.NoError(t, fcs.PutMany(ctx, batch))
+	for _, c := range batch {
+		has, err := fcs.Has(ctx, c.Hash())
+		require.NoError(t, err)
+		assert.True(t, has)
+	}
+	assert.Equal(t, 2, fcs.Calls(OpPutMany))
+	assert.Equal(t, 1, fcs.Injected(OpPutMany))
+}
+
+func TestFaultChunkStoreCounts(t *testing.T) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+	fcs := NewFaultChunkStore(storage.NewView(), NewFaultSchedule(0, Fault{Op: OpHas, Call: 2, Count: 2}, FailFrom(OpPut, 3)))
+
+	var hasErrs []bool
+	for i := 0; i < 5; i++ {
+		_, err := fcs.Has(ctx, hash.Of([]byte("a")))
+		hasErrs = append(hasErrs, err != nil)
+	}
+
+	assert.Equal(t, []bool{false, true, true, false, false}, hasErrs)
+
+	var putErrs []bool
+	for i := 0; i < 5; i++ {
+		err := fcs.Put(ctx, NewChunk([]byte{byte(i)}))
+		putErrs = append(putErrs, err != nil)
+	}
+
+	assert.Equal(t, []bool{false, false, true, true, true}, putErrs)
+}
+
+func TestFaultChunkStoreFailRandomlyIsSeeded(t *testing.T) {
+	ctx := context.Background()
+	failures := func(seed int64) []bool {
+		storage := &MemoryStorage{}
+		fcs := NewFaultChunkStore(storage.NewView(), NewFaultSchedule(seed, FailRandomly(OpGet, 0.5)))
+		require.NoError(t, fcs.Put(ctx, NewChunk([]byte("a"))))
+
+		var failed []bool
+		for i := 0; i < 64; i++ {
+			_, err := fcs.Get(ctx, hash.Of([]byte("a")))
+			failed = append(failed, err != nil)
+		}
+
+		return failed
+	}
+
+	assert.Equal(t, failures(1), failures(1))
+	assert.NotEqual(t, failures(1), failures(2))
+	assert.Contains(t, failures(1), true)
+	assert.Contains(t, failures(1), false)
+}
+
+func TestFaultChunkStoreRejectNthCommit(t *testing.T) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+	schedule := NewFaultSchedule(0, RejectNthCommit(1), FailNth(OpPut, 2))
+	fcs := NewFaultChunkStore(storage.NewView(), schedule)
+
+	c := NewChunk([]byte("root"))
+	require.NoError(t, fcs.Put(ctx, c))
+
+	// the root is reported to have moved, though the commit would have succeeded
+	success, err := fcs.Commit(ctx, c.Hash(), hash.Hash{})
+	require.NoError(t, err)
+	assert.False(t, success)
+	assert.True(t, storageRoot(t, storage).IsEmpty())
+
+	success, err = fcs.Commit(ctx, c.Hash(), hash.Hash{})
+	require.NoError(t, err)
+	assert.True(t, success)
+	assert.Equal(t, c.Hash(), storageRoot(t, storage))
+
+	assert.Equal(t, ErrInjectedFault, fcs.Put(ctx, NewChunk([]byte("other"))))
+	assert.Equal(t, 1, schedule.Injected(OpCommit))
+	assert.Equal(t, 2, schedule.InjectedTotal())
+}
+
+func TestFaultChunkStoreWithLatency(t *testing.T) {
+	ctx := context.Background()
+	latencies := NewLatencySchedule(map[Operation]LatencyDistribution{OpGet: FixedLatency(10 * time.Millisecond)})
+	fcs := NewFaultChunkStore(NewLatencyChunkStore((&MemoryStorage{}).NewView(), latencies), NewFaultSchedule(0, FailNth(OpGet, 1)))
+
+	start := time.Now()
+	_, err := fcs.Get(ctx, hash.Of([]byte("a")))
+	assert.Equal(t, ErrInjectedFault, err)
+
+	_, err = fcs.Get(ctx, hash.Of([]byte("a")))
+	assert.True(t, errors.Is(err, ErrChunkNotFound))
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+}
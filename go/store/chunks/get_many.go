@@ -0,0 +1,133 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/liquidata-inc/dolt/go/store/atomicerr"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// GetManyFunc is the signature of ChunkStore.GetMany.
+type GetManyFunc func(ctx context.Context, hashes hash.HashSet, foundChunks chan<- *Chunk) error
+
+// GetManyFFunc is the signature of ChunkStore.GetManyF.
+type GetManyFFunc func(ctx context.Context, hashes hash.HashSet, found func(*Chunk)) error
+
+// GetManyFromF implements GetMany with |getManyF|, sending each chunk it finds to |foundChunks|. If |ctx| is canceled
+// while a chunk is being sent, no more chunks are sent and the error of |ctx| is returned, so that the call doesn't
+// block forever when the reader of |foundChunks| has stopped reading. |getManyF| may call its callback from several
+// goroutines at once.
+func GetManyFromF(ctx context.Context, hashes hash.HashSet, foundChunks chan<- *Chunk, getManyF GetManyFFunc) error {
+	canceled := atomicerr.New()
+	err := getManyF(ctx, hashes, func(c *Chunk) {
+		if canceled.Get() != nil {
+			return
+		}
+
+		select {
+		case foundChunks <- c:
+		case <-ctx.Done():
+			canceled.SetIfError(ctx.Err())
+		}
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return canceled.Get()
+}
+
+// GetManyFFromChannel implements GetManyF with |getMany|, calling |found| for each chunk sent to the channel it's
+// given. It returns once every found chunk has been passed to |found|.
+func GetManyFFromChannel(ctx context.Context, hashes hash.HashSet, found func(*Chunk), getMany GetManyFunc) error {
+	foundChunks := make(chan *Chunk, 16)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		for c := range foundChunks {
+			found(c)
+		}
+	}()
+
+	err := getMany(ctx, hashes, foundChunks)
+	close(foundChunks)
+	<-done
+
+	return err
+}
+
+// GetManyConcurrently implements GetMany with the Get of |cs|, for stores whose Gets are expensive enough to be worth
+// making in parallel. Up to |concurrency| chunks are gotten at a time, and only the chunks which are found are sent to
+// |foundChunks|. The first error of a Get stops the others, and is returned once every goroutine getting chunks has
+// stopped, so nothing is sent to |foundChunks| after it returns.
+func GetManyConcurrently(ctx context.Context, cs ChunkStore, hashes hash.HashSet, foundChunks chan<- *Chunk, concurrency int) error {
+	if concurrency > len(hashes) {
+		concurrency = len(hashes)
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ae := atomicerr.New()
+	toGet := make(chan hash.Hash)
+	wg := &sync.WaitGroup{}
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for h := range toGet {
+				c, err := cs.Get(ctx, h)
+				ok, err := isFound(h, c, err)
+				if ae.SetIfError(err) {
+					cancel()
+					return
+				} else if !ok {
+					continue
+				}
+
+				select {
+				case foundChunks <- &c:
+				case <-ctx.Done():
+					ae.SetIfError(ctx.Err())
+					return
+				}
+			}
+		}()
+	}
+
+sendHashes:
+	for h := range hashes {
+		select {
+		case toGet <- h:
+		case <-ctx.Done():
+			ae.SetIfError(ctx.Err())
+			break sendHashes
+		}
+	}
+
+	close(toGet)
+	wg.Wait()
+
+	return ae.Get()
+}
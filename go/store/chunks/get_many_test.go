@@ -0,0 +1,321 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+func testChunks(n int) (hash.HashSet, map[hash.Hash]Chunk) {
+	hashes := hash.HashSet{}
+	chunks := make(map[hash.Hash]Chunk, n)
+	for i := 0; i < n; i++ {
+		c := NewChunk([]byte{byte(i), byte(i >> 8)})
+		hashes.Insert(c.Hash())
+		chunks[c.Hash()] = c
+	}
+
+	return hashes, chunks
+}
+
+func TestGetManyFFromChannel(t *testing.T) {
+	hashes, chunks := testChunks(1000)
+	testErr := errors.New("test error")
+
+	// sends each chunk from its own goroutine, and waits for them all to be sent
+	getMany := func(ctx context.Context, hashes hash.HashSet, foundChunks chan<- *Chunk) error {
+		wg := &sync.WaitGroup{}
+		for h := range hashes {
+			c := chunks[h]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				foundChunks <- &c
+			}()
+		}
+		wg.Wait()
+
+		return testErr
+	}
+
+	var mu sync.Mutex
+	found := hash.HashSet{}
+	err := GetManyFFromChannel(context.Background(), hashes, func(c *Chunk) {
+		mu.Lock()
+		defer mu.Unlock()
+		found.Insert(c.Hash())
+	}, getMany)
+
+	// every chunk is passed to |found| before the call returns
+	assert.Equal(t, testErr, err)
+	assert.Equal(t, hashes, found)
+}
+
+func TestGetManyFromF(t *testing.T) {
+	hashes, chunks := testChunks(100)
+	absent := hash.Parse("11111111111111111111111111111111")
+	hashes.Insert(absent)
+
+	getManyF := func(ctx context.Context, hashes hash.HashSet, found func(*Chunk)) error {
+		for h := range hashes {
+			if c, ok := chunks[h]; ok {
+				found(&c)
+			}
+		}
+
+		return nil
+	}
+
+	foundChunks := make(chan *Chunk, len(hashes))
+	err := GetManyFromF(context.Background(), hashes, foundChunks, getManyF)
+	assert.NoError(t, err)
+	close(foundChunks)
+
+	found := hash.HashSet{}
+	for c := range foundChunks {
+		assert.Equal(t, chunks[c.Hash()].Data(), c.Data())
+		found.Insert(c.Hash())
+	}
+
+	hashes.Remove(absent)
+	assert.Equal(t, hashes, found)
+}
+
+func TestGetManyFromFCancellation(t *testing.T) {
+	hashes, chunks := testChunks(100)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+	getManyF := func(ctx context.Context, hashes hash.HashSet, found func(*Chunk)) error {
+		for h := range hashes {
+			c := chunks[h]
+			found(&c)
+			calls++
+		}
+
+		return nil
+	}
+
+	// nothing reads the chunks, so the first send is only interrupted by the cancellation
+	foundChunks := make(chan *Chunk)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- GetManyFromF(ctx, hashes, foundChunks, getManyF)
+	}()
+
+	cancel()
+
+	select {
+	case err := <-errCh:
+		assert.Equal(t, context.Canceled, err)
+		assert.Equal(t, len(hashes), calls)
+	case <-time.After(10 * time.Second):
+		t.Fatal("GetManyFromF didn't return after its context was canceled")
+	}
+}
+
+func TestGetManyFromFConcurrentCallbacks(t *testing.T) {
+	hashes, chunks := testChunks(1000)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// calls |found| from a goroutine for each chunk, as stores which fan out their reads do
+	getManyF := func(ctx context.Context, hashes hash.HashSet, found func(*Chunk)) error {
+		wg := &sync.WaitGroup{}
+		for h := range hashes {
+			c := chunks[h]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				found(&c)
+			}()
+		}
+		wg.Wait()
+
+		return nil
+	}
+
+	// some of the chunks are read before the context is canceled, and the rest of the sends are interrupted by it
+	foundChunks := make(chan *Chunk)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- GetManyFromF(ctx, hashes, foundChunks, getManyF)
+	}()
+
+	for i := 0; i < 10; i++ {
+		<-foundChunks
+	}
+	cancel()
+
+	select {
+	case err := <-errCh:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("GetManyFromF didn't return after its context was canceled")
+	}
+}
+
+// slowGetStore counts the Gets in flight on the ChunkStore it wraps. If failAt is set, the Get it numbers fails, and
+// the Gets after it wait for their context to be canceled.
+type slowGetStore struct {
+	ChunkStore
+	failAt      int32
+	calls       int32
+	inFlight    int32
+	maxInFlight int32
+}
+
+var errSlowGet = errors.New("slow get error")
+
+func (s *slowGetStore) Get(ctx context.Context, h hash.Hash) (Chunk, error) {
+	call := atomic.AddInt32(&s.calls, 1)
+	n := atomic.AddInt32(&s.inFlight, 1)
+	defer atomic.AddInt32(&s.inFlight, -1)
+
+	for {
+		max := atomic.LoadInt32(&s.maxInFlight)
+		if n <= max || atomic.CompareAndSwapInt32(&s.maxInFlight, max, n) {
+			break
+		}
+	}
+
+	time.Sleep(time.Millisecond)
+
+	if s.failAt > 0 && call == s.failAt {
+		return EmptyChunk, errSlowGet
+	} else if s.failAt > 0 && call > s.failAt {
+		<-ctx.Done()
+		return EmptyChunk, ctx.Err()
+	}
+
+	return s.ChunkStore.Get(ctx, h)
+}
+
+func newSlowGetStore(t *testing.T, chunks map[hash.Hash]Chunk) *slowGetStore {
+	cs := (&MemoryStorage{}).NewView()
+	for _, c := range chunks {
+		assert.NoError(t, cs.Put(context.Background(), c))
+	}
+
+	return &slowGetStore{ChunkStore: cs}
+}
+
+func getManyConcurrently(cs ChunkStore, hashes hash.HashSet, concurrency int) (hash.HashSet, error) {
+	foundChunks := make(chan *Chunk, len(hashes))
+	err := GetManyConcurrently(context.Background(), cs, hashes, foundChunks, concurrency)
+
+	// a send after GetManyConcurrently returns would panic
+	close(foundChunks)
+
+	found := hash.HashSet{}
+	for c := range foundChunks {
+		found.Insert(c.Hash())
+	}
+
+	return found, err
+}
+
+func TestGetManyConcurrently(t *testing.T) {
+	hashes, chunks := testChunks(100)
+	cs := newSlowGetStore(t, chunks)
+
+	absent := hash.Parse("11111111111111111111111111111111")
+	requested := hash.HashSet{absent: struct{}{}}
+	for h := range hashes {
+		requested.Insert(h)
+	}
+
+	found, err := getManyConcurrently(cs, requested, 8)
+	assert.NoError(t, err)
+	assert.Equal(t, hashes, found)
+	assert.True(t, cs.maxInFlight <= 8, "%d gets were in flight", cs.maxInFlight)
+	assert.True(t, cs.maxInFlight > 1, "the gets weren't concurrent")
+}
+
+func TestGetManyConcurrentlyNoHashes(t *testing.T) {
+	_, chunks := testChunks(10)
+	cs := newSlowGetStore(t, chunks)
+
+	found, err := getManyConcurrently(cs, hash.HashSet{}, 8)
+	assert.NoError(t, err)
+	assert.Empty(t, found)
+	assert.Equal(t, int32(0), cs.maxInFlight)
+}
+
+func TestGetManyConcurrentlyMoreWorkersThanHashes(t *testing.T) {
+	hashes, chunks := testChunks(3)
+	cs := newSlowGetStore(t, chunks)
+
+	found, err := getManyConcurrently(cs, hashes, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, hashes, found)
+	assert.True(t, cs.maxInFlight <= 3, "%d gets were in flight", cs.maxInFlight)
+}
+
+func TestGetManyConcurrentlyError(t *testing.T) {
+	hashes, chunks := testChunks(100)
+	cs := newSlowGetStore(t, chunks)
+	cs.failAt = 10
+
+	// the gets after the failing one only return once the error cancels them
+	errCh := make(chan error, 1)
+	foundCh := make(chan hash.HashSet, 1)
+	go func() {
+		found, err := getManyConcurrently(cs, hashes, 4)
+		foundCh <- found
+		errCh <- err
+	}()
+
+	select {
+	case err := <-errCh:
+		assert.Equal(t, errSlowGet, err)
+		assert.True(t, len(<-foundCh) < 10, "chunks were found after the failing get")
+	case <-time.After(10 * time.Second):
+		t.Fatal("GetManyConcurrently didn't cancel its gets after one failed")
+	}
+}
+
+func TestGetManyConcurrentlyCancellation(t *testing.T) {
+	hashes, chunks := testChunks(100)
+	cs := newSlowGetStore(t, chunks)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// nothing reads the chunks, so the sends are only interrupted by the cancellation
+	foundChunks := make(chan *Chunk)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- GetManyConcurrently(ctx, cs, hashes, foundChunks, 4)
+	}()
+
+	cancel()
+
+	select {
+	case err := <-errCh:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("GetManyConcurrently didn't return after its context was canceled")
+	}
+}
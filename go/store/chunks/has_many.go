@@ -0,0 +1,84 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/liquidata-inc/dolt/go/store/atomicerr"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// HasManyConcurrently implements HasMany with up to |concurrency| concurrent calls of cs.Has, for stores whose Has is
+// bound by the latency of the network rather than by the store itself. It returns the members of |hashes| which are
+// absent from |cs|.
+func HasManyConcurrently(ctx context.Context, cs ChunkStore, hashes hash.HashSet, concurrency int) (hash.HashSet, error) {
+	if concurrency > len(hashes) {
+		concurrency = len(hashes)
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ae := atomicerr.New()
+	toCheck := make(chan hash.Hash)
+	wg := &sync.WaitGroup{}
+
+	var mu sync.Mutex
+	absent := hash.HashSet{}
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for h := range toCheck {
+				has, err := cs.Has(ctx, h)
+				if ae.SetIfError(err) {
+					cancel()
+					return
+				}
+
+				if !has {
+					mu.Lock()
+					absent.Insert(h)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+sendHashes:
+	for h := range hashes {
+		select {
+		case toCheck <- h:
+		case <-ctx.Done():
+			ae.SetIfError(ctx.Err())
+			break sendHashes
+		}
+	}
+
+	close(toCheck)
+	wg.Wait()
+
+	if err := ae.Get(); err != nil {
+		return nil, err
+	}
+
+	return absent, nil
+}
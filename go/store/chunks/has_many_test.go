@@ -0,0 +1,137 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// slowHasStore counts the Hases in flight on the ChunkStore it wraps. If failAt is set, the Has it numbers fails, and
+// the Hases after it wait for their context to be canceled.
+type slowHasStore struct {
+	ChunkStore
+	failAt      int32
+	calls       int32
+	inFlight    int32
+	maxInFlight int32
+}
+
+var errSlowHas = errors.New("slow has error")
+
+func (s *slowHasStore) Has(ctx context.Context, h hash.Hash) (bool, error) {
+	call := atomic.AddInt32(&s.calls, 1)
+	n := atomic.AddInt32(&s.inFlight, 1)
+	defer atomic.AddInt32(&s.inFlight, -1)
+
+	for {
+		max := atomic.LoadInt32(&s.maxInFlight)
+		if n <= max || atomic.CompareAndSwapInt32(&s.maxInFlight, max, n) {
+			break
+		}
+	}
+
+	time.Sleep(time.Millisecond)
+
+	if s.failAt > 0 && call == s.failAt {
+		return false, errSlowHas
+	} else if s.failAt > 0 && call > s.failAt {
+		<-ctx.Done()
+		return false, ctx.Err()
+	}
+
+	return s.ChunkStore.Has(ctx, h)
+}
+
+func newSlowHasStore(t *testing.T, chunks map[hash.Hash]Chunk) *slowHasStore {
+	cs := (&MemoryStorage{}).NewView()
+	for _, c := range chunks {
+		assert.NoError(t, cs.Put(context.Background(), c))
+	}
+
+	return &slowHasStore{ChunkStore: cs}
+}
+
+func TestHasManyConcurrently(t *testing.T) {
+	hashes, chunks := testChunks(100)
+	cs := newSlowHasStore(t, chunks)
+
+	absent := hash.NewHashSet(hash.Parse("11111111111111111111111111111111"), hash.Parse("22222222222222222222222222222222"))
+	requested := hash.HashSet{}
+	for h := range hashes {
+		requested.Insert(h)
+	}
+	for h := range absent {
+		requested.Insert(h)
+	}
+
+	found, err := HasManyConcurrently(context.Background(), cs, requested, 8)
+	assert.NoError(t, err)
+	assert.Equal(t, absent, found)
+	assert.True(t, cs.maxInFlight <= 8, "%d hases were in flight", cs.maxInFlight)
+	assert.True(t, cs.maxInFlight > 1, "the hases weren't concurrent")
+
+	// the absent set is the same as the one HasMany returns
+	expected, err := cs.HasMany(context.Background(), requested)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, found)
+}
+
+func TestHasManyConcurrentlyNoHashes(t *testing.T) {
+	_, chunks := testChunks(10)
+	cs := newSlowHasStore(t, chunks)
+
+	absent, err := HasManyConcurrently(context.Background(), cs, hash.HashSet{}, 8)
+	assert.NoError(t, err)
+	assert.Empty(t, absent)
+	assert.Equal(t, int32(0), cs.maxInFlight)
+}
+
+func TestHasManyConcurrentlyError(t *testing.T) {
+	hashes, chunks := testChunks(100)
+	cs := newSlowHasStore(t, chunks)
+	cs.failAt = 10
+
+	// the hases after the failing one only return once the error cancels them
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := HasManyConcurrently(context.Background(), cs, hashes, 4)
+		errCh <- err
+	}()
+
+	select {
+	case err := <-errCh:
+		assert.Equal(t, errSlowHas, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("HasManyConcurrently didn't cancel its hases after one failed")
+	}
+}
+
+func TestHasManyConcurrentlyCancellation(t *testing.T) {
+	hashes, chunks := testChunks(100)
+	cs := newSlowHasStore(t, chunks)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := HasManyConcurrently(ctx, cs, hashes, 4)
+	assert.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,102 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/liquidata-inc/dolt/go/store/constants"
+)
+
+// ErrIncompatibleFormat is returned when a ChunkStore holds data in a format version which can't be used, either
+// because it isn't supported by this binary, or because it differs from the format version of the ChunkStore the data
+// is being moved to or from.
+type ErrIncompatibleFormat struct {
+	// Found is the format version of the data which can't be used
+	Found string
+
+	// Supported lists the format versions which the data would need to have to be used
+	Supported []string
+
+	// CanMigrate is true if the data is in an older format version which can be migrated to a supported one
+	CanMigrate bool
+
+	// Remote is true if the data with the Found format version is stored in a remote
+	Remote bool
+}
+
+func (e *ErrIncompatibleFormat) Error() string {
+	location := "local"
+	if e.Remote {
+		location = "remote"
+	}
+
+	return fmt.Sprintf("incompatible %s storage format %s; supported storage formats: %s", location, e.Found, strings.Join(e.Supported, ", "))
+}
+
+// CheckFormatVersion returns an *ErrIncompatibleFormat if |vers| isn't one of the format versions supported by this
+// binary.
+func CheckFormatVersion(vers string) error {
+	if formatIndex(vers) != -1 {
+		return nil
+	}
+
+	return &ErrIncompatibleFormat{Found: vers, Supported: constants.SupportedFormatStrings}
+}
+
+// CheckFormatVersionsMatch returns an *ErrIncompatibleFormat if data in the format version |a| can't be moved to or
+// from a ChunkStore with the format version |b|. A format version which isn't supported by this binary is reported as
+// the one found, otherwise the older of the two is, as it's the data which can be migrated.
+func CheckFormatVersionsMatch(a, b string) error {
+	if a == b {
+		return nil
+	}
+
+	aIdx, bIdx := formatIndex(a), formatIndex(b)
+	if (bIdx == -1 && aIdx != -1) || (bIdx != -1 && aIdx != -1 && bIdx < aIdx) {
+		a, b = b, a
+		aIdx, bIdx = bIdx, aIdx
+	}
+
+	return &ErrIncompatibleFormat{Found: a, Supported: []string{b}, CanMigrate: aIdx != -1 && aIdx < bIdx}
+}
+
+// AsRemote returns a copy of the error for data which is stored in a remote.
+func (e *ErrIncompatibleFormat) AsRemote() *ErrIncompatibleFormat {
+	remoteErr := *e
+	remoteErr.Remote = true
+	return &remoteErr
+}
+
+// MarkRemoteFormat returns a copy of |err| with Remote set if it is an *ErrIncompatibleFormat whose Found format
+// version is |remoteVers|, the format version of a remote. Any other error is returned unchanged.
+func MarkRemoteFormat(err error, remoteVers string) error {
+	if ife, ok := err.(*ErrIncompatibleFormat); ok && ife.Found == remoteVers {
+		return ife.AsRemote()
+	}
+
+	return err
+}
+
+func formatIndex(vers string) int {
+	for i, s := range constants.SupportedFormatStrings {
+		if s == vers {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/constants"
+)
+
+func TestCheckFormatVersion(t *testing.T) {
+	for _, vers := range constants.SupportedFormatStrings {
+		assert.NoError(t, CheckFormatVersion(vers))
+	}
+
+	err := CheckFormatVersion("__LD_2__")
+	require.IsType(t, &ErrIncompatibleFormat{}, err)
+	ife := err.(*ErrIncompatibleFormat)
+	assert.Equal(t, "__LD_2__", ife.Found)
+	assert.Equal(t, constants.SupportedFormatStrings, ife.Supported)
+	assert.False(t, ife.CanMigrate)
+	assert.False(t, ife.Remote)
+}
+
+func TestCheckFormatVersionsMatch(t *testing.T) {
+	old, cur := constants.Format718String, constants.FormatLD1String
+
+	tests := []struct {
+		name       string
+		a, b       string
+		found      string
+		supported  string
+		canMigrate bool
+	}{
+		{"older first", old, cur, old, cur, true},
+		{"older second", cur, old, old, cur, true},
+		{"unsupported first", "__LD_2__", cur, "__LD_2__", cur, false},
+		{"unsupported second", cur, "__LD_2__", "__LD_2__", cur, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckFormatVersionsMatch(test.a, test.b)
+			require.IsType(t, &ErrIncompatibleFormat{}, err)
+			ife := err.(*ErrIncompatibleFormat)
+			assert.Equal(t, test.found, ife.Found)
+			assert.Equal(t, []string{test.supported}, ife.Supported)
+			assert.Equal(t, test.canMigrate, ife.CanMigrate)
+		})
+	}
+
+	assert.NoError(t, CheckFormatVersionsMatch(cur, cur))
+}
+
+func TestMarkRemoteFormat(t *testing.T) {
+	err := CheckFormatVersionsMatch(constants.Format718String, constants.FormatLD1String)
+
+	notRemote := MarkRemoteFormat(err, constants.FormatLD1String)
+	assert.False(t, notRemote.(*ErrIncompatibleFormat).Remote)
+
+	remote := MarkRemoteFormat(err, constants.Format718String)
+	assert.True(t, remote.(*ErrIncompatibleFormat).Remote)
+	assert.False(t, err.(*ErrIncompatibleFormat).Remote)
+
+	other := errors.New("other")
+	assert.Equal(t, other, MarkRemoteFormat(other, constants.Format718String))
+	assert.NoError(t, MarkRemoteFormat(nil, constants.Format718String))
+}
@@ -0,0 +1,329 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/metrics"
+)
+
+// instrumentedOps are the operations an InstrumentedChunkStore keeps metrics for, in the order they are reported.
+var instrumentedOps = []Operation{OpGet, OpGetMany, OpHas, OpHasMany, OpPut, OpPutMany, OpRebase, OpRoot, OpCommit}
+
+// instrumentedStores holds the metrics of every open InstrumentedChunkStore of the process by store name, and is
+// published through expvar.
+var instrumentedStores = expvar.NewMap("dolt_chunk_stores")
+
+// opMetrics are the running metrics of the calls of one Operation.
+type opMetrics struct {
+	calls   uint64
+	errors  uint64
+	chunks  uint64
+	bytes   uint64
+	latency metrics.Histogram
+}
+
+// OperationStats are the metrics of the calls of one Operation of an InstrumentedChunkStore.
+type OperationStats struct {
+	// Calls is the number of calls made
+	Calls uint64
+
+	// Errors is the number of calls which returned an error other than ErrChunkNotFound
+	Errors uint64
+
+	// Chunks is the number of chunks or hashes the calls were made with, such as the hashes asked for by a GetMany
+	Chunks uint64
+
+	// Bytes is the size of the data of the chunks which were gotten or put
+	Bytes uint64
+
+	// Latency is the histogram of the durations of the calls, in nanoseconds
+	Latency metrics.Histogram
+}
+
+// InstrumentedStats are the metrics of an InstrumentedChunkStore, which are returned by its Stats method.
+type InstrumentedStats struct {
+	// Name is the name of the store the metrics are tagged with
+	Name string
+
+	// Operations are the metrics of each Operation which has been called
+	Operations map[Operation]OperationStats
+}
+
+// String prints the metrics of each operation which has been called, one per line.
+func (is InstrumentedStats) String() string {
+	sb := &strings.Builder{}
+	sb.WriteString(is.Name)
+	for _, op := range instrumentedOps {
+		stats, ok := is.Operations[op]
+
+		if !ok {
+			continue
+		}
+
+		fmt.Fprintf(sb, "\n%s: %d calls, %d errors, %d chunks, %d bytes, latency %s", op, stats.Calls, stats.Errors, stats.Chunks, stats.Bytes, stats.Latency.String())
+	}
+
+	return sb.String()
+}
+
+// expvarMetrics returns the metrics in the form they are published through expvar.
+func (is InstrumentedStats) expvarMetrics() map[string]interface{} {
+	ops := make(map[string]interface{})
+	for op, stats := range is.Operations {
+		ops[string(op)] = map[string]interface{}{
+			"calls":           stats.Calls,
+			"errors":          stats.Errors,
+			"chunks":          stats.Chunks,
+			"bytes":           stats.Bytes,
+			"latency_mean_ns": stats.Latency.Mean(),
+			"latency_sum_ns":  stats.Latency.Sum(),
+		}
+	}
+
+	return ops
+}
+
+// instrumentedVar publishes the metrics of an InstrumentedChunkStore through expvar.
+type instrumentedVar struct {
+	ics *InstrumentedChunkStore
+}
+
+func (v instrumentedVar) String() string {
+	data, err := json.Marshal(v.ics.InstrumentedStats().expvarMetrics())
+
+	if err != nil {
+		return "{}"
+	}
+
+	return string(data)
+}
+
+// InstrumentedChunkStore is a ChunkStore which wraps a ChunkStore, keeping the counts, errors, chunks, bytes and
+// latencies of the calls to each of its methods. The metrics are tagged with the name of the store, and are published
+// through expvar, under dolt_chunk_stores, until the store is closed. A prometheus.Collector of them can be made with
+// NewInstrumentedCollector.
+type InstrumentedChunkStore struct {
+	name string
+	cs   ChunkStore
+	ops  map[Operation]*opMetrics
+}
+
+var _ ChunkStore = (*InstrumentedChunkStore)(nil)
+
+// NewInstrumentedChunkStore returns an InstrumentedChunkStore which wraps |cs|, tagging its metrics with |name|. A
+// store made with the name of an open store replaces it in expvar.
+func NewInstrumentedChunkStore(name string, cs ChunkStore) *InstrumentedChunkStore {
+	ops := make(map[Operation]*opMetrics, len(instrumentedOps))
+	for _, op := range instrumentedOps {
+		ops[op] = &opMetrics{latency: metrics.NewTimeHistogram()}
+	}
+
+	ics := &InstrumentedChunkStore{name, cs, ops}
+	instrumentedStores.Set(name, instrumentedVar{ics})
+
+	return ics
+}
+
+// Name returns the name the metrics of the store are tagged with.
+func (ics *InstrumentedChunkStore) Name() string {
+	return ics.name
+}
+
+// record adds a call of |op| made with |chunks| chunks of |bytes| bytes, which started at |start| and returned |err|.
+// Missing chunks aren't counted as errors.
+func (ics *InstrumentedChunkStore) record(op Operation, start time.Time, chunks, bytes int, err error) {
+	m := ics.ops[op]
+	atomic.AddUint64(&m.calls, 1)
+	atomic.AddUint64(&m.chunks, uint64(chunks))
+	atomic.AddUint64(&m.bytes, uint64(bytes))
+
+	if err != nil && !errors.Is(err, ErrChunkNotFound) {
+		atomic.AddUint64(&m.errors, 1)
+	}
+
+	m.latency.SampleTimeSince(start)
+}
+
+// InstrumentedStats returns the metrics of the store. Operations which haven't been called are left out.
+func (ics *InstrumentedChunkStore) InstrumentedStats() InstrumentedStats {
+	stats := InstrumentedStats{Name: ics.name, Operations: make(map[Operation]OperationStats)}
+	for op, m := range ics.ops {
+		calls := atomic.LoadUint64(&m.calls)
+
+		if calls == 0 {
+			continue
+		}
+
+		latency := metrics.NewTimeHistogram()
+		latency.Add(m.latency)
+		stats.Operations[op] = OperationStats{
+			Calls:   calls,
+			Errors:  atomic.LoadUint64(&m.errors),
+			Chunks:  atomic.LoadUint64(&m.chunks),
+			Bytes:   atomic.LoadUint64(&m.bytes),
+			Latency: latency,
+		}
+	}
+
+	return stats
+}
+
+func (ics *InstrumentedChunkStore) Get(ctx context.Context, h hash.Hash) (Chunk, error) {
+	start := time.Now()
+	c, err := ics.cs.Get(ctx, h)
+	ics.record(OpGet, start, 1, len(c.Data()), err)
+
+	return c, err
+}
+
+func (ics *InstrumentedChunkStore) GetMany(ctx context.Context, hashes hash.HashSet, foundChunks chan<- *Chunk) error {
+	return GetManyFromF(ctx, hashes, foundChunks, ics.GetManyF)
+}
+
+// GetManyF is recorded as a GetMany.
+func (ics *InstrumentedChunkStore) GetManyF(ctx context.Context, hashes hash.HashSet, found func(*Chunk)) error {
+	var bytes int64
+	start := time.Now()
+	err := ics.cs.GetManyF(ctx, hashes, func(c *Chunk) {
+		atomic.AddInt64(&bytes, int64(len(c.Data())))
+		found(c)
+	})
+	ics.record(OpGetMany, start, len(hashes), int(atomic.LoadInt64(&bytes)), err)
+
+	return err
+}
+
+// GetManyCompressed is recorded as a GetMany, counting the compressed bytes of the chunks found.
+func (ics *InstrumentedChunkStore) GetManyCompressed(ctx context.Context, hashes hash.HashSet, foundCmpChunks chan<- CompressedChunk) error {
+	start := time.Now()
+	cmpChunks := make(chan CompressedChunk, len(hashes))
+	done := make(chan int)
+	go func() {
+		defer close(done)
+
+		var bytes int
+		for cmp := range cmpChunks {
+			bytes += len(cmp.CompressedData)
+
+			select {
+			case foundCmpChunks <- cmp:
+			case <-ctx.Done():
+			}
+		}
+
+		done <- bytes
+	}()
+
+	err := ics.cs.GetManyCompressed(ctx, hashes, cmpChunks)
+	close(cmpChunks)
+	ics.record(OpGetMany, start, len(hashes), <-done, err)
+
+	return err
+}
+
+func (ics *InstrumentedChunkStore) Has(ctx context.Context, h hash.Hash) (bool, error) {
+	start := time.Now()
+	has, err := ics.cs.Has(ctx, h)
+	ics.record(OpHas, start, 1, 0, err)
+
+	return has, err
+}
+
+func (ics *InstrumentedChunkStore) HasMany(ctx context.Context, hashes hash.HashSet) (absent hash.HashSet, err error) {
+	start := time.Now()
+	absent, err = ics.cs.HasMany(ctx, hashes)
+	ics.record(OpHasMany, start, len(hashes), 0, err)
+
+	return absent, err
+}
+
+func (ics *InstrumentedChunkStore) Put(ctx context.Context, c Chunk) error {
+	start := time.Now()
+	err := ics.cs.Put(ctx, c)
+	ics.record(OpPut, start, 1, len(c.Data()), err)
+
+	return err
+}
+
+func (ics *InstrumentedChunkStore) PutMany(ctx context.Context, chunks []Chunk) error {
+	bytes := 0
+	for _, c := range chunks {
+		bytes += len(c.Data())
+	}
+
+	start := time.Now()
+	err := ics.cs.PutMany(ctx, chunks)
+	ics.record(OpPutMany, start, len(chunks), bytes, err)
+
+	return err
+}
+
+func (ics *InstrumentedChunkStore) Version() string {
+	return ics.cs.Version()
+}
+
+func (ics *InstrumentedChunkStore) Rebase(ctx context.Context) error {
+	start := time.Now()
+	err := ics.cs.Rebase(ctx)
+	ics.record(OpRebase, start, 0, 0, err)
+
+	return err
+}
+
+func (ics *InstrumentedChunkStore) Root(ctx context.Context) (hash.Hash, error) {
+	start := time.Now()
+	root, err := ics.cs.Root(ctx)
+	ics.record(OpRoot, start, 0, 0, err)
+
+	return root, err
+}
+
+func (ics *InstrumentedChunkStore) Commit(ctx context.Context, current, last hash.Hash) (bool, error) {
+	start := time.Now()
+	ok, err := ics.cs.Commit(ctx, current, last)
+	ics.record(OpCommit, start, 0, 0, err)
+
+	return ok, err
+}
+
+// Stats returns the InstrumentedStats of the store.
+func (ics *InstrumentedChunkStore) Stats() interface{} {
+	return ics.InstrumentedStats()
+}
+
+// StatsSummary returns the InstrumentedStats of the store as a string.
+func (ics *InstrumentedChunkStore) StatsSummary() string {
+	return ics.InstrumentedStats().String()
+}
+
+// Close closes the wrapped store and stops publishing the metrics of the store through expvar, unless another store
+// was made with its name since.
+func (ics *InstrumentedChunkStore) Close() error {
+	if v, ok := instrumentedStores.Get(ics.name).(instrumentedVar); ok && v.ics == ics {
+		instrumentedStores.Delete(ics.name)
+	}
+
+	return ics.cs.Close()
+}
@@ -0,0 +1,171 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+func TestInstrumentedChunkStore(t *testing.T) {
+	ctx := context.Background()
+	ics := NewInstrumentedChunkStore("test_store", (&MemoryStorage{}).NewView())
+	defer ics.Close()
+
+	hashes := putChunks(t, ics, "abc", "defg")
+	require.NoError(t, ics.PutMany(ctx, []Chunk{NewChunk([]byte("hi"))}))
+
+	for h := range hashes {
+		_, err := ics.Get(ctx, h)
+		require.NoError(t, err)
+	}
+
+	_, err := ics.Get(ctx, hash.Of([]byte("missing")))
+	assert.Error(t, err)
+
+	got, err := getMany(ics, hashes)
+	require.NoError(t, err)
+	assert.Equal(t, hashes, got)
+
+	_, err = ics.HasMany(ctx, hashes)
+	require.NoError(t, err)
+
+	root, err := ics.Root(ctx)
+	require.NoError(t, err)
+	_, err = ics.Commit(ctx, root, root)
+	require.NoError(t, err)
+
+	stats, ok := ics.Stats().(InstrumentedStats)
+	require.True(t, ok)
+	assert.Equal(t, "test_store", stats.Name)
+
+	put := stats.Operations[OpPut]
+	assert.Equal(t, uint64(2), put.Calls)
+	assert.Equal(t, uint64(2), put.Chunks)
+	assert.Equal(t, uint64(7), put.Bytes)
+	assert.Equal(t, uint64(2), put.Latency.Samples())
+
+	putMany := stats.Operations[OpPutMany]
+	assert.Equal(t, uint64(1), putMany.Calls)
+	assert.Equal(t, uint64(2), putMany.Bytes)
+
+	get := stats.Operations[OpGet]
+	assert.Equal(t, uint64(3), get.Calls)
+	assert.Equal(t, uint64(0), get.Errors)
+	assert.Equal(t, uint64(7), get.Bytes)
+
+	getMany := stats.Operations[OpGetMany]
+	assert.Equal(t, uint64(1), getMany.Calls)
+	assert.Equal(t, uint64(2), getMany.Chunks)
+	assert.Equal(t, uint64(7), getMany.Bytes)
+
+	assert.Equal(t, uint64(2), stats.Operations[OpHasMany].Chunks)
+	assert.Equal(t, uint64(1), stats.Operations[OpRoot].Calls)
+	assert.Equal(t, uint64(1), stats.Operations[OpCommit].Calls)
+
+	_, called := stats.Operations[OpRebase]
+	assert.False(t, called)
+	assert.Contains(t, ics.StatsSummary(), "Put: 2 calls, 0 errors, 2 chunks, 7 bytes")
+}
+
+func TestInstrumentedChunkStoreErrors(t *testing.T) {
+	ctx := context.Background()
+	schedule := NewFaultSchedule(0, FailNth(OpPut, 2), FailNth(OpRebase, 1))
+	ics := NewInstrumentedChunkStore("test_errors", NewFaultChunkStore((&MemoryStorage{}).NewView(), schedule))
+	defer ics.Close()
+
+	require.NoError(t, ics.Put(ctx, NewChunk([]byte("abc"))))
+	assert.Error(t, ics.Put(ctx, NewChunk([]byte("def"))))
+	assert.Error(t, ics.Rebase(ctx))
+
+	stats := ics.InstrumentedStats()
+	assert.Equal(t, uint64(2), stats.Operations[OpPut].Calls)
+	assert.Equal(t, uint64(1), stats.Operations[OpPut].Errors)
+	assert.Equal(t, uint64(1), stats.Operations[OpRebase].Errors)
+}
+
+func TestInstrumentedChunkStoreExpvar(t *testing.T) {
+	ics := NewInstrumentedChunkStore("test_expvar", (&MemoryStorage{}).NewView())
+	putChunks(t, ics, "abc")
+
+	v := instrumentedStores.Get("test_expvar")
+	require.NotNil(t, v)
+
+	var published map[string]map[string]uint64
+	require.NoError(t, json.Unmarshal([]byte(v.String()), &published))
+	assert.Equal(t, uint64(1), published["Put"]["calls"])
+	assert.Equal(t, uint64(3), published["Put"]["bytes"])
+
+	// a store made with the same name replaces the first, which then leaves it be when it's closed
+	replacement := NewInstrumentedChunkStore("test_expvar", (&MemoryStorage{}).NewView())
+	require.NoError(t, ics.Close())
+	assert.NotNil(t, instrumentedStores.Get("test_expvar"))
+
+	require.NoError(t, replacement.Close())
+	assert.Nil(t, instrumentedStores.Get("test_expvar"))
+}
+
+func TestInstrumentedCollector(t *testing.T) {
+	ctx := context.Background()
+	ics := NewInstrumentedChunkStore("test_collector", (&MemoryStorage{}).NewView())
+	defer ics.Close()
+
+	hashes := putChunks(t, ics, "abc", "def")
+	for h := range hashes {
+		_, err := ics.Get(ctx, h)
+		require.NoError(t, err)
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	require.NoError(t, reg.Register(NewInstrumentedCollector(ics)))
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	byName := make(map[string]int)
+	for _, mf := range families {
+		byName[mf.GetName()] = len(mf.GetMetric())
+
+		if mf.GetName() != "dolt_chunk_store_latency_seconds" {
+			continue
+		}
+
+		for _, m := range mf.GetMetric() {
+			assert.Equal(t, uint64(2), m.GetHistogram().GetSampleCount())
+
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "store" {
+					assert.Equal(t, "test_collector", l.GetValue())
+				}
+			}
+		}
+	}
+
+	// one metric for each of Get and Put
+	assert.Equal(t, map[string]int{
+		"dolt_chunk_store_calls_total":     2,
+		"dolt_chunk_store_errors_total":    2,
+		"dolt_chunk_store_chunks_total":    2,
+		"dolt_chunk_store_bytes_total":     2,
+		"dolt_chunk_store_latency_seconds": 2,
+	}, byName)
+}
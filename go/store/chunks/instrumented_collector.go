@@ -0,0 +1,89 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"math"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	instrumentedLabels = []string{"store", "op"}
+
+	instrumentedCallsDesc = prometheus.NewDesc("dolt_chunk_store_calls_total",
+		"The number of calls made to a chunk store.", instrumentedLabels, nil)
+	instrumentedErrorsDesc = prometheus.NewDesc("dolt_chunk_store_errors_total",
+		"The number of calls to a chunk store which failed.", instrumentedLabels, nil)
+	instrumentedChunksDesc = prometheus.NewDesc("dolt_chunk_store_chunks_total",
+		"The number of chunks or hashes the calls to a chunk store were made with.", instrumentedLabels, nil)
+	instrumentedBytesDesc = prometheus.NewDesc("dolt_chunk_store_bytes_total",
+		"The size of the chunks gotten from or put to a chunk store.", instrumentedLabels, nil)
+	instrumentedLatencyDesc = prometheus.NewDesc("dolt_chunk_store_latency_seconds",
+		"The latency of the calls made to a chunk store.", instrumentedLabels, nil)
+)
+
+// instrumentedCollector is a prometheus.Collector of the metrics of InstrumentedChunkStores.
+type instrumentedCollector struct {
+	stores []*InstrumentedChunkStore
+}
+
+// NewInstrumentedCollector returns a prometheus.Collector of the metrics of |stores|, labeled with the name of each
+// store and operation. The metrics are read from the stores whenever they are collected.
+func NewInstrumentedCollector(stores ...*InstrumentedChunkStore) prometheus.Collector {
+	return instrumentedCollector{stores}
+}
+
+func (ic instrumentedCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- instrumentedCallsDesc
+	ch <- instrumentedErrorsDesc
+	ch <- instrumentedChunksDesc
+	ch <- instrumentedBytesDesc
+	ch <- instrumentedLatencyDesc
+}
+
+func (ic instrumentedCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, ics := range ic.stores {
+		stats := ics.InstrumentedStats()
+		for _, op := range instrumentedOps {
+			opStats, ok := stats.Operations[op]
+
+			if !ok {
+				continue
+			}
+
+			labels := []string{stats.Name, string(op)}
+			ch <- prometheus.MustNewConstMetric(instrumentedCallsDesc, prometheus.CounterValue, float64(opStats.Calls), labels...)
+			ch <- prometheus.MustNewConstMetric(instrumentedErrorsDesc, prometheus.CounterValue, float64(opStats.Errors), labels...)
+			ch <- prometheus.MustNewConstMetric(instrumentedChunksDesc, prometheus.CounterValue, float64(opStats.Chunks), labels...)
+			ch <- prometheus.MustNewConstMetric(instrumentedBytesDesc, prometheus.CounterValue, float64(opStats.Bytes), labels...)
+
+			// the buckets of a prometheus histogram are cumulative, and the last one is implied by the count
+			buckets := make(map[float64]uint64)
+			count := uint64(0)
+			for _, b := range opStats.Latency.Buckets() {
+				count += b.Count
+
+				if b.UpperBound < math.MaxUint64 {
+					buckets[time.Duration(b.UpperBound).Seconds()] = count
+				}
+			}
+
+			sum := time.Duration(opStats.Latency.Sum()).Seconds()
+			ch <- prometheus.MustNewConstHistogram(instrumentedLatencyDesc, count, sum, buckets, labels...)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// Operation identifies a call made to a ChunkStore by the LatencyChunkStore and FaultChunkStore test wrappers.
+// Wrappers which add methods to a ChunkStore, such as the table file methods of a NomsBlockStore, can define their own
+// Operations for them.
+type Operation string
+
+const (
+	OpGet     Operation = "Get"
+	OpGetMany Operation = "GetMany"
+	OpHas     Operation = "Has"
+	OpHasMany Operation = "HasMany"
+	OpPut     Operation = "Put"
+	OpPutMany Operation = "PutMany"
+	OpRebase  Operation = "Rebase"
+	OpRoot    Operation = "Root"
+	OpCommit  Operation = "Commit"
+)
+
+// LatencyDistribution provides the latencies added to the calls of an Operation by a LatencySchedule.
+// Implementations must be safe for concurrent use.
+type LatencyDistribution interface {
+	// Latency returns the latency of the next call.
+	Latency() time.Duration
+}
+
+// FixedLatency is a LatencyDistribution which adds the same latency to every call.
+type FixedLatency time.Duration
+
+// Latency returns the latency of the next call.
+func (l FixedLatency) Latency() time.Duration {
+	return time.Duration(l)
+}
+
+// UniformLatency is a LatencyDistribution with latencies spread evenly between a minimum and a maximum.
+type UniformLatency struct {
+	min, max time.Duration
+	mu       *sync.Mutex
+	rng      *rand.Rand
+}
+
+// NewUniformLatency returns a UniformLatency between |min| and |max|. The latencies are chosen by a random number
+// generator seeded with |seed|.
+func NewUniformLatency(min, max time.Duration, seed int64) UniformLatency {
+	return UniformLatency{min, max, &sync.Mutex{}, rand.New(rand.NewSource(seed))}
+}
+
+// Latency returns the latency of the next call.
+func (l UniformLatency) Latency() time.Duration {
+	if l.max <= l.min {
+		return l.min
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.min + time.Duration(l.rng.Int63n(int64(l.max-l.min)))
+}
+
+// SpikyLatency is a LatencyDistribution which usually adds a base latency, and occasionally a much longer one, like
+// a store which stalls under load.
+type SpikyLatency struct {
+	base, spike time.Duration
+	probability float64
+	mu          *sync.Mutex
+	rng         *rand.Rand
+}
+
+// NewSpikyLatency returns a SpikyLatency which adds |spike| to a call with a probability of |probability|, and |base|
+// to it otherwise. The spikes are chosen by a random number generator seeded with |seed|.
+func NewSpikyLatency(base, spike time.Duration, probability float64, seed int64) SpikyLatency {
+	return SpikyLatency{base, spike, probability, &sync.Mutex{}, rand.New(rand.NewSource(seed))}
+}
+
+// Latency returns the latency of the next call.
+func (l SpikyLatency) Latency() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rng.Float64() < l.probability {
+		return l.spike
+	}
+
+	return l.base
+}
+
+// LatencySchedule holds the latency distributions of the calls of each Operation. It is shared by the wrappers which
+// delay calls to a store, and is safe for concurrent use.
+type LatencySchedule struct {
+	mu        *sync.RWMutex
+	latencies map[Operation]LatencyDistribution
+}
+
+// NewLatencySchedule returns a LatencySchedule which delays the calls of each Operation in |latencies| by the
+// latencies of its distribution. Operations which aren't in |latencies| aren't delayed.
+func NewLatencySchedule(latencies map[Operation]LatencyDistribution) *LatencySchedule {
+	ls := &LatencySchedule{mu: &sync.RWMutex{}, latencies: make(map[Operation]LatencyDistribution)}
+
+	for op, l := range latencies {
+		ls.latencies[op] = l
+	}
+
+	return ls
+}
+
+// SetLatency changes the distribution of the latencies added to the calls of |op|. A nil distribution stops delaying
+// them.
+func (ls *LatencySchedule) SetLatency(op Operation, l LatencyDistribution) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if l == nil {
+		delete(ls.latencies, op)
+	} else {
+		ls.latencies[op] = l
+	}
+}
+
+// Wait delays a call of |op|. It returns the error of |ctx| if it is canceled first.
+func (ls *LatencySchedule) Wait(ctx context.Context, op Operation) error {
+	ls.mu.RLock()
+	l, ok := ls.latencies[op]
+	ls.mu.RUnlock()
+
+	if !ok {
+		return nil
+	}
+
+	d := l.Latency()
+
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// LatencyChunkStore is a ChunkStore implementation that wraps a ChunkStore, and delays its calls according to a
+// LatencySchedule to simulate slow storage. A delayed call returns the error of its context if the context is canceled
+// before the delay is over, without calling the wrapped store.
+type LatencyChunkStore struct {
+	*LatencySchedule
+	cs ChunkStore
+}
+
+var _ ChunkStore = &LatencyChunkStore{}
+
+// NewLatencyChunkStore returns a LatencyChunkStore which delays the calls made to |cs| according to |schedule|.
+func NewLatencyChunkStore(cs ChunkStore, schedule *LatencySchedule) *LatencyChunkStore {
+	return &LatencyChunkStore{schedule, cs}
+}
+
+// Get the Chunk for the value of the hash in the store. If the hash is
+// absent from the store EmptyChunk is returned.
+func (lcs *LatencyChunkStore) Get(ctx context.Context, h hash.Hash) (Chunk, error) {
+	if err := lcs.Wait(ctx, OpGet); err != nil {
+		return EmptyChunk, err
+	}
+
+	return lcs.cs.Get(ctx, h)
+}
+
+// GetMany gets the Chunks with |hashes| from the store. On return,
+// |foundChunks| will have been fully sent all chunks which have been
+// found. Any non-present chunks will silently be ignored.
+func (lcs *LatencyChunkStore) GetMany(ctx context.Context, hashes hash.HashSet, foundChunks chan<- *Chunk) error {
+	if err := lcs.Wait(ctx, OpGetMany); err != nil {
+		return err
+	}
+
+	return lcs.cs.GetMany(ctx, hashes, foundChunks)
+}
+
+// GetManyF gets the Chunks with |hashes| from the store, calling |found|
+// with each one that's found. Any non-present chunks will silently be
+// ignored.
+func (lcs *LatencyChunkStore) GetManyF(ctx context.Context, hashes hash.HashSet, found func(*Chunk)) error {
+	if err := lcs.Wait(ctx, OpGetMany); err != nil {
+		return err
+	}
+
+	return lcs.cs.GetManyF(ctx, hashes, found)
+}
+
+func (lcs *LatencyChunkStore) GetManyCompressed(ctx context.Context, hashes hash.HashSet, foundCmpChunks chan<- CompressedChunk) error {
+	if err := lcs.Wait(ctx, OpGetMany); err != nil {
+		return err
+	}
+
+	return lcs.cs.GetManyCompressed(ctx, hashes, foundCmpChunks)
+}
+
+// Returns true iff the value at the address |h| is contained in the
+// store
+func (lcs *LatencyChunkStore) Has(ctx context.Context, h hash.Hash) (bool, error) {
+	if err := lcs.Wait(ctx, OpHas); err != nil {
+		return false, err
+	}
+
+	return lcs.cs.Has(ctx, h)
+}
+
+// Returns a new HashSet containing any members of |hashes| that are
+// absent from the store.
+func (lcs *LatencyChunkStore) HasMany(ctx context.Context, hashes hash.HashSet) (absent hash.HashSet, err error) {
+	if err := lcs.Wait(ctx, OpHasMany); err != nil {
+		return nil, err
+	}
+
+	return lcs.cs.HasMany(ctx, hashes)
+}
+
+// Put caches c in the ChunkSource. Upon return, c must be visible to
+// subsequent Get and Has calls, but must not be persistent until a call
+// to Flush(). Put may be called concurrently with other calls to Put(),
+// Get(), GetMany(), Has() and HasMany().
+func (lcs *LatencyChunkStore) Put(ctx context.Context, c Chunk) error {
+	if err := lcs.Wait(ctx, OpPut); err != nil {
+		return err
+	}
+
+	return lcs.cs.Put(ctx, c)
+}
+
+// PutMany caches all of |chunks| like Put. It may be called concurrently with
+// the other methods of the store.
+func (lcs *LatencyChunkStore) PutMany(ctx context.Context, chunks []Chunk) error {
+	if err := lcs.Wait(ctx, OpPutMany); err != nil {
+		return err
+	}
+
+	return lcs.cs.PutMany(ctx, chunks)
+}
+
+// Returns the NomsVersion with which this ChunkSource is compatible.
+func (lcs *LatencyChunkStore) Version() string {
+	return lcs.cs.Version()
+}
+
+// Rebase brings this ChunkStore into sync with the persistent storage's
+// current root.
+func (lcs *LatencyChunkStore) Rebase(ctx context.Context) error {
+	if err := lcs.Wait(ctx, OpRebase); err != nil {
+		return err
+	}
+
+	return lcs.cs.Rebase(ctx)
+}
+
+// Root returns the root of the database as of the time the ChunkStore
+// was opened or the most recent call to Rebase.
+func (lcs *LatencyChunkStore) Root(ctx context.Context) (hash.Hash, error) {
+	if err := lcs.Wait(ctx, OpRoot); err != nil {
+		return hash.Hash{}, err
+	}
+
+	return lcs.cs.Root(ctx)
+}
+
+// Commit atomically attempts to persist all novel Chunks and update the
+// persisted root hash from last to current (or keeps it the same).
+// If last doesn't match the root in persistent storage, returns false.
+func (lcs *LatencyChunkStore) Commit(ctx context.Context, current, last hash.Hash) (bool, error) {
+	if err := lcs.Wait(ctx, OpCommit); err != nil {
+		return false, err
+	}
+
+	return lcs.cs.Commit(ctx, current, last)
+}
+
+// Stats may return some kind of struct that reports statistics about the
+// ChunkStore instance. The type is implementation-dependent, and impls
+// may return nil
+func (lcs *LatencyChunkStore) Stats() interface{} {
+	return lcs.cs.Stats()
+}
+
+// StatsSummary may return a string containing summarized statistics for
+// this ChunkStore. It must return "Unsupported" if this operation is not
+// supported.
+func (lcs *LatencyChunkStore) StatsSummary() string {
+	return lcs.cs.StatsSummary()
+}
+
+// Close tears down any resources in use by the implementation. After
+// Close(), the ChunkStore may not be used again. It is NOT SAFE to call
+// Close() concurrently with any other ChunkStore method; behavior is
+// undefined and probably crashy.
+func (lcs *LatencyChunkStore) Close() error {
+	return lcs.cs.Close()
+}
@@ -0,0 +1,96 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+func TestLatencyDistributions(t *testing.T) {
+	assert.Equal(t, 5*time.Millisecond, FixedLatency(5*time.Millisecond).Latency())
+
+	u1 := NewUniformLatency(time.Millisecond, 10*time.Millisecond, 7)
+	u2 := NewUniformLatency(time.Millisecond, 10*time.Millisecond, 7)
+	for i := 0; i < 100; i++ {
+		l := u1.Latency()
+		assert.Equal(t, l, u2.Latency())
+		assert.True(t, l >= time.Millisecond && l < 10*time.Millisecond)
+	}
+
+	s := NewSpikyLatency(time.Millisecond, time.Second, 0.25, 7)
+	spikes := 0
+	for i := 0; i < 1000; i++ {
+		if s.Latency() == time.Second {
+			spikes++
+		}
+	}
+
+	assert.True(t, spikes > 150 && spikes < 350, "%d spikes", spikes)
+}
+
+func TestLatencyChunkStore(t *testing.T) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+	lcs := NewLatencyChunkStore(storage.NewView(), NewLatencySchedule(map[Operation]LatencyDistribution{
+		OpPut: FixedLatency(20 * time.Millisecond),
+	}))
+
+	c := NewChunk([]byte("abc"))
+	start := time.Now()
+	require.NoError(t, lcs.Put(ctx, c))
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+
+	start = time.Now()
+	read, err := lcs.Get(ctx, c.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, c.Data(), read.Data())
+	assert.True(t, time.Since(start) < 20*time.Millisecond)
+
+	success, err := lcs.Commit(ctx, hash.Hash{}, hash.Hash{})
+	require.NoError(t, err)
+	assert.True(t, success)
+
+	lcs.SetLatency(OpPut, nil)
+	start = time.Now()
+	require.NoError(t, lcs.Put(ctx, NewChunk([]byte("def"))))
+	assert.True(t, time.Since(start) < 20*time.Millisecond)
+}
+
+func TestLatencyChunkStoreCancellation(t *testing.T) {
+	storage := &MemoryStorage{}
+	lcs := NewLatencyChunkStore(storage.NewView(), NewLatencySchedule(map[Operation]LatencyDistribution{
+		OpGet:     FixedLatency(time.Minute),
+		OpGetMany: FixedLatency(time.Minute),
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := lcs.Get(ctx, hash.Of([]byte("abc")))
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	found := make(chan *Chunk, 1)
+	err = lcs.GetMany(ctx, hash.NewHashSet(hash.Of([]byte("abc"))), found)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, time.Since(start) < time.Minute)
+}
@@ -0,0 +1,181 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/util/sizecache"
+)
+
+// LRUChunkCacheStats counts the lookups of an LRUChunkCache, and is what its Stats method returns.
+type LRUChunkCacheStats struct {
+	// Hits and Misses count the chunks looked up by Get, GetMany, GetManyF, Has and HasMany
+	Hits   uint64
+	Misses uint64
+	// Evictions counts the chunks dropped to keep the cache within its byte budget
+	Evictions uint64
+
+	// Chunks and Bytes are the number of chunks in the cache, and the total size of their data
+	Chunks int
+	Bytes  uint64
+}
+
+// String returns a one line summary of the counts.
+func (s LRUChunkCacheStats) String() string {
+	return fmt.Sprintf("Hits: %d, misses: %d, evictions: %d, chunks: %d (%d bytes)",
+		s.Hits, s.Misses, s.Evictions, s.Chunks, s.Bytes)
+}
+
+// LRUChunkCache is a ChunkCache which holds chunks in memory up to a budget for the total size of their data, evicting
+// the least recently used chunks to make room for new ones. A chunk larger than the whole budget isn't cached. It's
+// safe for concurrent use.
+//
+// Unlike a MemoryStorage, an LRUChunkCache has no root, as it only ever holds some of the chunks of another store. It's
+// meant to be used as the cache of NewCachingChunkStore, which reads and commits the root on the backing store.
+type LRUChunkCache struct {
+	cache *sizecache.SizeCache
+
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+var _ ChunkCache = (*LRUChunkCache)(nil)
+
+// NewLRUChunkCache returns an empty LRUChunkCache which holds up to |maxBytes| of chunk data.
+func NewLRUChunkCache(maxBytes uint64) *LRUChunkCache {
+	lru := &LRUChunkCache{}
+	lru.cache = sizecache.NewWithExpireCallback(maxBytes, func(interface{}) {
+		atomic.AddUint64(&lru.evictions, 1)
+	})
+
+	return lru
+}
+
+// lookup returns the chunk with the hash |h| if it's in the cache, making it the most recently used chunk, and counts
+// the hit or miss.
+func (lru *LRUChunkCache) lookup(h hash.Hash) (Chunk, bool) {
+	v, ok := lru.cache.Get(h)
+
+	if !ok {
+		atomic.AddUint64(&lru.misses, 1)
+		return EmptyChunk, false
+	}
+
+	atomic.AddUint64(&lru.hits, 1)
+	return v.(Chunk), true
+}
+
+// Get returns the chunk with the hash |h|, or EmptyChunk and ErrChunkNotFound if it isn't in the cache.
+func (lru *LRUChunkCache) Get(ctx context.Context, h hash.Hash) (Chunk, error) {
+	if err := ctx.Err(); err != nil {
+		return EmptyChunk, err
+	}
+
+	if c, ok := lru.lookup(h); ok {
+		return c, nil
+	}
+
+	return EmptyChunk, ErrChunkNotFound
+}
+
+func (lru *LRUChunkCache) GetMany(ctx context.Context, hashes hash.HashSet, foundChunks chan<- *Chunk) error {
+	return GetManyFromF(ctx, hashes, foundChunks, lru.GetManyF)
+}
+
+// GetManyF calls |found| with each of the chunks of |hashes| which is in the cache.
+func (lru *LRUChunkCache) GetManyF(ctx context.Context, hashes hash.HashSet, found func(*Chunk)) error {
+	for h := range hashes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if c, ok := lru.lookup(h); ok {
+			found(&c)
+		}
+	}
+
+	return nil
+}
+
+func (lru *LRUChunkCache) GetManyCompressed(ctx context.Context, hashes hash.HashSet, foundCmpChunks chan<- CompressedChunk) error {
+	return GetManyCompressedFromF(ctx, hashes, foundCmpChunks, lru.GetManyF)
+}
+
+func (lru *LRUChunkCache) Has(ctx context.Context, h hash.Hash) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	_, ok := lru.lookup(h)
+	return ok, nil
+}
+
+func (lru *LRUChunkCache) HasMany(ctx context.Context, hashes hash.HashSet) (hash.HashSet, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	absent := hash.NewHashSet()
+	for h := range hashes {
+		if _, ok := lru.lookup(h); !ok {
+			absent.Insert(h)
+		}
+	}
+
+	return absent, nil
+}
+
+// Put adds |c| to the cache as its most recently used chunk, evicting the least recently used chunks if the cache is
+// over its budget. A chunk larger than the budget is ignored.
+func (lru *LRUChunkCache) Put(ctx context.Context, c Chunk) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	lru.cache.Add(c.Hash(), uint64(len(c.Data())), c)
+	return nil
+}
+
+func (lru *LRUChunkCache) PutMany(ctx context.Context, chunks []Chunk) error {
+	for _, c := range chunks {
+		if err := lru.Put(ctx, c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Stats returns the counts of the cache's lookups and evictions, and its current size.
+func (lru *LRUChunkCache) Stats() LRUChunkCacheStats {
+	return LRUChunkCacheStats{
+		Hits:      atomic.LoadUint64(&lru.hits),
+		Misses:    atomic.LoadUint64(&lru.misses),
+		Evictions: atomic.LoadUint64(&lru.evictions),
+		Chunks:    lru.cache.Len(),
+		Bytes:     lru.cache.Size(),
+	}
+}
+
+// Close drops all of the chunks of the cache.
+func (lru *LRUChunkCache) Close() error {
+	lru.cache.Purge()
+	return nil
+}
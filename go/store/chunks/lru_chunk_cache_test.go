@@ -0,0 +1,139 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+func TestLRUChunkCacheGetAndHas(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRUChunkCache(1 << 10)
+	abc, def := NewChunk([]byte("abc")), NewChunk([]byte("def"))
+	require.NoError(t, lru.PutMany(ctx, []Chunk{abc, def}))
+
+	c, err := lru.Get(ctx, abc.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, abc.Data(), c.Data())
+
+	c, err = lru.Get(ctx, hash.Of([]byte("missing")))
+	assert.True(t, errors.Is(err, ErrChunkNotFound))
+	assert.True(t, c.IsEmpty())
+
+	has, err := lru.Has(ctx, def.Hash())
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	missing := hash.Of([]byte("missing"))
+	absent, err := lru.HasMany(ctx, hash.NewHashSet(abc.Hash(), def.Hash(), missing))
+	require.NoError(t, err)
+	assert.Equal(t, hash.NewHashSet(missing), absent)
+
+	found := hash.NewHashSet()
+	err = lru.GetManyF(ctx, hash.NewHashSet(abc.Hash(), missing), func(c *Chunk) {
+		found.Insert(c.Hash())
+	})
+	require.NoError(t, err)
+	assert.Equal(t, hash.NewHashSet(abc.Hash()), found)
+
+	assert.Equal(t, LRUChunkCacheStats{Hits: 5, Misses: 3, Chunks: 2, Bytes: 6}, lru.Stats())
+}
+
+func TestLRUChunkCacheEviction(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRUChunkCache(10)
+	a, b, c := NewChunk([]byte("aaaa")), NewChunk([]byte("bbbb")), NewChunk([]byte("cccc"))
+	require.NoError(t, lru.Put(ctx, a))
+	require.NoError(t, lru.Put(ctx, b))
+
+	// reading a makes b the least recently used chunk, so it's evicted to make room for c
+	_, err := lru.Get(ctx, a.Hash())
+	require.NoError(t, err)
+	require.NoError(t, lru.Put(ctx, c))
+
+	absent, err := lru.HasMany(ctx, hash.NewHashSet(a.Hash(), b.Hash(), c.Hash()))
+	require.NoError(t, err)
+	assert.Equal(t, hash.NewHashSet(b.Hash()), absent)
+
+	stats := lru.Stats()
+	assert.Equal(t, uint64(1), stats.Evictions)
+	assert.Equal(t, 2, stats.Chunks)
+	assert.Equal(t, uint64(8), stats.Bytes)
+
+	// a chunk larger than the budget isn't cached, and doesn't evict anything
+	big := NewChunk([]byte("this chunk is too big"))
+	require.NoError(t, lru.Put(ctx, big))
+	has, err := lru.Has(ctx, big.Hash())
+	require.NoError(t, err)
+	assert.False(t, has)
+	assert.Equal(t, uint64(1), lru.Stats().Evictions)
+
+	require.NoError(t, lru.Close())
+	assert.Equal(t, 0, lru.Stats().Chunks)
+}
+
+func TestLRUChunkCacheConcurrency(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRUChunkCache(64)
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c := NewChunk([]byte(fmt.Sprintf("chunk %d", (i+j)%20)))
+				assert.NoError(t, lru.Put(ctx, c))
+				_, err := lru.Get(ctx, c.Hash())
+				if err != nil {
+					assert.True(t, errors.Is(err, ErrChunkNotFound))
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	stats := lru.Stats()
+	assert.Equal(t, uint64(800), stats.Hits+stats.Misses)
+	assert.True(t, stats.Bytes <= 64)
+}
+
+func TestCachingChunkStoreWithLRUChunkCache(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRUChunkCache(1 << 10)
+	backing := (&TestStorage{}).NewView()
+	hashes := putChunks(t, backing, "abc", "def")
+	cs := NewCachingChunkStore(lru, backing)
+
+	for i := 0; i < 2; i++ {
+		for h := range hashes {
+			c, err := cs.Get(ctx, h)
+			require.NoError(t, err)
+			assert.Equal(t, h, c.Hash())
+		}
+	}
+
+	assert.Equal(t, 2, backing.Reads())
+	assert.Equal(t, uint64(2), lru.Stats().Hits)
+}
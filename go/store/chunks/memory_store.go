@@ -23,7 +23,11 @@ package chunks
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 
 	"github.com/liquidata-inc/dolt/go/store/constants"
 
@@ -41,8 +45,41 @@ type MemoryStorage struct {
 	rootHash hash.Hash
 	mu       sync.RWMutex
 	version  string
+
+	// size is the size of the data of the chunks in memory and on disk, which is kept as chunks are added and dropped
+	size uint64
+
+	// deleted is set when the storage's namespace is deleted from a MemoryStoreFactory, after which it can't be updated
+	deleted bool
+
+	// spill holds the chunks which have been written to disk, for a storage made by NewSpillingStorage
+	spill *chunkSpill
+
+	// views are the views which have put chunks since they last committed and haven't been closed, whose pending and
+	// retained chunks are checked by CollectGarbage. Views are only kept here while they have chunks, so that views
+	// which are dropped without being closed don't pile up. viewsMu is locked before the locks of the views, which are
+	// locked before mu.
+	views   map[*MemoryStoreView]struct{}
+	viewsMu sync.Mutex
+
+	// shuttered is set to 1 when the storage's MemoryStoreFactory is shuttered, after which all of its views act as if
+	// they're closed. It's read and written atomically.
+	shuttered int32
+
+	// subs are the subscriptions to the changes of the root, which are made by SubscribeRootChanges. subsMu is locked
+	// after mu.
+	subs   map[*rootSubscription]struct{}
+	subsMu sync.Mutex
 }
 
+// ErrStoreDeleted is returned by the Commits of views of a MemoryStorage whose namespace has been deleted from its
+// MemoryStoreFactory.
+var ErrStoreDeleted = errors.New("the store has been deleted")
+
+// ErrGCPendingReference is returned by CollectGarbage when a pending chunk of a view, or a persisted chunk which it has
+// put since its last commit, refers to a chunk which would be dropped.
+var ErrGCPendingReference = errors.New("a pending chunk refers to a chunk which would be dropped")
+
 // NewView vends a MemoryStoreView backed by this MemoryStorage. It's
 // initialized with the currently "persisted" root.
 func (ms *MemoryStorage) NewView() ChunkStore {
@@ -51,60 +88,342 @@ func (ms *MemoryStorage) NewView() ChunkStore {
 		version = constants.NomsVersion
 	}
 
+	return ms.NewViewWithVersion(version)
+}
+
+// NewViewWithPendingLimit vends a MemoryStoreView backed by this MemoryStorage whose Puts fail with
+// ErrPendingBufferFull once its pending chunks hold |maxPendingBytes| bytes of data, so that callers writing many
+// chunks can commit them incrementally. A limit of 0 leaves the pending chunks unbounded, as NewView does.
+func (ms *MemoryStorage) NewViewWithPendingLimit(maxPendingBytes uint64) ChunkStore {
+	view := ms.NewView().(*MemoryStoreView)
+	view.maxPendingBytes = maxPendingBytes
+	return view
+}
+
+// NewViewWithVersion vends a MemoryStoreView backed by this MemoryStorage which reports the storage format |version|.
+// It's initialized with the currently "persisted" root.
+func (ms *MemoryStorage) NewViewWithVersion(version string) ChunkStore {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
 	return &MemoryStoreView{storage: ms, rootHash: ms.rootHash, version: version}
 }
 
-// Get retrieves the Chunk with the Hash h, returning EmptyChunk if it's not
-// present.
+// CollectGarbage drops all of the chunks which aren't in |keep|, other than the root chunk, and returns the number of
+// chunks dropped and the bytes of their data. Every view is locked while it runs, so that none sees a partly collected
+// storage. The persisted chunks which a view which hasn't been closed has put since its last commit are kept, and it
+// fails with ErrGCPendingReference, dropping nothing, if one of those, or a pending chunk of such a view, refers to a
+// chunk which would be dropped. A pending chunk is taken to refer to every chunk whose hash
+// appears in its data, which finds all of its references, and rarely some which aren't. A view which hasn't been
+// rebased since a root which has been collected may find the chunks of that root missing.
+func (ms *MemoryStorage) CollectGarbage(ctx context.Context, keep hash.HashSet) (chunksDropped int, bytesReclaimed uint64, err error) {
+	if err := ctx.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	ms.viewsMu.Lock()
+	defer ms.viewsMu.Unlock()
+	for view := range ms.views {
+		view.mu.RLock()
+		defer view.mu.RUnlock()
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	dropped := hash.HashSet{}
+	for _, h := range ms.hashesLocked() {
+		if h != ms.rootHash && !keep.Has(h) {
+			dropped.Insert(h)
+		}
+	}
+
+	for view := range ms.views {
+		for h := range view.retained {
+			dropped.Remove(h)
+		}
+	}
+
+	if len(dropped) == 0 {
+		return 0, 0, nil
+	}
+
+	for view := range ms.views {
+		for _, c := range view.pending {
+			if err := ctx.Err(); err != nil {
+				return 0, 0, err
+			}
+
+			if h, ok := referencedHash(c, dropped); ok {
+				return 0, 0, fmt.Errorf("%w: %s refers to %s", ErrGCPendingReference, c.Hash().String(), h.String())
+			}
+		}
+
+		for retained := range view.retained {
+			if err := ctx.Err(); err != nil {
+				return 0, 0, err
+			}
+
+			c, _, err := ms.getLocked(retained)
+
+			if err != nil {
+				return 0, 0, err
+			}
+
+			if h, ok := referencedHash(c, dropped); ok {
+				return 0, 0, fmt.Errorf("%w: %s refers to %s", ErrGCPendingReference, c.Hash().String(), h.String())
+			}
+		}
+	}
+
+	for h := range dropped {
+		size, err := ms.deleteLocked(h)
+
+		if err != nil {
+			return chunksDropped, bytesReclaimed, err
+		}
+
+		chunksDropped++
+		bytesReclaimed += size
+	}
+
+	return chunksDropped, bytesReclaimed, nil
+}
+
+// getLocked returns the chunk with the hash |h|, from memory or from disk. ms.mu must be held.
+func (ms *MemoryStorage) getLocked(h hash.Hash) (Chunk, bool, error) {
+	if c, ok := ms.data[h]; ok {
+		return c, true, nil
+	}
+
+	if ms.spill == nil {
+		return EmptyChunk, false, nil
+	}
+
+	return ms.spill.get(h)
+}
+
+// hasLocked returns whether the chunk with the hash |h| is in memory or on disk. ms.mu must be held.
+func (ms *MemoryStorage) hasLocked(h hash.Hash) bool {
+	if _, ok := ms.data[h]; ok {
+		return true
+	}
+
+	return ms.spill != nil && ms.spill.has(h)
+}
+
+// lenLocked returns the number of chunks in memory and on disk. ms.mu must be held.
+func (ms *MemoryStorage) lenLocked() int {
+	if ms.spill == nil {
+		return len(ms.data)
+	}
+
+	return len(ms.data) + len(ms.spill.spilled)
+}
+
+// hashesLocked returns the hashes of the chunks in memory and on disk. ms.mu must be held.
+func (ms *MemoryStorage) hashesLocked() hash.HashSlice {
+	hashes := make(hash.HashSlice, 0, ms.lenLocked())
+	for h := range ms.data {
+		hashes = append(hashes, h)
+	}
+
+	if ms.spill != nil {
+		for h := range ms.spill.spilled {
+			hashes = append(hashes, h)
+		}
+	}
+
+	return hashes
+}
+
+// deleteLocked removes the chunk with the hash |h| from memory or from disk, and returns the size of its data. ms.mu
+// must be held for writing.
+func (ms *MemoryStorage) deleteLocked(h hash.Hash) (uint64, error) {
+	if c, ok := ms.data[h]; ok {
+		size := uint64(len(c.Data()))
+		delete(ms.data, h)
+		ms.size -= size
+
+		if ms.spill != nil {
+			ms.spill.memBytes -= size
+		}
+
+		return size, nil
+	}
+
+	if ms.spill == nil {
+		return 0, nil
+	}
+
+	size, err := ms.spill.remove(h)
+
+	if err != nil {
+		return 0, err
+	}
+
+	ms.size -= size
+	return size, nil
+}
+
+// referencedHash returns a hash of |hashes| which appears in the data of |c|, if there is one.
+func referencedHash(c Chunk, hashes hash.HashSet) (hash.Hash, bool) {
+	data := c.Data()
+	for i := 0; i+hash.ByteLen <= len(data); i++ {
+		var h hash.Hash
+		copy(h[:], data[i:])
+
+		if hashes.Has(h) {
+			return h, true
+		}
+	}
+
+	return hash.Hash{}, false
+}
+
+// Get retrieves the Chunk with the Hash h, returning EmptyChunk and
+// ErrChunkNotFound if it's not present. A chunk with no data which is present
+// is returned without an error.
 func (ms *MemoryStorage) Get(ctx context.Context, h hash.Hash) (Chunk, error) {
+	if err := ctx.Err(); err != nil {
+		return EmptyChunk, err
+	}
+
 	ms.mu.RLock()
 	defer ms.mu.RUnlock()
-	if c, ok := ms.data[h]; ok {
-		return c, nil
+	c, ok, err := ms.getLocked(h)
+
+	if err != nil {
+		return EmptyChunk, err
+	} else if !ok {
+		return EmptyChunk, ErrChunkNotFound
 	}
-	return EmptyChunk, nil
+
+	return c, nil
 }
 
-// Has returns true if the Chunk with the Hash h is present in ms.data, false
-// if not.
+// Has returns true if the Chunk with the Hash h is present in ms, false if
+// not.
 func (ms *MemoryStorage) Has(ctx context.Context, r hash.Hash) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
 	ms.mu.RLock()
 	defer ms.mu.RUnlock()
-	_, ok := ms.data[r]
-	return ok, nil
+	return ms.hasLocked(r), nil
 }
 
-// Len returns the number of Chunks in ms.data.
+// hasManyCancelInterval is the number of hashes HasMany checks between checks of its context.
+const hasManyCancelInterval = 4096
+
+// HasMany returns the hashes of |hashes| which aren't present in ms, checking them all under a single lock.
+func (ms *MemoryStorage) HasMany(ctx context.Context, hashes hash.HashSet) (hash.HashSet, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	toCheck := make(hash.HashSlice, 0, len(hashes))
+	for h := range hashes {
+		toCheck = append(toCheck, h)
+	}
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.absentLocked(ctx, toCheck)
+}
+
+// absentLocked returns the hashes of |hashes| which aren't present in ms. ms.mu must be held.
+func (ms *MemoryStorage) absentLocked(ctx context.Context, hashes hash.HashSlice) (hash.HashSet, error) {
+	absent := hash.HashSet{}
+	for i, h := range hashes {
+		if i%hasManyCancelInterval == hasManyCancelInterval-1 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		if !ms.hasLocked(h) {
+			absent.Insert(h)
+		}
+	}
+
+	return absent, nil
+}
+
+// Len returns the number of Chunks in ms, in memory or on disk.
 func (ms *MemoryStorage) Len() int {
 	ms.mu.RLock()
 	defer ms.mu.RUnlock()
-	return len(ms.data)
+	return ms.lenLocked()
+}
+
+// Size returns the size of the data of the Chunks in ms, in memory or on disk.
+func (ms *MemoryStorage) Size() uint64 {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.size
 }
 
 // Root returns the currently "persisted" root hash of this in-memory store.
-func (ms *MemoryStorage) Root(ctx context.Context) hash.Hash {
+func (ms *MemoryStorage) Root(ctx context.Context) (hash.Hash, error) {
+	if err := ctx.Err(); err != nil {
+		return hash.Hash{}, err
+	}
+
 	ms.mu.RLock()
 	defer ms.mu.RUnlock()
-	return ms.rootHash
+	return ms.rootHash, nil
 }
 
 // Update checks the "persisted" root against last and, iff it matches,
-// updates the root to current, adds all of novel to ms.data, and returns
-// true. Otherwise returns false.
-func (ms *MemoryStorage) Update(current, last hash.Hash, novel map[hash.Hash]Chunk) bool {
+// updates the root to current, adds all of novel to ms, and returns true.
+// Otherwise returns false. A storage which spills writes the chunks which
+// don't fit in its budget to disk before the root is updated, and if one
+// can't be written, returns the error with nothing added. The new root is
+// sent to the subscriptions made by SubscribeRootChanges. It returns
+// ErrStoreDeleted if the storage has been deleted from its MemoryStoreFactory.
+func (ms *MemoryStorage) Update(ctx context.Context, current, last hash.Hash, novel map[hash.Hash]Chunk) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
+	if ms.deleted {
+		return false, ErrStoreDeleted
+	}
 	if last != ms.rootHash {
-		return false
+		return false, nil
 	}
 	if ms.data == nil {
 		ms.data = map[hash.Hash]Chunk{}
 	}
-	for h, c := range novel {
-		ms.data[h] = c
+	if ms.spill == nil {
+		for h, c := range novel {
+			if _, ok := ms.data[h]; !ok {
+				ms.size += uint64(len(c.Data()))
+			}
+			ms.data[h] = c
+		}
+	} else {
+		var added []Chunk
+		for h, c := range novel {
+			if !ms.hasLocked(h) {
+				added = append(added, c)
+			}
+		}
+
+		if err := ms.spill.add(ms.data, added); err != nil {
+			return false, err
+		}
+
+		for _, c := range added {
+			ms.size += uint64(len(c.Data()))
+		}
 	}
 	ms.rootHash = current
-	return true
+	ms.publishRoot(current)
+	return true, nil
 }
 
 // MemoryStoreView is an in-memory implementation of store.ChunkStore. Useful
@@ -113,142 +432,599 @@ func (ms *MemoryStorage) Update(current, last hash.Hash, novel map[hash.Hash]Chu
 // storage := &MemoryStorage{}
 // ms := storage.NewView()
 type MemoryStoreView struct {
+	// stats is first so that its counters are aligned for atomic operations on 32 bit platforms
+	stats MemoryStoreStats
+
 	pending  map[hash.Hash]Chunk
 	rootHash hash.Hash
 	mu       sync.RWMutex
 	version  string
+	closed   bool
+
+	// pendingBytes is the size of the data of the pending chunks, and maxPendingBytes is the size at which Puts fail
+	// with ErrPendingBufferFull, or 0 if they never do
+	pendingBytes    uint64
+	maxPendingBytes uint64
+
+	// retained holds the hashes of the persisted chunks which have been put since the last commit, and so weren't
+	// made pending, which CollectGarbage keeps until the view commits or is closed
+	retained hash.HashSet
 
 	storage *MemoryStorage
 }
 
+// ErrStoreClosed is returned by the methods of a MemoryStoreView which has been closed.
+var ErrStoreClosed = errors.New("the store has been closed")
+
+// ErrPendingBufferFull is returned by the Puts of a MemoryStoreView made by NewViewWithPendingLimit once its pending
+// chunks have reached the limit. The chunks put are not added, and can be put again after the view is committed.
+var ErrPendingBufferFull = errors.New("the pending chunks of the store have reached their limit")
+
+// PendingStats are the number of chunks pending in a MemoryStoreView, and the size of their data.
+type PendingStats struct {
+	Chunks int
+	Bytes  uint64
+}
+
+// Get returns the chunk with the hash h, pending or persisted, or EmptyChunk and ErrChunkNotFound if there isn't one.
 func (ms *MemoryStoreView) Get(ctx context.Context, h hash.Hash) (Chunk, error) {
+	if err := ctx.Err(); err != nil {
+		return EmptyChunk, err
+	}
+
+	atomic.AddUint64(&ms.stats.Gets, 1)
+
 	ms.mu.RLock()
 	defer ms.mu.RUnlock()
+	if ms.isClosedLocked() {
+		return EmptyChunk, ErrStoreClosed
+	}
 	if c, ok := ms.pending[h]; ok {
+		ms.stats.chunkRead(c)
 		return c, nil
 	}
-	return ms.storage.Get(ctx, h)
+
+	c, err := ms.storage.Get(ctx, h)
+	if err == nil {
+		ms.stats.chunkRead(c)
+	}
+
+	return c, err
 }
 
 func (ms *MemoryStoreView) GetMany(ctx context.Context, hashes hash.HashSet, foundChunks chan<- *Chunk) error {
-	for h := range hashes {
-		c, err := ms.Get(ctx, h)
+	return GetManyFromF(ctx, hashes, foundChunks, ms.GetManyF)
+}
 
-		if err != nil {
-			return err
+// GetManyF looks up all of |hashes| under a single lock of the view and of its storage, rather than locking for each.
+func (ms *MemoryStoreView) GetManyF(ctx context.Context, hashes hash.HashSet, found func(*Chunk)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	atomic.AddUint64(&ms.stats.GetManys, 1)
+
+	var chunks []*Chunk
+	remaining := make(hash.HashSlice, 0, len(hashes))
+
+	err := func() error {
+		ms.mu.RLock()
+		defer ms.mu.RUnlock()
+		if ms.isClosedLocked() {
+			return ErrStoreClosed
+		}
+
+		for h := range hashes {
+			if c, ok := ms.pending[h]; ok {
+				chunks = append(chunks, &c)
+			} else {
+				remaining = append(remaining, h)
+			}
+		}
+
+		if len(remaining) == 0 {
+			return nil
 		}
 
-		if !c.IsEmpty() {
-			foundChunks <- &c
+		ms.storage.mu.RLock()
+		defer ms.storage.mu.RUnlock()
+
+		for _, h := range remaining {
+			c, ok, err := ms.storage.getLocked(h)
+
+			if err != nil {
+				return err
+			}
+
+			if ok {
+				chunks = append(chunks, &c)
+			}
+		}
+
+		return nil
+	}()
+
+	if err != nil {
+		return err
+	}
+
+	// |found| is called outside of the locks, so that it can use the store
+	for _, c := range chunks {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
+
+		ms.stats.chunkRead(*c)
+		found(c)
 	}
 
 	return nil
 }
 
+// GetManyCompressed compresses each of the chunks of |hashes| which are found as it's sent to |foundCmpChunks|.
+func (ms *MemoryStoreView) GetManyCompressed(ctx context.Context, hashes hash.HashSet, foundCmpChunks chan<- CompressedChunk) error {
+	return GetManyCompressedFromF(ctx, hashes, foundCmpChunks, ms.GetManyF)
+}
+
 func (ms *MemoryStoreView) Has(ctx context.Context, h hash.Hash) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	atomic.AddUint64(&ms.stats.Hases, 1)
+
 	ms.mu.RLock()
 	defer ms.mu.RUnlock()
+	if ms.isClosedLocked() {
+		return false, ErrStoreClosed
+	}
 	if _, ok := ms.pending[h]; ok {
 		return true, nil
 	}
 	return ms.storage.Has(ctx, h)
 }
 
+// HasMany checks |hashes| against the pending chunks of the view under a single lock of the view, and the rest
+// against its storage under a single lock of the storage, returning the error of |ctx| as soon as it's canceled.
 func (ms *MemoryStoreView) HasMany(ctx context.Context, hashes hash.HashSet) (hash.HashSet, error) {
-	absent := hash.HashSet{}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	atomic.AddUint64(&ms.stats.HasManys, 1)
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	if ms.isClosedLocked() {
+		return nil, ErrStoreClosed
+	}
+
+	notPending := make(hash.HashSlice, 0, len(hashes))
 	for h := range hashes {
-		exists, err := ms.Has(ctx, h)
+		if _, ok := ms.pending[h]; !ok {
+			notPending = append(notPending, h)
+		}
+	}
+
+	// the view stays locked, so that a commit can't move chunks from pending to the storage between the two passes
+	ms.storage.mu.RLock()
+	defer ms.storage.mu.RUnlock()
+	return ms.storage.absentLocked(ctx, notPending)
+}
+
+// IterateAllChunks calls |cb| with each of the pending and persisted chunks of the view. The hashes of the chunks are
+// snapshotted first, and each chunk is looked up again before |cb| is called with it, outside of the locks, so chunks
+// which are collected as garbage during the iteration are skipped.
+func (ms *MemoryStoreView) IterateAllChunks(ctx context.Context, cb func(Chunk) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var hashes hash.HashSlice
+	err := func() error {
+		ms.mu.RLock()
+		defer ms.mu.RUnlock()
+		if ms.isClosedLocked() {
+			return ErrStoreClosed
+		}
+		ms.storage.mu.RLock()
+		defer ms.storage.mu.RUnlock()
+
+		hashes = make(hash.HashSlice, 0, len(ms.pending)+ms.storage.lenLocked())
+		for h := range ms.pending {
+			hashes = append(hashes, h)
+		}
+		for _, h := range ms.storage.hashesLocked() {
+			if _, ok := ms.pending[h]; !ok {
+				hashes = append(hashes, h)
+			}
+		}
+
+		return nil
+	}()
+
+	if err != nil {
+		return err
+	}
+
+	for _, h := range hashes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		c, ok, err := ms.lookup(h)
+
 		if err != nil {
-			return nil, err
-		} else if !exists {
-			absent.Insert(h)
+			return err
+		} else if !ok {
+			continue
+		}
+
+		if err := cb(c); err != nil {
+			return err
 		}
 	}
-	return absent, nil
+
+	return nil
+}
+
+// lookup returns the pending or persisted chunk with the hash |h|, without counting it as a read.
+func (ms *MemoryStoreView) lookup(h hash.Hash) (Chunk, bool, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	if c, ok := ms.pending[h]; ok {
+		return c, true, nil
+	}
+
+	ms.storage.mu.RLock()
+	defer ms.storage.mu.RUnlock()
+	return ms.storage.getLocked(h)
 }
 
 func (ms *MemoryStoreView) Version() string {
 	return ms.version
 }
 
+// Put adds |c| to the pending chunks of the view, unless it's already pending or persisted in the storage, in which
+// case Has reports it without a second copy being kept. A persisted chunk which is put is kept by CollectGarbage until
+// the view commits, as a pending chunk would be. It returns ErrPendingBufferFull if the view has a pending limit which has been reached.
 func (ms *MemoryStoreView) Put(ctx context.Context, c Chunk) error {
-	ms.mu.Lock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	atomic.AddUint64(&ms.stats.Puts, 1)
+	ms.stats.chunkWritten(c)
+
+	if err := ms.lockForPut(); err != nil {
+		return err
+	}
 	defer ms.mu.Unlock()
+	ms.storage.mu.RLock()
+	defer ms.storage.mu.RUnlock()
+
+	if !ms.isNovelLocked(c.Hash()) {
+		ms.retainLocked(c.Hash())
+		return nil
+	}
+	if ms.pendingFullLocked() {
+		return ErrPendingBufferFull
+	}
 	if ms.pending == nil {
 		ms.pending = map[hash.Hash]Chunk{}
 	}
-	ms.pending[c.Hash()] = c
+	ms.addPendingLocked(c)
+
+	return nil
+}
+
+// PutMany adds all of |chunks| to the pending chunks of the view under a single lock, skipping those which are
+// already pending or persisted as Put does. If the view has a pending limit which has been reached, it returns
+// ErrPendingBufferFull without adding any of them.
+func (ms *MemoryStoreView) PutMany(ctx context.Context, chunks []Chunk) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	atomic.AddUint64(&ms.stats.PutManys, 1)
+	for _, c := range chunks {
+		ms.stats.chunkWritten(c)
+	}
+
+	if err := ms.lockForPut(); err != nil {
+		return err
+	}
+	defer ms.mu.Unlock()
+	ms.storage.mu.RLock()
+	defer ms.storage.mu.RUnlock()
+
+	novel := make([]Chunk, 0, len(chunks))
+	var known []hash.Hash
+	for _, c := range chunks {
+		if ms.isNovelLocked(c.Hash()) {
+			novel = append(novel, c)
+		} else {
+			known = append(known, c.Hash())
+		}
+	}
+
+	if len(novel) > 0 && ms.pendingFullLocked() {
+		return ErrPendingBufferFull
+	}
+	if len(novel) > 0 && ms.pending == nil {
+		ms.pending = make(map[hash.Hash]Chunk, len(novel))
+	}
+	for _, c := range novel {
+		ms.addPendingLocked(c)
+	}
+	for _, h := range known {
+		ms.retainLocked(h)
+	}
+
+	return nil
+}
+
+// lockForPut locks ms.mu for writing for a Put, and adds the view to the views of its storage which CollectGarbage
+// checks, where it's kept until it commits or is closed. If the view is closed it returns ErrStoreClosed, and leaves
+// ms.mu unlocked.
+func (ms *MemoryStoreView) lockForPut() error {
+	ms.storage.viewsMu.Lock()
+	defer ms.storage.viewsMu.Unlock()
+
+	ms.mu.Lock()
+	if ms.isClosedLocked() {
+		ms.mu.Unlock()
+		return ErrStoreClosed
+	}
+
+	if ms.storage.views == nil {
+		ms.storage.views = make(map[*MemoryStoreView]struct{})
+	}
+	ms.storage.views[ms] = struct{}{}
 
 	return nil
 }
 
+// isClosedLocked returns whether the view has been closed, or the factory of its storage has been shuttered. ms.mu
+// must be held.
+func (ms *MemoryStoreView) isClosedLocked() bool {
+	return ms.closed || atomic.LoadInt32(&ms.storage.shuttered) != 0
+}
+
+// isNovelLocked returns whether the chunk with the hash |h| is neither pending nor persisted. ms.mu and
+// ms.storage.mu must be held.
+func (ms *MemoryStoreView) isNovelLocked(h hash.Hash) bool {
+	if _, ok := ms.pending[h]; ok {
+		return false
+	}
+
+	return !ms.storage.hasLocked(h)
+}
+
+// retainLocked records that the chunk with the hash |h|, which is pending or persisted, was put, so that
+// CollectGarbage keeps it until the view commits if it's persisted. ms.mu must be held for writing.
+func (ms *MemoryStoreView) retainLocked(h hash.Hash) {
+	if _, ok := ms.pending[h]; ok {
+		return
+	}
+	if ms.retained == nil {
+		ms.retained = hash.HashSet{}
+	}
+	ms.retained.Insert(h)
+}
+
+// pendingFullLocked returns whether the pending chunks have reached the view's limit. ms.mu must be held.
+func (ms *MemoryStoreView) pendingFullLocked() bool {
+	return ms.maxPendingBytes > 0 && ms.pendingBytes >= ms.maxPendingBytes
+}
+
+// addPendingLocked adds |c| to the pending chunks, which must not have it. ms.mu must be held for writing.
+func (ms *MemoryStoreView) addPendingLocked(c Chunk) {
+	ms.pending[c.Hash()] = c
+	ms.pendingBytes += uint64(len(c.Data()))
+}
+
+// PendingStats returns the number of chunks pending in the view, which haven't been committed, and the size of their
+// data. Chunks which were put when they were already persisted aren't pending.
+func (ms *MemoryStoreView) PendingStats() PendingStats {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return PendingStats{Chunks: len(ms.pending), Bytes: ms.pendingBytes}
+}
+
 func (ms *MemoryStoreView) Len() int {
 	ms.mu.RLock()
 	defer ms.mu.RUnlock()
 	return len(ms.pending) + ms.storage.Len()
 }
 
+// Size returns the size of the data of the chunks pending in the view and of the chunks of its storage, counting the
+// chunks as Len does.
+func (ms *MemoryStoreView) Size() uint64 {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.pendingBytes + ms.storage.Size()
+}
+
 func (ms *MemoryStoreView) Rebase(ctx context.Context) error {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
-	ms.rootHash = ms.storage.Root(ctx)
+	if ms.isClosedLocked() {
+		return ErrStoreClosed
+	}
+	root, err := ms.storage.Root(ctx)
+
+	if err != nil {
+		return err
+	}
 
+	ms.rootHash = root
 	return nil
 }
 
 func (ms *MemoryStoreView) Root(ctx context.Context) (hash.Hash, error) {
+	if err := ctx.Err(); err != nil {
+		return hash.Hash{}, err
+	}
+
 	ms.mu.RLock()
 	defer ms.mu.RUnlock()
+	if ms.isClosedLocked() {
+		return hash.Hash{}, ErrStoreClosed
+	}
 	return ms.rootHash, nil
 }
 
 func (ms *MemoryStoreView) Commit(ctx context.Context, current, last hash.Hash) (bool, error) {
+	atomic.AddUint64(&ms.stats.Commits, 1)
+
+	ms.storage.viewsMu.Lock()
+	defer ms.storage.viewsMu.Unlock()
+
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
+	return ms.commitLocked(ctx, current, last)
+}
+
+// commitLocked commits the pending chunks of the view, and removes the view from the views of its storage which
+// CollectGarbage checks if it succeeds. ms.storage.viewsMu and ms.mu must be held.
+func (ms *MemoryStoreView) commitLocked(ctx context.Context, current, last hash.Hash) (bool, error) {
+	if ms.isClosedLocked() {
+		return false, ErrStoreClosed
+	}
 	if last != ms.rootHash {
+		atomic.AddUint64(&ms.stats.FailedCommits, 1)
 		return false, nil
 	}
 
-	success := ms.storage.Update(current, last, ms.pending)
+	success, err := ms.storage.Update(ctx, current, last, ms.pending)
+
+	if err != nil {
+		return false, err
+	}
+
 	if success {
 		ms.pending = nil
+		ms.pendingBytes = 0
+		ms.retained = nil
+		delete(ms.storage.views, ms)
+	} else {
+		atomic.AddUint64(&ms.stats.FailedCommits, 1)
+	}
+
+	root, err := ms.storage.Root(ctx)
+
+	if err != nil {
+		return false, err
 	}
-	ms.rootHash = ms.storage.Root(ctx)
+
+	ms.rootHash = root
 	return success, nil
 }
 
+// Stats returns a MemoryStoreStats holding a copy of the counts of the view's operations, and of the chunks pending
+// in the view and stored in its storage.
 func (ms *MemoryStoreView) Stats() interface{} {
-	return nil
+	return ms.statsSnapshot()
 }
 
+// StatsSummary returns the counts of the view's operations, with a line for each kind of operation, and of its
+// chunks.
 func (ms *MemoryStoreView) StatsSummary() string {
-	return "Unsupported"
+	return ms.statsSnapshot().String()
+}
+
+func (ms *MemoryStoreView) statsSnapshot() MemoryStoreStats {
+	stats := ms.stats.snapshot()
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	stats.PendingChunks, stats.PendingBytes = uint64(len(ms.pending)), ms.pendingBytes
+
+	ms.storage.mu.RLock()
+	defer ms.storage.mu.RUnlock()
+	stats.StoredChunks, stats.StoredBytes = uint64(ms.storage.lenLocked()), ms.storage.size
+
+	return stats
 }
 
+// Close closes the view, after which its methods return ErrStoreClosed, and removes it from the views whose pending
+// chunks are checked by CollectGarbage. The pending chunks of the view are discarded; CloseWithCommit commits them
+// first. Closing a view which has been closed does nothing.
 func (ms *MemoryStoreView) Close() error {
+	ms.storage.viewsMu.Lock()
+	defer ms.storage.viewsMu.Unlock()
+	delete(ms.storage.views, ms)
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.closeLocked()
+
 	return nil
 }
 
-type memoryStoreFactory struct {
+// CloseWithCommit commits the pending chunks of the view as Commit does, and closes the view if the commit succeeds.
+// If it doesn't, the view is left open with its pending chunks, so that it can be rebased and committed again, or
+// closed to discard them.
+func (ms *MemoryStoreView) CloseWithCommit(ctx context.Context, current, last hash.Hash) (bool, error) {
+	atomic.AddUint64(&ms.stats.Commits, 1)
+
+	ms.storage.viewsMu.Lock()
+	defer ms.storage.viewsMu.Unlock()
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	success, err := ms.commitLocked(ctx, current, last)
+
+	if err != nil || !success {
+		return false, err
+	}
+
+	ms.closeLocked()
+	return true, nil
+}
+
+// closeLocked marks the view closed and discards its pending chunks. ms.mu must be held.
+func (ms *MemoryStoreView) closeLocked() {
+	ms.closed = true
+	ms.pending = nil
+	ms.pendingBytes = 0
+	ms.retained = nil
+}
+
+// MemoryStoreFactory vends views of MemoryStorages which are kept by namespace, so that the views created for a
+// namespace share the same chunks and root.
+type MemoryStoreFactory struct {
 	stores map[string]*MemoryStorage
 	mu     *sync.Mutex
 }
 
-func NewMemoryStoreFactory() *memoryStoreFactory {
-	return &memoryStoreFactory{map[string]*MemoryStorage{}, &sync.Mutex{}}
+func NewMemoryStoreFactory() *MemoryStoreFactory {
+	return &MemoryStoreFactory{map[string]*MemoryStorage{}, &sync.Mutex{}}
 }
 
-func (f *memoryStoreFactory) CreateStoreFromCache(ctx context.Context, ns string) ChunkStore {
-	return f.CreateStore(ctx, ns)
+// CreateStoreFromCache returns a view of the storage of the namespace |ns|, or nil if the namespace hasn't been
+// created.
+func (f *MemoryStoreFactory) CreateStoreFromCache(ctx context.Context, ns string) ChunkStore {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.stores == nil {
+		d.Panic("Cannot use MemoryStoreFactory after Shutter().")
+	}
+	if ms, present := f.stores[ns]; present {
+		return ms.NewView()
+	}
+	return nil
 }
 
-func (f *memoryStoreFactory) CreateStore(ctx context.Context, ns string) ChunkStore {
+// CreateStore returns a view of the storage of the namespace |ns|, which is created if it doesn't exist.
+func (f *MemoryStoreFactory) CreateStore(ctx context.Context, ns string) ChunkStore {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
 	if f.stores == nil {
-		d.Panic("Cannot use memoryStoreFactory after Shutter().")
+		d.Panic("Cannot use MemoryStoreFactory after Shutter().")
 	}
 	if ms, present := f.stores[ns]; present {
 		return ms.NewView()
@@ -257,6 +1033,61 @@ func (f *memoryStoreFactory) CreateStore(ctx context.Context, ns string) ChunkSt
 	return f.stores[ns].NewView()
 }
 
-func (f *memoryStoreFactory) Shutter() {
+// ListStores returns the namespaces which have been created, in sorted order.
+func (f *MemoryStoreFactory) ListStores() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	namespaces := make([]string, 0, len(f.stores))
+	for ns := range f.stores {
+		namespaces = append(namespaces, ns)
+	}
+
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// DeleteStore deletes the namespace |ns| and releases its chunks, returning false if it hasn't been created. The
+// Commits of views of the namespace which are still open fail with ErrStoreDeleted, and a later CreateStore for the
+// namespace creates a new, empty storage.
+func (f *MemoryStoreFactory) DeleteStore(ns string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ms, present := f.stores[ns]
+	if !present {
+		return false
+	}
+	delete(f.stores, ns)
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.deleted = true
+	ms.data = nil
+	ms.size = 0
+	return true
+}
+
+// Shutter closes all of the open views of the factory's stores, discarding their pending chunks. The factory can't
+// be used after it's shuttered.
+func (f *MemoryStoreFactory) Shutter() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, ms := range f.stores {
+		atomic.StoreInt32(&ms.shuttered, 1)
+
+		ms.viewsMu.Lock()
+		views := make([]*MemoryStoreView, 0, len(ms.views))
+		for view := range ms.views {
+			views = append(views, view)
+		}
+		ms.viewsMu.Unlock()
+
+		for _, view := range views {
+			_ = view.Close()
+		}
+	}
+
 	f.stores = nil
 }
@@ -0,0 +1,232 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+/*
+  Memory Storage Archive:
+    Magic    // 8 bytes, memArchiveMagic
+    Version  // 4-byte int
+    Root     // 20-byte hash
+    Count    // 8-byte int, the number of chunks
+    Chunk 0
+     ..
+    Chunk Count-1
+
+  Chunk:
+    Hash  // 20-byte hash
+    Len   // 4-byte int
+    Data  // len(Data) == Len
+
+  All of the ints are big endian, and the chunks are in the order of their hashes, so that the archive of a storage
+  is always the same.
+*/
+
+// memArchiveMagic is the first bytes of an archive of a MemoryStorage.
+var memArchiveMagic = [8]byte{'D', 'O', 'L', 'T', 'M', 'E', 'M', 0}
+
+// memArchiveVersion is the version of the format of the archives written by Export.
+const memArchiveVersion uint32 = 1
+
+// memArchiveReadStep is the largest chunk whose data ImportMemoryStorage allocates before reading it.
+const memArchiveReadStep = 1 << 20
+
+// ErrNotMemoryArchive is returned by ImportMemoryStorage when its input doesn't start with the header of an archive.
+var ErrNotMemoryArchive = errors.New("not an archive of a memory storage")
+
+// ErrUnknownArchiveVersion is returned by ImportMemoryStorage for an archive of a version of the format it can't read.
+var ErrUnknownArchiveVersion = errors.New("unknown memory storage archive version")
+
+// ErrArchiveHashMismatch is returned by ImportMemoryStorage when the data of a chunk doesn't hash to its recorded hash.
+var ErrArchiveHashMismatch = errors.New("the data of a chunk of the archive doesn't match its hash")
+
+// Export writes the root and all of the chunks of |ms| to |w|, as an archive which ImportMemoryStorage reads. The
+// chunks in memory are snapshotted first, so |ms| isn't locked while they're written, unless it has spilled chunks to
+// disk, which are read one at a time as they're written while |ms| is locked for reading.
+func (ms *MemoryStorage) Export(w io.Writer) error {
+	ms.mu.RLock()
+	locked := true
+	defer func() {
+		if locked {
+			ms.mu.RUnlock()
+		}
+	}()
+
+	root := ms.rootHash
+	hashes := ms.hashesLocked()
+	sort.Sort(hashes)
+
+	chunkAt := func(i int) (Chunk, error) {
+		c, _, err := ms.getLocked(hashes[i])
+		return c, err
+	}
+
+	if ms.spill == nil || len(ms.spill.spilled) == 0 {
+		chunks := make([]Chunk, len(hashes))
+		for i, h := range hashes {
+			chunks[i] = ms.data[h]
+		}
+
+		chunkAt = func(i int) (Chunk, error) {
+			return chunks[i], nil
+		}
+
+		ms.mu.RUnlock()
+		locked = false
+	}
+
+	bw := bufio.NewWriter(w)
+
+	var header bytes.Buffer
+	header.Write(memArchiveMagic[:])
+	_ = binary.Write(&header, binary.BigEndian, memArchiveVersion)
+	header.Write(root[:])
+	_ = binary.Write(&header, binary.BigEndian, uint64(len(hashes)))
+
+	if _, err := bw.Write(header.Bytes()); err != nil {
+		return err
+	}
+
+	for i := range hashes {
+		c, err := chunkAt(i)
+
+		if err != nil {
+			return err
+		}
+
+		h := c.Hash()
+
+		var size [4]byte
+		binary.BigEndian.PutUint32(size[:], uint32(len(c.Data())))
+
+		if _, err := bw.Write(h[:]); err != nil {
+			return err
+		}
+		if _, err := bw.Write(size[:]); err != nil {
+			return err
+		}
+		if _, err := bw.Write(c.Data()); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// ImportMemoryStorage returns a new MemoryStorage with the root and the chunks of the archive read from |r|, which
+// was written by Export. Every chunk is checked against its recorded hash.
+func ImportMemoryStorage(r io.Reader) (*MemoryStorage, error) {
+	br := bufio.NewReader(r)
+
+	var magic [8]byte
+	if _, err := io.ReadFull(br, magic[:]); err != nil {
+		return nil, archiveReadError(err)
+	}
+
+	if magic != memArchiveMagic {
+		return nil, ErrNotMemoryArchive
+	}
+
+	var version uint32
+	if err := binary.Read(br, binary.BigEndian, &version); err != nil {
+		return nil, archiveReadError(err)
+	}
+
+	if version != memArchiveVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownArchiveVersion, version)
+	}
+
+	var root hash.Hash
+	if _, err := io.ReadFull(br, root[:]); err != nil {
+		return nil, archiveReadError(err)
+	}
+
+	var count uint64
+	if err := binary.Read(br, binary.BigEndian, &count); err != nil {
+		return nil, archiveReadError(err)
+	}
+
+	data := make(map[hash.Hash]Chunk)
+	var total uint64
+	for i := uint64(0); i < count; i++ {
+		var h hash.Hash
+		if _, err := io.ReadFull(br, h[:]); err != nil {
+			return nil, archiveReadError(err)
+		}
+
+		var size uint32
+		if err := binary.Read(br, binary.BigEndian, &size); err != nil {
+			return nil, archiveReadError(err)
+		}
+
+		buf, err := readArchiveChunkData(br, size)
+
+		if err != nil {
+			return nil, archiveReadError(err)
+		}
+
+		c := NewChunk(buf)
+
+		if c.Hash() != h {
+			return nil, fmt.Errorf("%w: %s is recorded as %s", ErrArchiveHashMismatch, c.Hash().String(), h.String())
+		}
+
+		if _, ok := data[h]; !ok {
+			total += uint64(size)
+		}
+		data[h] = c
+	}
+
+	return &MemoryStorage{data: data, rootHash: root, size: total}, nil
+}
+
+// readArchiveChunkData reads the |size| bytes of the data of a chunk from |r|. |size| is read from the archive, so it
+// can't be trusted to allocate the data up front. Past memArchiveReadStep bytes the data is read into a buffer which
+// grows as it's read, so an archive which claims a chunk larger than it is fails without allocating the chunk.
+func readArchiveChunkData(r io.Reader, size uint32) ([]byte, error) {
+	if size <= memArchiveReadStep {
+		buf := make([]byte, size)
+		_, err := io.ReadFull(r, buf)
+		return buf, err
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(memArchiveReadStep)
+	if _, err := io.CopyN(&buf, r, int64(size)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// archiveReadError returns the error of reading an archive which ended early as io.ErrUnexpectedEOF.
+func archiveReadError(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
@@ -0,0 +1,161 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+func roundTrip(t *testing.T, storage *MemoryStorage) (*MemoryStorage, []byte) {
+	var buf bytes.Buffer
+	require.NoError(t, storage.Export(&buf))
+	archive := buf.Bytes()
+
+	imported, err := ImportMemoryStorage(bytes.NewReader(archive))
+	require.NoError(t, err)
+
+	return imported, archive
+}
+
+func TestMemoryStorageArchiveRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("empty", func(t *testing.T) {
+		imported, _ := roundTrip(t, &MemoryStorage{})
+		assert.Equal(t, 0, imported.Len())
+
+		root, err := imported.Root(ctx)
+		require.NoError(t, err)
+		assert.True(t, root.IsEmpty())
+	})
+
+	t.Run("unset root", func(t *testing.T) {
+		storage := &MemoryStorage{}
+		c := NewChunk([]byte("abc"))
+		ok, err := storage.Update(ctx, hash.Hash{}, hash.Hash{}, map[hash.Hash]Chunk{c.Hash(): c})
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		imported, _ := roundTrip(t, storage)
+		root, err := imported.Root(ctx)
+		require.NoError(t, err)
+		assert.True(t, root.IsEmpty())
+
+		got, err := imported.Get(ctx, c.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, c.Data(), got.Data())
+	})
+
+	t.Run("large chunks", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(0))
+		novel := map[hash.Hash]Chunk{}
+		for _, size := range []int{0, 1, 3 << 20, 5 << 20} {
+			data := make([]byte, size)
+			rng.Read(data)
+			c := NewChunk(data)
+			novel[c.Hash()] = c
+		}
+		root := NewChunk([]byte("root"))
+		novel[root.Hash()] = root
+
+		storage := &MemoryStorage{}
+		ok, err := storage.Update(ctx, root.Hash(), hash.Hash{}, novel)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		imported, archive := roundTrip(t, storage)
+		importedRoot, err := imported.Root(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, root.Hash(), importedRoot)
+		require.Equal(t, len(novel), imported.Len())
+		assert.Equal(t, storage.Size(), imported.Size())
+
+		for h, c := range novel {
+			got, err := imported.Get(ctx, h)
+			require.NoError(t, err)
+			assert.True(t, bytes.Equal(c.Data(), got.Data()))
+		}
+
+		// the archive of the imported storage is the same
+		_, again := roundTrip(t, imported)
+		assert.True(t, bytes.Equal(archive, again))
+	})
+}
+
+func TestImportMemoryStorageErrors(t *testing.T) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+	c := NewChunk([]byte("abc"))
+	ok, err := storage.Update(ctx, c.Hash(), hash.Hash{}, map[hash.Hash]Chunk{c.Hash(): c})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	var buf bytes.Buffer
+	require.NoError(t, storage.Export(&buf))
+	archive := buf.Bytes()
+
+	corrupt := func(f func(b []byte)) io.Reader {
+		b := append([]byte(nil), archive...)
+		f(b)
+		return bytes.NewReader(b)
+	}
+
+	_, err = ImportMemoryStorage(corrupt(func(b []byte) { b[0] = 'X' }))
+	assert.True(t, errors.Is(err, ErrNotMemoryArchive))
+
+	_, err = ImportMemoryStorage(corrupt(func(b []byte) { binary.BigEndian.PutUint32(b[8:], 2) }))
+	assert.True(t, errors.Is(err, ErrUnknownArchiveVersion))
+
+	_, err = ImportMemoryStorage(corrupt(func(b []byte) { b[len(b)-1] = 'x' }))
+	assert.True(t, errors.Is(err, ErrArchiveHashMismatch))
+
+	_, err = ImportMemoryStorage(bytes.NewReader(archive[:len(archive)-1]))
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+
+	_, err = ImportMemoryStorage(bytes.NewReader(nil))
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+
+	// the length of the chunk claims far more data than the archive has
+	_, err = ImportMemoryStorage(corrupt(func(b []byte) { binary.BigEndian.PutUint32(b[60:], 0xffffffff) }))
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+}
+
+func TestMemoryStorageArchiveLargeChunk(t *testing.T) {
+	ctx := context.Background()
+	data := make([]byte, 3*memArchiveReadStep+1)
+	rand.Read(data)
+
+	storage := &MemoryStorage{}
+	c := NewChunk(data)
+	ok, err := storage.Update(ctx, c.Hash(), hash.Hash{}, map[hash.Hash]Chunk{c.Hash(): c})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	imported, _ := roundTrip(t, storage)
+	got, err := imported.NewView().Get(ctx, c.Hash())
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(data, got.Data()))
+}
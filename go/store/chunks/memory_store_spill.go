@@ -0,0 +1,168 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// NewSpillingStorage returns a MemoryStorage which keeps at most |memoryBudgetBytes| of chunk data in memory. Once
+// a commit takes it over its budget, the chunks which have been in memory longest are written to files in |dir|,
+// named by their hashes, and are read back from their files, one at a time, when they're needed. |dir| is created
+// if it doesn't exist. Views of the storage behave exactly like views of a MemoryStorage which doesn't spill.
+func NewSpillingStorage(dir string, memoryBudgetBytes uint64) (*MemoryStorage, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	return &MemoryStorage{spill: &chunkSpill{dir: dir, budget: memoryBudgetBytes, spilled: map[hash.Hash]uint64{}}}, nil
+}
+
+// chunkSpill tracks the chunks of a MemoryStorage which have been written to disk, and the order in which the
+// chunks still in memory were added, which is the order they're spilled in. It's guarded by the mutex of its storage.
+type chunkSpill struct {
+	dir    string
+	budget uint64
+
+	// memBytes is the size of the data of the chunks in memory
+	memBytes uint64
+	// order holds the hashes of the chunks in memory, oldest first. Hashes of chunks which have since been collected
+	// as garbage are skipped when they're reached.
+	order hash.HashSlice
+	// spilled maps the hash of each chunk on disk to the size of its data
+	spilled map[hash.Hash]uint64
+}
+
+func (sp *chunkSpill) path(h hash.Hash) string {
+	return filepath.Join(sp.dir, h.String())
+}
+
+// has returns whether the chunk with the hash |h| is on disk.
+func (sp *chunkSpill) has(h hash.Hash) bool {
+	_, ok := sp.spilled[h]
+	return ok
+}
+
+// get reads the chunk with the hash |h| from disk, returning false if it hasn't been spilled.
+func (sp *chunkSpill) get(h hash.Hash) (Chunk, bool, error) {
+	if !sp.has(h) {
+		return EmptyChunk, false, nil
+	}
+
+	data, err := ioutil.ReadFile(sp.path(h))
+
+	if err != nil {
+		return EmptyChunk, false, err
+	}
+
+	return NewChunkWithHash(h, data), true, nil
+}
+
+// add adds |chunks|, which aren't in the storage yet, to |data|, then writes the chunks which have been in memory
+// longest to disk and removes them from |data| until the chunks left in memory are within the budget. Every chunk to
+// be spilled is written before |data| or |sp| are changed, so if one can't be written, the files already written are
+// removed and the error is returned with nothing added or spilled.
+func (sp *chunkSpill) add(data map[hash.Hash]Chunk, chunks []Chunk) error {
+	memBytes := sp.memBytes
+	// the capacity is limited so that appending to order doesn't change the backing array of sp.order
+	order := sp.order[:len(sp.order):len(sp.order)]
+	added := make(map[hash.Hash]Chunk, len(chunks))
+	for _, c := range chunks {
+		memBytes += uint64(len(c.Data()))
+		order = append(order, c.Hash())
+		added[c.Hash()] = c
+	}
+
+	var spilled []Chunk
+	spilling := map[hash.Hash]bool{}
+	for memBytes > sp.budget && len(order) > 0 {
+		h := order[0]
+		order = order[1:]
+
+		c, ok := data[h]
+		if !ok {
+			c, ok = added[h]
+		}
+
+		if !ok || spilling[h] {
+			continue
+		}
+
+		if err := ioutil.WriteFile(sp.path(h), c.Data(), 0644); err != nil {
+			for _, c := range spilled {
+				_ = os.Remove(sp.path(c.Hash()))
+			}
+
+			return err
+		}
+
+		spilled = append(spilled, c)
+		spilling[h] = true
+		memBytes -= uint64(len(c.Data()))
+	}
+
+	for h, c := range added {
+		data[h] = c
+	}
+
+	for _, c := range spilled {
+		sp.spilled[c.Hash()] = uint64(len(c.Data()))
+		delete(data, c.Hash())
+	}
+
+	sp.memBytes = memBytes
+	sp.order = order
+	return nil
+}
+
+// remove deletes the file of the spilled chunk with the hash |h|, returning the size of its data, or 0 if it hasn't
+// been spilled.
+func (sp *chunkSpill) remove(h hash.Hash) (uint64, error) {
+	size, ok := sp.spilled[h]
+
+	if !ok {
+		return 0, nil
+	}
+
+	if err := os.Remove(sp.path(h)); err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	delete(sp.spilled, h)
+	return size, nil
+}
+
+// Close releases the storage. If |removeSpillDir| is true and the storage spills to disk, the directory its chunks
+// were spilled to is deleted, along with everything in it. The storage, and its views, mustn't be used after it's
+// closed.
+func (ms *MemoryStorage) Close(removeSpillDir bool) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.spill == nil || !removeSpillDir {
+		return nil
+	}
+
+	if err := os.RemoveAll(ms.spill.dir); err != nil {
+		return err
+	}
+
+	ms.spill.spilled = map[hash.Hash]uint64{}
+	return nil
+}
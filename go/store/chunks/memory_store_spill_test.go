@@ -0,0 +1,236 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+func spillingStorage(t *testing.T, budget uint64) (*MemoryStorage, string) {
+	dir, err := ioutil.TempDir("", "spill")
+	require.NoError(t, err)
+
+	storage, err := NewSpillingStorage(filepath.Join(dir, "chunks"), budget)
+	require.NoError(t, err)
+
+	return storage, filepath.Join(dir, "chunks")
+}
+
+func spillChunks(n int) []Chunk {
+	chunks := make([]Chunk, n)
+	for i := range chunks {
+		chunks[i] = NewChunk([]byte(fmt.Sprintf("chunk %02d", i)))
+	}
+
+	return chunks
+}
+
+func TestSpillingStorage(t *testing.T) {
+	ctx := context.Background()
+	chunks := spillChunks(10)
+	// each chunk holds 8 bytes, so 3 of them fit in memory
+	storage, dir := spillingStorage(t, 24)
+	defer os.RemoveAll(filepath.Dir(dir))
+
+	view := storage.NewView()
+	for _, c := range chunks[:5] {
+		require.NoError(t, view.Put(ctx, c))
+	}
+	success, err := view.Commit(ctx, chunks[4].Hash(), hash.Hash{})
+	require.NoError(t, err)
+	require.True(t, success)
+
+	for _, c := range chunks[5:] {
+		require.NoError(t, view.Put(ctx, c))
+	}
+	success, err = view.Commit(ctx, chunks[9].Hash(), chunks[4].Hash())
+	require.NoError(t, err)
+	require.True(t, success)
+
+	// the chunks of the first commit are spilled before those of the second
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 7)
+	for _, c := range chunks[:5] {
+		assert.FileExists(t, filepath.Join(dir, c.Hash().String()))
+	}
+	assert.Equal(t, 3, len(storage.data))
+	assert.Equal(t, 10, storage.Len())
+	assert.Equal(t, uint64(80), storage.Size())
+
+	other := storage.NewView()
+	for _, c := range chunks {
+		got, err := other.Get(ctx, c.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, c.Data(), got.Data())
+	}
+
+	all := hash.HashSet{}
+	for _, c := range chunks {
+		all.Insert(c.Hash())
+	}
+	absent, err := other.HasMany(ctx, all)
+	require.NoError(t, err)
+	assert.Empty(t, absent)
+
+	found := hash.HashSet{}
+	require.NoError(t, other.(*MemoryStoreView).GetManyF(ctx, all, func(c *Chunk) {
+		found.Insert(c.Hash())
+	}))
+	assert.Equal(t, all, found)
+
+	// a view which hasn't been rebased can't commit over the new root
+	stale := &MemoryStoreView{storage: storage}
+	success, err = stale.Commit(ctx, chunks[0].Hash(), hash.Hash{})
+	require.NoError(t, err)
+	assert.False(t, success)
+	root, err := storage.Root(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, chunks[9].Hash(), root)
+}
+
+func TestSpillingStorageCollectGarbage(t *testing.T) {
+	ctx := context.Background()
+	chunks := spillChunks(6)
+	storage, dir := spillingStorage(t, 16)
+	defer os.RemoveAll(filepath.Dir(dir))
+
+	novel := map[hash.Hash]Chunk{}
+	for _, c := range chunks {
+		novel[c.Hash()] = c
+	}
+	success, err := storage.Update(ctx, chunks[5].Hash(), hash.Hash{}, novel)
+	require.NoError(t, err)
+	require.True(t, success)
+
+	dropped, reclaimed, err := storage.CollectGarbage(ctx, hash.NewHashSet(chunks[0].Hash()))
+	require.NoError(t, err)
+	assert.Equal(t, 4, dropped)
+	assert.Equal(t, uint64(32), reclaimed)
+	assert.Equal(t, 2, storage.Len())
+	assert.Equal(t, uint64(16), storage.Size())
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	for _, f := range files {
+		assert.Contains(t, []string{chunks[0].Hash().String(), chunks[5].Hash().String()}, f.Name())
+	}
+}
+
+func TestSpillingStorageUpdateSpillFailure(t *testing.T) {
+	ctx := context.Background()
+	chunks := spillChunks(4)
+	// each chunk holds 8 bytes, so only 1 of them fits in memory
+	storage, dir := spillingStorage(t, 8)
+	defer os.RemoveAll(filepath.Dir(dir))
+
+	success, err := storage.Update(ctx, chunks[0].Hash(), hash.Hash{}, map[hash.Hash]Chunk{chunks[0].Hash(): chunks[0]})
+	require.NoError(t, err)
+	require.True(t, success)
+
+	// the new chunks can't be written, so the update fails after spilling the old one
+	for _, c := range chunks[1:] {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, c.Hash().String()), os.ModePerm))
+	}
+
+	novel := map[hash.Hash]Chunk{}
+	for _, c := range chunks[1:] {
+		novel[c.Hash()] = c
+	}
+	_, err = storage.Update(ctx, chunks[3].Hash(), chunks[0].Hash(), novel)
+	require.Error(t, err)
+
+	// nothing was added or spilled, and the root is unchanged
+	root, err := storage.Root(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, chunks[0].Hash(), root)
+	assert.Equal(t, 1, storage.Len())
+	assert.Equal(t, uint64(8), storage.Size())
+	assert.Equal(t, 1, len(storage.data))
+	assert.Empty(t, storage.spill.spilled)
+	assert.NoFileExists(t, filepath.Join(dir, chunks[0].Hash().String()))
+
+	for _, c := range chunks[1:] {
+		require.NoError(t, os.Remove(filepath.Join(dir, c.Hash().String())))
+	}
+
+	success, err = storage.Update(ctx, chunks[3].Hash(), chunks[0].Hash(), novel)
+	require.NoError(t, err)
+	require.True(t, success)
+	assert.Equal(t, 4, storage.Len())
+	assert.Equal(t, uint64(32), storage.Size())
+	assert.Equal(t, 1, len(storage.data))
+
+	view := storage.NewView()
+	for _, c := range chunks {
+		got, err := view.Get(ctx, c.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, c.Data(), got.Data())
+	}
+}
+
+func TestSpillingStorageExport(t *testing.T) {
+	ctx := context.Background()
+	chunks := spillChunks(5)
+	storage, dir := spillingStorage(t, 8)
+	defer os.RemoveAll(filepath.Dir(dir))
+
+	view := storage.NewView()
+	for _, c := range chunks {
+		require.NoError(t, view.Put(ctx, c))
+	}
+	success, err := view.Commit(ctx, chunks[4].Hash(), hash.Hash{})
+	require.NoError(t, err)
+	require.True(t, success)
+
+	var buf bytes.Buffer
+	require.NoError(t, storage.Export(&buf))
+	imported, err := ImportMemoryStorage(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, 5, imported.Len())
+	for _, c := range chunks {
+		got, err := imported.Get(ctx, c.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, c.Data(), got.Data())
+	}
+}
+
+func TestSpillingStorageClose(t *testing.T) {
+	storage, dir := spillingStorage(t, 0)
+	defer os.RemoveAll(filepath.Dir(dir))
+
+	c := NewChunk([]byte("abc"))
+	success, err := storage.Update(context.Background(), c.Hash(), hash.Hash{}, map[hash.Hash]Chunk{c.Hash(): c})
+	require.NoError(t, err)
+	require.True(t, success)
+	assert.FileExists(t, filepath.Join(dir, c.Hash().String()))
+
+	require.NoError(t, storage.Close(false))
+	assert.DirExists(t, dir)
+	require.NoError(t, storage.Close(true))
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err))
+}
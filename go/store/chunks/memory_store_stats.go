@@ -0,0 +1,90 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// MemoryStoreStats counts the operations of a MemoryStoreView, and is what its Stats method returns. The view updates
+// the counts atomically, so counting doesn't add to the contention of its locks.
+type MemoryStoreStats struct {
+	Gets     uint64
+	Hases    uint64
+	Puts     uint64
+	PutManys uint64
+	// GetManys counts the calls of both GetMany and GetManyF
+	GetManys uint64
+	HasManys uint64
+
+	ChunksRead    uint64
+	BytesRead     uint64
+	ChunksWritten uint64
+	BytesWritten  uint64
+
+	Commits uint64
+	// FailedCommits counts the commits which failed because the root of the store wasn't the one expected
+	FailedCommits uint64
+
+	// PendingChunks and PendingBytes are the number of chunks pending in the view and the size of their data, and
+	// StoredChunks and StoredBytes are those of the chunks of its storage, as of when the stats were taken
+	PendingChunks uint64
+	PendingBytes  uint64
+	StoredChunks  uint64
+	StoredBytes   uint64
+}
+
+func (s *MemoryStoreStats) chunkRead(c Chunk) {
+	atomic.AddUint64(&s.ChunksRead, 1)
+	atomic.AddUint64(&s.BytesRead, uint64(len(c.Data())))
+}
+
+func (s *MemoryStoreStats) chunkWritten(c Chunk) {
+	atomic.AddUint64(&s.ChunksWritten, 1)
+	atomic.AddUint64(&s.BytesWritten, uint64(len(c.Data())))
+}
+
+// snapshot returns a copy of the counts.
+func (s *MemoryStoreStats) snapshot() MemoryStoreStats {
+	return MemoryStoreStats{
+		Gets:          atomic.LoadUint64(&s.Gets),
+		Hases:         atomic.LoadUint64(&s.Hases),
+		Puts:          atomic.LoadUint64(&s.Puts),
+		PutManys:      atomic.LoadUint64(&s.PutManys),
+		GetManys:      atomic.LoadUint64(&s.GetManys),
+		HasManys:      atomic.LoadUint64(&s.HasManys),
+		ChunksRead:    atomic.LoadUint64(&s.ChunksRead),
+		BytesRead:     atomic.LoadUint64(&s.BytesRead),
+		ChunksWritten: atomic.LoadUint64(&s.ChunksWritten),
+		BytesWritten:  atomic.LoadUint64(&s.BytesWritten),
+		Commits:       atomic.LoadUint64(&s.Commits),
+		FailedCommits: atomic.LoadUint64(&s.FailedCommits),
+	}
+}
+
+// String returns a summary of the counts, with a line for each kind of operation.
+func (s MemoryStoreStats) String() string {
+	return fmt.Sprintf(`Gets: %d, GetManys: %d, Hases: %d, HasManys: %d
+Puts: %d, PutManys: %d
+Chunks read: %d (%d bytes), chunks written: %d (%d bytes)
+Commits: %d, failed commits: %d
+Chunks pending: %d (%d bytes), chunks stored: %d (%d bytes)`,
+		s.Gets, s.GetManys, s.Hases, s.HasManys,
+		s.Puts, s.PutManys,
+		s.ChunksRead, s.BytesRead, s.ChunksWritten, s.BytesWritten,
+		s.Commits, s.FailedCommits,
+		s.PendingChunks, s.PendingBytes, s.StoredChunks, s.StoredBytes)
+}
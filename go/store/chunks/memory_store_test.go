@@ -22,9 +22,16 @@
 package chunks
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
 )
 
 func TestMemoryStoreTestSuite(t *testing.T) {
@@ -42,3 +49,697 @@ func (suite *MemoryStoreTestSuite) SetupTest() {
 func (suite *MemoryStoreTestSuite) TearDownTest() {
 	suite.Factory.Shutter()
 }
+
+func TestMemoryStorageGet(t *testing.T) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+	c := NewChunk([]byte("abc"))
+	storage.data = map[hash.Hash]Chunk{c.Hash(): c, EmptyChunk.Hash(): EmptyChunk}
+
+	got, err := storage.Get(ctx, c.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, c.Data(), got.Data())
+
+	got, err = storage.Get(ctx, EmptyChunk.Hash())
+	require.NoError(t, err)
+	assert.True(t, got.IsEmpty())
+
+	got, err = storage.Get(ctx, hash.Of([]byte("absent")))
+	assert.True(t, errors.Is(err, ErrChunkNotFound))
+	assert.True(t, got.IsEmpty())
+}
+
+func TestMemoryStoreViewGetManyCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	view := (&MemoryStorage{}).NewView()
+	hashes := hash.HashSet{}
+	for _, c := range benchmarkChunks(100) {
+		require.NoError(t, view.Put(ctx, c))
+		hashes.Insert(c.Hash())
+	}
+
+	// the reader stops after the first chunk, as one which hit an error would
+	foundChunks := make(chan *Chunk)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- view.GetMany(ctx, hashes, foundChunks)
+	}()
+
+	<-foundChunks
+	cancel()
+
+	select {
+	case err := <-errCh:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("GetMany didn't return after its context was canceled")
+	}
+}
+
+func TestMemoryStoreViewHasManyCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	view := (&MemoryStorage{}).NewView()
+	hashes := hash.HashSet{}
+	for _, c := range benchmarkChunks(100) {
+		require.NoError(t, view.Put(ctx, c))
+		hashes.Insert(c.Hash())
+	}
+
+	absent, err := view.HasMany(ctx, hashes)
+	require.NoError(t, err)
+	assert.Empty(t, absent)
+
+	cancel()
+	_, err = view.HasMany(ctx, hashes)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestMemoryStorageHasMany(t *testing.T) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+	view := storage.NewView()
+
+	persisted, pending := NewChunk([]byte("abc")), NewChunk([]byte("def"))
+	require.NoError(t, view.Put(ctx, persisted))
+	_, err := view.Commit(ctx, persisted.Hash(), hash.Hash{})
+	require.NoError(t, err)
+	require.NoError(t, view.Put(ctx, pending))
+
+	absent := hash.Parse("11111111111111111111111111111111")
+	hashes := hash.NewHashSet(persisted.Hash(), pending.Hash(), absent)
+
+	// pending chunks of a view aren't in its storage
+	found, err := storage.HasMany(ctx, hashes)
+	require.NoError(t, err)
+	assert.Equal(t, hash.NewHashSet(pending.Hash(), absent), found)
+
+	found, err = view.HasMany(ctx, hashes)
+	require.NoError(t, err)
+	assert.Equal(t, hash.NewHashSet(absent), found)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = storage.HasMany(canceled, hashes)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func benchmarkChunks(n int) []Chunk {
+	chunks := make([]Chunk, n)
+	for i := range chunks {
+		chunks[i] = NewChunk([]byte{byte(i), byte(i >> 8), byte(i >> 16)})
+	}
+
+	return chunks
+}
+
+func BenchmarkMemoryStoreViewPut(b *testing.B) {
+	ctx := context.Background()
+	chunks := benchmarkChunks(100000)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		view := (&MemoryStorage{}).NewView()
+		for _, c := range chunks {
+			if err := view.Put(ctx, c); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkMemoryStoreViewPutMany(b *testing.B) {
+	ctx := context.Background()
+	chunks := benchmarkChunks(100000)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		view := (&MemoryStorage{}).NewView()
+		if err := view.PutMany(ctx, chunks); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkMemoryStoreViewRewrite puts a dataset which is already persisted, whose chunks aren't kept again as pending
+// chunks of the view.
+func BenchmarkMemoryStoreViewRewrite(b *testing.B) {
+	ctx := context.Background()
+	chunks := benchmarkChunks(100000)
+	storage := &MemoryStorage{}
+	view := storage.NewView()
+	if err := view.PutMany(ctx, chunks); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := view.Commit(ctx, chunks[0].Hash(), hash.Hash{}); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		view := storage.NewView()
+		for _, c := range chunks {
+			if err := view.Put(ctx, c); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkMemoryStoreViewHasMany checks a million hashes, a third of them persisted, a third pending and a third
+// absent, with a Has for each hash and with a single HasMany.
+func BenchmarkMemoryStoreViewHasMany(b *testing.B) {
+	ctx := context.Background()
+	chunks := benchmarkChunks(1000000)
+	view := (&MemoryStorage{}).NewView()
+	hashes := make(hash.HashSet, len(chunks))
+	for i, c := range chunks {
+		hashes.Insert(c.Hash())
+
+		if i%3 == 0 {
+			if err := view.Put(ctx, c); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	if _, err := view.Commit(ctx, chunks[0].Hash(), hash.Hash{}); err != nil {
+		b.Fatal(err)
+	}
+	for i, c := range chunks {
+		if i%3 == 1 {
+			if err := view.Put(ctx, c); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	b.Run("Has", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			absent := hash.HashSet{}
+			for h := range hashes {
+				has, err := view.Has(ctx, h)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if !has {
+					absent.Insert(h)
+				}
+			}
+		}
+	})
+
+	b.Run("HasMany", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := view.HasMany(ctx, hashes); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestMemoryStoreViewPendingStats(t *testing.T) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+	view := storage.NewView().(*MemoryStoreView)
+
+	persisted := NewChunk([]byte("abc"))
+	require.NoError(t, view.Put(ctx, persisted))
+	require.NoError(t, view.Put(ctx, persisted))
+	assert.Equal(t, PendingStats{Chunks: 1, Bytes: 3}, view.PendingStats())
+	success, err := view.Commit(ctx, persisted.Hash(), hash.Hash{})
+	require.NoError(t, err)
+	require.True(t, success)
+	assert.Equal(t, PendingStats{}, view.PendingStats())
+
+	// a chunk which is persisted isn't kept again, but is still reported by Has
+	c := NewChunk([]byte("defg"))
+	require.NoError(t, view.PutMany(ctx, []Chunk{persisted, c}))
+	require.NoError(t, view.Put(ctx, persisted))
+	assert.Equal(t, PendingStats{Chunks: 1, Bytes: 4}, view.PendingStats())
+	ok, err := view.Has(ctx, persisted.Hash())
+	require.NoError(t, err)
+	assert.True(t, ok)
+	absent, err := view.HasMany(ctx, hash.NewHashSet(persisted.Hash(), c.Hash()))
+	require.NoError(t, err)
+	assert.Empty(t, absent)
+
+	require.NoError(t, view.Close())
+	assert.Equal(t, PendingStats{}, view.PendingStats())
+}
+
+func TestMemoryStoreViewPendingLimit(t *testing.T) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+	view := storage.NewViewWithPendingLimit(5).(*MemoryStoreView)
+
+	a, b, c := NewChunk([]byte("abc")), NewChunk([]byte("def")), NewChunk([]byte("ghi"))
+	require.NoError(t, view.Put(ctx, a))
+	require.NoError(t, view.Put(ctx, b))
+	assert.Equal(t, PendingStats{Chunks: 2, Bytes: 6}, view.PendingStats())
+
+	// chunks which would add to the pending chunks are refused, and the ones which wouldn't are skipped
+	assert.True(t, errors.Is(view.Put(ctx, c), ErrPendingBufferFull))
+	assert.True(t, errors.Is(view.PutMany(ctx, []Chunk{a, c}), ErrPendingBufferFull))
+	require.NoError(t, view.Put(ctx, a))
+	assert.Equal(t, PendingStats{Chunks: 2, Bytes: 6}, view.PendingStats())
+	ok, err := view.Has(ctx, c.Hash())
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// committing empties the pending chunks, after which the refused chunks can be put
+	success, err := view.Commit(ctx, a.Hash(), hash.Hash{})
+	require.NoError(t, err)
+	require.True(t, success)
+	require.NoError(t, view.PutMany(ctx, []Chunk{a, b, c}))
+	assert.Equal(t, PendingStats{Chunks: 1, Bytes: 3}, view.PendingStats())
+}
+
+func TestMemoryStoreViewStats(t *testing.T) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+	ms := storage.NewView()
+	assert.Equal(t, MemoryStoreStats{}, ms.Stats())
+
+	abc, de := NewChunk([]byte("abc")), NewChunk([]byte("de"))
+	absent := hash.Of([]byte("absent"))
+	require.NoError(t, ms.Put(ctx, abc))
+	require.NoError(t, ms.PutMany(ctx, []Chunk{de}))
+
+	root, err := ms.Root(ctx)
+	require.NoError(t, err)
+	ok, err := ms.Commit(ctx, abc.Hash(), root)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// a commit from a root which is no longer the root of the store fails
+	ok, err = ms.Commit(ctx, de.Hash(), root)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, err = ms.Get(ctx, abc.Hash())
+	require.NoError(t, err)
+	_, err = ms.Get(ctx, absent)
+	require.Error(t, err)
+	_, err = ms.Has(ctx, absent)
+	require.NoError(t, err)
+	_, err = ms.HasMany(ctx, hash.NewHashSet(abc.Hash(), absent))
+	require.NoError(t, err)
+
+	foundChunks := make(chan *Chunk, 2)
+	require.NoError(t, ms.GetMany(ctx, hash.NewHashSet(abc.Hash(), de.Hash(), absent), foundChunks))
+
+	expected := MemoryStoreStats{
+		Gets:          2,
+		Hases:         1,
+		Puts:          1,
+		PutManys:      1,
+		GetManys:      1,
+		HasManys:      1,
+		ChunksRead:    3,
+		BytesRead:     8,
+		ChunksWritten: 2,
+		BytesWritten:  5,
+		Commits:       2,
+		FailedCommits: 1,
+		StoredChunks:  2,
+		StoredBytes:   5,
+	}
+	assert.Equal(t, expected, ms.Stats())
+	assert.Equal(t, expected.String(), ms.StatsSummary())
+
+	// the stats returned are a copy, which later operations don't change
+	stats := ms.Stats()
+	_, err = ms.Get(ctx, abc.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, expected, stats)
+	assert.Equal(t, uint64(3), ms.Stats().(MemoryStoreStats).Gets)
+
+	// each view of a storage counts its own operations, and shares the chunks of the storage
+	assert.Equal(t, MemoryStoreStats{StoredChunks: 2, StoredBytes: 5}, storage.NewView().Stats())
+}
+
+func TestMemoryStorageSize(t *testing.T) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+	view := storage.NewView().(*MemoryStoreView)
+	abc, de, f := NewChunk([]byte("abc")), NewChunk([]byte("de")), NewChunk([]byte("f"))
+	assert.Equal(t, uint64(0), storage.Size())
+	assert.Equal(t, uint64(0), view.Size())
+
+	// pending chunks are counted by the view, and only count toward the storage once they're committed
+	require.NoError(t, view.Put(ctx, abc))
+	require.NoError(t, view.PutMany(ctx, []Chunk{abc, de}))
+	assert.Equal(t, uint64(0), storage.Size())
+	assert.Equal(t, uint64(5), view.Size())
+
+	root, err := view.Root(ctx)
+	require.NoError(t, err)
+	success, err := view.Commit(ctx, abc.Hash(), root)
+	require.NoError(t, err)
+	require.True(t, success)
+	assert.Equal(t, uint64(5), storage.Size())
+	assert.Equal(t, uint64(5), view.Size())
+
+	stats := view.Stats().(MemoryStoreStats)
+	assert.Equal(t, [4]uint64{0, 0, 2, 5}, [4]uint64{stats.PendingChunks, stats.PendingBytes, stats.StoredChunks, stats.StoredBytes})
+
+	// chunks which are already stored aren't counted again when updates overlap
+	success, err = storage.Update(ctx, de.Hash(), abc.Hash(), map[hash.Hash]Chunk{abc.Hash(): abc, de.Hash(): de, f.Hash(): f})
+	require.NoError(t, err)
+	require.True(t, success)
+	assert.Equal(t, uint64(6), storage.Size())
+	success, err = storage.Update(ctx, f.Hash(), de.Hash(), map[hash.Hash]Chunk{de.Hash(): de, f.Hash(): f})
+	require.NoError(t, err)
+	require.True(t, success)
+	assert.Equal(t, uint64(6), storage.Size())
+	assert.Equal(t, 3, storage.Len())
+
+	// a failed update changes nothing
+	success, err = storage.Update(ctx, abc.Hash(), abc.Hash(), map[hash.Hash]Chunk{hash.Of([]byte("gh")): NewChunk([]byte("gh"))})
+	require.NoError(t, err)
+	require.False(t, success)
+	assert.Equal(t, uint64(6), storage.Size())
+
+	// collecting garbage drops the size of the chunks dropped
+	_, bytesReclaimed, err := storage.CollectGarbage(ctx, hash.NewHashSet(de.Hash()))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), bytesReclaimed)
+	assert.Equal(t, uint64(3), storage.Size())
+
+	require.NoError(t, view.Rebase(ctx))
+	stats = view.Stats().(MemoryStoreStats)
+	assert.Equal(t, [4]uint64{0, 0, 2, 3}, [4]uint64{stats.PendingChunks, stats.PendingBytes, stats.StoredChunks, stats.StoredBytes})
+}
+
+// gcStorage returns a storage whose root is the chunk "root", which also has the chunks "keep" and "garbage".
+func gcStorage(t *testing.T) (storage *MemoryStorage, root, keep, garbage Chunk) {
+	ctx := context.Background()
+	storage = &MemoryStorage{}
+	root, keep, garbage = NewChunk([]byte("root")), NewChunk([]byte("keep")), NewChunk([]byte("garbage"))
+	novel := map[hash.Hash]Chunk{root.Hash(): root, keep.Hash(): keep, garbage.Hash(): garbage}
+	_, err := storage.Update(ctx, root.Hash(), hash.Hash{}, novel)
+	require.NoError(t, err)
+
+	return storage, root, keep, garbage
+}
+
+// referringChunk returns a chunk whose data refers to |c|.
+func referringChunk(c Chunk, name string) Chunk {
+	h := c.Hash()
+	return NewChunk(append([]byte(name), h[:]...))
+}
+
+func TestMemoryStorageCollectGarbage(t *testing.T) {
+	ctx := context.Background()
+	storage, root, keep, garbage := gcStorage(t)
+	view := storage.NewView()
+	defer view.Close()
+
+	dropped, reclaimed, err := storage.CollectGarbage(ctx, hash.NewHashSet(keep.Hash()))
+	require.NoError(t, err)
+	assert.Equal(t, 1, dropped)
+	assert.Equal(t, uint64(len(garbage.Data())), reclaimed)
+
+	absent, err := view.HasMany(ctx, hash.NewHashSet(root.Hash(), keep.Hash(), garbage.Hash()))
+	require.NoError(t, err)
+	assert.Equal(t, hash.NewHashSet(garbage.Hash()), absent)
+
+	// there is nothing left to collect
+	dropped, reclaimed, err = storage.CollectGarbage(ctx, hash.NewHashSet(keep.Hash()))
+	require.NoError(t, err)
+	assert.Equal(t, 0, dropped)
+	assert.Equal(t, uint64(0), reclaimed)
+}
+
+func TestMemoryStorageCollectGarbagePendingReference(t *testing.T) {
+	ctx := context.Background()
+	storage, _, keep, garbage := gcStorage(t)
+	view := storage.NewView()
+	require.NoError(t, view.Put(ctx, referringChunk(garbage, "pending")))
+
+	_, _, err := storage.CollectGarbage(ctx, hash.NewHashSet(keep.Hash()))
+	assert.True(t, errors.Is(err, ErrGCPendingReference))
+	assert.Equal(t, 3, storage.Len())
+
+	// a pending chunk which only refers to chunks which are kept doesn't stop a collection
+	other := storage.NewView()
+	defer other.Close()
+	require.NoError(t, other.Put(ctx, referringChunk(keep, "pending")))
+
+	// the pending chunks of a closed view aren't checked
+	require.NoError(t, view.Close())
+	dropped, _, err := storage.CollectGarbage(ctx, hash.NewHashSet(keep.Hash()))
+	require.NoError(t, err)
+	assert.Equal(t, 1, dropped)
+}
+
+func TestMemoryStorageCollectGarbagePutPersisted(t *testing.T) {
+	ctx := context.Background()
+	storage, root, keep, garbage := gcStorage(t)
+	view := storage.NewView()
+	defer view.Close()
+
+	// garbage is persisted, so putting it doesn't make it pending, but the view relies on it being kept
+	next := referringChunk(garbage, "next root")
+	require.NoError(t, view.Put(ctx, garbage))
+	require.NoError(t, view.PutMany(ctx, []Chunk{keep, next}))
+	assert.Equal(t, PendingStats{Chunks: 1, Bytes: uint64(len(next.Data()))}, view.(*MemoryStoreView).PendingStats())
+
+	dropped, _, err := storage.CollectGarbage(ctx, hash.NewHashSet(keep.Hash()))
+	require.NoError(t, err)
+	assert.Equal(t, 0, dropped)
+
+	success, err := view.Commit(ctx, next.Hash(), root.Hash())
+	require.NoError(t, err)
+	require.True(t, success)
+
+	got, err := view.Get(ctx, garbage.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, garbage.Data(), got.Data())
+
+	// once the view has committed, the chunks it put are only kept if they're in the keep set
+	dropped, _, err = storage.CollectGarbage(ctx, hash.NewHashSet(keep.Hash(), garbage.Hash()))
+	require.NoError(t, err)
+	assert.Equal(t, 1, dropped)
+
+	// a persisted chunk which was put, and refers to a chunk which would be dropped, stops a collection
+	other := storage.NewView()
+	defer other.Close()
+	require.NoError(t, other.Put(ctx, next))
+	_, _, err = storage.CollectGarbage(ctx, hash.NewHashSet(keep.Hash()))
+	assert.True(t, errors.Is(err, ErrGCPendingReference))
+	assert.Equal(t, 3, storage.Len())
+}
+
+func TestMemoryStorageTracksViewsWithChunks(t *testing.T) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+	tracked := func(view ChunkStore) bool {
+		storage.viewsMu.Lock()
+		defer storage.viewsMu.Unlock()
+		_, ok := storage.views[view.(*MemoryStoreView)]
+		return ok
+	}
+
+	// views are only kept by the storage while they have chunks which CollectGarbage must check
+	view := storage.NewView()
+	assert.False(t, tracked(view))
+
+	c := NewChunk([]byte("abc"))
+	require.NoError(t, view.Put(ctx, c))
+	assert.True(t, tracked(view))
+
+	success, err := view.Commit(ctx, c.Hash(), hash.Hash{})
+	require.NoError(t, err)
+	require.True(t, success)
+	assert.False(t, tracked(view))
+
+	// a persisted chunk which is put is retained, so the view is kept until it commits again
+	require.NoError(t, view.PutMany(ctx, []Chunk{c}))
+	assert.True(t, tracked(view))
+	require.NoError(t, view.Close())
+	assert.False(t, tracked(view))
+
+	for i := 0; i < 100; i++ {
+		_, err := storage.NewView().Root(ctx)
+		require.NoError(t, err)
+	}
+	assert.Empty(t, storage.views)
+}
+
+func TestMemoryStorageCollectGarbageConcurrentCommit(t *testing.T) {
+	ctx := context.Background()
+	for i := 0; i < 100; i++ {
+		storage, root, keep, garbage := gcStorage(t)
+		view := storage.NewView()
+		next := referringChunk(keep, "next root")
+		require.NoError(t, view.Put(ctx, next))
+
+		done := make(chan error)
+		go func() {
+			success, err := view.Commit(ctx, next.Hash(), root.Hash())
+			if err == nil && !success {
+				err = errors.New("commit failed")
+			}
+			done <- err
+		}()
+
+		dropped, _, err := storage.CollectGarbage(ctx, hash.NewHashSet(keep.Hash()))
+		require.NoError(t, err)
+		require.NoError(t, <-done)
+
+		// the old root is only kept if the collection ran before the commit
+		absent, err := view.HasMany(ctx, hash.NewHashSet(next.Hash(), keep.Hash(), garbage.Hash(), root.Hash()))
+		require.NoError(t, err)
+		assert.True(t, absent.Has(garbage.Hash()))
+		assert.False(t, absent.Has(next.Hash()))
+		assert.False(t, absent.Has(keep.Hash()))
+		assert.Equal(t, dropped == 2, absent.Has(root.Hash()))
+
+		current, err := storage.Root(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, next.Hash(), current)
+		require.NoError(t, view.Close())
+	}
+}
+
+func TestMemoryStoreFactory(t *testing.T) {
+	ctx := context.Background()
+	factory := NewMemoryStoreFactory()
+	defer factory.Shutter()
+
+	assert.Nil(t, factory.CreateStoreFromCache(ctx, "a"))
+	assert.Empty(t, factory.ListStores())
+
+	a := factory.CreateStore(ctx, "a")
+	factory.CreateStore(ctx, "b")
+	assert.Equal(t, []string{"a", "b"}, factory.ListStores())
+
+	c := NewChunk([]byte("abc"))
+	require.NoError(t, a.Put(ctx, c))
+	success, err := a.Commit(ctx, c.Hash(), hash.Hash{})
+	require.NoError(t, err)
+	require.True(t, success)
+
+	cached := factory.CreateStoreFromCache(ctx, "a")
+	require.NotNil(t, cached)
+	root, err := cached.Root(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, c.Hash(), root)
+
+	assert.True(t, factory.DeleteStore("a"))
+	assert.False(t, factory.DeleteStore("a"))
+	assert.Equal(t, []string{"b"}, factory.ListStores())
+	assert.Nil(t, factory.CreateStoreFromCache(ctx, "a"))
+
+	// the views of the deleted namespace can't commit, so they don't resurrect it
+	for _, view := range []ChunkStore{a, cached} {
+		c2 := NewChunk([]byte("def"))
+		require.NoError(t, view.Put(ctx, c2))
+		success, err = view.Commit(ctx, c2.Hash(), c.Hash())
+		assert.True(t, errors.Is(err, ErrStoreDeleted), "unexpected error %v", err)
+		assert.False(t, success)
+	}
+
+	assert.Equal(t, []string{"b"}, factory.ListStores())
+	recreated := factory.CreateStore(ctx, "a")
+	root, err = recreated.Root(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, hash.Hash{}, root)
+	ok, err := recreated.Has(ctx, c.Hash())
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestMemoryStoreViewClose(t *testing.T) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+	view := storage.NewView().(*MemoryStoreView)
+
+	c := NewChunk([]byte("abc"))
+	require.NoError(t, view.Put(ctx, c))
+	require.NoError(t, view.Close())
+	require.NoError(t, view.Close())
+
+	_, err := view.Get(ctx, c.Hash())
+	assert.True(t, errors.Is(err, ErrStoreClosed), "unexpected error %v", err)
+	_, err = view.Has(ctx, c.Hash())
+	assert.True(t, errors.Is(err, ErrStoreClosed), "unexpected error %v", err)
+	_, err = view.HasMany(ctx, hash.NewHashSet(c.Hash()))
+	assert.True(t, errors.Is(err, ErrStoreClosed), "unexpected error %v", err)
+	err = view.GetMany(ctx, hash.NewHashSet(c.Hash()), make(chan *Chunk, 1))
+	assert.True(t, errors.Is(err, ErrStoreClosed), "unexpected error %v", err)
+	err = view.IterateAllChunks(ctx, func(Chunk) error { return nil })
+	assert.True(t, errors.Is(err, ErrStoreClosed), "unexpected error %v", err)
+	assert.True(t, errors.Is(view.Put(ctx, c), ErrStoreClosed))
+	assert.True(t, errors.Is(view.PutMany(ctx, []Chunk{c}), ErrStoreClosed))
+	assert.True(t, errors.Is(view.Rebase(ctx), ErrStoreClosed))
+	_, err = view.Root(ctx)
+	assert.True(t, errors.Is(err, ErrStoreClosed), "unexpected error %v", err)
+	success, err := view.Commit(ctx, c.Hash(), hash.Hash{})
+	assert.True(t, errors.Is(err, ErrStoreClosed), "unexpected error %v", err)
+	assert.False(t, success)
+
+	// the pending chunk was discarded
+	assert.Equal(t, 0, storage.Len())
+	ok, err := storage.NewView().Has(ctx, c.Hash())
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestMemoryStoreViewCloseWithCommit(t *testing.T) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+	view := storage.NewView().(*MemoryStoreView)
+	other := storage.NewView()
+
+	c := NewChunk([]byte("abc"))
+	require.NoError(t, other.Put(ctx, c))
+	success, err := other.Commit(ctx, c.Hash(), hash.Hash{})
+	require.NoError(t, err)
+	require.True(t, success)
+
+	// a commit which fails leaves the view open with its pending chunks
+	c2 := NewChunk([]byte("def"))
+	require.NoError(t, view.Put(ctx, c2))
+	success, err = view.CloseWithCommit(ctx, c2.Hash(), hash.Hash{})
+	require.NoError(t, err)
+	require.False(t, success)
+	ok, err := view.Has(ctx, c2.Hash())
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.NoError(t, view.Rebase(ctx))
+	success, err = view.CloseWithCommit(ctx, c2.Hash(), c.Hash())
+	require.NoError(t, err)
+	require.True(t, success)
+
+	_, err = view.Root(ctx)
+	assert.True(t, errors.Is(err, ErrStoreClosed), "unexpected error %v", err)
+	root, err := storage.Root(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, c2.Hash(), root)
+	ok, err = storage.Has(ctx, c2.Hash())
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestMemoryStoreFactoryShutter(t *testing.T) {
+	ctx := context.Background()
+	factory := NewMemoryStoreFactory()
+	a := factory.CreateStore(ctx, "a")
+	b := factory.CreateStore(ctx, "b")
+	closed := factory.CreateStore(ctx, "b")
+	require.NoError(t, closed.Close())
+
+	factory.Shutter()
+
+	for _, view := range []ChunkStore{a, b, closed} {
+		_, err := view.Root(ctx)
+		assert.True(t, errors.Is(err, ErrStoreClosed), "unexpected error %v", err)
+		assert.True(t, errors.Is(view.Put(ctx, NewChunk([]byte("abc"))), ErrStoreClosed))
+	}
+}
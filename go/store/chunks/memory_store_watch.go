@@ -0,0 +1,84 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// rootSubscription is a subscription to the changes of the root of a MemoryStorage. Its channel holds the latest root
+// which hasn't been received.
+type rootSubscription struct {
+	ch   chan hash.Hash
+	done chan struct{}
+	once sync.Once
+}
+
+// SubscribeRootChanges returns a channel which receives the new root after every successful Update of the storage,
+// and a func which ends the subscription and closes the channel. The subscription also ends when |ctx| is done. A
+// subscriber which falls behind misses the roots which were replaced before it read them, but it always receives the
+// latest root, and Update never waits for a subscriber.
+func (ms *MemoryStorage) SubscribeRootChanges(ctx context.Context) (<-chan hash.Hash, func()) {
+	sub := &rootSubscription{ch: make(chan hash.Hash, 1), done: make(chan struct{})}
+
+	ms.subsMu.Lock()
+	if ms.subs == nil {
+		ms.subs = make(map[*rootSubscription]struct{})
+	}
+	ms.subs[sub] = struct{}{}
+	ms.subsMu.Unlock()
+
+	cancel := func() {
+		sub.once.Do(func() {
+			// the channel is closed while subsMu is held so that publishRoot never sends on it once it's closed
+			ms.subsMu.Lock()
+			delete(ms.subs, sub)
+			close(sub.ch)
+			ms.subsMu.Unlock()
+
+			close(sub.done)
+		})
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			cancel()
+		case <-sub.done:
+		}
+	}()
+
+	return sub.ch, cancel
+}
+
+// publishRoot sends |root| to each subscription, replacing any root the subscriber hasn't received. ms.mu must be
+// held, so that the roots are sent in the order they were committed.
+func (ms *MemoryStorage) publishRoot(root hash.Hash) {
+	ms.subsMu.Lock()
+	defer ms.subsMu.Unlock()
+
+	for sub := range ms.subs {
+		select {
+		case <-sub.ch:
+		default:
+		}
+
+		// publishRoot is the only sender, so the channel has room once any unreceived root is dropped
+		sub.ch <- root
+	}
+}
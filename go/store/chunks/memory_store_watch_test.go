@@ -0,0 +1,153 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// updateRoot commits a new root holding |data| on top of the root of |storage|.
+func updateRoot(t *testing.T, storage *MemoryStorage, data string) hash.Hash {
+	ctx := context.Background()
+	c := NewChunk([]byte(data))
+	last, err := storage.Root(ctx)
+	require.NoError(t, err)
+	success, err := storage.Update(ctx, c.Hash(), last, map[hash.Hash]Chunk{c.Hash(): c})
+	require.NoError(t, err)
+	require.True(t, success)
+
+	return c.Hash()
+}
+
+func receiveRoot(t *testing.T, ch <-chan hash.Hash) (hash.Hash, bool) {
+	select {
+	case root, ok := <-ch:
+		return root, ok
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "timed out waiting for a root")
+		return hash.Hash{}, false
+	}
+}
+
+func TestSubscribeRootChanges(t *testing.T) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+
+	first, cancelFirst := storage.SubscribeRootChanges(ctx)
+	defer cancelFirst()
+	second, cancelSecond := storage.SubscribeRootChanges(ctx)
+
+	root := updateRoot(t, storage, "one")
+	got, ok := receiveRoot(t, first)
+	assert.True(t, ok)
+	assert.Equal(t, root, got)
+	got, _ = receiveRoot(t, second)
+	assert.Equal(t, root, got)
+
+	// a failed update isn't sent
+	success, err := storage.Update(ctx, hash.Of([]byte("stale")), hash.Hash{}, nil)
+	require.NoError(t, err)
+	require.False(t, success)
+
+	cancelSecond()
+	cancelSecond()
+	_, ok = receiveRoot(t, second)
+	assert.False(t, ok)
+
+	root = updateRoot(t, storage, "two")
+	got, _ = receiveRoot(t, first)
+	assert.Equal(t, root, got)
+}
+
+func TestSubscribeRootChangesSlowSubscriber(t *testing.T) {
+	storage := &MemoryStorage{}
+	ch, cancel := storage.SubscribeRootChanges(context.Background())
+	defer cancel()
+
+	// a subscriber which doesn't read doesn't hold up commits, and receives the latest root once it does
+	var root hash.Hash
+	for i := 0; i < 100; i++ {
+		root = updateRoot(t, storage, fmt.Sprint(i))
+	}
+
+	got, _ := receiveRoot(t, ch)
+	assert.Equal(t, root, got)
+	select {
+	case <-ch:
+		assert.Fail(t, "received a replaced root")
+	default:
+	}
+}
+
+func TestSubscribeRootChangesContextDone(t *testing.T) {
+	storage := &MemoryStorage{}
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	ch, cancel := storage.SubscribeRootChanges(ctx)
+	defer cancel()
+
+	cancelCtx()
+	_, ok := receiveRoot(t, ch)
+	assert.False(t, ok)
+
+	// updates don't send to the ended subscription
+	updateRoot(t, storage, "one")
+	storage.subsMu.Lock()
+	assert.Empty(t, storage.subs)
+	storage.subsMu.Unlock()
+}
+
+func TestSubscribeRootChangesConcurrent(t *testing.T) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+	ch, cancel := storage.SubscribeRootChanges(ctx)
+	defer cancel()
+
+	const writers, commits = 4, 50
+	done := make(chan struct{})
+	for i := 0; i < writers; i++ {
+		go func(i int) {
+			defer func() { done <- struct{}{} }()
+			view := storage.NewView()
+			for j := 0; j < commits; j++ {
+				_, err := CommitWithRetry(ctx, view, 1000, func(upstream hash.Hash) (hash.Hash, error) {
+					c := NewChunk([]byte(fmt.Sprintf("%s %d %d", upstream.String(), i, j)))
+					return c.Hash(), view.Put(ctx, c)
+				})
+				assert.NoError(t, err)
+			}
+		}(i)
+	}
+
+	for i := 0; i < writers; i++ {
+		<-done
+	}
+
+	final, err := storage.Root(ctx)
+	require.NoError(t, err)
+	for {
+		got, _ := receiveRoot(t, ch)
+		if got == final {
+			break
+		}
+	}
+}
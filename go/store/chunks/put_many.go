@@ -0,0 +1,45 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// PutFunc is the signature of ChunkStore.Put.
+type PutFunc func(ctx context.Context, c Chunk) error
+
+// PutManyWithPut implements PutMany with |put| for stores which have no cheaper way of adding a batch of chunks,
+// calling it once for each distinct chunk of |chunks|.
+func PutManyWithPut(ctx context.Context, chunks []Chunk, put PutFunc) error {
+	seen := make(hash.HashSet, len(chunks))
+	for _, c := range chunks {
+		h := c.Hash()
+
+		if seen.Has(h) {
+			continue
+		}
+
+		seen.Insert(h)
+
+		if err := put(ctx, c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+func TestPutManyWithPut(t *testing.T) {
+	a, b := NewChunk([]byte("a")), NewChunk([]byte("b"))
+
+	put := map[hash.Hash]int{}
+	err := PutManyWithPut(context.Background(), []Chunk{a, b, a}, func(ctx context.Context, c Chunk) error {
+		put[c.Hash()]++
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[hash.Hash]int{a.Hash(): 1, b.Hash(): 1}, put)
+
+	// the first error stops the batch
+	testErr := errors.New("test error")
+	calls := 0
+	err = PutManyWithPut(context.Background(), []Chunk{a, b}, func(ctx context.Context, c Chunk) error {
+		calls++
+		return testErr
+	})
+	assert.Equal(t, testErr, err)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,143 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+type readStatsKey struct{}
+
+// ReadStats counts the chunk reads made on behalf of a single operation, such as a query. A *ReadStats is attached to
+// a context with WithReadStats, and the stores which read chunks record their work in the ReadStats of the context
+// they are given. Counts are updated atomically so a ReadStats can be shared by concurrent readers, and every method is
+// a no-op on a nil *ReadStats so that callers don't need to check whether stats are being collected.
+type ReadStats struct {
+	chunksRead   uint64
+	bytesRead    uint64
+	cacheHits    uint64
+	roundTrips   uint64
+	storageNanos int64
+
+	// detailed enables timing of the chunk store, which requires reading the clock around every read
+	detailed bool
+}
+
+// NewReadStats returns a new *ReadStats. If detailed is true the time spent reading from the chunk store is also
+// measured.
+func NewReadStats(detailed bool) *ReadStats {
+	return &ReadStats{detailed: detailed}
+}
+
+// WithReadStats returns a context which records the chunk reads made with it in rs.
+func WithReadStats(ctx context.Context, rs *ReadStats) context.Context {
+	return context.WithValue(ctx, readStatsKey{}, rs)
+}
+
+// GetReadStats returns the *ReadStats attached to ctx, or nil if there is none.
+func GetReadStats(ctx context.Context) *ReadStats {
+	rs, _ := ctx.Value(readStatsKey{}).(*ReadStats)
+	return rs
+}
+
+// ChunkRead records a chunk of the given size being read from a chunk store.
+func (rs *ReadStats) ChunkRead(size int) {
+	if rs == nil {
+		return
+	}
+
+	atomic.AddUint64(&rs.chunksRead, 1)
+	atomic.AddUint64(&rs.bytesRead, uint64(size))
+}
+
+// CacheHit records a chunk being found in a cache instead of being read from a chunk store.
+func (rs *ReadStats) CacheHit() {
+	if rs == nil {
+		return
+	}
+
+	atomic.AddUint64(&rs.cacheHits, 1)
+}
+
+// RoundTrip records a request being made to a remote.
+func (rs *ReadStats) RoundTrip() {
+	if rs == nil {
+		return
+	}
+
+	atomic.AddUint64(&rs.roundTrips, 1)
+}
+
+// StartStorageTimer returns the time at which a read from the chunk store starts, to be passed to StopStorageTimer once
+// the read completes. The zero time is returned unless detailed stats are being collected.
+func (rs *ReadStats) StartStorageTimer() time.Time {
+	if rs == nil || !rs.detailed {
+		return time.Time{}
+	}
+
+	return time.Now()
+}
+
+// StopStorageTimer records the time spent on a read from the chunk store which started at start.
+func (rs *ReadStats) StopStorageTimer(start time.Time) {
+	if rs == nil || start.IsZero() {
+		return
+	}
+
+	atomic.AddInt64(&rs.storageNanos, int64(time.Since(start)))
+}
+
+// Snapshot returns the current counts.
+func (rs *ReadStats) Snapshot() ReadStatsSnapshot {
+	if rs == nil {
+		return ReadStatsSnapshot{}
+	}
+
+	return ReadStatsSnapshot{
+		ChunksRead:  atomic.LoadUint64(&rs.chunksRead),
+		BytesRead:   atomic.LoadUint64(&rs.bytesRead),
+		CacheHits:   atomic.LoadUint64(&rs.cacheHits),
+		RoundTrips:  atomic.LoadUint64(&rs.roundTrips),
+		StorageTime: time.Duration(atomic.LoadInt64(&rs.storageNanos)),
+		Detailed:    rs.detailed,
+	}
+}
+
+// ReadStatsSnapshot holds the counts of a ReadStats at a point in time.
+type ReadStatsSnapshot struct {
+	ChunksRead uint64
+	BytesRead  uint64
+	CacheHits  uint64
+	RoundTrips uint64
+	// StorageTime is only measured when Detailed is true
+	StorageTime time.Duration
+	Detailed    bool
+}
+
+// String returns a one line summary of the counts.
+func (s ReadStatsSnapshot) String() string {
+	str := fmt.Sprintf("chunks read: %d (%s), cache hits: %d, remote round trips: %d", s.ChunksRead, humanize.Bytes(s.BytesRead), s.CacheHits, s.RoundTrips)
+
+	if s.Detailed {
+		str += fmt.Sprintf(", storage time: %v", s.StorageTime)
+	}
+
+	return str
+}
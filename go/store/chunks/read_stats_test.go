@@ -0,0 +1,74 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadStats(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, GetReadStats(ctx))
+
+	rs := NewReadStats(false)
+	ctx = WithReadStats(ctx, rs)
+	assert.Equal(t, rs, GetReadStats(ctx))
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			stats := GetReadStats(ctx)
+			stats.ChunkRead(100)
+			stats.CacheHit()
+			stats.RoundTrip()
+			stats.StopStorageTimer(stats.StartStorageTimer())
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, ReadStatsSnapshot{ChunksRead: 10, BytesRead: 1000, CacheHits: 10, RoundTrips: 10}, rs.Snapshot())
+	assert.Equal(t, "chunks read: 10 (1.0 kB), cache hits: 10, remote round trips: 10", rs.Snapshot().String())
+}
+
+func TestReadStatsDetailed(t *testing.T) {
+	rs := NewReadStats(true)
+
+	start := rs.StartStorageTimer()
+	assert.False(t, start.IsZero())
+	time.Sleep(time.Millisecond)
+	rs.StopStorageTimer(start)
+
+	snapshot := rs.Snapshot()
+	assert.True(t, snapshot.Detailed)
+	assert.True(t, snapshot.StorageTime >= time.Millisecond)
+	assert.Contains(t, snapshot.String(), "storage time: ")
+}
+
+func TestNilReadStats(t *testing.T) {
+	var rs *ReadStats
+	rs.ChunkRead(100)
+	rs.CacheHit()
+	rs.RoundTrip()
+	rs.StopStorageTimer(rs.StartStorageTimer())
+	assert.Equal(t, ReadStatsSnapshot{}, rs.Snapshot())
+}
@@ -0,0 +1,325 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// OpOpen is the Operation of the first entry of a trace, which records the root of the store when it started being
+// recorded.
+const OpOpen Operation = "Open"
+
+// TraceEntry is a call to a RecordingChunkStore, in the form it is written to a trace, as one line of JSON.
+type TraceEntry struct {
+	// Seq is the order in which the call was made. Calls made concurrently are written in the order they return, so
+	// entries may be out of Seq order in a trace.
+	Seq uint64 `json:"seq"`
+
+	// Op is the Operation which was called
+	Op Operation `json:"op"`
+
+	// Hashes are the hashes the call was made with, or the hashes of the chunks which were put
+	Hashes []string `json:"hashes,omitempty"`
+
+	// Found are the hashes of Hashes which a Get, GetMany, Has or HasMany found, sorted
+	Found []string `json:"found,omitempty"`
+
+	// Sizes are the sizes of the chunks which were put, in the order of Hashes, or which were gotten, in the order of
+	// Found
+	Sizes []int `json:"sizes,omitempty"`
+
+	// Data are the chunks in the order of Sizes, if the trace includes them
+	Data [][]byte `json:"data,omitempty"`
+
+	// Root is the root returned by a Root, the current root of a Commit, or the root of the store when it was opened
+	Root string `json:"root,omitempty"`
+
+	// Last is the last root of a Commit
+	Last string `json:"last,omitempty"`
+
+	// Success is whether a Commit succeeded
+	Success bool `json:"success,omitempty"`
+
+	// Err is the error the call returned, if any
+	Err string `json:"err,omitempty"`
+}
+
+// RecordingChunkStore is a ChunkStore which wraps a ChunkStore, and writes a trace of every call made to it to an
+// io.Writer, as one TraceEntry per line of JSON. A trace can be replayed with ReplayTrace. The data of the chunks
+// read and written is left out of the trace unless it was asked for, leaving only their hashes and sizes.
+type RecordingChunkStore struct {
+	cs          ChunkStore
+	includeData bool
+	seq         uint64
+
+	mu       *sync.Mutex
+	enc      *json.Encoder
+	traceErr error
+}
+
+var _ ChunkStore = (*RecordingChunkStore)(nil)
+
+// NewRecordingChunkStore returns a RecordingChunkStore which wraps |cs| and writes its trace to |w|, starting with an
+// OpOpen entry of the current root of |cs|. The data of the chunks is included in the trace if |includeData| is true.
+func NewRecordingChunkStore(ctx context.Context, cs ChunkStore, w io.Writer, includeData bool) (*RecordingChunkStore, error) {
+	rcs := &RecordingChunkStore{cs: cs, includeData: includeData, mu: &sync.Mutex{}, enc: json.NewEncoder(w)}
+	root, err := cs.Root(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rcs.record(TraceEntry{Op: OpOpen, Root: root.String()}, nil)
+
+	if rcs.traceErr != nil {
+		return nil, rcs.traceErr
+	}
+
+	return rcs, nil
+}
+
+// begin returns the entry of a call of |op| which is being made, numbered in the order of the calls.
+func (rcs *RecordingChunkStore) begin(op Operation) TraceEntry {
+	return TraceEntry{Seq: atomic.AddUint64(&rcs.seq, 1), Op: op}
+}
+
+// record writes |e|, which returned |err|, to the trace. The first error writing the trace is kept, and returned by
+// Close, rather than failing the calls to the store.
+func (rcs *RecordingChunkStore) record(e TraceEntry, err error) {
+	if err != nil {
+		e.Err = err.Error()
+	}
+
+	rcs.mu.Lock()
+	defer rcs.mu.Unlock()
+
+	if rcs.traceErr != nil {
+		return
+	}
+
+	rcs.traceErr = rcs.enc.Encode(e)
+}
+
+// addChunks adds the sizes of |chunks|, and their data if the trace includes it, to |e|.
+func (rcs *RecordingChunkStore) addChunks(e *TraceEntry, chunks []Chunk) {
+	for _, c := range chunks {
+		e.Sizes = append(e.Sizes, len(c.Data()))
+
+		if rcs.includeData {
+			e.Data = append(e.Data, c.Data())
+		}
+	}
+}
+
+// addFound adds the chunks of |found| to |e| in the order of their hashes.
+func (rcs *RecordingChunkStore) addFound(e *TraceEntry, found []Chunk) {
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Hash().Less(found[j].Hash())
+	})
+
+	for _, c := range found {
+		e.Found = append(e.Found, c.Hash().String())
+	}
+
+	rcs.addChunks(e, found)
+}
+
+func (rcs *RecordingChunkStore) Get(ctx context.Context, h hash.Hash) (Chunk, error) {
+	e := rcs.begin(OpGet)
+	e.Hashes = []string{h.String()}
+	c, err := rcs.cs.Get(ctx, h)
+
+	// some stores return EmptyChunk, rather than an error, for a missing chunk
+	if err == nil && c.Hash() == h {
+		rcs.addFound(&e, []Chunk{c})
+	}
+
+	rcs.record(e, err)
+
+	return c, err
+}
+
+func (rcs *RecordingChunkStore) GetMany(ctx context.Context, hashes hash.HashSet, foundChunks chan<- *Chunk) error {
+	return GetManyFromF(ctx, hashes, foundChunks, rcs.GetManyF)
+}
+
+// GetManyF is recorded as a GetMany.
+func (rcs *RecordingChunkStore) GetManyF(ctx context.Context, hashes hash.HashSet, found func(*Chunk)) error {
+	e := rcs.begin(OpGetMany)
+	e.Hashes = hashStrings(hashes)
+
+	mu := &sync.Mutex{}
+	var chunks []Chunk
+	err := rcs.cs.GetManyF(ctx, hashes, func(c *Chunk) {
+		mu.Lock()
+		chunks = append(chunks, *c)
+		mu.Unlock()
+
+		found(c)
+	})
+
+	rcs.addFound(&e, chunks)
+	rcs.record(e, err)
+
+	return err
+}
+
+// GetManyCompressed is recorded as a GetMany, with the chunks compressed as they're sent.
+func (rcs *RecordingChunkStore) GetManyCompressed(ctx context.Context, hashes hash.HashSet, foundCmpChunks chan<- CompressedChunk) error {
+	return GetManyCompressedFromF(ctx, hashes, foundCmpChunks, rcs.GetManyF)
+}
+
+func (rcs *RecordingChunkStore) Has(ctx context.Context, h hash.Hash) (bool, error) {
+	e := rcs.begin(OpHas)
+	e.Hashes = []string{h.String()}
+	has, err := rcs.cs.Has(ctx, h)
+
+	if has {
+		e.Found = e.Hashes
+	}
+
+	rcs.record(e, err)
+
+	return has, err
+}
+
+func (rcs *RecordingChunkStore) HasMany(ctx context.Context, hashes hash.HashSet) (absent hash.HashSet, err error) {
+	e := rcs.begin(OpHasMany)
+	e.Hashes = hashStrings(hashes)
+	absent, err = rcs.cs.HasMany(ctx, hashes)
+
+	if err == nil {
+		present := hash.HashSet{}
+		for h := range hashes {
+			if !absent.Has(h) {
+				present.Insert(h)
+			}
+		}
+
+		e.Found = hashStrings(present)
+	}
+
+	rcs.record(e, err)
+
+	return absent, err
+}
+
+func (rcs *RecordingChunkStore) Put(ctx context.Context, c Chunk) error {
+	e := rcs.begin(OpPut)
+	e.Hashes = []string{c.Hash().String()}
+	rcs.addChunks(&e, []Chunk{c})
+
+	err := rcs.cs.Put(ctx, c)
+	rcs.record(e, err)
+
+	return err
+}
+
+func (rcs *RecordingChunkStore) PutMany(ctx context.Context, chunks []Chunk) error {
+	e := rcs.begin(OpPutMany)
+	for _, c := range chunks {
+		e.Hashes = append(e.Hashes, c.Hash().String())
+	}
+	rcs.addChunks(&e, chunks)
+
+	err := rcs.cs.PutMany(ctx, chunks)
+	rcs.record(e, err)
+
+	return err
+}
+
+func (rcs *RecordingChunkStore) Version() string {
+	return rcs.cs.Version()
+}
+
+func (rcs *RecordingChunkStore) Rebase(ctx context.Context) error {
+	e := rcs.begin(OpRebase)
+	err := rcs.cs.Rebase(ctx)
+	rcs.record(e, err)
+
+	return err
+}
+
+func (rcs *RecordingChunkStore) Root(ctx context.Context) (hash.Hash, error) {
+	e := rcs.begin(OpRoot)
+	root, err := rcs.cs.Root(ctx)
+
+	if err == nil {
+		e.Root = root.String()
+	}
+
+	rcs.record(e, err)
+
+	return root, err
+}
+
+func (rcs *RecordingChunkStore) Commit(ctx context.Context, current, last hash.Hash) (bool, error) {
+	e := rcs.begin(OpCommit)
+	e.Root = current.String()
+	e.Last = last.String()
+	success, err := rcs.cs.Commit(ctx, current, last)
+	e.Success = success
+	rcs.record(e, err)
+
+	return success, err
+}
+
+func (rcs *RecordingChunkStore) Stats() interface{} {
+	return rcs.cs.Stats()
+}
+
+func (rcs *RecordingChunkStore) StatsSummary() string {
+	return rcs.cs.StatsSummary()
+}
+
+// Close closes the wrapped store. If it closes without an error, the first error writing the trace, if there was one,
+// is returned. The io.Writer of the trace is left for the caller to close.
+func (rcs *RecordingChunkStore) Close() error {
+	err := rcs.cs.Close()
+
+	if err != nil {
+		return err
+	}
+
+	rcs.mu.Lock()
+	defer rcs.mu.Unlock()
+
+	return rcs.traceErr
+}
+
+// hashStrings returns the sorted strings of |hashes|.
+func hashStrings(hashes hash.HashSet) []string {
+	hs := make(hash.HashSlice, 0, len(hashes))
+	for h := range hashes {
+		hs = append(hs, h)
+	}
+
+	sort.Sort(hs)
+
+	strs := make([]string, len(hs))
+	for i, h := range hs {
+		strs[i] = h.String()
+	}
+
+	return strs
+}
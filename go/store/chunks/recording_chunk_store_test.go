@@ -0,0 +1,189 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// recordCalls makes a sequence of calls to |cs| which covers every operation, including a commit which fails.
+func recordCalls(t *testing.T, cs ChunkStore) hash.Hash {
+	ctx := context.Background()
+	hashes := putChunks(t, cs, "abc", "defg")
+	require.NoError(t, cs.PutMany(ctx, []Chunk{NewChunk([]byte("hi")), NewChunk([]byte("there"))}))
+
+	_, err := cs.Get(ctx, NewChunk([]byte("abc")).Hash())
+	require.NoError(t, err)
+	_, err = cs.Get(ctx, hash.Of([]byte("missing")))
+	assert.True(t, errors.Is(err, ErrChunkNotFound))
+
+	hashes.Insert(hash.Of([]byte("missing")))
+	_, err = getMany(cs, hashes)
+	require.NoError(t, err)
+	_, err = cs.HasMany(ctx, hashes)
+	require.NoError(t, err)
+
+	root, err := cs.Root(ctx)
+	require.NoError(t, err)
+	current := NewChunk([]byte("hi")).Hash()
+	success, err := cs.Commit(ctx, current, hash.Of([]byte("stale")))
+	require.NoError(t, err)
+	assert.False(t, success)
+	success, err = cs.Commit(ctx, current, root)
+	require.NoError(t, err)
+	assert.True(t, success)
+	require.NoError(t, cs.Rebase(ctx))
+
+	has, err := cs.Has(ctx, NewChunk([]byte("defg")).Hash())
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	return current
+}
+
+func TestRecordingChunkStoreReplay(t *testing.T) {
+	ctx := context.Background()
+
+	for _, includeData := range []bool{true, false} {
+		trace := &bytes.Buffer{}
+		rcs, err := NewRecordingChunkStore(ctx, (&MemoryStorage{}).NewView(), trace, includeData)
+		require.NoError(t, err)
+		root := recordCalls(t, rcs)
+		require.NoError(t, rcs.Close())
+
+		entries, err := ReadTrace(bytes.NewReader(trace.Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, OpOpen, entries[0].Op)
+		assert.Equal(t, includeData, strings.Contains(trace.String(), `"data"`))
+
+		for i, e := range entries {
+			assert.Equal(t, uint64(i), e.Seq)
+		}
+
+		storage, err := ReplayTrace(ctx, bytes.NewReader(trace.Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, root, storageRoot(t, storage))
+		assert.Equal(t, 4, storage.Len())
+
+		c, err := storage.Get(ctx, NewChunk([]byte("there")).Hash())
+		require.NoError(t, err)
+		assert.Len(t, c.Data(), 5)
+		assert.Equal(t, includeData, string(c.Data()) == "there")
+	}
+}
+
+func TestRecordingChunkStorePreexistingChunks(t *testing.T) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+	cs := storage.NewView()
+	hashes := putChunks(t, cs, "abc", "def")
+	root := NewChunk([]byte("abc")).Hash()
+	success, err := cs.Commit(ctx, root, hash.Hash{})
+	require.NoError(t, err)
+	require.True(t, success)
+
+	// the chunks and root of the store from before the trace are added to the replayed storage
+	trace := &bytes.Buffer{}
+	rcs, err := NewRecordingChunkStore(ctx, storage.NewView(), trace, false)
+	require.NoError(t, err)
+	got, err := getMany(rcs, hashes)
+	require.NoError(t, err)
+	assert.Equal(t, hashes, got)
+	_, err = rcs.Root(ctx)
+	require.NoError(t, err)
+
+	replayed, err := ReplayTrace(ctx, bytes.NewReader(trace.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, root, storageRoot(t, replayed))
+	assert.Equal(t, 2, replayed.Len())
+}
+
+func TestRecordingChunkStoreErrors(t *testing.T) {
+	ctx := context.Background()
+	fcs := NewFaultChunkStore((&MemoryStorage{}).NewView(), NewFaultSchedule(0, FailNth(OpPut, 1)))
+
+	trace := &bytes.Buffer{}
+	rcs, err := NewRecordingChunkStore(ctx, fcs, trace, false)
+	require.NoError(t, err)
+	assert.Equal(t, ErrInjectedFault, rcs.Put(ctx, NewChunk([]byte("abc"))))
+
+	entries, err := ReadTrace(bytes.NewReader(trace.Bytes()))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, ErrInjectedFault.Error(), entries[1].Err)
+
+	// the failed put isn't replayed
+	storage, err := ReplayTrace(ctx, bytes.NewReader(trace.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, 0, storage.Len())
+}
+
+// replayTampered replays |trace| after changing the first entry of |op| with |tamper|.
+func replayTampered(t *testing.T, trace []byte, op Operation, tamper func(e *TraceEntry)) error {
+	entries, err := ReadTrace(bytes.NewReader(trace))
+	require.NoError(t, err)
+
+	tampered := &bytes.Buffer{}
+	enc := json.NewEncoder(tampered)
+	done := false
+	for _, e := range entries {
+		if e.Op == op && !done {
+			tamper(&e)
+			done = true
+		}
+
+		require.NoError(t, enc.Encode(e))
+	}
+
+	_, err = ReplayTrace(context.Background(), tampered)
+	return err
+}
+
+func TestReplayTraceMismatch(t *testing.T) {
+	ctx := context.Background()
+	trace := &bytes.Buffer{}
+	rcs, err := NewRecordingChunkStore(ctx, (&MemoryStorage{}).NewView(), trace, false)
+	require.NoError(t, err)
+	recordCalls(t, rcs)
+
+	require.NoError(t, replayTampered(t, trace.Bytes(), OpCommit, func(e *TraceEntry) {}))
+
+	// a trace in which the failed commit succeeded doesn't replay
+	err = replayTampered(t, trace.Bytes(), OpCommit, func(e *TraceEntry) {
+		e.Success = true
+	})
+	assert.True(t, errors.Is(err, ErrTraceMismatch))
+
+	// nor does one in which a chunk which was put wasn't found
+	err = replayTampered(t, trace.Bytes(), OpHasMany, func(e *TraceEntry) {
+		e.Found = e.Found[1:]
+	})
+	assert.True(t, errors.Is(err, ErrTraceMismatch))
+
+	err = replayTampered(t, trace.Bytes(), OpRoot, func(e *TraceEntry) {
+		e.Root = hash.Of([]byte("other")).String()
+	})
+	assert.True(t, errors.Is(err, ErrTraceMismatch))
+}
@@ -0,0 +1,385 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// ErrTraceMismatch is returned by ReplayTrace when a replayed call returns something other than the recorded call.
+var ErrTraceMismatch = errors.New("replayed call doesn't match the trace")
+
+// ReadTrace reads the entries of a trace written by a RecordingChunkStore, sorted in the order the calls were made.
+func ReadTrace(r io.Reader) ([]TraceEntry, error) {
+	var entries []TraceEntry
+	dec := json.NewDecoder(r)
+	for {
+		var e TraceEntry
+		err := dec.Decode(&e)
+
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, e)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Seq < entries[j].Seq
+	})
+
+	return entries, nil
+}
+
+// ReplayTrace makes the calls of the trace read from |r| to a view of a new MemoryStorage, one at a time in the order
+// they were made, and returns the storage. An error wrapping ErrTraceMismatch is returned as soon as a call finds
+// different chunks than the recorded one, returns a different root, or a Commit succeeds where the recorded one failed
+// or the other way around.
+//
+// The storage starts with the root the trace was opened with. Chunks which the recorded store found, but which
+// weren't put during the trace, were in it before it was recorded, and are added to the storage before the call is
+// replayed. The chunks of a trace without their data are replayed as chunks of zeroes of their recorded size, under
+// their recorded hashes. Calls which returned an error aren't replayed, as what they did before failing isn't known.
+// Calls made concurrently are replayed in the order they were made in, which may not be the order they were served in.
+func ReplayTrace(ctx context.Context, r io.Reader) (*MemoryStorage, error) {
+	entries, err := ReadTrace(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	storage := &MemoryStorage{}
+	if len(entries) > 0 && entries[0].Op == OpOpen {
+		root, err := parseTraceHash(entries[0].Root)
+
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = storage.Update(ctx, root, hash.Hash{}, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		entries = entries[1:]
+	}
+
+	rp := &replayer{storage: storage, view: storage.NewView(), put: hash.HashSet{}}
+	for _, e := range entries {
+		if e.Err != "" {
+			continue
+		}
+
+		err := rp.replay(ctx, e)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return storage, nil
+}
+
+// replayer replays the entries of a trace to a view of a MemoryStorage.
+type replayer struct {
+	storage *MemoryStorage
+	view    ChunkStore
+
+	// put are the hashes of the chunks which have been put during the replay
+	put hash.HashSet
+}
+
+func (rp *replayer) replay(ctx context.Context, e TraceEntry) error {
+	hashes, err := parseTraceHashes(e.Hashes)
+
+	if err != nil {
+		return err
+	}
+
+	switch e.Op {
+	case OpGet, OpGetMany, OpHas, OpHasMany:
+		err = rp.addPreexisting(ctx, e)
+
+		if err != nil {
+			return err
+		}
+
+		var found hash.HashSet
+		found, err = rp.find(ctx, e.Op, hashes)
+
+		if err != nil {
+			return err
+		}
+
+		if got := hashStrings(found); !stringsEqual(got, e.Found) {
+			return fmt.Errorf("%w: %s %d found %v, but the recorded call found %v", ErrTraceMismatch, e.Op, e.Seq, got, e.Found)
+		}
+
+	case OpPut, OpPutMany:
+		var chunks []Chunk
+		chunks, err = traceChunks(e, e.Hashes)
+
+		if err != nil {
+			return err
+		}
+
+		if e.Op == OpPut && len(chunks) == 1 {
+			err = rp.view.Put(ctx, chunks[0])
+		} else {
+			err = rp.view.PutMany(ctx, chunks)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		for _, c := range chunks {
+			rp.put.Insert(c.Hash())
+		}
+
+	case OpRebase:
+		return rp.view.Rebase(ctx)
+
+	case OpRoot:
+		root, err := rp.view.Root(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		if root.String() != e.Root {
+			return fmt.Errorf("%w: %s %d returned %s, but the recorded call returned %s", ErrTraceMismatch, e.Op, e.Seq, root.String(), e.Root)
+		}
+
+	case OpCommit:
+		current, err := parseTraceHash(e.Root)
+
+		if err != nil {
+			return err
+		}
+
+		last, err := parseTraceHash(e.Last)
+
+		if err != nil {
+			return err
+		}
+
+		success, err := rp.view.Commit(ctx, current, last)
+
+		if err != nil {
+			return err
+		}
+
+		if success != e.Success {
+			return fmt.Errorf("%w: %s %d returned %t, but the recorded call returned %t", ErrTraceMismatch, e.Op, e.Seq, success, e.Success)
+		}
+
+	default:
+		return fmt.Errorf("unknown operation in trace: '%s'", e.Op)
+	}
+
+	return nil
+}
+
+// find makes the call of |op| with |hashes|, and returns the hashes it found.
+func (rp *replayer) find(ctx context.Context, op Operation, hashes []hash.Hash) (hash.HashSet, error) {
+	found := hash.HashSet{}
+	switch op {
+	case OpGet:
+		c, err := rp.view.Get(ctx, hashes[0])
+
+		if err != nil && err != ErrChunkNotFound {
+			return nil, err
+		}
+
+		if err == nil && c.Hash() == hashes[0] {
+			found.Insert(c.Hash())
+		}
+
+	case OpHas:
+		has, err := rp.view.Has(ctx, hashes[0])
+
+		if err != nil {
+			return nil, err
+		}
+
+		if has {
+			found.Insert(hashes[0])
+		}
+
+	case OpGetMany:
+		foundChunks := make(chan *Chunk, len(hashes))
+		err := rp.view.GetMany(ctx, hash.HashSlice(hashes).HashSet(), foundChunks)
+		close(foundChunks)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for c := range foundChunks {
+			found.Insert(c.Hash())
+		}
+
+	case OpHasMany:
+		absent, err := rp.view.HasMany(ctx, hash.HashSlice(hashes).HashSet())
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, h := range hashes {
+			if !absent.Has(h) {
+				found.Insert(h)
+			}
+		}
+	}
+
+	return found, nil
+}
+
+// addPreexisting adds the chunks which the call of |e| found, but which haven't been put during the replay and aren't
+// in the storage, to the storage.
+func (rp *replayer) addPreexisting(ctx context.Context, e TraceEntry) error {
+	found, err := parseTraceHashes(e.Found)
+
+	if err != nil {
+		return err
+	}
+
+	missing := hash.HashSet{}
+	for _, h := range found {
+		if !rp.put.Has(h) {
+			missing.Insert(h)
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	missing, err = rp.view.HasMany(ctx, missing)
+
+	if err != nil || len(missing) == 0 {
+		return err
+	}
+
+	// the chunks found by a Has or HasMany have no recorded sizes
+	var chunks []Chunk
+	if e.Op == OpGet || e.Op == OpGetMany {
+		chunks, err = traceChunks(e, e.Found)
+
+		if err != nil {
+			return err
+		}
+	} else {
+		for h := range missing {
+			chunks = append(chunks, NewChunkWithHash(h, []byte{}))
+		}
+	}
+
+	novel := make(map[hash.Hash]Chunk)
+	for _, c := range chunks {
+		if missing.Has(c.Hash()) {
+			novel[c.Hash()] = c
+		}
+	}
+
+	root, err := rp.storage.Root(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = rp.storage.Update(ctx, root, root, novel)
+
+	return err
+}
+
+// traceChunks returns the chunks of |e| with the hashes |hashes|, in the order of its Sizes and Data.
+func traceChunks(e TraceEntry, hashes []string) ([]Chunk, error) {
+	if len(e.Sizes) != len(hashes) || (len(e.Data) != 0 && len(e.Data) != len(hashes)) {
+		return nil, fmt.Errorf("%s %d has %d hashes, but %d sizes and %d chunks", e.Op, e.Seq, len(hashes), len(e.Sizes), len(e.Data))
+	}
+
+	chunks := make([]Chunk, len(hashes))
+	for i, s := range hashes {
+		h, err := parseTraceHash(s)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(e.Data) != 0 {
+			chunks[i] = NewChunkWithHash(h, e.Data[i])
+		} else {
+			chunks[i] = NewChunkWithHash(h, make([]byte, e.Sizes[i]))
+		}
+	}
+
+	return chunks, nil
+}
+
+func parseTraceHash(s string) (hash.Hash, error) {
+	if s == "" {
+		return hash.Hash{}, nil
+	}
+
+	h, ok := hash.MaybeParse(s)
+
+	if !ok {
+		return hash.Hash{}, fmt.Errorf("invalid hash in trace: '%s'", s)
+	}
+
+	return h, nil
+}
+
+func parseTraceHashes(strs []string) ([]hash.Hash, error) {
+	hashes := make([]hash.Hash, len(strs))
+	for i, s := range strs {
+		h, err := parseTraceHash(s)
+
+		if err != nil {
+			return nil, err
+		}
+
+		hashes[i] = h
+	}
+
+	return hashes, nil
+}
+
+func stringsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -23,6 +23,7 @@ package chunks
 
 import (
 	"context"
+	"errors"
 	"sync/atomic"
 
 	"github.com/stretchr/testify/assert"
@@ -40,7 +41,7 @@ func assertInputInStore(input string, h hash.Hash, s ChunkStore, assert *assert.
 
 func assertInputNotInStore(input string, h hash.Hash, s ChunkStore, assert *assert.Assertions) {
 	chunk, err := s.Get(context.Background(), h)
-	assert.NoError(err)
+	assert.True(errors.Is(err, ErrChunkNotFound), "Should get ErrChunkNotFound for %s, got %v", h.String(), err)
 	assert.True(chunk.IsEmpty(), "Shouldn't get non-empty chunk for %s: %v", h.String(), chunk)
 }
 
@@ -48,8 +49,9 @@ type TestStorage struct {
 	MemoryStorage
 }
 
+// NewView returns a view of the storage which checks the hash of every chunk put to or gotten from it.
 func (t *TestStorage) NewView() *TestStoreView {
-	return &TestStoreView{ChunkStore: t.MemoryStorage.NewView()}
+	return &TestStoreView{ChunkStore: NewValidatingChunkStore(t.MemoryStorage.NewView())}
 }
 
 type TestStoreView struct {
@@ -69,6 +71,16 @@ func (s *TestStoreView) GetMany(ctx context.Context, hashes hash.HashSet, foundC
 	return s.ChunkStore.GetMany(ctx, hashes, foundChunks)
 }
 
+func (s *TestStoreView) GetManyF(ctx context.Context, hashes hash.HashSet, found func(*Chunk)) error {
+	atomic.AddInt32(&s.reads, int32(len(hashes)))
+	return s.ChunkStore.GetManyF(ctx, hashes, found)
+}
+
+func (s *TestStoreView) GetManyCompressed(ctx context.Context, hashes hash.HashSet, foundCmpChunks chan<- CompressedChunk) error {
+	atomic.AddInt32(&s.reads, int32(len(hashes)))
+	return s.ChunkStore.GetManyCompressed(ctx, hashes, foundCmpChunks)
+}
+
 func (s *TestStoreView) Has(ctx context.Context, h hash.Hash) (bool, error) {
 	atomic.AddInt32(&s.hases, 1)
 	return s.ChunkStore.Has(ctx, h)
@@ -84,6 +96,11 @@ func (s *TestStoreView) Put(ctx context.Context, c Chunk) error {
 	return s.ChunkStore.Put(ctx, c)
 }
 
+func (s *TestStoreView) PutMany(ctx context.Context, chunks []Chunk) error {
+	atomic.AddInt32(&s.writes, int32(len(chunks)))
+	return s.ChunkStore.PutMany(ctx, chunks)
+}
+
 func (s *TestStoreView) Reads() int {
 	reads := atomic.LoadInt32(&s.reads)
 	return int(reads)
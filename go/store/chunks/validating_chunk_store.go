@@ -0,0 +1,165 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/liquidata-inc/dolt/go/store/atomicerr"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// ErrCorruptChunk is returned by a ChunkStore made by NewValidatingChunkStore when the data of a chunk which is put or
+// gotten doesn't hash to the hash of the chunk.
+type ErrCorruptChunk struct {
+	// Expected is the hash of the chunk, which is the hash it was put with or the one it was gotten by
+	Expected hash.Hash
+
+	// Actual is the hash of the chunk's data
+	Actual hash.Hash
+}
+
+func (e *ErrCorruptChunk) Error() string {
+	return fmt.Sprintf("corrupt chunk %s: its data hashes to %s", e.Expected.String(), e.Actual.String())
+}
+
+// checkChunk returns an *ErrCorruptChunk if the data of |c| doesn't hash to |expected|.
+func checkChunk(expected hash.Hash, c Chunk) error {
+	if actual := hash.Of(c.Data()); actual != expected {
+		return &ErrCorruptChunk{Expected: expected, Actual: actual}
+	}
+
+	return nil
+}
+
+// validatingChunkStore is a ChunkStore which hashes the data of every chunk put to or gotten from the ChunkStore it
+// wraps, so that a chunk whose data doesn't match its hash is reported where it enters or leaves the store, rather
+// than when a value referencing it can't be read.
+type validatingChunkStore struct {
+	cs ChunkStore
+}
+
+// NewValidatingChunkStore returns a ChunkStore which wraps |cs|, failing with an *ErrCorruptChunk whenever the data of
+// a chunk put to or gotten from |cs| doesn't hash to its hash. A corrupt chunk is never put to |cs|. Hashing the data
+// of every chunk costs about as much as creating the chunks did, which is cheap enough for tests and for checking a
+// store which is suspected to be corrupt.
+func NewValidatingChunkStore(cs ChunkStore) ChunkStore {
+	return validatingChunkStore{cs}
+}
+
+func (vcs validatingChunkStore) Get(ctx context.Context, h hash.Hash) (Chunk, error) {
+	c, err := vcs.cs.Get(ctx, h)
+
+	if ok, _ := isFound(h, c, err); !ok {
+		return c, err
+	}
+
+	if err := checkChunk(h, c); err != nil {
+		return EmptyChunk, err
+	}
+
+	return c, nil
+}
+
+func (vcs validatingChunkStore) GetMany(ctx context.Context, hashes hash.HashSet, foundChunks chan<- *Chunk) error {
+	return vcs.GetManyF(ctx, hashes, func(c *Chunk) {
+		foundChunks <- c
+	})
+}
+
+// GetManyF only calls |found| with the chunks which are valid. Once a corrupt chunk is gotten, the remaining chunks
+// are dropped and the *ErrCorruptChunk is returned.
+func (vcs validatingChunkStore) GetManyF(ctx context.Context, hashes hash.HashSet, found func(*Chunk)) error {
+	ae := atomicerr.New()
+	err := vcs.cs.GetManyF(ctx, hashes, func(c *Chunk) {
+		if ae.IsSet() {
+			return
+		}
+
+		if ae.SetIfError(checkChunk(c.Hash(), *c)) {
+			return
+		}
+
+		found(c)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ae.Get()
+}
+
+// GetManyCompressed compresses the chunks found once they've been checked, so that chunks which are invalid are never
+// sent.
+func (vcs validatingChunkStore) GetManyCompressed(ctx context.Context, hashes hash.HashSet, foundCmpChunks chan<- CompressedChunk) error {
+	return GetManyCompressedFromF(ctx, hashes, foundCmpChunks, vcs.GetManyF)
+}
+
+func (vcs validatingChunkStore) Has(ctx context.Context, h hash.Hash) (bool, error) {
+	return vcs.cs.Has(ctx, h)
+}
+
+func (vcs validatingChunkStore) HasMany(ctx context.Context, hashes hash.HashSet) (absent hash.HashSet, err error) {
+	return vcs.cs.HasMany(ctx, hashes)
+}
+
+func (vcs validatingChunkStore) Put(ctx context.Context, c Chunk) error {
+	if err := checkChunk(c.Hash(), c); err != nil {
+		return err
+	}
+
+	return vcs.cs.Put(ctx, c)
+}
+
+// PutMany puts none of |chunks| if any of them is corrupt.
+func (vcs validatingChunkStore) PutMany(ctx context.Context, chunks []Chunk) error {
+	for _, c := range chunks {
+		if err := checkChunk(c.Hash(), c); err != nil {
+			return err
+		}
+	}
+
+	return vcs.cs.PutMany(ctx, chunks)
+}
+
+func (vcs validatingChunkStore) Version() string {
+	return vcs.cs.Version()
+}
+
+func (vcs validatingChunkStore) Rebase(ctx context.Context) error {
+	return vcs.cs.Rebase(ctx)
+}
+
+func (vcs validatingChunkStore) Root(ctx context.Context) (hash.Hash, error) {
+	return vcs.cs.Root(ctx)
+}
+
+func (vcs validatingChunkStore) Commit(ctx context.Context, current, last hash.Hash) (bool, error) {
+	return vcs.cs.Commit(ctx, current, last)
+}
+
+func (vcs validatingChunkStore) Stats() interface{} {
+	return vcs.cs.Stats()
+}
+
+func (vcs validatingChunkStore) StatsSummary() string {
+	return vcs.cs.StatsSummary()
+}
+
+func (vcs validatingChunkStore) Close() error {
+	return vcs.cs.Close()
+}
@@ -0,0 +1,132 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunks
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// injectCorruptChunk stores the data of |good| under the hash of a chunk with other data, bypassing the validation of
+// any view of |storage|, and returns the hash.
+func injectCorruptChunk(t *testing.T, storage *MemoryStorage, good Chunk) hash.Hash {
+	h := NewChunk([]byte("the real data")).Hash()
+	root := storageRoot(t, storage)
+	ok, err := storage.Update(context.Background(), root, root, map[hash.Hash]Chunk{h: NewChunkWithHash(h, good.Data())})
+	require.NoError(t, err)
+	require.True(t, ok)
+	return h
+}
+
+func requireCorruptChunk(t *testing.T, err error, expected hash.Hash) {
+	var corrupt *ErrCorruptChunk
+	require.True(t, errors.As(err, &corrupt), "expected an ErrCorruptChunk, got %v", err)
+	assert.Equal(t, expected, corrupt.Expected)
+	assert.NotEqual(t, expected, corrupt.Actual)
+}
+
+func TestValidatingChunkStoreGet(t *testing.T) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+	cs := NewValidatingChunkStore(storage.NewView())
+
+	good := putChunks(t, cs, "abc", "def")
+	bad := injectCorruptChunk(t, storage, NewChunk([]byte("not the real data")))
+
+	for h := range good {
+		c, err := cs.Get(ctx, h)
+		require.NoError(t, err)
+		assert.Equal(t, h, c.Hash())
+	}
+
+	_, err := cs.Get(ctx, bad)
+	requireCorruptChunk(t, err, bad)
+
+	// missing chunks aren't corrupt
+	c, err := cs.Get(ctx, hash.Of([]byte("missing")))
+	assert.True(t, errors.Is(err, ErrChunkNotFound))
+	assert.True(t, c.IsEmpty())
+
+	// nor are chunks with no data
+	require.NoError(t, cs.Put(ctx, EmptyChunk))
+	c, err = cs.Get(ctx, EmptyChunk.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, EmptyChunk.Hash(), c.Hash())
+
+	// the chunk is still there, only reads through the wrapper notice
+	has, err := cs.Has(ctx, bad)
+	require.NoError(t, err)
+	assert.True(t, has)
+}
+
+func TestValidatingChunkStoreGetMany(t *testing.T) {
+	storage := &MemoryStorage{}
+	cs := NewValidatingChunkStore(storage.NewView())
+
+	good := putChunks(t, cs, "abc", "def", "ghi")
+	got, err := getMany(cs, good)
+	require.NoError(t, err)
+	assert.Equal(t, good, got)
+
+	bad := injectCorruptChunk(t, storage, NewChunk([]byte("not the real data")))
+	hashes := hash.NewHashSet(bad)
+	for h := range good {
+		hashes.Insert(h)
+	}
+
+	got, err = getMany(cs, hashes)
+	requireCorruptChunk(t, err, bad)
+	assert.False(t, got.Has(bad))
+}
+
+func TestValidatingChunkStorePut(t *testing.T) {
+	ctx := context.Background()
+	storage := &MemoryStorage{}
+	view := storage.NewView()
+	cs := NewValidatingChunkStore(view)
+
+	h := NewChunk([]byte("the real data")).Hash()
+	corrupt := NewChunkWithHash(h, []byte("not the real data"))
+
+	err := cs.Put(ctx, corrupt)
+	requireCorruptChunk(t, err, h)
+
+	good := NewChunk([]byte("abc"))
+	err = cs.PutMany(ctx, []Chunk{good, corrupt})
+	requireCorruptChunk(t, err, h)
+
+	// neither put reached the store
+	absent, err := view.HasMany(ctx, hash.NewHashSet(h, good.Hash()))
+	require.NoError(t, err)
+	assert.Equal(t, hash.NewHashSet(h, good.Hash()), absent)
+
+	// the rest of the store is passed through
+	require.NoError(t, cs.Put(ctx, good))
+	root, err := cs.Root(ctx)
+	require.NoError(t, err)
+	ok, err := cs.Commit(ctx, good.Hash(), root)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, good.Hash(), storageRoot(t, storage))
+	require.NoError(t, cs.Rebase(ctx))
+	assert.Equal(t, view.Version(), cs.Version())
+	assert.Equal(t, view.StatsSummary(), cs.StatsSummary())
+}
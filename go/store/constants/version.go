@@ -41,3 +41,6 @@ const Format718String = "7.18"
 const FormatLD1String = "__LD_1__"
 
 var FormatDefaultString = FormatLD1String
+
+// SupportedFormatStrings lists the format versions which can be read and written, from oldest to newest.
+var SupportedFormatStrings = []string{Format718String, FormatLD1String}
@@ -33,12 +33,16 @@ import (
 
 const (
 	ParentsField = "parents"
-	ValueField   = "value"
-	MetaField    = "meta"
-	commitName   = "Commit"
+	// ParentsListField is the optional field holding the parents of a commit in order. It's only written on commits
+	// with more than one parent, whose order is lost in the parents set.
+	ParentsListField = "parents_list"
+	ValueField       = "value"
+	MetaField        = "meta"
+	commitName       = "Commit"
 )
 
 var commitTemplate = types.MakeStructTemplate(commitName, []string{MetaField, ParentsField, ValueField})
+var commitWithParentsListTemplate = types.MakeStructTemplate(commitName, []string{MetaField, ParentsField, ParentsListField, ValueField})
 
 var valueCommitType = nomdl.MustParseType(`Struct Commit {
         meta: Struct {},
@@ -62,6 +66,29 @@ func NewCommit(value types.Value, parents types.Set, meta types.Struct) (types.S
 	return commitTemplate.NewStruct(meta.Format(), []types.Value{meta, parents, value})
 }
 
+// NewCommitWithParentsList creates a new commit object which also records the order of its parents. |parentsList|
+// holds the same refs as |parents|, in order, and is stored in the field parents_list:
+//
+// ```
+// struct Commit {
+//   meta: M,
+//   parents: Set<Ref<Cycle<Commit>>>,
+//   parents_list: List<Ref<Cycle<Commit>>>,
+//   value: T,
+// }
+// ```
+func NewCommitWithParentsList(value types.Value, parents types.Set, parentsList types.List, meta types.Struct) (types.Struct, error) {
+	return commitWithParentsListTemplate.NewStruct(meta.Format(), []types.Value{meta, parents, parentsList, value})
+}
+
+func newCommitFromOptions(value types.Value, parents types.Set, opts CommitOptions, meta types.Struct) (types.Struct, error) {
+	if (opts.ParentsList != types.List{}) && opts.ParentsList.Len() > 0 {
+		return NewCommitWithParentsList(value, parents, opts.ParentsList, meta)
+	}
+
+	return NewCommit(value, parents, meta)
+}
+
 // FindCommonAncestor returns the most recent common ancestor of c1 and c2, if
 // one exists, setting ok to true. If there is no common ancestor, ok is set
 // to false.
@@ -32,6 +32,12 @@ type CommitOptions struct {
 	// creating.
 	Parents types.Set
 
+	// ParentsList, if provided, is the same parent commits as Parents, in
+	// order. Sets are ordered by hash, so a commit with more than one parent
+	// needs the list to know which of them was the head it was committed on
+	// top of. It is stored on the commit as parents_list.
+	ParentsList types.List
+
 	// Meta is a Struct that describes arbitrary metadata about this Commit,
 	// e.g. a timestamp or descriptive text.
 	Meta types.Struct
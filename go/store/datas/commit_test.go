@@ -210,6 +210,49 @@ func TestCommitWithoutMetaField(t *testing.T) {
 	assert.False(IsCommit(noMetaCommit))
 }
 
+func TestCommitWithParentsList(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	storage := &chunks.TestStorage{}
+	db := NewDatabase(storage.NewView())
+	defer db.Close()
+
+	ds1, err := db.GetDataset(ctx, "ds1")
+	assert.NoError(err)
+	ds1, err = db.CommitValue(ctx, ds1, types.Float(1))
+	assert.NoError(err)
+	ds2, err := db.GetDataset(ctx, "ds2")
+	assert.NoError(err)
+	ds2, err = db.CommitValue(ctx, ds2, types.Float(2))
+	assert.NoError(err)
+
+	// the list holds the parents with ds2 first, whatever the order of the set is
+	head1, head2 := mustHeadRef(ds1), mustHeadRef(ds2)
+	parents := mustSet(types.NewSet(ctx, db, head1, head2))
+	parentsList, err := types.NewList(ctx, db, head2, head1)
+	assert.NoError(err)
+
+	ds2, err = db.Commit(ctx, ds2, types.Float(3), CommitOptions{Parents: parents, ParentsList: parentsList})
+	assert.NoError(err)
+	merge := mustHead(ds2)
+	assert.True(IsCommit(merge))
+
+	listVal, ok, err := merge.MaybeGet(ParentsListField)
+	assert.NoError(err)
+	assert.True(ok)
+	first, err := listVal.(types.List).Get(ctx, 0)
+	assert.NoError(err)
+	assert.True(first.Equals(head2))
+
+	// commits without the list can still be committed on top of commits with it
+	ds2, err = db.CommitValue(ctx, ds2, types.Float(4))
+	assert.NoError(err)
+	_, ok, err = mustHead(ds2).MaybeGet(ParentsListField)
+	assert.NoError(err)
+	assert.False(ok)
+}
+
 // Convert list of Struct's to Set<Ref>
 func toRefSet(vrw types.ValueReadWriter, commits ...types.Struct) (types.Set, error) {
 	s, err := types.NewSet(context.Background(), vrw)
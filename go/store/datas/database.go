@@ -149,6 +149,11 @@ func NewDatabase(cs chunks.ChunkStore) Database {
 	return newDatabase(cs)
 }
 
+// ChunkStoreFromDatabase returns the ChunkStore which backs |db|.
+func ChunkStoreFromDatabase(db Database) chunks.ChunkStore {
+	return db.chunkStore()
+}
+
 // CanUsePuller returns true if a datas.Puller can be used to pull data from one Database into another.  Not all
 // Databases support this yet.
 func CanUsePuller(db Database) bool {
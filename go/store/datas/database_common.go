@@ -257,7 +257,7 @@ func (db *database) CommitDangling(ctx context.Context, v types.Value, opts Comm
 	if opts.Meta.IsZeroValue() {
 		opts.Meta = types.EmptyStruct(db.Format())
 	}
-	commitStruct, err := NewCommit(v, opts.Parents, opts.Meta)
+	commitStruct, err := newCommitFromOptions(v, opts.Parents, opts, opts.Meta)
 
 	if err != nil {
 		return types.Struct{}, err
@@ -552,7 +552,7 @@ func buildNewCommit(ctx context.Context, ds Dataset, v types.Value, opts CommitO
 	if meta.IsZeroValue() {
 		meta = types.EmptyStruct(ds.Database().Format())
 	}
-	return NewCommit(v, parents, meta)
+	return newCommitFromOptions(v, parents, opts, meta)
 }
 
 func (db *database) doHeadUpdate(ctx context.Context, ds Dataset, updateFunc func(ds Dataset) error) (Dataset, error) {
@@ -0,0 +1,407 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datas
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// hashRecordLen is the size of a hashRecord in a run file: the hash, followed by a byte which is 1 for a leaf.
+const hashRecordLen = hash.ByteLen + 1
+
+// maxOpenRuns is the number of run files a hashRunSet keeps before merging them into one, which bounds the number of
+// files read at once by its iterators.
+const maxOpenRuns = 16
+
+// hashRecord is a hash found by the walk of a Puller, and whether the chunk it addresses is a leaf, whose refs don't
+// need to be read.
+type hashRecord struct {
+	h    hash.Hash
+	leaf bool
+}
+
+// hashRunSet is a set of hashRecords which holds at most |maxInMemory| of them in memory. Once it has that many it
+// sorts them and writes them to a run file in |tempDir|, so the memory it uses doesn't depend on how many hashes are
+// added to it. Its records are read back in hash order, without duplicates, by merging its runs.
+type hashRunSet struct {
+	tempDir     string
+	maxInMemory int
+	mem         map[hash.Hash]bool
+	runs        []string
+}
+
+func newHashRunSet(tempDir string, maxInMemory int) *hashRunSet {
+	if maxInMemory < 1 {
+		maxInMemory = 1
+	}
+
+	return &hashRunSet{tempDir: tempDir, maxInMemory: maxInMemory, mem: make(map[hash.Hash]bool)}
+}
+
+func (s *hashRunSet) insert(h hash.Hash, leaf bool) error {
+	s.mem[h] = leaf
+
+	if len(s.mem) >= s.maxInMemory {
+		return s.spill()
+	}
+
+	return nil
+}
+
+func (s *hashRunSet) empty() bool {
+	return len(s.mem) == 0 && len(s.runs) == 0
+}
+
+// spilled returns the number of run files the set has written which haven't been merged or removed.
+func (s *hashRunSet) spilled() int {
+	return len(s.runs)
+}
+
+// spill writes the records in memory to a run file, merging the runs into one if there are more than maxOpenRuns.
+func (s *hashRunSet) spill() error {
+	path, err := writeRun(s.tempDir, &sliceSource{recs: sortedRecords(s.mem)})
+
+	if err != nil {
+		return err
+	}
+
+	s.runs = append(s.runs, path)
+	s.mem = make(map[hash.Hash]bool)
+
+	if len(s.runs) <= maxOpenRuns {
+		return nil
+	}
+
+	itr, err := s.iter()
+
+	if err != nil {
+		return err
+	}
+
+	path, err = writeRun(s.tempDir, itr)
+	closeErr := itr.close()
+
+	if err != nil {
+		return err
+	} else if closeErr != nil {
+		return closeErr
+	}
+
+	for _, run := range s.runs {
+		if err := os.Remove(run); err != nil {
+			return err
+		}
+	}
+
+	s.runs = []string{path}
+	return nil
+}
+
+// iter returns a recordSource of the records of the set in hash order, without duplicates. The set mustn't be changed
+// until the source is closed.
+func (s *hashRunSet) iter() (recordSource, error) {
+	sources := []recordSource{&sliceSource{recs: sortedRecords(s.mem)}}
+	for _, run := range s.runs {
+		rd, err := openRun(run)
+
+		if err != nil {
+			_ = (&mergeSource{sources: sources}).close()
+			return nil, err
+		}
+
+		sources = append(sources, rd)
+	}
+
+	m, err := newMergeSource(sources)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// close removes the run files of the set, which is empty afterwards.
+func (s *hashRunSet) close() error {
+	var firstErr error
+	for _, run := range s.runs {
+		if err := os.Remove(run); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	s.runs = nil
+	s.mem = make(map[hash.Hash]bool)
+	return firstErr
+}
+
+func sortedRecords(mem map[hash.Hash]bool) []hashRecord {
+	recs := make([]hashRecord, 0, len(mem))
+	for h, leaf := range mem {
+		recs = append(recs, hashRecord{h, leaf})
+	}
+
+	sort.Slice(recs, func(i, j int) bool {
+		return recs[i].h.Less(recs[j].h)
+	})
+
+	return recs
+}
+
+// recordSource is a sequence of hashRecords. next returns false once there are no more.
+type recordSource interface {
+	next() (hashRecord, bool, error)
+	close() error
+}
+
+type sliceSource struct {
+	recs []hashRecord
+}
+
+func (s *sliceSource) next() (hashRecord, bool, error) {
+	if len(s.recs) == 0 {
+		return hashRecord{}, false, nil
+	}
+
+	rec := s.recs[0]
+	s.recs = s.recs[1:]
+	return rec, true, nil
+}
+
+func (s *sliceSource) close() error {
+	return nil
+}
+
+// writeRun writes the records of |src| to a new run file in |tempDir|, and returns its path.
+func writeRun(tempDir string, src recordSource) (path string, err error) {
+	f, err := ioutil.TempFile(tempDir, "hashes-*.run")
+
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		closeErr := f.Close()
+
+		if err == nil {
+			err = closeErr
+		}
+
+		if err != nil {
+			_ = os.Remove(f.Name())
+		}
+	}()
+
+	wr := bufio.NewWriter(f)
+	var buf [hashRecordLen]byte
+	for {
+		rec, ok, err := src.next()
+
+		if err != nil {
+			return "", err
+		} else if !ok {
+			break
+		}
+
+		copy(buf[:], rec.h[:])
+		buf[hash.ByteLen] = 0
+		if rec.leaf {
+			buf[hash.ByteLen] = 1
+		}
+
+		if _, err := wr.Write(buf[:]); err != nil {
+			return "", err
+		}
+	}
+
+	if err := wr.Flush(); err != nil {
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+// runReader is a recordSource of the records of a run file.
+type runReader struct {
+	f  *os.File
+	rd *bufio.Reader
+}
+
+func openRun(path string) (*runReader, error) {
+	f, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &runReader{f: f, rd: bufio.NewReader(f)}, nil
+}
+
+func (r *runReader) next() (hashRecord, bool, error) {
+	var buf [hashRecordLen]byte
+	_, err := io.ReadFull(r.rd, buf[:])
+
+	if err == io.EOF {
+		return hashRecord{}, false, nil
+	} else if err != nil {
+		return hashRecord{}, false, err
+	}
+
+	var rec hashRecord
+	copy(rec.h[:], buf[:hash.ByteLen])
+	rec.leaf = buf[hash.ByteLen] == 1
+	return rec, true, nil
+}
+
+func (r *runReader) close() error {
+	return r.f.Close()
+}
+
+// mergeSource merges sorted recordSources into one sorted recordSource, without duplicates.
+type mergeSource struct {
+	sources []recordSource
+	heads   []hashRecord
+	ok      []bool
+}
+
+func newMergeSource(sources []recordSource) (*mergeSource, error) {
+	m := &mergeSource{sources: sources, heads: make([]hashRecord, len(sources)), ok: make([]bool, len(sources))}
+	for i := range sources {
+		if err := m.advance(i); err != nil {
+			_ = m.close()
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+func (m *mergeSource) advance(i int) error {
+	var err error
+	m.heads[i], m.ok[i], err = m.sources[i].next()
+	return err
+}
+
+func (m *mergeSource) next() (hashRecord, bool, error) {
+	min := -1
+	for i := range m.sources {
+		if m.ok[i] && (min == -1 || m.heads[i].h.Less(m.heads[min].h)) {
+			min = i
+		}
+	}
+
+	if min == -1 {
+		return hashRecord{}, false, nil
+	}
+
+	rec := m.heads[min]
+	for i := range m.sources {
+		if m.ok[i] && m.heads[i].h == rec.h {
+			if err := m.advance(i); err != nil {
+				return hashRecord{}, false, err
+			}
+		}
+	}
+
+	return rec, true, nil
+}
+
+func (m *mergeSource) close() error {
+	var firstErr error
+	for _, src := range m.sources {
+		if err := src.close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// subtractSource is a recordSource of the records of the sorted source |src| whose hashes aren't in the sorted source
+// |exclude|.
+type subtractSource struct {
+	src       recordSource
+	exclude   recordSource
+	excl      hashRecord
+	exclOk    bool
+	exclStart bool
+}
+
+func (s *subtractSource) next() (hashRecord, bool, error) {
+	if !s.exclStart {
+		s.exclStart = true
+		if err := s.advanceExclude(); err != nil {
+			return hashRecord{}, false, err
+		}
+	}
+
+	for {
+		rec, ok, err := s.src.next()
+
+		if err != nil || !ok {
+			return hashRecord{}, false, err
+		}
+
+		for s.exclOk && s.excl.h.Less(rec.h) {
+			if err := s.advanceExclude(); err != nil {
+				return hashRecord{}, false, err
+			}
+		}
+
+		if !s.exclOk || s.excl.h != rec.h {
+			return rec, true, nil
+		}
+	}
+}
+
+func (s *subtractSource) advanceExclude() error {
+	var err error
+	s.excl, s.exclOk, err = s.exclude.next()
+	return err
+}
+
+func (s *subtractSource) close() error {
+	err := s.src.close()
+
+	if exclErr := s.exclude.close(); err == nil {
+		err = exclErr
+	}
+
+	return err
+}
+
+// readBatch reads up to |n| records from |src|.
+func readBatch(src recordSource, n int) ([]hashRecord, error) {
+	var batch []hashRecord
+	for len(batch) < n {
+		rec, ok, err := src.next()
+
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			break
+		}
+
+		batch = append(batch, rec)
+	}
+
+	return batch, nil
+}
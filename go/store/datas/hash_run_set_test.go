@@ -0,0 +1,168 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datas
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+func testRecords(n int) []hashRecord {
+	recs := make([]hashRecord, n)
+	for i := range recs {
+		var data [8]byte
+		binary.BigEndian.PutUint64(data[:], uint64(i))
+		recs[i] = hashRecord{h: hash.Of(data[:]), leaf: i%3 == 0}
+	}
+
+	return recs
+}
+
+func readAll(t *testing.T, src recordSource) []hashRecord {
+	defer func() {
+		require.NoError(t, src.close())
+	}()
+
+	var recs []hashRecord
+	for {
+		rec, ok, err := src.next()
+		require.NoError(t, err)
+
+		if !ok {
+			return recs
+		}
+
+		recs = append(recs, rec)
+	}
+}
+
+func sorted(recs []hashRecord) []hashRecord {
+	sortedRecs := append([]hashRecord(nil), recs...)
+	sort.Slice(sortedRecs, func(i, j int) bool {
+		return sortedRecs[i].h.Less(sortedRecs[j].h)
+	})
+
+	return sortedRecs
+}
+
+func runFiles(t *testing.T, dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "hashes-*.run"))
+	require.NoError(t, err)
+	return files
+}
+
+func TestHashRunSet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hash_run_set")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name        string
+		maxInMemory int
+		spilled     int
+	}{
+		{"in memory", 1000, 0},
+		{"spilled", 100, 10},
+		{"merged", 10, 4},
+	}
+
+	recs := testRecords(500)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newHashRunSet(dir, test.maxInMemory)
+			assert.True(t, s.empty())
+
+			// every record is inserted twice, and read back once
+			for i := 0; i < 2; i++ {
+				for _, rec := range recs {
+					require.NoError(t, s.insert(rec.h, rec.leaf))
+				}
+			}
+
+			assert.False(t, s.empty())
+			assert.Equal(t, test.spilled, s.spilled())
+			assert.True(t, s.spilled() <= maxOpenRuns)
+			assert.Len(t, runFiles(t, dir), s.spilled())
+
+			itr, err := s.iter()
+			require.NoError(t, err)
+			assert.Equal(t, sorted(recs), readAll(t, itr))
+
+			require.NoError(t, s.close())
+			assert.True(t, s.empty())
+			assert.Empty(t, runFiles(t, dir))
+		})
+	}
+}
+
+func TestSubtractSource(t *testing.T) {
+	recs := sorted(testRecords(100))
+
+	var src, exclude, expected []hashRecord
+	for i, rec := range recs {
+		if i%2 == 0 {
+			exclude = append(exclude, rec)
+		} else {
+			expected = append(expected, rec)
+		}
+
+		if i%4 != 1 {
+			src = append(src, rec)
+		}
+	}
+
+	var remaining []hashRecord
+	for _, rec := range expected {
+		for _, srcRec := range src {
+			if srcRec == rec {
+				remaining = append(remaining, rec)
+			}
+		}
+	}
+
+	itr := &subtractSource{src: &sliceSource{recs: src}, exclude: &sliceSource{recs: exclude}}
+	assert.Equal(t, remaining, readAll(t, itr))
+
+	itr = &subtractSource{src: &sliceSource{recs: recs}, exclude: &sliceSource{}}
+	assert.Equal(t, recs, readAll(t, itr))
+}
+
+func TestReadBatch(t *testing.T) {
+	recs := testRecords(25)
+	src := &sliceSource{recs: recs}
+
+	var batches [][]hashRecord
+	for {
+		batch, err := readBatch(src, 10)
+		require.NoError(t, err)
+
+		if len(batch) == 0 {
+			break
+		}
+
+		batches = append(batches, batch)
+	}
+
+	assert.Equal(t, [][]hashRecord{recs[:10], recs[10:20], recs[20:]}, batches)
+}
@@ -24,7 +24,6 @@ package datas
 import (
 	"context"
 	"errors"
-	"fmt"
 	"io"
 	"math"
 	"math/rand"
@@ -75,9 +74,53 @@ func Clone(ctx context.Context, srcDB, sinkDB Database, eventCh chan<- TableFile
 		return errors.New("sink db is not a Table File Store")
 	}
 
+	if srcLocal, ok := srcTS.(nbs.LocalTableFileStore); ok {
+		if sinkLocal, ok := sinkTS.(nbs.LocalTableFileStore); ok {
+			cloned, err := localClone(ctx, srcLocal, sinkLocal, eventCh)
+
+			if err != nil || cloned {
+				return err
+			}
+		}
+	}
+
 	return clone(ctx, srcTS, sinkTS, eventCh)
 }
 
+// localClone clones a store whose table files are on the local filesystem by hard linking its table files into the
+// sink, or copying them when they are on a different device, and then setting the sink's root. It returns false when
+// the table files can't be linked, in which case the sink is unchanged and the table files must be cloned by reading
+// them.
+func localClone(ctx context.Context, srcTS, sinkTS nbs.LocalTableFileStore, eventCh chan<- TableFileEvent) (bool, error) {
+	srcDir, srcOK := srcTS.LocalTableFileDir()
+	_, sinkOK := sinkTS.LocalTableFileDir()
+
+	if !srcOK || !sinkOK {
+		return false, nil
+	}
+
+	root, tblFiles, err := srcTS.Sources(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	err = sinkTS.LinkTableFiles(ctx, srcDir, tblFiles)
+
+	if err != nil {
+		// fall back on reading the table files, which reports its own errors
+		return false, nil
+	}
+
+	if eventCh != nil {
+		eventCh <- TableFileEvent{Listed, tblFiles}
+		eventCh <- TableFileEvent{DownloadStart, tblFiles}
+		eventCh <- TableFileEvent{DownloadSuccess, tblFiles}
+	}
+
+	return true, sinkTS.SetRootChunk(ctx, root, hash.Hash{})
+}
+
 type CloneTableFileEvent int
 
 const (
@@ -249,8 +292,10 @@ func pull(ctx context.Context, srcDB, sinkDB Database, sourceRef types.Ref, prog
 		return nil // already up to date
 	}
 
-	if srcDB.chunkStore().Version() != sinkDB.chunkStore().Version() {
-		return fmt.Errorf("cannot pull from src to sink; src version is %v and sink version is %v", srcDB.chunkStore().Version(), sinkDB.chunkStore().Version())
+	err = chunks.CheckFormatVersionsMatch(srcDB.chunkStore().Version(), sinkDB.chunkStore().Version())
+
+	if err != nil {
+		return err
 	}
 
 	var sampleSize, sampleCount uint64
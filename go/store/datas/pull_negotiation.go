@@ -0,0 +1,215 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datas
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/nbs"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// CommitHeads returns the refs of the commits at the heads of the datasets of |db|, which a pull into |db| can use as
+// its known heads. The heads of datasets which aren't commits are left out.
+func CommitHeads(ctx context.Context, db Database) ([]types.Ref, error) {
+	datasets, err := db.Datasets(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []types.Ref
+	var hashes hash.HashSlice
+	err = datasets.IterAll(ctx, func(_, v types.Value) error {
+		if r, ok := v.(types.Ref); ok {
+			refs = append(refs, r)
+			hashes = append(hashes, r.TargetHash())
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the refs of the datasets aren't typed by what they refer to, so the heads are read to find the commits
+	vals, err := db.ReadManyValues(ctx, hashes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var heads []types.Ref
+	for i, v := range vals {
+		if v == nil {
+			continue
+		}
+
+		isCommit, err := IsCommit(v)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if isCommit {
+			heads = append(heads, refs[i])
+		}
+	}
+
+	return heads, nil
+}
+
+// findNewCommits walks the history of |head| in |srcDB| and the history of |knownHeads| in |sinkDB| together, in
+// order of decreasing height, the way FindCommonAncestor does, to find the commits of |head| which |sinkDB| is missing.
+// As a commit's parents are always lower than it, every ancestor of |knownHeads| at least as high as a source commit has
+// been seen by the time the source commit is, so only the new commits are read from |srcDB|, a generation at a time.
+// Each generation is checked against |sinkDB| with a single HasMany before it's read, so that commits the sink has
+// which aren't ancestors of |knownHeads| stop the walk too.
+//
+// It returns the compressed chunks of the new commits, as they were read from |srcCS|, and the hashes of the commits
+// which were found to be in |sinkDB|.
+func findNewCommits(ctx context.Context, srcCS NBSCompressedChunkStore, srcDB, sinkDB Database, head types.Ref, knownHeads []types.Ref) (map[hash.Hash]nbs.CompressedChunk, hash.HashSet, error) {
+	srcQ := types.RefByHeight{head}
+	sinkQ := append(types.RefByHeight{}, knownHeads...)
+	sort.Sort(sinkQ)
+
+	commits := make(map[hash.Hash]nbs.CompressedChunk)
+	known := hash.HashSet{}
+
+	for !srcQ.Empty() {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		if !sinkQ.Empty() && sinkQ.MaxHeight() >= srcQ.MaxHeight() {
+			var unseen hash.HashSlice
+			for _, r := range sinkQ.PopRefsOfHeight(sinkQ.MaxHeight()) {
+				if !known.Has(r.TargetHash()) {
+					known.Insert(r.TargetHash())
+					unseen = append(unseen, r.TargetHash())
+				}
+			}
+
+			vals, err := sinkDB.ReadManyValues(ctx, unseen)
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			for i, v := range vals {
+				if v == nil {
+					return nil, nil, errors.New("commit " + unseen[i].String() + " of a known head is missing")
+				}
+
+				if err := pushParents(ctx, v, &sinkQ); err != nil {
+					return nil, nil, err
+				}
+			}
+
+			sort.Sort(sinkQ)
+			continue
+		}
+
+		generation := hash.HashSet{}
+		for _, r := range srcQ.PopRefsOfHeight(srcQ.MaxHeight()) {
+			h := r.TargetHash()
+			if _, ok := commits[h]; !ok && !known.Has(h) {
+				generation.Insert(h)
+			}
+		}
+
+		if len(generation) == 0 {
+			continue
+		}
+
+		absent, err := sinkDB.chunkStore().HasMany(ctx, generation)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for h := range generation {
+			if !absent.Has(h) {
+				known.Insert(h)
+			}
+		}
+
+		if len(absent) == 0 {
+			continue
+		}
+
+		found := make(chan nbs.CompressedChunk, len(absent))
+		err = srcCS.GetManyCompressed(ctx, absent, found)
+		close(found)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for cmp := range found {
+			chnk, err := cmp.ToChunk()
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			v, err := types.DecodeValue(chnk, srcDB)
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if err := pushParents(ctx, v, &srcQ); err != nil {
+				return nil, nil, err
+			}
+
+			commits[cmp.H] = cmp
+		}
+
+		for h := range absent {
+			if _, ok := commits[h]; !ok {
+				return nil, nil, errors.New("failed to get commit " + h.String())
+			}
+		}
+
+		sort.Sort(srcQ)
+	}
+
+	return commits, known, nil
+}
+
+// pushParents pushes the refs of the parents of the commit |v| onto |q|.
+func pushParents(ctx context.Context, v types.Value, q *types.RefByHeight) error {
+	c, ok := v.(types.Struct)
+
+	if !ok {
+		return errors.New("value is not a commit")
+	}
+
+	ps, ok, err := c.MaybeGet(ParentsField)
+
+	if err != nil || !ok {
+		return err
+	}
+
+	return ps.(types.Set).IterAll(ctx, func(v types.Value) error {
+		q.PushBack(v.(types.Ref))
+		return nil
+	})
+}
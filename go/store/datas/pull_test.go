@@ -28,6 +28,7 @@ import (
 	"io/ioutil"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -444,9 +445,36 @@ func (ttfs *TestTableFileStore) SupportedOperations() nbs.TableFileStoreOps {
 	}
 }
 
-func TestClone(t *testing.T) {
+const writeTableFileOp chunks.Operation = "WriteTableFile"
+
+// faultyTableFileStore is a TableFileStore which fails and delays its WriteTableFile calls according to its schedules.
+type faultyTableFileStore struct {
+	nbs.TableFileStore
+	faults  *chunks.FaultSchedule
+	latency *chunks.LatencySchedule
+}
+
+func (ftfs faultyTableFileStore) WriteTableFile(ctx context.Context, fileId string, numChunks int, rd io.Reader, contentLength uint64, contentHash []byte) error {
+	if err := ftfs.latency.Wait(ctx, writeTableFileOp); err != nil {
+		return err
+	}
+
+	partial, err := ftfs.faults.Inject(writeTableFileOp)
+
+	if err == nil || partial {
+		writeErr := ftfs.TableFileStore.WriteTableFile(ctx, fileId, numChunks, rd, contentLength, contentHash)
+
+		if writeErr != nil {
+			return writeErr
+		}
+	}
+
+	return err
+}
+
+func newCloneSource() *TestTableFileStore {
 	hashBytes := [hash.ByteLen]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E, 0x0F, 0x10, 0x11, 0x12, 0x13}
-	src := &TestTableFileStore{
+	return &TestTableFileStore{
 		root: hash.Of(hashBytes[:]),
 		tableFiles: map[string]nbs.TableFile{
 			"file1": &TestTableFile{
@@ -476,11 +504,18 @@ func TestClone(t *testing.T) {
 			},
 		},
 	}
+}
 
-	dest := &TestTableFileStore{
+func newCloneDest() *TestTableFileStore {
+	return &TestTableFileStore{
 		root:       hash.Hash{},
 		tableFiles: map[string]nbs.TableFile{},
 	}
+}
+
+func TestClone(t *testing.T) {
+	src := newCloneSource()
+	dest := newCloneDest()
 
 	ctx := context.Background()
 	err := clone(ctx, src, dest, nil)
@@ -491,3 +526,70 @@ func TestClone(t *testing.T) {
 
 	assert.True(t, reflect.DeepEqual(src, dest))
 }
+
+func TestCloneResilience(t *testing.T) {
+	tests := []struct {
+		name          string
+		faults        []chunks.Fault
+		expectedError error
+	}{
+		{
+			name:   "a write fails once",
+			faults: []chunks.Fault{chunks.FailNth(writeTableFileOp, 2)},
+		},
+		{
+			name:   "a write fails after writing its table file",
+			faults: []chunks.Fault{{Op: writeTableFileOp, Call: 1, Partial: true}},
+		},
+		{
+			name:   "writes fail on fewer retries than the limit",
+			faults: []chunks.Fault{{Op: writeTableFileOp, Call: 3, Count: 3}},
+		},
+		{
+			name:          "writes fail after making progress",
+			faults:        []chunks.Fault{chunks.FailFrom(writeTableFileOp, 3)},
+			expectedError: chunks.ErrInjectedFault,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			src := newCloneSource()
+			dest := newCloneDest()
+			faults := chunks.NewFaultSchedule(0, test.faults...)
+			latency := chunks.NewLatencySchedule(nil)
+
+			err := clone(ctx, src, faultyTableFileStore{dest, faults, latency}, nil)
+
+			if test.expectedError != nil {
+				assert.Equal(t, test.expectedError, err)
+				assert.True(t, dest.root.IsEmpty())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, faults.Injected(writeTableFileOp) > 0)
+			assert.True(t, reflect.DeepEqual(src, dest))
+		})
+	}
+}
+
+func TestCloneCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	src := newCloneSource()
+	dest := newCloneDest()
+	faults := chunks.NewFaultSchedule(0)
+	latency := chunks.NewLatencySchedule(map[chunks.Operation]chunks.LatencyDistribution{
+		writeTableFileOp: chunks.FixedLatency(time.Minute),
+	})
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := clone(ctx, src, faultyTableFileStore{dest, faults, latency}, nil)
+	assert.Equal(t, context.Canceled, err)
+	assert.True(t, time.Since(start) < time.Minute)
+	assert.True(t, dest.root.IsEmpty())
+	assert.Empty(t, dest.tableFiles)
+}
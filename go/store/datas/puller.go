@@ -17,7 +17,6 @@ package datas
 import (
 	"context"
 	"errors"
-	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
@@ -49,6 +48,10 @@ const (
 	maxChunkWorkers = 2
 )
 
+// DefaultMaxHashesInMemory is the number of hashes each of the sets of hashes of the walk of a Puller holds in memory,
+// unless it's changed with SetMaxHashesInMemory.
+const DefaultMaxHashesInMemory = 1 << 20
+
 // FilledWriters store CmpChunkTableWriter that have been filled and are ready to be flushed.  In the future will likely
 // add the md5 of the data to this structure to be used to verify table upload calls.
 type FilledWriters struct {
@@ -61,9 +64,10 @@ type CmpChnkAndRefs struct {
 	refs    map[hash.Hash]int
 }
 
+// NBSCompressedChunkStore is the ChunkStore a Puller pulls from. Every ChunkStore can now get its chunks compressed,
+// so it's kept for the stores and errors named after it.
 type NBSCompressedChunkStore interface {
 	chunks.ChunkStore
-	GetManyCompressed(context.Context, hash.HashSet, chan<- nbs.CompressedChunk) error
 }
 
 // Puller is used to sync data between to Databases
@@ -74,12 +78,21 @@ type Puller struct {
 	srcChunkStore NBSCompressedChunkStore
 	sinkDB        Database
 	rootChunkHash hash.Hash
-	downloaded    hash.HashSet
+
+	// head and knownHeads are set by NewPullerWithKnownHeads, and known holds the commits which its negotiation found
+	// the sink has, which are never checked for again.
+	head       types.Ref
+	knownHeads []types.Ref
+	known      hash.HashSet
 
 	wr          *nbs.CmpChunkTableWriter
 	tempDir     string
 	chunksPerTF int
 
+	// maxHashesInMemory is the number of hashes each of the sets of hashes of the walk holds in memory before it
+	// spills them to disk, and the number of chunks asked for at a time
+	maxHashesInMemory int
+
 	eventCh chan PullerEvent
 }
 
@@ -151,8 +164,10 @@ func NewPuller(ctx context.Context, tempDir string, chunksPerTF int, srcDB, sink
 		return nil, ErrDBUpToDate
 	}
 
-	if srcDB.chunkStore().Version() != sinkDB.chunkStore().Version() {
-		return nil, fmt.Errorf("cannot pull from src to sink; src version is %v and sink version is %v", srcDB.chunkStore().Version(), sinkDB.chunkStore().Version())
+	err = chunks.CheckFormatVersionsMatch(srcDB.chunkStore().Version(), sinkDB.chunkStore().Version())
+
+	if err != nil {
+		return nil, err
 	}
 
 	srcChunkStore, ok := srcDB.chunkStore().(NBSCompressedChunkStore)
@@ -167,19 +182,40 @@ func NewPuller(ctx context.Context, tempDir string, chunksPerTF int, srcDB, sink
 	}
 
 	return &Puller{
-		fmt:           srcDB.Format(),
-		srcDB:         srcDB,
-		srcChunkStore: srcChunkStore,
-		sinkDB:        sinkDB,
-		rootChunkHash: rootChunkHash,
-		downloaded:    hash.HashSet{},
-		tempDir:       tempDir,
-		wr:            wr,
-		chunksPerTF:   chunksPerTF,
-		eventCh:       eventCh,
+		fmt:               srcDB.Format(),
+		srcDB:             srcDB,
+		srcChunkStore:     srcChunkStore,
+		sinkDB:            sinkDB,
+		rootChunkHash:     rootChunkHash,
+		known:             hash.HashSet{},
+		tempDir:           tempDir,
+		wr:                wr,
+		chunksPerTF:       chunksPerTF,
+		maxHashesInMemory: DefaultMaxHashesInMemory,
+		eventCh:           eventCh,
 	}, nil
 }
 
+// NewPullerWithKnownHeads creates a Puller of the commit |head| which negotiates what to pull using commit ancestry.
+// |knownHeads| are commits which the sink has, such as the heads of its branches, and the puller walks the history of
+// |head| only down to their ancestors, reading just the new commits from the source. The level by level walk of the
+// chunks then starts from the children of the new commits, so the sink is only asked which chunks it has for those,
+// rather than for every level of history back to the commits it has. With no known heads it's the same as NewPuller.
+func NewPullerWithKnownHeads(ctx context.Context, tempDir string, chunksPerTF int, srcDB, sinkDB Database, head types.Ref, knownHeads []types.Ref, eventCh chan PullerEvent) (*Puller, error) {
+	p, err := NewPuller(ctx, tempDir, chunksPerTF, srcDB, sinkDB, head.TargetHash(), eventCh)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(knownHeads) > 0 {
+		p.head = head
+		p.knownHeads = knownHeads
+	}
+
+	return p, nil
+}
+
 func (p *Puller) processCompletedTables(ctx context.Context, ae *atomicerr.AtomicError, completedTables <-chan FilledWriters) {
 	type tempTblFile struct {
 		id          string
@@ -262,14 +298,21 @@ func (p *Puller) processCompletedTables(ctx context.Context, ae *atomicerr.Atomi
 	}
 }
 
+// SetMaxHashesInMemory sets the number of hashes each of the sets of hashes of the walk holds in memory before it
+// spills them to temporary files, which bounds the memory used by the walk whatever the number of chunks pulled. It's
+// also the number of chunks the sink is asked about, and read from the source, at a time.
+func (p *Puller) SetMaxHashesInMemory(maxHashes int) {
+	if maxHashes < 1 {
+		maxHashes = 1
+	}
+
+	p.maxHashesInMemory = maxHashes
+}
+
 // Pull executes the sync operation
 func (p *Puller) Pull(ctx context.Context) error {
 	twDetails := &TreeWalkEventDetails{TreeLevel: -1}
 
-	leaves := make(hash.HashSet)
-	absent := make(hash.HashSet)
-	absent.Insert(p.rootChunkHash)
-
 	ae := atomicerr.New()
 	wg := &sync.WaitGroup{}
 	completedTables := make(chan FilledWriters, 8)
@@ -280,74 +323,322 @@ func (p *Puller) Pull(ctx context.Context) error {
 		p.processCompletedTables(ctx, ae, completedTables)
 	}()
 
-	for len(absent) > 0 {
-		limitToNewChunks(absent, p.downloaded)
+	err := p.walk(ctx, twDetails, completedTables)
+	ae.SetIfError(err)
 
-		chunksInLevel := len(absent)
-		twDetails.ChunksInLevel = chunksInLevel
-		p.eventCh <- NewTWPullerEvent(NewLevelTWEvent, twDetails)
+	if p.wr.Size() > 0 {
+		completedTables <- FilledWriters{p.wr}
+	}
 
-		var err error
-		absent, err = p.sinkDB.chunkStore().HasMany(ctx, absent)
+	close(completedTables)
 
-		if ae.SetIfError(err) {
+	wg.Wait()
+	return ae.Get()
+}
+
+// walk walks the chunks of the root level by level, adding those the sink is missing to the table files sent to
+// |completedTables|. The hashes of each level, and those of the chunks which have been added, are kept in
+// hashRunSets, so that however many chunks there are at most p.maxHashesInMemory of each are held in memory at once.
+func (p *Puller) walk(ctx context.Context, twDetails *TreeWalkEventDetails, completedTables chan FilledWriters) error {
+	added := newHashRunSet(p.tempDir, p.maxHashesInMemory)
+	defer added.close()
+
+	level := newHashRunSet(p.tempDir, p.maxHashesInMemory)
+	defer func() {
+		_ = level.close()
+	}()
+
+	if p.knownHeads != nil {
+		if err := p.addNewCommits(ctx, twDetails, added, level, completedTables); err != nil {
+			return err
+		}
+	} else if err := level.insert(p.rootChunkHash, false); err != nil {
+		return err
+	}
+
+	for !level.empty() {
+		next, err := p.walkLevel(ctx, twDetails, added, level, completedTables)
+
+		if err != nil {
+			return err
+		}
+
+		if err := level.close(); err != nil {
+			return err
+		}
+
+		level = next
+	}
+
+	return nil
+}
+
+// walkLevel adds the chunks of |level| which haven't been |added| already, and which the sink is missing, to the
+// table files, and returns the hashes of their children. The sink is asked which chunks it has, and the chunks are
+// read from the source, in batches of at most p.maxHashesInMemory.
+func (p *Puller) walkLevel(ctx context.Context, twDetails *TreeWalkEventDetails, added, level *hashRunSet, completedTables chan FilledWriters) (*hashRunSet, error) {
+	newChunks, chunksInLevel, err := p.newChunks(level, added)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer newChunks.close()
+
+	twDetails.ChunksInLevel = chunksInLevel
+	p.eventCh <- NewTWPullerEvent(NewLevelTWEvent, twDetails)
+
+	absent, absentCount, err := p.absentChunks(ctx, newChunks)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer absent.close()
+
+	twDetails.ChunksAlreadyHad = chunksInLevel - absentCount
+	p.eventCh <- NewTWPullerEvent(DestDBHasTWEvent, twDetails)
+
+	next := newHashRunSet(p.tempDir, p.maxHashesInMemory)
+	if absentCount == 0 {
+		return next, nil
+	}
+
+	err = p.getAbsentChunks(ctx, twDetails, absent, absentCount, added, next, completedTables)
+
+	if err != nil {
+		_ = next.close()
+		return nil, err
+	}
+
+	return next, nil
+}
+
+// newChunks returns the hashes of |level| which haven't been |added| already, or found to be in the sink by the
+// negotiation of NewPullerWithKnownHeads, and how many there are.
+func (p *Puller) newChunks(level, added *hashRunSet) (*hashRunSet, int, error) {
+	levelItr, err := level.iter()
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	addedItr, err := added.iter()
+
+	if err != nil {
+		_ = levelItr.close()
+		return nil, 0, err
+	}
+
+	itr := &subtractSource{src: levelItr, exclude: addedItr}
+	defer itr.close()
+
+	newChunks := newHashRunSet(p.tempDir, p.maxHashesInMemory)
+	count := 0
+	for {
+		rec, ok, err := itr.next()
+
+		if err != nil {
+			_ = newChunks.close()
+			return nil, 0, err
+		} else if !ok {
 			break
 		}
 
-		twDetails.ChunksAlreadyHad = chunksInLevel - len(absent)
-		p.eventCh <- NewTWPullerEvent(DestDBHasTWEvent, twDetails)
+		if p.known.Has(rec.h) {
+			continue
+		}
 
-		if len(absent) > 0 {
-			leaves, absent, err = p.getCmp(ctx, twDetails, leaves, absent, completedTables)
+		if err := newChunks.insert(rec.h, rec.leaf); err != nil {
+			_ = newChunks.close()
+			return nil, 0, err
+		}
 
-			if ae.SetIfError(err) {
+		count++
+	}
+
+	return newChunks, count, nil
+}
+
+// absentChunks returns the hashes of |newChunks| which the sink is missing, and how many there are.
+func (p *Puller) absentChunks(ctx context.Context, newChunks *hashRunSet) (*hashRunSet, int, error) {
+	itr, err := newChunks.iter()
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer itr.close()
+
+	absent := newHashRunSet(p.tempDir, p.maxHashesInMemory)
+	count := 0
+	for {
+		batch, err := readBatch(itr, p.maxHashesInMemory)
+
+		if err == nil && len(batch) == 0 {
+			return absent, count, nil
+		}
+
+		var absentInBatch hash.HashSet
+		if err == nil {
+			absentInBatch, err = p.sinkDB.chunkStore().HasMany(ctx, recordHashes(batch))
+		}
+
+		for _, rec := range batch {
+			if err != nil {
 				break
 			}
+
+			if absentInBatch.Has(rec.h) {
+				err = absent.insert(rec.h, rec.leaf)
+				count++
+			}
+		}
+
+		if err != nil {
+			_ = absent.close()
+			return nil, 0, err
 		}
 	}
+}
 
-	if p.wr.Size() > 0 {
-		completedTables <- FilledWriters{p.wr}
+// getAbsentChunks reads the |absentCount| chunks of |absent| from the source in batches, adding them to the table
+// files and to |added|, and adding their children to |next|.
+func (p *Puller) getAbsentChunks(ctx context.Context, twDetails *TreeWalkEventDetails, absent *hashRunSet, absentCount int, added, next *hashRunSet, completedTables chan FilledWriters) error {
+	itr, err := absent.iter()
+
+	if err != nil {
+		return err
 	}
 
-	close(completedTables)
+	defer itr.close()
 
-	wg.Wait()
-	return ae.Get()
+	var maxHeight int
+	twDetails.ChunksBuffered = 0
+	for {
+		batch, err := readBatch(itr, p.maxHashesInMemory)
+
+		if err != nil {
+			return err
+		} else if len(batch) == 0 {
+			break
+		}
+
+		batchHeight, err := p.getCmp(ctx, twDetails, batch, added, next, completedTables)
+
+		if err != nil {
+			return err
+		}
+
+		if batchHeight > maxHeight {
+			maxHeight = batchHeight
+		}
+	}
+
+	if twDetails.ChunksBuffered != absentCount {
+		return errors.New("failed to get all chunks.")
+	}
+
+	p.eventCh <- NewTWPullerEvent(LevelDoneTWEvent, twDetails)
+
+	twDetails.TreeLevel = maxHeight
+	return nil
 }
 
-func limitToNewChunks(absent hash.HashSet, downloaded hash.HashSet) {
-	smaller := absent
-	longer := downloaded
-	if len(absent) > len(downloaded) {
-		smaller = downloaded
-		longer = absent
+func recordHashes(recs []hashRecord) hash.HashSet {
+	hashes := make(hash.HashSet, len(recs))
+	for _, rec := range recs {
+		hashes.Insert(rec.h)
 	}
 
-	for k := range smaller {
-		if longer.Has(k) {
-			absent.Remove(k)
+	return hashes
+}
+
+// addNewCommits finds the commits of the head which the sink is missing with findNewCommits, and adds them to the
+// table files being written and to |added|. Their children, which are the first level of the walk of the chunks, are
+// added to |level|.
+func (p *Puller) addNewCommits(ctx context.Context, twDetails *TreeWalkEventDetails, added, level *hashRunSet, completedTables chan FilledWriters) error {
+	commits, known, err := findNewCommits(ctx, p.srcChunkStore, p.srcDB, p.sinkDB, p.head, p.knownHeads)
+
+	if err != nil {
+		return err
+	}
+
+	p.known = known
+
+	twDetails.ChunksInLevel = len(commits)
+	twDetails.ChunksAlreadyHad = 0
+	twDetails.ChunksBuffered = 0
+	p.eventCh <- NewTWPullerEvent(NewLevelTWEvent, twDetails)
+
+	for h, cmp := range commits {
+		if err := added.insert(h, false); err != nil {
+			return err
 		}
+
+		chnk, err := cmp.ToChunk()
+
+		if err != nil {
+			return err
+		}
+
+		err = types.WalkRefs(chnk, p.fmt, func(r types.Ref) error {
+			twDetails.ChildrenFound++
+			return level.insert(r.TargetHash(), r.Height() == 1)
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if err := p.addCmpChunk(cmp, completedTables); err != nil {
+			return err
+		}
+
+		twDetails.ChunksBuffered++
 	}
+
+	p.eventCh <- NewTWPullerEvent(LevelDoneTWEvent, twDetails)
+
+	return nil
+}
+
+// addCmpChunk adds |cmp| to the table file being written, sending it to |completedTables| once it's full.
+func (p *Puller) addCmpChunk(cmp nbs.CompressedChunk, completedTables chan FilledWriters) error {
+	err := p.wr.AddCmpChunk(cmp)
+
+	if err != nil {
+		return err
+	}
+
+	if p.wr.Size() >= p.chunksPerTF {
+		completedTables <- FilledWriters{p.wr}
+		p.wr, err = nbs.NewCmpChunkTableWriter()
+	}
+
+	return err
 }
 
-func (p *Puller) getCmp(ctx context.Context, twDetails *TreeWalkEventDetails, leaves, batch hash.HashSet, completedTables chan FilledWriters) (hash.HashSet, hash.HashSet, error) {
+// getCmp reads the chunks of |batch| from the source, adding them to the table files and to |added|, and adding their
+// children to |next|. It returns the greatest height of their children.
+func (p *Puller) getCmp(ctx context.Context, twDetails *TreeWalkEventDetails, batch []hashRecord, added, next *hashRunSet, completedTables chan FilledWriters) (int, error) {
+	leaves := make(hash.HashSet)
+	for _, rec := range batch {
+		if rec.leaf {
+			leaves.Insert(rec.h)
+		}
+	}
+
 	found := make(chan nbs.CompressedChunk, 4096)
 	processed := make(chan CmpChnkAndRefs, 4096)
 
 	ae := atomicerr.New()
 	go func() {
 		defer close(found)
-		err := p.srcChunkStore.GetManyCompressed(ctx, batch, found)
+		err := p.srcChunkStore.GetManyCompressed(ctx, recordHashes(batch), found)
 		ae.SetIfError(err)
 	}()
 
-	batchSize := len(batch)
-	numChunkWorkers := (batchSize / 1024) + 1
-	if numChunkWorkers > maxChunkWorkers {
-		numChunkWorkers = maxChunkWorkers
-	}
-
 	go func() {
 		defer close(processed)
 		for cmpChnk := range found {
@@ -355,8 +646,6 @@ func (p *Puller) getCmp(ctx context.Context, twDetails *TreeWalkEventDetails, le
 				break
 			}
 
-			p.downloaded.Insert(cmpChnk.H)
-
 			if leaves.Has(cmpChnk.H) {
 				processed <- CmpChnkAndRefs{cmpChnk: cmpChnk}
 			} else {
@@ -377,14 +666,9 @@ func (p *Puller) getCmp(ctx context.Context, twDetails *TreeWalkEventDetails, le
 		}
 	}()
 
-	var err error
 	var maxHeight int
-	nextLeaves := make(hash.HashSet, batchSize)
-	nextLevel := make(hash.HashSet, batchSize)
-
-	twDetails.ChunksBuffered = 0
 	for cmpAndRef := range processed {
-		if err != nil {
+		if ae.IsSet() {
 			// drain to prevent deadlock
 			continue
 		}
@@ -395,27 +679,21 @@ func (p *Puller) getCmp(ctx context.Context, twDetails *TreeWalkEventDetails, le
 			p.eventCh <- NewTWPullerEvent(LevelUpdateTWEvent, twDetails)
 		}
 
-		err = p.wr.AddCmpChunk(cmpAndRef.cmpChnk)
+		err := p.addCmpChunk(cmpAndRef.cmpChnk, completedTables)
 
-		if ae.SetIfError(err) {
-			continue
+		if err == nil {
+			err = added.insert(cmpAndRef.cmpChnk.H, false)
 		}
 
-		if p.wr.Size() >= p.chunksPerTF {
-			completedTables <- FilledWriters{p.wr}
-			p.wr, err = nbs.NewCmpChunkTableWriter()
-
-			if ae.SetIfError(err) {
-				continue
-			}
+		if ae.SetIfError(err) {
+			continue
 		}
 
 		for h, height := range cmpAndRef.refs {
-			nextLevel.Insert(h)
 			twDetails.ChildrenFound++
 
-			if height == 1 {
-				nextLeaves.Insert(h)
+			if ae.SetIfError(next.insert(h, height == 1)) {
+				break
 			}
 
 			if height > maxHeight {
@@ -425,15 +703,8 @@ func (p *Puller) getCmp(ctx context.Context, twDetails *TreeWalkEventDetails, le
 	}
 
 	if err := ae.Get(); err != nil {
-		return nil, nil, err
+		return 0, err
 	}
 
-	if twDetails.ChunksBuffered != len(batch) {
-		return nil, nil, errors.New("failed to get all chunks.")
-	}
-
-	p.eventCh <- NewTWPullerEvent(LevelDoneTWEvent, twDetails)
-
-	twDetails.TreeLevel = maxHeight
-	return nextLeaves, nextLevel, nil
+	return maxHeight, nil
 }
@@ -18,14 +18,20 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"math/rand"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/hash"
 	"github.com/liquidata-inc/dolt/go/store/nbs"
 	"github.com/liquidata-inc/dolt/go/store/types"
 	"github.com/liquidata-inc/dolt/go/store/util/clienttest"
@@ -128,20 +134,62 @@ func deleteTableValues(ctx context.Context, vrw types.ValueReadWriter, m types.M
 }
 
 func tempDirDB(ctx context.Context) (Database, error) {
-	dir := filepath.Join(os.TempDir(), uuid.New().String())
-	err := os.MkdirAll(dir, os.ModePerm)
+	st, err := tempDirStore(ctx)
 
 	if err != nil {
 		return nil, err
 	}
 
-	st, err := nbs.NewLocalStore(ctx, types.Format_Default.VersionString(), dir, clienttest.DefaultMemTableSize)
+	return NewDatabase(st), nil
+}
+
+func tempDirStore(ctx context.Context) (*nbs.NomsBlockStore, error) {
+	dir := filepath.Join(os.TempDir(), uuid.New().String())
+	err := os.MkdirAll(dir, os.ModePerm)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return NewDatabase(st), nil
+	return nbs.NewLocalStore(ctx, types.Format_Default.VersionString(), dir, clienttest.DefaultMemTableSize)
+}
+
+const getManyCompressedOp chunks.Operation = "GetManyCompressed"
+
+// faultyNBS is a NomsBlockStore which fails and delays calls according to its schedules, including the calls a Puller
+// makes to read compressed chunks and write table files.
+type faultyNBS struct {
+	*chunks.FaultChunkStore
+	faultyTableFileStore
+	nbs *nbs.NomsBlockStore
+}
+
+func newFaultyNBS(st *nbs.NomsBlockStore, faults *chunks.FaultSchedule, latency *chunks.LatencySchedule) faultyNBS {
+	return faultyNBS{
+		chunks.NewFaultChunkStore(chunks.NewLatencyChunkStore(st, latency), faults),
+		faultyTableFileStore{st, faults, latency},
+		st,
+	}
+}
+
+func (fnbs faultyNBS) GetManyCompressed(ctx context.Context, hashes hash.HashSet, cmpChChan chan<- nbs.CompressedChunk) error {
+	if err := fnbs.latency.Wait(ctx, getManyCompressedOp); err != nil {
+		return err
+	}
+
+	partial, err := fnbs.faults.Inject(getManyCompressedOp)
+
+	if err == nil {
+		return fnbs.nbs.GetManyCompressed(ctx, hashes, cmpChChan)
+	}
+
+	if partial {
+		if getErr := fnbs.nbs.GetManyCompressed(ctx, chunks.HalfOf(hashes), cmpChChan); getErr != nil {
+			return getErr
+		}
+	}
+
+	return err
 }
 
 func TestPuller(t *testing.T) {
@@ -346,6 +394,147 @@ func TestPuller(t *testing.T) {
 	}
 }
 
+func runPuller(ctx context.Context, srcDB, sinkDB Database, rootRef types.Ref) error {
+	eventCh := make(chan PullerEvent, 128)
+	go func() {
+		for range eventCh {
+		}
+	}()
+	defer close(eventCh)
+
+	tmpDir := filepath.Join(os.TempDir(), uuid.New().String())
+	err := os.MkdirAll(tmpDir, os.ModePerm)
+
+	if err != nil {
+		return err
+	}
+
+	plr, err := NewPuller(ctx, tmpDir, 128, srcDB, sinkDB, rootRef.TargetHash(), eventCh)
+
+	if err != nil {
+		return err
+	}
+
+	return plr.Pull(ctx)
+}
+
+func makeBigTableCommit(t *testing.T, ctx context.Context, db Database) types.Ref {
+	tbl, err := makeABigTable(ctx, db)
+	require.NoError(t, err)
+	tblRef, err := writeValAndGetRef(ctx, db, tbl)
+	require.NoError(t, err)
+	rootMap, err := types.NewMap(ctx, db, types.String("big_table"), tblRef)
+	require.NoError(t, err)
+
+	ds, err := db.GetDataset(ctx, "ds")
+	require.NoError(t, err)
+	ds, err = db.CommitValue(ctx, ds, rootMap)
+	require.NoError(t, err)
+
+	r, ok, err := ds.MaybeHeadRef()
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	return r
+}
+
+func requirePulled(t *testing.T, ctx context.Context, rootRef types.Ref, srcDB, sinkDB Database) {
+	sinkDS, err := sinkDB.GetDataset(ctx, "ds")
+	require.NoError(t, err)
+	sinkDS, err = sinkDB.FastForward(ctx, sinkDS, rootRef)
+	require.NoError(t, err)
+	sinkRootRef, ok, err := sinkDS.MaybeHeadRef()
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	eq, err := pullerRefEquality(ctx, rootRef, sinkRootRef, srcDB, sinkDB)
+	require.NoError(t, err)
+	assert.True(t, eq)
+}
+
+func TestPullerResilience(t *testing.T) {
+	ctx := context.Background()
+	srcSt, err := tempDirStore(ctx)
+	require.NoError(t, err)
+	rootRef := makeBigTableCommit(t, ctx, NewDatabase(srcSt))
+
+	tests := []struct {
+		name       string
+		srcFaults  []chunks.Fault
+		sinkFaults []chunks.Fault
+	}{
+		{
+			name:      "a read fails",
+			srcFaults: []chunks.Fault{chunks.FailNth(getManyCompressedOp, 2)},
+		},
+		{
+			name:      "a read fails after getting some chunks",
+			srcFaults: []chunks.Fault{{Op: getManyCompressedOp, Call: 3, Partial: true}},
+		},
+		{
+			name:       "the sink fails to say which chunks it has",
+			sinkFaults: []chunks.Fault{chunks.FailNth(chunks.OpHasMany, 2)},
+		},
+		{
+			name:       "a table file write fails after some succeed",
+			sinkFaults: []chunks.Fault{chunks.FailNth(writeTableFileOp, 2)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srcFaults := chunks.NewFaultSchedule(0, test.srcFaults...)
+			srcDB := NewDatabase(newFaultyNBS(srcSt, srcFaults, chunks.NewLatencySchedule(nil)))
+			sinkSt, err := tempDirStore(ctx)
+			require.NoError(t, err)
+			sinkFaults := chunks.NewFaultSchedule(0, test.sinkFaults...)
+			sinkDB := NewDatabase(newFaultyNBS(sinkSt, sinkFaults, chunks.NewLatencySchedule(nil)))
+
+			err = runPuller(ctx, srcDB, sinkDB, rootRef)
+			assert.Equal(t, chunks.ErrInjectedFault, err)
+
+			// pulling again without faults must fill in whatever the failed pull left out
+			srcFaults.SetFaults()
+			sinkFaults.SetFaults()
+			err = runPuller(ctx, srcDB, sinkDB, rootRef)
+			require.NoError(t, err)
+			requirePulled(t, ctx, rootRef, srcDB, sinkDB)
+		})
+	}
+}
+
+func TestPullerCancellation(t *testing.T) {
+	srcSt, err := tempDirStore(context.Background())
+	require.NoError(t, err)
+	rootRef := makeBigTableCommit(t, context.Background(), NewDatabase(srcSt))
+
+	latency := chunks.NewLatencySchedule(map[chunks.Operation]chunks.LatencyDistribution{
+		getManyCompressedOp: chunks.FixedLatency(time.Minute),
+	})
+	srcDB := NewDatabase(newFaultyNBS(srcSt, chunks.NewFaultSchedule(0), latency))
+	sinkDB, err := tempDirDB(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- runPuller(ctx, srcDB, sinkDB, rootRef)
+	}()
+
+	select {
+	case err := <-errCh:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "the pull wasn't canceled")
+	}
+
+	has, err := sinkDB.chunkStore().Has(context.Background(), rootRef.TargetHash())
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
 func makeABigTable(ctx context.Context, db Database) (types.Map, error) {
 	m, err := types.NewMap(ctx, db)
 
@@ -512,3 +701,307 @@ func writeValAndGetRef(ctx context.Context, vrw types.ValueReadWriter, val types
 
 	return valRef, err
 }
+
+// countingNBS is a NomsBlockStore which counts the calls which read chunks from it, and which ask it which chunks it
+// has, the round trips a pull would make to it if it were remote, along with the chunks and compressed bytes read.
+type countingNBS struct {
+	*nbs.NomsBlockStore
+	counts pullCounts
+}
+
+type pullCounts struct {
+	Reads         int
+	ChunksRead    int
+	BytesRead     int
+	HasManys      int
+	HashesChecked int
+}
+
+func (cnbs *countingNBS) GetManyCompressed(ctx context.Context, hashes hash.HashSet, cmpChChan chan<- nbs.CompressedChunk) error {
+	cnbs.counts.Reads++
+
+	found := make(chan nbs.CompressedChunk, 128)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for cmp := range found {
+			cnbs.counts.ChunksRead++
+			cnbs.counts.BytesRead += len(cmp.FullCompressedChunk)
+			cmpChChan <- cmp
+		}
+	}()
+
+	err := cnbs.NomsBlockStore.GetManyCompressed(ctx, hashes, found)
+	close(found)
+	<-done
+
+	return err
+}
+
+func (cnbs *countingNBS) HasMany(ctx context.Context, hashes hash.HashSet) (hash.HashSet, error) {
+	cnbs.counts.HasManys++
+	cnbs.counts.HashesChecked += len(hashes)
+	return cnbs.NomsBlockStore.HasMany(ctx, hashes)
+}
+
+// commitBigTableEdit commits a change to a few rows of the big table of the head of "ds" in |db|.
+func commitBigTableEdit(t *testing.T, ctx context.Context, db Database, n int) types.Ref {
+	ds, err := db.GetDataset(ctx, "ds")
+	require.NoError(t, err)
+	rootVal, ok, err := ds.MaybeHeadValue()
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	rootMap := rootVal.(types.Map)
+	tblRef, ok, err := rootMap.MaybeGet(ctx, types.String("big_table"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	tblVal, err := tblRef.(types.Ref).TargetValue(ctx, db)
+	require.NoError(t, err)
+
+	me := tblVal.(types.Map).Edit()
+	for i := 0; i < 3; i++ {
+		me.Set(types.Int(i*100*1000+n), types.String(uuid.New().String()))
+	}
+	tbl, err := me.Map(ctx)
+	require.NoError(t, err)
+
+	newTblRef, err := writeValAndGetRef(ctx, db, tbl)
+	require.NoError(t, err)
+	rootMap, err = rootMap.Edit().Set(types.String("big_table"), newTblRef).Map(ctx)
+	require.NoError(t, err)
+
+	ds, err = db.CommitValue(ctx, ds, rootMap)
+	require.NoError(t, err)
+	r, ok, err := ds.MaybeHeadRef()
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	return r
+}
+
+// TestPullerKnownHeads fetches 3 commits, each of which changes a few rows of a big table, into a database which has
+// their parent, with and without the known heads of the sink, and compares the work done.
+func TestPullerKnownHeads(t *testing.T) {
+	ctx := context.Background()
+	srcSt, err := tempDirStore(ctx)
+	require.NoError(t, err)
+	src := &countingNBS{NomsBlockStore: srcSt}
+	srcDB := NewDatabase(src)
+
+	// the shape of the tables' trees depends on their values, so they're generated from a fixed seed to keep the
+	// counts the same from run to run
+	uuid.SetRand(rand.New(rand.NewSource(0)))
+	base := makeBigTableCommit(t, ctx, srcDB)
+	head := base
+	for i := 0; i < 3; i++ {
+		head = commitBigTableEdit(t, ctx, srcDB, i)
+	}
+	uuid.SetRand(nil)
+
+	pull := func(withKnownHeads bool) (srcCounts, sinkCounts pullCounts) {
+		sinkSt, err := tempDirStore(ctx)
+		require.NoError(t, err)
+		sink := &countingNBS{NomsBlockStore: sinkSt}
+		sinkDB := NewDatabase(sink)
+		require.NoError(t, runPuller(ctx, srcDB, sinkDB, base))
+		requirePulled(t, ctx, base, srcDB, sinkDB)
+
+		var knownHeads []types.Ref
+		if withKnownHeads {
+			knownHeads, err = CommitHeads(ctx, sinkDB)
+			require.NoError(t, err)
+			require.Len(t, knownHeads, 1)
+		}
+
+		eventCh := make(chan PullerEvent, 128)
+		go func() {
+			for range eventCh {
+			}
+		}()
+		defer close(eventCh)
+
+		tmpDir := filepath.Join(os.TempDir(), uuid.New().String())
+		require.NoError(t, os.MkdirAll(tmpDir, os.ModePerm))
+		defer os.RemoveAll(tmpDir)
+
+		src.counts, sink.counts = pullCounts{}, pullCounts{}
+		plr, err := NewPullerWithKnownHeads(ctx, tmpDir, 128, srcDB, sinkDB, head, knownHeads, eventCh)
+		require.NoError(t, err)
+		require.NoError(t, plr.Pull(ctx))
+		srcCounts, sinkCounts = src.counts, sink.counts
+
+		requirePulled(t, ctx, head, srcDB, sinkDB)
+		return srcCounts, sinkCounts
+	}
+
+	srcBefore, sinkBefore := pull(false)
+	srcAfter, sinkAfter := pull(true)
+	t.Logf("without known heads: source %+v, sink %+v", srcBefore, sinkBefore)
+	t.Logf("with known heads:    source %+v, sink %+v", srcAfter, sinkAfter)
+
+	// the same chunks are read from the source, in no more round trips, and the sink is asked about fewer of them
+	assert.Equal(t, srcBefore.ChunksRead, srcAfter.ChunksRead)
+	assert.Equal(t, srcBefore.BytesRead, srcAfter.BytesRead)
+	assert.LessOrEqual(t, srcAfter.Reads, srcBefore.Reads)
+	assert.Less(t, sinkAfter.HashesChecked, sinkBefore.HashesChecked)
+}
+
+// pullWithMaxHashes pulls |rootRef| from |srcDB| to a new database, with at most |maxHashes| of each of the sets of
+// hashes of the walk in memory. |sample| is called periodically during the pull with the temp dir of the puller.
+func pullWithMaxHashes(t *testing.T, ctx context.Context, srcDB Database, rootRef types.Ref, maxHashes int, sample func(tmpDir string)) Database {
+	sinkDB, err := tempDirDB(ctx)
+	require.NoError(t, err)
+
+	eventCh := make(chan PullerEvent, 128)
+	go func() {
+		for range eventCh {
+		}
+	}()
+	defer close(eventCh)
+
+	tmpDir := filepath.Join(os.TempDir(), uuid.New().String())
+	require.NoError(t, os.MkdirAll(tmpDir, os.ModePerm))
+	defer os.RemoveAll(tmpDir)
+
+	plr, err := NewPuller(ctx, tmpDir, 128, srcDB, sinkDB, rootRef.TargetHash(), eventCh)
+	require.NoError(t, err)
+	plr.SetMaxHashesInMemory(maxHashes)
+
+	done := make(chan struct{})
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(5 * time.Millisecond):
+				if sample != nil {
+					sample(tmpDir)
+				}
+			}
+		}
+	}()
+
+	err = plr.Pull(ctx)
+	close(done)
+	<-sampled
+	require.NoError(t, err)
+
+	// the hashes spilled by the walk are removed once it's done
+	runs, err := filepath.Glob(filepath.Join(tmpDir, "hashes-*.run"))
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+
+	return sinkDB
+}
+
+func TestPullerMaxHashesInMemory(t *testing.T) {
+	ctx := context.Background()
+	srcSt, err := tempDirStore(ctx)
+	require.NoError(t, err)
+	src := &countingNBS{NomsBlockStore: srcSt}
+	srcDB := NewDatabase(src)
+	rootRef := makeBigTableCommit(t, ctx, srcDB)
+
+	pull := func(maxHashes int) (pullCounts, bool) {
+		src.counts = pullCounts{}
+		spilled := false
+		sinkDB := pullWithMaxHashes(t, ctx, srcDB, rootRef, maxHashes, func(tmpDir string) {
+			runs, _ := filepath.Glob(filepath.Join(tmpDir, "hashes-*.run"))
+			spilled = spilled || len(runs) > 0
+		})
+
+		requirePulled(t, ctx, rootRef, srcDB, sinkDB)
+		return src.counts, spilled
+	}
+
+	unlimited, _ := pull(DefaultMaxHashesInMemory)
+	limited, spilled := pull(64)
+
+	// the same chunks are read, each of them once, in smaller batches
+	assert.True(t, spilled, "no hashes were spilled")
+	assert.Equal(t, unlimited.ChunksRead, limited.ChunksRead)
+	assert.Equal(t, unlimited.BytesRead, limited.BytesRead)
+	assert.Greater(t, limited.Reads, unlimited.Reads)
+}
+
+// TestPullerMemorySoak pulls a synthetic graph of many small chunks, which is soakChunks chunks or the number in
+// DOLT_PULLER_SOAK_CHUNKS, with the hashes in memory limited to soakMaxHashes and then with the default limit, which is
+// more than the number of chunks, so nothing is spilled. The growth of the live heap during the limited pull must be
+// smaller than during the unlimited one by at least the size of the hashes of all of the chunks, which the unlimited
+// pull holds in memory at once.
+func TestPullerMemorySoak(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	const soakChunks = 100 * 1000
+	const soakMaxHashes = 4096
+
+	numChunks := soakChunks
+	if n, err := strconv.Atoi(os.Getenv("DOLT_PULLER_SOAK_CHUNKS")); err == nil && n > 0 {
+		numChunks = n
+	}
+	require.Greater(t, numChunks, 10*soakMaxHashes, "too few chunks to tell a limited pull from an unlimited one")
+	require.Less(t, numChunks, DefaultMaxHashesInMemory, "too many chunks for the default limit to hold them all")
+
+	ctx := context.Background()
+	srcSt, err := tempDirStore(ctx)
+	require.NoError(t, err)
+	srcDB := NewDatabase(srcSt)
+
+	// each value is written as a chunk of its own, and the list of their refs is a tree of chunks above them
+	refs := make([]types.Value, numChunks)
+	for i := range refs {
+		refs[i], err = srcDB.WriteValue(ctx, types.String(strconv.Itoa(i)))
+		require.NoError(t, err)
+	}
+	l, err := types.NewList(ctx, srcDB, refs...)
+	require.NoError(t, err)
+	refs = nil
+
+	ds, err := srcDB.GetDataset(ctx, "ds")
+	require.NoError(t, err)
+	ds, err = srcDB.CommitValue(ctx, ds, l)
+	require.NoError(t, err)
+	rootRef, ok, err := ds.MaybeHeadRef()
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// the heap is collected before each sample, so that the peak is of the live heap rather than of the garbage the
+	// pull happened to leave uncollected
+	pullMeasured := func(maxHashes int) (Database, uint64) {
+		runtime.GC()
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		baseline, peak := stats.HeapAlloc, stats.HeapAlloc
+
+		sinkDB := pullWithMaxHashes(t, ctx, srcDB, rootRef, maxHashes, func(string) {
+			runtime.GC()
+			var stats runtime.MemStats
+			runtime.ReadMemStats(&stats)
+			if stats.HeapAlloc > peak {
+				peak = stats.HeapAlloc
+			}
+		})
+
+		return sinkDB, peak - baseline
+	}
+
+	sinkDB, limited := pullMeasured(soakMaxHashes)
+	_, unlimited := pullMeasured(DefaultMaxHashesInMemory)
+
+	t.Logf("pulled %d chunks, heap grew by %d bytes limited to %d hashes, and %d bytes unlimited", numChunks, limited, soakMaxHashes, unlimited)
+	assert.Less(t, limited+uint64(numChunks*hash.ByteLen), unlimited)
+
+	sinkDS, err := sinkDB.GetDataset(ctx, "ds")
+	require.NoError(t, err)
+	sinkDS, err = sinkDB.FastForward(ctx, sinkDS, rootRef)
+	require.NoError(t, err)
+	sinkVal, ok, err := sinkDS.MaybeHeadValue()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, uint64(numChunks), sinkVal.(types.List).Len())
+}
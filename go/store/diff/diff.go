@@ -117,6 +117,20 @@ func Diff(ctx context.Context, ae *atomicerr.AtomicError, v1, v2 types.Value, dC
 	}
 }
 
+// DiffMapRange is like Diff with leftRight set for the maps |v1| and |v2|, but it only returns the Differences of
+// the keys in the range which begins at |start| and continues while |inRange| returns true. A nil |start| begins at the
+// first key and a nil |inRange| continues through the last key.
+func DiffMapRange(ctx context.Context, ae *atomicerr.AtomicError, v1, v2 types.Map, start types.Value, inRange func(types.Value) (bool, error), dChan chan<- Difference, stopChan chan struct{}, descFunc ShouldDescFunc) {
+	if descFunc == nil {
+		descFunc = ShouldDescend
+	}
+
+	d := differ{diffChan: dChan, stopChan: stopChan, leftRight: true, shouldDescend: descFunc}
+	d.diffMapsWithFunc(ctx, nil, ae, v1, v2, func(cc chan<- types.ValueChanged, sc <-chan struct{}) {
+		v2.DiffLeftRightInRange(ctx, v1, start, inRange, ae, cc, sc)
+	})
+}
+
 func (d differ) diff(ctx context.Context, p types.Path, ae *atomicerr.AtomicError, v1, v2 types.Value) bool {
 	switch v1.Kind() {
 	case types.ListKind:
@@ -233,6 +247,16 @@ func (d differ) diffLists(ctx context.Context, p types.Path, ae *atomicerr.Atomi
 }
 
 func (d differ) diffMaps(ctx context.Context, p types.Path, ae *atomicerr.AtomicError, v1, v2 types.Map) bool {
+	return d.diffMapsWithFunc(ctx, p, ae, v1, v2, func(cc chan<- types.ValueChanged, sc <-chan struct{}) {
+		if d.leftRight {
+			v2.DiffLeftRight(ctx, v1, ae, cc, sc)
+		} else {
+			v2.DiffHybrid(ctx, v1, ae, cc, sc)
+		}
+	})
+}
+
+func (d differ) diffMapsWithFunc(ctx context.Context, p types.Path, ae *atomicerr.AtomicError, v1, v2 types.Map, df diffFunc) bool {
 	return d.diffOrdered(ctx, p, ae,
 		func(v types.Value) (types.PathPart, error) {
 			if types.ValueCanBePathIndex(v) {
@@ -247,13 +271,7 @@ func (d differ) diffMaps(ctx context.Context, p types.Path, ae *atomicerr.Atomic
 				return types.NewHashIndexPath(h), nil
 			}
 		},
-		func(cc chan<- types.ValueChanged, sc <-chan struct{}) {
-			if d.leftRight {
-				v2.DiffLeftRight(ctx, v1, ae, cc, sc)
-			} else {
-				v2.DiffHybrid(ctx, v1, ae, cc, sc)
-			}
-		},
+		df,
 		func(k types.Value) (types.Value, error) {
 			return k, nil
 		},
@@ -169,6 +169,56 @@ func TestNomsDiffPrintMap(t *testing.T) {
 	tf(false)
 }
 
+func TestDiffMapRange(t *testing.T) {
+	assert := assert.New(t)
+
+	m1 := createMap("a", 1, "b", 2, "c", 3, "d", 4, "e", 5)
+	m2 := createMap("a", 10, "c", 3, "d", 40, "e", 50, "f", 6)
+
+	diffRange := func(start types.Value, end string) []Difference {
+		var inRange func(types.Value) (bool, error)
+		if end != "" {
+			inRange = func(v types.Value) (bool, error) {
+				return v.Less(types.Format_7_18, types.String(end))
+			}
+		}
+
+		ae := atomicerr.New()
+		dChan := make(chan Difference)
+		go func() {
+			DiffMapRange(context.Background(), ae, m1, m2, start, inRange, dChan, make(chan struct{}), nil)
+			close(dChan)
+		}()
+
+		var diffs []Difference
+		for d := range dChan {
+			diffs = append(diffs, d)
+		}
+
+		assert.NoError(ae.Get())
+		return diffs
+	}
+
+	diffs := diffRange(types.String("b"), "e")
+	assert.Len(diffs, 2)
+	assert.Equal(types.DiffChangeRemoved, diffs[0].ChangeType)
+	assert.Equal(types.String("b"), diffs[0].KeyValue)
+	assert.Equal(types.DiffChangeModified, diffs[1].ChangeType)
+	assert.Equal(types.String("d"), diffs[1].KeyValue)
+	assert.Equal(types.Float(4), diffs[1].OldValue)
+	assert.Equal(types.Float(40), diffs[1].NewValue)
+
+	diffs = diffRange(nil, "b")
+	assert.Len(diffs, 1)
+	assert.Equal(types.String("a"), diffs[0].KeyValue)
+
+	diffs = diffRange(types.String("e"), "")
+	assert.Len(diffs, 2)
+	assert.Equal(types.String("e"), diffs[0].KeyValue)
+	assert.Equal(types.DiffChangeAdded, diffs[1].ChangeType)
+	assert.Equal(types.String("f"), diffs[1].KeyValue)
+}
+
 func TestNomsDiffPrintSet(t *testing.T) {
 	assert := assert.New(t)
 
@@ -307,7 +307,7 @@ func structEncoder(nbf *types.NomsBinFormat, t reflect.Type, seenStructs map[str
 		return nomsValueEncoder, nil
 	}
 
-	e := encoderCache.get(t)
+	e := encoderCache.get(nbf, t)
 	if e != nil {
 		return e, nil
 	}
@@ -390,7 +390,7 @@ func structEncoder(nbf *types.NomsBinFormat, t reflect.Type, seenStructs map[str
 		}
 	}
 
-	encoderCache.set(t, e)
+	encoderCache.set(nbf, t, e)
 	return e, nil
 }
 
@@ -429,9 +429,16 @@ func (fs fieldSlice) Len() int           { return len(fs) }
 func (fs fieldSlice) Swap(i, j int)      { fs[i], fs[j] = fs[j], fs[i] }
 func (fs fieldSlice) Less(i, j int) bool { return fs[i].name < fs[j].name }
 
+// encoderCacheKey identifies a cached encoder. Encoders build values of the format they were created for, so encoders
+// for different formats are cached separately.
+type encoderCacheKey struct {
+	nbf *types.NomsBinFormat
+	t   reflect.Type
+}
+
 type encoderCacheT struct {
 	sync.RWMutex
-	m map[reflect.Type]encoderFunc
+	m map[encoderCacheKey]encoderFunc
 }
 
 var encoderCache = &encoderCacheT{}
@@ -440,19 +447,19 @@ var encoderCache = &encoderCacheT{}
 // `noms:",set"` tag encode differently (Set vs Map).
 var setEncoderCache = &encoderCacheT{}
 
-func (c *encoderCacheT) get(t reflect.Type) encoderFunc {
+func (c *encoderCacheT) get(nbf *types.NomsBinFormat, t reflect.Type) encoderFunc {
 	c.RLock()
 	defer c.RUnlock()
-	return c.m[t]
+	return c.m[encoderCacheKey{nbf, t}]
 }
 
-func (c *encoderCacheT) set(t reflect.Type, e encoderFunc) {
+func (c *encoderCacheT) set(nbf *types.NomsBinFormat, t reflect.Type, e encoderFunc) {
 	c.Lock()
 	defer c.Unlock()
 	if c.m == nil {
-		c.m = map[reflect.Type]encoderFunc{}
+		c.m = map[encoderCacheKey]encoderFunc{}
 	}
-	c.m[t] = e
+	c.m[encoderCacheKey{nbf, t}] = e
 }
 
 func getTags(f reflect.StructField) (tags nomsTags, err error) {
@@ -590,7 +597,7 @@ func typeFields(nbf *types.NomsBinFormat, t reflect.Type, seenStructs map[string
 }
 
 func listEncoder(nbf *types.NomsBinFormat, t reflect.Type, seenStructs map[string]reflect.Type) (encoderFunc, error) {
-	e := encoderCache.get(t)
+	e := encoderCache.get(nbf, t)
 	if e != nil {
 		return e, nil
 	}
@@ -615,7 +622,7 @@ func listEncoder(nbf *types.NomsBinFormat, t reflect.Type, seenStructs map[strin
 		return types.NewList(ctx, vrw, values...)
 	}
 
-	encoderCache.set(t, e)
+	encoderCache.set(nbf, t, e)
 	var err error
 	elemEncoder, err = typeEncoder(nbf, t.Elem(), seenStructs, nomsTags{})
 
@@ -628,7 +635,7 @@ func listEncoder(nbf *types.NomsBinFormat, t reflect.Type, seenStructs map[strin
 
 // Encode set from array or slice
 func setFromListEncoder(nbf *types.NomsBinFormat, t reflect.Type, seenStructs map[string]reflect.Type) (encoderFunc, error) {
-	e := setEncoderCache.get(t)
+	e := setEncoderCache.get(nbf, t)
 	if e != nil {
 		return e, nil
 	}
@@ -653,7 +660,7 @@ func setFromListEncoder(nbf *types.NomsBinFormat, t reflect.Type, seenStructs ma
 		return types.NewSet(ctx, vrw, values...)
 	}
 
-	setEncoderCache.set(t, e)
+	setEncoderCache.set(nbf, t, e)
 
 	var err error
 	elemEncoder, err = typeEncoder(nbf, t.Elem(), seenStructs, nomsTags{})
@@ -666,7 +673,7 @@ func setFromListEncoder(nbf *types.NomsBinFormat, t reflect.Type, seenStructs ma
 }
 
 func setEncoder(nbf *types.NomsBinFormat, t reflect.Type, seenStructs map[string]reflect.Type) (encoderFunc, error) {
-	e := setEncoderCache.get(t)
+	e := setEncoderCache.get(nbf, t)
 	if e != nil {
 		return e, nil
 	}
@@ -691,7 +698,7 @@ func setEncoder(nbf *types.NomsBinFormat, t reflect.Type, seenStructs map[string
 		return types.NewSet(ctx, vrw, values...)
 	}
 
-	setEncoderCache.set(t, e)
+	setEncoderCache.set(nbf, t, e)
 
 	var err error
 	encoder, err = typeEncoder(nbf, t.Key(), seenStructs, nomsTags{})
@@ -704,7 +711,7 @@ func setEncoder(nbf *types.NomsBinFormat, t reflect.Type, seenStructs map[string
 }
 
 func mapEncoder(nbf *types.NomsBinFormat, t reflect.Type, seenStructs map[string]reflect.Type) (encoderFunc, error) {
-	e := encoderCache.get(t)
+	e := encoderCache.get(nbf, t)
 	if e != nil {
 		return e, nil
 	}
@@ -737,7 +744,7 @@ func mapEncoder(nbf *types.NomsBinFormat, t reflect.Type, seenStructs map[string
 		return types.NewMap(ctx, vrw, kvs...)
 	}
 
-	encoderCache.set(t, e)
+	encoderCache.set(nbf, t, e)
 
 	var err error
 	keyEncoder, err = typeEncoder(nbf, t.Key(), seenStructs, nomsTags{})
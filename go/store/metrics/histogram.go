@@ -23,6 +23,7 @@ package metrics
 
 import (
 	"fmt"
+	"math"
 	"sync/atomic"
 	"time"
 
@@ -95,6 +96,37 @@ func (h Histogram) bucketVal(bucket int) uint64 {
 	return 1 << (uint64(bucket))
 }
 
+// Bucket is a bucket of a Histogram, counting the samples which are at least
+// the upper bound of the previous bucket and less than its own.
+type Bucket struct {
+	UpperBound uint64
+	Count      uint64
+}
+
+// Buckets returns the buckets of the histogram, smallest first, up to the
+// last one which holds any samples. The upper bound of the last possible
+// bucket is math.MaxUint64, which is included in it.
+func (h Histogram) Buckets() []Bucket {
+	last := -1
+	for i := 0; i < bucketCount; i++ {
+		if atomic.LoadUint64(&h.buckets[i]) > 0 {
+			last = i
+		}
+	}
+
+	buckets := make([]Bucket, last+1)
+	for i := range buckets {
+		upper := uint64(math.MaxUint64)
+		if i < bucketCount-1 {
+			upper = h.bucketVal(i + 1)
+		}
+
+		buckets[i] = Bucket{UpperBound: upper, Count: atomic.LoadUint64(&h.buckets[i])}
+	}
+
+	return buckets
+}
+
 // Sum return the sum of sampled values, note that Sum can be overflowed without
 // overflowing the histogram buckets.
 func (h Histogram) Sum() uint64 {
@@ -74,6 +74,23 @@ func TestHistogramLarge(t *testing.T) {
 	assert.Equal(uint64(0xfffffffffffffe30), h.Sum())
 }
 
+func TestHistogramBuckets(t *testing.T) {
+	assert := assert.New(t)
+
+	h := Histogram{}
+	assert.Empty(h.Buckets())
+
+	h.Sample(1)
+	h.Sample(5)
+	h.Sample(6)
+	assert.Equal([]Bucket{{2, 1}, {4, 0}, {8, 2}}, h.Buckets())
+
+	h.Sample(0xfffffffffffffe30)
+	buckets := h.Buckets()
+	assert.Len(buckets, 64)
+	assert.Equal(Bucket{0xffffffffffffffff, 1}, buckets[63])
+}
+
 func TestHistogramAdd(t *testing.T) {
 	assert := assert.New(t)
 
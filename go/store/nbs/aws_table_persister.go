@@ -24,14 +24,19 @@ package nbs
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"io"
 	"net/url"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/s3"
 
 	"github.com/liquidata-inc/dolt/go/store/atomicerr"
@@ -61,6 +66,7 @@ type awsTablePersister struct {
 	limits     awsLimits
 	indexCache *indexCache
 	ns         string
+	pushStates PushStateStore
 }
 
 type awsLimits struct {
@@ -143,20 +149,102 @@ func (s3p awsTablePersister) Persist(ctx context.Context, mt *memTable, haver ch
 	return newReaderFromIndexData(s3p.indexCache, data, name, tra, s3BlockSize)
 }
 
+// multipartUpload uploads |data| to |key| in parts. The parts S3 acknowledges are recorded in the push state of the
+// table file, and when the push states are kept on disk an upload which fails is left in progress, rather than
+// aborted, so that a later upload of the same table file only uploads the parts which weren't acknowledged. The ETag
+// S3 reports for the completed object is checked against the MD5s of the parts before the table is persisted.
 func (s3p awsTablePersister) multipartUpload(ctx context.Context, data []byte, key string) error {
-	uploadID, err := s3p.startMultipartUpload(ctx, key)
+	contentHash := md5.Sum(data)
+	state, err := s3p.pushStates.Load(key, uint64(len(data)), contentHash[:], s3p.limits.partTarget)
 
 	if err != nil {
 		return err
 	}
 
-	multipartUpload, err := s3p.uploadParts(ctx, data, key, uploadID)
+	if state.UploadID == "" {
+		if err := s3p.restartMultipartUpload(ctx, key, state); err != nil {
+			return err
+		}
+	}
+
+	multipartUpload, err := s3p.uploadParts(ctx, data, key, state)
+
+	if isAWSErrorCode(err, "NoSuchUpload") {
+		// the upload being resumed has expired, or was aborted, so it's started over
+		if err := s3p.restartMultipartUpload(ctx, key, state); err != nil {
+			return err
+		}
+
+		multipartUpload, err = s3p.uploadParts(ctx, data, key, state)
+	}
+
 	if err != nil {
-		_ = s3p.abortMultipartUpload(ctx, key, uploadID)
+		if !s3p.pushStates.persistent() {
+			_ = s3p.abortMultipartUpload(ctx, key, state.UploadID)
+		}
+
 		return err
 	}
 
-	return s3p.completeMultipartUpload(ctx, key, uploadID, multipartUpload)
+	etag, err := s3p.completeMultipartUpload(ctx, key, state.UploadID, multipartUpload)
+
+	if err != nil {
+		return err
+	}
+
+	err = verifyMultipartETag(etag, state)
+	removeErr := state.Remove()
+
+	if err == nil {
+		err = removeErr
+	}
+
+	return err
+}
+
+func (s3p awsTablePersister) restartMultipartUpload(ctx context.Context, key string, state *PushState) error {
+	uploadID, err := s3p.startMultipartUpload(ctx, key)
+
+	if err != nil {
+		return err
+	}
+
+	return state.SetUploadID(uploadID)
+}
+
+func isAWSErrorCode(err error, code string) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == code
+}
+
+// verifyMultipartETag checks the ETag S3 reported for an object uploaded in the parts of |state|. The ETag of an
+// object uploaded in parts is the MD5 of the MD5s of its parts, followed by the number of parts. Objects in buckets
+// which encrypt with KMS have ETags of another form, which aren't checked, though S3 still checks each of their parts
+// against its MD5.
+func verifyMultipartETag(etag string, state *PushState) error {
+	etag = strings.Trim(etag, "\"")
+	sep := strings.LastIndexByte(etag, '-')
+
+	if sep != 32 {
+		return nil
+	}
+
+	parts := make([]UploadedPart, len(state.Parts))
+	copy(parts, state.Parts)
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+
+	digests := md5.New()
+	for _, part := range parts {
+		digests.Write(part.MD5)
+	}
+
+	expected := fmt.Sprintf("%x-%d", digests.Sum(nil), len(parts))
+
+	if etag != expected {
+		return fmt.Errorf("%w: S3 reported the ETag %s for %s, which should be %s", ErrUploadHashMismatch, etag, state.FileID, expected)
+	}
+
+	return nil
 }
 
 func (s3p awsTablePersister) startMultipartUpload(ctx context.Context, key string) (string, error) {
@@ -182,18 +270,25 @@ func (s3p awsTablePersister) abortMultipartUpload(ctx context.Context, key, uplo
 	return abrtErr
 }
 
-func (s3p awsTablePersister) completeMultipartUpload(ctx context.Context, key, uploadID string, mpu *s3.CompletedMultipartUpload) error {
-	_, err := s3p.s3.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+// completeMultipartUpload completes the upload, returning the ETag S3 reports for the object.
+func (s3p awsTablePersister) completeMultipartUpload(ctx context.Context, key, uploadID string, mpu *s3.CompletedMultipartUpload) (string, error) {
+	res, err := s3p.s3.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
 		Bucket:          aws.String(s3p.bucket),
 		Key:             aws.String(s3p.key(key)),
 		MultipartUpload: mpu,
 		UploadId:        aws.String(uploadID),
 	})
 
-	return err
+	if err != nil || res.ETag == nil {
+		return "", err
+	}
+
+	return *res.ETag, nil
 }
 
-func (s3p awsTablePersister) uploadParts(ctx context.Context, data []byte, key, uploadID string) (*s3.CompletedMultipartUpload, error) {
+// uploadParts uploads the parts of |data| which |state| doesn't record as acknowledged, recording each part as it's
+// acknowledged.
+func (s3p awsTablePersister) uploadParts(ctx context.Context, data []byte, key string, state *PushState) (*s3.CompletedMultipartUpload, error) {
 	sent, failed, done := make(chan s3UploadedPart), make(chan error), make(chan struct{})
 
 	numParts := getNumParts(uint64(len(data)), s3p.limits.partTarget)
@@ -220,11 +315,23 @@ func (s3p awsTablePersister) uploadParts(ctx context.Context, data []byte, key,
 		if partNum == numParts { // If this is the last part, make sure it includes any overflow
 			end = uint64(len(data))
 		}
-		etag, err := s3p.uploadPart(ctx, data[start:end], key, uploadID, int64(partNum))
-		if err != nil {
-			failed <- err
-			return
+
+		part, acked := state.Part(int(partNum))
+		if !acked || part.Offset != start || part.Length != end-start {
+			partMD5 := md5.Sum(data[start:end])
+			etag, err := s3p.uploadPart(ctx, data[start:end], partMD5[:], key, state.UploadID, int64(partNum))
+
+			if err == nil {
+				part = UploadedPart{Number: int(partNum), Offset: start, Length: end - start, MD5: partMD5[:], ETag: etag}
+				err = state.Acknowledge(part)
+			}
+
+			if err != nil {
+				failed <- err
+				return
+			}
 		}
+		etag := part.ETag
 		// Try to send along part info. In the case that the upload was aborted, reading from done allows this worker to exit correctly.
 		select {
 		case sent <- s3UploadedPart{int64(partNum), etag}:
@@ -295,7 +402,7 @@ func (s partsByPartNum) Swap(i, j int) {
 }
 
 func (s3p awsTablePersister) ConjoinAll(ctx context.Context, sources chunkSources, stats *Stats) (chunkSource, error) {
-	plan, err := planConjoin(sources, stats)
+	plan, err := planConjoin(ctx, sources, stats)
 
 	if err != nil {
 		return nil, err
@@ -354,7 +461,8 @@ func (s3p awsTablePersister) executeCompactionPlan(ctx context.Context, plan com
 		return err
 	}
 
-	return s3p.completeMultipartUpload(ctx, key, uploadID, multipartUpload)
+	_, err = s3p.completeMultipartUpload(ctx, key, uploadID, multipartUpload)
+	return err
 }
 
 func (s3p awsTablePersister) assembleTable(ctx context.Context, plan compactionPlan, key, uploadID string) (*s3.CompletedMultipartUpload, error) {
@@ -442,7 +550,8 @@ func (s3p awsTablePersister) assembleTable(ctx context.Context, plan compactionP
 		uploadWg.Add(1)
 		go func(data []byte, partNum int64) {
 			sendPart(partNum, func() (etag string, err error) {
-				return s3p.uploadPart(ctx, data, key, uploadID, partNum)
+				partMD5 := md5.Sum(data)
+				return s3p.uploadPart(ctx, data, partMD5[:], key, uploadID, partNum)
 			})
 		}(buff[start:end], partNum)
 		partNum++
@@ -588,13 +697,15 @@ func (s3p awsTablePersister) uploadPartCopy(ctx context.Context, src string, src
 	return
 }
 
-func (s3p awsTablePersister) uploadPart(ctx context.Context, data []byte, key, uploadID string, partNum int64) (etag string, err error) {
+// uploadPart uploads a part with the MD5 |partMD5|, which S3 checks the part it receives against.
+func (s3p awsTablePersister) uploadPart(ctx context.Context, data, partMD5 []byte, key, uploadID string, partNum int64) (etag string, err error) {
 	res, err := s3p.s3.UploadPartWithContext(ctx, &s3.UploadPartInput{
 		Bucket:     aws.String(s3p.bucket),
 		Key:        aws.String(s3p.key(key)),
 		PartNumber: aws.Int64(int64(partNum)),
 		UploadId:   aws.String(uploadID),
 		Body:       bytes.NewReader(data),
+		ContentMD5: aws.String(base64.StdEncoding.EncodeToString(partMD5)),
 	})
 	if err == nil {
 		etag = *res.ETag
@@ -296,7 +296,7 @@ func TestAWSTablePersisterDividePlan(t *testing.T) {
 	tooBig := bytesToChunkSource(t, bigUns...)
 
 	sources := chunkSources{justRight, tooBig, tooSmall}
-	plan, err := planConjoin(sources, &Stats{})
+	plan, err := planConjoin(context.Background(), sources, &Stats{})
 	assert.NoError(err)
 	copies, manuals, _, err := dividePlan(context.Background(), plan, minPartSize, maxPartSize)
 	assert.NoError(err)
@@ -356,7 +356,7 @@ func TestAWSTablePersisterConjoinAll(t *testing.T) {
 	defer close(rl)
 
 	newPersister := func(s3svc s3svc, ddb *ddbTableStore) awsTablePersister {
-		return awsTablePersister{s3svc, "bucket", rl, nil, ddb, awsLimits{targetPartSize, minPartSize, maxPartSize, maxItemSize, maxChunkCount}, ic, ""}
+		return awsTablePersister{s3svc, "bucket", rl, nil, ddb, awsLimits{targetPartSize, minPartSize, maxPartSize, maxItemSize, maxChunkCount}, ic, "", PushStateStore{}}
 	}
 
 	var smallChunks [][]byte
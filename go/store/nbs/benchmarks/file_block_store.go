@@ -50,6 +50,14 @@ func (fb fileBlockStore) GetMany(ctx context.Context, hashes hash.HashSet, found
 	panic("not impl")
 }
 
+func (fb fileBlockStore) GetManyF(ctx context.Context, hashes hash.HashSet, found func(*chunks.Chunk)) error {
+	panic("not impl")
+}
+
+func (fb fileBlockStore) GetManyCompressed(ctx context.Context, hashes hash.HashSet, foundCmpChunks chan<- chunks.CompressedChunk) error {
+	panic("not impl")
+}
+
 func (fb fileBlockStore) Has(ctx context.Context, h hash.Hash) (bool, error) {
 	panic("not impl")
 }
@@ -63,6 +71,10 @@ func (fb fileBlockStore) Put(ctx context.Context, c chunks.Chunk) error {
 	return err
 }
 
+func (fb fileBlockStore) PutMany(ctx context.Context, chnks []chunks.Chunk) error {
+	return chunks.PutManyWithPut(ctx, chnks, fb.Put)
+}
+
 func (fb fileBlockStore) Version() string {
 	panic("not impl")
 }
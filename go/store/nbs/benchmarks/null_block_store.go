@@ -43,6 +43,14 @@ func (nb nullBlockStore) GetMany(ctx context.Context, hashes hash.HashSet, found
 	panic("not impl")
 }
 
+func (nb nullBlockStore) GetManyF(ctx context.Context, hashes hash.HashSet, found func(*chunks.Chunk)) error {
+	panic("not impl")
+}
+
+func (nb nullBlockStore) GetManyCompressed(ctx context.Context, hashes hash.HashSet, foundCmpChunks chan<- chunks.CompressedChunk) error {
+	panic("not impl")
+}
+
 func (nb nullBlockStore) Has(ctx context.Context, h hash.Hash) (bool, error) {
 	panic("not impl")
 }
@@ -55,6 +63,10 @@ func (nb nullBlockStore) Put(ctx context.Context, c chunks.Chunk) error {
 	return nil
 }
 
+func (nb nullBlockStore) PutMany(ctx context.Context, chnks []chunks.Chunk) error {
+	return nil
+}
+
 func (nb nullBlockStore) Version() string {
 	panic("not impl")
 }
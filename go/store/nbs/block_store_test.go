@@ -128,6 +128,31 @@ func (suite *BlockStoreSuite) TestChunkStorePut() {
 	}
 }
 
+func (suite *BlockStoreSuite) TestChunkStoreCanceledContext() {
+	c := chunks.NewChunk([]byte("abc"))
+	err := suite.store.Put(context.Background(), c)
+	suite.NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = suite.store.Put(ctx, chunks.NewChunk([]byte("def")))
+	suite.Equal(context.Canceled, err)
+	_, err = suite.store.Has(ctx, c.Hash())
+	suite.Equal(context.Canceled, err)
+	_, err = suite.store.HasMany(ctx, hash.NewHashSet(c.Hash()))
+	suite.Equal(context.Canceled, err)
+	err = suite.store.Rebase(ctx)
+	suite.Equal(context.Canceled, err)
+
+	has, err := suite.store.Has(context.Background(), chunks.NewChunk([]byte("def")).Hash())
+	suite.NoError(err)
+	suite.False(has)
+	if suite.putCountFn != nil {
+		suite.Equal(1, suite.putCountFn())
+	}
+}
+
 func (suite *BlockStoreSuite) TestChunkStorePutMany() {
 	input1, input2 := []byte("abc"), []byte("def")
 	c1, c2 := chunks.NewChunk(input1), chunks.NewChunk(input2)
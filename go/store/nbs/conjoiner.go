@@ -29,6 +29,7 @@ import (
 	"time"
 
 	"github.com/liquidata-inc/dolt/go/store/atomicerr"
+	"github.com/liquidata-inc/dolt/go/store/background"
 )
 
 type conjoiner interface {
@@ -49,14 +50,31 @@ type conjoiner interface {
 
 type inlineConjoiner struct {
 	maxTables int
+
+	// sched schedules the IO of the conjoins. nil schedules it with background.DefaultScheduler.
+	sched *background.Scheduler
 }
 
 func (c inlineConjoiner) ConjoinRequired(ts tableSet) bool {
 	return ts.Size() > c.maxTables
 }
 
+// Conjoin conjoins the tables as a background task of ConjoinPriority, unless |ctx| carries a task, such as a push
+// which committed the tables, in which case they are conjoined as part of it.
 func (c inlineConjoiner) Conjoin(ctx context.Context, upstream manifestContents, mm manifestUpdater, p tablePersister, stats *Stats) (manifestContents, error) {
-	return conjoin(ctx, upstream, mm, p, stats)
+	if background.TaskFromContext(ctx) != nil {
+		return conjoin(ctx, upstream, mm, p, stats)
+	}
+
+	sched := c.sched
+	if sched == nil {
+		sched = background.DefaultScheduler
+	}
+
+	task := sched.Start("conjoin", background.ConjoinPriority)
+	defer task.Done()
+
+	return conjoin(background.WithTask(ctx, task), upstream, mm, p, stats)
 }
 
 func conjoin(ctx context.Context, upstream manifestContents, mm manifestUpdater, p tablePersister, stats *Stats) (manifestContents, error) {
@@ -25,11 +25,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"io/ioutil"
+	"math/rand"
+	"os"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/liquidata-inc/dolt/go/store/background"
+	"github.com/liquidata-inc/dolt/go/store/chunks"
 	"github.com/liquidata-inc/dolt/go/store/constants"
 	"github.com/liquidata-inc/dolt/go/store/hash"
 )
@@ -218,3 +225,113 @@ func (u updatePreemptManifest) Update(ctx context.Context, lastLock addr, newCon
 	}
 	return u.manifest.Update(ctx, lastLock, newContents, stats, writeHook)
 }
+
+// conjoinUnderLoad forces a conjoin of a local store, scheduled by |sched|, while its chunks are read concurrently, and
+// returns how long the conjoin took along with the latencies of the reads made meanwhile.
+func conjoinUnderLoad(t *testing.T, sched *background.Scheduler) (time.Duration, []time.Duration) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewLocalStore(ctx, constants.FormatDefaultString, dir, 1<<20)
+	require.NoError(t, err)
+	defer store.Close()
+
+	// commit 8 tables of 64k of incompressible data each
+	rng := rand.New(rand.NewSource(0))
+	var hashes []hash.Hash
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 16; j++ {
+			data := make([]byte, 4*1024)
+			rng.Read(data)
+			c := chunks.NewChunk(data)
+			require.NoError(t, store.Put(ctx, c))
+			hashes = append(hashes, c.Hash())
+		}
+
+		root, err := store.Root(ctx)
+		require.NoError(t, err)
+		_, err = store.Commit(ctx, root, root)
+		require.NoError(t, err)
+	}
+
+	stop := make(chan struct{})
+	latencies := make(chan []time.Duration)
+	go func() {
+		var lats []time.Duration
+		defer func() {
+			latencies <- lats
+		}()
+
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			start := time.Now()
+			_, err := store.Get(ctx, hashes[i%len(hashes)])
+			lats = append(lats, time.Since(start))
+
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	store.c = inlineConjoiner{maxTables: 4, sched: sched}
+	require.NoError(t, store.Put(ctx, chunks.NewChunk([]byte("force a conjoin"))))
+	root, err := store.Root(ctx)
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = store.Commit(ctx, root, root)
+	require.NoError(t, err)
+	elapsed := time.Since(start)
+
+	close(stop)
+	lats := <-latencies
+	assert.Equal(t, uint64(1), store.Stats().(Stats).ConjoinLatency.Samples())
+
+	return elapsed, lats
+}
+
+func maxLatency(lats []time.Duration) time.Duration {
+	var max time.Duration
+	for _, l := range lats {
+		if l > max {
+			max = l
+		}
+	}
+
+	return max
+}
+
+func TestThrottledConjoin(t *testing.T) {
+	elapsed, lats := conjoinUnderLoad(t, background.NewScheduler())
+	t.Logf("unthrottled conjoin took %v, %d reads made meanwhile took at most %v", elapsed, len(lats), maxLatency(lats))
+
+	// the 512k of the tables are conjoined at 1m a second
+	sched := background.NewScheduler()
+	sched.SetBytesPerSecond(1024 * 1024)
+	throttled, throttledLats := conjoinUnderLoad(t, sched)
+	t.Logf("throttled conjoin took %v, %d reads made meanwhile took at most %v", throttled, len(throttledLats), maxLatency(throttledLats))
+
+	assert.True(t, throttled >= 400*time.Millisecond)
+
+	// the store isn't locked while its tables are conjoined, so the reads aren't held back by the conjoin
+	assert.True(t, len(throttledLats) > 1)
+	assert.True(t, maxLatency(throttledLats) < throttled/2)
+	assert.Empty(t, sched.Tasks())
+}
+
+func TestConjoinPausedBySlowQueries(t *testing.T) {
+	sched := background.NewScheduler()
+	sched.SetPauseLatency(time.Millisecond, 300*time.Millisecond)
+	sched.ObserveLatency(time.Second)
+
+	elapsed, _ := conjoinUnderLoad(t, sched)
+	assert.True(t, elapsed >= 250*time.Millisecond)
+}
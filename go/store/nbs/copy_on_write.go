@@ -0,0 +1,88 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNoStoreToCopy is returned by CopyOnWriteStore when the source directory doesn't hold a store.
+var ErrNoStoreToCopy = errors.New("there is no store to copy")
+
+// ErrCopyDestinationExists is returned by CopyOnWriteStore when the destination directory already holds a store.
+var ErrCopyDestinationExists = errors.New("the destination of the copy already holds a store")
+
+// CopyOnWriteStore makes |destDir| a local store holding the same root and table files as the local store in |srcDir|,
+// without copying the table files. Each table file in the source's manifest is hard linked into |destDir|, or copied
+// when |destDir| is on a different device, and the destination gets its own manifest and lock, so the stores diverge
+// from the first write to either of them.
+//
+// The links are what track the references to the shared files. Local stores never write over a table file, they write
+// new table files beside it and rename them into place, and a table file which is dropped from one store's manifest,
+// by a conjoin or any other rewrite of its tables, only loses that store's link. Its data is freed by the filesystem
+// once neither store links it.
+func CopyOnWriteStore(ctx context.Context, srcDir, destDir string) (err error) {
+	exists, contents, err := fileManifest{srcDir}.ParseIfExists(ctx, &Stats{}, nil)
+
+	if err != nil {
+		return err
+	} else if !exists {
+		return errors.Wrap(ErrNoStoreToCopy, srcDir)
+	}
+
+	if exists, _, err = (fileManifest{destDir}).ParseIfExists(ctx, &Stats{}, nil); err != nil {
+		return err
+	} else if exists {
+		return errors.Wrap(ErrCopyDestinationExists, destDir)
+	}
+
+	if err := os.MkdirAll(destDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	var added []string
+	defer func() {
+		if err != nil {
+			for _, path := range added {
+				_ = os.Remove(path)
+			}
+		}
+	}()
+
+	for _, spec := range contents.specs {
+		name := spec.name.String()
+		destPath := filepath.Join(destDir, name)
+
+		if err = linkTableFile(destDir, filepath.Join(srcDir, name), destPath); err != nil {
+			return err
+		}
+
+		added = append(added, destPath)
+	}
+
+	updated, err := fileManifest{destDir}.Update(ctx, addr{}, contents, &Stats{}, nil)
+
+	if err != nil {
+		return err
+	} else if updated.lock != contents.lock {
+		return errors.Wrap(ErrCopyDestinationExists, destDir)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// commitChunk puts a chunk holding |data| into |st| and commits it as the new root.
+func commitChunk(t *testing.T, st *NomsBlockStore, data string) chunks.Chunk {
+	ctx := context.Background()
+	c := chunks.NewChunk([]byte(data))
+	require.NoError(t, st.Put(ctx, c))
+
+	last, err := st.Root(ctx)
+	require.NoError(t, err)
+	success, err := st.Commit(ctx, c.Hash(), last)
+	require.NoError(t, err)
+	require.True(t, success)
+
+	return c
+}
+
+func TestCopyOnWriteStore(t *testing.T) {
+	ctx := context.Background()
+	src, srcDir := makeLinkTestStore(t)
+	defer os.RemoveAll(srcDir)
+	destDir := filepath.Join(os.TempDir(), uuid.New().String())
+	defer os.RemoveAll(destDir)
+
+	var shared []chunks.Chunk
+	for i := 0; i < 4; i++ {
+		shared = append(shared, commitChunk(t, src, fmt.Sprintf("shared %d", i)))
+	}
+
+	require.NoError(t, CopyOnWriteStore(ctx, srcDir, destDir))
+
+	dest, err := NewLocalStore(ctx, types.Format_Default.VersionString(), destDir, defaultMemTableSize)
+	require.NoError(t, err)
+
+	srcRoot, err := src.Root(ctx)
+	require.NoError(t, err)
+	destRoot, err := dest.Root(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, srcRoot, destRoot)
+
+	_, tblFiles, err := dest.Sources(ctx)
+	require.NoError(t, err)
+	require.Len(t, tblFiles, len(shared))
+	for _, tf := range tblFiles {
+		srcInfo, err := os.Stat(filepath.Join(srcDir, tf.FileID()))
+		require.NoError(t, err)
+		destInfo, err := os.Stat(filepath.Join(destDir, tf.FileID()))
+		require.NoError(t, err)
+		assert.True(t, os.SameFile(srcInfo, destInfo))
+	}
+
+	for _, c := range shared {
+		has, err := dest.Has(ctx, c.Hash())
+		require.NoError(t, err)
+		assert.True(t, has)
+	}
+
+	// writes to either store aren't seen by the other
+	ours := commitChunk(t, dest, "ours")
+	theirs := commitChunk(t, src, "theirs")
+
+	has, err := src.Has(ctx, ours.Hash())
+	require.NoError(t, err)
+	assert.False(t, has)
+	has, err = dest.Has(ctx, theirs.Hash())
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	srcRoot, err = src.Root(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, theirs.Hash(), srcRoot)
+
+	// dropping the copy's table files, as a conjoin or a collection of garbage would, leaves the source intact
+	require.NoError(t, dest.Close())
+	require.NoError(t, os.RemoveAll(destDir))
+
+	reopened, err := NewLocalStore(ctx, types.Format_Default.VersionString(), srcDir, defaultMemTableSize)
+	require.NoError(t, err)
+	for _, c := range append(shared, theirs) {
+		got, err := reopened.Get(ctx, c.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, c.Data(), got.Data())
+	}
+}
+
+func TestCopyOnWriteStoreErrors(t *testing.T) {
+	ctx := context.Background()
+	src, srcDir := makeLinkTestStore(t)
+	defer os.RemoveAll(srcDir)
+	dest, destDir := makeLinkTestStore(t)
+	defer os.RemoveAll(destDir)
+
+	commitChunk(t, src, "chunk")
+	commitChunk(t, dest, "chunk")
+
+	err := CopyOnWriteStore(ctx, srcDir, destDir)
+	assert.Equal(t, ErrCopyDestinationExists, errors.Cause(err))
+
+	emptyDir := filepath.Join(os.TempDir(), uuid.New().String())
+	err = CopyOnWriteStore(ctx, emptyDir, filepath.Join(os.TempDir(), uuid.New().String()))
+	assert.Equal(t, ErrNoStoreToCopy, errors.Cause(err))
+}
@@ -0,0 +1,236 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+)
+
+const (
+	// zstdMagic begins every zstd frame. No snappy encoding begins with these bytes, which is what allows chunk
+	// records compressed with a dictionary to sit alongside snappy encoded ones.
+	zstdMagic = "\x28\xb5\x2f\xfd"
+
+	// maxDictionarySize is the size of the dictionaries trained from sampled chunks.
+	maxDictionarySize = 64 * 1024
+
+	// minDictionarySamplesSize is the least amount of sampled chunk data a dictionary is trained from.
+	minDictionarySamplesSize = 1024
+
+	// maxDictionarySamplesSize bounds the amount of chunk data sampled to train a dictionary.
+	maxDictionarySamplesSize = 100 * maxDictionarySize
+
+	// maxSampledChunkSize is the size of the largest chunk worth sampling. Larger chunks compress well on their own.
+	maxSampledChunkSize = 16 * 1024
+
+	// minDictionaryID is the first dictionary ID which zstd leaves unreserved.
+	minDictionaryID = 32768
+)
+
+// ErrDictionaryCompressedChunk is returned when trying to decode a chunk compressed with a table file's dictionary
+// without access to the table file.
+var ErrDictionaryCompressedChunk = chunks.ErrDictionaryCompressedChunk
+
+func isDictionaryCompressed(data []byte) bool {
+	return len(data) >= len(zstdMagic) && string(data[:len(zstdMagic)]) == zstdMagic
+}
+
+// trainDictionary builds a zstd dictionary from |samples|. It returns nil if there isn't enough data in |samples| to
+// build one.
+func trainDictionary(samples [][]byte) (dict []byte, err error) {
+	// zstd.BuildDict panics when |samples| are too small to yield the few hundred sequences it builds its tables from.
+	defer func() {
+		if r := recover(); r != nil {
+			dict, err = nil, nil
+		}
+	}()
+
+	var history []byte
+	for _, sample := range samples {
+		if len(history)+len(sample) > maxDictionarySize {
+			break
+		}
+
+		history = append(history, sample...)
+	}
+
+	if len(history) < minDictionarySamplesSize {
+		return nil, nil
+	}
+
+	id := minDictionaryID + crc(history)%(1<<31-minDictionaryID)
+	return zstd.BuildDict(zstd.BuildDictOptions{
+		ID:       id,
+		Contents: samples,
+		History:  history,
+		Offsets:  [3]int{1, 4, 8},
+		Level:    zstd.SpeedBetterCompression,
+	})
+}
+
+func dictionaryID(dict []byte) (uint32, error) {
+	d, err := zstd.InspectDictionary(dict)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return d.ID(), nil
+}
+
+// mergeDictionaries returns the distinct dictionaries found in |dictLists|.
+func mergeDictionaries(dictLists ...[][]byte) ([][]byte, error) {
+	var merged [][]byte
+	seen := make(map[uint32][]byte)
+	for _, dicts := range dictLists {
+		for _, dict := range dicts {
+			id, err := dictionaryID(dict)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if existing, ok := seen[id]; ok {
+				if string(existing) != string(dict) {
+					return nil, fmt.Errorf("tables contain different dictionaries with the id %d", id)
+				}
+
+				continue
+			}
+
+			seen[id] = dict
+			merged = append(merged, dict)
+		}
+	}
+
+	return merged, nil
+}
+
+func dictionariesSize(dicts [][]byte) uint64 {
+	size := uint64(2 * uint32Size)
+	for _, dict := range dicts {
+		size += uint32Size + uint64(len(dict))
+	}
+
+	return size
+}
+
+func writeDictionaries(dst []byte, dicts [][]byte) (consumed uint64) {
+	binary.BigEndian.PutUint32(dst[consumed:], uint32(dictionariesSize(dicts)))
+	consumed += uint32Size
+	binary.BigEndian.PutUint32(dst[consumed:], uint32(len(dicts)))
+	consumed += uint32Size
+
+	for _, dict := range dicts {
+		binary.BigEndian.PutUint32(dst[consumed:], uint32(len(dict)))
+		consumed += uint32Size
+		consumed += uint64(copy(dst[consumed:], dict))
+	}
+
+	return consumed
+}
+
+// parses the dictionaries from |buff|, which must hold exactly the dictionaries section of a table file.
+func parseDictionaries(buff []byte) ([][]byte, error) {
+	if len(buff) < 2*uint32Size || uint64(binary.BigEndian.Uint32(buff)) != uint64(len(buff)) {
+		return nil, ErrInvalidTableFile
+	}
+
+	count := binary.BigEndian.Uint32(buff[uint32Size:])
+	pos := uint64(2 * uint32Size)
+
+	dicts := make([][]byte, 0, count)
+	for i := uint32(0); i < count; i++ {
+		if pos+uint32Size > uint64(len(buff)) {
+			return nil, ErrInvalidTableFile
+		}
+
+		length := uint64(binary.BigEndian.Uint32(buff[pos:]))
+		pos += uint32Size
+
+		if pos+length > uint64(len(buff)) {
+			return nil, ErrInvalidTableFile
+		}
+
+		dict := make([]byte, length)
+		copy(dict, buff[pos:])
+		dicts = append(dicts, dict)
+		pos += length
+	}
+
+	return dicts, nil
+}
+
+// dictionaryEncoder is a snappyEncoder which compresses chunks with a zstd dictionary, falling back to snappy for
+// any chunk the dictionary doesn't compress better.
+type dictionaryEncoder struct {
+	enc *zstd.Encoder
+}
+
+func newDictionaryEncoder(dict []byte) (dictionaryEncoder, error) {
+	enc, err := zstd.NewWriter(nil,
+		zstd.WithEncoderDict(dict),
+		zstd.WithEncoderLevel(zstd.SpeedBetterCompression),
+		zstd.WithEncoderCRC(false), // chunk records are already checksummed
+		zstd.WithEncoderConcurrency(1),
+	)
+
+	if err != nil {
+		return dictionaryEncoder{}, err
+	}
+
+	return dictionaryEncoder{enc}, nil
+}
+
+func (de dictionaryEncoder) Encode(dst, src []byte) []byte {
+	compressed := snappy.Encode(dst, src)
+	zstdCompressed := de.enc.EncodeAll(src, nil)
+
+	if len(zstdCompressed) < len(compressed) {
+		return append(compressed[:0], zstdCompressed...)
+	}
+
+	return compressed
+}
+
+// dictionarySection holds the dictionaries of a table file once they've been read.
+type dictionarySection struct {
+	mu      sync.Mutex
+	loaded  bool
+	size    uint64
+	dicts   [][]byte
+	decoder *zstd.Decoder
+}
+
+func (ds *dictionarySection) toChunk(cmp CompressedChunk) (chunks.Chunk, error) {
+	if ds.decoder == nil {
+		return chunks.Chunk{}, ErrDictionaryCompressedChunk
+	}
+
+	data, err := ds.decoder.DecodeAll(cmp.CompressedData, nil)
+
+	if err != nil {
+		return chunks.Chunk{}, err
+	}
+
+	return chunks.NewChunkWithHash(cmp.H, data), nil
+}
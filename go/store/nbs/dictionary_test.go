@@ -0,0 +1,278 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/atomicerr"
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/constants"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// makeRowChunks returns small chunks which resemble one another, the way the leaves of a table's row map do.
+func makeRowChunks(start, count int) [][]byte {
+	statuses := []string{"active", "inactive", "pending"}
+	rows := make([][]byte, count)
+	for i := range rows {
+		id := start + i
+		rows[i] = []byte(fmt.Sprintf(`{"id":%d,"name":"user-%d","email":"user-%d@example.com","status":"%s","created_at":"2020-03-%02dT%02d:00:00Z","score":%d}`,
+			id, id, id, statuses[id%len(statuses)], id%28+1, id%24, id*37%1000))
+	}
+
+	return rows
+}
+
+func buildDictionaryTable(t testing.TB, chunks [][]byte, dict []byte) ([]byte, addr) {
+	totalData := uint64(0)
+	for _, chunk := range chunks {
+		totalData += uint64(len(chunk))
+	}
+
+	buff := make([]byte, maxTableSize(uint64(len(chunks)), totalData)+dictionariesSize([][]byte{dict}))
+	tw, err := newDictionaryTableWriter(buff, dict)
+	require.NoError(t, err)
+
+	for _, chunk := range chunks {
+		tw.addChunk(computeAddr(chunk), chunk)
+	}
+
+	length, name, err := tw.finish()
+	require.NoError(t, err)
+
+	return buff[:length], name
+}
+
+func TestDictionaryTable(t *testing.T) {
+	ctx := context.Background()
+	rows := makeRowChunks(0, 1000)
+	dict, err := trainDictionary(rows)
+	require.NoError(t, err)
+	require.NotNil(t, dict)
+
+	data, _ := buildDictionaryTable(t, rows, dict)
+	snappyData, _, err := buildTable(rows)
+	require.NoError(t, err)
+	assert.Less(t, len(data), len(snappyData))
+
+	ti, err := parseTableIndex(data)
+	require.NoError(t, err)
+	assert.True(t, ti.hasDictionaries)
+
+	tr := newTableReader(ti, tableReaderAtFromBytes(data), fileBlockSize)
+	dicts, err := tr.dictionaries(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{dict}, dicts)
+
+	for _, row := range rows {
+		actual, err := tr.get(ctx, computeAddr(row), &Stats{})
+		require.NoError(t, err)
+		assert.Equal(t, row, actual)
+	}
+
+	// chunks compressed with the dictionary can't be decoded without it
+	var dictCompressed int
+	for i := uint32(0); i < ti.chunkCount; i++ {
+		cmp, err := NewCompressedChunk(hash.Hash{}, data[ti.offsets[i]:ti.offsets[i]+uint64(ti.lengths[i])])
+		require.NoError(t, err)
+
+		if isDictionaryCompressed(cmp.CompressedData) {
+			dictCompressed++
+			_, err = cmp.ToChunk()
+			assert.Equal(t, ErrDictionaryCompressedChunk, err)
+		}
+	}
+	assert.Equal(t, len(rows), dictCompressed)
+
+	// so compressed chunks are handed out snappy encoded
+	hashes := hash.HashSet{}
+	for _, row := range rows {
+		hashes.Insert(hash.Of(row))
+	}
+	cmpChunks := make(chan CompressedChunk, len(rows))
+	wg := &sync.WaitGroup{}
+	ae := atomicerr.New()
+	remaining := tr.getManyCompressed(ctx, toGetRecords(hashes), cmpChunks, wg, ae, &Stats{})
+	wg.Wait()
+	close(cmpChunks)
+	require.NoError(t, ae.Get())
+	assert.False(t, remaining)
+
+	var found int
+	for cmp := range cmpChunks {
+		chk, err := cmp.ToChunk()
+		require.NoError(t, err)
+		assert.Equal(t, cmp.H, hash.Of(chk.Data()))
+		found++
+	}
+	assert.Equal(t, len(rows), found)
+
+	rd, err := tr.reader(ctx)
+	require.NoError(t, err)
+	contents, err := ioutil.ReadAll(rd)
+	require.NoError(t, err)
+	assert.Equal(t, data, contents)
+}
+
+func TestConjoinKeepsDictionaries(t *testing.T) {
+	ctx := context.Background()
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	fc := newFDCache(defaultMaxTables)
+	defer fc.Drop()
+	fts := newFSTablePersister(dir, fc, nil)
+
+	rows := makeRowChunks(0, 300)
+	dict, err := trainDictionary(makeRowChunks(1000, 1000))
+	require.NoError(t, err)
+	require.NotNil(t, dict)
+
+	persist := func(data []byte, name addr, count int) chunkSource {
+		src, err := fts.(*fsTablePersister).persistTable(ctx, name, data, uint32(count), &Stats{})
+		require.NoError(t, err)
+		return src
+	}
+
+	data1, name1 := buildDictionaryTable(t, rows[:100], dict)
+	data2, name2 := buildDictionaryTable(t, rows[100:200], dict)
+	data3, name3, err := buildTable(rows[200:])
+	require.NoError(t, err)
+	sources := chunkSources{persist(data1, name1, 100), persist(data2, name2, 100), persist(data3, name3, 100)}
+
+	conjoined, err := fts.ConjoinAll(ctx, sources, &Stats{})
+	require.NoError(t, err)
+
+	index, err := conjoined.index()
+	require.NoError(t, err)
+	assert.True(t, index.hasDictionaries)
+	dicts, err := conjoined.dictionaries(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{dict}, dicts)
+
+	for _, row := range rows {
+		actual, err := conjoined.get(ctx, computeAddr(row), &Stats{})
+		require.NoError(t, err)
+		assert.Equal(t, row, actual)
+	}
+}
+
+func TestRecompress(t *testing.T) {
+	ctx := context.Background()
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	store, err := NewLocalStore(ctx, constants.FormatDefaultString, dir, defaultMemTableSize)
+	require.NoError(t, err)
+
+	var rows [][]byte
+	for i := 0; i < 2; i++ {
+		batch := makeRowChunks(i*1000, 1000)
+		for _, row := range batch {
+			require.NoError(t, store.Put(ctx, chunks.NewChunk(row)))
+		}
+
+		last, err := store.Root(ctx)
+		require.NoError(t, err)
+		ok, err := store.Commit(ctx, hash.Of(batch[0]), last)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		rows = append(rows, batch...)
+	}
+
+	root, err := store.Root(ctx)
+	require.NoError(t, err)
+
+	stats, err := store.Recompress(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Tables)
+	assert.Equal(t, 1, stats.NewTables)
+	assert.Equal(t, uint64(len(rows)), stats.Chunks)
+	assert.Less(t, stats.SizeAfter, stats.SizeBefore)
+
+	assertRows := func(store *NomsBlockStore) {
+		actualRoot, err := store.Root(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, root, actualRoot)
+
+		for _, row := range rows {
+			chk, err := store.Get(ctx, hash.Of(row))
+			require.NoError(t, err)
+			assert.Equal(t, row, chk.Data())
+		}
+	}
+
+	assertRows(store)
+	require.NoError(t, store.Close())
+
+	store, err = NewLocalStore(ctx, constants.FormatDefaultString, dir, defaultMemTableSize)
+	require.NoError(t, err)
+	assertRows(store)
+
+	_, tableFiles, err := store.Sources(ctx)
+	require.NoError(t, err)
+	var size uint64
+	for _, tf := range tableFiles {
+		rd, err := tf.Open()
+		require.NoError(t, err)
+		contents, err := ioutil.ReadAll(rd)
+		require.NoError(t, err)
+		require.NoError(t, rd.Close())
+		size += uint64(len(contents))
+	}
+	assert.Equal(t, stats.SizeAfter, size)
+}
+
+// BenchmarkDictionaryCompression reports the size of a table of small, similar chunks when compressed with snappy
+// alone, and with a dictionary trained on a sample of its chunks.
+func BenchmarkDictionaryCompression(b *testing.B) {
+	rows := makeRowChunks(0, 10000)
+
+	b.Run("Snappy", func(b *testing.B) {
+		var size int
+		for i := 0; i < b.N; i++ {
+			data, _, err := buildTable(rows)
+			require.NoError(b, err)
+			size = len(data)
+		}
+		b.ReportMetric(float64(size), "table-bytes")
+	})
+
+	b.Run("Dictionary", func(b *testing.B) {
+		var samples [][]byte
+		for i := 0; i < len(rows); i += 10 {
+			samples = append(samples, rows[i])
+		}
+
+		var size int
+		for i := 0; i < b.N; i++ {
+			dict, err := trainDictionary(samples)
+			require.NoError(b, err)
+			data, _ := buildDictionaryTable(b, rows, dict)
+			size = len(data)
+		}
+		b.ReportMetric(float64(size), "table-bytes")
+	})
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 
+	"github.com/liquidata-inc/dolt/go/store/chunks"
 	"github.com/liquidata-inc/dolt/go/store/d"
 	"github.com/liquidata-inc/dolt/go/store/hash"
 )
@@ -93,6 +94,10 @@ func (dm dynamoManifest) ParseIfExists(ctx context.Context, stats *Stats, readHo
 
 	// !exists(dbAttr) => unitialized store
 	if len(result.Item) > 0 {
+		if vers := result.Item[nbsVersAttr]; vers != nil && vers.S != nil && *vers.S != StorageVersion {
+			return false, contents, &chunks.ErrIncompatibleFormat{Found: *vers.S, Supported: []string{StorageVersion}}
+		}
+
 		valid, hasSpecs := validateManifest(result.Item)
 		if !valid {
 			return false, contents, ErrCorruptManifest
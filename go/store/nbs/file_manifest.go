@@ -33,6 +33,7 @@ import (
 
 	"github.com/juju/fslock"
 
+	"github.com/liquidata-inc/dolt/go/store/chunks"
 	"github.com/liquidata-inc/dolt/go/store/hash"
 )
 
@@ -187,7 +188,7 @@ func parseManifest(r io.Reader) (manifestContents, error) {
 	}
 
 	if StorageVersion != string(slices[0]) {
-		return manifestContents{}, errors.New("invalid storage version")
+		return manifestContents{}, &chunks.ErrIncompatibleFormat{Found: string(slices[0]), Supported: []string{StorageVersion}}
 	}
 
 	specs, err := parseSpecs(slices[4:])
@@ -30,6 +30,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/liquidata-inc/dolt/go/store/background"
 	"github.com/liquidata-inc/dolt/go/store/d"
 )
 
@@ -129,7 +130,7 @@ func (ftp *fsTablePersister) persistTable(ctx context.Context, name addr, data [
 }
 
 func (ftp *fsTablePersister) ConjoinAll(ctx context.Context, sources chunkSources, stats *Stats) (chunkSource, error) {
-	plan, err := planConjoin(sources, stats)
+	plan, err := planConjoin(ctx, sources, stats)
 
 	if err != nil {
 		return emptyChunkSource{}, err
@@ -156,6 +157,12 @@ func (ftp *fsTablePersister) ConjoinAll(ctx context.Context, sources chunkSource
 			}
 		}()
 
+		// the conjoined table is written at the pace allowed by the background task of the conjoin, if there is one
+		if t := background.TaskFromContext(ctx); t != nil {
+			t.SetTotal(int64(plan.totalCompressedData) + int64(len(plan.mergedIndex)))
+		}
+
+		w := background.NewWriter(ctx, temp)
 		for _, sws := range plan.sources.sws {
 			var r io.Reader
 			r, ferr = sws.source.reader(ctx)
@@ -164,7 +171,7 @@ func (ftp *fsTablePersister) ConjoinAll(ctx context.Context, sources chunkSource
 				return "", ferr
 			}
 
-			n, ferr := io.CopyN(temp, r, int64(sws.dataLen))
+			n, ferr := io.CopyN(w, r, int64(sws.dataLen))
 
 			if ferr != nil {
 				return "", ferr
@@ -175,7 +182,7 @@ func (ftp *fsTablePersister) ConjoinAll(ctx context.Context, sources chunkSource
 			}
 		}
 
-		_, ferr = temp.Write(plan.mergedIndex)
+		_, ferr = w.Write(plan.mergedIndex)
 
 		if ferr != nil {
 			return "", ferr
@@ -40,6 +40,7 @@ func NewNBSMetricWrapper(nbs *NomsBlockStore) *NBSMetricWrapper {
 }
 
 var _ TableFileStore = &NBSMetricWrapper{}
+var _ Recompressor = &NBSMetricWrapper{}
 
 // Sources retrieves the current root hash, and a list of all the table files
 func (nbsMW *NBSMetricWrapper) Sources(ctx context.Context) (hash.Hash, []TableFile, error) {
@@ -56,6 +57,11 @@ func (nbsMW *NBSMetricWrapper) SetRootChunk(ctx context.Context, root, previous
 	return nbsMW.nbs.SetRootChunk(ctx, root, previous)
 }
 
+// Recompress forwards to the wrapped block store.
+func (nbsMW *NBSMetricWrapper) Recompress(ctx context.Context) (RecompressStats, error) {
+	return nbsMW.nbs.Recompress(ctx)
+}
+
 // Forwards SupportedOperations to wrapped block store.
 func (nbsMW *NBSMetricWrapper) SupportedOperations() TableFileStoreOps {
 	return nbsMW.nbs.SupportedOperations()
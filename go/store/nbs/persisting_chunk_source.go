@@ -206,6 +206,20 @@ func (ccs *persistingChunkSource) index() (tableIndex, error) {
 	return ccs.cs.index()
 }
 
+func (ccs *persistingChunkSource) dictionaries(ctx context.Context) ([][]byte, error) {
+	err := ccs.wait()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ccs.cs == nil {
+		return nil, ErrNoChunkSource
+	}
+
+	return ccs.cs.dictionaries(ctx)
+}
+
 func (ccs *persistingChunkSource) reader(ctx context.Context) (io.Reader, error) {
 	err := ccs.wait()
 
@@ -286,6 +300,10 @@ func (ecs emptyChunkSource) index() (tableIndex, error) {
 	return tableIndex{}, nil
 }
 
+func (ecs emptyChunkSource) dictionaries(context.Context) ([][]byte, error) {
+	return nil, nil
+}
+
 func (ecs emptyChunkSource) reader(context.Context) (io.Reader, error) {
 	return &bytes.Buffer{}, nil
 }
@@ -0,0 +1,219 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const pushStateExt = ".push"
+
+// ErrUploadHashMismatch is returned when the hash a remote reports for an uploaded table file doesn't match the hash of
+// the table file which was uploaded.
+var ErrUploadHashMismatch = errors.New("the remote's hash of the uploaded table file doesn't match")
+
+// PushStateStore keeps the state of the uploads of table files in a directory, in a file named by the id of each
+// table file, so that an upload which fails partway can be resumed from its last acknowledged part by a later push.
+// The zero PushStateStore keeps no state on disk, so uploads are only resumed within the call which started them.
+type PushStateStore struct {
+	dir string
+}
+
+// NewPushStateStore returns a PushStateStore which keeps the state of uploads in |dir|, which is created if it doesn't
+// exist when a state is first saved.
+func NewPushStateStore(dir string) PushStateStore {
+	return PushStateStore{dir}
+}
+
+// persistent returns whether the store keeps the state of uploads on disk.
+func (s PushStateStore) persistent() bool {
+	return s.dir != ""
+}
+
+// UploadedPart is a part of a table file which the remote has acknowledged.
+type UploadedPart struct {
+	Number int    `json:"number"`
+	Offset uint64 `json:"offset"`
+	Length uint64 `json:"length"`
+	MD5    []byte `json:"md5"`
+	ETag   string `json:"etag,omitempty"`
+}
+
+// PushState is the state of the upload of a table file, which records the parts of the file which the remote has
+// acknowledged. Each change to the state is saved before its method returns. It's safe for concurrent use.
+type PushState struct {
+	FileID        string         `json:"file_id"`
+	ContentLength uint64         `json:"content_length"`
+	ContentHash   []byte         `json:"content_hash"`
+	PartSize      uint64         `json:"part_size"`
+	UploadID      string         `json:"upload_id,omitempty"`
+	Parts         []UploadedPart `json:"parts"`
+
+	path string
+	mu   sync.Mutex
+}
+
+// Load returns the state of the upload of the table file |fileID|. A new state is returned if there isn't a saved one,
+// or if the saved state can't be read or is for an upload of different content or in parts of a different size.
+func (s PushStateStore) Load(fileID string, contentLength uint64, contentHash []byte, partSize uint64) (*PushState, error) {
+	fresh := &PushState{FileID: fileID, ContentLength: contentLength, ContentHash: contentHash, PartSize: partSize}
+
+	if s.dir == "" {
+		return fresh, nil
+	}
+
+	fresh.path = filepath.Join(s.dir, fileID+pushStateExt)
+	data, err := ioutil.ReadFile(fresh.path)
+
+	if os.IsNotExist(err) {
+		return fresh, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var saved PushState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fresh, nil
+	}
+
+	if saved.FileID != fileID || saved.ContentLength != contentLength || !bytes.Equal(saved.ContentHash, contentHash) || saved.PartSize != partSize {
+		return fresh, nil
+	}
+
+	saved.path = fresh.path
+	return &saved, nil
+}
+
+// Part returns the acknowledged part with the number given, if there is one.
+func (ps *PushState) Part(number int) (UploadedPart, bool) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for _, part := range ps.Parts {
+		if part.Number == number {
+			return part, true
+		}
+	}
+
+	return UploadedPart{}, false
+}
+
+// Acknowledge records that the remote has acknowledged |part|, replacing any earlier part with its number.
+func (ps *PushState) Acknowledge(part UploadedPart) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for i := range ps.Parts {
+		if ps.Parts[i].Number == part.Number {
+			ps.Parts[i] = part
+			return ps.save()
+		}
+	}
+
+	ps.Parts = append(ps.Parts, part)
+	return ps.save()
+}
+
+// Truncate forgets the acknowledged parts which end after |offset|.
+func (ps *PushState) Truncate(offset uint64) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	parts := ps.Parts[:0]
+	for _, part := range ps.Parts {
+		if part.Offset+part.Length <= offset {
+			parts = append(parts, part)
+		}
+	}
+
+	ps.Parts = parts
+	return ps.save()
+}
+
+// SetUploadID records the id the remote gave the upload, and forgets the parts acknowledged for any earlier upload.
+func (ps *PushState) SetUploadID(uploadID string) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.UploadID = uploadID
+	ps.Parts = nil
+	return ps.save()
+}
+
+// Remove deletes the saved state, once the upload has completed or can't be resumed.
+func (ps *PushState) Remove() error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if ps.path == "" {
+		return nil
+	}
+
+	err := os.Remove(ps.path)
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// save writes the state to a temporary file which is renamed over the saved state, so that a push which is killed
+// while the state is saved leaves the earlier state. ps.mu must be held.
+func (ps *PushState) save() error {
+	if ps.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(ps)
+
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(ps.path)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(dir, filepath.Base(ps.path))
+
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	closeErr := f.Close()
+
+	if err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(f.Name(), ps.path)
+	}
+
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+
+	return err
+}
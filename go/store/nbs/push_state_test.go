@@ -0,0 +1,177 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"context"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "push_state")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store := NewPushStateStore(filepath.Join(dir, "push"))
+	hash := []byte("content hash")
+
+	state, err := store.Load("file", 100, hash, 40)
+	require.NoError(t, err)
+	require.NoError(t, state.SetUploadID("upload"))
+	require.NoError(t, state.Acknowledge(UploadedPart{Number: 1, Offset: 0, Length: 40, ETag: "a"}))
+	require.NoError(t, state.Acknowledge(UploadedPart{Number: 2, Offset: 40, Length: 40, ETag: "b"}))
+
+	loaded, err := store.Load("file", 100, hash, 40)
+	require.NoError(t, err)
+	assert.Equal(t, "upload", loaded.UploadID)
+	part, ok := loaded.Part(2)
+	assert.True(t, ok)
+	assert.Equal(t, "b", part.ETag)
+
+	require.NoError(t, loaded.Truncate(60))
+	_, ok = loaded.Part(2)
+	assert.False(t, ok)
+	_, ok = loaded.Part(1)
+	assert.True(t, ok)
+
+	// the state of an upload of other content, or in parts of another size, isn't resumed
+	for _, other := range []struct {
+		length   uint64
+		hash     []byte
+		partSize uint64
+	}{{101, hash, 40}, {100, []byte("other hash"), 40}, {100, hash, 50}} {
+		fresh, err := store.Load("file", other.length, other.hash, other.partSize)
+		require.NoError(t, err)
+		assert.Empty(t, fresh.UploadID)
+		assert.Empty(t, fresh.Parts)
+	}
+
+	require.NoError(t, loaded.Remove())
+	fresh, err := store.Load("file", 100, hash, 40)
+	require.NoError(t, err)
+	assert.Empty(t, fresh.Parts)
+}
+
+// flakyFakeS3 fails uploads of parts at random, and counts the uploads of each part which succeed.
+type flakyFakeS3 struct {
+	*fakeS3
+	mu       sync.Mutex
+	rand     *rand.Rand
+	failRate float64
+	uploads  map[int64]int
+	badETag  bool
+}
+
+func (m *flakyFakeS3) UploadPartWithContext(ctx aws.Context, input *s3.UploadPartInput, opts ...request.Option) (*s3.UploadPartOutput, error) {
+	m.mu.Lock()
+	fail := m.rand.Float64() < m.failRate
+	m.mu.Unlock()
+
+	if fail {
+		return nil, mockAWSError("RequestTimeout")
+	}
+
+	out, err := m.fakeS3.UploadPartWithContext(ctx, input)
+
+	if err == nil {
+		m.mu.Lock()
+		m.uploads[*input.PartNumber]++
+		m.mu.Unlock()
+	}
+
+	return out, err
+}
+
+func (m *flakyFakeS3) CompleteMultipartUploadWithContext(ctx aws.Context, input *s3.CompleteMultipartUploadInput, opts ...request.Option) (*s3.CompleteMultipartUploadOutput, error) {
+	out, err := m.fakeS3.CompleteMultipartUploadWithContext(ctx, input)
+
+	if err == nil && m.badETag {
+		out.ETag = aws.String(fmt.Sprintf("\"%x-%d\"", md5.Sum(nil), len(input.MultipartUpload.Parts)))
+	}
+
+	return out, err
+}
+
+func TestAWSTablePersisterResumesUpload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "push_state")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	data := make([]byte, 64*1024)
+	rand.New(rand.NewSource(0)).Read(data)
+	const partSize = 1024
+	numParts := len(data) / partSize
+
+	newPersister := func(s3svc s3svc) awsTablePersister {
+		return awsTablePersister{s3: s3svc, bucket: "bucket", limits: awsLimits{partTarget: partSize}, pushStates: NewPushStateStore(dir)}
+	}
+
+	t.Run("RandomFailures", func(t *testing.T) {
+		s3svc := &flakyFakeS3{fakeS3: makeFakeS3(t), rand: rand.New(rand.NewSource(1)), failRate: 0.05, uploads: map[int64]int{}}
+		s3p := newPersister(s3svc)
+
+		attempts := 0
+		for err = errors.New("not uploaded"); err != nil && attempts < 100; attempts++ {
+			err = s3p.multipartUpload(context.Background(), data, "table")
+		}
+
+		require.NoError(t, err)
+		assert.True(t, attempts > 1, "no part upload failed")
+		assert.Equal(t, data, s3svc.data["table"])
+
+		// every part was uploaded exactly once, despite the failures
+		assert.Len(t, s3svc.uploads, numParts)
+		for partNum, count := range s3svc.uploads {
+			assert.Equal(t, 1, count, "part %d was uploaded %d times", partNum, count)
+		}
+
+		files, err := ioutil.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Empty(t, files)
+	})
+
+	t.Run("ExpiredUpload", func(t *testing.T) {
+		contentHash := md5.Sum(data)
+		state, err := NewPushStateStore(dir).Load("table", uint64(len(data)), contentHash[:], partSize)
+		require.NoError(t, err)
+		require.NoError(t, state.SetUploadID("expired"))
+		require.NoError(t, state.Acknowledge(UploadedPart{Number: 1, Offset: 0, Length: partSize, ETag: "expired"}))
+
+		s3svc := &flakyFakeS3{fakeS3: makeFakeS3(t), rand: rand.New(rand.NewSource(1)), uploads: map[int64]int{}}
+		require.NoError(t, newPersister(s3svc).multipartUpload(context.Background(), data, "table"))
+		assert.Equal(t, data, s3svc.data["table"])
+		assert.Len(t, s3svc.uploads, numParts)
+	})
+
+	t.Run("ETagMismatch", func(t *testing.T) {
+		s3svc := &flakyFakeS3{fakeS3: makeFakeS3(t), rand: rand.New(rand.NewSource(1)), uploads: map[int64]int{}, badETag: true}
+		err := newPersister(s3svc).multipartUpload(context.Background(), data, "table")
+		assert.True(t, errors.Is(err, ErrUploadHashMismatch), "unexpected error %v", err)
+	})
+}
@@ -0,0 +1,331 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrRecompressUnsupported is returned when recompressing a store whose table files aren't kept on the local
+// filesystem.
+var ErrRecompressUnsupported = errors.New("recompressing table files is only supported for local databases")
+
+// ErrRecompressPendingWrites is returned when recompressing a store which holds chunks that haven't been committed.
+var ErrRecompressPendingWrites = errors.New("cannot recompress a database with uncommitted chunks")
+
+var errRecompressManifestChanged = errors.New("the database was modified while recompressing, try again")
+
+// Recompressor is implemented by chunk stores which can recompress their table files.
+type Recompressor interface {
+	Recompress(ctx context.Context) (RecompressStats, error)
+}
+
+// RecompressStats describes the table files rewritten by Recompress.
+type RecompressStats struct {
+	// Tables is the number of table files which were rewritten, and NewTables the number they were rewritten into
+	Tables, NewTables int
+	Chunks            uint64
+	DictionarySize    int
+	SizeBefore        uint64
+	SizeAfter         uint64
+}
+
+// maxRecompressedTableSize bounds the uncompressed size of the chunks rewritten into each table by Recompress, as
+// each new table is built in memory.
+const maxRecompressedTableSize = 1 << 28
+
+// Recompress trains a zstd dictionary on a sample of the store's chunks and rewrites the table files, compressing
+// each chunk with the dictionary wherever it beats snappy. Tables are combined as they're rewritten, so that fewer
+// copies of the dictionary are stored, and are left as they are if rewriting them wouldn't make them any smaller.
+// The rewritten tables hold exactly the same chunks, and replace the originals in the manifest. If the store doesn't
+// hold enough chunk data to train a dictionary, nothing is rewritten.
+func (nbs *NomsBlockStore) Recompress(ctx context.Context) (stats RecompressStats, err error) {
+	fsPersister, ok := nbs.p.(*fsTablePersister)
+
+	if !ok {
+		return RecompressStats{}, ErrRecompressUnsupported
+	}
+
+	nbs.mm.LockForUpdate()
+	defer func() {
+		unlockErr := nbs.mm.UnlockForUpdate()
+
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+
+	nbs.mu.Lock()
+	defer nbs.mu.Unlock()
+
+	if nbs.tables.Novel() > 0 {
+		return RecompressStats{}, ErrRecompressPendingWrites
+	} else if nbs.mt != nil {
+		cnt, err := nbs.mt.count()
+
+		if err != nil {
+			return RecompressStats{}, err
+		} else if cnt > 0 {
+			return RecompressStats{}, ErrRecompressPendingWrites
+		}
+	}
+
+	ok, contents, err := nbs.mm.Fetch(ctx, nbs.stats)
+
+	if err != nil || !ok {
+		return RecompressStats{}, err
+	}
+
+	sources := make(chunkSources, 0, len(contents.specs))
+	for _, spec := range contents.specs {
+		src, err := nbs.p.Open(ctx, spec.name, spec.chunkCount, nbs.stats)
+
+		if err != nil {
+			return RecompressStats{}, err
+		}
+
+		sources = append(sources, src)
+	}
+
+	dict, err := trainDictionary(sampleChunks(ctx, sources))
+
+	if err != nil || dict == nil {
+		return RecompressStats{}, err
+	}
+
+	stats.DictionarySize = len(dict)
+	specs := make([]tableSpec, 0, len(sources))
+	for start := 0; start < len(sources); {
+		end := start + 1
+		groupSize, err := sources[start].uncompressedLen()
+
+		if err != nil {
+			return RecompressStats{}, err
+		}
+
+		for ; end < len(sources); end++ {
+			uncmp, err := sources[end].uncompressedLen()
+
+			if err != nil {
+				return RecompressStats{}, err
+			}
+
+			if groupSize+uncmp > maxRecompressedTableSize {
+				break
+			}
+
+			groupSize += uncmp
+		}
+
+		group := sources[start:end]
+		groupSpecs := contents.specs[start:end]
+		start = end
+
+		var before uint64
+		for _, src := range group {
+			size, err := tableFileSize(ctx, src)
+
+			if err != nil {
+				return RecompressStats{}, err
+			}
+
+			before += size
+		}
+
+		data, name, count, err := recompressTables(ctx, group, dict)
+
+		if err != nil {
+			return RecompressStats{}, err
+		}
+
+		if uint64(len(data)) >= before {
+			specs = append(specs, groupSpecs...)
+			continue
+		}
+
+		_, err = fsPersister.persistTable(ctx, name, data, count, nbs.stats)
+
+		if err != nil {
+			return RecompressStats{}, err
+		}
+
+		specs = append(specs, tableSpec{name, count})
+		stats.Tables += len(group)
+		stats.NewTables++
+		stats.Chunks += uint64(count)
+		stats.SizeBefore += before
+		stats.SizeAfter += uint64(len(data))
+	}
+
+	if stats.Tables == 0 {
+		return stats, nil
+	}
+
+	newContents := manifestContents{
+		vers:  contents.vers,
+		root:  contents.root,
+		lock:  generateLockHash(contents.root, specs),
+		specs: specs,
+	}
+
+	upstream, err := nbs.mm.Update(ctx, contents.lock, newContents, nbs.stats, nil)
+
+	if err != nil {
+		return RecompressStats{}, err
+	}
+
+	if upstream.lock != newContents.lock {
+		return RecompressStats{}, errRecompressManifestChanged
+	}
+
+	newTables, err := nbs.tables.Rebase(ctx, specs, nbs.stats)
+
+	if err != nil {
+		return RecompressStats{}, err
+	}
+
+	nbs.upstream = upstream
+	nbs.tables = newTables
+
+	return stats, nil
+}
+
+// sampleChunks returns an evenly spaced sample of the small chunks in |sources|, of at most
+// maxDictionarySamplesSize bytes.
+func sampleChunks(ctx context.Context, sources chunkSources) [][]byte {
+	var total uint64
+	for _, src := range sources {
+		uncmp, err := src.uncompressedLen()
+
+		if err == nil {
+			total += uncmp
+		}
+	}
+
+	stride := total/maxDictionarySamplesSize + 1
+
+	var samples [][]byte
+	var sampled, seen uint64
+	for _, src := range sources {
+		// chunks which can't be read simply aren't sampled, errors reading them will surface when rewriting the table
+		_ = extractChunks(ctx, src, func(a addr, data []byte) error {
+			seen++
+
+			if len(data) <= maxSampledChunkSize && seen%stride == 0 && sampled+uint64(len(data)) <= maxDictionarySamplesSize {
+				samples = append(samples, data)
+				sampled += uint64(len(data))
+			}
+
+			return nil
+		})
+	}
+
+	return samples
+}
+
+// recompressTables builds a table holding the chunks of |sources|, compressed with |dict|.
+func recompressTables(ctx context.Context, sources chunkSources, dict []byte) (data []byte, name addr, count uint32, err error) {
+	var uncmp uint64
+	for _, src := range sources {
+		cnt, err := src.count()
+
+		if err != nil {
+			return nil, addr{}, 0, err
+		}
+
+		len, err := src.uncompressedLen()
+
+		if err != nil {
+			return nil, addr{}, 0, err
+		}
+
+		count += cnt
+		uncmp += len
+	}
+
+	buff := make([]byte, maxTableSize(uint64(count), uncmp)+dictionariesSize([][]byte{dict}))
+	tw, err := newDictionaryTableWriter(buff, dict)
+
+	if err != nil {
+		return nil, addr{}, 0, err
+	}
+
+	for _, src := range sources {
+		err = extractChunks(ctx, src, func(a addr, data []byte) error {
+			tw.addChunk(a, data)
+			return nil
+		})
+
+		if err != nil {
+			return nil, addr{}, 0, err
+		}
+	}
+
+	length, name, err := tw.finish()
+
+	if err != nil {
+		return nil, addr{}, 0, err
+	}
+
+	return buff[:length], name, count, nil
+}
+
+// tableFileSize returns the size of the table file backing |src|.
+func tableFileSize(ctx context.Context, src chunkSource) (uint64, error) {
+	index, err := src.index()
+
+	if err != nil {
+		return 0, err
+	}
+
+	size := index.tableFileSize()
+
+	if index.hasDictionaries {
+		dicts, err := src.dictionaries(ctx)
+
+		if err != nil {
+			return 0, err
+		}
+
+		size += dictionariesSize(dicts)
+	}
+
+	return size, nil
+}
+
+// extractChunks calls |cb| with each of the chunks in |src|.
+func extractChunks(ctx context.Context, src chunkSource, cb func(a addr, data []byte) error) error {
+	chunkChan := make(chan extractRecord, 64)
+
+	var extractErr error
+	go func() {
+		defer close(chunkChan)
+		extractErr = src.extract(ctx, chunkChan)
+	}()
+
+	var cbErr error
+	for rec := range chunkChan {
+		if cbErr == nil {
+			cbErr = cb(rec.a, rec.data)
+		}
+	}
+
+	if cbErr != nil {
+		return cbErr
+	}
+
+	return extractErr
+}
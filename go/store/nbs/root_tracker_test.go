@@ -162,7 +162,7 @@ func TestChunkStoreManifestFirstWriteByOtherProcess(t *testing.T) {
 	newRoot, chunks, err := interloperWrite(fm, p, []byte("new root"), []byte("hello2"), []byte("goodbye2"), []byte("badbye2"))
 	assert.NoError(err)
 
-	store, err := newNomsBlockStore(context.Background(), constants.Format718String, mm, p, inlineConjoiner{defaultMaxTables}, defaultMemTableSize)
+	store, err := newNomsBlockStore(context.Background(), constants.Format718String, mm, p, inlineConjoiner{maxTables: defaultMaxTables}, defaultMemTableSize)
 	assert.NoError(err)
 	defer store.Close()
 
@@ -197,7 +197,7 @@ func TestChunkStoreManifestPreemptiveOptimisticLockFail(t *testing.T) {
 	fm := &fakeManifest{}
 	mm := manifestManager{fm, newManifestCache(defaultManifestCacheSize), newManifestLocks()}
 	p := newFakeTablePersister()
-	c := inlineConjoiner{defaultMaxTables}
+	c := inlineConjoiner{maxTables: defaultMaxTables}
 
 	store, err := newNomsBlockStore(context.Background(), constants.Format718String, mm, p, c, defaultMemTableSize)
 	assert.NoError(err)
@@ -240,7 +240,7 @@ func TestChunkStoreCommitLocksOutFetch(t *testing.T) {
 	upm := &updatePreemptManifest{manifest: fm}
 	mm := manifestManager{upm, newManifestCache(defaultManifestCacheSize), newManifestLocks()}
 	p := newFakeTablePersister()
-	c := inlineConjoiner{defaultMaxTables}
+	c := inlineConjoiner{maxTables: defaultMaxTables}
 
 	store, err := newNomsBlockStore(context.Background(), constants.Format718String, mm, p, c, defaultMemTableSize)
 	assert.NoError(err)
@@ -281,7 +281,7 @@ func TestChunkStoreSerializeCommits(t *testing.T) {
 	mc := newManifestCache(defaultManifestCacheSize)
 	l := newManifestLocks()
 	p := newFakeTablePersister()
-	c := inlineConjoiner{defaultMaxTables}
+	c := inlineConjoiner{maxTables: defaultMaxTables}
 
 	store, err := newNomsBlockStore(context.Background(), constants.Format718String, manifestManager{upm, mc, l}, p, c, defaultMemTableSize)
 	assert.NoError(err)
@@ -338,7 +338,7 @@ func makeStoreWithFakes(t *testing.T) (fm *fakeManifest, p tablePersister, store
 	fm = &fakeManifest{}
 	mm := manifestManager{fm, newManifestCache(0), newManifestLocks()}
 	p = newFakeTablePersister()
-	store, err := newNomsBlockStore(context.Background(), constants.Format718String, mm, p, inlineConjoiner{defaultMaxTables}, 0)
+	store, err := newNomsBlockStore(context.Background(), constants.Format718String, mm, p, inlineConjoiner{maxTables: defaultMaxTables}, 0)
 	assert.NoError(t, err)
 	return
 }
@@ -23,6 +23,9 @@ package nbs
 
 import (
 	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/url"
@@ -30,7 +33,6 @@ import (
 	"strings"
 	"sync"
 	"testing"
-	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/request"
@@ -38,7 +40,6 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/liquidata-inc/dolt/go/store/d"
-	"github.com/liquidata-inc/dolt/go/store/hash"
 )
 
 type mockAWSError string
@@ -64,7 +65,7 @@ type fakeS3 struct {
 	data              map[string][]byte
 	inProgressCounter int
 	inProgress        map[string]fakeS3Multipart // Key -> {UploadId, Etags...}
-	parts             map[string][]byte          // ETag -> data
+	parts             map[string][]byte          // UploadId/ETag -> data
 	getCount          int
 }
 
@@ -115,7 +116,7 @@ func (m *fakeS3) AbortMultipartUploadWithContext(ctx aws.Context, input *s3.Abor
 	defer m.mu.Unlock()
 	m.assert.Equal(m.inProgress[*input.Key].uploadID, *input.UploadId)
 	for _, etag := range m.inProgress[*input.Key].etags {
-		delete(m.parts, etag)
+		delete(m.parts, *input.UploadId+"/"+etag)
 	}
 	delete(m.inProgress, *input.Key)
 	return &s3.AbortMultipartUploadOutput{}, nil
@@ -149,14 +150,21 @@ func (m *fakeS3) UploadPartWithContext(ctx aws.Context, input *s3.UploadPartInpu
 	data, err := ioutil.ReadAll(input.Body)
 	m.assert.NoError(err)
 
+	sum := md5.Sum(data)
+	if input.ContentMD5 != nil && *input.ContentMD5 != base64.StdEncoding.EncodeToString(sum[:]) {
+		return nil, mockAWSError("BadDigest")
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	etag := hash.Of(data).String() + time.Now().String()
-	m.parts[etag] = data
-
 	inProgress, present := m.inProgress[*input.Key]
-	m.assert.True(present)
-	m.assert.Equal(inProgress.uploadID, *input.UploadId)
+	if !present || inProgress.uploadID != *input.UploadId {
+		return nil, mockAWSError("NoSuchUpload")
+	}
+
+	// like S3, the ETag of a part is the quoted MD5 of its data
+	etag := fmt.Sprintf("\"%x\"", sum)
+	m.parts[*input.UploadId+"/"+etag] = data
 	inProgress.etags = append(inProgress.etags, etag)
 	m.inProgress[*input.Key] = inProgress
 	return &s3.UploadPartOutput{ETag: aws.String(etag)}, nil
@@ -185,8 +193,8 @@ func (m *fakeS3) UploadPartCopyWithContext(ctx aws.Context, input *s3.UploadPart
 		start, end := parseRange(*input.CopySourceRange, len(obj))
 		obj = obj[start:end]
 	}
-	etag := hash.Of(obj).String() + time.Now().String()
-	m.parts[etag] = obj
+	etag := fmt.Sprintf("\"%x\"", md5.Sum(obj))
+	m.parts[*input.UploadId+"/"+etag] = obj
 
 	inProgress, present := m.inProgress[*input.Key]
 	m.assert.True(present)
@@ -206,14 +214,23 @@ func (m *fakeS3) CompleteMultipartUploadWithContext(ctx aws.Context, input *s3.C
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.assert.Equal(m.inProgress[*input.Key].uploadID, *input.UploadId)
+	m.data[*input.Key] = nil
+	digests := md5.New()
 	for idx, part := range input.MultipartUpload.Parts {
 		m.assert.EqualValues(idx+1, *part.PartNumber) // Part numbers are 1-indexed
-		m.data[*input.Key] = append(m.data[*input.Key], m.parts[*part.ETag]...)
-		delete(m.parts, *part.ETag)
+		data := m.parts[*input.UploadId+"/"+*part.ETag]
+		m.data[*input.Key] = append(m.data[*input.Key], data...)
+		sum := md5.Sum(data)
+		digests.Write(sum[:])
+	}
+	for _, etag := range m.inProgress[*input.Key].etags {
+		delete(m.parts, *input.UploadId+"/"+etag)
 	}
 	delete(m.inProgress, *input.Key)
 
-	return &s3.CompleteMultipartUploadOutput{Bucket: input.Bucket, Key: input.Key}, nil
+	// like S3, the ETag of an object uploaded in parts is the MD5 of the MD5s of its parts, and the number of parts
+	etag := fmt.Sprintf("\"%x-%d\"", digests.Sum(nil), len(input.MultipartUpload.Parts))
+	return &s3.CompleteMultipartUploadOutput{Bucket: input.Bucket, Key: input.Key, ETag: aws.String(etag)}, nil
 }
 
 func (m *fakeS3) GetObjectWithContext(ctx aws.Context, input *s3.GetObjectInput, opts ...request.Option) (*s3.GetObjectOutput, error) {
@@ -124,7 +124,7 @@ func TestStats(t *testing.T) {
 	assert.Equal(uint64(54), stats(store).FileBytesPerRead.Sum())
 
 	// Force a conjoin
-	store.c = inlineConjoiner{2}
+	store.c = inlineConjoiner{maxTables: 2}
 	err = store.Put(context.Background(), c4)
 	assert.NoError(err)
 	h, err = store.Root(context.Background())
@@ -245,6 +245,13 @@ func (nbs *NomsBlockStore) UpdateManifest(ctx context.Context, updates map[hash.
 }
 
 func NewAWSStore(ctx context.Context, nbfVerStr string, table, ns, bucket string, s3 s3svc, ddb ddbsvc, memTableSize uint64) (*NomsBlockStore, error) {
+	return NewAWSStoreWithPushStates(ctx, nbfVerStr, table, ns, bucket, s3, ddb, memTableSize, PushStateStore{})
+}
+
+// NewAWSStoreWithPushStates returns an nbs implementation backed by S3 and DynamoDB, which keeps the state of its
+// uploads of table files in |pushStates|, so that an upload which fails partway is resumed by the next upload of the
+// same table file rather than restarted.
+func NewAWSStoreWithPushStates(ctx context.Context, nbfVerStr string, table, ns, bucket string, s3 s3svc, ddb ddbsvc, memTableSize uint64, pushStates PushStateStore) (*NomsBlockStore, error) {
 	cacheOnce.Do(makeGlobalCaches)
 	readRateLimiter := make(chan struct{}, 32)
 	p := &awsTablePersister{
@@ -256,9 +263,10 @@ func NewAWSStore(ctx context.Context, nbfVerStr string, table, ns, bucket string
 		awsLimits{defaultS3PartSize, minS3PartSize, maxS3PartSize, maxDynamoItemSize, maxDynamoChunks},
 		globalIndexCache,
 		ns,
+		pushStates,
 	}
 	mm := makeManifestManager(newDynamoManifest(table, ns, ddb))
-	return newNomsBlockStore(ctx, nbfVerStr, mm, p, inlineConjoiner{defaultMaxTables}, memTableSize)
+	return newNomsBlockStore(ctx, nbfVerStr, mm, p, inlineConjoiner{maxTables: defaultMaxTables}, memTableSize)
 }
 
 // NewGCSStore returns an nbs implementation backed by a GCSBlobstore
@@ -270,7 +278,7 @@ func NewGCSStore(ctx context.Context, nbfVerStr string, bucketName, path string,
 	mm := makeManifestManager(blobstoreManifest{"manifest", bs})
 
 	p := &blobstorePersister{bs, s3BlockSize, globalIndexCache}
-	return newNomsBlockStore(ctx, nbfVerStr, mm, p, inlineConjoiner{defaultMaxTables}, memTableSize)
+	return newNomsBlockStore(ctx, nbfVerStr, mm, p, inlineConjoiner{maxTables: defaultMaxTables}, memTableSize)
 }
 
 func NewLocalStore(ctx context.Context, nbfVerStr string, dir string, memTableSize uint64) (*NomsBlockStore, error) {
@@ -283,7 +291,7 @@ func NewLocalStore(ctx context.Context, nbfVerStr string, dir string, memTableSi
 
 	mm := makeManifestManager(fileManifest{dir})
 	p := newFSTablePersister(dir, globalFDCache, globalIndexCache)
-	nbs, err := newNomsBlockStore(ctx, nbfVerStr, mm, p, inlineConjoiner{defaultMaxTables}, memTableSize)
+	nbs, err := newNomsBlockStore(ctx, nbfVerStr, mm, p, inlineConjoiner{maxTables: defaultMaxTables}, memTableSize)
 
 	if err != nil {
 		return nil, err
@@ -328,6 +336,12 @@ func newNomsBlockStore(ctx context.Context, nbfVerStr string, mm manifestManager
 	}
 
 	if exists {
+		err = chunks.CheckFormatVersion(contents.vers)
+
+		if err != nil {
+			return nil, err
+		}
+
 		newTables, err := nbs.tables.Rebase(ctx, contents.specs, nbs.stats)
 
 		if err != nil {
@@ -342,6 +356,10 @@ func newNomsBlockStore(ctx context.Context, nbfVerStr string, mm manifestManager
 }
 
 func (nbs *NomsBlockStore) Put(ctx context.Context, c chunks.Chunk) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	t1 := time.Now()
 	a := addr(c.Hash())
 	success := nbs.addChunk(ctx, a, c.Data())
@@ -357,6 +375,11 @@ func (nbs *NomsBlockStore) Put(ctx context.Context, c chunks.Chunk) error {
 	return nil
 }
 
+// PutMany adds each of |chunks| to the memtable like Put.
+func (nbs *NomsBlockStore) PutMany(ctx context.Context, chnks []chunks.Chunk) error {
+	return chunks.PutManyWithPut(ctx, chnks, nbs.Put)
+}
+
 func (nbs *NomsBlockStore) addChunk(ctx context.Context, h addr, data []byte) bool {
 	nbs.mu.Lock()
 	defer nbs.mu.Unlock()
@@ -421,6 +444,10 @@ func (nbs *NomsBlockStore) GetMany(ctx context.Context, hashes hash.HashSet, fou
 	})
 }
 
+func (nbs *NomsBlockStore) GetManyF(ctx context.Context, hashes hash.HashSet, found func(*chunks.Chunk)) error {
+	return chunks.GetManyFFromChannel(ctx, hashes, found, nbs.GetMany)
+}
+
 func (nbs *NomsBlockStore) GetManyCompressed(ctx context.Context, hashes hash.HashSet, foundCmpChunks chan<- CompressedChunk) error {
 	return nbs.getManyWithFunc(ctx, hashes, func(ctx context.Context, cr chunkReader, reqs []getRecord, wg *sync.WaitGroup, ae *atomicerr.AtomicError, stats *Stats) bool {
 		return cr.getManyCompressed(ctx, reqs, foundCmpChunks, wg, ae, nbs.stats)
@@ -537,6 +564,10 @@ func (nbs *NomsBlockStore) Count() (uint32, error) {
 }
 
 func (nbs *NomsBlockStore) Has(ctx context.Context, h hash.Hash) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
 	t1 := time.Now()
 	defer func() {
 		nbs.stats.HasLatency.SampleTimeSince(t1)
@@ -577,6 +608,10 @@ func (nbs *NomsBlockStore) Has(ctx context.Context, h hash.Hash) (bool, error) {
 }
 
 func (nbs *NomsBlockStore) HasMany(ctx context.Context, hashes hash.HashSet) (hash.HashSet, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	t1 := time.Now()
 
 	reqs := toHasRecords(hashes)
@@ -642,6 +677,10 @@ func toHasRecords(hashes hash.HashSet) []hasRecord {
 }
 
 func (nbs *NomsBlockStore) Rebase(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	nbs.mu.Lock()
 	defer nbs.mu.Unlock()
 	exists, contents, err := nbs.mm.Fetch(ctx, nbs.stats)
@@ -651,6 +690,12 @@ func (nbs *NomsBlockStore) Rebase(ctx context.Context) error {
 	}
 
 	if exists {
+		err = chunks.CheckFormatVersion(contents.vers)
+
+		if err != nil {
+			return err
+		}
+
 		newTables, err := nbs.tables.Rebase(ctx, contents.specs, nbs.stats)
 
 		if err != nil {
@@ -790,8 +835,12 @@ func (nbs *NomsBlockStore) updateManifest(ctx context.Context, current, last has
 	}
 
 	if nbs.c.ConjoinRequired(nbs.tables) {
-		var err error
-		newUpstream, err := nbs.c.Conjoin(ctx, nbs.upstream, nbs.mm, nbs.p, nbs.stats)
+		// the store is unlocked while the tables are conjoined, so that it can be read from meanwhile. Other commits
+		// are held back by the lock for update of the manifest.
+		upstream := nbs.upstream
+		nbs.mu.Unlock()
+		newUpstream, err := nbs.c.Conjoin(ctx, upstream, nbs.mm, nbs.p, nbs.stats)
+		nbs.mu.Lock()
 
 		if err != nil {
 			return err
@@ -970,12 +1019,14 @@ func (nbs *NomsBlockStore) WriteTableFile(ctx context.Context, fileId string, nu
 
 	path := filepath.Join(fsPersister.dir, fileId)
 
-	err := func() (err error) {
+	// the table file is written to a temporary file which is renamed into place, rather than being written over any
+	// existing file at |path|, as that file may be hard linked into another store.
+	tempName, err := func() (tempName string, err error) {
 		var f *os.File
-		f, err = os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.ModePerm)
+		f, err = ioutil.TempFile(fsPersister.dir, tempTablePrefix)
 
 		if err != nil {
-			return err
+			return "", err
 		}
 
 		defer func() {
@@ -988,10 +1039,18 @@ func (nbs *NomsBlockStore) WriteTableFile(ctx context.Context, fileId string, nu
 
 		_, err = io.Copy(f, rd)
 
-		return err
+		return f.Name(), err
 	}()
 
+	if err == nil {
+		err = os.Rename(tempName, path)
+	}
+
 	if err != nil {
+		if tempName != "" {
+			_ = os.Remove(tempName)
+		}
+
 		return err
 	}
 
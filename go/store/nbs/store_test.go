@@ -27,6 +27,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/hash"
 	"github.com/liquidata-inc/dolt/go/store/types"
 )
 
@@ -77,3 +79,83 @@ func TestNBSAsTableFileStore(t *testing.T) {
 		assert.Equal(t, expected, data)
 	}
 }
+
+func TestNBSIncompatibleFormat(t *testing.T) {
+	ctx := context.Background()
+	testDir := filepath.Join(os.TempDir(), uuid.New().String())
+
+	err := os.MkdirAll(testDir, os.ModePerm)
+	require.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	st, err := NewLocalStore(ctx, types.Format_Default.VersionString(), testDir, defaultMemTableSize)
+	require.NoError(t, err)
+	require.NoError(t, st.Put(ctx, chunks.NewChunk([]byte("data"))))
+	_, err = st.Commit(ctx, hash.Of([]byte("data")), hash.Hash{})
+	require.NoError(t, err)
+
+	manifestPath := filepath.Join(testDir, manifestFileName)
+	manifest, err := ioutil.ReadFile(manifestPath)
+	require.NoError(t, err)
+
+	newerManifest := bytes.Replace(manifest, []byte(":"+types.Format_Default.VersionString()+":"), []byte(":__LD_2__:"), 1)
+	require.NoError(t, ioutil.WriteFile(manifestPath, newerManifest, os.ModePerm))
+
+	_, err = NewLocalStore(ctx, types.Format_Default.VersionString(), testDir, defaultMemTableSize)
+	require.IsType(t, &chunks.ErrIncompatibleFormat{}, err)
+	assert.Equal(t, "__LD_2__", err.(*chunks.ErrIncompatibleFormat).Found)
+
+	newerManifest = append([]byte("5"), manifest[1:]...)
+	require.NoError(t, ioutil.WriteFile(manifestPath, newerManifest, os.ModePerm))
+
+	_, err = NewLocalStore(ctx, types.Format_Default.VersionString(), testDir, defaultMemTableSize)
+	require.IsType(t, &chunks.ErrIncompatibleFormat{}, err)
+	assert.Equal(t, "5", err.(*chunks.ErrIncompatibleFormat).Found)
+	assert.Equal(t, []string{StorageVersion}, err.(*chunks.ErrIncompatibleFormat).Supported)
+}
+
+// BenchmarkPassThroughGetMany compares serving the chunks of a store as a pass-through server does, sending them
+// compressed, with GetManyCompressed and with GetMany, which decompresses each chunk only for it to be compressed again.
+func BenchmarkPassThroughGetMany(b *testing.B) {
+	ctx := context.Background()
+	testDir, err := ioutil.TempDir("", "pass_through")
+	require.NoError(b, err)
+	defer os.RemoveAll(testDir)
+
+	st, err := NewLocalStore(ctx, types.Format_Default.VersionString(), testDir, defaultMemTableSize)
+	require.NoError(b, err)
+	defer st.Close()
+
+	hashes := hash.HashSet{}
+	for _, data := range makeRowChunks(0, 10000) {
+		c := chunks.NewChunk(data)
+		require.NoError(b, st.Put(ctx, c))
+		hashes.Insert(c.Hash())
+	}
+
+	root, err := st.Root(ctx)
+	require.NoError(b, err)
+	_, err = st.Commit(ctx, root, root)
+	require.NoError(b, err)
+
+	b.Run("GetManyCompressed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			found := make(chan CompressedChunk, len(hashes))
+			require.NoError(b, st.GetManyCompressed(ctx, hashes, found))
+			close(found)
+			require.Equal(b, len(hashes), len(found))
+		}
+	})
+
+	b.Run("GetMany", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			found := make(chan CompressedChunk, len(hashes))
+			err := st.GetManyF(ctx, hashes, func(c *chunks.Chunk) {
+				found <- ChunkToCompressedChunk(*c)
+			})
+			require.NoError(b, err)
+			close(found)
+			require.Equal(b, len(hashes), len(found))
+		}
+	})
+}
@@ -98,6 +98,26 @@ import (
      -Total Uncompressed Chunk Data is the sum of the uncompressed byte lengths of all contained chunk byte slices.
      -Magic Number is the first 8 bytes of the SHA256 hash of "https://github.com/attic-labs/nbs".
 
+   Table with Dictionaries:
+   +----------------+-----+----------------+--------------+-------+--------+
+   | Chunk Record 0 | ... | Chunk Record N | Dictionaries | Index | Footer |
+   +----------------+-----+----------------+--------------+-------+--------+
+
+     -A table may carry zstd dictionaries which are used to compress its chunks. Such a table ends with a footer whose Magic Number is the first 8 bytes of the SHA256 hash of "https://github.com/liquidata-inc/dolt/nbs/dictionaries", so that readers which predate dictionaries reject it.
+     -The Chunk Data of each Chunk Record is either snappy encoded, or is a zstd frame compressed with one of the table's dictionaries. zstd frames are identified by their leading magic bytes, which can never begin a snappy encoding, and name their dictionary by its ID.
+
+   Dictionaries:
+   +-------------------------+---------------------------+---------------------+-----+---------------------+
+   | (Uint32) Section Length | (Uint32) Dictionary Count | Dictionary Record 0 | ... | Dictionary Record M |
+   +-------------------------+---------------------------+---------------------+-----+---------------------+
+
+     -Section Length is the length in bytes of the entire Dictionaries section, including itself.
+
+   Dictionary Record:
+   +-----------------+---------------------+
+   | (Uint32) Length | (Length) Dictionary |
+   +-----------------+---------------------+
+
     NOTE: Unsigned integer quanities, hashes and hash suffix are all encoded big-endian
 
 
@@ -131,6 +151,7 @@ const (
 	ordinalSize     = uint32Size
 	lengthSize      = uint32Size
 	magicNumber     = "\xff\xb5\xd8\xc2\x24\x63\xee\x50"
+	dictMagicNumber = "\x07\x56\x4b\x85\x91\x61\x30\x68"
 	magicNumberSize = 8 //len(magicNumber)
 	footerSize      = uint32Size + uint64Size + magicNumberSize
 	prefixTupleSize = addrPrefixSize + ordinalSize
@@ -264,6 +285,9 @@ type chunkSource interface {
 	// opens a Reader to the first byte of the chunkData segment of this table.
 	reader(context.Context) (io.Reader, error)
 	index() (tableIndex, error)
+
+	// returns the zstd dictionaries stored in this table, if any.
+	dictionaries(context.Context) ([][]byte, error)
 }
 
 type chunkSources []chunkSource
@@ -0,0 +1,181 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/pkg/errors"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// ErrNotLocalTableFileStore is returned when linking table files into a store which doesn't keep its table files on
+// the local filesystem.
+var ErrNotLocalTableFileStore = errors.New("table files are not stored on the local filesystem")
+
+// LocalTableFileStore is a TableFileStore whose table files are stored in a directory on the local filesystem, so that
+// they can be added to another local store without being read.
+type LocalTableFileStore interface {
+	TableFileStore
+
+	// LocalTableFileDir returns the directory containing the store's table files, or false if they aren't stored on
+	// the local filesystem.
+	LocalTableFileDir() (string, bool)
+
+	// LinkTableFiles adds the table files |tblFiles|, which are stored in the directory |srcDir|, to the store.
+	LinkTableFiles(ctx context.Context, srcDir string, tblFiles []TableFile) error
+}
+
+var _ LocalTableFileStore = &NomsBlockStore{}
+var _ LocalTableFileStore = &NBSMetricWrapper{}
+
+// LocalTableFileDir returns the directory containing the store's table files, or false if they aren't stored on the
+// local filesystem.
+func (nbs *NomsBlockStore) LocalTableFileDir() (string, bool) {
+	fsPersister, ok := nbs.p.(*fsTablePersister)
+
+	if !ok {
+		return "", false
+	}
+
+	return fsPersister.dir, true
+}
+
+// LinkTableFiles adds the table files |tblFiles|, which are stored in the directory |srcDir|, to the store by hard
+// linking them into the store's directory. Table files are never modified once they are written, so the linked files
+// aren't state shared between the stores. Files on a different device than the store are copied instead. If any
+// table file can't be linked or copied then the files which were already added are removed and the manifest is left
+// unchanged.
+func (nbs *NomsBlockStore) LinkTableFiles(ctx context.Context, srcDir string, tblFiles []TableFile) (err error) {
+	destDir, ok := nbs.LocalTableFileDir()
+
+	if !ok {
+		return ErrNotLocalTableFileStore
+	}
+
+	var added []string
+	defer func() {
+		if err != nil {
+			for _, path := range added {
+				_ = os.Remove(path)
+			}
+		}
+	}()
+
+	updates := make(map[hash.Hash]uint32, len(tblFiles))
+	for _, tf := range tblFiles {
+		fileIdHash, ok := hash.MaybeParse(tf.FileID())
+
+		if !ok {
+			return errors.New("invalid base32 encoded hash: " + tf.FileID())
+		}
+
+		destPath := filepath.Join(destDir, tf.FileID())
+		if _, statErr := os.Stat(destPath); statErr == nil {
+			// the table file is already in the store
+			updates[fileIdHash] = uint32(tf.NumChunks())
+			continue
+		}
+
+		err = linkTableFile(destDir, filepath.Join(srcDir, tf.FileID()), destPath)
+
+		if err != nil {
+			return err
+		}
+
+		added = append(added, destPath)
+		updates[fileIdHash] = uint32(tf.NumChunks())
+	}
+
+	_, err = nbs.UpdateManifest(ctx, updates)
+	return err
+}
+
+// linkTableFile hard links the table file at |srcPath| to |destPath| in |destDir|, or copies it if |destDir| is on a
+// different device.
+func linkTableFile(destDir, srcPath, destPath string) error {
+	err := os.Link(srcPath, destPath)
+
+	if isCrossDeviceLinkErr(err) {
+		err = copyTableFile(destDir, srcPath, destPath)
+	}
+
+	return err
+}
+
+func isCrossDeviceLinkErr(err error) bool {
+	if linkErr, ok := err.(*os.LinkError); ok {
+		return linkErr.Err == syscall.EXDEV
+	}
+
+	return false
+}
+
+// copyTableFile copies the table file at |srcPath| to a temporary file in |destDir| which is then renamed to |destPath|
+func copyTableFile(destDir, srcPath, destPath string) error {
+	src, err := os.Open(srcPath)
+
+	if err != nil {
+		return err
+	}
+
+	defer src.Close()
+
+	tempName, err := func() (tempName string, err error) {
+		var temp *os.File
+		temp, err = ioutil.TempFile(destDir, tempTablePrefix)
+
+		if err != nil {
+			return "", err
+		}
+
+		defer func() {
+			closeErr := temp.Close()
+
+			if err == nil {
+				err = closeErr
+			}
+		}()
+
+		_, err = io.Copy(temp, src)
+		return temp.Name(), err
+	}()
+
+	if err == nil {
+		err = os.Rename(tempName, destPath)
+	}
+
+	if err != nil && tempName != "" {
+		_ = os.Remove(tempName)
+	}
+
+	return err
+}
+
+// LocalTableFileDir forwards LocalTableFileDir to the wrapped block store.
+func (nbsMW *NBSMetricWrapper) LocalTableFileDir() (string, bool) {
+	return nbsMW.nbs.LocalTableFileDir()
+}
+
+// LinkTableFiles forwards LinkTableFiles to the wrapped block store.
+func (nbsMW *NBSMetricWrapper) LinkTableFiles(ctx context.Context, srcDir string, tblFiles []TableFile) error {
+	return nbsMW.nbs.LinkTableFiles(ctx, srcDir, tblFiles)
+}
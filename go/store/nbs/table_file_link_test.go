@@ -0,0 +1,142 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+func makeLinkTestStore(t *testing.T) (*NomsBlockStore, string) {
+	dir := filepath.Join(os.TempDir(), uuid.New().String())
+	err := os.MkdirAll(dir, os.ModePerm)
+	require.NoError(t, err)
+
+	st, err := NewLocalStore(context.Background(), types.Format_Default.VersionString(), dir, defaultMemTableSize)
+	require.NoError(t, err)
+
+	return st, dir
+}
+
+func TestNBSLinkTableFiles(t *testing.T) {
+	ctx := context.Background()
+	src, srcDir := makeLinkTestStore(t)
+	defer os.RemoveAll(srcDir)
+	dest, destDir := makeLinkTestStore(t)
+	defer os.RemoveAll(destDir)
+
+	fileToData := make(map[string][]byte)
+	for i := 0; i < 8; i++ {
+		var chunkData [][]byte
+		for j := 0; j < i+1; j++ {
+			chunkData = append(chunkData, []byte(fmt.Sprintf("%d:%d", i, j)))
+		}
+
+		data, addr, err := buildTable(chunkData)
+		require.NoError(t, err)
+		fileToData[addr.String()] = data
+		err = src.WriteTableFile(ctx, addr.String(), i+1, bytes.NewReader(data), 0, nil)
+		require.NoError(t, err)
+	}
+
+	dir, ok := dest.LocalTableFileDir()
+	require.True(t, ok)
+	assert.Equal(t, destDir, dir)
+
+	_, tblFiles, err := src.Sources(ctx)
+	require.NoError(t, err)
+
+	err = dest.LinkTableFiles(ctx, srcDir, tblFiles)
+	require.NoError(t, err)
+
+	_, destFiles, err := dest.Sources(ctx)
+	require.NoError(t, err)
+	require.Len(t, destFiles, len(fileToData))
+
+	for _, tf := range destFiles {
+		srcInfo, err := os.Stat(filepath.Join(srcDir, tf.FileID()))
+		require.NoError(t, err)
+		destInfo, err := os.Stat(filepath.Join(destDir, tf.FileID()))
+		require.NoError(t, err)
+		assert.True(t, os.SameFile(srcInfo, destInfo))
+
+		rd, err := tf.Open()
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(rd)
+		require.NoError(t, err)
+		require.NoError(t, rd.Close())
+		assert.Equal(t, fileToData[tf.FileID()], data)
+	}
+
+	// rewriting a linked table file in one store replaces it rather than writing through the link
+	fileID := destFiles[0].FileID()
+	err = dest.WriteTableFile(ctx, fileID, destFiles[0].NumChunks(), bytes.NewReader(fileToData[fileID]), 0, nil)
+	require.NoError(t, err)
+
+	srcInfo, err := os.Stat(filepath.Join(srcDir, fileID))
+	require.NoError(t, err)
+	destInfo, err := os.Stat(filepath.Join(destDir, fileID))
+	require.NoError(t, err)
+	assert.False(t, os.SameFile(srcInfo, destInfo))
+}
+
+func TestNBSLinkTableFilesMissingFile(t *testing.T) {
+	ctx := context.Background()
+	src, srcDir := makeLinkTestStore(t)
+	defer os.RemoveAll(srcDir)
+	dest, destDir := makeLinkTestStore(t)
+	defer os.RemoveAll(destDir)
+
+	var ids []string
+	for i := 0; i < 2; i++ {
+		data, addr, err := buildTable([][]byte{[]byte(fmt.Sprintf("chunk %d", i))})
+		require.NoError(t, err)
+		err = src.WriteTableFile(ctx, addr.String(), 1, bytes.NewReader(data), 0, nil)
+		require.NoError(t, err)
+		ids = append(ids, addr.String())
+	}
+
+	_, tblFiles, err := src.Sources(ctx)
+	require.NoError(t, err)
+
+	err = os.Remove(filepath.Join(srcDir, ids[1]))
+	require.NoError(t, err)
+
+	err = dest.LinkTableFiles(ctx, srcDir, tblFiles)
+	assert.Error(t, err)
+
+	// neither table file was added
+	for _, id := range ids {
+		_, err = os.Stat(filepath.Join(destDir, id))
+		assert.True(t, os.IsNotExist(err))
+	}
+
+	root, destFiles, err := dest.Sources(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, destFiles)
+	assert.Equal(t, hash.Hash{}, root)
+}
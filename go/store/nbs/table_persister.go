@@ -199,18 +199,20 @@ type sourceWithSize struct {
 
 type compactionPlan struct {
 	sources             chunkSourcesByDescendingDataSize
-	mergedIndex         []byte
+	mergedIndex         []byte // preceded by the dictionaries section if any of the sources have dictionaries
 	chunkCount          uint32
 	totalCompressedData uint64
+	dictionariesLen     uint64
 }
 
 func (cp compactionPlan) suffixes() []byte {
-	suffixesStart := uint64(cp.chunkCount) * (prefixTupleSize + lengthSize)
+	suffixesStart := cp.dictionariesLen + uint64(cp.chunkCount)*(prefixTupleSize+lengthSize)
 	return cp.mergedIndex[suffixesStart : suffixesStart+uint64(cp.chunkCount)*addrSuffixSize]
 }
 
-func planConjoin(sources chunkSources, stats *Stats) (plan compactionPlan, err error) {
+func planConjoin(ctx context.Context, sources chunkSources, stats *Stats) (plan compactionPlan, err error) {
 	var totalUncompressedData uint64
+	var dictLists [][][]byte
 	for _, src := range sources {
 		var uncmp uint64
 		uncmp, err = src.uncompressedLen()
@@ -228,6 +230,16 @@ func planConjoin(sources chunkSources, stats *Stats) (plan compactionPlan, err e
 
 		plan.chunkCount += index.chunkCount
 
+		if index.hasDictionaries {
+			dicts, err := src.dictionaries(ctx)
+
+			if err != nil {
+				return compactionPlan{}, err
+			}
+
+			dictLists = append(dictLists, dicts)
+		}
+
 		// Calculate the amount of chunk data in |src|
 		chunkDataLen := calcChunkDataLen(index)
 		plan.sources.sws = append(plan.sources.sws, sourceWithSize{src, chunkDataLen})
@@ -239,9 +251,24 @@ func planConjoin(sources chunkSources, stats *Stats) (plan compactionPlan, err e
 		return compactionPlan{}, plan.sources.err
 	}
 
-	lengthsPos := lengthsOffset(plan.chunkCount)
-	suffixesPos := suffixesOffset(plan.chunkCount)
-	plan.mergedIndex = make([]byte, indexSize(plan.chunkCount)+footerSize)
+	// Chunks compressed with a dictionary are copied over as-is, so the conjoined table keeps all of their dictionaries.
+	dicts, err := mergeDictionaries(dictLists...)
+
+	if err != nil {
+		return compactionPlan{}, err
+	}
+
+	if len(dicts) > 0 {
+		plan.dictionariesLen = dictionariesSize(dicts)
+	}
+
+	lengthsPos := plan.dictionariesLen + lengthsOffset(plan.chunkCount)
+	suffixesPos := plan.dictionariesLen + suffixesOffset(plan.chunkCount)
+	plan.mergedIndex = make([]byte, plan.dictionariesLen+indexSize(plan.chunkCount)+footerSize)
+
+	if len(dicts) > 0 {
+		writeDictionaries(plan.mergedIndex, dicts)
+	}
 
 	prefixIndexRecs := make(prefixIndexSlice, 0, plan.chunkCount)
 	var ordinalOffset uint32
@@ -287,7 +314,7 @@ func planConjoin(sources chunkSources, stats *Stats) (plan compactionPlan, err e
 
 	// Sort all prefixTuples by hash and then insert them starting at the beginning of plan.mergedIndex
 	sort.Sort(prefixIndexRecs)
-	var pfxPos uint64
+	pfxPos := plan.dictionariesLen
 	for _, pi := range prefixIndexRecs {
 		binary.BigEndian.PutUint64(plan.mergedIndex[pfxPos:], pi.prefix)
 		pfxPos += addrPrefixSize
@@ -295,7 +322,12 @@ func planConjoin(sources chunkSources, stats *Stats) (plan compactionPlan, err e
 		pfxPos += ordinalSize
 	}
 
-	writeFooter(plan.mergedIndex[uint64(len(plan.mergedIndex))-footerSize:], plan.chunkCount, totalUncompressedData)
+	footer := plan.mergedIndex[uint64(len(plan.mergedIndex))-footerSize:]
+	writeFooter(footer, plan.chunkCount, totalUncompressedData)
+
+	if len(dicts) > 0 {
+		writeDictionariesMagic(footer)
+	}
 
 	stats.BytesPerConjoin.Sample(uint64(plan.totalCompressedData) + uint64(len(plan.mergedIndex)))
 	return plan, nil
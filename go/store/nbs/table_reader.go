@@ -30,7 +30,7 @@ import (
 	"sort"
 	"sync"
 
-	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 
 	"github.com/liquidata-inc/dolt/go/store/atomicerr"
 	"github.com/liquidata-inc/dolt/go/store/chunks"
@@ -38,16 +38,7 @@ import (
 )
 
 // CompressedChunk represents a chunk of data in a table file which is still compressed via snappy.
-type CompressedChunk struct {
-	// H is the hash of the chunk
-	H hash.Hash
-
-	// FullCompressedChunk is the entirety of the compressed chunk data including the crc
-	FullCompressedChunk []byte
-
-	// CompressedData is just the snappy encoded byte buffer that stores the chunk data
-	CompressedData []byte
-}
+type CompressedChunk = chunks.CompressedChunk
 
 // NewCompressedChunk creates a CompressedChunk
 func NewCompressedChunk(h hash.Hash, buff []byte) (CompressedChunk, error) {
@@ -63,33 +54,8 @@ func NewCompressedChunk(h hash.Hash, buff []byte) (CompressedChunk, error) {
 	return CompressedChunk{H: h, FullCompressedChunk: buff, CompressedData: compressedData}, nil
 }
 
-// ToChunk snappy decodes the compressed data and returns a chunks.Chunk
-func (cmp CompressedChunk) ToChunk() (chunks.Chunk, error) {
-	data, err := snappy.Decode(nil, cmp.CompressedData)
-
-	if err != nil {
-		return chunks.Chunk{}, err
-	}
-
-	return chunks.NewChunkWithHash(cmp.H, data), nil
-}
-
 func ChunkToCompressedChunk(chunk chunks.Chunk) CompressedChunk {
-	compressed := snappy.Encode(nil, chunk.Data())
-	length := len(compressed)
-	compressed = append(compressed, []byte{0, 0, 0, 0}...)
-	binary.BigEndian.PutUint32(compressed[length:], crc(compressed[:length]))
-	return CompressedChunk{H: chunk.Hash(), FullCompressedChunk: compressed, CompressedData: compressed[:length]}
-}
-
-// Hash returns the hash of the data
-func (cmp CompressedChunk) Hash() hash.Hash {
-	return cmp.H
-}
-
-// IsEmpty returns true if the chunk contains no data.
-func (cmp CompressedChunk) IsEmpty() bool {
-	return len(cmp.CompressedData) == 0 || (len(cmp.CompressedData) == 1 && cmp.CompressedData[0] == 0)
+	return chunks.NewCompressedChunk(chunk)
 }
 
 var EmptyCompressedChunk CompressedChunk
@@ -107,6 +73,7 @@ type tableIndex struct {
 	prefixes, offsets     []uint64
 	lengths, ordinals     []uint32
 	suffixes              []byte
+	hasDictionaries       bool
 }
 
 type tableReaderAt interface {
@@ -122,6 +89,7 @@ type tableReader struct {
 	tableIndex
 	r         tableReaderAt
 	blockSize uint64
+	dicts     *dictionarySection
 }
 
 // parses a valid nbs tableIndex from a byte stream. |buff| must end with an NBS index
@@ -133,7 +101,9 @@ func parseTableIndex(buff []byte) (tableIndex, error) {
 	// footer
 	pos -= magicNumberSize
 
-	if string(buff[pos:]) != magicNumber {
+	hasDictionaries := string(buff[pos:]) == dictMagicNumber
+
+	if string(buff[pos:]) != magicNumber && !hasDictionaries {
 		return tableIndex{}, ErrInvalidTableFile
 	}
 
@@ -188,6 +158,7 @@ func parseTableIndex(buff []byte) (tableIndex, error) {
 		prefixes, offsets,
 		lengths, ordinals,
 		suffixes,
+		hasDictionaries,
 	}, nil
 }
 
@@ -220,10 +191,10 @@ func (ti tableIndex) prefixIdxToOrdinal(idx uint32) uint32 {
 	return ti.ordinals[idx]
 }
 
-// Returns the size of the table file that this index references.
-// This assumes that the index follows immediately after the last
-// chunk in the file and that the last chunk in the file is in the
-// index.
+// Returns the size of the table file that this index references,
+// not including any dictionaries section. This assumes that the
+// index follows immediately after the last chunk (or dictionaries)
+// in the file and that the last chunk in the file is in the index.
 func (ti tableIndex) tableFileSize() uint64 {
 	len, offset := uint64(0), uint64(0)
 	for i := range ti.offsets {
@@ -278,7 +249,7 @@ func (ti tableIndex) lookupOrdinal(h addr) uint32 {
 // and footer, though it may contain an unspecified number of bytes before that data. r should allow
 // retrieving any desired range of bytes from the table.
 func newTableReader(index tableIndex, r tableReaderAt, blockSize uint64) tableReader {
-	return tableReader{index, r, blockSize}
+	return tableReader{index, r, blockSize, &dictionarySection{}}
 }
 
 // Scan across (logically) two ordered slices of address prefixes.
@@ -335,6 +306,97 @@ func (tr tableReader) index() (tableIndex, error) {
 	return tr.tableIndex, nil
 }
 
+func (tr tableReader) dictionaries(ctx context.Context) ([][]byte, error) {
+	ds, err := tr.loadDictionaries(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ds.dicts, nil
+}
+
+// loadDictionaries reads the dictionaries section of the table, which begins immediately after the last chunk record,
+// the first time it's needed.
+func (tr tableReader) loadDictionaries(ctx context.Context) (*dictionarySection, error) {
+	ds := tr.dicts
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	if ds.loaded || !tr.hasDictionaries || tr.chunkCount == 0 {
+		return ds, nil
+	}
+
+	start := int64(calcChunkDataLen(tr.tableIndex))
+	sizeBuff := make([]byte, uint32Size)
+	n, err := tr.r.ReadAtWithStats(ctx, sizeBuff, start, &Stats{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if n != len(sizeBuff) {
+		return nil, errors.New("failed to read all data")
+	}
+
+	buff := make([]byte, binary.BigEndian.Uint32(sizeBuff))
+	n, err = tr.r.ReadAtWithStats(ctx, buff, start, &Stats{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if n != len(buff) {
+		return nil, errors.New("failed to read all data")
+	}
+
+	dicts, err := parseDictionaries(buff)
+
+	if err != nil {
+		return nil, err
+	}
+
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderDicts(dicts...))
+
+	if err != nil {
+		return nil, err
+	}
+
+	ds.loaded, ds.size, ds.dicts, ds.decoder = true, uint64(len(buff)), dicts, decoder
+	return ds, nil
+}
+
+// toChunk decodes |cmp|, which was read from this table.
+func (tr tableReader) toChunk(ctx context.Context, cmp CompressedChunk) (chunks.Chunk, error) {
+	if !isDictionaryCompressed(cmp.CompressedData) {
+		return cmp.ToChunk()
+	}
+
+	ds, err := tr.loadDictionaries(ctx)
+
+	if err != nil {
+		return chunks.Chunk{}, err
+	}
+
+	return ds.toChunk(cmp)
+}
+
+// toSnappy returns |cmp|, which was read from this table, as a snappy encoded CompressedChunk. Chunks compressed with
+// the table's dictionaries are recompressed so that they can be read without the table.
+func (tr tableReader) toSnappy(ctx context.Context, cmp CompressedChunk) (CompressedChunk, error) {
+	if !isDictionaryCompressed(cmp.CompressedData) {
+		return cmp, nil
+	}
+
+	chnk, err := tr.toChunk(ctx, cmp)
+
+	if err != nil {
+		return CompressedChunk{}, err
+	}
+
+	return ChunkToCompressedChunk(chnk), nil
+}
+
 // returns true iff |h| can be found in this table.
 func (tr tableReader) has(h addr) (bool, error) {
 	ordinal := tr.lookupOrdinal(h)
@@ -385,7 +447,7 @@ func (tr tableReader) get(ctx context.Context, h addr, stats *Stats) ([]byte, er
 		return nil, errors.New("failed to get data")
 	}
 
-	chnk, err := cmp.ToChunk()
+	chnk, err := tr.toChunk(ctx, cmp)
 
 	if err != nil {
 		return nil, err
@@ -415,6 +477,12 @@ func (tr tableReader) readCompressedAtOffsets(
 	stats *Stats,
 ) error {
 	return tr.readAtOffsetsWithCB(ctx, readStart, readEnd, reqs, offsets, stats, func(cmp CompressedChunk) error {
+		cmp, err := tr.toSnappy(ctx, cmp)
+
+		if err != nil {
+			return err
+		}
+
 		foundCmpChunks <- cmp
 		return nil
 	})
@@ -429,7 +497,7 @@ func (tr tableReader) readAtOffsets(
 	stats *Stats,
 ) error {
 	return tr.readAtOffsetsWithCB(ctx, readStart, readEnd, reqs, offsets, stats, func(cmp CompressedChunk) error {
-		chk, err := cmp.ToChunk()
+		chk, err := tr.toChunk(ctx, cmp)
 
 		if err != nil {
 			return err
@@ -763,7 +831,7 @@ func (tr tableReader) extract(ctx context.Context, chunks chan<- extractRecord)
 			return err
 		}
 
-		chnk, err := cmp.ToChunk()
+		chnk, err := tr.toChunk(ctx, cmp)
 
 		if err != nil {
 			return err
@@ -785,7 +853,13 @@ func (tr tableReader) extract(ctx context.Context, chunks chan<- extractRecord)
 }
 
 func (tr tableReader) reader(ctx context.Context) (io.Reader, error) {
-	return io.LimitReader(&readerAdapter{tr.r, 0, ctx}, int64(tr.tableIndex.tableFileSize())), nil
+	ds, err := tr.loadDictionaries(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return io.LimitReader(&readerAdapter{tr.r, 0, ctx}, int64(tr.tableIndex.tableFileSize()+ds.size)), nil
 }
 
 type readerAdapter struct {
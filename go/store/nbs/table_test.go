@@ -32,7 +32,6 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/liquidata-inc/dolt/go/store/atomicerr"
-	"github.com/liquidata-inc/dolt/go/store/chunks"
 	"github.com/liquidata-inc/dolt/go/store/hash"
 )
 
@@ -216,8 +215,10 @@ func TestGetMany(t *testing.T) {
 	wg := &sync.WaitGroup{}
 	ae := atomicerr.New()
 
-	chunkChan := make(chan *chunks.Chunk, len(getBatch))
-	tr.getMany(context.Background(), getBatch, chunkChan, wg, ae, &Stats{})
+	// the chunks are read compressed, as the addresses made by computeAddrCommonPrefix aren't the hashes of the chunks'
+	// data, and would fail the check of CompressedChunk.ToChunk
+	chunkChan := make(chan CompressedChunk, len(getBatch))
+	tr.getManyCompressed(context.Background(), getBatch, chunkChan, wg, ae, &Stats{})
 	wg.Wait()
 	close(chunkChan)
 
@@ -382,8 +383,10 @@ func doTestNGetMany(t *testing.T, count int) {
 
 	ae := atomicerr.New()
 	wg := &sync.WaitGroup{}
-	chunkChan := make(chan *chunks.Chunk, len(getBatch))
-	tr.getMany(context.Background(), getBatch, chunkChan, wg, ae, &Stats{})
+	// the chunks are read compressed, as the addresses made by computeAddrCommonPrefix aren't the hashes of the chunks'
+	// data, and would fail the check of CompressedChunk.ToChunk
+	chunkChan := make(chan CompressedChunk, len(getBatch))
+	tr.getManyCompressed(context.Background(), getBatch, chunkChan, wg, ae, &Stats{})
 	wg.Wait()
 	close(chunkChan)
 
@@ -42,6 +42,7 @@ type tableWriter struct {
 	totalUncompressedData uint64
 	prefixes              prefixIndexSlice // TODO: This is in danger of exploding memory
 	blockHash             hash.Hash
+	dictionaries          [][]byte
 
 	snapper snappyEncoder
 }
@@ -88,6 +89,20 @@ func newTableWriter(buff []byte, snapper snappyEncoder) *tableWriter {
 	}
 }
 
+// newDictionaryTableWriter returns a tableWriter which compresses chunks with |dict| wherever doing so beats snappy.
+// len(buff) must be >= maxTableSize(numChunks, totalData) + dictionariesSize(dict)
+func newDictionaryTableWriter(buff []byte, dict []byte) (*tableWriter, error) {
+	enc, err := newDictionaryEncoder(dict)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tw := newTableWriter(buff, enc)
+	tw.dictionaries = [][]byte{dict}
+	return tw, nil
+}
+
 func (tw *tableWriter) addChunk(h addr, data []byte) bool {
 	if len(data) == 0 {
 		panic("NBS blocks cannont be zero length")
@@ -125,6 +140,10 @@ func (tw *tableWriter) addChunk(h addr, data []byte) bool {
 }
 
 func (tw *tableWriter) finish() (uncompressedLength uint64, blockAddr addr, err error) {
+	if len(tw.dictionaries) > 0 {
+		tw.writeDictionaries()
+	}
+
 	err = tw.writeIndex()
 
 	if err != nil {
@@ -194,8 +213,21 @@ func (tw *tableWriter) writeIndex() error {
 	return nil
 }
 
+// writeDictionaries writes the dictionaries section, which also contributes to the name of the table so that it
+// can't collide with the name of a table holding the same chunks without them.
+func (tw *tableWriter) writeDictionaries() {
+	n := writeDictionaries(tw.buff[tw.pos:], tw.dictionaries)
+	tw.blockHash.Write(tw.buff[tw.pos : tw.pos+n])
+	tw.pos += n
+}
+
 func (tw *tableWriter) writeFooter() {
-	tw.pos += writeFooter(tw.buff[tw.pos:], uint32(len(tw.prefixes)), tw.totalUncompressedData)
+	footer := tw.buff[tw.pos:]
+	tw.pos += writeFooter(footer, uint32(len(tw.prefixes)), tw.totalUncompressedData)
+
+	if len(tw.dictionaries) > 0 {
+		writeDictionariesMagic(footer)
+	}
 }
 
 func writeFooter(dst []byte, chunkCount uint32, uncData uint64) (consumed uint64) {
@@ -212,3 +244,8 @@ func writeFooter(dst []byte, chunkCount uint32, uncData uint64) (consumed uint64
 	consumed += magicNumberSize
 	return
 }
+
+// writeDictionariesMagic replaces the magic number of |footer| with the one marking tables which have dictionaries.
+func writeDictionariesMagic(footer []byte) {
+	copy(footer[footerSize-magicNumberSize:], dictMagicNumber)
+}
@@ -15,8 +15,7 @@
 package types
 
 import (
-	"errors"
-
+	"github.com/liquidata-inc/dolt/go/store/chunks"
 	"github.com/liquidata-inc/dolt/go/store/constants"
 )
 
@@ -52,7 +51,7 @@ func GetFormatForVersionString(s string) (*NomsBinFormat, error) {
 	} else if s == constants.FormatLD1String {
 		return Format_LD_1, nil
 	} else {
-		return nil, errors.New("unsupported ChunkStore version " + s)
+		return nil, chunks.CheckFormatVersion(s)
 	}
 }
 
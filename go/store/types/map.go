@@ -192,6 +192,16 @@ func (m Map) DiffLeftRight(ctx context.Context, last Map, ae *atomicerr.AtomicEr
 	orderedSequenceDiffLeftRight(ctx, last.orderedSequence, m.orderedSequence, ae, changes, closeChan)
 }
 
+// DiffLeftRightInRange computes the diff from |last| to |m| like DiffLeftRight, but only for the keys in the range
+// which begins at |start| and continues while |inRange| returns true. A nil |start| begins at the first key and a nil
+// |inRange| continues through the last key.
+func (m Map) DiffLeftRightInRange(ctx context.Context, last Map, start Value, inRange func(Value) (bool, error), ae *atomicerr.AtomicError, changes chan<- ValueChanged, closeChan <-chan struct{}) {
+	if m.Equals(last) {
+		return
+	}
+	orderedSequenceDiffLeftRightInRange(ctx, last.orderedSequence, m.orderedSequence, start, inRange, ae, changes, closeChan)
+}
+
 // Collection interface
 
 func (m Map) asSequence() sequence {
@@ -464,6 +474,73 @@ func (m Map) IterFrom(ctx context.Context, start Value, cb mapIterCallback) erro
 	})
 }
 
+// RemoveRange returns a Map without the keys from |start|, inclusive, to |end|, exclusive, along with the number of
+// entries removed. A nil |start| or |end| leaves the range unbounded on that side. Only the chunks at either end of
+// the range are rewritten; the chunks entirely within it are dropped without being read.
+func (m Map) RemoveRange(ctx context.Context, start, end Value) (Map, uint64, error) {
+	if m.Empty() {
+		return m, 0, nil
+	}
+
+	startCur, err := newCursorAtValue(ctx, m.orderedSequence, start, true, false)
+
+	if err != nil {
+		return EmptyMap, 0, err
+	}
+
+	var endCur *sequenceCursor
+	if end != nil {
+		endCur, err = newCursorAtValue(ctx, m.orderedSequence, end, true, false)
+	} else {
+		endCur, err = newCursorAt(ctx, m.orderedSequence, emptyKey, true, true)
+
+		if err == nil {
+			_, err = endCur.advance(ctx)
+		}
+	}
+
+	if err != nil {
+		return EmptyMap, 0, err
+	}
+
+	if startCur.compare(endCur) >= 0 {
+		return m, 0, nil
+	}
+
+	startIdx, err := startCur.leafIndex()
+
+	if err != nil {
+		return EmptyMap, 0, err
+	}
+
+	endIdx, err := endCur.leafIndex()
+
+	if err != nil {
+		return EmptyMap, 0, err
+	}
+
+	vrw := m.orderedSequence.valueReadWriter()
+	ch, err := newSequenceChunker(ctx, startCur, 0, vrw, makeMapLeafChunkFn(vrw), newOrderedMetaSequenceChunkFn(MapKind, vrw), mapHashValueBytes)
+
+	if err != nil {
+		return EmptyMap, 0, err
+	}
+
+	err = ch.skipTo(ctx, endCur)
+
+	if err != nil {
+		return EmptyMap, 0, err
+	}
+
+	seq, err := ch.Done(ctx)
+
+	if err != nil {
+		return EmptyMap, 0, err
+	}
+
+	return newMap(seq.(orderedSequence)), endIdx - startIdx, nil
+}
+
 func (m Map) Edit() *MapEditor {
 	return NewMapEditor(m)
 }
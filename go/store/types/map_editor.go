@@ -97,6 +97,27 @@ func (med *MapEditor) Remove(k LesserValuable) *MapEditor {
 	return med
 }
 
+// RemoveRange removes the keys from |start|, inclusive, to |end|, exclusive, and returns the number of entries removed.
+// A nil |start| or |end| leaves the range unbounded on that side. The edits added before are applied first, so the
+// keys they set within the range are removed too. See Map.RemoveRange.
+func (med *MapEditor) RemoveRange(ctx context.Context, start, end Value) (uint64, error) {
+	m, err := med.Map(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	m, removed, err := m.RemoveRange(ctx, start, end)
+
+	if err != nil {
+		return 0, err
+	}
+
+	med.m = m
+	med.acc = CreateEditAccForMapEdits(m.format())
+	return removed, nil
+}
+
 func (med *MapEditor) set(k LesserValuable, v Valuable) {
 	med.numEdits++
 	med.acc.AddEdit(k, v)
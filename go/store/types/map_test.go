@@ -567,6 +567,49 @@ func TestMapMutationReadWriteCount(t *testing.T) {
 	assert.Equal(t, 62, cs.Writes()-wrCnt)
 }
 
+func TestMapRemoveRangeReadWriteCount(t *testing.T) {
+	// Removing a range reads and writes only the sequences at either end of it, however many it covers.
+	ts := &chunks.TestStorage{}
+	cs := ts.NewView()
+	vs := newValueStoreWithCacheAndPending(cs, 0, 0)
+
+	m, err := NewMap(context.Background(), vs)
+	assert.NoError(t, err)
+	me := m.Edit()
+	for i := 0; i < 10000; i++ {
+		me.Set(Float(i), String(fmt.Sprintf("I am the fairly long value of the map entry with the key %d", i)))
+	}
+	m, err = me.Map(context.Background())
+	assert.NoError(t, err)
+	r, err := vs.WriteValue(context.Background(), m)
+	assert.NoError(t, err)
+	rt, err := vs.Root(context.Background())
+	assert.NoError(t, err)
+	_, err = vs.Commit(context.Background(), rt, rt)
+	assert.NoError(t, err)
+	v, err := r.TargetValue(context.Background(), vs)
+	assert.NoError(t, err)
+	m = v.(Map)
+
+	wrCnt := cs.Writes()
+	rdCnt := cs.Reads()
+
+	m, removed, err := m.RemoveRange(context.Background(), Float(500), Float(9500))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(9000), removed)
+	assert.Equal(t, uint64(1000), m.Len())
+	_, err = vs.WriteValue(context.Background(), m)
+	assert.NoError(t, err)
+
+	rt, err = vs.Root(context.Background())
+	assert.NoError(t, err)
+	_, err = vs.Commit(context.Background(), rt, rt)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 4, cs.Reads()-rdCnt)
+	assert.Equal(t, 2, cs.Writes()-wrCnt)
+}
+
 func TestMapInfiniteChunkBug(t *testing.T) {
 	smallTestChunks()
 	defer normalProductionChunks()
@@ -829,6 +872,80 @@ func TestMapRemoveNonexistentKey(t *testing.T) {
 	assert.True(original.Equals(actual))
 }
 
+func TestMapRemoveRange(t *testing.T) {
+	assert := assert.New(t)
+
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	vs := newTestValueStore()
+	tm := newSortedTestMap(64*32, newNumber)
+	whole := tm.toMap(vs)
+	n := len(tm.entries.entries)
+
+	keyAt := func(i int) Value {
+		if i == n {
+			return nil
+		}
+		return tm.entries.entries[i].key
+	}
+
+	run := func(start, end Value, from, to int) {
+		actual, removed, err := whole.RemoveRange(context.Background(), start, end)
+		assert.NoError(err)
+		assert.Equal(uint64(to-from), removed)
+		validateMap(t, vs, actual, tm.Remove(from, to).entries)
+	}
+
+	ranges := [][2]int{{0, 0}, {0, 1}, {5, 6}, {10, 20}, {100, 1500}, {0, n / 2}, {n / 2, n}, {n - 1, n}, {0, n}}
+	for from := 0; from < n; from += 97 {
+		ranges = append(ranges, [2]int{from, from + (from*7)%(n-from)})
+	}
+
+	for _, r := range ranges {
+		run(keyAt(r[0]), keyAt(r[1]), r[0], r[1])
+	}
+
+	// ranges unbounded at the start, and bounded by keys which aren't in the map
+	run(nil, keyAt(10), 0, 10)
+	run(nil, nil, 0, n)
+	run(Float(n), nil, n, n)
+	run(Float(9.5), Float(20.5), 10, 21)
+	run(Float(20), Float(10), 0, 0)
+
+	empty, err := NewMap(context.Background(), vs)
+	assert.NoError(err)
+	empty, removed, err := empty.RemoveRange(context.Background(), nil, nil)
+	assert.NoError(err)
+	assert.Equal(uint64(0), removed)
+	assert.True(empty.Empty())
+}
+
+func TestMapEditorRemoveRange(t *testing.T) {
+	assert := assert.New(t)
+
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	vs := newTestValueStore()
+	tm := newSortedTestMap(64*8, newNumber)
+	me := tm.toMap(vs).Edit()
+
+	// edits before the range is removed are applied first, and those after it are applied to the result
+	me.Set(Float(1.5), Float(0)).Set(Float(400.5), Float(0)).Remove(Float(0))
+	removed, err := me.RemoveRange(context.Background(), Float(1), Float(300))
+	assert.NoError(err)
+	assert.Equal(uint64(300), removed)
+	me.Set(Float(2), Float(0))
+
+	actual, err := me.Map(context.Background())
+	assert.NoError(err)
+
+	expected, err := tm.Remove(0, 300).toMap(vs).Edit().Set(Float(400.5), Float(0)).Set(Float(2), Float(0)).Map(context.Background())
+	assert.NoError(err)
+	assert.True(expected.Equals(actual))
+}
+
 func TestMapFirst(t *testing.T) {
 	assert := assert.New(t)
 
@@ -211,19 +211,50 @@ func orderedSequenceDiffInternalNodes(ctx context.Context, last orderedSequence,
 // Streams the diff from |last| to |current| into |changes|, using a left-right approach.
 // Left-right immediately descends to the first change and starts streaming changes, but compared to top-down it's serial and much slower to calculate the full diff.
 func orderedSequenceDiffLeftRight(ctx context.Context, last orderedSequence, current orderedSequence, ae *atomicerr.AtomicError, changes chan<- ValueChanged, stopChan <-chan struct{}) bool {
-	lastCur, err := newCursorAt(ctx, last, emptyKey, false, false)
+	return orderedSequenceDiffLeftRightInRange(ctx, last, current, nil, nil, ae, changes, stopChan)
+}
+
+// Streams the diff from |last| to |current| into |changes| using a left-right approach, like
+// orderedSequenceDiffLeftRight, but only for the keys in the range which begins at |start| and continues while
+// |inRange| returns true.  A nil |start| begins at the first key, and a nil |inRange| continues to the last key.
+// Chunks which are equal in both sequences are skipped as usual, so only the parts of the sequences which overlap the
+// range are read.
+func orderedSequenceDiffLeftRightInRange(ctx context.Context, last orderedSequence, current orderedSequence, start Value, inRange func(Value) (bool, error), ae *atomicerr.AtomicError, changes chan<- ValueChanged, stopChan <-chan struct{}) bool {
+	lastCur, err := newCursorAtValue(ctx, last, start, false, false)
 
 	if ae.SetIfError(err) {
 		return false
 	}
 
-	currentCur, err := newCursorAt(ctx, current, emptyKey, false, false)
+	currentCur, err := newCursorAtValue(ctx, current, start, false, false)
 
 	if ae.SetIfError(err) {
 		return false
 	}
 
-	for lastCur.valid() && currentCur.valid() {
+	valid := func(cur *sequenceCursor) bool {
+		if !cur.valid() {
+			return false
+		} else if inRange == nil {
+			return true
+		}
+
+		key, err := getCurrentKey(cur)
+
+		if ae.SetIfError(err) {
+			return false
+		}
+
+		ok, err := inRange(key.v)
+
+		if ae.SetIfError(err) {
+			return false
+		}
+
+		return ok
+	}
+
+	for valid(lastCur) && valid(currentCur) {
 		if ae.IsSet() {
 			return false
 		}
@@ -234,7 +265,7 @@ func orderedSequenceDiffLeftRight(ctx context.Context, last orderedSequence, cur
 			return false
 		}
 
-		for lastCur.valid() && currentCur.valid() {
+		for valid(lastCur) && valid(currentCur) {
 			if ae.IsSet() {
 				return false
 			}
@@ -331,7 +362,7 @@ func orderedSequenceDiffLeftRight(ctx context.Context, last orderedSequence, cur
 		}
 	}
 
-	for lastCur.valid() && !ae.IsSet() {
+	for valid(lastCur) && !ae.IsSet() {
 		lastKey, err := getCurrentKey(lastCur)
 
 		if ae.SetIfError(err) {
@@ -354,7 +385,7 @@ func orderedSequenceDiffLeftRight(ctx context.Context, last orderedSequence, cur
 		}
 	}
 
-	for currentCur.valid() && !ae.IsSet() {
+	for valid(currentCur) && !ae.IsSet() {
 		currKey, err := getCurrentKey(currentCur)
 
 		if ae.SetIfError(err) {
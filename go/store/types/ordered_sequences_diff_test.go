@@ -322,3 +322,42 @@ func TestOrderedSequenceDiffWithMetaNodeGap(t *testing.T) {
 	runTest(orderedSequenceDiffLeftRight)
 	runTest(orderedSequenceDiffTopDown)
 }
+
+func TestOrderedSequenceDiffLeftRightInRange(t *testing.T) {
+	vs := newTestValueStore()
+
+	all, err := NewSet(context.Background(), vs, generateNumbersAsValuesFromToBy(0, lengthOfNumbersTest, 1)...)
+	assert.NoError(t, err)
+	evens, err := NewSet(context.Background(), vs, generateNumbersAsValuesFromToBy(0, lengthOfNumbersTest, 2)...)
+	assert.NoError(t, err)
+
+	rangeDiff := func(start Value, inRange func(Value) (bool, error)) diffFn {
+		return func(ctx context.Context, last orderedSequence, current orderedSequence, ae *atomicerr.AtomicError, changes chan<- ValueChanged, closeChan <-chan struct{}) bool {
+			return orderedSequenceDiffLeftRightInRange(ctx, last, current, start, inRange, ae, changes, closeChan)
+		}
+	}
+
+	lessThan := func(end Float) func(Value) (bool, error) {
+		return func(v Value) (bool, error) {
+			return v.Less(Format_7_18, end)
+		}
+	}
+
+	added, removed, modified, err := accumulateOrderedSequenceDiffChanges(all.orderedSequence, evens.orderedSequence, rangeDiff(Float(101), lessThan(200)))
+	assert.NoError(t, err)
+	assert.Empty(t, added)
+	assert.Empty(t, modified)
+	assert.Equal(t, generateNumbersAsValuesFromToBy(101, 200, 2), ValueSlice(removed))
+
+	added, removed, modified, err = accumulateOrderedSequenceDiffChanges(evens.orderedSequence, all.orderedSequence, rangeDiff(nil, lessThan(10)))
+	assert.NoError(t, err)
+	assert.Equal(t, generateNumbersAsValuesFromToBy(1, 10, 2), ValueSlice(added))
+	assert.Empty(t, removed)
+	assert.Empty(t, modified)
+
+	added, removed, modified, err = accumulateOrderedSequenceDiffChanges(evens.orderedSequence, all.orderedSequence, rangeDiff(Float(lengthOfNumbersTest-10), nil))
+	assert.NoError(t, err)
+	assert.Equal(t, generateNumbersAsValuesFromToBy(lengthOfNumbersTest-9, lengthOfNumbersTest, 2), ValueSlice(added))
+	assert.Empty(t, removed)
+	assert.Empty(t, modified)
+}
@@ -229,6 +229,23 @@ func (sc *sequenceChunker) advanceTo(ctx context.Context, next *sequenceCursor)
 	return nil
 }
 
+// skipTo moves the sequenceChunker to |next| without appending the items between its cursor and |next|, removing them
+// from the resulting sequence. The parent chunkers skip to the parents of |next| the same way, so the sequences
+// entirely between the two are dropped without being loaded, and only the sequences holding the cursor and |next| are
+// rechunked.
+func (sc *sequenceChunker) skipTo(ctx context.Context, next *sequenceCursor) error {
+	if sc.parent != nil && next.parent != nil {
+		err := sc.parent.skipTo(ctx, next.parent)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	sc.cur = next
+	return nil
+}
+
 func (sc *sequenceChunker) Append(ctx context.Context, item sequenceItem) (bool, error) {
 	d.PanicIfTrue(item == nil)
 	sc.current = append(sc.current, item)
@@ -109,6 +109,24 @@ func (cur *sequenceCursor) indexInChunk() int {
 	return cur.idx
 }
 
+// leafIndex returns the index of the cursor's position among the leaf items of the whole tree.
+func (cur *sequenceCursor) leafIndex() (uint64, error) {
+	idx := uint64(cur.idx)
+	for p := cur.parent; p != nil; p = p.parent {
+		if p.idx > 0 {
+			n, err := p.seq.cumulativeNumberOfLeaves(p.idx - 1)
+
+			if err != nil {
+				return 0, err
+			}
+
+			idx += n
+		}
+	}
+
+	return idx, nil
+}
+
 func (cur *sequenceCursor) atLastItem() bool {
 	return cur.idx == cur.length()-1
 }
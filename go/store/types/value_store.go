@@ -26,11 +26,10 @@ import (
 	"errors"
 	"sync"
 
-	"github.com/liquidata-inc/dolt/go/store/atomicerr"
-
 	"github.com/liquidata-inc/dolt/go/store/chunks"
 	"github.com/liquidata-inc/dolt/go/store/d"
 	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/util/cacheregistry"
 	"github.com/liquidata-inc/dolt/go/store/util/sizecache"
 )
 
@@ -95,6 +94,9 @@ const (
 	defaultPendingPutMax     = 1 << 28 // 256MB
 )
 
+// DecodedChunksCacheName is the name the cache of the decoded values of a ValueStore is registered with.
+const DecodedChunksCacheName = "decoded_chunks"
+
 // newTestValueStore creates a simple struct that satisfies ValueReadWriter
 // and is backed by a chunks.TestStore.
 func newTestValueStore() *ValueStore {
@@ -146,35 +148,46 @@ func (lvs *ValueStore) Format() *NomsBinFormat {
 	return lvs.nbf
 }
 
+// getBufferedChunk returns the chunk of |h| if it has been written but not yet flushed to the ChunkStore.
+func (lvs *ValueStore) getBufferedChunk(h hash.Hash) (chunks.Chunk, bool) {
+	lvs.bufferMu.RLock()
+	defer lvs.bufferMu.RUnlock()
+	c, ok := lvs.bufferedChunks[h]
+	return c, ok
+}
+
 // ReadValue reads and decodes a value from lvs. It is not considered an error
 // for the requested chunk to be empty; in this case, the function simply
 // returns nil.
 func (lvs *ValueStore) ReadValue(ctx context.Context, h hash.Hash) (Value, error) {
 	lvs.versOnce.Do(lvs.expectVersion)
+	readStats := chunks.GetReadStats(ctx)
 	if v, ok := lvs.decodedChunks.Get(h); ok {
 		if v == nil {
 			return nil, errors.New("value present but empty")
 		}
 
+		readStats.CacheHit()
 		return v.(Value), nil
 	}
 
-	chunk := func() chunks.Chunk {
-		lvs.bufferMu.RLock()
-		defer lvs.bufferMu.RUnlock()
-		if pending, ok := lvs.bufferedChunks[h]; ok {
-			return pending
-		}
-		return chunks.EmptyChunk
-	}()
+	chunk, buffered := lvs.getBufferedChunk(h)
 
-	if chunk.IsEmpty() {
+	if !buffered {
 		var err error
+		start := readStats.StartStorageTimer()
 		chunk, err = lvs.cs.Get(ctx, h)
+		readStats.StopStorageTimer(start)
 
-		if err != nil {
+		if errors.Is(err, chunks.ErrChunkNotFound) {
+			return nil, nil
+		} else if err != nil {
 			return nil, err
 		}
+
+		readStats.ChunkRead(len(chunk.Data()))
+	} else {
+		readStats.CacheHit()
 	}
 	if chunk.IsEmpty() {
 		return nil, nil
@@ -215,6 +228,7 @@ func (lvs *ValueStore) ReadManyValues(ctx context.Context, hashes hash.HashSlice
 	}
 
 	foundValues := make(map[hash.Hash]Value, len(hashes))
+	readStats := chunks.GetReadStats(ctx)
 
 	// First, see which hashes can be found in either the Value cache or bufferedChunks.
 	// Put the rest into a new HashSet to be requested en masse from the ChunkStore.
@@ -223,18 +237,13 @@ func (lvs *ValueStore) ReadManyValues(ctx context.Context, hashes hash.HashSlice
 		if v, ok := lvs.decodedChunks.Get(h); ok {
 			d.PanicIfTrue(v == nil)
 			foundValues[h] = v.(Value)
+			readStats.CacheHit()
 			continue
 		}
 
-		chunk := func() chunks.Chunk {
-			lvs.bufferMu.RLock()
-			defer lvs.bufferMu.RUnlock()
-			if pending, ok := lvs.bufferedChunks[h]; ok {
-				return pending
-			}
-			return chunks.EmptyChunk
-		}()
-		if !chunk.IsEmpty() {
+		if chunk, ok := lvs.getBufferedChunk(h); ok {
+			readStats.CacheHit()
+
 			var err error
 			foundValues[h], err = decode(h, &chunk)
 
@@ -250,33 +259,37 @@ func (lvs *ValueStore) ReadManyValues(ctx context.Context, hashes hash.HashSlice
 
 	if len(remaining) != 0 {
 		// Request remaining hashes from ChunkStore, processing the found chunks as they come in.
-		foundChunks := make(chan *chunks.Chunk, 16)
+		// They may come in concurrently, so the results are collected under a lock.
+		var mu sync.Mutex
+		var decodeErr error
 
-		ae := atomicerr.New()
-		go func() {
-			defer close(foundChunks)
-			err := lvs.cs.GetMany(ctx, remaining, foundChunks)
-			ae.SetIfError(err)
-		}()
+		start := readStats.StartStorageTimer()
+		err := lvs.cs.GetManyF(ctx, remaining, func(c *chunks.Chunk) {
+			h := c.Hash()
+			readStats.ChunkRead(len(c.Data()))
+			v, err := decode(h, c)
+
+			mu.Lock()
+			defer mu.Unlock()
 
-		var err error
-		for c := range foundChunks {
 			if err != nil {
-				continue // continue to drain even if there is an error
+				if decodeErr == nil {
+					decodeErr = err
+				}
+				return
 			}
 
-			h := c.Hash()
-
-			foundValues[h], err = decode(h, c)
-		}
-
-		if ae.IsSet() {
-			return nil, ae.Get()
-		}
+			foundValues[h] = v
+		})
+		readStats.StopStorageTimer(start)
 
 		if err != nil {
 			return nil, err
 		}
+
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
 	}
 
 	rv := make(ValueSlice, len(hashes))
@@ -554,3 +567,9 @@ func (lvs *ValueStore) Commit(ctx context.Context, current, last hash.Hash) (boo
 func (lvs *ValueStore) Close() error {
 	return lvs.cs.Close()
 }
+
+// RegisterCaches registers the cache of decoded values with the cacheregistry, so that it can be reported and flushed.
+// The function returned unregisters it, and should be called once the ValueStore is no longer used.
+func (lvs *ValueStore) RegisterCaches() (unregister func()) {
+	return cacheregistry.Register(DecodedChunksCacheName, lvs.decodedChunks)
+}
@@ -76,6 +76,35 @@ func TestReadWriteCache(t *testing.T) {
 	assert.Equal(1, ts.Reads())
 }
 
+func TestReadStats(t *testing.T) {
+	assert := assert.New(t)
+	storage := &chunks.TestStorage{}
+	vs := NewValueStore(storage.NewView())
+
+	r, err := vs.WriteValue(context.Background(), Bool(true))
+	assert.NoError(err)
+	rt, err := vs.Root(context.Background())
+	assert.NoError(err)
+	_, err = vs.Commit(context.Background(), rt, rt)
+	assert.NoError(err)
+
+	vs = NewValueStore(storage.NewView())
+	readStats := chunks.NewReadStats(false)
+	ctx := chunks.WithReadStats(context.Background(), readStats)
+
+	_, err = vs.ReadValue(ctx, r.TargetHash())
+	assert.NoError(err)
+	_, err = vs.ReadValue(ctx, r.TargetHash())
+	assert.NoError(err)
+	_, err = vs.ReadManyValues(ctx, hash.HashSlice{r.TargetHash()})
+	assert.NoError(err)
+
+	snapshot := readStats.Snapshot()
+	assert.Equal(uint64(1), snapshot.ChunksRead)
+	assert.True(snapshot.BytesRead > 0)
+	assert.Equal(uint64(2), snapshot.CacheHits)
+}
+
 func TestValueReadMany(t *testing.T) {
 	assert := assert.New(t)
 
@@ -0,0 +1,207 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cacheregistry keeps track of the in-memory caches of a process, so that their sizes can be reported and
+// they can be flushed, all at once or by name, without restarting the process.
+package cacheregistry
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownCache is the error returned when flushing a cache by a name which no cache was registered with.
+var ErrUnknownCache = errors.New("unknown cache")
+
+// Cache is a cache which can be registered. All of its methods must be safe to call while the cache is in use.
+type Cache interface {
+	// Len returns the number of entries in the cache.
+	Len() int
+
+	// Size returns an estimate of the number of bytes held by the entries of the cache.
+	Size() uint64
+
+	// Purge removes every entry from the cache.
+	Purge()
+}
+
+// Stats are the totals of the caches registered with a name.
+type Stats struct {
+	Name string
+	// Caches is the number of caches registered with the name. Each database has its own instance of some caches.
+	Caches  int
+	Entries int
+	Bytes   uint64
+}
+
+// Registry is a set of named caches. Several caches can be registered with the same name, in which case they're
+// reported and flushed together.
+type Registry struct {
+	mu sync.Mutex
+	// caches holds the number of times each cache was registered under a name. Names stay known once all of their
+	// caches are unregistered.
+	caches map[string]map[Cache]int
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{caches: make(map[string]map[Cache]int)}
+}
+
+// Register adds |c| to the caches of |name|. The function returned removes it again. Registering the same cache
+// more than once counts it once, until each registration is removed.
+func (r *Registry) Register(name string, c Cache) (unregister func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.caches[name] == nil {
+		r.caches[name] = make(map[Cache]int)
+	}
+
+	r.caches[name][c]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			if r.caches[name][c]--; r.caches[name][c] <= 0 {
+				delete(r.caches[name], c)
+			}
+		})
+	}
+}
+
+// Names returns the names caches were registered with, sorted.
+func (r *Registry) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.caches))
+	for name := range r.caches {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Stats returns the totals of the caches of each name, sorted by name.
+func (r *Registry) Stats() []Stats {
+	var stats []Stats
+	for _, name := range r.Names() {
+		stats = append(stats, totals(name, r.cachesOf(name)))
+	}
+
+	return stats
+}
+
+// Flush purges the caches registered with |name|, or all of the caches if |name| is empty, and returns their totals
+// from before they were purged. It returns ErrUnknownCache if no cache was registered with |name|.
+func (r *Registry) Flush(name string) ([]Stats, error) {
+	names := []string{name}
+
+	if name == "" {
+		names = r.Names()
+	} else if !r.known(name) {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownCache, name)
+	}
+
+	var flushed []Stats
+	for _, name := range names {
+		caches := r.cachesOf(name)
+		flushed = append(flushed, totals(name, caches))
+
+		// the caches are purged outside of the registry's lock, each under its own lock, so queries using them
+		// only wait for their own cache
+		for _, c := range caches {
+			c.Purge()
+		}
+	}
+
+	return flushed, nil
+}
+
+func (r *Registry) known(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.caches[name]
+	return ok
+}
+
+func (r *Registry) cachesOf(name string) []Cache {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	caches := make([]Cache, 0, len(r.caches[name]))
+	for c := range r.caches[name] {
+		caches = append(caches, c)
+	}
+
+	return caches
+}
+
+func totals(name string, caches []Cache) Stats {
+	stats := Stats{Name: name, Caches: len(caches)}
+	for _, c := range caches {
+		stats.Entries += c.Len()
+		stats.Bytes += c.Size()
+	}
+
+	return stats
+}
+
+var globalRegistry = NewRegistry()
+
+func init() {
+	// the totals of the caches of the process are published through expvar along with the other metrics
+	expvar.Publish("dolt_caches", expvar.Func(func() interface{} {
+		metrics := make(map[string]interface{})
+		for _, stats := range globalRegistry.Stats() {
+			metrics[stats.Name] = map[string]interface{}{
+				"caches":  stats.Caches,
+				"entries": stats.Entries,
+				"bytes":   stats.Bytes,
+			}
+		}
+
+		return metrics
+	}))
+}
+
+// Register adds |c| to the caches of the process registered with |name|. The function returned removes it again.
+func Register(name string, c Cache) (unregister func()) {
+	return globalRegistry.Register(name, c)
+}
+
+// Names returns the names the caches of the process were registered with, sorted.
+func Names() []string {
+	return globalRegistry.Names()
+}
+
+// GetStats returns the totals of the caches of the process for each name, sorted by name.
+func GetStats() []Stats {
+	return globalRegistry.Stats()
+}
+
+// Flush purges the caches of the process registered with |name|, or all of them if |name| is empty, and returns their
+// totals from before they were purged.
+func Flush(name string) ([]Stats, error) {
+	return globalRegistry.Flush(name)
+}
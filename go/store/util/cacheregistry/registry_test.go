@@ -0,0 +1,127 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cacheregistry
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/util/sizecache"
+)
+
+func newTestCache(n int) *sizecache.SizeCache {
+	c := sizecache.New(1 << 20)
+	for i := 0; i < n; i++ {
+		c.Add(i, 10, i)
+	}
+
+	return c
+}
+
+func TestRegistryStatsAndFlush(t *testing.T) {
+	r := NewRegistry()
+	a1, a2, b := newTestCache(1), newTestCache(2), newTestCache(5)
+	r.Register("a", a1)
+	r.Register("a", a2)
+	r.Register("b", b)
+
+	assert.Equal(t, []string{"a", "b"}, r.Names())
+	assert.Equal(t, []Stats{{"a", 2, 3, 30}, {"b", 1, 5, 50}}, r.Stats())
+
+	flushed, err := r.Flush("a")
+	require.NoError(t, err)
+	assert.Equal(t, []Stats{{"a", 2, 3, 30}}, flushed)
+	assert.Equal(t, 0, a1.Len())
+	assert.Equal(t, 0, a2.Len())
+	assert.Equal(t, 5, b.Len())
+
+	flushed, err = r.Flush("")
+	require.NoError(t, err)
+	assert.Equal(t, []Stats{{"a", 2, 0, 0}, {"b", 1, 5, 50}}, flushed)
+	assert.Equal(t, 0, b.Len())
+
+	_, err = r.Flush("c")
+	assert.True(t, errors.Is(err, ErrUnknownCache))
+}
+
+func TestRegistryUnregister(t *testing.T) {
+	r := NewRegistry()
+	c := newTestCache(1)
+	unregister1 := r.Register("a", c)
+	unregister2 := r.Register("a", c)
+
+	// a cache registered twice is counted once
+	assert.Equal(t, []Stats{{"a", 1, 1, 10}}, r.Stats())
+
+	unregister1()
+	unregister1()
+	assert.Equal(t, []Stats{{"a", 1, 1, 10}}, r.Stats())
+
+	unregister2()
+	assert.Equal(t, []Stats{{"a", 0, 0, 0}}, r.Stats())
+
+	// the name stays known, and flushing it flushes nothing
+	flushed, err := r.Flush("a")
+	require.NoError(t, err)
+	assert.Equal(t, []Stats{{"a", 0, 0, 0}}, flushed)
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestRegistryConcurrentFlush(t *testing.T) {
+	r := NewRegistry()
+	c := sizecache.New(1 << 20)
+	r.Register("a", c)
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				key := fmt.Sprintf("%d-%d", i, j)
+				c.Add(key, 1, j)
+				c.Get(key)
+			}
+		}(i)
+	}
+
+	for i := 0; i < 100; i++ {
+		_, err := r.Flush("")
+		require.NoError(t, err)
+	}
+
+	wg.Wait()
+	_, err := r.Flush("")
+	require.NoError(t, err)
+	assert.Equal(t, 0, c.Len())
+	assert.Equal(t, uint64(0), c.Size())
+}
+
+func TestMetrics(t *testing.T) {
+	c := newTestCache(2)
+	unregister := Register("test_metrics", c)
+	defer unregister()
+
+	var metrics map[string]map[string]uint64
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("dolt_caches").String()), &metrics))
+	assert.Equal(t, map[string]uint64{"caches": 1, "entries": 2, "bytes": 20}, metrics["test_metrics"])
+}
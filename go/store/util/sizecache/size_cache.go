@@ -139,3 +139,30 @@ func (c *SizeCache) Drop(key interface{}) {
 		delete(c.cache, key)
 	}
 }
+
+// Len returns the number of items in the cache.
+func (c *SizeCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.cache)
+}
+
+// Size returns the total size of the items in the cache.
+func (c *SizeCache) Size() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.totalSize
+}
+
+// Purge removes every item from the cache. The expire callback isn't called for
+// the removed items.
+func (c *SizeCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cache = map[interface{}]sizeCacheEntry{}
+	c.lru.Init()
+	c.totalSize = 0
+}
@@ -173,3 +173,33 @@ func TestZeroSizeCache(t *testing.T) {
 	_, ok := c.Get(hashFromString("data1"))
 	assert.False(ok)
 }
+
+func TestPurge(t *testing.T) {
+	assert := assert.New(t)
+
+	expired := 0
+	c := NewWithExpireCallback(1024, func(key interface{}) {
+		expired++
+	})
+	for _, v := range []string{"data-1", "data-2", "data-3"} {
+		c.Add(hashFromString(v), 100, v)
+	}
+
+	assert.Equal(3, c.Len())
+	assert.Equal(uint64(300), c.Size())
+
+	c.Purge()
+	assert.Equal(0, c.Len())
+	assert.Equal(uint64(0), c.Size())
+	assert.Equal(0, expired)
+
+	_, ok := c.Get(hashFromString("data-1"))
+	assert.False(ok)
+
+	// the cache is usable after being purged
+	c.Add(hashFromString("data-4"), 100, "data-4")
+	v, ok := c.Get(hashFromString("data-4"))
+	assert.True(ok)
+	assert.Equal("data-4", v)
+	assert.Equal(1, c.Len())
+}
@@ -273,6 +273,11 @@ func (rs *RemoteChunkStore) GetRepoMetadata(ctx context.Context, req *remotesapi
 	logger := getReqLogger("GRPC", "GetRepoMetadata")
 	defer func() { logger("finished") }()
 
+	if err := remotestorage.ServeCapabilities(ctx, remotestorage.LocalCapabilities()); err != nil {
+		logger(err.Error())
+		return nil, err
+	}
+
 	cs := rs.getOrCreateStore(req.RepoId, "GetRepoMetadata", req.ClientRepoFormat.NbfVersion)
 	if cs == nil {
 		return nil, status.Error(codes.Internal, "Could not get chunkstore")
@@ -17,6 +17,7 @@ package main
 import (
 	"bytes"
 	"crypto/md5"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
@@ -29,12 +30,16 @@ import (
 
 	remotesapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/remotesapi/v1alpha1"
 
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/remotestorage"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/iohelp"
 	"github.com/liquidata-inc/dolt/go/store/hash"
 )
 
 var expectedFiles = make(map[string]remotesapi.TableFileDetails)
 
+// uploadExt is the extension of the files holding the parts of table files which are being uploaded in parts
+const uploadExt = ".upload"
+
 func ServeHTTP(respWr http.ResponseWriter, req *http.Request) {
 	logger := getReqLogger("HTTP_"+req.Method, req.RequestURI)
 	defer func() { logger("finished") }()
@@ -62,8 +67,15 @@ func ServeHTTP(respWr http.ResponseWriter, req *http.Request) {
 			statusCode = readChunk(logger, org, repo, hashStr, rangeStr, respWr)
 		}
 
+	case http.MethodHead:
+		statusCode = uploadStatus(logger, org, repo, hashStr, respWr)
+
 	case http.MethodPost, http.MethodPut:
-		statusCode = writeTableFile(logger, org, repo, hashStr, req)
+		if req.Header.Get("Content-Range") == "" {
+			statusCode = writeTableFile(logger, org, repo, hashStr, req)
+		} else {
+			statusCode = writeTableFilePart(logger, org, repo, hashStr, req, respWr)
+		}
 	}
 
 	if statusCode != -1 {
@@ -113,6 +125,139 @@ func writeTableFile(logger func(string), org, repo, fileId string, request *http
 	return http.StatusOK
 }
 
+// uploadStatus reports the number of bytes of a table file which have been uploaded, and the MD5 of the file once it's
+// complete, for clients resuming an upload in parts.
+func uploadStatus(logger func(string), org, repo, fileId string, respWr http.ResponseWriter) int {
+	path := filepath.Join(org, repo, fileId)
+
+	if data, err := ioutil.ReadFile(path); err == nil {
+		md5Bytes := md5.Sum(data)
+		respWr.Header().Set(remotestorage.UploadOffsetHeader, strconv.Itoa(len(data)))
+		respWr.Header().Set(remotestorage.UploadContentMD5Header, base64.StdEncoding.EncodeToString(md5Bytes[:]))
+		return http.StatusOK
+	}
+
+	if _, ok := expectedFiles[fileId]; !ok {
+		return http.StatusNotFound
+	}
+
+	var offset int64
+	if info, err := os.Stat(path + uploadExt); err == nil {
+		offset = info.Size()
+	}
+
+	logger(fmt.Sprintf("%d bytes of %s have been uploaded", offset, fileId))
+	respWr.Header().Set(remotestorage.UploadOffsetHeader, strconv.FormatInt(offset, 10))
+	return http.StatusOK
+}
+
+// writeTableFilePart writes a part of a table file which is being uploaded in parts to the partial upload of the file,
+// dropping anything past the start of the part. Once the last part is written, the file is checked against its
+// expected MD5 and moved into place.
+func writeTableFilePart(logger func(string), org, repo, fileId string, request *http.Request, respWr http.ResponseWriter) int {
+	tfd, ok := expectedFiles[fileId]
+
+	if !ok {
+		return http.StatusBadRequest
+	}
+
+	start, end, total, err := parseContentRange(request.Header.Get("Content-Range"))
+
+	if err != nil {
+		logger(err.Error())
+		return http.StatusBadRequest
+	}
+
+	data, err := ioutil.ReadAll(request.Body)
+
+	if err != nil {
+		logger("failed to read body " + err.Error())
+		return http.StatusBadRequest
+	}
+
+	md5Bytes := md5.Sum(data)
+	if uint64(len(data)) != end-start+1 || request.Header.Get("Content-MD5") != base64.StdEncoding.EncodeToString(md5Bytes[:]) {
+		logger(fmt.Sprintf("the part of %s at %d doesn't match its length or MD5", fileId, start))
+		return http.StatusBadRequest
+	}
+
+	path := filepath.Join(org, repo, fileId)
+	f, err := os.OpenFile(path+uploadExt, os.O_RDWR|os.O_CREATE, os.ModePerm)
+
+	if err != nil {
+		logger(fmt.Sprintf("failed to open the partial upload of %s: %v", fileId, err))
+		return http.StatusInternalServerError
+	}
+
+	offset, err := f.Seek(0, io.SeekEnd)
+
+	if err == nil && uint64(offset) < start {
+		_ = f.Close()
+		respWr.Header().Set(remotestorage.UploadOffsetHeader, strconv.FormatInt(offset, 10))
+		return http.StatusConflict
+	}
+
+	if err == nil {
+		err = f.Truncate(int64(start))
+	}
+
+	if err == nil {
+		_, err = f.WriteAt(data, int64(start))
+	}
+
+	closeErr := f.Close()
+
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		logger(fmt.Sprintf("failed to write the part of %s at %d: %v", fileId, start, err))
+		return http.StatusInternalServerError
+	}
+
+	respWr.Header().Set(remotestorage.UploadOffsetHeader, strconv.FormatUint(end+1, 10))
+
+	if end+1 < total {
+		return http.StatusOK
+	}
+
+	uploaded, err := ioutil.ReadFile(path + uploadExt)
+
+	if err != nil {
+		return http.StatusInternalServerError
+	}
+
+	uploadedMD5 := md5.Sum(uploaded)
+	if (tfd.ContentLength != 0 && tfd.ContentLength != uint64(len(uploaded))) || (len(tfd.ContentHash) > 0 && !bytes.Equal(tfd.ContentHash, uploadedMD5[:])) {
+		logger(fmt.Sprintf("the upload of %s doesn't match its expected length or MD5", fileId))
+		_ = os.Remove(path + uploadExt)
+		return http.StatusBadRequest
+	}
+
+	if err := os.Rename(path+uploadExt, path); err != nil {
+		logger(fmt.Sprintf("failed to move the upload of %s into place: %v", fileId, err))
+		return http.StatusInternalServerError
+	}
+
+	respWr.Header().Set(remotestorage.UploadContentMD5Header, base64.StdEncoding.EncodeToString(uploadedMD5[:]))
+	logger("Successfully wrote object to storage")
+	return http.StatusOK
+}
+
+// parseContentRange parses a Content-Range header of the form "bytes start-end/total".
+func parseContentRange(rngStr string) (start, end, total uint64, err error) {
+	if _, err := fmt.Sscanf(rngStr, "bytes %d-%d/%d", &start, &end, &total); err != nil {
+		return 0, 0, 0, fmt.Errorf("%s is not a valid content range", rngStr)
+	}
+
+	if end < start || end >= total {
+		return 0, 0, 0, fmt.Errorf("%s is not a valid content range", rngStr)
+	}
+
+	return start, end, total, nil
+}
+
 func writeLocal(logger func(string), org, repo, fileId string, data []byte) error {
 	path := filepath.Join(org, repo, fileId)
 